@@ -1,20 +1,50 @@
 package gortsplib
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/bluenviron/gortsplib/v4/pkg/ringbuffer"
 )
 
+type asyncProcessorEntry struct {
+	cb   func()
+	size uint64
+}
+
 // this struct contains a queue that allows to detach the routine that is reading a stream
 // from the routine that is writing a stream.
 type asyncProcessor struct {
+	// parameters
+	maxBytes     uint64
+	policy       ServerWriteQueuePolicy
+	blockTimeout time.Duration
+
+	// called by the processing routine when the queue becomes empty,
+	// before waiting for new entries.
+	onIdle func()
+
 	running bool
 	buffer  *ringbuffer.RingBuffer
+	size    int
+	dropped uint64
+
+	// state of the byte limit and of the wait for room
+	mutex       sync.Mutex
+	cond        *sync.Cond
+	queuedBytes uint64
+	closed      bool
 
 	done chan struct{}
 }
 
 func (w *asyncProcessor) allocateBuffer(size int) {
 	w.buffer, _ = ringbuffer.New(uint64(size))
+	w.size = size
+	w.cond = sync.NewCond(&w.mutex)
+	w.queuedBytes = 0
+	w.closed = false
 }
 
 func (w *asyncProcessor) start() {
@@ -25,6 +55,11 @@ func (w *asyncProcessor) start() {
 
 func (w *asyncProcessor) stop() {
 	if w.running {
+		w.mutex.Lock()
+		w.closed = true
+		w.mutex.Unlock()
+		w.cond.Broadcast()
+
 		w.buffer.Close()
 		<-w.done
 		w.running = false
@@ -35,15 +70,126 @@ func (w *asyncProcessor) run() {
 	defer close(w.done)
 
 	for {
-		tmp, ok := w.buffer.Pull()
+		tmp, ok := w.buffer.Pop()
 		if !ok {
-			return
+			if w.onIdle != nil {
+				w.onIdle()
+			}
+
+			tmp, ok = w.buffer.Pull()
+			if !ok {
+				return
+			}
+		}
+
+		switch e := tmp.(type) {
+		case func():
+			e()
+
+		case asyncProcessorEntry:
+			e.cb()
+
+			w.mutex.Lock()
+			w.queuedBytes -= e.size
+			w.mutex.Unlock()
+			w.cond.Broadcast()
 		}
+	}
+}
+
+// queued returns the number of entries that are waiting to be processed.
+func (w *asyncProcessor) queued() int {
+	return w.buffer.Len()
+}
+
+// full returns whether an entry of the given size can't be pushed without waiting or dropping.
+func (w *asyncProcessor) full(size int) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
 
-		tmp.(func())()
+	if w.maxBytes != 0 && w.queuedBytes != 0 && (w.queuedBytes+uint64(size)) > w.maxBytes {
+		return true
 	}
+	return w.buffer.Len() >= w.size
 }
 
-func (w *asyncProcessor) push(cb func()) bool {
+// pushBarrier pushes a callback without applying the policy,
+// that is called after entries that are already in the queue.
+// It returns false when the queue is full.
+func (w *asyncProcessor) pushBarrier(cb func()) bool {
 	return w.buffer.Push(cb)
 }
+
+func (w *asyncProcessor) push(cb func()) bool {
+	return w.pushSized(0, cb)
+}
+
+// pushSized pushes a callback that writes size bytes, applying the policy when the queue is full.
+func (w *asyncProcessor) pushSized(size int, cb func()) bool {
+	if w.maxBytes == 0 && w.policy != ServerWriteQueuePolicyDropOldest &&
+		w.policy != ServerWriteQueuePolicyBlock {
+		ok := w.buffer.Push(cb)
+		if !ok {
+			atomic.AddUint64(&w.dropped, 1)
+		}
+		return ok
+	}
+
+	e := asyncProcessorEntry{cb: cb, size: uint64(size)}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var timedOut bool
+	var timer *time.Timer
+
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		// a single entry is always accepted, even if it exceeds the byte limit
+		fits := w.maxBytes == 0 || w.queuedBytes == 0 || (w.queuedBytes+e.size) <= w.maxBytes
+
+		if fits && w.buffer.Push(e) {
+			w.queuedBytes += e.size
+			return true
+		}
+
+		switch w.policy {
+		case ServerWriteQueuePolicyDropOldest:
+			tmp, ok := w.buffer.Pop()
+			if !ok {
+				// the queued bytes belong to the entry that is being processed
+				atomic.AddUint64(&w.dropped, 1)
+				return false
+			}
+
+			w.queuedBytes -= tmp.(asyncProcessorEntry).size
+			atomic.AddUint64(&w.dropped, 1)
+
+		case ServerWriteQueuePolicyBlock:
+			if w.closed || timedOut {
+				atomic.AddUint64(&w.dropped, 1)
+				return false
+			}
+
+			if timer == nil {
+				timer = time.AfterFunc(w.blockTimeout, func() {
+					w.mutex.Lock()
+					timedOut = true
+					w.mutex.Unlock()
+					w.cond.Broadcast()
+				})
+			}
+
+			w.cond.Wait()
+
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+			return false
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package gortsplib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncProcessorPolicies(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		policy   ServerWriteQueuePolicy
+		maxBytes uint64
+		pushed   []bool
+		dropped  uint64
+		executed []int
+	}{
+		{
+			"drop newest",
+			ServerWriteQueuePolicyDropNewest,
+			0,
+			[]bool{true, true, true, true, false, false},
+			2,
+			[]int{0, 1, 2, 3},
+		},
+		{
+			"drop oldest",
+			ServerWriteQueuePolicyDropOldest,
+			0,
+			[]bool{true, true, true, true, true, true},
+			2,
+			[]int{2, 3, 4, 5},
+		},
+		{
+			"drop newest bytes",
+			ServerWriteQueuePolicyDropNewest,
+			20,
+			[]bool{true, true, false, false, false, false},
+			4,
+			[]int{0, 1},
+		},
+		{
+			"drop oldest bytes",
+			ServerWriteQueuePolicyDropOldest,
+			20,
+			[]bool{true, true, true, true, true, true},
+			4,
+			[]int{4, 5},
+		},
+		{
+			"block",
+			ServerWriteQueuePolicyBlock,
+			0,
+			[]bool{true, true, true, true, false, false},
+			2,
+			[]int{0, 1, 2, 3},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			w := &asyncProcessor{
+				maxBytes:     ca.maxBytes,
+				policy:       ca.policy,
+				blockTimeout: 50 * time.Millisecond,
+			}
+			w.allocateBuffer(4)
+
+			var executed []int
+
+			for i := 0; i < 6; i++ {
+				i := i
+				ok := w.pushSized(10, func() {
+					executed = append(executed, i)
+				})
+				require.Equal(t, ca.pushed[i], ok)
+			}
+
+			require.Equal(t, ca.dropped, w.dropped)
+
+			for {
+				tmp, ok := w.buffer.Pop()
+				if !ok {
+					break
+				}
+
+				switch e := tmp.(type) {
+				case func():
+					e()
+
+				case asyncProcessorEntry:
+					e.cb()
+				}
+			}
+
+			require.Equal(t, ca.executed, executed)
+		})
+	}
+}
+
+func TestAsyncProcessorBlock(t *testing.T) {
+	w := &asyncProcessor{
+		policy:       ServerWriteQueuePolicyBlock,
+		blockTimeout: 5 * time.Second,
+	}
+	w.allocateBuffer(1)
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+
+	ok := w.pushSized(10, func() {
+		close(started)
+		<-unblock
+	})
+	require.True(t, ok)
+
+	w.start()
+	defer w.stop()
+
+	<-started
+
+	ok = w.pushSized(10, func() {})
+	require.True(t, ok)
+
+	pushed := make(chan bool)
+
+	go func() {
+		pushed <- w.pushSized(10, func() {})
+	}()
+
+	select {
+	case <-pushed:
+		t.Errorf("should not happen")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(unblock)
+
+	require.True(t, <-pushed)
+	require.Equal(t, uint64(0), w.dropped)
+}
+
+func TestAsyncProcessorOnIdle(t *testing.T) {
+	var events []string
+	idle := make(chan struct{}, 1)
+
+	p := &asyncProcessor{
+		onIdle: func() {
+			events = append(events, "idle")
+			select {
+			case idle <- struct{}{}:
+			default:
+			}
+		},
+	}
+	p.allocateBuffer(8)
+
+	for i := 0; i < 3; i++ {
+		ok := p.push(func() {
+			events = append(events, "entry")
+		})
+		require.True(t, ok)
+	}
+
+	p.start()
+	<-idle
+	p.stop()
+
+	// entries that are queued together are followed by a single call
+	require.Equal(t, []string{"entry", "entry", "entry", "idle"}, events[:4])
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package gortsplib
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func bindToDevice(_ syscall.RawConn, _ string) error {
+	return fmt.Errorf("BindToDevice is supported on Linux only")
+}
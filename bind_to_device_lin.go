@@ -0,0 +1,21 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func bindToDevice(rc syscall.RawConn, name string) error {
+	var err2 error
+	err := rc.Control(func(fd uintptr) {
+		err2 = unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE, name)
+	})
+	if err != nil {
+		return err
+	}
+	return err2
+}
@@ -0,0 +1,126 @@
+package gortsplib
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// isDiscardable checks whether a RTP packet belongs to a frame
+// that is not used as reference by other frames.
+func isDiscardable(forma format.Format, pkt *rtp.Packet) bool {
+	if len(pkt.Payload) == 0 {
+		return false
+	}
+
+	switch forma.(type) {
+	case *format.H264:
+		// nal_ref_idc is zero
+		return (pkt.Payload[0] & 0x60) == 0
+
+	case *format.H265:
+		typ := (pkt.Payload[0] >> 1) & 0b111111
+
+		// fragmentation unit
+		if typ == 49 {
+			if len(pkt.Payload) < 3 {
+				return false
+			}
+			typ = pkt.Payload[2] & 0b111111
+		}
+
+		// sub-layer non-reference pictures have even types lower than 16
+		return typ < 16 && (typ%2) == 0
+	}
+
+	return false
+}
+
+// bitrateLimiterUnit is the state of the access unit that is being sent.
+type bitrateLimiterUnit struct {
+	inProgress bool
+	allowed    bool
+}
+
+// bitrateLimiter is a token bucket that limits the bitrate of outgoing packets.
+// When the bucket is less than half full, only packets that are not discardable are allowed.
+// Decisions are taken once per access unit, in order not to send partial frames.
+type bitrateLimiter struct {
+	byteRate float64
+	timeNow  func() time.Time
+
+	mutex     sync.Mutex
+	tokens    float64
+	last      time.Time
+	throttled bool
+}
+
+func newBitrateLimiter(bitrate int, timeNow func() time.Time) *bitrateLimiter {
+	byteRate := float64(bitrate) / 8
+
+	return &bitrateLimiter{
+		byteRate: byteRate,
+		timeNow:  timeNow,
+		tokens:   byteRate, // allow a burst of one second
+		last:     timeNow(),
+	}
+}
+
+// allow checks whether a packet can be sent.
+// The decision is taken at the first packet of an access unit and applies
+// to all its packets, until the last one. Following packets of allowed units
+// are always sent, even when the bucket is empty.
+// It returns whether the packet can be sent and whether the throttling state has changed.
+func (l *bitrateLimiter) allow(
+	unit *bitrateLimiterUnit,
+	size int,
+	discardable bool,
+	last bool,
+) (bool, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.timeNow()
+	l.tokens += now.Sub(l.last).Seconds() * l.byteRate
+	if l.tokens > l.byteRate {
+		l.tokens = l.byteRate
+	}
+	l.last = now
+
+	inProgress := unit.inProgress
+	unit.inProgress = !last
+
+	if inProgress {
+		if unit.allowed {
+			l.tokens -= float64(size)
+		}
+		return unit.allowed, false
+	}
+
+	if float64(size) > l.tokens || (discardable && l.tokens < (l.byteRate/2)) {
+		unit.allowed = false
+		changed := !l.throttled
+		l.throttled = true
+		return false, changed
+	}
+
+	unit.allowed = true
+	l.tokens -= float64(size)
+
+	if l.throttled && l.tokens >= (l.byteRate/2) {
+		l.throttled = false
+		return true, true
+	}
+
+	return true, false
+}
+
+// isThrottled returns whether some packets are being discarded.
+func (l *bitrateLimiter) isThrottled() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.throttled
+}
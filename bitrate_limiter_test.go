@@ -0,0 +1,126 @@
+package gortsplib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+func TestIsDiscardable(t *testing.T) {
+	for _, ca := range []struct {
+		name        string
+		format      format.Format
+		payload     []byte
+		discardable bool
+	}{
+		{
+			"h264 reference",
+			&format.H264{},
+			[]byte{0x65, 0x01},
+			false,
+		},
+		{
+			"h264 non-reference",
+			&format.H264{},
+			[]byte{0x01, 0x01},
+			true,
+		},
+		{
+			"h265 reference",
+			&format.H265{},
+			[]byte{0x02, 0x01, 0x01},
+			false,
+		},
+		{
+			"h265 non-reference",
+			&format.H265{},
+			[]byte{0x00, 0x01, 0x01},
+			true,
+		},
+		{
+			"h265 non-reference fragmented",
+			&format.H265{},
+			[]byte{0x62, 0x01, 0x80},
+			true,
+		},
+		{
+			"other",
+			&format.Opus{},
+			[]byte{0x00},
+			false,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.discardable, isDiscardable(ca.format, &rtp.Packet{Payload: ca.payload}))
+		})
+	}
+}
+
+func TestBitrateLimiter(t *testing.T) {
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	l := newBitrateLimiter(8000, func() time.Time {
+		return now
+	})
+
+	var u bitrateLimiterUnit
+
+	ok, changed := l.allow(&u, 600, false, true)
+	require.Equal(t, true, ok)
+	require.Equal(t, false, changed)
+
+	// less than half of the bucket is available, discardable packets are dropped
+	ok, changed = l.allow(&u, 100, true, true)
+	require.Equal(t, false, ok)
+	require.Equal(t, true, changed)
+
+	ok, changed = l.allow(&u, 100, false, true)
+	require.Equal(t, true, ok)
+	require.Equal(t, false, changed)
+
+	ok, changed = l.allow(&u, 400, false, true)
+	require.Equal(t, false, ok)
+	require.Equal(t, false, changed)
+
+	now = now.Add(500 * time.Millisecond)
+
+	ok, changed = l.allow(&u, 100, true, true)
+	require.Equal(t, true, ok)
+	require.Equal(t, true, changed)
+}
+
+func TestBitrateLimiterAccessUnits(t *testing.T) {
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	l := newBitrateLimiter(8000, func() time.Time {
+		return now
+	})
+
+	var u bitrateLimiterUnit
+
+	// packets of an allowed unit are sent even when the bucket is empty
+	for i, exp := range []bool{true, true, true} {
+		ok, _ := l.allow(&u, 400, false, i == 2)
+		require.Equal(t, exp, ok)
+	}
+
+	require.Equal(t, false, l.isThrottled())
+
+	// packets of a discarded unit are discarded even when the bucket is refilled
+	ok, changed := l.allow(&u, 400, false, false)
+	require.Equal(t, false, ok)
+	require.Equal(t, true, changed)
+
+	now = now.Add(2 * time.Second)
+
+	ok, _ = l.allow(&u, 400, false, true)
+	require.Equal(t, false, ok)
+
+	ok, changed = l.allow(&u, 400, false, true)
+	require.Equal(t, true, ok)
+	require.Equal(t, true, changed)
+}
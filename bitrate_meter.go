@@ -0,0 +1,65 @@
+package gortsplib
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	bitrateMeterWindow = 1 * time.Second
+)
+
+// bitrateMeter measures the bitrate of outgoing packets over windows of one second.
+type bitrateMeter struct {
+	timeNow func() time.Time
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	windowBytes uint64
+	bitrate     uint64
+}
+
+func newBitrateMeter(timeNow func() time.Time) *bitrateMeter {
+	return &bitrateMeter{
+		timeNow:     timeNow,
+		windowStart: timeNow(),
+	}
+}
+
+func (m *bitrateMeter) add(size int) {
+	now := m.timeNow()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.roll(now)
+	m.windowBytes += uint64(size)
+}
+
+// get returns the bitrate measured in the last complete window, in bits per second.
+func (m *bitrateMeter) get() uint64 {
+	now := m.timeNow()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.roll(now)
+	return m.bitrate
+}
+
+func (m *bitrateMeter) roll(now time.Time) {
+	elapsed := now.Sub(m.windowStart)
+	if elapsed < bitrateMeterWindow {
+		return
+	}
+
+	// the last window was followed by another one without packets
+	if elapsed >= 2*bitrateMeterWindow {
+		m.bitrate = 0
+	} else {
+		m.bitrate = m.windowBytes * 8 * uint64(time.Second) / uint64(elapsed)
+	}
+
+	m.windowStart = now
+	m.windowBytes = 0
+}
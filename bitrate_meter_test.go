@@ -0,0 +1,33 @@
+package gortsplib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitrateMeter(t *testing.T) {
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	m := newBitrateMeter(func() time.Time {
+		return now
+	})
+
+	for i := 0; i < 10; i++ {
+		m.add(12500)
+		now = now.Add(100 * time.Millisecond)
+	}
+
+	require.Equal(t, uint64(1000000), m.get())
+
+	m.add(1000)
+	now = now.Add(500 * time.Millisecond)
+	require.Equal(t, uint64(1000000), m.get())
+
+	now = now.Add(500 * time.Millisecond)
+	require.Equal(t, uint64(8000), m.get())
+
+	now = now.Add(2 * time.Second)
+	require.Equal(t, uint64(0), m.get())
+}
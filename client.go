@@ -9,10 +9,12 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"log"
+	"io"
 	"net"
+	gourl "net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -22,11 +24,19 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/bytecounter"
+	"github.com/bluenviron/gortsplib/v4/pkg/chaos"
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+	"github.com/bluenviron/gortsplib/v4/pkg/conformance"
 	"github.com/bluenviron/gortsplib/v4/pkg/conn"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/logger"
+	"github.com/bluenviron/gortsplib/v4/pkg/metrics"
+	"github.com/bluenviron/gortsplib/v4/pkg/multicast"
+	"github.com/bluenviron/gortsplib/v4/pkg/onvifreplay"
+	"github.com/bluenviron/gortsplib/v4/pkg/parameters"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtptime"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 )
@@ -90,12 +100,67 @@ func findBaseURL(sd *sdp.SessionDescription, res *base.Response, u *base.URL) (*
 	return u, nil
 }
 
+func findRecordBaseURL(res *base.Response, u *base.URL) (*base.URL, error) {
+	// use Content-Location
+	if cl, ok := res.Header["Content-Location"]; ok {
+		if len(cl) != 1 {
+			return nil, liberrors.ErrClientContentLocationInvalid{Value: cl}
+		}
+
+		ref, err := gourl.Parse(cl[0])
+		if err != nil {
+			return nil, liberrors.ErrClientContentLocationInvalid{Value: cl}
+		}
+
+		// Content-Location can be relative to the request URL
+		ret, err := base.ParseURL((*gourl.URL)(u).ResolveReference(ref).String())
+		if err != nil {
+			return nil, liberrors.ErrClientContentLocationInvalid{Value: cl}
+		}
+
+		// add credentials
+		ret.User = u.User
+
+		return ret, nil
+	}
+
+	// use URL of request
+	return u.Clone(), nil
+}
+
 func prepareForAnnounce(desc *description.Session) {
 	for i, media := range desc.Medias {
 		media.Control = "trackID=" + strconv.FormatInt(int64(i), 10)
 	}
 }
 
+// marshalAnnounceSDP encodes a description and makes sure that it can be decoded by the server,
+// since it may have been modified by OnAnnounceSDP.
+func marshalAnnounceSDP(desc *description.Session) ([]byte, error) {
+	if desc == nil {
+		return nil, liberrors.ErrClientSDPInvalid{Err: fmt.Errorf("description is nil")}
+	}
+
+	byts, err := desc.Marshal(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var ssd sdp.SessionDescription
+	err = ssd.Unmarshal(byts)
+	if err != nil {
+		return nil, liberrors.ErrClientSDPInvalid{Err: err}
+	}
+
+	var tmp description.Session
+	err = tmp.Unmarshal(&ssd)
+	if err != nil {
+		return nil, liberrors.ErrClientSDPInvalid{Err: err}
+	}
+
+	return byts, nil
+}
+
 func supportsGetParameter(header base.Header) bool {
 	pub, ok := header["Public"]
 	if !ok || len(pub) != 1 {
@@ -110,6 +175,9 @@ func supportsGetParameter(header base.Header) bool {
 	return false
 }
 
+// maximum extension of the stall threshold, expressed as a multiple of StallTimeout.
+const clientStallTimeoutMaxFactor = 4
+
 type clientState int
 
 const (
@@ -137,22 +205,26 @@ func (s clientState) String() string {
 }
 
 type optionsReq struct {
+	ctx context.Context
 	url *base.URL
 	res chan clientRes
 }
 
 type describeReq struct {
+	ctx context.Context
 	url *base.URL
 	res chan clientRes
 }
 
 type announceReq struct {
+	ctx  context.Context
 	url  *base.URL
 	desc *description.Session
 	res  chan clientRes
 }
 
 type setupReq struct {
+	ctx      context.Context
 	baseURL  *base.URL
 	media    *description.Media
 	rtpPort  int
@@ -161,22 +233,51 @@ type setupReq struct {
 }
 
 type playReq struct {
-	ra  *headers.Range
-	res chan clientRes
+	ctx   context.Context
+	ra    *headers.Range
+	media *description.Media
+	res   chan clientRes
 }
 
 type recordReq struct {
+	ctx context.Context
 	res chan clientRes
 }
 
 type pauseReq struct {
-	res chan clientRes
+	ctx   context.Context
+	media *description.Media
+	res   chan clientRes
+}
+
+type getParameterReq struct {
+	ctx   context.Context
+	names []string
+	res   chan clientRes
+}
+
+type setParameterReq struct {
+	ctx    context.Context
+	params map[string]string
+	res    chan clientRes
 }
 
 type clientRes struct {
-	sd  *description.Session // describe only
-	res *base.Response
-	err error
+	sd     *description.Session  // describe only
+	params parameters.Parameters // get parameter only
+	res    *base.Response
+	err    error
+}
+
+// ClientDescriptionParser is a parser of bodies of DESCRIBE responses.
+type ClientDescriptionParser struct {
+	// Content-Type of bodies handled by the parser.
+	ContentType string
+
+	// Parse decodes a DESCRIBE response into a stream description.
+	// When BaseURL of the description is nil, it is filled with
+	// the Content-Base header or with the request URL.
+	Parse func(res *base.Response) (*description.Session, error)
 }
 
 // ClientOnRequestFunc is the prototype of Client.OnRequest.
@@ -185,6 +286,12 @@ type ClientOnRequestFunc func(*base.Request)
 // ClientOnResponseFunc is the prototype of Client.OnResponse.
 type ClientOnResponseFunc func(*base.Response)
 
+// ClientOnResponseValidateFunc is the prototype of Client.OnResponseValidate.
+type ClientOnResponseValidateFunc func(*base.Request, *base.Response) error
+
+// ClientOnAnnounceSDPFunc is the prototype of Client.OnAnnounceSDP.
+type ClientOnAnnounceSDPFunc func(*description.Session) *description.Session
+
 // ClientOnTransportSwitchFunc is the prototype of Client.OnTransportSwitch.
 type ClientOnTransportSwitchFunc func(err error)
 
@@ -194,18 +301,63 @@ type ClientOnPacketLostFunc func(err error)
 // ClientOnDecodeErrorFunc is the prototype of Client.OnDecodeError.
 type ClientOnDecodeErrorFunc func(err error)
 
+// ClientOnWarningFunc is the prototype of Client.OnWarning.
+type ClientOnWarningFunc func(err error)
+
+// ClientOnStreamStallFunc is the prototype of Client.OnStreamStall.
+type ClientOnStreamStallFunc func(err error)
+
+// ClientOnReconnectingFunc is the prototype of Client.OnReconnecting.
+type ClientOnReconnectingFunc func(attempt int, err error)
+
+// ClientOnReconnectedFunc is the prototype of Client.OnReconnected.
+type ClientOnReconnectedFunc func()
+
+// ClientOnStreamEndedFunc is the prototype of Client.OnStreamEnded.
+type ClientOnStreamEndedFunc func(reason string)
+
+// ClientOnRedirectFunc is the prototype of Client.OnRedirect.
+type ClientOnRedirectFunc func(location *base.URL)
+
+// ClientOnQualityReportFunc is the prototype of Client.OnQualityReport.
+type ClientOnQualityReportFunc func(stats []ReceiverStats)
+
+// ClientOnConformanceViolationFunc is the prototype of Client.OnConformanceViolation.
+type ClientOnConformanceViolationFunc func(v *conformance.Violation)
+
 // OnPacketRTPFunc is the prototype of the callback passed to OnPacketRTP().
 type OnPacketRTPFunc func(*rtp.Packet)
 
 // OnPacketRTPAnyFunc is the prototype of the callback passed to OnPacketRTP(Any).
 type OnPacketRTPAnyFunc func(*description.Media, format.Format, *rtp.Packet)
 
+// OnPacketRTPExtensionFunc is the prototype of the callback passed to OnPacketRTPExtension().
+// payload is the payload of the RTP header extension.
+type OnPacketRTPExtensionFunc func(pkt *rtp.Packet, payload []byte)
+
 // OnPacketRTCPFunc is the prototype of the callback passed to OnPacketRTCP().
 type OnPacketRTCPFunc func(rtcp.Packet)
 
 // OnPacketRTCPAnyFunc is the prototype of the callback passed to OnPacketRTCPAny().
 type OnPacketRTCPAnyFunc func(*description.Media, rtcp.Packet)
 
+// OnPacketRTCPRawFunc is the prototype of the callback passed to OnPacketRTCPRaw().
+// payload is a compound RTCP packet and must not be retained after the callback returns.
+type OnPacketRTCPRawFunc func(payload []byte)
+
+// OnPacketRTCPRawAnyFunc is the prototype of the callback passed to OnPacketRTCPRawAny().
+type OnPacketRTCPRawAnyFunc func(medi *description.Media, payload []byte)
+
+// OnBandwidthEstimateFunc is the prototype of the callback passed to OnBandwidthEstimate().
+// bitrate is expressed in bits per second.
+type OnBandwidthEstimateFunc func(medi *description.Media, bitrate uint64)
+
+// OnKeyframeRequestFunc is the prototype of the callback passed to OnKeyframeRequest().
+type OnKeyframeRequestFunc func(medi *description.Media)
+
+// OnInterleavedFrameFunc is the prototype of the callback passed to OnInterleavedFrame().
+type OnInterleavedFrameFunc func(channel int, payload []byte)
+
 // Client is a RTSP client.
 type Client struct {
 	//
@@ -217,6 +369,27 @@ type Client struct {
 	// timeout of write operations.
 	// It defaults to 10 seconds.
 	WriteTimeout time.Duration
+	// timeout of responses to DESCRIBE and SETUP requests.
+	// Servers that prepare streams on demand (i.e. by starting a transcoder)
+	// may take longer to reply to these requests.
+	// It defaults to ReadTimeout.
+	PrepareTimeout time.Duration
+	// period of keepalives (OPTIONS requests) sent while waiting for
+	// responses to DESCRIBE and SETUP requests.
+	// It must be used only with servers that accept pipelined requests
+	// and that fill the CSeq header of responses.
+	// It defaults to zero (disabled).
+	PrepareKeepalivePeriod time.Duration
+	// method of keepalives sent while playing.
+	// It defaults to ClientKeepaliveMethodAuto.
+	KeepaliveMethod ClientKeepaliveMethod
+	// period of keepalives sent while playing.
+	// It defaults to 80% of the session timeout advertised by the server,
+	// or to 30 seconds when the server does not advertise it.
+	KeepalivePeriod time.Duration
+	// period of quality reports passed to OnQualityReport while playing.
+	// It defaults to zero (disabled).
+	QualityReportPeriod time.Duration
 	// a TLS configuration to connect to TLS (RTSPS) servers.
 	// It defaults to nil.
 	TLSConfig *tls.Config
@@ -225,14 +398,95 @@ type Client struct {
 	// This can be a security issue.
 	// It defaults to false.
 	AnyPortEnable bool
+	// number of UDP packets that are read with a single system call (recvmmsg).
+	// This is supported on Linux only, on other platforms packets are read one at a time.
+	// It defaults to 1.
+	UDPBatchSize int
 	// transport protocol (UDP, Multicast or TCP).
-	// If nil, it is chosen automatically (first UDP, then, if it fails, TCP).
+	// If nil, it is chosen automatically among the ones in TransportOrder.
 	// It defaults to nil.
 	Transport *Transport
+	// tunneling method.
+	// When tunneling is enabled, the RTSP connection is wrapped
+	// into HTTP (or HTTPS, when the scheme is rtsps) and TCP transport is used.
+	// It defaults to TunnelNone.
+	Tunnel Tunnel
+	// additional HTTP headers that are added to the requests that open the tunnel.
+	// They override default headers (User-Agent, Accept, ...), but can't override
+	// x-sessioncookie and Content-Type, that are required by the tunneling method itself.
+	// It defaults to nil.
+	TunnelHeader base.Header
+	// transport protocols that are tried in sequence when Transport is nil.
+	// The next one is tried when the server replies to the first SETUP request
+	// with 461 Unsupported Transport or with an unusable Transport header.
+	// It defaults to UDP, TCP.
+	TransportOrder []Transport
 	// If the client is reading with UDP, it must receive
 	// at least a packet within this timeout, otherwise it switches to TCP.
 	// It defaults to 3 seconds.
 	InitialUDPReadTimeout time.Duration
+	// If the client is reading, OnStreamStall is called when no RTP packet
+	// is received within this interval. RTCP packets do not reset it.
+	// The interval is extended to twice the longest gap observed between
+	// RTP packets, in order not to report sparse streams as stalled,
+	// up to four times StallTimeout.
+	// It defaults to zero (disabled).
+	StallTimeout time.Duration
+	// traversal of NATs and firewalls with the UDP transport, and fallback to TCP.
+	// It defaults to a single hole punching packet and to the fallback to TCP.
+	TransportFallback *ClientTransportFallback
+	// reordering of incoming UDP packets (jitter buffer).
+	// Packets are buffered until missing ones are received,
+	// up to a maximum number of packets or a maximum delay.
+	// It defaults to a buffer of 64 packets and no maximum delay.
+	PacketReordering *ClientPacketReordering
+	// decode SDPs received with DESCRIBE with a lenient parser, that tolerates
+	// common violations of the specification.
+	// Corrected or skipped values are reported through OnWarning.
+	// It defaults to false.
+	LenientSDP bool
+	// decode responses with a lenient parser, that accepts LF-only line endings,
+	// missing spaces after colons, folded headers and irregular whitespace.
+	// It defaults to false (responses must comply with the specification).
+	LenientParsing bool
+	// validate requests and responses exchanged with the server against
+	// RFC 2326 and RFC 7826 (required headers, Session presence, CSeq monotonicity,
+	// status code legality), in order to certify devices and debug interoperability issues.
+	// Violations are reported through OnConformanceViolation and don't interrupt the session.
+	// It defaults to false.
+	ConformanceCheck bool
+	// maximum difference between the CSeq of a response that doesn't match any request
+	// and the CSeq of the oldest request waiting for a response, below which the response
+	// is associated with that request and subsequent responses are matched with the numbering
+	// of the server. This allows to communicate with servers that reset or duplicate CSeq,
+	// for instance after authentication retries.
+	// It defaults to 4.
+	ResponseCSeqTolerance int
+	// ignore the CSeq of responses and associate them with requests
+	// in the order in which requests were sent, in order to communicate
+	// with servers that fill CSeq with arbitrary values.
+	// It defaults to false.
+	IgnoreResponseCSeq bool
+	// maximum number of redirects (3xx responses to DESCRIBE and SETUP requests)
+	// that are followed by a single request. Redirect loops are always interrupted.
+	// It defaults to 5.
+	MaxRedirects int
+	// when the server rejects a SETUP request with a status code that suggests
+	// that the media URL is not accepted, try alternative URLs: the control attribute
+	// resolved against the request URL, trackID=N and stream=N suffixes and the aggregate URL.
+	// It defaults to false.
+	ControlURLFallback bool
+	// send the SETUP requests of SetupAll() without waiting for responses (pipelining),
+	// except the first one, that is needed to obtain the session ID.
+	// Requests rejected by the server are sent again one by one.
+	// It defaults to false.
+	PipelineSetup bool
+	// function that resolves the URL of a media (optional).
+	// It receives the media, the base URL and the URL obtained with the standard
+	// resolution of the control attribute (nil if resolution failed).
+	// The returned URL is used for SETUP requests and for PLAY and PAUSE requests
+	// directed to the single media. If it is nil, the standard URL is used.
+	ResolveMediaURL func(medi *description.Media, baseURL *base.URL, defaultURL *base.URL) (*base.URL, error)
 	// Size of the queue of outgoing packets.
 	// It defaults to 256.
 	WriteQueueSize int
@@ -245,22 +499,172 @@ type Client struct {
 	UserAgent string
 	// disable automatic RTCP sender reports.
 	DisableRTCPSenderReports bool
+	// disable NACKs that request retransmission of lost packets.
+	// NACKs are sent when reading with the UDP transport
+	// and the server provides a RTX format.
+	DisableRTCPNACKs bool
+	// disable recovery of lost packets through FEC packets.
+	// Packets are recovered when reading with the UDP transport
+	// and the server provides a FlexFEC format.
+	DisableFECRecovery bool
+	// number of previous payloads carried by each RED packet, when publishing
+	// a stream whose description contains a RED format.
+	// RED packets replace packets of the primary format of RED.
+	// It defaults to 1.
+	REDDepth int
+	// when reading, send RTCP congestion control feedback to the server:
+	// REMB packets and, when the server provides the transport-wide
+	// sequence number extension, TWCC packets.
+	// It defaults to false.
+	RTCPCongestionFeedbackEnable bool
+	// when reading, send RTCP extended reports (RFC 3611) to the server, together with receiver reports.
+	// They allow to compute the round-trip time, available with Stats().
+	// It defaults to false.
+	RTCPExtendedReportsEnable bool
+	// reuse buffers of incoming packets, in order to reduce allocations and GC pressure.
+	// When enabled, packets passed to OnPacketRTP and OnPacketRTCP callbacks,
+	// together with their payloads, are valid only until the callback returns;
+	// they must be cloned (i.e. with rtp.Packet.Clone()) in order to be stored
+	// or passed to other routines. This includes decoders returned by
+	// format.CreateDecoder(), that keep references to payloads between calls.
+	// It defaults to false.
+	PacketBufferReuseEnable bool
+	// send a RTCP sender report as soon as the first RTP packet of each format
+	// is written, before switching to the regular period.
+	// This speeds up A/V sync of servers that wait for sender reports.
+	InitialRTCPSenderReport bool
+	// when publishing, request the server to append data to an existing recording
+	// instead of replacing it, by adding the append parameter to the Transport header
+	// of SETUP requests. Servers that don't support appending refuse the request.
+	// It defaults to false.
+	RecordAppend bool
+	// when publishing, rewrite sequence numbers and timestamps of RTP packets written
+	// after a RECORD has been resumed with Record(), in order to continue the ones
+	// written before Pause(). The pause is removed from the timeline of the recording.
+	// It defaults to false.
+	RecordTimestampContinuity bool
 	// explicitly request back channels to the server.
 	RequestBackChannels bool
+	// enable compatibility with 3GPP PSS servers (3GPP TS 26.234).
+	// When enabled, the 3GPP-Adaptation header is sent with SETUP requests,
+	// and QoE metrics requested by the server through the 3GPP-QoE-Metrics header
+	// are sent periodically with SET_PARAMETER requests.
+	// Successive_Loss is filled with packets lost since the previous report,
+	// Jitter_Duration with the interarrival jitter in milliseconds,
+	// other metrics are reported as not measured.
+	Compatibility3GPP bool
+	// send a Timestamp header with every request, in order to measure
+	// the round-trip time of the control connection through the timestamp
+	// and delay echoed by the server. The result is returned by ControlRTT().
+	SendTimestamp bool
+	// send conditional DESCRIBE requests.
+	// When the server sent a Last-Modified header in response to a previous DESCRIBE
+	// of the same URL, If-Modified-Since is sent, and in case of a 304 Not Modified response,
+	// Describe() returns the previous description together with the response.
+	ConditionalDescribe bool
+	// parsers of DESCRIBE responses with Content-Types different than application/sdp (optional).
+	// Their Content-Types are listed in the Accept header of DESCRIBE requests after application/sdp,
+	// in order of preference. A parser of application/sdp replaces the built-in one.
+	// When the Content-Type of a response is not supported, Describe() returns
+	// ErrClientContentTypeUnsupported together with the response, that contains the raw body.
+	DescriptionParsers []ClientDescriptionParser
+	// ONVIF profile token, returned by GetStreamUri (optional).
+	// If set, it is attached to DESCRIBE and SETUP requests, as a query parameter
+	// named ProfileTokenQueryParam and/or as a header named ProfileTokenHeader.
+	// The token is attached after control URLs are resolved, in order to preserve it.
+	ProfileToken           string
+	ProfileTokenQueryParam string
+	ProfileTokenHeader     string
+	// enable the ONVIF replay extension, in order to read recordings (optional).
+	// When set, 'Require: onvif-replay' is sent with SETUP and PLAY requests,
+	// and the absolute time of frames, provided by the server through
+	// a RTP header extension, is returned by PacketNTP().
+	ONVIFReplay *ClientONVIFReplay
+	// scale requested with PLAY requests, in order to perform
+	// fast-forward, slow motion or reverse playback (optional).
+	// The scale applied by the server is returned in the Scale header of the response.
+	// It defaults to nil (normal playback).
+	Scale *headers.Scale
+	// speed requested with PLAY requests, in order to receive data
+	// faster or slower than real time (optional).
+	// It defaults to nil (real time).
+	Speed *headers.Speed
+	// overrides the clock rate of formats, in order to handle devices
+	// that advertise a wrong one. It is called once for each format after SETUP.
+	// If it returns zero, the advertised clock rate is used.
+	// It defaults to nil.
+	ClockRateOverride func(medi *description.Media, forma format.Format) int
+	// credentials used to authenticate with a proxy that replies
+	// with 407 Proxy Authentication Required.
+	// They are independent from the credentials of the server, that are read from the URL.
+	ProxyUser string
+	ProxyPass string
+	// enable the automatic reconnection, when reading (optional).
+	// During a reconnection, requests wait until the session is established again.
+	// It defaults to nil (disabled).
+	AutoReconnect *ClientAutoReconnect
+	// adjustments of the client behavior, applied when the
+	// Server header of the first response matches one of them.
+	// The first matching set is used.
+	Quirks []ClientQuirks
+	// middlewares that wrap the sending of requests, in order to observe and
+	// rewrite requests and responses (header injection, fix-ups for non-compliant servers,
+	// rate limiting, logging). The first middleware is the outermost one.
+	Middlewares []ClientMiddleware
+	// pool of UDP sockets, that allows clients used sequentially to
+	// share sockets instead of allocating new ones for each session.
+	// It is used when client ports are not provided to Setup().
+	// It defaults to nil.
+	UDPSocketPool *ClientUDPSocketPool
+	// multiplexer that allows clients to share the TCP connection to a server,
+	// while keeping independent sessions.
+	// It is not used when Tunnel is enabled.
+	// It defaults to nil.
+	ConnMux *ClientConnMux
+	// collector of metrics, like packets, bytes, losses and jitter.
+	// It defaults to metrics.Discard.
+	Metrics metrics.Collector
+	// logger of requests, responses, transport negotiation, RTCP reports and retries.
+	// Warnings of the default callbacks are written into it too.
+	// It defaults to logger.Standard, that writes warnings and errors with the standard log package.
+	Logger logger.Logger
 	// pointer to a variable that stores received bytes.
 	BytesReceived *uint64
 	// pointer to a variable that stores sent bytes.
 	BytesSent *uint64
+	// pointer to a variable that stores whitespace bytes that were skipped
+	// between messages of the control connection.
+	// Some cameras send them as a keepalive.
+	BytesSkipped *uint64
+	// if set, it receives raw bytes read from the control connection, before parsing.
+	// This includes interleaved frames when TCP transport is in use.
+	ControlReadDump io.Writer
+	// if set, it receives raw bytes written to the control connection, after marshaling.
+	// This includes interleaved frames when TCP transport is in use.
+	ControlWriteDump io.Writer
 
 	//
 	// system functions (all optional)
 	//
 	// function used to initialize the TCP client.
+	// It can return any net.Conn, allowing to run RTSP over custom connections
+	// (QUIC streams, WebSockets, serial lines, in-memory pipes).
+	// When the connection is not IP-based, the TCP transport is used.
 	// It defaults to (&net.Dialer{}).DialContext.
 	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
 	// function used to initialize UDP listeners.
+	// It can return any net.PacketConn that implements SetReadBuffer(int) error
+	// and that uses *net.UDPAddr addresses, like the in-memory ones of the rtsptest package.
 	// It defaults to net.ListenPacket.
 	ListenPacket func(network, address string) (net.PacketConn, error)
+	// options of the TCP connection (optional).
+	TCPSocketOptions *SocketOptions
+	// options of UDP and UDP-multicast listeners (optional).
+	UDPSocketOptions *SocketOptions
+	// clock used to obtain the current time and to schedule keepalives, RTCP reports and timeouts.
+	// It can be replaced with a clock.Virtual in order to run tests and simulations deterministically.
+	// It defaults to the system clock.
+	Clock clock.Clock
 
 	//
 	// callbacks (all optional)
@@ -269,6 +673,9 @@ type Client struct {
 	OnRequest ClientOnRequestFunc
 	// called when receiving a response from the server.
 	OnResponse ClientOnResponseFunc
+	// called when receiving a response to a request, in order to validate it.
+	// If it returns an error, the current operation is aborted with that error.
+	OnResponseValidate ClientOnResponseValidateFunc
 	// called when receiving a request from the server.
 	OnServerRequest ClientOnRequestFunc
 	// called when sending a response to the server.
@@ -279,6 +686,38 @@ type Client struct {
 	OnPacketLost ClientOnPacketLostFunc
 	// called when a non-fatal decode error occurs.
 	OnDecodeError ClientOnDecodeErrorFunc
+	// called when a non-fatal error occurs that degrades the session.
+	OnWarning ClientOnWarningFunc
+	// called when no RTP packet is received within StallTimeout.
+	// It is called again only after the stream resumes.
+	OnStreamStall ClientOnStreamStallFunc
+	// called before sending an ANNOUNCE request, in order to adjust the description
+	// that is sent to the server. The returned description is validated before sending it,
+	// and its medias are the ones that must be passed to Setup().
+	OnAnnounceSDP ClientOnAnnounceSDPFunc
+	// called before each attempt of reconnecting to the server.
+	// err is the error that caused the connection to be lost.
+	OnReconnecting ClientOnReconnectingFunc
+	// called when the session has been established again.
+	OnReconnected ClientOnReconnectedFunc
+	// called when the server signals the end of the stream with a RTCP BYE packet
+	// or with an ANNOUNCE request that contains an end-of-stream notice.
+	// It is called once per PLAY request.
+	OnStreamEnded ClientOnStreamEndedFunc
+	// called when the client is redirected to another location, by a 3xx response
+	// or by a REDIRECT request of the server.
+	// After a REDIRECT request, the client is closed with liberrors.ErrClientRedirected,
+	// unless AutoReconnect is set, in that case it connects to the server of the new location.
+	OnRedirect ClientOnRedirectFunc
+	// called periodically while playing, with statistics and quality of the formats
+	// that are being read. The period is set by QualityReportPeriod.
+	OnQualityReport ClientOnQualityReportFunc
+	// called when a request or a response violates the specification.
+	// It is used only when ConformanceCheck is true.
+	OnConformanceViolation ClientOnConformanceViolationFunc
+	// listener of lifecycle events (requests, responses, bytes, sessions, transports).
+	// It may implement one or more of the EventsListener* interfaces.
+	EventsListener EventsListener
 
 	//
 	// private
@@ -289,6 +728,11 @@ type Client struct {
 	receiverReportPeriod time.Duration
 	checkTimeoutPeriod   time.Duration
 
+	log                  logger.Logger
+	events               *eventsEmitter
+	metrics              *roleMetrics
+	capture              packetCapture
+	impairer             *chaos.Impairer
 	connURL              *base.URL
 	ctx                  context.Context
 	ctxCancel            func()
@@ -296,40 +740,84 @@ type Client struct {
 	nconn                net.Conn
 	conn                 *conn.Conn
 	session              string
+	metricsSessionID     string
+	quirksChecked        bool
+	quirks               *ClientQuirks
 	sender               *auth.Sender
+	proxySender          *auth.Sender
 	cseq                 int
+	outstandingCSeqs     []int
+	cseqOffset           int
 	optionsSent          bool
 	useGetParameter      bool
+	keepaliveRejected    map[base.Method]struct{}
+	keepaliveCSeq        string
+	keepaliveLastMethod  base.Method
+	setupRedirectFrom    *base.URL
+	setupRedirectTo      *base.URL
 	lastDescribeURL      *base.URL
+	lastMedias           []*description.Media
+	requestCtx           context.Context
+	pendingRequests      map[string]*clientPendingRequest
+	pendingSetups        []*clientSetup
+	describeCache        *clientDescribeCache
+	timestampBase        time.Time
+	controlRTT           *int64
+	conformanceRequests  *conformance.Checker // requests of the client
+	conformanceServer    *conformance.Checker // requests of the server
+	announceURL          *base.URL
 	baseURL              *base.URL
 	effectiveTransport   *Transport
+	transportIndex       int
 	backChannelSetupped  bool
 	stdChannelSetupped   bool
 	medias               map[*description.Media]*clientMedia
 	tcpCallbackByChannel map[int]readFunc
 	lastRange            *headers.Range
-	checkTimeoutTimer    *time.Timer
+	recordRange          *headers.Range
+	checkTimeoutTimer    clock.Timer
 	checkTimeoutInitial  bool
+	punchTimer           clock.Timer
 	tcpLastFrameTime     *int64
+	streamEnded          *int32
+	playStartTime        time.Time
+	lastRTPTime          *int64
+	maxRTPGap            *int64
+	stalled              *int32
 	keepalivePeriod      time.Duration
-	keepaliveTimer       *time.Timer
+	keepaliveTimer       clock.Timer
+	qoeMetrics           headers.QoEMetrics3GPP
+	qoeLastLost          map[*clientMedia]uint32
+	qoeTimer             clock.Timer
+	qualityReportTimer   clock.Timer
 	closeError           error
 	writer               asyncProcessor
 	reader               *clientReader
 	timeDecoder          *rtptime.GlobalDecoder
 	mustClose            bool
+	onBandwidthEstimate  OnBandwidthEstimateFunc
+	onBandwidthMutex     sync.RWMutex
+	onKeyframeRequest    OnKeyframeRequestFunc
+	onKeyframeMutex      sync.RWMutex
+	onInterleavedFrame   OnInterleavedFrameFunc
 
 	// in
-	chOptions      chan optionsReq
-	chDescribe     chan describeReq
-	chAnnounce     chan announceReq
-	chSetup        chan setupReq
-	chPlay         chan playReq
-	chRecord       chan recordReq
-	chPause        chan pauseReq
-	chReadError    chan error
-	chReadResponse chan *base.Response
-	chReadRequest  chan *base.Request
+	chOptions       chan optionsReq
+	chDescribe      chan describeReq
+	chAnnounce      chan announceReq
+	chSetup         chan setupReq
+	chPlay          chan playReq
+	chRecord        chan recordReq
+	chPause         chan pauseReq
+	chAddMedia      chan setupReq
+	chTeardownMedia chan teardownMediaReq
+	chGetParameter  chan getParameterReq
+	chSetParameter  chan setParameterReq
+	chSendRequest   chan sendRequestReq
+	chSetupAll      chan setupAllReq
+	chReadError     chan error
+	chReadResponse  chan *base.Response
+	chReadRequest   chan *base.Request
 
 	// out
 	done chan struct{}
@@ -341,9 +829,15 @@ func (c *Client) Start(scheme string, host string) error {
 	if c.ReadTimeout == 0 {
 		c.ReadTimeout = 10 * time.Second
 	}
+	if c.PrepareTimeout == 0 {
+		c.PrepareTimeout = c.ReadTimeout
+	}
 	if c.WriteTimeout == 0 {
 		c.WriteTimeout = 10 * time.Second
 	}
+	if len(c.TransportOrder) == 0 {
+		c.TransportOrder = []Transport{TransportUDP, TransportTCP}
+	}
 	if c.InitialUDPReadTimeout == 0 {
 		c.InitialUDPReadTimeout = 3 * time.Second
 	}
@@ -352,24 +846,66 @@ func (c *Client) Start(scheme string, host string) error {
 	} else if (c.WriteQueueSize & (c.WriteQueueSize - 1)) != 0 {
 		return fmt.Errorf("WriteQueueSize must be a power of two")
 	}
+	if c.ProfileToken != "" && c.ProfileTokenQueryParam == "" && c.ProfileTokenHeader == "" {
+		return fmt.Errorf("ProfileToken requires ProfileTokenQueryParam or ProfileTokenHeader")
+	}
 	if c.MaxPacketSize == 0 {
 		c.MaxPacketSize = udpMaxPayloadSize
 	} else if c.MaxPacketSize > udpMaxPayloadSize {
 		return fmt.Errorf("MaxPacketSize must be less than %d", udpMaxPayloadSize)
 	}
+	if c.MaxRedirects == 0 {
+		c.MaxRedirects = 5
+	}
+	if c.ResponseCSeqTolerance == 0 {
+		c.ResponseCSeqTolerance = 4
+	}
+	if c.UDPBatchSize == 0 {
+		c.UDPBatchSize = 1
+	} else if c.UDPBatchSize < 0 {
+		return fmt.Errorf("UDPBatchSize must be greater than zero")
+	}
+	if c.PacketReordering != nil && (c.PacketReordering.Size < 0 || c.PacketReordering.Window < 0) {
+		return fmt.Errorf("invalid PacketReordering")
+	}
+	if c.TransportFallback != nil && (c.TransportFallback.PunchInterval < 0 || c.TransportFallback.PredictedPorts < 0) {
+		return fmt.Errorf("invalid TransportFallback")
+	}
+	err := c.TCPSocketOptions.validate()
+	if err != nil {
+		return err
+	}
+	err = c.UDPSocketOptions.validate()
+	if err != nil {
+		return err
+	}
 	if c.UserAgent == "" {
 		c.UserAgent = "gortsplib"
 	}
+	if c.Metrics == nil {
+		c.Metrics = metrics.Discard
+	}
+	if c.Logger == nil {
+		c.Logger = logger.Standard
+	}
 	if c.BytesReceived == nil {
 		c.BytesReceived = new(uint64)
 	}
 	if c.BytesSent == nil {
 		c.BytesSent = new(uint64)
 	}
+	if c.BytesSkipped == nil {
+		c.BytesSkipped = new(uint64)
+	}
 
 	// system functions
 	if c.DialContext == nil {
-		c.DialContext = (&net.Dialer{}).DialContext
+		dialer := &net.Dialer{}
+		if c.TCPSocketOptions != nil {
+			// the connection must be bound to the device before it is established
+			dialer.Control = c.TCPSocketOptions.control
+		}
+		c.DialContext = dialer.DialContext
 	}
 	if c.ListenPacket == nil {
 		c.ListenPacket = net.ListenPacket
@@ -384,6 +920,11 @@ func (c *Client) Start(scheme string, host string) error {
 		c.OnResponse = func(*base.Response) {
 		}
 	}
+	if c.OnResponseValidate == nil {
+		c.OnResponseValidate = func(*base.Request, *base.Response) error {
+			return nil
+		}
+	}
 	if c.OnServerRequest == nil {
 		c.OnServerRequest = func(*base.Request) {
 		}
@@ -393,24 +934,69 @@ func (c *Client) Start(scheme string, host string) error {
 		}
 	}
 	if c.OnTransportSwitch == nil {
-		c.OnTransportSwitch = func(err error) {
-			log.Println(err.Error())
+		c.OnTransportSwitch = func(error) {
 		}
 	}
 	if c.OnPacketLost == nil {
 		c.OnPacketLost = func(err error) {
-			log.Println(err.Error())
+			c.log.Warn("packets lost", "err", err)
 		}
 	}
 	if c.OnDecodeError == nil {
 		c.OnDecodeError = func(err error) {
-			log.Println(err.Error())
+			c.log.Warn("decode error", "err", err)
+		}
+	}
+	if c.OnWarning == nil {
+		c.OnWarning = func(err error) {
+			c.log.Warn("warning", "err", err)
+		}
+	}
+	if c.OnStreamStall == nil {
+		c.OnStreamStall = func(err error) {
+			c.log.Warn("stream stalled", "err", err)
+		}
+	}
+	if c.OnReconnecting == nil {
+		c.OnReconnecting = func(int, error) {
+		}
+	}
+	if c.OnReconnected == nil {
+		c.OnReconnected = func() {
+		}
+	}
+	if c.OnStreamEnded == nil {
+		c.OnStreamEnded = func(string) {
+		}
+	}
+	if c.OnRedirect == nil {
+		c.OnRedirect = func(*base.URL) {
+		}
+	}
+	if c.OnQualityReport == nil {
+		c.OnQualityReport = func([]ReceiverStats) {
+		}
+	}
+	if c.OnConformanceViolation == nil {
+		c.OnConformanceViolation = func(v *conformance.Violation) {
+			c.log.Warn("conformance violation", "err", v)
+		}
+	}
+	if c.OnAnnounceSDP == nil {
+		c.OnAnnounceSDP = func(desc *description.Session) *description.Session {
+			return desc
 		}
 	}
 
+	if c.Clock == nil {
+		c.Clock = clock.System{}
+	}
+
 	// private
 	if c.timeNow == nil {
-		c.timeNow = time.Now
+		c.timeNow = c.Clock.Now
+	} else {
+		c.Clock = clock.WithNow(c.Clock, c.timeNow)
 	}
 	if c.senderReportPeriod == 0 {
 		c.senderReportPeriod = 10 * time.Second
@@ -423,6 +1009,14 @@ func (c *Client) Start(scheme string, host string) error {
 		c.checkTimeoutPeriod = 1 * time.Second
 	}
 
+	c.timestampBase = c.timeNow()
+	c.log = logger.With(c.Logger, "host", host)
+	c.events = newEventsEmitter(c.EventsListener)
+	c.metrics = newRoleMetrics(c.Metrics, "client")
+	c.controlRTT = int64Ptr(-1)
+	c.impairer = &chaos.Impairer{Clock: c.Clock}
+	c.impairer.Init()
+
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	c.connURL = &base.URL{
@@ -432,8 +1026,14 @@ func (c *Client) Start(scheme string, host string) error {
 	c.ctx = ctx
 	c.ctxCancel = ctxCancel
 	c.checkTimeoutTimer = emptyTimer()
+	c.punchTimer = emptyTimer()
 	c.keepalivePeriod = 30 * time.Second
+	if c.KeepalivePeriod != 0 {
+		c.keepalivePeriod = c.KeepalivePeriod
+	}
 	c.keepaliveTimer = emptyTimer()
+	c.qoeTimer = emptyTimer()
+	c.qualityReportTimer = emptyTimer()
 	c.chOptions = make(chan optionsReq)
 	c.chDescribe = make(chan describeReq)
 	c.chAnnounce = make(chan announceReq)
@@ -441,6 +1041,12 @@ func (c *Client) Start(scheme string, host string) error {
 	c.chPlay = make(chan playReq)
 	c.chRecord = make(chan recordReq)
 	c.chPause = make(chan pauseReq)
+	c.chAddMedia = make(chan setupReq)
+	c.chTeardownMedia = make(chan teardownMediaReq)
+	c.chGetParameter = make(chan getParameterReq)
+	c.chSetParameter = make(chan setParameterReq)
+	c.chSendRequest = make(chan sendRequestReq)
+	c.chSetupAll = make(chan setupAllReq)
 	c.chReadError = make(chan error)
 	c.chReadResponse = make(chan *base.Response)
 	c.chReadRequest = make(chan *base.Request)
@@ -453,6 +1059,11 @@ func (c *Client) Start(scheme string, host string) error {
 
 // StartRecording connects to the address and starts publishing given media.
 func (c *Client) StartRecording(address string, desc *description.Session) error {
+	return c.StartRecordingContext(context.Background(), address, desc)
+}
+
+// StartRecordingContext is like StartRecording(), but the requests can be canceled with a context.
+func (c *Client) StartRecordingContext(ctx context.Context, address string, desc *description.Session) error {
 	u, err := base.ParseURL(address)
 	if err != nil {
 		return err
@@ -463,19 +1074,19 @@ func (c *Client) StartRecording(address string, desc *description.Session) error
 		return err
 	}
 
-	_, err = c.Announce(u, desc)
+	_, err = c.AnnounceContext(ctx, u, desc)
 	if err != nil {
 		c.Close()
 		return err
 	}
 
-	err = c.SetupAll(u, desc.Medias)
+	err = c.SetupAllContext(ctx, u, desc.Medias)
 	if err != nil {
 		c.Close()
 		return err
 	}
 
-	_, err = c.Record()
+	_, err = c.RecordContext(ctx)
 	if err != nil {
 		c.Close()
 		return err
@@ -500,7 +1111,19 @@ func (c *Client) Wait() error {
 func (c *Client) run() {
 	defer close(c.done)
 
-	c.closeError = c.runInner()
+	for {
+		err := c.runInner()
+
+		if c.canReconnect(err) {
+			err = c.doReconnect(err)
+			if err == nil {
+				continue
+			}
+		}
+
+		c.closeError = err
+		break
+	}
 
 	c.ctxCancel()
 
@@ -511,7 +1134,9 @@ func (c *Client) runInner() error {
 	for {
 		select {
 		case req := <-c.chOptions:
+			c.requestCtx = req.ctx
 			res, err := c.doOptions(req.url)
+			c.requestCtx = nil
 			req.res <- clientRes{res: res, err: err}
 
 			if c.mustClose {
@@ -519,7 +1144,9 @@ func (c *Client) runInner() error {
 			}
 
 		case req := <-c.chDescribe:
+			c.requestCtx = req.ctx
 			sd, res, err := c.doDescribe(req.url)
+			c.requestCtx = nil
 			req.res <- clientRes{sd: sd, res: res, err: err}
 
 			if c.mustClose {
@@ -527,7 +1154,9 @@ func (c *Client) runInner() error {
 			}
 
 		case req := <-c.chAnnounce:
+			c.requestCtx = req.ctx
 			res, err := c.doAnnounce(req.url, req.desc)
+			c.requestCtx = nil
 			req.res <- clientRes{res: res, err: err}
 
 			if c.mustClose {
@@ -535,7 +1164,9 @@ func (c *Client) runInner() error {
 			}
 
 		case req := <-c.chSetup:
+			c.requestCtx = req.ctx
 			res, err := c.doSetup(req.baseURL, req.media, req.rtpPort, req.rtcpPort)
+			c.requestCtx = nil
 			req.res <- clientRes{res: res, err: err}
 
 			if c.mustClose {
@@ -543,7 +1174,15 @@ func (c *Client) runInner() error {
 			}
 
 		case req := <-c.chPlay:
-			res, err := c.doPlay(req.ra)
+			c.requestCtx = req.ctx
+			var res *base.Response
+			var err error
+			if req.media != nil {
+				res, err = c.doResumeMedia(req.media)
+			} else {
+				res, err = c.doPlay(req.ra)
+			}
+			c.requestCtx = nil
 			req.res <- clientRes{res: res, err: err}
 
 			if c.mustClose {
@@ -551,7 +1190,9 @@ func (c *Client) runInner() error {
 			}
 
 		case req := <-c.chRecord:
+			c.requestCtx = req.ctx
 			res, err := c.doRecord()
+			c.requestCtx = nil
 			req.res <- clientRes{res: res, err: err}
 
 			if c.mustClose {
@@ -559,26 +1200,106 @@ func (c *Client) runInner() error {
 			}
 
 		case req := <-c.chPause:
-			res, err := c.doPause()
+			c.requestCtx = req.ctx
+			var res *base.Response
+			var err error
+			if req.media != nil {
+				res, err = c.doPauseMedia(req.media)
+			} else {
+				res, err = c.doPause()
+			}
+			c.requestCtx = nil
+			req.res <- clientRes{res: res, err: err}
+
+			if c.mustClose {
+				return err
+			}
+
+		case req := <-c.chAddMedia:
+			c.requestCtx = req.ctx
+			res, err := c.doAddMedia(req.baseURL, req.media, req.rtpPort, req.rtcpPort)
+			c.requestCtx = nil
+			req.res <- clientRes{res: res, err: err}
+
+			if c.mustClose {
+				return err
+			}
+
+		case req := <-c.chTeardownMedia:
+			c.requestCtx = req.ctx
+			res, err := c.doTeardownMedia(req.media)
+			c.requestCtx = nil
+			req.res <- clientRes{res: res, err: err}
+
+			if c.mustClose {
+				return err
+			}
+
+		case req := <-c.chGetParameter:
+			c.requestCtx = req.ctx
+			params, res, err := c.doGetParameter(req.names)
+			c.requestCtx = nil
+			req.res <- clientRes{params: params, res: res, err: err}
+
+			if c.mustClose {
+				return err
+			}
+
+		case req := <-c.chSetParameter:
+			c.requestCtx = req.ctx
+			res, err := c.doSetParameter(req.params)
+			c.requestCtx = nil
 			req.res <- clientRes{res: res, err: err}
 
 			if c.mustClose {
 				return err
 			}
 
-		case <-c.checkTimeoutTimer.C:
+		case req := <-c.chSendRequest:
+			f, err := c.doSendRequest(req.req)
+			req.res <- sendRequestRes{future: f, err: err}
+
+			if c.mustClose {
+				return err
+			}
+
+		case req := <-c.chSetupAll:
+			c.requestCtx = req.ctx
+			err := c.doSetupAll(req.baseURL, req.medias)
+			c.requestCtx = nil
+			req.res <- clientRes{err: err}
+
+			if c.mustClose {
+				return err
+			}
+
+		case <-c.checkTimeoutTimer.C():
 			err := c.doCheckTimeout()
 			if err != nil {
 				return err
 			}
-			c.checkTimeoutTimer = time.NewTimer(c.checkTimeoutPeriod)
+			c.checkTimeoutTimer = c.Clock.NewTimer(c.checkTimeoutPeriod)
+
+		case <-c.punchTimer.C():
+			c.doPunch()
 
-		case <-c.keepaliveTimer.C:
+		case <-c.keepaliveTimer.C():
 			err := c.doKeepAlive()
 			if err != nil {
 				return err
 			}
-			c.keepaliveTimer = time.NewTimer(c.keepalivePeriod)
+			c.keepaliveTimer = c.Clock.NewTimer(c.keepalivePeriod)
+
+		case <-c.qoeTimer.C():
+			err := c.doQoEFeedback()
+			if err != nil {
+				return err
+			}
+			c.qoeTimer = c.Clock.NewTimer(c.qoeReportPeriod())
+
+		case <-c.qualityReportTimer.C():
+			c.OnQualityReport(c.Stats())
+			c.qualityReportTimer = c.Clock.NewTimer(c.QualityReportPeriod)
 
 		case err := <-c.chReadError:
 			c.reader = nil
@@ -586,7 +1307,11 @@ func (c *Client) runInner() error {
 
 		case res := <-c.chReadResponse:
 			c.OnResponse(res)
-			// these are responses to keepalives, ignore them.
+			c.events.responseReceived(EventSource{Client: c}, res)
+			// these are responses to keepalives or to requests sent with SendRequest().
+			if cseqStr, ok := c.correlateResponse(res, ""); ok {
+				c.dispatchResponse(cseqStr, res)
+			}
 
 		case req := <-c.chReadRequest:
 			err := c.handleServerRequest(req)
@@ -600,27 +1325,60 @@ func (c *Client) runInner() error {
 	}
 }
 
-func (c *Client) waitResponse(requestCseqStr string) (*base.Response, error) {
-	t := time.NewTimer(c.ReadTimeout)
+func (c *Client) waitResponse(req *base.Request, requestCseqStr string) (*base.Response, error) {
+	timeout := c.ReadTimeout
+	var keepaliveC <-chan time.Time
+
+	if req.Method == base.Describe || req.Method == base.Setup {
+		timeout = c.PrepareTimeout
+
+		if c.PrepareKeepalivePeriod != 0 {
+			keepaliveTicker := c.Clock.NewTicker(c.PrepareKeepalivePeriod)
+			defer keepaliveTicker.Stop()
+			keepaliveC = keepaliveTicker.C()
+		}
+	}
+
+	t := c.Clock.NewTimer(timeout)
 	defer t.Stop()
 
 	for {
 		select {
-		case <-t.C:
+		case <-t.C():
 			return nil, liberrors.ErrClientRequestTimedOut{}
 
+		case <-keepaliveC:
+			// the response is discarded since its CSeq is different
+			_, err := c.do(&base.Request{
+				Method: base.Options,
+				URL:    req.URL,
+			}, true)
+			if err != nil {
+				return nil, err
+			}
+
 		case err := <-c.chReadError:
 			c.reader = nil
 			return nil, err
 
+		case <-c.requestDone():
+			return nil, c.requestCtx.Err()
+
 		case res := <-c.chReadResponse:
 			c.OnResponse(res)
+			c.events.responseReceived(EventSource{Client: c}, res)
 
-			// accept response if CSeq equals request CSeq, or if CSeq is not present
-			if cseq, ok := res.Header["CSeq"]; !ok || len(cseq) != 1 || strings.TrimSpace(cseq[0]) == requestCseqStr {
+			cseqStr, ok := c.correlateResponse(res, requestCseqStr)
+			if !ok {
+				continue
+			}
+
+			if cseqStr == requestCseqStr {
 				return res, nil
 			}
 
+			c.dispatchResponse(cseqStr, res)
+
 		case req := <-c.chReadRequest:
 			err := c.handleServerRequest(req)
 			if err != nil {
@@ -633,35 +1391,109 @@ func (c *Client) waitResponse(requestCseqStr string) (*base.Response, error) {
 	}
 }
 
+// requestDone returns a channel that is closed when the request that is being processed is canceled.
+func (c *Client) requestDone() <-chan struct{} {
+	if c.requestCtx == nil {
+		return nil
+	}
+	return c.requestCtx.Done()
+}
+
+// isEndOfStreamNotice checks whether an ANNOUNCE request of the server signals the end of the stream.
+func isEndOfStreamNotice(req *base.Request) bool {
+	return strings.HasPrefix(req.Header.Get("X-Notice"), "2101")
+}
+
 func (c *Client) handleServerRequest(req *base.Request) error {
 	c.OnServerRequest(req)
+	c.events.requestReceived(EventSource{Client: c}, req)
+
+	if c.conformanceServer != nil {
+		c.conformanceServer.CheckRequest(req)
+	}
+
+	var res *base.Response
+	var redirectErr error
+
+	switch req.Method {
+	case base.Options, base.GetParameter:
+		res = &base.Response{
+			StatusCode: base.StatusOK,
+		}
+
+	case base.Announce:
+		switch {
+		case isEndOfStreamNotice(req):
+			res = &base.Response{
+				StatusCode: base.StatusOK,
+			}
 
-	if req.Method != base.Options {
+			if c.state == clientStatePlay && atomic.CompareAndSwapInt32(c.streamEnded, 0, 1) {
+				c.OnStreamEnded("end of stream")
+			}
+
+		case isDescriptionUpdate(req):
+			res = c.handleDescriptionUpdate(req)
+
+		default:
+			return liberrors.ErrClientUnhandledMethod{Method: req.Method}
+		}
+
+	case base.Redirect:
+		res, redirectErr = c.handleServerRedirect(req)
+
+	default:
 		return liberrors.ErrClientUnhandledMethod{Method: req.Method}
 	}
 
-	h := base.Header{
+	res.Header = base.Header{
 		"User-Agent": base.HeaderValue{c.UserAgent},
 	}
 
 	if cseq, ok := req.Header["CSeq"]; ok {
-		h["CSeq"] = cseq
+		res.Header["CSeq"] = cseq
+	}
+
+	if req.Method == base.Options {
+		res.Header["Public"] = base.HeaderValue{strings.Join([]string{
+			string(base.Options),
+			string(base.GetParameter),
+			string(base.Announce),
+			string(base.Redirect),
+		}, ", ")}
 	}
 
-	res := &base.Response{
-		StatusCode: base.StatusOK,
-		Header:     h,
+	if c.conformanceServer != nil {
+		c.conformanceServer.CheckResponse(req, res)
 	}
 
 	c.OnServerResponse(res)
 
 	c.nconn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
-	return c.conn.WriteResponse(res)
+	err := c.conn.WriteResponse(res)
+	if err != nil {
+		return err
+	}
+
+	c.events.responseSent(EventSource{Client: c}, res)
+	return redirectErr
 }
 
 func (c *Client) doClose() {
+	// send final QoE metrics before statistics are released
+	if c.state == clientStatePlay && c.qoeMetrics != nil && c.nconn != nil {
+		c.doQoEFeedback() //nolint:errcheck
+	}
+
 	if c.state == clientStatePlay || c.state == clientStateRecord {
 		c.stopWriter()
+
+		if c.nconn != nil {
+			for _, cm := range c.medias {
+				cm.writeGoodbye()
+			}
+		}
+
 		c.stopReadRoutines()
 	}
 
@@ -691,9 +1523,16 @@ func (c *Client) doClose() {
 		c.conn = nil
 	}
 
+	c.failPendingRequests(liberrors.ErrClientTerminated{})
+
 	for _, cm := range c.medias {
 		cm.close()
 	}
+
+	if c.session != "" {
+		c.metrics.sessions.Add(-1)
+		c.events.sessionDestroyed(EventSource{Client: c}, c.closeError)
+	}
 }
 
 func (c *Client) reset() {
@@ -701,16 +1540,28 @@ func (c *Client) reset() {
 
 	c.state = clientStateInitial
 	c.session = ""
+	c.quirksChecked = false
+	c.quirks = nil
 	c.sender = nil
+	c.proxySender = nil
 	c.cseq = 0
+	c.outstandingCSeqs = nil
+	c.cseqOffset = 0
 	c.optionsSent = false
 	c.useGetParameter = false
+	c.keepaliveRejected = nil
+	c.keepaliveCSeq = ""
+	c.announceURL = nil
 	c.baseURL = nil
 	c.effectiveTransport = nil
+	c.transportIndex = 0
 	c.backChannelSetupped = false
 	c.stdChannelSetupped = false
 	c.medias = nil
 	c.tcpCallbackByChannel = nil
+	c.qoeMetrics = nil
+	c.qoeLastLost = nil
+	c.recordRange = nil
 }
 
 func (c *Client) checkState(allowed map[clientState]struct{}) error {
@@ -728,8 +1579,13 @@ func (c *Client) checkState(allowed map[clientState]struct{}) error {
 	return liberrors.ErrClientInvalidState{AllowedList: allowedList, State: c.state}
 }
 
+func (c *Client) switchTransport(err error) {
+	c.log.Warn("switching transport", "reason", err)
+	c.OnTransportSwitch(err)
+}
+
 func (c *Client) trySwitchingProtocol() error {
-	c.OnTransportSwitch(liberrors.ErrClientSwitchToTCP{})
+	c.switchTransport(liberrors.ErrClientSwitchToTCP{})
 
 	prevConnURL := c.connURL
 	prevBaseURL := c.baseURL
@@ -754,6 +1610,7 @@ func (c *Client) trySwitchingProtocol() error {
 		}
 
 		c.medias[i].onPacketRTCP = cm.onPacketRTCP
+		c.medias[i].onPacketRTCPRaw = cm.onPacketRTCPRaw
 		for j, tr := range cm.formats {
 			c.medias[i].formats[j].onPacketRTP = tr.onPacketRTP
 		}
@@ -768,7 +1625,7 @@ func (c *Client) trySwitchingProtocol() error {
 }
 
 func (c *Client) trySwitchingProtocol2(medi *description.Media, baseURL *base.URL) (*base.Response, error) {
-	c.OnTransportSwitch(liberrors.ErrClientSwitchToTCP2{})
+	c.switchTransport(liberrors.ErrClientSwitchToTCP2{})
 
 	prevConnURL := c.connURL
 
@@ -798,25 +1655,35 @@ func (c *Client) startReadRoutines() {
 		c.writer.allocateBuffer(8)
 	}
 
+	c.streamEnded = new(int32)
+
 	c.timeDecoder = rtptime.NewGlobalDecoder()
+	c.lastRTPTime = new(int64)
+	c.maxRTPGap = new(int64)
+	c.stalled = new(int32)
 
 	for _, cm := range c.medias {
 		cm.start()
 	}
 
 	if c.state == clientStatePlay && c.stdChannelSetupped {
-		c.keepaliveTimer = time.NewTimer(c.keepalivePeriod)
+		c.keepaliveTimer = c.Clock.NewTimer(c.keepalivePeriod)
+		c.playStartTime = c.timeNow()
+
+		if c.QualityReportPeriod != 0 {
+			c.qualityReportTimer = c.Clock.NewTimer(c.QualityReportPeriod)
+		}
 
 		switch *c.effectiveTransport {
 		case TransportUDP:
-			c.checkTimeoutTimer = time.NewTimer(c.InitialUDPReadTimeout)
+			c.checkTimeoutTimer = c.Clock.NewTimer(c.InitialUDPReadTimeout)
 			c.checkTimeoutInitial = true
 
 		case TransportUDPMulticast:
-			c.checkTimeoutTimer = time.NewTimer(c.checkTimeoutPeriod)
+			c.checkTimeoutTimer = c.Clock.NewTimer(c.checkTimeoutPeriod)
 
 		default: // TCP
-			c.checkTimeoutTimer = time.NewTimer(c.checkTimeoutPeriod)
+			c.checkTimeoutTimer = c.Clock.NewTimer(c.checkTimeoutPeriod)
 			v := c.timeNow().Unix()
 			c.tcpLastFrameTime = &v
 		}
@@ -833,7 +1700,10 @@ func (c *Client) stopReadRoutines() {
 	}
 
 	c.checkTimeoutTimer = emptyTimer()
+	c.punchTimer = emptyTimer()
 	c.keepaliveTimer = emptyTimer()
+	c.qoeTimer = emptyTimer()
+	c.qualityReportTimer = emptyTimer()
 
 	for _, cm := range c.medias {
 		cm.stop()
@@ -850,7 +1720,9 @@ func (c *Client) stopWriter() {
 	c.writer.stop()
 }
 
-func (c *Client) connOpen() error {
+// connOpen opens the connection. u is the URL of the request that is going to be sent,
+// that is also used to open the tunnel, if any.
+func (c *Client) connOpen(u *base.URL) error {
 	if c.nconn != nil {
 		return nil
 	}
@@ -863,58 +1735,125 @@ func (c *Client) connOpen() error {
 		return liberrors.ErrClientRTSPSTCP{}
 	}
 
+	if c.Tunnel != TunnelNone && c.Transport != nil && *c.Transport != TransportTCP {
+		return liberrors.ErrClientTunnelTCP{}
+	}
+
 	dialCtx, dialCtxCancel := context.WithTimeout(c.ctx, c.ReadTimeout)
 	defer dialCtxCancel()
 
-	nconn, err := c.DialContext(dialCtx, "tcp", canonicalAddr(c.connURL))
-	if err != nil {
-		return err
+	// the dial is interrupted also when the request is canceled
+	if c.requestCtx != nil {
+		go func(requestCtx context.Context) {
+			select {
+			case <-requestCtx.Done():
+				dialCtxCancel()
+			case <-dialCtx.Done():
+			}
+		}(c.requestCtx)
 	}
 
-	if c.connURL.Scheme == "rtsps" {
-		tlsConfig := c.TLSConfig
-		if tlsConfig == nil {
-			tlsConfig = &tls.Config{}
+	dial := func(ctx context.Context) (net.Conn, error) {
+		nconn, err := c.DialContext(ctx, "tcp", canonicalAddr(c.connURL))
+		if err != nil {
+			return nil, err
 		}
-		tlsConfig.ServerName = c.connURL.Hostname()
-
-		nconn = tls.Client(nconn, tlsConfig)
-	}
-
-	c.nconn = nconn
-	bc := bytecounter.New(c.nconn, c.BytesReceived, c.BytesSent)
-	c.conn = conn.NewConn(bc)
-	c.reader = newClientReader(c)
-
-	return nil
-}
 
-func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error) {
-	if !c.optionsSent && req.Method != base.Options {
-		_, err := c.doOptions(req.URL)
+		err = c.TCPSocketOptions.apply(nconn, nil)
 		if err != nil {
+			nconn.Close()
 			return nil, err
 		}
-	}
 
-	if req.Header == nil {
-		req.Header = make(base.Header)
-	}
-
-	if c.session != "" {
-		req.Header["Session"] = base.HeaderValue{c.session}
-	}
-
-	c.cseq++
-	cseqStr := strconv.FormatInt(int64(c.cseq), 10)
-	req.Header["CSeq"] = base.HeaderValue{cseqStr}
+		if c.connURL.Scheme == "rtsps" {
+			tlsConfig := c.TLSConfig
+			if tlsConfig == nil {
+				tlsConfig = &tls.Config{}
+			}
+			tlsConfig.ServerName = c.connURL.Hostname()
 
-	req.Header["User-Agent"] = base.HeaderValue{c.UserAgent}
+			nconn = tls.Client(nconn, tlsConfig)
+		}
 
-	if c.sender != nil {
-		c.sender.AddAuthorization(req)
+		return nconn, nil
 	}
 
+	var nconn net.Conn
+
+	if c.Tunnel == TunnelHTTP {
+		tc := &clientTunnelConn{}
+		err := tc.initialize(dialCtx, c, u, dial)
+		if err != nil {
+			return err
+		}
+		nconn = tc
+	} else if c.ConnMux != nil {
+		var err error
+		nconn, err = c.ConnMux.dial(dialCtx, c.connURL.Scheme+"://"+canonicalAddr(c.connURL), dial)
+		if err != nil {
+			return err
+		}
+	} else {
+		var err error
+		nconn, err = dial(dialCtx)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.nconn = nconn
+	var rw io.ReadWriter = c.nconn
+	if c.ControlReadDump != nil || c.ControlWriteDump != nil {
+		rw = &clientDumpConn{
+			rw:        rw,
+			readDump:  c.ControlReadDump,
+			writeDump: c.ControlWriteDump,
+		}
+	}
+
+	if c.events.onBytesReceived != nil || c.events.onBytesSent != nil {
+		rw = &eventsConn{
+			rw:     rw,
+			events: c.events,
+			src:    EventSource{Client: c},
+		}
+	}
+
+	if c.Metrics != metrics.Discard {
+		rw = &metricsConn{
+			rw: rw,
+			m:  c.metrics,
+		}
+	}
+
+	// always wrap the connection, since the capture can be started at any time.
+	rw = &captureConn{
+		rw:         rw,
+		capture:    &c.capture,
+		localAddr:  c.nconn.LocalAddr(),
+		remoteAddr: c.nconn.RemoteAddr(),
+	}
+
+	bc := bytecounter.New(rw, c.BytesReceived, c.BytesSent)
+	c.conn = conn.NewConn(bc)
+	c.conn.SetReuseFramePayloads(c.PacketBufferReuseEnable)
+	c.conn.SetLenientParsing(c.LenientParsing)
+	c.reader = newClientReader(c)
+
+	// responses to requests sent through previous connections will never be received
+	c.outstandingCSeqs = nil
+	c.cseqOffset = 0
+
+	if c.ConformanceCheck {
+		c.conformanceRequests = &conformance.Checker{OnViolation: c.OnConformanceViolation}
+		c.conformanceServer = &conformance.Checker{OnViolation: c.OnConformanceViolation}
+	}
+
+	return nil
+}
+
+// roundTrip is the innermost ClientRoundTripFunc.
+func (c *Client) roundTrip(req *base.Request, cseqStr string, skipResponse bool) (*base.Response, error) {
 	c.OnRequest(req)
 
 	c.nconn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
@@ -923,32 +1862,85 @@ func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error
 		return nil, err
 	}
 
+	c.events.requestSent(EventSource{Client: c}, req)
+	c.log.Debug("request sent", "method", req.Method, "url", req.URL, "cseq", cseqStr)
+
+	c.trackRequest(cseqStr)
+
+	if c.conformanceRequests != nil {
+		c.conformanceRequests.CheckRequest(req)
+	}
+
 	if skipResponse {
 		return nil, nil
 	}
 
-	res, err := c.waitResponse(cseqStr)
+	res, err := c.waitResponse(req, cseqStr)
 	if err != nil {
-		c.mustClose = true
+		// the connection can still be used when the request is canceled,
+		// since the response will be discarded due to its CSeq.
+		if c.requestCtx == nil || err != c.requestCtx.Err() {
+			c.mustClose = true
+		}
+		c.log.Debug("request failed", "method", req.Method, "cseq", cseqStr, "err", err)
 		return nil, err
 	}
 
-	// get session from response
-	if v, ok := res.Header["Session"]; ok {
-		var sx headers.Session
-		err := sx.Unmarshal(v)
+	c.log.Debug("response received", "method", req.Method, "cseq", cseqStr, "status", res.StatusCode)
+
+	if c.conformanceRequests != nil {
+		c.conformanceRequests.CheckResponse(req, res)
+	}
+
+	return res, nil
+}
+
+func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error) {
+	return c.doWithAuth(req, skipResponse, false, false)
+}
+
+// doWithAuth sends a request. The request is sent again at most once with authentication
+// and at most once with proxy authentication, in order to avoid looping forever
+// with servers that always reply with stale nonces.
+func (c *Client) doWithAuth(
+	req *base.Request,
+	skipResponse bool,
+	authRetried bool,
+	proxyAuthRetried bool,
+) (*base.Response, error) {
+	if !c.optionsSent && req.Method != base.Options {
+		_, err := c.doOptions(req.URL)
 		if err != nil {
-			return nil, liberrors.ErrClientSessionHeaderInvalid{Err: err}
+			return nil, err
 		}
-		c.session = sx.Session
+	}
 
-		if sx.Timeout != nil && *sx.Timeout > 0 {
-			c.keepalivePeriod = time.Duration(*sx.Timeout) * time.Second * 8 / 10
-		}
+	cseqStr := c.prepareRequest(req)
+
+	res, err := chainClientMiddlewares(c.Middlewares, func(req *base.Request) (*base.Response, error) {
+		return c.roundTrip(req, cseqStr, skipResponse)
+	})(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if skipResponse {
+		return nil, nil
+	}
+
+	if res == nil {
+		return nil, liberrors.ErrClientResponseMissing{}
+	}
+
+	err = c.processResponse(req, res)
+	if err != nil {
+		return nil, err
 	}
 
 	// send request again with authentication
-	if res.StatusCode == base.StatusUnauthorized && req.URL.User != nil && c.sender == nil {
+	// (or when the nonce is expired)
+	if res.StatusCode == base.StatusUnauthorized && req.URL.User != nil && !authRetried &&
+		(c.sender == nil || c.sender.IsStale(res.Header["WWW-Authenticate"])) {
 		pass, _ := req.URL.User.Password()
 		user := req.URL.User.Username()
 
@@ -958,12 +1950,133 @@ func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error
 		}
 		c.sender = sender
 
-		return c.do(req, skipResponse)
+		c.log.Debug("retrying request with authentication", "method", req.Method)
+
+		return c.doWithAuth(req, skipResponse, true, proxyAuthRetried)
+	}
+
+	// send request again with proxy authentication
+	if res.StatusCode == base.StatusProxyAuthRequired && c.ProxyUser != "" && !proxyAuthRetried &&
+		(c.proxySender == nil || c.proxySender.IsStale(res.Header["Proxy-Authenticate"])) {
+		sender, err := auth.NewSender(res.Header["Proxy-Authenticate"], c.ProxyUser, c.ProxyPass)
+		if err != nil {
+			return nil, liberrors.ErrClientAuthSetup{Err: err}
+		}
+		c.proxySender = sender
+
+		c.log.Debug("retrying request with proxy authentication", "method", req.Method)
+
+		return c.doWithAuth(req, skipResponse, authRetried, true)
 	}
 
 	return res, nil
 }
 
+// prepareRequest fills the headers of a request and returns its CSeq.
+func (c *Client) prepareRequest(req *base.Request) string {
+	if req.Header == nil {
+		req.Header = make(base.Header)
+	}
+
+	if c.session != "" {
+		req.Header["Session"] = base.HeaderValue{c.session}
+	}
+
+	c.cseq++
+	cseqStr := strconv.FormatInt(int64(c.cseq), 10)
+	req.Header["CSeq"] = base.HeaderValue{cseqStr}
+
+	req.Header["User-Agent"] = base.HeaderValue{c.UserAgent}
+
+	if c.sender != nil {
+		c.sender.AddAuthorization(req)
+	}
+
+	if c.proxySender != nil {
+		c.proxySender.AddProxyAuthorization(req)
+	}
+
+	if c.SendTimestamp {
+		req.Header["Timestamp"] = headers.Timestamp{
+			Value: c.timeNow().Sub(c.timestampBase),
+		}.Marshal()
+	}
+
+	return cseqStr
+}
+
+// processResponse processes the response to a request.
+func (c *Client) processResponse(req *base.Request, res *base.Response) error {
+	if c.SendTimestamp {
+		c.readTimestamp(res)
+	}
+
+	if !c.quirksChecked {
+		c.quirksChecked = true
+		c.quirks = findQuirks(c.Quirks, res)
+	}
+
+	if c.quirks != nil && c.quirks.isOKStatusCode(res.StatusCode) {
+		res.StatusCode = base.StatusOK
+	}
+
+	err := c.OnResponseValidate(req, res)
+	if err != nil {
+		return liberrors.ErrClientResponseValidation{Err: err}
+	}
+
+	// get session from response.
+	// some servers return the Session header only in response to SETUP,
+	// therefore the stored one is kept when the header is missing.
+	if v, ok := res.Header["Session"]; ok {
+		var sx headers.Session
+		err := sx.Unmarshal(v)
+		if err != nil {
+			return liberrors.ErrClientSessionHeaderInvalid{Err: err}
+		}
+
+		if c.session != "" && sx.Session != c.session && (c.quirks == nil || !c.quirks.IgnoreSessionMismatch) {
+			return liberrors.ErrClientSessionHeaderMismatch{Expected: c.session, Received: sx.Session}
+		}
+		if c.session == "" {
+			c.metricsSessionID = c.metrics.newSessionID()
+			c.metrics.sessions.Add(1)
+			c.events.sessionCreated(EventSource{Client: c})
+		}
+		c.session = sx.Session
+
+		if sx.Timeout != nil && *sx.Timeout > 0 {
+			c.setKeepalivePeriod(*sx.Timeout)
+		}
+	}
+
+	return nil
+}
+
+// readTimestamp computes the round-trip time of the control connection
+// from the Timestamp header echoed by the server.
+func (c *Client) readTimestamp(res *base.Response) {
+	v, ok := res.Header["Timestamp"]
+	if !ok {
+		return
+	}
+
+	var ts headers.Timestamp
+	err := ts.Unmarshal(v)
+	if err != nil {
+		return
+	}
+
+	rtt := c.timeNow().Sub(c.timestampBase) - ts.Value
+	if ts.Delay != nil {
+		rtt -= *ts.Delay
+	}
+
+	if rtt >= 0 {
+		atomic.StoreInt64(c.controlRTT, int64(rtt))
+	}
+}
+
 func (c *Client) atLeastOneUDPPacketHasBeenReceived() bool {
 	for _, ct := range c.medias {
 		lft := atomic.LoadInt64(ct.udpRTPListener.lastPacketTime)
@@ -1001,6 +2114,39 @@ func (c *Client) isInTCPTimeout() bool {
 	return now.Sub(lft) >= c.ReadTimeout
 }
 
+// punchHoles sends empty packets to the server ports,
+// and to the predicted ones, in order to open NATs and firewalls.
+func (c *Client) punchHoles() {
+	predictedPorts := 0
+	if c.TransportFallback != nil {
+		predictedPorts = c.TransportFallback.PredictedPorts
+	}
+
+	for _, cm := range c.medias {
+		byts, _ := (&rtp.Packet{Header: rtp.Header{Version: 2}}).Marshal()
+		if cm.srtp != nil {
+			byts, _ = cm.srtp.out.EncryptRTP(byts)
+		}
+		cm.udpRTPListener.punch(byts, predictedPorts)
+
+		byts, _ = (&rtcp.ReceiverReport{}).Marshal()
+		if cm.srtp != nil {
+			byts, _ = cm.srtp.out.EncryptRTCP(byts)
+		}
+		cm.udpRTCPListener.punch(byts, predictedPorts)
+	}
+}
+
+func (c *Client) doPunch() {
+	// stop when the first packet is received or when the initial timeout is expired
+	if !c.checkTimeoutInitial || !c.atLeastOneUDPPacketHasBeenReceived() {
+		return
+	}
+
+	c.punchHoles()
+	c.punchTimer = c.Clock.NewTimer(c.TransportFallback.PunchInterval)
+}
+
 func (c *Client) doCheckTimeout() error {
 	if *c.effectiveTransport == TransportUDP ||
 		*c.effectiveTransport == TransportUDPMulticast {
@@ -1008,6 +2154,10 @@ func (c *Client) doCheckTimeout() error {
 			c.checkTimeoutInitial = false
 
 			if c.atLeastOneUDPPacketHasBeenReceived() {
+				if c.TransportFallback != nil && c.TransportFallback.DisableTCP {
+					return liberrors.ErrClientUDPTimeout{}
+				}
+
 				err := c.trySwitchingProtocol()
 				if err != nil {
 					return err
@@ -1020,23 +2170,90 @@ func (c *Client) doCheckTimeout() error {
 		return liberrors.ErrClientTCPTimeout{}
 	}
 
+	c.checkStall()
+
 	return nil
 }
 
+func (c *Client) checkStall() {
+	if c.StallTimeout == 0 {
+		return
+	}
+
+	now := c.timeNow()
+	stalled := atomic.LoadInt32(c.stalled) == 1
+
+	lrt := atomic.LoadInt64(c.lastRTPTime)
+	if lrt == 0 {
+		if !stalled && now.Sub(c.playStartTime) >= c.StallTimeout {
+			atomic.StoreInt32(c.stalled, 1)
+			c.OnStreamStall(liberrors.ErrClientStreamNotStarted{})
+		}
+		return
+	}
+
+	threshold := c.StallTimeout
+	if gap := 2 * time.Duration(atomic.LoadInt64(c.maxRTPGap)); gap > threshold {
+		threshold = gap
+	}
+
+	// prevent a single long gap from hiding stalls forever
+	if maxThreshold := clientStallTimeoutMaxFactor * c.StallTimeout; threshold > maxThreshold {
+		threshold = maxThreshold
+	}
+
+	elapsed := now.Sub(time.Unix(0, lrt))
+
+	if elapsed < threshold {
+		if stalled {
+			atomic.StoreInt32(c.stalled, 0)
+		}
+		return
+	}
+
+	if !stalled {
+		atomic.StoreInt32(c.stalled, 1)
+		c.OnStreamStall(liberrors.ErrClientStreamStalled{Duration: elapsed})
+	}
+}
+
+func (c *Client) updateLastRTPTime(now time.Time) {
+	v := now.UnixNano()
+	prev := atomic.SwapInt64(c.lastRTPTime, v)
+
+	// gaps that caused a stall are not taken into account,
+	// otherwise they would extend the stall threshold.
+	if prev == 0 || atomic.LoadInt32(c.stalled) == 1 {
+		return
+	}
+
+	gap := v - prev
+	for {
+		cur := atomic.LoadInt64(c.maxRTPGap)
+		if gap <= cur || atomic.CompareAndSwapInt64(c.maxRTPGap, cur, gap) {
+			return
+		}
+	}
+}
+
 func (c *Client) doKeepAlive() error {
-	// some cameras do not reply to keepalives, do not wait for responses.
-	_, err := c.do(&base.Request{
-		Method: func() base.Method {
-			// the VLC integrated rtsp server requires GET_PARAMETER
-			if c.useGetParameter {
-				return base.GetParameter
-			}
-			return base.Options
-		}(),
+	req := &base.Request{
+		Method: c.keepaliveMethod(),
 		// use the stream base URL, otherwise some cameras do not reply
 		URL: c.baseURL,
-	}, true)
-	return err
+	}
+
+	// some cameras do not reply to keepalives, do not wait for responses.
+	// responses are checked asynchronously, in order to detect the methods that are rejected.
+	_, err := c.do(req, true)
+	if err != nil {
+		return err
+	}
+
+	c.keepaliveCSeq = req.Header["CSeq"][0]
+	c.keepaliveLastMethod = req.Method
+
+	return nil
 }
 
 func (c *Client) doOptions(u *base.URL) (*base.Response, error) {
@@ -1049,7 +2266,7 @@ func (c *Client) doOptions(u *base.URL) (*base.Response, error) {
 		return nil, err
 	}
 
-	err = c.connOpen()
+	err = c.connOpen(u)
 	if err != nil {
 		return nil, err
 	}
@@ -1068,7 +2285,7 @@ func (c *Client) doOptions(u *base.URL) (*base.Response, error) {
 		if res.StatusCode == base.StatusNotFound {
 			return res, nil
 		}
-		return nil, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage}
+		return nil, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage, Response: res}
 	}
 
 	c.optionsSent = true
@@ -1079,18 +2296,30 @@ func (c *Client) doOptions(u *base.URL) (*base.Response, error) {
 
 // Options sends an OPTIONS request.
 func (c *Client) Options(u *base.URL) (*base.Response, error) {
+	return c.OptionsContext(context.Background(), u)
+}
+
+// OptionsContext is like Options(), but the request can be canceled with a context.
+func (c *Client) OptionsContext(ctx context.Context, u *base.URL) (*base.Response, error) {
 	cres := make(chan clientRes)
 	select {
-	case c.chOptions <- optionsReq{url: u, res: cres}:
+	case c.chOptions <- optionsReq{ctx: ctx, url: u, res: cres}:
 		res := <-cres
 		return res.res, res.err
 
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
 	case <-c.done:
 		return nil, c.closeError
 	}
 }
 
 func (c *Client) doDescribe(u *base.URL) (*description.Session, *base.Response, error) {
+	return c.doDescribeInner(u, nil)
+}
+
+func (c *Client) doDescribeInner(u *base.URL, redirects []string) (*description.Session, *base.Response, error) {
 	err := c.checkState(map[clientState]struct{}{
 		clientStateInitial:   {},
 		clientStatePrePlay:   {},
@@ -1100,53 +2329,66 @@ func (c *Client) doDescribe(u *base.URL) (*description.Session, *base.Response,
 		return nil, nil, err
 	}
 
-	err = c.connOpen()
+	err = c.connOpen(u)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	header := base.Header{
-		"Accept": base.HeaderValue{"application/sdp"},
+		"Accept": base.HeaderValue{c.describeAccept()},
 	}
 
 	if c.RequestBackChannels {
 		header["Require"] = base.HeaderValue{"www.onvif.org/ver20/backchannel"}
 	}
 
+	c.addProfileTokenHeader(header)
+
+	cache := c.describeCache
+	if !c.ConditionalDescribe || cache == nil || cache.url != u.String() {
+		cache = nil
+	} else {
+		header["If-Modified-Since"] = base.HeaderValue{cache.lastModified}
+	}
+
 	res, err := c.do(&base.Request{
 		Method: base.Describe,
-		URL:    u,
+		URL:    c.addProfileTokenQuery(u),
 		Header: header,
 	}, false)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if res.StatusCode != base.StatusOK {
-		// redirect
-		if res.StatusCode >= base.StatusMovedPermanently &&
-			res.StatusCode <= base.StatusUseProxy &&
-			len(res.Header["Location"]) == 1 {
-			c.reset()
+	if cache != nil && res.StatusCode == base.StatusNotModified {
+		c.lastDescribeURL = u
+		c.lastMedias = cache.desc.Medias
+		return cache.desc, res, nil
+	}
 
-			ru, err := base.ParseURL(res.Header["Location"][0])
+	if res.StatusCode != base.StatusOK {
+		if isRedirect(res) {
+			ru, err := parseLocation(u, res.Header["Location"][0])
 			if err != nil {
 				return nil, nil, err
 			}
 
-			if u.User != nil {
-				ru.User = u.User
+			err = c.checkRedirect(u, ru, ru, res, redirects)
+			if err != nil {
+				return nil, nil, err
 			}
 
+			c.reset()
+
 			c.connURL = &base.URL{
 				Scheme: ru.Scheme,
 				Host:   ru.Host,
 			}
 
-			return c.doDescribe(ru)
+			return c.doDescribeInner(ru, append(redirects, u.String()))
 		}
 
-		return nil, res, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage}
+		return nil, res, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage, Response: res}
 	}
 
 	ct, ok := res.Header["Content-Type"]
@@ -1155,22 +2397,36 @@ func (c *Client) doDescribe(u *base.URL) (*description.Session, *base.Response,
 	}
 
 	// strip encoding information from Content-Type header
-	ct = base.HeaderValue{strings.Split(ct[0], ";")[0]}
+	ct = base.HeaderValue{strings.TrimSpace(strings.Split(ct[0], ";")[0])}
 
-	if ct[0] != "application/sdp" {
-		return nil, nil, liberrors.ErrClientContentTypeUnsupported{CT: ct}
+	if parser := c.descriptionParser(ct[0]); parser != nil {
+		return c.describeCustom(u, res, parser)
 	}
 
-	var ssd sdp.SessionDescription
-	err = ssd.Unmarshal(res.Body)
-	if err != nil {
-		return nil, nil, liberrors.ErrClientSDPInvalid{Err: err}
+	if !strings.EqualFold(ct[0], "application/sdp") {
+		return nil, res, liberrors.ErrClientContentTypeUnsupported{CT: ct}
 	}
 
+	var ssd sdp.SessionDescription
 	var desc description.Session
-	err = desc.Unmarshal(&ssd)
-	if err != nil {
-		return nil, nil, liberrors.ErrClientSDPInvalid{Err: err}
+
+	if c.LenientSDP || (c.quirks != nil && c.quirks.LenientSDP) {
+		warnings := ssd.UnmarshalLenient(res.Body)
+		warnings = append(warnings, desc.UnmarshalLenient(&ssd)...)
+
+		for _, w := range warnings {
+			c.OnWarning(liberrors.ErrClientSDPWarning{Err: w})
+		}
+	} else {
+		err = ssd.Unmarshal(res.Body)
+		if err != nil {
+			return nil, nil, liberrors.ErrClientSDPInvalid{Err: err}
+		}
+
+		err = desc.Unmarshal(&ssd)
+		if err != nil {
+			return nil, nil, liberrors.ErrClientSDPInvalid{Err: err}
+		}
 	}
 
 	baseURL, err := findBaseURL(&ssd, res, u)
@@ -1178,20 +2434,89 @@ func (c *Client) doDescribe(u *base.URL) (*description.Session, *base.Response,
 		return nil, nil, err
 	}
 	desc.BaseURL = baseURL
+	desc.SDP = &ssd
 
 	c.lastDescribeURL = u
+	c.lastMedias = desc.Medias
+
+	if c.ConditionalDescribe {
+		c.describeCache = newClientDescribeCache(u, res, &desc)
+	}
 
 	return &desc, res, nil
 }
 
+// describeAccept returns the value of the Accept header of DESCRIBE requests.
+func (c *Client) describeAccept() string {
+	types := []string{"application/sdp"}
+
+	for _, p := range c.DescriptionParsers {
+		if !strings.EqualFold(p.ContentType, "application/sdp") {
+			types = append(types, p.ContentType)
+		}
+	}
+
+	return strings.Join(types, ", ")
+}
+
+func (c *Client) descriptionParser(ct string) *ClientDescriptionParser {
+	for i, p := range c.DescriptionParsers {
+		if strings.EqualFold(p.ContentType, ct) {
+			return &c.DescriptionParsers[i]
+		}
+	}
+	return nil
+}
+
+// describeCustom decodes a DESCRIBE response with a parser provided by the user.
+func (c *Client) describeCustom(
+	u *base.URL,
+	res *base.Response,
+	parser *ClientDescriptionParser,
+) (*description.Session, *base.Response, error) {
+	desc, err := parser.Parse(res)
+	if err == nil && desc == nil {
+		err = fmt.Errorf("parser didn't return a description")
+	}
+	if err != nil {
+		return nil, res, liberrors.ErrClientDescriptionInvalid{CT: parser.ContentType, Err: err}
+	}
+
+	if desc.BaseURL == nil {
+		desc.BaseURL, err = findBaseURL(&sdp.SessionDescription{}, res, u)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	c.lastDescribeURL = u
+	c.lastMedias = desc.Medias
+
+	if c.ConditionalDescribe {
+		c.describeCache = newClientDescribeCache(u, res, desc)
+	}
+
+	return desc, res, nil
+}
+
 // Describe sends a DESCRIBE request.
+// When ConditionalDescribe is enabled and the description didn't change,
+// the previous description is returned, and the response status code is base.StatusNotModified.
 func (c *Client) Describe(u *base.URL) (*description.Session, *base.Response, error) {
+	return c.DescribeContext(context.Background(), u)
+}
+
+// DescribeContext is like Describe(), but the request can be canceled with a context.
+func (c *Client) DescribeContext(ctx context.Context, u *base.URL) (*description.Session, *base.Response, error) {
 	cres := make(chan clientRes)
 	select {
-	case c.chDescribe <- describeReq{url: u, res: cres}:
+	case c.chDescribe <- describeReq{ctx: ctx, url: u, res: cres}:
 		res := <-cres
 		return res.sd, res.res, res.err
 
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+
 	case <-c.done:
 		return nil, nil, c.closeError
 	}
@@ -1205,14 +2530,16 @@ func (c *Client) doAnnounce(u *base.URL, desc *description.Session) (*base.Respo
 		return nil, err
 	}
 
-	err = c.connOpen()
+	err = c.connOpen(u)
 	if err != nil {
 		return nil, err
 	}
 
 	prepareForAnnounce(desc)
 
-	byts, err := desc.Marshal(false)
+	desc = c.OnAnnounceSDP(desc)
+
+	byts, err := marshalAnnounceSDP(desc)
 	if err != nil {
 		return nil, err
 	}
@@ -1231,11 +2558,18 @@ func (c *Client) doAnnounce(u *base.URL, desc *description.Session) (*base.Respo
 
 	if res.StatusCode != base.StatusOK {
 		return nil, liberrors.ErrClientBadStatusCode{
-			Code: res.StatusCode, Message: res.StatusMessage,
+			Code: res.StatusCode, Message: res.StatusMessage, Response: res,
 		}
 	}
 
-	c.baseURL = u.Clone()
+	baseURL, err := findRecordBaseURL(res, u)
+	if err != nil {
+		return nil, err
+	}
+
+	c.announceURL = u.Clone()
+	c.lastMedias = desc.Medias
+	c.baseURL = baseURL
 	c.state = clientStatePreRecord
 
 	return res, nil
@@ -1243,23 +2577,174 @@ func (c *Client) doAnnounce(u *base.URL, desc *description.Session) (*base.Respo
 
 // Announce sends an ANNOUNCE request.
 func (c *Client) Announce(u *base.URL, desc *description.Session) (*base.Response, error) {
+	return c.AnnounceContext(context.Background(), u, desc)
+}
+
+// AnnounceContext is like Announce(), but the request can be canceled with a context.
+func (c *Client) AnnounceContext(ctx context.Context, u *base.URL, desc *description.Session) (*base.Response, error) {
 	cres := make(chan clientRes)
 	select {
-	case c.chAnnounce <- announceReq{url: u, desc: desc, res: cres}:
+	case c.chAnnounce <- announceReq{ctx: ctx, url: u, desc: desc, res: cres}:
 		res := <-cres
 		return res.res, res.err
 
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
 	case <-c.done:
 		return nil, c.closeError
 	}
 }
 
+// isTransportError returns whether a SETUP error can be solved by using another transport.
+func isTransportError(err error) bool {
+	switch err := err.(type) {
+	case liberrors.ErrClientBadStatusCode:
+		return err.Code == base.StatusUnsupportedTransport
+
+	case liberrors.ErrClientTransportHeaderInvalid,
+		liberrors.ErrClientTransportHeaderInvalidDelivery,
+		liberrors.ErrClientTransportHeaderNoPorts,
+		liberrors.ErrClientTransportHeaderNoDestination,
+		liberrors.ErrClientTransportHeaderNoInterleavedIDs,
+		liberrors.ErrClientTransportHeaderInvalidInterleavedIDs,
+		liberrors.ErrClientServerPortsNotProvided,
+		liberrors.ErrClientServerRequestedTCP,
+		liberrors.ErrClientServerRequestedUDP:
+		return true
+	}
+
+	return false
+}
+
 func (c *Client) doSetup(
 	baseURL *base.URL,
 	medi *description.Media,
 	rtpPort int,
 	rtcpPort int,
 ) (*base.Response, error) {
+	return c.doSetupWithRedirects(baseURL, medi, rtpPort, rtcpPort, nil)
+}
+
+func (c *Client) doSetupWithRedirects(
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+	redirects []string,
+) (*base.Response, error) {
+	res, err := c.doSetupInner(baseURL, medi, rtpPort, rtcpPort)
+	if err == nil {
+		return res, nil
+	}
+
+	// the first SETUP request can be redirected to another server, before the session is created.
+	if e, ok := err.(liberrors.ErrClientBadStatusCode); ok && e.Response != nil && isRedirect(e.Response) &&
+		c.session == "" && c.state != clientStatePreRecord {
+		return c.followSetupRedirect(baseURL, medi, rtpPort, rtcpPort, e.Response, redirects)
+	}
+
+	// try the next transport of TransportOrder.
+	// This is possible only before the transport of the session is decided.
+	if c.effectiveTransport != nil || (c.transportIndex+1) >= len(c.TransportOrder) || !isTransportError(err) {
+		return res, err
+	}
+
+	next := c.transportIndex + 1
+
+	if e, ok := err.(liberrors.ErrClientBadStatusCode); ok && e.Code == base.StatusUnsupportedTransport {
+		if c.TransportOrder[next] == TransportTCP {
+			c.switchTransport(liberrors.ErrClientSwitchToTCP2{})
+		} else {
+			c.switchTransport(liberrors.ErrClientSwitchTransport{Transport: c.TransportOrder[next].String(), Err: err})
+		}
+		c.transportIndex = next
+		return c.doSetupWithRedirects(baseURL, medi, rtpPort, rtcpPort, redirects)
+	}
+
+	// the server accepted the SETUP request, therefore the session must be recreated.
+	// This can't be done when recording, since the stream would have to be announced again.
+	if c.state == clientStatePreRecord {
+		return res, err
+	}
+
+	c.switchTransport(liberrors.ErrClientSwitchTransport{Transport: c.TransportOrder[next].String(), Err: err})
+
+	prevConnURL := c.connURL
+
+	c.reset()
+
+	c.connURL = prevConnURL
+	c.transportIndex = next
+
+	// some Hikvision cameras require a describe before a setup
+	if c.lastDescribeURL != nil {
+		_, _, err = c.doDescribe(c.lastDescribeURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.doSetupWithRedirects(baseURL, medi, rtpPort, rtcpPort, redirects)
+}
+
+// clientSetup contains the state of a SETUP request that has been prepared but not completed yet.
+type clientSetup struct {
+	baseURL          *base.URL
+	medi             *description.Media
+	cm               *clientMedia
+	th               headers.Transport
+	desiredTransport Transport
+	mediaURLs        []*base.URL
+}
+
+func (c *Client) doSetupInner(
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+) (*base.Response, error) {
+	s, err := c.setupPrepare(baseURL, medi, rtpPort, rtcpPort)
+	if err != nil {
+		return nil, err
+	}
+
+	var mediaURL *base.URL
+	var res *base.Response
+
+	for i, u := range s.mediaURLs {
+		mediaURL = u
+
+		res, err = c.do(c.setupRequest(s, mediaURL), false)
+		if err != nil {
+			s.cm.close()
+			return nil, err
+		}
+
+		if res.StatusCode == base.StatusOK {
+			break
+		}
+
+		// try the next URL
+		if i != (len(s.mediaURLs)-1) && isControlURLRejection(res.StatusCode) {
+			continue
+		}
+
+		s.cm.close()
+		return nil, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage, Response: res}
+	}
+
+	return c.setupFinish(s, mediaURL, res)
+}
+
+// setupPrepare allocates the resources of a media and computes the Transport header
+// and the URLs of a SETUP request.
+func (c *Client) setupPrepare(
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+) (*clientSetup, error) {
 	err := c.checkState(map[clientState]struct{}{
 		clientStateInitial:   {},
 		clientStatePrePlay:   {},
@@ -1269,11 +2754,22 @@ func (c *Client) doSetup(
 		return nil, err
 	}
 
-	err = c.connOpen()
+	// after a SETUP request has been redirected, requests are sent to the new server
+	if c.setupRedirectFrom != nil && *baseURL == *c.setupRedirectFrom {
+		baseURL = c.setupRedirectTo
+	}
+
+	err = c.connOpen(baseURL)
 	if err != nil {
 		return nil, err
 	}
 
+	// when recording, the base URL is the one returned by ANNOUNCE,
+	// that takes precedence over the URL of the request.
+	if c.state == clientStatePreRecord && c.announceURL != nil && *baseURL == *c.announceURL {
+		baseURL = c.baseURL
+	}
+
 	if c.baseURL != nil && *baseURL != *c.baseURL {
 		return nil, liberrors.ErrClientCannotSetupMediasDifferentURLs{}
 	}
@@ -1288,14 +2784,31 @@ func (c *Client) doSetup(
 			v := headers.TransportModePlay
 			return &v
 		}(),
+		Append: c.state == clientStatePreRecord && c.RecordAppend,
 	}
 
 	cm := newClientMedia(c)
 
+	cm.srtp, err = newMediaSRTP(medi)
+	if err != nil {
+		return nil, err
+	}
+
+	th.Secure = (cm.srtp != nil)
+
 	if c.effectiveTransport == nil {
 		if c.connURL.Scheme == "rtsps" { // always use TCP if encrypted
 			v := TransportTCP
 			c.effectiveTransport = &v
+		} else if c.Tunnel != TunnelNone { // always use TCP if tunneled
+			v := TransportTCP
+			c.effectiveTransport = &v
+		} else if ip, _ := addrIPZone(c.nconn.RemoteAddr()); ip == nil { // always use TCP if connection is not IP-based
+			v := TransportTCP
+			c.effectiveTransport = &v
+		} else if c.quirks != nil && c.quirks.ForceTCP {
+			v := TransportTCP
+			c.effectiveTransport = &v
 		} else if c.Transport != nil { // take transport from config
 			c.effectiveTransport = c.Transport
 		}
@@ -1305,7 +2818,7 @@ func (c *Client) doSetup(
 	if c.effectiveTransport != nil {
 		desiredTransport = *c.effectiveTransport
 	} else {
-		desiredTransport = TransportUDP
+		desiredTransport = c.TransportOrder[c.transportIndex]
 	}
 
 	switch desiredTransport {
@@ -1322,6 +2835,7 @@ func (c *Client) doSetup(
 		err := cm.allocateUDPListeners(
 			false,
 			nil,
+			multicast.SingleConnOptions{},
 			net.JoinHostPort("", strconv.FormatInt(int64(rtpPort), 10)),
 			net.JoinHostPort("", strconv.FormatInt(int64(rtcpPort), 10)),
 		)
@@ -1329,6 +2843,12 @@ func (c *Client) doSetup(
 			return nil, err
 		}
 
+		err = cm.applyUDPSocketOptions(medi)
+		if err != nil {
+			cm.close()
+			return nil, err
+		}
+
 		v1 := headers.TransportDeliveryUnicast
 		th.Delivery = &v1
 		th.Protocol = headers.TransportProtocolUDP
@@ -1347,47 +2867,58 @@ func (c *Client) doSetup(
 		th.InterleavedIDs = &[2]int{ch, ch + 1}
 	}
 
-	mediaURL, err := medi.URL(baseURL)
+	mediaURLs, err := c.mediaURLs(baseURL, medi)
 	if err != nil {
 		cm.close()
 		return nil, err
 	}
 
+	return &clientSetup{
+		baseURL:          baseURL,
+		medi:             medi,
+		cm:               cm,
+		th:               th,
+		desiredTransport: desiredTransport,
+		mediaURLs:        mediaURLs,
+	}, nil
+}
+
+// setupRequest returns the SETUP request of a media.
+func (c *Client) setupRequest(s *clientSetup, mediaURL *base.URL) *base.Request {
 	header := base.Header{
-		"Transport": th.Marshal(),
+		"Transport": s.th.Marshal(),
 	}
 
-	if medi.IsBackChannel {
+	if s.medi.IsBackChannel {
 		header["Require"] = base.HeaderValue{"www.onvif.org/ver20/backchannel"}
 	}
 
-	res, err := c.do(&base.Request{
-		Method: base.Setup,
-		URL:    mediaURL,
-		Header: header,
-	}, false)
-	if err != nil {
-		cm.close()
-		return nil, err
+	if c.ONVIFReplay != nil {
+		header["Require"] = append(header["Require"], onvifreplay.RequireTag)
 	}
 
-	if res.StatusCode != base.StatusOK {
-		cm.close()
+	if c.Compatibility3GPP {
+		header["3GPP-Adaptation"] = adaptation3GPPHeader(mediaURL)
+	}
 
-		// switch transport automatically
-		if res.StatusCode == base.StatusUnsupportedTransport &&
-			c.effectiveTransport == nil {
-			c.OnTransportSwitch(liberrors.ErrClientSwitchToTCP2{})
-			v := TransportTCP
-			c.effectiveTransport = &v
-			return c.doSetup(baseURL, medi, 0, 0)
-		}
+	c.addProfileTokenHeader(header)
 
-		return nil, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage}
+	return &base.Request{
+		Method: base.Setup,
+		URL:    c.addProfileTokenQuery(mediaURL),
+		Header: header,
 	}
+}
+
+// setupFinish processes the response to a SETUP request.
+func (c *Client) setupFinish(s *clientSetup, mediaURL *base.URL, res *base.Response) (*base.Response, error) {
+	baseURL := s.baseURL
+	medi := s.medi
+	cm := s.cm
+	desiredTransport := s.desiredTransport
 
 	var thRes headers.Transport
-	err = thRes.Unmarshal(res.Header["Transport"])
+	err := thRes.Unmarshal(res.Header["Transport"])
 	if err != nil {
 		cm.close()
 		return nil, liberrors.ErrClientTransportHeaderInvalid{Err: err}
@@ -1416,6 +2947,8 @@ func (c *Client) doSetup(
 			return nil, liberrors.ErrClientTransportHeaderInvalidDelivery{}
 		}
 
+		remoteIP, remoteZone := addrIPZone(c.nconn.RemoteAddr())
+
 		serverPortsValid := thRes.ServerPorts != nil && !isAnyPort(thRes.ServerPorts[0]) && !isAnyPort(thRes.ServerPorts[1])
 
 		if (c.state == clientStatePreRecord || !c.AnyPortEnable) && !serverPortsValid {
@@ -1427,7 +2960,7 @@ func (c *Client) doSetup(
 		if thRes.Source != nil {
 			readIP = *thRes.Source
 		} else {
-			readIP = c.nconn.RemoteAddr().(*net.TCPAddr).IP
+			readIP = remoteIP
 		}
 
 		if serverPortsValid {
@@ -1435,8 +2968,8 @@ func (c *Client) doSetup(
 				cm.udpRTPListener.readPort = thRes.ServerPorts[0]
 			}
 			cm.udpRTPListener.writeAddr = &net.UDPAddr{
-				IP:   c.nconn.RemoteAddr().(*net.TCPAddr).IP,
-				Zone: c.nconn.RemoteAddr().(*net.TCPAddr).Zone,
+				IP:   remoteIP,
+				Zone: remoteZone,
 				Port: thRes.ServerPorts[0],
 			}
 		}
@@ -1447,8 +2980,8 @@ func (c *Client) doSetup(
 				cm.udpRTCPListener.readPort = thRes.ServerPorts[1]
 			}
 			cm.udpRTCPListener.writeAddr = &net.UDPAddr{
-				IP:   c.nconn.RemoteAddr().(*net.TCPAddr).IP,
-				Zone: c.nconn.RemoteAddr().(*net.TCPAddr).Zone,
+				IP:   remoteIP,
+				Zone: remoteZone,
 				Port: thRes.ServerPorts[1],
 			}
 		}
@@ -1467,23 +3000,39 @@ func (c *Client) doSetup(
 			return nil, liberrors.ErrClientTransportHeaderNoDestination{}
 		}
 
+		var multicastOpts multicast.SingleConnOptions
+
 		var readIP net.IP
 		if thRes.Source != nil {
 			readIP = *thRes.Source
+			multicastOpts.Source = *thRes.Source
 		} else {
-			readIP = c.nconn.RemoteAddr().(*net.TCPAddr).IP
+			readIP, _ = addrIPZone(c.nconn.RemoteAddr())
 		}
 
+		if thRes.TTL != nil {
+			multicastOpts.TTL = int(*thRes.TTL)
+		}
+
+		rtcpIP, rtcpPort := multicastRTCPAddress(medi, *thRes.Destination, thRes.Ports[1])
+
 		err := cm.allocateUDPListeners(
 			true,
 			readIP,
+			multicastOpts,
 			net.JoinHostPort(thRes.Destination.String(), strconv.FormatInt(int64(thRes.Ports[0]), 10)),
-			net.JoinHostPort(thRes.Destination.String(), strconv.FormatInt(int64(thRes.Ports[1]), 10)),
+			net.JoinHostPort(rtcpIP.String(), strconv.FormatInt(int64(rtcpPort), 10)),
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		err = cm.applyUDPSocketOptions(medi)
+		if err != nil {
+			cm.close()
+			return nil, err
+		}
+
 		cm.udpRTPListener.readIP = readIP
 		cm.udpRTPListener.readPort = thRes.Ports[0]
 		cm.udpRTPListener.writeAddr = &net.UDPAddr{
@@ -1492,10 +3041,10 @@ func (c *Client) doSetup(
 		}
 
 		cm.udpRTCPListener.readIP = readIP
-		cm.udpRTCPListener.readPort = thRes.Ports[1]
+		cm.udpRTCPListener.readPort = rtcpPort
 		cm.udpRTCPListener.writeAddr = &net.UDPAddr{
-			IP:   *thRes.Destination,
-			Port: thRes.Ports[1],
+			IP:   rtcpIP,
+			Port: rtcpPort,
 		}
 
 	case TransportTCP:
@@ -1529,11 +3078,15 @@ func (c *Client) doSetup(
 	}
 
 	c.medias[medi] = cm
+	cm.url = mediaURL
 	cm.setMedia(medi)
 
 	c.baseURL = baseURL
 	c.effectiveTransport = &desiredTransport
 
+	c.events.transportNegotiated(EventSource{Client: c}, medi, desiredTransport)
+	c.log.Info("transport negotiated", "media", medi.Type, "url", mediaURL, "transport", desiredTransport)
+
 	if medi.IsBackChannel {
 		c.backChannelSetupped = true
 	} else {
@@ -1544,6 +3097,10 @@ func (c *Client) doSetup(
 		c.state = clientStatePrePlay
 	}
 
+	if c.Compatibility3GPP {
+		c.readQoEMetrics(res)
+	}
+
 	return res, nil
 }
 
@@ -1553,6 +3110,21 @@ func (c *Client) isChannelPairInUse(channel int) bool {
 			return true
 		}
 	}
+	for _, s := range c.pendingSetups {
+		if ids := s.th.InterleavedIDs; ids != nil &&
+			((ids[0]+1) == channel || ids[0] == channel || ids[0] == (channel+1)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) isChannelInUse(channel int) bool {
+	for _, cm := range c.medias {
+		if cm.tcpChannel == channel || (cm.tcpChannel+1) == channel {
+			return true
+		}
+	}
 	return false
 }
 
@@ -1572,10 +3144,22 @@ func (c *Client) Setup(
 	media *description.Media,
 	rtpPort int,
 	rtcpPort int,
+) (*base.Response, error) {
+	return c.SetupContext(context.Background(), baseURL, media, rtpPort, rtcpPort)
+}
+
+// SetupContext is like Setup(), but the request can be canceled with a context.
+func (c *Client) SetupContext(
+	ctx context.Context,
+	baseURL *base.URL,
+	media *description.Media,
+	rtpPort int,
+	rtcpPort int,
 ) (*base.Response, error) {
 	cres := make(chan clientRes)
 	select {
 	case c.chSetup <- setupReq{
+		ctx:      ctx,
 		baseURL:  baseURL,
 		media:    media,
 		rtpPort:  rtpPort,
@@ -1585,6 +3169,9 @@ func (c *Client) Setup(
 		res := <-cres
 		return res.res, res.err
 
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
 	case <-c.done:
 		return nil, c.closeError
 	}
@@ -1592,8 +3179,28 @@ func (c *Client) Setup(
 
 // SetupAll setups all the given medias.
 func (c *Client) SetupAll(baseURL *base.URL, medias []*description.Media) error {
+	return c.SetupAllContext(context.Background(), baseURL, medias)
+}
+
+// SetupAllContext is like SetupAll(), but the requests can be canceled with a context.
+func (c *Client) SetupAllContext(ctx context.Context, baseURL *base.URL, medias []*description.Media) error {
+	if c.PipelineSetup {
+		cres := make(chan clientRes)
+		select {
+		case c.chSetupAll <- setupAllReq{ctx: ctx, baseURL: baseURL, medias: medias, res: cres}:
+			res := <-cres
+			return res.err
+
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-c.done:
+			return c.closeError
+		}
+	}
+
 	for _, m := range medias {
-		_, err := c.Setup(baseURL, m, 0, 0)
+		_, err := c.SetupContext(ctx, baseURL, m, 0, 0)
 		if err != nil {
 			return err
 		}
@@ -1629,6 +3236,23 @@ func (c *Client) doPlay(ra *headers.Range) (*base.Response, error) {
 		header["Require"] = base.HeaderValue{"www.onvif.org/ver20/backchannel"}
 	}
 
+	if c.ONVIFReplay != nil {
+		c.ONVIFReplay.fillHeader(header)
+	}
+
+	if c.Scale != nil {
+		header["Scale"] = c.Scale.Marshal()
+	}
+
+	if c.Speed != nil {
+		header["Speed"] = c.Speed.Marshal()
+	}
+
+	// acknowledge metrics requested by the server
+	if c.qoeMetrics != nil {
+		header["3GPP-QoE-Metrics"] = c.qoeMetrics.Marshal()
+	}
+
 	res, err := c.do(&base.Request{
 		Method: base.Play,
 		URL:    c.baseURL,
@@ -1644,7 +3268,7 @@ func (c *Client) doPlay(ra *headers.Range) (*base.Response, error) {
 		c.stopReadRoutines()
 		c.state = clientStatePrePlay
 		return nil, liberrors.ErrClientBadStatusCode{
-			Code: res.StatusCode, Message: res.StatusMessage,
+			Code: res.StatusCode, Message: res.StatusMessage, Response: res,
 		}
 	}
 
@@ -1653,30 +3277,51 @@ func (c *Client) doPlay(ra *headers.Range) (*base.Response, error) {
 	// don't do this with multicast, otherwise the RTP packet is going to be broadcasted
 	// to all listeners, including us, messing up the stream.
 	if *c.effectiveTransport == TransportUDP {
-		for _, cm := range c.medias {
-			byts, _ := (&rtp.Packet{Header: rtp.Header{Version: 2}}).Marshal()
-			cm.udpRTPListener.write(byts) //nolint:errcheck
+		c.punchHoles()
 
-			byts, _ = (&rtcp.ReceiverReport{}).Marshal()
-			cm.udpRTCPListener.write(byts) //nolint:errcheck
+		if c.TransportFallback != nil && c.TransportFallback.PunchInterval != 0 {
+			c.punchTimer = c.Clock.NewTimer(c.TransportFallback.PunchInterval)
 		}
 	}
 
+	c.readPlayInfo(res)
 	c.startWriter()
 	c.lastRange = ra
 
+	if c.Compatibility3GPP {
+		c.readQoEMetrics(res)
+
+		if c.qoeMetrics != nil {
+			if c.qoeLastLost == nil {
+				c.qoeLastLost = make(map[*clientMedia]uint32)
+			}
+
+			if period := c.qoeReportPeriod(); period != 0 {
+				c.qoeTimer = c.Clock.NewTimer(period)
+			}
+		}
+	}
+
 	return res, nil
 }
 
 // Play sends a PLAY request.
 // This can be called only after Setup().
 func (c *Client) Play(ra *headers.Range) (*base.Response, error) {
+	return c.PlayContext(context.Background(), ra)
+}
+
+// PlayContext is like Play(), but the request can be canceled with a context.
+func (c *Client) PlayContext(ctx context.Context, ra *headers.Range) (*base.Response, error) {
 	cres := make(chan clientRes)
 	select {
-	case c.chPlay <- playReq{ra: ra, res: cres}:
+	case c.chPlay <- playReq{ctx: ctx, ra: ra, res: cres}:
 		res := <-cres
 		return res.res, res.err
 
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
 	case <-c.done:
 		return nil, c.closeError
 	}
@@ -1707,24 +3352,133 @@ func (c *Client) doRecord() (*base.Response, error) {
 		c.stopReadRoutines()
 		c.state = clientStatePreRecord
 		return nil, liberrors.ErrClientBadStatusCode{
-			Code: res.StatusCode, Message: res.StatusMessage,
+			Code: res.StatusCode, Message: res.StatusMessage, Response: res,
 		}
 	}
 
+	c.readRecordInfo(res)
 	c.startWriter()
 
-	return nil, nil
+	return res, nil
+}
+
+// readRecordInfo reads the Range and RTP-Info headers that some servers send
+// in the RECORD response, in order to indicate where they began recording.
+func (c *Client) readRecordInfo(res *base.Response) {
+	c.recordRange = nil
+	for _, cm := range c.medias {
+		cm.recordRTPInfo = nil
+	}
+
+	if v, ok := res.Header["Range"]; ok {
+		var ra headers.Range
+		err := ra.Unmarshal(v)
+		if err == nil {
+			c.recordRange = &ra
+		}
+	}
+
+	v, ok := res.Header["RTP-Info"]
+	if !ok {
+		return
+	}
+
+	var ri headers.RTPInfo
+	err := ri.Unmarshal(v)
+	if err != nil {
+		return
+	}
+
+	for _, cm := range c.medias {
+		cm.recordRTPInfo = c.findRTPInfoEntry(ri, cm)
+	}
+}
+
+// findRTPInfoEntry returns the entry of a RTP-Info header that refers to a media.
+func (c *Client) findRTPInfoEntry(ri headers.RTPInfo, cm *clientMedia) *headers.RTPInfoEntry {
+	// a single entry can be associated with a single media even if URLs differ
+	if len(c.medias) == 1 && len(ri) == 1 {
+		return ri[0]
+	}
+
+	for _, e := range ri {
+		if e.URL == cm.url.String() || (cm.media.Control != "" && e.URL == cm.media.Control) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// readPlayInfo reads the RTP-Info header of the PLAY response, that contains
+// the sequence number and RTP timestamp of the first packet of each media.
+// Timestamps of packets are decoded relative to the RTP timestamp, that corresponds
+// to the start of the requested range, and packets that precede the sequence number
+// (i.e. packets sent before a seek) are discarded.
+func (c *Client) readPlayInfo(res *base.Response) {
+	for _, cm := range c.medias {
+		cm.playRTPInfo = nil
+	}
+
+	if c.quirks != nil && c.quirks.IgnoreRTPInfo {
+		return
+	}
+
+	v, ok := res.Header["RTP-Info"]
+	if !ok {
+		return
+	}
+
+	var ri headers.RTPInfo
+	err := ri.Unmarshal(v)
+	if err != nil {
+		c.log.Warn("invalid RTP-Info header", "err", err)
+		return
+	}
+
+	for _, cm := range c.medias {
+		e := c.findRTPInfoEntry(ri, cm)
+		if e == nil {
+			continue
+		}
+
+		cm.playRTPInfo = e
+
+		// RTP-Info doesn't support multiple sequence numbers / timestamps
+		// inside a single media stream.
+		if len(cm.formats) != 1 {
+			continue
+		}
+
+		for _, ct := range cm.formats {
+			if e.SequenceNumber != nil {
+				ct.setPlayStartSequenceNumber(*e.SequenceNumber)
+			}
+
+			if e.Timestamp != nil {
+				c.timeDecoder.InitializeTrack(ct, *e.Timestamp, 0)
+			}
+		}
+	}
 }
 
 // Record sends a RECORD request.
 // This can be called only after Announce() and Setup().
 func (c *Client) Record() (*base.Response, error) {
+	return c.RecordContext(context.Background())
+}
+
+// RecordContext is like Record(), but the request can be canceled with a context.
+func (c *Client) RecordContext(ctx context.Context) (*base.Response, error) {
 	cres := make(chan clientRes)
 	select {
-	case c.chRecord <- recordReq{res: cres}:
+	case c.chRecord <- recordReq{ctx: ctx, res: cres}:
 		res := <-cres
 		return res.res, res.err
 
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
 	case <-c.done:
 		return nil, c.closeError
 	}
@@ -1753,31 +3507,190 @@ func (c *Client) doPause() (*base.Response, error) {
 	if res.StatusCode != base.StatusOK {
 		c.startWriter()
 		return nil, liberrors.ErrClientBadStatusCode{
-			Code: res.StatusCode, Message: res.StatusMessage,
+			Code: res.StatusCode, Message: res.StatusMessage, Response: res,
 		}
 	}
 
 	c.stopReadRoutines()
 
+	for _, cm := range c.medias {
+		atomic.StoreInt32(cm.paused, 0)
+	}
+
 	switch c.state {
 	case clientStatePlay:
 		c.state = clientStatePrePlay
 	case clientStateRecord:
+		now := c.timeNow()
+		for _, cm := range c.medias {
+			for _, ct := range cm.formats {
+				ct.recordContinuity.pause(now)
+			}
+		}
 		c.state = clientStatePreRecord
 	}
 
 	return res, nil
 }
 
+func (c *Client) doMediaRequest(method base.Method, medi *description.Media) (*base.Response, *clientMedia, error) {
+	err := c.checkState(map[clientState]struct{}{
+		clientStatePlay: {},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cm, ok := c.medias[medi]
+	if !ok {
+		return nil, nil, liberrors.ErrClientMediaNotSetup{}
+	}
+
+	res, err := c.do(&base.Request{
+		Method: method,
+		URL:    cm.url,
+	}, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, nil, liberrors.ErrClientBadStatusCode{
+			Code: res.StatusCode, Message: res.StatusMessage, Response: res,
+		}
+	}
+
+	return res, cm, nil
+}
+
+func (c *Client) doPauseMedia(medi *description.Media) (*base.Response, error) {
+	res, cm, err := c.doMediaRequest(base.Pause, medi)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.StoreInt32(cm.paused, 1)
+
+	return res, nil
+}
+
+func (c *Client) doResumeMedia(medi *description.Media) (*base.Response, error) {
+	res, cm, err := c.doMediaRequest(base.Play, medi)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.StoreInt32(cm.paused, 0)
+
+	return res, nil
+}
+
+// ControlRTT returns the round-trip time of the control connection,
+// measured through the last Timestamp header echoed by the server.
+// It requires SendTimestamp.
+// The second return value is false when the round-trip time is not available yet.
+func (c *Client) ControlRTT() (time.Duration, bool) {
+	v := atomic.LoadInt64(c.controlRTT)
+	if v < 0 {
+		return 0, false
+	}
+	return time.Duration(v), true
+}
+
+// RecordRange returns the Range header that the server sent in the RECORD response,
+// that indicates the position where recording began.
+// It returns nil if the server didn't send it.
+// This can be called only after Record().
+func (c *Client) RecordRange() *headers.Range {
+	return c.recordRange
+}
+
+// PlayRTPInfo returns the RTP-Info entry that the server sent in the PLAY response
+// for the given media, or nil if the server didn't send any.
+func (c *Client) PlayRTPInfo(medi *description.Media) *headers.RTPInfoEntry {
+	cm, ok := c.medias[medi]
+	if !ok {
+		return nil
+	}
+	return cm.playRTPInfo
+}
+
+// RecordRTPInfo returns the RTP-Info entry that the server sent in the RECORD response
+// for a media, that contains the sequence number and timestamp where recording began.
+// It returns nil if the server didn't send it.
+// This can be called only after Record().
+func (c *Client) RecordRTPInfo(medi *description.Media) *headers.RTPInfoEntry {
+	cm, ok := c.medias[medi]
+	if !ok {
+		return nil
+	}
+	return cm.recordRTPInfo
+}
+
 // Pause sends a PAUSE request.
 // This can be called only after Play() or Record().
 func (c *Client) Pause() (*base.Response, error) {
+	return c.PauseContext(context.Background())
+}
+
+// PauseContext is like Pause(), but the request can be canceled with a context.
+func (c *Client) PauseContext(ctx context.Context) (*base.Response, error) {
 	cres := make(chan clientRes)
 	select {
-	case c.chPause <- pauseReq{res: cres}:
+	case c.chPause <- pauseReq{ctx: ctx, res: cres}:
 		res := <-cres
 		return res.res, res.err
 
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-c.done:
+		return nil, c.closeError
+	}
+}
+
+// PauseMedia sends a PAUSE request that refers to a single media,
+// in order to stop receiving it while the other medias keep playing.
+// Receiver reports of the media are suppressed until ResumeMedia() is called.
+// This can be called only after Play().
+func (c *Client) PauseMedia(medi *description.Media) (*base.Response, error) {
+	return c.PauseMediaContext(context.Background(), medi)
+}
+
+// PauseMediaContext is like PauseMedia(), but the request can be canceled with a context.
+func (c *Client) PauseMediaContext(ctx context.Context, medi *description.Media) (*base.Response, error) {
+	cres := make(chan clientRes)
+	select {
+	case c.chPause <- pauseReq{ctx: ctx, media: medi, res: cres}:
+		res := <-cres
+		return res.res, res.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-c.done:
+		return nil, c.closeError
+	}
+}
+
+// ResumeMedia sends a PLAY request that refers to a single media,
+// in order to resume a media paused with PauseMedia().
+// This can be called only after Play().
+func (c *Client) ResumeMedia(medi *description.Media) (*base.Response, error) {
+	return c.ResumeMediaContext(context.Background(), medi)
+}
+
+// ResumeMediaContext is like ResumeMedia(), but the request can be canceled with a context.
+func (c *Client) ResumeMediaContext(ctx context.Context, medi *description.Media) (*base.Response, error) {
+	cres := make(chan clientRes)
+	select {
+	case c.chPlay <- playReq{ctx: ctx, media: medi, res: cres}:
+		res := <-cres
+		return res.res, res.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
 	case <-c.done:
 		return nil, c.closeError
 	}
@@ -1785,12 +3698,146 @@ func (c *Client) Pause() (*base.Response, error) {
 
 // Seek asks the server to re-start the stream from a specific timestamp.
 func (c *Client) Seek(ra *headers.Range) (*base.Response, error) {
-	_, err := c.Pause()
+	return c.SeekContext(context.Background(), ra)
+}
+
+// SeekContext is like Seek(), but the requests can be canceled with a context.
+func (c *Client) SeekContext(ctx context.Context, ra *headers.Range) (*base.Response, error) {
+	_, err := c.PauseContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.PlayContext(ctx, ra)
+}
+
+// parameterURL returns the URL of GET_PARAMETER and SET_PARAMETER requests.
+func (c *Client) parameterURL() *base.URL {
+	switch {
+	case c.baseURL != nil:
+		return c.baseURL
+
+	case c.lastDescribeURL != nil:
+		return c.lastDescribeURL
+
+	case c.announceURL != nil:
+		return c.announceURL
+	}
+
+	return &base.URL{
+		Scheme: c.connURL.Scheme,
+		Host:   c.connURL.Host,
+		Path:   "/",
+	}
+}
+
+func (c *Client) doGetParameter(names []string) (parameters.Parameters, *base.Response, error) {
+	u := c.parameterURL()
+
+	err := c.connOpen(u)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := &base.Request{
+		Method: base.GetParameter,
+		URL:    u,
+	}
+
+	if len(names) != 0 {
+		req.Header = base.Header{
+			"Content-Type": base.HeaderValue{parameters.ContentType},
+		}
+		req.Body = parameters.Names(names).Marshal()
+	}
+
+	res, err := c.do(req, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, nil, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage, Response: res}
+	}
+
+	var params parameters.Parameters
+	err = params.Unmarshal(res.Body)
+	if err != nil {
+		return nil, nil, liberrors.ErrClientParametersInvalid{Err: err}
+	}
+
+	return params, res, nil
+}
+
+// GetParameter sends a GET_PARAMETER request that asks for the value of some parameters,
+// and returns the parameters contained in the response.
+// names can be empty, in this case the request acts as a keepalive.
+func (c *Client) GetParameter(names []string) (map[string]string, error) {
+	return c.GetParameterContext(context.Background(), names)
+}
+
+// GetParameterContext is like GetParameter(), but the request can be canceled with a context.
+func (c *Client) GetParameterContext(ctx context.Context, names []string) (map[string]string, error) {
+	cres := make(chan clientRes)
+	select {
+	case c.chGetParameter <- getParameterReq{ctx: ctx, names: names, res: cres}:
+		res := <-cres
+		return res.params, res.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-c.done:
+		return nil, c.closeError
+	}
+}
+
+func (c *Client) doSetParameter(params map[string]string) (*base.Response, error) {
+	u := c.parameterURL()
+
+	err := c.connOpen(u)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.Play(ra)
+	res, err := c.do(&base.Request{
+		Method: base.SetParameter,
+		URL:    u,
+		Header: base.Header{
+			"Content-Type": base.HeaderValue{parameters.ContentType},
+		},
+		Body: parameters.Parameters(params).Marshal(),
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage, Response: res}
+	}
+
+	return res, nil
+}
+
+// SetParameter sends a SET_PARAMETER request that sets the value of some parameters.
+func (c *Client) SetParameter(params map[string]string) (*base.Response, error) {
+	return c.SetParameterContext(context.Background(), params)
+}
+
+// SetParameterContext is like SetParameter(), but the request can be canceled with a context.
+func (c *Client) SetParameterContext(ctx context.Context, params map[string]string) (*base.Response, error) {
+	cres := make(chan clientRes)
+	select {
+	case c.chSetParameter <- setParameterReq{ctx: ctx, params: params, res: cres}:
+		res := <-cres
+		return res.res, res.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-c.done:
+		return nil, c.closeError
+	}
 }
 
 // OnPacketRTPAny sets the callback that is called when a RTP packet is read from any setupped media.
@@ -1815,6 +3862,63 @@ func (c *Client) OnPacketRTCPAny(cb OnPacketRTCPAnyFunc) {
 	}
 }
 
+// OnPacketRTCPRawAny sets the callback that is called when a compound RTCP packet
+// is read from any setupped media.
+func (c *Client) OnPacketRTCPRawAny(cb OnPacketRTCPRawAnyFunc) {
+	for _, cm := range c.medias {
+		cmedia := cm.media
+		c.OnPacketRTCPRaw(cm.media, func(payload []byte) {
+			cb(cmedia, payload)
+		})
+	}
+}
+
+// OnBandwidthEstimate sets the callback that is called when the server
+// sends a bandwidth estimate (REMB) while recording.
+// It can be used to adapt the bitrate of encoders.
+func (c *Client) OnBandwidthEstimate(cb OnBandwidthEstimateFunc) {
+	c.onBandwidthMutex.Lock()
+	defer c.onBandwidthMutex.Unlock()
+	c.onBandwidthEstimate = cb
+}
+
+func (c *Client) serverBandwidthEstimate(medi *description.Media, bitrate uint64) {
+	c.onBandwidthMutex.RLock()
+	cb := c.onBandwidthEstimate
+	c.onBandwidthMutex.RUnlock()
+
+	if cb != nil {
+		cb(medi, bitrate)
+	}
+}
+
+// OnKeyframeRequest sets the callback that is called when the server
+// asks for a keyframe (RTCP PLI or FIR) while recording.
+// It can be used to make encoders produce a keyframe immediately.
+func (c *Client) OnKeyframeRequest(cb OnKeyframeRequestFunc) {
+	c.onKeyframeMutex.Lock()
+	defer c.onKeyframeMutex.Unlock()
+	c.onKeyframeRequest = cb
+}
+
+func (c *Client) serverKeyframeRequest(medi *description.Media) {
+	c.onKeyframeMutex.RLock()
+	cb := c.onKeyframeRequest
+	c.onKeyframeMutex.RUnlock()
+
+	if cb != nil {
+		cb(medi)
+	}
+}
+
+// OnInterleavedFrame sets the callback that is called when an interleaved frame
+// is read on a channel that is not bound to any media,
+// like proprietary metadata channels used by some cameras.
+// It is used with the TCP transport only.
+func (c *Client) OnInterleavedFrame(cb OnInterleavedFrameFunc) {
+	c.onInterleavedFrame = cb
+}
+
 // OnPacketRTP sets the callback that is called when a RTP packet is read.
 func (c *Client) OnPacketRTP(medi *description.Media, forma format.Format, cb OnPacketRTPFunc) {
 	cm := c.medias[medi]
@@ -1822,12 +3926,27 @@ func (c *Client) OnPacketRTP(medi *description.Media, forma format.Format, cb On
 	ct.onPacketRTP = cb
 }
 
+// OnPacketRTPExtension sets the callback that is called when a RTP packet
+// that contains the header extension with the given URI is read.
+// The callback is never called if the extension has not been negotiated.
+func (c *Client) OnPacketRTPExtension(medi *description.Media, uri string, cb OnPacketRTPExtensionFunc) {
+	cm := c.medias[medi]
+	cm.setPacketRTPExtensionCallback(uri, cb)
+}
+
 // OnPacketRTCP sets the callback that is called when a RTCP packet is read.
 func (c *Client) OnPacketRTCP(medi *description.Media, cb OnPacketRTCPFunc) {
 	cm := c.medias[medi]
 	cm.onPacketRTCP = cb
 }
 
+// OnPacketRTCPRaw sets the callback that is called when a compound RTCP packet is read,
+// before it is decoded. Packets that can't be decoded are passed to the callback too.
+func (c *Client) OnPacketRTCPRaw(medi *description.Media, cb OnPacketRTCPRawFunc) {
+	cm := c.medias[medi]
+	cm.onPacketRTCPRaw = cb
+}
+
 // WritePacketRTP writes a RTP packet to the server.
 func (c *Client) WritePacketRTP(medi *description.Media, pkt *rtp.Packet) error {
 	return c.WritePacketRTPWithNTP(medi, pkt, c.timeNow())
@@ -1836,6 +3955,27 @@ func (c *Client) WritePacketRTP(medi *description.Media, pkt *rtp.Packet) error
 // WritePacketRTPWithNTP writes a RTP packet to the server.
 // ntp is the absolute time of the packet, and is sent with periodic RTCP sender reports.
 func (c *Client) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet, ntp time.Time) error {
+	select {
+	case <-c.done:
+		return c.closeError
+	default:
+	}
+
+	cm := c.medias[medi]
+	ct, ok := cm.formats[pkt.PayloadType]
+	if !ok {
+		return liberrors.ErrClientRTPPacketPayloadTypeNotInMedia{PayloadType: pkt.PayloadType}
+	}
+
+	if cm.redEncoder != nil && ct == cm.redTarget {
+		pkt = cm.encodeRED(pkt)
+		ct = cm.redFormat
+	}
+
+	if c.RecordTimestampContinuity {
+		pkt = ct.recordContinuity.process(pkt, c.timeNow())
+	}
+
 	byts := make([]byte, c.MaxPacketSize)
 	n, err := pkt.MarshalTo(byts)
 	if err != nil {
@@ -1843,6 +3983,16 @@ func (c *Client) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet,
 	}
 	byts = byts[:n]
 
+	return ct.writePacketRTP(byts, pkt, ntp)
+}
+
+// WritePacketRTCP writes a RTCP packet to the server.
+func (c *Client) WritePacketRTCP(medi *description.Media, pkt rtcp.Packet) error {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
 	select {
 	case <-c.done:
 		return c.closeError
@@ -1850,17 +4000,21 @@ func (c *Client) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet,
 	}
 
 	cm := c.medias[medi]
-	ct := cm.formats[pkt.PayloadType]
-	return ct.writePacketRTP(byts, pkt, ntp)
+	return cm.writePacketRTCP(byts)
 }
 
-// WritePacketRTCP writes a RTCP packet to the server.
-func (c *Client) WritePacketRTCP(medi *description.Media, pkt rtcp.Packet) error {
-	byts, err := pkt.Marshal()
+// WritePacketRTCPRaw writes a compound RTCP packet to the server.
+// It allows to send packets that are not supported by the RTCP library,
+// like application-defined (APP) packets.
+func (c *Client) WritePacketRTCPRaw(medi *description.Media, payload []byte) error {
+	_, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		return err
 	}
 
+	// the packet is written asynchronously
+	byts := append([]byte(nil), payload...)
+
 	select {
 	case <-c.done:
 		return c.closeError
@@ -1871,22 +4025,148 @@ func (c *Client) WritePacketRTCP(medi *description.Media, pkt rtcp.Packet) error
 	return cm.writePacketRTCP(byts)
 }
 
+// WriteInterleavedFrame writes an interleaved frame to the server,
+// on a channel that is not bound to any media.
+// It can be called only when playing or recording with the TCP transport.
+func (c *Client) WriteInterleavedFrame(channel int, payload []byte) error {
+	if channel < 0 || channel > 255 {
+		return fmt.Errorf("invalid channel: %d", channel)
+	}
+	if len(payload) > 65535 {
+		return fmt.Errorf("payload is too big")
+	}
+
+	select {
+	case <-c.done:
+		return c.closeError
+	default:
+	}
+
+	if c.effectiveTransport == nil || *c.effectiveTransport != TransportTCP {
+		return liberrors.ErrClientInterleavedFramesNotAvailable{}
+	}
+
+	if c.isChannelInUse(channel) {
+		return liberrors.ErrClientInterleavedChannelInUse{Channel: channel}
+	}
+
+	fr := &base.InterleavedFrame{
+		Channel: channel,
+		Payload: payload,
+	}
+
+	ok := c.writer.push(func() {
+		atomic.AddUint64(c.BytesSent, uint64(len(payload)))
+		c.nconn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+		c.conn.WriteInterleavedFrame(fr, make([]byte, fr.MarshalSize())) //nolint:errcheck
+	})
+	if !ok {
+		return liberrors.ErrClientWriteQueueFull{}
+	}
+
+	return nil
+}
+
+// RequestKeyframe asks the server to send a keyframe of a media,
+// by sending a RTCP Picture Loss Indication.
+// It can be called only after at least one RTP packet of the media has been received.
+func (c *Client) RequestKeyframe(medi *description.Media) error {
+	cm := c.medias[medi]
+	sent := false
+
+	for _, ct := range cm.formats {
+		if ct.rtcpReceiver == nil {
+			continue
+		}
+
+		ssrc, ok := ct.rtcpReceiver.SenderSSRC()
+		if !ok {
+			continue
+		}
+
+		err := c.WritePacketRTCP(medi, &rtcp.PictureLossIndication{
+			MediaSSRC: ssrc,
+		})
+		if err != nil {
+			return err
+		}
+		sent = true
+	}
+
+	if !sent {
+		return liberrors.ErrClientSSRCUnknown{}
+	}
+
+	return nil
+}
+
 // PacketPTS returns the PTS of an incoming RTP packet.
 // It is computed by decoding the packet timestamp and sychronizing it with other tracks.
 func (c *Client) PacketPTS(medi *description.Media, pkt *rtp.Packet) (time.Duration, bool) {
 	cm := c.medias[medi]
 	ct := cm.formats[pkt.PayloadType]
-	return c.timeDecoder.Decode(ct.format, pkt)
+	return c.timeDecoder.Decode(ct, pkt)
 }
 
 // PacketNTP returns the NTP timestamp of an incoming RTP packet.
-// The NTP timestamp is computed from sender reports.
+// The NTP timestamp is computed from sender reports, or, when ONVIFReplay is set,
+// read from the ONVIF replay header extension.
 func (c *Client) PacketNTP(medi *description.Media, pkt *rtp.Packet) (time.Time, bool) {
+	if c.ONVIFReplay != nil {
+		var ext onvifreplay.Extension
+		if ext.Unmarshal(pkt) == nil {
+			return ext.NTPTime, true
+		}
+	}
+
 	cm := c.medias[medi]
 	ct := cm.formats[pkt.PayloadType]
 	return ct.rtcpReceiver.PacketNTP(pkt.Timestamp)
 }
 
+// UDPKernelDrops returns the number of packets of a media that were dropped by the
+// operating system because the UDP receive buffer was full.
+// It returns false if the media is not received with UDP or if the counter
+// is not available on the current platform (it is available on Linux only).
+func (c *Client) UDPKernelDrops(medi *description.Media) (uint64, bool) {
+	cm, ok := c.medias[medi]
+	if !ok || cm.udpRTPListener == nil {
+		return 0, false
+	}
+
+	rtpDrops, ok := cm.udpRTPListener.kernelDrops()
+	if !ok {
+		return 0, false
+	}
+
+	rtcpDrops, ok := cm.udpRTCPListener.kernelDrops()
+	if !ok {
+		return 0, false
+	}
+
+	return rtpDrops + rtcpDrops, true
+}
+
+// StartPacketCapture starts writing RTSP messages, RTP and RTCP packets
+// exchanged with the server into w, in the pcapng format.
+// It can be called at any time, and replaces any previous capture.
+func (c *Client) StartPacketCapture(w io.Writer) error {
+	return c.capture.start(w)
+}
+
+// StopPacketCapture stops writing packets into the capture started with StartPacketCapture.
+func (c *Client) StopPacketCapture() {
+	c.capture.stop()
+}
+
+// SetImpairment sets impairments (loss, duplication, reordering, jitter)
+// to apply to RTP and RTCP packets received from the server, with any transport,
+// in order to test jitter buffers and retransmissions against realistic network conditions.
+// It can be called at any time after Start(). A nil value disables impairments.
+func (c *Client) SetImpairment(conf *chaos.Config) {
+	c.impairer.SetConfig(conf)
+}
+
 func (c *Client) readResponse(res *base.Response) {
 	c.chReadResponse <- res
 }
@@ -0,0 +1,181 @@
+package gortsplib
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+)
+
+// 3GPP QoE metrics that can be computed from internal statistics.
+const (
+	qoeMetricSuccessiveLoss = "Successive_Loss"
+	qoeMetricJitterDuration = "Jitter_Duration"
+)
+
+// adaptation3GPPHeader returns the 3GPP-Adaptation header of a SETUP request.
+// The reception buffer is the kernel read buffer of UDP sockets.
+func adaptation3GPPHeader(mediaURL *base.URL) base.HeaderValue {
+	return headers.Adaptation3GPP{{
+		URL:  mediaURL.String(),
+		Size: udpKernelReadBufferSize,
+	}}.Marshal()
+}
+
+// readQoEMetrics stores metrics requested by the server in a response.
+// Invalid headers are ignored, since compatibility with 3GPP is optional.
+func (c *Client) readQoEMetrics(res *base.Response) {
+	v, ok := res.Header["3GPP-QoE-Metrics"]
+	if !ok {
+		return
+	}
+
+	var h headers.QoEMetrics3GPP
+	err := h.Unmarshal(v)
+	if err != nil {
+		return
+	}
+
+	// a header set to Off disables all metrics
+	if len(h) == 0 {
+		c.qoeMetrics = nil
+		return
+	}
+
+	// entries replace the ones with the same URL
+	for _, e := range h {
+		found := false
+
+		for i, cur := range c.qoeMetrics {
+			if cur.URL == e.URL {
+				c.qoeMetrics[i] = e
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			c.qoeMetrics = append(c.qoeMetrics, e)
+		}
+	}
+}
+
+// qoeReportPeriod returns the shortest period among requested metrics.
+// It returns zero if metrics must be sent at the end of the session only.
+func (c *Client) qoeReportPeriod() time.Duration {
+	var period time.Duration
+
+	for _, e := range c.qoeMetrics {
+		if !e.Off && e.Rate != nil && *e.Rate != 0 {
+			v := time.Duration(*e.Rate) * time.Second
+			if period == 0 || v < period {
+				period = v
+			}
+		}
+	}
+
+	return period
+}
+
+// qoeEntryMedias returns the medias an entry refers to.
+// Entries that don't refer to a specific media refer to the whole session.
+func (c *Client) qoeEntryMedias(e *headers.QoEMetrics3GPPEntry) []*clientMedia {
+	for _, cm := range c.medias {
+		if cm.url.String() == e.URL {
+			return []*clientMedia{cm}
+		}
+	}
+
+	ret := make([]*clientMedia, 0, len(c.medias))
+	for _, cm := range c.medias {
+		ret = append(ret, cm)
+	}
+	return ret
+}
+
+func (c *Client) qoeFeedback() headers.QoEFeedback3GPP {
+	var fb headers.QoEFeedback3GPP
+
+	// lost packets are counted since the previous report,
+	// therefore counters are updated once per media.
+	lostSinceReport := make(map[*clientMedia]uint32)
+
+	for _, cm := range c.medias {
+		var totalLost uint32
+		for _, ct := range cm.formats {
+			if ct.rtcpReceiver != nil {
+				totalLost += ct.rtcpReceiver.Stats().TotalLost
+			}
+		}
+
+		lostSinceReport[cm] = totalLost - c.qoeLastLost[cm]
+		c.qoeLastLost[cm] = totalLost
+	}
+
+	for _, e := range c.qoeMetrics {
+		if e.Off {
+			continue
+		}
+
+		medias := c.qoeEntryMedias(e)
+
+		entry := &headers.QoEFeedback3GPPEntry{
+			URL: e.URL,
+		}
+
+		for _, name := range e.Metrics {
+			m := headers.QoEFeedback3GPPMetric{
+				Name: name,
+			}
+
+			switch name {
+			case qoeMetricSuccessiveLoss:
+				var lost uint32
+				for _, cm := range medias {
+					lost += lostSinceReport[cm]
+				}
+				m.Values = []string{strconv.FormatUint(uint64(lost), 10)}
+
+			case qoeMetricJitterDuration:
+				var jitter time.Duration
+				for _, cm := range medias {
+					for _, ct := range cm.formats {
+						if ct.rtcpReceiver != nil {
+							if v := ct.rtcpReceiver.Stats().Jitter; v > jitter {
+								jitter = v
+							}
+						}
+					}
+				}
+				m.Values = []string{strconv.FormatInt(jitter.Milliseconds(), 10)}
+
+			default:
+				// metric is not measured
+			}
+
+			entry.Metrics = append(entry.Metrics, m)
+		}
+
+		fb = append(fb, entry)
+	}
+
+	return fb
+}
+
+func (c *Client) doQoEFeedback() error {
+	fb := c.qoeFeedback()
+	if len(fb) == 0 {
+		return nil
+	}
+
+	// do not wait for responses, like keepalives.
+	_, err := c.do(&base.Request{
+		Method: base.SetParameter,
+		URL:    c.baseURL,
+		Header: base.Header{
+			"3GPP-QoE-Feedback": fb.Marshal(),
+		},
+	}, true)
+	return err
+}
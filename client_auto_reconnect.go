@@ -0,0 +1,207 @@
+package gortsplib
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+const (
+	clientAutoReconnectInitialDelay = 1 * time.Second
+	clientAutoReconnectMaxDelay     = 30 * time.Second
+)
+
+// ClientAutoReconnect contains the parameters of the automatic reconnection.
+// When reading, if the connection with the server is lost, the client
+// connects again and repeats the DESCRIBE, SETUP and PLAY requests,
+// preserving medias and callbacks.
+type ClientAutoReconnect struct {
+	// delay before the first attempt.
+	// It is doubled after every failed attempt.
+	// It defaults to 1 second.
+	InitialDelay time.Duration
+
+	// maximum delay between attempts.
+	// It defaults to 30 seconds.
+	MaxDelay time.Duration
+
+	// maximum number of consecutive attempts.
+	// It defaults to 0 (unlimited).
+	MaxAttempts int
+
+	// resume playback at the last known position, instead of
+	// repeating the PLAY request with the last range.
+	// This is useful with VOD sources.
+	ResumePosition bool
+}
+
+func (r *ClientAutoReconnect) delay(attempt int) time.Duration {
+	initialDelay := r.InitialDelay
+	if initialDelay == 0 {
+		initialDelay = clientAutoReconnectInitialDelay
+	}
+
+	maxDelay := r.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = clientAutoReconnectMaxDelay
+	}
+
+	d := initialDelay
+	for i := 1; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+
+	if d > maxDelay {
+		return maxDelay
+	}
+	return d
+}
+
+func (c *Client) canReconnect(err error) bool {
+	if c.AutoReconnect == nil || c.state != clientStatePlay || !c.stdChannelSetupped {
+		return false
+	}
+
+	_, ok := err.(liberrors.ErrClientTerminated)
+	return !ok
+}
+
+// resumeRange returns the range of the PLAY request sent after a reconnection.
+func (c *Client) resumeRange() *headers.Range {
+	if !c.AutoReconnect.ResumePosition {
+		return c.lastRange
+	}
+
+	return c.currentRange()
+}
+
+// currentRange returns a range that starts from the current playback position,
+// estimated with the range of the last PLAY request and the time elapsed since then.
+func (c *Client) currentRange() *headers.Range {
+	var start time.Duration
+
+	if c.lastRange != nil {
+		npt, ok := c.lastRange.Value.(*headers.RangeNPT)
+		if !ok || npt.Now {
+			return c.lastRange
+		}
+		start = npt.Start
+	}
+
+	if lrt := atomic.LoadInt64(c.lastRTPTime); lrt != 0 {
+		elapsed := time.Unix(0, lrt).Sub(c.playStartTime)
+		if c.Scale != nil {
+			elapsed = time.Duration(float64(elapsed) * c.Scale.Value)
+		}
+		start += elapsed
+		if start < 0 {
+			start = 0
+		}
+	}
+
+	return &headers.Range{
+		Value: &headers.RangeNPT{
+			Start: start,
+		},
+	}
+}
+
+func (c *Client) doReconnect(err error) error {
+	prevConnURL := c.connURL
+	prevBaseURL := c.baseURL
+	prevMedias := c.medias
+	prevTransport := c.effectiveTransport
+	ra := c.resumeRange()
+
+	// the server asked to connect to another location.
+	// The connection is performed immediately, with the host of the new location.
+	redirect, redirected := err.(liberrors.ErrClientRedirected)
+	if redirected {
+		prevConnURL = &base.URL{
+			Scheme: redirect.Location.Scheme,
+			Host:   redirect.Location.Host,
+		}
+		prevBaseURL = replaceHost(prevBaseURL, redirect.Location)
+
+		if c.lastDescribeURL != nil {
+			c.lastDescribeURL = replaceHost(c.lastDescribeURL, redirect.Location)
+		}
+	}
+
+	c.reset()
+	c.mustClose = false
+
+	for attempt := 1; ; attempt++ {
+		if c.AutoReconnect.MaxAttempts != 0 && attempt > c.AutoReconnect.MaxAttempts {
+			return err
+		}
+
+		c.log.Warn("reconnecting", "attempt", attempt, "err", err)
+		c.OnReconnecting(attempt, err)
+
+		delay := c.AutoReconnect.delay(attempt)
+		if redirected && attempt == 1 {
+			delay = 0
+		}
+
+		t := c.Clock.NewTimer(delay)
+		select {
+		case <-t.C():
+		case <-c.ctx.Done():
+			t.Stop()
+			return liberrors.ErrClientTerminated{}
+		}
+
+		err = c.tryReconnect(prevConnURL, prevBaseURL, prevMedias, prevTransport, ra)
+		if err == nil {
+			c.OnReconnected()
+			return nil
+		}
+
+		if _, ok := err.(liberrors.ErrClientTerminated); ok {
+			return err
+		}
+
+		c.reset()
+		c.mustClose = false
+	}
+}
+
+func (c *Client) tryReconnect(
+	prevConnURL *base.URL,
+	prevBaseURL *base.URL,
+	prevMedias map[*description.Media]*clientMedia,
+	prevTransport *Transport,
+	ra *headers.Range,
+) error {
+	c.connURL = prevConnURL
+	c.effectiveTransport = prevTransport
+
+	if c.lastDescribeURL != nil {
+		_, _, err := c.doDescribe(c.lastDescribeURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, cm := range prevMedias {
+		_, err := c.doSetup(prevBaseURL, cm.media, 0, 0)
+		if err != nil {
+			return err
+		}
+
+		c.medias[i].onPacketRTCP = cm.onPacketRTCP
+		c.medias[i].onPacketRTCPRaw = cm.onPacketRTCPRaw
+		c.medias[i].onPacketRTPExtensions = cm.onPacketRTPExtensions
+		for j, tr := range cm.formats {
+			c.medias[i].formats[j].onPacketRTP = tr.onPacketRTP
+		}
+	}
+
+	_, err := c.doPlay(ra)
+	return err
+}
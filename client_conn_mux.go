@@ -0,0 +1,496 @@
+package gortsplib
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/conn"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// clientMuxPipeConn is the end of a pipe used by a client.
+// It reports the addresses of the shared connection, in order to allow the UDP transport.
+type clientMuxPipeConn struct {
+	net.Conn
+	localAddr  net.Addr
+	remoteAddr net.Addr
+}
+
+func (c *clientMuxPipeConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *clientMuxPipeConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+type clientMuxChannel struct {
+	s     *clientMuxSession
+	local int
+}
+
+type clientMuxPending struct {
+	s    *clientMuxSession
+	cseq base.HeaderValue
+
+	// SETUP requests with the TCP transport
+	localChannel  int
+	globalChannel int
+	hasChannel    bool
+}
+
+// clientMuxSession is a client attached to a shared connection.
+type clientMuxSession struct {
+	mc      *clientMuxConn
+	pipe    net.Conn
+	conn    *conn.Conn
+	session string
+	// local interleaved channel -> channel of the shared connection
+	channels map[int]int
+}
+
+func (s *clientMuxSession) run() {
+	var buf []byte
+
+	for {
+		what, err := s.conn.Read()
+		if err != nil {
+			s.mc.detach(s)
+			return
+		}
+
+		switch what := what.(type) {
+		case *base.Request:
+			s.mc.writeRequest(s, what)
+
+		case *base.Response:
+			// response to a request of the server
+			s.mc.write(func(c *conn.Conn) error {
+				return c.WriteResponse(what)
+			})
+
+		case *base.InterleavedFrame:
+			s.mc.mutex.Lock()
+			channel, ok := s.channels[what.Channel]
+			s.mc.mutex.Unlock()
+
+			if !ok {
+				continue
+			}
+
+			what.Channel = channel
+
+			if n := what.MarshalSize(); len(buf) < n {
+				buf = make([]byte, n)
+			}
+
+			s.mc.write(func(c *conn.Conn) error {
+				return c.WriteInterleavedFrame(what, buf)
+			})
+		}
+	}
+}
+
+// clientMuxConn is a TCP connection shared by multiple clients.
+type clientMuxConn struct {
+	m     *ClientConnMux
+	key   string
+	nconn net.Conn
+	conn  *conn.Conn
+
+	writeMutex sync.Mutex
+
+	mutex    sync.Mutex
+	sessions map[*clientMuxSession]struct{}
+	cseq     int
+	pending  map[int]*clientMuxPending
+	channels map[int]clientMuxChannel
+	closed   bool
+}
+
+func newClientMuxConn(m *ClientConnMux, key string, nconn net.Conn) *clientMuxConn {
+	mc := &clientMuxConn{
+		m:        m,
+		key:      key,
+		nconn:    nconn,
+		conn:     conn.NewConn(nconn),
+		sessions: make(map[*clientMuxSession]struct{}),
+		pending:  make(map[int]*clientMuxPending),
+		channels: make(map[int]clientMuxChannel),
+	}
+
+	go mc.run()
+
+	return mc
+}
+
+func (mc *clientMuxConn) attach() net.Conn {
+	clientEnd, muxEnd := net.Pipe()
+
+	s := &clientMuxSession{
+		mc:       mc,
+		pipe:     muxEnd,
+		conn:     conn.NewConn(muxEnd),
+		channels: make(map[int]int),
+	}
+
+	mc.mutex.Lock()
+	if mc.closed {
+		muxEnd.Close()
+	} else {
+		mc.sessions[s] = struct{}{}
+	}
+	mc.mutex.Unlock()
+
+	go s.run()
+
+	return &clientMuxPipeConn{
+		Conn:       clientEnd,
+		localAddr:  mc.nconn.LocalAddr(),
+		remoteAddr: mc.nconn.RemoteAddr(),
+	}
+}
+
+// detach is called when a client closes its connection.
+// The shared connection is closed when it is not used anymore.
+func (mc *clientMuxConn) detach(s *clientMuxSession) {
+	s.pipe.Close()
+
+	mc.m.mutex.Lock()
+	mc.mutex.Lock()
+
+	delete(mc.sessions, s)
+
+	for cseq, p := range mc.pending {
+		if p.s == s {
+			delete(mc.pending, cseq)
+		}
+	}
+
+	for channel, ch := range mc.channels {
+		if ch.s == s {
+			delete(mc.channels, channel)
+		}
+	}
+
+	// remove the connection from the multiplexer before releasing the lock,
+	// in order to prevent other clients from attaching to it.
+	unused := len(mc.sessions) == 0
+	if unused && mc.m.conns[mc.key] == mc {
+		delete(mc.m.conns, mc.key)
+	}
+
+	mc.mutex.Unlock()
+	mc.m.mutex.Unlock()
+
+	if unused {
+		mc.close()
+	}
+}
+
+func (mc *clientMuxConn) close() {
+	mc.m.remove(mc)
+
+	mc.mutex.Lock()
+	mc.closed = true
+	sessions := mc.sessions
+	mc.sessions = make(map[*clientMuxSession]struct{})
+	mc.mutex.Unlock()
+
+	mc.nconn.Close()
+
+	for s := range sessions {
+		s.pipe.Close()
+	}
+}
+
+func (mc *clientMuxConn) write(cb func(c *conn.Conn) error) {
+	mc.writeMutex.Lock()
+	defer mc.writeMutex.Unlock()
+
+	err := cb(mc.conn)
+	if err != nil {
+		mc.nconn.Close()
+	}
+}
+
+func (mc *clientMuxConn) isChannelPairFree(channel int) bool {
+	_, ok1 := mc.channels[channel]
+	_, ok2 := mc.channels[channel+1]
+	return !ok1 && !ok2
+}
+
+func (mc *clientMuxConn) writeRequest(s *clientMuxSession, req *base.Request) {
+	mc.mutex.Lock()
+
+	mc.cseq++
+	cseq := mc.cseq
+
+	p := &clientMuxPending{
+		s:    s,
+		cseq: req.Header["CSeq"],
+	}
+
+	if req.Header == nil {
+		req.Header = make(base.Header)
+	}
+
+	req.Header["CSeq"] = base.HeaderValue{strconv.FormatInt(int64(cseq), 10)}
+
+	// interleaved channels of different clients are remapped in order not to collide
+	if req.Method == base.Setup {
+		var th headers.Transport
+		err := th.Unmarshal(req.Header["Transport"])
+		if err == nil && th.InterleavedIDs != nil {
+			global := 0
+			for !mc.isChannelPairFree(global) {
+				global += 2
+			}
+
+			p.localChannel = th.InterleavedIDs[0]
+			p.globalChannel = global
+			p.hasChannel = true
+
+			mc.channels[global] = clientMuxChannel{s: s, local: p.localChannel}
+			mc.channels[global+1] = clientMuxChannel{s: s, local: p.localChannel + 1}
+
+			th.InterleavedIDs = &[2]int{global, global + 1}
+			req.Header["Transport"] = th.Marshal()
+		}
+	}
+
+	mc.pending[cseq] = p
+
+	mc.mutex.Unlock()
+
+	mc.write(func(c *conn.Conn) error {
+		return c.WriteRequest(req)
+	})
+}
+
+func (mc *clientMuxConn) run() {
+	var buf []byte
+
+	for {
+		what, err := mc.conn.Read()
+		if err != nil {
+			mc.close()
+			return
+		}
+
+		switch what := what.(type) {
+		case *base.Response:
+			mc.routeResponse(what)
+
+		case *base.Request:
+			mc.routeRequest(what)
+
+		case *base.InterleavedFrame:
+			mc.mutex.Lock()
+			ch, ok := mc.channels[what.Channel]
+			mc.mutex.Unlock()
+
+			if !ok {
+				continue
+			}
+
+			what.Channel = ch.local
+
+			if n := what.MarshalSize(); len(buf) < n {
+				buf = make([]byte, n)
+			}
+
+			ch.s.conn.WriteInterleavedFrame(what, buf) //nolint:errcheck
+		}
+	}
+}
+
+func (mc *clientMuxConn) routeResponse(res *base.Response) {
+	mc.mutex.Lock()
+
+	cseq := -1
+
+	if v, ok := res.Header["CSeq"]; ok && len(v) == 1 {
+		tmp, err := strconv.ParseInt(strings.TrimSpace(v[0]), 10, 64)
+		if err == nil {
+			cseq = int(tmp)
+		}
+	} else {
+		// responses without CSeq are associated with the oldest request
+		for k := range mc.pending {
+			if cseq < 0 || k < cseq {
+				cseq = k
+			}
+		}
+	}
+
+	p, ok := mc.pending[cseq]
+	if !ok {
+		mc.mutex.Unlock()
+		return
+	}
+
+	delete(mc.pending, cseq)
+
+	if res.Header == nil {
+		res.Header = make(base.Header)
+	}
+
+	if p.cseq != nil {
+		res.Header["CSeq"] = p.cseq
+	} else {
+		delete(res.Header, "CSeq")
+	}
+
+	if v, ok := res.Header["Session"]; ok {
+		var sx headers.Session
+		err := sx.Unmarshal(v)
+		if err == nil {
+			p.s.session = sx.Session
+		}
+	}
+
+	if p.hasChannel {
+		mc.routeSetupResponse(p, res)
+	}
+
+	mc.mutex.Unlock()
+
+	p.s.conn.WriteResponse(res) //nolint:errcheck
+}
+
+func (mc *clientMuxConn) routeSetupResponse(p *clientMuxPending, res *base.Response) {
+	var th headers.Transport
+	err := th.Unmarshal(res.Header["Transport"])
+
+	if res.StatusCode != base.StatusOK || err != nil || th.InterleavedIDs == nil {
+		delete(mc.channels, p.globalChannel)
+		delete(mc.channels, p.globalChannel+1)
+		return
+	}
+
+	// the server chose different channels
+	if global := th.InterleavedIDs[0]; global != p.globalChannel {
+		delete(mc.channels, p.globalChannel)
+		delete(mc.channels, p.globalChannel+1)
+
+		if !mc.isChannelPairFree(global) {
+			return
+		}
+
+		p.globalChannel = global
+		mc.channels[global] = clientMuxChannel{s: p.s, local: p.localChannel}
+		mc.channels[global+1] = clientMuxChannel{s: p.s, local: p.localChannel + 1}
+	}
+
+	p.s.channels[p.localChannel] = p.globalChannel
+	p.s.channels[p.localChannel+1] = p.globalChannel + 1
+
+	th.InterleavedIDs = &[2]int{p.localChannel, p.localChannel + 1}
+	res.Header["Transport"] = th.Marshal()
+}
+
+// routeRequest delivers a request of the server to the client that owns the session.
+func (mc *clientMuxConn) routeRequest(req *base.Request) {
+	mc.mutex.Lock()
+
+	var target *clientMuxSession
+
+	if v, ok := req.Header["Session"]; ok {
+		var sx headers.Session
+		err := sx.Unmarshal(v)
+		if err == nil {
+			for s := range mc.sessions {
+				if s.session == sx.Session {
+					target = s
+					break
+				}
+			}
+		}
+	}
+
+	if target == nil {
+		for s := range mc.sessions {
+			target = s
+			break
+		}
+	}
+
+	mc.mutex.Unlock()
+
+	if target != nil {
+		target.conn.WriteRequest(req) //nolint:errcheck
+	}
+}
+
+// ClientConnMux allows multiple clients to share TCP connections to servers,
+// while keeping independent sessions (i.e. to read dozens of channels from a NVR
+// with a single connection).
+// Requests of different clients are told apart by rewriting their CSeq,
+// and interleaved channels are remapped in order to avoid collisions.
+// A client that reads slowly slows down the other clients that share its connection.
+// Connections are closed when they are not used by any client.
+type ClientConnMux struct {
+	mutex  sync.Mutex
+	conns  map[string]*clientMuxConn
+	closed bool
+}
+
+// Close closes all shared connections.
+func (m *ClientConnMux) Close() {
+	m.mutex.Lock()
+	m.closed = true
+	conns := m.conns
+	m.conns = nil
+	m.mutex.Unlock()
+
+	for _, mc := range conns {
+		mc.close()
+	}
+}
+
+// dial returns a connection that shares the connection to the given address.
+// The shared connection is created with the given function when it does not exist.
+func (m *ClientConnMux) dial(
+	ctx context.Context,
+	key string,
+	dial func(ctx context.Context) (net.Conn, error),
+) (net.Conn, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return nil, liberrors.ErrClientTerminated{}
+	}
+
+	mc, ok := m.conns[key]
+	if !ok {
+		nconn, err := dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if m.conns == nil {
+			m.conns = make(map[string]*clientMuxConn)
+		}
+
+		mc = newClientMuxConn(m, key, nconn)
+		m.conns[key] = mc
+	}
+
+	return mc.attach(), nil
+}
+
+func (m *ClientConnMux) remove(mc *clientMuxConn) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.conns[mc.key] == mc {
+		delete(m.conns, mc.key)
+	}
+}
@@ -0,0 +1,95 @@
+package gortsplib
+
+import (
+	"strconv"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+)
+
+// isControlURLRejection checks whether the status code of a SETUP response
+// suggests that the media URL is not accepted by the server.
+func isControlURLRejection(code base.StatusCode) bool {
+	switch code {
+	case base.StatusBadRequest,
+		base.StatusNotFound,
+		base.StatusAggregateOperationNotAllowed,
+		base.StatusOnlyAggregateOperationAllowed:
+		return true
+	}
+	return false
+}
+
+// mediaIndex returns the position of a media inside the last described or announced session.
+func (c *Client) mediaIndex(medi *description.Media) int {
+	for i, m := range c.lastMedias {
+		if m == medi {
+			return i
+		}
+	}
+	return len(c.medias)
+}
+
+// mediaURLs returns the URLs that can be used to setup a media, in order of preference.
+func (c *Client) mediaURLs(baseURL *base.URL, medi *description.Media) ([]*base.URL, error) {
+	var ret []*base.URL
+
+	add := func(u *base.URL) {
+		for _, existing := range ret {
+			if existing.String() == u.String() {
+				return
+			}
+		}
+		ret = append(ret, u)
+	}
+
+	defaultURL, err := medi.URL(baseURL)
+
+	if c.ResolveMediaURL != nil {
+		u, err2 := c.ResolveMediaURL(medi, baseURL, defaultURL)
+		if err2 != nil {
+			return nil, err2
+		}
+		if u != nil {
+			add(u)
+		}
+	}
+
+	if err == nil {
+		add(defaultURL)
+	}
+
+	if c.ControlURLFallback {
+		// control attribute relative to the request URL instead of the base URL
+		if c.lastDescribeURL != nil {
+			u, err2 := medi.URL(c.lastDescribeURL)
+			if err2 == nil {
+				add(u)
+			}
+		}
+
+		i := c.mediaIndex(medi)
+
+		for _, control := range []string{
+			"trackID=" + strconv.FormatInt(int64(i), 10),
+			"trackID=" + strconv.FormatInt(int64(i+1), 10),
+			"stream=" + strconv.FormatInt(int64(i), 10),
+		} {
+			u, err2 := description.Media{Control: control}.URL(baseURL)
+			if err2 == nil {
+				add(u)
+			}
+		}
+
+		// aggregate URL
+		if baseURL != nil {
+			add(baseURL)
+		}
+	}
+
+	if len(ret) == 0 {
+		return nil, err
+	}
+
+	return ret, nil
+}
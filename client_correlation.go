@@ -0,0 +1,102 @@
+package gortsplib
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// maximum number of requests waiting for a response that are tracked.
+// Older requests are forgotten, since the server may never reply to them.
+const clientMaxOutstandingRequests = 32
+
+// trackRequest registers a request that has been sent and is waiting for a response.
+func (c *Client) trackRequest(cseqStr string) {
+	cseq, err := strconv.Atoi(cseqStr)
+	if err != nil {
+		return
+	}
+
+	if len(c.outstandingCSeqs) == clientMaxOutstandingRequests {
+		c.outstandingCSeqs = c.outstandingCSeqs[1:]
+	}
+	c.outstandingCSeqs = append(c.outstandingCSeqs, cseq)
+}
+
+// untrackRequest removes a request from the ones waiting for a response and returns its CSeq.
+func (c *Client) untrackRequest(i int) string {
+	cseq := c.outstandingCSeqs[i]
+	c.outstandingCSeqs = append(c.outstandingCSeqs[:i], c.outstandingCSeqs[i+1:]...)
+	return strconv.Itoa(cseq)
+}
+
+func (c *Client) findOutstandingRequest(cseq int) int {
+	for i, v := range c.outstandingCSeqs {
+		if v == cseq {
+			return i
+		}
+	}
+	return -1
+}
+
+// correlateResponse returns the CSeq of the request that a response refers to.
+// current is the CSeq of the request that is being waited for, if any.
+func (c *Client) correlateResponse(res *base.Response, current string) (string, bool) {
+	if c.IgnoreResponseCSeq {
+		if len(c.outstandingCSeqs) == 0 {
+			return "", false
+		}
+		return c.untrackRequest(0), true
+	}
+
+	v, ok := res.Header["CSeq"]
+	if !ok || len(v) != 1 {
+		// responses without CSeq refer to the request that is being waited for
+		if current == "" {
+			return "", false
+		}
+		if cur, err := strconv.Atoi(current); err == nil {
+			if i := c.findOutstandingRequest(cur); i >= 0 {
+				c.untrackRequest(i)
+			}
+		}
+		return current, true
+	}
+
+	cseq, err := strconv.Atoi(strings.TrimSpace(v[0]))
+	if err != nil {
+		return "", false
+	}
+
+	if i := c.findOutstandingRequest(cseq); i >= 0 {
+		c.cseqOffset = 0
+		return c.untrackRequest(i), true
+	}
+
+	// the server is following its own numbering since the last resynchronization
+	if c.cseqOffset != 0 {
+		if i := c.findOutstandingRequest(cseq + c.cseqOffset); i >= 0 {
+			return c.untrackRequest(i), true
+		}
+	}
+
+	// the server has reset or duplicated CSeq: associate the response
+	// with the oldest request and follow the numbering of the server.
+	if len(c.outstandingCSeqs) != 0 {
+		oldest := c.outstandingCSeqs[0]
+		diff := oldest - cseq
+		if diff < 0 {
+			diff = -diff
+		}
+
+		if diff <= c.ResponseCSeqTolerance {
+			c.log.Warn("response CSeq doesn't match any request, resynchronizing",
+				"cseq", cseq, "request", oldest)
+			c.cseqOffset = oldest - cseq
+			return c.untrackRequest(0), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,35 @@
+package gortsplib
+
+import (
+	"net/http"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+)
+
+// clientDescribeCache contains the result of the last DESCRIBE,
+// that can be reused when the server replies with 304 Not Modified.
+type clientDescribeCache struct {
+	url          string
+	lastModified string
+	desc         *description.Session
+}
+
+func newClientDescribeCache(u *base.URL, res *base.Response, desc *description.Session) *clientDescribeCache {
+	v, ok := res.Header["Last-Modified"]
+	if !ok || len(v) != 1 {
+		return nil
+	}
+
+	// the value is sent back as is, but it must be a valid HTTP date.
+	_, err := http.ParseTime(v[0])
+	if err != nil {
+		return nil
+	}
+
+	return &clientDescribeCache{
+		url:          u.String(),
+		lastModified: v[0],
+		desc:         desc,
+	}
+}
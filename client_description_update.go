@@ -0,0 +1,78 @@
+package gortsplib
+
+import (
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
+)
+
+func isDescriptionUpdate(req *base.Request) bool {
+	return strings.Split(req.Header.Get("Content-Type"), ";")[0] == "application/sdp"
+}
+
+// handleDescriptionUpdate handles an ANNOUNCE request of the server that contains
+// an updated stream description (i.e. after parameter sets have changed),
+// and updates parameters of described formats.
+func (c *Client) handleDescriptionUpdate(req *base.Request) *base.Response {
+	var ssd sdp.SessionDescription
+	err := ssd.Unmarshal(req.Body)
+	if err != nil {
+		c.OnWarning(liberrors.ErrClientSDPInvalid{Err: err})
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}
+	}
+
+	var desc description.Session
+	err = desc.Unmarshal(&ssd)
+	if err != nil {
+		c.OnWarning(liberrors.ErrClientSDPInvalid{Err: err})
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}
+	}
+
+	// medias are matched by position, since control attributes are not stable
+	for i, medi := range c.lastMedias {
+		if i >= len(desc.Medias) {
+			break
+		}
+
+		for _, forma := range medi.Formats {
+			for _, updated := range desc.Medias[i].Formats {
+				if updated.PayloadType() == forma.PayloadType() {
+					updateFormatParams(forma, updated)
+				}
+			}
+		}
+	}
+
+	c.log.Debug("stream description updated")
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}
+}
+
+// updateFormatParams copies parameter sets of a format into another one.
+func updateFormatParams(dest format.Format, src format.Format) {
+	switch dest := dest.(type) {
+	case *format.H264:
+		if src, ok := src.(*format.H264); ok {
+			if sps, pps := src.SafeParams(); sps != nil && pps != nil {
+				dest.SafeSetParams(sps, pps)
+			}
+		}
+
+	case *format.H265:
+		if src, ok := src.(*format.H265); ok {
+			if vps, sps, pps := src.SafeParams(); vps != nil && sps != nil && pps != nil {
+				dest.SafeSetParams(vps, sps, pps)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package gortsplib
+
+import (
+	"io"
+)
+
+// clientDumpConn is a io.ReadWriter wrapper that copies
+// read and written bytes into the control connection dumps.
+type clientDumpConn struct {
+	rw        io.ReadWriter
+	readDump  io.Writer
+	writeDump io.Writer
+}
+
+// Read implements io.ReadWriter.
+func (dc *clientDumpConn) Read(p []byte) (int, error) {
+	n, err := dc.rw.Read(p)
+	if n > 0 && dc.readDump != nil {
+		dc.readDump.Write(p[:n]) //nolint:errcheck
+	}
+	return n, err
+}
+
+// Write implements io.ReadWriter.
+func (dc *clientDumpConn) Write(p []byte) (int, error) {
+	n, err := dc.rw.Write(p)
+	if n > 0 && dc.writeDump != nil {
+		dc.writeDump.Write(p[:n]) //nolint:errcheck
+	}
+	return n, err
+}
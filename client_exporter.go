@@ -0,0 +1,301 @@
+package gortsplib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph265"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpmpeg4audio"
+)
+
+// ClientExportedUnit is a unit exported by ClientExporter.
+type ClientExportedUnit struct {
+	Media  *description.Media
+	Format format.Format
+
+	// presentation timestamp, relative to the start of the session.
+	PTS time.Duration
+
+	// decoding timestamp, relative to the start of the session.
+	// It differs from PTS when the stream contains B-frames.
+	DTS time.Duration
+
+	// absolute timestamp of the unit, computed from RTCP sender reports.
+	// It can be used to fill EXT-X-PROGRAM-DATE-TIME of HLS playlists.
+	// It is zero until the first sender report is received.
+	NTP time.Time
+
+	// access unit (H264, H265) or frame (other formats).
+	Data [][]byte
+}
+
+// ClientExporter converts RTP packets received by a client into units
+// with PTS and DTS, that can be fed to a muxer.
+// Supported formats are H264, H265, MPEG-4 Audio and Opus.
+// Medias must be set up before calling Initialize(), and the callback
+// is called by the routines of each media.
+// Callbacks set with Client.OnPacketRTP() before Initialize() are preserved
+// and called before packets are exported.
+type ClientExporter struct {
+	Client *Client
+
+	// called when a unit is ready.
+	OnUnit func(*ClientExportedUnit)
+}
+
+// Initialize initializes ClientExporter.
+func (e *ClientExporter) Initialize() error {
+	if e.OnUnit == nil {
+		return fmt.Errorf("OnUnit not provided")
+	}
+
+	n := 0
+
+	for medi, cm := range e.Client.medias {
+		for _, ct := range cm.formats {
+			cb, err := e.packetProcessor(medi, ct.format)
+			if err != nil {
+				return err
+			}
+
+			if cb != nil {
+				prev := ct.onPacketRTP
+				e.Client.OnPacketRTP(medi, ct.format, func(pkt *rtp.Packet) {
+					prev(pkt)
+					cb(pkt)
+				})
+				n++
+			}
+		}
+	}
+
+	if n == 0 {
+		return fmt.Errorf("no supported formats found")
+	}
+
+	return nil
+}
+
+func (e *ClientExporter) emit(
+	medi *description.Media,
+	forma format.Format,
+	pkt *rtp.Packet,
+	pts time.Duration,
+	dts time.Duration,
+	data [][]byte,
+) {
+	ntp, _ := e.Client.PacketNTP(medi, pkt)
+
+	e.OnUnit(&ClientExportedUnit{
+		Media:  medi,
+		Format: forma,
+		PTS:    pts,
+		DTS:    dts,
+		NTP:    ntp,
+		Data:   data,
+	})
+}
+
+func (e *ClientExporter) packetProcessor(medi *description.Media, forma format.Format) (OnPacketRTPFunc, error) {
+	switch forma := forma.(type) {
+	case *format.H264:
+		return e.processorH264(medi, forma)
+
+	case *format.H265:
+		return e.processorH265(medi, forma)
+
+	case *format.MPEG4Audio:
+		return e.processorMPEG4Audio(medi, forma)
+
+	case *format.Opus:
+		rtpDec, err := forma.CreateDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(pkt *rtp.Packet) {
+			pts, ok := e.Client.PacketPTS(medi, pkt)
+			if !ok {
+				return
+			}
+
+			frame, err := rtpDec.Decode(pkt)
+			if err != nil {
+				e.Client.OnDecodeError(err)
+				return
+			}
+
+			e.emit(medi, forma, pkt, pts, pts, [][]byte{frame})
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func (e *ClientExporter) processorH264(medi *description.Media, forma *format.H264) (OnPacketRTPFunc, error) {
+	// the DTS extractor needs parameters, that may be sent out of band.
+	// The decoder prepends them to the first IDR.
+	createDecoder := func() (*rtph264.Decoder, error) {
+		d, err := forma.CreateDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		d.SPS, d.PPS = forma.SafeParams()
+		return d, nil
+	}
+
+	rtpDec, err := createDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	var dtsExtractor *h264.DTSExtractor
+
+	return func(pkt *rtp.Packet) {
+		pts, ok := e.Client.PacketPTS(medi, pkt)
+		if !ok {
+			return
+		}
+
+		au, err := rtpDec.Decode(pkt)
+		if err != nil {
+			if !errors.Is(err, rtph264.ErrMorePacketsNeeded) &&
+				!errors.Is(err, rtph264.ErrNonStartingPacketAndNoPrevious) {
+				e.Client.OnDecodeError(err)
+			}
+			return
+		}
+
+		if dtsExtractor == nil {
+			// wait for a IDR, that is required to extract DTS
+			if !h264.IDRPresent(au) {
+				return
+			}
+			dtsExtractor = h264.NewDTSExtractor()
+		}
+
+		dts, err := dtsExtractor.Extract(au, pts)
+		if err != nil {
+			e.Client.OnDecodeError(err)
+			dtsExtractor = nil
+
+			// prepend parameters to the next IDR again
+			newDec, err := createDecoder()
+			if err != nil {
+				e.Client.OnDecodeError(err)
+				return
+			}
+			rtpDec = newDec
+			return
+		}
+
+		e.emit(medi, forma, pkt, pts, dts, au)
+	}, nil
+}
+
+func (e *ClientExporter) processorH265(medi *description.Media, forma *format.H265) (OnPacketRTPFunc, error) {
+	// the DTS extractor needs parameters, that may be sent out of band.
+	// The decoder prepends them to the first random access unit.
+	createDecoder := func() (*rtph265.Decoder, error) {
+		d, err := forma.CreateDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		d.VPS, d.SPS, d.PPS = forma.SafeParams()
+		return d, nil
+	}
+
+	rtpDec, err := createDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	var dtsExtractor *h265.DTSExtractor
+
+	return func(pkt *rtp.Packet) {
+		pts, ok := e.Client.PacketPTS(medi, pkt)
+		if !ok {
+			return
+		}
+
+		au, err := rtpDec.Decode(pkt)
+		if err != nil {
+			if !errors.Is(err, rtph265.ErrMorePacketsNeeded) &&
+				!errors.Is(err, rtph265.ErrNonStartingPacketAndNoPrevious) {
+				e.Client.OnDecodeError(err)
+			}
+			return
+		}
+
+		if dtsExtractor == nil {
+			// wait for a random access unit, that is required to extract DTS
+			if !h265.IsRandomAccess(au) {
+				return
+			}
+			dtsExtractor = h265.NewDTSExtractor()
+		}
+
+		dts, err := dtsExtractor.Extract(au, pts)
+		if err != nil {
+			e.Client.OnDecodeError(err)
+			dtsExtractor = nil
+
+			// prepend parameters to the next random access unit again
+			newDec, err := createDecoder()
+			if err != nil {
+				e.Client.OnDecodeError(err)
+				return
+			}
+			rtpDec = newDec
+			return
+		}
+
+		e.emit(medi, forma, pkt, pts, dts, au)
+	}, nil
+}
+
+func (e *ClientExporter) processorMPEG4Audio(
+	medi *description.Media,
+	forma *format.MPEG4Audio,
+) (OnPacketRTPFunc, error) {
+	rtpDec, err := forma.CreateDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	clockRate := e.Client.medias[medi].formats[forma.PayloadType()].clockRate
+
+	return func(pkt *rtp.Packet) {
+		pts, ok := e.Client.PacketPTS(medi, pkt)
+		if !ok {
+			return
+		}
+
+		aus, err := rtpDec.Decode(pkt)
+		if err != nil {
+			if !errors.Is(err, rtpmpeg4audio.ErrMorePacketsNeeded) {
+				e.Client.OnDecodeError(err)
+			}
+			return
+		}
+
+		// access units of a packet share the same RTP timestamp
+		for i, au := range aus {
+			auPTS := pts + time.Duration(i)*mpeg4audio.SamplesPerAccessUnit*
+				time.Second/time.Duration(clockRate)
+
+			e.emit(medi, forma, pkt, auPTS, auPTS, [][]byte{au})
+		}
+	}, nil
+}
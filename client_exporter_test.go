@@ -0,0 +1,244 @@
+package gortsplib
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/conn"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+)
+
+func TestClientExporter(t *testing.T) {
+	sps := []byte{
+		0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78,
+		0x02, 0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00,
+		0x04, 0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60,
+		0xc6, 0x58,
+	}
+	pps := []byte{0x08}
+
+	medi := &description.Media{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			SPS:               sps,
+			PPS:               pps,
+			PacketizationMode: 1,
+		}},
+	}
+
+	// B-frames: PTS is not monotonic
+	samples := []struct {
+		nalu      []byte
+		timestamp uint32
+	}{
+		{[]byte{0x41, 0x9a, 0x21, 0x6c, 0x45, 0xff}, 0}, // non-IDR, discarded
+		{[]byte{0x65, 0x88, 0x84, 0x00, 0x33, 0xff}, 0},
+		{[]byte{0x41, 0x9a, 0x21, 0x6c, 0x45, 0xff}, 3000},
+		{[]byte{0x41, 0x9a, 0x42, 0x3c, 0x21, 0x93}, 6000},
+		{[]byte{0x41, 0x9a, 0x63, 0x49, 0xe1, 0x0f}, 9000},
+		{[]byte{0x41, 0x9a, 0x86, 0x49, 0xe1, 0x0f}, 18000},
+		{[]byte{0x41, 0x9e, 0xa5, 0x42, 0x7f, 0xf9}, 15000},
+		{[]byte{0x01, 0x9e, 0xc4, 0x69, 0x13, 0xff}, 12000},
+	}
+
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP([]*description.Media{medi}),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		for i, sample := range samples {
+			byts, err := (&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 1000 + uint16(i),
+					Timestamp:      sample.timestamp,
+					SSRC:           0x38F27A2F,
+				},
+				Payload: sample.nalu,
+			}).Marshal()
+			require.NoError(t, err)
+
+			err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+				Channel: 0,
+				Payload: byts,
+			}, make([]byte, 1024))
+			require.NoError(t, err)
+		}
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	// callbacks set before Initialize() are preserved
+	packets := make(chan uint16, len(samples))
+	c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+		packets <- pkt.SequenceNumber
+	})
+
+	units := make(chan *ClientExportedUnit, len(samples))
+
+	e := &ClientExporter{
+		Client: &c,
+		OnUnit: func(u *ClientExportedUnit) {
+			units <- u
+		},
+	}
+	err = e.Initialize()
+	require.NoError(t, err)
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	type ts struct {
+		pts time.Duration
+		dts time.Duration
+	}
+
+	var received []ts
+
+	for i := 0; i < len(samples)-1; i++ {
+		u := <-units
+		require.Equal(t, sd.Medias[0], u.Media)
+		require.Equal(t, time.Time{}, u.NTP)
+
+		if i == 0 {
+			require.Equal(t, [][]byte{sps, pps, samples[1].nalu}, u.Data)
+		} else {
+			require.Equal(t, [][]byte{samples[i+1].nalu}, u.Data)
+		}
+
+		received = append(received, ts{u.PTS, u.DTS})
+	}
+
+	require.Equal(t, []ts{
+		{0, 0},
+		{33333333 * time.Nanosecond, 33333333 * time.Nanosecond},
+		{66666666 * time.Nanosecond, 66666666 * time.Nanosecond},
+		{100 * time.Millisecond, 100 * time.Millisecond},
+		{200 * time.Millisecond, 101 * time.Millisecond},
+		{166666666 * time.Nanosecond, 102 * time.Millisecond},
+		{133333333 * time.Nanosecond, 133333333 * time.Nanosecond},
+	}, received)
+
+	for i := range samples {
+		require.Equal(t, 1000+uint16(i), <-packets)
+	}
+}
+
+func TestClientExporterErrors(t *testing.T) {
+	e := &ClientExporter{Client: &Client{}}
+	err := e.Initialize()
+	require.EqualError(t, err, "OnUnit not provided")
+
+	e = &ClientExporter{
+		Client: &Client{},
+		OnUnit: func(*ClientExportedUnit) {},
+	}
+	err = e.Initialize()
+	require.EqualError(t, err, "no supported formats found")
+}
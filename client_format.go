@@ -1,73 +1,164 @@
 package gortsplib
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/metrics"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtcpreceiver"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtcpsender"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtplossdetector"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpred"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtpreorderer"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpretransmission"
 )
 
 type clientFormat struct {
-	cm              *clientMedia
-	format          format.Format
-	udpReorderer    *rtpreorderer.Reorderer       // play
-	tcpLossDetector *rtplossdetector.LossDetector // play
-	rtcpReceiver    *rtcpreceiver.RTCPReceiver    // play
-	rtcpSender      *rtcpsender.RTCPSender        // record or back channel
-	onPacketRTP     OnPacketRTPFunc
+	cm               *clientMedia
+	format           format.Format
+	clockRate        int
+	udpReorderer     *rtpreorderer.Reorderer       // play
+	udpReorderMutex  sync.Mutex                    // play
+	udpReorderTimer  clock.Timer                   // play
+	udpReorderStop   bool                          // play
+	tcpLossDetector  *rtplossdetector.LossDetector // play
+	rtcpReceiver     *rtcpreceiver.RTCPReceiver    // play
+	rtcpSender       *rtcpsender.RTCPSender        // record or back channel
+	initialSRSent    *int32                        // record or back channel
+	rtxAvailable     bool                          // play
+	rtxReceiver      *rtpretransmission.Receiver   // play
+	rtxTarget        *clientFormat                 // play
+	isFEC            bool                          // play
+	redDecoder       *rtpred.Decoder               // play
+	packetsReceived  metrics.Counter               // play
+	metricsLabels    metrics.Labels                // play
+	packetsLost      metrics.Counter               // play
+	jitter           metrics.Gauge                 // play
+	packetsSent      metrics.Counter               // record or back channel
+	playStartSeqNum  int32                         // play
+	quality          *receiverQuality              // play
+	recordContinuity *recordContinuity             // record
+	onPacketRTP      OnPacketRTPFunc
 }
 
 func newClientFormat(cm *clientMedia, forma format.Format) *clientFormat {
+	clockRate := forma.ClockRate()
+	if cm.c.ClockRateOverride != nil {
+		if v := cm.c.ClockRateOverride(cm.media, forma); v != 0 {
+			clockRate = v
+		}
+	}
+
 	return &clientFormat{
-		cm:          cm,
-		format:      forma,
-		onPacketRTP: func(*rtp.Packet) {},
+		cm:               cm,
+		format:           forma,
+		clockRate:        clockRate,
+		recordContinuity: &recordContinuity{clockRate: clockRate},
+		onPacketRTP:      func(*rtp.Packet) {},
 	}
 }
 
+// ClockRate implements rtptime.GlobalDecoderTrack.
+func (ct *clientFormat) ClockRate() int {
+	return ct.clockRate
+}
+
+// PTSEqualsDTS implements rtptime.GlobalDecoderTrack.
+func (ct *clientFormat) PTSEqualsDTS(pkt *rtp.Packet) bool {
+	return ct.format.PTSEqualsDTS(pkt)
+}
+
 func (ct *clientFormat) start() {
 	if ct.cm.c.state == clientStateRecord || ct.cm.media.IsBackChannel {
-		ct.rtcpSender = rtcpsender.New(
-			ct.format.ClockRate(),
+		ct.rtcpSender = rtcpsender.NewWithClock(
+			ct.clockRate,
 			ct.cm.c.senderReportPeriod,
-			ct.cm.c.timeNow,
+			ct.cm.c.Clock,
 			func(pkt rtcp.Packet) {
 				if !ct.cm.c.DisableRTCPSenderReports {
+					ct.cm.c.log.Debug("RTCP sender report sent", "media", ct.cm.media.Type, "payload_type", ct.format.PayloadType())
 					ct.cm.c.WritePacketRTCP(ct.cm.media, pkt) //nolint:errcheck
 				}
 			})
+		ct.initialSRSent = new(int32)
+		ct.packetsSent = ct.cm.c.metrics.packetsSent[*ct.cm.c.effectiveTransport]
 	} else {
+		atomic.StoreInt32(&ct.playStartSeqNum, 0)
+
+		path := ct.cm.c.baseURL.Path
+		ct.packetsReceived = ct.cm.c.metrics.packetsReceived[*ct.cm.c.effectiveTransport]
+		ct.metricsLabels = ct.cm.c.metrics.trackLabels(ct.cm.c.metricsSessionID, path, ct.cm.media, ct.format)
+		ct.packetsLost = ct.cm.c.metrics.packetsLost(ct.metricsLabels)
+		ct.jitter = ct.cm.c.metrics.jitter(ct.metricsLabels)
+		ct.quality = newReceiverQuality(ct.cm.media, ct.format, ct.clockRate)
+
+		if ct == ct.cm.redFormat {
+			ct.redDecoder = &rtpred.Decoder{}
+		}
+
 		if ct.cm.udpRTPListener != nil {
-			ct.udpReorderer = rtpreorderer.New()
+			ct.udpReorderStop = false
+
+			if r := ct.cm.c.PacketReordering; r != nil {
+				ct.udpReorderer = rtpreorderer.NewWithOptions(r.Size, r.Window)
+			} else {
+				ct.udpReorderer = rtpreorderer.New()
+			}
+
+			// missing packets are requested with NACKs only when the server
+			// is able to retransmit them.
+			if ct.rtxAvailable && !ct.cm.c.DisableRTCPNACKs {
+				ct.rtxReceiver = &rtpretransmission.Receiver{
+					PayloadType: ct.format.PayloadType(),
+				}
+			}
 		} else {
 			ct.tcpLossDetector = rtplossdetector.New()
 		}
 
 		var err error
-		ct.rtcpReceiver, err = rtcpreceiver.New(
-			ct.format.ClockRate(),
+		ct.rtcpReceiver, err = rtcpreceiver.NewWithClock(
+			ct.clockRate,
 			nil,
 			ct.cm.c.receiverReportPeriod,
-			ct.cm.c.timeNow,
+			ct.cm.c.Clock,
 			func(pkt rtcp.Packet) {
-				if ct.cm.udpRTPListener != nil {
+				if rr, ok := pkt.(*rtcp.ReceiverReport); ok && len(rr.Reports) != 0 {
+					ct.jitter.Set(float64(rr.Reports[0].Jitter) / float64(ct.clockRate))
+				}
+
+				// receiver reports are suppressed while the media is paused
+				if ct.cm.udpRTPListener != nil && atomic.LoadInt32(ct.cm.paused) == 0 {
+					ct.cm.c.log.Debug("RTCP receiver report sent", "media", ct.cm.media.Type, "payload_type", ct.format.PayloadType())
 					ct.cm.c.WritePacketRTCP(ct.cm.media, pkt) //nolint:errcheck
 				}
 			})
 		if err != nil {
 			panic(err)
 		}
+
+		if ct.cm.c.RTCPExtendedReportsEnable {
+			ct.rtcpReceiver.EnableExtendedReports()
+		}
 	}
 }
 
 func (ct *clientFormat) stop() {
+	ct.udpReorderMutex.Lock()
+	if ct.udpReorderTimer != nil {
+		ct.udpReorderTimer.Stop()
+		ct.udpReorderTimer = nil
+	}
+	ct.udpReorderStop = true
+	ct.udpReorderMutex.Unlock()
+
 	if ct.rtcpReceiver != nil {
 		ct.rtcpReceiver.Close()
 		ct.rtcpReceiver = nil
@@ -78,6 +169,12 @@ func (ct *clientFormat) stop() {
 	}
 }
 
+func (ct *clientFormat) deleteMetrics() {
+	if ct.metricsLabels != nil {
+		ct.cm.c.metrics.deleteTrack(ct.metricsLabels)
+	}
+}
+
 func (ct *clientFormat) writePacketRTP(byts []byte, pkt *rtp.Packet, ntp time.Time) error {
 	ct.rtcpSender.ProcessPacket(pkt, ntp, ct.format.PTSEqualsDTS(pkt))
 
@@ -88,17 +185,148 @@ func (ct *clientFormat) writePacketRTP(byts []byte, pkt *rtp.Packet, ntp time.Ti
 		return liberrors.ErrClientWriteQueueFull{}
 	}
 
+	ct.packetsSent.Add(1)
+
+	if ct.cm.c.InitialRTCPSenderReport && !ct.cm.c.DisableRTCPSenderReports &&
+		atomic.LoadInt32(ct.initialSRSent) == 0 {
+		// the report is available once a packet with PTS equal to DTS is processed
+		if sr := ct.rtcpSender.Report(); sr != nil && atomic.CompareAndSwapInt32(ct.initialSRSent, 0, 1) {
+			ct.cm.c.WritePacketRTCP(ct.cm.media, sr) //nolint:errcheck
+		}
+	}
+
 	return nil
 }
 
+// setPlayStartSequenceNumber sets the sequence number of the first packet
+// sent by the server after PLAY.
+func (ct *clientFormat) setPlayStartSequenceNumber(seqNum uint16) {
+	atomic.StoreInt32(&ct.playStartSeqNum, int32(seqNum)|(1<<16))
+}
+
+// precedesPlayStart checks whether a packet has been sent before
+// the one whose sequence number is in the RTP-Info header.
+func (ct *clientFormat) precedesPlayStart(pkt *rtp.Packet) bool {
+	v := atomic.LoadInt32(&ct.playStartSeqNum)
+	if v == 0 {
+		return false
+	}
+
+	if int16(pkt.SequenceNumber-uint16(v)) < 0 {
+		return true
+	}
+
+	// stop checking once the first packet has been received
+	atomic.StoreInt32(&ct.playStartSeqNum, 0)
+	return false
+}
+
 func (ct *clientFormat) readRTPUDP(pkt *rtp.Packet) {
-	packets, lost := ct.udpReorderer.Process(pkt)
+	if ct.rtxTarget != nil && ct.rtxTarget.rtxReceiver != nil {
+		var err error
+		pkt, err = ct.rtxTarget.rtxReceiver.Decode(pkt)
+		if err != nil {
+			ct.cm.c.OnDecodeError(err)
+			return
+		}
+
+		ct.rtxTarget.readRTPUDP(pkt)
+		return
+	}
+
+	// FEC packets are used to recover lost packets and are not delivered
+	if ct.isFEC {
+		ct.cm.readRecoveredRTPUDP(ct.cm.processFEC(ct, pkt))
+		return
+	}
+
+	// RED packets are replaced by the packets they contain
+	if ct.redDecoder != nil {
+		recovered := ct.cm.processFEC(ct, pkt)
+		ct.cm.readRED(ct, pkt, (*clientFormat).readRTPUDP)
+		ct.cm.readRecoveredRTPUDP(recovered)
+		return
+	}
+
+	if ct.precedesPlayStart(pkt) {
+		return
+	}
+
+	recovered := ct.cm.processFEC(ct, pkt)
+	if recovered != nil {
+		defer ct.cm.readRecoveredRTPUDP(recovered)
+	}
+
+	ct.quality.processArrival(pkt)
+
+	if ct.rtxReceiver != nil {
+		if missing := ct.rtxReceiver.ProcessPacket(pkt); missing != nil {
+			ct.cm.c.WritePacketRTCP(ct.cm.media, &rtcp.TransportLayerNack{ //nolint:errcheck
+				MediaSSRC: pkt.SSRC,
+				Nacks:     rtcp.NackPairsFromSequenceNumbers(missing),
+			})
+		}
+	}
+
+	now := ct.cm.c.timeNow()
+
+	ct.udpReorderMutex.Lock()
+	defer ct.udpReorderMutex.Unlock()
+
+	if ct.udpReorderStop {
+		return
+	}
+
+	packets, lost := ct.udpReorderer.ProcessAt(pkt, now)
+	if ct.cm.c.PacketBufferReuseEnable {
+		detachBufferedPacketRTP(pkt, packets)
+	}
+
+	ct.handleReorderedRTPUDP(packets, lost, now)
+	ct.scheduleReorderExpiry(now)
+}
+
+// expireReordered is called when the reordering window of a buffered packet expires.
+func (ct *clientFormat) expireReordered() {
+	ct.udpReorderMutex.Lock()
+	defer ct.udpReorderMutex.Unlock()
+
+	if ct.udpReorderStop {
+		return
+	}
+
+	now := ct.cm.c.timeNow()
+	packets, lost := ct.udpReorderer.Expire(now)
+
+	ct.handleReorderedRTPUDP(packets, lost, now)
+	ct.scheduleReorderExpiry(now)
+}
+
+func (ct *clientFormat) scheduleReorderExpiry(now time.Time) {
+	deadline, ok := ct.udpReorderer.Deadline()
+	if !ok {
+		return
+	}
+
+	if ct.udpReorderTimer == nil {
+		ct.udpReorderTimer = ct.cm.c.Clock.AfterFunc(deadline.Sub(now), ct.expireReordered)
+	} else {
+		ct.udpReorderTimer.Reset(deadline.Sub(now))
+	}
+}
+
+func (ct *clientFormat) handleReorderedRTPUDP(packets []*rtp.Packet, lost int, now time.Time) {
 	if lost != 0 {
+		ct.packetsLost.Add(uint64(lost))
+		ct.quality.processLost(lost)
 		ct.cm.c.OnPacketLost(liberrors.ErrClientRTPPacketsLost{Lost: lost})
 		// do not return
 	}
 
-	now := ct.cm.c.timeNow()
+	if len(packets) != 0 {
+		ct.cm.c.updateLastRTPTime(now)
+		ct.packetsReceived.Add(uint64(len(packets)))
+	}
 
 	for _, pkt := range packets {
 		err := ct.rtcpReceiver.ProcessPacket(pkt, now, ct.format.PTSEqualsDTS(pkt))
@@ -107,18 +335,39 @@ func (ct *clientFormat) readRTPUDP(pkt *rtp.Packet) {
 			continue
 		}
 
+		ct.quality.processPacket(pkt)
+		ct.cm.readRTPExtensions(pkt)
 		ct.onPacketRTP(pkt)
 	}
 }
 
 func (ct *clientFormat) readRTPTCP(pkt *rtp.Packet) {
+	if ct.isFEC {
+		return
+	}
+
+	if ct.redDecoder != nil {
+		ct.cm.readRED(ct, pkt, (*clientFormat).readRTPTCP)
+		return
+	}
+
+	if ct.precedesPlayStart(pkt) {
+		return
+	}
+
+	ct.quality.processArrival(pkt)
+
 	lost := ct.tcpLossDetector.Process(pkt)
 	if lost != 0 {
+		ct.packetsLost.Add(uint64(lost))
+		ct.quality.processLost(lost)
 		ct.cm.c.OnPacketLost(liberrors.ErrClientRTPPacketsLost{Lost: lost})
 		// do not return
 	}
 
 	now := ct.cm.c.timeNow()
+	ct.cm.c.updateLastRTPTime(now)
+	ct.packetsReceived.Add(1)
 
 	err := ct.rtcpReceiver.ProcessPacket(pkt, now, ct.format.PTSEqualsDTS(pkt))
 	if err != nil {
@@ -126,5 +375,7 @@ func (ct *clientFormat) readRTPTCP(pkt *rtp.Packet) {
 		return
 	}
 
+	ct.quality.processPacket(pkt)
+	ct.cm.readRTPExtensions(pkt)
 	ct.onPacketRTP(pkt)
 }
@@ -0,0 +1,124 @@
+package gortsplib
+
+import (
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// ClientKeepaliveMethod is the method of the requests that a Client sends
+// periodically in order to keep sessions alive.
+type ClientKeepaliveMethod int
+
+// keepalive methods.
+const (
+	// use GET_PARAMETER when the server advertises it, otherwise OPTIONS.
+	// When the server rejects keepalives, the other methods are tried,
+	// including SET_PARAMETER.
+	ClientKeepaliveMethodAuto ClientKeepaliveMethod = iota
+
+	// always use OPTIONS.
+	ClientKeepaliveMethodOptions
+
+	// always use GET_PARAMETER.
+	ClientKeepaliveMethodGetParameter
+
+	// always use SET_PARAMETER, without body.
+	ClientKeepaliveMethodSetParameter
+)
+
+// String implements fmt.Stringer.
+func (m ClientKeepaliveMethod) String() string {
+	switch m {
+	case ClientKeepaliveMethodOptions:
+		return string(base.Options)
+
+	case ClientKeepaliveMethodGetParameter:
+		return string(base.GetParameter)
+
+	case ClientKeepaliveMethodSetParameter:
+		return string(base.SetParameter)
+	}
+	return "auto"
+}
+
+// isKeepaliveRejected checks whether a status code means that
+// the server does not accept the method of a keepalive.
+func isKeepaliveRejected(code base.StatusCode) bool {
+	return code == base.StatusBadRequest ||
+		code == base.StatusMethodNotAllowed ||
+		code == base.StatusMethodNotValidInThisState ||
+		code == base.StatusNotImplemented
+}
+
+func (c *Client) keepaliveMethod() base.Method {
+	switch c.KeepaliveMethod {
+	case ClientKeepaliveMethodOptions:
+		return base.Options
+
+	case ClientKeepaliveMethodGetParameter:
+		return base.GetParameter
+
+	case ClientKeepaliveMethodSetParameter:
+		return base.SetParameter
+	}
+
+	candidates := []base.Method{base.Options, base.GetParameter, base.SetParameter}
+
+	// the VLC integrated rtsp server requires GET_PARAMETER
+	if c.useGetParameter {
+		candidates = []base.Method{base.GetParameter, base.Options, base.SetParameter}
+	}
+
+	for _, m := range candidates {
+		if _, ok := c.keepaliveRejected[m]; !ok {
+			return m
+		}
+	}
+
+	return candidates[0]
+}
+
+// checkKeepaliveResponse switches to another keepalive method
+// when the server rejects the current one.
+func (c *Client) checkKeepaliveResponse(cseqStr string, res *base.Response) {
+	if c.keepaliveCSeq == "" || c.KeepaliveMethod != ClientKeepaliveMethodAuto ||
+		cseqStr != c.keepaliveCSeq {
+		return
+	}
+
+	method := c.keepaliveLastMethod
+	c.keepaliveCSeq = ""
+
+	if !isKeepaliveRejected(res.StatusCode) {
+		return
+	}
+
+	if c.keepaliveRejected == nil {
+		c.keepaliveRejected = make(map[base.Method]struct{})
+	}
+	c.keepaliveRejected[method] = struct{}{}
+
+	c.log.Warn("keepalive rejected, switching method", "method", method,
+		"status", res.StatusCode, "next", c.keepaliveMethod())
+}
+
+// setKeepalivePeriod sets the keepalive period from the session timeout advertised by the server.
+func (c *Client) setKeepalivePeriod(timeout uint) {
+	if c.KeepalivePeriod != 0 {
+		return
+	}
+
+	period := time.Duration(timeout) * time.Second * 8 / 10
+	if period == c.keepalivePeriod {
+		return
+	}
+
+	c.keepalivePeriod = period
+
+	// apply the new period immediately, since the server may have shortened the timeout
+	if c.state == clientStatePlay && c.stdChannelSetupped {
+		c.keepaliveTimer.Stop()
+		c.keepaliveTimer = c.Clock.NewTimer(c.keepalivePeriod)
+	}
+}
@@ -2,6 +2,7 @@ package gortsplib
 
 import (
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -10,12 +11,19 @@ import (
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/multicast"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpextension"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpfec"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpred"
 )
 
 type clientMedia struct {
 	c                      *Client
 	media                  *description.Media
+	url                    *base.URL
 	formats                map[uint8]*clientFormat
 	tcpChannel             int
 	udpRTPListener         *clientUDPListener
@@ -26,12 +34,30 @@ type clientMedia struct {
 	writePacketRTPInQueue  func([]byte)
 	writePacketRTCPInQueue func([]byte)
 	onPacketRTCP           OnPacketRTCPFunc
+	onPacketRTCPRaw        OnPacketRTCPRawFunc
+	onPacketRTPExtensions  map[uint8]OnPacketRTPExtensionFunc
+	recordRTPInfo          *headers.RTPInfoEntry
+	playRTPInfo            *headers.RTPInfoEntry
+	srtp                   *mediaSRTP
+	congestionFeedback     *congestionFeedbackGenerator // play
+	paused                 *int32                       // play
+	fecAvailable           bool                         // play
+	fecDecoder             *rtpfec.Decoder              // play
+	fecMutex               sync.Mutex                   // play
+	redFormat              *clientFormat
+	redTarget              *clientFormat
+	redEncoder             *rtpred.Encoder // record or back channel
+	redMutex               sync.Mutex      // record or back channel
+	roundTripTime          *int64          // record
 }
 
 func newClientMedia(c *Client) *clientMedia {
 	return &clientMedia{
-		c:            c,
-		onPacketRTCP: func(rtcp.Packet) {},
+		c:               c,
+		onPacketRTCP:    func(rtcp.Packet) {},
+		onPacketRTCPRaw: func([]byte) {},
+		paused:          new(int32),
+		roundTripTime:   new(int64),
 	}
 }
 
@@ -39,12 +65,113 @@ func (cm *clientMedia) close() {
 	if cm.udpRTPListener != nil {
 		cm.udpRTPListener.close()
 		cm.udpRTCPListener.close()
+
+		if cm.udpRTPListener.pooled {
+			cm.c.UDPSocketPool.release(cm.udpRTPListener.pc, cm.udpRTCPListener.pc)
+		}
+	}
+
+	for _, ct := range cm.formats {
+		ct.deleteMetrics()
+	}
+}
+
+func (cm *clientMedia) setPacketRTPExtensionCallback(uri string, cb OnPacketRTPExtensionFunc) {
+	id, ok := rtpextension.ID(cm.media, uri)
+	if !ok {
+		return
+	}
+
+	if cm.onPacketRTPExtensions == nil {
+		cm.onPacketRTPExtensions = make(map[uint8]OnPacketRTPExtensionFunc)
+	}
+	cm.onPacketRTPExtensions[id] = cb
+}
+
+func (cm *clientMedia) readRTPExtensions(pkt *rtp.Packet) {
+	for id, cb := range cm.onPacketRTPExtensions {
+		if payload := pkt.Header.GetExtension(id); payload != nil {
+			cb(pkt, payload)
+		}
+	}
+}
+
+// processFEC processes a received packet with the FEC decoder,
+// and returns packets that have been recovered.
+func (cm *clientMedia) processFEC(ct *clientFormat, pkt *rtp.Packet) []*rtp.Packet {
+	if cm.fecDecoder == nil {
+		return nil
+	}
+
+	cm.fecMutex.Lock()
+	defer cm.fecMutex.Unlock()
+
+	var recovered []*rtp.Packet
+	var err error
+
+	if ct.isFEC {
+		recovered, err = cm.fecDecoder.ProcessFEC(pkt)
+	} else {
+		recovered, err = cm.fecDecoder.ProcessPacket(pkt)
+	}
+
+	if err != nil {
+		cm.c.OnDecodeError(err)
+		return nil
+	}
+
+	return recovered
+}
+
+// readRecoveredRTPUDP delivers packets that have been recovered by the FEC decoder.
+func (cm *clientMedia) readRecoveredRTPUDP(packets []*rtp.Packet) {
+	for _, pkt := range packets {
+		ct, ok := cm.formats[pkt.PayloadType]
+		if !ok || ct.isFEC || ct.rtxTarget != nil {
+			continue
+		}
+
+		ct.readRTPUDP(pkt)
 	}
 }
 
+// encodeRED encodes an outgoing packet of the primary format of RED into a RED packet.
+func (cm *clientMedia) encodeRED(pkt *rtp.Packet) *rtp.Packet {
+	cm.redMutex.Lock()
+	defer cm.redMutex.Unlock()
+
+	return cm.redEncoder.Encode(pkt)
+}
+
+// readRED extracts packets from a RED packet and passes them to their formats.
+func (cm *clientMedia) readRED(ct *clientFormat, pkt *rtp.Packet, read func(*clientFormat, *rtp.Packet)) {
+	packets, err := ct.redDecoder.Decode(pkt)
+	if err != nil {
+		cm.c.OnDecodeError(err)
+		return
+	}
+
+	for _, pkt := range packets {
+		target, ok := cm.formats[pkt.PayloadType]
+		if !ok || target == ct {
+			cm.c.OnDecodeError(liberrors.ErrClientRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+			continue
+		}
+
+		read(target, pkt)
+	}
+}
+
+func isSeparateGroup(rtpAddress string, rtcpAddress string) bool {
+	rtpHost, _, _ := net.SplitHostPort(rtpAddress)
+	rtcpHost, _, _ := net.SplitHostPort(rtcpAddress)
+	return rtpHost != rtcpHost
+}
+
 func (cm *clientMedia) allocateUDPListeners(
 	multicastEnable bool,
 	multicastSourceIP net.IP,
+	multicastOpts multicast.SingleConnOptions,
 	rtpAddress string,
 	rtcpAddress string,
 ) error {
@@ -53,6 +180,7 @@ func (cm *clientMedia) allocateUDPListeners(
 			cm.c,
 			multicastEnable,
 			multicastSourceIP,
+			multicastOpts,
 			rtpAddress,
 		)
 		if err != nil {
@@ -63,11 +191,30 @@ func (cm *clientMedia) allocateUDPListeners(
 			cm.c,
 			multicastEnable,
 			multicastSourceIP,
+			multicastOpts,
 			rtcpAddress,
 		)
 		if err != nil {
-			l1.close()
-			return err
+			if !multicastEnable || !isSeparateGroup(rtpAddress, rtcpAddress) {
+				l1.close()
+				return err
+			}
+
+			// RTCP packets can still be sent to the group without joining it.
+			cm.c.OnWarning(liberrors.ErrClientRTCPMulticastJoin{Err: err})
+
+			_, port, _ := net.SplitHostPort(rtcpAddress)
+			l2, err = newClientUDPListener(
+				cm.c,
+				false,
+				nil,
+				multicast.SingleConnOptions{},
+				net.JoinHostPort("", port),
+			)
+			if err != nil {
+				l1.close()
+				return err
+			}
 		}
 
 		cm.udpRTPListener, cm.udpRTCPListener = l1, l2
@@ -79,6 +226,15 @@ func (cm *clientMedia) allocateUDPListeners(
 	return err
 }
 
+func (cm *clientMedia) applyUDPSocketOptions(medi *description.Media) error {
+	err := cm.c.UDPSocketOptions.apply(cm.udpRTPListener.pc, medi)
+	if err != nil {
+		return err
+	}
+
+	return cm.c.UDPSocketOptions.apply(cm.udpRTCPListener.pc, medi)
+}
+
 func (cm *clientMedia) setMedia(medi *description.Media) {
 	cm.media = medi
 
@@ -86,6 +242,27 @@ func (cm *clientMedia) setMedia(medi *description.Media) {
 	for _, forma := range medi.Formats {
 		cm.formats[forma.PayloadType()] = newClientFormat(cm, forma)
 	}
+
+	for _, ct := range cm.formats {
+		if rtx, ok := ct.format.(*format.RTX); ok {
+			if target, ok := cm.formats[rtx.APT]; ok {
+				ct.rtxTarget = target
+				target.rtxAvailable = true
+			}
+		}
+
+		if _, ok := ct.format.(*format.FlexFEC); ok {
+			ct.isFEC = true
+			cm.fecAvailable = true
+		}
+
+		if red, ok := ct.format.(*format.RED); ok && cm.redFormat == nil && len(red.PayloadTypes) != 0 {
+			if target, ok := cm.formats[red.PayloadTypes[0]]; ok && target != ct {
+				cm.redFormat = ct
+				cm.redTarget = target
+			}
+		}
+	}
 }
 
 func (cm *clientMedia) start() {
@@ -121,6 +298,38 @@ func (cm *clientMedia) start() {
 		cm.tcpBuffer = make([]byte, cm.c.MaxPacketSize+4)
 	}
 
+	if cm.srtp != nil {
+		cm.startSRTP()
+	}
+
+	cm.startImpairment()
+
+	if cm.c.RTCPCongestionFeedbackEnable && cm.c.state != clientStateRecord && !cm.media.IsBackChannel {
+		cm.congestionFeedback = newCongestionFeedbackGenerator(cm.media)
+	}
+
+	if cm.redFormat != nil && (cm.c.state == clientStateRecord || cm.media.IsBackChannel) {
+		cm.redEncoder = &rtpred.Encoder{
+			PayloadType: cm.redFormat.format.PayloadType(),
+			Depth:       cm.c.REDDepth,
+		}
+		err := cm.redEncoder.Init()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// lost packets are recovered only when reading with UDP,
+	// since TCP doesn't lose packets.
+	if cm.fecAvailable && !cm.c.DisableFECRecovery && cm.udpRTPListener != nil &&
+		cm.c.state != clientStateRecord && !cm.media.IsBackChannel {
+		cm.fecDecoder = &rtpfec.Decoder{}
+		err := cm.fecDecoder.Init()
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	for _, ct := range cm.formats {
 		ct.start()
 	}
@@ -131,12 +340,51 @@ func (cm *clientMedia) start() {
 	}
 }
 
+// wrap read and write functions in order to decrypt and encrypt packets.
+func (cm *clientMedia) startSRTP() {
+	cm.writePacketRTPInQueue = cm.srtp.encryptRTP(cm.writePacketRTPInQueue)
+	cm.writePacketRTCPInQueue = cm.srtp.encryptRTCP(cm.writePacketRTCPInQueue)
+
+	// RTP packets are read only when playing
+	readRTP := cm.c.state != clientStateRecord && !cm.media.IsBackChannel
+
+	if cm.udpRTPListener != nil {
+		if readRTP {
+			cm.udpRTPListener.readFunc = cm.srtp.decryptRTP(cm.udpRTPListener.readFunc, cm.c.OnDecodeError)
+		}
+		cm.udpRTCPListener.readFunc = cm.srtp.decryptRTCP(cm.udpRTCPListener.readFunc, cm.c.OnDecodeError)
+	} else {
+		if readRTP {
+			cm.c.tcpCallbackByChannel[cm.tcpChannel] = cm.srtp.decryptRTP(
+				cm.c.tcpCallbackByChannel[cm.tcpChannel], cm.c.OnDecodeError)
+		}
+		cm.c.tcpCallbackByChannel[cm.tcpChannel+1] = cm.srtp.decryptRTCP(
+			cm.c.tcpCallbackByChannel[cm.tcpChannel+1], cm.c.OnDecodeError)
+	}
+}
+
+// wrap read functions in order to apply impairments to incoming packets.
+// Packets are impaired before being decrypted, as it happens in the network.
+func (cm *clientMedia) startImpairment() {
+	if cm.udpRTPListener != nil {
+		cm.udpRTPListener.readFunc = impairRead(cm.c.impairer, cm.udpRTPListener.readFunc)
+		cm.udpRTCPListener.readFunc = impairRead(cm.c.impairer, cm.udpRTCPListener.readFunc)
+	} else {
+		cm.c.tcpCallbackByChannel[cm.tcpChannel] = impairRead(cm.c.impairer, cm.c.tcpCallbackByChannel[cm.tcpChannel])
+		cm.c.tcpCallbackByChannel[cm.tcpChannel+1] = impairRead(cm.c.impairer,
+			cm.c.tcpCallbackByChannel[cm.tcpChannel+1])
+	}
+}
+
 func (cm *clientMedia) stop() {
 	if cm.udpRTPListener != nil {
 		cm.udpRTPListener.stop()
 		cm.udpRTCPListener.stop()
 	}
 
+	// discard delayed packets before stopping formats
+	cm.c.impairer.Reset()
+
 	for _, ct := range cm.formats {
 		ct.stop()
 	}
@@ -152,13 +400,89 @@ func (cm *clientMedia) findFormatWithSSRC(ssrc uint32) *clientFormat {
 	return nil
 }
 
+// processSourceDescription routes chunks of RTCP source descriptions to receivers of formats.
+func (cm *clientMedia) processSourceDescription(sdes *rtcp.SourceDescription) {
+	for i := range sdes.Chunks {
+		format := cm.findFormatWithSSRC(sdes.Chunks[i].Source)
+		if format != nil {
+			format.rtcpReceiver.ProcessSourceDescription(&sdes.Chunks[i])
+		}
+	}
+}
+
+// processGoodbye signals the end of the stream, once per PLAY request.
+func (cm *clientMedia) processGoodbye(bye *rtcp.Goodbye) {
+	if atomic.CompareAndSwapInt32(cm.c.streamEnded, 0, 1) {
+		cm.c.OnStreamEnded(bye.Reason)
+	}
+}
+
+// writeGoodbye sends a RTCP BYE packet that contains the SSRCs of sent formats.
+// It must be called after the writer has been stopped.
+func (cm *clientMedia) writeGoodbye() {
+	var sources []uint32
+
+	for _, ct := range cm.formats {
+		if ct.rtcpSender != nil {
+			if ssrc, ok := ct.rtcpSender.SenderSSRC(); ok {
+				sources = append(sources, ssrc)
+			}
+		}
+	}
+
+	if sources == nil {
+		return
+	}
+
+	byts, err := (&rtcp.Goodbye{
+		Sources: sources,
+	}).Marshal()
+	if err != nil {
+		return
+	}
+
+	cm.writePacketRTCPInQueue(byts)
+}
+
+// processReceptionReports computes the round-trip time from reception reports
+// that refer to the formats being written.
+func (cm *clientMedia) processReceptionReports(reports []rtcp.ReceptionReport, now time.Time) {
+	for i := range reports {
+		for _, format := range cm.formats {
+			if format.rtcpSender == nil {
+				continue
+			}
+
+			if rtt, ok := format.rtcpSender.ProcessReceptionReport(&reports[i], now); ok {
+				atomic.StoreInt64(cm.roundTripTime, int64(rtt))
+			}
+		}
+	}
+}
+
+// processExtendedReport routes RTCP extended reports to receivers or senders of formats.
+func (cm *clientMedia) processExtendedReport(xr *rtcp.ExtendedReport, now time.Time) {
+	for _, format := range cm.formats {
+		if format.rtcpReceiver != nil {
+			format.rtcpReceiver.ProcessExtendedReport(xr, now)
+		}
+		if format.rtcpSender != nil {
+			format.rtcpSender.ProcessExtendedReport(xr, now)
+		}
+	}
+}
+
 func (cm *clientMedia) writePacketRTPInQueueUDP(payload []byte) {
 	atomic.AddUint64(cm.c.BytesSent, uint64(len(payload)))
+	cm.c.events.bytesSent(EventSource{Client: cm.c}, len(payload))
+	cm.c.metrics.bytesSent[*cm.c.effectiveTransport].Add(uint64(len(payload)))
 	cm.udpRTPListener.write(payload) //nolint:errcheck
 }
 
 func (cm *clientMedia) writePacketRTCPInQueueUDP(payload []byte) {
 	atomic.AddUint64(cm.c.BytesSent, uint64(len(payload)))
+	cm.c.events.bytesSent(EventSource{Client: cm.c}, len(payload))
+	cm.c.metrics.bytesSent[*cm.c.effectiveTransport].Add(uint64(len(payload)))
 	cm.udpRTCPListener.write(payload) //nolint:errcheck
 }
 
@@ -187,6 +511,16 @@ func (cm *clientMedia) writePacketRTCP(byts []byte) error {
 	return nil
 }
 
+func (cm *clientMedia) processCongestionFeedback(pkt *rtp.Packet, size int, now time.Time) {
+	if cm.congestionFeedback == nil {
+		return
+	}
+
+	for _, fb := range cm.congestionFeedback.processPacket(pkt, size, now) {
+		cm.c.WritePacketRTCP(cm.media, fb) //nolint:errcheck
+	}
+}
+
 func (cm *clientMedia) readRTPTCPPlay(payload []byte) {
 	now := cm.c.timeNow()
 	atomic.StoreInt64(cm.c.tcpLastFrameTime, now.Unix())
@@ -204,6 +538,8 @@ func (cm *clientMedia) readRTPTCPPlay(payload []byte) {
 		return
 	}
 
+	cm.processCongestionFeedback(pkt, len(payload), now)
+
 	forma.readRTPTCP(pkt)
 }
 
@@ -216,6 +552,12 @@ func (cm *clientMedia) readRTCPTCPPlay(payload []byte) {
 		return
 	}
 
+	if cm.c.quirks != nil && cm.c.quirks.IgnoreRTCP {
+		return
+	}
+
+	cm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		cm.c.OnDecodeError(err)
@@ -230,6 +572,18 @@ func (cm *clientMedia) readRTCPTCPPlay(payload []byte) {
 			}
 		}
 
+		if xr, ok := pkt.(*rtcp.ExtendedReport); ok {
+			cm.processExtendedReport(xr, now)
+		}
+
+		if sdes, ok := pkt.(*rtcp.SourceDescription); ok {
+			cm.processSourceDescription(sdes)
+		}
+
+		if bye, ok := pkt.(*rtcp.Goodbye); ok {
+			cm.processGoodbye(bye)
+		}
+
 		cm.onPacketRTCP(pkt)
 	}
 }
@@ -243,6 +597,12 @@ func (cm *clientMedia) readRTCPTCPRecord(payload []byte) {
 		return
 	}
 
+	if cm.c.quirks != nil && cm.c.quirks.IgnoreRTCP {
+		return
+	}
+
+	cm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		cm.c.OnDecodeError(err)
@@ -250,6 +610,22 @@ func (cm *clientMedia) readRTCPTCPRecord(payload []byte) {
 	}
 
 	for _, pkt := range packets {
+		if bitrate, ok := bandwidthEstimate(pkt); ok {
+			cm.c.serverBandwidthEstimate(cm.media, bitrate)
+		}
+
+		if isKeyframeRequest(pkt) {
+			cm.c.serverKeyframeRequest(cm.media)
+		}
+
+		if xr, ok := pkt.(*rtcp.ExtendedReport); ok {
+			cm.processExtendedReport(xr, cm.c.timeNow())
+		}
+
+		if rr, ok := pkt.(*rtcp.ReceiverReport); ok {
+			cm.processReceptionReports(rr.Reports, cm.c.timeNow())
+		}
+
 		cm.onPacketRTCP(pkt)
 	}
 }
@@ -258,6 +634,8 @@ func (cm *clientMedia) readRTPUDPPlay(payload []byte) {
 	plen := len(payload)
 
 	atomic.AddUint64(cm.c.BytesReceived, uint64(plen))
+	cm.c.events.bytesReceived(EventSource{Client: cm.c}, plen)
+	cm.c.metrics.bytesReceived[*cm.c.effectiveTransport].Add(uint64(plen))
 
 	if plen == (udpMaxPayloadSize + 1) {
 		cm.c.OnDecodeError(liberrors.ErrClientRTPPacketTooBigUDP{})
@@ -277,6 +655,8 @@ func (cm *clientMedia) readRTPUDPPlay(payload []byte) {
 		return
 	}
 
+	cm.processCongestionFeedback(pkt, plen, cm.c.timeNow())
+
 	forma.readRTPUDP(pkt)
 }
 
@@ -285,12 +665,20 @@ func (cm *clientMedia) readRTCPUDPPlay(payload []byte) {
 	plen := len(payload)
 
 	atomic.AddUint64(cm.c.BytesReceived, uint64(plen))
+	cm.c.events.bytesReceived(EventSource{Client: cm.c}, plen)
+	cm.c.metrics.bytesReceived[*cm.c.effectiveTransport].Add(uint64(plen))
 
 	if plen == (udpMaxPayloadSize + 1) {
 		cm.c.OnDecodeError(liberrors.ErrClientRTCPPacketTooBigUDP{})
 		return
 	}
 
+	if cm.c.quirks != nil && cm.c.quirks.IgnoreRTCP {
+		return
+	}
+
+	cm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		cm.c.OnDecodeError(err)
@@ -305,6 +693,18 @@ func (cm *clientMedia) readRTCPUDPPlay(payload []byte) {
 			}
 		}
 
+		if xr, ok := pkt.(*rtcp.ExtendedReport); ok {
+			cm.processExtendedReport(xr, now)
+		}
+
+		if sdes, ok := pkt.(*rtcp.SourceDescription); ok {
+			cm.processSourceDescription(sdes)
+		}
+
+		if bye, ok := pkt.(*rtcp.Goodbye); ok {
+			cm.processGoodbye(bye)
+		}
+
 		cm.onPacketRTCP(pkt)
 	}
 }
@@ -316,12 +716,20 @@ func (cm *clientMedia) readRTCPUDPRecord(payload []byte) {
 	plen := len(payload)
 
 	atomic.AddUint64(cm.c.BytesReceived, uint64(plen))
+	cm.c.events.bytesReceived(EventSource{Client: cm.c}, plen)
+	cm.c.metrics.bytesReceived[*cm.c.effectiveTransport].Add(uint64(plen))
 
 	if plen == (udpMaxPayloadSize + 1) {
 		cm.c.OnDecodeError(liberrors.ErrClientRTCPPacketTooBigUDP{})
 		return
 	}
 
+	if cm.c.quirks != nil && cm.c.quirks.IgnoreRTCP {
+		return
+	}
+
+	cm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		cm.c.OnDecodeError(err)
@@ -329,6 +737,22 @@ func (cm *clientMedia) readRTCPUDPRecord(payload []byte) {
 	}
 
 	for _, pkt := range packets {
+		if bitrate, ok := bandwidthEstimate(pkt); ok {
+			cm.c.serverBandwidthEstimate(cm.media, bitrate)
+		}
+
+		if isKeyframeRequest(pkt) {
+			cm.c.serverKeyframeRequest(cm.media)
+		}
+
+		if xr, ok := pkt.(*rtcp.ExtendedReport); ok {
+			cm.processExtendedReport(xr, cm.c.timeNow())
+		}
+
+		if rr, ok := pkt.(*rtcp.ReceiverReport); ok {
+			cm.processReceptionReports(rr.Reports, cm.c.timeNow())
+		}
+
 		cm.onPacketRTCP(pkt)
 	}
 }
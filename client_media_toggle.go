@@ -0,0 +1,179 @@
+package gortsplib
+
+import (
+	"context"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+type teardownMediaReq struct {
+	ctx   context.Context
+	media *description.Media
+	res   chan clientRes
+}
+
+func (c *Client) doAddMedia(
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+) (*base.Response, error) {
+	if c.state != clientStatePlay {
+		return c.doSetup(baseURL, medi, rtpPort, rtcpPort)
+	}
+
+	// playback is resumed from the position reached before the pause
+	ra := c.currentRange()
+
+	_, err := c.doPause()
+	if err != nil {
+		return nil, err
+	}
+
+	res, setupErr := c.doSetup(baseURL, medi, rtpPort, rtcpPort)
+
+	_, err = c.doPlay(ra)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, setupErr
+}
+
+func (c *Client) doTeardownMedia(medi *description.Media) (*base.Response, error) {
+	err := c.checkState(map[clientState]struct{}{
+		clientStatePrePlay: {},
+		clientStatePlay:    {},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cm, ok := c.medias[medi]
+	if !ok {
+		return nil, liberrors.ErrClientMediaNotSetup{}
+	}
+
+	// tearing down the last media would destroy the session
+	if len(c.medias) == 1 {
+		return nil, liberrors.ErrClientCannotTeardownLastMedia{}
+	}
+
+	res, err := c.do(&base.Request{
+		Method: base.Teardown,
+		URL:    cm.url,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, liberrors.ErrClientBadStatusCode{
+			Code: res.StatusCode, Message: res.StatusMessage, Response: res,
+		}
+	}
+
+	// read routines are restarted in order to release the media
+	// while the other ones keep playing.
+	playing := (c.state == clientStatePlay)
+	if playing {
+		c.stopWriter()
+		c.stopReadRoutines()
+	}
+
+	cm.close()
+	delete(c.medias, medi)
+
+	if c.tcpCallbackByChannel != nil {
+		delete(c.tcpCallbackByChannel, cm.tcpChannel)
+		delete(c.tcpCallbackByChannel, cm.tcpChannel+1)
+	}
+
+	c.backChannelSetupped = false
+	c.stdChannelSetupped = false
+	for _, cm := range c.medias {
+		if cm.media.IsBackChannel {
+			c.backChannelSetupped = true
+		} else {
+			c.stdChannelSetupped = true
+		}
+	}
+
+	if playing {
+		c.startReadRoutines()
+		c.startWriter()
+	}
+
+	return res, nil
+}
+
+// AddMedia sets up a media after Play() has been called, in order to start
+// receiving it together with the medias that are already playing.
+// The session is paused, the media is set up and the session is played again
+// from the position reached before the pause.
+// If the media can't be set up, the session is played again anyway.
+// It can be called also before Play(), and in that case it is equal to Setup().
+// It can't be used when recording.
+func (c *Client) AddMedia(
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+) (*base.Response, error) {
+	return c.AddMediaContext(context.Background(), baseURL, medi, rtpPort, rtcpPort)
+}
+
+// AddMediaContext is like AddMedia(), but the requests can be canceled with a context.
+func (c *Client) AddMediaContext(
+	ctx context.Context,
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+) (*base.Response, error) {
+	cres := make(chan clientRes)
+	select {
+	case c.chAddMedia <- setupReq{
+		ctx:      ctx,
+		baseURL:  baseURL,
+		media:    medi,
+		rtpPort:  rtpPort,
+		rtcpPort: rtcpPort,
+		res:      cres,
+	}:
+		res := <-cres
+		return res.res, res.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-c.done:
+		return nil, c.closeError
+	}
+}
+
+// RemoveMedia sends a TEARDOWN request that refers to a single media,
+// in order to stop receiving it without destroying the session.
+// The other medias keep playing. The last media of a session can't be removed.
+// This can be called only after Setup() or Play(), against servers that support aggregate control.
+func (c *Client) RemoveMedia(medi *description.Media) (*base.Response, error) {
+	return c.RemoveMediaContext(context.Background(), medi)
+}
+
+// RemoveMediaContext is like RemoveMedia(), but the request can be canceled with a context.
+func (c *Client) RemoveMediaContext(ctx context.Context, medi *description.Media) (*base.Response, error) {
+	cres := make(chan clientRes)
+	select {
+	case c.chTeardownMedia <- teardownMediaReq{ctx: ctx, media: medi, res: cres}:
+		res := <-cres
+		return res.res, res.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-c.done:
+		return nil, c.closeError
+	}
+}
@@ -0,0 +1,37 @@
+package gortsplib
+
+import (
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/onvifreplay"
+)
+
+// ClientONVIFReplay contains the parameters of the ONVIF replay extension.
+// Specification: ONVIF Streaming Specification, section 6
+type ClientONVIFReplay struct {
+	// disable rate control: the server sends data as fast as possible (Rate-Control: no).
+	DisableRateControl bool
+
+	// ask the server to discard buffered data and to start
+	// sending data from the new position immediately (Immediate: yes).
+	Immediate bool
+
+	// frames to send (Frames header), for instance "intra", "intra/1000" or "predicted" (optional).
+	// It defaults to all frames.
+	Frames string
+}
+
+func (r *ClientONVIFReplay) fillHeader(header base.Header) {
+	header["Require"] = append(header["Require"], onvifreplay.RequireTag)
+
+	if r.DisableRateControl {
+		header["Rate-Control"] = base.HeaderValue{"no"}
+	}
+
+	if r.Immediate {
+		header["Immediate"] = base.HeaderValue{"yes"}
+	}
+
+	if r.Frames != "" {
+		header["Frames"] = base.HeaderValue{r.Frames}
+	}
+}
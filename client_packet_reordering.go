@@ -0,0 +1,18 @@
+package gortsplib
+
+import (
+	"time"
+)
+
+// ClientPacketReordering configures the reordering of incoming UDP packets.
+type ClientPacketReordering struct {
+	// maximum time a packet waits for the packets that precede it.
+	// After this time, missing packets are considered lost.
+	// It defaults to zero, that means that packets wait until the buffer is full.
+	Window time.Duration
+
+	// maximum number of buffered packets.
+	// It is rounded up to the next power of two.
+	// It defaults to 64.
+	Size int
+}
@@ -0,0 +1,288 @@
+package gortsplib
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// ClientResponseFuture is the response to a request sent with Client.SendRequest,
+// that becomes available when the server replies.
+type ClientResponseFuture struct {
+	done  chan struct{}
+	once  sync.Once
+	timer clock.Timer
+	res   *base.Response
+	err   error
+}
+
+func newClientResponseFuture(clk clock.Clock, timeout time.Duration) *ClientResponseFuture {
+	f := &ClientResponseFuture{
+		done: make(chan struct{}),
+	}
+	f.timer = clk.AfterFunc(timeout, func() {
+		f.complete(nil, liberrors.ErrClientRequestTimedOut{})
+	})
+	return f
+}
+
+func (f *ClientResponseFuture) complete(res *base.Response, err error) {
+	f.once.Do(func() {
+		f.timer.Stop()
+		f.res = res
+		f.err = err
+		close(f.done)
+	})
+}
+
+// Done returns a channel that is closed when the response is available.
+func (f *ClientResponseFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the response.
+func (f *ClientResponseFuture) Wait() (*base.Response, error) {
+	<-f.done
+	return f.res, f.err
+}
+
+type clientPendingRequest struct {
+	req    *base.Request
+	future *ClientResponseFuture
+}
+
+type sendRequestReq struct {
+	req *base.Request
+	res chan sendRequestRes
+}
+
+type sendRequestRes struct {
+	future *ClientResponseFuture
+	err    error
+}
+
+type setupAllReq struct {
+	ctx     context.Context
+	baseURL *base.URL
+	medias  []*description.Media
+	res     chan clientRes
+}
+
+// SendRequest sends a request without waiting for the response, that is returned through a future.
+// This allows to send multiple requests in a row (pipelining), reducing latency
+// over links with a high round-trip time. Responses can be received in any order,
+// since they are associated with requests by CSeq.
+// Requests that change the state of the session (SETUP, PLAY, RECORD, PAUSE, TEARDOWN, ANNOUNCE)
+// can't be sent in this way. Middlewares are not applied and authentication is not retried.
+func (c *Client) SendRequest(req *base.Request) (*ClientResponseFuture, error) {
+	cres := make(chan sendRequestRes)
+	select {
+	case c.chSendRequest <- sendRequestReq{req: req, res: cres}:
+		res := <-cres
+		return res.future, res.err
+
+	case <-c.done:
+		return nil, c.closeError
+	}
+}
+
+func (c *Client) doSendRequest(req *base.Request) (*ClientResponseFuture, error) {
+	switch req.Method {
+	case base.Setup, base.Play, base.Record, base.Pause, base.Teardown, base.Announce:
+		return nil, liberrors.ErrClientMethodNotPipelinable{Method: req.Method}
+	}
+
+	err := c.connOpen(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.optionsSent && req.Method != base.Options {
+		_, err = c.doOptions(req.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.sendRequestAsync(req, c.ReadTimeout)
+}
+
+// sendRequestAsync writes a request and registers it among the pending ones.
+func (c *Client) sendRequestAsync(req *base.Request, timeout time.Duration) (*ClientResponseFuture, error) {
+	cseqStr := c.prepareRequest(req)
+
+	c.OnRequest(req)
+
+	c.nconn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+	err := c.conn.WriteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.events.requestSent(EventSource{Client: c}, req)
+	c.trackRequest(cseqStr)
+
+	f := newClientResponseFuture(c.Clock, timeout)
+
+	if c.pendingRequests == nil {
+		c.pendingRequests = make(map[string]*clientPendingRequest)
+	}
+	c.pendingRequests[cseqStr] = &clientPendingRequest{
+		req:    req,
+		future: f,
+	}
+
+	return f, nil
+}
+
+// dispatchResponse checks responses to keepalives and delivers
+// a response to the pending request with the given CSeq.
+func (c *Client) dispatchResponse(cseqStr string, res *base.Response) {
+	c.checkKeepaliveResponse(cseqStr, res)
+
+	pr, ok := c.pendingRequests[cseqStr]
+	if !ok {
+		return
+	}
+
+	delete(c.pendingRequests, cseqStr)
+
+	err := c.processResponse(pr.req, res)
+	if err != nil {
+		pr.future.complete(nil, err)
+		return
+	}
+
+	pr.future.complete(res, nil)
+}
+
+// failPendingRequests completes pending requests with an error.
+func (c *Client) failPendingRequests(err error) {
+	for _, pr := range c.pendingRequests {
+		pr.future.complete(nil, err)
+	}
+	c.pendingRequests = nil
+}
+
+// waitFuture processes incoming responses and requests until a future is completed.
+func (c *Client) waitFuture(f *ClientResponseFuture) error {
+	for {
+		select {
+		case <-f.done:
+			return nil
+
+		case err := <-c.chReadError:
+			c.reader = nil
+			return err
+
+		case <-c.requestDone():
+			return c.requestCtx.Err()
+
+		case res := <-c.chReadResponse:
+			c.OnResponse(res)
+			c.events.responseReceived(EventSource{Client: c}, res)
+			if cseqStr, ok := c.correlateResponse(res, ""); ok {
+				c.dispatchResponse(cseqStr, res)
+			}
+
+		case req := <-c.chReadRequest:
+			err := c.handleServerRequest(req)
+			if err != nil {
+				return err
+			}
+
+		case <-c.ctx.Done():
+			return liberrors.ErrClientTerminated{}
+		}
+	}
+}
+
+// doSetupAll sets up multiple medias by sending SETUP requests without waiting for responses.
+// The first request is sent alone, since the session ID and the transport are needed by the others.
+func (c *Client) doSetupAll(baseURL *base.URL, medias []*description.Media) error {
+	if len(medias) == 0 {
+		return nil
+	}
+
+	_, err := c.doSetup(baseURL, medias[0], 0, 0)
+	if err != nil {
+		return err
+	}
+
+	closePending := func() {
+		for _, s := range c.pendingSetups {
+			s.cm.close()
+		}
+		c.pendingSetups = nil
+	}
+
+	for _, medi := range medias[1:] {
+		var s *clientSetup
+		s, err = c.setupPrepare(baseURL, medi, 0, 0)
+		if err != nil {
+			closePending()
+			return err
+		}
+
+		// pending setups are taken into account when choosing interleaved channels
+		c.pendingSetups = append(c.pendingSetups, s)
+	}
+
+	futures := make([]*ClientResponseFuture, len(c.pendingSetups))
+
+	for i, s := range c.pendingSetups {
+		futures[i], err = c.sendRequestAsync(c.setupRequest(s, s.mediaURLs[0]), c.PrepareTimeout)
+		if err != nil {
+			closePending()
+			return err
+		}
+	}
+
+	for _, f := range futures {
+		err = c.waitFuture(f)
+		if err != nil {
+			closePending()
+			c.mustClose = true
+			return err
+		}
+	}
+
+	for _, f := range futures {
+		s := c.pendingSetups[0]
+		c.pendingSetups = c.pendingSetups[1:]
+
+		res, err := f.Wait()
+		if err != nil {
+			s.cm.close()
+			closePending()
+			c.mustClose = true
+			return err
+		}
+
+		if res.StatusCode == base.StatusOK {
+			_, err = c.setupFinish(s, s.mediaURLs[0], res)
+			if err != nil {
+				closePending()
+				return err
+			}
+			continue
+		}
+
+		s.cm.close()
+
+		// the request has been rejected: send it again alone,
+		// in order to apply authentication and alternative URLs.
+		_, err = c.doSetup(baseURL, s.medi, 0, 0)
+		if err != nil {
+			closePending()
+			return err
+		}
+	}
+
+	return nil
+}
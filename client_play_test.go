@@ -1,11 +1,16 @@
 package gortsplib
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
+	"io"
 	"net"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -17,10 +22,13 @@ import (
 
 	"github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/chaos"
 	"github.com/bluenviron/gortsplib/v4/pkg/conn"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/onvifreplay"
 	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
 )
 
@@ -686,6 +694,127 @@ func TestClientPlayPartial(t *testing.T) {
 	<-packetRecv
 }
 
+func TestClientPlayMulticastRTCPJoinError(t *testing.T) {
+	listenIP := multicastCapableIP(t)
+	l, err := net.Listen("tcp", listenIP+":8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{{
+			Type:        description.MediaTypeVideo,
+			RTCPPort:    25011,
+			RTCPAddress: "ff02::1", // IPv6 groups can't be joined
+			Formats:     testH264Media.Formats,
+		}}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://" + listenIP + ":8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		v := net.ParseIP("224.1.0.1")
+		th := headers.Transport{
+			Delivery:    deliveryPtr(headers.TransportDeliveryMulticast),
+			Protocol:    headers.TransportProtocolUDP,
+			Destination: &v,
+			Ports:       &[2]int{25000, 25001},
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	warning := make(chan error, 1)
+
+	c := Client{
+		Transport: transportPtr(TransportUDPMulticast),
+		OnWarning: func(err error) {
+			warning <- err
+		},
+	}
+
+	u, err := base.ParseURL("rtsp://" + listenIP + ":8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	_, err = c.Setup(sd.BaseURL, sd.Medias[0], 0, 0)
+	require.NoError(t, err)
+
+	err = <-warning
+	require.IsType(t, liberrors.ErrClientRTCPMulticastJoin{}, err)
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+}
+
 func TestClientPlayContentBase(t *testing.T) {
 	for _, ca := range []string{
 		"absent",
@@ -1792,6 +1921,244 @@ func TestClientPlayRedirect(t *testing.T) {
 	}
 }
 
+func TestClientPlayRedirectErrors(t *testing.T) {
+	for _, ca := range []string{"loop", "too many"} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+
+			serverDone := make(chan struct{})
+			go func() {
+				defer close(serverDone)
+
+				for {
+					nconn, err2 := l.Accept()
+					if err2 != nil {
+						return
+					}
+
+					func() {
+						defer nconn.Close()
+						conn := conn.NewConn(nconn)
+
+						for {
+							req, err2 := conn.ReadRequest()
+							if err2 != nil {
+								return
+							}
+
+							if req.Method == base.Options {
+								err2 = conn.WriteResponse(&base.Response{
+									StatusCode: base.StatusOK,
+									Header: base.Header{
+										"CSeq": req.Header["CSeq"],
+									},
+								})
+								require.NoError(t, err2)
+								continue
+							}
+
+							require.Equal(t, base.Describe, req.Method)
+
+							var location string
+							if ca == "loop" {
+								if req.URL.Path == "/a" {
+									location = "rtsp://localhost:8554/b"
+								} else {
+									location = "/a"
+								}
+							} else {
+								n, err3 := strconv.Atoi(req.URL.Path[1:])
+								require.NoError(t, err3)
+								location = "rtsp://localhost:8554/" + strconv.Itoa(n+1)
+							}
+
+							err2 = conn.WriteResponse(&base.Response{
+								StatusCode: base.StatusFound,
+								Header: base.Header{
+									"CSeq":     req.Header["CSeq"],
+									"Location": base.HeaderValue{location},
+								},
+							})
+							require.NoError(t, err2)
+						}
+					}()
+				}
+			}()
+
+			var redirects []string
+
+			c := Client{
+				MaxRedirects: 2,
+				OnRedirect: func(location *base.URL) {
+					redirects = append(redirects, location.String())
+				},
+			}
+
+			var u *base.URL
+			if ca == "loop" {
+				u = mustParseURL("rtsp://localhost:8554/a")
+			} else {
+				u = mustParseURL("rtsp://localhost:8554/1")
+			}
+
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+
+			_, _, err = c.Describe(u)
+
+			if ca == "loop" {
+				require.Equal(t, liberrors.ErrClientRedirectLoop{URL: "rtsp://localhost:8554/a"}, err)
+				require.Equal(t, []string{"rtsp://localhost:8554/b"}, redirects)
+			} else {
+				require.Equal(t, liberrors.ErrClientTooManyRedirects{Max: 2}, err)
+				require.Equal(t, []string{"rtsp://localhost:8554/2", "rtsp://localhost:8554/3"}, redirects)
+			}
+
+			c.Close()
+			l.Close()
+			<-serverDone
+		})
+	}
+}
+
+func TestClientPlaySetupRedirect(t *testing.T) {
+	l1, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l1.Close()
+
+	l2, err := net.Listen("tcp", "127.0.0.1:8555")
+	require.NoError(t, err)
+	defer l2.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		func() {
+			nconn, err2 := l1.Accept()
+			require.NoError(t, err2)
+			defer nconn.Close()
+			conn := conn.NewConn(nconn)
+
+			req, err2 := conn.ReadRequest()
+			require.NoError(t, err2)
+			require.Equal(t, base.Options, req.Method)
+
+			err2 = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusOK,
+			})
+			require.NoError(t, err2)
+
+			req, err2 = conn.ReadRequest()
+			require.NoError(t, err2)
+			require.Equal(t, base.Describe, req.Method)
+
+			err2 = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"Content-Type": base.HeaderValue{"application/sdp"},
+				},
+				Body: mediasToSDP([]*description.Media{testH264Media}),
+			})
+			require.NoError(t, err2)
+
+			req, err2 = conn.ReadRequest()
+			require.NoError(t, err2)
+			require.Equal(t, base.Setup, req.Method)
+			require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream/"+testH264Media.Control), req.URL)
+
+			err2 = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusFound,
+				Header: base.Header{
+					"Location": base.HeaderValue{"rtsp://127.0.0.1:8555/teststream/trackID=0"},
+				},
+			})
+			require.NoError(t, err2)
+		}()
+
+		nconn, err2 := l2.Accept()
+		require.NoError(t, err2)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err2 := conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Options, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Describe, req.Method)
+		require.Equal(t, mustParseURL("rtsp://127.0.0.1:8555/teststream"), req.URL)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+			},
+			Body: mediasToSDP([]*description.Media{testH264Media}),
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, mustParseURL("rtsp://127.0.0.1:8555/teststream/"+testH264Media.Control), req.URL)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": headers.Transport{
+					Protocol:       headers.TransportProtocolTCP,
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					InterleavedIDs: &[2]int{0, 1},
+				}.Marshal(),
+				"Session": base.HeaderValue{"ABCDE"},
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Play, req.Method)
+		require.Equal(t, mustParseURL("rtsp://127.0.0.1:8555/teststream"), req.URL)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+	}()
+
+	var redirects []string
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+		OnRedirect: func(location *base.URL) {
+			redirects = append(redirects, location.String())
+		},
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+	c.Close()
+
+	require.Equal(t, []string{"rtsp://127.0.0.1:8555/teststream/trackID=0"}, redirects)
+}
 func TestClientPlayPause(t *testing.T) {
 	writeFrames := func(inTH *headers.Transport, conn *conn.Conn) (chan struct{}, chan struct{}) {
 		writerTerminate := make(chan struct{})
@@ -2003,196 +2370,29 @@ func TestClientPlayPause(t *testing.T) {
 	}
 }
 
-func TestClientPlayRTCPReport(t *testing.T) {
-	reportReceived := make(chan struct{})
-
-	l, err := net.Listen("tcp", "localhost:8554")
-	require.NoError(t, err)
-	defer l.Close()
+func TestClientPlaySessionHeader(t *testing.T) {
+	for _, ca := range []string{
+		"only on setup",
+		"mismatch",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
 
-	serverDone := make(chan struct{})
-	defer func() { <-serverDone }()
-	go func() {
-		defer close(serverDone)
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
 
-		nconn, err := l.Accept()
-		require.NoError(t, err)
-		defer nconn.Close()
-		conn := conn.NewConn(nconn)
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
 
-		req, err := conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Options, req.Method)
-
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-			Header: base.Header{
-				"Public": base.HeaderValue{strings.Join([]string{
-					string(base.Describe),
-					string(base.Setup),
-					string(base.Play),
-				}, ", ")},
-			},
-		})
-		require.NoError(t, err)
-
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Describe, req.Method)
-
-		medias := []*description.Media{testH264Media}
-
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-			Header: base.Header{
-				"Content-Type": base.HeaderValue{"application/sdp"},
-				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
-			},
-			Body: mediasToSDP(medias),
-		})
-		require.NoError(t, err)
-
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Setup, req.Method)
-
-		var inTH headers.Transport
-		err = inTH.Unmarshal(req.Header["Transport"])
-		require.NoError(t, err)
-
-		l1, err := net.ListenPacket("udp", "localhost:27556")
-		require.NoError(t, err)
-		defer l1.Close()
-
-		l2, err := net.ListenPacket("udp", "localhost:27557")
-		require.NoError(t, err)
-		defer l2.Close()
-
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-			Header: base.Header{
-				"Transport": headers.Transport{
-					Protocol:    headers.TransportProtocolUDP,
-					Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
-					ServerPorts: &[2]int{27556, 27557},
-					ClientPorts: inTH.ClientPorts,
-				}.Marshal(),
-			},
-		})
-		require.NoError(t, err)
-
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Play, req.Method)
-
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-		})
-		require.NoError(t, err)
-
-		// skip firewall opening
-		buf := make([]byte, 2048)
-		_, _, err = l2.ReadFrom(buf)
-		require.NoError(t, err)
-
-		_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
-			Header: rtp.Header{
-				Version:        2,
-				Marker:         true,
-				PayloadType:    96,
-				SequenceNumber: 946,
-				Timestamp:      54352,
-				SSRC:           753621,
-			},
-			Payload: []byte{0x05, 0x02, 0x03, 0x04},
-		}), &net.UDPAddr{
-			IP:   net.ParseIP("127.0.0.1"),
-			Port: inTH.ClientPorts[0],
-		})
-		require.NoError(t, err)
-
-		// wait for the packet's SSRC to be saved
-		time.Sleep(200 * time.Millisecond)
-
-		_, err = l2.WriteTo(mustMarshalPacketRTCP(&rtcp.SenderReport{
-			SSRC:        753621,
-			NTPTime:     ntpTimeGoToRTCP(time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)),
-			RTPTime:     54352,
-			PacketCount: 1,
-			OctetCount:  4,
-		}), &net.UDPAddr{
-			IP:   net.ParseIP("127.0.0.1"),
-			Port: inTH.ClientPorts[1],
-		})
-		require.NoError(t, err)
-
-		buf = make([]byte, 2048)
-		n, _, err := l2.ReadFrom(buf)
-		require.NoError(t, err)
-		packets, err := rtcp.Unmarshal(buf[:n])
-		require.NoError(t, err)
-		rr, ok := packets[0].(*rtcp.ReceiverReport)
-		require.True(t, ok)
-		require.Equal(t, &rtcp.ReceiverReport{
-			SSRC: rr.SSRC,
-			Reports: []rtcp.ReceptionReport{
-				{
-					SSRC:               rr.Reports[0].SSRC,
-					LastSequenceNumber: 946,
-					LastSenderReport:   2641887232,
-					Delay:              rr.Reports[0].Delay,
-				},
-			},
-			ProfileExtensions: []uint8{},
-		}, rr)
-
-		close(reportReceived)
-
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Teardown, req.Method)
-
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-		})
-		require.NoError(t, err)
-	}()
-
-	c := Client{
-		receiverReportPeriod: 500 * time.Millisecond,
-	}
-
-	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
-	require.NoError(t, err)
-	defer c.Close()
-
-	<-reportReceived
-}
-
-func TestClientPlayErrorTimeout(t *testing.T) {
-	for _, transport := range []string{
-		"udp",
-		"tcp",
-		"auto",
-	} {
-		t.Run(transport, func(t *testing.T) {
-			l, err := net.Listen("tcp", "localhost:8554")
-			require.NoError(t, err)
-			defer l.Close()
-
-			serverDone := make(chan struct{})
-			defer func() { <-serverDone }()
-			go func() {
-				defer close(serverDone)
-
-				nconn, err := l.Accept()
-				require.NoError(t, err)
-				defer nconn.Close()
-				conn := conn.NewConn(nconn)
-
-				req, err := conn.ReadRequest()
-				require.NoError(t, err)
-				require.Equal(t, base.Options, req.Method)
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
 
 				err = conn.WriteResponse(&base.Response{
 					StatusCode: base.StatusOK,
@@ -2231,28 +2431,16 @@ func TestClientPlayErrorTimeout(t *testing.T) {
 				require.NoError(t, err)
 
 				th := headers.Transport{
-					Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
-				}
-
-				var l1 net.PacketConn
-				if transport == "udp" || transport == "auto" {
-					var err error
-					l1, err = net.ListenPacket("udp", "localhost:34556")
-					require.NoError(t, err)
-					defer l1.Close()
-
-					th.Protocol = headers.TransportProtocolUDP
-					th.ServerPorts = &[2]int{34556, 34557}
-					th.ClientPorts = inTH.ClientPorts
-				} else {
-					th.Protocol = headers.TransportProtocolTCP
-					th.InterleavedIDs = inTH.InterleavedIDs
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					Protocol:       headers.TransportProtocolTCP,
+					InterleavedIDs: inTH.InterleavedIDs,
 				}
 
 				err = conn.WriteResponse(&base.Response{
 					StatusCode: base.StatusOK,
 					Header: base.Header{
 						"Transport": th.Marshal(),
+						"Session":   base.HeaderValue{"ABCDEF"},
 					},
 				})
 				require.NoError(t, err)
@@ -2260,24 +2448,38 @@ func TestClientPlayErrorTimeout(t *testing.T) {
 				req, err = conn.ReadRequest()
 				require.NoError(t, err)
 				require.Equal(t, base.Play, req.Method)
+				require.Equal(t, base.HeaderValue{"ABCDEF"}, req.Header["Session"])
+
+				if ca == "mismatch" {
+					err = conn.WriteResponse(&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"Session": base.HeaderValue{"GHIJKL"},
+						},
+					})
+					require.NoError(t, err)
+					return
+				}
 
 				err = conn.WriteResponse(&base.Response{
 					StatusCode: base.StatusOK,
 				})
 				require.NoError(t, err)
 
-				if transport == "udp" || transport == "auto" {
-					// write a packet to skip the protocol autodetection feature
-					_, err = l1.WriteTo(testRTPPacketMarshaled, &net.UDPAddr{
-						IP:   net.ParseIP("127.0.0.1"),
-						Port: th.ClientPorts[0],
-					})
-					require.NoError(t, err)
-				}
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Pause, req.Method)
+				require.Equal(t, base.HeaderValue{"ABCDEF"}, req.Header["Session"])
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
 
 				req, err = conn.ReadRequest()
 				require.NoError(t, err)
 				require.Equal(t, base.Teardown, req.Method)
+				require.Equal(t, base.HeaderValue{"ABCDEF"}, req.Header["Session"])
 
 				err = conn.WriteResponse(&base.Response{
 					StatusCode: base.StatusOK,
@@ -2286,45 +2488,46 @@ func TestClientPlayErrorTimeout(t *testing.T) {
 			}()
 
 			c := Client{
-				Transport: func() *Transport {
-					switch transport {
-					case "udp":
-						v := TransportUDP
-						return &v
-
-					case "tcp":
-						v := TransportTCP
-						return &v
-					}
-					return nil
-				}(),
-				InitialUDPReadTimeout: 1 * time.Second,
-				ReadTimeout:           1 * time.Second,
+				Transport: transportPtr(TransportTCP),
 			}
 
-			err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
 			require.NoError(t, err)
 
-			err = c.Wait()
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
 
-			switch transport {
-			case "udp", "auto":
-				require.EqualError(t, err, "UDP timeout")
+			sd, _, err := c.Describe(u)
+			require.NoError(t, err)
 
-			case "tcp":
-				require.EqualError(t, err, "TCP timeout")
+			err = c.SetupAll(sd.BaseURL, sd.Medias)
+			require.NoError(t, err)
+
+			_, err = c.Play(nil)
+
+			if ca == "mismatch" {
+				require.EqualError(t, err, "session ID mismatch: expected 'ABCDEF', received 'GHIJKL'")
+				return
 			}
+
+			require.NoError(t, err)
+
+			_, err = c.Pause()
+			require.NoError(t, err)
 		})
 	}
 }
 
-func TestClientPlayIgnoreTCPInvalidMedia(t *testing.T) {
+func TestClientPlayRequestKeyframe(t *testing.T) {
 	l, err := net.Listen("tcp", "localhost:8554")
 	require.NoError(t, err)
 	defer l.Close()
 
 	serverDone := make(chan struct{})
 	defer func() { <-serverDone }()
+
+	pliRecv := make(chan struct{})
 	go func() {
 		defer close(serverDone)
 
@@ -2374,10 +2577,10 @@ func TestClientPlayIgnoreTCPInvalidMedia(t *testing.T) {
 		require.NoError(t, err)
 
 		th := headers.Transport{
-			Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
 		}
-		th.Protocol = headers.TransportProtocolTCP
-		th.InterleavedIDs = inTH.InterleavedIDs
 
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
@@ -2397,16 +2600,22 @@ func TestClientPlayIgnoreTCPInvalidMedia(t *testing.T) {
 		require.NoError(t, err)
 
 		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-			Channel: 6,
+			Channel: 0,
 			Payload: testRTPPacketMarshaled,
 		}, make([]byte, 1024))
 		require.NoError(t, err)
 
-		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-			Channel: 0,
-			Payload: testRTPPacketMarshaled,
-		}, make([]byte, 1024))
+		f, err := conn.ReadInterleavedFrame()
 		require.NoError(t, err)
+		require.Equal(t, 1, f.Channel)
+
+		pkts, err := rtcp.Unmarshal(f.Payload)
+		require.NoError(t, err)
+		require.Equal(t, []rtcp.Packet{&rtcp.PictureLossIndication{
+			MediaSSRC: 0x38F27A2F,
+		}}, pkts)
+
+		close(pliRecv)
 
 		req, err = conn.ReadRequest()
 		require.NoError(t, err)
@@ -2418,23 +2627,46 @@ func TestClientPlayIgnoreTCPInvalidMedia(t *testing.T) {
 		require.NoError(t, err)
 	}()
 
-	recv := make(chan struct{})
-
 	c := Client{
 		Transport: transportPtr(TransportTCP),
 	}
 
-	err = readAll(&c, "rtsp://localhost:8554/teststream",
-		func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
-			close(recv)
-		})
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
 	require.NoError(t, err)
 	defer c.Close()
 
-	<-recv
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	err = c.RequestKeyframe(sd.Medias[0])
+	require.Equal(t, liberrors.ErrClientSSRCUnknown{}, err)
+
+	packetRecv := make(chan struct{})
+
+	c.OnPacketRTPAny(func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+		close(packetRecv)
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	<-packetRecv
+
+	err = c.RequestKeyframe(sd.Medias[0])
+	require.NoError(t, err)
+
+	<-pliRecv
 }
 
-func TestClientPlaySeek(t *testing.T) {
+func TestClientPlayRTCPReport(t *testing.T) {
+	reportReceived := make(chan struct{})
+
 	l, err := net.Listen("tcp", "localhost:8554")
 	require.NoError(t, err)
 	defer l.Close()
@@ -2489,16 +2721,23 @@ func TestClientPlaySeek(t *testing.T) {
 		err = inTH.Unmarshal(req.Header["Transport"])
 		require.NoError(t, err)
 
-		th := headers.Transport{
-			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-			Protocol:       headers.TransportProtocolTCP,
-			InterleavedIDs: inTH.InterleavedIDs,
-		}
+		l1, err := net.ListenPacket("udp", "localhost:27556")
+		require.NoError(t, err)
+		defer l1.Close()
+
+		l2, err := net.ListenPacket("udp", "localhost:27557")
+		require.NoError(t, err)
+		defer l2.Close()
 
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
-				"Transport": th.Marshal(),
+				"Transport": headers.Transport{
+					Protocol:    headers.TransportProtocolUDP,
+					Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+					ServerPorts: &[2]int{27556, 27557},
+					ClientPorts: inTH.ClientPorts,
+				}.Marshal(),
 			},
 		})
 		require.NoError(t, err)
@@ -2507,45 +2746,68 @@ func TestClientPlaySeek(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, base.Play, req.Method)
 
-		var ra headers.Range
-		err = ra.Unmarshal(req.Header["Range"])
-		require.NoError(t, err)
-		require.Equal(t, headers.Range{
-			Value: &headers.RangeNPT{
-				Start: 5500 * time.Millisecond,
-			},
-		}, ra)
-
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 		})
 		require.NoError(t, err)
 
-		req, err = conn.ReadRequest()
+		// skip firewall opening
+		buf := make([]byte, 2048)
+		_, _, err = l2.ReadFrom(buf)
 		require.NoError(t, err)
-		require.Equal(t, base.Pause, req.Method)
 
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
+		_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 946,
+				Timestamp:      54352,
+				SSRC:           753621,
+			},
+			Payload: []byte{0x05, 0x02, 0x03, 0x04},
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[0],
 		})
 		require.NoError(t, err)
 
-		req, err = conn.ReadRequest()
+		// wait for the packet's SSRC to be saved
+		time.Sleep(200 * time.Millisecond)
+
+		_, err = l2.WriteTo(mustMarshalPacketRTCP(&rtcp.SenderReport{
+			SSRC:        753621,
+			NTPTime:     ntpTimeGoToRTCP(time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)),
+			RTPTime:     54352,
+			PacketCount: 1,
+			OctetCount:  4,
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[1],
+		})
 		require.NoError(t, err)
-		require.Equal(t, base.Play, req.Method)
 
-		err = ra.Unmarshal(req.Header["Range"])
+		buf = make([]byte, 2048)
+		n, _, err := l2.ReadFrom(buf)
 		require.NoError(t, err)
-		require.Equal(t, headers.Range{
-			Value: &headers.RangeNPT{
-				Start: 6400 * time.Millisecond,
+		packets, err := rtcp.Unmarshal(buf[:n])
+		require.NoError(t, err)
+		rr, ok := packets[0].(*rtcp.ReceiverReport)
+		require.True(t, ok)
+		require.Equal(t, &rtcp.ReceiverReport{
+			SSRC: rr.SSRC,
+			Reports: []rtcp.ReceptionReport{
+				{
+					SSRC:               rr.Reports[0].SSRC,
+					LastSequenceNumber: 946,
+					LastSenderReport:   2641887232,
+					Delay:              rr.Reports[0].Delay,
+				},
 			},
-		}, ra)
+			ProfileExtensions: []uint8{},
+		}, rr)
 
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-		})
-		require.NoError(t, err)
+		close(reportReceived)
 
 		req, err = conn.ReadRequest()
 		require.NoError(t, err)
@@ -2558,215 +2820,231 @@ func TestClientPlaySeek(t *testing.T) {
 	}()
 
 	c := Client{
-		Transport: transportPtr(TransportTCP),
+		receiverReportPeriod: 500 * time.Millisecond,
 	}
 
-	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
-	require.NoError(t, err)
-
-	err = c.Start(u.Scheme, u.Host)
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
 	require.NoError(t, err)
 	defer c.Close()
 
-	sd, _, err := c.Describe(u)
-	require.NoError(t, err)
+	<-reportReceived
+}
 
-	err = c.SetupAll(sd.BaseURL, sd.Medias)
-	require.NoError(t, err)
+func TestClientPlayRTCPExtendedReport(t *testing.T) {
+	reportReceived := make(chan struct{})
 
-	_, err = c.Play(&headers.Range{
-		Value: &headers.RangeNPT{
-			Start: 5500 * time.Millisecond,
-		},
-	})
+	l, err := net.Listen("tcp", "localhost:8554")
 	require.NoError(t, err)
+	defer l.Close()
 
-	_, err = c.Seek(&headers.Range{
-		Value: &headers.RangeNPT{
-			Start: 6400 * time.Millisecond,
-		},
-	})
-	require.NoError(t, err)
-}
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
 
-func TestClientPlayKeepalive(t *testing.T) {
-	for _, ca := range []string{"response before frame", "response after frame", "no response"} {
-		t.Run(ca, func(t *testing.T) {
-			l, err := net.Listen("tcp", "localhost:8554")
-			require.NoError(t, err)
-			defer l.Close()
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
 
-			serverDone := make(chan struct{})
-			defer func() { <-serverDone }()
-			go func() {
-				defer close(serverDone)
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
 
-				nconn, err := l.Accept()
-				require.NoError(t, err)
-				defer nconn.Close()
-				conn := conn.NewConn(nconn)
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
 
-				req, err := conn.ReadRequest()
-				require.NoError(t, err)
-				require.Equal(t, base.Options, req.Method)
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
 
-				err = conn.WriteResponse(&base.Response{
-					StatusCode: base.StatusOK,
-					Header: base.Header{
-						"CSeq": req.Header["CSeq"],
-						"Public": base.HeaderValue{strings.Join([]string{
-							string(base.Describe),
-							string(base.Setup),
-							string(base.Play),
-						}, ", ")},
-					},
-				})
-				require.NoError(t, err)
+		medias := []*description.Media{testH264Media}
 
-				req, err = conn.ReadRequest()
-				require.NoError(t, err)
-				require.Equal(t, base.Describe, req.Method)
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
 
-				medias := []*description.Media{testH264Media}
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
 
-				err = conn.WriteResponse(&base.Response{
-					StatusCode: base.StatusOK,
-					Header: base.Header{
-						"CSeq":         req.Header["CSeq"],
-						"Content-Type": base.HeaderValue{"application/sdp"},
-						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
-					},
-					Body: mediasToSDP(medias),
-				})
-				require.NoError(t, err)
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
 
-				req, err = conn.ReadRequest()
-				require.NoError(t, err)
-				require.Equal(t, base.Setup, req.Method)
+		l1, err := net.ListenPacket("udp", "localhost:27556")
+		require.NoError(t, err)
+		defer l1.Close()
 
-				var inTH headers.Transport
-				err = inTH.Unmarshal(req.Header["Transport"])
-				require.NoError(t, err)
-
-				err = conn.WriteResponse(&base.Response{
-					StatusCode: base.StatusOK,
-					Header: base.Header{
-						"CSeq": req.Header["CSeq"],
-						"Transport": headers.Transport{
-							Protocol:       headers.TransportProtocolTCP,
-							Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-							InterleavedIDs: &[2]int{0, 1},
-						}.Marshal(),
-						"Session": headers.Session{
-							Session: "ABCDE",
-							Timeout: uintPtr(1),
-						}.Marshal(),
-					},
-				})
-				require.NoError(t, err)
-
-				req, err = conn.ReadRequest()
-				require.NoError(t, err)
-				require.Equal(t, base.Play, req.Method)
+		l2, err := net.ListenPacket("udp", "localhost:27557")
+		require.NoError(t, err)
+		defer l2.Close()
 
-				err = conn.WriteResponse(&base.Response{
-					StatusCode: base.StatusOK,
-					Header: base.Header{
-						"CSeq": req.Header["CSeq"],
-					},
-				})
-				require.NoError(t, err)
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": headers.Transport{
+					Protocol:    headers.TransportProtocolUDP,
+					Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+					ServerPorts: &[2]int{27556, 27557},
+					ClientPorts: inTH.ClientPorts,
+				}.Marshal(),
+			},
+		})
+		require.NoError(t, err)
 
-				recv := make(chan struct{})
-				go func() {
-					defer close(recv)
-					req, err = conn.ReadRequest()
-					require.NoError(t, err)
-					require.Equal(t, base.Options, req.Method)
-				}()
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
 
-				select {
-				case <-recv:
-				case <-time.After(2 * time.Second):
-					t.Errorf("should not happen")
-				}
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
 
-				if ca == "response before frame" {
-					err = conn.WriteResponse(&base.Response{
-						StatusCode: base.StatusOK,
-						Header: base.Header{
-							"CSeq": req.Header["CSeq"],
-						},
-					})
-					require.NoError(t, err)
-				}
+		// skip firewall opening
+		buf := make([]byte, 2048)
+		_, _, err = l2.ReadFrom(buf)
+		require.NoError(t, err)
 
-				err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-					Channel: 0,
-					Payload: testRTPPacketMarshaled,
-				}, make([]byte, 1024))
-				require.NoError(t, err)
+		_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 946,
+				Timestamp:      54352,
+				SSRC:           753621,
+			},
+			Payload: []byte{0x05, 0x02, 0x03, 0x04},
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[0],
+		})
+		require.NoError(t, err)
 
-				if ca == "response after frame" {
-					err = conn.WriteResponse(&base.Response{
-						StatusCode: base.StatusOK,
-					})
-					require.NoError(t, err)
-				}
+		// wait for the packet's SSRC to be saved
+		time.Sleep(200 * time.Millisecond)
 
-				err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-					Channel: 0,
-					Payload: testRTPPacketMarshaled,
-				}, make([]byte, 1024))
-				require.NoError(t, err)
-			}()
+		_, err = l2.WriteTo(mustMarshalPacketRTCP(&rtcp.SenderReport{
+			SSRC:        753621,
+			NTPTime:     ntpTimeGoToRTCP(time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)),
+			RTPTime:     54352,
+			PacketCount: 1,
+			OctetCount:  4,
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[1],
+		})
+		require.NoError(t, err)
 
-			done1 := make(chan struct{})
-			done2 := make(chan struct{})
-			n := 0
-			m := 0
+		buf = make([]byte, 2048)
+		n, _, err := l2.ReadFrom(buf)
+		require.NoError(t, err)
+		packets, err := rtcp.Unmarshal(buf[:n])
+		require.NoError(t, err)
+		rr, ok := packets[0].(*rtcp.ReceiverReport)
+		require.True(t, ok)
+		require.Equal(t, &rtcp.ReceiverReport{
+			SSRC: rr.SSRC,
+			Reports: []rtcp.ReceptionReport{
+				{
+					SSRC:               rr.Reports[0].SSRC,
+					LastSequenceNumber: 946,
+					LastSenderReport:   2641887232,
+					Delay:              rr.Reports[0].Delay,
+				},
+			},
+			ProfileExtensions: []uint8{},
+		}, rr)
 
-			v := TransportTCP
-			c := Client{
-				Transport: &v,
-				OnResponse: func(res *base.Response) {
-					m++
-					if ca != "no response" {
-						if m >= 5 {
-							close(done2)
-						}
-					} else {
-						if m >= 4 {
-							close(done2)
-						}
-					}
+		n, _, err = l2.ReadFrom(buf)
+		require.NoError(t, err)
+		packets, err = rtcp.Unmarshal(buf[:n])
+		require.NoError(t, err)
+		xr, ok := packets[0].(*rtcp.ExtendedReport)
+		require.True(t, ok)
+		require.Equal(t, rr.SSRC, xr.SenderSSRC)
+		rrt, ok := xr.Reports[0].(*rtcp.ReceiverReferenceTimeReportBlock)
+		require.True(t, ok)
+		voip, ok := xr.Reports[1].(*rtcp.VoIPMetricsReportBlock)
+		require.True(t, ok)
+		require.Equal(t, uint8(16), voip.Gmin)
+
+		// reply with a DLRR block that makes the round-trip time at least one second
+		_, err = l2.WriteTo(mustMarshalPacketRTCP(&rtcp.ExtendedReport{
+			SenderSSRC: 753621,
+			Reports: []rtcp.ReportBlock{
+				&rtcp.DLRRReportBlock{
+					Reports: []rtcp.DLRRReport{{
+						SSRC:   xr.SenderSSRC,
+						LastRR: uint32(rrt.NTPTimestamp>>16) - 65536,
+						DLRR:   0,
+					}},
 				},
-			}
+			},
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[1],
+		})
+		require.NoError(t, err)
 
-			err = readAll(&c, "rtsp://localhost:8554/teststream",
-				func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
-					require.Equal(t, &testRTPPacket, pkt)
-					n++
-					if n == 2 {
-						close(done1)
-					}
-				})
-			require.NoError(t, err)
-			defer c.Close()
+		<-reportReceived
 
-			<-done1
-			<-done2
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
 		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		RTCPExtendedReportsEnable: true,
+		receiverReportPeriod:      500 * time.Millisecond,
 	}
-}
 
-func TestClientPlayDifferentSource(t *testing.T) {
-	packetRecv := make(chan struct{})
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for {
+		stats := c.Stats()
+		if len(stats) == 1 && stats[0].RoundTripTime != 0 {
+			require.GreaterOrEqual(t, stats[0].RoundTripTime, time.Second)
+			require.Less(t, stats[0].RoundTripTime, 2*time.Second)
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	close(reportReceived)
+}
 
+func TestClientPlayPacketReordering(t *testing.T) {
 	l, err := net.Listen("tcp", "localhost:8554")
 	require.NoError(t, err)
 	defer l.Close()
 
+	sendLate := make(chan struct{})
+
 	serverDone := make(chan struct{})
 	defer func() { <-serverDone }()
 	go func() {
@@ -2780,7 +3058,6 @@ func TestClientPlayDifferentSource(t *testing.T) {
 		req, err := conn.ReadRequest()
 		require.NoError(t, err)
 		require.Equal(t, base.Options, req.Method)
-		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value"), req.URL)
 
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
@@ -2797,7 +3074,6 @@ func TestClientPlayDifferentSource(t *testing.T) {
 		req, err = conn.ReadRequest()
 		require.NoError(t, err)
 		require.Equal(t, base.Describe, req.Method)
-		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value"), req.URL)
 
 		medias := []*description.Media{testH264Media}
 
@@ -2805,7 +3081,7 @@ func TestClientPlayDifferentSource(t *testing.T) {
 			StatusCode: base.StatusOK,
 			Header: base.Header{
 				"Content-Type": base.HeaderValue{"application/sdp"},
-				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/test/stream?param=value/"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
 			},
 			Body: mediasToSDP(medias),
 		})
@@ -2814,32 +3090,28 @@ func TestClientPlayDifferentSource(t *testing.T) {
 		req, err = conn.ReadRequest()
 		require.NoError(t, err)
 		require.Equal(t, base.Setup, req.Method)
-		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value/"+medias[0].Control), req.URL)
 
 		var inTH headers.Transport
 		err = inTH.Unmarshal(req.Header["Transport"])
 		require.NoError(t, err)
 
-		th := headers.Transport{
-			Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
-			Protocol:    headers.TransportProtocolUDP,
-			ClientPorts: inTH.ClientPorts,
-			ServerPorts: &[2]int{34556, 34557},
-			Source:      ipPtr(net.ParseIP("127.0.1.1")),
-		}
-
-		l1, err := net.ListenPacket("udp", "127.0.1.1:34556")
+		l1, err := net.ListenPacket("udp", "localhost:27556")
 		require.NoError(t, err)
 		defer l1.Close()
 
-		l2, err := net.ListenPacket("udp", "127.0.1.1:34557")
+		l2, err := net.ListenPacket("udp", "localhost:27557")
 		require.NoError(t, err)
 		defer l2.Close()
 
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
-				"Transport": th.Marshal(),
+				"Transport": headers.Transport{
+					Protocol:    headers.TransportProtocolUDP,
+					Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+					ServerPorts: &[2]int{27556, 27557},
+					ClientPorts: inTH.ClientPorts,
+				}.Marshal(),
 			},
 		})
 		require.NoError(t, err)
@@ -2847,24 +3119,36 @@ func TestClientPlayDifferentSource(t *testing.T) {
 		req, err = conn.ReadRequest()
 		require.NoError(t, err)
 		require.Equal(t, base.Play, req.Method)
-		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value/"), req.URL)
 
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 		})
 		require.NoError(t, err)
 
-		// server -> client (RTP)
-		_, err = l1.WriteTo(testRTPPacketMarshaled, &net.UDPAddr{
-			IP:   net.ParseIP("127.0.0.1"),
-			Port: th.ClientPorts[0],
-		})
-		require.NoError(t, err)
+		writeRTP := func(seq uint16) {
+			pkt := testRTPPacket
+			pkt.SequenceNumber = seq
+
+			_, err = l1.WriteTo(mustMarshalPacketRTP(&pkt), &net.UDPAddr{
+				IP:   net.ParseIP("127.0.0.1"),
+				Port: inTH.ClientPorts[0],
+			})
+			require.NoError(t, err)
+		}
+
+		// 101 is missing
+		for _, seq := range []uint16{100, 103, 102} {
+			writeRTP(seq)
+		}
+
+		<-sendLate
+
+		writeRTP(101)
+		writeRTP(104)
 
 		req, err = conn.ReadRequest()
 		require.NoError(t, err)
 		require.Equal(t, base.Teardown, req.Method)
-		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value/"), req.URL)
 
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
@@ -2872,42 +3156,174 @@ func TestClientPlayDifferentSource(t *testing.T) {
 		require.NoError(t, err)
 	}()
 
+	recv := make(chan uint16, 10)
+	lost := make(chan int, 10)
+
 	c := Client{
 		Transport: transportPtr(TransportUDP),
+		PacketReordering: &ClientPacketReordering{
+			Window: 50 * time.Millisecond,
+		},
+		OnPacketLost: func(err error) {
+			lost <- err.(liberrors.ErrClientRTPPacketsLost).Lost
+		},
 	}
 
-	err = readAll(&c, "rtsp://localhost:8554/test/stream?param=value",
-		func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
-			require.Equal(t, &testRTPPacket, pkt)
-			close(packetRecv)
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			recv <- pkt.SequenceNumber
 		})
 	require.NoError(t, err)
 	defer c.Close()
 
-	<-packetRecv
-}
+	// 102 and 103 are released when the window of 103 expires, without waiting for other packets
+	for _, seq := range []uint16{100, 102, 103} {
+		require.Equal(t, seq, <-recv)
+	}
+	require.Equal(t, 1, <-lost)
 
-func TestClientPlayDecodeErrors(t *testing.T) {
-	for _, ca := range []struct {
-		proto string
-		name  string
-	}{
-		{"udp", "rtp invalid"},
-		{"udp", "rtcp invalid"},
-		{"udp", "rtp packets lost"},
-		{"udp", "rtp unknown payload type"},
-		{"udp", "wrong ssrc"},
-		{"udp", "rtcp too big"},
-		{"udp", "rtp too big"},
-		{"tcp", "rtp invalid"},
-		{"tcp", "rtcp invalid"},
-		{"tcp", "rtp unknown payload type"},
-		{"tcp", "wrong ssrc"},
-		{"tcp", "rtcp too big"},
-	} {
-		t.Run(ca.proto+" "+ca.name, func(t *testing.T) {
-			errorRecv := make(chan struct{})
+	close(sendLate)
+
+	// 101 is discarded
+	require.Equal(t, uint16(104), <-recv)
+
+	stats := c.Stats()
+	require.Equal(t, uint64(1), stats[0].PacketsLate)
+	require.Equal(t, uint64(1), stats[0].PacketsDropped)
+}
+
+func TestClientPlayTransportFallback(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	var udpListeners []net.PacketConn
+	for _, port := range []int{34556, 34557, 34558, 34559} {
+		var pc net.PacketConn
+		pc, err = net.ListenPacket("udp", "localhost:"+strconv.FormatInt(int64(port), 10))
+		require.NoError(t, err)
+		defer pc.Close()
+		udpListeners = append(udpListeners, pc)
+	}
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err2 := l.Accept()
+		require.NoError(t, err2)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err2 := conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Options, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Describe, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP([]*description.Media{testH264Media}),
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err2 = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err2)
+
+		th := headers.Transport{
+			Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:    headers.TransportProtocolUDP,
+			ServerPorts: &[2]int{34556, 34557},
+			ClientPorts: inTH.ClientPorts,
+		}
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Play, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		// hole punching packets are sent to server ports and to predicted ports
+		for _, pc := range udpListeners {
+			buf := make([]byte, 2048)
+			_, _, err2 = pc.ReadFrom(buf)
+			require.NoError(t, err2)
+		}
+
+		// hole punching packets are repeated
+		buf := make([]byte, 2048)
+		_, _, err2 = udpListeners[0].ReadFrom(buf)
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+	}()
+
+	c := Client{
+		Transport: transportPtr(TransportUDP),
+		TransportFallback: &ClientTransportFallback{
+			PunchInterval:  100 * time.Millisecond,
+			PredictedPorts: 1,
+			DisableTCP:     true,
+		},
+		InitialUDPReadTimeout: 1 * time.Second,
+	}
 
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+
+	err = c.Wait()
+	require.EqualError(t, err, "UDP timeout")
+}
+
+func TestClientPlayErrorTimeout(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+		"auto",
+	} {
+		t.Run(transport, func(t *testing.T) {
 			l, err := net.Listen("tcp", "localhost:8554")
 			require.NoError(t, err)
 			defer l.Close()
@@ -2942,19 +3358,13 @@ func TestClientPlayDecodeErrors(t *testing.T) {
 				require.NoError(t, err)
 				require.Equal(t, base.Describe, req.Method)
 
-				medias := []*description.Media{{
-					Type: description.MediaTypeApplication,
-					Formats: []format.Format{&format.Generic{
-						PayloadTyp: 97,
-						RTPMa:      "private/90000",
-					}},
-				}}
+				medias := []*description.Media{testH264Media}
 
 				err = conn.WriteResponse(&base.Response{
 					StatusCode: base.StatusOK,
 					Header: base.Header{
 						"Content-Type": base.HeaderValue{"application/sdp"},
-						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/stream/"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
 					},
 					Body: mediasToSDP(medias),
 				})
@@ -2972,28 +3382,21 @@ func TestClientPlayDecodeErrors(t *testing.T) {
 					Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
 				}
 
-				if ca.proto == "udp" {
+				var l1 net.PacketConn
+				if transport == "udp" || transport == "auto" {
+					var err error
+					l1, err = net.ListenPacket("udp", "localhost:34556")
+					require.NoError(t, err)
+					defer l1.Close()
+
 					th.Protocol = headers.TransportProtocolUDP
-					th.ClientPorts = inTH.ClientPorts
 					th.ServerPorts = &[2]int{34556, 34557}
+					th.ClientPorts = inTH.ClientPorts
 				} else {
 					th.Protocol = headers.TransportProtocolTCP
 					th.InterleavedIDs = inTH.InterleavedIDs
 				}
 
-				var l1 net.PacketConn
-				var l2 net.PacketConn
-
-				if ca.proto == "udp" {
-					l1, err = net.ListenPacket("udp", "127.0.0.1:34556")
-					require.NoError(t, err)
-					defer l1.Close()
-
-					l2, err = net.ListenPacket("udp", "127.0.0.1:34557")
-					require.NoError(t, err)
-					defer l2.Close()
-				}
-
 				err = conn.WriteResponse(&base.Response{
 					StatusCode: base.StatusOK,
 					Header: base.Header{
@@ -3011,94 +3414,9 @@ func TestClientPlayDecodeErrors(t *testing.T) {
 				})
 				require.NoError(t, err)
 
-				var writeRTP func(buf []byte)
-				var writeRTCP func(byts []byte)
-
-				if ca.proto == "udp" { //nolint:dupl
-					writeRTP = func(byts []byte) {
-						_, err = l1.WriteTo(byts, &net.UDPAddr{
-							IP:   net.ParseIP("127.0.0.1"),
-							Port: th.ClientPorts[0],
-						})
-						require.NoError(t, err)
-					}
-
-					writeRTCP = func(byts []byte) {
-						_, err = l2.WriteTo(byts, &net.UDPAddr{
-							IP:   net.ParseIP("127.0.0.1"),
-							Port: th.ClientPorts[1],
-						})
-						require.NoError(t, err)
-					}
-				} else {
-					writeRTP = func(byts []byte) {
-						err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-							Channel: 0,
-							Payload: byts,
-						}, make([]byte, 2048))
-						require.NoError(t, err)
-					}
-
-					writeRTCP = func(byts []byte) {
-						err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-							Channel: 1,
-							Payload: byts,
-						}, make([]byte, 2048))
-						require.NoError(t, err)
-					}
-				}
-
-				switch { //nolint:dupl
-				case ca.name == "rtp invalid":
-					writeRTP([]byte{0x01, 0x02})
-
-				case ca.name == "rtcp invalid":
-					writeRTCP([]byte{0x01, 0x02})
-
-				case ca.name == "rtcp too big":
-					writeRTCP(bytes.Repeat([]byte{0x01, 0x02}, 2000/2))
-
-				case ca.name == "rtp packets lost":
-					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
-						Header: rtp.Header{
-							PayloadType:    97,
-							SequenceNumber: 30,
-						},
-					}))
-
-					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
-						Header: rtp.Header{
-							PayloadType:    97,
-							SequenceNumber: 100,
-						},
-					}))
-
-				case ca.name == "rtp unknown payload type":
-					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
-						Header: rtp.Header{
-							PayloadType: 111,
-						},
-					}))
-
-				case ca.name == "wrong ssrc":
-					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
-						Header: rtp.Header{
-							PayloadType:    97,
-							SequenceNumber: 1,
-							SSRC:           123,
-						},
-					}))
-
-					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
-						Header: rtp.Header{
-							PayloadType:    97,
-							SequenceNumber: 2,
-							SSRC:           456,
-						},
-					}))
-
-				case ca.proto == "udp" && ca.name == "rtp too big":
-					_, err := l1.WriteTo(bytes.Repeat([]byte{0x01, 0x02}, 2000/2), &net.UDPAddr{
+				if transport == "udp" || transport == "auto" {
+					// write a packet to skip the protocol autodetection feature
+					_, err = l1.WriteTo(testRTPPacketMarshaled, &net.UDPAddr{
 						IP:   net.ParseIP("127.0.0.1"),
 						Port: th.ClientPorts[0],
 					})
@@ -3117,57 +3435,38 @@ func TestClientPlayDecodeErrors(t *testing.T) {
 
 			c := Client{
 				Transport: func() *Transport {
-					if ca.proto == "udp" {
+					switch transport {
+					case "udp":
 						v := TransportUDP
 						return &v
+
+					case "tcp":
+						v := TransportTCP
+						return &v
 					}
-					v := TransportTCP
-					return &v
+					return nil
 				}(),
-				OnPacketLost: func(err error) {
-					require.EqualError(t, err, "69 RTP packets lost")
-					close(errorRecv)
-				},
-				OnDecodeError: func(err error) {
-					switch {
-					case ca.name == "rtp invalid":
-						require.EqualError(t, err, "RTP header size insufficient: 2 < 4")
-
-					case ca.name == "rtcp invalid":
-						require.EqualError(t, err, "rtcp: packet too short")
+				InitialUDPReadTimeout: 1 * time.Second,
+				ReadTimeout:           1 * time.Second,
+			}
 
-					case ca.name == "rtp unknown payload type":
-						require.EqualError(t, err, "received RTP packet with unknown payload type: 111")
-
-					case ca.name == "wrong ssrc":
-						require.EqualError(t, err, "received packet with wrong SSRC 456, expected 123")
-
-					case ca.proto == "udp" && ca.name == "rtcp too big":
-						require.EqualError(t, err, "RTCP packet is too big to be read with UDP")
+			err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+			require.NoError(t, err)
 
-					case ca.proto == "tcp" && ca.name == "rtcp too big":
-						require.EqualError(t, err, "RTCP packet size (2000) is greater than maximum allowed (1472)")
+			err = c.Wait()
 
-					case ca.proto == "udp" && ca.name == "rtp too big":
-						require.EqualError(t, err, "RTP packet is too big to be read with UDP")
+			switch transport {
+			case "udp", "auto":
+				require.EqualError(t, err, "UDP timeout")
 
-					default:
-						t.Errorf("unexpected")
-					}
-					close(errorRecv)
-				},
+			case "tcp":
+				require.EqualError(t, err, "TCP timeout")
 			}
-
-			err = readAll(&c, "rtsp://localhost:8554/stream", nil)
-			require.NoError(t, err)
-			defer c.Close()
-
-			<-errorRecv
 		})
 	}
 }
 
-func TestClientPlayPacketNTP(t *testing.T) {
+func TestClientPlayIgnoreTCPInvalidMedia(t *testing.T) {
 	l, err := net.Listen("tcp", "localhost:8554")
 	require.NoError(t, err)
 	defer l.Close()
@@ -3222,23 +3521,16 @@ func TestClientPlayPacketNTP(t *testing.T) {
 		err = inTH.Unmarshal(req.Header["Transport"])
 		require.NoError(t, err)
 
-		l1, err := net.ListenPacket("udp", "localhost:27556")
-		require.NoError(t, err)
-		defer l1.Close()
-
-		l2, err := net.ListenPacket("udp", "localhost:27557")
-		require.NoError(t, err)
-		defer l2.Close()
+		th := headers.Transport{
+			Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		}
+		th.Protocol = headers.TransportProtocolTCP
+		th.InterleavedIDs = inTH.InterleavedIDs
 
 		err = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
-				"Transport": headers.Transport{
-					Protocol:    headers.TransportProtocolUDP,
-					Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
-					ServerPorts: &[2]int{27556, 27557},
-					ClientPorts: inTH.ClientPorts,
-				}.Marshal(),
+				"Transport": th.Marshal(),
 			},
 		})
 		require.NoError(t, err)
@@ -3252,58 +3544,16 @@ func TestClientPlayPacketNTP(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		// skip firewall opening
-		buf := make([]byte, 2048)
-		_, _, err = l2.ReadFrom(buf)
-		require.NoError(t, err)
-
-		_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
-			Header: rtp.Header{
-				Version:        2,
-				Marker:         true,
-				PayloadType:    96,
-				SequenceNumber: 946,
-				Timestamp:      54352,
-				SSRC:           753621,
-			},
-			Payload: []byte{1, 2, 3, 4},
-		}), &net.UDPAddr{
-			IP:   net.ParseIP("127.0.0.1"),
-			Port: inTH.ClientPorts[0],
-		})
-		require.NoError(t, err)
-
-		// wait for the packet's SSRC to be saved
-		time.Sleep(100 * time.Millisecond)
-
-		_, err = l2.WriteTo(mustMarshalPacketRTCP(&rtcp.SenderReport{
-			SSRC:        753621,
-			NTPTime:     ntpTimeGoToRTCP(time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)),
-			RTPTime:     54352,
-			PacketCount: 1,
-			OctetCount:  4,
-		}), &net.UDPAddr{
-			IP:   net.ParseIP("127.0.0.1"),
-			Port: inTH.ClientPorts[1],
-		})
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 6,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
 		require.NoError(t, err)
 
-		time.Sleep(100 * time.Millisecond)
-
-		_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
-			Header: rtp.Header{
-				Version:        2,
-				Marker:         true,
-				PayloadType:    96,
-				SequenceNumber: 947,
-				Timestamp:      54352 + 90000,
-				SSRC:           753621,
-			},
-			Payload: []byte{5, 6, 7, 8},
-		}), &net.UDPAddr{
-			IP:   net.ParseIP("127.0.0.1"),
-			Port: inTH.ClientPorts[0],
-		})
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
 		require.NoError(t, err)
 
 		req, err = conn.ReadRequest()
@@ -3316,21 +3566,15 @@ func TestClientPlayPacketNTP(t *testing.T) {
 		require.NoError(t, err)
 	}()
 
-	c := Client{}
-
 	recv := make(chan struct{})
-	first := false
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
 
 	err = readAll(&c, "rtsp://localhost:8554/teststream",
 		func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
-			if !first {
-				first = true
-			} else {
-				ntp, ok := c.PacketNTP(medi, pkt)
-				require.Equal(t, true, ok)
-				require.Equal(t, time.Date(2017, 8, 12, 15, 30, 1, 0, time.UTC), ntp.UTC())
-				close(recv)
-			}
+			close(recv)
 		})
 	require.NoError(t, err)
 	defer c.Close()
@@ -3338,27 +3582,28 @@ func TestClientPlayPacketNTP(t *testing.T) {
 	<-recv
 }
 
-func TestClientPlayBackChannel(t *testing.T) {
+func TestClientPlayInterleavedFrames(t *testing.T) {
 	l, err := net.Listen("tcp", "localhost:8554")
 	require.NoError(t, err)
 	defer l.Close()
 
+	frameRecv := make(chan struct{})
+
 	serverDone := make(chan struct{})
 	defer func() { <-serverDone }()
-
 	go func() {
 		defer close(serverDone)
 
-		nconn, err := l.Accept()
-		require.NoError(t, err)
+		nconn, err2 := l.Accept()
+		require.NoError(t, err2)
 		defer nconn.Close()
 		conn := conn.NewConn(nconn)
 
-		req, err := conn.ReadRequest()
-		require.NoError(t, err)
+		req, err2 := conn.ReadRequest()
+		require.NoError(t, err2)
 		require.Equal(t, base.Options, req.Method)
 
-		err = conn.WriteResponse(&base.Response{
+		err2 = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
 				"Public": base.HeaderValue{strings.Join([]string{
@@ -3368,124 +3613,85 @@ func TestClientPlayBackChannel(t *testing.T) {
 				}, ", ")},
 			},
 		})
-		require.NoError(t, err)
+		require.NoError(t, err2)
 
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
 		require.Equal(t, base.Describe, req.Method)
-		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
 
-		err = conn.WriteResponse(&base.Response{
+		medias := []*description.Media{testH264Media}
+
+		err2 = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
 				"Content-Type": base.HeaderValue{"application/sdp"},
 				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
 			},
-			Body: mediasToSDP([]*description.Media{
-				testH264Media,
-				{
-					Type:          description.MediaTypeAudio,
-					Formats:       []format.Format{&format.G711{}},
-					IsBackChannel: true,
-				},
-			}),
+			Body: mediasToSDP(medias),
 		})
-		require.NoError(t, err)
+		require.NoError(t, err2)
 
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
 		require.Equal(t, base.Setup, req.Method)
-		require.Equal(t, base.HeaderValue(nil), req.Header["Require"])
 
 		var inTH headers.Transport
-		err = inTH.Unmarshal(req.Header["Transport"])
-		require.NoError(t, err)
+		err2 = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err2)
 
 		th := headers.Transport{
-			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-			Protocol:       headers.TransportProtocolTCP,
-			InterleavedIDs: inTH.InterleavedIDs,
-		}
-
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-			Header: base.Header{
-				"Transport": th.Marshal(),
-			},
-		})
-		require.NoError(t, err)
-
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Setup, req.Method)
-		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
-
-		err = inTH.Unmarshal(req.Header["Transport"])
-		require.NoError(t, err)
-
-		th = headers.Transport{
-			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-			Protocol:       headers.TransportProtocolTCP,
-			InterleavedIDs: inTH.InterleavedIDs,
+			Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
 		}
+		th.Protocol = headers.TransportProtocolTCP
+		th.InterleavedIDs = inTH.InterleavedIDs
 
-		err = conn.WriteResponse(&base.Response{
+		err2 = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
 				"Transport": th.Marshal(),
 			},
 		})
-		require.NoError(t, err)
+		require.NoError(t, err2)
 
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
 		require.Equal(t, base.Play, req.Method)
-		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
 
-		err = conn.WriteResponse(&base.Response{
+		err2 = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 		})
-		require.NoError(t, err)
-
-		f, err := conn.ReadInterleavedFrame()
-		require.NoError(t, err)
-		require.Equal(t, 2, f.Channel)
-
-		f, err = conn.ReadInterleavedFrame()
-		require.NoError(t, err)
-		require.Equal(t, 3, f.Channel)
+		require.NoError(t, err2)
 
-		packets, err := rtcp.Unmarshal(f.Payload)
-		require.NoError(t, err)
+		err2 = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 6,
+			Payload: []byte{1, 2, 3, 4},
+		}, make([]byte, 1024))
+		require.NoError(t, err2)
 
-		sr, ok := packets[0].(*rtcp.SenderReport)
-		require.Equal(t, true, ok)
-		require.Equal(t, uint32(0x38F27A2F), sr.SSRC)
-		require.Equal(t, uint32(1), sr.PacketCount)
-		require.Equal(t, uint32(4), sr.OctetCount)
+		for {
+			var fr *base.InterleavedFrame
+			fr, err2 = conn.ReadInterleavedFrame()
+			require.NoError(t, err2)
 
-		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-			Channel: 0,
-			Payload: testRTPPacketMarshaled,
-		}, make([]byte, 1024))
-		require.NoError(t, err)
+			if fr.Channel == 8 {
+				require.Equal(t, []byte{5, 6, 7, 8}, fr.Payload)
+				close(frameRecv)
+				break
+			}
+		}
 
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
 		require.Equal(t, base.Teardown, req.Method)
-		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
 
-		err = conn.WriteResponse(&base.Response{
+		err2 = conn.WriteResponse(&base.Response{
 			StatusCode: base.StatusOK,
 		})
-		require.NoError(t, err)
+		require.NoError(t, err2)
 	}()
 
 	c := Client{
-		RequestBackChannels:  true,
-		Transport:            transportPtr(TransportTCP),
-		senderReportPeriod:   500 * time.Millisecond,
-		receiverReportPeriod: 750 * time.Millisecond,
+		Transport: transportPtr(TransportTCP),
 	}
 
 	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
@@ -3503,23 +3709,4001 @@ func TestClientPlayBackChannel(t *testing.T) {
 
 	recv := make(chan struct{})
 
-	c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+	c.OnInterleavedFrame(func(channel int, payload []byte) {
+		require.Equal(t, 6, channel)
+		require.Equal(t, []byte{1, 2, 3, 4}, payload)
 		close(recv)
 	})
 
 	_, err = c.Play(nil)
 	require.NoError(t, err)
 
-	err = c.WritePacketRTP(sd.Medias[1], &rtp.Packet{
-		Header: rtp.Header{
-			Version:     2,
-			PayloadType: 8,
-			CSRC:        []uint32{},
-			SSRC:        0x38F27A2F,
-		},
-		Payload: []byte{1, 2, 3, 4},
-	})
+	<-recv
+
+	err = c.WriteInterleavedFrame(1, []byte{5, 6, 7, 8})
+	require.Equal(t, liberrors.ErrClientInterleavedChannelInUse{Channel: 1}, err)
+
+	err = c.WriteInterleavedFrame(8, []byte{5, 6, 7, 8})
 	require.NoError(t, err)
 
-	<-recv
+	<-frameRecv
+}
+
+func TestClientPlayStreamEnded(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err2 := l.Accept()
+		require.NoError(t, err2)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err2 := conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Options, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{testH264Media}
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err2 = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err2)
+
+		th := headers.Transport{
+			Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		}
+		th.Protocol = headers.TransportProtocolTCP
+		th.InterleavedIDs = inTH.InterleavedIDs
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Play, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		err2 = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err2)
+
+		for _, pkt := range []rtcp.Packet{
+			&rtcp.SourceDescription{
+				Chunks: []rtcp.SourceDescriptionChunk{{
+					Source: testRTPPacket.SSRC,
+					Items: []rtcp.SourceDescriptionItem{
+						{Type: rtcp.SDESCNAME, Text: "myhost"},
+						{Type: rtcp.SDESName, Text: "myname"},
+					},
+				}},
+			},
+			&rtcp.Goodbye{
+				Sources: []uint32{testRTPPacket.SSRC},
+				Reason:  "end of recording",
+			},
+			&rtcp.Goodbye{
+				Sources: []uint32{testRTPPacket.SSRC},
+				Reason:  "repeated",
+			},
+		} {
+			err2 = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+				Channel: 1,
+				Payload: mustMarshalPacketRTCP(pkt),
+			}, make([]byte, 1024))
+			require.NoError(t, err2)
+		}
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+	}()
+
+	ended := make(chan string, 2)
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+		OnStreamEnded: func(reason string) {
+			ended <- reason
+		},
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Equal(t, "end of recording", <-ended)
+
+	stats := c.Stats()
+	require.Len(t, stats, 1)
+	require.Equal(t, "myhost", stats[0].SourceDescription.CNAME)
+	require.Equal(t, "myname", stats[0].SourceDescription.Name)
+
+	c.Close()
+	require.Len(t, ended, 0)
+}
+
+func TestClientPlayQualityReport(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err2 := l.Accept()
+		require.NoError(t, err2)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err2 := conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Options, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{testH264Media}
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err2 = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err2)
+
+		th := headers.Transport{
+			Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		}
+		th.Protocol = headers.TransportProtocolTCP
+		th.InterleavedIDs = inTH.InterleavedIDs
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Play, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		// 101 is missing
+		for _, seq := range []uint16{100, 102, 103} {
+			pkt := testRTPPacket
+			pkt.SequenceNumber = seq
+
+			err2 = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+				Channel: 0,
+				Payload: mustMarshalPacketRTP(&pkt),
+			}, make([]byte, 1024))
+			require.NoError(t, err2)
+		}
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+	}()
+
+	reports := make(chan []ReceiverStats, 10)
+
+	c := Client{
+		Transport:           transportPtr(TransportTCP),
+		QualityReportPeriod: 100 * time.Millisecond,
+		OnQualityReport: func(stats []ReceiverStats) {
+			reports <- stats
+		},
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for {
+		stats := <-reports
+		require.Len(t, stats, 1)
+
+		// one packet out of four is lost
+		if stats[0].Quality.LossRate == 0.25 {
+			require.Less(t, stats[0].Quality.Score, float64(100))
+			break
+		}
+	}
+}
+
+func TestClientPlayAutoReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		for i := 0; i < 2; i++ {
+			func() {
+				nconn, err2 := l.Accept()
+				require.NoError(t, err2)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err2 := conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Options, req.Method)
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Describe),
+							string(base.Setup),
+							string(base.Play),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err2)
+
+				req, err2 = conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Describe, req.Method)
+
+				medias := []*description.Media{testH264Media}
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Content-Type": base.HeaderValue{"application/sdp"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+					},
+					Body: mediasToSDP(medias),
+				})
+				require.NoError(t, err2)
+
+				req, err2 = conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Setup, req.Method)
+
+				var inTH headers.Transport
+				err2 = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err2)
+				require.Equal(t, headers.TransportProtocolTCP, inTH.Protocol)
+
+				th := headers.Transport{
+					Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+				}
+				th.Protocol = headers.TransportProtocolTCP
+				th.InterleavedIDs = inTH.InterleavedIDs
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": th.Marshal(),
+						"Session":   base.HeaderValue{"ABCDE"},
+					},
+				})
+				require.NoError(t, err2)
+
+				req, err2 = conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Play, req.Method)
+
+				var ra headers.Range
+				err2 = ra.Unmarshal(req.Header["Range"])
+				require.NoError(t, err2)
+				start := ra.Value.(*headers.RangeNPT).Start
+
+				if i == 0 {
+					require.Equal(t, 5*time.Second, start)
+				} else {
+					require.GreaterOrEqual(t, start, 5*time.Second)
+					require.Less(t, start, 6*time.Second)
+				}
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err2)
+
+				err2 = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+					Channel: 0,
+					Payload: testRTPPacketMarshaled,
+				}, make([]byte, 1024))
+				require.NoError(t, err2)
+
+				if i == 0 {
+					// drop the connection
+					return
+				}
+
+				req, err2 = conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Teardown, req.Method)
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err2)
+			}()
+		}
+	}()
+
+	packetRecv := make(chan struct{}, 2)
+	reconnecting := make(chan int, 1)
+	reconnected := make(chan struct{})
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+		AutoReconnect: &ClientAutoReconnect{
+			InitialDelay:   100 * time.Millisecond,
+			ResumePosition: true,
+		},
+		OnReconnecting: func(attempt int, err error) {
+			require.Error(t, err)
+			reconnecting <- attempt
+		},
+		OnReconnected: func() {
+			close(reconnected)
+		},
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	c.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+		require.Equal(t, &testRTPPacket, pkt)
+		packetRecv <- struct{}{}
+	})
+
+	_, err = c.Play(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: 5 * time.Second,
+		},
+	})
+	require.NoError(t, err)
+
+	<-packetRecv
+	require.Equal(t, 1, <-reconnecting)
+	<-reconnected
+	<-packetRecv
+}
+
+func TestClientPlayTCPSkipWhitespace(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		}
+		th.Protocol = headers.TransportProtocolTCP
+		th.InterleavedIDs = inTH.InterleavedIDs
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		_, err = nconn.Write([]byte("\r\n\r\n"))
+		require.NoError(t, err)
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		_, err = nconn.Write([]byte("\r\n"))
+		require.NoError(t, err)
+
+		pkt := testRTPPacket
+		pkt.SequenceNumber++
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: mustMarshalPacketRTP(&pkt),
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	recv := make(chan struct{})
+	n := 0
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+			n++
+			if n == 2 {
+				close(recv)
+			}
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	<-recv
+	require.Equal(t, uint64(6), atomic.LoadUint64(c.BytesSkipped))
+}
+
+func TestClientPlay3GPP(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	feedbackReceived := make(chan struct{})
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var ad headers.Adaptation3GPP
+		err = ad.Unmarshal(req.Header["3GPP-Adaptation"])
+		require.NoError(t, err)
+		require.Equal(t, headers.Adaptation3GPP{{
+			URL:  req.URL.String(),
+			Size: udpKernelReadBufferSize,
+		}}, ad)
+
+		qoeMetrics := headers.QoEMetrics3GPP{{
+			URL:     req.URL.String(),
+			Metrics: []string{"Successive_Loss", "Jitter_Duration", "Rebuffering_Duration"},
+			Rate:    uintPtr(1),
+		}}
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		}
+		th.Protocol = headers.TransportProtocolTCP
+		th.InterleavedIDs = inTH.InterleavedIDs
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport":        th.Marshal(),
+				"3GPP-QoE-Metrics": qoeMetrics.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+		require.Equal(t, qoeMetrics.Marshal(), req.Header["3GPP-QoE-Metrics"])
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		for _, seq := range []uint16{1, 4} {
+			pkt := testRTPPacket
+			pkt.SequenceNumber = seq
+
+			err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+				Channel: 0,
+				Payload: mustMarshalPacketRTP(&pkt),
+			}, make([]byte, 1024))
+			require.NoError(t, err)
+		}
+
+		readFeedback := func() map[string][]string {
+			req, err = conn.ReadRequest()
+			require.NoError(t, err)
+			require.Equal(t, base.SetParameter, req.Method)
+
+			var fb headers.QoEFeedback3GPP
+			err = fb.Unmarshal(req.Header["3GPP-QoE-Feedback"])
+			require.NoError(t, err)
+			require.Equal(t, 1, len(fb))
+			require.Equal(t, qoeMetrics[0].URL, fb[0].URL)
+
+			ret := make(map[string][]string)
+			for _, m := range fb[0].Metrics {
+				ret[m.Name] = m.Values
+			}
+			return ret
+		}
+
+		fb := readFeedback()
+		require.Equal(t, []string{"2"}, fb["Successive_Loss"])
+		require.Equal(t, 1, len(fb["Jitter_Duration"]))
+		require.Equal(t, []string(nil), fb["Rebuffering_Duration"])
+
+		close(feedbackReceived)
+
+		// final report
+		fb = readFeedback()
+		require.Equal(t, []string{"0"}, fb["Successive_Loss"])
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport:         transportPtr(TransportTCP),
+		Compatibility3GPP: true,
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	<-feedbackReceived
+}
+
+func TestClientPlaySeek(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		var ra headers.Range
+		err = ra.Unmarshal(req.Header["Range"])
+		require.NoError(t, err)
+		require.Equal(t, headers.Range{
+			Value: &headers.RangeNPT{
+				Start: 5500 * time.Millisecond,
+			},
+		}, ra)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Pause, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = ra.Unmarshal(req.Header["Range"])
+		require.NoError(t, err)
+		require.Equal(t, headers.Range{
+			Value: &headers.RangeNPT{
+				Start: 6400 * time.Millisecond,
+			},
+		}, ra)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	_, err = c.Play(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: 5500 * time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = c.Seek(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: 6400 * time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestClientPlayAddRemoveMedia(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	added := make(chan struct{})
+	removed := make(chan struct{})
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+					string(base.Pause),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{
+			{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{PayloadTyp: 96, PacketizationMode: 1}},
+			},
+			{
+				Type:    description.MediaTypeAudio,
+				Formats: []format.Format{&format.G711{MULaw: true}},
+			},
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		setup := func(u string) {
+			req, err2 := conn.ReadRequest()
+			require.NoError(t, err2)
+			require.Equal(t, base.Setup, req.Method)
+			require.Equal(t, mustParseURL(u), req.URL)
+
+			var inTH headers.Transport
+			err2 = inTH.Unmarshal(req.Header["Transport"])
+			require.NoError(t, err2)
+
+			err2 = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"Transport": headers.Transport{
+						Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+						Protocol:       headers.TransportProtocolTCP,
+						InterleavedIDs: inTH.InterleavedIDs,
+					}.Marshal(),
+					"Session": base.HeaderValue{"ABCDE"},
+				},
+			})
+			require.NoError(t, err2)
+		}
+
+		setup("rtsp://localhost:8554/teststream/trackID=0")
+
+		for _, method := range []base.Method{base.Play, base.Pause} {
+			req, err = conn.ReadRequest()
+			require.NoError(t, err)
+			require.Equal(t, method, req.Method)
+
+			err = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusOK,
+			})
+			require.NoError(t, err)
+		}
+
+		setup("rtsp://localhost:8554/teststream/trackID=1")
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		var ra headers.Range
+		err = ra.Unmarshal(req.Header["Range"])
+		require.NoError(t, err)
+		require.Equal(t, headers.Range{
+			Value: &headers.RangeNPT{
+				Start: 5500 * time.Millisecond,
+			},
+		}, ra)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		<-added
+
+		pkt := testRTPPacket
+		pkt.PayloadType = 0
+		byts, _ := pkt.Marshal()
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 2,
+			Payload: byts,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream/trackID=0"), req.URL)
+		require.Equal(t, base.HeaderValue{"ABCDE"}, req.Header["Session"])
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		<-removed
+
+		// packets of the removed media are discarded
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 2,
+			Payload: byts,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream/"), req.URL)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	_, err = c.AddMedia(sd.BaseURL, sd.Medias[0], 0, 0)
+	require.NoError(t, err)
+
+	_, err = c.Play(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: 5500 * time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = c.AddMedia(sd.BaseURL, sd.Medias[1], 0, 0)
+	require.NoError(t, err)
+
+	recv := make(chan *description.Media, 2)
+
+	c.OnPacketRTPAny(func(medi *description.Media, _ format.Format, _ *rtp.Packet) {
+		recv <- medi
+	})
+
+	close(added)
+	require.Equal(t, sd.Medias[1], <-recv)
+
+	_, err = c.RemoveMedia(sd.Medias[0])
+	require.NoError(t, err)
+
+	_, err = c.RemoveMedia(sd.Medias[1])
+	require.Equal(t, liberrors.ErrClientCannotTeardownLastMedia{}, err)
+
+	close(removed)
+	require.Equal(t, sd.Medias[1], <-recv)
+}
+
+func TestClientPlayKeepalive(t *testing.T) {
+	for _, ca := range []string{"response before frame", "response after frame", "no response"} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
+
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq": req.Header["CSeq"],
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Describe),
+							string(base.Setup),
+							string(base.Play),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Describe, req.Method)
+
+				medias := []*description.Media{testH264Media}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq":         req.Header["CSeq"],
+						"Content-Type": base.HeaderValue{"application/sdp"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+					},
+					Body: mediasToSDP(medias),
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq": req.Header["CSeq"],
+						"Transport": headers.Transport{
+							Protocol:       headers.TransportProtocolTCP,
+							Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+							InterleavedIDs: &[2]int{0, 1},
+						}.Marshal(),
+						"Session": headers.Session{
+							Session: "ABCDE",
+							Timeout: uintPtr(1),
+						}.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Play, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq": req.Header["CSeq"],
+					},
+				})
+				require.NoError(t, err)
+
+				recv := make(chan struct{})
+				go func() {
+					defer close(recv)
+					req, err = conn.ReadRequest()
+					require.NoError(t, err)
+					require.Equal(t, base.Options, req.Method)
+				}()
+
+				select {
+				case <-recv:
+				case <-time.After(2 * time.Second):
+					t.Errorf("should not happen")
+				}
+
+				if ca == "response before frame" {
+					err = conn.WriteResponse(&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq": req.Header["CSeq"],
+						},
+					})
+					require.NoError(t, err)
+				}
+
+				err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+					Channel: 0,
+					Payload: testRTPPacketMarshaled,
+				}, make([]byte, 1024))
+				require.NoError(t, err)
+
+				if ca == "response after frame" {
+					err = conn.WriteResponse(&base.Response{
+						StatusCode: base.StatusOK,
+					})
+					require.NoError(t, err)
+				}
+
+				pkt := testRTPPacket
+				pkt.SequenceNumber++
+
+				err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+					Channel: 0,
+					Payload: mustMarshalPacketRTP(&pkt),
+				}, make([]byte, 1024))
+				require.NoError(t, err)
+			}()
+
+			done1 := make(chan struct{})
+			done2 := make(chan struct{})
+			n := 0
+			m := 0
+
+			v := TransportTCP
+			c := Client{
+				Transport: &v,
+				OnResponse: func(res *base.Response) {
+					m++
+					if ca != "no response" {
+						if m >= 5 {
+							close(done2)
+						}
+					} else {
+						if m >= 4 {
+							close(done2)
+						}
+					}
+				},
+			}
+
+			err = readAll(&c, "rtsp://localhost:8554/teststream",
+				func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+					expected := testRTPPacket
+					expected.SequenceNumber += uint16(n)
+					require.Equal(t, &expected, pkt)
+					n++
+					if n == 2 {
+						close(done1)
+					}
+				})
+			require.NoError(t, err)
+			defer c.Close()
+
+			<-done1
+			<-done2
+		})
+	}
+}
+
+func TestClientPlayKeepaliveMethod(t *testing.T) {
+	for _, ca := range []string{"auto", "set_parameter"} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
+
+				nconn, err2 := l.Accept()
+				require.NoError(t, err2)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err2 := conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Options, req.Method)
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq": req.Header["CSeq"],
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Describe),
+							string(base.Setup),
+							string(base.Play),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err2)
+
+				req, err2 = conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Describe, req.Method)
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq":         req.Header["CSeq"],
+						"Content-Type": base.HeaderValue{"application/sdp"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+					},
+					Body: mediasToSDP([]*description.Media{testH264Media}),
+				})
+				require.NoError(t, err2)
+
+				req, err2 = conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Setup, req.Method)
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq": req.Header["CSeq"],
+						"Transport": headers.Transport{
+							Protocol:       headers.TransportProtocolTCP,
+							Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+							InterleavedIDs: &[2]int{0, 1},
+						}.Marshal(),
+						"Session": headers.Session{
+							Session: "ABCDE",
+							Timeout: uintPtr(1),
+						}.Marshal(),
+					},
+				})
+				require.NoError(t, err2)
+
+				req, err2 = conn.ReadRequest()
+				require.NoError(t, err2)
+				require.Equal(t, base.Play, req.Method)
+
+				err2 = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"CSeq": req.Header["CSeq"],
+					},
+				})
+				require.NoError(t, err2)
+
+				var expected []base.Method
+				var codes []base.StatusCode
+
+				if ca == "auto" {
+					expected = []base.Method{base.Options, base.GetParameter, base.SetParameter, base.SetParameter}
+					codes = []base.StatusCode{
+						base.StatusMethodNotAllowed,
+						base.StatusNotImplemented,
+						base.StatusOK,
+						base.StatusOK,
+					}
+				} else {
+					expected = []base.Method{base.SetParameter, base.SetParameter}
+					codes = []base.StatusCode{base.StatusMethodNotAllowed, base.StatusOK}
+				}
+
+				for i, method := range expected {
+					req, err2 = conn.ReadRequest()
+					require.NoError(t, err2)
+					require.Equal(t, method, req.Method)
+
+					err2 = conn.WriteResponse(&base.Response{
+						StatusCode: codes[i],
+						Header: base.Header{
+							"CSeq": req.Header["CSeq"],
+						},
+					})
+					require.NoError(t, err2)
+				}
+			}()
+
+			c := Client{
+				Transport: transportPtr(TransportTCP),
+			}
+
+			if ca == "set_parameter" {
+				c.KeepaliveMethod = ClientKeepaliveMethodSetParameter
+			}
+
+			err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+			require.NoError(t, err)
+			defer c.Close()
+
+			<-serverDone
+		})
+	}
+}
+func TestClientPlayDifferentSource(t *testing.T) {
+	packetRecv := make(chan struct{})
+
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value"), req.URL)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value"), req.URL)
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/test/stream?param=value/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value/"+medias[0].Control), req.URL)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:    headers.TransportProtocolUDP,
+			ClientPorts: inTH.ClientPorts,
+			ServerPorts: &[2]int{34556, 34557},
+			Source:      ipPtr(net.ParseIP("127.0.1.1")),
+		}
+
+		l1, err := net.ListenPacket("udp", "127.0.1.1:34556")
+		require.NoError(t, err)
+		defer l1.Close()
+
+		l2, err := net.ListenPacket("udp", "127.0.1.1:34557")
+		require.NoError(t, err)
+		defer l2.Close()
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value/"), req.URL)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		// server -> client (RTP)
+		_, err = l1.WriteTo(testRTPPacketMarshaled, &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: th.ClientPorts[0],
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/test/stream?param=value/"), req.URL)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport: transportPtr(TransportUDP),
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/test/stream?param=value",
+		func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+			require.Equal(t, &testRTPPacket, pkt)
+			close(packetRecv)
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	<-packetRecv
+}
+
+func TestClientPlayDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		proto string
+		name  string
+	}{
+		{"udp", "rtp invalid"},
+		{"udp", "rtcp invalid"},
+		{"udp", "rtp packets lost"},
+		{"udp", "rtp unknown payload type"},
+		{"udp", "wrong ssrc"},
+		{"udp", "rtcp too big"},
+		{"udp", "rtp too big"},
+		{"tcp", "rtp invalid"},
+		{"tcp", "rtcp invalid"},
+		{"tcp", "rtp unknown payload type"},
+		{"tcp", "wrong ssrc"},
+		{"tcp", "rtcp too big"},
+	} {
+		t.Run(ca.proto+" "+ca.name, func(t *testing.T) {
+			errorRecv := make(chan struct{})
+
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
+
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Describe),
+							string(base.Setup),
+							string(base.Play),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Describe, req.Method)
+
+				medias := []*description.Media{{
+					Type: description.MediaTypeApplication,
+					Formats: []format.Format{&format.Generic{
+						PayloadTyp: 97,
+						RTPMa:      "private/90000",
+					}},
+				}}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Content-Type": base.HeaderValue{"application/sdp"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/stream/"},
+					},
+					Body: mediasToSDP(medias),
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				th := headers.Transport{
+					Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+				}
+
+				if ca.proto == "udp" {
+					th.Protocol = headers.TransportProtocolUDP
+					th.ClientPorts = inTH.ClientPorts
+					th.ServerPorts = &[2]int{34556, 34557}
+				} else {
+					th.Protocol = headers.TransportProtocolTCP
+					th.InterleavedIDs = inTH.InterleavedIDs
+				}
+
+				var l1 net.PacketConn
+				var l2 net.PacketConn
+
+				if ca.proto == "udp" {
+					l1, err = net.ListenPacket("udp", "127.0.0.1:34556")
+					require.NoError(t, err)
+					defer l1.Close()
+
+					l2, err = net.ListenPacket("udp", "127.0.0.1:34557")
+					require.NoError(t, err)
+					defer l2.Close()
+				}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": th.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Play, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				var writeRTP func(buf []byte)
+				var writeRTCP func(byts []byte)
+
+				if ca.proto == "udp" { //nolint:dupl
+					writeRTP = func(byts []byte) {
+						_, err = l1.WriteTo(byts, &net.UDPAddr{
+							IP:   net.ParseIP("127.0.0.1"),
+							Port: th.ClientPorts[0],
+						})
+						require.NoError(t, err)
+					}
+
+					writeRTCP = func(byts []byte) {
+						_, err = l2.WriteTo(byts, &net.UDPAddr{
+							IP:   net.ParseIP("127.0.0.1"),
+							Port: th.ClientPorts[1],
+						})
+						require.NoError(t, err)
+					}
+				} else {
+					writeRTP = func(byts []byte) {
+						err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+							Channel: 0,
+							Payload: byts,
+						}, make([]byte, 2048))
+						require.NoError(t, err)
+					}
+
+					writeRTCP = func(byts []byte) {
+						err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+							Channel: 1,
+							Payload: byts,
+						}, make([]byte, 2048))
+						require.NoError(t, err)
+					}
+				}
+
+				switch { //nolint:dupl
+				case ca.name == "rtp invalid":
+					writeRTP([]byte{0x01, 0x02})
+
+				case ca.name == "rtcp invalid":
+					writeRTCP([]byte{0x01, 0x02})
+
+				case ca.name == "rtcp too big":
+					writeRTCP(bytes.Repeat([]byte{0x01, 0x02}, 2000/2))
+
+				case ca.name == "rtp packets lost":
+					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
+						Header: rtp.Header{
+							PayloadType:    97,
+							SequenceNumber: 30,
+						},
+					}))
+
+					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
+						Header: rtp.Header{
+							PayloadType:    97,
+							SequenceNumber: 100,
+						},
+					}))
+
+				case ca.name == "rtp unknown payload type":
+					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
+						Header: rtp.Header{
+							PayloadType: 111,
+						},
+					}))
+
+				case ca.name == "wrong ssrc":
+					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
+						Header: rtp.Header{
+							PayloadType:    97,
+							SequenceNumber: 1,
+							SSRC:           123,
+						},
+					}))
+
+					writeRTP(mustMarshalPacketRTP(&rtp.Packet{
+						Header: rtp.Header{
+							PayloadType:    97,
+							SequenceNumber: 2,
+							SSRC:           456,
+						},
+					}))
+
+				case ca.proto == "udp" && ca.name == "rtp too big":
+					_, err := l1.WriteTo(bytes.Repeat([]byte{0x01, 0x02}, 2000/2), &net.UDPAddr{
+						IP:   net.ParseIP("127.0.0.1"),
+						Port: th.ClientPorts[0],
+					})
+					require.NoError(t, err)
+				}
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Teardown, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+			}()
+
+			c := Client{
+				Transport: func() *Transport {
+					if ca.proto == "udp" {
+						v := TransportUDP
+						return &v
+					}
+					v := TransportTCP
+					return &v
+				}(),
+				OnPacketLost: func(err error) {
+					require.EqualError(t, err, "69 RTP packets lost")
+					close(errorRecv)
+				},
+				OnDecodeError: func(err error) {
+					switch {
+					case ca.name == "rtp invalid":
+						require.EqualError(t, err, "RTP header size insufficient: 2 < 4")
+
+					case ca.name == "rtcp invalid":
+						require.EqualError(t, err, "rtcp: packet too short")
+
+					case ca.name == "rtp unknown payload type":
+						require.EqualError(t, err, "received RTP packet with unknown payload type: 111")
+
+					case ca.name == "wrong ssrc":
+						require.EqualError(t, err, "received packet with wrong SSRC 456, expected 123")
+
+					case ca.proto == "udp" && ca.name == "rtcp too big":
+						require.EqualError(t, err, "RTCP packet is too big to be read with UDP")
+
+					case ca.proto == "tcp" && ca.name == "rtcp too big":
+						require.EqualError(t, err, "RTCP packet size (2000) is greater than maximum allowed (1472)")
+
+					case ca.proto == "udp" && ca.name == "rtp too big":
+						require.EqualError(t, err, "RTP packet is too big to be read with UDP")
+
+					default:
+						t.Errorf("unexpected")
+					}
+					close(errorRecv)
+				},
+			}
+
+			err = readAll(&c, "rtsp://localhost:8554/stream", nil)
+			require.NoError(t, err)
+			defer c.Close()
+
+			<-errorRecv
+		})
+	}
+}
+
+func TestClientPlayPacketNTP(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		l1, err := net.ListenPacket("udp", "localhost:27556")
+		require.NoError(t, err)
+		defer l1.Close()
+
+		l2, err := net.ListenPacket("udp", "localhost:27557")
+		require.NoError(t, err)
+		defer l2.Close()
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": headers.Transport{
+					Protocol:    headers.TransportProtocolUDP,
+					Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+					ServerPorts: &[2]int{27556, 27557},
+					ClientPorts: inTH.ClientPorts,
+				}.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		// skip firewall opening
+		buf := make([]byte, 2048)
+		_, _, err = l2.ReadFrom(buf)
+		require.NoError(t, err)
+
+		_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 946,
+				Timestamp:      54352,
+				SSRC:           753621,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[0],
+		})
+		require.NoError(t, err)
+
+		// wait for the packet's SSRC to be saved
+		time.Sleep(100 * time.Millisecond)
+
+		_, err = l2.WriteTo(mustMarshalPacketRTCP(&rtcp.SenderReport{
+			SSRC:        753621,
+			NTPTime:     ntpTimeGoToRTCP(time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)),
+			RTPTime:     54352,
+			PacketCount: 1,
+			OctetCount:  4,
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[1],
+		})
+		require.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+
+		_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 947,
+				Timestamp:      54352 + 90000,
+				SSRC:           753621,
+			},
+			Payload: []byte{5, 6, 7, 8},
+		}), &net.UDPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: inTH.ClientPorts[0],
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{}
+
+	recv := make(chan struct{})
+	first := false
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+			if !first {
+				first = true
+			} else {
+				ntp, ok := c.PacketNTP(medi, pkt)
+				require.Equal(t, true, ok)
+				require.Equal(t, time.Date(2017, 8, 12, 15, 30, 1, 0, time.UTC), ntp.UTC())
+				close(recv)
+			}
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	<-recv
+}
+
+func TestClientPlayBackChannel(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP([]*description.Media{
+				testH264Media,
+				{
+					Type:          description.MediaTypeAudio,
+					Formats:       []format.Format{&format.G711{}},
+					IsBackChannel: true,
+				},
+			}),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, base.HeaderValue(nil), req.Header["Require"])
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
+
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th = headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		f, err := conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+		require.Equal(t, 2, f.Channel)
+
+		f, err = conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+		require.Equal(t, 3, f.Channel)
+
+		packets, err := rtcp.Unmarshal(f.Payload)
+		require.NoError(t, err)
+
+		sr, ok := packets[0].(*rtcp.SenderReport)
+		require.Equal(t, true, ok)
+		require.Equal(t, uint32(0x38F27A2F), sr.SSRC)
+		require.Equal(t, uint32(1), sr.PacketCount)
+		require.Equal(t, uint32(4), sr.OctetCount)
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		f, err = conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+		require.Equal(t, 3, f.Channel)
+
+		packets, err = rtcp.Unmarshal(f.Payload)
+		require.NoError(t, err)
+		require.Equal(t, []rtcp.Packet{&rtcp.Goodbye{
+			Sources: []uint32{0x38F27A2F},
+		}}, packets)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+		require.Equal(t, base.HeaderValue{"www.onvif.org/ver20/backchannel"}, req.Header["Require"])
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		RequestBackChannels:  true,
+		Transport:            transportPtr(TransportTCP),
+		senderReportPeriod:   500 * time.Millisecond,
+		receiverReportPeriod: 750 * time.Millisecond,
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan struct{})
+
+	c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+		close(recv)
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	err = c.WritePacketRTP(sd.Medias[1], &rtp.Packet{
+		Header: rtp.Header{
+			Version:     2,
+			PayloadType: 8,
+			CSRC:        []uint32{},
+			SSRC:        0x38F27A2F,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	})
+	require.NoError(t, err)
+
+	<-recv
+}
+
+func TestClientPlayONVIFReplay(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	ntp := time.Date(2023, 6, 10, 12, 0, 0, 500000000, time.UTC)
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP([]*description.Media{testH264Media}),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, base.HeaderValue{"onvif-replay"}, req.Header["Require"])
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+		require.Equal(t, base.HeaderValue{"onvif-replay"}, req.Header["Require"])
+		require.Equal(t, base.HeaderValue{"no"}, req.Header["Rate-Control"])
+		require.Equal(t, base.HeaderValue{"yes"}, req.Header["Immediate"])
+		require.Equal(t, base.HeaderValue{"intra"}, req.Header["Frames"])
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		pkt := testRTPPacket
+		err = onvifreplay.Extension{
+			NTPTime:    ntp,
+			CleanPoint: true,
+		}.Marshal(&pkt)
+		require.NoError(t, err)
+
+		byts, err := pkt.Marshal()
+		require.NoError(t, err)
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: byts,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+		ONVIFReplay: &ClientONVIFReplay{
+			DisableRateControl: true,
+			Immediate:          true,
+			Frames:             "intra",
+		},
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan struct{})
+
+	c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+		recvNTP, ok := c.PacketNTP(sd.Medias[0], pkt)
+		require.Equal(t, true, ok)
+		require.True(t, ntp.Equal(recvNTP))
+		close(recv)
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	<-recv
+}
+
+func TestClientPlayStreamStall(t *testing.T) {
+	for _, ca := range []string{
+		"not started",
+		"stalled",
+		"sparse",
+		"capped",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			sendPacket := make(chan struct{})
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+
+			go func() {
+				defer close(serverDone)
+
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Describe),
+							string(base.Setup),
+							string(base.Play),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Describe, req.Method)
+
+				medias := []*description.Media{testH264Media}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Content-Type": base.HeaderValue{"application/sdp"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+					},
+					Body: mediasToSDP(medias),
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				th := headers.Transport{
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					Protocol:       headers.TransportProtocolTCP,
+					InterleavedIDs: inTH.InterleavedIDs,
+				}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": th.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Play, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				var gaps []time.Duration
+
+				switch ca {
+				case "stalled":
+					gaps = []time.Duration{0}
+
+				case "sparse":
+					// the second gap is longer than StallTimeout,
+					// but shorter than twice the first one.
+					gaps = []time.Duration{0, 350 * time.Millisecond, 600 * time.Millisecond}
+				}
+
+				writeRTP := func(i int) {
+					pkt := testRTPPacket
+					pkt.SequenceNumber += uint16(i)
+
+					err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+						Channel: 0,
+						Payload: mustMarshalPacketRTP(&pkt),
+					}, make([]byte, 1024))
+					require.NoError(t, err)
+				}
+
+				for i, gap := range gaps {
+					time.Sleep(gap)
+					writeRTP(i)
+				}
+
+				if ca == "capped" {
+					for i := 0; i < 4; i++ {
+						<-sendPacket
+						writeRTP(i)
+					}
+				}
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Teardown, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+			}()
+
+			stallRecv := make(chan error, 1)
+			packetRecv := make(chan struct{}, 4)
+
+			var timeMutex sync.Mutex
+			curTime := time.Now()
+
+			// advances the clock of the client
+			advanceTime := func(d time.Duration) {
+				timeMutex.Lock()
+				defer timeMutex.Unlock()
+				curTime = curTime.Add(d)
+			}
+
+			c := Client{
+				Transport:          transportPtr(TransportTCP),
+				StallTimeout:       400 * time.Millisecond,
+				checkTimeoutPeriod: 50 * time.Millisecond,
+				OnStreamStall: func(err error) {
+					stallRecv <- err
+				},
+			}
+
+			if ca == "capped" {
+				c.StallTimeout = 1 * time.Second
+				c.timeNow = func() time.Time {
+					timeMutex.Lock()
+					defer timeMutex.Unlock()
+					return curTime
+				}
+			}
+
+			err = readAll(&c, "rtsp://localhost:8554/teststream",
+				func(_ *description.Media, _ format.Format, _ *rtp.Packet) {
+					packetRecv <- struct{}{}
+				})
+			require.NoError(t, err)
+			defer c.Close()
+
+			switch ca {
+			case "not started":
+				err = <-stallRecv
+				require.Equal(t, liberrors.ErrClientStreamNotStarted{}, err)
+
+			case "stalled":
+				<-packetRecv
+				err = <-stallRecv
+				require.IsType(t, liberrors.ErrClientStreamStalled{}, err)
+				require.GreaterOrEqual(t, err.(liberrors.ErrClientStreamStalled).Duration, 400*time.Millisecond)
+
+			case "sparse":
+				for i := 0; i < 3; i++ {
+					<-packetRecv
+				}
+
+				select {
+				case err = <-stallRecv:
+					t.Errorf("unexpected stall: %v", err)
+				default:
+				}
+
+			case "capped":
+				// each gap is shorter than twice the previous one,
+				// therefore the threshold would grow indefinitely without a cap.
+				for _, gap := range []time.Duration{0, 900 * time.Millisecond, 1700 * time.Millisecond, 3300 * time.Millisecond} {
+					advanceTime(gap)
+					sendPacket <- struct{}{}
+					<-packetRecv
+				}
+
+				advanceTime(5 * time.Second)
+
+				err = <-stallRecv
+				require.Equal(t, liberrors.ErrClientStreamStalled{Duration: 5 * time.Second}, err)
+			}
+		})
+	}
+}
+
+func TestClientPlayClockRateOverride(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		// audio stream with a wrong clock rate
+		medias := []*description.Media{{
+			Type: description.MediaTypeAudio,
+			Formats: []format.Format{&format.Generic{
+				PayloadTyp: 97,
+				RTPMa:      "private/90000",
+			}},
+		}}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		for i, ts := range []uint32{1000, 1000 + 8000} {
+			byts, err := (&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    97,
+					SequenceNumber: 100 + uint16(i),
+					Timestamp:      ts,
+					SSRC:           0x38F27A2F,
+				},
+				Payload: []byte{1, 2, 3, 4},
+			}).Marshal()
+			require.NoError(t, err)
+
+			err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+				Channel: 0,
+				Payload: byts,
+			}, make([]byte, 1024))
+			require.NoError(t, err)
+		}
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	ptsRecv := make(chan time.Duration, 2)
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+		ClockRateOverride: func(medi *description.Media, forma format.Format) int {
+			if medi.Type == description.MediaTypeAudio && forma.ClockRate() == 90000 {
+				return 8000
+			}
+			return 0
+		},
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(medi *description.Media, _ format.Format, pkt *rtp.Packet) {
+			pts, ok := c.PacketPTS(medi, pkt)
+			require.True(t, ok)
+			ptsRecv <- pts
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Equal(t, time.Duration(0), <-ptsRecv)
+	require.Equal(t, 1*time.Second, <-ptsRecv)
+}
+
+func TestClientPlayRTPInfo(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	played := make(chan struct{})
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{{
+			Type: description.MediaTypeAudio,
+			Formats: []format.Format{&format.Generic{
+				PayloadTyp: 97,
+				RTPMa:      "private/8000",
+			}},
+		}}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"RTP-Info": headers.RTPInfo{{
+					URL:            "rtsp://localhost:8554/teststream/trackID=0",
+					SequenceNumber: uint16Ptr(100),
+					Timestamp:      uint32Ptr(2000),
+				}}.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		<-played
+
+		for i, ts := range []uint32{0, 1000, 1000 + 8000} {
+			byts, err := (&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    97,
+					SequenceNumber: 99 + uint16(i),
+					Timestamp:      ts,
+					SSRC:           0x38F27A2F,
+				},
+				Payload: []byte{1, 2, 3, 4},
+			}).Marshal()
+			require.NoError(t, err)
+
+			err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+				Channel: 0,
+				Payload: byts,
+			}, make([]byte, 1024))
+			require.NoError(t, err)
+		}
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	recv := make(chan *rtp.Packet, 3)
+	ptsRecv := make(chan time.Duration, 3)
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	var medi *description.Media
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(medi2 *description.Media, _ format.Format, pkt *rtp.Packet) {
+			medi = medi2
+			pts, ok := c.PacketPTS(medi2, pkt)
+			require.True(t, ok)
+			recv <- pkt
+			ptsRecv <- pts
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	close(played)
+
+	// the packet that precedes the one in RTP-Info is discarded
+	require.Equal(t, uint16(100), (<-recv).SequenceNumber)
+	require.Equal(t, -125*time.Millisecond, <-ptsRecv)
+
+	require.Equal(t, uint16(101), (<-recv).SequenceNumber)
+	require.Equal(t, 875*time.Millisecond, <-ptsRecv)
+
+	require.Equal(t, &headers.RTPInfoEntry{
+		URL:            "rtsp://localhost:8554/teststream/trackID=0",
+		SequenceNumber: uint16Ptr(100),
+		Timestamp:      uint32Ptr(2000),
+	}, c.PlayRTPInfo(medi))
+}
+
+func TestClientPlayUDPSocketPool(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	l1, err := net.ListenPacket("udp", "localhost:34556")
+	require.NoError(t, err)
+	defer l1.Close()
+
+	clientPorts := make(chan [2]int, 2)
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		for i := 0; i < 2; i++ {
+			func() {
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Describe),
+							string(base.Setup),
+							string(base.Play),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Describe, req.Method)
+
+				medias := []*description.Media{testH264Media}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Content-Type": base.HeaderValue{"application/sdp"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+					},
+					Body: mediasToSDP(medias),
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				clientPorts <- *inTH.ClientPorts
+
+				th := headers.Transport{
+					Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+					Protocol:    headers.TransportProtocolUDP,
+					ClientPorts: inTH.ClientPorts,
+					ServerPorts: &[2]int{34556, 34557},
+				}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": th.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Play, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				clientAddr := &net.UDPAddr{
+					IP:   net.ParseIP("127.0.0.1"),
+					Port: inTH.ClientPorts[0],
+				}
+
+				_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: 100,
+						SSRC:           0x38F27A2F,
+					},
+					Payload: []byte{byte(i + 1)},
+				}), clientAddr)
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Teardown, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				if i == 0 {
+					// packet received after the end of the session,
+					// that must not be delivered to the next session.
+					_, err = l1.WriteTo(mustMarshalPacketRTP(&rtp.Packet{
+						Header: rtp.Header{
+							Version:        2,
+							PayloadType:    96,
+							SequenceNumber: 101,
+							SSRC:           0x38F27A2F,
+						},
+						Payload: []byte{0xFF},
+					}), clientAddr)
+					require.NoError(t, err)
+				}
+			}()
+		}
+	}()
+
+	pool := &ClientUDPSocketPool{}
+	defer pool.Close()
+
+	for i := 0; i < 2; i++ {
+		packetRecv := make(chan []byte, 10)
+
+		c := Client{
+			Transport:     transportPtr(TransportUDP),
+			UDPSocketPool: pool,
+		}
+
+		err = readAll(&c, "rtsp://localhost:8554/teststream",
+			func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+				packetRecv <- pkt.Payload
+			})
+		require.NoError(t, err)
+
+		require.Equal(t, []byte{byte(i + 1)}, <-packetRecv)
+
+		c.Close()
+
+		// wait for the stale packet to be received by the socket
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	ports1 := <-clientPorts
+	ports2 := <-clientPorts
+	require.Equal(t, ports1, ports2)
+}
+
+func TestClientPlayProfileToken(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream?channel=1&profile=Profile_1"), req.URL)
+		require.Equal(t, base.HeaderValue{"Profile_1"}, req.Header["X-Profile-Token"])
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream/trackID=0?profile=Profile_1"), req.URL)
+		require.Equal(t, base.HeaderValue{"Profile_1"}, req.Header["X-Profile-Token"])
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": headers.Transport{
+					Protocol:       headers.TransportProtocolTCP,
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					InterleavedIDs: inTH.InterleavedIDs,
+				}.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream/"), req.URL)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = readRequestIgnoreFrames(conn)
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport:              transportPtr(TransportTCP),
+		ProfileToken:           "Profile_1",
+		ProfileTokenQueryParam: "profile",
+		ProfileTokenHeader:     "X-Profile-Token",
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream?channel=1", nil)
+	require.NoError(t, err)
+	c.Close()
+}
+
+func TestClientPlayTransportOrder(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		order []Transport
+	}{
+		{
+			"multicast then tcp",
+			[]Transport{TransportUDPMulticast, TransportTCP},
+		},
+		{
+			"tcp then udp",
+			[]Transport{TransportTCP, TransportUDP},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
+
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Describe),
+							string(base.Setup),
+							string(base.Play),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Describe, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Content-Type": base.HeaderValue{"application/sdp"},
+						"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+					},
+					Body: mediasToSDP([]*description.Media{testH264Media}),
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				if ca.order[0] == TransportUDPMulticast {
+					require.Equal(t, deliveryPtr(headers.TransportDeliveryMulticast), inTH.Delivery)
+				} else {
+					require.Equal(t, headers.TransportProtocolTCP, inTH.Protocol)
+				}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusUnsupportedTransport,
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+
+				inTH = headers.Transport{}
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				th := headers.Transport{
+					Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+				}
+
+				if ca.order[1] == TransportTCP {
+					require.Equal(t, headers.TransportProtocolTCP, inTH.Protocol)
+					th.Protocol = headers.TransportProtocolTCP
+					th.InterleavedIDs = inTH.InterleavedIDs
+				} else {
+					require.Equal(t, headers.TransportProtocolUDP, inTH.Protocol)
+					require.Equal(t, deliveryPtr(headers.TransportDeliveryUnicast), inTH.Delivery)
+					th.Protocol = headers.TransportProtocolUDP
+					th.ClientPorts = inTH.ClientPorts
+					th.ServerPorts = &[2]int{34556, 34557}
+				}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": th.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Play, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				req, err = readRequestIgnoreFrames(conn)
+				require.NoError(t, err)
+				require.Equal(t, base.Teardown, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+			}()
+
+			var switches []error
+
+			c := Client{
+				TransportOrder: ca.order,
+				OnTransportSwitch: func(err error) {
+					switches = append(switches, err)
+				},
+			}
+
+			err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+			require.NoError(t, err)
+			defer c.Close()
+
+			if ca.order[1] == TransportTCP {
+				require.Equal(t, []error{liberrors.ErrClientSwitchToTCP2{}}, switches)
+			} else {
+				require.Len(t, switches, 1)
+				require.IsType(t, liberrors.ErrClientSwitchTransport{}, switches[0])
+			}
+		})
+	}
+}
+
+func TestClientPlayTunnel(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		getConn, err := l.Accept()
+		require.NoError(t, err)
+		defer getConn.Close()
+
+		hreq, err := http.ReadRequest(bufio.NewReader(getConn))
+		require.NoError(t, err)
+		require.Equal(t, http.MethodGet, hreq.Method)
+		require.Equal(t, "/teststream?param=value", hreq.RequestURI)
+		require.Equal(t, "application/x-rtsp-tunnelled", hreq.Header.Get("Accept"))
+		require.Equal(t, "http://localhost/", hreq.Header.Get("Referer"))
+		require.Equal(t, "custom-agent", hreq.Header.Get("User-Agent"))
+		cookie := hreq.Header.Get("x-sessioncookie")
+		require.NotEqual(t, "", cookie)
+		require.NotEqual(t, "overridden", cookie)
+
+		_, err = getConn.Write([]byte("HTTP/1.0 200 OK\r\n" +
+			"Content-Type: application/x-rtsp-tunnelled\r\n" +
+			"\r\n"))
+		require.NoError(t, err)
+
+		postConn, err := l.Accept()
+		require.NoError(t, err)
+		defer postConn.Close()
+
+		postBr := bufio.NewReader(postConn)
+
+		hreq, err = http.ReadRequest(postBr)
+		require.NoError(t, err)
+		require.Equal(t, http.MethodPost, hreq.Method)
+		require.Equal(t, "application/x-rtsp-tunnelled", hreq.Header.Get("Content-Type"))
+		require.Equal(t, "http://localhost/", hreq.Header.Get("Referer"))
+		require.Equal(t, "custom-agent", hreq.Header.Get("User-Agent"))
+		require.Equal(t, cookie, hreq.Header.Get("x-sessioncookie"))
+
+		conn := conn.NewConn(struct {
+			io.Reader
+			io.Writer
+		}{
+			&tunnelDecoder{br: postBr},
+			getConn,
+		})
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream?param=value"), req.URL)
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+		require.Equal(t, headers.TransportProtocolTCP, inTH.Protocol)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": headers.Transport{
+					Protocol:       headers.TransportProtocolTCP,
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					InterleavedIDs: inTH.InterleavedIDs,
+				}.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: inTH.InterleavedIDs[0],
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		req, err = readRequestIgnoreFrames(conn)
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	packetRecv := make(chan struct{})
+
+	c := Client{
+		Tunnel: TunnelHTTP,
+		TunnelHeader: base.Header{
+			"Referer":         base.HeaderValue{"http://localhost/"},
+			"user-agent":      base.HeaderValue{"custom-agent"},
+			"X-Sessioncookie": base.HeaderValue{"overridden"},
+			"content-type":    base.HeaderValue{"text/plain"},
+		},
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream?param=value",
+		func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			require.Equal(t, &testRTPPacket, pkt)
+			close(packetRecv)
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	<-packetRecv
+}
+
+func TestClientPlayControlURLFallback(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		medias := []*description.Media{testH264Media}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/base/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		for _, ur := range []string{
+			"rtsp://localhost:8554/base/trackID=0",
+			"rtsp://localhost:8554/teststream/trackID=0",
+			"rtsp://localhost:8554/base/trackID=1",
+		} {
+			req, err = conn.ReadRequest()
+			require.NoError(t, err)
+			require.Equal(t, base.Setup, req.Method)
+			require.Equal(t, mustParseURL(ur), req.URL)
+
+			err = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusNotFound,
+			})
+			require.NoError(t, err)
+		}
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/base/stream=0"), req.URL)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": headers.Transport{
+					Protocol:       headers.TransportProtocolTCP,
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					InterleavedIDs: inTH.InterleavedIDs,
+				}.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Play, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/base/"), req.URL)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = readRequestIgnoreFrames(conn)
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	c := Client{
+		Transport:          transportPtr(TransportTCP),
+		ControlURLFallback: true,
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+	c.Close()
+}
+
+func TestClientPlayPipelining(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	medias := []*description.Media{
+		{
+			Type:    "application",
+			Formats: []format.Format{&format.Generic{PayloadTyp: 97, RTPMa: "private/90000"}},
+		},
+		{
+			Type:    "application",
+			Formats: []format.Format{&format.Generic{PayloadTyp: 98, RTPMa: "private/90000"}},
+		},
+		{
+			Type:    "application",
+			Formats: []format.Format{&format.Generic{PayloadTyp: 99, RTPMa: "private/90000"}},
+		},
+	}
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"CSeq": req.Header["CSeq"],
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+					string(base.GetParameter),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Describe, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"CSeq":         req.Header["CSeq"],
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{"rtsp://localhost:8554/teststream/"},
+			},
+			Body: mediasToSDP(medias),
+		})
+		require.NoError(t, err)
+
+		setupResponse := func(req *base.Request) *base.Response {
+			var inTH headers.Transport
+			err2 := inTH.Unmarshal(req.Header["Transport"])
+			require.NoError(t, err2)
+
+			return &base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"CSeq":    req.Header["CSeq"],
+					"Session": base.HeaderValue{"ABCDE"},
+					"Transport": headers.Transport{
+						Protocol:       headers.TransportProtocolTCP,
+						Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+						InterleavedIDs: inTH.InterleavedIDs,
+					}.Marshal(),
+				},
+			}
+		}
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+		require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream/trackID=0"), req.URL)
+
+		err = conn.WriteResponse(setupResponse(req))
+		require.NoError(t, err)
+
+		// the remaining SETUP requests are received before any response is sent
+		var reqs []*base.Request
+
+		for i := 1; i < 3; i++ {
+			req, err = conn.ReadRequest()
+			require.NoError(t, err)
+			require.Equal(t, base.Setup, req.Method)
+			require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream/trackID="+strconv.FormatInt(int64(i), 10)), req.URL)
+			require.Equal(t, base.HeaderValue{"ABCDE"}, req.Header["Session"])
+			reqs = append(reqs, req)
+		}
+
+		// responses are sent in reverse order
+		for i := len(reqs) - 1; i >= 0; i-- {
+			err = conn.WriteResponse(setupResponse(reqs[i]))
+			require.NoError(t, err)
+		}
+
+		reqs = nil
+
+		for i := 0; i < 2; i++ {
+			req, err = conn.ReadRequest()
+			require.NoError(t, err)
+			require.Equal(t, base.GetParameter, req.Method)
+			reqs = append(reqs, req)
+		}
+
+		for i := len(reqs) - 1; i >= 0; i-- {
+			err = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"CSeq": reqs[i].Header["CSeq"],
+				},
+				Body: reqs[i].Body,
+			})
+			require.NoError(t, err)
+		}
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+	}()
+
+	c := Client{
+		Transport:     transportPtr(TransportTCP),
+		PipelineSetup: true,
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	_, err = c.SendRequest(&base.Request{
+		Method: base.Setup,
+		URL:    u,
+	})
+	require.EqualError(t, err, "method SETUP changes the state of the session and can't be sent without waiting for responses")
+
+	var futures []*ClientResponseFuture
+
+	for i := 0; i < 2; i++ {
+		var f *ClientResponseFuture
+		f, err = c.SendRequest(&base.Request{
+			Method: base.GetParameter,
+			URL:    u,
+			Body:   []byte("param" + strconv.FormatInt(int64(i), 10) + "\r\n"),
+		})
+		require.NoError(t, err)
+		futures = append(futures, f)
+	}
+
+	for i, f := range futures {
+		res, err2 := f.Wait()
+		require.NoError(t, err2)
+		require.Equal(t, []byte("param"+strconv.FormatInt(int64(i), 10)+"\r\n"), res.Body)
+	}
+}
+
+func TestClientPlayConnMux(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		// a single connection is used by both clients
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		channels := make(map[string]int)
+		teardowns := 0
+
+		for teardowns < 2 {
+			req, err := conn.ReadRequest()
+			require.NoError(t, err)
+
+			path := strings.SplitN(req.URL.Path, "/", 3)[1]
+
+			h := base.Header{
+				"CSeq": req.Header["CSeq"],
+			}
+
+			var body []byte
+
+			switch req.Method {
+			case base.Describe:
+				h["Content-Type"] = base.HeaderValue{"application/sdp"}
+				h["Content-Base"] = base.HeaderValue{"rtsp://localhost:8554/" + path + "/"}
+				body = mediasToSDP([]*description.Media{testH264Media})
+
+			case base.Setup:
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				_, ok := req.Header["Session"]
+				require.False(t, ok)
+
+				channels[path] = inTH.InterleavedIDs[0]
+
+				h["Session"] = base.HeaderValue{"session-" + path}
+				h["Transport"] = headers.Transport{
+					Protocol:       headers.TransportProtocolTCP,
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					InterleavedIDs: inTH.InterleavedIDs,
+				}.Marshal()
+
+			case base.Play:
+				require.Equal(t, base.HeaderValue{"session-" + path}, req.Header["Session"])
+
+			case base.Teardown:
+				teardowns++
+				continue
+			}
+
+			err = conn.WriteResponse(&base.Response{
+				StatusCode: base.StatusOK,
+				Header:     h,
+				Body:       body,
+			})
+			require.NoError(t, err)
+
+			if req.Method == base.Play {
+				pkt := testRTPPacket
+				pkt.Payload = []byte(path)
+
+				err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+					Channel: channels[path],
+					Payload: mustMarshalPacketRTP(&pkt),
+				}, make([]byte, 1024))
+				require.NoError(t, err)
+			}
+		}
+
+		// channels of the two sessions are different
+		require.NotEqual(t, channels["stream1"], channels["stream2"])
+	}()
+
+	mux := &ClientConnMux{}
+	defer mux.Close()
+
+	clients := make([]*Client, 2)
+
+	for i := range clients {
+		path := "stream" + strconv.FormatInt(int64(i+1), 10)
+
+		c := &Client{
+			Transport: transportPtr(TransportTCP),
+			ConnMux:   mux,
+		}
+
+		u, err := base.ParseURL("rtsp://localhost:8554/" + path)
+		require.NoError(t, err)
+
+		err = c.Start(u.Scheme, u.Host)
+		require.NoError(t, err)
+
+		desc, _, err := c.Describe(u)
+		require.NoError(t, err)
+
+		err = c.SetupAll(desc.BaseURL, desc.Medias)
+		require.NoError(t, err)
+
+		recv := make(chan struct{})
+
+		c.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			require.Equal(t, []byte(path), pkt.Payload)
+			close(recv)
+		})
+
+		_, err = c.Play(nil)
+		require.NoError(t, err)
+
+		<-recv
+
+		clients[i] = c
+	}
+
+	for _, c := range clients {
+		c.Close()
+	}
+}
+
+func TestClientPlayImpairment(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan uint16, 10)
+
+	c.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+		recv <- pkt.SequenceNumber
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	c.SetImpairment(&chaos.Config{
+		Duplication: 1,
+	})
+
+	pkt := testRTPPacket
+	pkt.SequenceNumber = 100
+	err = stream.WritePacketRTP(testH264Media, &pkt)
+	require.NoError(t, err)
+
+	require.Equal(t, uint16(100), <-recv)
+	require.Equal(t, uint16(100), <-recv)
+
+	c.SetImpairment(&chaos.Config{
+		Loss: 1,
+	})
+
+	pkt.SequenceNumber = 101
+	err = stream.WritePacketRTP(testH264Media, &pkt)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return c.impairer.Discarded() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	c.SetImpairment(nil)
+
+	pkt.SequenceNumber = 102
+	err = stream.WritePacketRTP(testH264Media, &pkt)
+	require.NoError(t, err)
+
+	require.Equal(t, uint16(102), <-recv)
+}
+
+func TestClientProbe(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				if ctx.Path != "/stream2" {
+					return &base.Response{
+						StatusCode: base.StatusNotFound,
+					}, nil, nil
+				}
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	t.Run("success", func(t *testing.T) {
+		p := &ClientProbe{
+			FallbackPaths: []string{"/stream1", "/stream2"},
+		}
+
+		res, err := p.Probe(context.Background(), "rtsp://localhost:8554/teststream")
+		require.NoError(t, err)
+
+		// the UDP transport and the tunnel are not enabled on the server
+		require.Equal(t, "rtsp://localhost:8554/stream2", res.URL.String())
+		require.Equal(t, TransportTCP, res.Transport)
+		require.Equal(t, TunnelNone, res.Tunnel)
+		require.Equal(t, 1, len(res.Description.Medias))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		p := &ClientProbe{
+			Transports: []Transport{TransportTCP},
+			Tunnels:    []Tunnel{TunnelNone},
+		}
+
+		_, err := p.Probe(context.Background(), "rtsp://localhost:8554/teststream")
+		require.Error(t, err)
+
+		var perr liberrors.ErrClientProbeFailed
+		require.ErrorAs(t, err, &perr)
+		require.Equal(t, 1, perr.Attempts)
+
+		var serr liberrors.ErrClientBadStatusCode
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, base.StatusNotFound, serr.Code)
+	})
 }
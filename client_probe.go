@@ -0,0 +1,253 @@
+package gortsplib
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+const (
+	clientProbeDefaultTimeout        = 10 * time.Second
+	clientProbeDefaultMaxConcurrency = 8
+)
+
+// ClientProbe finds a working configuration for reading a stream.
+// It sends DESCRIBE and SETUP requests with multiple combinations of transports,
+// tunnels, paths and credentials concurrently, and returns the first one that succeeds.
+type ClientProbe struct {
+	// transports to try.
+	// It defaults to UDP and TCP.
+	Transports []Transport
+
+	// tunnels to try. Tunnels are always used with the TCP transport.
+	// It defaults to TunnelNone and TunnelHTTP.
+	Tunnels []Tunnel
+
+	// try also RTSPS (RTSP over TLS) when the URL uses the rtsp scheme.
+	// The port of the URL is preserved if present.
+	// RTSPS is always used with the TCP transport.
+	// It defaults to false.
+	TryTLS bool
+
+	// paths to try when the path of the URL doesn't work,
+	// for instance "/Streaming/Channels/101" or "/cam/realmonitor".
+	// It defaults to nil.
+	FallbackPaths []string
+
+	// credentials to try when the URL doesn't contain any.
+	// Since attempts are concurrent, some devices may block the client
+	// after receiving multiple wrong credentials.
+	// It defaults to nil.
+	Credentials []*url.Userinfo
+
+	// maximum duration of the probe.
+	// It defaults to 10 seconds.
+	Timeout time.Duration
+
+	// maximum number of concurrent attempts.
+	// It defaults to 8.
+	MaxConcurrency int
+
+	// function that allocates the client used by each attempt.
+	// It allows to set timeouts, TLS configuration and dialers.
+	// Transport and Tunnel of the returned client are overridden.
+	// It defaults to a function that returns a Client with default settings.
+	NewClient func() *Client
+}
+
+// ClientProbeResult is a working configuration found by ClientProbe.
+type ClientProbeResult struct {
+	// URL of the stream, including the path and credentials that worked.
+	URL *base.URL
+
+	// transport that worked.
+	Transport Transport
+
+	// tunnel that worked.
+	Tunnel Tunnel
+
+	// description of the stream.
+	Description *description.Session
+}
+
+type clientProbeAttempt struct {
+	u         *base.URL
+	transport Transport
+	tunnel    Tunnel
+}
+
+func (p *ClientProbe) attempts(u *base.URL) []clientProbeAttempt {
+	transports := p.Transports
+	if len(transports) == 0 {
+		transports = []Transport{TransportUDP, TransportTCP}
+	}
+
+	tunnels := p.Tunnels
+	if len(tunnels) == 0 {
+		tunnels = []Tunnel{TunnelNone, TunnelHTTP}
+	}
+
+	users := []*url.Userinfo{u.User}
+	if u.User == nil {
+		users = append(users, p.Credentials...)
+	}
+
+	paths := append([]string{u.Path}, p.FallbackPaths...)
+
+	schemes := []string{u.Scheme}
+	if p.TryTLS && u.Scheme == "rtsp" {
+		schemes = append(schemes, "rtsps")
+	}
+
+	var ret []clientProbeAttempt
+
+	for _, user := range users {
+		for _, path := range paths {
+			for _, scheme := range schemes {
+				au := u.Clone()
+				au.User = user
+				if path != u.Path {
+					au.Path = path
+					au.RawPath = ""
+				}
+				au.Scheme = scheme
+
+				for _, tunnel := range tunnels {
+					for _, transport := range transports {
+						if (tunnel != TunnelNone || scheme == "rtsps") && transport != TransportTCP {
+							continue
+						}
+
+						ret = append(ret, clientProbeAttempt{
+							u:         au,
+							transport: transport,
+							tunnel:    tunnel,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return ret
+}
+
+func (p *ClientProbe) try(ctx context.Context, a clientProbeAttempt) (*ClientProbeResult, error) {
+	var c *Client
+	if p.NewClient != nil {
+		c = p.NewClient()
+	} else {
+		c = &Client{}
+	}
+
+	transport := a.transport
+	c.Transport = &transport
+	c.Tunnel = a.tunnel
+
+	err := c.Start(a.u.Scheme, a.u.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	desc, _, err := c.DescribeContext(ctx, a.u)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.SetupAllContext(ctx, desc.BaseURL, desc.Medias)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientProbeResult{
+		URL:         a.u,
+		Transport:   a.transport,
+		Tunnel:      a.tunnel,
+		Description: desc,
+	}, nil
+}
+
+// Probe finds a working configuration for reading the stream at the given address.
+// Attempts are started in order of preference, and the first one that succeeds
+// is returned while the others are canceled.
+func (p *ClientProbe) Probe(ctx context.Context, address string) (*ClientProbeResult, error) {
+	u, err := base.ParseURL(address)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = clientProbeDefaultTimeout
+	}
+
+	maxConcurrency := p.MaxConcurrency
+	if maxConcurrency == 0 {
+		maxConcurrency = clientProbeDefaultMaxConcurrency
+	}
+
+	ctx, ctxCancel := context.WithTimeout(ctx, timeout)
+	defer ctxCancel()
+
+	attempts := p.attempts(u)
+	errs := make([]error, len(attempts))
+	sem := make(chan struct{}, maxConcurrency)
+	chResult := make(chan *ClientProbeResult, 1)
+	var wg sync.WaitGroup
+
+	for i, a := range attempts {
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, a clientProbeAttempt) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := p.try(ctx, a)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			select {
+			case chResult <- res:
+				ctxCancel()
+			default:
+			}
+		}(i, a)
+	}
+
+	wg.Wait()
+
+	select {
+	case res := <-chResult:
+		return res, nil
+	default:
+	}
+
+	// report the error of the preferred attempt
+	for _, err := range errs {
+		if err != nil {
+			return nil, liberrors.ErrClientProbeFailed{Attempts: len(attempts), Err: err}
+		}
+	}
+
+	return nil, liberrors.ErrClientProbeFailed{Attempts: len(attempts), Err: ctx.Err()}
+}
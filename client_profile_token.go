@@ -0,0 +1,35 @@
+package gortsplib
+
+import (
+	"net/url"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// addProfileTokenQuery returns a copy of the URL that contains the profile token,
+// unless the token is already present.
+func (c *Client) addProfileTokenQuery(u *base.URL) *base.URL {
+	if c.ProfileToken == "" || c.ProfileTokenQueryParam == "" {
+		return u
+	}
+
+	if q, err := url.ParseQuery(u.RawQuery); err == nil && q.Get(c.ProfileTokenQueryParam) == c.ProfileToken {
+		return u
+	}
+
+	param := url.QueryEscape(c.ProfileTokenQueryParam) + "=" + url.QueryEscape(c.ProfileToken)
+
+	u = u.Clone()
+	if u.RawQuery != "" {
+		u.RawQuery += "&" + param
+	} else {
+		u.RawQuery = param
+	}
+	return u
+}
+
+func (c *Client) addProfileTokenHeader(header base.Header) {
+	if c.ProfileToken != "" && c.ProfileTokenHeader != "" {
+		header[c.ProfileTokenHeader] = base.HeaderValue{c.ProfileToken}
+	}
+}
@@ -0,0 +1,59 @@
+package gortsplib
+
+import (
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// ClientQuirks is a set of adjustments of the client behavior,
+// used to communicate with servers that are not fully standard-compliant.
+type ClientQuirks struct {
+	// prefix of the Server header of servers that need this set.
+	// If empty, the set is applied to all servers,
+	// including the ones that do not send a Server header.
+	Server string
+
+	// always use the TCP transport protocol.
+	ForceTCP bool
+
+	// ignore Session headers that don't match the session ID received with SETUP.
+	IgnoreSessionMismatch bool
+
+	// status codes that are handled as 200 OK.
+	OKStatusCodes []base.StatusCode
+
+	// decode SDPs with the lenient parser (see Client.LenientSDP).
+	LenientSDP bool
+
+	// discard incoming RTCP packets, for servers that send malformed compound packets.
+	// Sender reports are not processed, therefore packet NTP timestamps are not available.
+	IgnoreRTCP bool
+
+	// ignore RTP-Info headers, for servers that send invalid sequence numbers or timestamps.
+	IgnoreRTPInfo bool
+}
+
+func (q *ClientQuirks) isOKStatusCode(code base.StatusCode) bool {
+	for _, c := range q.OKStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func findQuirks(quirks []ClientQuirks, res *base.Response) *ClientQuirks {
+	var server string
+	if v, ok := res.Header["Server"]; ok && len(v) == 1 {
+		server = v[0]
+	}
+
+	for i, q := range quirks {
+		if q.Server == "" || (server != "" && strings.HasPrefix(server, q.Server)) {
+			return &quirks[i]
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package gortsplib
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
@@ -47,12 +48,19 @@ func (r *clientReader) run() {
 }
 
 func (r *clientReader) runInner() error {
+	var skipped uint64
+
 	for {
 		what, err := r.c.conn.Read()
 		if err != nil {
 			return err
 		}
 
+		if v := r.c.conn.SkippedBytes(); v != skipped {
+			atomic.AddUint64(r.c.BytesSkipped, v-skipped)
+			skipped = v
+		}
+
 		switch what := what.(type) {
 		case *base.Response:
 			r.c.readResponse(what)
@@ -70,6 +78,8 @@ func (r *clientReader) runInner() error {
 
 			if cb, ok := r.c.tcpCallbackByChannel[what.Channel]; ok {
 				cb(what.Payload)
+			} else if r.c.onInterleavedFrame != nil {
+				r.c.onInterleavedFrame(what.Channel, what.Payload)
 			}
 			r.mutex.Unlock()
 		}
@@ -0,0 +1,70 @@
+package gortsplib
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// recordContinuity rewrites sequence numbers and timestamps of RTP packets
+// that are written after a RECORD has been resumed, in order to continue
+// the ones written before the PAUSE.
+type recordContinuity struct {
+	clockRate int
+
+	mutex     sync.Mutex
+	started   bool
+	lastSeq   uint16
+	lastTS    uint32
+	lastTime  time.Time
+	resumed   bool
+	pausedFor time.Duration
+	seqOffset uint16
+	tsOffset  uint32
+}
+
+// pause is called when a PAUSE request is sent.
+// The media time that elapsed between the last packet and the pause
+// is preserved, while the pause itself is removed from the timeline.
+func (rc *recordContinuity) pause(now time.Time) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if !rc.started {
+		return
+	}
+
+	rc.resumed = true
+	rc.pausedFor = now.Sub(rc.lastTime)
+	if rc.pausedFor < 0 {
+		rc.pausedFor = 0
+	}
+}
+
+func (rc *recordContinuity) process(pkt *rtp.Packet, now time.Time) *rtp.Packet {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if rc.resumed {
+		rc.resumed = false
+		rc.seqOffset = rc.lastSeq + 1 - pkt.SequenceNumber
+		elapsed := uint32(int64(rc.pausedFor/time.Second)*int64(rc.clockRate) +
+			int64(rc.pausedFor%time.Second)*int64(rc.clockRate)/int64(time.Second))
+		rc.tsOffset = rc.lastTS + elapsed - pkt.Timestamp
+	}
+
+	if rc.seqOffset != 0 || rc.tsOffset != 0 {
+		pkt2 := *pkt
+		pkt2.SequenceNumber += rc.seqOffset
+		pkt2.Timestamp += rc.tsOffset
+		pkt = &pkt2
+	}
+
+	rc.started = true
+	rc.lastSeq = pkt.SequenceNumber
+	rc.lastTS = pkt.Timestamp
+	rc.lastTime = now
+
+	return pkt
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 )
 
@@ -765,6 +766,196 @@ func TestClientRecordPauseParallel(t *testing.T) {
 	}
 }
 
+func TestClientRecordPauseAppend(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan struct{})
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Announce),
+					string(base.Setup),
+					string(base.Record),
+					string(base.Pause),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Announce, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+		require.Equal(t, headers.TransportModeRecord, *inTH.Mode)
+		require.Equal(t, true, inTH.Append)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": headers.Transport{
+					Protocol:       headers.TransportProtocolTCP,
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					InterleavedIDs: inTH.InterleavedIDs,
+					Append:         true,
+				}.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		readRTP := func() *rtp.Packet {
+			for {
+				f, err2 := conn.ReadInterleavedFrame()
+				require.NoError(t, err2)
+
+				if f.Channel == 0 {
+					var pkt rtp.Packet
+					err2 = pkt.Unmarshal(f.Payload)
+					require.NoError(t, err2)
+					return &pkt
+				}
+			}
+		}
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Record, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		pkt := readRTP()
+		require.Equal(t, uint16(100), pkt.SequenceNumber)
+		require.Equal(t, uint32(1000), pkt.Timestamp)
+		received <- struct{}{}
+
+		req, err = readRequestIgnoreFrames(conn)
+		require.NoError(t, err)
+		require.Equal(t, base.Pause, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Record, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		// sequence numbers and timestamps continue the ones sent before the pause,
+		// and include the 100ms that elapsed between the last packet and the pause.
+		pkt = readRTP()
+		require.Equal(t, uint16(101), pkt.SequenceNumber)
+		require.Equal(t, uint32(1000+9000), pkt.Timestamp)
+
+		pkt = readRTP()
+		require.Equal(t, uint16(102), pkt.SequenceNumber)
+		require.Equal(t, uint32(1000+9000+3000), pkt.Timestamp)
+		received <- struct{}{}
+
+		req, err = readRequestIgnoreFrames(conn)
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	curTime := time.Date(2014, 5, 7, 15, 0, 0, 0, time.UTC)
+	var curTimeMutex sync.Mutex
+
+	setTime := func(v time.Time) {
+		curTimeMutex.Lock()
+		defer curTimeMutex.Unlock()
+		curTime = v
+	}
+
+	c := Client{
+		Transport:                 transportPtr(TransportTCP),
+		RecordAppend:              true,
+		RecordTimestampContinuity: true,
+		DisableRTCPSenderReports:  true,
+		timeNow: func() time.Time {
+			curTimeMutex.Lock()
+			defer curTimeMutex.Unlock()
+			return curTime
+		},
+	}
+
+	medi := testH264Media
+	medias := []*description.Media{medi}
+
+	err = record(&c, "rtsp://localhost:8554/teststream", medias, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	pkt := testRTPPacket
+	pkt.SequenceNumber = 100
+	pkt.Timestamp = 1000
+	err = c.WritePacketRTP(medi, &pkt)
+	require.NoError(t, err)
+	<-received
+
+	setTime(time.Date(2014, 5, 7, 15, 0, 0, 100000000, time.UTC))
+
+	_, err = c.Pause()
+	require.NoError(t, err)
+
+	setTime(time.Date(2014, 5, 7, 15, 0, 10, 0, time.UTC))
+
+	_, err = c.Record()
+	require.NoError(t, err)
+
+	pkt.SequenceNumber = 5
+	pkt.Timestamp = 70000
+	err = c.WritePacketRTP(medi, &pkt)
+	require.NoError(t, err)
+
+	pkt.SequenceNumber = 6
+	pkt.Timestamp = 73000
+	err = c.WritePacketRTP(medi, &pkt)
+	require.NoError(t, err)
+	<-received
+}
+
 func TestClientRecordAutomaticProtocol(t *testing.T) {
 	l, err := net.Listen("tcp", "localhost:8554")
 	require.NoError(t, err)
@@ -1185,7 +1376,8 @@ func TestClientRecordRTCPReport(t *testing.T) {
 
 				close(reportReceived)
 
-				req, err = conn.ReadRequest()
+				// the RTCP BYE sent before TEARDOWN is drained
+				req, err = readRequestIgnoreFrames(conn)
 				require.NoError(t, err)
 				require.Equal(t, base.Teardown, req.Method)
 
@@ -1249,91 +1441,287 @@ func TestClientRecordRTCPReport(t *testing.T) {
 	}
 }
 
-func TestClientRecordIgnoreTCPRTPPackets(t *testing.T) {
-	l, err := net.Listen("tcp", "localhost:8554")
-	require.NoError(t, err)
-	defer l.Close()
-
-	serverDone := make(chan struct{})
-	defer func() { <-serverDone }()
-	go func() {
-		defer close(serverDone)
+func TestClientRecordInitialRTCPSenderReport(t *testing.T) {
+	for _, ca := range []string{"udp", "tcp"} {
+		t.Run(ca, func(t *testing.T) {
+			reportReceived := make(chan struct{})
 
-		nconn, err := l.Accept()
-		require.NoError(t, err)
-		defer nconn.Close()
-		conn := conn.NewConn(nconn)
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
 
-		req, err := conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Options, req.Method)
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
 
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-			Header: base.Header{
-				"Public": base.HeaderValue{strings.Join([]string{
-					string(base.Announce),
-					string(base.Setup),
-					string(base.Record),
-				}, ", ")},
-			},
-		})
-		require.NoError(t, err)
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
 
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Announce, req.Method)
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
 
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-		})
-		require.NoError(t, err)
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Announce),
+							string(base.Setup),
+							string(base.Record),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
 
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Setup, req.Method)
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Announce, req.Method)
 
-		var inTH headers.Transport
-		err = inTH.Unmarshal(req.Header["Transport"])
-		require.NoError(t, err)
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
 
-		th := headers.Transport{
-			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-			Protocol:       headers.TransportProtocolTCP,
-			InterleavedIDs: inTH.InterleavedIDs,
-		}
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
 
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-			Header: base.Header{
-				"Transport": th.Marshal(),
-			},
-		})
-		require.NoError(t, err)
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
 
-		req, err = conn.ReadRequest()
-		require.NoError(t, err)
-		require.Equal(t, base.Record, req.Method)
+				th := headers.Transport{
+					Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+				}
 
-		err = conn.WriteResponse(&base.Response{
-			StatusCode: base.StatusOK,
-		})
-		require.NoError(t, err)
+				if ca == "udp" {
+					th.Protocol = headers.TransportProtocolUDP
+					th.ClientPorts = inTH.ClientPorts
+					th.ServerPorts = &[2]int{34556, 34557}
+				} else {
+					th.Protocol = headers.TransportProtocolTCP
+					th.InterleavedIDs = inTH.InterleavedIDs
+				}
 
-		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-			Channel: 0,
-			Payload: testRTPPacketMarshaled,
-		}, make([]byte, 1024))
-		require.NoError(t, err)
+				l1, err := net.ListenPacket("udp", "localhost:34556")
+				require.NoError(t, err)
+				defer l1.Close()
 
-		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
-			Channel: 1,
-			Payload: testRTCPPacketMarshaled,
-		}, make([]byte, 1024))
-		require.NoError(t, err)
+				l2, err := net.ListenPacket("udp", "localhost:34557")
+				require.NoError(t, err)
+				defer l2.Close()
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": th.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Record, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				var buf []byte
+
+				if ca == "udp" {
+					buf = make([]byte, 2048)
+					_, _, err := l1.ReadFrom(buf)
+					require.NoError(t, err)
+
+					n, _, err := l2.ReadFrom(buf)
+					require.NoError(t, err)
+					buf = buf[:n]
+				} else {
+					f, err := conn.ReadInterleavedFrame()
+					require.NoError(t, err)
+					require.Equal(t, 0, f.Channel)
+
+					f, err = conn.ReadInterleavedFrame()
+					require.NoError(t, err)
+					require.Equal(t, 1, f.Channel)
+					buf = f.Payload
+				}
+
+				packets, err := rtcp.Unmarshal(buf)
+				require.NoError(t, err)
+				require.Equal(t, &rtcp.SenderReport{
+					SSRC:        0x38F27A2F,
+					NTPTime:     ntpTimeGoToRTCP(time.Date(1996, 2, 13, 14, 32, 5, 0, time.UTC)),
+					RTPTime:     1300000,
+					PacketCount: 1,
+					OctetCount:  1,
+				}, packets[0])
+
+				close(reportReceived)
+
+				// the RTCP BYE sent before TEARDOWN is drained
+				req, err = readRequestIgnoreFrames(conn)
+				require.NoError(t, err)
+				require.Equal(t, base.Teardown, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+			}()
+
+			var curTime time.Time
+			var curTimeMutex sync.Mutex
+
+			c := Client{
+				Transport: func() *Transport {
+					if ca == "udp" {
+						v := TransportUDP
+						return &v
+					}
+					v := TransportTCP
+					return &v
+				}(),
+				timeNow: func() time.Time {
+					curTimeMutex.Lock()
+					defer curTimeMutex.Unlock()
+					return curTime
+				},
+				senderReportPeriod:      1 * time.Hour,
+				InitialRTCPSenderReport: true,
+			}
+
+			medi := testH264Media
+			medias := []*description.Media{medi}
+
+			err = record(&c, "rtsp://localhost:8554/teststream", medias, nil)
+			require.NoError(t, err)
+			defer c.Close()
+
+			curTimeMutex.Lock()
+			curTime = time.Date(2013, 6, 10, 1, 0, 0, 0, time.UTC)
+			curTimeMutex.Unlock()
+
+			err = c.WritePacketRTPWithNTP(
+				medi,
+				&rtp.Packet{
+					Header: rtp.Header{
+						Version:     2,
+						PayloadType: 96,
+						SSRC:        0x38F27A2F,
+						Timestamp:   1300000,
+					},
+					Payload: []byte{0x05}, // IDR
+				},
+				time.Date(1996, 2, 13, 14, 32, 5, 0, time.UTC))
+			require.NoError(t, err)
+
+			<-reportReceived
+		})
+	}
+}
+
+func TestClientRecordTransportInfo(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Announce),
+					string(base.Setup),
+					string(base.Record),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Announce, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: &[2]int{2, 3},
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
 
 		req, err = conn.ReadRequest()
 		require.NoError(t, err)
+		require.Equal(t, base.Record, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		f, err := conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+		require.Equal(t, 2, f.Channel)
+
+		f, err = conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+		require.Equal(t, 3, f.Channel)
+
+		packets, err := rtcp.Unmarshal(f.Payload)
+		require.NoError(t, err)
+		sr := packets[0].(*rtcp.SenderReport)
+
+		byts, _ := (&rtcp.ReceiverReport{
+			SSRC: 0x65f83afb,
+			Reports: []rtcp.ReceptionReport{{
+				SSRC:             sr.SSRC,
+				LastSenderReport: uint32(sr.NTPTime >> 16),
+				Delay:            65536 / 2,
+			}},
+		}).Marshal()
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 3,
+			Payload: byts,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		req, err = readRequestIgnoreFrames(conn)
+		require.NoError(t, err)
 		require.Equal(t, base.Teardown, req.Method)
 
 		err = conn.WriteResponse(&base.Response{
@@ -1342,20 +1730,551 @@ func TestClientRecordIgnoreTCPRTPPackets(t *testing.T) {
 		require.NoError(t, err)
 	}()
 
-	rtcpReceived := make(chan struct{})
+	var curTime time.Time
+	var curTimeMutex sync.Mutex
+
+	setCurTime := func(v time.Time) {
+		curTimeMutex.Lock()
+		defer curTimeMutex.Unlock()
+		curTime = v
+	}
 
 	c := Client{
 		Transport: transportPtr(TransportTCP),
+		timeNow: func() time.Time {
+			curTimeMutex.Lock()
+			defer curTimeMutex.Unlock()
+			return curTime
+		},
+		senderReportPeriod:      1 * time.Hour,
+		InitialRTCPSenderReport: true,
 	}
 
-	medias := []*description.Media{testH264Media}
+	medi := testH264Media
+	medias := []*description.Media{medi}
 
-	err = record(&c, "rtsp://localhost:8554/teststream", medias,
-		func(medi *description.Media, pkt rtcp.Packet) {
-			close(rtcpReceived)
-		})
+	err = record(&c, "rtsp://localhost:8554/teststream", medias, nil)
 	require.NoError(t, err)
 	defer c.Close()
 
-	<-rtcpReceived
+	setCurTime(time.Date(2013, 6, 10, 1, 0, 0, 0, time.UTC))
+
+	err = c.WritePacketRTPWithNTP(
+		medi,
+		&rtp.Packet{
+			Header: rtp.Header{
+				Version:     2,
+				PayloadType: 96,
+				SSRC:        0x38F27A2F,
+				Timestamp:   1300000,
+			},
+			Payload: []byte{0x05}, // IDR
+		},
+		time.Date(1996, 2, 13, 14, 32, 5, 0, time.UTC))
+	require.NoError(t, err)
+
+	// the receiver report is received 800ms after the sender report, and it was delayed by 500ms
+	setCurTime(time.Date(2013, 6, 10, 1, 0, 0, 800000000, time.UTC))
+
+	require.Eventually(t, func() bool {
+		infos := c.TransportInfo()
+		return len(infos) == 1 && infos[0].RoundTripTime != 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, []MediaTransportInfo{{
+		Media:          medi,
+		Transport:      TransportTCP,
+		InterleavedIDs: &[2]int{2, 3},
+		SSRCs:          map[uint8]uint32{96: 0x38F27A2F},
+		RoundTripTime:  300 * time.Millisecond,
+	}}, c.TransportInfo())
+}
+
+func TestClientRecordIgnoreTCPRTPPackets(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+	go func() {
+		defer close(serverDone)
+
+		nconn, err := l.Accept()
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		req, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Options, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Announce),
+					string(base.Setup),
+					string(base.Record),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Announce, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err)
+
+		th := headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Record, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 1,
+			Payload: testRTCPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+
+		req, err = conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, base.Teardown, req.Method)
+
+		err = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err)
+	}()
+
+	rtcpReceived := make(chan struct{})
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	medias := []*description.Media{testH264Media}
+
+	err = record(&c, "rtsp://localhost:8554/teststream", medias,
+		func(medi *description.Media, pkt rtcp.Packet) {
+			close(rtcpReceived)
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	<-rtcpReceived
+}
+
+func TestClientRecordContentLocation(t *testing.T) {
+	for _, ca := range []string{
+		"absolute",
+		"relative",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
+
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Announce),
+							string(base.Setup),
+							string(base.Record),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Announce, req.Method)
+				require.Equal(t, mustParseURL("rtsp://localhost:8554/teststream"), req.URL)
+
+				var desc sdp.SessionDescription
+				err = desc.Unmarshal(req.Body)
+				require.NoError(t, err)
+
+				var cl string
+				if ca == "absolute" {
+					cl = "rtsp://localhost:8554/otherstream/"
+				} else {
+					cl = "otherstream/"
+				}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Content-Location": base.HeaderValue{cl},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+				require.Equal(t, mustParseURL(
+					"rtsp://localhost:8554/otherstream/"+relativeControlAttribute(desc.MediaDescriptions[0])), req.URL)
+
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": headers.Transport{
+							Protocol:       headers.TransportProtocolTCP,
+							Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+							InterleavedIDs: inTH.InterleavedIDs,
+						}.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Record, req.Method)
+				require.Equal(t, mustParseURL("rtsp://localhost:8554/otherstream/"), req.URL)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				req, err = readRequestIgnoreFrames(conn)
+				require.NoError(t, err)
+				require.Equal(t, base.Teardown, req.Method)
+				require.Equal(t, mustParseURL("rtsp://localhost:8554/otherstream/"), req.URL)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+			}()
+
+			c := Client{
+				Transport: transportPtr(TransportTCP),
+			}
+
+			err = record(&c, "rtsp://localhost:8554/teststream",
+				[]*description.Media{testH264Media}, nil)
+			require.NoError(t, err)
+			c.Close()
+		})
+	}
+}
+
+func TestClientRecordRangeRTPInfo(t *testing.T) {
+	for _, ca := range []string{
+		"present",
+		"absent",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
+
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Announce),
+							string(base.Setup),
+							string(base.Record),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Announce, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Setup, req.Method)
+
+				var inTH headers.Transport
+				err = inTH.Unmarshal(req.Header["Transport"])
+				require.NoError(t, err)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": headers.Transport{
+							Protocol:       headers.TransportProtocolTCP,
+							Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+							InterleavedIDs: inTH.InterleavedIDs,
+						}.Marshal(),
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Record, req.Method)
+
+				header := base.Header{}
+
+				if ca == "present" {
+					header["Range"] = headers.Range{
+						Value: &headers.RangeNPT{
+							Start: 5 * time.Second,
+						},
+					}.Marshal()
+
+					header["RTP-Info"] = headers.RTPInfo{{
+						URL:            "rtsp://localhost:8554/teststream/trackID=0",
+						SequenceNumber: uint16Ptr(556),
+						Timestamp:      uint32Ptr(984512368),
+					}}.Marshal()
+				}
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header:     header,
+				})
+				require.NoError(t, err)
+
+				req, err = readRequestIgnoreFrames(conn)
+				require.NoError(t, err)
+				require.Equal(t, base.Teardown, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+			}()
+
+			c := Client{
+				Transport: transportPtr(TransportTCP),
+			}
+
+			medi := testH264Media
+
+			err = record(&c, "rtsp://localhost:8554/teststream", []*description.Media{medi}, nil)
+			require.NoError(t, err)
+			defer c.Close()
+
+			if ca == "present" {
+				require.Equal(t, &headers.Range{
+					Value: &headers.RangeNPT{
+						Start: 5 * time.Second,
+					},
+				}, c.RecordRange())
+
+				require.Equal(t, &headers.RTPInfoEntry{
+					URL:            "rtsp://localhost:8554/teststream/trackID=0",
+					SequenceNumber: uint16Ptr(556),
+					Timestamp:      uint32Ptr(984512368),
+				}, c.RecordRTPInfo(medi))
+			} else {
+				require.Nil(t, c.RecordRange())
+				require.Nil(t, c.RecordRTPInfo(medi))
+			}
+		})
+	}
+}
+
+func TestClientRecordOnAnnounceSDP(t *testing.T) {
+	for _, ca := range []string{
+		"modified",
+		"invalid",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			l, err := net.Listen("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer l.Close()
+
+			serverDone := make(chan struct{})
+			defer func() { <-serverDone }()
+			go func() {
+				defer close(serverDone)
+
+				nconn, err := l.Accept()
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				req, err := conn.ReadRequest()
+
+				if ca == "invalid" {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, base.Options, req.Method)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Public": base.HeaderValue{strings.Join([]string{
+							string(base.Announce),
+							string(base.Setup),
+							string(base.Record),
+						}, ", ")},
+					},
+				})
+				require.NoError(t, err)
+
+				req, err = conn.ReadRequest()
+				require.NoError(t, err)
+				require.Equal(t, base.Announce, req.Method)
+
+				var ssd sdp.SessionDescription
+				err = ssd.Unmarshal(req.Body)
+				require.NoError(t, err)
+
+				var desc description.Session
+				err = desc.Unmarshal(&ssd)
+				require.NoError(t, err)
+				require.Equal(t, "edited", desc.Title)
+				require.Equal(t, "trackID=0", desc.Medias[0].Control)
+
+				err = conn.WriteResponse(&base.Response{
+					StatusCode: base.StatusOK,
+				})
+				require.NoError(t, err)
+			}()
+
+			c := Client{
+				OnAnnounceSDP: func(desc *description.Session) *description.Session {
+					if ca == "invalid" {
+						return nil
+					}
+					desc.Title = "edited"
+					return desc
+				},
+			}
+
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
+
+			_, err = c.Announce(u, &description.Session{Medias: []*description.Media{testH264Media}})
+
+			if ca == "invalid" {
+				require.EqualError(t, err, "invalid SDP: description is nil")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClientRecordErrorPayloadTypeNotInMedia(t *testing.T) {
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(_ *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = c.StartRecording("rtsp://localhost:8554/teststream",
+		&description.Session{Medias: []*description.Media{testH264Media}})
+	require.NoError(t, err)
+	defer c.Close()
+
+	pkt := testRTPPacket
+	pkt.PayloadType = 97
+
+	err = c.WritePacketRTP(testH264Media, &pkt)
+	require.Equal(t, liberrors.ErrClientRTPPacketPayloadTypeNotInMedia{PayloadType: 97}, err)
 }
@@ -0,0 +1,144 @@
+package gortsplib
+
+import (
+	gourl "net/url"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+func isRedirect(res *base.Response) bool {
+	return res.StatusCode >= base.StatusMovedPermanently &&
+		res.StatusCode <= base.StatusUseProxy &&
+		len(res.Header["Location"]) == 1
+}
+
+// parseLocation parses the Location header of a redirect, that can be relative to the request URL.
+// Credentials of the request URL are preserved.
+func parseLocation(u *base.URL, location string) (*base.URL, error) {
+	ref, err := gourl.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	ru, err := base.ParseURL((*gourl.URL)(u).ResolveReference(ref).String())
+	if err != nil {
+		return nil, err
+	}
+
+	if u.User != nil {
+		ru.User = u.User
+	}
+
+	return ru, nil
+}
+
+// checkRedirect checks whether a request directed to u can be redirected to target,
+// that is derived from location. visited contains the URLs that have been already redirected.
+func (c *Client) checkRedirect(
+	u *base.URL,
+	target *base.URL,
+	location *base.URL,
+	res *base.Response,
+	visited []string,
+) error {
+	if len(visited) >= c.MaxRedirects {
+		return liberrors.ErrClientTooManyRedirects{Max: c.MaxRedirects}
+	}
+
+	for _, v := range append(visited, u.String()) {
+		if v == target.String() {
+			return liberrors.ErrClientRedirectLoop{URL: target.String()}
+		}
+	}
+
+	c.log.Info("redirected", "url", u, "location", location, "status", res.StatusCode)
+	c.OnRedirect(location)
+
+	return nil
+}
+
+// replaceHost replaces scheme, host and credentials of an URL with the ones of another URL.
+func replaceHost(u *base.URL, host *base.URL) *base.URL {
+	ret := u.Clone()
+	ret.Scheme = host.Scheme
+	ret.Host = host.Host
+	ret.User = host.User
+	return ret
+}
+
+// followSetupRedirect sends the SETUP request again to the server indicated by a redirect.
+// The server is replaced in the base URL too, in order to send the following requests to it.
+func (c *Client) followSetupRedirect(
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+	res *base.Response,
+	redirects []string,
+) (*base.Response, error) {
+	ru, err := parseLocation(baseURL, res.Header["Location"][0])
+	if err != nil {
+		return nil, err
+	}
+
+	redirectedBaseURL := replaceHost(baseURL, ru)
+
+	err = c.checkRedirect(baseURL, redirectedBaseURL, ru, res, redirects)
+	if err != nil {
+		return nil, err
+	}
+
+	lastDescribeURL := c.lastDescribeURL
+
+	c.reset()
+
+	c.connURL = &base.URL{
+		Scheme: ru.Scheme,
+		Host:   ru.Host,
+	}
+
+	// the new server needs to know the stream before SETUP
+	if lastDescribeURL != nil {
+		_, _, err = c.doDescribe(replaceHost(lastDescribeURL, ru))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.setupRedirectFrom = baseURL
+	c.setupRedirectTo = redirectedBaseURL
+
+	return c.doSetupWithRedirects(redirectedBaseURL, medi, rtpPort, rtcpPort, append(redirects, baseURL.String()))
+}
+
+// handleServerRedirect handles a REDIRECT request sent by the server,
+// that asks the client to connect to another location.
+func (c *Client) handleServerRedirect(req *base.Request) (*base.Response, error) {
+	loc, ok := req.Header["Location"]
+	if !ok || len(loc) != 1 {
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}, nil
+	}
+
+	u := c.baseURL
+	if u == nil {
+		u = c.connURL
+	}
+
+	ru, err := parseLocation(u, loc[0])
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}, nil
+	}
+
+	c.log.Info("redirected by server", "location", ru)
+	c.OnRedirect(ru)
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, liberrors.ErrClientRedirected{Location: ru}
+}
@@ -0,0 +1,25 @@
+package gortsplib
+
+import (
+	"time"
+)
+
+// ClientTransportFallback configures the traversal of NATs and firewalls
+// with the UDP transport, and the fallback to the TCP transport.
+// The fallback happens when no UDP packet is received within InitialUDPReadTimeout.
+type ClientTransportFallback struct {
+	// interval between hole punching packets sent to the server,
+	// until the first UDP packet is received.
+	// It defaults to zero (a single hole punching packet is sent).
+	PunchInterval time.Duration
+
+	// number of port pairs following the ones announced by the server
+	// that hole punching packets are sent to, in order to traverse NATs
+	// that map the server ports sequentially (port prediction).
+	// It defaults to zero.
+	PredictedPorts int
+
+	// do not switch to the TCP transport when no UDP packet is received;
+	// return ErrClientUDPTimeout instead.
+	DisableTCP bool
+}
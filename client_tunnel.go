@@ -0,0 +1,204 @@
+package gortsplib
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+func tunnelSessionCookie() (string, error) {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func isTunnelRequiredHeader(key string) bool {
+	return strings.EqualFold(key, "x-sessioncookie") || strings.EqualFold(key, "Content-Type")
+}
+
+func deleteHeaderKey(header base.Header, key string) {
+	for k := range header {
+		if strings.EqualFold(k, key) {
+			delete(header, k)
+		}
+	}
+}
+
+// clientTunnelConn is a net.Conn that implements RTSP-over-HTTP tunneling.
+// Incoming data is read from the GET connection,
+// outgoing data is encoded in base64 and written into the POST connection.
+// Specification: https://opensource.apple.com/source/QuickTimeStreamingServer/QuickTimeStreamingServer-412.42/Documentation/RTSP_Over_HTTP.pdf
+type clientTunnelConn struct {
+	getConn  net.Conn
+	getBr    *bufio.Reader
+	postConn net.Conn
+}
+
+func (tc *clientTunnelConn) initialize(
+	ctx context.Context,
+	c *Client,
+	u *base.URL,
+	dial func(ctx context.Context) (net.Conn, error),
+) error {
+	cookie, err := tunnelSessionCookie()
+	if err != nil {
+		return err
+	}
+
+	tc.getConn, err = dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = tc.writeHTTPRequest(tc.getConn, c, u, base.Get, base.Header{
+		"x-sessioncookie": base.HeaderValue{cookie},
+		"Accept":          base.HeaderValue{"application/x-rtsp-tunnelled"},
+		"Pragma":          base.HeaderValue{"no-cache"},
+		"Cache-Control":   base.HeaderValue{"no-cache"},
+	})
+	if err != nil {
+		tc.getConn.Close()
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		tc.getConn.SetReadDeadline(deadline)
+	}
+
+	tc.getBr = bufio.NewReaderSize(tc.getConn, 4096)
+
+	var res base.Response
+	err = res.Unmarshal(tc.getBr)
+	if err != nil {
+		tc.getConn.Close()
+		return err
+	}
+
+	tc.getConn.SetReadDeadline(time.Time{})
+
+	if res.StatusCode != base.StatusOK {
+		tc.getConn.Close()
+		return liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage, Response: &res}
+	}
+
+	tc.postConn, err = dial(ctx)
+	if err != nil {
+		tc.getConn.Close()
+		return err
+	}
+
+	err = tc.writeHTTPRequest(tc.postConn, c, u, base.Post, base.Header{
+		"x-sessioncookie": base.HeaderValue{cookie},
+		"Content-Type":    base.HeaderValue{"application/x-rtsp-tunnelled"},
+		"Pragma":          base.HeaderValue{"no-cache"},
+		"Cache-Control":   base.HeaderValue{"no-cache"},
+		"Content-Length":  base.HeaderValue{"32767"},
+		"Expires":         base.HeaderValue{"Sun, 9 Jan 1972 00:00:00 GMT"},
+	})
+	if err != nil {
+		tc.getConn.Close()
+		tc.postConn.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (tc *clientTunnelConn) writeHTTPRequest(
+	nconn net.Conn,
+	c *Client,
+	u *base.URL,
+	method base.Method,
+	header base.Header,
+) error {
+	header["User-Agent"] = base.HeaderValue{c.UserAgent}
+
+	// headers provided by the user override default ones,
+	// but not the ones required by the tunnel.
+	for k, v := range c.TunnelHeader {
+		if isTunnelRequiredHeader(k) {
+			continue
+		}
+		deleteHeaderKey(header, k)
+		header[k] = v
+	}
+
+	byts, err := base.Request{
+		Method:   method,
+		URL:      u,
+		Protocol: "HTTP/1.0",
+		Header:   header,
+	}.Marshal()
+	if err != nil {
+		return err
+	}
+
+	nconn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+	_, err = nconn.Write(byts)
+	return err
+}
+
+// Read implements net.Conn.
+func (tc *clientTunnelConn) Read(p []byte) (int, error) {
+	return tc.getBr.Read(p)
+}
+
+// Write implements net.Conn.
+func (tc *clientTunnelConn) Write(p []byte) (int, error) {
+	// every chunk is encoded separately, as done by most implementations.
+	_, err := tc.postConn.Write([]byte(base64.StdEncoding.EncodeToString(p)))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close implements net.Conn.
+func (tc *clientTunnelConn) Close() error {
+	err1 := tc.getConn.Close()
+	err2 := tc.postConn.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// LocalAddr implements net.Conn.
+func (tc *clientTunnelConn) LocalAddr() net.Addr {
+	return tc.getConn.LocalAddr()
+}
+
+// RemoteAddr implements net.Conn.
+func (tc *clientTunnelConn) RemoteAddr() net.Addr {
+	return tc.getConn.RemoteAddr()
+}
+
+// SetDeadline implements net.Conn.
+func (tc *clientTunnelConn) SetDeadline(t time.Time) error {
+	err := tc.getConn.SetDeadline(t)
+	if err != nil {
+		return err
+	}
+	return tc.postConn.SetDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+func (tc *clientTunnelConn) SetReadDeadline(t time.Time) error {
+	return tc.getConn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn.
+func (tc *clientTunnelConn) SetWriteDeadline(t time.Time) error {
+	return tc.postConn.SetWriteDeadline(t)
+}
@@ -2,6 +2,7 @@ package gortsplib
 
 import (
 	"crypto/rand"
+	"fmt"
 	"math/big"
 	"net"
 	"strconv"
@@ -33,13 +34,45 @@ type clientUDPListener struct {
 	readPort  int
 	writeAddr *net.UDPAddr
 
+	pooled         bool
 	running        bool
 	lastPacketTime *int64
+	initialDrops   uint64
 
 	done chan struct{}
 }
 
-func newClientUDPListenerPair(c *Client) (*clientUDPListener, *clientUDPListener, error) {
+type packetConn interface {
+	net.PacketConn
+	SetReadBuffer(int) error
+}
+
+func listenUDP(
+	listenPacket func(network, address string) (net.PacketConn, error),
+	address string,
+) (packetConn, error) {
+	tmp, err := listenPacket(restrictNetwork("udp", address))
+	if err != nil {
+		return nil, err
+	}
+	pc, ok := tmp.(packetConn)
+	if !ok {
+		tmp.Close()
+		return nil, fmt.Errorf("packet connection does not implement SetReadBuffer()")
+	}
+
+	err = pc.SetReadBuffer(udpKernelReadBufferSize)
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+
+	return pc, nil
+}
+
+func listenUDPPair(
+	listenPacket func(network, address string) (net.PacketConn, error),
+) (packetConn, packetConn, error) {
 	// choose two consecutive ports in range 65535-10000
 	// RTP port must be even and RTCP port odd
 	for {
@@ -49,41 +82,59 @@ func newClientUDPListenerPair(c *Client) (*clientUDPListener, *clientUDPListener
 		}
 
 		rtpPort := v*2 + 10000
-		rtpListener, err := newClientUDPListener(
-			c,
-			false,
-			nil,
-			net.JoinHostPort("", strconv.FormatInt(int64(rtpPort), 10)),
-		)
+		rtpPC, err := listenUDP(listenPacket, net.JoinHostPort("", strconv.FormatInt(int64(rtpPort), 10)))
 		if err != nil {
 			continue
 		}
 
 		rtcpPort := rtpPort + 1
-		rtcpListener, err := newClientUDPListener(
-			c,
-			false,
-			nil,
-			net.JoinHostPort("", strconv.FormatInt(int64(rtcpPort), 10)),
-		)
+		rtcpPC, err := listenUDP(listenPacket, net.JoinHostPort("", strconv.FormatInt(int64(rtcpPort), 10)))
 		if err != nil {
-			rtpListener.close()
+			rtpPC.Close()
 			continue
 		}
 
-		return rtpListener, rtcpListener, nil
+		return rtpPC, rtcpPC, nil
 	}
 }
 
-type packetConn interface {
-	net.PacketConn
-	SetReadBuffer(int) error
+func newClientUDPListenerPair(c *Client) (*clientUDPListener, *clientUDPListener, error) {
+	var rtpPC, rtcpPC packetConn
+	var err error
+
+	if c.UDPSocketPool != nil {
+		rtpPC, rtcpPC, err = c.UDPSocketPool.acquire()
+	} else {
+		rtpPC, rtcpPC, err = listenUDPPair(c.ListenPacket)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rtpListener := &clientUDPListener{
+		c:              c,
+		pc:             rtpPC,
+		pooled:         c.UDPSocketPool != nil,
+		lastPacketTime: int64Ptr(0),
+	}
+	rtpListener.initKernelDrops()
+
+	rtcpListener := &clientUDPListener{
+		c:              c,
+		pc:             rtcpPC,
+		pooled:         c.UDPSocketPool != nil,
+		lastPacketTime: int64Ptr(0),
+	}
+	rtcpListener.initKernelDrops()
+
+	return rtpListener, rtcpListener, nil
 }
 
 func newClientUDPListener(
 	c *Client,
 	multicastEnable bool,
 	multicastSourceIP net.IP,
+	multicastOpts multicast.SingleConnOptions,
 	address string,
 ) (*clientUDPListener, error) {
 	var pc packetConn
@@ -93,36 +144,56 @@ func newClientUDPListener(
 			return nil, err
 		}
 
-		pc, err = multicast.NewSingleConn(intf, address, c.ListenPacket)
+		pc, err = multicast.NewSingleConnWithOptions(intf, address, multicastOpts, c.ListenPacket)
+		if err != nil {
+			return nil, err
+		}
+
+		err = pc.SetReadBuffer(udpKernelReadBufferSize)
 		if err != nil {
+			pc.Close()
 			return nil, err
 		}
 	} else {
-		tmp, err := c.ListenPacket(restrictNetwork("udp", address))
+		var err error
+		pc, err = listenUDP(c.ListenPacket, address)
 		if err != nil {
 			return nil, err
 		}
-		pc = tmp.(*net.UDPConn)
-	}
-
-	err := pc.SetReadBuffer(udpKernelReadBufferSize)
-	if err != nil {
-		pc.Close()
-		return nil, err
 	}
 
-	return &clientUDPListener{
+	u := &clientUDPListener{
 		c:              c,
 		pc:             pc,
 		lastPacketTime: int64Ptr(0),
-	}, nil
+	}
+	u.initKernelDrops()
+
+	return u, nil
 }
 
 func (u *clientUDPListener) close() {
 	if u.running {
 		u.stop()
 	}
-	u.pc.Close()
+
+	// pooled sockets are released by clientMedia
+	if !u.pooled {
+		u.pc.Close()
+	}
+}
+
+// sockets may be reused, therefore drops are counted from the creation of the listener.
+func (u *clientUDPListener) initKernelDrops() {
+	u.initialDrops, _ = udpKernelDrops(u.pc)
+}
+
+func (u *clientUDPListener) kernelDrops() (uint64, bool) {
+	drops, ok := udpKernelDrops(u.pc)
+	if !ok {
+		return 0, false
+	}
+	return drops - u.initialDrops, true
 }
 
 func (u *clientUDPListener) port() int {
@@ -144,31 +215,56 @@ func (u *clientUDPListener) stop() {
 func (u *clientUDPListener) run() {
 	defer close(u.done)
 
+	var r udpReader
+	r.initialize(newUDPBatchConn(u.pc, u.c.UDPBatchSize), u.c.PacketBufferReuseEnable)
+	defer r.close()
+
 	for {
-		buf := make([]byte, udpMaxPayloadSize+1)
-		n, addr, err := u.pc.ReadFrom(buf)
+		err := r.read(u.processPacket)
 		if err != nil {
 			return
 		}
+	}
+}
 
-		uaddr := addr.(*net.UDPAddr)
+func (u *clientUDPListener) processPacket(buf []byte, uaddr *net.UDPAddr) {
+	if !u.readIP.Equal(uaddr.IP) {
+		return
+	}
 
-		if !u.readIP.Equal(uaddr.IP) {
-			continue
-		}
+	// in case of anyPortEnable, store the port of the first packet we receive.
+	// this reduces security issues
+	if u.c.AnyPortEnable && u.readPort == 0 {
+		u.readPort = uaddr.Port
+	} else if u.readPort != uaddr.Port {
+		return
+	}
 
-		// in case of anyPortEnable, store the port of the first packet we receive.
-		// this reduces security issues
-		if u.c.AnyPortEnable && u.readPort == 0 {
-			u.readPort = uaddr.Port
-		} else if u.readPort != uaddr.Port {
-			continue
-		}
+	now := u.c.timeNow()
+	atomic.StoreInt64(u.lastPacketTime, now.Unix())
+
+	u.c.capture.writeUDP(uaddr, u.pc.LocalAddr(), buf)
 
-		now := u.c.timeNow()
-		atomic.StoreInt64(u.lastPacketTime, now.Unix())
+	u.readFunc(buf)
+}
 
-		u.readFunc(buf[:n])
+// punch writes a hole punching packet to the server port
+// and to the ones that follow it.
+func (u *clientUDPListener) punch(payload []byte, predictedPorts int) {
+	u.write(payload) //nolint:errcheck
+
+	for i := 1; i <= predictedPorts; i++ {
+		port := u.writeAddr.Port + 2*i
+		if port > 65535 {
+			break
+		}
+
+		u.pc.SetWriteDeadline(time.Now().Add(u.c.WriteTimeout))
+		u.pc.WriteTo(payload, &net.UDPAddr{ //nolint:errcheck
+			IP:   u.writeAddr.IP,
+			Zone: u.writeAddr.Zone,
+			Port: port,
+		})
 	}
 }
 
@@ -177,5 +273,10 @@ func (u *clientUDPListener) write(payload []byte) error {
 	// https://github.com/golang/go/issues/27203#issuecomment-534386117
 	u.pc.SetWriteDeadline(time.Now().Add(u.c.WriteTimeout))
 	_, err := u.pc.WriteTo(payload, u.writeAddr)
-	return err
+	if err != nil {
+		return err
+	}
+
+	u.c.capture.writeUDP(u.pc.LocalAddr(), u.writeAddr, payload)
+	return nil
 }
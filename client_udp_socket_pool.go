@@ -0,0 +1,103 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// maximum number of stale packets discarded when a pair is reused.
+const udpSocketPoolMaxDrainedPackets = 1024
+
+type clientUDPSocketPair struct {
+	rtp  packetConn
+	rtcp packetConn
+}
+
+// drain discards packets that were received after the previous session was closed.
+func (p *clientUDPSocketPair) drain() {
+	buf := make([]byte, udpMaxPayloadSize+1)
+
+	for _, pc := range []packetConn{p.rtp, p.rtcp} {
+		for i := 0; i < udpSocketPoolMaxDrainedPackets; i++ {
+			pc.SetReadDeadline(time.Now().Add(time.Millisecond))
+			_, _, err := pc.ReadFrom(buf)
+			if err != nil {
+				break
+			}
+		}
+	}
+}
+
+// ClientUDPSocketPool is a pool of UDP socket pairs (RTP and RTCP).
+// It can be shared by clients that are used sequentially, in order to avoid
+// allocating new sockets, and therefore new ports, for each session.
+// Packets received by a socket while it is not in use are discarded.
+type ClientUDPSocketPool struct {
+	// function used to initialize UDP sockets.
+	// It defaults to net.ListenPacket.
+	ListenPacket func(network, address string) (net.PacketConn, error)
+
+	mutex  sync.Mutex
+	free   []*clientUDPSocketPair
+	closed bool
+}
+
+// Close closes sockets that are not in use.
+// Sockets in use are closed when they are released by clients.
+func (p *ClientUDPSocketPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+
+	for _, pair := range p.free {
+		pair.rtp.Close()
+		pair.rtcp.Close()
+	}
+	p.free = nil
+}
+
+func (p *ClientUDPSocketPool) acquire() (packetConn, packetConn, error) {
+	p.mutex.Lock()
+
+	if p.closed {
+		p.mutex.Unlock()
+		return nil, nil, fmt.Errorf("UDP socket pool is closed")
+	}
+
+	if len(p.free) != 0 {
+		pair := p.free[len(p.free)-1]
+		p.free = p.free[:len(p.free)-1]
+		p.mutex.Unlock()
+
+		pair.drain()
+		return pair.rtp, pair.rtcp, nil
+	}
+
+	p.mutex.Unlock()
+
+	listenPacket := p.ListenPacket
+	if listenPacket == nil {
+		listenPacket = net.ListenPacket
+	}
+
+	return listenUDPPair(listenPacket)
+}
+
+func (p *ClientUDPSocketPool) release(rtp net.PacketConn, rtcp net.PacketConn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		rtp.Close()
+		rtcp.Close()
+		return
+	}
+
+	p.free = append(p.free, &clientUDPSocketPair{
+		rtp:  rtp.(packetConn),
+		rtcp: rtcp.(packetConn),
+	})
+}
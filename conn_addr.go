@@ -0,0 +1,42 @@
+package gortsplib
+
+import (
+	"net"
+	"strings"
+)
+
+// addrIPZone extracts the IP and the zone of a connection address.
+// It returns a nil IP when the address is not IP-based,
+// as it happens with custom connections (in-memory pipes, serial lines, etc).
+func addrIPZone(addr net.Addr) (net.IP, string) {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP, addr.Zone
+
+	case *net.UDPAddr:
+		return addr.IP, addr.Zone
+
+	case *net.IPAddr:
+		return addr.IP, addr.Zone
+
+	case nil:
+		return nil, ""
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil, ""
+	}
+
+	var zone string
+	if i := strings.IndexByte(host, '%'); i >= 0 {
+		host, zone = host[:i], host[i+1:]
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, ""
+	}
+
+	return ip, zone
+}
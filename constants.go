@@ -6,4 +6,8 @@ const (
 
 	// 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header)
 	udpMaxPayloadSize = 1472
+
+	// maximum number of queued RTP packets that are written together
+	// when UDPWriteBatching is enabled and UDPBatchSize is not set.
+	udpDefaultWriteBatchSize = 64
 )
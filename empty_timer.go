@@ -1,11 +1,11 @@
 package gortsplib
 
 import (
-	"time"
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
 )
 
-func emptyTimer() *time.Timer {
-	t := time.NewTimer(0)
-	<-t.C
+func emptyTimer() clock.Timer {
+	t := clock.System{}.NewTimer(0)
+	<-t.C()
 	return t
 }
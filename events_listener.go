@@ -0,0 +1,259 @@
+package gortsplib
+
+import (
+	"io"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// EventsListener is the interface implemented by all the events listeners.
+// It allows to receive lifecycle events of a Client or a Server,
+// in order to build audit logs or traces.
+// It may implement one or more of the EventsListener* interfaces.
+// Methods are called by multiple goroutines and must not block.
+type EventsListener interface{}
+
+// EventSource contains the entity that generated an event.
+// Fields that are not related to the event are nil.
+type EventSource struct {
+	// client only.
+	Client *Client
+
+	// server only.
+	Conn    *ServerConn
+	Session *ServerSession
+}
+
+// EventRequestCtx is the context of OnRequestReceived and OnRequestSent.
+type EventRequestCtx struct {
+	EventSource
+	Request *base.Request
+}
+
+// EventResponseCtx is the context of OnResponseReceived and OnResponseSent.
+type EventResponseCtx struct {
+	EventSource
+	Response *base.Response
+}
+
+// EventBytesCtx is the context of OnBytesReceived and OnBytesSent.
+type EventBytesCtx struct {
+	EventSource
+	Bytes uint64
+}
+
+// EventSessionCtx is the context of OnSessionCreated and OnSessionDestroyed.
+type EventSessionCtx struct {
+	EventSource
+	Error error // session destroyed only
+}
+
+// EventTransportCtx is the context of OnTransportNegotiated.
+type EventTransportCtx struct {
+	EventSource
+	Media     *description.Media
+	Transport Transport
+}
+
+// EventSSRCCollisionCtx is the context of OnSSRCCollision.
+type EventSSRCCollisionCtx struct {
+	EventSource
+	Stream *ServerStream
+	Media  *description.Media
+	Format format.Format
+	// SSRC of written packets.
+	SSRC uint32
+	// SSRC of packets sent to readers.
+	// It is different from SSRC when ServerStream.RemapCollidingSSRCs is enabled.
+	RemappedSSRC uint32
+}
+
+// EventsListenerOnRequestReceived can be implemented by a EventsListener.
+type EventsListenerOnRequestReceived interface {
+	// called when a request is received.
+	OnRequestReceived(*EventRequestCtx)
+}
+
+// EventsListenerOnRequestSent can be implemented by a EventsListener.
+type EventsListenerOnRequestSent interface {
+	// called when a request is sent.
+	OnRequestSent(*EventRequestCtx)
+}
+
+// EventsListenerOnResponseReceived can be implemented by a EventsListener.
+type EventsListenerOnResponseReceived interface {
+	// called when a response is received.
+	OnResponseReceived(*EventResponseCtx)
+}
+
+// EventsListenerOnResponseSent can be implemented by a EventsListener.
+type EventsListenerOnResponseSent interface {
+	// called when a response is sent.
+	OnResponseSent(*EventResponseCtx)
+}
+
+// EventsListenerOnBytesReceived can be implemented by a EventsListener.
+type EventsListenerOnBytesReceived interface {
+	// called when bytes are read from the control connection or from UDP sockets.
+	OnBytesReceived(*EventBytesCtx)
+}
+
+// EventsListenerOnBytesSent can be implemented by a EventsListener.
+type EventsListenerOnBytesSent interface {
+	// called when bytes are written to the control connection or to UDP sockets.
+	OnBytesSent(*EventBytesCtx)
+}
+
+// EventsListenerOnSessionCreated can be implemented by a EventsListener.
+type EventsListenerOnSessionCreated interface {
+	// called when a session is created.
+	OnSessionCreated(*EventSessionCtx)
+}
+
+// EventsListenerOnSessionDestroyed can be implemented by a EventsListener.
+type EventsListenerOnSessionDestroyed interface {
+	// called when a session is destroyed.
+	OnSessionDestroyed(*EventSessionCtx)
+}
+
+// EventsListenerOnTransportNegotiated can be implemented by a EventsListener.
+type EventsListenerOnTransportNegotiated interface {
+	// called when the transport of a media is negotiated with SETUP.
+	OnTransportNegotiated(*EventTransportCtx)
+}
+
+// EventsListenerOnSSRCCollision can be implemented by a EventsListener.
+type EventsListenerOnSSRCCollision interface {
+	// called when packets written to a format of a ServerStream have
+	// the same SSRC of another format of the same stream.
+	OnSSRCCollision(*EventSSRCCollisionCtx)
+}
+
+// eventsEmitter forwards events to the methods implemented by a EventsListener.
+// Type assertions are performed once.
+type eventsEmitter struct {
+	onRequestReceived     EventsListenerOnRequestReceived
+	onRequestSent         EventsListenerOnRequestSent
+	onResponseReceived    EventsListenerOnResponseReceived
+	onResponseSent        EventsListenerOnResponseSent
+	onBytesReceived       EventsListenerOnBytesReceived
+	onBytesSent           EventsListenerOnBytesSent
+	onSessionCreated      EventsListenerOnSessionCreated
+	onSessionDestroyed    EventsListenerOnSessionDestroyed
+	onTransportNegotiated EventsListenerOnTransportNegotiated
+	onSSRCCollision       EventsListenerOnSSRCCollision
+}
+
+func newEventsEmitter(l EventsListener) *eventsEmitter {
+	e := &eventsEmitter{}
+	e.onRequestReceived, _ = l.(EventsListenerOnRequestReceived)
+	e.onRequestSent, _ = l.(EventsListenerOnRequestSent)
+	e.onResponseReceived, _ = l.(EventsListenerOnResponseReceived)
+	e.onResponseSent, _ = l.(EventsListenerOnResponseSent)
+	e.onBytesReceived, _ = l.(EventsListenerOnBytesReceived)
+	e.onBytesSent, _ = l.(EventsListenerOnBytesSent)
+	e.onSessionCreated, _ = l.(EventsListenerOnSessionCreated)
+	e.onSessionDestroyed, _ = l.(EventsListenerOnSessionDestroyed)
+	e.onTransportNegotiated, _ = l.(EventsListenerOnTransportNegotiated)
+	e.onSSRCCollision, _ = l.(EventsListenerOnSSRCCollision)
+	return e
+}
+
+func (e *eventsEmitter) requestReceived(src EventSource, req *base.Request) {
+	if e.onRequestReceived != nil {
+		e.onRequestReceived.OnRequestReceived(&EventRequestCtx{EventSource: src, Request: req})
+	}
+}
+
+func (e *eventsEmitter) requestSent(src EventSource, req *base.Request) {
+	if e.onRequestSent != nil {
+		e.onRequestSent.OnRequestSent(&EventRequestCtx{EventSource: src, Request: req})
+	}
+}
+
+func (e *eventsEmitter) responseReceived(src EventSource, res *base.Response) {
+	if e.onResponseReceived != nil {
+		e.onResponseReceived.OnResponseReceived(&EventResponseCtx{EventSource: src, Response: res})
+	}
+}
+
+func (e *eventsEmitter) responseSent(src EventSource, res *base.Response) {
+	if e.onResponseSent != nil {
+		e.onResponseSent.OnResponseSent(&EventResponseCtx{EventSource: src, Response: res})
+	}
+}
+
+func (e *eventsEmitter) bytesReceived(src EventSource, n int) {
+	if e.onBytesReceived != nil && n > 0 {
+		e.onBytesReceived.OnBytesReceived(&EventBytesCtx{EventSource: src, Bytes: uint64(n)})
+	}
+}
+
+func (e *eventsEmitter) bytesSent(src EventSource, n int) {
+	if e.onBytesSent != nil && n > 0 {
+		e.onBytesSent.OnBytesSent(&EventBytesCtx{EventSource: src, Bytes: uint64(n)})
+	}
+}
+
+func (e *eventsEmitter) sessionCreated(src EventSource) {
+	if e.onSessionCreated != nil {
+		e.onSessionCreated.OnSessionCreated(&EventSessionCtx{EventSource: src})
+	}
+}
+
+func (e *eventsEmitter) sessionDestroyed(src EventSource, err error) {
+	if e.onSessionDestroyed != nil {
+		e.onSessionDestroyed.OnSessionDestroyed(&EventSessionCtx{EventSource: src, Error: err})
+	}
+}
+
+func (e *eventsEmitter) transportNegotiated(src EventSource, medi *description.Media, transport Transport) {
+	if e.onTransportNegotiated != nil {
+		e.onTransportNegotiated.OnTransportNegotiated(&EventTransportCtx{
+			EventSource: src,
+			Media:       medi,
+			Transport:   transport,
+		})
+	}
+}
+
+func (e *eventsEmitter) ssrcCollision(
+	st *ServerStream,
+	medi *description.Media,
+	forma format.Format,
+	ssrc uint32,
+	remappedSSRC uint32,
+) {
+	if e.onSSRCCollision != nil {
+		e.onSSRCCollision.OnSSRCCollision(&EventSSRCCollisionCtx{
+			Stream:       st,
+			Media:        medi,
+			Format:       forma,
+			SSRC:         ssrc,
+			RemappedSSRC: remappedSSRC,
+		})
+	}
+}
+
+// eventsConn is a io.ReadWriter wrapper that emits bytes events.
+type eventsConn struct {
+	rw     io.ReadWriter
+	events *eventsEmitter
+	src    EventSource
+}
+
+// Read implements io.ReadWriter.
+func (c *eventsConn) Read(p []byte) (int, error) {
+	n, err := c.rw.Read(p)
+	c.events.bytesReceived(c.src, n)
+	return n, err
+}
+
+// Write implements io.ReadWriter.
+func (c *eventsConn) Write(p []byte) (int, error) {
+	n, err := c.rw.Write(p)
+	c.events.bytesSent(c.src, n)
+	return n, err
+}
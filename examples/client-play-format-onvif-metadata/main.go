@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtponvifmetadata"
+	"github.com/pion/rtp"
+)
+
+// This example shows how to
+// 1. connect to a RTSP server
+// 2. check if there's an ONVIF metadata media
+// 3. get metadata documents of that media and decode events and PTZ positions
+
+func main() {
+	c := gortsplib.Client{}
+
+	// parse URL
+	u, err := base.ParseURL("rtsp://localhost:8554/mystream")
+	if err != nil {
+		panic(err)
+	}
+
+	// connect to the server
+	err = c.Start(u.Scheme, u.Host)
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+
+	// find available medias
+	desc, _, err := c.Describe(u)
+	if err != nil {
+		panic(err)
+	}
+
+	// find the ONVIF metadata media and format
+	var forma *format.ONVIFMetadata
+	medi := desc.FindFormat(&forma)
+	if medi == nil {
+		panic("media not found")
+	}
+
+	// create decoder
+	rtpDec, err := forma.CreateDecoder()
+	if err != nil {
+		panic(err)
+	}
+
+	// setup a single media
+	_, err = c.Setup(desc.BaseURL, medi, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+
+	// called when a RTP packet arrives
+	c.OnPacketRTP(medi, forma, func(pkt *rtp.Packet) {
+		// decode timestamp
+		pts, ok := c.PacketPTS(medi, pkt)
+		if !ok {
+			log.Printf("waiting for timestamp")
+			return
+		}
+
+		// extract metadata documents from RTP packets
+		doc, err := rtpDec.Decode(pkt)
+		if err != nil {
+			if err != rtponvifmetadata.ErrMorePacketsNeeded {
+				log.Printf("ERR: %v", err)
+			}
+			return
+		}
+
+		// decode events and PTZ positions
+		var ms rtponvifmetadata.MetadataStream
+		err = ms.Unmarshal(doc)
+		if err != nil {
+			log.Printf("ERR: %v", err)
+			return
+		}
+
+		for _, ev := range ms.Events {
+			log.Printf("received event with PTS %v topic %s data %v\n", pts, ev.Topic, ev.Data)
+		}
+
+		for _, st := range ms.PTZ {
+			if st.PanTilt != nil {
+				log.Printf("received PTZ position with PTS %v pan %v tilt %v\n", pts, st.PanTilt[0], st.PanTilt[1])
+			}
+		}
+	})
+
+	// start playing
+	_, err = c.Play(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// wait until a fatal error
+	panic(c.Wait())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/proxy"
+)
+
+// This example shows how to
+// 1. create a server.
+// 2. read existing streams from external servers or cameras with a single connection each,
+//    and serve them to any number of readers.
+
+func main() {
+	// allocate the proxy.
+	p := &proxy.Proxy{
+		Sources: map[string]string{
+			"/stream": "rtsp://x.x.x.x:8554/mystream",
+		},
+		OnSourceReady: func(path string, _ *description.Session) {
+			log.Printf("stream is ready and can be read from the server at rtsp://localhost:8554%s", path)
+		},
+		OnSourceError: func(path string, err error) {
+			log.Printf("ERR (%s): %v", path, err)
+		},
+	}
+
+	// allocate the server and use the proxy as handler.
+	s := &gortsplib.Server{
+		Handler:           p,
+		RTSPAddress:       ":8554",
+		UDPRTPAddress:     ":8000",
+		UDPRTCPAddress:    ":8001",
+		MulticastIPRange:  "224.1.0.0/16",
+		MulticastRTPPort:  8002,
+		MulticastRTCPPort: 8003,
+	}
+	p.Server = s
+
+	// start the server
+	err := s.Start()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	// start pulling streams
+	err = p.Start()
+	if err != nil {
+		panic(err)
+	}
+	defer p.Close()
+
+	// wait until a fatal error
+	log.Printf("server is ready")
+	panic(s.Wait())
+}
@@ -3,6 +3,7 @@ module github.com/bluenviron/gortsplib/v4
 go 1.19
 
 require (
+	github.com/aler9/writerseeker v1.1.0
 	github.com/bluenviron/mediacommon v1.5.1
 	github.com/google/uuid v1.4.0
 	github.com/pion/rtcp v1.2.12
@@ -10,14 +11,15 @@ require (
 	github.com/pion/sdp/v3 v3.0.6
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/net v0.19.0
+	golang.org/x/sys v0.15.0
 )
 
 require (
+	github.com/abema/go-mp4 v1.1.1 // indirect
 	github.com/asticode/go-astikit v0.30.0 // indirect
 	github.com/asticode/go-astits v1.13.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pion/randutil v0.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.15.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
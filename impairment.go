@@ -0,0 +1,12 @@
+package gortsplib
+
+import (
+	"github.com/bluenviron/gortsplib/v4/pkg/chaos"
+)
+
+// impairRead wraps a readFunc in order to apply impairments to incoming packets.
+func impairRead(i *chaos.Impairer, cb readFunc) readFunc {
+	return func(payload []byte) {
+		i.Process(payload, cb)
+	}
+}
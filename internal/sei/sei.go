@@ -0,0 +1,49 @@
+// Package sei contains a SEI payload parser shared by H264 and H265.
+package sei
+
+import (
+	"fmt"
+)
+
+func readValue(buf []byte) (int, int, error) {
+	v := 0
+	for i, b := range buf {
+		v += int(b)
+		if b != 0xFF {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("SEI is too short")
+}
+
+// Unmarshal decodes the messages contained in a SEI payload.
+// The payload must not contain the NALU header and emulation prevention bytes.
+func Unmarshal(buf []byte, onMessage func(typ int, payload []byte)) error {
+	for len(buf) > 0 {
+		// rbsp_trailing_bits
+		if len(buf) == 1 && buf[0] == 0x80 {
+			break
+		}
+
+		typ, n, err := readValue(buf)
+		if err != nil {
+			return err
+		}
+		buf = buf[n:]
+
+		size, n, err := readValue(buf)
+		if err != nil {
+			return err
+		}
+		buf = buf[n:]
+
+		if size > len(buf) {
+			return fmt.Errorf("invalid SEI payload size: %d", size)
+		}
+
+		onMessage(typ, buf[:size])
+		buf = buf[size:]
+	}
+
+	return nil
+}
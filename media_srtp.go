@@ -0,0 +1,97 @@
+package gortsplib
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/srtp"
+)
+
+// mediaSRTP contains the SRTP contexts of a media
+// whose description contains a crypto attribute.
+// The key of the attribute is used in both directions,
+// since packets are distinguished by SSRC.
+type mediaSRTP struct {
+	in       *srtp.Context
+	out      *srtp.Context
+	outMutex sync.Mutex
+}
+
+func newMediaSRTP(medi *description.Media) (*mediaSRTP, error) {
+	if len(medi.Crypto) == 0 {
+		return nil, nil
+	}
+
+	for _, cr := range medi.Crypto {
+		profile, err := srtp.ProfileFromSuite(cr.Suite)
+		if err != nil {
+			continue
+		}
+
+		// MKI is not supported
+		if len(cr.Keys) != 1 || cr.Keys[0].MKILength != 0 {
+			continue
+		}
+
+		in, err := srtp.New(profile, cr.Keys[0].Key)
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := srtp.New(profile, cr.Keys[0].Key)
+		if err != nil {
+			return nil, err
+		}
+
+		return &mediaSRTP{
+			in:  in,
+			out: out,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("none of the crypto attributes is supported")
+}
+
+func (ms *mediaSRTP) encrypt(encryptFunc func([]byte) ([]byte, error), cb func([]byte)) func([]byte) {
+	return func(payload []byte) {
+		ms.outMutex.Lock()
+		enc, err := encryptFunc(payload)
+		ms.outMutex.Unlock()
+
+		// packets are generated by us and are always valid
+		if err != nil {
+			return
+		}
+
+		cb(enc)
+	}
+}
+
+func (ms *mediaSRTP) decrypt(decryptFunc func([]byte) ([]byte, error), cb readFunc, onError func(error)) readFunc {
+	return func(payload []byte) {
+		dec, err := decryptFunc(payload)
+		if err != nil {
+			onError(err)
+			return
+		}
+
+		cb(dec)
+	}
+}
+
+func (ms *mediaSRTP) encryptRTP(cb func([]byte)) func([]byte) {
+	return ms.encrypt(ms.out.EncryptRTP, cb)
+}
+
+func (ms *mediaSRTP) encryptRTCP(cb func([]byte)) func([]byte) {
+	return ms.encrypt(ms.out.EncryptRTCP, cb)
+}
+
+func (ms *mediaSRTP) decryptRTP(cb readFunc, onError func(error)) readFunc {
+	return ms.decrypt(ms.in.DecryptRTP, cb, onError)
+}
+
+func (ms *mediaSRTP) decryptRTCP(cb readFunc, onError func(error)) readFunc {
+	return ms.decrypt(ms.in.DecryptRTCP, cb, onError)
+}
@@ -0,0 +1,97 @@
+package gortsplib
+
+import (
+	"io"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/metrics"
+)
+
+// roleMetrics contains the metrics of a Client or a Server.
+// Counters related to transports are indexed by Transport.
+type roleMetrics struct {
+	lastSessionID   uint64 // must be first for atomic alignment on 32-bit platforms
+	collector       metrics.Collector
+	role            string
+	sessions        metrics.Gauge
+	packetsReceived [3]metrics.Counter
+	packetsSent     [3]metrics.Counter
+	bytesReceived   [3]metrics.Counter
+	bytesSent       [3]metrics.Counter
+}
+
+func newRoleMetrics(collector metrics.Collector, role string) *roleMetrics {
+	m := &roleMetrics{
+		collector: collector,
+		role:      role,
+		sessions:  collector.Gauge(metrics.NameSessions, metrics.Labels{"role": role}),
+	}
+
+	for _, tr := range []Transport{TransportUDP, TransportUDPMulticast, TransportTCP} {
+		labels := metrics.Labels{"role": role, "transport": tr.String()}
+		m.packetsReceived[tr] = collector.Counter(metrics.NamePacketsReceived, labels)
+		m.packetsSent[tr] = collector.Counter(metrics.NamePacketsSent, labels)
+		m.bytesReceived[tr] = collector.Counter(metrics.NameBytesReceived, labels)
+		m.bytesSent[tr] = collector.Counter(metrics.NameBytesSent, labels)
+	}
+
+	return m
+}
+
+// newSessionID returns an identifier that distinguishes track metrics of concurrent sessions.
+// It is not the RTSP session ID, that must not be shared.
+func (m *roleMetrics) newSessionID() string {
+	return strconv.FormatUint(atomic.AddUint64(&m.lastSessionID, 1), 10)
+}
+
+func (m *roleMetrics) trackLabels(
+	session string,
+	path string,
+	medi *description.Media,
+	forma format.Format,
+) metrics.Labels {
+	return metrics.Labels{
+		"role":         m.role,
+		"session":      session,
+		"path":         path,
+		"media":        string(medi.Type),
+		"payload_type": strconv.FormatUint(uint64(forma.PayloadType()), 10),
+	}
+}
+
+func (m *roleMetrics) packetsLost(labels metrics.Labels) metrics.Counter {
+	return m.collector.Counter(metrics.NamePacketsLost, labels)
+}
+
+func (m *roleMetrics) jitter(labels metrics.Labels) metrics.Gauge {
+	return m.collector.Gauge(metrics.NameJitter, labels)
+}
+
+// deleteTrack removes track metrics once the session that fed them is closed.
+func (m *roleMetrics) deleteTrack(labels metrics.Labels) {
+	m.collector.Delete(metrics.NamePacketsLost, labels)
+	m.collector.Delete(metrics.NameJitter, labels)
+}
+
+// metricsConn is a io.ReadWriter wrapper that feeds byte counters of the TCP transport.
+type metricsConn struct {
+	rw io.ReadWriter
+	m  *roleMetrics
+}
+
+// Read implements io.ReadWriter.
+func (c *metricsConn) Read(p []byte) (int, error) {
+	n, err := c.rw.Read(p)
+	c.m.bytesReceived[TransportTCP].Add(uint64(n))
+	return n, err
+}
+
+// Write implements io.ReadWriter.
+func (c *metricsConn) Write(p []byte) (int, error) {
+	n, err := c.rw.Write(p)
+	c.m.bytesSent[TransportTCP].Add(uint64(n))
+	return n, err
+}
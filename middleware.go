@@ -0,0 +1,38 @@
+package gortsplib
+
+import (
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// ClientRoundTripFunc sends a request to the server and returns its response.
+// The response is nil when the request does not expect one.
+type ClientRoundTripFunc func(req *base.Request) (*base.Response, error)
+
+// ClientMiddleware wraps the sending of requests of a Client.
+// It can inspect and rewrite the request before calling next,
+// and inspect and rewrite the response after calling next.
+type ClientMiddleware func(next ClientRoundTripFunc) ClientRoundTripFunc
+
+// ServerRequestHandler handles a request received by a connection and returns the response.
+// If an error is returned, the connection is closed after the response is written.
+type ServerRequestHandler func(sc *ServerConn, req *base.Request) (*base.Response, error)
+
+// ServerMiddleware wraps the handling of requests of a Server.
+// It can inspect and rewrite the request before calling next,
+// inspect and rewrite the response after calling next,
+// or return a response without calling next (i.e. to reject the request).
+type ServerMiddleware func(next ServerRequestHandler) ServerRequestHandler
+
+func chainClientMiddlewares(middlewares []ClientMiddleware, f ClientRoundTripFunc) ClientRoundTripFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		f = middlewares[i](f)
+	}
+	return f
+}
+
+func chainServerMiddlewares(middlewares []ServerMiddleware, h ServerRequestHandler) ServerRequestHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
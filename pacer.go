@@ -0,0 +1,81 @@
+package gortsplib
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+)
+
+// pacer spreads bursts of outgoing packets over a time window,
+// in order not to overflow buffers of switches and client networks.
+// When a burst is detected (i.e. packets are queued), packets are sent at a rate that allows
+// to send the whole burst in the window. The rate is increased when the burst grows.
+// wait() is called by the writer routine only, while windows can be changed at any time.
+type pacer struct {
+	clock  clock.Clock
+	queued func() int
+
+	streamWindow *int64 // window of the stream that is being read
+	window       *int64 // window set on the session, negative when unset
+
+	inBurst  bool
+	interval time.Duration
+	lastSend time.Time
+}
+
+func newPacer(clk clock.Clock, queued func() int) *pacer {
+	p := &pacer{
+		clock:        clk,
+		queued:       queued,
+		streamWindow: new(int64),
+		window:       new(int64),
+	}
+	*p.window = -1
+	return p
+}
+
+func (p *pacer) setStreamWindow(window time.Duration) {
+	atomic.StoreInt64(p.streamWindow, int64(window))
+}
+
+func (p *pacer) setWindow(window time.Duration) {
+	atomic.StoreInt64(p.window, int64(window))
+}
+
+func (p *pacer) currentWindow() time.Duration {
+	if v := atomic.LoadInt64(p.window); v >= 0 {
+		return time.Duration(v)
+	}
+	return time.Duration(atomic.LoadInt64(p.streamWindow))
+}
+
+// wait waits until the next packet can be sent.
+func (p *pacer) wait() {
+	window := p.currentWindow()
+	if window <= 0 {
+		p.inBurst = false
+		return
+	}
+
+	queued := p.queued()
+
+	interval := window / time.Duration(queued+1)
+	if p.inBurst && p.interval < interval {
+		interval = p.interval
+	}
+
+	now := p.clock.Now()
+
+	if p.inBurst {
+		if d := p.lastSend.Add(interval).Sub(now); d > 0 {
+			t := p.clock.NewTimer(d)
+			<-t.C()
+			now = now.Add(d)
+		}
+	}
+
+	p.inBurst = queued != 0
+	p.interval = interval
+	p.lastSend = now
+}
@@ -0,0 +1,75 @@
+package gortsplib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+)
+
+func TestPacer(t *testing.T) {
+	for _, ca := range []struct {
+		name          string
+		streamWindow  time.Duration
+		sessionWindow time.Duration
+		paced         bool
+	}{
+		{
+			"stream window",
+			100 * time.Millisecond,
+			-1,
+			true,
+		},
+		{
+			"session window",
+			0,
+			100 * time.Millisecond,
+			true,
+		},
+		{
+			"disabled by session",
+			100 * time.Millisecond,
+			0,
+			false,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			queued := 0
+
+			p := newPacer(clock.System{}, func() int { return queued })
+			p.setStreamWindow(ca.streamWindow)
+			p.setWindow(ca.sessionWindow)
+
+			start := time.Now()
+
+			// a burst of 10 packets
+			for queued = 9; queued >= 0; queued-- {
+				p.wait()
+			}
+
+			elapsed := time.Since(start)
+
+			if ca.paced {
+				require.GreaterOrEqual(t, elapsed, 85*time.Millisecond)
+				require.Less(t, elapsed, 300*time.Millisecond)
+			} else {
+				require.Less(t, elapsed, 50*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestPacerIsolatedPackets(t *testing.T) {
+	p := newPacer(clock.System{}, func() int { return 0 })
+	p.setStreamWindow(time.Second)
+
+	start := time.Now()
+
+	for i := 0; i < 10; i++ {
+		p.wait()
+	}
+
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -0,0 +1,169 @@
+package gortsplib
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/pcapng"
+)
+
+// packetCapture writes RTSP messages, RTP and RTCP packets into a pcapng file.
+// Its zero value is a disabled capture.
+type packetCapture struct {
+	enabled int32
+
+	mutex sync.Mutex
+	w     *pcapng.Writer
+}
+
+func (pc *packetCapture) start(w io.Writer) error {
+	pw := &pcapng.Writer{W: w}
+	err := pw.Initialize()
+	if err != nil {
+		return err
+	}
+
+	pc.mutex.Lock()
+	pc.w = pw
+	pc.mutex.Unlock()
+
+	atomic.StoreInt32(&pc.enabled, 1)
+	return nil
+}
+
+func (pc *packetCapture) stop() {
+	atomic.StoreInt32(&pc.enabled, 0)
+
+	pc.mutex.Lock()
+	pc.w = nil
+	pc.mutex.Unlock()
+}
+
+func (pc *packetCapture) isEnabled() bool {
+	return atomic.LoadInt32(&pc.enabled) == 1
+}
+
+func (pc *packetCapture) write(cb func(w *pcapng.Writer) error) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	if pc.w == nil {
+		return
+	}
+
+	// stop the capture when the destination can't be written anymore
+	err := cb(pc.w)
+	if err != nil {
+		atomic.StoreInt32(&pc.enabled, 0)
+		pc.w = nil
+	}
+}
+
+func (pc *packetCapture) writeUDP(src net.Addr, dst net.Addr, payload []byte) {
+	if !pc.isEnabled() {
+		return
+	}
+
+	now := time.Now()
+
+	pc.write(func(w *pcapng.Writer) error {
+		return w.WriteUDP(now, captureUDPAddr(src), captureUDPAddr(dst), payload)
+	})
+}
+
+func (pc *packetCapture) writeTCP(src net.Addr, dst net.Addr, payload []byte) {
+	if !pc.isEnabled() {
+		return
+	}
+
+	now := time.Now()
+
+	pc.write(func(w *pcapng.Writer) error {
+		return w.WriteTCP(now, captureTCPAddr(src), captureTCPAddr(dst), payload)
+	})
+}
+
+func (pc *packetCapture) writeFrame(src net.Addr, dst net.Addr, frame *base.InterleavedFrame) {
+	if !pc.isEnabled() {
+		return
+	}
+
+	buf, err := frame.Marshal()
+	if err != nil {
+		return
+	}
+
+	pc.writeTCP(src, dst, buf)
+}
+
+// writeMessages writes a request received from the counterpart and the related response.
+func (pc *packetCapture) writeMessages(local net.Addr, remote net.Addr, req *base.Request, res *base.Response) {
+	if !pc.isEnabled() {
+		return
+	}
+
+	buf, err := req.Marshal()
+	if err != nil {
+		return
+	}
+	pc.writeTCP(remote, local, buf)
+
+	buf, err = res.Marshal()
+	if err != nil {
+		return
+	}
+	pc.writeTCP(local, remote, buf)
+}
+
+// addrPort extracts the port of a connection address.
+func addrPort(addr net.Addr) int {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return addr.Port
+
+	case *net.UDPAddr:
+		return addr.Port
+	}
+	return 0
+}
+
+func captureUDPAddr(addr net.Addr) *net.UDPAddr {
+	ip, _ := addrIPZone(addr)
+	return &net.UDPAddr{IP: ip, Port: addrPort(addr)}
+}
+
+func captureTCPAddr(addr net.Addr) *net.TCPAddr {
+	ip, _ := addrIPZone(addr)
+	return &net.TCPAddr{IP: ip, Port: addrPort(addr)}
+}
+
+// captureConn is a io.ReadWriter wrapper that writes
+// read and written bytes into a packet capture.
+type captureConn struct {
+	rw         io.ReadWriter
+	capture    *packetCapture
+	localAddr  net.Addr
+	remoteAddr net.Addr
+}
+
+// Read implements io.ReadWriter.
+func (cc *captureConn) Read(p []byte) (int, error) {
+	n, err := cc.rw.Read(p)
+	if n > 0 {
+		cc.capture.writeTCP(cc.remoteAddr, cc.localAddr, p[:n])
+	}
+	return n, err
+}
+
+// Write implements io.ReadWriter.
+func (cc *captureConn) Write(p []byte) (int, error) {
+	n, err := cc.rw.Write(p)
+	if n > 0 {
+		cc.capture.writeTCP(cc.localAddr, cc.remoteAddr, p[:n])
+	}
+	return n, err
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,6 +10,10 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 )
 
+func stringPtr(v string) *string {
+	return &v
+}
+
 func mustParseURL(s string) *base.URL {
 	u, err := base.ParseURL(s)
 	if err != nil {
@@ -127,3 +132,100 @@ func TestAuthVLC(t *testing.T) {
 		require.Error(t, err)
 	}
 }
+
+func TestAuthDigest(t *testing.T) {
+	for _, ca := range []struct {
+		name       string
+		algorithms []DigestAlgorithm
+		qop        bool
+		algorithm  *string
+	}{
+		{
+			"md5",
+			[]DigestAlgorithm{DigestAlgorithmMD5},
+			false,
+			stringPtr("MD5"),
+		},
+		{
+			"sha-256 qop",
+			[]DigestAlgorithm{DigestAlgorithmSHA256},
+			true,
+			stringPtr("SHA-256"),
+		},
+		{
+			"sha-512-256 qop",
+			[]DigestAlgorithm{DigestAlgorithmMD5, DigestAlgorithmSHA512256, DigestAlgorithmSHA256},
+			true,
+			stringPtr("SHA-512-256"),
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			nonce, err := GenerateNonce()
+			require.NoError(t, err)
+
+			se, err := NewSender(WWWAuthenticate{
+				Methods:          []headers.AuthMethod{headers.AuthDigest},
+				DigestAlgorithms: ca.algorithms,
+				DigestQOP:        ca.qop,
+				Realm:            "IPCAM",
+				Nonce:            nonce,
+			}.Marshal(), "testuser", "testpass")
+			require.NoError(t, err)
+
+			for i := 1; i <= 2; i++ {
+				req := &base.Request{
+					Method: base.Announce,
+					URL:    mustParseURL("rtsp://myhost/mypath"),
+					Body:   []byte("testing"),
+				}
+				se.AddAuthorization(req)
+
+				var auth headers.Authorization
+				err = auth.Unmarshal(req.Header["Authorization"])
+				require.NoError(t, err)
+				require.Equal(t, ca.algorithm, auth.DigestValues.Algorithm)
+
+				if ca.qop {
+					require.Equal(t, "auth", *auth.DigestValues.QOP)
+					require.Equal(t, fmt.Sprintf("%08x", i), *auth.DigestValues.NC)
+				} else {
+					require.Nil(t, auth.DigestValues.QOP)
+				}
+
+				err = Validate(req, "testuser", "testpass", nil, nil, "IPCAM", nonce)
+				require.NoError(t, err)
+
+				err = Validate(req, "testuser", "test1pass", nil, nil, "IPCAM", nonce)
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthDigestAuthInt(t *testing.T) {
+	se, err := NewSender(base.HeaderValue{
+		`Digest realm="IPCAM", nonce="abcde", algorithm=SHA-256, qop="auth-int", opaque="xyz"`,
+	}, "testuser", "testpass")
+	require.NoError(t, err)
+
+	req := &base.Request{
+		Method: base.Announce,
+		URL:    mustParseURL("rtsp://myhost/mypath"),
+		Body:   []byte("testing"),
+	}
+	se.AddAuthorization(req)
+
+	var auth headers.Authorization
+	err = auth.Unmarshal(req.Header["Authorization"])
+	require.NoError(t, err)
+	require.Equal(t, "auth-int", *auth.DigestValues.QOP)
+	require.Equal(t, "xyz", *auth.DigestValues.Opaque)
+
+	err = Validate(req, "testuser", "testpass", nil, nil, "IPCAM", "abcde")
+	require.NoError(t, err)
+
+	// the body is protected too
+	req.Body = []byte("testinh")
+	err = Validate(req, "testuser", "testpass", nil, nil, "IPCAM", "abcde")
+	require.EqualError(t, err, "authentication failed")
+}
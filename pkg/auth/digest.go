@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// DigestAlgorithm is an algorithm of the Digest authentication method.
+type DigestAlgorithm string
+
+// digest algorithms.
+const (
+	DigestAlgorithmMD5       DigestAlgorithm = "MD5"
+	DigestAlgorithmSHA256    DigestAlgorithm = "SHA-256"
+	DigestAlgorithmSHA512256 DigestAlgorithm = "SHA-512-256"
+)
+
+// strength of algorithms. Higher is better.
+var digestAlgorithmStrength = map[DigestAlgorithm]int{
+	DigestAlgorithmMD5:       0,
+	DigestAlgorithmSHA256:    1,
+	DigestAlgorithmSHA512256: 2,
+}
+
+func digestAlgorithmUnmarshal(v *string) (DigestAlgorithm, error) {
+	// when algorithm is not present, MD5 is used
+	if v == nil {
+		return DigestAlgorithmMD5, nil
+	}
+
+	algo := DigestAlgorithm(strings.ToUpper(*v))
+	if _, ok := digestAlgorithmStrength[algo]; !ok {
+		return "", fmt.Errorf("unsupported algorithm (%v)", *v)
+	}
+
+	return algo, nil
+}
+
+func (a DigestAlgorithm) hash(in string) string {
+	var h hash.Hash
+
+	switch a {
+	case DigestAlgorithmSHA256:
+		h = sha256.New()
+
+	case DigestAlgorithmSHA512256:
+		h = sha512.New512_256()
+
+	default:
+		h = md5.New()
+	}
+
+	h.Write([]byte(in))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+const (
+	qopAuth    = "auth"
+	qopAuthInt = "auth-int"
+)
+
+// digestQOPChoose chooses a quality of protection amongst the ones offered by a server.
+func digestQOPChoose(v string) (string, error) {
+	authIntFound := false
+
+	for _, qop := range strings.Split(v, ",") {
+		switch strings.TrimSpace(qop) {
+		case qopAuth:
+			return qopAuth, nil
+
+		case qopAuthInt:
+			authIntFound = true
+		}
+	}
+
+	if authIntFound {
+		return qopAuthInt, nil
+	}
+
+	return "", fmt.Errorf("unsupported qop (%v)", v)
+}
+
+// digestResponse computes the response of the Digest authentication method (RFC 7616).
+// When qop is empty, the response is computed as in RFC 2069.
+func digestResponse(
+	algo DigestAlgorithm,
+	user string,
+	realm string,
+	pass string,
+	nonce string,
+	method string,
+	uri string,
+	qop string,
+	nc string,
+	cnonce string,
+	body []byte,
+) string {
+	ha1 := algo.hash(user + ":" + realm + ":" + pass)
+
+	var ha2 string
+	if qop == qopAuthInt {
+		ha2 = algo.hash(method + ":" + uri + ":" + algo.hash(string(body)))
+	} else {
+		ha2 = algo.hash(method + ":" + uri)
+	}
+
+	if qop == "" {
+		return algo.hash(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	return algo.hash(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// examples from RFC 7616, section 3.9.1
+func TestDigestResponse(t *testing.T) {
+	for _, ca := range []struct {
+		algo     DigestAlgorithm
+		response string
+	}{
+		{
+			DigestAlgorithmMD5,
+			"8ca523f5e9506fed4657c9700eebdbec",
+		},
+		{
+			DigestAlgorithmSHA256,
+			"753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		},
+	} {
+		t.Run(string(ca.algo), func(t *testing.T) {
+			response := digestResponse(
+				ca.algo,
+				"Mufasa",
+				"http-auth@example.org",
+				"Circle of Life",
+				"7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				"GET",
+				"/dir/index.html",
+				"auth",
+				"00000001",
+				"f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+				nil)
+			require.Equal(t, ca.response, response)
+		})
+	}
+}
@@ -8,12 +8,20 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 )
 
-func findHeader(v base.HeaderValue, prefix string) string {
+func findHeaders(v base.HeaderValue, prefix string) []string {
+	var ret []string
 	for _, vi := range v {
 		if strings.HasPrefix(vi, prefix) {
-			return vi
+			ret = append(ret, vi)
 		}
 	}
+	return ret
+}
+
+func findHeader(v base.HeaderValue, prefix string) string {
+	if ret := findHeaders(v, prefix); ret != nil {
+		return ret[0]
+	}
 	return ""
 }
 
@@ -24,35 +32,84 @@ type Sender struct {
 	method headers.AuthMethod
 	realm  string
 	nonce  string
+
+	// digest
+	algorithm       *string
+	digestAlgorithm DigestAlgorithm
+	opaque          *string
+	qop             string
+	cnonce          string
+	nc              uint32
 }
 
 // NewSender allocates a Sender.
-// It requires a WWW-Authenticate header (provided by the server)
-// and a set of credentials.
+// It requires a WWW-Authenticate or Proxy-Authenticate header
+// (provided by the server or by a proxy) and a set of credentials.
 func NewSender(v base.HeaderValue, user string, pass string) (*Sender, error) {
 	// prefer digest
-	if v0 := findHeader(v, "Digest"); v0 != "" {
-		var auth headers.Authenticate
-		err := auth.Unmarshal(base.HeaderValue{v0})
-		if err != nil {
-			return nil, err
+	if vs := findHeaders(v, "Digest"); vs != nil {
+		var se *Sender
+
+		for _, vi := range vs {
+			var auth headers.Authenticate
+			err := auth.Unmarshal(base.HeaderValue{vi})
+			if err != nil {
+				return nil, err
+			}
+
+			if auth.Realm == nil {
+				return nil, fmt.Errorf("realm is missing")
+			}
+
+			if auth.Nonce == nil {
+				return nil, fmt.Errorf("nonce is missing")
+			}
+
+			// skip challenges with unsupported algorithms
+			algo, err := digestAlgorithmUnmarshal(auth.Algorithm)
+			if err != nil {
+				continue
+			}
+
+			// prefer the strongest algorithm
+			if se != nil && digestAlgorithmStrength[algo] <= digestAlgorithmStrength[se.digestAlgorithm] {
+				continue
+			}
+
+			se = &Sender{
+				user:            user,
+				pass:            pass,
+				method:          headers.AuthDigest,
+				realm:           *auth.Realm,
+				nonce:           *auth.Nonce,
+				algorithm:       auth.Algorithm,
+				digestAlgorithm: algo,
+				opaque:          auth.Opaque,
+			}
+
+			if auth.QOP != nil {
+				se.qop, err = digestQOPChoose(*auth.QOP)
+				if err != nil {
+					return nil, err
+				}
+			}
 		}
 
-		if auth.Realm == nil {
-			return nil, fmt.Errorf("realm is missing")
-		}
+		if se != nil {
+			if se.qop != "" {
+				var err error
+				se.cnonce, err = GenerateNonce()
+				if err != nil {
+					return nil, err
+				}
+			}
 
-		if auth.Nonce == nil {
-			return nil, fmt.Errorf("nonce is missing")
+			return se, nil
 		}
 
-		return &Sender{
-			user:   user,
-			pass:   pass,
-			method: headers.AuthDigest,
-			realm:  *auth.Realm,
-			nonce:  *auth.Nonce,
-		}, nil
+		if findHeader(v, "Basic") == "" {
+			return nil, fmt.Errorf("no supported digest algorithms found")
+		}
 	}
 
 	if v0 := findHeader(v, "Basic"); v0 != "" {
@@ -77,8 +134,41 @@ func NewSender(v base.HeaderValue, user string, pass string) (*Sender, error) {
 	return nil, fmt.Errorf("no authentication methods available")
 }
 
+// IsStale checks whether a WWW-Authenticate or Proxy-Authenticate header
+// rejects the credentials because their nonce is expired.
+// In this case, a new Sender must be allocated with the new header.
+func (se *Sender) IsStale(v base.HeaderValue) bool {
+	if se.method != headers.AuthDigest {
+		return false
+	}
+
+	for _, vi := range findHeaders(v, "Digest") {
+		var auth headers.Authenticate
+		err := auth.Unmarshal(base.HeaderValue{vi})
+		if err != nil {
+			continue
+		}
+
+		if auth.Stale != nil && strings.EqualFold(*auth.Stale, "true") &&
+			auth.Nonce != nil && *auth.Nonce != se.nonce {
+			return true
+		}
+	}
+
+	return false
+}
+
 // AddAuthorization adds the Authorization header to a Request.
 func (se *Sender) AddAuthorization(req *base.Request) {
+	se.addHeader(req, "Authorization")
+}
+
+// AddProxyAuthorization adds the Proxy-Authorization header to a Request.
+func (se *Sender) AddProxyAuthorization(req *base.Request) {
+	se.addHeader(req, "Proxy-Authorization")
+}
+
+func (se *Sender) addHeader(req *base.Request, key string) {
 	urStr := req.URL.CloneWithoutCredentials().String()
 
 	h := headers.Authorization{
@@ -91,22 +181,35 @@ func (se *Sender) AddAuthorization(req *base.Request) {
 		h.BasicPass = se.pass
 
 	default: // headers.AuthDigest
-		response := md5Hex(md5Hex(se.user+":"+se.realm+":"+se.pass) + ":" +
-			se.nonce + ":" + md5Hex(string(req.Method)+":"+urStr))
-
 		h.DigestValues = headers.Authenticate{
-			Method:   headers.AuthDigest,
-			Username: &se.user,
-			Realm:    &se.realm,
-			Nonce:    &se.nonce,
-			URI:      &urStr,
-			Response: &response,
+			Method:    headers.AuthDigest,
+			Username:  &se.user,
+			Realm:     &se.realm,
+			Nonce:     &se.nonce,
+			URI:       &urStr,
+			Opaque:    se.opaque,
+			Algorithm: se.algorithm,
 		}
+
+		var nc string
+		if se.qop != "" {
+			se.nc++
+			nc = fmt.Sprintf("%08x", se.nc)
+
+			qop := se.qop
+			h.DigestValues.QOP = &qop
+			h.DigestValues.NC = &nc
+			h.DigestValues.CNonce = &se.cnonce
+		}
+
+		response := digestResponse(se.digestAlgorithm, se.user, se.realm, se.pass, se.nonce,
+			string(req.Method), urStr, se.qop, nc, se.cnonce, req.Body)
+		h.DigestValues.Response = &response
 	}
 
 	if req.Header == nil {
 		req.Header = make(base.Header)
 	}
 
-	req.Header["Authorization"] = h.Marshal()
+	req.Header[key] = h.Marshal()
 }
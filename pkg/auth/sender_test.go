@@ -34,6 +34,16 @@ func TestSenderErrors(t *testing.T) {
 			base.HeaderValue{`Digest realm=123`},
 			"nonce is missing",
 		},
+		{
+			"digest, unsupported algorithm",
+			base.HeaderValue{`Digest realm=123, nonce=123, algorithm=SHA-1`},
+			"no supported digest algorithms found",
+		},
+		{
+			"digest, unsupported qop",
+			base.HeaderValue{`Digest realm=123, nonce=123, qop="other"`},
+			"unsupported qop (other)",
+		},
 		{
 			"basic invalid",
 			base.HeaderValue{`Basic`},
@@ -51,3 +61,63 @@ func TestSenderErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestSenderAddProxyAuthorization(t *testing.T) {
+	nonce, err := GenerateNonce()
+	require.NoError(t, err)
+
+	se, err := NewSender(GenerateWWWAuthenticate(nil, "PROXY", nonce), "proxyuser", "proxypass")
+	require.NoError(t, err)
+
+	req := &base.Request{
+		Method: base.Describe,
+		URL:    mustParseURL("rtsp://myhost/mypath"),
+	}
+	se.AddProxyAuthorization(req)
+
+	require.Nil(t, req.Header["Authorization"])
+
+	err = Validate(&base.Request{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: base.Header{
+			"Authorization": req.Header["Proxy-Authorization"],
+		},
+	}, "proxyuser", "proxypass", nil, nil, "PROXY", nonce)
+	require.NoError(t, err)
+}
+
+func TestSenderFallbackToBasic(t *testing.T) {
+	se, err := NewSender(base.HeaderValue{
+		`Digest realm="IPCAM", nonce="abcde", algorithm=SHA-1`,
+		`Basic realm="IPCAM"`,
+	}, "testuser", "testpass")
+	require.NoError(t, err)
+
+	req := &base.Request{
+		Method: base.Describe,
+		URL:    mustParseURL("rtsp://myhost/mypath"),
+	}
+	se.AddAuthorization(req)
+
+	require.Equal(t, base.HeaderValue{"Basic dGVzdHVzZXI6dGVzdHBhc3M="}, req.Header["Authorization"])
+}
+
+func TestSenderIsStale(t *testing.T) {
+	se, err := NewSender(GenerateWWWAuthenticate(nil, "IPCAM", "abcde"), "testuser", "testpass")
+	require.NoError(t, err)
+
+	require.False(t, se.IsStale(GenerateWWWAuthenticate(nil, "IPCAM", "fghij")))
+
+	require.False(t, se.IsStale(WWWAuthenticate{
+		Realm: "IPCAM",
+		Nonce: "abcde",
+		Stale: true,
+	}.Marshal()))
+
+	require.True(t, se.IsStale(WWWAuthenticate{
+		Realm: "IPCAM",
+		Nonce: "fghij",
+		Stale: true,
+	}.Marshal()))
+}
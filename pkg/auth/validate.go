@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"crypto/md5"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -10,12 +9,6 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 )
 
-func md5Hex(in string) string {
-	h := md5.New()
-	h.Write([]byte(in))
-	return hex.EncodeToString(h.Sum(nil))
-}
-
 // GenerateNonce generates a nonce that can be used in Validate().
 func GenerateNonce() (string, error) {
 	byts := make([]byte, 16)
@@ -29,6 +22,40 @@ func GenerateNonce() (string, error) {
 
 // GenerateWWWAuthenticate generates a WWW-Authenticate header.
 func GenerateWWWAuthenticate(methods []headers.AuthMethod, realm string, nonce string) base.HeaderValue {
+	return WWWAuthenticate{
+		Methods: methods,
+		Realm:   realm,
+		Nonce:   nonce,
+	}.Marshal()
+}
+
+// WWWAuthenticate contains the parameters of a WWW-Authenticate header.
+type WWWAuthenticate struct {
+	// authentication methods.
+	// It defaults to Basic and Digest.
+	Methods []headers.AuthMethod
+
+	// algorithms of the Digest method, in order of preference.
+	// A challenge is generated for each algorithm.
+	// It defaults to MD5, without the algorithm parameter.
+	DigestAlgorithms []DigestAlgorithm
+
+	// allow clients to use qop="auth,auth-int" in the Digest method.
+	DigestQOP bool
+
+	// realm.
+	Realm string
+
+	// nonce.
+	Nonce string
+
+	// whether the request has been rejected because its nonce is expired.
+	Stale bool
+}
+
+// Marshal encodes a WWW-Authenticate header.
+func (w WWWAuthenticate) Marshal() base.HeaderValue {
+	methods := w.Methods
 	if methods == nil {
 		methods = []headers.AuthMethod{headers.AuthBasic, headers.AuthDigest}
 	}
@@ -39,15 +66,39 @@ func GenerateWWWAuthenticate(methods []headers.AuthMethod, realm string, nonce s
 		case headers.AuthBasic:
 			ret = append(ret, (&headers.Authenticate{
 				Method: headers.AuthBasic,
-				Realm:  &realm,
+				Realm:  &w.Realm,
 			}).Marshal()...)
 
 		case headers.AuthDigest:
-			ret = append(ret, headers.Authenticate{
-				Method: headers.AuthDigest,
-				Realm:  &realm,
-				Nonce:  &nonce,
-			}.Marshal()...)
+			algos := w.DigestAlgorithms
+			if algos == nil {
+				algos = []DigestAlgorithm{""}
+			}
+
+			for _, algo := range algos {
+				h := headers.Authenticate{
+					Method: headers.AuthDigest,
+					Realm:  &w.Realm,
+					Nonce:  &w.Nonce,
+				}
+
+				if w.Stale {
+					v := "true"
+					h.Stale = &v
+				}
+
+				if algo != "" {
+					v := string(algo)
+					h.Algorithm = &v
+				}
+
+				if w.DigestQOP {
+					v := qopAuth + "," + qopAuthInt
+					h.QOP = &v
+				}
+
+				ret = append(ret, h.Marshal()...)
+			}
 		}
 	}
 	return ret
@@ -124,6 +175,32 @@ func Validate(
 			return fmt.Errorf("authentication failed")
 		}
 
+		algo, err := digestAlgorithmUnmarshal(auth.DigestValues.Algorithm)
+		if err != nil {
+			return err
+		}
+
+		var qop string
+		var nc string
+		var cnonce string
+
+		if auth.DigestValues.QOP != nil {
+			qop = *auth.DigestValues.QOP
+			if qop != qopAuth && qop != qopAuthInt {
+				return fmt.Errorf("unsupported qop (%v)", qop)
+			}
+
+			if auth.DigestValues.NC == nil {
+				return fmt.Errorf("nc is missing")
+			}
+			nc = *auth.DigestValues.NC
+
+			if auth.DigestValues.CNonce == nil {
+				return fmt.Errorf("cnonce is missing")
+			}
+			cnonce = *auth.DigestValues.CNonce
+		}
+
 		ur := req.URL
 
 		if *auth.DigestValues.URI != ur.String() {
@@ -139,8 +216,8 @@ func Validate(
 			}
 		}
 
-		response := md5Hex(md5Hex(user+":"+realm+":"+pass) +
-			":" + nonce + ":" + md5Hex(string(req.Method)+":"+ur.String()))
+		response := digestResponse(algo, user, realm, pass, nonce,
+			string(req.Method), ur.String(), qop, nc, cnonce, req.Body)
 
 		if *auth.DigestValues.Response != response {
 			return fmt.Errorf("authentication failed")
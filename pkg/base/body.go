@@ -31,8 +31,9 @@ func (b *body) unmarshal(header Header, rb *bufio.Reader) error {
 	}
 
 	*b = make([]byte, cl)
-	n, err := io.ReadFull(rb, *b)
-	if err != nil && n != len(*b) {
+	// the body may arrive in multiple reads, possibly together with the final header CRLF.
+	_, err = io.ReadFull(rb, *b)
+	if err != nil {
 		return err
 	}
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -24,6 +25,15 @@ func headerKeyNormalize(in string) string {
 
 	case "cseq":
 		return "CSeq"
+
+	case "3gpp-adaptation":
+		return "3GPP-Adaptation"
+
+	case "3gpp-qoe-metrics":
+		return "3GPP-QoE-Metrics"
+
+	case "3gpp-qoe-feedback":
+		return "3GPP-QoE-Feedback"
 	}
 	return http.CanonicalHeaderKey(in)
 }
@@ -32,10 +42,143 @@ func headerKeyNormalize(in string) string {
 type HeaderValue []string
 
 // Header is a RTSP reader, present in both Requests and Responses.
+// Keys are normalized. Repeated entries with the same key are stored
+// as multiple values, in the order in which they appear.
 type Header map[string]HeaderValue
 
-func (h *Header) unmarshal(br *bufio.Reader) error {
+// Get returns the first value of an entry.
+// The key is normalized before being looked up.
+func (h Header) Get(key string) string {
+	vals := h[headerKeyNormalize(key)]
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+// GetInt returns the first value of an entry, decoded as an integer.
+func (h Header) GetInt(key string) (int, error) {
+	vals := h[headerKeyNormalize(key)]
+	if len(vals) == 0 {
+		return 0, fmt.Errorf("%s is missing", headerKeyNormalize(key))
+	}
+
+	v, err := strconv.ParseInt(strings.TrimSpace(vals[0]), 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s (%v)", headerKeyNormalize(key), vals[0])
+	}
+
+	return int(v), nil
+}
+
+// GetList returns all values of an entry, split by commas and trimmed.
+// It must not be used with entries whose values contain commas,
+// like WWW-Authenticate.
+func (h Header) GetList(key string) []string {
+	var ret []string
+
+	for _, val := range h[headerKeyNormalize(key)] {
+		for _, part := range strings.Split(val, ",") {
+			part = strings.TrimSpace(part)
+			if part != "" {
+				ret = append(ret, part)
+			}
+		}
+	}
+
+	return ret
+}
+
+// HeaderEntry is an entry of a header, with the original casing of its key.
+type HeaderEntry struct {
+	Key   string
+	Value string
+}
+
+// HeaderEntries are the entries of a header, in their original order.
+type HeaderEntries []HeaderEntry
+
+// Header converts entries into a Header.
+func (e HeaderEntries) Header() Header {
+	h := make(Header)
+	for _, entry := range e {
+		key := headerKeyNormalize(entry.Key)
+		h[key] = append(h[key], entry.Value)
+	}
+	return h
+}
+
+// matches checks whether entries contain the same values of a Header.
+func (e HeaderEntries) matches(h Header) bool {
+	if len(e) == 0 {
+		return false
+	}
+
+	count := 0
+	for _, vals := range h {
+		count += len(vals)
+	}
+
+	if count != len(e) {
+		return false
+	}
+
+	pos := make(map[string]int)
+
+	for _, entry := range e {
+		key := headerKeyNormalize(entry.Key)
+		vals := h[key]
+		i := pos[key]
+
+		if i >= len(vals) || vals[i] != entry.Value {
+			return false
+		}
+
+		pos[key] = i + 1
+	}
+
+	return true
+}
+
+func (e HeaderEntries) marshalSize() int {
+	n := 0
+	for _, entry := range e {
+		n += len(entry.Key) + 2 + len(entry.Value) + 2
+	}
+	return n + 2
+}
+
+func (e HeaderEntries) marshalTo(buf []byte) int {
+	pos := 0
+
+	for _, entry := range e {
+		pos += copy(buf[pos:], []byte(entry.Key+": "+entry.Value+"\r\n"))
+	}
+
+	pos += copy(buf[pos:], []byte("\r\n"))
+
+	return pos
+}
+
+// marshalHeaderSize returns the size of a header, written with entries
+// when they have not been modified, otherwise with Header.
+func marshalHeaderSize(h Header, e HeaderEntries) int {
+	if e.matches(h) {
+		return e.marshalSize()
+	}
+	return h.marshalSize()
+}
+
+func marshalHeaderTo(h Header, e HeaderEntries, buf []byte) int {
+	if e.matches(h) {
+		return e.marshalTo(buf)
+	}
+	return h.marshalTo(buf)
+}
+
+func (h *Header) unmarshal(br *bufio.Reader, entries *HeaderEntries) error {
 	*h = make(Header)
+	*entries = nil
 	count := 0
 
 	for {
@@ -63,6 +206,7 @@ func (h *Header) unmarshal(br *bufio.Reader) error {
 		}
 
 		key += string(byts[:len(byts)-1])
+		rawKey := key
 		key = headerKeyNormalize(key)
 
 		// https://tools.ietf.org/html/rfc2616
@@ -91,6 +235,75 @@ func (h *Header) unmarshal(br *bufio.Reader) error {
 		}
 
 		(*h)[key] = append((*h)[key], val)
+		*entries = append(*entries, HeaderEntry{Key: rawKey, Value: val})
+		count++
+	}
+
+	return nil
+}
+
+// unmarshalLenient reads headers, tolerating LF-only line endings, whitespace around
+// keys and values and values folded into multiple lines.
+func (h *Header) unmarshalLenient(br *bufio.Reader, entries *HeaderEntries) error {
+	*h = make(Header)
+	*entries = nil
+	count := 0
+	prevKey := ""
+
+	for {
+		line, err := readLineLenient(br, headerMaxKeyLength+headerMaxValueLength)
+		if err != nil {
+			return err
+		}
+
+		if strings.Trim(line, " \t") == "" {
+			break
+		}
+
+		// a line that starts with whitespace is the continuation of the previous value
+		if line[0] == ' ' || line[0] == '\t' {
+			if prevKey == "" {
+				return fmt.Errorf("folded value without a key")
+			}
+
+			vals := (*h)[prevKey]
+			val := vals[len(vals)-1] + " " + strings.Trim(line, " \t")
+			if len(val) > headerMaxValueLength {
+				return fmt.Errorf("value length exceeds %d", headerMaxValueLength)
+			}
+
+			vals[len(vals)-1] = val
+			(*entries)[len(*entries)-1].Value = val
+			continue
+		}
+
+		if count >= headerMaxEntryCount {
+			return fmt.Errorf("headers count exceeds %d", headerMaxEntryCount)
+		}
+
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return fmt.Errorf("value is missing")
+		}
+
+		key := strings.Trim(line[:i], " \t")
+		if key == "" {
+			return fmt.Errorf("empty key")
+		}
+		if len(key) > headerMaxKeyLength {
+			return fmt.Errorf("key length exceeds %d", headerMaxKeyLength)
+		}
+		rawKey := key
+		key = headerKeyNormalize(key)
+
+		val := strings.Trim(line[i+1:], " \t")
+		if len(val) > headerMaxValueLength {
+			return fmt.Errorf("value length exceeds %d", headerMaxValueLength)
+		}
+
+		(*h)[key] = append((*h)[key], val)
+		*entries = append(*entries, HeaderEntry{Key: rawKey, Value: val})
+		prevKey = key
 		count++
 	}
 
@@ -9,10 +9,11 @@ import (
 )
 
 var cases = []struct {
-	name   string
-	dec    []byte
-	enc    []byte
-	header Header
+	name    string
+	dec     []byte
+	enc     []byte
+	header  Header
+	entries HeaderEntries
 }{
 	{
 		"single",
@@ -26,6 +27,10 @@ var cases = []struct {
 			"Require":       HeaderValue{"implicit-play"},
 			"Proxy-Require": HeaderValue{"gzipped-messages"},
 		},
+		HeaderEntries{
+			{Key: "Proxy-Require", Value: "gzipped-messages"},
+			{Key: "Require", Value: "implicit-play"},
+		},
 	},
 	{
 		"multiple",
@@ -43,6 +48,13 @@ var cases = []struct {
 				`Basic realm="4419b63f5e51"`,
 			},
 		},
+		HeaderEntries{
+			{
+				Key:   "WWW-Authenticate",
+				Value: `Digest realm="4419b63f5e51", nonce="8b84a3b789283a8bea8da7fa7d41f08b", stale="FALSE"`,
+			},
+			{Key: "WWW-Authenticate", Value: `Basic realm="4419b63f5e51"`},
+		},
 	},
 	{
 		"empty",
@@ -53,6 +65,9 @@ var cases = []struct {
 		Header{
 			"Testing": HeaderValue{""},
 		},
+		HeaderEntries{
+			{Key: "Testing", Value: ""},
+		},
 	},
 	{
 		"without space",
@@ -63,6 +78,9 @@ var cases = []struct {
 		Header{
 			"CSeq": HeaderValue{"2"},
 		},
+		HeaderEntries{
+			{Key: "CSeq", Value: "2"},
+		},
 	},
 	{
 		"with multiple spaces",
@@ -73,6 +91,9 @@ var cases = []struct {
 		Header{
 			"CSeq": HeaderValue{"2"},
 		},
+		HeaderEntries{
+			{Key: "CSeq", Value: "2"},
+		},
 	},
 	{
 		"normalized keys, standard",
@@ -86,22 +107,35 @@ var cases = []struct {
 			"Content-Length": HeaderValue{"value"},
 			"Content-Type":   HeaderValue{"testing"},
 		},
+		HeaderEntries{
+			{Key: "content-type", Value: "testing"},
+			{Key: "content-length", Value: "value"},
+		},
 	},
 	{
 		"normalized keys, non-standard",
 		[]byte("www-authenticate: value\r\n" +
 			"cseq: value\r\n" +
 			"rtp-info: value\r\n" +
+			"3gpp-qoe-metrics: value\r\n" +
 			"\r\n"),
-		[]byte("CSeq: value\r\n" +
+		[]byte("3GPP-QoE-Metrics: value\r\n" +
+			"CSeq: value\r\n" +
 			"RTP-Info: value\r\n" +
 			"WWW-Authenticate: value\r\n" +
 			"\r\n"),
 		Header{
+			"3GPP-QoE-Metrics": HeaderValue{"value"},
 			"CSeq":             HeaderValue{"value"},
 			"RTP-Info":         HeaderValue{"value"},
 			"WWW-Authenticate": HeaderValue{"value"},
 		},
+		HeaderEntries{
+			{Key: "www-authenticate", Value: "value"},
+			{Key: "cseq", Value: "value"},
+			{Key: "rtp-info", Value: "value"},
+			{Key: "3gpp-qoe-metrics", Value: "value"},
+		},
 	},
 }
 
@@ -109,9 +143,12 @@ func TestHeaderUnmarshal(t *testing.T) {
 	for _, ca := range cases {
 		t.Run(ca.name, func(t *testing.T) {
 			h := make(Header)
-			err := h.unmarshal(bufio.NewReader(bytes.NewBuffer(ca.dec)))
+			var entries HeaderEntries
+			err := h.unmarshal(bufio.NewReader(bytes.NewBuffer(ca.dec)), &entries)
 			require.NoError(t, err)
 			require.Equal(t, ca.header, h)
+			require.Equal(t, ca.entries, entries)
+			require.Equal(t, ca.header, entries.Header())
 		})
 	}
 }
@@ -125,6 +162,59 @@ func TestHeaderWrite(t *testing.T) {
 	}
 }
 
+func TestHeaderEntriesMarshal(t *testing.T) {
+	h := Header{
+		"CSeq":             HeaderValue{"2"},
+		"WWW-Authenticate": HeaderValue{"Digest realm=\"a\"", "Basic realm=\"a\""},
+	}
+	entries := HeaderEntries{
+		{Key: "www-authenticate", Value: "Digest realm=\"a\""},
+		{Key: "cseq", Value: "2"},
+		{Key: "WWW-Authenticate", Value: "Basic realm=\"a\""},
+	}
+
+	buf := make([]byte, marshalHeaderSize(h, entries))
+	marshalHeaderTo(h, entries, buf)
+	require.Equal(t, "www-authenticate: Digest realm=\"a\"\r\n"+
+		"cseq: 2\r\n"+
+		"WWW-Authenticate: Basic realm=\"a\"\r\n"+
+		"\r\n", string(buf))
+
+	// entries are ignored when the header has been modified
+	h["CSeq"] = HeaderValue{"3"}
+
+	buf = make([]byte, marshalHeaderSize(h, entries))
+	marshalHeaderTo(h, entries, buf)
+	require.Equal(t, "CSeq: 3\r\n"+
+		"WWW-Authenticate: Digest realm=\"a\"\r\n"+
+		"WWW-Authenticate: Basic realm=\"a\"\r\n"+
+		"\r\n", string(buf))
+}
+
+func TestHeaderGetters(t *testing.T) {
+	h := Header{
+		"CSeq":    HeaderValue{"2"},
+		"Public":  HeaderValue{"DESCRIBE, SETUP,TEARDOWN", "PLAY"},
+		"Session": HeaderValue{"abc"},
+	}
+
+	require.Equal(t, "2", h.Get("cseq"))
+	require.Equal(t, "", h.Get("Range"))
+
+	v, err := h.GetInt("cseq")
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+
+	_, err = h.GetInt("Range")
+	require.EqualError(t, err, "Range is missing")
+
+	_, err = h.GetInt("Session")
+	require.EqualError(t, err, "invalid Session (abc)")
+
+	require.Equal(t, []string{"DESCRIBE", "SETUP", "TEARDOWN", "PLAY"}, h.GetList("public"))
+	require.Equal(t, []string(nil), h.GetList("Require"))
+}
+
 func FuzzHeaderUnmarshal(f *testing.F) {
 	str := ""
 	for i := 0; i < 300; i++ {
@@ -134,6 +224,23 @@ func FuzzHeaderUnmarshal(f *testing.F) {
 
 	f.Fuzz(func(t *testing.T, b []byte) {
 		var h Header
-		h.unmarshal(bufio.NewReader(bytes.NewBuffer(b))) //nolint:errcheck
+		var entries HeaderEntries
+		h.unmarshal(bufio.NewReader(bytes.NewBuffer(b)), &entries) //nolint:errcheck
+	})
+}
+
+func FuzzHeaderUnmarshalLenient(f *testing.F) {
+	str := ""
+	for i := 0; i < 300; i++ {
+		str += "Key:val\n"
+	}
+	f.Add([]byte(str))
+
+	f.Add([]byte("Key : val\n \tfolded\r\n\n"))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var h Header
+		var entries HeaderEntries
+		h.unmarshalLenient(bufio.NewReader(bytes.NewBuffer(b)), &entries) //nolint:errcheck
 	})
 }
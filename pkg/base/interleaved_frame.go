@@ -22,6 +22,7 @@ type InterleavedFrame struct {
 }
 
 // Unmarshal decodes an interleaved frame.
+// The existing payload buffer is reused when it is large enough.
 func (f *InterleavedFrame) Unmarshal(br *bufio.Reader) error {
 	var header [4]byte
 	_, err := io.ReadFull(br, header[:])
@@ -37,7 +38,13 @@ func (f *InterleavedFrame) Unmarshal(br *bufio.Reader) error {
 	payloadLen := int(uint16(header[2])<<8 | uint16(header[3]))
 
 	f.Channel = int(header[1])
-	f.Payload = make([]byte, payloadLen)
+
+	// reuse the existing payload buffer when it is large enough
+	if cap(f.Payload) >= payloadLen {
+		f.Payload = f.Payload[:payloadLen]
+	} else {
+		f.Payload = make([]byte, payloadLen)
+	}
 
 	_, err = io.ReadFull(br, f.Payload)
 	return err
@@ -5,10 +5,12 @@ import (
 	"bufio"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 const (
 	rtspProtocol10           = "RTSP/1.0"
+	rtspProtocol20           = "RTSP/2.0"
 	requestMaxMethodLength   = 64
 	requestMaxURLLength      = 2048
 	requestMaxProtocolLength = 64
@@ -32,11 +34,29 @@ const (
 	Pause        Method = "PAUSE"
 	Play         Method = "PLAY"
 	Record       Method = "RECORD"
+	Redirect     Method = "REDIRECT"
 	Setup        Method = "SETUP"
 	SetParameter Method = "SET_PARAMETER"
 	Teardown     Method = "TEARDOWN"
+
+	// RTSP 2.0 (RFC 7826):
+	PlayNotify Method = "PLAY_NOTIFY"
 )
 
+func protocolUnmarshal(proto string) (string, error) {
+	switch proto {
+	// RTSP/1.0 is the default protocol
+	case rtspProtocol10:
+		return "", nil
+
+	case rtspProtocol20, httpProtocol10:
+		return proto, nil
+	}
+
+	return "", fmt.Errorf("expected '%s', '%s' or '%s', got %v",
+		rtspProtocol10, rtspProtocol20, httpProtocol10, proto)
+}
+
 // Request is a RTSP request.
 type Request struct {
 	// request method
@@ -45,12 +65,19 @@ type Request struct {
 	// request url
 	URL *URL
 
-	// tunnel:
+	// protocol.
+	// It defaults to RTSP/1.0. RTSP/2.0 and HTTP/1.0 (tunneling) are supported too.
 	Protocol string
 
 	// map of header values
 	Header Header
 
+	// header entries, in their original order and casing.
+	// They are filled by Unmarshal and used by Marshal in place of Header,
+	// in order to reproduce the original message, as long as they contain
+	// the same values of Header (i.e. Header has not been modified).
+	HeaderEntries HeaderEntries
+
 	// optional body
 	Body []byte
 }
@@ -71,27 +98,18 @@ func (req *Request) Unmarshal(br *bufio.Reader) error {
 	if err != nil {
 		return err
 	}
-	rawURL := string(byts[:len(byts)-1])
-
-	if rawURL != "*" {
-		ur, err := ParseURL(rawURL)
-		if err != nil {
-			return fmt.Errorf("invalid URL (%v)", rawURL)
-		}
-		req.URL = ur
-	} else {
-		req.URL = nil
+	err = req.unmarshalURL(string(byts[:len(byts)-1]))
+	if err != nil {
+		return err
 	}
 
 	byts, err = readBytesLimited(br, '\r', requestMaxProtocolLength)
 	if err != nil {
 		return err
 	}
-	proto := byts[:len(byts)-1]
-
-	// tunnel:
-	if string(proto) != rtspProtocol10 && string(proto) != httpProtocol10 {
-		return fmt.Errorf("expected '%s' or '%s', got %v", rtspProtocol10, httpProtocol10, proto)
+	req.Protocol, err = protocolUnmarshal(string(byts[:len(byts)-1]))
+	if err != nil {
+		return err
 	}
 
 	err = readByteEqual(br, '\n')
@@ -99,7 +117,50 @@ func (req *Request) Unmarshal(br *bufio.Reader) error {
 		return err
 	}
 
-	err = req.Header.unmarshal(br)
+	err = req.Header.unmarshal(br, &req.HeaderEntries)
+	if err != nil {
+		return err
+	}
+
+	err = (*body)(&req.Body).unmarshal(req.Header, br)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalLenient reads a request, tolerating common violations of the specification:
+//   - lines terminated by LF instead of CRLF;
+//   - multiple spaces or tabs between the fields of the request line;
+//   - missing or multiple spaces around the colon of headers;
+//   - header values folded into multiple lines.
+//
+// The same length limits of Unmarshal are applied.
+func (req *Request) UnmarshalLenient(br *bufio.Reader) error {
+	line, err := readLineLenient(br, requestMaxMethodLength+requestMaxURLLength+requestMaxProtocolLength)
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return fmt.Errorf("invalid request line (%v)", line)
+	}
+
+	req.Method = Method(fields[0])
+
+	err = req.unmarshalURL(fields[1])
+	if err != nil {
+		return err
+	}
+
+	req.Protocol, err = protocolUnmarshal(fields[2])
+	if err != nil {
+		return err
+	}
+
+	err = req.Header.unmarshalLenient(br, &req.HeaderEntries)
 	if err != nil {
 		return err
 	}
@@ -112,23 +173,38 @@ func (req *Request) Unmarshal(br *bufio.Reader) error {
 	return nil
 }
 
+func (req *Request) unmarshalURL(rawURL string) error {
+	if rawURL == "*" {
+		req.URL = nil
+		return nil
+	}
+
+	ur, err := ParseURL(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL (%v)", rawURL)
+	}
+
+	req.URL = ur
+	return nil
+}
+
 // MarshalSize returns the size of a Request.
 func (req Request) MarshalSize() int {
 	n := len(req.Method) + 1
 
 	if req.URL != nil {
-		n += len(req.URL.CloneWithoutCredentials().String())
+		n += len(req.marshalURL())
 	} else {
 		n++
 	}
 
-	n += 1 + len(rtspProtocol10) + 2
+	n += 1 + len(req.proto()) + 2
 
 	if len(req.Body) != 0 {
 		req.Header["Content-Length"] = HeaderValue{strconv.FormatInt(int64(len(req.Body)), 10)}
 	}
 
-	n += req.Header.marshalSize()
+	n += marshalHeaderSize(req.Header, req.HeaderEntries)
 
 	n += body(req.Body).marshalSize()
 
@@ -144,14 +220,7 @@ func (req Request) MarshalTo(buf []byte) (int, error) {
 	pos++
 
 	if req.URL != nil {
-		// tunnel:
-		if req.proto() == httpProtocol10 {
-			// path: /profile/media.smp
-			path := []byte(req.URL.Path)
-			pos += copy(buf[pos:], path)
-		} else {
-			pos += copy(buf[pos:], []byte(req.URL.CloneWithoutCredentials().String()))
-		}
+		pos += copy(buf[pos:], []byte(req.marshalURL()))
 	} else {
 		pos += copy(buf[pos:], []byte("*"))
 	}
@@ -169,7 +238,7 @@ func (req Request) MarshalTo(buf []byte) (int, error) {
 		req.Header["Content-Length"] = HeaderValue{strconv.FormatInt(int64(len(req.Body)), 10)}
 	}
 
-	pos += req.Header.marshalTo(buf[pos:])
+	pos += marshalHeaderTo(req.Header, req.HeaderEntries, buf[pos:])
 
 	pos += body(req.Body).marshalTo(buf[pos:])
 
@@ -189,6 +258,23 @@ func (req Request) String() string {
 	return string(buf)
 }
 
+func (req Request) marshalURL() string {
+	// tunnel:
+	// path: /profile/media.smp
+	if req.proto() == httpProtocol10 {
+		ret := req.URL.Path
+		if ret == "" {
+			ret = "/"
+		}
+		if req.URL.RawQuery != "" {
+			ret += "?" + req.URL.RawQuery
+		}
+		return ret
+	}
+
+	return req.URL.CloneWithoutCredentials().String()
+}
+
 // tunnel:
 // get protocol
 func (req Request) proto() string {
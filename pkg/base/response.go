@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // StatusCode is the status code of a RTSP response.
@@ -32,6 +33,7 @@ const (
 	StatusRequestEntityTooLarge              StatusCode = 413
 	StatusRequestURITooLong                  StatusCode = 414
 	StatusUnsupportedMediaType               StatusCode = 415
+	StatusTooManyRequests                    StatusCode = 429
 	StatusParameterNotUnderstood             StatusCode = 451
 	StatusNotEnoughBandwidth                 StatusCode = 453
 	StatusSessionNotFound                    StatusCode = 454
@@ -88,6 +90,7 @@ var statusMessages = map[StatusCode]string{
 	StatusRequestEntityTooLarge:              "Request Entity Too Large",
 	StatusRequestURITooLong:                  "Request URI Too Long",
 	StatusUnsupportedMediaType:               "Unsupported Media Type",
+	StatusTooManyRequests:                    "Too Many Requests",
 	StatusParameterNotUnderstood:             "Parameter Not Understood",
 	StatusNotEnoughBandwidth:                 "Not Enough Bandwidth",
 	StatusSessionNotFound:                    "Session Not Found",
@@ -128,7 +131,14 @@ type Response struct {
 	// map of header values
 	Header Header
 
-	// tunnel: proto
+	// header entries, in their original order and casing.
+	// They are filled by Unmarshal and used by Marshal in place of Header,
+	// in order to reproduce the original message, as long as they contain
+	// the same values of Header (i.e. Header has not been modified).
+	HeaderEntries HeaderEntries
+
+	// protocol.
+	// It defaults to RTSP/1.0. RTSP/2.0 and HTTP/1.0 (tunneling) are supported too.
 	Protocol string
 
 	// optional body
@@ -141,11 +151,9 @@ func (res *Response) Unmarshal(br *bufio.Reader) error {
 	if err != nil {
 		return err
 	}
-	proto := byts[:len(byts)-1]
-
-	// tunnel:
-	if string(proto) != rtspProtocol10 && string(proto) != httpProtocol10 {
-		return fmt.Errorf("expected '%s' or '%s', got %v", rtspProtocol10, httpProtocol10, proto)
+	res.Protocol, err = protocolUnmarshal(string(byts[:len(byts)-1]))
+	if err != nil {
+		return err
 	}
 
 	byts, err = readBytesLimited(br, ' ', 4)
@@ -175,7 +183,52 @@ func (res *Response) Unmarshal(br *bufio.Reader) error {
 		return err
 	}
 
-	err = res.Header.unmarshal(br)
+	err = res.Header.unmarshal(br, &res.HeaderEntries)
+	if err != nil {
+		return err
+	}
+
+	err = (*body)(&res.Body).unmarshal(res.Header, br)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalLenient reads a response, tolerating common violations of the specification:
+//   - lines terminated by LF instead of CRLF;
+//   - multiple spaces or tabs between the fields of the status line;
+//   - a missing status message;
+//   - missing or multiple spaces around the colon of headers;
+//   - header values folded into multiple lines.
+//
+// The same length limits of Unmarshal are applied.
+func (res *Response) UnmarshalLenient(br *bufio.Reader) error {
+	line, err := readLineLenient(br, 255+4+255)
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid status line (%v)", line)
+	}
+
+	res.Protocol, err = protocolUnmarshal(fields[0])
+	if err != nil {
+		return err
+	}
+
+	tmp, err := strconv.ParseUint(fields[1], 10, 31)
+	if err != nil {
+		return fmt.Errorf("unable to parse status code")
+	}
+	res.StatusCode = StatusCode(tmp)
+
+	res.StatusMessage = strings.Join(fields[2:], " ")
+
+	err = res.Header.unmarshalLenient(br, &res.HeaderEntries)
 	if err != nil {
 		return err
 	}
@@ -198,13 +251,13 @@ func (res Response) MarshalSize() int {
 		}
 	}
 
-	n += len(rtspProtocol10) + 1 + len(strconv.FormatInt(int64(res.StatusCode), 10)) + 1 + len(res.StatusMessage) + 2
+	n += len(res.proto()) + 1 + len(strconv.FormatInt(int64(res.StatusCode), 10)) + 1 + len(res.StatusMessage) + 2
 
 	if len(res.Body) != 0 {
 		res.Header["Content-Length"] = HeaderValue{strconv.FormatInt(int64(len(res.Body)), 10)}
 	}
 
-	n += res.Header.marshalSize()
+	n += marshalHeaderSize(res.Header, res.HeaderEntries)
 
 	n += body(res.Body).marshalSize()
 
@@ -237,7 +290,7 @@ func (res Response) MarshalTo(buf []byte) (int, error) {
 		res.Header["Content-Length"] = HeaderValue{strconv.FormatInt(int64(len(res.Body)), 10)}
 	}
 
-	pos += res.Header.marshalTo(buf[pos:])
+	pos += marshalHeaderTo(res.Header, res.HeaderEntries, buf[pos:])
 
 	pos += body(res.Body).marshalTo(buf[pos:])
 
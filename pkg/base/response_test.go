@@ -3,7 +3,9 @@ package base
 import (
 	"bufio"
 	"bytes"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -35,6 +37,13 @@ var casesResponse = []struct {
 				},
 				"Date": HeaderValue{"Sat, Aug 16 2014 02:22:28 GMT"},
 			},
+			HeaderEntries: HeaderEntries{
+				{Key: "CSeq", Value: "2"},
+				{Key: "Date", Value: "Sat, Aug 16 2014 02:22:28 GMT"},
+				{Key: "Session", Value: "645252166"},
+				{Key: "WWW-Authenticate", Value: "Digest realm=\"4419b63f5e51\", nonce=\"8b84a3b789283a8bea8da7fa7d41f08b\", stale=\"FALSE\""},
+				{Key: "WWW-Authenticate", Value: "Basic realm=\"4419b63f5e51\""},
+			},
 		},
 	},
 	{
@@ -71,6 +80,12 @@ var casesResponse = []struct {
 				"Content-Type":   HeaderValue{"application/sdp"},
 				"CSeq":           HeaderValue{"2"},
 			},
+			HeaderEntries: HeaderEntries{
+				{Key: "CSeq", Value: "2"},
+				{Key: "Content-Base", Value: "rtsp://example.com/media.mp4"},
+				{Key: "Content-Length", Value: "444"},
+				{Key: "Content-Type", Value: "application/sdp"},
+			},
 			Body: []byte("m=video 0 RTP/AVP 96\n" +
 				"a=control:streamid=0\n" +
 				"a=range:npt=0-7.741000\n" +
@@ -90,6 +105,33 @@ var casesResponse = []struct {
 			),
 		},
 	},
+	{
+		"rtsp 2.0",
+		[]byte("RTSP/2.0 200 OK\r\n" +
+			"Accept-Ranges: npt, clock\r\n" +
+			"CSeq: 1\r\n" +
+			"Media-Properties: Random-Access=2.5, Unlimited, Immutable\r\n" +
+			"Public: DESCRIBE, SETUP, TEARDOWN, PLAY, PAUSE, PLAY_NOTIFY\r\n" +
+			"\r\n",
+		),
+		Response{
+			StatusCode:    200,
+			StatusMessage: "OK",
+			Protocol:      "RTSP/2.0",
+			Header: Header{
+				"Accept-Ranges":    HeaderValue{"npt, clock"},
+				"CSeq":             HeaderValue{"1"},
+				"Media-Properties": HeaderValue{"Random-Access=2.5, Unlimited, Immutable"},
+				"Public":           HeaderValue{"DESCRIBE, SETUP, TEARDOWN, PLAY, PAUSE, PLAY_NOTIFY"},
+			},
+			HeaderEntries: HeaderEntries{
+				{Key: "Accept-Ranges", Value: "npt, clock"},
+				{Key: "CSeq", Value: "1"},
+				{Key: "Media-Properties", Value: "Random-Access=2.5, Unlimited, Immutable"},
+				{Key: "Public", Value: "DESCRIBE, SETUP, TEARDOWN, PLAY, PAUSE, PLAY_NOTIFY"},
+			},
+		},
+	},
 }
 
 func TestResponseUnmarshal(t *testing.T) {
@@ -105,6 +147,67 @@ func TestResponseUnmarshal(t *testing.T) {
 	}
 }
 
+func TestResponseUnmarshalLenient(t *testing.T) {
+	for _, c := range casesResponse {
+		t.Run(c.name, func(t *testing.T) {
+			var res Response
+			err := res.UnmarshalLenient(bufio.NewReader(bytes.NewBuffer(c.byts)))
+			require.NoError(t, err)
+			require.Equal(t, c.res, res)
+		})
+	}
+
+	t.Run("non-compliant", func(t *testing.T) {
+		byts := []byte("RTSP/1.0  200\n" +
+			"CSeq:  3\n" +
+			"Session:12345678;\n" +
+			"\ttimeout=60\n" +
+			"\n")
+
+		var res Response
+		err := res.Unmarshal(bufio.NewReader(bytes.NewBuffer(byts)))
+		require.Error(t, err)
+
+		err = res.UnmarshalLenient(bufio.NewReader(bytes.NewBuffer(byts)))
+		require.NoError(t, err)
+		require.Equal(t, Response{
+			StatusCode: StatusOK,
+			Header: Header{
+				"CSeq":    HeaderValue{"3"},
+				"Session": HeaderValue{"12345678; timeout=60"},
+			},
+			HeaderEntries: HeaderEntries{
+				{Key: "CSeq", Value: "3"},
+				{Key: "Session", Value: "12345678; timeout=60"},
+			},
+		}, res)
+	})
+}
+
+func TestResponseUnmarshalFragmented(t *testing.T) {
+	for _, c := range casesResponse {
+		t.Run(c.name, func(t *testing.T) {
+			t.Run("one byte at a time", func(t *testing.T) {
+				var res Response
+				err := res.Unmarshal(bufio.NewReader(iotest.OneByteReader(bytes.NewBuffer(c.byts))))
+				require.NoError(t, err)
+				require.Equal(t, c.res, res)
+			})
+
+			// the body starts in the same read as the final header CRLF.
+			t.Run("body with final CRLF", func(t *testing.T) {
+				i := bytes.Index(c.byts, []byte("\r\n\r\n")) + 2
+				r := io.MultiReader(bytes.NewReader(c.byts[:i]), bytes.NewReader(c.byts[i:]))
+
+				var res Response
+				err := res.Unmarshal(bufio.NewReader(iotest.HalfReader(r)))
+				require.NoError(t, err)
+				require.Equal(t, c.res, res)
+			})
+		})
+	}
+}
+
 func TestResponseMarshal(t *testing.T) {
 	for _, c := range casesResponse {
 		t.Run(c.name, func(t *testing.T) {
@@ -168,3 +271,20 @@ func FuzzResponseUnmarshal(f *testing.F) {
 		res.Unmarshal(bufio.NewReader(bytes.NewBuffer(b))) //nolint:errcheck
 	})
 }
+
+func FuzzResponseUnmarshalLenient(f *testing.F) {
+	f.Add([]byte("RTSP/1.0 200\n"))
+
+	f.Add([]byte("RTSP/1.0\t200  OK\n" +
+		"CSeq:1\n" +
+		"Session: 12345678;\n" +
+		" timeout=60\r\n" +
+		"Content-Length: 100\n" +
+		"\n" +
+		"testing"))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var res Response
+		res.UnmarshalLenient(bufio.NewReader(bytes.NewBuffer(b))) //nolint:errcheck
+	})
+}
@@ -14,6 +14,8 @@ type URL url.URL
 
 var escapeRegexp = regexp.MustCompile(`^(.+?)://(.*?)@(.*?)/(.*?)$`)
 
+var zoneRegexp = regexp.MustCompile(`^([^:/]+://(?:[^/@]*@)?\[[^\]%]*)%([^\]]*\].*)$`)
+
 // ParseURL parses a RTSP URL.
 func ParseURL(s string) (*URL, error) {
 	// https://github.com/golang/go/issues/30611
@@ -24,6 +26,11 @@ func ParseURL(s string) (*URL, error) {
 		s = m[1] + "://" + m[2] + "@" + m[3] + "/" + m[4]
 	}
 
+	// escape zones of IPv6 addresses (RFC6874)
+	if m := zoneRegexp.FindStringSubmatch(s); m != nil && !strings.HasPrefix(m[2], "25") {
+		s = m[1] + "%25" + m[2]
+	}
+
 	u, err := url.Parse(s)
 	if err != nil {
 		return nil, err
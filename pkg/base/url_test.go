@@ -31,6 +31,24 @@ func TestParseURL(t *testing.T) {
 				User:   url.UserPassword("user", "pa#ss"),
 			},
 		},
+		{
+			"ipv6 zone without credentials",
+			`rtsp://[fe80::a8f4:3219:f33e:a072%wl0]:8554/stream`,
+			&URL{
+				Scheme: "rtsp",
+				Host:   "[fe80::a8f4:3219:f33e:a072%wl0]:8554",
+				Path:   "/stream",
+			},
+		},
+		{
+			"ipv6 escaped zone",
+			`rtsp://[fe80::a8f4:3219:f33e:a072%25wl0]:8554/stream`,
+			&URL{
+				Scheme: "rtsp",
+				Host:   "[fe80::a8f4:3219:f33e:a072%wl0]:8554",
+				Path:   "/stream",
+			},
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			u, err := ParseURL(ca.enc)
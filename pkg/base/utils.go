@@ -32,3 +32,18 @@ func readBytesLimited(rb *bufio.Reader, delim byte, n int) ([]byte, error) {
 	}
 	return nil, fmt.Errorf("buffer length exceeds %d", n)
 }
+
+// readLineLenient reads a line terminated by CRLF or by LF only.
+func readLineLenient(rb *bufio.Reader, n int) (string, error) {
+	byts, err := readBytesLimited(rb, '\n', n+2)
+	if err != nil {
+		return "", err
+	}
+
+	line := byts[:len(byts)-1]
+	if len(line) != 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+
+	return string(line), nil
+}
@@ -0,0 +1,184 @@
+// Package chaos contains a packet impairment layer, that injects loss, duplication,
+// reordering and jitter into flows of packets, in order to test receivers against realistic network conditions.
+package chaos
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+)
+
+// Config is a set of impairments.
+type Config struct {
+	// probability that a packet is lost, between 0 and 1.
+	Loss float64
+	// mean length of loss bursts, in packets.
+	// When greater than 1, losses are correlated and follow a Gilbert model,
+	// while the average loss probability is still Loss.
+	LossBurstLength float64
+	// probability that a packet is duplicated, between 0 and 1.
+	Duplication float64
+	// probability that a packet is reordered, between 0 and 1.
+	// Reordered packets are delayed by ReorderingDelay, in order to be overtaken by the following ones.
+	Reordering float64
+	// additional delay of reordered packets.
+	// It defaults to a constant delay of 10ms.
+	ReorderingDelay Distribution
+	// delay added to every packet (optional).
+	// Packets are reordered when the difference between their delays
+	// is greater than the interval between them.
+	Jitter Distribution
+}
+
+// Impairer applies a Config to a flow of packets.
+type Impairer struct {
+	// clock used to delay packets.
+	// It defaults to the system clock.
+	Clock clock.Clock
+	// seed of the random generator.
+	// It defaults to a seed based on the current time.
+	Seed int64
+
+	conf      atomic.Value // *Config
+	mutex     sync.Mutex
+	rand      *rand.Rand
+	inBurst   bool
+	epoch     uint64
+	discarded uint64
+}
+
+// Init initializes the Impairer.
+func (i *Impairer) Init() {
+	if i.Clock == nil {
+		i.Clock = clock.System{}
+	}
+	if i.Seed == 0 {
+		i.Seed = time.Now().UnixNano()
+	}
+
+	i.rand = rand.New(rand.NewSource(i.Seed)) //nolint:gosec
+	i.conf.Store((*Config)(nil))
+}
+
+// SetConfig sets the impairments to apply. It can be called at any time.
+// A nil Config disables impairments.
+func (i *Impairer) SetConfig(conf *Config) {
+	i.conf.Store(conf)
+}
+
+// Config returns the impairments that are being applied.
+func (i *Impairer) Config() *Config {
+	return i.conf.Load().(*Config)
+}
+
+// Reset discards packets that are being delayed.
+// When it returns, cb is not being called and it is not called anymore for these packets.
+func (i *Impairer) Reset() {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.epoch++
+	i.inBurst = false
+}
+
+// Discarded returns the number of packets that have been lost on purpose.
+func (i *Impairer) Discarded() uint64 {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.discarded
+}
+
+// Process processes a packet.
+// Depending on impairments, cb is called with the packet, with the packet and a duplicate of it,
+// or it is not called at all.
+// Delayed packets are copied and cb is called by a timer.
+// Calls to cb are serialized.
+func (i *Impairer) Process(payload []byte, cb func([]byte)) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	conf := i.Config()
+	if conf == nil {
+		i.inBurst = false
+		cb(payload)
+		return
+	}
+
+	if i.lost(conf) {
+		i.discarded++
+		return
+	}
+
+	n := 1
+	if conf.Duplication > 0 && i.rand.Float64() < conf.Duplication {
+		n = 2
+	}
+
+	for j := 0; j < n; j++ {
+		delay := i.delay(conf)
+		if delay <= 0 {
+			cb(payload)
+			continue
+		}
+
+		buf := append([]byte(nil), payload...)
+		epoch := i.epoch
+
+		i.Clock.AfterFunc(delay, func() {
+			i.mutex.Lock()
+			defer i.mutex.Unlock()
+
+			if i.epoch == epoch {
+				cb(buf)
+			}
+		})
+	}
+}
+
+func (i *Impairer) lost(conf *Config) bool {
+	if conf.Loss <= 0 {
+		i.inBurst = false
+		return false
+	}
+
+	if conf.LossBurstLength <= 1 {
+		return i.rand.Float64() < conf.Loss
+	}
+
+	// Gilbert model: r is the probability of leaving a burst,
+	// p the probability of entering one, chosen so that p / (p + r) = Loss.
+	r := 1 / conf.LossBurstLength
+
+	if i.inBurst {
+		if i.rand.Float64() < r {
+			i.inBurst = false
+		}
+	} else {
+		p := conf.Loss * r / (1 - conf.Loss)
+		if i.rand.Float64() < p {
+			i.inBurst = true
+		}
+	}
+
+	return i.inBurst
+}
+
+func (i *Impairer) delay(conf *Config) time.Duration {
+	var delay time.Duration
+
+	if conf.Jitter != nil {
+		delay = conf.Jitter.Sample(i.rand)
+	}
+
+	if conf.Reordering > 0 && i.rand.Float64() < conf.Reordering {
+		if conf.ReorderingDelay != nil {
+			delay += conf.ReorderingDelay.Sample(i.rand)
+		} else {
+			delay += 10 * time.Millisecond
+		}
+	}
+
+	return delay
+}
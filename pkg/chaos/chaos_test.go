@@ -0,0 +1,138 @@
+package chaos
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+)
+
+func TestImpairerDisabled(t *testing.T) {
+	i := &Impairer{}
+	i.Init()
+
+	var out [][]byte
+
+	for n := byte(0); n < 10; n++ {
+		i.Process([]byte{n}, func(buf []byte) {
+			out = append(out, buf)
+		})
+	}
+
+	require.Len(t, out, 10)
+}
+
+func TestImpairerLoss(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		burst float64
+	}{
+		{
+			"random",
+			0,
+		},
+		{
+			"bursty",
+			4,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			i := &Impairer{Seed: 1}
+			i.Init()
+			i.SetConfig(&Config{
+				Loss:            0.1,
+				LossBurstLength: ca.burst,
+			})
+
+			received := 0
+
+			for n := 0; n < 10000; n++ {
+				i.Process([]byte{1}, func(_ []byte) {
+					received++
+				})
+			}
+
+			require.InDelta(t, 9000, received, 300)
+			require.Equal(t, uint64(10000-received), i.Discarded())
+		})
+	}
+}
+
+func TestImpairerDuplication(t *testing.T) {
+	i := &Impairer{Seed: 1}
+	i.Init()
+	i.SetConfig(&Config{
+		Duplication: 1,
+	})
+
+	var out [][]byte
+
+	i.Process([]byte{1, 2}, func(buf []byte) {
+		out = append(out, buf)
+	})
+
+	require.Equal(t, [][]byte{{1, 2}, {1, 2}}, out)
+}
+
+func TestImpairerReordering(t *testing.T) {
+	clk := clock.NewVirtual(time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC))
+
+	i := &Impairer{Clock: clk}
+	i.Init()
+
+	var out []byte
+	cb := func(buf []byte) {
+		out = append(out, buf[0])
+	}
+
+	i.SetConfig(&Config{
+		Reordering:      1,
+		ReorderingDelay: Constant(15 * time.Millisecond),
+	})
+	i.Process([]byte{1}, cb)
+
+	i.SetConfig(nil)
+	i.Process([]byte{2}, cb)
+
+	require.Equal(t, []byte{2}, out)
+
+	clk.Advance(10 * time.Millisecond)
+	i.Process([]byte{3}, cb)
+
+	clk.Advance(10 * time.Millisecond)
+	require.Equal(t, []byte{2, 3, 1}, out)
+
+	i.SetConfig(&Config{
+		Jitter: Constant(10 * time.Millisecond),
+	})
+	i.Process([]byte{4}, cb)
+
+	i.Reset()
+
+	clk.Advance(20 * time.Millisecond)
+	require.Equal(t, []byte{2, 3, 1}, out)
+}
+
+func TestDistributions(t *testing.T) {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec
+
+	for _, d := range []Distribution{
+		Constant(10 * time.Millisecond),
+		Uniform{Min: 5 * time.Millisecond, Max: 15 * time.Millisecond},
+		Normal{Mean: 10 * time.Millisecond, StdDev: 2 * time.Millisecond},
+		Exponential{Mean: 10 * time.Millisecond},
+	} {
+		var sum time.Duration
+
+		for n := 0; n < 10000; n++ {
+			v := d.Sample(r)
+			require.GreaterOrEqual(t, v, time.Duration(0))
+			sum += v
+		}
+
+		require.InDelta(t, float64(10*time.Millisecond), float64(sum/10000), float64(500*time.Microsecond))
+	}
+}
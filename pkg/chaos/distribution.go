@@ -0,0 +1,60 @@
+package chaos
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Distribution is a distribution of durations.
+type Distribution interface {
+	// Sample returns a random duration.
+	Sample(r *rand.Rand) time.Duration
+}
+
+// Constant is a distribution that always returns the same duration.
+type Constant time.Duration
+
+// Sample implements Distribution.
+func (d Constant) Sample(_ *rand.Rand) time.Duration {
+	return time.Duration(d)
+}
+
+// Uniform is a uniform distribution between Min and Max.
+type Uniform struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// Sample implements Distribution.
+func (d Uniform) Sample(r *rand.Rand) time.Duration {
+	if d.Max <= d.Min {
+		return d.Min
+	}
+	return d.Min + time.Duration(r.Int63n(int64(d.Max-d.Min)))
+}
+
+// Normal is a normal distribution, truncated at zero.
+type Normal struct {
+	Mean   time.Duration
+	StdDev time.Duration
+}
+
+// Sample implements Distribution.
+func (d Normal) Sample(r *rand.Rand) time.Duration {
+	v := d.Mean + time.Duration(r.NormFloat64()*float64(d.StdDev))
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// Exponential is an exponential distribution,
+// that produces mostly short durations and occasional long ones.
+type Exponential struct {
+	Mean time.Duration
+}
+
+// Sample implements Distribution.
+func (d Exponential) Sample(r *rand.Rand) time.Duration {
+	return time.Duration(r.ExpFloat64() * float64(d.Mean))
+}
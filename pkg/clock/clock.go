@@ -0,0 +1,61 @@
+// Package clock contains an abstraction of time, that allows to replace the system clock
+// with a virtual one, in order to run time-dependent code deterministically.
+package clock
+
+import (
+	"time"
+)
+
+// Timer is a timer created by a Clock.
+type Timer interface {
+	// channel on which the time is sent when the timer expires.
+	// It is nil for timers created with AfterFunc.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing.
+	// It returns false if the timer has already expired or has been stopped.
+	Stop() bool
+
+	// Reset changes the timer to expire after duration d.
+	// It returns true if the timer had been active.
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a ticker created by a Clock.
+type Ticker interface {
+	// channel on which ticks are delivered.
+	C() <-chan time.Time
+
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// Clock provides the current time, timers and tickers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a timer that sends the current time on its channel after duration d.
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker creates a ticker that sends the current time on its channel every period d.
+	NewTicker(d time.Duration) Ticker
+
+	// AfterFunc calls f after duration d.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+type nowClock struct {
+	Clock
+	now func() time.Time
+}
+
+func (c nowClock) Now() time.Time {
+	return c.now()
+}
+
+// WithNow returns a Clock that provides the current time through the given function,
+// and timers and tickers through the given Clock.
+func WithNow(c Clock, now func() time.Time) Clock {
+	return nowClock{Clock: c, now: now}
+}
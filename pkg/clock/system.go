@@ -0,0 +1,56 @@
+package clock
+
+import (
+	"time"
+)
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+func (t systemTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(d)
+}
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t systemTicker) Stop() {
+	t.t.Stop()
+}
+
+// System is the system clock.
+type System struct{}
+
+// Now implements Clock.
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer implements Clock.
+func (System) NewTimer(d time.Duration) Timer {
+	return systemTimer{t: time.NewTimer(d)}
+}
+
+// NewTicker implements Clock.
+func (System) NewTicker(d time.Duration) Ticker {
+	return systemTicker{t: time.NewTicker(d)}
+}
+
+// AfterFunc implements Clock.
+func (System) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{t: time.AfterFunc(d, f)}
+}
@@ -0,0 +1,206 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type virtualTimer struct {
+	v      *Virtual
+	c      chan time.Time // nil for timers created with AfterFunc
+	f      func()
+	when   time.Time
+	period time.Duration // tickers only
+	active bool
+}
+
+func (t *virtualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *virtualTimer) Stop() bool {
+	t.v.mutex.Lock()
+	defer t.v.mutex.Unlock()
+
+	wasActive := t.active
+	t.v.remove(t)
+	return wasActive
+}
+
+func (t *virtualTimer) Reset(d time.Duration) bool {
+	t.v.mutex.Lock()
+	defer t.v.mutex.Unlock()
+
+	wasActive := t.active
+	t.v.remove(t)
+	t.when = t.v.now.Add(d)
+	t.v.add(t)
+	return wasActive
+}
+
+type virtualTicker struct {
+	t *virtualTimer
+}
+
+func (t virtualTicker) C() <-chan time.Time {
+	return t.t.c
+}
+
+func (t virtualTicker) Stop() {
+	t.t.Stop()
+}
+
+// Virtual is a clock whose time advances only when Advance or Set are called.
+// Timers and tickers expire inside Advance and Set, in order of expiration,
+// allowing to run time-dependent code deterministically and without waiting.
+type Virtual struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*virtualTimer
+}
+
+// NewVirtual allocates a Virtual clock, set to the given time.
+func NewVirtual(now time.Time) *Virtual {
+	v := &Virtual{
+		now: now,
+	}
+	v.cond = sync.NewCond(&v.mutex)
+	return v
+}
+
+// Now implements Clock.
+func (v *Virtual) Now() time.Time {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.now
+}
+
+// NewTimer implements Clock.
+func (v *Virtual) NewTimer(d time.Duration) Timer {
+	return v.newTimer(d, 0, make(chan time.Time, 1), nil)
+}
+
+// NewTicker implements Clock.
+func (v *Virtual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return virtualTicker{t: v.newTimer(d, d, make(chan time.Time, 1), nil)}
+}
+
+// AfterFunc implements Clock.
+func (v *Virtual) AfterFunc(d time.Duration, f func()) Timer {
+	return v.newTimer(d, 0, nil, f)
+}
+
+func (v *Virtual) newTimer(d time.Duration, period time.Duration, c chan time.Time, f func()) *virtualTimer {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	t := &virtualTimer{
+		v:      v,
+		c:      c,
+		f:      f,
+		when:   v.now.Add(d),
+		period: period,
+	}
+	v.add(t)
+
+	return t
+}
+
+func (v *Virtual) add(t *virtualTimer) {
+	t.active = true
+	v.timers = append(v.timers, t)
+	v.cond.Broadcast()
+}
+
+func (v *Virtual) remove(t *virtualTimer) {
+	if !t.active {
+		return
+	}
+
+	t.active = false
+
+	for i, ot := range v.timers {
+		if ot == t {
+			v.timers = append(v.timers[:i], v.timers[i+1:]...)
+			break
+		}
+	}
+}
+
+// Advance moves the clock forward by duration d,
+// firing the timers and tickers that expire in the meanwhile.
+func (v *Virtual) Advance(d time.Duration) {
+	v.mutex.Lock()
+	target := v.now.Add(d)
+	v.mutex.Unlock()
+
+	v.Set(target)
+}
+
+// Set moves the clock forward to the given time,
+// firing the timers and tickers that expire in the meanwhile.
+// Times in the past only change the current time.
+func (v *Virtual) Set(target time.Time) {
+	for {
+		v.mutex.Lock()
+
+		t := v.next(target)
+		if t == nil {
+			v.now = target
+			v.mutex.Unlock()
+			return
+		}
+
+		if t.when.After(v.now) {
+			v.now = t.when
+		}
+		now := v.now
+
+		if t.period != 0 {
+			t.when = t.when.Add(t.period)
+		} else {
+			v.remove(t)
+		}
+
+		v.mutex.Unlock()
+
+		if t.c != nil {
+			// like the ones of the time package, ticks are dropped when the receiver is not ready.
+			select {
+			case t.c <- now:
+			default:
+			}
+		} else {
+			t.f()
+		}
+	}
+}
+
+// next returns the first timer that expires before or at the given time.
+func (v *Virtual) next(target time.Time) *virtualTimer {
+	var ret *virtualTimer
+
+	for _, t := range v.timers {
+		if !t.when.After(target) && (ret == nil || t.when.Before(ret.when)) {
+			ret = t
+		}
+	}
+
+	return ret
+}
+
+// WaitTimers waits until at least n timers and tickers are active.
+// It allows to make sure that goroutines have scheduled their timers
+// before advancing the clock.
+func (v *Virtual) WaitTimers(n int) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	for len(v.timers) < n {
+		v.cond.Wait()
+	}
+}
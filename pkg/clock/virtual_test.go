@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtual(t *testing.T) {
+	start := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+	v := NewVirtual(start)
+	require.Equal(t, start, v.Now())
+
+	var calls []time.Time
+
+	timer := v.NewTimer(2 * time.Second)
+	ticker := v.NewTicker(1 * time.Second)
+	v.AfterFunc(1500*time.Millisecond, func() {
+		calls = append(calls, v.Now())
+	})
+	v.WaitTimers(3)
+
+	v.Advance(1 * time.Second)
+	require.Equal(t, start.Add(1*time.Second), <-ticker.C())
+
+	select {
+	case <-timer.C():
+		t.Errorf("should not happen")
+	default:
+	}
+
+	v.Advance(1 * time.Second)
+	require.Equal(t, []time.Time{start.Add(1500 * time.Millisecond)}, calls)
+	require.Equal(t, start.Add(2*time.Second), <-timer.C())
+	require.Equal(t, start.Add(2*time.Second), <-ticker.C())
+
+	require.False(t, timer.Stop())
+	require.False(t, timer.Reset(1*time.Second))
+	require.True(t, timer.Stop())
+
+	ticker.Stop()
+
+	v.Advance(5 * time.Second)
+	require.Equal(t, start.Add(7*time.Second), v.Now())
+
+	select {
+	case <-timer.C():
+		t.Errorf("should not happen")
+	case <-ticker.C():
+		t.Errorf("should not happen")
+	default:
+	}
+}
@@ -0,0 +1,305 @@
+// Package conformance contains a validator that checks RTSP messages
+// against the rules of RFC 2326 and RFC 7826.
+package conformance
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+)
+
+type sessionState int
+
+const (
+	sessionStateReady sessionState = iota
+	sessionStatePlaying
+	sessionStateRecording
+)
+
+// methods that must refer to a session.
+var sessionMethods = map[base.Method]struct{}{
+	base.Play:     {},
+	base.Pause:    {},
+	base.Record:   {},
+	base.Teardown: {},
+}
+
+// status codes that can be used only with some methods.
+var statusCodeMethods = map[base.StatusCode]map[base.Method]struct{}{
+	base.StatusParameterNotUnderstood: {
+		base.GetParameter: {},
+		base.SetParameter: {},
+	},
+	base.StatusInvalidRange: {
+		base.Play:   {},
+		base.Pause:  {},
+		base.Record: {},
+	},
+	base.StatusParameterIsReadOnly: {
+		base.SetParameter: {},
+	},
+	base.StatusUnsupportedTransport: {
+		base.Setup: {},
+	},
+}
+
+// Checker checks the messages that flow in one direction of a RTSP connection,
+// that are requests sent by one side and responses of the other side.
+// Violations are reported to OnViolation and do not interrupt the exchange.
+type Checker struct {
+	// called when a violation is found.
+	OnViolation func(*Violation)
+
+	mutex    sync.Mutex
+	lastCSeq int
+	cseqSeen bool
+	sessions map[string]sessionState
+}
+
+func (c *Checker) report(method base.Method, inResponse bool, rule Rule, format string, args ...interface{}) {
+	if c.OnViolation == nil {
+		return
+	}
+
+	c.OnViolation(&Violation{
+		Rule:        rule,
+		Method:      method,
+		InResponse:  inResponse,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *Checker) checkCSeq(method base.Method, inResponse bool, h base.Header) (int, bool) {
+	v, ok := h["CSeq"]
+	if !ok || len(v) == 0 {
+		c.report(method, inResponse, RuleCSeqMissing, "CSeq header is missing")
+		return 0, false
+	}
+
+	if len(v) != 1 {
+		c.report(method, inResponse, RuleCSeqInvalid, "CSeq header is repeated")
+		return 0, false
+	}
+
+	cseq, err := strconv.ParseUint(v[0], 10, 31)
+	if err != nil {
+		c.report(method, inResponse, RuleCSeqInvalid, "CSeq header is not a number: '%s'", v[0])
+		return 0, false
+	}
+
+	return int(cseq), true
+}
+
+func sessionID(h base.Header) (string, bool, error) {
+	v, ok := h["Session"]
+	if !ok {
+		return "", false, nil
+	}
+
+	var sx headers.Session
+	err := sx.Unmarshal(v)
+	if err != nil {
+		return "", true, err
+	}
+
+	return sx.Session, true, nil
+}
+
+func (c *Checker) checkBody(method base.Method, inResponse bool, h base.Header, body []byte) {
+	if len(body) != 0 {
+		if _, ok := h["Content-Type"]; !ok {
+			c.report(method, inResponse, RuleContentTypeMissing, "message has a body but no Content-Type header")
+		}
+	}
+}
+
+// CheckRequest checks a request.
+func (c *Checker) CheckRequest(req *base.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if cseq, ok := c.checkCSeq(req.Method, false, req.Header); ok {
+		if c.cseqSeen && cseq <= c.lastCSeq {
+			c.report(req.Method, false, RuleCSeqNotIncreasing,
+				"CSeq %d is not greater than the previous one (%d)", cseq, c.lastCSeq)
+		}
+		c.cseqSeen = true
+		c.lastCSeq = cseq
+	}
+
+	if req.URL == nil {
+		if req.Method != base.Options {
+			c.report(req.Method, false, RuleURLInvalid, "URL is missing")
+		}
+	} else if req.URL.Scheme != "rtsp" && req.URL.Scheme != "rtsps" {
+		c.report(req.Method, false, RuleURLInvalid, "URL scheme '%s' is not rtsp or rtsps", req.URL.Scheme)
+	}
+
+	c.checkBody(req.Method, false, req.Header, req.Body)
+
+	switch req.Method {
+	case base.Setup:
+		if _, ok := req.Header["Transport"]; !ok {
+			c.report(req.Method, false, RuleHeaderMissing, "Transport header is missing")
+		}
+
+	case base.Announce:
+		if len(req.Body) == 0 {
+			c.report(req.Method, false, RuleBodyMissing, "ANNOUNCE requests must contain a description")
+		}
+	}
+
+	id, ok, err := sessionID(req.Header)
+	if err != nil {
+		c.report(req.Method, false, RuleHeaderInvalid, "Session header is invalid: %v", err)
+		return
+	}
+
+	if !ok {
+		if _, ok := sessionMethods[req.Method]; ok {
+			c.report(req.Method, false, RuleSessionMissing, "Session header is missing")
+		}
+		return
+	}
+
+	// sessions established through other connections are not known
+	state, ok := c.sessions[id]
+	if !ok {
+		return
+	}
+
+	switch {
+	case req.Method == base.Play && state == sessionStateRecording:
+		c.report(req.Method, false, RuleMethodNotValidInState, "PLAY can't be sent while recording")
+
+	case req.Method == base.Record && state == sessionStatePlaying:
+		c.report(req.Method, false, RuleMethodNotValidInState, "RECORD can't be sent while playing")
+	}
+}
+
+// CheckResponse checks the response to a request.
+// The request must have been checked with CheckRequest.
+func (c *Checker) CheckResponse(req *base.Request, res *base.Response) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if cseq, ok := c.checkCSeq(req.Method, true, res.Header); ok {
+		if reqCSeq, ok := req.Header["CSeq"]; ok && len(reqCSeq) == 1 && strconv.Itoa(cseq) != reqCSeq[0] {
+			c.report(req.Method, true, RuleCSeqMismatch,
+				"CSeq %d is not equal to the one of the request (%s)", cseq, reqCSeq[0])
+		}
+	}
+
+	if res.StatusCode < 100 || res.StatusCode > 599 {
+		c.report(req.Method, true, RuleStatusCodeInvalid, "status code %d is outside of the allowed range", res.StatusCode)
+	} else if methods, ok := statusCodeMethods[res.StatusCode]; ok {
+		if _, ok := methods[req.Method]; !ok {
+			c.report(req.Method, true, RuleStatusCodeInvalid, "status code %d can't be used with %s",
+				res.StatusCode, req.Method)
+		}
+	}
+
+	c.checkBody(req.Method, true, res.Header, res.Body)
+
+	switch res.StatusCode {
+	case base.StatusUnauthorized:
+		if _, ok := res.Header["WWW-Authenticate"]; !ok {
+			c.report(req.Method, true, RuleHeaderMissing, "401 responses must contain a WWW-Authenticate header")
+		}
+
+	case base.StatusProxyAuthRequired:
+		if _, ok := res.Header["Proxy-Authenticate"]; !ok {
+			c.report(req.Method, true, RuleHeaderMissing, "407 responses must contain a Proxy-Authenticate header")
+		}
+
+	case base.StatusMethodNotAllowed:
+		if _, ok := res.Header["Allow"]; !ok {
+			c.report(req.Method, true, RuleHeaderMissing, "405 responses must contain an Allow header")
+		}
+
+	case base.StatusMovedPermanently, base.StatusFound, base.StatusSeeOther, base.StatusUseProxy:
+		if _, ok := res.Header["Location"]; !ok {
+			c.report(req.Method, true, RuleHeaderMissing, "%d responses must contain a Location header", res.StatusCode)
+		}
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return
+	}
+
+	switch req.Method {
+	case base.Options:
+		if _, ok := res.Header["Public"]; !ok {
+			c.report(req.Method, true, RuleHeaderMissing, "Public header is missing")
+		}
+
+	case base.Describe:
+		if len(res.Body) == 0 {
+			c.report(req.Method, true, RuleBodyMissing, "DESCRIBE responses must contain a description")
+		}
+
+	case base.Setup:
+		if _, ok := res.Header["Transport"]; !ok {
+			c.report(req.Method, true, RuleHeaderMissing, "Transport header is missing")
+		}
+	}
+
+	c.updateSession(req, res)
+}
+
+func (c *Checker) updateSession(req *base.Request, res *base.Response) {
+	reqID, reqOK, _ := sessionID(req.Header)
+
+	resID, resOK, err := sessionID(res.Header)
+	if err != nil {
+		c.report(req.Method, true, RuleHeaderInvalid, "Session header is invalid: %v", err)
+		return
+	}
+
+	if reqOK && resOK && reqID != resID {
+		c.report(req.Method, true, RuleSessionMismatch,
+			"Session '%s' is not equal to the one of the request ('%s')", resID, reqID)
+		return
+	}
+
+	if req.Method == base.Setup {
+		if !resOK {
+			c.report(req.Method, true, RuleSessionMissing, "SETUP responses must contain a Session header")
+			return
+		}
+
+		if c.sessions == nil {
+			c.sessions = make(map[string]sessionState)
+		}
+		if _, ok := c.sessions[resID]; !ok {
+			c.sessions[resID] = sessionStateReady
+		}
+		return
+	}
+
+	id := reqID
+	if resOK {
+		id = resID
+	}
+
+	if _, ok := c.sessions[id]; !ok {
+		return
+	}
+
+	switch req.Method {
+	case base.Play:
+		c.sessions[id] = sessionStatePlaying
+
+	case base.Record:
+		c.sessions[id] = sessionStateRecording
+
+	case base.Pause:
+		c.sessions[id] = sessionStateReady
+
+	case base.Teardown:
+		delete(c.sessions, id)
+	}
+}
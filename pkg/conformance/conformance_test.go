@@ -0,0 +1,277 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+func mustParseURL(s string) *base.URL {
+	u, err := base.ParseURL(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+type exchange struct {
+	req *base.Request
+	res *base.Response
+}
+
+func TestChecker(t *testing.T) {
+	for _, ca := range []struct {
+		name       string
+		exchanges  []exchange
+		violations []Rule
+	}{
+		{
+			"compliant",
+			[]exchange{
+				{
+					&base.Request{
+						Method: base.Options,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{"CSeq": base.HeaderValue{"1"}},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq":   base.HeaderValue{"1"},
+							"Public": base.HeaderValue{"DESCRIBE, SETUP, PLAY"},
+						},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Setup,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream/trackID=0"),
+						Header: base.Header{
+							"CSeq":      base.HeaderValue{"2"},
+							"Transport": base.HeaderValue{"RTP/AVP/TCP;unicast;interleaved=0-1"},
+						},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq":      base.HeaderValue{"2"},
+							"Transport": base.HeaderValue{"RTP/AVP/TCP;unicast;interleaved=0-1"},
+							"Session":   base.HeaderValue{"12345678;timeout=60"},
+						},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Play,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{
+							"CSeq":    base.HeaderValue{"3"},
+							"Session": base.HeaderValue{"12345678"},
+						},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq":    base.HeaderValue{"3"},
+							"Session": base.HeaderValue{"12345678"},
+						},
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"cseq",
+			[]exchange{
+				{
+					&base.Request{
+						Method: base.Options,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{"CSeq": base.HeaderValue{"2"}},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq":   base.HeaderValue{"3"},
+							"Public": base.HeaderValue{"DESCRIBE"},
+						},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Options,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{"CSeq": base.HeaderValue{"2"}},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"Public": base.HeaderValue{"DESCRIBE"},
+						},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Options,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{"CSeq": base.HeaderValue{"abc"}},
+					},
+					nil,
+				},
+			},
+			[]Rule{RuleCSeqMismatch, RuleCSeqNotIncreasing, RuleCSeqMissing, RuleCSeqInvalid},
+		},
+		{
+			"required headers",
+			[]exchange{
+				{
+					&base.Request{
+						Method: base.Setup,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream/trackID=0"),
+						Header: base.Header{"CSeq": base.HeaderValue{"1"}},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header:     base.Header{"CSeq": base.HeaderValue{"1"}},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Play,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{"CSeq": base.HeaderValue{"2"}},
+					},
+					&base.Response{
+						StatusCode: base.StatusUnauthorized,
+						Header:     base.Header{"CSeq": base.HeaderValue{"2"}},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Describe,
+						URL:    &base.URL{Scheme: "http", Host: "localhost:8554", Path: "/teststream"},
+						Header: base.Header{"CSeq": base.HeaderValue{"3"}},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header:     base.Header{"CSeq": base.HeaderValue{"3"}},
+						Body:       []byte("v=0"),
+					},
+				},
+			},
+			[]Rule{
+				RuleHeaderMissing,
+				RuleHeaderMissing,
+				RuleSessionMissing,
+				RuleSessionMissing,
+				RuleHeaderMissing,
+				RuleURLInvalid,
+				RuleContentTypeMissing,
+			},
+		},
+		{
+			"state",
+			[]exchange{
+				{
+					&base.Request{
+						Method: base.Setup,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream/trackID=0"),
+						Header: base.Header{
+							"CSeq":      base.HeaderValue{"1"},
+							"Transport": base.HeaderValue{"RTP/AVP/TCP;unicast;interleaved=0-1"},
+						},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq":      base.HeaderValue{"1"},
+							"Transport": base.HeaderValue{"RTP/AVP/TCP;unicast;interleaved=0-1"},
+							"Session":   base.HeaderValue{"12345678"},
+						},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Play,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{
+							"CSeq":    base.HeaderValue{"2"},
+							"Session": base.HeaderValue{"12345678"},
+						},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq":    base.HeaderValue{"2"},
+							"Session": base.HeaderValue{"87654321"},
+						},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Play,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{
+							"CSeq":    base.HeaderValue{"3"},
+							"Session": base.HeaderValue{"12345678"},
+						},
+					},
+					&base.Response{
+						StatusCode: base.StatusOK,
+						Header: base.Header{
+							"CSeq":    base.HeaderValue{"3"},
+							"Session": base.HeaderValue{"12345678"},
+						},
+					},
+				},
+				{
+					&base.Request{
+						Method: base.Record,
+						URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+						Header: base.Header{
+							"CSeq":    base.HeaderValue{"4"},
+							"Session": base.HeaderValue{"12345678"},
+						},
+					},
+					&base.Response{
+						StatusCode: base.StatusUnsupportedTransport,
+						Header: base.Header{
+							"CSeq": base.HeaderValue{"4"},
+						},
+					},
+				},
+			},
+			[]Rule{RuleSessionMismatch, RuleMethodNotValidInState, RuleStatusCodeInvalid},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var violations []Rule
+
+			c := &Checker{
+				OnViolation: func(v *Violation) {
+					violations = append(violations, v.Rule)
+				},
+			}
+
+			for _, ex := range ca.exchanges {
+				c.CheckRequest(ex.req)
+				if ex.res != nil {
+					c.CheckResponse(ex.req, ex.res)
+				}
+			}
+
+			require.Equal(t, ca.violations, violations)
+		})
+	}
+}
+
+func TestViolationError(t *testing.T) {
+	v := &Violation{
+		Rule:        RuleSessionMissing,
+		Method:      base.Play,
+		Description: "Session header is missing",
+	}
+	require.Equal(t, "PLAY request violates rule 'session-missing': Session header is missing", v.Error())
+}
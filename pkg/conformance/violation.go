@@ -0,0 +1,63 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// Rule is a rule of the RTSP specification.
+type Rule string
+
+// rules.
+const (
+	// requests and responses must contain a single, numeric CSeq header.
+	RuleCSeqMissing Rule = "cseq-missing"
+	RuleCSeqInvalid Rule = "cseq-invalid"
+	// CSeq of requests must increase.
+	RuleCSeqNotIncreasing Rule = "cseq-not-increasing"
+	// CSeq of responses must be equal to the one of the request.
+	RuleCSeqMismatch Rule = "cseq-mismatch"
+	// request URLs must be absolute RTSP URLs.
+	RuleURLInvalid Rule = "url-invalid"
+	// a header required by the method or by the status code is missing.
+	RuleHeaderMissing Rule = "header-missing"
+	// a header can't be decoded.
+	RuleHeaderInvalid Rule = "header-invalid"
+	// requests that refer to a session must contain its Session header.
+	RuleSessionMissing Rule = "session-missing"
+	// Session headers must refer to the session established by SETUP.
+	RuleSessionMismatch Rule = "session-mismatch"
+	// the method can't be used in the current state of the session.
+	RuleMethodNotValidInState Rule = "method-not-valid-in-state"
+	// the status code is outside of the allowed range, or can't be used with the method.
+	RuleStatusCodeInvalid Rule = "status-code-invalid"
+	// messages with a body must contain a Content-Type header.
+	RuleContentTypeMissing Rule = "content-type-missing"
+	// the method requires a body.
+	RuleBodyMissing Rule = "body-missing"
+)
+
+// Violation is a violation of the RTSP specification.
+type Violation struct {
+	// violated rule.
+	Rule Rule
+
+	// method of the request that the violation refers to.
+	Method base.Method
+
+	// whether the violation was found in the response instead of the request.
+	InResponse bool
+
+	// description of the violation.
+	Description string
+}
+
+// Error implements the error interface.
+func (v *Violation) Error() string {
+	msg := "request"
+	if v.InResponse {
+		msg = "response"
+	}
+	return fmt.Sprintf("%s %s violates rule '%s': %s", v.Method, msg, v.Rule, v.Description)
+}
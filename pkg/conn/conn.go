@@ -19,6 +19,11 @@ type Conn struct {
 
 	// reuse interleaved frames. they should never be passed to secondary routines
 	fr base.InterleavedFrame
+
+	reuseFramePayloads bool
+	lenientParsing     bool
+
+	skipped uint64
 }
 
 // NewConn allocates a Conn.
@@ -29,8 +34,27 @@ func NewConn(rw io.ReadWriter) *Conn {
 	}
 }
 
+func isSkippable(b byte) bool {
+	return b == '\r' || b == '\n' || b == ' ' || b == '\t'
+}
+
 // Read reads a Request, a Response or an Interleaved frame.
+// Whitespace between messages, that some cameras send as a keepalive, is skipped.
 func (c *Conn) Read() (interface{}, error) {
+	for {
+		byts, err := c.br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isSkippable(byts[0]) {
+			break
+		}
+
+		c.br.Discard(1) //nolint:errcheck
+		c.skipped++
+	}
+
 	byts, err := c.br.Peek(2)
 	if err != nil {
 		return nil, err
@@ -47,22 +71,55 @@ func (c *Conn) Read() (interface{}, error) {
 	return c.ReadRequest()
 }
 
+// SkippedBytes returns the number of whitespace bytes that were skipped by Read().
+func (c *Conn) SkippedBytes() uint64 {
+	return c.skipped
+}
+
 // ReadRequest reads a Request.
 func (c *Conn) ReadRequest() (*base.Request, error) {
 	var req base.Request
-	err := req.Unmarshal(c.br)
+	var err error
+	if c.lenientParsing {
+		err = req.UnmarshalLenient(c.br)
+	} else {
+		err = req.Unmarshal(c.br)
+	}
 	return &req, err
 }
 
 // ReadResponse reads a Response.
 func (c *Conn) ReadResponse() (*base.Response, error) {
 	var res base.Response
-	err := res.Unmarshal(c.br)
+	var err error
+	if c.lenientParsing {
+		err = res.UnmarshalLenient(c.br)
+	} else {
+		err = res.Unmarshal(c.br)
+	}
 	return &res, err
 }
 
+// SetReuseFramePayloads sets whether the payload buffer of interleaved frames
+// is reused between reads, avoiding an allocation for each frame.
+// When enabled, the payload of a frame is valid only until the next read.
+func (c *Conn) SetReuseFramePayloads(v bool) {
+	c.reuseFramePayloads = v
+}
+
+// SetLenientParsing sets whether requests and responses are decoded
+// with base.Request.UnmarshalLenient and base.Response.UnmarshalLenient,
+// that tolerate LF-only line endings, folded headers and irregular whitespace.
+func (c *Conn) SetLenientParsing(v bool) {
+	c.lenientParsing = v
+}
+
 // ReadInterleavedFrame reads a InterleavedFrame.
 func (c *Conn) ReadInterleavedFrame() (*base.InterleavedFrame, error) {
+	if !c.reuseFramePayloads {
+		c.fr.Payload = nil
+	}
+
 	err := c.fr.Unmarshal(c.br)
 	return &c.fr, err
 }
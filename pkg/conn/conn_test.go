@@ -40,6 +40,10 @@ func TestRead(t *testing.T) {
 					"Accept": base.HeaderValue{"application/sdp"},
 					"CSeq":   base.HeaderValue{"2"},
 				},
+				HeaderEntries: base.HeaderEntries{
+					{Key: "Accept", Value: "application/sdp"},
+					{Key: "CSeq", Value: "2"},
+				},
 			},
 		},
 		{
@@ -55,6 +59,10 @@ func TestRead(t *testing.T) {
 					"CSeq":   base.HeaderValue{"1"},
 					"Public": base.HeaderValue{"DESCRIBE, SETUP, TEARDOWN, PLAY, PAUSE"},
 				},
+				HeaderEntries: base.HeaderEntries{
+					{Key: "CSeq", Value: "1"},
+					{Key: "Public", Value: "DESCRIBE, SETUP, TEARDOWN, PLAY, PAUSE"},
+				},
 			},
 		},
 		{
@@ -76,6 +84,62 @@ func TestRead(t *testing.T) {
 	}
 }
 
+func TestReadSkipWhitespace(t *testing.T) {
+	buf := bytes.NewBuffer([]byte("\r\n\r\n" +
+		"RTSP/1.0 200 OK\r\n" +
+		"CSeq: 1\r\n" +
+		"\r\n" +
+		"\r\n \t" +
+		"\x24\x06\x00\x04\x01\x02\x03\x04" +
+		"\r\n"))
+	conn := NewConn(buf)
+
+	dec, err := conn.Read()
+	require.NoError(t, err)
+	require.Equal(t, &base.Response{
+		StatusCode:    200,
+		StatusMessage: "OK",
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+		HeaderEntries: base.HeaderEntries{
+			{Key: "CSeq", Value: "1"},
+		},
+	}, dec)
+
+	dec, err = conn.Read()
+	require.NoError(t, err)
+	require.Equal(t, &base.InterleavedFrame{
+		Channel: 6,
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	}, dec)
+
+	_, err = conn.Read()
+	require.Error(t, err)
+	require.Equal(t, uint64(10), conn.SkippedBytes())
+}
+
+func TestReadLenientParsing(t *testing.T) {
+	buf := bytes.NewBuffer([]byte("RTSP/1.0 200 OK\n" +
+		"CSeq:1\n" +
+		"\n"))
+	conn := NewConn(buf)
+	conn.SetLenientParsing(true)
+
+	dec, err := conn.Read()
+	require.NoError(t, err)
+	require.Equal(t, &base.Response{
+		StatusCode:    200,
+		StatusMessage: "OK",
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+		HeaderEntries: base.HeaderEntries{
+			{Key: "CSeq", Value: "1"},
+		},
+	}, dec)
+}
+
 func TestReadError(t *testing.T) {
 	var buf bytes.Buffer
 	conn := NewConn(&buf)
@@ -126,3 +190,63 @@ func TestWriteInterleavedFrame(t *testing.T) {
 	}, make([]byte, 1024))
 	require.NoError(t, err)
 }
+
+func TestReadReuseFramePayloads(t *testing.T) {
+	for _, ca := range []string{"enabled", "disabled"} {
+		t.Run(ca, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{
+				0x24, 0x6, 0x0, 0x4, 0x1, 0x2, 0x3, 0x4,
+				0x24, 0x6, 0x0, 0x2, 0x5, 0x6,
+			})
+			conn := NewConn(buf)
+			conn.SetReuseFramePayloads(ca == "enabled")
+
+			fr, err := conn.ReadInterleavedFrame()
+			require.NoError(t, err)
+			payload1 := fr.Payload
+			require.Equal(t, []byte{1, 2, 3, 4}, payload1)
+
+			fr, err = conn.ReadInterleavedFrame()
+			require.NoError(t, err)
+			require.Equal(t, []byte{5, 6}, fr.Payload)
+
+			if ca == "enabled" {
+				require.Equal(t, []byte{5, 6, 3, 4}, payload1)
+			} else {
+				require.Equal(t, []byte{1, 2, 3, 4}, payload1)
+			}
+		})
+	}
+}
+
+func benchmarkReadInterleavedFrame(b *testing.B, reuse bool) {
+	fr := base.InterleavedFrame{
+		Channel: 0,
+		Payload: bytes.Repeat([]byte{1}, 1400),
+	}
+	enc, _ := fr.Marshal()
+	src := bytes.Repeat(enc, 64)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		conn := NewConn(bytes.NewBuffer(src))
+		conn.SetReuseFramePayloads(reuse)
+
+		for i := 0; i < 64; i++ {
+			_, err := conn.ReadInterleavedFrame()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkReadInterleavedFrame(b *testing.B) {
+	benchmarkReadInterleavedFrame(b, false)
+}
+
+func BenchmarkReadInterleavedFrameReusePayloads(b *testing.B) {
+	benchmarkReadInterleavedFrame(b, true)
+}
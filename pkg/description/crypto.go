@@ -0,0 +1,176 @@
+package description
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// CryptoKey is a key of a crypto attribute.
+type CryptoKey struct {
+	// Concatenated master key and salt.
+	Key []byte
+
+	// Maximum number of packets that can be protected with the key (optional).
+	// If zero, the default lifetime of the crypto suite is used.
+	Lifetime uint64
+
+	// Master Key Identifier (optional).
+	MKI uint64
+
+	// Length in bytes of the MKI field of SRTP packets.
+	// If zero, MKI is not used.
+	MKILength int
+}
+
+func (k *CryptoKey) unmarshal(v string) error {
+	if !strings.HasPrefix(v, "inline:") {
+		return fmt.Errorf("unsupported key method: %v", v)
+	}
+
+	parts := strings.Split(v[len("inline:"):], "|")
+	if len(parts) > 3 {
+		return fmt.Errorf("invalid key parameters: %v", v)
+	}
+
+	var err error
+	k.Key, err = base64.StdEncoding.DecodeString(parts[0])
+	if err != nil || len(k.Key) == 0 {
+		return fmt.Errorf("invalid key: %v", parts[0])
+	}
+
+	k.Lifetime = 0
+	k.MKI = 0
+	k.MKILength = 0
+
+	for _, part := range parts[1:] {
+		// MKI contains a colon, lifetime doesn't
+		if i := strings.IndexByte(part, ':'); i >= 0 {
+			if k.MKILength != 0 {
+				return fmt.Errorf("MKI provided multiple times")
+			}
+
+			k.MKI, err = strconv.ParseUint(part[:i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid MKI: %v", part)
+			}
+
+			tmp, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || tmp == 0 || tmp > 128 {
+				return fmt.Errorf("invalid MKI length: %v", part)
+			}
+			k.MKILength = int(tmp)
+			continue
+		}
+
+		if k.Lifetime != 0 || k.MKILength != 0 {
+			return fmt.Errorf("invalid key parameters: %v", v)
+		}
+
+		if strings.HasPrefix(part, "2^") {
+			tmp, err := strconv.ParseUint(part[2:], 10, 8)
+			if err != nil || tmp == 0 || tmp > 63 {
+				return fmt.Errorf("invalid lifetime: %v", part)
+			}
+			k.Lifetime = 1 << tmp
+		} else {
+			k.Lifetime, err = strconv.ParseUint(part, 10, 64)
+			if err != nil || k.Lifetime == 0 {
+				return fmt.Errorf("invalid lifetime: %v", part)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (k CryptoKey) marshal() string {
+	ret := "inline:" + base64.StdEncoding.EncodeToString(k.Key)
+
+	if k.Lifetime != 0 {
+		// use the exponential form, that is the most common one, when possible
+		if k.Lifetime&(k.Lifetime-1) == 0 {
+			ret += "|2^" + strconv.FormatInt(int64(bits.TrailingZeros64(k.Lifetime)), 10)
+		} else {
+			ret += "|" + strconv.FormatUint(k.Lifetime, 10)
+		}
+	}
+
+	if k.MKILength != 0 {
+		ret += "|" + strconv.FormatUint(k.MKI, 10) + ":" + strconv.FormatInt(int64(k.MKILength), 10)
+	}
+
+	return ret
+}
+
+// Crypto is a crypto attribute, that contains SRTP parameters.
+// Specification: https://datatracker.ietf.org/doc/html/rfc4568
+type Crypto struct {
+	// Tag of the attribute, that identifies it among the ones of the same media.
+	Tag uint32
+
+	// Crypto suite.
+	Suite string
+
+	// Keys. When there are multiple keys, they are distinguished by MKI.
+	Keys []CryptoKey
+
+	// Session parameters (optional).
+	SessionParams []string
+}
+
+// Unmarshal decodes a crypto attribute.
+func (c *Crypto) Unmarshal(v string) error {
+	parts := strings.Fields(v)
+	if len(parts) < 3 {
+		return fmt.Errorf("invalid crypto attribute: %v", v)
+	}
+
+	tmp, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid crypto attribute: %v", v)
+	}
+	c.Tag = uint32(tmp)
+
+	c.Suite = parts[1]
+
+	c.Keys = nil
+	for _, kp := range strings.Split(parts[2], ";") {
+		var k CryptoKey
+		err := k.unmarshal(kp)
+		if err != nil {
+			return fmt.Errorf("invalid crypto attribute: %w", err)
+		}
+
+		if len(c.Keys) != 0 && (k.MKILength == 0 || c.Keys[0].MKILength == 0) {
+			return fmt.Errorf("invalid crypto attribute: multiple keys require MKI")
+		}
+
+		c.Keys = append(c.Keys, k)
+	}
+
+	c.SessionParams = nil
+	if len(parts) > 3 {
+		c.SessionParams = parts[3:]
+	}
+
+	return nil
+}
+
+// Marshal encodes a crypto attribute.
+func (c Crypto) Marshal() string {
+	keys := make([]string, len(c.Keys))
+	for i, k := range c.Keys {
+		keys[i] = k.marshal()
+	}
+
+	ret := strconv.FormatUint(uint64(c.Tag), 10) + " " + c.Suite + " " + strings.Join(keys, ";")
+
+	if len(c.SessionParams) != 0 {
+		ret += " " + strings.Join(c.SessionParams, " ")
+	}
+
+	return ret
+}
@@ -0,0 +1,79 @@
+package description
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HeaderExtension is a RTP header extension declared with the extmap attribute.
+// Specification: https://datatracker.ietf.org/doc/html/rfc8285#section-8
+type HeaderExtension struct {
+	// ID of the extension.
+	// IDs between 1 and 14 can be sent with both one-byte and two-byte headers,
+	// IDs between 15 and 255 can be sent with two-byte headers only.
+	ID uint8
+
+	// Direction of the extension (optional).
+	// It can be "sendonly", "recvonly", "sendrecv" or "inactive".
+	Direction string
+
+	// URI that identifies the extension.
+	URI string
+
+	// Extension attributes (optional).
+	Attributes string
+}
+
+// Unmarshal decodes an extmap attribute.
+func (e *HeaderExtension) Unmarshal(v string) error {
+	parts := strings.SplitN(v, " ", 3)
+	if len(parts) < 2 || parts[1] == "" {
+		return fmt.Errorf("invalid extmap attribute: %v", v)
+	}
+
+	tmp := strings.SplitN(parts[0], "/", 2)
+
+	id, err := strconv.ParseUint(tmp[0], 10, 8)
+	if err != nil || id == 0 {
+		return fmt.Errorf("invalid extmap attribute: %v", v)
+	}
+	e.ID = uint8(id)
+
+	e.Direction = ""
+	if len(tmp) == 2 {
+		switch tmp[1] {
+		case "sendonly", "recvonly", "sendrecv", "inactive":
+			e.Direction = tmp[1]
+
+		default:
+			return fmt.Errorf("invalid extmap attribute: %v", v)
+		}
+	}
+
+	e.URI = parts[1]
+
+	e.Attributes = ""
+	if len(parts) == 3 {
+		e.Attributes = parts[2]
+	}
+
+	return nil
+}
+
+// Marshal encodes an extmap attribute.
+func (e HeaderExtension) Marshal() string {
+	ret := strconv.FormatUint(uint64(e.ID), 10)
+
+	if e.Direction != "" {
+		ret += "/" + e.Direction
+	}
+
+	ret += " " + e.URI
+
+	if e.Attributes != "" {
+		ret += " " + e.Attributes
+	}
+
+	return ret
+}
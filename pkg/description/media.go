@@ -3,11 +3,14 @@ package description
 
 import (
 	"fmt"
+	"math"
+	"net"
 	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	psdp "github.com/pion/sdp/v3"
@@ -50,6 +53,45 @@ func isBackChannel(attributes []psdp.Attribute) bool {
 	return false
 }
 
+// parseRTCPAttribute parses a rtcp attribute.
+// It returns a zero port if the attribute is invalid.
+func parseRTCPAttribute(v string) (int, string) {
+	parts := strings.Split(v, " ")
+	if len(parts) != 1 && len(parts) != 4 {
+		return 0, ""
+	}
+
+	tmp, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil || tmp == 0 {
+		return 0, ""
+	}
+	port := int(tmp)
+
+	if len(parts) == 1 {
+		return port, ""
+	}
+
+	if parts[1] != "IN" || (parts[2] != "IP4" && parts[2] != "IP6") || parts[3] == "" {
+		return 0, ""
+	}
+
+	return port, parts[3]
+}
+
+// parsePacketTime parses a ptime or maxptime attribute, expressed in milliseconds.
+// It returns zero if the attribute is invalid.
+func parsePacketTime(v string) time.Duration {
+	tmp, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || tmp <= 0 {
+		return 0
+	}
+	return time.Duration(tmp * float64(time.Millisecond))
+}
+
+func marshalPacketTime(v time.Duration) string {
+	return strconv.FormatFloat(float64(v)/float64(time.Millisecond), 'f', -1, 64)
+}
+
 func getFormatAttribute(attributes []psdp.Attribute, payloadType uint8, key string) string {
 	for _, attr := range attributes {
 		if attr.Key == key {
@@ -64,6 +106,26 @@ func getFormatAttribute(attributes []psdp.Attribute, payloadType uint8, key stri
 	return ""
 }
 
+// fixRTPMapClockRate rounds a non-integer clock rate of a rtpmap attribute.
+func fixRTPMapClockRate(rtpMap string) (string, bool) {
+	parts := strings.Split(rtpMap, "/")
+	if len(parts) < 2 {
+		return "", false
+	}
+
+	if _, err := strconv.ParseUint(parts[1], 10, 31); err == nil {
+		return "", false
+	}
+
+	tmp, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || tmp < 1 || tmp > math.MaxInt32 {
+		return "", false
+	}
+
+	parts[1] = strconv.FormatInt(int64(math.Round(tmp)), 10)
+	return strings.Join(parts, "/"), true
+}
+
 func decodeFMTP(enc string) map[string]string {
 	if enc == "" {
 		return nil
@@ -79,7 +141,11 @@ func decodeFMTP(enc string) map[string]string {
 		}
 
 		tmp := strings.SplitN(kv, "=", 2)
+
+		// parameters that are not in the key=value form, like the ones of RED (RFC2198),
+		// are stored with an empty key.
 		if len(tmp) != 2 {
+			ret[""] = kv
 			continue
 		}
 
@@ -134,41 +200,144 @@ type Media struct {
 	// Control attribute.
 	Control string
 
+	// RTCP port (RFC3605, optional).
+	// If zero, RTCP is assumed to be on RTP port + 1.
+	RTCPPort int
+
+	// RTCP address (RFC3605, optional).
+	// It is used to send RTCP packets to a multicast group different from the RTP one.
+	// It requires RTCPPort.
+	RTCPAddress string
+
+	// Duration of the audio contained in each packet (ptime attribute, optional).
+	// It is forwarded to formats that support it.
+	PTime time.Duration
+
+	// Maximum duration of the audio contained in each packet (maxptime attribute, optional).
+	MaxPTime time.Duration
+
+	// SRTP parameters (crypto attributes, optional).
+	Crypto []Crypto
+
+	// RTP header extensions (extmap attributes, optional).
+	HeaderExtensions []HeaderExtension
+
 	// Formats contained into the media.
 	Formats []format.Format
 }
 
 // Unmarshal decodes the media from the SDP format.
 func (m *Media) Unmarshal(md *psdp.MediaDescription) error {
+	return m.unmarshal(md, nil, nil)
+}
+
+// unmarshal decodes the media. If onWarning is not nil, invalid values are
+// skipped or corrected instead of causing a failure, and format attributes
+// are also searched among session attributes.
+func (m *Media) unmarshal(
+	md *psdp.MediaDescription,
+	sessionAttributes []psdp.Attribute,
+	onWarning func(error),
+) error {
 	m.Type = MediaType(md.MediaName.Media)
 
 	m.ID = getAttribute(md.Attributes, "mid")
 	if m.ID != "" && !isAlphaNumeric(m.ID) {
-		return fmt.Errorf("invalid mid: %v", m.ID)
+		if onWarning == nil {
+			return fmt.Errorf("invalid mid: %v", m.ID)
+		}
+		onWarning(fmt.Errorf("invalid mid ignored: %v", m.ID))
+		m.ID = ""
 	}
 
 	m.IsBackChannel = isBackChannel(md.Attributes)
 	m.Control = getAttribute(md.Attributes, "control")
 
+	// invalid values are ignored
+	m.RTCPPort, m.RTCPAddress = parseRTCPAttribute(getAttribute(md.Attributes, "rtcp"))
+	m.PTime = parsePacketTime(getAttribute(md.Attributes, "ptime"))
+	m.MaxPTime = parsePacketTime(getAttribute(md.Attributes, "maxptime"))
+
+	// invalid crypto attributes are ignored
+	m.Crypto = nil
+	for _, attr := range md.Attributes {
+		if attr.Key == "crypto" {
+			var c Crypto
+			err := c.Unmarshal(attr.Value)
+			if err == nil {
+				m.Crypto = append(m.Crypto, c)
+			}
+		}
+	}
+
+	// invalid extmap attributes are ignored
+	m.HeaderExtensions = nil
+	for _, attr := range md.Attributes {
+		if attr.Key == "extmap" {
+			var e HeaderExtension
+			err := e.Unmarshal(attr.Value)
+			if err == nil {
+				m.HeaderExtensions = append(m.HeaderExtensions, e)
+			}
+		}
+	}
+
 	m.Formats = nil
 	for _, payloadType := range md.MediaName.Formats {
 		payloadType = replaceSmartPayloadType(payloadType, md.Attributes)
 
 		tmp, err := strconv.ParseUint(payloadType, 10, 8)
 		if err != nil {
-			return err
+			if onWarning == nil {
+				return err
+			}
+			onWarning(fmt.Errorf("format skipped: %w", err))
+			continue
 		}
 		payloadTypeInt := uint8(tmp)
 
 		rtpMap := getFormatAttribute(md.Attributes, payloadTypeInt, "rtpmap")
 		fmtp := decodeFMTP(getFormatAttribute(md.Attributes, payloadTypeInt, "fmtp"))
 
-		format, err := format.Unmarshal(string(m.Type), payloadTypeInt, rtpMap, fmtp)
+		if onWarning != nil {
+			if m.hasPayloadType(payloadTypeInt) {
+				onWarning(fmt.Errorf("duplicate payload type skipped: %d", payloadTypeInt))
+				continue
+			}
+
+			// some servers put format attributes before the media description
+			if rtpMap == "" {
+				rtpMap = getFormatAttribute(sessionAttributes, payloadTypeInt, "rtpmap")
+				if fmtp == nil {
+					fmtp = decodeFMTP(getFormatAttribute(sessionAttributes, payloadTypeInt, "fmtp"))
+				}
+			}
+
+			if fixed, ok := fixRTPMapClockRate(rtpMap); ok {
+				onWarning(fmt.Errorf("non-integer clock rate rounded: %v", rtpMap))
+				rtpMap = fixed
+			}
+		}
+
+		forma, err := format.Unmarshal(string(m.Type), payloadTypeInt, rtpMap, fmtp)
 		if err != nil {
-			return err
+			if onWarning == nil {
+				return err
+			}
+			onWarning(fmt.Errorf("format %d skipped: %w", payloadTypeInt, err))
+			continue
+		}
+
+		switch forma := forma.(type) {
+		case *format.G711:
+			forma.PTime = m.PTime
+
+		case *format.LPCM:
+			forma.PTime = m.PTime
+			forma.MaxPTime = m.MaxPTime
 		}
 
-		m.Formats = append(m.Formats, format)
+		m.Formats = append(m.Formats, forma)
 	}
 
 	if m.Formats == nil {
@@ -178,6 +347,15 @@ func (m *Media) Unmarshal(md *psdp.MediaDescription) error {
 	return nil
 }
 
+func (m *Media) hasPayloadType(payloadType uint8) bool {
+	for _, forma := range m.Formats {
+		if forma.PayloadType() == payloadType {
+			return true
+		}
+	}
+	return false
+}
+
 // Marshal encodes the media in SDP format.
 func (m Media) Marshal() *psdp.MediaDescription {
 	md := &psdp.MediaDescription{
@@ -187,6 +365,10 @@ func (m Media) Marshal() *psdp.MediaDescription {
 		},
 	}
 
+	if len(m.Crypto) != 0 {
+		md.MediaName.Protos = []string{"RTP", "SAVP"}
+	}
+
 	if m.ID != "" {
 		md.Attributes = append(md.Attributes, psdp.Attribute{
 			Key:   "mid",
@@ -205,6 +387,51 @@ func (m Media) Marshal() *psdp.MediaDescription {
 		Value: m.Control,
 	})
 
+	if m.RTCPPort != 0 {
+		v := strconv.FormatInt(int64(m.RTCPPort), 10)
+
+		if m.RTCPAddress != "" {
+			addrType := "IP4"
+			if ip := net.ParseIP(m.RTCPAddress); ip != nil && ip.To4() == nil {
+				addrType = "IP6"
+			}
+			v += " IN " + addrType + " " + m.RTCPAddress
+		}
+
+		md.Attributes = append(md.Attributes, psdp.Attribute{
+			Key:   "rtcp",
+			Value: v,
+		})
+	}
+
+	if m.PTime != 0 {
+		md.Attributes = append(md.Attributes, psdp.Attribute{
+			Key:   "ptime",
+			Value: marshalPacketTime(m.PTime),
+		})
+	}
+
+	if m.MaxPTime != 0 {
+		md.Attributes = append(md.Attributes, psdp.Attribute{
+			Key:   "maxptime",
+			Value: marshalPacketTime(m.MaxPTime),
+		})
+	}
+
+	for _, c := range m.Crypto {
+		md.Attributes = append(md.Attributes, psdp.Attribute{
+			Key:   "crypto",
+			Value: c.Marshal(),
+		})
+	}
+
+	for _, e := range m.HeaderExtensions {
+		md.Attributes = append(md.Attributes, psdp.Attribute{
+			Key:   "extmap",
+			Value: e.Marshal(),
+		})
+	}
+
 	for _, forma := range m.Formats {
 		typ := strconv.FormatUint(uint64(forma.PayloadType()), 10)
 		md.MediaName.Formats = append(md.MediaName.Formats, typ)
@@ -221,7 +448,11 @@ func (m Media) Marshal() *psdp.MediaDescription {
 		if len(fmtp) != 0 {
 			tmp := make([]string, len(fmtp))
 			for i, key := range sortedKeys(fmtp) {
-				tmp[i] = key + "=" + fmtp[key]
+				if key == "" {
+					tmp[i] = fmtp[key]
+				} else {
+					tmp[i] = key + "=" + fmtp[key]
+				}
 			}
 
 			md.Attributes = append(md.Attributes, psdp.Attribute{
@@ -274,8 +505,17 @@ func (m Media) URL(contentBase *base.URL) (*base.URL, error) {
 
 // FindFormat finds a certain format among all the formats in the media.
 func (m Media) FindFormat(forma interface{}) bool {
+	return m.FindFormatFunc(forma, nil)
+}
+
+// FindFormatFunc finds a certain format among all the formats in the media.
+// Formats of the requested type are passed to accept, and the first accepted one is returned.
+// It can be used to choose among multiple formats of the same type (i.e. with different payload types).
+// If accept is nil, the first format of the requested type is returned.
+func (m Media) FindFormatFunc(forma interface{}, accept func(format.Format) bool) bool {
 	for _, formak := range m.Formats {
-		if reflect.TypeOf(formak) == reflect.TypeOf(forma).Elem() {
+		if reflect.TypeOf(formak) == reflect.TypeOf(forma).Elem() &&
+			(accept == nil || accept(formak)) {
 			reflect.ValueOf(forma).Elem().Set(reflect.ValueOf(formak))
 			return true
 		}
@@ -2,7 +2,9 @@ package description
 
 import (
 	"testing"
+	"time"
 
+	psdp "github.com/pion/sdp/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
@@ -163,3 +165,366 @@ func TestMediaURLError(t *testing.T) {
 	_, err := media.URL(nil)
 	require.EqualError(t, err, "Content-Base header not provided")
 }
+
+func TestMediaRTCPAttribute(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		attr    string
+		port    int
+		address string
+	}{
+		{
+			"port only",
+			"53020",
+			53020,
+			"",
+		},
+		{
+			"ipv4 address",
+			"53020 IN IP4 224.2.1.4",
+			53020,
+			"224.2.1.4",
+		},
+		{
+			"ipv6 address",
+			"53020 IN IP6 ff15::103",
+			53020,
+			"ff15::103",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var sd sdp.SessionDescription
+			err := sd.Unmarshal([]byte("v=0\r\n" +
+				"s= \r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp:" + ca.attr + "\r\n"))
+			require.NoError(t, err)
+
+			var media Media
+			err = media.Unmarshal(sd.MediaDescriptions[0])
+			require.NoError(t, err)
+			require.Equal(t, ca.port, media.RTCPPort)
+			require.Equal(t, ca.address, media.RTCPAddress)
+
+			md := media.Marshal()
+			require.Contains(t, md.Attributes, psdp.Attribute{Key: "rtcp", Value: ca.attr})
+		})
+	}
+}
+
+func TestMediaPTimeAttribute(t *testing.T) {
+	var sd sdp.SessionDescription
+	err := sd.Unmarshal([]byte("v=0\r\n" +
+		"s= \r\n" +
+		"m=audio 0 RTP/AVP 0 8\r\n" +
+		"a=ptime:20\r\n" +
+		"a=maxptime:0.125\r\n"))
+	require.NoError(t, err)
+
+	var media Media
+	err = media.Unmarshal(sd.MediaDescriptions[0])
+	require.NoError(t, err)
+	require.Equal(t, 20*time.Millisecond, media.PTime)
+	require.Equal(t, 125*time.Microsecond, media.MaxPTime)
+	require.Equal(t, []format.Format{
+		&format.G711{MULaw: true, PTime: 20 * time.Millisecond},
+		&format.G711{MULaw: false, PTime: 20 * time.Millisecond},
+	}, media.Formats)
+
+	md := media.Marshal()
+	require.Contains(t, md.Attributes, psdp.Attribute{Key: "ptime", Value: "20"})
+	require.Contains(t, md.Attributes, psdp.Attribute{Key: "maxptime", Value: "0.125"})
+}
+
+func TestMediaFMTPWithoutKeys(t *testing.T) {
+	var sd sdp.SessionDescription
+	err := sd.Unmarshal([]byte("v=0\r\n" +
+		"s= \r\n" +
+		"m=audio 0 RTP/AVP 63 111\r\n" +
+		"a=rtpmap:63 red/48000/2\r\n" +
+		"a=fmtp:63 111/111\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n"))
+	require.NoError(t, err)
+
+	var media Media
+	err = media.Unmarshal(sd.MediaDescriptions[0])
+	require.NoError(t, err)
+	require.Equal(t, &format.RED{
+		PayloadTyp:   63,
+		ClockRat:     48000,
+		ChannelCount: 2,
+		PayloadTypes: []uint8{111, 111},
+	}, media.Formats[0])
+
+	md := media.Marshal()
+	require.Contains(t, md.Attributes, psdp.Attribute{Key: "fmtp", Value: "63 111/111"})
+}
+
+func TestMediaPTimeAttributeLPCM(t *testing.T) {
+	var sd sdp.SessionDescription
+	err := sd.Unmarshal([]byte("v=0\r\n" +
+		"s= \r\n" +
+		"m=audio 0 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 L24/48000/8\r\n" +
+		"a=ptime:0.125\r\n" +
+		"a=maxptime:1\r\n"))
+	require.NoError(t, err)
+
+	var media Media
+	err = media.Unmarshal(sd.MediaDescriptions[0])
+	require.NoError(t, err)
+	require.Equal(t, []format.Format{
+		&format.LPCM{
+			PayloadTyp:   96,
+			BitDepth:     24,
+			SampleRate:   48000,
+			ChannelCount: 8,
+			PTime:        125 * time.Microsecond,
+			MaxPTime:     1 * time.Millisecond,
+		},
+	}, media.Formats)
+}
+
+func TestMediaPTimeAttributeInvalid(t *testing.T) {
+	for _, ca := range []string{
+		"ptime:abc",
+		"maxptime:-1",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var sd sdp.SessionDescription
+			err := sd.Unmarshal([]byte("v=0\r\n" +
+				"s= \r\n" +
+				"m=audio 0 RTP/AVP 0\r\n" +
+				"a=" + ca + "\r\n"))
+			require.NoError(t, err)
+
+			var media Media
+			err = media.Unmarshal(sd.MediaDescriptions[0])
+			require.NoError(t, err)
+			require.Equal(t, time.Duration(0), media.PTime)
+			require.Equal(t, time.Duration(0), media.MaxPTime)
+		})
+	}
+}
+
+func TestMediaCryptoAttribute(t *testing.T) {
+	var sd sdp.SessionDescription
+	err := sd.Unmarshal([]byte("v=0\r\n" +
+		"s= \r\n" +
+		"m=video 0 RTP/SAVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:WVNfX19zZW1jdGwgKCkgewkyMjA7fQp9CnVubGVz|2^20|1:4;" +
+		"inline:QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVphYmNk|2^20|2:4 KDR=1 UNENCRYPTED_SRTCP\r\n" +
+		"a=crypto:2 AES_CM_128_HMAC_SHA1_32 inline:NzB4d1BINUAvLEw6UzF3WSJ+PSdFcGdUJShpX1Zj|1000\r\n"))
+	require.NoError(t, err)
+
+	var media Media
+	err = media.Unmarshal(sd.MediaDescriptions[0])
+	require.NoError(t, err)
+	require.Equal(t, []Crypto{
+		{
+			Tag:   1,
+			Suite: "AES_CM_128_HMAC_SHA1_80",
+			Keys: []CryptoKey{
+				{
+					Key:       []byte("YS___semctl () {\t220;}\n}\nunles"),
+					Lifetime:  1 << 20,
+					MKI:       1,
+					MKILength: 4,
+				},
+				{
+					Key:       []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcd"),
+					Lifetime:  1 << 20,
+					MKI:       2,
+					MKILength: 4,
+				},
+			},
+			SessionParams: []string{"KDR=1", "UNENCRYPTED_SRTCP"},
+		},
+		{
+			Tag:   2,
+			Suite: "AES_CM_128_HMAC_SHA1_32",
+			Keys: []CryptoKey{
+				{
+					Key:      []byte("70xwPH5@/,L:S1wY\"~='EpgT%(i_Vc"),
+					Lifetime: 1000,
+				},
+			},
+		},
+	}, media.Crypto)
+
+	md := media.Marshal()
+	require.Equal(t, []string{"RTP", "SAVP"}, md.MediaName.Protos)
+	require.Contains(t, md.Attributes, psdp.Attribute{
+		Key: "crypto",
+		Value: "1 AES_CM_128_HMAC_SHA1_80 inline:WVNfX19zZW1jdGwgKCkgewkyMjA7fQp9CnVubGVz|2^20|1:4;" +
+			"inline:QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVphYmNk|2^20|2:4 KDR=1 UNENCRYPTED_SRTCP",
+	})
+	require.Contains(t, md.Attributes, psdp.Attribute{
+		Key:   "crypto",
+		Value: "2 AES_CM_128_HMAC_SHA1_32 inline:NzB4d1BINUAvLEw6UzF3WSJ+PSdFcGdUJShpX1Zj|1000",
+	})
+}
+
+func TestMediaCryptoAttributeInvalid(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		v    string
+		err  string
+	}{
+		{
+			"missing key",
+			"1 AES_CM_128_HMAC_SHA1_80",
+			"invalid crypto attribute: 1 AES_CM_128_HMAC_SHA1_80",
+		},
+		{
+			"invalid tag",
+			"a AES_CM_128_HMAC_SHA1_80 inline:QUJD",
+			"invalid crypto attribute: a AES_CM_128_HMAC_SHA1_80 inline:QUJD",
+		},
+		{
+			"unsupported key method",
+			"1 AES_CM_128_HMAC_SHA1_80 uri:http://example.com",
+			"invalid crypto attribute: unsupported key method: uri:http://example.com",
+		},
+		{
+			"invalid lifetime",
+			"1 AES_CM_128_HMAC_SHA1_80 inline:QUJD|2^a",
+			"invalid crypto attribute: invalid lifetime: 2^a",
+		},
+		{
+			"invalid MKI length",
+			"1 AES_CM_128_HMAC_SHA1_80 inline:QUJD|1:0",
+			"invalid crypto attribute: invalid MKI length: 1:0",
+		},
+		{
+			"multiple keys without MKI",
+			"1 AES_CM_128_HMAC_SHA1_80 inline:QUJD;inline:QUJD",
+			"invalid crypto attribute: multiple keys require MKI",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var sd sdp.SessionDescription
+			err := sd.Unmarshal([]byte("v=0\r\n" +
+				"s= \r\n" +
+				"m=video 0 RTP/SAVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=crypto:" + ca.v + "\r\n" +
+				"a=crypto:2 AES_CM_128_HMAC_SHA1_32 inline:NzB4d1BINUAvLEw6UzF3WSJ+PSdFcGdUJShpX1Zj|1000\r\n"))
+			require.NoError(t, err)
+
+			var c Crypto
+			err = c.Unmarshal(ca.v)
+			require.EqualError(t, err, ca.err)
+
+			var media Media
+			err = media.Unmarshal(sd.MediaDescriptions[0])
+			require.NoError(t, err)
+			require.Equal(t, 1, len(media.Crypto))
+			require.Equal(t, uint32(2), media.Crypto[0].Tag)
+		})
+	}
+}
+
+func TestMediaRTCPAttributeInvalid(t *testing.T) {
+	for _, ca := range []string{
+		"abc",
+		"0",
+		"53020 IN",
+		"53020 XX IP4 224.2.1.4",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var sd sdp.SessionDescription
+			err := sd.Unmarshal([]byte("v=0\r\n" +
+				"s= \r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp:" + ca + "\r\n"))
+			require.NoError(t, err)
+
+			var media Media
+			err = media.Unmarshal(sd.MediaDescriptions[0])
+			require.NoError(t, err)
+			require.Equal(t, 0, media.RTCPPort)
+			require.Equal(t, "", media.RTCPAddress)
+		})
+	}
+}
+
+func TestMediaHeaderExtensions(t *testing.T) {
+	var sd sdp.SessionDescription
+	err := sd.Unmarshal([]byte("v=0\r\n" +
+		"s= \r\n" +
+		"m=video 0 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=extmap:2 http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time\r\n" +
+		"a=extmap:5/recvonly http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01\r\n" +
+		"a=extmap:16/sendonly urn:ietf:params:rtp-hdrext:sdes:mid\r\n" +
+		"a=extmap:3 urn:ietf:params:rtp-hdrext:ssrc-audio-level vad=on\r\n"))
+	require.NoError(t, err)
+
+	var media Media
+	err = media.Unmarshal(sd.MediaDescriptions[0])
+	require.NoError(t, err)
+	require.Equal(t, []HeaderExtension{
+		{
+			ID:  2,
+			URI: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time",
+		},
+		{
+			ID:        5,
+			Direction: "recvonly",
+			URI:       "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01",
+		},
+		{
+			ID:        16,
+			Direction: "sendonly",
+			URI:       "urn:ietf:params:rtp-hdrext:sdes:mid",
+		},
+		{
+			ID:         3,
+			URI:        "urn:ietf:params:rtp-hdrext:ssrc-audio-level",
+			Attributes: "vad=on",
+		},
+	}, media.HeaderExtensions)
+
+	md := media.Marshal()
+	require.Contains(t, md.Attributes, psdp.Attribute{
+		Key:   "extmap",
+		Value: "16/sendonly urn:ietf:params:rtp-hdrext:sdes:mid",
+	})
+	require.Contains(t, md.Attributes, psdp.Attribute{
+		Key:   "extmap",
+		Value: "3 urn:ietf:params:rtp-hdrext:ssrc-audio-level vad=on",
+	})
+}
+
+func TestMediaHeaderExtensionsInvalid(t *testing.T) {
+	for _, ca := range []string{
+		"abc urn:ietf:params:rtp-hdrext:toffset",
+		"0 urn:ietf:params:rtp-hdrext:toffset",
+		"256 urn:ietf:params:rtp-hdrext:toffset",
+		"1/abc urn:ietf:params:rtp-hdrext:toffset",
+		"1",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var sd sdp.SessionDescription
+			err := sd.Unmarshal([]byte("v=0\r\n" +
+				"s= \r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=extmap:" + ca + "\r\n" +
+				"a=extmap:2 http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time\r\n"))
+			require.NoError(t, err)
+
+			var media Media
+			err = media.Unmarshal(sd.MediaDescriptions[0])
+			require.NoError(t, err)
+			require.Equal(t, []HeaderExtension{{
+				ID:  2,
+				URI: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time",
+			}}, media.HeaderExtensions)
+		})
+	}
+}
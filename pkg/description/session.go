@@ -7,6 +7,7 @@ import (
 	psdp "github.com/pion/sdp/v3"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 )
 
@@ -37,6 +38,15 @@ func hasMediaWithID(medias []*Media, id string) bool {
 	return false
 }
 
+func isRTPMedia(md *psdp.MediaDescription) bool {
+	for _, proto := range md.MediaName.Protos {
+		if proto == "RTP" {
+			return true
+		}
+	}
+	return false
+}
+
 // SessionFECGroup is a FEC group.
 type SessionFECGroup []string
 
@@ -53,13 +63,31 @@ type Session struct {
 
 	// Media streams.
 	Medias []*Media
+
+	// Media descriptions that do not carry RTP (i.e. WebRTC data channels).
+	// They are not parsed, and are marshaled as they are after Medias.
+	OpaqueMedias []*psdp.MediaDescription
+
+	// SDP from which the description has been decoded (read only).
+	// It contains all lines and attributes, including the ones that are not supported.
+	// It is filled by Client.Describe().
+	SDP *sdp.SessionDescription
 }
 
 // FindFormat finds a certain format among all the formats in all the medias of the stream.
 // If the format is found, it is inserted into forma, and its media is returned.
 func (d *Session) FindFormat(forma interface{}) *Media {
+	return d.FindFormatFunc(forma, nil)
+}
+
+// FindFormatFunc finds a certain format among all the formats in all the medias of the stream.
+// Formats of the requested type are passed to accept, and the first accepted one is inserted into forma.
+// The media that contains the format is returned, and can be passed to Client.Setup().
+// Packets of formats without a callback are discarded by the client, therefore
+// other formats of the media can be safely ignored.
+func (d *Session) FindFormatFunc(forma interface{}, accept func(format.Format) bool) *Media {
 	for _, media := range d.Medias {
-		ok := media.FindFormat(forma)
+		ok := media.FindFormatFunc(forma, accept)
 		if ok {
 			return media
 		}
@@ -69,38 +97,83 @@ func (d *Session) FindFormat(forma interface{}) *Media {
 
 // Unmarshal decodes the description from SDP.
 func (d *Session) Unmarshal(ssd *sdp.SessionDescription) error {
+	return d.unmarshal(ssd, nil)
+}
+
+// UnmarshalLenient decodes the description from SDP, tolerating common violations of the specification:
+// invalid medias and formats are skipped, duplicate payload types are ignored,
+// non-integer clock rates are rounded, format attributes placed before medias are used,
+// invalid media IDs and FEC groups are ignored.
+// It returns a warning for each value that has been corrected or skipped.
+func (d *Session) UnmarshalLenient(ssd *sdp.SessionDescription) []error {
+	var warnings []error
+	d.unmarshal(ssd, func(err error) { //nolint:errcheck
+		warnings = append(warnings, err)
+	})
+	return warnings
+}
+
+func (d *Session) unmarshal(ssd *sdp.SessionDescription, onWarning func(error)) error {
 	d.Title = string(ssd.SessionName)
 	if d.Title == " " {
 		d.Title = ""
 	}
 
-	d.Medias = make([]*Media, len(ssd.MediaDescriptions))
+	d.Medias = nil
+	d.OpaqueMedias = nil
+	d.FECGroups = nil
 
 	for i, md := range ssd.MediaDescriptions {
+		if !isRTPMedia(md) {
+			d.OpaqueMedias = append(d.OpaqueMedias, md)
+			continue
+		}
+
 		var m Media
-		err := m.Unmarshal(md)
+		err := m.unmarshal(md, ssd.Attributes, onWarning)
 		if err != nil {
-			return fmt.Errorf("media %d is invalid: %v", i+1, err)
+			err = fmt.Errorf("media %d is invalid: %v", i+1, err)
+			if onWarning == nil {
+				return err
+			}
+			onWarning(fmt.Errorf("media skipped: %w", err))
+			continue
 		}
 
-		if m.ID != "" && hasMediaWithID(d.Medias[:i], m.ID) {
-			return fmt.Errorf("duplicate media IDs")
+		if m.ID != "" && hasMediaWithID(d.Medias, m.ID) {
+			if onWarning == nil {
+				return fmt.Errorf("duplicate media IDs")
+			}
+			onWarning(fmt.Errorf("duplicate media ID ignored: %v", m.ID))
+			m.ID = ""
 		}
 
-		d.Medias[i] = &m
+		d.Medias = append(d.Medias, &m)
 	}
 
 	if atLeastOneHasMID(d.Medias) && atLeastOneDoesntHaveMID(d.Medias) {
-		return fmt.Errorf("media IDs sent partially")
+		if onWarning == nil {
+			return fmt.Errorf("media IDs sent partially")
+		}
+		onWarning(fmt.Errorf("media IDs sent partially, ignoring them"))
+		for _, media := range d.Medias {
+			media.ID = ""
+		}
 	}
 
+outer:
 	for _, attr := range ssd.Attributes {
 		if attr.Key == "group" && strings.HasPrefix(attr.Value, "FEC ") {
 			group := SessionFECGroup(strings.Split(attr.Value[len("FEC "):], " "))
 
 			for _, id := range group {
 				if !hasMediaWithID(d.Medias, id) {
-					return fmt.Errorf("FEC group points to an invalid media ID: %v", id)
+					err := fmt.Errorf("FEC group points to an invalid media ID: %v", id)
+					if onWarning == nil {
+						return err
+					}
+					onWarning(fmt.Errorf("FEC group skipped: %w", err))
+					continue outer
 				}
 			}
 
@@ -113,6 +186,12 @@ func (d *Session) Unmarshal(ssd *sdp.SessionDescription) error {
 
 // Marshal encodes the description in SDP.
 func (d Session) Marshal(multicast bool) ([]byte, error) {
+	return d.MarshalSDP(multicast).Marshal()
+}
+
+// MarshalSDP encodes the description into a SDP structure,
+// that can be modified before being marshaled.
+func (d Session) MarshalSDP(multicast bool) *sdp.SessionDescription {
 	var sessionName psdp.SessionName
 	if d.Title != "" {
 		sessionName = psdp.SessionName(d.Title)
@@ -153,6 +232,8 @@ func (d Session) Marshal(multicast bool) ([]byte, error) {
 		sout.MediaDescriptions[i] = media.Marshal()
 	}
 
+	sout.MediaDescriptions = append(sout.MediaDescriptions, d.OpaqueMedias...)
+
 	for _, group := range d.FECGroups {
 		sout.Attributes = append(sout.Attributes, psdp.Attribute{
 			Key:   "group",
@@ -160,5 +241,5 @@ func (d Session) Marshal(multicast bool) ([]byte, error) {
 		})
 	}
 
-	return sout.Marshal()
+	return sout
 }
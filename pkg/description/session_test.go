@@ -3,12 +3,17 @@ package description
 import (
 	"testing"
 
+	psdp "github.com/pion/sdp/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 )
 
+func boolPtr(v bool) *bool {
+	return &v
+}
+
 var casesSession = []struct {
 	name string
 	in   string
@@ -263,8 +268,12 @@ var casesSession = []struct {
 			"a=mid:audio\r\n" +
 			"a=sendonly\r\n" +
 			"a=control\r\n" +
+			"a=rtcp:9 IN IP4 0.0.0.0\r\n" +
+			"a=extmap:1 urn:ietf:params:rtp-hdrext:ssrc-audio-level\r\n" +
+			"a=extmap:2 http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time\r\n" +
+			"a=extmap:3 http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01\r\n" +
 			"a=rtpmap:111 opus/48000/2\r\n" +
-			"a=fmtp:111 sprop-stereo=0\r\n" +
+			"a=fmtp:111 sprop-stereo=0; useinbandfec=1\r\n" +
 			"a=rtpmap:103 ISAC/16000\r\n" +
 			"a=rtpmap:104 ISAC/32000\r\n" +
 			"a=rtpmap:9 G722/8000\r\n" +
@@ -282,6 +291,15 @@ var casesSession = []struct {
 			"a=mid:video\r\n" +
 			"a=sendonly\r\n" +
 			"a=control\r\n" +
+			"a=rtcp:9 IN IP4 0.0.0.0\r\n" +
+			"a=extmap:14 urn:ietf:params:rtp-hdrext:toffset\r\n" +
+			"a=extmap:2 http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time\r\n" +
+			"a=extmap:13 urn:3gpp:video-orientation\r\n" +
+			"a=extmap:3 http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01\r\n" +
+			"a=extmap:5 http://www.webrtc.org/experiments/rtp-hdrext/playout-delay\r\n" +
+			"a=extmap:6 http://www.webrtc.org/experiments/rtp-hdrext/video-content-type\r\n" +
+			"a=extmap:7 http://www.webrtc.org/experiments/rtp-hdrext/video-timing\r\n" +
+			"a=extmap:8 http://www.webrtc.org/experiments/rtp-hdrext/color-space\r\n" +
 			"a=rtpmap:96 VP8/90000\r\n" +
 			"a=rtpmap:97 rtx/90000\r\n" +
 			"a=fmtp:97 apt=96\r\n" +
@@ -302,10 +320,18 @@ var casesSession = []struct {
 					ID:            "audio",
 					Type:          MediaTypeAudio,
 					IsBackChannel: true,
+					RTCPPort:      9,
+					RTCPAddress:   "0.0.0.0",
+					HeaderExtensions: []HeaderExtension{
+						{ID: 1, URI: "urn:ietf:params:rtp-hdrext:ssrc-audio-level"},
+						{ID: 2, URI: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"},
+						{ID: 3, URI: "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"},
+					},
 					Formats: []format.Format{
 						&format.Opus{
-							PayloadTyp: 111,
-							IsStereo:   false,
+							PayloadTyp:   111,
+							IsStereo:     false,
+							UseInbandFEC: boolPtr(true),
 						},
 						&format.Generic{
 							PayloadTyp: 103,
@@ -370,53 +396,53 @@ var casesSession = []struct {
 					ID:            "video",
 					Type:          MediaTypeVideo,
 					IsBackChannel: true,
+					RTCPPort:      9,
+					RTCPAddress:   "0.0.0.0",
+					HeaderExtensions: []HeaderExtension{
+						{ID: 14, URI: "urn:ietf:params:rtp-hdrext:toffset"},
+						{ID: 2, URI: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"},
+						{ID: 13, URI: "urn:3gpp:video-orientation"},
+						{ID: 3, URI: "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"},
+						{ID: 5, URI: "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"},
+						{ID: 6, URI: "http://www.webrtc.org/experiments/rtp-hdrext/video-content-type"},
+						{ID: 7, URI: "http://www.webrtc.org/experiments/rtp-hdrext/video-timing"},
+						{ID: 8, URI: "http://www.webrtc.org/experiments/rtp-hdrext/color-space"},
+					},
 					Formats: []format.Format{
 						&format.VP8{
 							PayloadTyp: 96,
 						},
-						&format.Generic{
+						&format.RTX{
 							PayloadTyp: 97,
-							RTPMa:      "rtx/90000",
-							FMT: map[string]string{
-								"apt": "96",
-							},
-							ClockRat: 90000,
+							ClockRat:   90000,
+							APT:        96,
 						},
 						&format.VP9{
 							PayloadTyp: 98,
 						},
-						&format.Generic{
+						&format.RTX{
 							PayloadTyp: 99,
-							RTPMa:      "rtx/90000",
-							FMT: map[string]string{
-								"apt": "98",
-							},
-							ClockRat: 90000,
+							ClockRat:   90000,
+							APT:        98,
 						},
 						&format.H264{
 							PayloadTyp:        100,
 							PacketizationMode: 1,
 						},
-						&format.Generic{
+						&format.RTX{
 							PayloadTyp: 101,
-							RTPMa:      "rtx/90000",
-							FMT: map[string]string{
-								"apt": "100",
-							},
-							ClockRat: 90000,
+							ClockRat:   90000,
+							APT:        100,
 						},
 						&format.Generic{
 							PayloadTyp: 127,
 							RTPMa:      "red/90000",
 							ClockRat:   90000,
 						},
-						&format.Generic{
+						&format.RTX{
 							PayloadTyp: 124,
-							RTPMa:      "rtx/90000",
-							FMT: map[string]string{
-								"apt": "127",
-							},
-							ClockRat: 90000,
+							ClockRat:   90000,
+							APT:        127,
 						},
 						&format.Generic{
 							PayloadTyp: 125,
@@ -704,6 +730,63 @@ var casesSession = []struct {
 			},
 		},
 	},
+	{
+		"webrtc data channel",
+		"v=0\r\n" +
+			"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+			"s= \r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"t=0 0\r\n" +
+			"m=video 0 RTP/AVP 96\r\n" +
+			"a=control\r\n" +
+			"a=rtpmap:96 VP8/90000\r\n" +
+			"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"a=mid:1\r\n" +
+			"a=sctp-port:5000\r\n" +
+			"a=max-message-size:262144\r\n",
+		"v=0\r\n" +
+			"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+			"s= \r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"t=0 0\r\n" +
+			"m=video 0 RTP/AVP 96\r\n" +
+			"a=control\r\n" +
+			"a=rtpmap:96 VP8/90000\r\n" +
+			"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"a=mid:1\r\n" +
+			"a=sctp-port:5000\r\n" +
+			"a=max-message-size:262144\r\n",
+		Session{
+			Medias: []*Media{
+				{
+					Type:    MediaTypeVideo,
+					Formats: []format.Format{&format.VP8{PayloadTyp: 96}},
+				},
+			},
+			OpaqueMedias: []*psdp.MediaDescription{
+				{
+					MediaName: psdp.MediaName{
+						Media:   "application",
+						Port:    psdp.RangedPort{Value: 9},
+						Protos:  []string{"UDP", "DTLS", "SCTP"},
+						Formats: []string{"webrtc-datachannel"},
+					},
+					ConnectionInformation: &psdp.ConnectionInformation{
+						NetworkType: "IN",
+						AddressType: "IP4",
+						Address:     &psdp.Address{Address: "0.0.0.0"},
+					},
+					Attributes: []psdp.Attribute{
+						{Key: "mid", Value: "1"},
+						{Key: "sctp-port", Value: "5000"},
+						{Key: "max-message-size", Value: "262144"},
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestSessionUnmarshal(t *testing.T) {
@@ -775,6 +858,105 @@ func TestSessionFindFormat(t *testing.T) {
 	require.Equal(t, tr, forma)
 }
 
+func TestSessionFindFormatFunc(t *testing.T) {
+	md1 := &Media{
+		Type: MediaTypeVideo,
+		Formats: []format.Format{
+			&format.H264{
+				PayloadTyp:        96,
+				PacketizationMode: 1,
+			},
+		},
+	}
+
+	second := &format.H264{
+		PayloadTyp:        97,
+		PacketizationMode: 1,
+	}
+
+	md2 := &Media{
+		Type: MediaTypeVideo,
+		Formats: []format.Format{
+			&format.H264{
+				PayloadTyp:        98,
+				PacketizationMode: 1,
+			},
+			second,
+		},
+	}
+
+	desc := &Session{
+		Medias: []*Media{md1, md2},
+	}
+
+	var forma *format.H264
+	me := desc.FindFormatFunc(&forma, func(f format.Format) bool {
+		return f.PayloadType() == 97
+	})
+	require.Equal(t, md2, me)
+	require.Equal(t, second, forma)
+
+	forma = nil
+	me = desc.FindFormatFunc(&forma, func(f format.Format) bool {
+		return f.PayloadType() == 100
+	})
+	require.Nil(t, me)
+	require.Nil(t, forma)
+}
+
+func TestSessionUnmarshalLenient(t *testing.T) {
+	byts := []byte("v=0\r" +
+		"o=- 0 0 IN IP4 127.0.0.1\r" +
+		"s=Stream\r" +
+		"a=rtpmap:96 H264/90000\r" +
+		"a=fmtp:96 packetization-mode=1\r" +
+		"m=video 70000 RTP/AVP 96 96\r" +
+		"a=control:trackID=1\r" +
+		"m=audio 0 RTP/AVP 97\r" +
+		"a=rtpmap:97 opus/48000.0/2\r" +
+		"a=control:trackID=2\r" +
+		"m=audio 0 RTP/AVP 98\r" +
+		"a=rtpmap:98 MPEG4-GENERIC/48000/2\r" +
+		"a=fmtp:98 config=zz\r" +
+		"a=control:trackID=3\r")
+
+	var sd sdp.SessionDescription
+	warnings := sd.UnmarshalLenient(byts)
+	require.Len(t, warnings, 1)
+
+	var desc Session
+	err := desc.Unmarshal(&sd)
+	require.Error(t, err)
+
+	desc = Session{}
+	warnings = desc.UnmarshalLenient(&sd)
+	require.Len(t, warnings, 4)
+	require.EqualError(t, warnings[0], "duplicate payload type skipped: 96")
+	require.EqualError(t, warnings[1], "non-integer clock rate rounded: opus/48000.0/2")
+	require.EqualError(t, warnings[3], "media skipped: media 3 is invalid: no formats found")
+
+	require.Equal(t, Session{
+		Title: "Stream",
+		Medias: []*Media{
+			{
+				Type:    MediaTypeVideo,
+				Control: "trackID=1",
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					PacketizationMode: 1,
+				}},
+			},
+			{
+				Type:    MediaTypeAudio,
+				Control: "trackID=2",
+				Formats: []format.Format{&format.Opus{
+					PayloadTyp: 97,
+				}},
+			},
+		},
+	}, desc)
+}
+
 func FuzzSessionUnmarshalErrors(f *testing.F) {
 	f.Add("v=0\r\n" +
 		"o=jdoe 2890844526 2890842807 IN IP4 10.47.16.5\r\n" +
@@ -838,5 +1020,8 @@ func FuzzSessionUnmarshalErrors(f *testing.F) {
 
 		var desc Session
 		desc.Unmarshal(&sd) //nolint:errcheck
+
+		desc = Session{}
+		desc.UnmarshalLenient(&sd)
 	})
 }
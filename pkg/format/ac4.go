@@ -0,0 +1,106 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpac4"
+)
+
+// AC4 is a RTP format for the AC-4 codec.
+// Specification: ETSI TS 103 190-2
+type AC4 struct {
+	// payload type of packets.
+	PayloadTyp uint8
+
+	// sample rate.
+	SampleRate int
+
+	// number of channels (optional).
+	ChannelCount int
+}
+
+func (f *AC4) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	tmp := strings.SplitN(ctx.clock, "/", 2)
+
+	sampleRate, err := strconv.ParseUint(tmp[0], 10, 31)
+	if err != nil || sampleRate == 0 {
+		return fmt.Errorf("invalid sample rate: %v", tmp[0])
+	}
+	f.SampleRate = int(sampleRate)
+
+	if len(tmp) >= 2 {
+		channelCount, err := strconv.ParseUint(tmp[1], 10, 31)
+		if err != nil || channelCount == 0 {
+			return fmt.Errorf("invalid channel count: %v", tmp[1])
+		}
+		f.ChannelCount = int(channelCount)
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *AC4) Codec() string {
+	return "AC-4"
+}
+
+// ClockRate implements Format.
+func (f *AC4) ClockRate() int {
+	return f.SampleRate
+}
+
+// PayloadType implements Format.
+func (f *AC4) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *AC4) RTPMap() string {
+	ret := "AC4/" + strconv.FormatInt(int64(f.SampleRate), 10)
+	if f.ChannelCount != 0 {
+		ret += "/" + strconv.FormatInt(int64(f.ChannelCount), 10)
+	}
+	return ret
+}
+
+// FMTP implements Format.
+func (f *AC4) FMTP() map[string]string {
+	return nil
+}
+
+// PTSEqualsDTS implements Format.
+func (f *AC4) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *AC4) CreateDecoder() (*rtpac4.Decoder, error) {
+	d := &rtpac4.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *AC4) CreateEncoder() (*rtpac4.Encoder, error) {
+	e := &rtpac4.Encoder{
+		PayloadType: f.PayloadTyp,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,41 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAC4Attributes(t *testing.T) {
+	format := &AC4{
+		PayloadTyp:   96,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	}
+	require.Equal(t, "AC-4", format.Codec())
+	require.Equal(t, 48000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestAC4DecEncoder(t *testing.T) {
+	format := &AC4{
+		PayloadTyp:   96,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([]byte{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
+}
@@ -0,0 +1,173 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpamr"
+)
+
+// AMR is a RTP format for the AMR and AMR-WB codecs.
+// Specification: https://datatracker.ietf.org/doc/html/rfc4867
+type AMR struct {
+	// payload type of packets.
+	PayloadTyp uint8
+
+	// whether to use AMR-WB instead of AMR.
+	Wideband bool
+
+	// number of channels.
+	ChannelCount int
+
+	// whether the octet-aligned mode is in use.
+	// Otherwise, the bandwidth-efficient mode is used.
+	OctetAlign bool
+
+	// codec modes that can be used (optional).
+	// If empty, all modes can be used.
+	ModeSet []int
+}
+
+func (f *AMR) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+	f.Wideband = (ctx.codec == "amr-wb")
+
+	tmp := strings.SplitN(ctx.clock, "/", 2)
+
+	sampleRate, err := strconv.ParseUint(tmp[0], 10, 31)
+	if err != nil || int(sampleRate) != f.ClockRate() {
+		return fmt.Errorf("invalid sample rate: %v", tmp[0])
+	}
+
+	if len(tmp) >= 2 {
+		channelCount, err := strconv.ParseUint(tmp[1], 10, 31)
+		if err != nil || channelCount == 0 {
+			return fmt.Errorf("invalid channel count: %v", tmp[1])
+		}
+		f.ChannelCount = int(channelCount)
+	} else {
+		// RFC4867: If omitted, it has a default value of 1.
+		f.ChannelCount = 1
+	}
+
+	maxMode := uint64(7)
+	if f.Wideband {
+		maxMode = 8
+	}
+
+	for key, val := range ctx.fmtp {
+		switch key {
+		case "octet-align":
+			f.OctetAlign = (val == "1")
+
+		case "mode-set":
+			for _, entry := range strings.Split(val, ",") {
+				mode, err := strconv.ParseUint(strings.TrimSpace(entry), 10, 31)
+				if err != nil || mode > maxMode {
+					return fmt.Errorf("invalid mode-set: %v", val)
+				}
+				f.ModeSet = append(f.ModeSet, int(mode))
+			}
+
+		case "crc", "robust-sorting":
+			if val == "1" {
+				return fmt.Errorf("%s is not supported", key)
+			}
+
+		case "interleaving":
+			return fmt.Errorf("interleaving is not supported")
+		}
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *AMR) Codec() string {
+	if f.Wideband {
+		return "AMR-WB"
+	}
+	return "AMR"
+}
+
+// ClockRate implements Format.
+func (f *AMR) ClockRate() int {
+	if f.Wideband {
+		return 16000
+	}
+	return 8000
+}
+
+// PayloadType implements Format.
+func (f *AMR) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *AMR) RTPMap() string {
+	return f.Codec() + "/" + strconv.FormatInt(int64(f.ClockRate()), 10) +
+		"/" + strconv.FormatInt(int64(f.ChannelCount), 10)
+}
+
+// FMTP implements Format.
+func (f *AMR) FMTP() map[string]string {
+	fmtp := make(map[string]string)
+
+	if f.OctetAlign {
+		fmtp["octet-align"] = "1"
+	}
+
+	if len(f.ModeSet) != 0 {
+		tmp := make([]string, len(f.ModeSet))
+		for i, mode := range f.ModeSet {
+			tmp[i] = strconv.FormatInt(int64(mode), 10)
+		}
+		fmtp["mode-set"] = strings.Join(tmp, ",")
+	}
+
+	if len(fmtp) == 0 {
+		return nil
+	}
+
+	return fmtp
+}
+
+// PTSEqualsDTS implements Format.
+func (f *AMR) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *AMR) CreateDecoder() (*rtpamr.Decoder, error) {
+	d := &rtpamr.Decoder{
+		Wideband:   f.Wideband,
+		OctetAlign: f.OctetAlign,
+	}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *AMR) CreateEncoder() (*rtpamr.Encoder, error) {
+	e := &rtpamr.Encoder{
+		PayloadType:  f.PayloadTyp,
+		Wideband:     f.Wideband,
+		OctetAlign:   f.OctetAlign,
+		ChannelCount: f.ChannelCount,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
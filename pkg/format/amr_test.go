@@ -0,0 +1,56 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAMRAttributes(t *testing.T) {
+	format := &AMR{
+		PayloadTyp:   96,
+		ChannelCount: 1,
+	}
+	require.Equal(t, "AMR", format.Codec())
+	require.Equal(t, 8000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+
+	format = &AMR{
+		PayloadTyp:   96,
+		Wideband:     true,
+		ChannelCount: 1,
+	}
+	require.Equal(t, "AMR-WB", format.Codec())
+	require.Equal(t, 16000, format.ClockRate())
+}
+
+func TestAMRDecEncoder(t *testing.T) {
+	format := &AMR{
+		PayloadTyp:   96,
+		ChannelCount: 1,
+		OctetAlign:   true,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([][]byte{{
+		0x24, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x10, 0x11, 0x12, 0x80,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{
+		0x24, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x10, 0x11, 0x12, 0x80,
+	}}, byts)
+}
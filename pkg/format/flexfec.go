@@ -0,0 +1,79 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pion/rtp"
+)
+
+// FlexFEC is a RTP format for Flexible Forward Error Correction packets,
+// that allow to recover lost packets of other formats of the same media.
+// Specification: https://datatracker.ietf.org/doc/html/rfc8627
+type FlexFEC struct {
+	PayloadTyp uint8
+	ClockRat   int
+	// time window, in microseconds, that spans protected packets and FEC packets.
+	RepairWindow int
+}
+
+func (f *FlexFEC) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	clockRate, err := strconv.ParseUint(ctx.clock, 10, 31)
+	if err != nil {
+		return fmt.Errorf("invalid clock rate (%v)", ctx.clock)
+	}
+	f.ClockRat = int(clockRate)
+
+	repairWindowFound := false
+
+	for key, val := range ctx.fmtp {
+		if key == "repair-window" {
+			tmp, err := strconv.ParseUint(val, 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid repair-window (%v)", val)
+			}
+			f.RepairWindow = int(tmp)
+			repairWindowFound = true
+		}
+	}
+
+	if !repairWindowFound {
+		return fmt.Errorf("repair-window is missing")
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *FlexFEC) Codec() string {
+	return "FlexFEC"
+}
+
+// ClockRate implements Format.
+func (f *FlexFEC) ClockRate() int {
+	return f.ClockRat
+}
+
+// PayloadType implements Format.
+func (f *FlexFEC) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *FlexFEC) RTPMap() string {
+	return "flexfec/" + strconv.FormatInt(int64(f.ClockRat), 10)
+}
+
+// FMTP implements Format.
+func (f *FlexFEC) FMTP() map[string]string {
+	return map[string]string{
+		"repair-window": strconv.FormatInt(int64(f.RepairWindow), 10),
+	}
+}
+
+// PTSEqualsDTS implements Format.
+func (f *FlexFEC) PTSEqualsDTS(*rtp.Packet) bool {
+	return false
+}
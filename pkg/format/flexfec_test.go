@@ -0,0 +1,19 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlexFECAttributes(t *testing.T) {
+	format := &FlexFEC{
+		PayloadTyp:   98,
+		ClockRat:     90000,
+		RepairWindow: 200000,
+	}
+	require.Equal(t, "FlexFEC", format.Codec())
+	require.Equal(t, 90000, format.ClockRate())
+	require.Equal(t, false, format.PTSEqualsDTS(&rtp.Packet{}))
+}
@@ -31,9 +31,15 @@ type Format interface {
 	unmarshal(ctx *unmarshalContext) error
 
 	// Codec returns the codec name.
+	// It doesn't depend on format parameters, therefore it can be used
+	// to identify codecs without type switches.
 	Codec() string
 
-	// ClockRate returns the clock rate.
+	// ClockRate returns the clock rate of RTP timestamps,
+	// that can be used as timescale of tracks when muxing.
+	// It is positive for all formats except Generic ones whose clock rate is unknown.
+	// It is not always equal to the sample rate: Opus always returns 48000
+	// and G722 returns 8000, although it is sampled at 16000 (RFC3551).
 	ClockRate() int
 
 	// PayloadType returns the payload type.
@@ -66,6 +72,9 @@ func Unmarshal(mediaType string, payloadType uint8, rtpMap string, fmtp map[stri
 		case codec == "vp8" && clock == "90000":
 			return &VP8{}
 
+		case codec == "h266" && clock == "90000":
+			return &H266{}
+
 		case codec == "h265" && clock == "90000":
 			return &H265{}
 
@@ -75,18 +84,27 @@ func Unmarshal(mediaType string, payloadType uint8, rtpMap string, fmtp map[stri
 		case codec == "mp4v-es" && clock == "90000":
 			return &MPEG4Video{}
 
+		case (codec == "h263-1998" || codec == "h263-2000") && clock == "90000":
+			return &H263P{}
+
 		case payloadType == 32:
 			return &MPEG1Video{}
 
+		case codec == "smpte291" && clock == "90000":
+			return &SMPTE291{}
+
 		case payloadType == 26:
 			return &MJPEG{}
 
+		case codec == "jxsv" && clock == "90000":
+			return &JPEGXS{}
+
 		case payloadType == 33:
 			return &MPEGTS{}
 
 		// audio
 
-		case codec == "opus":
+		case codec == "opus", codec == "multiopus":
 			return &Opus{}
 
 		case codec == "vorbis":
@@ -101,9 +119,21 @@ func Unmarshal(mediaType string, payloadType uint8, rtpMap string, fmtp map[stri
 		case codec == "ac3":
 			return &AC3{}
 
+		case codec == "ac4":
+			return &AC4{}
+
+		case codec == "mhas":
+			return &MPEGH3DAudio{}
+
 		case codec == "speex":
 			return &Speex{}
 
+		case codec == "rtp-midi":
+			return &MIDI{}
+
+		case codec == "amr", codec == "amr-wb":
+			return &AMR{}
+
 		case (codec == "g726-16" ||
 			codec == "g726-24" ||
 			codec == "g726-32" ||
@@ -114,6 +144,9 @@ func Unmarshal(mediaType string, payloadType uint8, rtpMap string, fmtp map[stri
 			codec == "aal2-g726-40") && clock == "8000":
 			return &G726{}
 
+		case codec == "g7221":
+			return &G7221{}
+
 		case payloadType == 9:
 			return &G722{}
 
@@ -122,6 +155,26 @@ func Unmarshal(mediaType string, payloadType uint8, rtpMap string, fmtp map[stri
 
 		case codec == "l8", codec == "l16", codec == "l24":
 			return &LPCM{}
+
+		case codec == "rtx" && fmtp["apt"] != "":
+			return &RTX{}
+
+		case codec == "flexfec" && fmtp["repair-window"] != "":
+			return &FlexFEC{}
+
+		case codec == "red" && fmtp[""] != "":
+			return &RED{}
+
+		// application
+
+		case codec == "smpte336m":
+			return &KLV{}
+
+		case (codec == "vnd.onvif.metadata" ||
+			codec == "vnd.onvif.metadata.gzip" ||
+			codec == "vnd.onvif.metadata.exi.onvif" ||
+			codec == "vnd.onvif.metadata.exi.ext") && clock == "90000":
+			return &ONVIFMetadata{}
 		}
 
 		return &Generic{}
@@ -124,6 +124,42 @@ var casesFormat = []struct {
 		"AAL2-G726-32/8000",
 		nil,
 	},
+	{
+		"audio g722.1",
+		"audio",
+		97,
+		"G7221/16000",
+		map[string]string{
+			"bitrate": "24000",
+		},
+		&G7221{
+			PayloadTyp: 97,
+			SampleRate: 16000,
+			BitRate:    24000,
+		},
+		"G7221/16000",
+		map[string]string{
+			"bitrate": "24000",
+		},
+	},
+	{
+		"audio g722.1 annex c",
+		"audio",
+		97,
+		"G7221/32000/1",
+		map[string]string{
+			"bitrate": "48000",
+		},
+		&G7221{
+			PayloadTyp: 97,
+			SampleRate: 32000,
+			BitRate:    48000,
+		},
+		"G7221/32000",
+		map[string]string{
+			"bitrate": "48000",
+		},
+	},
 	{
 		"audio lpcm 8",
 		"audio",
@@ -531,6 +567,19 @@ var casesFormat = []struct {
 			"config":           "400023103fc0",
 		},
 	},
+	{
+		"audio midi",
+		"audio",
+		96,
+		"rtp-midi/44100",
+		nil,
+		&MIDI{
+			PayloadTyp: 96,
+			ClockRat:   44100,
+		},
+		"rtp-midi/44100",
+		nil,
+	},
 	{
 		"audio speex",
 		"audio",
@@ -585,6 +634,59 @@ var casesFormat = []struct {
 			"sprop-stereo": "1",
 		},
 	},
+	{
+		"audio opus fec dtx",
+		"audio",
+		96,
+		"opus/48000/2",
+		map[string]string{
+			"sprop-stereo":      "0",
+			"useinbandfec":      "1",
+			"usedtx":            "1",
+			"maxaveragebitrate": "64000",
+			"maxplaybackrate":   "16000",
+		},
+		&Opus{
+			PayloadTyp:        96,
+			UseInbandFEC:      boolPtr(true),
+			UseDTX:            boolPtr(true),
+			MaxAverageBitrate: intPtr(64000),
+			MaxPlaybackRate:   intPtr(16000),
+		},
+		"opus/48000/2",
+		map[string]string{
+			"sprop-stereo":      "0",
+			"useinbandfec":      "1",
+			"usedtx":            "1",
+			"maxaveragebitrate": "64000",
+			"maxplaybackrate":   "16000",
+		},
+	},
+	{
+		"audio opus multichannel",
+		"audio",
+		96,
+		"multiopus/48000/6",
+		map[string]string{
+			"channel_mapping": "0,4,1,2,3,5",
+			"num_streams":     "4",
+			"coupled_streams": "2",
+		},
+		&Opus{
+			PayloadTyp:     96,
+			ChannelCount:   6,
+			ChannelMapping: []uint8{0, 4, 1, 2, 3, 5},
+			NumStreams:     intPtr(4),
+			CoupledStreams: intPtr(2),
+		},
+		"multiopus/48000/6",
+		map[string]string{
+			"sprop-stereo":    "0",
+			"channel_mapping": "0,4,1,2,3,5",
+			"num_streams":     "4",
+			"coupled_streams": "2",
+		},
+	},
 	{
 		"audio ac3",
 		"audio",
@@ -613,6 +715,78 @@ var casesFormat = []struct {
 		"AC3/48000/6",
 		nil,
 	},
+	{
+		"audio ac4",
+		"audio",
+		96,
+		"AC4/48000/2",
+		nil,
+		&AC4{
+			PayloadTyp:   96,
+			SampleRate:   48000,
+			ChannelCount: 2,
+		},
+		"AC4/48000/2",
+		nil,
+	},
+	{
+		"audio mpeg-h 3d audio",
+		"audio",
+		96,
+		"MHAS/48000",
+		map[string]string{
+			"profile-level-id": "13",
+			"config":           "f00505",
+		},
+		&MPEGH3DAudio{
+			PayloadTyp:     96,
+			SampleRate:     48000,
+			ProfileLevelID: 13,
+			Config:         []byte{0xf0, 0x05, 0x05},
+		},
+		"MHAS/48000",
+		map[string]string{
+			"profile-level-id": "13",
+			"config":           "f00505",
+		},
+	},
+	{
+		"audio amr",
+		"audio",
+		96,
+		"AMR/8000",
+		map[string]string{
+			"octet-align": "1",
+			"mode-set":    "0,2,5,7",
+		},
+		&AMR{
+			PayloadTyp:   96,
+			ChannelCount: 1,
+			OctetAlign:   true,
+			ModeSet:      []int{0, 2, 5, 7},
+		},
+		"AMR/8000/1",
+		map[string]string{
+			"octet-align": "1",
+			"mode-set":    "0,2,5,7",
+		},
+	},
+	{
+		"audio amr-wb",
+		"audio",
+		97,
+		"AMR-WB/16000/2",
+		map[string]string{
+			"mode-change-period": "2",
+		},
+		&AMR{
+			PayloadTyp:   97,
+			Wideband:     true,
+			ChannelCount: 2,
+		},
+		"AMR-WB/16000/2",
+		nil,
+	},
 	{
 		"video jpeg",
 		"video",
@@ -623,6 +797,73 @@ var casesFormat = []struct {
 		"JPEG/90000",
 		nil,
 	},
+	{
+		"video jpeg xs",
+		"video",
+		96,
+		"jxsv/90000",
+		map[string]string{
+			"packetmode":     "1",
+			"transmode":      "0",
+			"profile":        "High444.12",
+			"level":          "2k-1",
+			"sublevel":       "Sublev3bpp",
+			"sampling":       "YCbCr-4:2:2",
+			"depth":          "10",
+			"width":          "1920",
+			"height":         "1080",
+			"exactframerate": "60000/1001",
+			"colorimetry":    "BT709",
+		},
+		&JPEGXS{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+			OutOfOrder:        true,
+			Profile:           "High444.12",
+			Level:             "2k-1",
+			Sublevel:          "Sublev3bpp",
+			Sampling:          "YCbCr-4:2:2",
+			Depth:             10,
+			Width:             1920,
+			Height:            1080,
+			ExactFramerate:    "60000/1001",
+			Colorimetry:       "BT709",
+		},
+		"jxsv/90000",
+		map[string]string{
+			"packetmode":     "1",
+			"transmode":      "0",
+			"profile":        "High444.12",
+			"level":          "2k-1",
+			"sublevel":       "Sublev3bpp",
+			"sampling":       "YCbCr-4:2:2",
+			"depth":          "10",
+			"width":          "1920",
+			"height":         "1080",
+			"exactframerate": "60000/1001",
+			"colorimetry":    "BT709",
+		},
+	},
+	{
+		"video smpte291",
+		"video",
+		96,
+		"smpte291/90000",
+		map[string]string{
+			"DID_SDID":  "{0x61,0x02}",
+			"VPID_Code": "132",
+		},
+		&SMPTE291{
+			PayloadTyp: 96,
+			DIDSDID:    &[2]uint8{0x61, 0x02},
+			VPIDCode:   intPtr(132),
+		},
+		"smpte291/90000",
+		map[string]string{
+			"DID_SDID":  "{0x61,0x02}",
+			"VPID_Code": "132",
+		},
+	},
 	{
 		"video mpeg1 video",
 		"video",
@@ -779,6 +1020,26 @@ var casesFormat = []struct {
 			"packetization-mode": "1",
 		},
 	},
+	{
+		"video h264 interleaved",
+		"video",
+		96,
+		"H264/90000",
+		map[string]string{
+			"packetization-mode":       "2",
+			"sprop-interleaving-depth": "3",
+		},
+		&H264{
+			PayloadTyp:        96,
+			PacketizationMode: 2,
+			InterleavingDepth: 3,
+		},
+		"H264/90000",
+		map[string]string{
+			"packetization-mode":       "2",
+			"sprop-interleaving-depth": "3",
+		},
+	},
 	{
 		"video h264 annexb",
 		"video",
@@ -866,6 +1127,32 @@ var casesFormat = []struct {
 			"sprop-max-don-diff": "2",
 		},
 	},
+	{
+		"video h266",
+		"video",
+		96,
+		"H266/90000",
+		map[string]string{
+			"sprop-vps":          "AHEBAg==",
+			"sprop-sps":          "AHkDBA==",
+			"sprop-pps":          "AIEFBg==",
+			"sprop-max-don-diff": "2",
+		},
+		&H266{
+			PayloadTyp: 96,
+			VPS:        []byte{0x00, 0x71, 0x01, 0x02},
+			SPS:        []byte{0x00, 0x79, 0x03, 0x04},
+			PPS:        []byte{0x00, 0x81, 0x05, 0x06},
+			MaxDONDiff: 2,
+		},
+		"H266/90000",
+		map[string]string{
+			"sprop-vps":          "AHEBAg==",
+			"sprop-sps":          "AHkDBA==",
+			"sprop-pps":          "AIEFBg==",
+			"sprop-max-don-diff": "2",
+		},
+	},
 	{
 		"video h265 annexb",
 		"video",
@@ -900,6 +1187,39 @@ var casesFormat = []struct {
 			"sprop-pps": "RAHgdrAmQA==",
 		},
 	},
+	{
+		"video h263-1998",
+		"video",
+		96,
+		"H263-1998/90000",
+		map[string]string{},
+		&H263P{
+			PayloadTyp: 96,
+		},
+		"H263-1998/90000",
+		map[string]string{},
+	},
+	{
+		"video h263-2000",
+		"video",
+		96,
+		"H263-2000/90000",
+		map[string]string{
+			"profile": "3",
+			"level":   "10",
+		},
+		&H263P{
+			PayloadTyp: 96,
+			Is2000:     true,
+			Profile:    intPtr(3),
+			Level:      intPtr(10),
+		},
+		"H263-2000/90000",
+		map[string]string{
+			"profile": "3",
+			"level":   "10",
+		},
+	},
 	{
 		"video vp8",
 		"video",
@@ -980,6 +1300,44 @@ var casesFormat = []struct {
 		"MetaData/80000",
 		nil,
 	},
+	{
+		"application klv",
+		"application",
+		96,
+		"SMPTE336M/90000",
+		nil,
+		&KLV{
+			PayloadTyp: 96,
+			ClockRat:   90000,
+		},
+		"SMPTE336M/90000",
+		nil,
+	},
+	{
+		"application onvif metadata",
+		"application",
+		107,
+		"vnd.onvif.metadata/90000",
+		nil,
+		&ONVIFMetadata{
+			PayloadTyp: 107,
+		},
+		"vnd.onvif.metadata/90000",
+		nil,
+	},
+	{
+		"application onvif metadata gzip",
+		"application",
+		107,
+		"VND.ONVIF.METADATA.GZIP/90000",
+		nil,
+		&ONVIFMetadata{
+			PayloadTyp: 107,
+			Encoding:   "gzip",
+		},
+		"vnd.onvif.metadata.gzip/90000",
+		nil,
+	},
 	{
 		"application without clock rate",
 		"application",
@@ -1005,6 +1363,78 @@ var casesFormat = []struct {
 		"custom",
 		nil,
 	},
+	{
+		"video rtx",
+		"video",
+		97,
+		"rtx/90000",
+		map[string]string{
+			"apt":      "96",
+			"rtx-time": "3000",
+		},
+		&RTX{
+			PayloadTyp: 97,
+			ClockRat:   90000,
+			APT:        96,
+			RTXTime:    intPtr(3000),
+		},
+		"rtx/90000",
+		map[string]string{
+			"apt":      "96",
+			"rtx-time": "3000",
+		},
+	},
+	{
+		"video rtx without apt",
+		"video",
+		97,
+		"rtx/90000",
+		nil,
+		&Generic{
+			PayloadTyp: 97,
+			RTPMa:      "rtx/90000",
+			ClockRat:   90000,
+		},
+		"rtx/90000",
+		nil,
+	},
+	{
+		"video flexfec",
+		"video",
+		98,
+		"flexfec/90000",
+		map[string]string{
+			"repair-window": "200000",
+		},
+		&FlexFEC{
+			PayloadTyp:   98,
+			ClockRat:     90000,
+			RepairWindow: 200000,
+		},
+		"flexfec/90000",
+		map[string]string{
+			"repair-window": "200000",
+		},
+	},
+	{
+		"audio red",
+		"audio",
+		63,
+		"red/48000/2",
+		map[string]string{
+			"": "111/111",
+		},
+		&RED{
+			PayloadTyp:   63,
+			ClockRat:     48000,
+			ChannelCount: 2,
+			PayloadTypes: []uint8{111, 111},
+		},
+		"red/48000/2",
+		map[string]string{
+			"": "111/111",
+		},
+	},
 }
 
 func TestUnmarshal(t *testing.T) {
@@ -1027,12 +1457,99 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestCodecAndClockRate(t *testing.T) {
+	for _, ca := range casesFormat {
+		t.Run(ca.name, func(t *testing.T) {
+			require.NotEqual(t, "", ca.dec.Codec())
+
+			if _, ok := ca.dec.(*Generic); !ok {
+				require.Greater(t, ca.dec.ClockRate(), 0)
+			}
+		})
+	}
+}
+
 func TestUnmarshalErrors(t *testing.T) {
 	t.Run("invalid video", func(t *testing.T) {
 		_, err := Unmarshal("video", 96, "", map[string]string{})
 		require.Error(t, err)
 	})
 
+	t.Run("amr", func(t *testing.T) {
+		_, err := Unmarshal("audio", 96, "AMR/16000", nil)
+		require.Error(t, err)
+
+		_, err = Unmarshal("audio", 96, "AMR/8000/0", nil)
+		require.Error(t, err)
+
+		_, err = Unmarshal("audio", 96, "AMR/8000", map[string]string{
+			"mode-set": "0,8",
+		})
+		require.Error(t, err)
+
+		_, err = Unmarshal("audio", 96, "AMR/8000", map[string]string{
+			"octet-align": "1",
+			"crc":         "1",
+		})
+		require.Error(t, err)
+
+		_, err = Unmarshal("audio", 96, "AMR/8000", map[string]string{
+			"octet-align":  "1",
+			"interleaving": "4",
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("g722.1", func(t *testing.T) {
+		_, err := Unmarshal("audio", 96, "G7221/8000", map[string]string{
+			"bitrate": "24000",
+		})
+		require.Error(t, err)
+
+		_, err = Unmarshal("audio", 96, "G7221/16000/2", map[string]string{
+			"bitrate": "24000",
+		})
+		require.Error(t, err)
+
+		_, err = Unmarshal("audio", 96, "G7221/16000", nil)
+		require.Error(t, err)
+
+		_, err = Unmarshal("audio", 96, "G7221/16000", map[string]string{
+			"bitrate": "48000",
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("klv", func(t *testing.T) {
+		_, err := Unmarshal("application", 96, "SMPTE336M/aa", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("rtx", func(t *testing.T) {
+		_, err := Unmarshal("video", 97, "rtx/aa", map[string]string{
+			"apt": "96",
+		})
+		require.Error(t, err)
+
+		_, err = Unmarshal("video", 97, "rtx/90000", map[string]string{
+			"apt": "256",
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("h264", func(t *testing.T) {
+		_, err := Unmarshal("video", 96, "H264/90000", map[string]string{
+			"packetization-mode": "aa",
+		})
+		require.Error(t, err)
+
+		_, err = Unmarshal("video", 96, "H264/90000", map[string]string{
+			"packetization-mode":       "2",
+			"sprop-interleaving-depth": "aa",
+		})
+		require.Error(t, err)
+	})
+
 	t.Run("mpeg-4 audio generic", func(t *testing.T) {
 		_, err := Unmarshal("audio", 96, "MPEG4-generic/48000/2", map[string]string{
 			"streamtype": "10",
@@ -1159,6 +1676,17 @@ func FuzzUnmarshalH265(f *testing.F) {
 	})
 }
 
+func FuzzUnmarshalH266(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a, b, c, d string) {
+		Unmarshal("video", 96, "H266/90000", map[string]string{ //nolint:errcheck
+			"sprop-vps":          a,
+			"sprop-sps":          b,
+			"sprop-pps":          c,
+			"sprop-max-don-diff": d,
+		})
+	})
+}
+
 func FuzzUnmarshalLPCM(f *testing.F) {
 	f.Fuzz(func(t *testing.T, a string) {
 		Unmarshal("audio", 96, "L16/"+a, nil) //nolint:errcheck
@@ -1174,6 +1702,39 @@ func FuzzUnmarshalMPEG4Video(f *testing.F) {
 	})
 }
 
+func FuzzUnmarshalAC4(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a string) {
+		Unmarshal("audio", 96, "AC4/"+a, nil) //nolint:errcheck
+	})
+}
+
+func FuzzUnmarshalMPEGH3DAudio(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		Unmarshal("audio", 96, "MHAS/"+a, map[string]string{ //nolint:errcheck
+			"profile-level-id": b,
+			"config":           c,
+		})
+	})
+}
+
+func FuzzUnmarshalJPEGXS(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		Unmarshal("video", 96, "jxsv/90000", map[string]string{ //nolint:errcheck
+			"packetmode": a,
+			"transmode":  b,
+			"width":      c,
+		})
+	})
+}
+
+func FuzzUnmarshalAMR(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a, b string) {
+		Unmarshal("audio", 96, "AMR/"+a, map[string]string{ //nolint:errcheck
+			"mode-set": b,
+		})
+	})
+}
+
 func FuzzUnmarshalOpus(f *testing.F) {
 	f.Add("48000/a")
 
@@ -1182,6 +1743,15 @@ func FuzzUnmarshalOpus(f *testing.F) {
 	})
 }
 
+func FuzzUnmarshalMultiOpus(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		Unmarshal("audio", 96, "multiopus/48000/"+a, map[string]string{ //nolint:errcheck
+			"channel_mapping":   b,
+			"maxaveragebitrate": c,
+		})
+	})
+}
+
 func FuzzUnmarshalVorbis(f *testing.F) {
 	f.Fuzz(func(t *testing.T, a, b string) {
 		Unmarshal("audio", 96, "Vorbis/"+a, map[string]string{ //nolint:errcheck
@@ -1190,6 +1760,24 @@ func FuzzUnmarshalVorbis(f *testing.F) {
 	})
 }
 
+func FuzzUnmarshalH263P(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a, b string) {
+		Unmarshal("video", 96, "H263-2000/90000", map[string]string{ //nolint:errcheck
+			"profile": a,
+			"level":   b,
+		})
+	})
+}
+
+func FuzzUnmarshalSMPTE291(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a, b string) {
+		Unmarshal("video", 96, "smpte291/90000", map[string]string{ //nolint:errcheck
+			"DID_SDID":  a,
+			"VPID_Code": b,
+		})
+	})
+}
+
 func FuzzUnmarshalVP8(f *testing.F) {
 	f.Fuzz(func(t *testing.T, a, b string) {
 		Unmarshal("video", 96, "VP8/90000", map[string]string{ //nolint:errcheck
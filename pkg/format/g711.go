@@ -1,8 +1,11 @@
 package format
 
 import (
+	"time"
+
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtplpcm"
 	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpsimpleaudio"
 )
 
@@ -11,6 +14,10 @@ import (
 type G711 struct {
 	// whether to use mu-law. Otherwise, A-law is used.
 	MULaw bool
+
+	// duration of the samples contained in each packet (optional).
+	// It is filled with the ptime attribute of the media.
+	PTime time.Duration
 }
 
 func (f *G711) unmarshal(ctx *unmarshalContext) error {
@@ -79,3 +86,26 @@ func (f *G711) CreateEncoder() (*rtpsimpleaudio.Encoder, error) {
 
 	return e, nil
 }
+
+// CreateEncoderWithPTime creates an encoder able to encode the content of the format,
+// that splits samples into multiple packets.
+// If PTime is set, each packet contains samples of that duration.
+func (f *G711) CreateEncoderWithPTime() (*rtplpcm.Encoder, error) {
+	e := &rtplpcm.Encoder{
+		PayloadType:  f.PayloadType(),
+		BitDepth:     8,
+		ChannelCount: 1,
+	}
+
+	if f.PTime != 0 {
+		// G711 samples are one byte long
+		e.PayloadMaxSize = int(f.PTime * time.Duration(f.ClockRate()) / time.Second)
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -1,7 +1,9 @@
 package format
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
@@ -37,3 +39,21 @@ func TestG711DecEncoder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
 }
+
+func TestG711EncoderPTime(t *testing.T) {
+	format := &G711{
+		MULaw: true,
+		PTime: 20 * time.Millisecond,
+	}
+
+	enc, err := format.CreateEncoderWithPTime()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode(bytes.Repeat([]byte{0x01}, 400))
+	require.NoError(t, err)
+	require.Equal(t, 3, len(pkts))
+	require.Equal(t, 160, len(pkts[0].Payload))
+	require.Equal(t, 160, len(pkts[1].Payload))
+	require.Equal(t, 80, len(pkts[2].Payload))
+	require.Equal(t, uint32(160), pkts[1].Timestamp-pkts[0].Timestamp)
+}
@@ -21,6 +21,8 @@ func (f *G722) Codec() string {
 
 // ClockRate implements Format.
 func (f *G722) ClockRate() int {
+	// RFC3551: although the sampling rate is 16000 Hz, the RTP timestamp
+	// is incremented with a 8000 Hz clock rate for backward compatibility.
 	return 8000
 }
 
@@ -0,0 +1,125 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpg7221"
+)
+
+// G7221 is a RTP format for the G722.1 codec.
+// Specification: https://datatracker.ietf.org/doc/html/rfc5577
+type G7221 struct {
+	// payload type of packets.
+	PayloadTyp uint8
+
+	// sample rate (16000, or 32000 with G722.1 annex C).
+	SampleRate int
+
+	// bit rate, in bit/s.
+	BitRate int
+}
+
+func (f *G7221) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	tmp := strings.SplitN(ctx.clock, "/", 2)
+
+	sampleRate, err := strconv.ParseUint(tmp[0], 10, 31)
+	if err != nil || (sampleRate != 16000 && sampleRate != 32000) {
+		return fmt.Errorf("invalid sample rate: %v", tmp[0])
+	}
+	f.SampleRate = int(sampleRate)
+
+	// RFC5577: the channel count is 1 and it can be omitted.
+	if len(tmp) >= 2 && tmp[1] != "1" {
+		return fmt.Errorf("invalid channel count: %v", tmp[1])
+	}
+
+	for key, val := range ctx.fmtp {
+		if key == "bitrate" {
+			var bitRate uint64
+			bitRate, err = strconv.ParseUint(val, 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid bitrate: %v", val)
+			}
+			f.BitRate = int(bitRate)
+		}
+	}
+
+	switch {
+	case f.BitRate == 0:
+		return fmt.Errorf("bitrate is missing")
+
+	case f.BitRate != 24000 && f.BitRate != 32000 && f.BitRate != 48000,
+		f.BitRate == 48000 && f.SampleRate == 16000:
+		return fmt.Errorf("invalid bitrate: %d", f.BitRate)
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *G7221) Codec() string {
+	return "G722.1"
+}
+
+// ClockRate implements Format.
+func (f *G7221) ClockRate() int {
+	return f.SampleRate
+}
+
+// PayloadType implements Format.
+func (f *G7221) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *G7221) RTPMap() string {
+	return "G7221/" + strconv.FormatInt(int64(f.SampleRate), 10)
+}
+
+// FMTP implements Format.
+func (f *G7221) FMTP() map[string]string {
+	return map[string]string{
+		"bitrate": strconv.FormatInt(int64(f.BitRate), 10),
+	}
+}
+
+// PTSEqualsDTS implements Format.
+func (f *G7221) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *G7221) CreateDecoder() (*rtpg7221.Decoder, error) {
+	d := &rtpg7221.Decoder{
+		BitRate: f.BitRate,
+	}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *G7221) CreateEncoder() (*rtpg7221.Encoder, error) {
+	e := &rtpg7221.Encoder{
+		PayloadType: f.PayloadTyp,
+		SampleRate:  f.SampleRate,
+		BitRate:     f.BitRate,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
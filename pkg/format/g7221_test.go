@@ -0,0 +1,44 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestG7221Attributes(t *testing.T) {
+	format := &G7221{
+		PayloadTyp: 96,
+		SampleRate: 32000,
+		BitRate:    48000,
+	}
+	require.Equal(t, "G722.1", format.Codec())
+	require.Equal(t, 32000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestG7221DecEncoder(t *testing.T) {
+	format := &G7221{
+		PayloadTyp: 96,
+		SampleRate: 16000,
+		BitRate:    24000,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	frame := make([]byte, 60)
+	frame[0] = 0x01
+
+	pkts, err := enc.Encode([][]byte{frame})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	frames, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{frame}, frames)
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpg726"
 )
 
 // G726 is a RTP format for the G726 codec.
@@ -71,3 +73,34 @@ func (f *G726) FMTP() map[string]string {
 func (f *G726) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
 }
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *G726) CreateDecoder() (*rtpg726.Decoder, error) {
+	d := &rtpg726.Decoder{
+		BitRate:   f.BitRate,
+		BigEndian: f.BigEndian,
+	}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *G726) CreateEncoder() (*rtpg726.Encoder, error) {
+	e := &rtpg726.Encoder{
+		PayloadType: f.PayloadTyp,
+		BitRate:     f.BitRate,
+		BigEndian:   f.BigEndian,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
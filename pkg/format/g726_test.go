@@ -13,3 +13,25 @@ func TestG726Attributes(t *testing.T) {
 	require.Equal(t, 8000, format.ClockRate())
 	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
 }
+
+func TestG726DecEncoder(t *testing.T) {
+	format := &G726{
+		PayloadTyp: 96,
+		BitRate:    24,
+		BigEndian:  true,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([]uint8{0, 1, 2, 3, 4, 5, 6, 7})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	codewords, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, []uint8{0, 1, 2, 3, 4, 5, 6, 7}, codewords)
+}
@@ -0,0 +1,117 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph263p"
+)
+
+// H263P is a RTP format for the H263+ codec (H263-1998 and H263-2000).
+// Specification: https://datatracker.ietf.org/doc/html/rfc4629
+type H263P struct {
+	PayloadTyp uint8
+	Is2000     bool
+	Profile    *int
+	Level      *int
+}
+
+func (f *H263P) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+	f.Is2000 = (ctx.codec == "h263-2000")
+
+	for key, val := range ctx.fmtp {
+		switch key {
+		case "profile":
+			n, err := strconv.ParseUint(val, 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid profile: %v", val)
+			}
+
+			v2 := int(n)
+			f.Profile = &v2
+
+		case "level":
+			n, err := strconv.ParseUint(val, 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid level: %v", val)
+			}
+
+			v2 := int(n)
+			f.Level = &v2
+		}
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *H263P) Codec() string {
+	return "H263+"
+}
+
+// ClockRate implements Format.
+func (f *H263P) ClockRate() int {
+	return 90000
+}
+
+// PayloadType implements Format.
+func (f *H263P) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *H263P) RTPMap() string {
+	if f.Is2000 {
+		return "H263-2000/90000"
+	}
+	return "H263-1998/90000"
+}
+
+// FMTP implements Format.
+func (f *H263P) FMTP() map[string]string {
+	fmtp := make(map[string]string)
+
+	if f.Profile != nil {
+		fmtp["profile"] = strconv.FormatInt(int64(*f.Profile), 10)
+	}
+
+	if f.Level != nil {
+		fmtp["level"] = strconv.FormatInt(int64(*f.Level), 10)
+	}
+
+	return fmtp
+}
+
+// PTSEqualsDTS implements Format.
+func (f *H263P) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *H263P) CreateDecoder() (*rtph263p.Decoder, error) {
+	d := &rtph263p.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *H263P) CreateEncoder() (*rtph263p.Encoder, error) {
+	e := &rtph263p.Encoder{
+		PayloadType: f.PayloadTyp,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,35 @@
+package format //nolint:dupl
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestH263PAttributes(t *testing.T) {
+	format := &H263P{
+		PayloadTyp: 96,
+	}
+	require.Equal(t, "H263+", format.Codec())
+	require.Equal(t, 90000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestH263PDecEncoder(t *testing.T) {
+	format := &H263P{}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([]byte{0x00, 0x00, 0x80, 0x02, 0x03, 0x04})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x00, 0x00, 0x80, 0x02, 0x03, 0x04}, byts)
+}
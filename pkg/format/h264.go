@@ -23,6 +23,14 @@ type H264 struct {
 	PPS               []byte
 	PacketizationMode int
 
+	// interleaving depth (packetization mode 2 only).
+	InterleavingDepth int
+
+	// prepend SPS and PPS to the first IDR access unit returned by decoders
+	// created with CreateDecoder(), unless they are received in-band.
+	// It is not part of the SDP.
+	DecoderPrependParams bool
+
 	mutex sync.RWMutex
 }
 
@@ -67,6 +75,14 @@ func (f *H264) unmarshal(ctx *unmarshalContext) error {
 			}
 
 			f.PacketizationMode = int(tmp)
+
+		case "sprop-interleaving-depth":
+			tmp, err := strconv.ParseUint(val, 10, 16)
+			if err != nil {
+				return fmt.Errorf("invalid sprop-interleaving-depth (%v)", val)
+			}
+
+			f.InterleavingDepth = int(tmp)
 		}
 	}
 
@@ -104,6 +120,10 @@ func (f *H264) FMTP() map[string]string {
 		fmtp["packetization-mode"] = strconv.FormatInt(int64(f.PacketizationMode), 10)
 	}
 
+	if f.PacketizationMode == 2 {
+		fmtp["sprop-interleaving-depth"] = strconv.FormatInt(int64(f.InterleavingDepth), 10)
+	}
+
 	var tmp []string
 	if f.SPS != nil {
 		tmp = append(tmp, base64.StdEncoding.EncodeToString(f.SPS))
@@ -133,9 +153,17 @@ func (f *H264) PTSEqualsDTS(pkt *rtp.Packet) bool {
 	case h264.NALUTypeIDR, h264.NALUTypeSPS, h264.NALUTypePPS:
 		return true
 
-	case 24: // STAP-A
+	case 24, 25: // STAP-A, STAP-B
 		payload := pkt.Payload[1:]
 
+		// skip DON
+		if typ == 25 {
+			if len(payload) < 2 {
+				return false
+			}
+			payload = payload[2:]
+		}
+
 		for len(payload) > 0 {
 			if len(payload) < 2 {
 				return false
@@ -158,7 +186,7 @@ func (f *H264) PTSEqualsDTS(pkt *rtp.Packet) bool {
 			}
 		}
 
-	case 28: // FU-A
+	case 28, 29: // FU-A, FU-B
 		if len(pkt.Payload) < 2 {
 			return false
 		}
@@ -182,6 +210,11 @@ func (f *H264) PTSEqualsDTS(pkt *rtp.Packet) bool {
 func (f *H264) CreateDecoder() (*rtph264.Decoder, error) {
 	d := &rtph264.Decoder{
 		PacketizationMode: f.PacketizationMode,
+		InterleavingDepth: f.InterleavingDepth,
+	}
+
+	if f.DecoderPrependParams {
+		d.SPS, d.PPS = f.SafeParams()
 	}
 
 	err := d.Init()
@@ -61,3 +61,42 @@ func TestH264DecEncoder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, [][]byte{{0x01, 0x02, 0x03, 0x04}}, byts)
 }
+
+func TestH264DecoderPrependParams(t *testing.T) {
+	format := &H264{
+		SPS:                  []byte{0x67, 0x01},
+		PPS:                  []byte{0x68, 0x02},
+		DecoderPrependParams: true,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		au  [][]byte
+		out [][]byte
+	}{
+		{
+			[][]byte{{0x01, 0x02}},
+			[][]byte{{0x01, 0x02}},
+		},
+		{
+			[][]byte{{0x05, 0x02}},
+			[][]byte{{0x67, 0x01}, {0x68, 0x02}, {0x05, 0x02}},
+		},
+		{
+			[][]byte{{0x05, 0x03}},
+			[][]byte{{0x05, 0x03}},
+		},
+	} {
+		pkts, err := enc.Encode(ca.au)
+		require.NoError(t, err)
+
+		byts, err := dec.Decode(pkts[0])
+		require.NoError(t, err)
+		require.Equal(t, ca.out, byts)
+	}
+}
@@ -22,6 +22,11 @@ type H265 struct {
 	PPS        []byte
 	MaxDONDiff int
 
+	// prepend VPS, SPS and PPS to the first random access unit returned by decoders
+	// created with CreateDecoder(), unless they are received in-band.
+	// It is not part of the SDP.
+	DecoderPrependParams bool
+
 	mutex sync.RWMutex
 }
 
@@ -192,6 +197,10 @@ func (f *H265) CreateDecoder() (*rtph265.Decoder, error) {
 		MaxDONDiff: f.MaxDONDiff,
 	}
 
+	if f.DecoderPrependParams {
+		d.VPS, d.SPS, d.PPS = f.SafeParams()
+	}
+
 	err := d.Init()
 	if err != nil {
 		return nil, err
@@ -70,3 +70,43 @@ func TestH265DecEncoder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, [][]byte{{0x01, 0x02, 0x03, 0x04}}, byts)
 }
+
+func TestH265DecoderPrependParams(t *testing.T) {
+	format := &H265{
+		VPS:                  []byte{0x40, 0x01, 0x01},
+		SPS:                  []byte{0x42, 0x01, 0x02},
+		PPS:                  []byte{0x44, 0x01, 0x03},
+		DecoderPrependParams: true,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		au  [][]byte
+		out [][]byte
+	}{
+		{
+			[][]byte{{0x02, 0x01, 0x01}},
+			[][]byte{{0x02, 0x01, 0x01}},
+		},
+		{
+			[][]byte{{0x26, 0x01, 0x01}},
+			[][]byte{{0x40, 0x01, 0x01}, {0x42, 0x01, 0x02}, {0x44, 0x01, 0x03}, {0x26, 0x01, 0x01}},
+		},
+		{
+			[][]byte{{0x26, 0x01, 0x02}},
+			[][]byte{{0x26, 0x01, 0x02}},
+		},
+	} {
+		pkts, err := enc.Encode(ca.au)
+		require.NoError(t, err)
+
+		byts, err := dec.Decode(pkts[0])
+		require.NoError(t, err)
+		require.Equal(t, ca.out, byts)
+	}
+}
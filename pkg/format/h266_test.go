@@ -0,0 +1,75 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestH266Attributes(t *testing.T) {
+	format := &H266{
+		PayloadTyp: 96,
+		VPS:        []byte{0x01, 0x02},
+		SPS:        []byte{0x03, 0x04},
+		PPS:        []byte{0x05, 0x06},
+	}
+	require.Equal(t, "H266", format.Codec())
+	require.Equal(t, 90000, format.ClockRate())
+
+	vps, sps, pps := format.SafeParams()
+	require.Equal(t, []byte{0x01, 0x02}, vps)
+	require.Equal(t, []byte{0x03, 0x04}, sps)
+	require.Equal(t, []byte{0x05, 0x06}, pps)
+
+	format.SafeSetParams([]byte{0x07, 0x08}, []byte{0x09, 0x0A}, []byte{0x0B, 0x0C})
+
+	vps, sps, pps = format.SafeParams()
+	require.Equal(t, []byte{0x07, 0x08}, vps)
+	require.Equal(t, []byte{0x09, 0x0A}, sps)
+	require.Equal(t, []byte{0x0B, 0x0C}, pps)
+}
+
+func TestH266PTSEqualsDTS(t *testing.T) {
+	format := &H266{
+		PayloadTyp: 96,
+	}
+
+	// CRA
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{
+		Payload: []byte{0x00, 0x49, 0x01},
+	}))
+
+	// IDR_W_RADL inside fragmentation unit
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{
+		Payload: []byte{0x00, 0xe9, 0x87, 0x01},
+	}))
+
+	// SPS inside aggregation packet
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{
+		Payload: []byte{0x00, 0xe1, 0x00, 0x03, 0x00, 0x79, 0x01},
+	}))
+
+	// TRAIL
+	require.Equal(t, false, format.PTSEqualsDTS(&rtp.Packet{
+		Payload: []byte{0x00, 0x01, 0x01},
+	}))
+}
+
+func TestH266DecEncoder(t *testing.T) {
+	format := &H266{}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([][]byte{{0x00, 0x01, 0x03, 0x04}})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x00, 0x01, 0x03, 0x04}}, byts)
+}
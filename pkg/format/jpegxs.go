@@ -0,0 +1,203 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpjpegxs"
+)
+
+// JPEGXS is a RTP format for the JPEG XS codec.
+// Specification: https://datatracker.ietf.org/doc/html/rfc9134
+type JPEGXS struct {
+	// payload type of packets.
+	PayloadTyp uint8
+
+	// packetization mode.
+	// 0 = codestream packetization mode, 1 = slice packetization mode.
+	PacketizationMode int
+
+	// whether packets can be transmitted out of order (transmode=0).
+	OutOfOrder bool
+
+	// profile, level and sublevel of the codestream (optional).
+	Profile  string
+	Level    string
+	Sublevel string
+
+	// color sampling of the codestream (optional).
+	Sampling string
+
+	// bit depth of the codestream (optional).
+	Depth int
+
+	// size of frames (optional).
+	Width  int
+	Height int
+
+	// frame rate, as a integer or a fraction (optional).
+	ExactFramerate string
+
+	// colorimetry (optional).
+	Colorimetry string
+}
+
+func (f *JPEGXS) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	for key, val := range ctx.fmtp {
+		switch key {
+		case "packetmode":
+			switch val {
+			case "0", "1":
+				f.PacketizationMode = int(val[0] - '0')
+
+			default:
+				return fmt.Errorf("invalid packetmode: %v", val)
+			}
+
+		case "transmode":
+			switch val {
+			case "0":
+				f.OutOfOrder = true
+
+			case "1":
+
+			default:
+				return fmt.Errorf("invalid transmode: %v", val)
+			}
+
+		case "profile":
+			f.Profile = val
+
+		case "level":
+			f.Level = val
+
+		case "sublevel":
+			f.Sublevel = val
+
+		case "sampling":
+			f.Sampling = val
+
+		case "depth", "width", "height":
+			tmp, err := strconv.ParseUint(val, 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", key, val)
+			}
+
+			switch key {
+			case "depth":
+				f.Depth = int(tmp)
+			case "width":
+				f.Width = int(tmp)
+			default:
+				f.Height = int(tmp)
+			}
+
+		case "exactframerate":
+			f.ExactFramerate = val
+
+		case "colorimetry":
+			f.Colorimetry = val
+		}
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *JPEGXS) Codec() string {
+	return "JPEG XS"
+}
+
+// ClockRate implements Format.
+func (f *JPEGXS) ClockRate() int {
+	return 90000
+}
+
+// PayloadType implements Format.
+func (f *JPEGXS) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *JPEGXS) RTPMap() string {
+	return "jxsv/90000"
+}
+
+// FMTP implements Format.
+func (f *JPEGXS) FMTP() map[string]string {
+	fmtp := map[string]string{
+		"packetmode": strconv.FormatInt(int64(f.PacketizationMode), 10),
+	}
+
+	if f.OutOfOrder {
+		fmtp["transmode"] = "0"
+	} else {
+		fmtp["transmode"] = "1"
+	}
+
+	if f.Profile != "" {
+		fmtp["profile"] = f.Profile
+	}
+	if f.Level != "" {
+		fmtp["level"] = f.Level
+	}
+	if f.Sublevel != "" {
+		fmtp["sublevel"] = f.Sublevel
+	}
+	if f.Sampling != "" {
+		fmtp["sampling"] = f.Sampling
+	}
+	if f.Depth != 0 {
+		fmtp["depth"] = strconv.FormatInt(int64(f.Depth), 10)
+	}
+	if f.Width != 0 {
+		fmtp["width"] = strconv.FormatInt(int64(f.Width), 10)
+	}
+	if f.Height != 0 {
+		fmtp["height"] = strconv.FormatInt(int64(f.Height), 10)
+	}
+	if f.ExactFramerate != "" {
+		fmtp["exactframerate"] = f.ExactFramerate
+	}
+	if f.Colorimetry != "" {
+		fmtp["colorimetry"] = f.Colorimetry
+	}
+
+	return fmtp
+}
+
+// PTSEqualsDTS implements Format.
+func (f *JPEGXS) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *JPEGXS) CreateDecoder() (*rtpjpegxs.Decoder, error) {
+	d := &rtpjpegxs.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *JPEGXS) CreateEncoder() (*rtpjpegxs.Encoder, error) {
+	e := &rtpjpegxs.Encoder{
+		PayloadType:       f.PayloadTyp,
+		PacketizationMode: f.PacketizationMode,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -3,12 +3,16 @@ package format
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/format/rtplpcm"
 )
 
+// default maximum payload size of the LPCM encoder.
+const lpcmDefaultPayloadMaxSize = 1460
+
 // LPCM is a RTP format for the uncompressed, Linear PCM codec.
 // Specification: https://datatracker.ietf.org/doc/html/rfc3190
 type LPCM struct {
@@ -16,6 +20,14 @@ type LPCM struct {
 	BitDepth     int
 	SampleRate   int
 	ChannelCount int
+
+	// duration of the samples contained in each packet (optional).
+	// It is filled with the ptime attribute of the media.
+	PTime time.Duration
+
+	// maximum duration of the samples contained in each packet (optional).
+	// It is filled with the maxptime attribute of the media.
+	MaxPTime time.Duration
 }
 
 func (f *LPCM) unmarshal(ctx *unmarshalContext) error {
@@ -119,6 +131,20 @@ func (f *LPCM) CreateEncoder() (*rtplpcm.Encoder, error) {
 		ChannelCount: f.ChannelCount,
 	}
 
+	// packets contain samples of the advertised duration, even when this makes them
+	// bigger than the default maximum payload size.
+	// When only the maximum duration is advertised, it is used as a limit.
+	switch {
+	case f.PTime != 0:
+		e.PayloadMaxSize = f.packetSize(f.PTime)
+
+	case f.MaxPTime != 0:
+		e.PayloadMaxSize = f.packetSize(f.MaxPTime)
+		if e.PayloadMaxSize > lpcmDefaultPayloadMaxSize {
+			e.PayloadMaxSize = lpcmDefaultPayloadMaxSize
+		}
+	}
+
 	err := e.Init()
 	if err != nil {
 		return nil, err
@@ -126,3 +152,12 @@ func (f *LPCM) CreateEncoder() (*rtplpcm.Encoder, error) {
 
 	return e, nil
 }
+
+// packetSize returns the size of a packet that contains samples of the given duration.
+func (f *LPCM) packetSize(d time.Duration) int {
+	sampleCount := int(d * time.Duration(f.SampleRate) / time.Second)
+	if sampleCount < 1 {
+		sampleCount = 1
+	}
+	return sampleCount * f.BitDepth * f.ChannelCount / 8
+}
@@ -2,6 +2,7 @@ package format
 
 import (
 	"testing"
+	"time"
 
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
@@ -41,3 +42,72 @@ func TestLPCMDecEncoder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
 }
+
+func TestLPCMEncoderPTime(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		ptime    time.Duration
+		maxPTime time.Duration
+		sizes    []int
+	}{
+		{
+			"ptime 125us",
+			125 * time.Microsecond,
+			0,
+			[]int{144, 144, 144, 144, 144, 144, 144, 144},
+		},
+		{
+			"ptime 1ms",
+			1 * time.Millisecond,
+			0,
+			[]int{1152},
+		},
+		{
+			"maxptime",
+			0,
+			250 * time.Microsecond,
+			[]int{288, 288, 288, 288},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			format := &LPCM{
+				PayloadTyp:   96,
+				BitDepth:     24,
+				SampleRate:   48000,
+				ChannelCount: 8,
+				PTime:        ca.ptime,
+				MaxPTime:     ca.maxPTime,
+			}
+
+			enc, err := format.CreateEncoder()
+			require.NoError(t, err)
+
+			// 1ms of samples
+			pkts, err := enc.Encode(make([]byte, 48*3*8))
+			require.NoError(t, err)
+
+			sizes := make([]int, len(pkts))
+			for i, pkt := range pkts {
+				sizes[i] = len(pkt.Payload)
+			}
+			require.Equal(t, ca.sizes, sizes)
+		})
+	}
+}
+
+func TestLPCMEncoderManyChannels(t *testing.T) {
+	format := &LPCM{
+		PayloadTyp:   96,
+		BitDepth:     24,
+		SampleRate:   48000,
+		ChannelCount: 64,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode(make([]byte, 3*64*10))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pkts))
+	require.Equal(t, 3*64*7, len(pkts[0].Payload))
+}
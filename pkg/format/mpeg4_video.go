@@ -27,6 +27,8 @@ func (f *MPEG4Video) unmarshal(ctx *unmarshalContext) error {
 	f.PayloadTyp = ctx.payloadType
 	f.ProfileLevelID = 1 // default value imposed by specification
 
+	_, hasProfileLevelID := ctx.fmtp["profile-level-id"]
+
 	for key, val := range ctx.fmtp {
 		switch key {
 		case "profile-level-id":
@@ -51,6 +53,15 @@ func (f *MPEG4Video) unmarshal(ctx *unmarshalContext) error {
 		}
 	}
 
+	// some encoders provide the profile and level only inside the configuration
+	if !hasProfileLevelID && f.Config != nil {
+		var conf MPEG4VideoConfig
+		err := conf.Unmarshal(f.Config)
+		if err == nil && conf.ProfileLevelID != 0 {
+			f.ProfileLevelID = conf.ProfileLevelID
+		}
+	}
+
 	return nil
 }
 
@@ -131,3 +142,19 @@ func (f *MPEG4Video) SafeParams() []byte {
 	defer f.mutex.RUnlock()
 	return f.Config
 }
+
+// ParseConfig decodes the codec parameters.
+func (f *MPEG4Video) ParseConfig() (*MPEG4VideoConfig, error) {
+	config := f.SafeParams()
+	if config == nil {
+		return nil, fmt.Errorf("config is missing")
+	}
+
+	var conf MPEG4VideoConfig
+	err := conf.Unmarshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
@@ -0,0 +1,141 @@
+package format
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/pkg/bits"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4video"
+)
+
+// MPEG4VideoConfig contains the parameters decoded from the configuration of a MPEG-4 Video format.
+// Specification: ISO 14496-2, section 6.2
+type MPEG4VideoConfig struct {
+	// profile and level indication of the visual object sequence header.
+	// It is zero when the header is missing.
+	ProfileLevelID int
+
+	// type of the video object layer.
+	ObjectType int
+
+	// size of frames.
+	// They are zero when the shape of the video object layer is not rectangular.
+	Width  int
+	Height int
+}
+
+type mpeg4VideoBitReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *mpeg4VideoBitReader) read(n int) uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	var v uint64
+	v, r.err = bits.ReadBits(r.buf, &r.pos, n)
+	return v
+}
+
+func bitLength(v uint64) int {
+	n := 0
+	for v != 0 {
+		n++
+		v >>= 1
+	}
+	return n
+}
+
+// Unmarshal decodes a configuration.
+func (c *MPEG4VideoConfig) Unmarshal(buf []byte) error {
+	for i := 0; (i + 4) <= len(buf); i++ {
+		if buf[i] != 0 || buf[i+1] != 0 || buf[i+2] != 1 {
+			continue
+		}
+
+		startCode := mpeg4video.StartCode(buf[i+3])
+		body := buf[i+4:]
+
+		switch {
+		case startCode == mpeg4video.VisualObjectSequenceStartCode:
+			if len(body) < 1 {
+				return fmt.Errorf("invalid visual object sequence header")
+			}
+			c.ProfileLevelID = int(body[0])
+
+		case startCode >= mpeg4video.VideoObjectLayerStartCodeFirst &&
+			startCode <= mpeg4video.VideoObjectLayerStartCodeLast:
+			err := c.unmarshalVOL(body)
+			if err != nil {
+				return fmt.Errorf("invalid video object layer header: %w", err)
+			}
+			return nil
+		}
+
+		i += 3
+	}
+
+	return fmt.Errorf("video object layer header not found")
+}
+
+func (c *MPEG4VideoConfig) unmarshalVOL(buf []byte) error {
+	r := &mpeg4VideoBitReader{buf: buf}
+
+	r.read(1) // random_accessible_vol
+	c.ObjectType = int(r.read(8))
+
+	verID := uint64(1)
+	if r.read(1) == 1 { // is_object_layer_identifier
+		verID = r.read(4)
+		r.read(3) // video_object_layer_priority
+	}
+
+	if r.read(4) == 0x0F { // aspect_ratio_info
+		r.read(16) // par_width, par_height
+	}
+
+	if r.read(1) == 1 { // vol_control_parameters
+		r.read(3)           // chroma_format, low_delay
+		if r.read(1) == 1 { // vbv_parameters
+			r.read(64)
+			r.read(15)
+		}
+	}
+
+	shape := r.read(2)
+	if shape == 3 && verID != 1 {
+		r.read(4) // video_object_layer_shape_extension
+	}
+
+	r.read(1) // marker_bit
+	timeIncrementResolution := r.read(16)
+	r.read(1) // marker_bit
+
+	if r.err != nil {
+		return r.err
+	}
+
+	if timeIncrementResolution == 0 {
+		return fmt.Errorf("invalid vop_time_increment_resolution")
+	}
+
+	if r.read(1) == 1 { // fixed_vop_rate
+		n := bitLength(timeIncrementResolution - 1)
+		if n == 0 {
+			n = 1
+		}
+		r.read(n) // fixed_vop_time_increment
+	}
+
+	// rectangular
+	if shape == 0 {
+		r.read(1) // marker_bit
+		c.Width = int(r.read(13))
+		r.read(1) // marker_bit
+		c.Height = int(r.read(13))
+	}
+
+	return r.err
+}
@@ -37,3 +37,33 @@ func TestMPEG4VideoDecEncoder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
 }
+
+func TestMPEG4VideoParseConfig(t *testing.T) {
+	format := &MPEG4Video{
+		PayloadTyp: 96,
+		Config: []byte{
+			0x00, 0x00, 0x01, 0xb0, 0x01, 0x00, 0x00, 0x01,
+			0xb5, 0x89, 0x13, 0x00, 0x00, 0x01, 0x00, 0x00,
+			0x00, 0x01, 0x20, 0x00, 0xc4, 0x8d, 0x8a, 0xee,
+			0x05, 0x3c, 0x04, 0x64, 0x14, 0x43, 0x00, 0x00,
+			0x01, 0xb2, 0x4c, 0x61, 0x76, 0x63, 0x35, 0x38,
+			0x2e, 0x31, 0x33, 0x34, 0x2e, 0x31, 0x30, 0x30,
+		},
+	}
+
+	conf, err := format.ParseConfig()
+	require.NoError(t, err)
+	require.Equal(t, &MPEG4VideoConfig{
+		ProfileLevelID: 1,
+		ObjectType:     1,
+		Width:          1920,
+		Height:         800,
+	}, conf)
+}
+
+func FuzzMPEG4VideoConfigUnmarshal(f *testing.F) {
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var conf MPEG4VideoConfig
+		conf.Unmarshal(b) //nolint:errcheck
+	})
+}
@@ -0,0 +1,146 @@
+package format
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpmpegh3daudio"
+)
+
+// MPEGH3DAudio is a RTP format for the MPEG-H 3D Audio codec.
+// Access units are transmitted as MPEG-H 3D Audio Stream (MHAS) packets.
+// Specification: ISO/IEC 23008-3
+type MPEGH3DAudio struct {
+	// payload type of packets.
+	PayloadTyp uint8
+
+	// sample rate.
+	SampleRate int
+
+	// number of channels (optional).
+	ChannelCount int
+
+	// mpegh3daProfileLevelIndication (optional).
+	ProfileLevelID int
+
+	// mpegh3daConfig (optional).
+	// It can also be transmitted in-band, with a MHAS packet.
+	Config []byte
+}
+
+func (f *MPEGH3DAudio) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	tmp := strings.SplitN(ctx.clock, "/", 2)
+
+	sampleRate, err := strconv.ParseUint(tmp[0], 10, 31)
+	if err != nil || sampleRate == 0 {
+		return fmt.Errorf("invalid sample rate: %v", tmp[0])
+	}
+	f.SampleRate = int(sampleRate)
+
+	if len(tmp) >= 2 {
+		channelCount, err := strconv.ParseUint(tmp[1], 10, 31)
+		if err != nil || channelCount == 0 {
+			return fmt.Errorf("invalid channel count: %v", tmp[1])
+		}
+		f.ChannelCount = int(channelCount)
+	}
+
+	for key, val := range ctx.fmtp {
+		switch key {
+		case "profile-level-id":
+			tmp, err := strconv.ParseUint(val, 10, 8)
+			if err != nil {
+				return fmt.Errorf("invalid profile-level-id: %v", val)
+			}
+			f.ProfileLevelID = int(tmp)
+
+		case "config":
+			f.Config, err = hex.DecodeString(val)
+			if err != nil || len(f.Config) == 0 {
+				return fmt.Errorf("invalid config: %v", val)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *MPEGH3DAudio) Codec() string {
+	return "MPEG-H 3D Audio"
+}
+
+// ClockRate implements Format.
+func (f *MPEGH3DAudio) ClockRate() int {
+	return f.SampleRate
+}
+
+// PayloadType implements Format.
+func (f *MPEGH3DAudio) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *MPEGH3DAudio) RTPMap() string {
+	ret := "MHAS/" + strconv.FormatInt(int64(f.SampleRate), 10)
+	if f.ChannelCount != 0 {
+		ret += "/" + strconv.FormatInt(int64(f.ChannelCount), 10)
+	}
+	return ret
+}
+
+// FMTP implements Format.
+func (f *MPEGH3DAudio) FMTP() map[string]string {
+	fmtp := make(map[string]string)
+
+	if f.ProfileLevelID != 0 {
+		fmtp["profile-level-id"] = strconv.FormatInt(int64(f.ProfileLevelID), 10)
+	}
+
+	if f.Config != nil {
+		fmtp["config"] = hex.EncodeToString(f.Config)
+	}
+
+	if len(fmtp) == 0 {
+		return nil
+	}
+
+	return fmtp
+}
+
+// PTSEqualsDTS implements Format.
+func (f *MPEGH3DAudio) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *MPEGH3DAudio) CreateDecoder() (*rtpmpegh3daudio.Decoder, error) {
+	d := &rtpmpegh3daudio.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *MPEGH3DAudio) CreateEncoder() (*rtpmpegh3daudio.Encoder, error) {
+	e := &rtpmpegh3daudio.Encoder{
+		PayloadType: f.PayloadTyp,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,39 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMPEGH3DAudioAttributes(t *testing.T) {
+	format := &MPEGH3DAudio{
+		PayloadTyp: 96,
+		SampleRate: 48000,
+	}
+	require.Equal(t, "MPEG-H 3D Audio", format.Codec())
+	require.Equal(t, 48000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestMPEGH3DAudioDecEncoder(t *testing.T) {
+	format := &MPEGH3DAudio{
+		PayloadTyp: 96,
+		SampleRate: 48000,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([]byte{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
+}
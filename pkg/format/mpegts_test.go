@@ -1,6 +1,7 @@
 package format
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/pion/rtp"
@@ -13,3 +14,23 @@ func TestMPEGTSAttributes(t *testing.T) {
 	require.Equal(t, 90000, format.ClockRate())
 	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
 }
+
+func TestMPEGTSDecEncoder(t *testing.T) {
+	format := &MPEGTS{}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	tsPkt := append([]byte{0x47, 0x01, 0x00, 0x10}, bytes.Repeat([]byte{0x01}, 184)...)
+
+	pkts, err := enc.Encode([][]byte{tsPkt})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	tsPkts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{tsPkt}, tsPkts)
+}
@@ -0,0 +1,91 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtponvifmetadata"
+)
+
+// ONVIFMetadata is a RTP format for ONVIF metadata streams,
+// that carry XML documents with analytics events and PTZ positions.
+// Specification: ONVIF Streaming Specification, section 5.1.2.1.1
+type ONVIFMetadata struct {
+	PayloadTyp uint8
+
+	// encoding of documents: "" (plain XML), "gzip", "exi.onvif" or "exi.ext".
+	// Decoders and encoders are able to decompress and compress gzip documents only,
+	// EXI documents are delivered as they are.
+	Encoding string
+}
+
+func (f *ONVIFMetadata) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	f.Encoding = strings.TrimPrefix(strings.TrimPrefix(ctx.codec, "vnd.onvif.metadata"), ".")
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *ONVIFMetadata) Codec() string {
+	return "ONVIF Metadata"
+}
+
+// ClockRate implements Format.
+func (f *ONVIFMetadata) ClockRate() int {
+	return 90000
+}
+
+// PayloadType implements Format.
+func (f *ONVIFMetadata) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *ONVIFMetadata) RTPMap() string {
+	if f.Encoding != "" {
+		return "vnd.onvif.metadata." + f.Encoding + "/90000"
+	}
+	return "vnd.onvif.metadata/90000"
+}
+
+// FMTP implements Format.
+func (f *ONVIFMetadata) FMTP() map[string]string {
+	return nil
+}
+
+// PTSEqualsDTS implements Format.
+func (f *ONVIFMetadata) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *ONVIFMetadata) CreateDecoder() (*rtponvifmetadata.Decoder, error) {
+	d := &rtponvifmetadata.Decoder{
+		Decompress: (f.Encoding == "gzip"),
+	}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *ONVIFMetadata) CreateEncoder() (*rtponvifmetadata.Encoder, error) {
+	e := &rtponvifmetadata.Encoder{
+		PayloadType: f.PayloadTyp,
+		Compress:    (f.Encoding == "gzip"),
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
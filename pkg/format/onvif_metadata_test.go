@@ -0,0 +1,44 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestONVIFMetadataAttributes(t *testing.T) {
+	format := &ONVIFMetadata{
+		PayloadTyp: 107,
+	}
+	require.Equal(t, "ONVIF Metadata", format.Codec())
+	require.Equal(t, 90000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestONVIFMetadataDecEncoder(t *testing.T) {
+	for _, enc := range []string{"", "gzip"} {
+		t.Run("encoding "+enc, func(t *testing.T) {
+			format := &ONVIFMetadata{
+				PayloadTyp: 107,
+				Encoding:   enc,
+			}
+
+			e, err := format.CreateEncoder()
+			require.NoError(t, err)
+
+			doc := []byte(`<tt:MetadataStream xmlns:tt="http://www.onvif.org/ver10/schema"/>`)
+
+			pkts, err := e.Encode(doc)
+			require.NoError(t, err)
+			require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+			d, err := format.CreateDecoder()
+			require.NoError(t, err)
+
+			dec, err := d.Decode(pkts[0])
+			require.NoError(t, err)
+			require.Equal(t, doc, dec)
+		})
+	}
+}
@@ -10,11 +10,68 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpsimpleaudio"
 )
 
+// maximum duration of a Opus packet, in milliseconds.
+const opusMaxPacketDuration = 120
+
 // Opus is a RTP format for the Opus codec.
+// Multichannel streams are supported through the "multiopus" extension.
 // Specification: https://datatracker.ietf.org/doc/html/rfc7587
 type Opus struct {
 	PayloadTyp uint8
 	IsStereo   bool
+
+	// channel count of multichannel streams.
+	// It is zero in case of standard (mono or stereo) streams.
+	ChannelCount int
+
+	// mapping between channels and decoded streams of multichannel streams.
+	ChannelMapping []uint8
+
+	// stream count of multichannel streams.
+	NumStreams *int
+
+	// coupled (stereo) stream count of multichannel streams.
+	CoupledStreams *int
+
+	// whether inband forward error correction is in use.
+	UseInbandFEC *bool
+
+	// whether discontinuous transmission is in use.
+	UseDTX *bool
+
+	// maximum average bitrate, in bits per second.
+	// It limits the size of packets produced by the encoder.
+	MaxAverageBitrate *int
+
+	// maximum sample rate that the receiver is able to play.
+	// It is a hint for encoders and does not affect packetization.
+	MaxPlaybackRate *int
+}
+
+func opusParseInt(key string, val string) (*int, error) {
+	n, err := strconv.ParseUint(val, 10, 31)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", key, val)
+	}
+
+	v := int(n)
+	return &v, nil
+}
+
+func opusParseBool(key string, val string) (*bool, error) {
+	if val != "0" && val != "1" {
+		return nil, fmt.Errorf("invalid %s: %v", key, val)
+	}
+
+	v := (val == "1")
+	return &v, nil
+}
+
+func opusMarshalBool(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
 }
 
 func (f *Opus) unmarshal(ctx *unmarshalContext) error {
@@ -31,16 +88,76 @@ func (f *Opus) unmarshal(ctx *unmarshalContext) error {
 	}
 
 	channelCount, err := strconv.ParseUint(tmp[1], 10, 31)
-	if err != nil || channelCount != 2 {
+	if err != nil {
+		return fmt.Errorf("invalid channel count: %d", channelCount)
+	}
+
+	if ctx.codec == "multiopus" {
+		if channelCount < 1 || channelCount > 255 {
+			return fmt.Errorf("invalid channel count: %d", channelCount)
+		}
+		f.ChannelCount = int(channelCount)
+	} else if channelCount != 2 {
 		return fmt.Errorf("invalid channel count: %d", channelCount)
 	}
 
 	for key, val := range ctx.fmtp {
-		if key == "sprop-stereo" {
+		switch key {
+		case "sprop-stereo":
 			f.IsStereo = (val == "1")
+
+		case "channel_mapping":
+			for _, entry := range strings.Split(val, ",") {
+				var n uint64
+				n, err = strconv.ParseUint(entry, 10, 8)
+				if err != nil {
+					return fmt.Errorf("invalid channel_mapping: %v", val)
+				}
+				f.ChannelMapping = append(f.ChannelMapping, uint8(n))
+			}
+
+		case "num_streams":
+			f.NumStreams, err = opusParseInt(key, val)
+			if err != nil {
+				return err
+			}
+
+		case "coupled_streams":
+			f.CoupledStreams, err = opusParseInt(key, val)
+			if err != nil {
+				return err
+			}
+
+		case "useinbandfec":
+			f.UseInbandFEC, err = opusParseBool(key, val)
+			if err != nil {
+				return err
+			}
+
+		case "usedtx":
+			f.UseDTX, err = opusParseBool(key, val)
+			if err != nil {
+				return err
+			}
+
+		case "maxaveragebitrate":
+			f.MaxAverageBitrate, err = opusParseInt(key, val)
+			if err != nil {
+				return err
+			}
+
+		case "maxplaybackrate":
+			f.MaxPlaybackRate, err = opusParseInt(key, val)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
+	if f.ChannelCount != 0 && f.ChannelMapping != nil && len(f.ChannelMapping) != f.ChannelCount {
+		return fmt.Errorf("channel_mapping does not match channel count")
+	}
+
 	return nil
 }
 
@@ -63,6 +180,10 @@ func (f *Opus) PayloadType() uint8 {
 
 // RTPMap implements Format.
 func (f *Opus) RTPMap() string {
+	if f.ChannelCount != 0 {
+		return "multiopus/48000/" + strconv.FormatInt(int64(f.ChannelCount), 10)
+	}
+
 	// RFC7587: The RTP clock rate in "a=rtpmap" MUST be 48000, and the
 	// number of channels MUST be 2.
 	return "opus/48000/2"
@@ -78,6 +199,39 @@ func (f *Opus) FMTP() map[string]string {
 			return "0"
 		}(),
 	}
+
+	if f.ChannelMapping != nil {
+		tmp := make([]string, len(f.ChannelMapping))
+		for i, v := range f.ChannelMapping {
+			tmp[i] = strconv.FormatUint(uint64(v), 10)
+		}
+		fmtp["channel_mapping"] = strings.Join(tmp, ",")
+	}
+
+	if f.NumStreams != nil {
+		fmtp["num_streams"] = strconv.FormatInt(int64(*f.NumStreams), 10)
+	}
+
+	if f.CoupledStreams != nil {
+		fmtp["coupled_streams"] = strconv.FormatInt(int64(*f.CoupledStreams), 10)
+	}
+
+	if f.UseInbandFEC != nil {
+		fmtp["useinbandfec"] = opusMarshalBool(*f.UseInbandFEC)
+	}
+
+	if f.UseDTX != nil {
+		fmtp["usedtx"] = opusMarshalBool(*f.UseDTX)
+	}
+
+	if f.MaxAverageBitrate != nil {
+		fmtp["maxaveragebitrate"] = strconv.FormatInt(int64(*f.MaxAverageBitrate), 10)
+	}
+
+	if f.MaxPlaybackRate != nil {
+		fmtp["maxplaybackrate"] = strconv.FormatInt(int64(*f.MaxPlaybackRate), 10)
+	}
+
 	return fmtp
 }
 
@@ -104,6 +258,14 @@ func (f *Opus) CreateEncoder() (*rtpsimpleaudio.Encoder, error) {
 		PayloadType: f.PayloadTyp,
 	}
 
+	if f.MaxAverageBitrate != nil {
+		// size of the longest packet at the maximum average bitrate
+		e.PayloadMaxSize = *f.MaxAverageBitrate * opusMaxPacketDuration / 1000 / 8
+		if e.PayloadMaxSize < 1 {
+			e.PayloadMaxSize = 1
+		}
+	}
+
 	err := e.Init()
 	if err != nil {
 		return nil, err
@@ -34,3 +34,19 @@ func TestOpusDecEncoder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
 }
+
+func TestOpusEncoderMaxAverageBitrate(t *testing.T) {
+	format := &Opus{
+		PayloadTyp:        96,
+		MaxAverageBitrate: intPtr(6000),
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	_, err = enc.Encode(make([]byte, 90))
+	require.NoError(t, err)
+
+	_, err = enc.Encode(make([]byte, 91))
+	require.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+)
+
+// RED is a RTP format for redundant audio data,
+// that allows to recover lost packets of another format of the same media.
+// Specification: https://datatracker.ietf.org/doc/html/rfc2198
+type RED struct {
+	PayloadTyp   uint8
+	ClockRat     int
+	ChannelCount int
+	// payload types of the primary encoding and of redundant encodings.
+	PayloadTypes []uint8
+}
+
+func (f *RED) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	tmp := strings.SplitN(ctx.clock, "/", 2)
+
+	clockRate, err := strconv.ParseUint(tmp[0], 10, 31)
+	if err != nil {
+		return fmt.Errorf("invalid clock rate (%v)", ctx.clock)
+	}
+	f.ClockRat = int(clockRate)
+
+	if len(tmp) >= 2 {
+		channelCount, err := strconv.ParseUint(tmp[1], 10, 31)
+		if err != nil || channelCount == 0 {
+			return fmt.Errorf("invalid channel count (%v)", tmp[1])
+		}
+		f.ChannelCount = int(channelCount)
+	} else {
+		f.ChannelCount = 1
+	}
+
+	for _, val := range strings.Split(ctx.fmtp[""], "/") {
+		tmp, err := strconv.ParseUint(val, 10, 7)
+		if err != nil {
+			return fmt.Errorf("invalid payload type (%v)", val)
+		}
+		f.PayloadTypes = append(f.PayloadTypes, uint8(tmp))
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *RED) Codec() string {
+	return "RED"
+}
+
+// ClockRate implements Format.
+func (f *RED) ClockRate() int {
+	return f.ClockRat
+}
+
+// PayloadType implements Format.
+func (f *RED) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *RED) RTPMap() string {
+	ret := "red/" + strconv.FormatInt(int64(f.ClockRat), 10)
+
+	if f.ChannelCount > 1 {
+		ret += "/" + strconv.FormatInt(int64(f.ChannelCount), 10)
+	}
+
+	return ret
+}
+
+// FMTP implements Format.
+func (f *RED) FMTP() map[string]string {
+	tmp := make([]string, len(f.PayloadTypes))
+	for i, typ := range f.PayloadTypes {
+		tmp[i] = strconv.FormatUint(uint64(typ), 10)
+	}
+
+	return map[string]string{
+		"": strings.Join(tmp, "/"),
+	}
+}
+
+// PTSEqualsDTS implements Format.
+func (f *RED) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
@@ -0,0 +1,20 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREDAttributes(t *testing.T) {
+	format := &RED{
+		PayloadTyp:   63,
+		ClockRat:     48000,
+		ChannelCount: 2,
+		PayloadTypes: []uint8{111, 111},
+	}
+	require.Equal(t, "RED", format.Codec())
+	require.Equal(t, 48000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
@@ -0,0 +1,68 @@
+package rtpac4
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+func joinFragments(fragments [][]byte, size int) []byte {
+	ret := make([]byte, size)
+	n := 0
+	for _, p := range fragments {
+		n += copy(ret[n:], p)
+	}
+	return ret
+}
+
+// Decoder is a RTP/AC-4 decoder.
+// Each frame is carried by one or more RTP packets.
+// The last packet of a frame has the marker bit set.
+// Specification: ETSI TS 103 190-2
+type Decoder struct {
+	fragments     [][]byte
+	fragmentsSize int
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes a frame from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	if len(pkt.Payload) == 0 {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		d.fragmentsSize = 0
+		return nil, fmt.Errorf("payload is empty")
+	}
+
+	if d.fragmentsSize == 0 && pkt.Marker {
+		return pkt.Payload, nil
+	}
+
+	d.fragmentsSize += len(pkt.Payload)
+	if d.fragmentsSize > MaxFrameSize {
+		errSize := d.fragmentsSize
+		d.fragments = d.fragments[:0] // discard pending fragments
+		d.fragmentsSize = 0
+		return nil, fmt.Errorf("frame size (%d) is too big, maximum is %d",
+			errSize, MaxFrameSize)
+	}
+
+	d.fragments = append(d.fragments, pkt.Payload)
+
+	if !pkt.Marker {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	frame := joinFragments(d.fragments, d.fragmentsSize)
+	d.fragments = d.fragments[:0]
+	d.fragmentsSize = 0
+
+	return frame, nil
+}
@@ -0,0 +1,5 @@
+// Package rtpac4 contains a RTP/AC-4 decoder and encoder.
+package rtpac4
+
+// MaxFrameSize is the maximum size of a frame.
+const MaxFrameSize = 64 * 1024
@@ -0,0 +1,106 @@
+package rtpamr
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/pkg/bits"
+	"github.com/pion/rtp"
+)
+
+// Decoder is a RTP/AMR decoder.
+// Frames are returned in the storage format (RFC4867, section 5.3):
+// a header byte containing frame type and quality indicator,
+// followed by speech bits, padded to a byte boundary.
+// Specification: https://datatracker.ietf.org/doc/html/rfc4867
+type Decoder struct {
+	// whether the AMR-WB variant is in use.
+	Wideband bool
+
+	// whether the octet-aligned mode is in use.
+	// Otherwise, the bandwidth-efficient mode is used.
+	OctetAlign bool
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes frames from a RTP packet.
+// In case of multiple channels, frames of each frame-block are returned in order of channel.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
+	payload := pkt.Payload
+	pos := 0
+
+	// codec mode request
+	_, err := bits.ReadBits(payload, &pos, 4)
+	if err != nil {
+		return nil, fmt.Errorf("payload is too short")
+	}
+
+	if d.OctetAlign {
+		pos += 4
+	}
+
+	// table of contents
+	var headers []byte
+
+	for {
+		err = bits.HasSpace(payload, pos, 6)
+		if err != nil {
+			return nil, fmt.Errorf("payload is too short")
+		}
+
+		follow := bits.ReadFlagUnsafe(payload, &pos)
+		frameType := uint8(bits.ReadBitsUnsafe(payload, &pos, 4))
+		quality := bits.ReadFlagUnsafe(payload, &pos)
+
+		if d.OctetAlign {
+			pos += 2
+		}
+
+		header := frameType << 3
+		if quality {
+			header |= 1 << 2
+		}
+		headers = append(headers, header)
+
+		if !follow {
+			break
+		}
+	}
+
+	frames := make([][]byte, len(headers))
+
+	for i, header := range headers {
+		n, err := frameBits(d.Wideband, header>>3)
+		if err != nil {
+			return nil, err
+		}
+
+		err = bits.HasSpace(payload, pos, n)
+		if err != nil {
+			return nil, fmt.Errorf("payload is too short")
+		}
+
+		frame := make([]byte, 1+(n+7)/8)
+		frame[0] = header
+
+		for j := 1; n > 0; j++ {
+			le := n
+			if le > 8 {
+				le = 8
+			}
+			frame[j] = byte(bits.ReadBitsUnsafe(payload, &pos, le) << (8 - le))
+			n -= le
+		}
+
+		if d.OctetAlign {
+			pos = (pos + 7) &^ 7
+		}
+
+		frames[i] = frame
+	}
+
+	return frames, nil
+}
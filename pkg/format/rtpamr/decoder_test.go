@@ -0,0 +1,111 @@
+package rtpamr
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				Wideband:   ca.wideband,
+				OctetAlign: ca.octetAlign,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var frames [][]byte
+
+			for _, pkt := range ca.pkts {
+				clone := pkt.Clone()
+
+				addFrames, err := d.Decode(pkt)
+				require.NoError(t, err)
+
+				// test input integrity
+				require.Equal(t, clone, pkt)
+
+				frames = append(frames, addFrames...)
+			}
+
+			require.Equal(t, ca.frames, frames)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name       string
+		octetAlign bool
+		payload    []byte
+		err        string
+	}{
+		{
+			"empty",
+			false,
+			[]byte{},
+			"payload is too short",
+		},
+		{
+			"missing table of contents",
+			true,
+			[]byte{0xf0},
+			"payload is too short",
+		},
+		{
+			"invalid frame type",
+			true,
+			[]byte{0xf0, 12 << 3},
+			"invalid frame type: 12",
+		},
+		{
+			"missing speech bits",
+			true,
+			[]byte{0xf0, 0x3c, 0x01, 0x02},
+			"payload is too short",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				OctetAlign: ca.octetAlign,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			_, err = d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: ca.payload,
+			})
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, wideband bool, octetAlign bool, payload []byte) {
+		d := &Decoder{
+			Wideband:   wideband,
+			OctetAlign: octetAlign,
+		}
+		err := d.Init()
+		require.NoError(t, err)
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: 17645,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: payload,
+		})
+	})
+}
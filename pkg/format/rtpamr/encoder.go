@@ -0,0 +1,214 @@
+package rtpamr
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/pkg/bits"
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+
+	// RFC4867: no mode request is present.
+	codecModeRequestNone = 15
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/AMR encoder.
+// Frames must be provided in the storage format (RFC4867, section 5.3).
+// Specification: https://datatracker.ietf.org/doc/html/rfc4867
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// whether the AMR-WB variant is in use.
+	Wideband bool
+
+	// whether to use the octet-aligned mode.
+	// Otherwise, the bandwidth-efficient mode is used.
+	OctetAlign bool
+
+	// number of channels (optional).
+	// It defaults to 1.
+	ChannelCount int
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	sequenceNumber uint16
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.ChannelCount == 0 {
+		e.ChannelCount = 1
+	}
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	return nil
+}
+
+// Encode encodes frames into RTP packets.
+// In case of multiple channels, frames of each frame-block must be provided in order of channel.
+func (e *Encoder) Encode(frames [][]byte) ([]*rtp.Packet, error) {
+	if (len(frames) % e.ChannelCount) != 0 {
+		return nil, fmt.Errorf("frame count is not a multiple of channel count")
+	}
+
+	speechBits := make([]int, len(frames))
+
+	for i, frame := range frames {
+		if len(frame) == 0 {
+			return nil, fmt.Errorf("frame is empty")
+		}
+
+		n, err := frameBits(e.Wideband, frame[0]>>3)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(frame) != 1+(n+7)/8 {
+			return nil, fmt.Errorf("invalid frame size")
+		}
+
+		speechBits[i] = n
+	}
+
+	var rets []*rtp.Packet
+	timestamp := uint32(0)
+
+	// split frame-blocks into batches
+	for len(frames) > 0 {
+		blockCount := 0
+
+		for (blockCount+1)*e.ChannelCount <= len(frames) &&
+			e.payloadSize(speechBits[:(blockCount+1)*e.ChannelCount]) <= e.PayloadMaxSize {
+			blockCount++
+		}
+
+		if blockCount == 0 {
+			return nil, fmt.Errorf("frame is too big")
+		}
+
+		n := blockCount * e.ChannelCount
+		rets = append(rets, e.writeBatch(frames[:n], speechBits[:n], timestamp))
+		timestamp += uint32(blockCount * SamplesPerFrame(e.Wideband))
+
+		frames = frames[n:]
+		speechBits = speechBits[n:]
+	}
+
+	return rets, nil
+}
+
+func (e *Encoder) payloadSize(speechBits []int) int {
+	if e.OctetAlign {
+		n := 1 + len(speechBits)
+		for _, sb := range speechBits {
+			n += (sb + 7) / 8
+		}
+		return n
+	}
+
+	n := 4 + len(speechBits)*6
+	for _, sb := range speechBits {
+		n += sb
+	}
+	return (n + 7) / 8
+}
+
+func (e *Encoder) writeBatch(frames [][]byte, speechBits []int, timestamp uint32) *rtp.Packet {
+	payload := make([]byte, e.payloadSize(speechBits))
+	pos := 0
+
+	bits.WriteBits(payload, &pos, codecModeRequestNone, 4)
+	if e.OctetAlign {
+		pos += 4
+	}
+
+	for i, frame := range frames {
+		if i != (len(frames) - 1) {
+			bits.WriteBits(payload, &pos, 1, 1)
+		} else {
+			pos++
+		}
+
+		// frame type and quality indicator
+		bits.WriteBits(payload, &pos, uint64((frame[0]>>2)&0x1F), 5)
+
+		if e.OctetAlign {
+			pos += 2
+		}
+	}
+
+	for i, frame := range frames {
+		n := speechBits[i]
+
+		for _, b := range frame[1:] {
+			le := n
+			if le > 8 {
+				le = 8
+			}
+			bits.WriteBits(payload, &pos, uint64(b>>(8-le)), le)
+			n -= le
+		}
+
+		if e.OctetAlign {
+			pos = (pos + 7) &^ 7
+		}
+	}
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.PayloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      timestamp,
+			SSRC:           *e.SSRC,
+			Marker:         false,
+		},
+		Payload: payload,
+	}
+
+	e.sequenceNumber++
+
+	return pkt
+}
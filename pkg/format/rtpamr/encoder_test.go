@@ -0,0 +1,283 @@
+//nolint:dupl
+package rtpamr
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+var cases = []struct {
+	name       string
+	wideband   bool
+	octetAlign bool
+	frames     [][]byte
+	pkts       []*rtp.Packet
+}{
+	{
+		"narrowband octet-aligned",
+		false,
+		true,
+		[][]byte{
+			{
+				0x3c, 0x10, 0x17, 0x1e, 0x25, 0x2c, 0x33, 0x3a,
+				0x41, 0x48, 0x4f, 0x56, 0x5d, 0x64, 0x6b, 0x72,
+				0x79, 0x80, 0x87, 0x8e, 0x95, 0x9c, 0xa3, 0xaa,
+				0xb1, 0xb8, 0xbf, 0xc6, 0xcd, 0xd4, 0xdb, 0xe0,
+			},
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0xf0, 0x3c, 0x10, 0x17, 0x1e, 0x25, 0x2c, 0x33,
+					0x3a, 0x41, 0x48, 0x4f, 0x56, 0x5d, 0x64, 0x6b,
+					0x72, 0x79, 0x80, 0x87, 0x8e, 0x95, 0x9c, 0xa3,
+					0xaa, 0xb1, 0xb8, 0xbf, 0xc6, 0xcd, 0xd4, 0xdb,
+					0xe0,
+				},
+			},
+		},
+	},
+	{
+		"narrowband bandwidth-efficient",
+		false,
+		false,
+		[][]byte{
+			{
+				0x3c, 0x10, 0x17, 0x1e, 0x25, 0x2c, 0x33, 0x3a,
+				0x41, 0x48, 0x4f, 0x56, 0x5d, 0x64, 0x6b, 0x72,
+				0x79, 0x80, 0x87, 0x8e, 0x95, 0x9c, 0xa3, 0xaa,
+				0xb1, 0xb8, 0xbf, 0xc6, 0xcd, 0xd4, 0xdb, 0xe0,
+			},
+			{
+				0x14, 0x40, 0x47, 0x4e, 0x55, 0x5c, 0x63, 0x6a,
+				0x71, 0x78, 0x7f, 0x86, 0x8d, 0x94, 0x9b, 0xa0,
+			},
+			{
+				0x78,
+			},
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0xfb, 0xe5, 0x78, 0x40, 0x5c, 0x78, 0x94, 0xb0,
+					0xcc, 0xe9, 0x05, 0x21, 0x3d, 0x59, 0x75, 0x91,
+					0xad, 0xc9, 0xe6, 0x02, 0x1e, 0x3a, 0x56, 0x72,
+					0x8e, 0xaa, 0xc6, 0xe2, 0xff, 0x1b, 0x37, 0x53,
+					0x6f, 0x90, 0x11, 0xd3, 0x95, 0x57, 0x18, 0xda,
+					0x9c, 0x5e, 0x1f, 0xe1, 0xa3, 0x65, 0x26, 0xe8,
+				},
+			},
+		},
+	},
+	{
+		"wideband octet-aligned",
+		true,
+		true,
+		[][]byte{
+			{
+				0x44, 0x20, 0x27, 0x2e, 0x35, 0x3c, 0x43, 0x4a,
+				0x51, 0x58, 0x5f, 0x66, 0x6d, 0x74, 0x7b, 0x82,
+				0x89, 0x90, 0x97, 0x9e, 0xa5, 0xac, 0xb3, 0xba,
+				0xc1, 0xc8, 0xcf, 0xd6, 0xdd, 0xe4, 0xeb, 0xf2,
+				0xf9, 0x00, 0x07, 0x0e, 0x15, 0x1c, 0x23, 0x2a,
+				0x31, 0x38, 0x3f, 0x46, 0x4d, 0x54, 0x5b, 0x62,
+				0x69, 0x70, 0x77, 0x7e, 0x85, 0x8c, 0x93, 0x9a,
+				0xa1, 0xa8, 0xaf, 0xb6, 0xb8,
+			},
+			{
+				0x00, 0x55, 0x5c, 0x63, 0x6a, 0x71, 0x78, 0x7f,
+				0x86, 0x8d, 0x94, 0x9b, 0xa2, 0xa9, 0xb0, 0xb7,
+				0xbe, 0xc0,
+			},
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0xf0, 0xc4, 0x00, 0x20, 0x27, 0x2e, 0x35, 0x3c,
+					0x43, 0x4a, 0x51, 0x58, 0x5f, 0x66, 0x6d, 0x74,
+					0x7b, 0x82, 0x89, 0x90, 0x97, 0x9e, 0xa5, 0xac,
+					0xb3, 0xba, 0xc1, 0xc8, 0xcf, 0xd6, 0xdd, 0xe4,
+					0xeb, 0xf2, 0xf9, 0x00, 0x07, 0x0e, 0x15, 0x1c,
+					0x23, 0x2a, 0x31, 0x38, 0x3f, 0x46, 0x4d, 0x54,
+					0x5b, 0x62, 0x69, 0x70, 0x77, 0x7e, 0x85, 0x8c,
+					0x93, 0x9a, 0xa1, 0xa8, 0xaf, 0xb6, 0xb8, 0x55,
+					0x5c, 0x63, 0x6a, 0x71, 0x78, 0x7f, 0x86, 0x8d,
+					0x94, 0x9b, 0xa2, 0xa9, 0xb0, 0xb7, 0xbe, 0xc0,
+				},
+			},
+		},
+	},
+	{
+		"wideband bandwidth-efficient",
+		true,
+		false,
+		[][]byte{
+			{
+				0x44, 0x20, 0x27, 0x2e, 0x35, 0x3c, 0x43, 0x4a,
+				0x51, 0x58, 0x5f, 0x66, 0x6d, 0x74, 0x7b, 0x82,
+				0x89, 0x90, 0x97, 0x9e, 0xa5, 0xac, 0xb3, 0xba,
+				0xc1, 0xc8, 0xcf, 0xd6, 0xdd, 0xe4, 0xeb, 0xf2,
+				0xf9, 0x00, 0x07, 0x0e, 0x15, 0x1c, 0x23, 0x2a,
+				0x31, 0x38, 0x3f, 0x46, 0x4d, 0x54, 0x5b, 0x62,
+				0x69, 0x70, 0x77, 0x7e, 0x85, 0x8c, 0x93, 0x9a,
+				0xa1, 0xa8, 0xaf, 0xb6, 0xb8,
+			},
+			{
+				0x4c, 0x33, 0x3a, 0x41, 0x48, 0x4f,
+			},
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0xfc, 0x53, 0x20, 0x27, 0x2e, 0x35, 0x3c, 0x43,
+					0x4a, 0x51, 0x58, 0x5f, 0x66, 0x6d, 0x74, 0x7b,
+					0x82, 0x89, 0x90, 0x97, 0x9e, 0xa5, 0xac, 0xb3,
+					0xba, 0xc1, 0xc8, 0xcf, 0xd6, 0xdd, 0xe4, 0xeb,
+					0xf2, 0xf9, 0x00, 0x07, 0x0e, 0x15, 0x1c, 0x23,
+					0x2a, 0x31, 0x38, 0x3f, 0x46, 0x4d, 0x54, 0x5b,
+					0x62, 0x69, 0x70, 0x77, 0x7e, 0x85, 0x8c, 0x93,
+					0x9a, 0xa1, 0xa8, 0xaf, 0xb6, 0xb9, 0x99, 0xd2,
+					0x0a, 0x42, 0x78,
+				},
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				Wideband:              ca.wideband,
+				OctetAlign:            ca.octetAlign,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(0x44ed),
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.frames)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeSplit(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		ChannelCount:          2,
+		SSRC:                  uint32Ptr(0x9dbb7812),
+		InitialSequenceNumber: uint16Ptr(0x44ed),
+		PayloadMaxSize:        30,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	// mode 0, 95 speech bits
+	frame := make([]byte, 13)
+
+	pkts, err := e.Encode([][]byte{frame, frame, frame, frame})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pkts))
+	require.Equal(t, uint16(0x44ed), pkts[0].SequenceNumber)
+	require.Equal(t, uint32(0), pkts[0].Timestamp)
+	require.Equal(t, uint16(0x44ee), pkts[1].SequenceNumber)
+	require.Equal(t, uint32(160), pkts[1].Timestamp)
+
+	d := &Decoder{}
+	err = d.Init()
+	require.NoError(t, err)
+
+	for _, pkt := range pkts {
+		frames, err := d.Decode(pkt)
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{frame, frame}, frames)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name   string
+		frames [][]byte
+		err    string
+	}{
+		{
+			"empty",
+			[][]byte{{}},
+			"frame is empty",
+		},
+		{
+			"invalid frame type",
+			[][]byte{{12 << 3}},
+			"invalid frame type: 12",
+		},
+		{
+			"invalid size",
+			[][]byte{{0x00, 0x01}},
+			"invalid frame size",
+		},
+		{
+			"too big",
+			[][]byte{append([]byte{0x00}, make([]byte, 12)...)},
+			"frame is too big",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:    96,
+				PayloadMaxSize: 10,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			_, err = e.Encode(ca.frames)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
@@ -0,0 +1,39 @@
+package rtpamr
+
+import (
+	"fmt"
+)
+
+// number of speech bits of each frame type.
+// a negative value means that the frame type is reserved.
+var (
+	// 3GPP TS 26.101, table 1a
+	frameBitsNarrowband = [16]int{95, 103, 118, 134, 148, 159, 204, 244, 39, 43, 38, 37, -1, -1, -1, 0}
+
+	// 3GPP TS 26.201, table 1a
+	frameBitsWideband = [16]int{132, 177, 253, 285, 317, 365, 397, 461, 477, 40, -1, -1, -1, -1, 0, 0}
+)
+
+func frameBits(wideband bool, frameType uint8) (int, error) {
+	var n int
+	if wideband {
+		n = frameBitsWideband[frameType&0x0F]
+	} else {
+		n = frameBitsNarrowband[frameType&0x0F]
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("invalid frame type: %d", frameType)
+	}
+
+	return n, nil
+}
+
+// SamplesPerFrame returns the number of samples contained in a frame.
+func SamplesPerFrame(wideband bool) int {
+	// each frame contains 20ms of audio.
+	if wideband {
+		return 320
+	}
+	return 160
+}
@@ -0,0 +1,2 @@
+// Package rtpamr contains a RTP/AMR decoder and encoder.
+package rtpamr
@@ -31,6 +31,14 @@ func joinFragments(fragments [][]byte, size int) []byte {
 // Decoder is a RTP/AV1 decoder.
 // Specification: https://aomediacodec.github.io/av1-rtp-spec/
 type Decoder struct {
+	// if set, OBUs with a temporal ID greater than this value are discarded.
+	// OBUs without an extension header belong to the base layer and are never discarded.
+	MaxTemporalID *uint8
+
+	// if set, OBUs with a spatial ID greater than this value are discarded.
+	// OBUs without an extension header belong to the base layer and are never discarded.
+	MaxSpatialID *uint8
+
 	firstPacketReceived bool
 	fragmentsSize       int
 	fragments           [][]byte
@@ -160,10 +168,35 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 
 	ret := d.frameBuffer
 
+	if d.MaxTemporalID != nil || d.MaxSpatialID != nil {
+		ret = d.filterLayers(ret)
+	}
+
 	// do not reuse frameBuffer to avoid race conditions
 	d.frameBuffer = nil
 	d.frameBufferLen = 0
 	d.frameBufferSize = 0
 
+	if len(ret) == 0 {
+		return nil, ErrMorePacketsNeeded
+	}
+
 	return ret, nil
 }
+
+func (d *Decoder) filterLayers(obus [][]byte) [][]byte {
+	ret := make([][]byte, 0, len(obus))
+
+	for _, obu := range obus {
+		temporalID, spatialID := OBULayer(obu)
+
+		if (d.MaxTemporalID != nil && temporalID > *d.MaxTemporalID) ||
+			(d.MaxSpatialID != nil && spatialID > *d.MaxSpatialID) {
+			continue
+		}
+
+		ret = append(ret, obu)
+	}
+
+	return ret
+}
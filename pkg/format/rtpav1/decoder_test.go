@@ -84,3 +84,43 @@ func FuzzDecoder(f *testing.F) {
 		})
 	})
 }
+
+func TestDecodeMaxLayers(t *testing.T) {
+	maxTemporalID := uint8(0)
+	maxSpatialID := uint8(1)
+
+	d := &Decoder{
+		MaxTemporalID: &maxTemporalID,
+		MaxSpatialID:  &maxSpatialID,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		obu       []byte
+		discarded bool
+	}{
+		{[]byte{0x30, 0x01}, false},
+		{[]byte{0x34, 0x08, 0x01}, false},
+		{[]byte{0x34, 0x20, 0x01}, true},
+		{[]byte{0x34, 0x10, 0x01}, true},
+	} {
+		obus, err := d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 17645,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: append([]byte{0x00, byte(len(ca.obu))}, ca.obu...),
+		})
+
+		if ca.discarded {
+			require.Equal(t, ErrMorePacketsNeeded, err)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{ca.obu}, obus)
+		}
+	}
+}
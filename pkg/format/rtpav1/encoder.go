@@ -68,12 +68,17 @@ func (e *Encoder) Init() error {
 }
 
 // Encode encodes OBUs into RTP packets.
+// Temporal delimiters and tile lists are not transmitted.
+// OBUs that fit into a single packet are never fragmented.
 func (e *Encoder) Encode(obus [][]byte) ([]*rtp.Packet, error) {
-	isKeyFrame, err := av1.ContainsKeyFrame(obus)
+	obus, err := filterOBUs(obus)
 	if err != nil {
 		return nil, err
 	}
 
+	// a temporal unit that starts with a sequence header contains a key frame
+	isKeyFrame := obuType(obus[0]) == obuTypeSequenceHeader
+
 	var curPacket *rtp.Packet
 	var packets []*rtp.Packet
 	curPayloadLen := 0
@@ -120,6 +125,14 @@ func (e *Encoder) Encode(obus [][]byte) ([]*rtp.Packet, error) {
 				break
 			}
 
+			// start a new packet instead of fragmenting the OBU,
+			// in order to keep tile groups and frames decodable by themselves.
+			if curPayloadLen > 1 && needed <= (e.PayloadMaxSize-1) {
+				finalizeCurPacket(false)
+				createNewPacket(false)
+				continue
+			}
+
 			if avail > 2 {
 				fragmentLen := avail - 2
 				buf := make([]byte, av1.LEB128MarshalSize(uint(fragmentLen)))
@@ -127,10 +140,14 @@ func (e *Encoder) Encode(obus [][]byte) ([]*rtp.Packet, error) {
 				curPacket.Payload = append(curPacket.Payload, buf...)
 				curPacket.Payload = append(curPacket.Payload, obu[:fragmentLen]...)
 				obu = obu[fragmentLen:]
+
+				finalizeCurPacket(true)
+				createNewPacket(true)
+				continue
 			}
 
-			finalizeCurPacket(true)
-			createNewPacket(true)
+			finalizeCurPacket(false)
+			createNewPacket(false)
 		}
 	}
 
@@ -1001,3 +1001,41 @@ func TestEncodeRandomInitialState(t *testing.T) {
 	require.NotEqual(t, nil, e.SSRC)
 	require.NotEqual(t, nil, e.InitialSequenceNumber)
 }
+
+func TestEncodeRemoveOBUs(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		SSRC:                  uint32Ptr(0x9dbb7812),
+		InitialSequenceNumber: uint16Ptr(0x44ed),
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	// temporal delimiter and tile list
+	pkts, err := e.Encode([][]byte{{0x12, 0x00}, shortOBU, {0x40, 0x01}})
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{0x08, 0x10}, shortOBU...), pkts[0].Payload)
+
+	_, err = e.Encode([][]byte{{0x12, 0x00}})
+	require.EqualError(t, err, "temporal unit is empty")
+}
+
+func TestEncodeAvoidFragmentation(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		SSRC:                  uint32Ptr(0x9dbb7812),
+		InitialSequenceNumber: uint16Ptr(0x44ed),
+		PayloadMaxSize:        100,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	obu1 := layerOBU(0, 0, 60)
+	obu2 := layerOBU(0, 1, 60)
+
+	pkts, err := e.Encode([][]byte{obu1, obu2})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pkts))
+	require.Equal(t, append([]byte{0x00, byte(len(obu1))}, obu1...), pkts[0].Payload)
+	require.Equal(t, append([]byte{0x00, byte(len(obu2))}, obu2...), pkts[1].Payload)
+}
@@ -0,0 +1,126 @@
+package rtpav1
+
+import (
+	"github.com/bluenviron/mediacommon/pkg/codecs/av1"
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+// LayerFilter removes OBUs that belong to enhancement layers from RTP/AV1 packets,
+// allowing to reduce the bitrate of a scalable stream without re-encoding it.
+// Packets that remain empty are dropped and sequence numbers
+// of the following packets are rewritten in order to hide the gap.
+type LayerFilter struct {
+	// if set, OBUs with a temporal ID greater than this value are removed.
+	MaxTemporalID *uint8
+
+	// if set, OBUs with a spatial ID greater than this value are removed.
+	MaxSpatialID *uint8
+
+	fragmentAllowed bool
+	droppedCount    uint16
+}
+
+func (f *LayerFilter) allowed(obu []byte) bool {
+	temporalID, spatialID := OBULayer(obu)
+
+	return (f.MaxTemporalID == nil || temporalID <= *f.MaxTemporalID) &&
+		(f.MaxSpatialID == nil || spatialID <= *f.MaxSpatialID)
+}
+
+// Process processes a RTP packet.
+// It returns nil if the packet must be dropped, otherwise it returns
+// either the packet itself or a modified copy of it.
+// The input packet is never modified.
+func (f *LayerFilter) Process(pkt *rtp.Packet) *rtp.Packet {
+	var av1header codecs.AV1Packet
+	_, err := av1header.Unmarshal(pkt.Payload)
+	if err != nil {
+		// forward packets that can't be parsed
+		return f.rewrite(pkt, nil)
+	}
+
+	elementCount := len(av1header.OBUElements)
+	kept := make([][]byte, 0, elementCount)
+	keptFirst := false
+	keptLast := false
+
+	for i, el := range av1header.OBUElements {
+		var allowed bool
+
+		if i == 0 && av1header.Z {
+			// continuation of the OBU fragment of the previous packet
+			allowed = f.fragmentAllowed
+		} else {
+			allowed = f.allowed(el)
+		}
+
+		if i == (elementCount-1) && av1header.Y {
+			f.fragmentAllowed = allowed
+		}
+
+		if allowed {
+			kept = append(kept, el)
+			if i == 0 {
+				keptFirst = true
+			}
+			if i == (elementCount - 1) {
+				keptLast = true
+			}
+		}
+	}
+
+	if len(kept) == elementCount {
+		return f.rewrite(pkt, nil)
+	}
+
+	if len(kept) == 0 {
+		// the marker must reach the receiver, otherwise the end of the temporal unit
+		// can't be detected. Replace the packet with one that contains a padding OBU.
+		if pkt.Marker {
+			return f.rewrite(pkt, []byte{0, 1, obuTypePadding << 3})
+		}
+
+		f.droppedCount++
+		return nil
+	}
+
+	var aggregationHeader byte
+	if av1header.Z && keptFirst {
+		aggregationHeader |= 1 << 7
+	}
+	if av1header.Y && keptLast {
+		aggregationHeader |= 1 << 6
+	}
+	if av1header.N {
+		aggregationHeader |= 1 << 3
+	}
+
+	payload := []byte{aggregationHeader}
+
+	for _, el := range kept {
+		buf := make([]byte, av1.LEB128MarshalSize(uint(len(el))))
+		av1.LEB128MarshalTo(uint(len(el)), buf)
+		payload = append(payload, buf...)
+		payload = append(payload, el...)
+	}
+
+	return f.rewrite(pkt, payload)
+}
+
+func (f *LayerFilter) rewrite(pkt *rtp.Packet, payload []byte) *rtp.Packet {
+	if f.droppedCount == 0 && payload == nil {
+		return pkt
+	}
+
+	ret := *pkt
+	ret.SequenceNumber -= f.droppedCount
+
+	if payload != nil {
+		ret.Payload = payload
+		ret.Padding = false
+		ret.PaddingSize = 0
+	}
+
+	return &ret
+}
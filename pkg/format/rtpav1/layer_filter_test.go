@@ -0,0 +1,125 @@
+package rtpav1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func layerOBU(temporalID uint8, spatialID uint8, size int) []byte {
+	return append([]byte{0x34, temporalID<<5 | spatialID<<3}, bytes.Repeat([]byte{temporalID + 1}, size)...)
+}
+
+func TestLayerFilter(t *testing.T) {
+	maxTemporalID := uint8(0)
+	maxSpatialID := uint8(0)
+
+	for _, ca := range []struct {
+		name string
+		obus [][]byte
+		kept [][]byte
+	}{
+		{
+			"base layer only",
+			[][]byte{shortOBU, layerOBU(0, 0, 50)},
+			[][]byte{shortOBU, layerOBU(0, 0, 50)},
+		},
+		{
+			"aggregated",
+			[][]byte{layerOBU(0, 0, 50), layerOBU(1, 0, 50), layerOBU(0, 1, 50)},
+			[][]byte{layerOBU(0, 0, 50)},
+		},
+		{
+			"fragmented",
+			[][]byte{layerOBU(0, 0, 500), layerOBU(1, 0, 2000), layerOBU(0, 1, 3000)},
+			[][]byte{layerOBU(0, 0, 500)},
+		},
+		{
+			"fragmented base layer",
+			[][]byte{layerOBU(1, 0, 100), layerOBU(0, 0, 2000)},
+			[][]byte{layerOBU(0, 0, 2000)},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(0x44ed),
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			f := &LayerFilter{
+				MaxTemporalID: &maxTemporalID,
+				MaxSpatialID:  &maxSpatialID,
+			}
+
+			d := &Decoder{}
+			err = d.Init()
+			require.NoError(t, err)
+
+			var obus [][]byte
+
+			// encode two temporal units, in order to check sequence numbers
+			for i := 0; i < 2; i++ {
+				var pkts []*rtp.Packet
+				pkts, err = e.Encode(ca.obus)
+				require.NoError(t, err)
+
+				for _, pkt := range pkts {
+					orig := pkt.SequenceNumber
+
+					out := f.Process(pkt)
+					require.Equal(t, orig, pkt.SequenceNumber)
+
+					if out == nil {
+						continue
+					}
+
+					var addOBUs [][]byte
+					addOBUs, err = d.Decode(out)
+					if err == ErrMorePacketsNeeded {
+						continue
+					}
+					require.NoError(t, err)
+
+					for _, obu := range addOBUs {
+						// skip padding OBUs
+						if obuType(obu) != obuTypePadding {
+							obus = append(obus, obu)
+						}
+					}
+				}
+			}
+
+			require.Equal(t, append(append([][]byte(nil), ca.kept...), ca.kept...), obus)
+		})
+	}
+}
+
+func TestLayerFilterSequenceNumber(t *testing.T) {
+	maxSpatialID := uint8(0)
+
+	f := &LayerFilter{
+		MaxSpatialID: &maxSpatialID,
+	}
+
+	var seqNums []uint16
+
+	for i, obu := range [][]byte{layerOBU(0, 0, 10), layerOBU(0, 1, 10), layerOBU(0, 0, 10)} {
+		out := f.Process(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				SequenceNumber: uint16(100 + i),
+			},
+			Payload: append([]byte{0x00, byte(len(obu))}, obu...),
+		})
+		if out != nil {
+			seqNums = append(seqNums, out.SequenceNumber)
+		}
+	}
+
+	require.Equal(t, []uint16{100, 101}, seqNums)
+}
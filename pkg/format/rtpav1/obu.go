@@ -0,0 +1,63 @@
+package rtpav1
+
+import (
+	"fmt"
+)
+
+// OBU types that are handled by the encoder and the layer filter.
+// Specification: https://aomediacodec.github.io/av1-spec/#obu-header-semantics
+const (
+	obuTypeSequenceHeader    = 1
+	obuTypeTemporalDelimiter = 2
+	obuTypeTileList          = 8
+	obuTypePadding           = 15
+)
+
+func obuType(obu []byte) uint8 {
+	return (obu[0] >> 3) & 0b1111
+}
+
+// OBULayer returns the temporal ID and the spatial ID of an OBU.
+// They are read from the OBU extension header.
+// OBUs without an extension header belong to the base layer.
+func OBULayer(obu []byte) (uint8, uint8) {
+	if len(obu) < 2 {
+		return 0, 0
+	}
+
+	extensionFlag := ((obu[0] >> 2) & 0b1) != 0
+	if !extensionFlag {
+		return 0, 0
+	}
+
+	return obu[1] >> 5, (obu[1] >> 3) & 0b11
+}
+
+// filterOBUs validates OBUs and removes the ones that must not be transmitted,
+// that are temporal delimiters and tile lists.
+func filterOBUs(obus [][]byte) ([][]byte, error) {
+	ret := make([][]byte, 0, len(obus))
+
+	for _, obu := range obus {
+		if len(obu) == 0 {
+			return nil, fmt.Errorf("OBU is empty")
+		}
+
+		if (obu[0] >> 7) != 0 {
+			return nil, fmt.Errorf("forbidden bit is set")
+		}
+
+		typ := obuType(obu)
+		if typ == obuTypeTemporalDelimiter || typ == obuTypeTileList {
+			continue
+		}
+
+		ret = append(ret, obu)
+	}
+
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("temporal unit is empty")
+	}
+
+	return ret, nil
+}
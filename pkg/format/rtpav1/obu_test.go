@@ -0,0 +1,41 @@
+package rtpav1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOBULayer(t *testing.T) {
+	for _, ca := range []struct {
+		name       string
+		obu        []byte
+		temporalID uint8
+		spatialID  uint8
+	}{
+		{
+			"no extension",
+			[]byte{0x30, 0x01, 0x02},
+			0,
+			0,
+		},
+		{
+			"extension",
+			[]byte{0x34, 0x48, 0x01, 0x02},
+			2,
+			1,
+		},
+		{
+			"truncated",
+			[]byte{0x34},
+			0,
+			0,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			temporalID, spatialID := OBULayer(ca.obu)
+			require.Equal(t, ca.temporalID, temporalID)
+			require.Equal(t, ca.spatialID, spatialID)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package rtpg7221
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// Decoder is a RTP/G722.1 decoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc5577
+type Decoder struct {
+	// bit rate, in bit/s.
+	BitRate int
+
+	frameSize int
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	err := checkBitRate(d.BitRate)
+	if err != nil {
+		return err
+	}
+
+	d.frameSize = FrameSize(d.BitRate)
+	return nil
+}
+
+// Decode decodes frames from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
+	plen := len(pkt.Payload)
+	if plen == 0 {
+		return nil, fmt.Errorf("payload is empty")
+	}
+
+	// RFC5577: the payload consists of one or more consecutive frames,
+	// whose size is determined by the bit rate.
+	if (plen % d.frameSize) != 0 {
+		return nil, fmt.Errorf("payload size (%d) is not a multiple of frame size (%d)", plen, d.frameSize)
+	}
+
+	frames := make([][]byte, plen/d.frameSize)
+	for i := range frames {
+		frames[i] = pkt.Payload[i*d.frameSize : (i+1)*d.frameSize]
+	}
+
+	return frames, nil
+}
@@ -0,0 +1,94 @@
+package rtpg7221
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				BitRate: ca.bitRate,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var frames [][]byte
+
+			for _, pkt := range ca.pkts {
+				clone := pkt.Clone()
+
+				addFrames, err := d.Decode(pkt)
+				require.NoError(t, err)
+
+				// test input integrity
+				require.Equal(t, clone, pkt)
+
+				frames = append(frames, addFrames...)
+			}
+
+			require.Equal(t, ca.frames, frames)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		payload []byte
+		err     string
+	}{
+		{
+			"empty",
+			[]byte{},
+			"payload is empty",
+		},
+		{
+			"invalid size",
+			make([]byte, 70),
+			"payload size (70) is not a multiple of frame size (60)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				BitRate: 24000,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			_, err = d.Decode(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: ca.payload,
+			})
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		d := &Decoder{
+			BitRate: 24000,
+		}
+		err := d.Init()
+		require.NoError(t, err)
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: 17645,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: payload,
+		})
+	})
+}
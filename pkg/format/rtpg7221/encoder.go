@@ -0,0 +1,143 @@
+package rtpg7221
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/G722.1 encoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc5577
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// sample rate (16000, or 32000 with G722.1 annex C).
+	SampleRate int
+
+	// bit rate, in bit/s.
+	BitRate int
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	sequenceNumber     uint16
+	frameSize          int
+	maxFramesPerPacket int
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	if e.SampleRate != 16000 && e.SampleRate != 32000 {
+		return fmt.Errorf("unsupported sample rate: %d", e.SampleRate)
+	}
+
+	err := checkBitRate(e.BitRate)
+	if err != nil {
+		return err
+	}
+
+	// RFC5577: 48000 bit/s is available only with G722.1 annex C.
+	if e.SampleRate == 16000 && e.BitRate == 48000 {
+		return fmt.Errorf("bit rate %d is not supported with sample rate %d", e.BitRate, e.SampleRate)
+	}
+
+	e.frameSize = FrameSize(e.BitRate)
+
+	if e.frameSize > e.PayloadMaxSize {
+		return fmt.Errorf("frame size (%d) is greater than payload max size (%d)", e.frameSize, e.PayloadMaxSize)
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	e.maxFramesPerPacket = e.PayloadMaxSize / e.frameSize
+	return nil
+}
+
+// Encode encodes frames into RTP packets.
+func (e *Encoder) Encode(frames [][]byte) ([]*rtp.Packet, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("frames are empty")
+	}
+
+	for _, frame := range frames {
+		if len(frame) != e.frameSize {
+			return nil, fmt.Errorf("invalid frame size")
+		}
+	}
+
+	var rets []*rtp.Packet
+	timestamp := uint32(0)
+
+	for len(frames) > 0 {
+		n := e.maxFramesPerPacket
+		if n > len(frames) {
+			n = len(frames)
+		}
+
+		payload := make([]byte, n*e.frameSize)
+		for i, frame := range frames[:n] {
+			copy(payload[i*e.frameSize:], frame)
+		}
+
+		rets = append(rets, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.PayloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      timestamp,
+				SSRC:           *e.SSRC,
+				Marker:         false,
+			},
+			Payload: payload,
+		})
+
+		e.sequenceNumber++
+		timestamp += uint32(n * SamplesPerFrame(e.SampleRate))
+		frames = frames[n:]
+	}
+
+	return rets, nil
+}
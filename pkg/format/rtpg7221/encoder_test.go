@@ -0,0 +1,203 @@
+package rtpg7221
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+var cases = []struct {
+	name       string
+	sampleRate int
+	bitRate    int
+	frames     [][]byte
+	pkts       []*rtp.Packet
+}{
+	{
+		"16khz single",
+		16000,
+		24000,
+		[][]byte{bytes.Repeat([]byte{0x01}, 60)},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x01}, 60),
+			},
+		},
+	},
+	{
+		"32khz aggregated",
+		32000,
+		48000,
+		[][]byte{
+			bytes.Repeat([]byte{0x01}, 120),
+			bytes.Repeat([]byte{0x02}, 120),
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: append(
+					bytes.Repeat([]byte{0x01}, 120),
+					bytes.Repeat([]byte{0x02}, 120)...,
+				),
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				SampleRate:            ca.sampleRate,
+				BitRate:               ca.bitRate,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(0x44ed),
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.frames)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeSplit(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		SampleRate:            16000,
+		BitRate:               32000,
+		SSRC:                  uint32Ptr(0x9dbb7812),
+		InitialSequenceNumber: uint16Ptr(0x44ed),
+		PayloadMaxSize:        200,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	frame := make([]byte, 80)
+
+	pkts, err := e.Encode([][]byte{frame, frame, frame, frame})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pkts))
+	require.Equal(t, uint16(0x44ed), pkts[0].SequenceNumber)
+	require.Equal(t, uint32(0), pkts[0].Timestamp)
+	require.Equal(t, uint16(0x44ee), pkts[1].SequenceNumber)
+	require.Equal(t, uint32(640), pkts[1].Timestamp)
+
+	d := &Decoder{
+		BitRate: 32000,
+	}
+	err = d.Init()
+	require.NoError(t, err)
+
+	for _, pkt := range pkts {
+		frames, err := d.Decode(pkt)
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{frame, frame}, frames)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name   string
+		frames [][]byte
+		err    string
+	}{
+		{
+			"empty",
+			[][]byte{},
+			"frames are empty",
+		},
+		{
+			"invalid size",
+			[][]byte{make([]byte, 61)},
+			"invalid frame size",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType: 96,
+				SampleRate:  16000,
+				BitRate:     24000,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			_, err = e.Encode(ca.frames)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncodeInitErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name       string
+		sampleRate int
+		bitRate    int
+		err        string
+	}{
+		{
+			"invalid sample rate",
+			8000,
+			24000,
+			"unsupported sample rate: 8000",
+		},
+		{
+			"invalid bit rate",
+			16000,
+			64000,
+			"unsupported bit rate: 64000",
+		},
+		{
+			"bit rate not supported by sample rate",
+			16000,
+			48000,
+			"bit rate 48000 is not supported with sample rate 16000",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType: 96,
+				SampleRate:  ca.sampleRate,
+				BitRate:     ca.bitRate,
+			}
+			err := e.Init()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+		SampleRate:  16000,
+		BitRate:     24000,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
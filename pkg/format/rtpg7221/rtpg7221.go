@@ -0,0 +1,26 @@
+// Package rtpg7221 contains a RTP/G722.1 decoder and encoder.
+package rtpg7221
+
+import (
+	"fmt"
+)
+
+// FrameSize returns the size of a frame encoded with the given bit rate.
+func FrameSize(bitRate int) int {
+	// each frame contains 20ms of audio.
+	return bitRate / 50 / 8
+}
+
+// SamplesPerFrame returns the number of samples contained in a frame.
+func SamplesPerFrame(sampleRate int) int {
+	// each frame contains 20ms of audio.
+	return sampleRate / 50
+}
+
+func checkBitRate(bitRate int) error {
+	switch bitRate {
+	case 24000, 32000, 48000:
+		return nil
+	}
+	return fmt.Errorf("unsupported bit rate: %d", bitRate)
+}
@@ -0,0 +1,37 @@
+package rtpg726
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// Decoder is a RTP/G726 decoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc3551
+type Decoder struct {
+	// bit rate, in kbit/s (16, 24, 32 or 40).
+	BitRate int
+
+	// whether codewords are packed in big-endian order (AAL2).
+	// Otherwise, they are packed in little-endian order.
+	BigEndian bool
+
+	bits int
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	var err error
+	d.bits, err = codewordBits(d.BitRate)
+	return err
+}
+
+// Decode decodes codewords from a RTP packet.
+// Codewords are returned one per byte, each one corresponding to a sample.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]uint8, error) {
+	if len(pkt.Payload) == 0 {
+		return nil, fmt.Errorf("payload is empty")
+	}
+
+	return unpackCodewords(pkt.Payload, d.bits, d.BigEndian), nil
+}
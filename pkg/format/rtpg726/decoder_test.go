@@ -0,0 +1,79 @@
+package rtpg726
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				BitRate:   ca.bitRate,
+				BigEndian: ca.bigEndian,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var codewords []uint8
+
+			for _, pkt := range ca.pkts {
+				clone := pkt.Clone()
+
+				addCodewords, err := d.Decode(pkt)
+				require.NoError(t, err)
+
+				// test input integrity
+				require.Equal(t, clone, pkt)
+
+				codewords = append(codewords, addCodewords...)
+			}
+
+			require.Equal(t, ca.codewords, codewords)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	d := &Decoder{
+		BitRate: 32,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{},
+	})
+	require.EqualError(t, err, "payload is empty")
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, bigEndian bool, payload []byte) {
+		for _, bitRate := range []int{16, 24, 32, 40} {
+			d := &Decoder{
+				BitRate:   bitRate,
+				BigEndian: bigEndian,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			d.Decode(&rtp.Packet{ //nolint:errcheck
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: payload,
+			})
+		}
+	})
+}
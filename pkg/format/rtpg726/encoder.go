@@ -0,0 +1,130 @@
+package rtpg726
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/G726 encoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc3551
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// bit rate, in kbit/s (16, 24, 32 or 40).
+	BitRate int
+
+	// whether to pack codewords in big-endian order (AAL2).
+	// Otherwise, they are packed in little-endian order.
+	BigEndian bool
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	sequenceNumber        uint16
+	bits                  int
+	maxCodewordsPerPacket int
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	var err error
+	e.bits, err = codewordBits(e.BitRate)
+	if err != nil {
+		return err
+	}
+
+	// groups of 8 codewords always fill an integer number of bytes
+	if e.PayloadMaxSize < e.bits {
+		return fmt.Errorf("payload max size (%d) is too small", e.PayloadMaxSize)
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	e.maxCodewordsPerPacket = (e.PayloadMaxSize / e.bits) * 8
+	return nil
+}
+
+// Encode encodes codewords into RTP packets.
+// Codewords must be provided one per byte, each one corresponding to a sample,
+// and they must fill an integer number of bytes.
+func (e *Encoder) Encode(codewords []uint8) ([]*rtp.Packet, error) {
+	if len(codewords) == 0 {
+		return nil, fmt.Errorf("codewords are empty")
+	}
+
+	if (len(codewords)*e.bits)%8 != 0 {
+		return nil, fmt.Errorf("codewords don't fill an integer number of bytes")
+	}
+
+	var ret []*rtp.Packet
+	timestamp := uint32(0)
+
+	for len(codewords) != 0 {
+		n := e.maxCodewordsPerPacket
+		if n > len(codewords) {
+			n = len(codewords)
+		}
+
+		ret = append(ret, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.PayloadType,
+				SequenceNumber: e.sequenceNumber,
+				Timestamp:      timestamp,
+				SSRC:           *e.SSRC,
+				Marker:         false,
+			},
+			Payload: packCodewords(codewords[:n], e.bits, e.BigEndian),
+		})
+
+		e.sequenceNumber++
+		timestamp += uint32(n)
+		codewords = codewords[n:]
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,259 @@
+package rtpg726
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+var cases = []struct {
+	name      string
+	bitRate   int
+	bigEndian bool
+	codewords []uint8
+	pkts      []*rtp.Packet
+}{
+	{
+		"16 little-endian",
+		16,
+		false,
+		[]uint8{0, 1, 2, 3},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0xe4},
+			},
+		},
+	},
+	{
+		"16 big-endian",
+		16,
+		true,
+		[]uint8{0, 1, 2, 3},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x1b},
+			},
+		},
+	},
+	{
+		"24 little-endian",
+		24,
+		false,
+		[]uint8{0, 1, 2, 3, 4, 5, 6, 7},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x88, 0xc6, 0xfa},
+			},
+		},
+	},
+	{
+		"24 big-endian",
+		24,
+		true,
+		[]uint8{0, 1, 2, 3, 4, 5, 6, 7},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x05, 0x39, 0x77},
+			},
+		},
+	},
+	{
+		"32 little-endian",
+		32,
+		false,
+		[]uint8{1, 2, 3, 4},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x21, 0x43},
+			},
+		},
+	},
+	{
+		"32 big-endian",
+		32,
+		true,
+		[]uint8{1, 2, 3, 4},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x12, 0x34},
+			},
+		},
+	},
+	{
+		"40 big-endian",
+		40,
+		true,
+		[]uint8{0, 1, 2, 3, 4, 5, 6, 7},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x00, 0x44, 0x32, 0x14, 0xc7},
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				BitRate:               ca.bitRate,
+				BigEndian:             ca.bigEndian,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(0x44ed),
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.codewords)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeSplit(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		BitRate:               40,
+		SSRC:                  uint32Ptr(0x9dbb7812),
+		InitialSequenceNumber: uint16Ptr(0x44ed),
+		PayloadMaxSize:        12,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	codewords := make([]uint8, 24)
+	for i := range codewords {
+		codewords[i] = uint8(i)
+	}
+
+	pkts, err := e.Encode(codewords)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pkts))
+	require.Equal(t, uint16(0x44ed), pkts[0].SequenceNumber)
+	require.Equal(t, uint32(0), pkts[0].Timestamp)
+	require.Equal(t, 10, len(pkts[0].Payload))
+	require.Equal(t, uint16(0x44ee), pkts[1].SequenceNumber)
+	require.Equal(t, uint32(16), pkts[1].Timestamp)
+	require.Equal(t, 5, len(pkts[1].Payload))
+
+	d := &Decoder{
+		BitRate: 40,
+	}
+	err = d.Init()
+	require.NoError(t, err)
+
+	var decoded []uint8
+
+	for _, pkt := range pkts {
+		var cws []uint8
+		cws, err = d.Decode(pkt)
+		require.NoError(t, err)
+		decoded = append(decoded, cws...)
+	}
+
+	require.Equal(t, codewords, decoded)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name      string
+		codewords []uint8
+		err       string
+	}{
+		{
+			"empty",
+			[]uint8{},
+			"codewords are empty",
+		},
+		{
+			"unaligned",
+			[]uint8{1, 2, 3},
+			"codewords don't fill an integer number of bytes",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType: 96,
+				BitRate:     24,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			_, err = e.Encode(ca.codewords)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestEncodeInvalidBitRate(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+		BitRate:     64,
+	}
+	err := e.Init()
+	require.EqualError(t, err, "unsupported bit rate: 64")
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+		BitRate:     32,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
@@ -0,0 +1,81 @@
+// Package rtpg726 contains a RTP/G726 decoder and encoder.
+package rtpg726
+
+import (
+	"fmt"
+)
+
+// codewordBits returns the size of codewords of the given bit rate, in kbit/s.
+func codewordBits(bitRate int) (int, error) {
+	switch bitRate {
+	case 16, 24, 32, 40:
+		return bitRate / 8, nil
+	}
+	return 0, fmt.Errorf("unsupported bit rate: %d", bitRate)
+}
+
+// RFC3551: the first codeword is placed into the first octet such that
+// its least significant bit aligns with the least significant bit of the octet.
+// With the big-endian packing (ITU-T I.366.2 annex E, AAL2),
+// the first codeword is placed into the most significant bits of the octet.
+func packCodewords(codewords []uint8, bits int, bigEndian bool) []byte {
+	ret := make([]byte, 0, len(codewords)*bits/8)
+	mask := uint32(1<<bits) - 1
+	acc := uint32(0)
+	n := 0
+
+	for _, cw := range codewords {
+		if bigEndian {
+			acc = acc<<bits | (uint32(cw) & mask)
+			n += bits
+
+			for n >= 8 {
+				n -= 8
+				ret = append(ret, byte(acc>>n))
+				acc &= 1<<n - 1
+			}
+		} else {
+			acc |= (uint32(cw) & mask) << n
+			n += bits
+
+			for n >= 8 {
+				ret = append(ret, byte(acc))
+				acc >>= 8
+				n -= 8
+			}
+		}
+	}
+
+	return ret
+}
+
+func unpackCodewords(buf []byte, bits int, bigEndian bool) []uint8 {
+	ret := make([]uint8, 0, len(buf)*8/bits)
+	mask := uint32(1<<bits) - 1
+	acc := uint32(0)
+	n := 0
+
+	for _, b := range buf {
+		if bigEndian {
+			acc = acc<<8 | uint32(b)
+			n += 8
+
+			for n >= bits {
+				n -= bits
+				ret = append(ret, uint8((acc>>n)&mask))
+				acc &= 1<<n - 1
+			}
+		} else {
+			acc |= uint32(b) << n
+			n += 8
+
+			for n >= bits {
+				ret = append(ret, uint8(acc&mask))
+				acc >>= bits
+				n -= bits
+			}
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,94 @@
+package rtph263p
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// maximum size of a frame.
+const maxFrameSize = 1 * 1024 * 1024
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we decoded a non-starting
+// packet of a frame and we didn't receive the previous packet.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"received a non-starting fragment without any previous starting fragment")
+
+func joinFragments(fragments [][]byte, size int) []byte {
+	ret := make([]byte, size)
+	n := 0
+	for _, p := range fragments {
+		n += copy(ret[n:], p)
+	}
+	return ret
+}
+
+// Decoder is a RTP/H263+ decoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc4629
+type Decoder struct {
+	fragments     [][]byte
+	fragmentsSize int
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes a frame from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	if len(pkt.Payload) < 2 {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		return nil, fmt.Errorf("invalid payload size")
+	}
+
+	pictureStart := (pkt.Payload[0] & 0x04) != 0
+	vrc := (pkt.Payload[0] & 0x02) != 0
+	pLen := int(pkt.Payload[0]&0x01)<<5 | int(pkt.Payload[1]>>3)
+
+	pos := 2
+	if vrc {
+		pos++
+	}
+	pos += pLen
+
+	if len(pkt.Payload) < pos {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		return nil, fmt.Errorf("invalid payload size")
+	}
+
+	fragment := pkt.Payload[pos:]
+
+	// the first two bytes of the start code are omitted
+	if pictureStart {
+		fragment = append([]byte{0, 0}, fragment...)
+	} else if len(d.fragments) == 0 {
+		return nil, ErrNonStartingPacketAndNoPrevious
+	}
+
+	if len(d.fragments) == 0 {
+		d.fragmentsSize = 0
+	}
+
+	d.fragmentsSize += len(fragment)
+
+	if d.fragmentsSize > maxFrameSize {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		return nil, fmt.Errorf("frame size (%d) is too big, maximum is %d", d.fragmentsSize, maxFrameSize)
+	}
+
+	d.fragments = append(d.fragments, fragment)
+
+	if !pkt.Marker {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	frame := joinFragments(d.fragments, d.fragmentsSize)
+	d.fragments = d.fragments[:0]
+
+	return frame, nil
+}
@@ -0,0 +1,2 @@
+// Package rtph263p contains a RTP/H263+ decoder and encoder.
+package rtph263p
@@ -35,25 +35,59 @@ type Decoder struct {
 	// indicates the packetization mode.
 	PacketizationMode int
 
+	// maximum number of NALUs that precede any NALU in transmission order
+	// and follow it in decoding order (packetization mode 2 only).
+	InterleavingDepth int
+
+	// if set, access units with a temporal ID greater than this value are discarded
+	// and ErrMorePacketsNeeded is returned in their place.
+	// Access units that are needed to decode the following ones
+	// (random access points and parameters) are never discarded.
+	MaxTemporalID *uint8
+
+	// if set, SPS and PPS are prepended to the first access unit that contains an IDR,
+	// unless they have already been received in-band.
+	// They are usually extracted from the SDP (sprop-parameter-sets).
+	SPS []byte
+	PPS []byte
+
 	firstPacketReceived bool
+	paramsChecked       bool
 	fragmentsSize       int
 	fragments           [][]byte
 	annexBMode          bool
+	accessUnitTimestamp uint32
 
 	// for Decode()
 	frameBuffer     [][]byte
 	frameBufferLen  int
 	frameBufferSize int
+
+	// for packetization mode 2
+	fragmentsDON uint16
+	deintBuffer  []interleavedNALU
+	curAU        *interleavedAccessUnit
+	readyAUs     []*interleavedAccessUnit
 }
 
 // Init initializes the decoder.
 func (d *Decoder) Init() error {
-	if d.PacketizationMode >= 2 {
-		return fmt.Errorf("PacketizationMode >= 2 is not supported")
+	if d.PacketizationMode > 2 {
+		return fmt.Errorf("PacketizationMode > 2 is not supported")
+	}
+	if d.InterleavingDepth < 0 {
+		return fmt.Errorf("invalid InterleavingDepth")
 	}
 	return nil
 }
 
+// AccessUnitTimestamp returns the RTP timestamp of the last access unit returned by Decode().
+// With packetization mode 2, access units are reordered by decoding order,
+// therefore this differs from the timestamp of the last packet passed to Decode().
+func (d *Decoder) AccessUnitTimestamp() uint32 {
+	return d.accessUnitTimestamp
+}
+
 func (d *Decoder) decodeNALUs(pkt *rtp.Packet) ([][]byte, error) {
 	if len(pkt.Payload) < 1 {
 		d.fragments = d.fragments[:0] // discard pending fragments
@@ -165,7 +199,12 @@ func (d *Decoder) decodeNALUs(pkt *rtp.Packet) ([][]byte, error) {
 }
 
 // Decode decodes an access unit from a RTP packet.
+// With packetization mode 2, NALUs are returned in decoding order, one access unit at a time.
 func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
+	if d.PacketizationMode == 2 {
+		return d.decodeInterleaved(pkt)
+	}
+
 	nalus, err := d.decodeNALUs(pkt)
 	if err != nil {
 		return nil, err
@@ -209,7 +248,48 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 	d.frameBufferLen = 0
 	d.frameBufferSize = 0
 
-	return ret, nil
+	if d.MaxTemporalID != nil && TemporalID(ret) > *d.MaxTemporalID && !isReferenceAccessUnit(ret) {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	d.accessUnitTimestamp = pkt.Timestamp
+
+	return d.prependParams(ret), nil
+}
+
+// prependParams prepends out-of-band parameters to the first IDR access unit.
+// It stops as soon as parameters are found in-band, in order to avoid duplicates.
+func (d *Decoder) prependParams(au [][]byte) [][]byte {
+	if d.paramsChecked || (d.SPS == nil && d.PPS == nil) {
+		return au
+	}
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		typ := h264.NALUType(nalu[0] & 0x1F)
+		if typ == h264.NALUTypeSPS || typ == h264.NALUTypePPS {
+			d.paramsChecked = true
+			return au
+		}
+	}
+
+	if !h264.IDRPresent(au) {
+		return au
+	}
+
+	d.paramsChecked = true
+
+	ret := make([][]byte, 0, 2+len(au))
+	if d.SPS != nil {
+		ret = append(ret, d.SPS)
+	}
+	if d.PPS != nil {
+		ret = append(ret, d.PPS)
+	}
+	return append(ret, au...)
 }
 
 // some cameras / servers wrap NALUs into Annex-B
@@ -0,0 +1,308 @@
+package rtph264
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+)
+
+// maximum number of access units that can be pending in interleaved mode.
+const maxPendingAccessUnits = 64
+
+type interleavedNALU struct {
+	don      uint16
+	naluTime uint32
+	nalu     []byte
+}
+
+type interleavedAccessUnit struct {
+	naluTime uint32
+	nalus    [][]byte
+	size     int
+}
+
+func (d *Decoder) resetInterleaved() {
+	d.fragments = d.fragments[:0]
+	d.deintBuffer = nil
+	d.curAU = nil
+	d.readyAUs = nil
+}
+
+// decodeInterleavedNALUs decodes NALUs of a packet sent with packetization mode 2,
+// together with their decoding order number (DON) and NALU-time.
+func (d *Decoder) decodeInterleavedNALUs(pkt *rtp.Packet) ([]interleavedNALU, error) {
+	if len(pkt.Payload) < 1 {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		return nil, fmt.Errorf("payload is too short")
+	}
+
+	typ := h264.NALUType(pkt.Payload[0] & 0x1F)
+
+	switch typ {
+	case h264.NALUTypeFUB:
+		d.fragments = d.fragments[:0] // discard pending fragments
+
+		if len(pkt.Payload) < 4 {
+			return nil, fmt.Errorf("invalid FU-B packet (invalid size)")
+		}
+
+		start := pkt.Payload[1] >> 7
+		end := (pkt.Payload[1] >> 6) & 0x01
+
+		if start != 1 {
+			return nil, fmt.Errorf("invalid FU-B packet (non-starting)")
+		}
+
+		if end != 0 {
+			return nil, fmt.Errorf("invalid FU-B packet (can't contain both a start and end bit)")
+		}
+
+		nri := (pkt.Payload[0] >> 5) & 0x03
+		typ := pkt.Payload[1] & 0x1F
+		d.fragmentsDON = uint16(pkt.Payload[2])<<8 | uint16(pkt.Payload[3])
+		d.fragmentsSize = 1 + len(pkt.Payload[4:])
+		d.fragments = append(d.fragments, []byte{(nri << 5) | typ}, pkt.Payload[4:])
+		d.firstPacketReceived = true
+
+		return nil, ErrMorePacketsNeeded
+
+	case h264.NALUTypeFUA:
+		// in interleaved mode, FU-A is used for fragments that follow a FU-B
+		if len(pkt.Payload) < 2 {
+			d.fragments = d.fragments[:0]
+			return nil, fmt.Errorf("invalid FU-A packet (invalid size)")
+		}
+
+		start := pkt.Payload[1] >> 7
+		end := (pkt.Payload[1] >> 6) & 0x01
+
+		if start == 1 {
+			d.fragments = d.fragments[:0]
+			return nil, fmt.Errorf("invalid FU-A packet (starting fragments must be sent with FU-B)")
+		}
+
+		if len(d.fragments) == 0 {
+			if !d.firstPacketReceived {
+				return nil, ErrNonStartingPacketAndNoPrevious
+			}
+
+			return nil, fmt.Errorf("invalid FU-A packet (non-starting)")
+		}
+
+		d.fragmentsSize += len(pkt.Payload[2:])
+
+		if d.fragmentsSize > h264.MaxAccessUnitSize {
+			d.fragments = d.fragments[:0]
+			return nil, fmt.Errorf("NALU size (%d) is too big, maximum is %d", d.fragmentsSize, h264.MaxAccessUnitSize)
+		}
+
+		d.fragments = append(d.fragments, pkt.Payload[2:])
+
+		if end != 1 {
+			return nil, ErrMorePacketsNeeded
+		}
+
+		nalu := joinFragments(d.fragments, d.fragmentsSize)
+		d.fragments = d.fragments[:0]
+
+		return []interleavedNALU{{
+			don:      d.fragmentsDON,
+			naluTime: pkt.Timestamp,
+			nalu:     nalu,
+		}}, nil
+
+	case h264.NALUTypeSTAPB:
+		d.fragments = d.fragments[:0] // discard pending fragments
+
+		if len(pkt.Payload) < 3 {
+			return nil, fmt.Errorf("invalid STAP-B packet (invalid size)")
+		}
+
+		don := uint16(pkt.Payload[1])<<8 | uint16(pkt.Payload[2])
+		payload := pkt.Payload[3:]
+		var ret []interleavedNALU
+
+		for len(payload) > 0 {
+			if len(payload) < 2 {
+				return nil, fmt.Errorf("invalid STAP-B packet (invalid size)")
+			}
+
+			size := uint16(payload[0])<<8 | uint16(payload[1])
+			payload = payload[2:]
+
+			// avoid final padding
+			if size == 0 {
+				break
+			}
+
+			if int(size) > len(payload) {
+				return nil, fmt.Errorf("invalid STAP-B packet (invalid size)")
+			}
+
+			// DON of following NALUs is incremented by one
+			ret = append(ret, interleavedNALU{
+				don:      don,
+				naluTime: pkt.Timestamp,
+				nalu:     payload[:size],
+			})
+			don++
+			payload = payload[size:]
+		}
+
+		if ret == nil {
+			return nil, fmt.Errorf("STAP-B packet doesn't contain any NALU")
+		}
+
+		d.firstPacketReceived = true
+		return ret, nil
+
+	case h264.NALUTypeMTAP16, h264.NALUTypeMTAP24:
+		d.fragments = d.fragments[:0] // discard pending fragments
+
+		tsOffsetSize := 2
+		if typ == h264.NALUTypeMTAP24 {
+			tsOffsetSize = 3
+		}
+
+		if len(pkt.Payload) < 3 {
+			return nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+		}
+
+		donb := uint16(pkt.Payload[1])<<8 | uint16(pkt.Payload[2])
+		payload := pkt.Payload[3:]
+		var ret []interleavedNALU
+
+		for len(payload) > 0 {
+			if len(payload) < 2 {
+				return nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+			}
+
+			// size includes DOND and TS offset
+			size := int(uint16(payload[0])<<8 | uint16(payload[1]))
+			payload = payload[2:]
+
+			// avoid final padding
+			if size == 0 {
+				break
+			}
+
+			if size <= (1+tsOffsetSize) || size > len(payload) {
+				return nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+			}
+
+			dond := payload[0]
+
+			var tsOffset uint32
+			for i := 0; i < tsOffsetSize; i++ {
+				tsOffset = tsOffset<<8 | uint32(payload[1+i])
+			}
+
+			// the RTP timestamp is the minimum NALU-time of the packet
+			ret = append(ret, interleavedNALU{
+				don:      donb + uint16(dond),
+				naluTime: pkt.Timestamp + tsOffset,
+				nalu:     payload[1+tsOffsetSize : size],
+			})
+			payload = payload[size:]
+		}
+
+		if ret == nil {
+			return nil, fmt.Errorf("%v packet doesn't contain any NALU", typ)
+		}
+
+		d.firstPacketReceived = true
+		return ret, nil
+	}
+
+	d.fragments = d.fragments[:0] // discard pending fragments
+	d.firstPacketReceived = true
+	return nil, fmt.Errorf("packet type not supported in interleaved mode (%v)", typ)
+}
+
+// insertInterleaved inserts a NALU into the deinterleaving buffer, sorted by DON.
+func (d *Decoder) insertInterleaved(n interleavedNALU) {
+	i := len(d.deintBuffer)
+	for i > 0 && int16(n.don-d.deintBuffer[i-1].don) < 0 {
+		i--
+	}
+
+	d.deintBuffer = append(d.deintBuffer, interleavedNALU{})
+	copy(d.deintBuffer[i+1:], d.deintBuffer[i:])
+	d.deintBuffer[i] = n
+}
+
+// pushInterleaved adds a NALU, in decoding order, to the current access unit.
+// NALUs of the same access unit share the same NALU-time.
+func (d *Decoder) pushInterleaved(n interleavedNALU) error {
+	if d.curAU != nil && d.curAU.naluTime != n.naluTime {
+		if len(d.readyAUs) >= maxPendingAccessUnits {
+			d.resetInterleaved()
+			return fmt.Errorf("pending access unit count exceeds maximum allowed (%d)", maxPendingAccessUnits)
+		}
+
+		d.readyAUs = append(d.readyAUs, d.curAU)
+		d.curAU = nil
+	}
+
+	if d.curAU == nil {
+		d.curAU = &interleavedAccessUnit{
+			naluTime: n.naluTime,
+		}
+	}
+
+	if (len(d.curAU.nalus) + 1) > h264.MaxNALUsPerAccessUnit {
+		d.resetInterleaved()
+		return fmt.Errorf("NALU count exceeds maximum allowed (%d)",
+			h264.MaxNALUsPerAccessUnit)
+	}
+
+	if (d.curAU.size + len(n.nalu)) > h264.MaxAccessUnitSize {
+		size := d.curAU.size + len(n.nalu)
+		d.resetInterleaved()
+		return fmt.Errorf("access unit size (%d) is too big, maximum is %d",
+			size, h264.MaxAccessUnitSize)
+	}
+
+	d.curAU.nalus = append(d.curAU.nalus, n.nalu)
+	d.curAU.size += len(n.nalu)
+
+	return nil
+}
+
+func (d *Decoder) decodeInterleaved(pkt *rtp.Packet) ([][]byte, error) {
+	nalus, err := d.decodeInterleavedNALUs(pkt)
+	if err != nil && (err != ErrMorePacketsNeeded || len(d.readyAUs) == 0) {
+		return nil, err
+	}
+
+	for _, n := range nalus {
+		d.insertInterleaved(n)
+
+		// a NALU can be output when the buffer contains more NALUs than the interleaving depth
+		for len(d.deintBuffer) > d.InterleavingDepth {
+			n := d.deintBuffer[0]
+			d.deintBuffer = d.deintBuffer[1:]
+
+			err := d.pushInterleaved(n)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for len(d.readyAUs) != 0 {
+		au := d.readyAUs[0]
+		d.readyAUs = d.readyAUs[1:]
+
+		if d.MaxTemporalID != nil && TemporalID(au.nalus) > *d.MaxTemporalID && !isReferenceAccessUnit(au.nalus) {
+			continue
+		}
+
+		d.accessUnitTimestamp = au.naluTime
+		return d.prependParams(au.nalus), nil
+	}
+
+	return nil, ErrMorePacketsNeeded
+}
@@ -189,6 +189,95 @@ func TestDecodeAccessUnit(t *testing.T) {
 	require.Equal(t, [][]byte{{0x01, 0x02}, {0x01, 0x02}}, nalus)
 }
 
+func TestDecodeMaxTemporalID(t *testing.T) {
+	maxTemporalID := uint8(0)
+
+	d := &Decoder{
+		MaxTemporalID: &maxTemporalID,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		payload   []byte
+		discarded bool
+	}{
+		{[]byte{0x01, 0x02}, false},
+		{[]byte{0x0e, 0x80, 0x00, 0x40}, true},
+		{[]byte{0x05, 0x02}, false},
+	} {
+		nalus, err := d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 17647,
+				Timestamp:      2289531307,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: ca.payload,
+		})
+
+		if ca.discarded {
+			require.Equal(t, ErrMorePacketsNeeded, err)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{ca.payload}, nalus)
+		}
+	}
+}
+
+func TestDecodePrependParams(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		payloads [][]byte
+		outs     [][][]byte
+	}{
+		{
+			"out of band",
+			[][]byte{{0x01, 0x02}, {0x05, 0x02}, {0x05, 0x02}},
+			[][][]byte{
+				{{0x01, 0x02}},
+				{{0x67, 0x01}, {0x68, 0x02}, {0x05, 0x02}},
+				{{0x05, 0x02}},
+			},
+		},
+		{
+			"in band",
+			[][]byte{{0x67, 0x03}, {0x05, 0x02}},
+			[][][]byte{
+				{{0x67, 0x03}},
+				{{0x05, 0x02}},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				SPS: []byte{0x67, 0x01},
+				PPS: []byte{0x68, 0x02},
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			for i, payload := range ca.payloads {
+				nalus, err := d.Decode(&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         true,
+						PayloadType:    96,
+						SequenceNumber: 17647 + uint16(i),
+						Timestamp:      2289531307,
+						SSRC:           0x9dbb7812,
+					},
+					Payload: payload,
+				})
+				require.NoError(t, err)
+				require.Equal(t, ca.outs[i], nalus)
+			}
+		})
+	}
+}
+
 func TestDecoderErrorLimit(t *testing.T) {
 	d := &Decoder{}
 	err := d.Init()
@@ -211,6 +300,120 @@ func TestDecoderErrorLimit(t *testing.T) {
 	require.EqualError(t, err, "NALU count exceeds maximum allowed (21)")
 }
 
+func TestDecodeInterleaved(t *testing.T) {
+	d := &Decoder{
+		PacketizationMode: 2,
+		InterleavingDepth: 2,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	type out struct {
+		timestamp uint32
+		nalus     [][]byte
+	}
+
+	var aus []out
+
+	for _, pkt := range []*rtp.Packet{
+		{ // STAP-B, DON 0 and 1
+			Header:  rtp.Header{Timestamp: 1000},
+			Payload: []byte{0x19, 0x00, 0x00, 0x00, 0x02, 0x67, 0x01, 0x00, 0x02, 0x68, 0x02},
+		},
+		{ // MTAP16, DON 3 and 4, sent before DON 2
+			Header: rtp.Header{Timestamp: 1000},
+			Payload: []byte{
+				0x1a, 0x00, 0x03,
+				0x00, 0x05, 0x00, 0x17, 0x70, 0x41, 0x04,
+				0x00, 0x05, 0x01, 0x0b, 0xb8, 0x01, 0x05,
+			},
+		},
+		{ // FU-B, start of DON 2
+			Header:  rtp.Header{Timestamp: 1000},
+			Payload: []byte{0x7d, 0x85, 0x00, 0x02, 0xaa, 0xbb},
+		},
+		{ // FU-A, end of DON 2
+			Header:  rtp.Header{Timestamp: 1000, Marker: true},
+			Payload: []byte{0x7c, 0x45, 0xcc},
+		},
+		{ // STAP-B, DON 5
+			Header:  rtp.Header{Timestamp: 10000},
+			Payload: []byte{0x19, 0x00, 0x05, 0x00, 0x02, 0x41, 0x06},
+		},
+		{ // STAP-B, DON 6
+			Header:  rtp.Header{Timestamp: 13000, Marker: true},
+			Payload: []byte{0x19, 0x00, 0x06, 0x00, 0x02, 0x41, 0x07},
+		},
+	} {
+		nalus, err := d.Decode(pkt)
+		if err == ErrMorePacketsNeeded {
+			continue
+		}
+
+		require.NoError(t, err)
+		aus = append(aus, out{d.AccessUnitTimestamp(), nalus})
+	}
+
+	require.Equal(t, []out{
+		{1000, [][]byte{{0x67, 0x01}, {0x68, 0x02}, {0x65, 0xaa, 0xbb, 0xcc}}},
+		{7000, [][]byte{{0x41, 0x04}}},
+	}, aus)
+}
+
+func TestDecodeInterleavedErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		payload []byte
+		err     string
+	}{
+		{
+			"single NALU",
+			[]byte{0x65, 0x01},
+			"packet type not supported in interleaved mode (IDR)",
+		},
+		{
+			"STAP-B without NALUs",
+			[]byte{0x19, 0x00, 0x00},
+			"STAP-B packet doesn't contain any NALU",
+		},
+		{
+			"MTAP24 invalid size",
+			[]byte{0x1b, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00},
+			"invalid MTAP-24 packet (invalid size)",
+		},
+		{
+			"FU-A starting",
+			[]byte{0x7c, 0x85, 0x01},
+			"invalid FU-A packet (starting fragments must be sent with FU-B)",
+		},
+		{
+			"FU-B non-starting",
+			[]byte{0x7d, 0x45, 0x00, 0x00, 0x01},
+			"invalid FU-B packet (non-starting)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				PacketizationMode: 2,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			_, err = d.Decode(&rtp.Packet{
+				Header:  rtp.Header{Marker: true},
+				Payload: ca.payload,
+			})
+			require.EqualError(t, err, ca.err)
+		})
+	}
+
+	d := &Decoder{
+		PacketizationMode: 3,
+	}
+	err := d.Init()
+	require.EqualError(t, err, "PacketizationMode > 2 is not supported")
+}
+
 func FuzzDecoder(f *testing.F) {
 	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
 		d := &Decoder{}
@@ -241,3 +444,27 @@ func FuzzDecoder(f *testing.F) {
 		})
 	})
 }
+
+func FuzzDecoderInterleaved(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		d := &Decoder{
+			PacketizationMode: 2,
+			InterleavingDepth: 1,
+		}
+		d.Init() //nolint:errcheck
+
+		for _, payload := range [][]byte{a, b, a} {
+			d.Decode(&rtp.Packet{ //nolint:errcheck
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					Timestamp:      2289527317,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: payload,
+			})
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package rtph264
+
+import (
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+
+	"github.com/bluenviron/gortsplib/v4/internal/sei"
+)
+
+// SEI payload types.
+// Specification: ITU-T Rec. H.264, Annex D
+const (
+	SEIPayloadTypePicTiming            = 1
+	SEIPayloadTypeUserDataRegistered   = 4
+	SEIPayloadTypeUserDataUnregistered = 5
+)
+
+// SEIMessage is a message contained in a SEI NALU.
+type SEIMessage struct {
+	// payload type.
+	Type int
+
+	// payload, without emulation prevention bytes.
+	Payload []byte
+}
+
+// SEIMessages returns the messages contained in the SEI NALUs of an access unit.
+// They can be used to extract picture timings or closed captions (CEA-608/708),
+// that are carried in user data registered by ITU-T Rec. T.35.
+func SEIMessages(au [][]byte) ([]SEIMessage, error) {
+	var ret []SEIMessage
+
+	for _, nalu := range au {
+		if len(nalu) == 0 || h264.NALUType(nalu[0]&0x1F) != h264.NALUTypeSEI {
+			continue
+		}
+
+		err := sei.Unmarshal(h264.EmulationPreventionRemove(nalu[1:]), func(typ int, payload []byte) {
+			ret = append(ret, SEIMessage{Type: typ, Payload: payload})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
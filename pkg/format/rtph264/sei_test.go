@@ -0,0 +1,60 @@
+package rtph264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSEIMessages(t *testing.T) {
+	msgs, err := SEIMessages([][]byte{
+		{0x09, 0xf0},
+		{
+			0x06,
+			0x04, 0x03, 0xb5, 0x00, 0x31,
+			0x01, 0x03, 0x00, 0x00, 0x03, 0x01,
+			0xff, 0x05, 0x01, 0xaa,
+			0x80,
+		},
+		{0x05, 0x01, 0x02},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []SEIMessage{
+		{
+			Type:    SEIPayloadTypeUserDataRegistered,
+			Payload: []byte{0xb5, 0x00, 0x31},
+		},
+		{
+			Type:    SEIPayloadTypePicTiming,
+			Payload: []byte{0x00, 0x00, 0x01},
+		},
+		{
+			Type:    260,
+			Payload: []byte{0xaa},
+		},
+	}, msgs)
+}
+
+func TestSEIMessagesErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		nalu []byte
+		err  string
+	}{
+		{
+			"missing size",
+			[]byte{0x06, 0x05},
+			"SEI is too short",
+		},
+		{
+			"invalid size",
+			[]byte{0x06, 0x05, 0x10, 0x01},
+			"invalid SEI payload size: 16",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := SEIMessages([][]byte{ca.nalu})
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
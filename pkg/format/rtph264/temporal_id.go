@@ -0,0 +1,46 @@
+package rtph264
+
+import (
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+)
+
+// TemporalID returns the temporal ID of an access unit.
+// The temporal ID is read from the header extension of SVC and MVC NALUs.
+// Access units that do not contain such NALUs belong to the base layer (temporal ID 0).
+func TemporalID(au [][]byte) uint8 {
+	for _, nalu := range au {
+		if len(nalu) < 4 {
+			continue
+		}
+
+		typ := h264.NALUType(nalu[0] & 0x1F)
+
+		if typ == h264.NALUTypePrefix || typ == h264.NALUTypeSliceExtension {
+			// svc_extension_flag
+			if (nalu[1] & 0x80) != 0 {
+				return nalu[3] >> 5
+			}
+
+			return (nalu[3] >> 3) & 0x07
+		}
+	}
+
+	return 0
+}
+
+// access units that contain IDR frames or parameters must never be discarded,
+// since they are the reference of all the following ones.
+func isReferenceAccessUnit(au [][]byte) bool {
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeIDR, h264.NALUTypeSPS, h264.NALUTypePPS, h264.NALUTypeSubsetSPS:
+			return true
+		}
+	}
+
+	return false
+}
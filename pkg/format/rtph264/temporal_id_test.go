@@ -0,0 +1,40 @@
+package rtph264
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemporalID(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		au   [][]byte
+		tid  uint8
+	}{
+		{
+			"avc",
+			[][]byte{{0x01, 0x02, 0x03, 0x04}},
+			0,
+		},
+		{
+			"svc prefix",
+			[][]byte{{0x0e, 0x80, 0x00, 0x40}, {0x01, 0x02, 0x03, 0x04}},
+			2,
+		},
+		{
+			"svc slice extension",
+			[][]byte{{0x14, 0x80, 0x00, 0x20, 0x01}},
+			1,
+		},
+		{
+			"mvc slice extension",
+			[][]byte{{0x14, 0x00, 0x00, 0x18, 0x01}},
+			3,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.tid, TemporalID(ca.au))
+		})
+	}
+}
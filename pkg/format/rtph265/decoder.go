@@ -34,7 +34,21 @@ type Decoder struct {
 	// indicates that NALUs have an additional field that specifies the decoding order.
 	MaxDONDiff int
 
+	// if set, access units with a temporal ID greater than this value are discarded
+	// and ErrMorePacketsNeeded is returned in their place.
+	// Access units that are needed to decode the following ones
+	// (random access points and parameters) are never discarded.
+	MaxTemporalID *uint8
+
+	// if set, VPS, SPS and PPS are prepended to the first random access unit,
+	// unless they have already been received in-band.
+	// They are usually extracted from the SDP (sprop-vps, sprop-sps, sprop-pps).
+	VPS []byte
+	SPS []byte
+	PPS []byte
+
 	firstPacketReceived bool
+	paramsChecked       bool
 	fragmentsSize       int
 	fragments           [][]byte
 
@@ -200,5 +214,47 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 	d.frameBufferLen = 0
 	d.frameBufferSize = 0
 
-	return ret, nil
+	if d.MaxTemporalID != nil && TemporalID(ret) > *d.MaxTemporalID && !isReferenceAccessUnit(ret) {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	return d.prependParams(ret), nil
+}
+
+// prependParams prepends out-of-band parameters to the first random access unit.
+// It stops as soon as parameters are found in-band, in order to avoid duplicates.
+func (d *Decoder) prependParams(au [][]byte) [][]byte {
+	if d.paramsChecked || (d.VPS == nil && d.SPS == nil && d.PPS == nil) {
+		return au
+	}
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+		if typ == h265.NALUType_VPS_NUT || typ == h265.NALUType_SPS_NUT || typ == h265.NALUType_PPS_NUT {
+			d.paramsChecked = true
+			return au
+		}
+	}
+
+	if !h265.IsRandomAccess(au) {
+		return au
+	}
+
+	d.paramsChecked = true
+
+	ret := make([][]byte, 0, 3+len(au))
+	if d.VPS != nil {
+		ret = append(ret, d.VPS)
+	}
+	if d.SPS != nil {
+		ret = append(ret, d.SPS)
+	}
+	if d.PPS != nil {
+		ret = append(ret, d.PPS)
+	}
+	return append(ret, au...)
 }
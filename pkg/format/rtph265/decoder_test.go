@@ -38,6 +38,96 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeMaxTemporalID(t *testing.T) {
+	maxTemporalID := uint8(0)
+
+	d := &Decoder{
+		MaxTemporalID: &maxTemporalID,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		payload   []byte
+		discarded bool
+	}{
+		{[]byte{0x02, 0x01, 0x01}, false},
+		{[]byte{0x02, 0x03, 0x01}, true},
+		{[]byte{0x26, 0x03, 0x01}, false},
+	} {
+		nalus, err := d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 17647,
+				Timestamp:      2289531307,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: ca.payload,
+		})
+
+		if ca.discarded {
+			require.Equal(t, ErrMorePacketsNeeded, err)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{ca.payload}, nalus)
+		}
+	}
+}
+
+func TestDecodePrependParams(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		payloads [][]byte
+		outs     [][][]byte
+	}{
+		{
+			"out of band",
+			[][]byte{{0x02, 0x01, 0x01}, {0x26, 0x01, 0x01}, {0x26, 0x01, 0x01}},
+			[][][]byte{
+				{{0x02, 0x01, 0x01}},
+				{{0x40, 0x01, 0x01}, {0x42, 0x01, 0x02}, {0x44, 0x01, 0x03}, {0x26, 0x01, 0x01}},
+				{{0x26, 0x01, 0x01}},
+			},
+		},
+		{
+			"in band",
+			[][]byte{{0x42, 0x01, 0x04}, {0x26, 0x01, 0x01}},
+			[][][]byte{
+				{{0x42, 0x01, 0x04}},
+				{{0x26, 0x01, 0x01}},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				VPS: []byte{0x40, 0x01, 0x01},
+				SPS: []byte{0x42, 0x01, 0x02},
+				PPS: []byte{0x44, 0x01, 0x03},
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			for i, payload := range ca.payloads {
+				nalus, err := d.Decode(&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         true,
+						PayloadType:    96,
+						SequenceNumber: 17647 + uint16(i),
+						Timestamp:      2289531307,
+						SSRC:           0x9dbb7812,
+					},
+					Payload: payload,
+				})
+				require.NoError(t, err)
+				require.Equal(t, ca.outs[i], nalus)
+			}
+		})
+	}
+}
+
 func TestDecoderErrorLimit(t *testing.T) {
 	d := &Decoder{}
 	err := d.Init()
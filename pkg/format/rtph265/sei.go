@@ -0,0 +1,48 @@
+package rtph265
+
+import (
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+
+	"github.com/bluenviron/gortsplib/v4/internal/sei"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
+)
+
+// SEI payload types.
+// Specification: ITU-T Rec. H.265, Annex D
+const (
+	SEIPayloadTypePicTiming            = 1
+	SEIPayloadTypeUserDataRegistered   = 4
+	SEIPayloadTypeUserDataUnregistered = 5
+)
+
+// SEIMessage is a message contained in a prefix or suffix SEI NALU.
+type SEIMessage = rtph264.SEIMessage
+
+// SEIMessages returns the messages contained in the SEI NALUs of an access unit.
+// They can be used to extract picture timings or closed captions (CEA-608/708),
+// that are carried in user data registered by ITU-T Rec. T.35.
+func SEIMessages(au [][]byte) ([]SEIMessage, error) {
+	var ret []SEIMessage
+
+	for _, nalu := range au {
+		if len(nalu) < 2 {
+			continue
+		}
+
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+		if typ != h265.NALUType_PREFIX_SEI_NUT && typ != h265.NALUType_SUFFIX_SEI_NUT {
+			continue
+		}
+
+		// H265 and H264 share the same emulation prevention mechanism
+		err := sei.Unmarshal(h264.EmulationPreventionRemove(nalu[2:]), func(typ int, payload []byte) {
+			ret = append(ret, SEIMessage{Type: typ, Payload: payload})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,39 @@
+package rtph265
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSEIMessages(t *testing.T) {
+	msgs, err := SEIMessages([][]byte{
+		{
+			0x4e, 0x01,
+			0x01, 0x03, 0x00, 0x00, 0x03, 0x01,
+			0x80,
+		},
+		{0x26, 0x01, 0x02},
+		{
+			0x50, 0x01,
+			0x04, 0x03, 0xb5, 0x00, 0x31,
+			0x80,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []SEIMessage{
+		{
+			Type:    SEIPayloadTypePicTiming,
+			Payload: []byte{0x00, 0x00, 0x01},
+		},
+		{
+			Type:    SEIPayloadTypeUserDataRegistered,
+			Payload: []byte{0xb5, 0x00, 0x31},
+		},
+	}, msgs)
+}
+
+func TestSEIMessagesErrors(t *testing.T) {
+	_, err := SEIMessages([][]byte{{0x4e, 0x01, 0x05, 0x10, 0x01}})
+	require.EqualError(t, err, "invalid SEI payload size: 16")
+}
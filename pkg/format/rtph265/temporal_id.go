@@ -0,0 +1,48 @@
+package rtph265
+
+import (
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+)
+
+// TemporalID returns the temporal ID of an access unit.
+// The temporal ID is read from the header of the first VCL NALU.
+func TemporalID(au [][]byte) uint8 {
+	for _, nalu := range au {
+		if len(nalu) < 2 {
+			continue
+		}
+
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+
+		// VCL NALUs
+		if typ <= 31 {
+			tidPlus1 := nalu[1] & 0b111
+			if tidPlus1 == 0 {
+				return 0
+			}
+			return tidPlus1 - 1
+		}
+	}
+
+	return 0
+}
+
+// access units that contain IRAP pictures or parameters must never be discarded,
+// since they are the reference of all the following ones.
+func isReferenceAccessUnit(au [][]byte) bool {
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+
+		switch {
+		case typ >= h265.NALUType_BLA_W_LP && typ <= h265.NALUType_RSV_IRAP_VCL23,
+			typ == h265.NALUType_VPS_NUT, typ == h265.NALUType_SPS_NUT, typ == h265.NALUType_PPS_NUT:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+package rtph265
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemporalID(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		au   [][]byte
+		tid  uint8
+	}{
+		{
+			"base layer",
+			[][]byte{{0x02, 0x01, 0x03, 0x04}},
+			0,
+		},
+		{
+			"parameters and vcl",
+			[][]byte{{0x44, 0x01, 0x01}, {0x02, 0x03, 0x03, 0x04}},
+			2,
+		},
+		{
+			"no vcl",
+			[][]byte{{0x4e, 0x02, 0x01}},
+			0,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.tid, TemporalID(ca.au))
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Package rtph266 contains a RTP/H266 decoder and encoder.
+package rtph266
+
+const (
+	// MaxAccessUnitSize is the maximum size of an access unit.
+	// With a 50 Mbps 2160p60 H266 video, the maximum size does not seem to exceed 8 MiB.
+	MaxAccessUnitSize = 8 * 1024 * 1024
+
+	// MaxNALUsPerAccessUnit is the maximum number of NALUs per access unit.
+	MaxNALUsPerAccessUnit = 21
+)
+
+// NALUType is the type of a NALU.
+// Specification: ITU-T Rec. H.266, table 5
+type NALUType uint8
+
+// NALU types.
+const (
+	NALUTypeTRAIL             NALUType = 0
+	NALUTypeSTSA              NALUType = 1
+	NALUTypeRADL              NALUType = 2
+	NALUTypeRASL              NALUType = 3
+	NALUTypeIDRWRADL          NALUType = 7
+	NALUTypeIDRNLP            NALUType = 8
+	NALUTypeCRA               NALUType = 9
+	NALUTypeGDR               NALUType = 10
+	NALUTypeOPI               NALUType = 12
+	NALUTypeDCI               NALUType = 13
+	NALUTypeVPS               NALUType = 14
+	NALUTypeSPS               NALUType = 15
+	NALUTypePPS               NALUType = 16
+	NALUTypePrefixAPS         NALUType = 17
+	NALUTypeSuffixAPS         NALUType = 18
+	NALUTypePH                NALUType = 19
+	NALUTypeAUD               NALUType = 20
+	NALUTypeEOS               NALUType = 21
+	NALUTypeEOB               NALUType = 22
+	NALUTypePrefixSEI         NALUType = 23
+	NALUTypeSuffixSEI         NALUType = 24
+	NALUTypeFD                NALUType = 25
+	NALUTypeAggregationPacket NALUType = 28
+	NALUTypeFragmentationUnit NALUType = 29
+)
+
+// NALUTypeOf returns the type of a NALU.
+func NALUTypeOf(nalu []byte) NALUType {
+	return NALUType(nalu[1] >> 3)
+}
+
+// IsRandomAccess checks whether the access unit can be randomly accessed.
+func IsRandomAccess(au [][]byte) bool {
+	for _, nalu := range au {
+		if len(nalu) < 2 {
+			continue
+		}
+
+		switch NALUTypeOf(nalu) {
+		case NALUTypeIDRWRADL, NALUTypeIDRNLP, NALUTypeCRA, NALUTypeGDR:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package rtpjpegxs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we received a non-starting
+// packet of a fragmented frame and we didn't received anything before.
+// It's normal to receive this when decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"received a non-starting fragment without any previous starting fragment")
+
+type fragment struct {
+	sepCounter    uint16
+	packetCounter uint16
+	last          bool
+	marker        bool
+	payload       []byte
+}
+
+// Decoder is a RTP/JPEG XS decoder.
+// Both packetization modes and both transmission modes are supported.
+// Specification: https://datatracker.ietf.org/doc/html/rfc9134
+type Decoder struct {
+	firstFrameReceived bool
+	frameCounter       uint8
+	fragments          []*fragment
+	fragmentsSize      int
+	markerReceived     bool
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+func (d *Decoder) resetFragments() {
+	d.fragments = d.fragments[:0]
+	d.fragmentsSize = 0
+	d.markerReceived = false
+}
+
+// Decode decodes a frame from a RTP packet.
+// It returns the JPEG XS codestream of the frame.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	var h header
+	n, err := h.unmarshal(pkt.Payload)
+	if err != nil {
+		d.resetFragments()
+		return nil, err
+	}
+
+	// a packet of a new frame has been received before the current frame was completed
+	if len(d.fragments) != 0 && h.FrameCounter != d.frameCounter {
+		d.resetFragments()
+	}
+
+	d.frameCounter = h.FrameCounter
+
+	d.fragmentsSize += len(pkt.Payload[n:])
+	if d.fragmentsSize > MaxFrameSize {
+		errSize := d.fragmentsSize
+		d.resetFragments()
+		return nil, fmt.Errorf("frame size (%d) is too big, maximum is %d", errSize, MaxFrameSize)
+	}
+
+	d.fragments = append(d.fragments, &fragment{
+		sepCounter:    h.SEPCounter,
+		packetCounter: h.PacketCounter,
+		last:          h.Last,
+		marker:        pkt.Marker,
+		payload:       pkt.Payload[n:],
+	})
+
+	if pkt.Marker {
+		d.markerReceived = true
+	}
+
+	if !d.markerReceived {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	frame, ok := d.assemble(h.SliceMode)
+	if !ok {
+		// in out-of-order mode, missing packets may still arrive
+		if !h.Sequential {
+			return nil, ErrMorePacketsNeeded
+		}
+
+		d.resetFragments()
+
+		if !d.firstFrameReceived {
+			return nil, ErrNonStartingPacketAndNoPrevious
+		}
+
+		return nil, fmt.Errorf("discarding frame since a RTP packet is missing")
+	}
+
+	d.resetFragments()
+	d.firstFrameReceived = true
+
+	return frame, nil
+}
+
+func (d *Decoder) assemble(sliceMode bool) ([]byte, bool) {
+	sort.SliceStable(d.fragments, func(i, j int) bool {
+		if d.fragments[i].sepCounter != d.fragments[j].sepCounter {
+			return d.fragments[i].sepCounter < d.fragments[j].sepCounter
+		}
+		return d.fragments[i].packetCounter < d.fragments[j].packetCounter
+	})
+
+	sepCounter := uint16(0)
+	packetCounter := uint16(0)
+
+	for _, f := range d.fragments {
+		if f.sepCounter != sepCounter || f.packetCounter != packetCounter {
+			return nil, false
+		}
+
+		switch {
+		case sliceMode && f.last:
+			sepCounter++
+			packetCounter = 0
+
+		case packetCounter == maxCounter:
+			sepCounter++
+			packetCounter = 0
+
+		default:
+			packetCounter++
+		}
+	}
+
+	if !d.fragments[len(d.fragments)-1].marker {
+		return nil, false
+	}
+
+	ret := make([]byte, d.fragmentsSize)
+	n := 0
+	for _, f := range d.fragments {
+		n += copy(ret[n:], f.payload)
+	}
+
+	return ret, true
+}
@@ -0,0 +1,97 @@
+package rtpjpegxs
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var frame []byte
+
+			for _, pkt := range ca.pkts {
+				frame, err = d.Decode(pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, ca.frame, frame)
+		})
+	}
+}
+
+func TestDecodeOutOfOrder(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	pkts := cases[2].pkts
+
+	for _, i := range []int{2, 0} {
+		pkt := *pkts[i]
+		pkt.Payload = append([]byte{pkt.Payload[0] &^ 0x80}, pkt.Payload[1:]...)
+
+		_, err = d.Decode(&pkt)
+		require.Equal(t, ErrMorePacketsNeeded, err)
+	}
+
+	pkt := *pkts[1]
+	pkt.Payload = append([]byte{pkt.Payload[0] &^ 0x80}, pkt.Payload[1:]...)
+
+	frame, err := d.Decode(&pkt)
+	require.NoError(t, err)
+	require.Equal(t, cases[2].frame, frame)
+}
+
+func TestDecodeMissingPacket(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	pkts := cases[2].pkts
+
+	_, err = d.Decode(pkts[2])
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+
+	for _, pkt := range pkts {
+		_, err = d.Decode(pkt)
+	}
+	require.NoError(t, err)
+
+	_, err = d.Decode(pkts[0])
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	_, err = d.Decode(pkts[2])
+	require.EqualError(t, err, "discarding frame since a RTP packet is missing")
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a []byte, am bool, b []byte, bm bool) {
+		d := &Decoder{}
+		d.Init() //nolint:errcheck
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Marker: am,
+			},
+			Payload: a,
+		})
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Marker: bm,
+			},
+			Payload: b,
+		})
+	})
+}
@@ -0,0 +1,175 @@
+package rtpjpegxs
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+	maxCounter            = 0x7FF
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+func packetCount(avail, le int) int {
+	n := le / avail
+	if (le % avail) != 0 {
+		n++
+	}
+	return n
+}
+
+func joinFragments(fragments [][]byte, size int) []byte {
+	ret := make([]byte, size)
+	n := 0
+	for _, p := range fragments {
+		n += copy(ret[n:], p)
+	}
+	return ret
+}
+
+// Encoder is a RTP/JPEG XS encoder.
+// Packets are always transmitted in sequential order.
+// Specification: https://datatracker.ietf.org/doc/html/rfc9134
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	// packetization mode (optional).
+	// 0 = codestream packetization mode, 1 = slice packetization mode.
+	PacketizationMode int
+
+	sequenceNumber uint16
+	frameCounter   uint8
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.PacketizationMode != 0 && e.PacketizationMode != 1 {
+		return fmt.Errorf("unsupported packetization mode: %d", e.PacketizationMode)
+	}
+
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	return nil
+}
+
+// Encode encodes a frame into RTP packets.
+// In codestream packetization mode, units are concatenated and sent as a single codestream.
+// In slice packetization mode, the first unit must contain the codestream header
+// and each of the following units must contain a single slice.
+func (e *Encoder) Encode(units [][]byte) ([]*rtp.Packet, error) {
+	if len(units) == 0 {
+		return nil, fmt.Errorf("frame is empty")
+	}
+
+	if e.PacketizationMode == 0 {
+		size := 0
+		for _, unit := range units {
+			size += len(unit)
+		}
+		units = [][]byte{joinFragments(units, size)}
+	}
+
+	avail := e.PayloadMaxSize - headerSize
+	var ret []*rtp.Packet
+	sepCounter := uint16(0)
+	packetCounter := uint16(0)
+
+	for i, unit := range units {
+		if len(unit) == 0 {
+			return nil, fmt.Errorf("packetization unit is empty")
+		}
+
+		packetCount := packetCount(avail, len(unit))
+
+		for j := 0; j < packetCount; j++ {
+			le := avail
+			if j == (packetCount - 1) {
+				le = len(unit)
+			}
+
+			h := header{
+				Sequential:    true,
+				SliceMode:     e.PacketizationMode == 1,
+				Last:          j == (packetCount - 1),
+				FrameCounter:  e.frameCounter,
+				SEPCounter:    sepCounter,
+				PacketCounter: packetCounter,
+			}
+
+			payload := h.marshal(make([]byte, 0, headerSize+le))
+			payload = append(payload, unit[:le]...)
+			unit = unit[le:]
+
+			ret = append(ret, &rtp.Packet{
+				Header: rtp.Header{
+					Version:        rtpVersion,
+					PayloadType:    e.PayloadType,
+					SequenceNumber: e.sequenceNumber,
+					SSRC:           *e.SSRC,
+					Marker:         h.Last && i == (len(units)-1),
+				},
+				Payload: payload,
+			})
+			e.sequenceNumber++
+
+			// in slice mode, the SEP counter is the slice index.
+			// in codestream mode, it is incremented when the packet counter wraps around.
+			if h.SliceMode && h.Last {
+				sepCounter = (sepCounter + 1) & maxCounter
+				packetCounter = 0
+			} else if packetCounter == maxCounter {
+				sepCounter = (sepCounter + 1) & maxCounter
+				packetCounter = 0
+			} else {
+				packetCounter++
+			}
+		}
+	}
+
+	e.frameCounter = (e.frameCounter + 1) & 0b11111
+
+	return ret, nil
+}
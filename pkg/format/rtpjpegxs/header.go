@@ -0,0 +1,59 @@
+package rtpjpegxs
+
+import (
+	"fmt"
+)
+
+const (
+	headerSize = 4
+)
+
+// payload header.
+// Specification: https://datatracker.ietf.org/doc/html/rfc9134#section-4.3
+type header struct {
+	Sequential    bool
+	SliceMode     bool
+	Last          bool
+	Interlace     uint8
+	FrameCounter  uint8
+	SEPCounter    uint16
+	PacketCounter uint16
+}
+
+func (h *header) unmarshal(byts []byte) (int, error) {
+	if len(byts) < headerSize {
+		return 0, fmt.Errorf("buffer is too short")
+	}
+
+	v := uint32(byts[0])<<24 | uint32(byts[1])<<16 | uint32(byts[2])<<8 | uint32(byts[3])
+
+	h.Sequential = ((v >> 31) & 0b1) != 0
+	h.SliceMode = ((v >> 30) & 0b1) != 0
+	h.Last = ((v >> 29) & 0b1) != 0
+	h.Interlace = uint8((v >> 27) & 0b11)
+	h.FrameCounter = uint8((v >> 22) & 0b11111)
+	h.SEPCounter = uint16((v >> 11) & 0x7FF)
+	h.PacketCounter = uint16(v & 0x7FF)
+
+	return headerSize, nil
+}
+
+func (h header) marshal(byts []byte) []byte {
+	var v uint32
+
+	if h.Sequential {
+		v |= 1 << 31
+	}
+	if h.SliceMode {
+		v |= 1 << 30
+	}
+	if h.Last {
+		v |= 1 << 29
+	}
+	v |= uint32(h.Interlace&0b11) << 27
+	v |= uint32(h.FrameCounter&0b11111) << 22
+	v |= uint32(h.SEPCounter&0x7FF) << 11
+	v |= uint32(h.PacketCounter & 0x7FF)
+
+	return append(byts, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
@@ -0,0 +1,57 @@
+package rtpjpegxs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesHeader = []struct {
+	name string
+	enc  []byte
+	dec  header
+}{
+	{
+		"codestream",
+		[]byte{0x80, 0x80, 0x08, 0x05},
+		header{
+			Sequential:    true,
+			FrameCounter:  2,
+			SEPCounter:    1,
+			PacketCounter: 5,
+		},
+	},
+	{
+		"slice",
+		[]byte{0xff, 0xff, 0xff, 0xff},
+		header{
+			Sequential:    true,
+			SliceMode:     true,
+			Last:          true,
+			Interlace:     3,
+			FrameCounter:  31,
+			SEPCounter:    2047,
+			PacketCounter: 2047,
+		},
+	},
+}
+
+func TestHeaderUnmarshal(t *testing.T) {
+	for _, ca := range casesHeader {
+		t.Run(ca.name, func(t *testing.T) {
+			var h header
+			_, err := h.unmarshal(ca.enc)
+			require.NoError(t, err)
+			require.Equal(t, ca.dec, h)
+		})
+	}
+}
+
+func TestHeaderMarshal(t *testing.T) {
+	for _, ca := range casesHeader {
+		t.Run(ca.name, func(t *testing.T) {
+			buf := ca.dec.marshal(nil)
+			require.Equal(t, ca.enc, buf)
+		})
+	}
+}
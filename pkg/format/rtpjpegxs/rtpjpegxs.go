@@ -0,0 +1,5 @@
+// Package rtpjpegxs contains a RTP/JPEG XS decoder and encoder.
+package rtpjpegxs
+
+// MaxFrameSize is the maximum size of a frame.
+const MaxFrameSize = 16 * 1024 * 1024
@@ -0,0 +1,66 @@
+package rtpklv
+
+const defaultAlignerMaxPending = 64
+
+type alignerUnit struct {
+	unit      []byte
+	timestamp uint32
+}
+
+// Aligner associates KLV units to video frames by RTP timestamp.
+// KLV units and video frames must share the clock rate and the timestamp base,
+// as it happens when timestamps of KLV units are bound to the video track.
+type Aligner struct {
+	// maximum number of KLV units waiting for a video frame.
+	// When exceeded, the oldest units are discarded.
+	// It defaults to 64.
+	MaxPending int
+
+	pending []alignerUnit
+}
+
+// Init initializes the Aligner.
+func (a *Aligner) Init() {
+	if a.MaxPending == 0 {
+		a.MaxPending = defaultAlignerMaxPending
+	}
+}
+
+// PushKLV adds a KLV unit and its timestamp.
+func (a *Aligner) PushKLV(unit []byte, timestamp uint32) {
+	if len(a.pending) >= a.MaxPending {
+		a.pending = a.pending[1:]
+	}
+	a.pending = append(a.pending, alignerUnit{unit: unit, timestamp: timestamp})
+}
+
+// AlignVideo returns the KLV units that refer to a video frame,
+// that are the pending ones whose timestamp is less than or equal to the timestamp of the frame.
+// Returned units are removed from pending ones.
+// Units whose timestamp is greater are kept for the next frames.
+func (a *Aligner) AlignVideo(timestamp uint32) [][]byte {
+	var ret [][]byte
+	n := 0
+
+	for _, u := range a.pending {
+		// use a signed difference in order to support timestamp wrap-arounds
+		if int32(u.timestamp-timestamp) <= 0 {
+			ret = append(ret, u.unit)
+		} else {
+			a.pending[n] = u
+			n++
+		}
+	}
+
+	for i := n; i < len(a.pending); i++ {
+		a.pending[i] = alignerUnit{}
+	}
+	a.pending = a.pending[:n]
+
+	return ret
+}
+
+// Pending returns the number of KLV units waiting for a video frame.
+func (a *Aligner) Pending() int {
+	return len(a.pending)
+}
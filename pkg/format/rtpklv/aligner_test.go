@@ -0,0 +1,35 @@
+package rtpklv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAligner(t *testing.T) {
+	a := &Aligner{}
+	a.Init()
+
+	a.PushKLV([]byte{1}, 0xFFFFFF00)
+	a.PushKLV([]byte{2}, 0xFFFFFFF0)
+	a.PushKLV([]byte{3}, 3000)
+
+	require.Equal(t, [][]byte(nil), a.AlignVideo(0xFFFFFE00))
+	require.Equal(t, [][]byte{{1}, {2}}, a.AlignVideo(0xFFFFFFF0))
+
+	// timestamps wrap around
+	require.Equal(t, [][]byte{{3}}, a.AlignVideo(6000))
+	require.Equal(t, 0, a.Pending())
+}
+
+func TestAlignerMaxPending(t *testing.T) {
+	a := &Aligner{MaxPending: 2}
+	a.Init()
+
+	a.PushKLV([]byte{1}, 1000)
+	a.PushKLV([]byte{2}, 2000)
+	a.PushKLV([]byte{3}, 3000)
+
+	require.Equal(t, 2, a.Pending())
+	require.Equal(t, [][]byte{{2}, {3}}, a.AlignVideo(3000))
+}
@@ -0,0 +1,81 @@
+package rtpklv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// MaxUnitSize is the maximum size of a KLV unit.
+const MaxUnitSize = 1 * 1024 * 1024
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+func joinFragments(fragments [][]byte, size int) []byte {
+	ret := make([]byte, size)
+	n := 0
+	for _, p := range fragments {
+		n += copy(ret[n:], p)
+	}
+	return ret
+}
+
+// Decoder is a RTP/KLV decoder.
+// Units can be split into multiple packets, the last of which has the marker bit set.
+// All packets of a unit share the same timestamp.
+// Specification: https://datatracker.ietf.org/doc/html/rfc6597
+type Decoder struct {
+	fragments     [][]byte
+	fragmentsSize int
+	timestamp     uint32
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes a KLV unit from a RTP packet.
+// The timestamp of the unit is the one of the packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	if len(d.fragments) == 0 {
+		// the first packet of a unit starts with a universal key
+		if !hasUniversalKeyPrefix(pkt.Payload) {
+			return nil, fmt.Errorf("payload doesn't start with a universal key")
+		}
+
+		if pkt.Marker {
+			return pkt.Payload, nil
+		}
+
+		d.fragmentsSize = len(pkt.Payload)
+		d.fragments = append(d.fragments, pkt.Payload)
+		d.timestamp = pkt.Timestamp
+		return nil, ErrMorePacketsNeeded
+	}
+
+	// the packet with the marker bit of the previous unit has been lost
+	if pkt.Timestamp != d.timestamp {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		return nil, fmt.Errorf("received a fragment with a different timestamp than the previous one")
+	}
+
+	d.fragmentsSize += len(pkt.Payload)
+	if d.fragmentsSize > MaxUnitSize {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		return nil, fmt.Errorf("unit size (%d) is too big, maximum is %d", d.fragmentsSize, MaxUnitSize)
+	}
+
+	d.fragments = append(d.fragments, pkt.Payload)
+
+	if !pkt.Marker {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	unit := joinFragments(d.fragments, d.fragmentsSize)
+	d.fragments = d.fragments[:0]
+
+	return unit, nil
+}
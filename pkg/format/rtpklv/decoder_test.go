@@ -0,0 +1,101 @@
+package rtpklv
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var unit []byte
+
+			for _, pkt := range ca.pkts {
+				unit, err = d.Decode(pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, ca.unit, unit)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	t.Run("missing key", func(t *testing.T) {
+		d := &Decoder{}
+		err := d.Init()
+		require.NoError(t, err)
+
+		_, err = d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Marker: true,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		})
+		require.EqualError(t, err, "payload doesn't start with a universal key")
+	})
+
+	t.Run("missing marker", func(t *testing.T) {
+		d := &Decoder{}
+		err := d.Init()
+		require.NoError(t, err)
+
+		_, err = d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Timestamp: 1000,
+			},
+			Payload: testKey[:],
+		})
+		require.Equal(t, ErrMorePacketsNeeded, err)
+
+		_, err = d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Marker:    true,
+				Timestamp: 2000,
+			},
+			Payload: []byte{1, 2},
+		})
+		require.EqualError(t, err, "received a fragment with a different timestamp than the previous one")
+	})
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a []byte, am bool, b []byte, bm bool) {
+		d := &Decoder{}
+		d.Init() //nolint:errcheck
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         am,
+				PayloadType:    96,
+				SequenceNumber: 17645,
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: a,
+		})
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         bm,
+				PayloadType:    96,
+				SequenceNumber: 17646,
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: b,
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package rtpklv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+var testKey = [16]byte{
+	0x06, 0x0e, 0x2b, 0x34, 0x02, 0x0b, 0x01, 0x01,
+	0x0e, 0x01, 0x03, 0x01, 0x01, 0x00, 0x00, 0x00,
+}
+
+var cases = []struct {
+	name string
+	unit []byte
+	pkts []*rtp.Packet
+}{
+	{
+		"single",
+		append(append(testKey[:len(testKey):len(testKey)], 0x04), 1, 2, 3, 4),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: append(append(testKey[:len(testKey):len(testKey)], 0x04), 1, 2, 3, 4),
+			},
+		},
+	},
+	{
+		"fragmented",
+		append(append(testKey[:len(testKey):len(testKey)], 0x81, 0x84), bytes.Repeat([]byte{1, 2}, 66)...),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: append(append(testKey[:len(testKey):len(testKey)], 0x81, 0x84),
+					bytes.Repeat([]byte{1, 2}, 41)...),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{1, 2}, 25),
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(17645),
+				PayloadMaxSize:        100,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.unit)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
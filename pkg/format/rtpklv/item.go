@@ -0,0 +1,139 @@
+package rtpklv
+
+import (
+	"fmt"
+)
+
+// prefix of SMPTE universal keys.
+var universalKeyPrefix = [4]byte{0x06, 0x0E, 0x2B, 0x34}
+
+func hasUniversalKeyPrefix(buf []byte) bool {
+	return len(buf) >= 4 &&
+		buf[0] == universalKeyPrefix[0] &&
+		buf[1] == universalKeyPrefix[1] &&
+		buf[2] == universalKeyPrefix[2] &&
+		buf[3] == universalKeyPrefix[3]
+}
+
+// Item is a KLV item (SMPTE ST 336), made of a 16-byte universal key,
+// a BER-encoded length and a value.
+// The value of MISB ST 0601 items (UAS Datalink Local Set) is a local set
+// that can be decoded in turn.
+type Item struct {
+	Key   [16]byte
+	Value []byte
+}
+
+func (i Item) marshalSize() int {
+	return 16 + berLengthSize(len(i.Value)) + len(i.Value)
+}
+
+func berLengthSize(le int) int {
+	if le < 128 {
+		return 1
+	}
+
+	n := 1
+	for v := le; v != 0; v >>= 8 {
+		n++
+	}
+	return n
+}
+
+func marshalBERLength(buf []byte, le int) int {
+	if le < 128 {
+		buf[0] = byte(le)
+		return 1
+	}
+
+	n := berLengthSize(le) - 1
+	buf[0] = 0x80 | byte(n)
+	for j := 0; j < n; j++ {
+		buf[n-j] = byte(le >> (8 * j))
+	}
+	return 1 + n
+}
+
+func unmarshalBERLength(buf []byte) (int, int, error) {
+	if len(buf) < 1 {
+		return 0, 0, fmt.Errorf("not enough bytes")
+	}
+
+	if (buf[0] & 0x80) == 0 {
+		return int(buf[0]), 1, nil
+	}
+
+	n := int(buf[0] & 0x7F)
+	if n == 0 || n > 4 {
+		return 0, 0, fmt.Errorf("unsupported length size: %d", n)
+	}
+
+	if len(buf) < 1+n {
+		return 0, 0, fmt.Errorf("not enough bytes")
+	}
+
+	le := 0
+	for j := 0; j < n; j++ {
+		le = le<<8 | int(buf[1+j])
+	}
+
+	if le < 0 || le > MaxUnitSize {
+		return 0, 0, fmt.Errorf("length (%d) is too big, maximum is %d", le, MaxUnitSize)
+	}
+
+	return le, 1 + n, nil
+}
+
+// UnmarshalUnit decodes the KLV items of a KLV unit.
+func UnmarshalUnit(unit []byte) ([]*Item, error) {
+	var items []*Item
+
+	for len(unit) != 0 {
+		if !hasUniversalKeyPrefix(unit) || len(unit) < 16 {
+			return nil, fmt.Errorf("invalid universal key")
+		}
+
+		var item Item
+		copy(item.Key[:], unit[:16])
+		unit = unit[16:]
+
+		le, n, err := unmarshalBERLength(unit)
+		if err != nil {
+			return nil, err
+		}
+		unit = unit[n:]
+
+		if len(unit) < le {
+			return nil, fmt.Errorf("value is too short: expected %d bytes, got %d", le, len(unit))
+		}
+
+		item.Value = unit[:le]
+		unit = unit[le:]
+
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+// MarshalUnit encodes KLV items into a KLV unit.
+func MarshalUnit(items []*Item) ([]byte, error) {
+	size := 0
+	for _, item := range items {
+		if !hasUniversalKeyPrefix(item.Key[:]) {
+			return nil, fmt.Errorf("invalid universal key")
+		}
+		size += item.marshalSize()
+	}
+
+	buf := make([]byte, size)
+	n := 0
+
+	for _, item := range items {
+		n += copy(buf[n:], item.Key[:])
+		n += marshalBERLength(buf[n:], len(item.Value))
+		n += copy(buf[n:], item.Value)
+	}
+
+	return buf, nil
+}
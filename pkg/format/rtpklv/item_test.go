@@ -0,0 +1,102 @@
+package rtpklv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesItems = []struct {
+	name  string
+	unit  []byte
+	items []*Item
+}{
+	{
+		"short length",
+		append(append(testKey[:len(testKey):len(testKey)], 0x03), 1, 2, 3),
+		[]*Item{{
+			Key:   testKey,
+			Value: []byte{1, 2, 3},
+		}},
+	},
+	{
+		"long length and multiple items",
+		append(append(append(append(testKey[:len(testKey):len(testKey)], 0x82, 0x01, 0x2c),
+			bytes.Repeat([]byte{1}, 300)...), testKey[:]...), 0x00),
+		[]*Item{
+			{
+				Key:   testKey,
+				Value: bytes.Repeat([]byte{1}, 300),
+			},
+			{
+				Key:   testKey,
+				Value: []byte{},
+			},
+		},
+	},
+}
+
+func TestUnmarshalUnit(t *testing.T) {
+	for _, ca := range casesItems {
+		t.Run(ca.name, func(t *testing.T) {
+			items, err := UnmarshalUnit(ca.unit)
+			require.NoError(t, err)
+			require.Equal(t, ca.items, items)
+		})
+	}
+}
+
+func TestMarshalUnit(t *testing.T) {
+	for _, ca := range casesItems {
+		t.Run(ca.name, func(t *testing.T) {
+			unit, err := MarshalUnit(ca.items)
+			require.NoError(t, err)
+			require.Equal(t, ca.unit, unit)
+		})
+	}
+}
+
+func TestUnmarshalUnitErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		unit []byte
+		err  string
+	}{
+		{
+			"invalid key",
+			[]byte{1, 2, 3, 4},
+			"invalid universal key",
+		},
+		{
+			"missing length",
+			testKey[:],
+			"not enough bytes",
+		},
+		{
+			"unsupported length size",
+			append(testKey[:len(testKey):len(testKey)], 0x85),
+			"unsupported length size: 5",
+		},
+		{
+			"value too short",
+			append(testKey[:len(testKey):len(testKey)], 0x05, 1, 2),
+			"value is too short: expected 5 bytes, got 2",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := UnmarshalUnit(ca.unit)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func FuzzUnmarshalUnit(f *testing.F) {
+	f.Fuzz(func(t *testing.T, b []byte) {
+		items, err := UnmarshalUnit(b)
+		if err == nil {
+			_, err = MarshalUnit(items)
+			require.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,2 @@
+// Package rtpklv contains a RTP/KLV decoder and encoder.
+package rtpklv
@@ -73,6 +73,15 @@ func (e *Encoder) Init() error {
 
 	e.sequenceNumber = *e.InitialSequenceNumber
 	e.sampleSize = e.BitDepth * e.ChannelCount / 8
+
+	if e.sampleSize == 0 {
+		return fmt.Errorf("invalid bit depth or channel count")
+	}
+
+	if e.sampleSize > e.PayloadMaxSize {
+		return fmt.Errorf("sample size (%d) is greater than payload max size (%d)", e.sampleSize, e.PayloadMaxSize)
+	}
+
 	e.maxPayloadSize = (e.PayloadMaxSize / e.sampleSize) * e.sampleSize
 	return nil
 }
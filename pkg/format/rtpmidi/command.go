@@ -0,0 +1,75 @@
+package rtpmidi
+
+import (
+	"fmt"
+)
+
+// Command is a MIDI command.
+type Command struct {
+	// time elapsed since the previous command, in clock ticks.
+	// The delta time of the first command is relative to the RTP timestamp.
+	DeltaTime uint32
+
+	// command, including the status byte.
+	Data []byte
+}
+
+// dataLength returns the number of data bytes that follow a status byte.
+// System exclusive messages are terminated by 0xF7 and are handled separately.
+func dataLength(status byte) int {
+	switch {
+	case status >= 0x80 && status <= 0xBF, status >= 0xE0 && status <= 0xEF:
+		return 2
+
+	case status >= 0xC0 && status <= 0xDF:
+		return 1
+
+	case status == 0xF1, status == 0xF3:
+		return 1
+
+	case status == 0xF2:
+		return 2
+	}
+
+	return 0
+}
+
+func readDeltaTime(buf []byte, pos *int) (uint32, error) {
+	var v uint32
+
+	for i := 0; i < 4; i++ {
+		if *pos >= len(buf) {
+			return 0, fmt.Errorf("not enough bytes")
+		}
+
+		b := buf[*pos]
+		*pos++
+
+		v = v<<7 | uint32(b&0x7F)
+
+		if (b & 0x80) == 0 {
+			return v, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid delta time")
+}
+
+func deltaTimeSize(v uint32) int {
+	n := 1
+	for v >>= 7; v != 0; v >>= 7 {
+		n++
+	}
+	return n
+}
+
+func writeDeltaTime(buf []byte, v uint32) int {
+	n := deltaTimeSize(v)
+	for i := n - 1; i >= 0; i-- {
+		buf[n-1-i] = byte(v>>(7*i)) & 0x7F
+		if i != 0 {
+			buf[n-1-i] |= 0x80
+		}
+	}
+	return n
+}
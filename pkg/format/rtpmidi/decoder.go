@@ -0,0 +1,141 @@
+package rtpmidi
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// Decoder is a RTP/MIDI decoder.
+// The recovery journal is not supported and is skipped.
+// Specification: https://datatracker.ietf.org/doc/html/rfc6295
+type Decoder struct {
+	// status byte of the last channel command, used by running status.
+	runningStatus byte
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes MIDI commands from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]*Command, error) {
+	if len(pkt.Payload) < 1 {
+		return nil, fmt.Errorf("payload is too short")
+	}
+
+	b := (pkt.Payload[0] & 0x80) != 0
+	z := (pkt.Payload[0] & 0x20) != 0
+	length := int(pkt.Payload[0] & 0x0F)
+	pos := 1
+
+	if b {
+		if len(pkt.Payload) < 2 {
+			return nil, fmt.Errorf("payload is too short")
+		}
+
+		length = length<<8 | int(pkt.Payload[1])
+		pos++
+	}
+
+	if len(pkt.Payload[pos:]) < length {
+		return nil, fmt.Errorf("invalid length")
+	}
+
+	list := pkt.Payload[pos : pos+length]
+	pos = 0
+
+	// the first channel command of a list always has a status byte
+	d.runningStatus = 0
+
+	var cmds []*Command
+
+	for pos < len(list) {
+		var deltaTime uint32
+
+		if len(cmds) != 0 || z {
+			var err error
+			deltaTime, err = readDeltaTime(list, &pos)
+			if err != nil {
+				return nil, err
+			}
+
+			if pos >= len(list) {
+				return nil, fmt.Errorf("delta time without command")
+			}
+		}
+
+		data, err := d.readCommand(list, &pos)
+		if err != nil {
+			return nil, err
+		}
+
+		cmds = append(cmds, &Command{
+			DeltaTime: deltaTime,
+			Data:      data,
+		})
+	}
+
+	return cmds, nil
+}
+
+func (d *Decoder) readCommand(list []byte, pos *int) ([]byte, error) {
+	status := list[*pos]
+
+	// running status
+	if (status & 0x80) == 0 {
+		if d.runningStatus == 0 {
+			return nil, fmt.Errorf("data byte without status")
+		}
+
+		n := dataLength(d.runningStatus)
+		if len(list[*pos:]) < n {
+			return nil, fmt.Errorf("not enough bytes")
+		}
+
+		data := make([]byte, 1+n)
+		data[0] = d.runningStatus
+		copy(data[1:], list[*pos:*pos+n])
+		*pos += n
+		return data, nil
+	}
+
+	start := *pos
+	*pos++
+
+	switch {
+	case status == 0xF0:
+		for {
+			if *pos >= len(list) {
+				return nil, fmt.Errorf("unterminated system exclusive message")
+			}
+
+			b := list[*pos]
+			*pos++
+
+			if b == 0xF7 {
+				break
+			}
+		}
+
+		d.runningStatus = 0
+
+	case status < 0xF0:
+		d.runningStatus = status
+		*pos += dataLength(status)
+
+	// system common messages cancel running status, real-time messages do not
+	case status < 0xF8:
+		d.runningStatus = 0
+		*pos += dataLength(status)
+	}
+
+	if *pos > len(list) {
+		return nil, fmt.Errorf("not enough bytes")
+	}
+
+	data := make([]byte, *pos-start)
+	copy(data, list[start:*pos])
+	return data, nil
+}
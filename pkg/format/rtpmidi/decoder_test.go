@@ -0,0 +1,94 @@
+package rtpmidi
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var cmds []*Command
+
+			for _, pkt := range ca.pkts {
+				var tmp []*Command
+				tmp, err = d.Decode(pkt)
+				require.NoError(t, err)
+
+				// delta times of the first command of subsequent packets are relative to the timestamp
+				if len(cmds) != 0 && len(tmp) != 0 {
+					var elapsed uint32
+					for _, cmd := range cmds {
+						elapsed += cmd.DeltaTime
+					}
+					tmp[0].DeltaTime -= elapsed
+				}
+
+				cmds = append(cmds, tmp...)
+			}
+
+			require.Equal(t, ca.cmds, cmds)
+		})
+	}
+}
+
+func TestDecodeRunningStatusAndJournal(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	cmds, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{
+			0x4b, 0x90, 0x3c, 0x40, 0x00, 0x3e, 0x40, 0x00,
+			0xf8, 0x00, 0x40, 0x40,
+			0x01, 0x02, 0x03, // recovery journal
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*Command{
+		{
+			Data: []byte{0x90, 0x3c, 0x40},
+		},
+		{
+			Data: []byte{0x90, 0x3e, 0x40},
+		},
+		{
+			Data: []byte{0xf8},
+		},
+		{
+			Data: []byte{0x90, 0x40, 0x40},
+		},
+	}, cmds)
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a []byte) {
+		d := &Decoder{}
+		d.Init() //nolint:errcheck
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 17645,
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: a,
+		})
+	})
+}
@@ -0,0 +1,191 @@
+package rtpmidi
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+	maxListLength         = 0x0FFF
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/MIDI encoder.
+// The recovery journal is not supported.
+// Specification: https://datatracker.ietf.org/doc/html/rfc6295
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	sequenceNumber uint16
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	return nil
+}
+
+func (e *Encoder) listMaxSize() int {
+	n := e.PayloadMaxSize - 2
+	if n > maxListLength {
+		n = maxListLength
+	}
+	return n
+}
+
+func (e *Encoder) encodeList(cmds []*Command, firstDeltaTime uint32) []byte {
+	z := firstDeltaTime != 0
+
+	n := 0
+	for i, cmd := range cmds {
+		if i != 0 {
+			n += deltaTimeSize(cmd.DeltaTime)
+		} else if z {
+			n += deltaTimeSize(firstDeltaTime)
+		}
+		n += len(cmd.Data)
+	}
+
+	var payload []byte
+	var pos int
+
+	if n > 0x0F {
+		payload = make([]byte, 2+n)
+		payload[0] = 0x80 | byte(n>>8)
+		payload[1] = byte(n)
+		pos = 2
+	} else {
+		payload = make([]byte, 1+n)
+		payload[0] = byte(n)
+		pos = 1
+	}
+
+	if z {
+		payload[0] |= 0x20
+	}
+
+	for i, cmd := range cmds {
+		if i != 0 {
+			pos += writeDeltaTime(payload[pos:], cmd.DeltaTime)
+		} else if z {
+			pos += writeDeltaTime(payload[pos:], firstDeltaTime)
+		}
+		pos += copy(payload[pos:], cmd.Data)
+	}
+
+	return payload
+}
+
+// Encode encodes MIDI commands into RTP/MIDI packets.
+// When commands do not fit into a single packet, they are split among multiple packets
+// that share the same timestamp; delta times are adjusted accordingly.
+func (e *Encoder) Encode(cmds []*Command) ([]*rtp.Packet, error) {
+	listMaxSize := e.listMaxSize()
+
+	var payloads [][]byte
+	var batch []*Command
+	batchSize := 0
+	var batchDeltaTime uint32
+	var elapsed uint32
+
+	for _, cmd := range cmds {
+		if len(cmd.Data) == 0 || (cmd.Data[0]&0x80) == 0 {
+			return nil, fmt.Errorf("command without status byte")
+		}
+
+		if cmd.DeltaTime > 0x0FFFFFFF {
+			return nil, fmt.Errorf("delta time is too big")
+		}
+
+		elapsed += cmd.DeltaTime
+
+		size := deltaTimeSize(cmd.DeltaTime) + len(cmd.Data)
+
+		if batch != nil && (batchSize+size) > listMaxSize {
+			payloads = append(payloads, e.encodeList(batch, batchDeltaTime))
+			batch = nil
+			batchSize = 0
+		}
+
+		if batch == nil {
+			// the delta time of the first command is relative to the RTP timestamp
+			batchDeltaTime = elapsed
+			if batchDeltaTime > 0x0FFFFFFF {
+				return nil, fmt.Errorf("delta time is too big")
+			}
+			size = deltaTimeSize(batchDeltaTime) + len(cmd.Data)
+		}
+
+		if size > listMaxSize {
+			return nil, fmt.Errorf("command is too big")
+		}
+
+		batch = append(batch, cmd)
+		batchSize += size
+	}
+
+	payloads = append(payloads, e.encodeList(batch, batchDeltaTime))
+
+	ret := make([]*rtp.Packet, len(payloads))
+
+	for i, payload := range payloads {
+		ret[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.PayloadType,
+				SequenceNumber: e.sequenceNumber,
+				SSRC:           *e.SSRC,
+				Marker:         len(payload) > 1, // LEN is not zero
+			},
+			Payload: payload,
+		}
+
+		e.sequenceNumber++
+	}
+
+	return ret, nil
+}
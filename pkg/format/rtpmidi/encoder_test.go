@@ -0,0 +1,142 @@
+package rtpmidi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+var cases = []struct {
+	name string
+	cmds []*Command
+	pkts []*rtp.Packet
+}{
+	{
+		"single",
+		[]*Command{{
+			Data: []byte{0x90, 0x3c, 0x40},
+		}},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0x03, 0x90, 0x3c, 0x40,
+				},
+			},
+		},
+	},
+	{
+		"delta times",
+		[]*Command{
+			{
+				DeltaTime: 10,
+				Data:      []byte{0x90, 0x3c, 0x40},
+			},
+			{
+				DeltaTime: 200,
+				Data:      []byte{0x80, 0x3c, 0x00},
+			},
+			{
+				DeltaTime: 0,
+				Data:      []byte{0xf0, 0x7e, 0x7f, 0x09, 0x01, 0xf7},
+			},
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0x80 | 0x20, 0x10, 0x0a, 0x90, 0x3c, 0x40, 0x81, 0x48,
+					0x80, 0x3c, 0x00, 0x00, 0xf0, 0x7e, 0x7f, 0x09,
+					0x01, 0xf7,
+				},
+			},
+		},
+	},
+	{
+		"split",
+		[]*Command{
+			{
+				Data: append(append([]byte{0xf0}, bytes.Repeat([]byte{0x01}, 95)...), 0xf7),
+			},
+			{
+				DeltaTime: 5,
+				Data:      []byte{0x90, 0x3c, 0x40},
+			},
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: append(append([]byte{0x80, 0x61, 0xf0}, bytes.Repeat([]byte{0x01}, 95)...), 0xf7),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0x24, 0x05, 0x90, 0x3c, 0x40,
+				},
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(0x44ed),
+				PayloadMaxSize:        100,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.cmds)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
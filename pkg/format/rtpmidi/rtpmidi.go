@@ -0,0 +1,2 @@
+// Package rtpmidi contains a RTP/MIDI decoder and encoder.
+package rtpmidi
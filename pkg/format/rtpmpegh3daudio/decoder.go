@@ -0,0 +1,68 @@
+package rtpmpegh3daudio
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+func joinFragments(fragments [][]byte, size int) []byte {
+	ret := make([]byte, size)
+	n := 0
+	for _, p := range fragments {
+		n += copy(ret[n:], p)
+	}
+	return ret
+}
+
+// Decoder is a RTP/MPEG-H 3D Audio decoder.
+// Each access unit is a sequence of MHAS packets, that is carried by one or more
+// RTP packets. The last packet of an access unit has the marker bit set.
+// Specification: ISO/IEC 23008-3, MPEG-H 3D Audio Stream (MHAS)
+type Decoder struct {
+	fragments     [][]byte
+	fragmentsSize int
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes an access unit from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	if len(pkt.Payload) == 0 {
+		d.fragments = d.fragments[:0] // discard pending fragments
+		d.fragmentsSize = 0
+		return nil, fmt.Errorf("payload is empty")
+	}
+
+	if d.fragmentsSize == 0 && pkt.Marker {
+		return pkt.Payload, nil
+	}
+
+	d.fragmentsSize += len(pkt.Payload)
+	if d.fragmentsSize > MaxAccessUnitSize {
+		errSize := d.fragmentsSize
+		d.fragments = d.fragments[:0] // discard pending fragments
+		d.fragmentsSize = 0
+		return nil, fmt.Errorf("access unit size (%d) is too big, maximum is %d",
+			errSize, MaxAccessUnitSize)
+	}
+
+	d.fragments = append(d.fragments, pkt.Payload)
+
+	if !pkt.Marker {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	au := joinFragments(d.fragments, d.fragmentsSize)
+	d.fragments = d.fragments[:0]
+	d.fragmentsSize = 0
+
+	return au, nil
+}
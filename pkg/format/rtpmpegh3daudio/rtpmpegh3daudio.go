@@ -0,0 +1,5 @@
+// Package rtpmpegh3daudio contains a RTP/MPEG-H 3D Audio decoder and encoder.
+package rtpmpegh3daudio
+
+// MaxAccessUnitSize is the maximum size of an access unit.
+const MaxAccessUnitSize = 1 * 1024 * 1024
@@ -0,0 +1,60 @@
+package rtpmpegts
+
+import (
+	"errors"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/MPEG-TS decoder.
+// Payloads are expected to contain MPEG-TS packets,
+// although packets split among multiple payloads are reassembled too.
+// Specification: https://datatracker.ietf.org/doc/html/rfc2250
+type Decoder struct {
+	buf []byte
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes MPEG-TS packets from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
+	buf := pkt.Payload
+	if len(d.buf) != 0 {
+		buf = append(d.buf, buf...)
+	}
+
+	var ret [][]byte
+
+	for {
+		// find sync byte
+		i := 0
+		for i < len(buf) && buf[i] != syncByte {
+			i++
+		}
+		buf = buf[i:]
+
+		if len(buf) < packetSize {
+			break
+		}
+
+		tsPkt := make([]byte, packetSize)
+		copy(tsPkt, buf[:packetSize])
+		ret = append(ret, tsPkt)
+		buf = buf[packetSize:]
+	}
+
+	// keep the remaining bytes, that are the beginning of the next packet
+	d.buf = append(d.buf[:0], buf...)
+
+	if ret == nil {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,102 @@
+package rtpmpegts
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var tsPackets [][]byte
+
+			for _, pkt := range ca.pkts {
+				var tmp [][]byte
+				tmp, err = d.Decode(pkt)
+				require.NoError(t, err)
+				tsPackets = append(tsPackets, tmp...)
+			}
+
+			require.Equal(t, ca.tsPackets, tsPackets)
+		})
+	}
+}
+
+func TestDecodeSplit(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	payload := mergeBytes([]byte{0x01, 0x02}, tsPacket(1), tsPacket(2))
+
+	tsPackets, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    33,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: payload[:250],
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{tsPacket(1)}, tsPackets)
+
+	tsPackets, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    33,
+			SequenceNumber: 17646,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: payload[250:300],
+	})
+	require.Equal(t, ErrMorePacketsNeeded, err)
+	require.Equal(t, [][]byte(nil), tsPackets)
+
+	tsPackets, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    33,
+			SequenceNumber: 17647,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: payload[300:],
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{tsPacket(2)}, tsPackets)
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		d := &Decoder{}
+		d.Init() //nolint:errcheck
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    33,
+				SequenceNumber: 17645,
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: a,
+		})
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    33,
+				SequenceNumber: 17646,
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: b,
+		})
+	})
+}
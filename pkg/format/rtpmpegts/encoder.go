@@ -0,0 +1,110 @@
+package rtpmpegts
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/MPEG-TS encoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc2250
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	sequenceNumber uint16
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	if e.PayloadMaxSize < packetSize {
+		return fmt.Errorf("payload max size must be at least %d", packetSize)
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	return nil
+}
+
+// Encode encodes MPEG-TS packets into RTP/MPEG-TS packets.
+func (e *Encoder) Encode(tsPackets [][]byte) ([]*rtp.Packet, error) {
+	perPacket := e.PayloadMaxSize / packetSize
+
+	var ret []*rtp.Packet
+
+	for len(tsPackets) != 0 {
+		n := perPacket
+		if n > len(tsPackets) {
+			n = len(tsPackets)
+		}
+
+		payload := make([]byte, n*packetSize)
+
+		for i, tsPkt := range tsPackets[:n] {
+			if len(tsPkt) != packetSize || tsPkt[0] != syncByte {
+				return nil, fmt.Errorf("invalid MPEG-TS packet")
+			}
+			copy(payload[i*packetSize:], tsPkt)
+		}
+
+		ret = append(ret, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.PayloadType,
+				SequenceNumber: e.sequenceNumber,
+				SSRC:           *e.SSRC,
+			},
+			Payload: payload,
+		})
+
+		e.sequenceNumber++
+		tsPackets = tsPackets[n:]
+	}
+
+	return ret, nil
+}
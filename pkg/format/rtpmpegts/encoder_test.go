@@ -0,0 +1,104 @@
+package rtpmpegts
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func tsPacket(b byte) []byte {
+	return append([]byte{0x47, 0x01, 0x00, 0x10}, bytes.Repeat([]byte{b}, 184)...)
+}
+
+func mergeBytes(vals ...[]byte) []byte {
+	var ret []byte
+	for _, v := range vals {
+		ret = append(ret, v...)
+	}
+	return ret
+}
+
+var cases = []struct {
+	name      string
+	tsPackets [][]byte
+	pkts      []*rtp.Packet
+}{
+	{
+		"single",
+		[][]byte{tsPacket(1)},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    33,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: tsPacket(1),
+			},
+		},
+	},
+	{
+		"multiple",
+		[][]byte{tsPacket(1), tsPacket(2), tsPacket(3)},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    33,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(tsPacket(1), tsPacket(2)),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    33,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: tsPacket(3),
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           33,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(0x44ed),
+				PayloadMaxSize:        400,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.tsPackets)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 33,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
@@ -0,0 +1,34 @@
+package rtpmpegts
+
+// PCRClockRate is the clock rate of PCRs.
+const PCRClockRate = 27000000
+
+// PCR extracts the program clock reference of a MPEG-TS packet.
+// It returns false when the packet doesn't contain a PCR.
+// The PCR is expressed in units of PCRClockRate.
+// Specification: ISO 13818-1, section 2.4.3.5
+func PCR(pkt []byte) (uint64, bool) {
+	if len(pkt) != packetSize || pkt[0] != syncByte {
+		return 0, false
+	}
+
+	// adaptation_field_control
+	if (pkt[3] & 0x20) == 0 {
+		return 0, false
+	}
+
+	// adaptation_field_length, PCR_flag
+	if pkt[4] < 7 || (pkt[5]&0x10) == 0 {
+		return 0, false
+	}
+
+	base := uint64(pkt[6])<<25 | uint64(pkt[7])<<17 | uint64(pkt[8])<<9 | uint64(pkt[9])<<1 | uint64(pkt[10])>>7
+	ext := uint64(pkt[10]&0x01)<<8 | uint64(pkt[11])
+
+	return base*300 + ext, true
+}
+
+// PCRToTimestamp converts a PCR into a RTP timestamp (with a 90khz clock rate).
+func PCRToTimestamp(pcr uint64) uint32 {
+	return uint32(pcr / 300)
+}
@@ -0,0 +1,24 @@
+package rtpmpegts
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPCR(t *testing.T) {
+	pkt := append([]byte{
+		0x47, 0x01, 0x00, 0x30, // adaptation field and payload
+		0x07, 0x10, // adaptation field length, PCR flag
+		0x00, 0x00, 0x03, 0xe8, 0x7e, 0x05, // base = 2000, ext = 5
+	}, bytes.Repeat([]byte{0xff}, 176)...)
+
+	pcr, ok := PCR(pkt)
+	require.Equal(t, true, ok)
+	require.Equal(t, uint64(2000*300+5), pcr)
+	require.Equal(t, uint32(2000), PCRToTimestamp(pcr))
+
+	_, ok = PCR(tsPacket(1))
+	require.Equal(t, false, ok)
+}
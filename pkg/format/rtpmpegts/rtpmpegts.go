@@ -0,0 +1,9 @@
+// Package rtpmpegts contains a RTP/MPEG-TS decoder and encoder.
+package rtpmpegts
+
+const (
+	// packetSize is the size of a MPEG-TS packet.
+	packetSize = 188
+
+	syncByte = 0x47
+)
@@ -0,0 +1,107 @@
+package rtponvifmetadata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pion/rtp"
+)
+
+// MaxDocumentSize is the maximum size of a metadata document.
+const MaxDocumentSize = 1 * 1024 * 1024
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+func joinFragments(fragments [][]byte, size int) []byte {
+	ret := make([]byte, size)
+	n := 0
+	for _, p := range fragments {
+		n += copy(ret[n:], p)
+	}
+	return ret
+}
+
+// Decoder is a RTP/ONVIF metadata decoder.
+// Documents can be split into multiple packets, the last of which has the marker bit set.
+// All packets of a document share the same timestamp.
+// Specification: ONVIF Streaming Specification, section 5.1.2.1.1
+type Decoder struct {
+	// decompress documents compressed with gzip.
+	Decompress bool
+
+	fragments     [][]byte
+	fragmentsSize int
+	timestamp     uint32
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes a metadata document from a RTP packet.
+// The timestamp of the document is the one of the packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	var doc []byte
+
+	if len(d.fragments) == 0 {
+		if pkt.Marker {
+			doc = pkt.Payload
+		} else {
+			d.fragmentsSize = len(pkt.Payload)
+			d.fragments = append(d.fragments, pkt.Payload)
+			d.timestamp = pkt.Timestamp
+			return nil, ErrMorePacketsNeeded
+		}
+	} else {
+		// the packet with the marker bit of the previous document has been lost
+		if pkt.Timestamp != d.timestamp {
+			d.fragments = d.fragments[:0] // discard pending fragments
+			return nil, fmt.Errorf("received a fragment with a different timestamp than the previous one")
+		}
+
+		d.fragmentsSize += len(pkt.Payload)
+		if d.fragmentsSize > MaxDocumentSize {
+			d.fragments = d.fragments[:0] // discard pending fragments
+			return nil, fmt.Errorf("document size (%d) is too big, maximum is %d", d.fragmentsSize, MaxDocumentSize)
+		}
+
+		d.fragments = append(d.fragments, pkt.Payload)
+
+		if !pkt.Marker {
+			return nil, ErrMorePacketsNeeded
+		}
+
+		doc = joinFragments(d.fragments, d.fragmentsSize)
+		d.fragments = d.fragments[:0]
+	}
+
+	if d.Decompress {
+		return decompress(doc)
+	}
+
+	return doc, nil
+}
+
+func decompress(doc []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(doc))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	ret, err := io.ReadAll(io.LimitReader(r, MaxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) > MaxDocumentSize {
+		return nil, fmt.Errorf("decompressed document is too big, maximum is %d", MaxDocumentSize)
+	}
+
+	return ret, nil
+}
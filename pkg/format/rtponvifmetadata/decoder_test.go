@@ -0,0 +1,96 @@
+package rtponvifmetadata
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var doc []byte
+
+			for _, pkt := range ca.pkts {
+				doc, err = d.Decode(pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, ca.doc, doc)
+		})
+	}
+}
+
+func TestDecodeMissingMarker(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Timestamp: 1000,
+		},
+		Payload: []byte{1, 2},
+	})
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	_, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Marker:    true,
+			Timestamp: 2000,
+		},
+		Payload: []byte{3, 4},
+	})
+	require.EqualError(t, err, "received a fragment with a different timestamp than the previous one")
+
+	// decoding restarts from the next document
+	doc, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Marker:    true,
+			Timestamp: 3000,
+		},
+		Payload: []byte{5, 6},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 6}, doc)
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a []byte, am bool, b []byte, bm bool) {
+		d := &Decoder{}
+		d.Init() //nolint:errcheck
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         am,
+				PayloadType:    107,
+				SequenceNumber: 17645,
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: a,
+		})
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         bm,
+				PayloadType:    107,
+				SequenceNumber: 17646,
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: b,
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package rtponvifmetadata
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+var cases = []struct {
+	name string
+	doc  []byte
+	pkts []*rtp.Packet
+}{
+	{
+		"single",
+		[]byte(`<tt:MetadataStream xmlns:tt="http://www.onvif.org/ver10/schema"/>`),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    107,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte(`<tt:MetadataStream xmlns:tt="http://www.onvif.org/ver10/schema"/>`),
+			},
+		},
+	},
+	{
+		"fragmented",
+		bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 150/4),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    107,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 100/4),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    107,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 50/4),
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           107,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(17645),
+				PayloadMaxSize:        100,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.doc)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 107,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
+
+func TestEncodeDecodeCompressed(t *testing.T) {
+	doc := bytes.Repeat([]byte(`<tt:MetadataStream xmlns:tt="http://www.onvif.org/ver10/schema"/>`), 100)
+
+	e := &Encoder{
+		PayloadType:    107,
+		PayloadMaxSize: 100,
+		Compress:       true,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	pkts, err := e.Encode(doc)
+	require.NoError(t, err)
+
+	d := &Decoder{
+		Decompress: true,
+	}
+	err = d.Init()
+	require.NoError(t, err)
+
+	var dec []byte
+
+	for _, pkt := range pkts {
+		dec, err = d.Decode(pkt)
+		if err == ErrMorePacketsNeeded {
+			continue
+		}
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, doc, dec)
+}
@@ -0,0 +1,159 @@
+package rtponvifmetadata
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// SimpleItem is a name-value pair of an event.
+type SimpleItem struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+// Event is a notification carried by a metadata document, like an analytics event.
+type Event struct {
+	// topic of the event, i.e. "tns1:RuleEngine/CellMotionDetector/Motion".
+	Topic string
+	// time of the event.
+	UtcTime time.Time
+	// operation that caused the event: "Initialized", "Changed" or "Deleted".
+	// It is empty for events that are not related to properties.
+	PropertyOperation string
+	// items that identify the source of the event.
+	Source []SimpleItem
+	// items that identify the key of the event.
+	Key []SimpleItem
+	// items that contain the data of the event.
+	Data []SimpleItem
+}
+
+// PTZStatus is the position and the movement status of a PTZ unit.
+type PTZStatus struct {
+	// pan and tilt position. It is nil when not provided.
+	PanTilt *[2]float64
+	// zoom position. It is nil when not provided.
+	Zoom *float64
+	// movement status of pan and tilt ("IDLE", "MOVING" or "UNKNOWN").
+	MoveStatusPanTilt string
+	// movement status of zoom ("IDLE", "MOVING" or "UNKNOWN").
+	MoveStatusZoom string
+	// time of the status.
+	UtcTime time.Time
+}
+
+// MetadataStream is the content of a metadata document (tt:MetadataStream).
+// Only events and PTZ statuses are decoded;
+// the other elements (like video analytics frames) can be decoded from the raw document.
+type MetadataStream struct {
+	Events []*Event
+	PTZ    []*PTZStatus
+}
+
+type xmlSimpleItems struct {
+	SimpleItems []SimpleItem `xml:"SimpleItem"`
+}
+
+type xmlVector2D struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+}
+
+type xmlVector1D struct {
+	X float64 `xml:"x,attr"`
+}
+
+type xmlMetadataStream struct {
+	XMLName xml.Name `xml:"MetadataStream"`
+	Events  []struct {
+		NotificationMessages []struct {
+			Topic   string `xml:"Topic"`
+			Message struct {
+				UtcTime           string         `xml:"UtcTime,attr"`
+				PropertyOperation string         `xml:"PropertyOperation,attr"`
+				Source            xmlSimpleItems `xml:"Source"`
+				Key               xmlSimpleItems `xml:"Key"`
+				Data              xmlSimpleItems `xml:"Data"`
+			} `xml:"Message>Message"`
+		} `xml:"NotificationMessage"`
+	} `xml:"Event"`
+	PTZ []struct {
+		PTZStatus []struct {
+			Position struct {
+				PanTilt *xmlVector2D `xml:"PanTilt"`
+				Zoom    *xmlVector1D `xml:"Zoom"`
+			} `xml:"Position"`
+			MoveStatus struct {
+				PanTilt string `xml:"PanTilt"`
+				Zoom    string `xml:"Zoom"`
+			} `xml:"MoveStatus"`
+			UtcTime string `xml:"UtcTime"`
+		} `xml:"PTZStatus"`
+	} `xml:"PTZ"`
+}
+
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, v)
+}
+
+// Unmarshal decodes a MetadataStream from a document.
+func (m *MetadataStream) Unmarshal(doc []byte) error {
+	var x xmlMetadataStream
+	err := xml.Unmarshal(doc, &x)
+	if err != nil {
+		return err
+	}
+
+	m.Events = nil
+	m.PTZ = nil
+
+	for _, ev := range x.Events {
+		for _, nm := range ev.NotificationMessages {
+			t, err := parseTime(nm.Message.UtcTime)
+			if err != nil {
+				return err
+			}
+
+			m.Events = append(m.Events, &Event{
+				Topic:             strings.TrimSpace(nm.Topic),
+				UtcTime:           t,
+				PropertyOperation: nm.Message.PropertyOperation,
+				Source:            nm.Message.Source.SimpleItems,
+				Key:               nm.Message.Key.SimpleItems,
+				Data:              nm.Message.Data.SimpleItems,
+			})
+		}
+	}
+
+	for _, ptz := range x.PTZ {
+		for _, st := range ptz.PTZStatus {
+			t, err := parseTime(strings.TrimSpace(st.UtcTime))
+			if err != nil {
+				return err
+			}
+
+			s := &PTZStatus{
+				MoveStatusPanTilt: strings.TrimSpace(st.MoveStatus.PanTilt),
+				MoveStatusZoom:    strings.TrimSpace(st.MoveStatus.Zoom),
+				UtcTime:           t,
+			}
+
+			if st.Position.PanTilt != nil {
+				s.PanTilt = &[2]float64{st.Position.PanTilt.X, st.Position.PanTilt.Y}
+			}
+
+			if st.Position.Zoom != nil {
+				v := st.Position.Zoom.X
+				s.Zoom = &v
+			}
+
+			m.PTZ = append(m.PTZ, s)
+		}
+	}
+
+	return nil
+}
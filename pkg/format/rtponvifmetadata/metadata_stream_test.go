@@ -0,0 +1,102 @@
+package rtponvifmetadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func TestMetadataStreamUnmarshal(t *testing.T) {
+	doc := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<tt:MetadataStream xmlns:tt="http://www.onvif.org/ver10/schema"
+  xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2"
+  xmlns:tns1="http://www.onvif.org/ver10/topics">
+  <tt:PTZ>
+    <tt:PTZStatus>
+      <tt:Position>
+        <tt:PanTilt x="0.5" y="-0.25"/>
+        <tt:Zoom x="0.125"/>
+      </tt:Position>
+      <tt:MoveStatus>
+        <tt:PanTilt>MOVING</tt:PanTilt>
+        <tt:Zoom>IDLE</tt:Zoom>
+      </tt:MoveStatus>
+      <tt:UtcTime>2008-10-10T12:24:57.321Z</tt:UtcTime>
+    </tt:PTZStatus>
+  </tt:PTZ>
+  <tt:Event>
+    <wsnt:NotificationMessage>
+      <wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">
+        tns1:RuleEngine/CellMotionDetector/Motion
+      </wsnt:Topic>
+      <wsnt:Message>
+        <tt:Message UtcTime="2008-10-10T12:24:57.628Z" PropertyOperation="Changed">
+          <tt:Source>
+            <tt:SimpleItem Name="VideoSourceConfigurationToken" Value="1"/>
+            <tt:SimpleItem Name="Rule" Value="MyMotionDetectorRule"/>
+          </tt:Source>
+          <tt:Data>
+            <tt:SimpleItem Name="IsMotion" Value="true"/>
+          </tt:Data>
+        </tt:Message>
+      </wsnt:Message>
+    </wsnt:NotificationMessage>
+  </tt:Event>
+</tt:MetadataStream>`)
+
+	var m MetadataStream
+	err := m.Unmarshal(doc)
+	require.NoError(t, err)
+
+	require.Equal(t, MetadataStream{
+		Events: []*Event{{
+			Topic:             "tns1:RuleEngine/CellMotionDetector/Motion",
+			UtcTime:           time.Date(2008, 10, 10, 12, 24, 57, 628000000, time.UTC),
+			PropertyOperation: "Changed",
+			Source: []SimpleItem{
+				{Name: "VideoSourceConfigurationToken", Value: "1"},
+				{Name: "Rule", Value: "MyMotionDetectorRule"},
+			},
+			Data: []SimpleItem{
+				{Name: "IsMotion", Value: "true"},
+			},
+		}},
+		PTZ: []*PTZStatus{{
+			PanTilt:           &[2]float64{0.5, -0.25},
+			Zoom:              float64Ptr(0.125),
+			MoveStatusPanTilt: "MOVING",
+			MoveStatusZoom:    "IDLE",
+			UtcTime:           time.Date(2008, 10, 10, 12, 24, 57, 321000000, time.UTC),
+		}},
+	}, m)
+}
+
+func TestMetadataStreamUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{
+			"invalid xml",
+			`<tt:MetadataStream`,
+			"XML syntax error on line 1: unexpected EOF",
+		},
+		{
+			"wrong root",
+			`<tt:Other xmlns:tt="http://www.onvif.org/ver10/schema"/>`,
+			"expected element type <MetadataStream> but have <Other>",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var m MetadataStream
+			err := m.Unmarshal([]byte(ca.doc))
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package rtponvifmetadata contains a RTP/ONVIF metadata decoder and encoder.
+package rtponvifmetadata
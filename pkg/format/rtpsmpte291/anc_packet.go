@@ -0,0 +1,183 @@
+package rtpsmpte291
+
+import (
+	"fmt"
+)
+
+// ANCPacket is a SMPTE ST 291 ancillary data packet.
+type ANCPacket struct {
+	// whether the packet is carried in the color-difference data channel.
+	C bool
+
+	// line number of the SDI raster.
+	LineNumber uint16
+
+	// horizontal offset inside the line.
+	HorizontalOffset uint16
+
+	// whether StreamNum is in use.
+	S bool
+
+	// source data stream number.
+	StreamNum uint8
+
+	// data identifier.
+	DID uint8
+
+	// secondary data identifier.
+	SDID uint8
+
+	// user data words, with 10 bits each.
+	UserData []uint16
+}
+
+// wordParity returns a 10-bit word, made of a 8-bit value, its even parity bit and the inverse of the parity bit.
+func wordParity(v uint8) uint16 {
+	p := uint16(0)
+	for i := 0; i < 8; i++ {
+		p ^= uint16(v>>i) & 0x01
+	}
+	return uint16(v) | p<<8 | (p^0x01)<<9
+}
+
+type bitReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *bitReader) read(n int) (uint32, error) {
+	if (r.pos + n) > len(r.buf)*8 {
+		return 0, fmt.Errorf("not enough bits")
+	}
+
+	var v uint32
+	for i := 0; i < n; i++ {
+		v <<= 1
+		v |= uint32(r.buf[r.pos>>3]>>(7-(r.pos&0x07))) & 0x01
+		r.pos++
+	}
+
+	return v, nil
+}
+
+type bitWriter struct {
+	buf []byte
+	pos int
+}
+
+func (w *bitWriter) write(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if (w.pos >> 3) >= len(w.buf) {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[w.pos>>3] |= byte((v>>i)&0x01) << (7 - (w.pos & 0x07))
+		w.pos++
+	}
+}
+
+// unmarshal decodes an ANC packet and its word alignment.
+func (p *ANCPacket) unmarshal(r *bitReader) error {
+	var fields [6]uint32
+	for i, n := range []int{1, 11, 12, 1, 7, 10} {
+		var err error
+		fields[i], err = r.read(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.C = fields[0] == 1
+	p.LineNumber = uint16(fields[1])
+	p.HorizontalOffset = uint16(fields[2])
+	p.S = fields[3] == 1
+	p.StreamNum = uint8(fields[4])
+	p.DID = uint8(fields[5])
+
+	v, err := r.read(10)
+	if err != nil {
+		return err
+	}
+	p.SDID = uint8(v)
+
+	v, err = r.read(10)
+	if err != nil {
+		return err
+	}
+	dataCount := int(uint8(v))
+
+	p.UserData = make([]uint16, dataCount)
+	for i := range p.UserData {
+		v, err = r.read(10)
+		if err != nil {
+			return err
+		}
+		p.UserData[i] = uint16(v)
+	}
+
+	// checksum
+	_, err = r.read(10)
+	if err != nil {
+		return err
+	}
+
+	// word_align
+	if rem := r.pos % 32; rem != 0 {
+		_, err = r.read(32 - rem)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// marshalSize returns the size of the ANC packet, including its word alignment.
+func (p *ANCPacket) marshalSize() int {
+	n := 32 + 10*(4+len(p.UserData))
+	return ((n + 31) / 32) * 4
+}
+
+// marshal encodes an ANC packet and its word alignment.
+func (p *ANCPacket) marshal(w *bitWriter) error {
+	if len(p.UserData) > 255 {
+		return fmt.Errorf("too many user data words")
+	}
+
+	if p.LineNumber > 0x7FF || p.HorizontalOffset > 0xFFF || p.StreamNum > 0x7F {
+		return fmt.Errorf("invalid ANC packet header")
+	}
+
+	boolToUint := func(v bool) uint32 {
+		if v {
+			return 1
+		}
+		return 0
+	}
+
+	w.write(boolToUint(p.C), 1)
+	w.write(uint32(p.LineNumber), 11)
+	w.write(uint32(p.HorizontalOffset), 12)
+	w.write(boolToUint(p.S), 1)
+	w.write(uint32(p.StreamNum), 7)
+
+	words := make([]uint16, 0, 3+len(p.UserData))
+	words = append(words, wordParity(p.DID), wordParity(p.SDID), wordParity(uint8(len(p.UserData))))
+	words = append(words, p.UserData...)
+
+	// the checksum is the sum of the 9 least significant bits of the words,
+	// followed by the inverse of bit 8.
+	sum := uint16(0)
+	for _, word := range words {
+		w.write(uint32(word&0x3FF), 10)
+		sum += word & 0x1FF
+	}
+	sum &= 0x1FF
+	sum |= (^sum & 0x100) << 1
+	w.write(uint32(sum), 10)
+
+	if rem := w.pos % 32; rem != 0 {
+		w.write(0, 32-rem)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package rtpsmpte291
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// maximum number of ANC packets of a frame.
+const maxANCPacketsPerFrame = 1024
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/SMPTE ST 291 decoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc8331
+type Decoder struct {
+	ancPackets []*ANCPacket
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes the ANC packets of a frame or field from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]*ANCPacket, error) {
+	if len(pkt.Payload) < 8 {
+		d.ancPackets = nil // discard pending packets
+		return nil, fmt.Errorf("payload is too short")
+	}
+
+	length := int(pkt.Payload[2])<<8 | int(pkt.Payload[3])
+	ancCount := int(pkt.Payload[4])
+
+	if len(pkt.Payload[8:]) < length {
+		d.ancPackets = nil // discard pending packets
+		return nil, fmt.Errorf("invalid length")
+	}
+
+	if (len(d.ancPackets) + ancCount) > maxANCPacketsPerFrame {
+		d.ancPackets = nil // discard pending packets
+		return nil, fmt.Errorf("ANC packet count exceeds maximum (%d)", maxANCPacketsPerFrame)
+	}
+
+	r := &bitReader{buf: pkt.Payload[8 : 8+length]}
+
+	for i := 0; i < ancCount; i++ {
+		var p ANCPacket
+		err := p.unmarshal(r)
+		if err != nil {
+			d.ancPackets = nil // discard pending packets
+			return nil, err
+		}
+
+		d.ancPackets = append(d.ancPackets, &p)
+	}
+
+	if !pkt.Marker {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	ret := d.ancPackets
+	if ret == nil {
+		ret = []*ANCPacket{}
+	}
+	d.ancPackets = nil
+
+	return ret, nil
+}
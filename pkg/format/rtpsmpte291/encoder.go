@@ -0,0 +1,132 @@
+package rtpsmpte291
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1460 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header)
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/SMPTE ST 291 encoder.
+// Specification: https://datatracker.ietf.org/doc/html/rfc8331
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1460.
+	PayloadMaxSize int
+
+	// the extended sequence number is carried inside payloads.
+	sequenceNumber uint32
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	e.sequenceNumber = uint32(*e.InitialSequenceNumber)
+	return nil
+}
+
+// Encode encodes the ANC packets of a frame or field into RTP/SMPTE ST 291 packets.
+func (e *Encoder) Encode(ancPackets []*ANCPacket) ([]*rtp.Packet, error) {
+	avail := e.PayloadMaxSize - 8
+
+	var batches [][]*ANCPacket
+	var batch []*ANCPacket
+	batchSize := 0
+
+	for _, p := range ancPackets {
+		size := p.marshalSize()
+		if size > avail {
+			return nil, fmt.Errorf("ANC packet is too big")
+		}
+
+		if (batchSize+size) > avail || len(batch) == 255 {
+			batches = append(batches, batch)
+			batch = nil
+			batchSize = 0
+		}
+
+		batch = append(batch, p)
+		batchSize += size
+	}
+
+	// a frame without ANC packets is signaled with an empty payload
+	batches = append(batches, batch)
+
+	ret := make([]*rtp.Packet, len(batches))
+
+	for i, batch := range batches {
+		w := &bitWriter{}
+		for _, p := range batch {
+			err := p.marshal(w)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		payload := make([]byte, 8+len(w.buf))
+		payload[0] = byte(e.sequenceNumber >> 24)
+		payload[1] = byte(e.sequenceNumber >> 16)
+		payload[2] = byte(len(w.buf) >> 8)
+		payload[3] = byte(len(w.buf))
+		payload[4] = byte(len(batch))
+		copy(payload[8:], w.buf)
+
+		ret[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.PayloadType,
+				SequenceNumber: uint16(e.sequenceNumber),
+				SSRC:           *e.SSRC,
+				Marker:         i == (len(batches) - 1),
+			},
+			Payload: payload,
+		}
+
+		e.sequenceNumber++
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,153 @@
+package rtpsmpte291
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+var testANCPacket = &ANCPacket{
+	LineNumber: 9,
+	DID:        0x61,
+	SDID:       0x01,
+	UserData:   []uint16{0x296, 0x269, 0x152},
+}
+
+var testANCPacketEnc = []byte{
+	0x00, 0x90, 0x00, 0x00, 0x58, 0x50, 0x18, 0x0e,
+	0x96, 0x9a, 0x55, 0x2a, 0xd8, 0x00, 0x00, 0x00,
+}
+
+var cases = []struct {
+	name  string
+	frame []*ANCPacket
+	pkts  []*rtp.Packet
+}{
+	{
+		"single",
+		[]*ANCPacket{testANCPacket},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: append([]byte{
+					0x00, 0x00, 0x00, 0x10, 0x01, 0x00, 0x00, 0x00,
+				}, testANCPacketEnc...),
+			},
+		},
+	},
+	{
+		"multiple",
+		[]*ANCPacket{
+			testANCPacket, testANCPacket, testANCPacket, testANCPacket,
+			testANCPacket, testANCPacket, testANCPacket,
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: append([]byte{
+					0x00, 0x00, 0x00, 0x50, 0x05, 0x00, 0x00, 0x00,
+				}, bytes.Repeat(testANCPacketEnc, 5)...),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: append([]byte{
+					0x00, 0x00, 0x00, 0x20, 0x02, 0x00, 0x00, 0x00,
+				}, bytes.Repeat(testANCPacketEnc, 2)...),
+			},
+		},
+	},
+	{
+		"empty",
+		[]*ANCPacket{},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				},
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				SSRC:                  uint32Ptr(0x9dbb7812),
+				InitialSequenceNumber: uint16Ptr(0x44ed),
+				PayloadMaxSize:        100,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.frame)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeExtendedSequenceNumber(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		SSRC:                  uint32Ptr(0x9dbb7812),
+		InitialSequenceNumber: uint16Ptr(0xFFFF),
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	pkts, err := e.Encode([]*ANCPacket{testANCPacket})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x00, 0x00}, pkts[0].Payload[:2])
+
+	pkts, err = e.Encode([]*ANCPacket{testANCPacket})
+	require.NoError(t, err)
+	require.Equal(t, uint16(0), pkts[0].SequenceNumber)
+	require.Equal(t, []byte{0x00, 0x01}, pkts[0].Payload[:2])
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
@@ -0,0 +1,2 @@
+// Package rtpsmpte291 contains a RTP/SMPTE ST 291 decoder and encoder.
+package rtpsmpte291
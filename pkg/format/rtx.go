@@ -0,0 +1,95 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pion/rtp"
+)
+
+// RTX is a RTP format for retransmissions of packets of another format.
+// Specification: https://datatracker.ietf.org/doc/html/rfc4588
+type RTX struct {
+	PayloadTyp uint8
+	ClockRat   int
+	// payload type of the retransmitted format.
+	APT uint8
+	// (optional) time in milliseconds during which packets are kept for retransmission.
+	RTXTime *int
+}
+
+func (f *RTX) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	clockRate, err := strconv.ParseUint(ctx.clock, 10, 31)
+	if err != nil {
+		return fmt.Errorf("invalid clock rate (%v)", ctx.clock)
+	}
+	f.ClockRat = int(clockRate)
+
+	aptFound := false
+
+	for key, val := range ctx.fmtp {
+		switch key {
+		case "apt":
+			tmp, err := strconv.ParseUint(val, 10, 7)
+			if err != nil {
+				return fmt.Errorf("invalid apt (%v)", val)
+			}
+			f.APT = uint8(tmp)
+			aptFound = true
+
+		case "rtx-time":
+			tmp, err := strconv.ParseUint(val, 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid rtx-time (%v)", val)
+			}
+			v := int(tmp)
+			f.RTXTime = &v
+		}
+	}
+
+	if !aptFound {
+		return fmt.Errorf("apt is missing")
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *RTX) Codec() string {
+	return "RTX"
+}
+
+// ClockRate implements Format.
+func (f *RTX) ClockRate() int {
+	return f.ClockRat
+}
+
+// PayloadType implements Format.
+func (f *RTX) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *RTX) RTPMap() string {
+	return "rtx/" + strconv.FormatInt(int64(f.ClockRat), 10)
+}
+
+// FMTP implements Format.
+func (f *RTX) FMTP() map[string]string {
+	fmtp := map[string]string{
+		"apt": strconv.FormatUint(uint64(f.APT), 10),
+	}
+
+	if f.RTXTime != nil {
+		fmtp["rtx-time"] = strconv.FormatInt(int64(*f.RTXTime), 10)
+	}
+
+	return fmtp
+}
+
+// PTSEqualsDTS implements Format.
+func (f *RTX) PTSEqualsDTS(*rtp.Packet) bool {
+	return false
+}
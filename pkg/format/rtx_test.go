@@ -0,0 +1,19 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTXAttributes(t *testing.T) {
+	format := &RTX{
+		PayloadTyp: 97,
+		ClockRat:   90000,
+		APT:        96,
+	}
+	require.Equal(t, "RTX", format.Codec())
+	require.Equal(t, 90000, format.ClockRate())
+	require.Equal(t, false, format.PTSEqualsDTS(&rtp.Packet{}))
+}
@@ -0,0 +1,126 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpsmpte291"
+)
+
+// SMPTE291 is a RTP format for SMPTE ST 291 ancillary data (captions, timecode, etc).
+// Specification: https://datatracker.ietf.org/doc/html/rfc8331
+type SMPTE291 struct {
+	PayloadTyp uint8
+
+	// data identifier and secondary data identifier of the carried ANC packets.
+	DIDSDID *[2]uint8
+
+	// video payload ID of the related SDI source.
+	VPIDCode *int
+}
+
+func (f *SMPTE291) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	for key, val := range ctx.fmtp {
+		switch key {
+		case "DID_SDID":
+			tmp := strings.TrimSuffix(strings.TrimPrefix(val, "{"), "}")
+			parts := strings.Split(tmp, ",")
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid DID_SDID: %v", val)
+			}
+
+			var v2 [2]uint8
+			for i, part := range parts {
+				n, err := strconv.ParseUint(strings.TrimSpace(part), 0, 8)
+				if err != nil {
+					return fmt.Errorf("invalid DID_SDID: %v", val)
+				}
+				v2[i] = uint8(n)
+			}
+
+			f.DIDSDID = &v2
+
+		case "VPID_Code":
+			n, err := strconv.ParseUint(val, 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid VPID_Code: %v", val)
+			}
+
+			v2 := int(n)
+			f.VPIDCode = &v2
+		}
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *SMPTE291) Codec() string {
+	return "SMPTE 291"
+}
+
+// ClockRate implements Format.
+func (f *SMPTE291) ClockRate() int {
+	return 90000
+}
+
+// PayloadType implements Format.
+func (f *SMPTE291) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *SMPTE291) RTPMap() string {
+	return "smpte291/90000"
+}
+
+// FMTP implements Format.
+func (f *SMPTE291) FMTP() map[string]string {
+	fmtp := make(map[string]string)
+
+	if f.DIDSDID != nil {
+		fmtp["DID_SDID"] = fmt.Sprintf("{0x%02X,0x%02X}", f.DIDSDID[0], f.DIDSDID[1])
+	}
+
+	if f.VPIDCode != nil {
+		fmtp["VPID_Code"] = strconv.FormatInt(int64(*f.VPIDCode), 10)
+	}
+
+	return fmtp
+}
+
+// PTSEqualsDTS implements Format.
+func (f *SMPTE291) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *SMPTE291) CreateDecoder() (*rtpsmpte291.Decoder, error) {
+	d := &rtpsmpte291.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *SMPTE291) CreateEncoder() (*rtpsmpte291.Encoder, error) {
+	e := &rtpsmpte291.Encoder{
+		PayloadType: f.PayloadTyp,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
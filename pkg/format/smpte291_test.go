@@ -0,0 +1,44 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpsmpte291"
+)
+
+func TestSMPTE291Attributes(t *testing.T) {
+	format := &SMPTE291{
+		PayloadTyp: 96,
+	}
+	require.Equal(t, "SMPTE 291", format.Codec())
+	require.Equal(t, 90000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestSMPTE291DecEncoder(t *testing.T) {
+	format := &SMPTE291{}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	anc := []*rtpsmpte291.ANCPacket{{
+		LineNumber: 9,
+		DID:        0x60,
+		SDID:       0x60,
+		UserData:   []uint16{0x101, 0x102, 0x203},
+	}}
+
+	pkts, err := enc.Encode(anc)
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	res, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, anc, res)
+}
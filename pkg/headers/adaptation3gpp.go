@@ -0,0 +1,107 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// Adaptation3GPPEntry is an entry of a 3GPP-Adaptation header.
+type Adaptation3GPPEntry struct {
+	// URL of the stream
+	URL string
+
+	// size of the reception buffer, in bytes
+	Size uint64
+
+	// (optional) target buffering time, in milliseconds
+	TargetTime *uint64
+}
+
+// Adaptation3GPP is a 3GPP-Adaptation header.
+// Specification: 3GPP TS 26.234, 5.3.2.2
+type Adaptation3GPP []*Adaptation3GPPEntry
+
+// Unmarshal decodes a 3GPP-Adaptation header.
+func (h *Adaptation3GPP) Unmarshal(v base.HeaderValue) error {
+	if len(v) == 0 {
+		return fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	for _, part := range splitEntries(v[0]) {
+		e := &Adaptation3GPPEntry{}
+
+		// remove leading spaces
+		part = strings.TrimLeft(part, " ")
+
+		kvs, err := keyValParse(part, ';')
+		if err != nil {
+			return err
+		}
+
+		sizeProvided := false
+
+		for k, v := range kvs {
+			switch k {
+			case "url":
+				e.URL = v
+
+			case "size":
+				vi, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					return err
+				}
+				e.Size = vi
+				sizeProvided = true
+
+			case "target-time":
+				vi, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					return err
+				}
+				e.TargetTime = &vi
+
+			default:
+				// ignore non-standard keys
+			}
+		}
+
+		if e.URL == "" {
+			return fmt.Errorf("URL is missing")
+		}
+
+		if !sizeProvided {
+			return fmt.Errorf("size is missing")
+		}
+
+		*h = append(*h, e)
+	}
+
+	return nil
+}
+
+// Marshal encodes a 3GPP-Adaptation header.
+func (h Adaptation3GPP) Marshal() base.HeaderValue {
+	rets := make([]string, len(h))
+
+	for i, e := range h {
+		tmp := []string{
+			"url=\"" + e.URL + "\"",
+			"size=" + strconv.FormatUint(e.Size, 10),
+		}
+
+		if e.TargetTime != nil {
+			tmp = append(tmp, "target-time="+strconv.FormatUint(*e.TargetTime, 10))
+		}
+
+		rets[i] = strings.Join(tmp, ";")
+	}
+
+	return base.HeaderValue{strings.Join(rets, ",")}
+}
@@ -0,0 +1,120 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+var casesAdaptation3GPP = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    Adaptation3GPP
+}{
+	{
+		"single value",
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";size=20000;target-time=5000`},
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";size=20000;target-time=5000`},
+		Adaptation3GPP{
+			{
+				URL:        "rtsp://example.com/stream/trackID=1",
+				Size:       20000,
+				TargetTime: uint64Ptr(5000),
+			},
+		},
+	},
+	{
+		"multiple values",
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";size=20000, ` +
+			`url="rtsp://example.com/stream/trackID=2";size=14000`},
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";size=20000,` +
+			`url="rtsp://example.com/stream/trackID=2";size=14000`},
+		Adaptation3GPP{
+			{
+				URL:  "rtsp://example.com/stream/trackID=1",
+				Size: 20000,
+			},
+			{
+				URL:  "rtsp://example.com/stream/trackID=2",
+				Size: 14000,
+			},
+		},
+	},
+}
+
+func TestAdaptation3GPPUnmarshal(t *testing.T) {
+	for _, ca := range casesAdaptation3GPP {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Adaptation3GPP
+			err := h.Unmarshal(ca.vin)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+		})
+	}
+}
+
+func TestAdaptation3GPPUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		hv   base.HeaderValue
+		err  string
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+			"value not provided",
+		},
+		{
+			"2 values",
+			base.HeaderValue{"a", "b"},
+			"value provided multiple times ([a b])",
+		},
+		{
+			"invalid key-value",
+			base.HeaderValue{"test=\"a"},
+			"apexes not closed (test=\"a)",
+		},
+		{
+			"invalid size",
+			base.HeaderValue{`url="rtsp://example.com/stream";size=aa`},
+			"strconv.ParseUint: parsing \"aa\": invalid syntax",
+		},
+		{
+			"invalid target time",
+			base.HeaderValue{`url="rtsp://example.com/stream";size=100;target-time=aa`},
+			"strconv.ParseUint: parsing \"aa\": invalid syntax",
+		},
+		{
+			"missing URL",
+			base.HeaderValue{`size=100`},
+			"URL is missing",
+		},
+		{
+			"missing size",
+			base.HeaderValue{`url="rtsp://example.com/stream"`},
+			"size is missing",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Adaptation3GPP
+			err := h.Unmarshal(ca.hv)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestAdaptation3GPPMarshal(t *testing.T) {
+	for _, ca := range casesAdaptation3GPP {
+		t.Run(ca.name, func(t *testing.T) {
+			req := ca.h.Marshal()
+			require.Equal(t, ca.vout, req)
+		})
+	}
+}
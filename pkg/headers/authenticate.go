@@ -47,6 +47,16 @@ type Authenticate struct {
 
 	// (optional) algorithm
 	Algorithm *string
+
+	// (optional) quality of protection.
+	// In challenges, it contains a comma-separated list of values.
+	QOP *string
+
+	// (optional) nonce count
+	NC *string
+
+	// (optional) client nonce
+	CNonce *string
 }
 
 // Unmarshal decodes an Authenticate or a WWW-Authenticate header.
@@ -110,6 +120,15 @@ func (h *Authenticate) Unmarshal(v base.HeaderValue) error {
 
 		case "algorithm":
 			h.Algorithm = &v
+
+		case "qop":
+			h.QOP = &v
+
+		case "nc":
+			h.NC = &v
+
+		case "cnonce":
+			h.CNonce = &v
 		}
 	}
 
@@ -164,6 +183,23 @@ func (h Authenticate) Marshal() base.HeaderValue {
 		rets = append(rets, "algorithm=\""+*h.Algorithm+"\"")
 	}
 
+	if h.QOP != nil {
+		// in responses, qop contains a single token and is not quoted.
+		if h.Response != nil {
+			rets = append(rets, "qop="+*h.QOP)
+		} else {
+			rets = append(rets, "qop=\""+*h.QOP+"\"")
+		}
+	}
+
+	if h.NC != nil {
+		rets = append(rets, "nc="+*h.NC)
+	}
+
+	if h.CNonce != nil {
+		rets = append(rets, "cnonce=\""+*h.CNonce+"\"")
+	}
+
 	ret += strings.Join(rets, ", ")
 
 	return base.HeaderValue{ret}
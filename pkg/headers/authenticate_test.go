@@ -105,6 +105,37 @@ var casesAuthenticate = []struct {
 			Algorithm: stringPtr("MD5"),
 		},
 	},
+	{
+		"digest request with qop",
+		base.HeaderValue{`Digest realm="IPCAM", nonce="abcd", algorithm=SHA-256, qop="auth,auth-int"`},
+		base.HeaderValue{`Digest realm="IPCAM", nonce="abcd", algorithm="SHA-256", qop="auth,auth-int"`},
+		Authenticate{
+			Method:    AuthDigest,
+			Realm:     stringPtr("IPCAM"),
+			Nonce:     stringPtr("abcd"),
+			Algorithm: stringPtr("SHA-256"),
+			QOP:       stringPtr("auth,auth-int"),
+		},
+	},
+	{
+		"digest response with qop",
+		base.HeaderValue{`Digest username="aa", realm="bb", nonce="cc", uri="dd", response="ee", ` +
+			`algorithm=SHA-256, qop=auth, nc=00000001, cnonce="ff"`},
+		base.HeaderValue{`Digest username="aa", realm="bb", nonce="cc", uri="dd", response="ee", ` +
+			`algorithm="SHA-256", qop=auth, nc=00000001, cnonce="ff"`},
+		Authenticate{
+			Method:    AuthDigest,
+			Username:  stringPtr("aa"),
+			Realm:     stringPtr("bb"),
+			Nonce:     stringPtr("cc"),
+			URI:       stringPtr("dd"),
+			Response:  stringPtr("ee"),
+			Algorithm: stringPtr("SHA-256"),
+			QOP:       stringPtr("auth"),
+			NC:        stringPtr("00000001"),
+			CNonce:    stringPtr("ff"),
+		},
+	},
 }
 
 func TestAuthenticateUnmarshal(t *testing.T) {
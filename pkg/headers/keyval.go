@@ -77,3 +77,37 @@ func keyValParse(str string, separator byte) (map[string]string, error) {
 
 	return ret, nil
 }
+
+// splitEntries splits a header value into entries separated by commas,
+// ignoring commas inside apexes and braces.
+func splitEntries(str string) []string {
+	var ret []string
+	inApexes := false
+	braces := 0
+	start := 0
+
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '"':
+			inApexes = !inApexes
+
+		case '{':
+			if !inApexes {
+				braces++
+			}
+
+		case '}':
+			if !inApexes && braces > 0 {
+				braces--
+			}
+
+		case ',':
+			if !inApexes && braces == 0 {
+				ret = append(ret, str[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(ret, str[start:])
+}
@@ -0,0 +1,108 @@
+package headers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// QoEFeedback3GPPMetric is a metric of a 3GPP-QoE-Feedback entry.
+type QoEFeedback3GPPMetric struct {
+	// name of the metric
+	Name string
+
+	// measures, each in the format "value [timestamp]".
+	// If empty, the metric was not measured.
+	Values []string
+}
+
+// QoEFeedback3GPPEntry is an entry of a 3GPP-QoE-Feedback header.
+type QoEFeedback3GPPEntry struct {
+	// URL of the stream, or aggregate URL for session-level metrics
+	URL string
+
+	// metrics
+	Metrics []QoEFeedback3GPPMetric
+}
+
+// QoEFeedback3GPP is a 3GPP-QoE-Feedback header.
+// Specification: 3GPP TS 26.234, 5.3.2.3.3
+type QoEFeedback3GPP []*QoEFeedback3GPPEntry
+
+// Unmarshal decodes a 3GPP-QoE-Feedback header.
+func (h *QoEFeedback3GPP) Unmarshal(v base.HeaderValue) error {
+	if len(v) == 0 {
+		return fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	for _, part := range splitEntries(v[0]) {
+		e := &QoEFeedback3GPPEntry{}
+
+		// remove leading spaces
+		part = strings.TrimLeft(part, " ")
+
+		kvs, err := keyValParse(part, ';')
+		if err != nil {
+			return err
+		}
+
+		for k, v := range kvs {
+			switch {
+			case k == "url":
+				e.URL = v
+
+			case strings.HasPrefix(v, "{"):
+				values, err := parseMetricsList(v)
+				if err != nil {
+					return err
+				}
+				e.Metrics = append(e.Metrics, QoEFeedback3GPPMetric{
+					Name:   k,
+					Values: values,
+				})
+
+			default:
+				// ignore measure range and non-standard keys
+			}
+		}
+
+		if e.URL == "" {
+			return fmt.Errorf("URL is missing")
+		}
+
+		sort.Slice(e.Metrics, func(i, j int) bool {
+			return e.Metrics[i].Name < e.Metrics[j].Name
+		})
+
+		*h = append(*h, e)
+	}
+
+	return nil
+}
+
+// Marshal encodes a 3GPP-QoE-Feedback header.
+func (h QoEFeedback3GPP) Marshal() base.HeaderValue {
+	rets := make([]string, len(h))
+
+	for i, e := range h {
+		tmp := []string{"url=\"" + e.URL + "\""}
+
+		for _, m := range e.Metrics {
+			if len(m.Values) == 0 {
+				tmp = append(tmp, m.Name+"={ }")
+			} else {
+				tmp = append(tmp, m.Name+"={"+strings.Join(m.Values, ",")+"}")
+			}
+		}
+
+		rets[i] = strings.Join(tmp, ";")
+	}
+
+	return base.HeaderValue{strings.Join(rets, ",")}
+}
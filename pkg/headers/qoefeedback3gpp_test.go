@@ -0,0 +1,123 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+var casesQoEFeedback3GPP = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    QoEFeedback3GPP
+}{
+	{
+		"single value",
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";Jitter_Duration={ };Successive_Loss={3,1 2000}`},
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";Jitter_Duration={ };Successive_Loss={3,1 2000}`},
+		QoEFeedback3GPP{
+			{
+				URL: "rtsp://example.com/stream/trackID=1",
+				Metrics: []QoEFeedback3GPPMetric{
+					{
+						Name: "Jitter_Duration",
+					},
+					{
+						Name:   "Successive_Loss",
+						Values: []string{"3", "1 2000"},
+					},
+				},
+			},
+		},
+	},
+	{
+		"multiple values",
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";Successive_Loss={3};range:npt=0-40, ` +
+			`url="rtsp://example.com/stream/trackID=2";Successive_Loss={0}`},
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";Successive_Loss={3},` +
+			`url="rtsp://example.com/stream/trackID=2";Successive_Loss={0}`},
+		QoEFeedback3GPP{
+			{
+				URL: "rtsp://example.com/stream/trackID=1",
+				Metrics: []QoEFeedback3GPPMetric{
+					{
+						Name:   "Successive_Loss",
+						Values: []string{"3"},
+					},
+				},
+			},
+			{
+				URL: "rtsp://example.com/stream/trackID=2",
+				Metrics: []QoEFeedback3GPPMetric{
+					{
+						Name:   "Successive_Loss",
+						Values: []string{"0"},
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestQoEFeedback3GPPUnmarshal(t *testing.T) {
+	for _, ca := range casesQoEFeedback3GPP {
+		t.Run(ca.name, func(t *testing.T) {
+			var h QoEFeedback3GPP
+			err := h.Unmarshal(ca.vin)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+		})
+	}
+}
+
+func TestQoEFeedback3GPPUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		hv   base.HeaderValue
+		err  string
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+			"value not provided",
+		},
+		{
+			"2 values",
+			base.HeaderValue{"a", "b"},
+			"value provided multiple times ([a b])",
+		},
+		{
+			"invalid key-value",
+			base.HeaderValue{"test=\"a"},
+			"apexes not closed (test=\"a)",
+		},
+		{
+			"invalid metric",
+			base.HeaderValue{`url="rtsp://example.com/stream";Successive_Loss={3`},
+			"invalid metrics ({3)",
+		},
+		{
+			"missing URL",
+			base.HeaderValue{`Successive_Loss={3}`},
+			"URL is missing",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h QoEFeedback3GPP
+			err := h.Unmarshal(ca.hv)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestQoEFeedback3GPPMarshal(t *testing.T) {
+	for _, ca := range casesQoEFeedback3GPP {
+		t.Run(ca.name, func(t *testing.T) {
+			req := ca.h.Marshal()
+			require.Equal(t, ca.vout, req)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// QoEMetrics3GPPEntry is an entry of a 3GPP-QoE-Metrics header.
+type QoEMetrics3GPPEntry struct {
+	// URL of the stream, or aggregate URL for session-level metrics
+	URL string
+
+	// whether metrics of the stream are disabled
+	Off bool
+
+	// names of the metrics to report
+	Metrics []string
+
+	// interval between reports, in seconds.
+	// If nil, metrics are reported at the end of the session only.
+	Rate *uint
+}
+
+// QoEMetrics3GPP is a 3GPP-QoE-Metrics header.
+// An empty header means that metrics are disabled.
+// Specification: 3GPP TS 26.234, 5.3.2.3.2
+type QoEMetrics3GPP []*QoEMetrics3GPPEntry
+
+func parseMetricsList(v string) ([]string, error) {
+	if !strings.HasPrefix(v, "{") || !strings.HasSuffix(v, "}") {
+		return nil, fmt.Errorf("invalid metrics (%v)", v)
+	}
+
+	var ret []string
+	for _, m := range strings.Split(v[1:len(v)-1], ",") {
+		m = strings.Trim(m, " ")
+		if m != "" {
+			ret = append(ret, m)
+		}
+	}
+
+	return ret, nil
+}
+
+// Unmarshal decodes a 3GPP-QoE-Metrics header.
+func (h *QoEMetrics3GPP) Unmarshal(v base.HeaderValue) error {
+	if len(v) == 0 {
+		return fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	if strings.Trim(v[0], " ") == "Off" {
+		*h = QoEMetrics3GPP{}
+		return nil
+	}
+
+	for _, part := range splitEntries(v[0]) {
+		e := &QoEMetrics3GPPEntry{}
+
+		// remove leading spaces
+		part = strings.TrimLeft(part, " ")
+
+		kvs, err := keyValParse(part, ';')
+		if err != nil {
+			return err
+		}
+
+		for k, v := range kvs {
+			switch k {
+			case "url":
+				e.URL = v
+
+			case "Off":
+				e.Off = true
+
+			case "metrics":
+				e.Metrics, err = parseMetricsList(v)
+				if err != nil {
+					return err
+				}
+
+			case "rate":
+				if v != "End" {
+					vi, err := strconv.ParseUint(v, 10, 31)
+					if err != nil {
+						return err
+					}
+					vi2 := uint(vi)
+					e.Rate = &vi2
+				}
+
+			default:
+				// ignore measure range and non-standard keys
+			}
+		}
+
+		if e.URL == "" {
+			return fmt.Errorf("URL is missing")
+		}
+
+		if !e.Off && e.Metrics == nil {
+			return fmt.Errorf("metrics are missing")
+		}
+
+		*h = append(*h, e)
+	}
+
+	return nil
+}
+
+// Marshal encodes a 3GPP-QoE-Metrics header.
+func (h QoEMetrics3GPP) Marshal() base.HeaderValue {
+	if len(h) == 0 {
+		return base.HeaderValue{"Off"}
+	}
+
+	rets := make([]string, len(h))
+
+	for i, e := range h {
+		tmp := []string{"url=\"" + e.URL + "\""}
+
+		if e.Off {
+			tmp = append(tmp, "Off")
+		} else {
+			tmp = append(tmp, "metrics={"+strings.Join(e.Metrics, ",")+"}")
+
+			if e.Rate != nil {
+				tmp = append(tmp, "rate="+strconv.FormatUint(uint64(*e.Rate), 10))
+			} else {
+				tmp = append(tmp, "rate=End")
+			}
+		}
+
+		rets[i] = strings.Join(tmp, ";")
+	}
+
+	return base.HeaderValue{strings.Join(rets, ",")}
+}
@@ -0,0 +1,124 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+var casesQoEMetrics3GPP = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    QoEMetrics3GPP
+}{
+	{
+		"off",
+		base.HeaderValue{`Off`},
+		base.HeaderValue{`Off`},
+		QoEMetrics3GPP{},
+	},
+	{
+		"session and stream",
+		base.HeaderValue{`url="rtsp://example.com/stream";metrics={Initial_Buffering_Duration,Rebuffering_Duration};` +
+			`rate=End,url="rtsp://example.com/stream/trackID=1";metrics={Successive_Loss, Jitter_Duration};` +
+			`rate=15;range:npt=0-40`},
+		base.HeaderValue{`url="rtsp://example.com/stream";metrics={Initial_Buffering_Duration,Rebuffering_Duration};` +
+			`rate=End,url="rtsp://example.com/stream/trackID=1";metrics={Successive_Loss,Jitter_Duration};` +
+			`rate=15`},
+		QoEMetrics3GPP{
+			{
+				URL:     "rtsp://example.com/stream",
+				Metrics: []string{"Initial_Buffering_Duration", "Rebuffering_Duration"},
+			},
+			{
+				URL:     "rtsp://example.com/stream/trackID=1",
+				Metrics: []string{"Successive_Loss", "Jitter_Duration"},
+				Rate:    uintPtr(15),
+			},
+		},
+	},
+	{
+		"stream off",
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";Off`},
+		base.HeaderValue{`url="rtsp://example.com/stream/trackID=1";Off`},
+		QoEMetrics3GPP{
+			{
+				URL: "rtsp://example.com/stream/trackID=1",
+				Off: true,
+			},
+		},
+	},
+}
+
+func TestQoEMetrics3GPPUnmarshal(t *testing.T) {
+	for _, ca := range casesQoEMetrics3GPP {
+		t.Run(ca.name, func(t *testing.T) {
+			var h QoEMetrics3GPP
+			err := h.Unmarshal(ca.vin)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+		})
+	}
+}
+
+func TestQoEMetrics3GPPUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		hv   base.HeaderValue
+		err  string
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+			"value not provided",
+		},
+		{
+			"2 values",
+			base.HeaderValue{"a", "b"},
+			"value provided multiple times ([a b])",
+		},
+		{
+			"invalid key-value",
+			base.HeaderValue{"test=\"a"},
+			"apexes not closed (test=\"a)",
+		},
+		{
+			"invalid metrics",
+			base.HeaderValue{`url="rtsp://example.com/stream";metrics=Successive_Loss;rate=1`},
+			"invalid metrics (Successive_Loss)",
+		},
+		{
+			"invalid rate",
+			base.HeaderValue{`url="rtsp://example.com/stream";metrics={Successive_Loss};rate=aa`},
+			"strconv.ParseUint: parsing \"aa\": invalid syntax",
+		},
+		{
+			"missing URL",
+			base.HeaderValue{`metrics={Successive_Loss};rate=1`},
+			"URL is missing",
+		},
+		{
+			"missing metrics",
+			base.HeaderValue{`url="rtsp://example.com/stream";rate=1`},
+			"metrics are missing",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h QoEMetrics3GPP
+			err := h.Unmarshal(ca.hv)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestQoEMetrics3GPPMarshal(t *testing.T) {
+	for _, ca := range casesQoEMetrics3GPP {
+		t.Run(ca.name, func(t *testing.T) {
+			req := ca.h.Marshal()
+			require.Equal(t, ca.vout, req)
+		})
+	}
+}
@@ -175,12 +175,20 @@ func marshalRangeNPTTime(d time.Duration) string {
 type RangeNPT struct {
 	Start time.Duration
 	End   *time.Duration
+
+	// whether the range starts from the current position of a live stream ("now").
+	// When true, Start is ignored.
+	Now bool
 }
 
 func (r *RangeNPT) unmarshal(start string, end string) error {
-	err := unmarshalRangeNPTTime(&r.Start, start)
-	if err != nil {
-		return err
+	if start == "now" {
+		r.Now = true
+	} else {
+		err := unmarshalRangeNPTTime(&r.Start, start)
+		if err != nil {
+			return err
+		}
 	}
 
 	if end != "" {
@@ -196,7 +204,13 @@ func (r *RangeNPT) unmarshal(start string, end string) error {
 }
 
 func (r RangeNPT) marshal() string {
-	ret := "npt=" + marshalRangeNPTTime(r.Start) + "-"
+	ret := "npt="
+	if r.Now {
+		ret += "now"
+	} else {
+		ret += marshalRangeNPTTime(r.Start)
+	}
+	ret += "-"
 	if r.End != nil {
 		ret += marshalRangeNPTTime(*r.End)
 	}
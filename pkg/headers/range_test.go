@@ -86,6 +86,16 @@ var casesRange = []struct {
 			},
 		},
 	},
+	{
+		"npt now",
+		base.HeaderValue{`npt=now-`},
+		base.HeaderValue{`npt=now-`},
+		Range{
+			Value: &RangeNPT{
+				Now: true,
+			},
+		},
+	},
 	{
 		"clock",
 		base.HeaderValue{`clock=19961108T142300Z-19961108T143520Z`},
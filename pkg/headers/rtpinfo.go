@@ -1,6 +1,7 @@
 package headers
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
@@ -13,6 +14,9 @@ type RTPInfoEntry struct {
 	URL            string
 	SequenceNumber *uint16
 	Timestamp      *uint32
+
+	// (optional) SSRC of the packets the entry refers to
+	SSRC *uint32
 }
 
 // RTPInfo is a RTP-Info header.
@@ -60,6 +64,19 @@ func (h *RTPInfo) Unmarshal(v base.HeaderValue) error {
 				vi2 := uint32(vi)
 				e.Timestamp = &vi2
 
+			case "ssrc":
+				if (len(v) % 2) != 0 {
+					v = "0" + v
+				}
+
+				// ignore invalid values, like the Transport header does
+				if tmp, err := hex.DecodeString(v); err == nil && len(tmp) <= 4 {
+					var ssrc [4]byte
+					copy(ssrc[4-len(tmp):], tmp)
+					v := uint32(ssrc[0])<<24 | uint32(ssrc[1])<<16 | uint32(ssrc[2])<<8 | uint32(ssrc[3])
+					e.SSRC = &v
+				}
+
 			default:
 				// ignore non-standard keys
 			}
@@ -91,6 +108,11 @@ func (h RTPInfo) Marshal() base.HeaderValue {
 			tmp = append(tmp, "rtptime="+strconv.FormatUint(uint64(*e.Timestamp), 10))
 		}
 
+		if e.SSRC != nil {
+			tmp2 := []byte{byte(*e.SSRC >> 24), byte(*e.SSRC >> 16), byte(*e.SSRC >> 8), byte(*e.SSRC)}
+			tmp = append(tmp, "ssrc="+strings.ToUpper(hex.EncodeToString(tmp2)))
+		}
+
 		rets[i] = strings.Join(tmp, ";")
 	}
 
@@ -114,18 +114,36 @@ var casesRTPInfo = []struct {
 		"with session",
 		base.HeaderValue{`url=trackID=1;seq=55664;rtptime=254718369;ssrc=56597976,` +
 			`url=trackID=2;seq=43807;rtptime=1702259566;ssrc=ee839a80`},
-		base.HeaderValue{`url=trackID=1;seq=55664;rtptime=254718369,` +
-			`url=trackID=2;seq=43807;rtptime=1702259566`},
+		base.HeaderValue{`url=trackID=1;seq=55664;rtptime=254718369;ssrc=56597976,` +
+			`url=trackID=2;seq=43807;rtptime=1702259566;ssrc=EE839A80`},
 		RTPInfo{
 			{
 				URL:            "trackID=1",
 				SequenceNumber: uint16Ptr(55664),
 				Timestamp:      uint32Ptr(254718369),
+				SSRC:           uint32Ptr(0x56597976),
 			},
 			{
 				URL:            "trackID=2",
 				SequenceNumber: uint16Ptr(43807),
 				Timestamp:      uint32Ptr(1702259566),
+				SSRC:           uint32Ptr(0xee839a80),
+			},
+		},
+	},
+	{
+		"short and invalid ssrc",
+		base.HeaderValue{`url=trackID=1;seq=55664;ssrc=5A,url=trackID=2;seq=43807;ssrc=zz`},
+		base.HeaderValue{`url=trackID=1;seq=55664;ssrc=0000005A,url=trackID=2;seq=43807`},
+		RTPInfo{
+			{
+				URL:            "trackID=1",
+				SequenceNumber: uint16Ptr(55664),
+				SSRC:           uint32Ptr(0x5a),
+			},
+			{
+				URL:            "trackID=2",
+				SequenceNumber: uint16Ptr(43807),
 			},
 		},
 	},
@@ -0,0 +1,41 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// Scale is a Scale header.
+// It is used to request fast-forward, slow motion or reverse playback.
+type Scale struct {
+	// ratio between the playback rate and the normal rate.
+	// Values greater than 1 mean fast-forward, values between 0 and 1 mean slow motion,
+	// negative values mean reverse playback.
+	Value float64
+}
+
+// Unmarshal decodes a Scale header.
+func (h *Scale) Unmarshal(v base.HeaderValue) error {
+	if len(v) == 0 {
+		return fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	tmp, err := strconv.ParseFloat(v[0], 64)
+	if err != nil || tmp == 0 {
+		return fmt.Errorf("invalid value (%v)", v[0])
+	}
+	h.Value = tmp
+
+	return nil
+}
+
+// Marshal encodes a Scale header.
+func (h Scale) Marshal() base.HeaderValue {
+	return base.HeaderValue{strconv.FormatFloat(h.Value, 'f', -1, 64)}
+}
@@ -0,0 +1,96 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+var casesScale = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    Scale
+}{
+	{
+		"fast forward",
+		base.HeaderValue{`2.0`},
+		base.HeaderValue{`2`},
+		Scale{
+			Value: 2,
+		},
+	},
+	{
+		"slow motion",
+		base.HeaderValue{`0.5`},
+		base.HeaderValue{`0.5`},
+		Scale{
+			Value: 0.5,
+		},
+	},
+	{
+		"reverse",
+		base.HeaderValue{`-1`},
+		base.HeaderValue{`-1`},
+		Scale{
+			Value: -1,
+		},
+	},
+}
+
+func TestScaleUnmarshal(t *testing.T) {
+	for _, ca := range casesScale {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Scale
+			err := h.Unmarshal(ca.vin)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+		})
+	}
+}
+
+func TestScaleUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		hv   base.HeaderValue
+		err  string
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+			"value not provided",
+		},
+		{
+			"2 values",
+			base.HeaderValue{"a", "b"},
+			"value provided multiple times ([a b])",
+		},
+		{
+			"invalid",
+			base.HeaderValue{"aa"},
+			"invalid value (aa)",
+		},
+		{
+			"zero",
+			base.HeaderValue{"0"},
+			"invalid value (0)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Scale
+			err := h.Unmarshal(ca.hv)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestScaleMarshal(t *testing.T) {
+	for _, ca := range casesScale {
+		t.Run(ca.name, func(t *testing.T) {
+			req := ca.h.Marshal()
+			require.Equal(t, ca.vout, req)
+		})
+	}
+}
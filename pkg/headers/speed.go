@@ -0,0 +1,81 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+func parseSpeed(v string) (float64, error) {
+	tmp, err := strconv.ParseFloat(v, 64)
+	if err != nil || tmp <= 0 {
+		return 0, fmt.Errorf("invalid value (%v)", v)
+	}
+	return tmp, nil
+}
+
+func formatSpeed(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Speed is a Speed header.
+// It is used to request delivery of data at a rate different from the
+// normal one, without changing the playback rate.
+type Speed struct {
+	// requested speed, or lower bound of the requested speed range.
+	Value float64
+
+	// (optional) upper bound of the requested speed range (RTSP/2.0).
+	Max *float64
+}
+
+// Unmarshal decodes a Speed header.
+func (h *Speed) Unmarshal(v base.HeaderValue) error {
+	if len(v) == 0 {
+		return fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	parts := strings.Split(strings.TrimSpace(v[0]), "-")
+	if len(parts) != 1 && len(parts) != 2 {
+		return fmt.Errorf("invalid value (%v)", v[0])
+	}
+
+	var err error
+	h.Value, err = parseSpeed(parts[0])
+	if err != nil {
+		return err
+	}
+
+	h.Max = nil
+	if len(parts) == 2 {
+		tmp, err := parseSpeed(parts[1])
+		if err != nil {
+			return err
+		}
+
+		if tmp < h.Value {
+			return fmt.Errorf("invalid value (%v)", v[0])
+		}
+
+		h.Max = &tmp
+	}
+
+	return nil
+}
+
+// Marshal encodes a Speed header.
+func (h Speed) Marshal() base.HeaderValue {
+	ret := formatSpeed(h.Value)
+
+	if h.Max != nil {
+		ret += "-" + formatSpeed(*h.Max)
+	}
+
+	return base.HeaderValue{ret}
+}
@@ -0,0 +1,108 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+var casesSpeed = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    Speed
+}{
+	{
+		"value",
+		base.HeaderValue{`2.5`},
+		base.HeaderValue{`2.5`},
+		Speed{
+			Value: 2.5,
+		},
+	},
+	{
+		"range",
+		base.HeaderValue{`1.0-2.5`},
+		base.HeaderValue{`1-2.5`},
+		Speed{
+			Value: 1,
+			Max:   float64Ptr(2.5),
+		},
+	},
+}
+
+func TestSpeedUnmarshal(t *testing.T) {
+	for _, ca := range casesSpeed {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Speed
+			err := h.Unmarshal(ca.vin)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+		})
+	}
+}
+
+func TestSpeedUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		hv   base.HeaderValue
+		err  string
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+			"value not provided",
+		},
+		{
+			"2 values",
+			base.HeaderValue{"a", "b"},
+			"value provided multiple times ([a b])",
+		},
+		{
+			"too many parts",
+			base.HeaderValue{"1-2-3"},
+			"invalid value (1-2-3)",
+		},
+		{
+			"invalid value",
+			base.HeaderValue{"aa"},
+			"invalid value (aa)",
+		},
+		{
+			"negative",
+			base.HeaderValue{"-1"},
+			"invalid value ()",
+		},
+		{
+			"invalid max",
+			base.HeaderValue{"1-aa"},
+			"invalid value (aa)",
+		},
+		{
+			"inverted range",
+			base.HeaderValue{"2-1"},
+			"invalid value (2-1)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Speed
+			err := h.Unmarshal(ca.hv)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestSpeedMarshal(t *testing.T) {
+	for _, ca := range casesSpeed {
+		t.Run(ca.name, func(t *testing.T) {
+			req := ca.h.Marshal()
+			require.Equal(t, ca.vout, req)
+		})
+	}
+}
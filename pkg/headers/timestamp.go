@@ -0,0 +1,76 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+func parseSeconds(v string) (time.Duration, error) {
+	tmp, err := strconv.ParseFloat(v, 64)
+	if err != nil || tmp < 0 {
+		return 0, fmt.Errorf("invalid value (%v)", v)
+	}
+	return time.Duration(tmp * float64(time.Second)), nil
+}
+
+func formatSeconds(v time.Duration) string {
+	return strconv.FormatFloat(v.Seconds(), 'f', -1, 64)
+}
+
+// Timestamp is a Timestamp header.
+// It is sent by clients and echoed by servers, in order to compute the round-trip time.
+type Timestamp struct {
+	// timestamp, chosen by the client
+	Value time.Duration
+
+	// (optional) time elapsed between the reception of the request and the sending of the response
+	Delay *time.Duration
+}
+
+// Unmarshal decodes a Timestamp header.
+func (h *Timestamp) Unmarshal(v base.HeaderValue) error {
+	if len(v) == 0 {
+		return fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	parts := strings.Fields(v[0])
+	if len(parts) != 1 && len(parts) != 2 {
+		return fmt.Errorf("invalid value (%v)", v[0])
+	}
+
+	var err error
+	h.Value, err = parseSeconds(parts[0])
+	if err != nil {
+		return err
+	}
+
+	h.Delay = nil
+	if len(parts) == 2 {
+		delay, err := parseSeconds(parts[1])
+		if err != nil {
+			return err
+		}
+		h.Delay = &delay
+	}
+
+	return nil
+}
+
+// Marshal encodes a Timestamp header.
+func (h Timestamp) Marshal() base.HeaderValue {
+	ret := formatSeconds(h.Value)
+
+	if h.Delay != nil {
+		ret += " " + formatSeconds(*h.Delay)
+	}
+
+	return base.HeaderValue{ret}
+}
@@ -0,0 +1,104 @@
+package headers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+var casesTimestamp = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    Timestamp
+}{
+	{
+		"base",
+		base.HeaderValue{`12.345`},
+		base.HeaderValue{`12.345`},
+		Timestamp{
+			Value: 12345 * time.Millisecond,
+		},
+	},
+	{
+		"with delay",
+		base.HeaderValue{`12.345 0.5`},
+		base.HeaderValue{`12.345 0.5`},
+		Timestamp{
+			Value: 12345 * time.Millisecond,
+			Delay: durationPtr(500 * time.Millisecond),
+		},
+	},
+	{
+		"integer with multiple spaces",
+		base.HeaderValue{`45  3`},
+		base.HeaderValue{`45 3`},
+		Timestamp{
+			Value: 45 * time.Second,
+			Delay: durationPtr(3 * time.Second),
+		},
+	},
+}
+
+func TestTimestampUnmarshal(t *testing.T) {
+	for _, ca := range casesTimestamp {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Timestamp
+			err := h.Unmarshal(ca.vin)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+		})
+	}
+}
+
+func TestTimestampUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		hv   base.HeaderValue
+		err  string
+	}{
+		{
+			"empty",
+			base.HeaderValue{},
+			"value not provided",
+		},
+		{
+			"2 values",
+			base.HeaderValue{"a", "b"},
+			"value provided multiple times ([a b])",
+		},
+		{
+			"too many parts",
+			base.HeaderValue{"1 2 3"},
+			"invalid value (1 2 3)",
+		},
+		{
+			"invalid timestamp",
+			base.HeaderValue{"aa"},
+			"invalid value (aa)",
+		},
+		{
+			"invalid delay",
+			base.HeaderValue{"1.5 -2"},
+			"invalid value (-2)",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Timestamp
+			err := h.Unmarshal(ca.hv)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestTimestampMarshal(t *testing.T) {
+	for _, ca := range casesTimestamp {
+		t.Run(ca.name, func(t *testing.T) {
+			req := ca.h.Marshal()
+			require.Equal(t, ca.vout, req)
+		})
+	}
+}
@@ -10,6 +10,14 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 )
 
+// trimBrackets removes brackets around IPv6 literals.
+func trimBrackets(v string) string {
+	if len(v) >= 2 && v[0] == '[' && v[len(v)-1] == ']' {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
 func parsePorts(val string) (*[2]int, error) {
 	ports := strings.Split(val, "-")
 	if len(ports) == 2 {
@@ -38,6 +46,141 @@ func parsePorts(val string) (*[2]int, error) {
 	return &[2]int{0, 0}, fmt.Errorf("invalid ports (%v)", val)
 }
 
+// unquote removes apexes around a value.
+func unquote(v string) string {
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// splitOutsideApexes splits a string by a separator, ignoring separators inside apexes.
+func splitOutsideApexes(str string, separator byte) []string {
+	var ret []string
+	inApexes := false
+	start := 0
+
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '"':
+			inApexes = !inApexes
+
+		case separator:
+			if !inApexes {
+				ret = append(ret, str[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(ret, str[start:])
+}
+
+// transportParametersParse splits a transport into parameters, preserving their order
+// and the apexes around their values.
+func transportParametersParse(str string) ([]TransportParameter, error) {
+	if strings.Count(str, "\"")%2 != 0 {
+		return nil, fmt.Errorf("apexes not closed (%v)", str)
+	}
+
+	var ret []TransportParameter
+
+	for _, part := range splitOutsideApexes(str, ';') {
+		part = strings.TrimLeft(part, " ")
+
+		var p TransportParameter
+		i := strings.IndexByte(part, '=')
+
+		// skip parameters without a key
+		if part == "" || i == 0 {
+			continue
+		}
+
+		if i > 0 {
+			p.Key = part[:i]
+			p.Value = part[i+1:]
+			p.HasValue = true
+		} else {
+			p.Key = part
+		}
+
+		ret = append(ret, p)
+	}
+
+	return ret, nil
+}
+
+func parseTransportMode(v string) (TransportMode, error) {
+	str := strings.ToLower(strings.TrimSpace(v))
+
+	switch str {
+	case "play":
+		return TransportModePlay, nil
+
+		// receive is an old alias for record, used by ffmpeg with the
+		// -listen flag, and by Darwin Streaming Server
+	case "record", "receive":
+		return TransportModeRecord, nil
+	}
+
+	return 0, fmt.Errorf("invalid transport mode: '%s'", str)
+}
+
+func parseSSRC(v string) (uint32, bool) {
+	v = strings.TrimLeft(v, " ")
+
+	if (len(v) % 2) != 0 {
+		v = "0" + v
+	}
+
+	tmp, err := hex.DecodeString(v)
+	if err != nil || len(tmp) > 4 {
+		return 0, false
+	}
+
+	var ssrc [4]byte
+	copy(ssrc[4-len(tmp):], tmp)
+	return uint32(ssrc[0])<<24 | uint32(ssrc[1])<<16 | uint32(ssrc[2])<<8 | uint32(ssrc[3]), true
+}
+
+func marshalSSRC(v uint32) string {
+	tmp := make([]byte, 4)
+	tmp[0] = byte(v >> 24)
+	tmp[1] = byte(v >> 16)
+	tmp[2] = byte(v >> 8)
+	tmp[3] = byte(v)
+	return strings.ToUpper(hex.EncodeToString(tmp))
+}
+
+// parseAddresses decodes a list of addresses in the RTSP 2.0 format ("host:port"/"host:port").
+func parseAddresses(v string) []string {
+	var ret []string
+	for _, addr := range splitOutsideApexes(v, '/') {
+		ret = append(ret, unquote(addr))
+	}
+	return ret
+}
+
+func marshalAddresses(addrs []string) string {
+	tmp := make([]string, len(addrs))
+	for i, addr := range addrs {
+		tmp[i] = "\"" + addr + "\""
+	}
+	return strings.Join(tmp, "/")
+}
+
+// TransportParameter is a parameter of a Transport header that is not supported.
+type TransportParameter struct {
+	// key
+	Key string
+
+	// value, with apexes, if present.
+	Value string
+
+	// whether the parameter has a value ("key=value" instead of "key").
+	HasValue bool
+}
+
 // TransportProtocol is a transport protocol.
 type TransportProtocol int
 
@@ -67,11 +210,22 @@ const (
 	TransportModeRecord
 )
 
+// String implements fmt.Stringer.
+func (m TransportMode) String() string {
+	if m == TransportModePlay {
+		return "play"
+	}
+	return "record"
+}
+
 // Transport is a Transport header.
 type Transport struct {
 	// protocol of the stream
 	Protocol TransportProtocol
 
+	// whether the stream is protected with SRTP (RTP/SAVP profile)
+	Secure bool
+
 	// (optional) delivery method of the stream
 	Delivery *TransportDelivery
 
@@ -99,8 +253,32 @@ type Transport struct {
 	// (optional) SSRC of the packets of the stream
 	SSRC *uint32
 
+	// (optional) SSRCs of the packets of the stream, when there are more than one (RTSP 2.0).
+	// SSRC contains the first one.
+	SSRCs []uint32
+
 	// (optional) mode
 	Mode *TransportMode
+
+	// (optional) modes, when there are more than one (i.e. mode="PLAY,RECORD").
+	// Mode contains the first one.
+	Modes []TransportMode
+
+	// whether the stream must be appended to an existing one (record only)
+	Append bool
+
+	// (optional) number of multicast layers
+	Layers *uint
+
+	// (optional) destination addresses, in the "host:port" format (RTSP 2.0)
+	DestinationAddresses []string
+
+	// (optional) source addresses, in the "host:port" format (RTSP 2.0)
+	SourceAddresses []string
+
+	// (optional) parameters that are not supported.
+	// They are preserved in order to allow proxies to forward them.
+	UnknownParameters []TransportParameter
 }
 
 // Unmarshal decodes a Transport header.
@@ -115,15 +293,16 @@ func (h *Transport) Unmarshal(v base.HeaderValue) error {
 
 	v0 := v[0]
 
-	kvs, err := keyValParse(v0, ';')
+	params, err := transportParametersParse(v0)
 	if err != nil {
 		return err
 	}
 
 	protocolFound := false
 
-	for k, rv := range kvs {
-		v := rv
+	for _, p := range params {
+		k := p.Key
+		v := unquote(p.Value)
 
 		switch k {
 		case "RTP/AVP", "RTP/AVP/UDP":
@@ -134,6 +313,16 @@ func (h *Transport) Unmarshal(v base.HeaderValue) error {
 			h.Protocol = TransportProtocolTCP
 			protocolFound = true
 
+		case "RTP/SAVP", "RTP/SAVP/UDP":
+			h.Protocol = TransportProtocolUDP
+			h.Secure = true
+			protocolFound = true
+
+		case "RTP/SAVP/TCP":
+			h.Protocol = TransportProtocolTCP
+			h.Secure = true
+			protocolFound = true
+
 		case "unicast":
 			v := TransportDeliveryUnicast
 			h.Delivery = &v
@@ -144,6 +333,7 @@ func (h *Transport) Unmarshal(v base.HeaderValue) error {
 
 		case "source":
 			if v != "" {
+				v = trimBrackets(v)
 				ip := net.ParseIP(v)
 				if ip == nil {
 					addrs, err := net.LookupHost(v)
@@ -160,7 +350,7 @@ func (h *Transport) Unmarshal(v base.HeaderValue) error {
 
 		case "destination":
 			if v != "" {
-				ip := net.ParseIP(v)
+				ip := net.ParseIP(trimBrackets(v))
 				if ip == nil {
 					return fmt.Errorf("invalid destination (%v)", v)
 				}
@@ -204,41 +394,60 @@ func (h *Transport) Unmarshal(v base.HeaderValue) error {
 			h.ServerPorts = ports
 
 		case "ssrc":
-			v = strings.TrimLeft(v, " ")
+			var ssrcs []uint32
 
-			if (len(v) % 2) != 0 {
-				v = "0" + v
+			for _, part := range strings.Split(v, "/") {
+				ssrc, ok := parseSSRC(part)
+				if !ok {
+					break
+				}
+				ssrcs = append(ssrcs, ssrc)
+			}
+
+			if len(ssrcs) != 0 {
+				h.SSRC = &ssrcs[0]
 			}
 
-			if tmp, err := hex.DecodeString(v); err == nil && len(tmp) <= 4 {
-				var ssrc [4]byte
-				copy(ssrc[4-len(tmp):], tmp)
-				v := uint32(ssrc[0])<<24 | uint32(ssrc[1])<<16 | uint32(ssrc[2])<<8 | uint32(ssrc[3])
-				h.SSRC = &v
+			if len(ssrcs) > 1 {
+				h.SSRCs = ssrcs
 			}
 
 		case "mode":
-			str := strings.ToLower(v)
-			str = strings.TrimPrefix(str, "\"")
-			str = strings.TrimSuffix(str, "\"")
-
-			switch str {
-			case "play":
-				v := TransportModePlay
-				h.Mode = &v
-
-				// receive is an old alias for record, used by ffmpeg with the
-				// -listen flag, and by Darwin Streaming Server
-			case "record", "receive":
-				v := TransportModeRecord
-				h.Mode = &v
-
-			default:
-				return fmt.Errorf("invalid transport mode: '%s'", str)
+			var modes []TransportMode
+
+			for _, part := range strings.Split(v, ",") {
+				mode, err := parseTransportMode(part)
+				if err != nil {
+					return err
+				}
+				modes = append(modes, mode)
+			}
+
+			h.Mode = &modes[0]
+
+			if len(modes) > 1 {
+				h.Modes = modes
 			}
 
+		case "append":
+			h.Append = true
+
+		case "layers":
+			tmp, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return err
+			}
+			vu := uint(tmp)
+			h.Layers = &vu
+
+		case "dest_addr":
+			h.DestinationAddresses = parseAddresses(p.Value)
+
+		case "src_addr":
+			h.SourceAddresses = parseAddresses(p.Value)
+
 		default:
-			// ignore non-standard keys
+			h.UnknownParameters = append(h.UnknownParameters, p)
 		}
 	}
 
@@ -253,10 +462,15 @@ func (h *Transport) Unmarshal(v base.HeaderValue) error {
 func (h Transport) Marshal() base.HeaderValue {
 	var rets []string
 
+	profile := "RTP/AVP"
+	if h.Secure {
+		profile = "RTP/SAVP"
+	}
+
 	if h.Protocol == TransportProtocolUDP {
-		rets = append(rets, "RTP/AVP")
+		rets = append(rets, profile)
 	} else {
-		rets = append(rets, "RTP/AVP/TCP")
+		rets = append(rets, profile+"/TCP")
 	}
 
 	if h.Delivery != nil {
@@ -299,20 +513,47 @@ func (h Transport) Marshal() base.HeaderValue {
 			"-"+strconv.FormatInt(int64(h.ServerPorts[1]), 10))
 	}
 
-	if h.SSRC != nil {
-		tmp := make([]byte, 4)
-		tmp[0] = byte(*h.SSRC >> 24)
-		tmp[1] = byte(*h.SSRC >> 16)
-		tmp[2] = byte(*h.SSRC >> 8)
-		tmp[3] = byte(*h.SSRC)
-		rets = append(rets, "ssrc="+strings.ToUpper(hex.EncodeToString(tmp)))
+	if len(h.SSRCs) != 0 {
+		tmp := make([]string, len(h.SSRCs))
+		for i, ssrc := range h.SSRCs {
+			tmp[i] = marshalSSRC(ssrc)
+		}
+		rets = append(rets, "ssrc="+strings.Join(tmp, "/"))
+	} else if h.SSRC != nil {
+		rets = append(rets, "ssrc="+marshalSSRC(*h.SSRC))
+	}
+
+	if len(h.Modes) != 0 {
+		tmp := make([]string, len(h.Modes))
+		for i, mode := range h.Modes {
+			tmp[i] = mode.String()
+		}
+		rets = append(rets, "mode=\""+strings.Join(tmp, ",")+"\"")
+	} else if h.Mode != nil {
+		rets = append(rets, "mode="+h.Mode.String())
+	}
+
+	if h.Append {
+		rets = append(rets, "append")
+	}
+
+	if h.Layers != nil {
+		rets = append(rets, "layers="+strconv.FormatUint(uint64(*h.Layers), 10))
+	}
+
+	if len(h.DestinationAddresses) != 0 {
+		rets = append(rets, "dest_addr="+marshalAddresses(h.DestinationAddresses))
+	}
+
+	if len(h.SourceAddresses) != 0 {
+		rets = append(rets, "src_addr="+marshalAddresses(h.SourceAddresses))
 	}
 
-	if h.Mode != nil {
-		if *h.Mode == TransportModePlay {
-			rets = append(rets, "mode=play")
+	for _, p := range h.UnknownParameters {
+		if p.HasValue {
+			rets = append(rets, p.Key+"="+p.Value)
 		} else {
-			rets = append(rets, "mode=record")
+			rets = append(rets, p.Key)
 		}
 	}
 
@@ -333,7 +574,7 @@ func (ts *Transports) Unmarshal(v base.HeaderValue) error {
 	}
 
 	v0 := v[0]
-	transports := strings.Split(v0, ",") // , separated per RFC2326 section 12.39
+	transports := splitEntries(v0) // , separated per RFC2326 section 12.39
 	*ts = make([]Transport, len(transports))
 
 	for i, transport := range transports {
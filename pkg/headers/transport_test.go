@@ -49,6 +49,28 @@ var casesTransport = []struct {
 			ServerPorts: &[2]int{5000, 5001},
 		},
 	},
+	{
+		"secure udp unicast play request",
+		base.HeaderValue{`RTP/SAVP/UDP;unicast;client_port=3456-3457`},
+		base.HeaderValue{`RTP/SAVP;unicast;client_port=3456-3457`},
+		Transport{
+			Protocol:    TransportProtocolUDP,
+			Secure:      true,
+			Delivery:    deliveryPtr(TransportDeliveryUnicast),
+			ClientPorts: &[2]int{3456, 3457},
+		},
+	},
+	{
+		"secure tcp play request",
+		base.HeaderValue{`RTP/SAVP/TCP;unicast;interleaved=0-1`},
+		base.HeaderValue{`RTP/SAVP/TCP;unicast;interleaved=0-1`},
+		Transport{
+			Protocol:       TransportProtocolTCP,
+			Secure:         true,
+			Delivery:       deliveryPtr(TransportDeliveryUnicast),
+			InterleavedIDs: &[2]int{0, 1},
+		},
+	},
 	{
 		"udp multicast play request / response",
 		base.HeaderValue{`RTP/AVP;multicast;destination=225.219.201.15;port=7000-7001;ttl=127`},
@@ -61,6 +83,19 @@ var casesTransport = []struct {
 			Ports:       &[2]int{7000, 7001},
 		},
 	},
+	{
+		"udp multicast ipv6 with brackets",
+		base.HeaderValue{`RTP/AVP;multicast;source=[2001:db8::1];destination=[ff15::1];port=7000-7001;ttl=127`},
+		base.HeaderValue{`RTP/AVP;multicast;source=2001:db8::1;destination=ff15::1;port=7000-7001;ttl=127`},
+		Transport{
+			Protocol:    TransportProtocolUDP,
+			Delivery:    deliveryPtr(TransportDeliveryMulticast),
+			Source:      ipPtr(net.ParseIP("2001:db8::1")),
+			Destination: ipPtr(net.ParseIP("ff15::1")),
+			TTL:         uintPtr(127),
+			Ports:       &[2]int{7000, 7001},
+		},
+	},
 	{
 		"tcp play request / response",
 		base.HeaderValue{`RTP/AVP/TCP;interleaved=0-1`},
@@ -168,6 +203,55 @@ var casesTransport = []struct {
 			ServerPorts: &[2]int{56002, 56003},
 		},
 	},
+	{
+		"multicast record with append and layers",
+		base.HeaderValue{`RTP/AVP;multicast;destination=225.219.201.15;port=7000-7001;ttl=127;layers=2;` +
+			`mode="PLAY,RECORD";append`},
+		base.HeaderValue{`RTP/AVP;multicast;destination=225.219.201.15;port=7000-7001;ttl=127;` +
+			`mode="play,record";append;layers=2`},
+		Transport{
+			Protocol:    TransportProtocolUDP,
+			Delivery:    deliveryPtr(TransportDeliveryMulticast),
+			Destination: ipPtr(net.ParseIP("225.219.201.15")),
+			Ports:       &[2]int{7000, 7001},
+			TTL:         uintPtr(127),
+			Layers:      uintPtr(2),
+			Mode:        transportModePtr(TransportModePlay),
+			Modes:       []TransportMode{TransportModePlay, TransportModeRecord},
+			Append:      true,
+		},
+	},
+	{
+		"rtsp 2.0 addresses and ssrc list",
+		base.HeaderValue{`RTP/AVP/UDP;unicast;dest_addr="192.0.2.5:3456"/"192.0.2.5:3457";` +
+			`src_addr="192.0.2.224:6256"/":6257";ssrc=0A13C760/4D2A1E7B;mode="PLAY"`},
+		base.HeaderValue{`RTP/AVP;unicast;ssrc=0A13C760/4D2A1E7B;mode=play;` +
+			`dest_addr="192.0.2.5:3456"/"192.0.2.5:3457";src_addr="192.0.2.224:6256"/":6257"`},
+		Transport{
+			Protocol:             TransportProtocolUDP,
+			Delivery:             deliveryPtr(TransportDeliveryUnicast),
+			SSRC:                 uint32Ptr(0x0A13C760),
+			SSRCs:                []uint32{0x0A13C760, 0x4D2A1E7B},
+			Mode:                 transportModePtr(TransportModePlay),
+			DestinationAddresses: []string{"192.0.2.5:3456", "192.0.2.5:3457"},
+			SourceAddresses:      []string{"192.0.2.224:6256", ":6257"},
+		},
+	},
+	{
+		"unknown parameters",
+		base.HeaderValue{`RTP/AVP/TCP;unicast;interleaved=4-5;x-dynamic-rate=1;x-key="a;b";x-flag`},
+		base.HeaderValue{`RTP/AVP/TCP;unicast;interleaved=4-5;x-dynamic-rate=1;x-key="a;b";x-flag`},
+		Transport{
+			Protocol:       TransportProtocolTCP,
+			Delivery:       deliveryPtr(TransportDeliveryUnicast),
+			InterleavedIDs: &[2]int{4, 5},
+			UnknownParameters: []TransportParameter{
+				{Key: "x-dynamic-rate", Value: "1", HasValue: true},
+				{Key: "x-key", Value: `"a;b"`, HasValue: true},
+				{Key: "x-flag"},
+			},
+		},
+	},
 }
 
 func TestTransportUnmarshal(t *testing.T) {
@@ -257,6 +341,11 @@ func TestTransportUnmarshalErrors(t *testing.T) {
 			base.HeaderValue{`RTP/AVP;unicast;mode=aa`},
 			"invalid transport mode: 'aa'",
 		},
+		{
+			"invalid layers",
+			base.HeaderValue{`RTP/AVP;multicast;layers=aa`},
+			"strconv.ParseUint: parsing \"aa\": invalid syntax",
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			var h Transport
@@ -299,6 +388,24 @@ var casesTransports = []struct {
 			},
 		},
 	},
+	{
+		"mode list",
+		base.HeaderValue{`RTP/AVP;unicast;client_port=3456-3457;mode="PLAY,RECORD",RTP/AVP/TCP;unicast`},
+		base.HeaderValue{`RTP/AVP;unicast;client_port=3456-3457;mode="play,record",RTP/AVP/TCP;unicast`},
+		Transports{
+			{
+				Protocol:    TransportProtocolUDP,
+				Delivery:    deliveryPtr(TransportDeliveryUnicast),
+				ClientPorts: &[2]int{3456, 3457},
+				Mode:        transportModePtr(TransportModePlay),
+				Modes:       []TransportMode{TransportModePlay, TransportModeRecord},
+			},
+			{
+				Protocol: TransportProtocolTCP,
+				Delivery: deliveryPtr(TransportDeliveryUnicast),
+			},
+		},
+	},
 }
 
 func TestTransportsUnmarshal(t *testing.T) {
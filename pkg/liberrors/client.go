@@ -2,6 +2,7 @@ package liberrors
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 )
@@ -36,10 +37,35 @@ func (e ErrClientSessionHeaderInvalid) Error() string {
 	return fmt.Sprintf("invalid session header: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error.
+func (e ErrClientSessionHeaderInvalid) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientSessionHeaderMismatch is an error that can be returned by a client.
+type ErrClientSessionHeaderMismatch struct {
+	Expected string
+	Received string
+}
+
+// Error implements the error interface.
+func (e ErrClientSessionHeaderMismatch) Error() string {
+	return fmt.Sprintf("session ID mismatch: expected '%s', received '%s'", e.Expected, e.Received)
+}
+
+// ErrClientSSRCUnknown is an error that can be returned by a client.
+type ErrClientSSRCUnknown struct{}
+
+// Error implements the error interface.
+func (e ErrClientSSRCUnknown) Error() string {
+	return "SSRC of the media is not known yet"
+}
+
 // ErrClientBadStatusCode is an error that can be returned by a client.
 type ErrClientBadStatusCode struct {
-	Code    base.StatusCode
-	Message string
+	Code     base.StatusCode
+	Message  string
+	Response *base.Response
 }
 
 // Error implements the error interface.
@@ -47,6 +73,21 @@ func (e ErrClientBadStatusCode) Error() string {
 	return fmt.Sprintf("bad status code: %d (%s)", e.Code, e.Message)
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientBadStatusCode) Is(target error) bool {
+	switch target {
+	case ErrAuthFailed:
+		return e.Code == base.StatusUnauthorized || e.Code == base.StatusForbidden
+	case ErrTimeout:
+		return e.Code == base.StatusRequestTimeout
+	case ErrUnsupportedTransport:
+		return e.Code == base.StatusUnsupportedTransport
+	case ErrServerClosedSession:
+		return e.Code == base.StatusSessionNotFound
+	}
+	return false
+}
+
 // ErrClientContentTypeMissing is an error that can be returned by a client.
 type ErrClientContentTypeMissing struct{}
 
@@ -65,6 +106,17 @@ func (e ErrClientContentTypeUnsupported) Error() string {
 	return fmt.Sprintf("unsupported Content-Type header '%v'", e.CT)
 }
 
+// ErrClientDescriptionInvalid is an error that can be returned by a client.
+type ErrClientDescriptionInvalid struct {
+	CT  string
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrClientDescriptionInvalid) Error() string {
+	return fmt.Sprintf("invalid description (%s): %v", e.CT, e.Err)
+}
+
 // ErrClientCannotSetupMediasDifferentURLs is an error that can be returned by a client.
 type ErrClientCannotSetupMediasDifferentURLs struct{}
 
@@ -73,6 +125,22 @@ func (e ErrClientCannotSetupMediasDifferentURLs) Error() string {
 	return "cannot setup medias with different base URLs"
 }
 
+// ErrClientMediaNotSetup is an error that can be returned by a client.
+type ErrClientMediaNotSetup struct{}
+
+// Error implements the error interface.
+func (e ErrClientMediaNotSetup) Error() string {
+	return "media has not been setup"
+}
+
+// ErrClientCannotTeardownLastMedia is an error that can be returned by a client.
+type ErrClientCannotTeardownLastMedia struct{}
+
+// Error implements the error interface.
+func (e ErrClientCannotTeardownLastMedia) Error() string {
+	return "the last media of a session can't be torn down, close the session instead"
+}
+
 // ErrClientUDPPortsZero is an error that can be returned by a client.
 type ErrClientUDPPortsZero struct{}
 
@@ -107,6 +175,11 @@ func (e ErrClientTransportHeaderInvalid) Error() string {
 	return fmt.Sprintf("invalid transport header: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error.
+func (e ErrClientTransportHeaderInvalid) Unwrap() error {
+	return e.Err
+}
+
 // ErrClientServerRequestedTCP is an error that can be returned by a client.
 type ErrClientServerRequestedTCP struct{}
 
@@ -115,6 +188,11 @@ func (e ErrClientServerRequestedTCP) Error() string {
 	return "server wants to use the TCP transport protocol"
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientServerRequestedTCP) Is(target error) bool {
+	return target == ErrUnsupportedTransport
+}
+
 // ErrClientServerRequestedUDP is an error that can be returned by a client.
 type ErrClientServerRequestedUDP struct{}
 
@@ -123,6 +201,11 @@ func (e ErrClientServerRequestedUDP) Error() string {
 	return "server wants to use the UDP transport protocol"
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientServerRequestedUDP) Is(target error) bool {
+	return target == ErrUnsupportedTransport
+}
+
 // ErrClientTransportHeaderInvalidDelivery is an error that can be returned by a client.
 type ErrClientTransportHeaderInvalidDelivery struct{}
 
@@ -179,6 +262,11 @@ func (e ErrClientUDPTimeout) Error() string {
 	return "UDP timeout"
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientUDPTimeout) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // ErrClientTCPTimeout is an error that can be returned by a client.
 type ErrClientTCPTimeout struct{}
 
@@ -187,6 +275,39 @@ func (e ErrClientTCPTimeout) Error() string {
 	return "TCP timeout"
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientTCPTimeout) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// ErrClientStreamNotStarted is an error that can be returned by a client.
+type ErrClientStreamNotStarted struct{}
+
+// Error implements the error interface.
+func (e ErrClientStreamNotStarted) Error() string {
+	return "no RTP packets received since the stream was started"
+}
+
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientStreamNotStarted) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// ErrClientStreamStalled is an error that can be returned by a client.
+type ErrClientStreamStalled struct {
+	Duration time.Duration
+}
+
+// Error implements the error interface.
+func (e ErrClientStreamStalled) Error() string {
+	return fmt.Sprintf("no RTP packets received in %v", e.Duration)
+}
+
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientStreamStalled) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // ErrClientRTPInfoInvalid is an error that can be returned by a client.
 type ErrClientRTPInfoInvalid struct {
 	Err error
@@ -197,6 +318,11 @@ func (e ErrClientRTPInfoInvalid) Error() string {
 	return fmt.Sprintf("invalid RTP-Info: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error.
+func (e ErrClientRTPInfoInvalid) Unwrap() error {
+	return e.Err
+}
+
 // ErrClientUnexpectedFrame is an error that can be returned by a client.
 type ErrClientUnexpectedFrame struct{}
 
@@ -213,6 +339,11 @@ func (e ErrClientRequestTimedOut) Error() string {
 	return "request timed out"
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientRequestTimedOut) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // ErrClientUnsupportedScheme is an error that can be returned by a client.
 type ErrClientUnsupportedScheme struct {
 	Scheme string
@@ -231,6 +362,24 @@ func (e ErrClientRTSPSTCP) Error() string {
 	return "RTSPS can be used only with TCP"
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientRTSPSTCP) Is(target error) bool {
+	return target == ErrUnsupportedTransport
+}
+
+// ErrClientTunnelTCP is an error that can be returned by a client.
+type ErrClientTunnelTCP struct{}
+
+// Error implements the error interface.
+func (e ErrClientTunnelTCP) Error() string {
+	return "tunneling can be used only with TCP"
+}
+
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientTunnelTCP) Is(target error) bool {
+	return target == ErrUnsupportedTransport
+}
+
 // ErrClientUnhandledMethod is an error that can be returned by a client.
 type ErrClientUnhandledMethod struct {
 	Method base.Method
@@ -241,6 +390,21 @@ func (e ErrClientUnhandledMethod) Error() string {
 	return fmt.Sprintf("unhandled method: %v", e.Method)
 }
 
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientUnhandledMethod) Is(target error) bool {
+	return target == ErrServerClosedSession && e.Method == base.Teardown
+}
+
+// ErrClientMethodNotPipelinable is an error that can be returned by a client.
+type ErrClientMethodNotPipelinable struct {
+	Method base.Method
+}
+
+// Error implements the error interface.
+func (e ErrClientMethodNotPipelinable) Error() string {
+	return fmt.Sprintf("method %v changes the state of the session and can't be sent without waiting for responses", e.Method)
+}
+
 // ErrClientWriteQueueFull is an error that can be returned by a client.
 type ErrClientWriteQueueFull struct{}
 
@@ -276,6 +440,16 @@ func (e ErrClientRTPPacketUnknownPayloadType) Error() string {
 	return fmt.Sprintf("received RTP packet with unknown payload type: %d", e.PayloadType)
 }
 
+// ErrClientRTPPacketPayloadTypeNotInMedia is an error that can be returned by a client.
+type ErrClientRTPPacketPayloadTypeNotInMedia struct {
+	PayloadType uint8
+}
+
+// Error implements the error interface.
+func (e ErrClientRTPPacketPayloadTypeNotInMedia) Error() string {
+	return fmt.Sprintf("RTP packet payload type (%d) does not match any format of the media", e.PayloadType)
+}
+
 // ErrClientRTCPPacketTooBig is an error that can be returned by a client.
 type ErrClientRTCPPacketTooBig struct {
 	L   int
@@ -320,6 +494,22 @@ func (e ErrClientSwitchToTCP2) Error() string {
 	return "switching to TCP because server requested it"
 }
 
+// ErrClientSwitchTransport is an error that can be returned by a client.
+type ErrClientSwitchTransport struct {
+	Transport string
+	Err       error
+}
+
+// Error implements the error interface.
+func (e ErrClientSwitchTransport) Error() string {
+	return fmt.Sprintf("switching to %s: %v", e.Transport, e.Err)
+}
+
+// Unwrap returns the wrapped error.
+func (e ErrClientSwitchTransport) Unwrap() error {
+	return e.Err
+}
+
 // ErrClientAuthSetup is an error that can be returned by a client.
 type ErrClientAuthSetup struct {
 	Err error
@@ -330,6 +520,16 @@ func (e ErrClientAuthSetup) Error() string {
 	return fmt.Sprintf("unable to setup authentication: %s", e.Err)
 }
 
+// Unwrap returns the wrapped error.
+func (e ErrClientAuthSetup) Unwrap() error {
+	return e.Err
+}
+
+// Is allows to check the category of the error with errors.Is().
+func (e ErrClientAuthSetup) Is(target error) bool {
+	return target == ErrAuthFailed
+}
+
 // ErrClientSDPInvalid is an error that can be returned by a client.
 type ErrClientSDPInvalid struct {
 	Err error
@@ -339,3 +539,150 @@ type ErrClientSDPInvalid struct {
 func (e ErrClientSDPInvalid) Error() string {
 	return fmt.Sprintf("invalid SDP: %v", e.Err)
 }
+
+// Unwrap returns the wrapped error.
+func (e ErrClientSDPInvalid) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientResponseMissing is an error that can be returned by a client.
+type ErrClientResponseMissing struct{}
+
+// Error implements the error interface.
+func (e ErrClientResponseMissing) Error() string {
+	return "response is missing"
+}
+
+// ErrClientSDPWarning is an error that can be returned by a client.
+type ErrClientSDPWarning struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrClientSDPWarning) Error() string {
+	return fmt.Sprintf("non-compliant SDP: %v", e.Err)
+}
+
+// Unwrap returns the wrapped error.
+func (e ErrClientSDPWarning) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientRTCPMulticastJoin is an error that can be returned by a client.
+type ErrClientRTCPMulticastJoin struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrClientRTCPMulticastJoin) Error() string {
+	return fmt.Sprintf("unable to join RTCP multicast group, RTCP packets will not be received: %v", e.Err)
+}
+
+// Unwrap returns the wrapped error.
+func (e ErrClientRTCPMulticastJoin) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientContentLocationInvalid is an error that can be returned by a client.
+type ErrClientContentLocationInvalid struct {
+	Value base.HeaderValue
+}
+
+// Error implements the error interface.
+func (e ErrClientContentLocationInvalid) Error() string {
+	return fmt.Sprintf("invalid Content-Location: '%v'", e.Value)
+}
+
+// ErrClientResponseValidation is an error that can be returned by a client.
+type ErrClientResponseValidation struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrClientResponseValidation) Error() string {
+	return fmt.Sprintf("response validation failed: %v", e.Err)
+}
+
+// Unwrap returns the validation error.
+func (e ErrClientResponseValidation) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientInterleavedFramesNotAvailable is an error that can be returned by a client.
+type ErrClientInterleavedFramesNotAvailable struct{}
+
+// Error implements the error interface.
+func (e ErrClientInterleavedFramesNotAvailable) Error() string {
+	return "interleaved frames can be written only when the TCP transport is in use"
+}
+
+// ErrClientInterleavedChannelInUse is an error that can be returned by a client.
+type ErrClientInterleavedChannelInUse struct {
+	Channel int
+}
+
+// Error implements the error interface.
+func (e ErrClientInterleavedChannelInUse) Error() string {
+	return fmt.Sprintf("interleaved channel %d is in use by a media", e.Channel)
+}
+
+// ErrClientParametersInvalid is an error that can be returned by a client.
+type ErrClientParametersInvalid struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrClientParametersInvalid) Error() string {
+	return fmt.Sprintf("invalid parameters: %v", e.Err)
+}
+
+// Unwrap returns the wrapped error.
+func (e ErrClientParametersInvalid) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientTooManyRedirects is an error that can be returned by a client.
+type ErrClientTooManyRedirects struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrClientTooManyRedirects) Error() string {
+	return fmt.Sprintf("too many redirects (maximum is %d)", e.Max)
+}
+
+// ErrClientRedirectLoop is an error that can be returned by a client.
+type ErrClientRedirectLoop struct {
+	URL string
+}
+
+// Error implements the error interface.
+func (e ErrClientRedirectLoop) Error() string {
+	return fmt.Sprintf("redirect loop detected (%s)", e.URL)
+}
+
+// ErrClientRedirected is an error that can be returned by a client.
+type ErrClientRedirected struct {
+	Location *base.URL
+}
+
+// Error implements the error interface.
+func (e ErrClientRedirected) Error() string {
+	return fmt.Sprintf("redirected by the server to %v", e.Location)
+}
+
+// ErrClientProbeFailed is an error that can be returned by a client.
+type ErrClientProbeFailed struct {
+	Attempts int
+	Err      error
+}
+
+// Error implements the error interface.
+func (e ErrClientProbeFailed) Error() string {
+	return fmt.Sprintf("no working configuration found after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the wrapped error.
+func (e ErrClientProbeFailed) Unwrap() error {
+	return e.Err
+}
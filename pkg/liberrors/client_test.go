@@ -0,0 +1,88 @@
+package liberrors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+func TestClientErrorCategories(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		err      error
+		category error
+	}{
+		{
+			"unauthorized",
+			ErrClientBadStatusCode{Code: base.StatusUnauthorized},
+			ErrAuthFailed,
+		},
+		{
+			"auth setup",
+			ErrClientAuthSetup{Err: fmt.Errorf("no supported methods")},
+			ErrAuthFailed,
+		},
+		{
+			"unsupported transport",
+			ErrClientBadStatusCode{Code: base.StatusUnsupportedTransport},
+			ErrUnsupportedTransport,
+		},
+		{
+			"server requested tcp",
+			ErrClientServerRequestedTCP{},
+			ErrUnsupportedTransport,
+		},
+		{
+			"udp timeout",
+			ErrClientUDPTimeout{},
+			ErrTimeout,
+		},
+		{
+			"request timed out",
+			ErrClientRequestTimedOut{},
+			ErrTimeout,
+		},
+		{
+			"session not found",
+			ErrClientBadStatusCode{Code: base.StatusSessionNotFound},
+			ErrServerClosedSession,
+		},
+		{
+			"teardown",
+			ErrClientUnhandledMethod{Method: base.Teardown},
+			ErrServerClosedSession,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.ErrorIs(t, ca.err, ca.category)
+			require.ErrorIs(t, fmt.Errorf("wrapped: %w", ca.err), ca.category)
+
+			for _, other := range []error{
+				ErrAuthFailed,
+				ErrTimeout,
+				ErrUnsupportedTransport,
+				ErrServerClosedSession,
+			} {
+				if other != ca.category {
+					require.NotErrorIs(t, ca.err, other)
+				}
+			}
+		})
+	}
+}
+
+func TestClientErrorUnwrap(t *testing.T) {
+	inner := fmt.Errorf("inner")
+
+	err := error(ErrClientSwitchTransport{
+		Transport: "TCP",
+		Err:       ErrClientUDPTimeout{},
+	})
+	require.ErrorIs(t, err, ErrTimeout)
+
+	err = ErrClientTransportHeaderInvalid{Err: inner}
+	require.ErrorIs(t, err, inner)
+}
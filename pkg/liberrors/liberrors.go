@@ -1,2 +1,27 @@
 // Package liberrors contains errors returned by the library.
 package liberrors
+
+import (
+	"errors"
+)
+
+// categories of errors, that can be checked with errors.Is().
+// Errors returned by the network, like timeouts of read and write operations,
+// are returned as they are and can be checked with errors.Is() and os.ErrDeadlineExceeded.
+var (
+	// ErrAuthFailed is matched by errors caused by credentials that are missing,
+	// rejected by the server or that can't be used with the methods proposed by the server.
+	ErrAuthFailed = errors.New("authentication failed")
+
+	// ErrTimeout is matched by errors caused by requests that have not been answered in time
+	// and by streams that stopped receiving packets.
+	ErrTimeout = errors.New("timeout")
+
+	// ErrUnsupportedTransport is matched by errors caused by a transport protocol
+	// that is rejected by the server or that can't be used with the current configuration.
+	ErrUnsupportedTransport = errors.New("unsupported transport")
+
+	// ErrServerClosedSession is matched by errors caused by the server
+	// closing or not recognizing the session.
+	ErrServerClosedSession = errors.New("server closed the session")
+)
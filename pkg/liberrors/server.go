@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 )
 
@@ -54,6 +55,16 @@ func (e ErrServerInvalidPath) Error() string {
 	return "invalid path"
 }
 
+// ErrServerRouteNotFound is an error that can be returned by a server.
+type ErrServerRouteNotFound struct {
+	Path string
+}
+
+// Error implements the error interface.
+func (e ErrServerRouteNotFound) Error() string {
+	return fmt.Sprintf("no route matches path '%s'", e.Path)
+}
+
 // ErrServerContentTypeMissing is an error that can be returned by a server.
 type ErrServerContentTypeMissing = ErrClientContentTypeMissing
 
@@ -108,6 +119,26 @@ func (e ErrServerTransportHeaderInvalidInterleavedIDs) Error() string {
 	return "invalid interleaved IDs"
 }
 
+// ErrServerScaleHeaderInvalid is an error that can be returned by a server.
+type ErrServerScaleHeaderInvalid struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrServerScaleHeaderInvalid) Error() string {
+	return fmt.Sprintf("invalid scale header: %v", e.Err)
+}
+
+// ErrServerSpeedHeaderInvalid is an error that can be returned by a server.
+type ErrServerSpeedHeaderInvalid struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrServerSpeedHeaderInvalid) Error() string {
+	return fmt.Sprintf("invalid speed header: %v", e.Err)
+}
+
 // ErrServerTransportHeaderInterleavedIDsInUse is an error that can be returned by a server.
 type ErrServerTransportHeaderInterleavedIDsInUse struct{}
 
@@ -156,6 +187,106 @@ func (e ErrServerLinkedToOtherSession) Error() string {
 	return "connection is linked to another session"
 }
 
+// ErrServerShuttingDown is an error that can be returned by a server.
+type ErrServerShuttingDown struct{}
+
+// Error implements the error interface.
+func (e ErrServerShuttingDown) Error() string {
+	return "server is shutting down"
+}
+
+// ErrServerSessionsLimitReached is an error that can be returned by a server.
+type ErrServerSessionsLimitReached struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrServerSessionsLimitReached) Error() string {
+	return fmt.Sprintf("maximum number of sessions reached (%d)", e.Max)
+}
+
+// ErrServerSessionsPerIPLimitReached is an error that can be returned by a server.
+type ErrServerSessionsPerIPLimitReached struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrServerSessionsPerIPLimitReached) Error() string {
+	return fmt.Sprintf("maximum number of sessions per IP reached (%d)", e.Max)
+}
+
+// ErrServerInitialSessionsPerIPLimitReached is an error that can be returned by a server.
+type ErrServerInitialSessionsPerIPLimitReached struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrServerInitialSessionsPerIPLimitReached) Error() string {
+	return fmt.Sprintf("maximum number of sessions per IP that are not playing or recording reached (%d)", e.Max)
+}
+
+// ErrServerRequestRateLimitReached is an error that can be returned by a server.
+type ErrServerRequestRateLimitReached struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrServerRequestRateLimitReached) Error() string {
+	return fmt.Sprintf("maximum number of requests per second reached (%d)", e.Max)
+}
+
+// ErrServerHeaderSizeLimitReached is an error that can be returned by a server.
+type ErrServerHeaderSizeLimitReached struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrServerHeaderSizeLimitReached) Error() string {
+	return fmt.Sprintf("header size (%d) exceeds maximum (%d)", e.Size, e.Max)
+}
+
+// ErrServerHeaderCountLimitReached is an error that can be returned by a server.
+type ErrServerHeaderCountLimitReached struct {
+	Count int
+	Max   int
+}
+
+// Error implements the error interface.
+func (e ErrServerHeaderCountLimitReached) Error() string {
+	return fmt.Sprintf("header count (%d) exceeds maximum (%d)", e.Count, e.Max)
+}
+
+// ErrServerReadersLimitReached is an error that can be returned by a server.
+type ErrServerReadersLimitReached struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrServerReadersLimitReached) Error() string {
+	return fmt.Sprintf("maximum number of readers of the stream reached (%d)", e.Max)
+}
+
+// ErrServerBitrateLimitReached is an error that can be returned by a server.
+type ErrServerBitrateLimitReached struct {
+	Max uint64
+}
+
+// Error implements the error interface.
+func (e ErrServerBitrateLimitReached) Error() string {
+	return fmt.Sprintf("maximum outbound bitrate reached (%d bit/s)", e.Max)
+}
+
+// ErrServerAdmissionRejected is an error that can be returned by a server.
+type ErrServerAdmissionRejected struct {
+	StatusCode base.StatusCode
+}
+
+// Error implements the error interface.
+func (e ErrServerAdmissionRejected) Error() string {
+	return fmt.Sprintf("session rejected by admission (%d)", e.StatusCode)
+}
+
 // ErrServerSessionTornDown is an error that can be returned by a server.
 type ErrServerSessionTornDown struct {
 	Author net.Addr
@@ -234,12 +365,52 @@ func (e ErrServerUnexpectedResponse) Error() string {
 // ErrServerWriteQueueFull is an error that can be returned by a server.
 type ErrServerWriteQueueFull = ErrClientWriteQueueFull
 
+// ErrServerWriteQueueOverflow is an error that can be returned by a server.
+type ErrServerWriteQueueOverflow struct{}
+
+// Error implements the error interface.
+func (e ErrServerWriteQueueOverflow) Error() string {
+	return "write queue is full, closing the session"
+}
+
+// ErrServerRecordQueueFull is an error that can be returned by a server.
+type ErrServerRecordQueueFull struct{}
+
+// Error implements the error interface.
+func (e ErrServerRecordQueueFull) Error() string {
+	return "queue of received frames is full, closing the connection"
+}
+
+// ErrServerRecordPacketTooBig is an error that can be returned by a server.
+type ErrServerRecordPacketTooBig struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrServerRecordPacketTooBig) Error() string {
+	return fmt.Sprintf("received packet size (%d) is greater than maximum allowed (%d)", e.Size, e.Max)
+}
+
+// ErrServerRecordReorderBufferFull is an error that can be returned by a server.
+type ErrServerRecordReorderBufferFull struct {
+	Skipped int
+}
+
+// Error implements the error interface.
+func (e ErrServerRecordReorderBufferFull) Error() string {
+	return fmt.Sprintf("reorder buffer is full, %d missing packets have been skipped", e.Skipped)
+}
+
 // ErrServerRTPPacketsLost is an error that can be returned by a server.
 type ErrServerRTPPacketsLost = ErrClientRTPPacketsLost
 
 // ErrServerRTPPacketUnknownPayloadType is an error that can be returned by a server.
 type ErrServerRTPPacketUnknownPayloadType = ErrClientRTPPacketUnknownPayloadType
 
+// ErrServerRTPPacketPayloadTypeNotInMedia is an error that can be returned by a server.
+type ErrServerRTPPacketPayloadTypeNotInMedia = ErrClientRTPPacketPayloadTypeNotInMedia
+
 // ErrServerRTCPPacketTooBig is an error that can be returned by a server.
 type ErrServerRTCPPacketTooBig = ErrClientRTCPPacketTooBig
 
@@ -266,3 +437,102 @@ func (ErrServerPathNoSlash) Error() string {
 		"This typically happens when VLC fails a request, and then switches to an " +
 		"unsupported RTSP dialect"
 }
+
+// ErrServerTunnelSessionCookieMissing is an error that can be returned by a server.
+type ErrServerTunnelSessionCookieMissing struct{}
+
+// Error implements the error interface.
+func (ErrServerTunnelSessionCookieMissing) Error() string {
+	return "x-sessioncookie header is missing"
+}
+
+// ErrServerTunnelSessionCookieInUse is an error that can be returned by a server.
+type ErrServerTunnelSessionCookieInUse struct{}
+
+// Error implements the error interface.
+func (ErrServerTunnelSessionCookieInUse) Error() string {
+	return "x-sessioncookie is already in use by another tunnel"
+}
+
+// ErrServerTunnelGetNotFound is an error that can be returned by a server.
+type ErrServerTunnelGetNotFound struct{}
+
+// Error implements the error interface.
+func (ErrServerTunnelGetNotFound) Error() string {
+	return "GET connection of the tunnel not found"
+}
+
+// ErrServerTunnelPostTimeout is an error that can be returned by a server.
+type ErrServerTunnelPostTimeout struct{}
+
+// Error implements the error interface.
+func (ErrServerTunnelPostTimeout) Error() string {
+	return "POST connection of the tunnel not received"
+}
+
+// ErrServerClientCertificateRejected is an error that can be returned by a server.
+type ErrServerClientCertificateRejected struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrServerClientCertificateRejected) Error() string {
+	return fmt.Sprintf("client certificate rejected: %v", e.Err)
+}
+
+// ErrServerInterleavedFramesNotAvailable is an error that can be returned by a server.
+type ErrServerInterleavedFramesNotAvailable = ErrClientInterleavedFramesNotAvailable
+
+// ErrServerInterleavedChannelInUse is an error that can be returned by a server.
+type ErrServerInterleavedChannelInUse = ErrClientInterleavedChannelInUse
+
+// ErrServerParametersInvalid is an error that can be returned by a server.
+type ErrServerParametersInvalid = ErrClientParametersInvalid
+
+// ErrServerStreamReadersMoved is an error that can be returned by a server.
+type ErrServerStreamReadersMoved struct{}
+
+// Error implements the error interface.
+func (ErrServerStreamReadersMoved) Error() string {
+	return "readers of the stream are being moved to another stream"
+}
+
+// ErrServerStreamUpdateMediasChanged is an error that can be returned by a server.
+type ErrServerStreamUpdateMediasChanged struct{}
+
+// Error implements the error interface.
+func (ErrServerStreamUpdateMediasChanged) Error() string {
+	return "updated stream description must contain the same medias of the previous one"
+}
+
+// ErrServerRequestTimedOut is an error that can be returned by a server.
+type ErrServerRequestTimedOut struct{}
+
+// Error implements the error interface.
+func (ErrServerRequestTimedOut) Error() string {
+	return "timed out while waiting for the response of the client"
+}
+
+// ErrServerSessionNoConnection is an error that can be returned by a server.
+type ErrServerSessionNoConnection struct{}
+
+// Error implements the error interface.
+func (ErrServerSessionNoConnection) Error() string {
+	return "session is not associated with any connection"
+}
+
+// ErrServerBadStatusCode is an error that can be returned by a server.
+type ErrServerBadStatusCode = ErrClientBadStatusCode
+
+// ErrServerSSRCUnknown is an error that can be returned by a server.
+type ErrServerSSRCUnknown = ErrClientSSRCUnknown
+
+// ErrServerRenditionNotFound is an error that can be returned by a server.
+type ErrServerRenditionNotFound struct {
+	Name string
+}
+
+// Error implements the error interface.
+func (e ErrServerRenditionNotFound) Error() string {
+	return fmt.Sprintf("rendition '%s' not found", e.Name)
+}
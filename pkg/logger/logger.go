@@ -0,0 +1,163 @@
+// Package logger contains a structured logger that is fed by clients and servers.
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Level is the level of an entry.
+type Level int
+
+// levels.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String implements fmt.Stringer.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "UNKNOWN"
+}
+
+// Logger is the interface implemented by loggers.
+// Entries are made of a message and of key-value pairs, whose keys are strings.
+// It can be implemented in order to bridge entries to log/slog, zap, zerolog or logrus.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+type discard struct{}
+
+func (discard) Debug(string, ...interface{}) {}
+
+func (discard) Info(string, ...interface{}) {}
+
+func (discard) Warn(string, ...interface{}) {}
+
+func (discard) Error(string, ...interface{}) {}
+
+// Discard is a Logger that discards all entries.
+var Discard Logger = discard{}
+
+type standard struct {
+	l     *log.Logger
+	level Level
+}
+
+// New allocates a Logger that writes entries whose level is greater or equal than the given one
+// into a log.Logger, in the "LEVEL message key=value" format.
+func New(l *log.Logger, level Level) Logger {
+	return &standard{
+		l:     l,
+		level: level,
+	}
+}
+
+// Standard is a Logger that writes warnings and errors with the standard log package.
+var Standard = New(log.Default(), LevelWarn)
+
+func (s *standard) write(level Level, msg string, keysAndValues []interface{}) {
+	if level < s.level {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		b.WriteByte(' ')
+		b.WriteString(fmt.Sprint(keysAndValues[i]))
+		b.WriteByte('=')
+
+		if (i + 1) < len(keysAndValues) {
+			v := fmt.Sprint(keysAndValues[i+1])
+			if v == "" || strings.ContainsAny(v, " \t\r\n\"=") {
+				v = strconv.Quote(v)
+			}
+			b.WriteString(v)
+		}
+	}
+
+	s.l.Print(b.String())
+}
+
+func (s *standard) Debug(msg string, keysAndValues ...interface{}) {
+	s.write(LevelDebug, msg, keysAndValues)
+}
+
+func (s *standard) Info(msg string, keysAndValues ...interface{}) {
+	s.write(LevelInfo, msg, keysAndValues)
+}
+
+func (s *standard) Warn(msg string, keysAndValues ...interface{}) {
+	s.write(LevelWarn, msg, keysAndValues)
+}
+
+func (s *standard) Error(msg string, keysAndValues ...interface{}) {
+	s.write(LevelError, msg, keysAndValues)
+}
+
+type with struct {
+	l             Logger
+	keysAndValues []interface{}
+}
+
+// With returns a Logger that adds the given key-value pairs to all entries,
+// in order to provide context, like the connection or the session that generated them.
+func With(l Logger, keysAndValues ...interface{}) Logger {
+	if l == Discard {
+		return l
+	}
+
+	if w, ok := l.(*with); ok {
+		return &with{
+			l:             w.l,
+			keysAndValues: w.merge(keysAndValues),
+		}
+	}
+
+	return &with{
+		l:             l,
+		keysAndValues: keysAndValues,
+	}
+}
+
+func (w *with) merge(keysAndValues []interface{}) []interface{} {
+	return append(append([]interface{}(nil), w.keysAndValues...), keysAndValues...)
+}
+
+func (w *with) Debug(msg string, keysAndValues ...interface{}) {
+	w.l.Debug(msg, w.merge(keysAndValues)...)
+}
+
+func (w *with) Info(msg string, keysAndValues ...interface{}) {
+	w.l.Info(msg, w.merge(keysAndValues)...)
+}
+
+func (w *with) Warn(msg string, keysAndValues ...interface{}) {
+	w.l.Warn(msg, w.merge(keysAndValues)...)
+}
+
+func (w *with) Error(msg string, keysAndValues ...interface{}) {
+	w.l.Error(msg, w.merge(keysAndValues)...)
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandard(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(log.New(&buf, "", 0), LevelInfo)
+
+	l.Debug("not written")
+	l.Info("request sent", "method", "DESCRIBE", "cseq", 2)
+	l.Warn("switching transport", "reason", "no UDP packets received")
+	l.Error("empty", "value", "")
+
+	require.Equal(t, "INFO request sent method=DESCRIBE cseq=2\n"+
+		"WARN switching transport reason=\"no UDP packets received\"\n"+
+		"ERROR empty value=\"\"\n", buf.String())
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := With(New(log.New(&buf, "", 0), LevelDebug), "conn", "127.0.0.1:3456")
+	l = With(l, "session", 1)
+
+	l.Debug("transport negotiated", "transport", "TCP")
+
+	require.Equal(t, "DEBUG transport negotiated conn=127.0.0.1:3456 session=1 transport=TCP\n", buf.String())
+}
+
+func TestWithDiscard(t *testing.T) {
+	require.Equal(t, Discard, With(Discard, "conn", "127.0.0.1:3456"))
+}
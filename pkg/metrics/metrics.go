@@ -0,0 +1,88 @@
+// Package metrics contains a metrics collector that is fed by clients, servers and server streams.
+package metrics
+
+// names of the metrics fed by clients, servers and server streams.
+const (
+	// gauge, number of active sessions.
+	// Labels: role.
+	NameSessions = "rtsp_sessions"
+
+	// counter, number of received RTP packets.
+	// Labels: role, transport.
+	NamePacketsReceived = "rtsp_rtp_packets_received_total"
+
+	// counter, number of sent RTP packets.
+	// Labels: role, transport.
+	NamePacketsSent = "rtsp_rtp_packets_sent_total"
+
+	// counter, number of lost RTP packets.
+	// Labels: role, session, path, media, payload_type.
+	NamePacketsLost = "rtsp_rtp_packets_lost_total"
+
+	// gauge, interarrival jitter of received RTP packets, computed when RTCP receiver reports are generated.
+	// Labels: role, session, path, media, payload_type.
+	NameJitter = "rtsp_rtp_jitter_seconds"
+
+	// counter, number of received bytes.
+	// Labels: role, transport.
+	NameBytesReceived = "rtsp_bytes_received_total"
+
+	// counter, number of sent bytes.
+	// Labels: role, transport.
+	NameBytesSent = "rtsp_bytes_sent_total"
+)
+
+// Labels are the dimensions of a metric.
+type Labels map[string]string
+
+// Counter is a metric that can only increase.
+type Counter interface {
+	Add(delta uint64)
+}
+
+// Gauge is a metric that can increase and decrease.
+type Gauge interface {
+	Set(v float64)
+	Add(delta float64)
+}
+
+// Collector is the interface implemented by metrics collectors.
+// Counters and gauges are requested once, when a session or a media is set up,
+// and are then updated without further lookups.
+// It can be implemented in order to bridge metrics to Prometheus, OpenTelemetry or expvar.
+type Collector interface {
+	// returns the counter with given name and labels.
+	Counter(name string, labels Labels) Counter
+
+	// returns the gauge with given name and labels.
+	Gauge(name string, labels Labels) Gauge
+
+	// removes the counter or gauge with given name and labels.
+	// It is called when the session that fed the metric is closed.
+	Delete(name string, labels Labels)
+}
+
+type discardCounter struct{}
+
+func (discardCounter) Add(uint64) {}
+
+type discardGauge struct{}
+
+func (discardGauge) Set(float64) {}
+
+func (discardGauge) Add(float64) {}
+
+type discard struct{}
+
+func (discard) Counter(string, Labels) Counter {
+	return discardCounter{}
+}
+
+func (discard) Gauge(string, Labels) Gauge {
+	return discardGauge{}
+}
+
+func (discard) Delete(string, Labels) {}
+
+// Discard is a Collector that discards all metrics.
+var Discard Collector = discard{}
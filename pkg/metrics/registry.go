@@ -0,0 +1,206 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type registryCounter struct {
+	v uint64
+}
+
+func (c *registryCounter) Add(delta uint64) {
+	atomic.AddUint64(&c.v, delta)
+}
+
+func (c *registryCounter) value() float64 {
+	return float64(atomic.LoadUint64(&c.v))
+}
+
+type registryGauge struct {
+	bits uint64
+}
+
+func (g *registryGauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+func (g *registryGauge) Add(delta float64) {
+	for {
+		cur := atomic.LoadUint64(&g.bits)
+		next := math.Float64bits(math.Float64frombits(cur) + delta)
+		if atomic.CompareAndSwapUint64(&g.bits, cur, next) {
+			return
+		}
+	}
+}
+
+func (g *registryGauge) value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+type registryMetric interface {
+	value() float64
+}
+
+type registryFamily struct {
+	typ     string
+	metrics map[string]registryMetric
+}
+
+func marshalLabels(labels Labels) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	for i, k := range keys {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(k + "=" + strconv.Quote(labels[k]))
+	}
+
+	return buf.String()
+}
+
+// Registry is a Collector that stores metrics in memory
+// and exports them in the Prometheus text format.
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]*registryFamily
+}
+
+func (r *Registry) get(typ string, name string, labels Labels, create func() registryMetric) registryMetric {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.families == nil {
+		r.families = make(map[string]*registryFamily)
+	}
+
+	fam, ok := r.families[name]
+	if !ok {
+		fam = &registryFamily{
+			typ:     typ,
+			metrics: make(map[string]registryMetric),
+		}
+		r.families[name] = fam
+	} else if fam.typ != typ {
+		panic(fmt.Errorf("metric %s is a %s", name, fam.typ))
+	}
+
+	key := marshalLabels(labels)
+
+	m, ok := fam.metrics[key]
+	if !ok {
+		m = create()
+		fam.metrics[key] = m
+	}
+
+	return m
+}
+
+// Counter implements Collector.
+func (r *Registry) Counter(name string, labels Labels) Counter {
+	return r.get("counter", name, labels, func() registryMetric {
+		return &registryCounter{}
+	}).(*registryCounter)
+}
+
+// Gauge implements Collector.
+func (r *Registry) Gauge(name string, labels Labels) Gauge {
+	return r.get("gauge", name, labels, func() registryMetric {
+		return &registryGauge{}
+	}).(*registryGauge)
+}
+
+// Delete implements Collector.
+func (r *Registry) Delete(name string, labels Labels) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	fam, ok := r.families[name]
+	if !ok {
+		return
+	}
+
+	delete(fam.metrics, marshalLabels(labels))
+
+	if len(fam.metrics) == 0 {
+		delete(r.families, name)
+	}
+}
+
+// Value returns the current value of a metric.
+// It returns false if the metric does not exist.
+func (r *Registry) Value(name string, labels Labels) (float64, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	fam, ok := r.families[name]
+	if !ok {
+		return 0, false
+	}
+
+	m, ok := fam.metrics[marshalLabels(labels)]
+	if !ok {
+		return 0, false
+	}
+
+	return m.value(), true
+}
+
+// WritePrometheus writes all metrics in the Prometheus text format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mutex.Lock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+
+	for _, name := range names {
+		fam := r.families[name]
+		buf.WriteString("# TYPE " + name + " " + fam.typ + "\n")
+
+		keys := make([]string, 0, len(fam.metrics))
+		for key := range fam.metrics {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			buf.WriteString(name)
+			if key != "" {
+				buf.WriteString("{" + key + "}")
+			}
+			buf.WriteString(" " + strconv.FormatFloat(fam.metrics[key].value(), 'g', -1, 64) + "\n")
+		}
+	}
+
+	r.mutex.Unlock()
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ServeHTTP implements http.Handler.
+// It writes all metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WritePrometheus(w) //nolint:errcheck
+}
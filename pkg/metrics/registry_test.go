@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := &Registry{}
+
+	c := r.Counter(NamePacketsReceived, Labels{"role": "server", "transport": "UDP"})
+	c.Add(3)
+	c.Add(2)
+
+	// the same handle is returned for the same name and labels
+	r.Counter(NamePacketsReceived, Labels{"transport": "UDP", "role": "server"}).Add(1)
+
+	g := r.Gauge(NameSessions, Labels{"role": "server"})
+	g.Add(2)
+	g.Add(-1)
+
+	r.Gauge(NameJitter, Labels{"role": "client", "path": "/stream"}).Set(0.25)
+
+	v, ok := r.Value(NamePacketsReceived, Labels{"role": "server", "transport": "UDP"})
+	require.True(t, ok)
+	require.Equal(t, float64(6), v)
+
+	v, ok = r.Value(NameSessions, Labels{"role": "server"})
+	require.True(t, ok)
+	require.Equal(t, float64(1), v)
+
+	_, ok = r.Value(NameSessions, Labels{"role": "client"})
+	require.False(t, ok)
+
+	var buf bytes.Buffer
+	err := r.WritePrometheus(&buf)
+	require.NoError(t, err)
+	require.Equal(t, "# TYPE rtsp_rtp_jitter_seconds gauge\n"+
+		"rtsp_rtp_jitter_seconds{path=\"/stream\",role=\"client\"} 0.25\n"+
+		"# TYPE rtsp_rtp_packets_received_total counter\n"+
+		"rtsp_rtp_packets_received_total{role=\"server\",transport=\"UDP\"} 6\n"+
+		"# TYPE rtsp_sessions gauge\n"+
+		"rtsp_sessions{role=\"server\"} 1\n", buf.String())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, buf.String(), w.Body.String())
+}
+
+func TestRegistryDelete(t *testing.T) {
+	r := &Registry{}
+
+	r.Gauge(NameJitter, Labels{"session": "1"}).Set(0.25)
+	r.Gauge(NameJitter, Labels{"session": "2"}).Set(0.5)
+
+	r.Delete(NameJitter, Labels{"session": "1"})
+
+	_, ok := r.Value(NameJitter, Labels{"session": "1"})
+	require.False(t, ok)
+
+	v, ok := r.Value(NameJitter, Labels{"session": "2"})
+	require.True(t, ok)
+	require.Equal(t, 0.5, v)
+
+	r.Delete(NameJitter, Labels{"session": "2"})
+	r.Delete("missing", nil)
+
+	var buf bytes.Buffer
+	err := r.WritePrometheus(&buf)
+	require.NoError(t, err)
+	require.Equal(t, "", buf.String())
+}
+
+func TestRegistryTypeMismatch(t *testing.T) {
+	r := &Registry{}
+	r.Counter("test", nil)
+
+	require.Panics(t, func() {
+		r.Gauge("test", nil)
+	})
+}
+
+func TestDiscard(t *testing.T) {
+	Discard.Counter(NamePacketsSent, nil).Add(1)
+	Discard.Gauge(NameSessions, nil).Add(1)
+	Discard.Delete(NameSessions, nil)
+}
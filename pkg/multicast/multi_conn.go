@@ -28,6 +28,20 @@ func NewMultiConn(
 	readOnly bool,
 	listenPacket func(network, address string) (net.PacketConn, error),
 ) (Conn, error) {
+	return NewMultiConnWithOptions(address, readOnly, MultiConnOptions{}, listenPacket)
+}
+
+// NewMultiConnWithOptions allocates a MultiConn with options.
+func NewMultiConnWithOptions(
+	address string,
+	readOnly bool,
+	opts MultiConnOptions,
+	listenPacket func(network, address string) (net.PacketConn, error),
+) (Conn, error) {
+	if opts.TTL == 0 {
+		opts.TTL = multicastTTL
+	}
+
 	addr, err := net.ResolveUDPAddr("udp4", address)
 	if err != nil {
 		return nil, err
@@ -97,7 +111,7 @@ func NewMultiConn(
 				return nil, err
 			}
 
-			err = writeConnIP.SetMulticastTTL(multicastTTL)
+			err = writeConnIP.SetMulticastTTL(opts.TTL)
 			if err != nil {
 				for j := 0; j < i; j++ {
 					writeConns[j].Close() //nolint:errcheck
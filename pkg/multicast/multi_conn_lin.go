@@ -25,8 +25,22 @@ type MultiConn struct {
 func NewMultiConn(
 	address string,
 	readOnly bool,
+	listenPacket func(network, address string) (net.PacketConn, error),
+) (Conn, error) {
+	return NewMultiConnWithOptions(address, readOnly, MultiConnOptions{}, listenPacket)
+}
+
+// NewMultiConnWithOptions allocates a MultiConn with options.
+func NewMultiConnWithOptions(
+	address string,
+	readOnly bool,
+	opts MultiConnOptions,
 	_ func(network, address string) (net.PacketConn, error),
 ) (Conn, error) {
+	if opts.TTL == 0 {
+		opts.TTL = multicastTTL
+	}
+
 	addr, err := net.ResolveUDPAddr("udp4", address)
 	if err != nil {
 		return nil, err
@@ -137,7 +151,7 @@ func NewMultiConn(
 				return nil, err
 			}
 
-			err = syscall.SetsockoptInt(writeSock, syscall.IPPROTO_IP, syscall.IP_MULTICAST_TTL, multicastTTL)
+			err = syscall.SetsockoptInt(writeSock, syscall.IPPROTO_IP, syscall.IP_MULTICAST_TTL, opts.TTL)
 			if err != nil {
 				syscall.Close(writeSock) //nolint:errcheck
 				for j := 0; j < i; j++ {
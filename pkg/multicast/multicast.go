@@ -12,6 +12,25 @@ type Conn interface {
 	SetReadBuffer(int) error
 }
 
+// SingleConnOptions contains options of a SingleConn.
+type SingleConnOptions struct {
+	// source of the multicast stream (optional).
+	// When set, a source-specific join (IGMPv3) is performed.
+	// If the system doesn't support it, a standard join is performed instead.
+	Source net.IP
+
+	// TTL of outgoing packets (optional).
+	// It defaults to 16.
+	TTL int
+}
+
+// MultiConnOptions contains options of a MultiConn.
+type MultiConnOptions struct {
+	// TTL of outgoing packets (optional).
+	// It defaults to 16.
+	TTL int
+}
+
 // InterfaceForSource returns a multicast-capable interface that can communicate with given IP.
 func InterfaceForSource(ip net.IP) (*net.Interface, error) {
 	if ip.Equal(net.ParseIP("127.0.0.1")) {
@@ -16,6 +16,19 @@ const (
 	multicastTTL = 16
 )
 
+// joinGroup joins a multicast group.
+// When source is provided, a source-specific join is attempted first.
+func joinGroup(connIP *ipv4.PacketConn, intf *net.Interface, group net.IP, source net.IP) error {
+	if source != nil {
+		err := connIP.JoinSourceSpecificGroup(intf, &net.UDPAddr{IP: group}, &net.UDPAddr{IP: source})
+		if err == nil {
+			return nil
+		}
+	}
+
+	return connIP.JoinGroup(intf, &net.UDPAddr{IP: group})
+}
+
 // SingleConn is a multicast connection
 // that works on a single interface.
 type SingleConn struct {
@@ -30,6 +43,20 @@ func NewSingleConn(
 	address string,
 	listenPacket func(network, address string) (net.PacketConn, error),
 ) (Conn, error) {
+	return NewSingleConnWithOptions(intf, address, SingleConnOptions{}, listenPacket)
+}
+
+// NewSingleConnWithOptions allocates a SingleConn with options.
+func NewSingleConnWithOptions(
+	intf *net.Interface,
+	address string,
+	opts SingleConnOptions,
+	listenPacket func(network, address string) (net.PacketConn, error),
+) (Conn, error) {
+	if opts.TTL == 0 {
+		opts.TTL = multicastTTL
+	}
+
 	addr, err := net.ResolveUDPAddr("udp4", address)
 	if err != nil {
 		return nil, err
@@ -43,7 +70,7 @@ func NewSingleConn(
 
 	connIP := ipv4.NewPacketConn(conn)
 
-	err = connIP.JoinGroup(intf, &net.UDPAddr{IP: addr.IP})
+	err = joinGroup(connIP, intf, addr.IP, opts.Source)
 	if err != nil {
 		conn.Close() //nolint:errcheck
 		return nil, err
@@ -55,7 +82,7 @@ func NewSingleConn(
 		return nil, err
 	}
 
-	err = connIP.SetMulticastTTL(multicastTTL)
+	err = connIP.SetMulticastTTL(opts.TTL)
 	if err != nil {
 		conn.Close() //nolint:errcheck
 		return nil, err
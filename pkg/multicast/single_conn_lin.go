@@ -9,6 +9,8 @@ import (
 	"os"
 	"syscall"
 	"time"
+
+	"golang.org/x/net/ipv4"
 )
 
 const (
@@ -54,8 +56,22 @@ type SingleConn struct {
 func NewSingleConn(
 	intf *net.Interface,
 	address string,
+	listenPacket func(network, address string) (net.PacketConn, error),
+) (Conn, error) {
+	return NewSingleConnWithOptions(intf, address, SingleConnOptions{}, listenPacket)
+}
+
+// NewSingleConnWithOptions allocates a SingleConn with options.
+func NewSingleConnWithOptions(
+	intf *net.Interface,
+	address string,
+	opts SingleConnOptions,
 	_ func(network, address string) (net.PacketConn, error),
 ) (Conn, error) {
+	if opts.TTL == 0 {
+		opts.TTL = multicastTTL
+	}
+
 	addr, err := net.ResolveUDPAddr("udp4", address)
 	if err != nil {
 		return nil, err
@@ -87,20 +103,6 @@ func NewSingleConn(
 		return nil, err
 	}
 
-	var mreq syscall.IPMreq
-	copy(mreq.Multiaddr[:], addr.IP.To4())
-	err = setIPMreqInterface(&mreq, intf)
-	if err != nil {
-		syscall.Close(sock) //nolint:errcheck
-		return nil, err
-	}
-
-	err = syscall.SetsockoptIPMreq(sock, syscall.IPPROTO_IP, syscall.IP_ADD_MEMBERSHIP, &mreq)
-	if err != nil {
-		syscall.Close(sock) //nolint:errcheck
-		return nil, err
-	}
-
 	var mreqn syscall.IPMreqn
 	mreqn.Ifindex = int32(intf.Index)
 
@@ -110,7 +112,7 @@ func NewSingleConn(
 		return nil, err
 	}
 
-	err = syscall.SetsockoptInt(sock, syscall.IPPROTO_IP, syscall.IP_MULTICAST_TTL, multicastTTL)
+	err = syscall.SetsockoptInt(sock, syscall.IPPROTO_IP, syscall.IP_MULTICAST_TTL, opts.TTL)
 	if err != nil {
 		syscall.Close(sock) //nolint:errcheck
 		return nil, err
@@ -123,6 +125,32 @@ func NewSingleConn(
 		return nil, err
 	}
 
+	// source-specific join (IGMPv3)
+	sourceJoined := false
+	if opts.Source != nil {
+		err = ipv4.NewPacketConn(conn).JoinSourceSpecificGroup(intf,
+			&net.UDPAddr{IP: addr.IP}, &net.UDPAddr{IP: opts.Source})
+		sourceJoined = (err == nil)
+	}
+
+	if !sourceJoined {
+		var mreq syscall.IPMreq
+		copy(mreq.Multiaddr[:], addr.IP.To4())
+		err = setIPMreqInterface(&mreq, intf)
+		if err != nil {
+			conn.Close()
+			file.Close()
+			return nil, err
+		}
+
+		err = syscall.SetsockoptIPMreq(int(file.Fd()), syscall.IPPROTO_IP, syscall.IP_ADD_MEMBERSHIP, &mreq)
+		if err != nil {
+			conn.Close()
+			file.Close()
+			return nil, err
+		}
+	}
+
 	return &SingleConn{
 		addr: addr,
 		file: file,
@@ -171,3 +199,8 @@ func (c *SingleConn) WriteTo(b []byte, addr net.Addr) (int, error) {
 func (c *SingleConn) ReadFrom(b []byte) (int, net.Addr, error) {
 	return c.conn.ReadFrom(b)
 }
+
+// SyscallConn implements syscall.Conn.
+func (c *SingleConn) SyscallConn() (syscall.RawConn, error) {
+	return c.file.SyscallConn()
+}
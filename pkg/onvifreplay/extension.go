@@ -0,0 +1,103 @@
+// Package onvifreplay contains utilities to deal with the ONVIF replay extension.
+// Specification: ONVIF Streaming Specification, section 6
+package onvifreplay
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// ExtensionProfile is the profile of the RTP header extension.
+	ExtensionProfile = 0xABAC
+
+	// RequireTag is the tag that must be inserted into the Require header.
+	RequireTag = "onvif-replay"
+
+	extensionSize = 12
+)
+
+// seconds since 1st January 1900
+// higher 32 bits are the integer part, lower 32 bits are the fractional part
+func ntpTimeToGo(v uint64) time.Time {
+	secs := int64(v>>32) - 2208988800
+	nanos := int64(((v & 0xFFFFFFFF) * 1000000000) >> 32)
+	return time.Unix(secs, nanos)
+}
+
+func ntpTimeFromGo(v time.Time) uint64 {
+	secs := uint64(v.Unix() + 2208988800)
+	frac := (uint64(v.Nanosecond()) << 32) / 1000000000
+	return secs<<32 | frac
+}
+
+// Extension is the RTP header extension that carries the absolute time of a frame.
+type Extension struct {
+	// absolute time of the frame.
+	NTPTime time.Time
+
+	// whether the frame can be decoded independently (C flag).
+	CleanPoint bool
+
+	// whether the packet is the last one of a contiguous section of the recording (E flag).
+	End bool
+
+	// whether there's a discontinuity with the previous frame (D flag).
+	Discontinuity bool
+
+	// whether the packet is the last one of the playback range (T flag).
+	Terminate bool
+
+	// lower 8 bits of the CSeq of the PLAY request that started the transmission.
+	CSeq uint8
+}
+
+// Unmarshal decodes the extension from a RTP packet.
+func (e *Extension) Unmarshal(pkt *rtp.Packet) error {
+	if !pkt.Header.Extension || pkt.Header.ExtensionProfile != ExtensionProfile {
+		return fmt.Errorf("ONVIF replay extension not found")
+	}
+
+	buf := pkt.Header.GetExtension(0)
+	if len(buf) < extensionSize {
+		return fmt.Errorf("invalid ONVIF replay extension size: %d", len(buf))
+	}
+
+	e.NTPTime = ntpTimeToGo(binary.BigEndian.Uint64(buf))
+	e.CleanPoint = (buf[8] & 0x80) != 0
+	e.End = (buf[8] & 0x40) != 0
+	e.Discontinuity = (buf[8] & 0x20) != 0
+	e.Terminate = (buf[8] & 0x10) != 0
+	e.CSeq = buf[9]
+
+	return nil
+}
+
+// Marshal encodes the extension into a RTP packet.
+func (e Extension) Marshal(pkt *rtp.Packet) error {
+	buf := make([]byte, extensionSize)
+	binary.BigEndian.PutUint64(buf, ntpTimeFromGo(e.NTPTime))
+
+	if e.CleanPoint {
+		buf[8] |= 0x80
+	}
+	if e.End {
+		buf[8] |= 0x40
+	}
+	if e.Discontinuity {
+		buf[8] |= 0x20
+	}
+	if e.Terminate {
+		buf[8] |= 0x10
+	}
+	buf[9] = e.CSeq
+
+	pkt.Header.Extension = true
+	pkt.Header.ExtensionProfile = ExtensionProfile
+	pkt.Header.Extensions = nil
+
+	return pkt.Header.SetExtension(0, buf)
+}
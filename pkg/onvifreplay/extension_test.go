@@ -0,0 +1,104 @@
+package onvifreplay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+var casesExtension = []struct {
+	name string
+	byts []byte
+	ext  Extension
+}{
+	{
+		"clean point",
+		[]byte{
+			0x90, 0x60, 0x04, 0xd2, 0x00, 0x00, 0x00, 0x00,
+			0x11, 0x22, 0x33, 0x44, 0xab, 0xac, 0x00, 0x03,
+			0xe8, 0x2e, 0xe4, 0x40, 0x80, 0x00, 0x00, 0x00,
+			0x80, 0x05, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		},
+		Extension{
+			NTPTime:    time.Date(2023, 6, 10, 12, 0, 0, 500000000, time.UTC),
+			CleanPoint: true,
+			CSeq:       5,
+		},
+	},
+	{
+		"end and terminate",
+		[]byte{
+			0x90, 0x60, 0x04, 0xd2, 0x00, 0x00, 0x00, 0x00,
+			0x11, 0x22, 0x33, 0x44, 0xab, 0xac, 0x00, 0x03,
+			0xe8, 0x2e, 0xe4, 0x41, 0x00, 0x00, 0x00, 0x00,
+			0x70, 0xff, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		},
+		Extension{
+			NTPTime:       time.Date(2023, 6, 10, 12, 0, 1, 0, time.UTC),
+			End:           true,
+			Discontinuity: true,
+			Terminate:     true,
+			CSeq:          255,
+		},
+	},
+}
+
+func TestExtensionUnmarshal(t *testing.T) {
+	for _, ca := range casesExtension {
+		t.Run(ca.name, func(t *testing.T) {
+			var pkt rtp.Packet
+			err := pkt.Unmarshal(ca.byts)
+			require.NoError(t, err)
+
+			var ext Extension
+			err = ext.Unmarshal(&pkt)
+			require.NoError(t, err)
+			require.True(t, ca.ext.NTPTime.Equal(ext.NTPTime))
+			ext.NTPTime = ca.ext.NTPTime
+			require.Equal(t, ca.ext, ext)
+		})
+	}
+}
+
+func TestExtensionMarshal(t *testing.T) {
+	for _, ca := range casesExtension {
+		t.Run(ca.name, func(t *testing.T) {
+			pkt := rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 1234,
+					SSRC:           0x11223344,
+				},
+				Payload: []byte{1, 2, 3, 4},
+			}
+
+			err := ca.ext.Marshal(&pkt)
+			require.NoError(t, err)
+
+			byts, err := pkt.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, ca.byts, byts)
+		})
+	}
+}
+
+func TestExtensionUnmarshalErrors(t *testing.T) {
+	var ext Extension
+	err := ext.Unmarshal(&rtp.Packet{})
+	require.EqualError(t, err, "ONVIF replay extension not found")
+
+	pkt := rtp.Packet{
+		Header: rtp.Header{
+			Extension:        true,
+			ExtensionProfile: ExtensionProfile,
+		},
+	}
+	err = pkt.Header.SetExtension(0, []byte{1, 2, 3, 4})
+	require.NoError(t, err)
+
+	err = ext.Unmarshal(&pkt)
+	require.EqualError(t, err, "invalid ONVIF replay extension size: 4")
+}
@@ -0,0 +1,35 @@
+package parameters
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names is a list of parameter names.
+// It is the body of GET_PARAMETER requests.
+// Specification: RFC2326, appendix F.
+type Names []string
+
+// Unmarshal decodes Names.
+func (n *Names) Unmarshal(byts []byte) error {
+	*n = nil
+
+	for _, line := range splitLines(byts) {
+		if strings.ContainsAny(line, ": \t") {
+			return fmt.Errorf("invalid parameter name: %v", line)
+		}
+
+		*n = append(*n, line)
+	}
+
+	return nil
+}
+
+// Marshal encodes Names.
+func (n Names) Marshal() []byte {
+	var buf []byte
+	for _, name := range n {
+		buf = append(buf, name+"\r\n"...)
+	}
+	return buf
+}
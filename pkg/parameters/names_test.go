@@ -0,0 +1,50 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesNames = []struct {
+	name string
+	enc  []byte
+	dec  Names
+}{
+	{
+		"single",
+		[]byte("packets_received\r\n"),
+		Names{"packets_received"},
+	},
+	{
+		"multiple",
+		[]byte("packets_received\r\n" +
+			"jitter\r\n"),
+		Names{"packets_received", "jitter"},
+	},
+}
+
+func TestNamesUnmarshal(t *testing.T) {
+	for _, ca := range casesNames {
+		t.Run(ca.name, func(t *testing.T) {
+			var n Names
+			err := n.Unmarshal(ca.enc)
+			require.NoError(t, err)
+			require.Equal(t, ca.dec, n)
+		})
+	}
+}
+
+func TestNamesUnmarshalErrors(t *testing.T) {
+	var n Names
+	err := n.Unmarshal([]byte("packets_received: 10\r\n"))
+	require.EqualError(t, err, "invalid parameter name: packets_received: 10")
+}
+
+func TestNamesMarshal(t *testing.T) {
+	for _, ca := range casesNames {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.enc, ca.dec.Marshal())
+		})
+	}
+}
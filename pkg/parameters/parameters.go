@@ -0,0 +1,68 @@
+// Package parameters contains utilities to encode and decode bodies
+// of GET_PARAMETER and SET_PARAMETER requests and responses.
+package parameters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ContentType is the content type of bodies that contain parameters.
+const ContentType = "text/parameters"
+
+func splitLines(byts []byte) []string {
+	var ret []string
+
+	for _, line := range strings.Split(string(byts), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			ret = append(ret, line)
+		}
+	}
+
+	return ret
+}
+
+// Parameters is a list of parameters with their values.
+// It is the body of SET_PARAMETER requests and GET_PARAMETER responses.
+// Specification: RFC2326, appendix F.
+type Parameters map[string]string
+
+// Unmarshal decodes Parameters.
+func (p *Parameters) Unmarshal(byts []byte) error {
+	*p = make(Parameters)
+
+	for _, line := range splitLines(byts) {
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return fmt.Errorf("invalid parameter: %v", line)
+		}
+
+		name := strings.TrimSpace(line[:i])
+		if name == "" {
+			return fmt.Errorf("invalid parameter: %v", line)
+		}
+
+		(*p)[name] = strings.TrimSpace(line[i+1:])
+	}
+
+	return nil
+}
+
+// Marshal encodes Parameters.
+// Parameters are sorted by name.
+func (p Parameters) Marshal() []byte {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf []byte
+	for _, name := range names {
+		buf = append(buf, name+": "+p[name]+"\r\n"...)
+	}
+
+	return buf
+}
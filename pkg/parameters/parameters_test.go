@@ -0,0 +1,102 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesParameters = []struct {
+	name string
+	enc  []byte
+	dec  Parameters
+}{
+	{
+		"single",
+		[]byte("packets_received: 10\r\n"),
+		Parameters{
+			"packets_received": "10",
+		},
+	},
+	{
+		"multiple",
+		[]byte("jitter: 0.3838\r\n" +
+			"packets_received: 10\r\n"),
+		Parameters{
+			"packets_received": "10",
+			"jitter":           "0.3838",
+		},
+	},
+	{
+		"empty value",
+		[]byte("position: \r\n"),
+		Parameters{
+			"position": "",
+		},
+	},
+}
+
+func TestParametersUnmarshal(t *testing.T) {
+	for _, ca := range casesParameters {
+		t.Run(ca.name, func(t *testing.T) {
+			var p Parameters
+			err := p.Unmarshal(ca.enc)
+			require.NoError(t, err)
+			require.Equal(t, ca.dec, p)
+		})
+	}
+}
+
+func TestParametersUnmarshalLF(t *testing.T) {
+	var p Parameters
+	err := p.Unmarshal([]byte("\npackets_received:10\n  jitter :  0.3838\n\n"))
+	require.NoError(t, err)
+	require.Equal(t, Parameters{
+		"packets_received": "10",
+		"jitter":           "0.3838",
+	}, p)
+}
+
+func TestParametersUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"missing separator",
+			[]byte("packets_received\r\n"),
+			"invalid parameter: packets_received",
+		},
+		{
+			"missing name",
+			[]byte(": 10\r\n"),
+			"invalid parameter: : 10",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var p Parameters
+			err := p.Unmarshal(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestParametersMarshal(t *testing.T) {
+	for _, ca := range casesParameters {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.enc, ca.dec.Marshal())
+		})
+	}
+}
+
+func FuzzParametersUnmarshal(f *testing.F) {
+	for _, ca := range casesParameters {
+		f.Add(ca.enc)
+	}
+
+	f.Fuzz(func(_ *testing.T, b []byte) {
+		var p Parameters
+		p.Unmarshal(b) //nolint:errcheck
+	})
+}
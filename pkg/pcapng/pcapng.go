@@ -0,0 +1,254 @@
+// Package pcapng contains a writer of pcapng files.
+// Specification: https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-02.html
+package pcapng
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	blockTypeSectionHeader     = 0x0A0D0D0A
+	blockTypeInterfaceDesc     = 0x00000001
+	blockTypeEnhancedPacket    = 0x00000006
+	byteOrderMagic             = 0x1A2B3C4D
+	linkTypeRaw                = 101
+	protocolTCP                = 6
+	protocolUDP                = 17
+	ipv4HeaderSize             = 20
+	ipv6HeaderSize             = 40
+	udpHeaderSize              = 8
+	tcpHeaderSize              = 20
+	maxIPPayloadSize           = 65535 - ipv6HeaderSize
+	tcpMaxSegmentPayloadSize   = maxIPPayloadSize - tcpHeaderSize
+	enhancedPacketBlockMinSize = 32
+)
+
+type tcpFlow struct {
+	srcIP   string
+	srcPort int
+	dstIP   string
+	dstPort int
+}
+
+// Writer writes packets into a pcapng file.
+// Packets are encapsulated into synthetic IPv4 or IPv6, UDP and TCP headers,
+// in order to allow network analyzers (i.e. Wireshark) to decode them.
+type Writer struct {
+	// destination.
+	W io.Writer
+
+	ipID   uint16
+	tcpSeq map[tcpFlow]uint32
+}
+
+// Initialize initializes a Writer and writes the header of the file.
+func (w *Writer) Initialize() error {
+	w.tcpSeq = make(map[tcpFlow]uint32)
+
+	shb := make([]byte, 28)
+	binary.LittleEndian.PutUint32(shb[0:], blockTypeSectionHeader)
+	binary.LittleEndian.PutUint32(shb[4:], 28)
+	binary.LittleEndian.PutUint32(shb[8:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[12:], 1) // major version
+	binary.LittleEndian.PutUint16(shb[14:], 0) // minor version
+	binary.LittleEndian.PutUint64(shb[16:], 0xFFFFFFFFFFFFFFFF)
+	binary.LittleEndian.PutUint32(shb[24:], 28)
+
+	idb := make([]byte, 20)
+	binary.LittleEndian.PutUint32(idb[0:], blockTypeInterfaceDesc)
+	binary.LittleEndian.PutUint32(idb[4:], 20)
+	binary.LittleEndian.PutUint16(idb[8:], linkTypeRaw)
+	binary.LittleEndian.PutUint32(idb[12:], 0) // snap length
+	binary.LittleEndian.PutUint32(idb[16:], 20)
+
+	_, err := w.W.Write(append(shb, idb...))
+	return err
+}
+
+// WriteUDP writes a UDP packet.
+func (w *Writer) WriteUDP(ntp time.Time, src *net.UDPAddr, dst *net.UDPAddr, payload []byte) error {
+	if (udpHeaderSize + len(payload)) > maxIPPayloadSize {
+		return fmt.Errorf("payload size (%d) is too big", len(payload))
+	}
+
+	udp := make([]byte, udpHeaderSize+len(payload))
+	binary.BigEndian.PutUint16(udp[0:], uint16(src.Port))
+	binary.BigEndian.PutUint16(udp[2:], uint16(dst.Port))
+	binary.BigEndian.PutUint16(udp[4:], uint16(len(udp)))
+	copy(udp[udpHeaderSize:], payload)
+
+	return w.writeIP(ntp, src.IP, dst.IP, protocolUDP, udp, 6)
+}
+
+// WriteTCP writes a TCP segment, or multiple ones when the payload is too big.
+// Sequence and acknowledgement numbers are computed from the amount of data
+// that has been written in both directions.
+func (w *Writer) WriteTCP(ntp time.Time, src *net.TCPAddr, dst *net.TCPAddr, payload []byte) error {
+	flow := tcpFlow{
+		srcIP:   string(normalizeIP(src.IP)),
+		srcPort: src.Port,
+		dstIP:   string(normalizeIP(dst.IP)),
+		dstPort: dst.Port,
+	}
+	reverse := tcpFlow{
+		srcIP:   flow.dstIP,
+		srcPort: flow.dstPort,
+		dstIP:   flow.srcIP,
+		dstPort: flow.srcPort,
+	}
+
+	for len(payload) != 0 {
+		n := len(payload)
+		if n > tcpMaxSegmentPayloadSize {
+			n = tcpMaxSegmentPayloadSize
+		}
+
+		seq, ok := w.tcpSeq[flow]
+		if !ok {
+			seq = 1
+		}
+
+		ack, ok := w.tcpSeq[reverse]
+		if !ok {
+			ack = 1
+		}
+
+		tcp := make([]byte, tcpHeaderSize+n)
+		binary.BigEndian.PutUint16(tcp[0:], uint16(src.Port))
+		binary.BigEndian.PutUint16(tcp[2:], uint16(dst.Port))
+		binary.BigEndian.PutUint32(tcp[4:], seq)
+		binary.BigEndian.PutUint32(tcp[8:], ack)
+		tcp[12] = (tcpHeaderSize / 4) << 4
+		tcp[13] = 0x18 // PSH, ACK
+		binary.BigEndian.PutUint16(tcp[14:], 65535)
+		copy(tcp[tcpHeaderSize:], payload[:n])
+
+		err := w.writeIP(ntp, src.IP, dst.IP, protocolTCP, tcp, 16)
+		if err != nil {
+			return err
+		}
+
+		w.tcpSeq[flow] = seq + uint32(n)
+		payload = payload[n:]
+	}
+
+	return nil
+}
+
+func normalizeIP(ip net.IP) net.IP {
+	if ip == nil {
+		return net.IPv4zero.To4()
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+func checksum(buf []byte, initial uint32) uint16 {
+	sum := initial
+
+	for i := 0; (i + 1) < len(buf); i += 2 {
+		sum += uint32(buf[i])<<8 | uint32(buf[i+1])
+	}
+	if (len(buf) % 2) != 0 {
+		sum += uint32(buf[len(buf)-1]) << 8
+	}
+
+	for (sum >> 16) != 0 {
+		sum = (sum & 0xFFFF) + (sum >> 16)
+	}
+
+	return ^uint16(sum)
+}
+
+func pseudoHeaderSum(src net.IP, dst net.IP, protocol uint8, l int) uint32 {
+	var sum uint32
+
+	for _, ip := range []net.IP{src, dst} {
+		for i := 0; i < len(ip); i += 2 {
+			sum += uint32(ip[i])<<8 | uint32(ip[i+1])
+		}
+	}
+
+	sum += uint32(protocol)
+	sum += uint32(l)
+
+	return sum
+}
+
+func (w *Writer) writeIP(
+	ntp time.Time,
+	srcIP net.IP,
+	dstIP net.IP,
+	protocol uint8,
+	payload []byte,
+	checksumOffset int,
+) error {
+	srcIP = normalizeIP(srcIP)
+	dstIP = normalizeIP(dstIP)
+
+	// use IPv6 when one of the addresses is IPv6
+	if len(srcIP) != len(dstIP) {
+		srcIP = srcIP.To16()
+		dstIP = dstIP.To16()
+	}
+
+	cs := checksum(payload, pseudoHeaderSum(srcIP, dstIP, protocol, len(payload)))
+	if cs == 0 && protocol == protocolUDP {
+		cs = 0xFFFF
+	}
+	binary.BigEndian.PutUint16(payload[checksumOffset:], cs)
+
+	var pkt []byte
+
+	if len(srcIP) == net.IPv4len {
+		pkt = make([]byte, ipv4HeaderSize+len(payload))
+		pkt[0] = 0x45
+		binary.BigEndian.PutUint16(pkt[2:], uint16(len(pkt)))
+		binary.BigEndian.PutUint16(pkt[4:], w.ipID)
+		binary.BigEndian.PutUint16(pkt[6:], 0x4000) // don't fragment
+		pkt[8] = 64                                 // TTL
+		pkt[9] = protocol
+		copy(pkt[12:], srcIP)
+		copy(pkt[16:], dstIP)
+		binary.BigEndian.PutUint16(pkt[10:], checksum(pkt[:ipv4HeaderSize], 0))
+		copy(pkt[ipv4HeaderSize:], payload)
+		w.ipID++
+	} else {
+		pkt = make([]byte, ipv6HeaderSize+len(payload))
+		pkt[0] = 0x60
+		binary.BigEndian.PutUint16(pkt[4:], uint16(len(payload)))
+		pkt[6] = protocol
+		pkt[7] = 64 // hop limit
+		copy(pkt[8:], srcIP)
+		copy(pkt[24:], dstIP)
+		copy(pkt[ipv6HeaderSize:], payload)
+	}
+
+	return w.writeEnhancedPacket(ntp, pkt)
+}
+
+func (w *Writer) writeEnhancedPacket(ntp time.Time, pkt []byte) error {
+	padded := (len(pkt) + 3) &^ 3
+	l := enhancedPacketBlockMinSize + padded
+	ts := uint64(ntp.UnixNano() / int64(time.Microsecond))
+
+	buf := make([]byte, l)
+	binary.LittleEndian.PutUint32(buf[0:], blockTypeEnhancedPacket)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(l))
+	binary.LittleEndian.PutUint32(buf[8:], 0) // interface ID
+	binary.LittleEndian.PutUint32(buf[12:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(buf[16:], uint32(ts))
+	binary.LittleEndian.PutUint32(buf[20:], uint32(len(pkt)))
+	binary.LittleEndian.PutUint32(buf[24:], uint32(len(pkt)))
+	copy(buf[28:], pkt)
+	binary.LittleEndian.PutUint32(buf[l-4:], uint32(l))
+
+	_, err := w.W.Write(buf)
+	return err
+}
@@ -0,0 +1,129 @@
+package pcapng
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readPackets(t *testing.T, buf []byte) [][]byte {
+	require.Equal(t, uint32(blockTypeSectionHeader), binary.LittleEndian.Uint32(buf[0:]))
+	require.Equal(t, uint32(byteOrderMagic), binary.LittleEndian.Uint32(buf[8:]))
+	buf = buf[binary.LittleEndian.Uint32(buf[4:]):]
+
+	require.Equal(t, uint32(blockTypeInterfaceDesc), binary.LittleEndian.Uint32(buf[0:]))
+	require.Equal(t, uint16(linkTypeRaw), binary.LittleEndian.Uint16(buf[8:]))
+	buf = buf[binary.LittleEndian.Uint32(buf[4:]):]
+
+	var pkts [][]byte
+
+	for len(buf) != 0 {
+		require.Equal(t, uint32(blockTypeEnhancedPacket), binary.LittleEndian.Uint32(buf[0:]))
+		l := binary.LittleEndian.Uint32(buf[4:])
+		require.Equal(t, l, binary.LittleEndian.Uint32(buf[l-4:]))
+		require.Zero(t, l%4)
+
+		ts := uint64(binary.LittleEndian.Uint32(buf[12:]))<<32 | uint64(binary.LittleEndian.Uint32(buf[16:]))
+		require.Equal(t, uint64(1704067200000001), ts)
+
+		pl := binary.LittleEndian.Uint32(buf[20:])
+		pkts = append(pkts, buf[28:28+pl])
+		buf = buf[l:]
+	}
+
+	return pkts
+}
+
+func TestWriterUDP(t *testing.T) {
+	var buf bytes.Buffer
+	w := &Writer{W: &buf}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	ntp := time.Date(2024, 1, 1, 0, 0, 0, 1000, time.UTC)
+
+	err = w.WriteUDP(ntp,
+		&net.UDPAddr{IP: net.ParseIP("192.168.1.2"), Port: 8000},
+		&net.UDPAddr{IP: net.ParseIP("192.168.1.3"), Port: 35466},
+		[]byte{0x80, 0x60, 0x00, 0x01, 0x01, 0x02, 0x03})
+	require.NoError(t, err)
+
+	err = w.WriteUDP(ntp,
+		&net.UDPAddr{IP: net.ParseIP("::1"), Port: 8000},
+		&net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 35466},
+		[]byte{0x80, 0x60, 0x00, 0x02})
+	require.NoError(t, err)
+
+	pkts := readPackets(t, buf.Bytes())
+	require.Len(t, pkts, 2)
+
+	pkt := pkts[0]
+	require.Equal(t, byte(0x45), pkt[0])
+	require.Equal(t, uint16(35), binary.BigEndian.Uint16(pkt[2:]))
+	require.Equal(t, byte(protocolUDP), pkt[9])
+	require.Equal(t, uint16(0), checksum(pkt[:ipv4HeaderSize], 0))
+	require.Equal(t, []byte{192, 168, 1, 2, 192, 168, 1, 3}, pkt[12:20])
+	require.Equal(t, uint16(8000), binary.BigEndian.Uint16(pkt[20:]))
+	require.Equal(t, uint16(35466), binary.BigEndian.Uint16(pkt[22:]))
+	require.Equal(t, uint16(0), checksum(pkt[20:],
+		pseudoHeaderSum(pkt[12:16], pkt[16:20], protocolUDP, len(pkt)-ipv4HeaderSize)))
+	require.Equal(t, []byte{0x80, 0x60, 0x00, 0x01, 0x01, 0x02, 0x03}, pkt[28:])
+
+	pkt = pkts[1]
+	require.Equal(t, byte(0x60), pkt[0])
+	require.Equal(t, uint16(12), binary.BigEndian.Uint16(pkt[4:]))
+	require.Equal(t, byte(protocolUDP), pkt[6])
+	require.Equal(t, net.ParseIP("::1"), net.IP(pkt[8:24]))
+	require.Equal(t, net.ParseIP("127.0.0.1"), net.IP(pkt[24:40]))
+	require.Equal(t, uint16(0), checksum(pkt[40:],
+		pseudoHeaderSum(pkt[8:24], pkt[24:40], protocolUDP, len(pkt)-ipv6HeaderSize)))
+}
+
+func TestWriterTCP(t *testing.T) {
+	var buf bytes.Buffer
+	w := &Writer{W: &buf}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	ntp := time.Date(2024, 1, 1, 0, 0, 0, 1000, time.UTC)
+
+	client := &net.TCPAddr{IP: net.ParseIP("192.168.1.3"), Port: 45678}
+	server := &net.TCPAddr{IP: net.ParseIP("192.168.1.2"), Port: 8554}
+
+	err = w.WriteTCP(ntp, client, server, []byte("OPTIONS rtsp://192.168.1.2:8554 RTSP/1.0\r\n\r\n"))
+	require.NoError(t, err)
+
+	err = w.WriteTCP(ntp, server, client, []byte("RTSP/1.0 200 OK\r\n\r\n"))
+	require.NoError(t, err)
+
+	err = w.WriteTCP(ntp, client, server, bytes.Repeat([]byte{1}, tcpMaxSegmentPayloadSize+10))
+	require.NoError(t, err)
+
+	pkts := readPackets(t, buf.Bytes())
+	require.Len(t, pkts, 4)
+
+	for i, ca := range []struct {
+		seq uint32
+		ack uint32
+		l   int
+	}{
+		{1, 1, 44},
+		{1, 45, 19},
+		{45, 20, tcpMaxSegmentPayloadSize},
+		{45 + tcpMaxSegmentPayloadSize, 20, 10},
+	} {
+		pkt := pkts[i]
+		require.Equal(t, byte(protocolTCP), pkt[9])
+		require.Equal(t, uint16(0), checksum(pkt[:ipv4HeaderSize], 0))
+
+		tcp := pkt[ipv4HeaderSize:]
+		require.Equal(t, ca.seq, binary.BigEndian.Uint32(tcp[4:]))
+		require.Equal(t, ca.ack, binary.BigEndian.Uint32(tcp[8:]))
+		require.Equal(t, ca.l, len(tcp)-tcpHeaderSize)
+		require.Equal(t, uint16(0), checksum(tcp, pseudoHeaderSum(pkt[12:16], pkt[16:20], protocolTCP, len(tcp))))
+	}
+}
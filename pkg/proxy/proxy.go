@@ -0,0 +1,281 @@
+// Package proxy contains a RTSP proxy, that relays streams pulled from upstream servers.
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+const (
+	reconnectPause = 2 * time.Second
+)
+
+// Proxy relays streams pulled from upstream servers.
+//
+// Each upstream stream is read with a single Client and republished
+// through a ServerStream, that is shared by all readers.
+// Medias and formats are passed through without being decoded.
+// When the upstream connection is lost, readers are disconnected
+// and the connection is established again after ReconnectPause.
+// Readers that are too slow to receive packets don't slow down
+// the upstream connection: they are disconnected when their write queue
+// (Server.WriteQueueSize) is full.
+//
+// Proxy implements the ServerHandler interface and must be used as the Handler of Server.
+type Proxy struct {
+	//
+	// Server
+	//
+	// server that republishes streams.
+	// It must be started before calling Start().
+	Server *gortsplib.Server
+	// upstream URLs, indexed by the path they are republished on (i.e. "/mystream").
+	Sources map[string]string
+
+	//
+	// Client
+	//
+	// function that allocates the client used to pull a stream (optional).
+	// It can be used to set client settings, like the transport or credentials.
+	// It defaults to a function that returns a Client with default settings.
+	NewClient func() *gortsplib.Client
+	// pause between two connection attempts (optional).
+	// It defaults to 2 seconds.
+	ReconnectPause time.Duration
+
+	//
+	// callbacks (all optional)
+	//
+	// called when a stream becomes ready.
+	OnSourceReady func(path string, desc *description.Session)
+	// called when a stream stops being ready, or when a connection attempt fails.
+	OnSourceError func(path string, err error)
+
+	ctx       context.Context
+	ctxCancel func()
+	sources   map[string]*source
+}
+
+// Start starts pulling streams.
+func (p *Proxy) Start() error {
+	if p.Server == nil {
+		return fmt.Errorf("Server not provided")
+	}
+
+	if p.NewClient == nil {
+		p.NewClient = func() *gortsplib.Client {
+			return &gortsplib.Client{}
+		}
+	}
+	if p.ReconnectPause == 0 {
+		p.ReconnectPause = reconnectPause
+	}
+
+	urls := make(map[string]*base.URL, len(p.Sources))
+
+	for path, ur := range p.Sources {
+		u, err := base.ParseURL(ur)
+		if err != nil {
+			return fmt.Errorf("invalid source of path '%s': %w", path, err)
+		}
+		urls[path] = u
+	}
+
+	p.ctx, p.ctxCancel = context.WithCancel(context.Background())
+	p.sources = make(map[string]*source, len(urls))
+
+	for path, u := range urls {
+		s := &source{
+			p:    p,
+			path: path,
+			url:  u,
+			done: make(chan struct{}),
+		}
+		p.sources[path] = s
+		go s.run()
+	}
+
+	return nil
+}
+
+// Close stops pulling streams and closes republished streams.
+func (p *Proxy) Close() {
+	p.ctxCancel()
+
+	for _, s := range p.sources {
+		<-s.done
+	}
+}
+
+// Stream returns the stream republished on given path, or nil if the stream is not ready.
+func (p *Proxy) Stream(path string) *gortsplib.ServerStream {
+	s, ok := p.sources[path]
+	if !ok {
+		return nil
+	}
+
+	return s.currentStream()
+}
+
+// OnDescribe implements gortsplib.ServerHandlerOnDescribe.
+func (p *Proxy) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	return p.findStream(ctx.Path)
+}
+
+// OnSetup implements gortsplib.ServerHandlerOnSetup.
+func (p *Proxy) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	return p.findStream(ctx.Path)
+}
+
+// OnPlay implements gortsplib.ServerHandlerOnPlay.
+func (p *Proxy) OnPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func (p *Proxy) findStream(path string) (*base.Response, *gortsplib.ServerStream, error) {
+	stream := p.Stream(path)
+	if stream == nil {
+		return &base.Response{
+			StatusCode: base.StatusNotFound,
+		}, nil, nil
+	}
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, stream, nil
+}
+
+type source struct {
+	p    *Proxy
+	path string
+	url  *base.URL
+
+	mutex  sync.RWMutex
+	stream *gortsplib.ServerStream
+
+	done chan struct{}
+}
+
+func (s *source) currentStream() *gortsplib.ServerStream {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.stream
+}
+
+func (s *source) run() {
+	defer close(s.done)
+
+	for {
+		err := s.runInner()
+
+		select {
+		case <-s.p.ctx.Done():
+			return
+		default:
+		}
+
+		if s.p.OnSourceError != nil {
+			s.p.OnSourceError(s.path, err)
+		}
+
+		select {
+		case <-time.After(s.p.ReconnectPause):
+		case <-s.p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *source) runInner() error {
+	c := s.p.NewClient()
+
+	err := c.Start(s.url.Scheme, s.url.Host)
+	if err != nil {
+		return err
+	}
+
+	waitErr := make(chan error)
+	go func() {
+		waitErr <- c.Wait()
+	}()
+
+	err = s.runClient(c)
+	if err != nil {
+		c.Close()
+		<-waitErr
+		return err
+	}
+
+	defer s.setStream(nil)
+
+	select {
+	case err := <-waitErr:
+		return err
+
+	case <-s.p.ctx.Done():
+		c.Close()
+		<-waitErr
+		return fmt.Errorf("terminated")
+	}
+}
+
+func (s *source) runClient(c *gortsplib.Client) error {
+	desc, _, err := c.Describe(s.url)
+	if err != nil {
+		return err
+	}
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	if err != nil {
+		return err
+	}
+
+	stream := gortsplib.NewServerStream(s.p.Server, desc)
+
+	c.OnPacketRTPAny(func(medi *description.Media, _ format.Format, pkt *rtp.Packet) {
+		// preserve the absolute timestamp of the upstream stream, in order to
+		// allow readers to synchronize medias.
+		ntp, ok := c.PacketNTP(medi, pkt)
+		if !ok {
+			ntp = time.Now()
+		}
+
+		stream.WritePacketRTPWithNTP(medi, pkt, ntp) //nolint:errcheck
+	})
+
+	_, err = c.Play(nil)
+	if err != nil {
+		stream.Close()
+		return err
+	}
+
+	s.setStream(stream)
+
+	if s.p.OnSourceReady != nil {
+		s.p.OnSourceReady(s.path, desc)
+	}
+
+	return nil
+}
+
+func (s *source) setStream(stream *gortsplib.ServerStream) {
+	s.mutex.Lock()
+	prev := s.stream
+	s.stream = stream
+	s.mutex.Unlock()
+
+	if prev != nil {
+		prev.Close()
+	}
+}
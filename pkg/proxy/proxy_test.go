@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+type upstreamHandler struct {
+	mutex  sync.Mutex
+	stream *gortsplib.ServerStream
+}
+
+func (h *upstreamHandler) setStream(stream *gortsplib.ServerStream) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.stream = stream
+}
+
+func (h *upstreamHandler) currentStream() *gortsplib.ServerStream {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.stream
+}
+
+func (h *upstreamHandler) OnDescribe(
+	_ *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	stream := h.currentStream()
+	if stream == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, nil
+	}
+	return &base.Response{StatusCode: base.StatusOK}, stream, nil
+}
+
+func (h *upstreamHandler) OnSetup(
+	_ *gortsplib.ServerHandlerOnSetupCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return h.OnDescribe(&gortsplib.ServerHandlerOnDescribeCtx{})
+}
+
+func (h *upstreamHandler) OnPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+func newUpstreamStream(s *gortsplib.Server) *gortsplib.ServerStream {
+	return gortsplib.NewServerStream(s, &description.Session{
+		Medias: []*description.Media{{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				PacketizationMode: 1,
+			}},
+		}},
+	})
+}
+
+func TestProxy(t *testing.T) {
+	uh := &upstreamHandler{}
+
+	upstream := &gortsplib.Server{
+		Handler:     uh,
+		RTSPAddress: "127.0.0.1:8555",
+	}
+	err := upstream.Start()
+	require.NoError(t, err)
+	defer upstream.Close()
+
+	upstreamStream := newUpstreamStream(upstream)
+	uh.setStream(upstreamStream)
+
+	p := &Proxy{
+		Sources: map[string]string{
+			"/relayed": "rtsp://127.0.0.1:8555/teststream",
+		},
+		NewClient: func() *gortsplib.Client {
+			return &gortsplib.Client{
+				Transport: transportPtr(gortsplib.TransportTCP),
+			}
+		},
+		ReconnectPause: 100 * time.Millisecond,
+	}
+
+	ready := make(chan struct{}, 2)
+	p.OnSourceReady = func(path string, desc *description.Session) {
+		require.Equal(t, "/relayed", path)
+		require.Equal(t, 1, len(desc.Medias))
+		select {
+		case ready <- struct{}{}:
+		default:
+		}
+	}
+
+	sourceErr := make(chan struct{}, 2)
+	p.OnSourceError = func(_ string, _ error) {
+		select {
+		case sourceErr <- struct{}{}:
+		default:
+		}
+	}
+
+	s := &gortsplib.Server{
+		Handler:     p,
+		RTSPAddress: "127.0.0.1:8554",
+	}
+	p.Server = s
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	err = p.Start()
+	require.NoError(t, err)
+	defer p.Close()
+
+	<-ready
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8554/relayed")
+	require.NoError(t, err)
+
+	packetRecv := make(chan struct{}, 2)
+
+	for i := 0; i < 2; i++ {
+		c := gortsplib.Client{
+			Transport: transportPtr(gortsplib.TransportTCP),
+		}
+
+		err = c.Start(u.Scheme, u.Host)
+		require.NoError(t, err)
+		defer c.Close()
+
+		var desc *description.Session
+		desc, _, err = c.Describe(u)
+		require.NoError(t, err)
+
+		err = c.SetupAll(desc.BaseURL, desc.Medias)
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		c.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			require.Equal(t, []byte{1, 2, 3, 4}, pkt.Payload)
+			select {
+			case <-done:
+			default:
+				close(done)
+				packetRecv <- struct{}{}
+			}
+		})
+
+		_, err = c.Play(nil)
+		require.NoError(t, err)
+	}
+
+	// both readers are served by the same upstream connection
+	for n := 0; n < 2; {
+		err = upstreamStream.WritePacketRTP(upstreamStream.Description().Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: 123,
+				Timestamp:      45343,
+				SSRC:           563423,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		})
+		require.NoError(t, err)
+
+		select {
+		case <-packetRecv:
+			n++
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// the upstream connection is established again after a failure
+	uh.setStream(nil)
+	upstreamStream.Close()
+
+	<-sourceErr
+	require.Nil(t, p.Stream("/relayed"))
+
+	upstreamStream = newUpstreamStream(upstream)
+	defer upstreamStream.Close()
+	uh.setStream(upstreamStream)
+
+	<-ready
+	require.NotNil(t, p.Stream("/relayed"))
+}
+
+func transportPtr(v gortsplib.Transport) *gortsplib.Transport {
+	return &v
+}
@@ -0,0 +1,256 @@
+// Package record contains a recorder that writes RTP streams into fMP4 or MPEG-TS segments.
+package record
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtptime"
+)
+
+const (
+	defaultSegmentDuration = 1 * time.Hour
+)
+
+// Format is the format of segments.
+type Format int
+
+// formats.
+const (
+	FormatFMP4 Format = iota
+	FormatMPEGTS
+)
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	switch f {
+	case FormatFMP4:
+		return "fMP4"
+	case FormatMPEGTS:
+		return "MPEG-TS"
+	}
+	return "unknown"
+}
+
+// Recorder writes RTP packets into segments.
+// Supported formats are H264, H265, MPEG-4 Audio and Opus.
+// Segments are rotated on random access points of the first video track,
+// or on any sample when there are no video tracks.
+type Recorder struct {
+	// medias to record. Formats that are not supported are discarded.
+	Medias []*description.Media
+
+	// format of segments.
+	// It defaults to FormatFMP4.
+	Format Format
+
+	// minimum duration of a segment (optional).
+	// It defaults to 1 hour.
+	SegmentDuration time.Duration
+
+	// called when a segment is created.
+	// start is the DTS of the first sample of the segment.
+	// It must return the writer where the segment is written,
+	// that is closed when the segment is complete.
+	OnSegmentCreate func(start time.Duration) (io.WriteCloser, error)
+
+	// called when a segment is complete (optional).
+	OnSegmentComplete func(start time.Duration, duration time.Duration)
+
+	mutex        sync.Mutex
+	timeDecoder  *rtptime.GlobalDecoder
+	tracks       []*track
+	tracksByMedi map[*description.Media]*track
+	leadingTrack *track
+	seg          segment
+	segStart     time.Duration
+	segEnd       time.Duration
+	closed       bool
+}
+
+// Initialize initializes a Recorder.
+func (r *Recorder) Initialize() error {
+	if r.OnSegmentCreate == nil {
+		return fmt.Errorf("OnSegmentCreate not provided")
+	}
+	if r.Format != FormatFMP4 && r.Format != FormatMPEGTS {
+		return fmt.Errorf("unsupported format: %v", r.Format)
+	}
+
+	if r.SegmentDuration == 0 {
+		r.SegmentDuration = defaultSegmentDuration
+	}
+
+	r.tracksByMedi = make(map[*description.Media]*track)
+
+	for _, medi := range r.Medias {
+		for _, forma := range medi.Formats {
+			codec, err := newTrackCodec(forma)
+			if err != nil {
+				return err
+			}
+			if codec == nil {
+				continue
+			}
+
+			t := &track{
+				id:     len(r.tracks) + 1,
+				format: forma,
+				codec:  codec,
+			}
+			r.tracks = append(r.tracks, t)
+			r.tracksByMedi[medi] = t
+			break
+		}
+	}
+
+	if r.tracks == nil {
+		return fmt.Errorf("none of the medias can be recorded")
+	}
+
+	for _, t := range r.tracks {
+		if t.codec.isVideo() {
+			r.leadingTrack = t
+			break
+		}
+	}
+	if r.leadingTrack == nil {
+		r.leadingTrack = r.tracks[0]
+	}
+
+	r.timeDecoder = rtptime.NewGlobalDecoder()
+
+	return nil
+}
+
+// Close closes the Recorder, completing the current segment.
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.closed = true
+
+	return r.closeSegment()
+}
+
+// WritePacketRTP writes a RTP packet.
+// It can be called by multiple goroutines.
+func (r *Recorder) WritePacketRTP(medi *description.Media, pkt *rtp.Packet) error {
+	t, ok := r.tracksByMedi[medi]
+	if !ok || pkt.PayloadType != t.format.PayloadType() {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("recorder is closed")
+	}
+
+	pts, ok := r.timeDecoder.Decode(t.format, pkt)
+	if !ok {
+		return nil
+	}
+
+	samples, err := t.codec.decode(pkt, pts)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range samples {
+		err = r.writeSample(t, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Recorder) writeSample(t *track, s *sample) error {
+	if t == r.leadingTrack && s.randomAccess {
+		if r.seg != nil && (s.dts-r.segStart) >= r.SegmentDuration {
+			err := r.closeSegment()
+			if err != nil {
+				return err
+			}
+		}
+
+		if r.seg == nil {
+			err := r.createSegment(s.dts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.seg == nil || s.dts < r.segStart {
+		return nil
+	}
+
+	err := r.seg.writeSample(t, s)
+	if err != nil {
+		return err
+	}
+
+	if s.dts > r.segEnd {
+		r.segEnd = s.dts
+	}
+
+	return nil
+}
+
+func (r *Recorder) createSegment(start time.Duration) error {
+	w, err := r.OnSegmentCreate(start)
+	if err != nil {
+		return err
+	}
+
+	switch r.Format {
+	case FormatFMP4:
+		r.seg = &segmentFMP4{
+			w:      w,
+			start:  start,
+			tracks: r.tracks,
+		}
+
+	default:
+		r.seg = &segmentMPEGTS{
+			w:      w,
+			start:  start,
+			tracks: r.tracks,
+		}
+	}
+
+	err = r.seg.initialize()
+	if err != nil {
+		w.Close()
+		r.seg = nil
+		return err
+	}
+
+	r.segStart = start
+	r.segEnd = start
+	return nil
+}
+
+func (r *Recorder) closeSegment() error {
+	if r.seg == nil {
+		return nil
+	}
+
+	err := r.seg.close()
+	r.seg = nil
+
+	if r.OnSegmentComplete != nil {
+		r.OnSegmentComplete(r.segStart, r.segEnd-r.segStart)
+	}
+
+	return err
+}
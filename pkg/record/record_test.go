@@ -0,0 +1,236 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func mp4BoxTypes(t *testing.T, buf []byte) []string {
+	var types []string
+
+	for len(buf) != 0 {
+		require.GreaterOrEqual(t, len(buf), 8)
+		size := int(binary.BigEndian.Uint32(buf))
+		require.LessOrEqual(t, size, len(buf))
+		types = append(types, string(buf[4:8]))
+		buf = buf[size:]
+	}
+
+	return types
+}
+
+func writeTestStream(t *testing.T, r *Recorder, videoMedia *description.Media, audioMedia *description.Media) {
+	videoForma := videoMedia.Formats[0].(*format.H264)
+	videoEnc, err := videoForma.CreateEncoder()
+	require.NoError(t, err)
+
+	audioForma := audioMedia.Formats[0].(*format.MPEG4Audio)
+	audioEnc, err := audioForma.CreateEncoder()
+	require.NoError(t, err)
+
+	// 3 seconds of video at 10 FPS, with a IDR every second,
+	// and audio.
+	for i := 0; i < 30; i++ {
+		var au [][]byte
+		if (i % 10) == 0 {
+			au = [][]byte{testSPS, testPPS, {0x05, 1}}
+		} else {
+			au = [][]byte{{0x01, byte(i)}}
+		}
+
+		var pkts []*rtp.Packet
+		pkts, err = videoEnc.Encode(au)
+		require.NoError(t, err)
+
+		for _, pkt := range pkts {
+			pkt.Timestamp = uint32(i * 9000)
+			err = r.WritePacketRTP(videoMedia, pkt)
+			require.NoError(t, err)
+		}
+
+		pkts, err = audioEnc.Encode([][]byte{{1, 2, 3, 4}})
+		require.NoError(t, err)
+
+		for _, pkt := range pkts {
+			pkt.Timestamp = uint32(i * 4410)
+			err = r.WritePacketRTP(audioMedia, pkt)
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	for _, ca := range []Format{FormatFMP4, FormatMPEGTS} {
+		t.Run(ca.String(), func(t *testing.T) {
+			videoMedia := &description.Media{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					SPS:               testSPS,
+					PPS:               testPPS,
+					PacketizationMode: 1,
+				}},
+			}
+
+			audioMedia := &description.Media{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{&format.MPEG4Audio{
+					PayloadTyp: 97,
+					Config: &mpeg4audio.Config{
+						Type:         2,
+						SampleRate:   44100,
+						ChannelCount: 2,
+					},
+					SizeLength:       13,
+					IndexLength:      3,
+					IndexDeltaLength: 3,
+				}},
+			}
+
+			var segments []*bufferCloser
+			var starts []time.Duration
+			var durations []time.Duration
+
+			r := &Recorder{
+				Medias: []*description.Media{
+					videoMedia,
+					audioMedia,
+					{
+						Type:    description.MediaTypeApplication,
+						Formats: []format.Format{&format.Generic{PayloadTyp: 98}},
+					},
+				},
+				Format:          ca,
+				SegmentDuration: 1 * time.Second,
+				OnSegmentCreate: func(start time.Duration) (io.WriteCloser, error) {
+					starts = append(starts, start)
+					seg := &bufferCloser{}
+					segments = append(segments, seg)
+					return seg, nil
+				},
+				OnSegmentComplete: func(_ time.Duration, duration time.Duration) {
+					durations = append(durations, duration)
+				},
+			}
+			err := r.Initialize()
+			require.NoError(t, err)
+
+			writeTestStream(t, r, videoMedia, audioMedia)
+
+			err = r.Close()
+			require.NoError(t, err)
+
+			require.Equal(t, []time.Duration{0, 1 * time.Second, 2 * time.Second}, starts)
+			require.Equal(t, 3, len(durations))
+			require.InDelta(t, float64(900*time.Millisecond), float64(durations[2]), float64(50*time.Millisecond))
+
+			for _, seg := range segments {
+				require.True(t, seg.closed)
+
+				if ca == FormatFMP4 {
+					types := mp4BoxTypes(t, seg.Bytes())
+					require.Equal(t, []string{"ftyp", "moov", "moof", "mdat"}, types)
+
+					var init fmp4.Init
+					err := init.Unmarshal(seg.Bytes())
+					require.NoError(t, err)
+					require.Equal(t, 2, len(init.Tracks))
+					require.Equal(t, &fmp4.CodecH264{SPS: testSPS, PPS: testPPS}, init.Tracks[0].Codec)
+
+					var parts fmp4.Parts
+					err = parts.Unmarshal(seg.Bytes())
+					require.NoError(t, err)
+					require.Equal(t, 1, len(parts))
+					require.Equal(t, 10, len(parts[0].Tracks[0].Samples))
+					require.Equal(t, false, parts[0].Tracks[0].Samples[0].IsNonSyncSample)
+					require.Equal(t, uint32(9000), parts[0].Tracks[0].Samples[0].Duration)
+					continue
+				}
+
+				mr, err := mpegts.NewReader(bytes.NewReader(seg.Bytes()))
+				require.NoError(t, err)
+
+				var videoFrames int
+				var audioFrames int
+
+				for _, track := range mr.Tracks() {
+					switch track.Codec.(type) {
+					case *mpegts.CodecH264:
+						mr.OnDataH26x(track, func(pts int64, dts int64, _ [][]byte) error {
+							require.Equal(t, pts, dts)
+							require.Equal(t, int64(videoFrames)*9000, pts)
+							videoFrames++
+							return nil
+						})
+
+					case *mpegts.CodecMPEG4Audio:
+						mr.OnDataMPEG4Audio(track, func(_ int64, aus [][]byte) error {
+							require.Equal(t, [][]byte{{1, 2, 3, 4}}, aus)
+							audioFrames++
+							return nil
+						})
+					}
+				}
+
+				for {
+					err = mr.Read()
+					if err != nil {
+						break
+					}
+				}
+
+				require.Equal(t, 10, videoFrames)
+				require.NotZero(t, audioFrames)
+			}
+		})
+	}
+}
+
+func TestRecorderErrors(t *testing.T) {
+	r := &Recorder{
+		Medias: []*description.Media{{
+			Type:    description.MediaTypeApplication,
+			Formats: []format.Format{&format.Generic{PayloadTyp: 98}},
+		}},
+		OnSegmentCreate: func(_ time.Duration) (io.WriteCloser, error) {
+			return &bufferCloser{}, nil
+		},
+	}
+	err := r.Initialize()
+	require.EqualError(t, err, "none of the medias can be recorded")
+
+	r = &Recorder{}
+	err = r.Initialize()
+	require.EqualError(t, err, "OnSegmentCreate not provided")
+}
@@ -0,0 +1,19 @@
+package record
+
+import (
+	"time"
+)
+
+type segment interface {
+	initialize() error
+	writeSample(t *track, s *sample) error
+	close() error
+}
+
+// converts a duration into a timestamp with given clock rate,
+// avoiding overflows.
+func durationToTimestamp(d time.Duration, clockRate int) int64 {
+	secs := d / time.Second
+	dec := d % time.Second
+	return int64(secs)*int64(clockRate) + int64(dec)*int64(clockRate)/int64(time.Second)
+}
@@ -0,0 +1,210 @@
+package record
+
+import (
+	"io"
+	"time"
+
+	"github.com/aler9/writerseeker"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+)
+
+const (
+	fmp4FragmentDuration = 1 * time.Second
+)
+
+type segmentFMP4Track struct {
+	pending      *fmp4.PartSample
+	pendingDTS   int64
+	lastDuration uint32
+	baseTime     uint64
+	samples      []*fmp4.PartSample
+}
+
+type segmentFMP4 struct {
+	w      io.WriteCloser
+	start  time.Duration
+	tracks []*track
+
+	fmp4Tracks     map[*track]*segmentFMP4Track
+	fragmentStart  time.Duration
+	sequenceNumber uint32
+}
+
+func (s *segmentFMP4) initialize() error {
+	init := fmp4.Init{}
+	s.fmp4Tracks = make(map[*track]*segmentFMP4Track)
+
+	for _, t := range s.tracks {
+		codec := s.initCodec(t)
+
+		// parameters are not available yet: skip the track in this segment
+		if codec == nil {
+			continue
+		}
+
+		init.Tracks = append(init.Tracks, &fmp4.InitTrack{
+			ID:        t.id,
+			TimeScale: uint32(t.format.ClockRate()),
+			Codec:     codec,
+		})
+		s.fmp4Tracks[t] = &segmentFMP4Track{}
+	}
+
+	s.fragmentStart = s.start
+
+	var buf writerseeker.WriterSeeker
+	err := init.Marshal(&buf)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.w.Write(buf.Bytes())
+	return err
+}
+
+func (s *segmentFMP4) initCodec(t *track) fmp4.Codec {
+	switch codec := t.codec.(type) {
+	case *trackCodecH264:
+		if codec.sps == nil || codec.pps == nil {
+			return nil
+		}
+
+		return &fmp4.CodecH264{
+			SPS: codec.sps,
+			PPS: codec.pps,
+		}
+
+	case *trackCodecH265:
+		if codec.vps == nil || codec.sps == nil || codec.pps == nil {
+			return nil
+		}
+
+		return &fmp4.CodecH265{
+			VPS: codec.vps,
+			SPS: codec.sps,
+			PPS: codec.pps,
+		}
+
+	case *trackCodecMPEG4Audio:
+		return &fmp4.CodecMPEG4Audio{
+			Config: *codec.config,
+		}
+
+	case *trackCodecOpus:
+		return &fmp4.CodecOpus{
+			ChannelCount: codec.channelCount,
+		}
+	}
+
+	return nil
+}
+
+func (s *segmentFMP4) writeSample(t *track, smp *sample) error {
+	ft, ok := s.fmp4Tracks[t]
+	if !ok {
+		return nil
+	}
+
+	// flush completed samples periodically, in order to allow
+	// the segment to be read while it's being written.
+	if (smp.dts - s.fragmentStart) >= fmp4FragmentDuration {
+		err := s.writeFragment()
+		if err != nil {
+			return err
+		}
+		s.fragmentStart = smp.dts
+	}
+
+	clockRate := t.format.ClockRate()
+	dts := durationToTimestamp(smp.dts-s.start, clockRate)
+
+	var ps *fmp4.PartSample
+
+	if t.codec.isVideo() {
+		var err error
+		ps, err = fmp4.NewPartSampleH26x(
+			int32(durationToTimestamp(smp.pts-s.start, clockRate)-dts),
+			smp.randomAccess,
+			smp.au)
+		if err != nil {
+			return err
+		}
+	} else {
+		ps = &fmp4.PartSample{
+			Payload: smp.au[0],
+		}
+	}
+
+	if ft.pending != nil {
+		ft.pending.Duration = uint32(dts - ft.pendingDTS)
+		ft.lastDuration = ft.pending.Duration
+		ft.appendPending()
+	}
+
+	ft.pending = ps
+	ft.pendingDTS = dts
+
+	return nil
+}
+
+func (ft *segmentFMP4Track) appendPending() {
+	if ft.samples == nil {
+		ft.baseTime = uint64(ft.pendingDTS)
+	}
+	ft.samples = append(ft.samples, ft.pending)
+}
+
+func (s *segmentFMP4) writeFragment() error {
+	part := fmp4.Part{
+		SequenceNumber: s.sequenceNumber + 1,
+	}
+
+	for _, t := range s.tracks {
+		ft, ok := s.fmp4Tracks[t]
+		if !ok || len(ft.samples) == 0 {
+			continue
+		}
+
+		part.Tracks = append(part.Tracks, &fmp4.PartTrack{
+			ID:       t.id,
+			BaseTime: ft.baseTime,
+			Samples:  ft.samples,
+		})
+		ft.samples = nil
+	}
+
+	if part.Tracks == nil {
+		return nil
+	}
+
+	s.sequenceNumber++
+
+	var buf writerseeker.WriterSeeker
+	err := part.Marshal(&buf)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.w.Write(buf.Bytes())
+	return err
+}
+
+func (s *segmentFMP4) close() error {
+	// the duration of the last sample is unknown: use the one of the previous sample.
+	for _, ft := range s.fmp4Tracks {
+		if ft.pending != nil {
+			ft.pending.Duration = ft.lastDuration
+			ft.appendPending()
+			ft.pending = nil
+		}
+	}
+
+	err := s.writeFragment()
+
+	err2 := s.w.Close()
+	if err == nil {
+		err = err2
+	}
+
+	return err
+}
@@ -0,0 +1,97 @@
+package record
+
+import (
+	"bufio"
+	"io"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+)
+
+const (
+	mpegtsClockRate = 90000
+)
+
+type segmentMPEGTS struct {
+	w      io.WriteCloser
+	start  time.Duration
+	tracks []*track
+
+	bw           *bufio.Writer
+	mw           *mpegts.Writer
+	mpegtsTracks map[*track]*mpegts.Track
+}
+
+func (s *segmentMPEGTS) initialize() error {
+	s.mpegtsTracks = make(map[*track]*mpegts.Track)
+	var tracks []*mpegts.Track
+
+	for _, t := range s.tracks {
+		var codec mpegts.Codec
+
+		switch tcodec := t.codec.(type) {
+		case *trackCodecH264:
+			codec = &mpegts.CodecH264{}
+
+		case *trackCodecH265:
+			codec = &mpegts.CodecH265{}
+
+		case *trackCodecMPEG4Audio:
+			codec = &mpegts.CodecMPEG4Audio{
+				Config: *tcodec.config,
+			}
+
+		case *trackCodecOpus:
+			codec = &mpegts.CodecOpus{
+				ChannelCount: tcodec.channelCount,
+			}
+		}
+
+		mt := &mpegts.Track{
+			Codec: codec,
+		}
+		tracks = append(tracks, mt)
+		s.mpegtsTracks[t] = mt
+	}
+
+	s.bw = bufio.NewWriter(s.w)
+	s.mw = mpegts.NewWriter(s.bw, tracks)
+
+	return nil
+}
+
+func (s *segmentMPEGTS) writeSample(t *track, smp *sample) error {
+	mt := s.mpegtsTracks[t]
+	pts := durationToTimestamp(smp.pts-s.start, mpegtsClockRate)
+
+	switch t.codec.(type) {
+	case *trackCodecH264:
+		// prepend an AUD. This is required by some players
+		au := append([][]byte{{byte(h264.NALUTypeAccessUnitDelimiter), 240}}, smp.au...)
+
+		return s.mw.WriteH26x(mt, pts, durationToTimestamp(smp.dts-s.start, mpegtsClockRate),
+			smp.randomAccess, au)
+
+	case *trackCodecH265:
+		return s.mw.WriteH26x(mt, pts, durationToTimestamp(smp.dts-s.start, mpegtsClockRate),
+			smp.randomAccess, smp.au)
+
+	case *trackCodecMPEG4Audio:
+		return s.mw.WriteMPEG4Audio(mt, pts, smp.au)
+
+	default:
+		return s.mw.WriteOpus(mt, pts, smp.au)
+	}
+}
+
+func (s *segmentMPEGTS) close() error {
+	err := s.bw.Flush()
+
+	err2 := s.w.Close()
+	if err == nil {
+		err = err2
+	}
+
+	return err
+}
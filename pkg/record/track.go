@@ -0,0 +1,327 @@
+package record
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph265"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpmpeg4audio"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpsimpleaudio"
+)
+
+// sample is a decoded access unit or frame.
+type sample struct {
+	pts          time.Duration
+	dts          time.Duration
+	randomAccess bool
+
+	// NALUs for video codecs, a single frame for audio codecs.
+	au [][]byte
+}
+
+type trackCodec interface {
+	isVideo() bool
+	decode(pkt *rtp.Packet, pts time.Duration) ([]*sample, error)
+}
+
+type track struct {
+	id     int
+	format format.Format
+	codec  trackCodec
+}
+
+func newTrackCodec(forma format.Format) (trackCodec, error) {
+	switch forma := forma.(type) {
+	case *format.H264:
+		dec, err := forma.CreateDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		sps, pps := forma.SafeParams()
+
+		return &trackCodecH264{
+			decoder: dec,
+			sps:     sps,
+			pps:     pps,
+		}, nil
+
+	case *format.H265:
+		dec, err := forma.CreateDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		vps, sps, pps := forma.SafeParams()
+
+		return &trackCodecH265{
+			decoder: dec,
+			vps:     vps,
+			sps:     sps,
+			pps:     pps,
+		}, nil
+
+	case *format.MPEG4Audio:
+		var conf *mpeg4audio.Config
+
+		if !forma.LATM {
+			conf = forma.Config
+		} else if forma.StreamMuxConfig != nil &&
+			len(forma.StreamMuxConfig.Programs) == 1 &&
+			len(forma.StreamMuxConfig.Programs[0].Layers) == 1 {
+			conf = forma.StreamMuxConfig.Programs[0].Layers[0].AudioSpecificConfig
+		}
+
+		if conf == nil {
+			return nil, nil
+		}
+
+		dec, err := forma.CreateDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		return &trackCodecMPEG4Audio{
+			decoder: dec,
+			config:  conf,
+		}, nil
+
+	case *format.Opus:
+		dec, err := forma.CreateDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		channelCount := 1
+		if forma.IsStereo {
+			channelCount = 2
+		}
+
+		return &trackCodecOpus{
+			decoder:      dec,
+			channelCount: channelCount,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+type trackCodecH264 struct {
+	decoder      *rtph264.Decoder
+	sps          []byte
+	pps          []byte
+	dtsExtractor *h264.DTSExtractor
+}
+
+func (c *trackCodecH264) isVideo() bool {
+	return true
+}
+
+func (c *trackCodecH264) decode(pkt *rtp.Packet, pts time.Duration) ([]*sample, error) {
+	au, err := c.decoder.Decode(pkt)
+	if err != nil {
+		if errors.Is(err, rtph264.ErrMorePacketsNeeded) ||
+			errors.Is(err, rtph264.ErrNonStartingPacketAndNoPrevious) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	filteredAU := make([][]byte, 0, len(au)+2)
+	randomAccess := false
+
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			c.sps = nalu
+			continue
+
+		case h264.NALUTypePPS:
+			c.pps = nalu
+			continue
+
+		case h264.NALUTypeAccessUnitDelimiter:
+			continue
+
+		case h264.NALUTypeIDR:
+			randomAccess = true
+		}
+
+		filteredAU = append(filteredAU, nalu)
+	}
+
+	if len(filteredAU) == 0 {
+		return nil, nil
+	}
+
+	if randomAccess {
+		if c.sps == nil || c.pps == nil {
+			return nil, nil
+		}
+		filteredAU = append([][]byte{c.sps, c.pps}, filteredAU...)
+	}
+
+	if c.dtsExtractor == nil {
+		// wait for a random access point
+		if !randomAccess {
+			return nil, nil
+		}
+		c.dtsExtractor = h264.NewDTSExtractor()
+	}
+
+	dts, err := c.dtsExtractor.Extract(filteredAU, pts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*sample{{
+		pts:          pts,
+		dts:          dts,
+		randomAccess: randomAccess,
+		au:           filteredAU,
+	}}, nil
+}
+
+type trackCodecH265 struct {
+	decoder      *rtph265.Decoder
+	vps          []byte
+	sps          []byte
+	pps          []byte
+	dtsExtractor *h265.DTSExtractor
+}
+
+func (c *trackCodecH265) isVideo() bool {
+	return true
+}
+
+func (c *trackCodecH265) decode(pkt *rtp.Packet, pts time.Duration) ([]*sample, error) {
+	au, err := c.decoder.Decode(pkt)
+	if err != nil {
+		if errors.Is(err, rtph265.ErrMorePacketsNeeded) ||
+			errors.Is(err, rtph265.ErrNonStartingPacketAndNoPrevious) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	filteredAU := make([][]byte, 0, len(au)+3)
+
+	for _, nalu := range au {
+		switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+		case h265.NALUType_VPS_NUT:
+			c.vps = nalu
+			continue
+
+		case h265.NALUType_SPS_NUT:
+			c.sps = nalu
+			continue
+
+		case h265.NALUType_PPS_NUT:
+			c.pps = nalu
+			continue
+
+		case h265.NALUType_AUD_NUT:
+			continue
+		}
+
+		filteredAU = append(filteredAU, nalu)
+	}
+
+	if len(filteredAU) == 0 {
+		return nil, nil
+	}
+
+	randomAccess := h265.IsRandomAccess(filteredAU)
+
+	if randomAccess {
+		if c.vps == nil || c.sps == nil || c.pps == nil {
+			return nil, nil
+		}
+		filteredAU = append([][]byte{c.vps, c.sps, c.pps}, filteredAU...)
+	}
+
+	if c.dtsExtractor == nil {
+		// wait for a random access point
+		if !randomAccess {
+			return nil, nil
+		}
+		c.dtsExtractor = h265.NewDTSExtractor()
+	}
+
+	dts, err := c.dtsExtractor.Extract(filteredAU, pts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*sample{{
+		pts:          pts,
+		dts:          dts,
+		randomAccess: randomAccess,
+		au:           filteredAU,
+	}}, nil
+}
+
+type trackCodecMPEG4Audio struct {
+	decoder *rtpmpeg4audio.Decoder
+	config  *mpeg4audio.Config
+}
+
+func (c *trackCodecMPEG4Audio) isVideo() bool {
+	return false
+}
+
+func (c *trackCodecMPEG4Audio) decode(pkt *rtp.Packet, pts time.Duration) ([]*sample, error) {
+	aus, err := c.decoder.Decode(pkt)
+	if err != nil {
+		if errors.Is(err, rtpmpeg4audio.ErrMorePacketsNeeded) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	samples := make([]*sample, len(aus))
+
+	for i, au := range aus {
+		auPTS := pts + time.Duration(i)*mpeg4audio.SamplesPerAccessUnit*
+			time.Second/time.Duration(c.config.SampleRate)
+
+		samples[i] = &sample{
+			pts:          auPTS,
+			dts:          auPTS,
+			randomAccess: true,
+			au:           [][]byte{au},
+		}
+	}
+
+	return samples, nil
+}
+
+type trackCodecOpus struct {
+	decoder      *rtpsimpleaudio.Decoder
+	channelCount int
+}
+
+func (c *trackCodecOpus) isVideo() bool {
+	return false
+}
+
+func (c *trackCodecOpus) decode(pkt *rtp.Packet, pts time.Duration) ([]*sample, error) {
+	frame, err := c.decoder.Decode(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*sample{{
+		pts:          pts,
+		dts:          pts,
+		randomAccess: true,
+		au:           [][]byte{frame},
+	}}, nil
+}
@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+type recorderConn struct {
+	net.Conn
+	r *Recorder
+}
+
+// Read implements net.Conn.
+func (c *recorderConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.r.add(DirectionServerToClient, p[:n])
+	}
+	return n, err
+}
+
+// Write implements net.Conn.
+func (c *recorderConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		c.r.add(DirectionClientToServer, p[:n])
+	}
+	return n, err
+}
+
+// Recorder records the control connection of a RTSP client.
+// Only data exchanged through the control connection is recorded,
+// therefore the session must use the TCP transport in order to be replayed entirely.
+type Recorder struct {
+	// function used to obtain the current time.
+	// It defaults to time.Now.
+	TimeNow func() time.Time
+
+	mutex   sync.Mutex
+	start   time.Time
+	entries []Entry
+}
+
+// DialContext wraps a dial function in order to record the created connection.
+// It can be passed to Client.DialContext.
+func (r *Recorder) DialContext(
+	dial func(ctx context.Context, network, address string) (net.Conn, error),
+) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		nconn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		return r.Wrap(nconn), nil
+	}
+}
+
+// Wrap wraps a connection in order to record it.
+func (r *Recorder) Wrap(nconn net.Conn) net.Conn {
+	return &recorderConn{
+		Conn: nconn,
+		r:    r,
+	}
+}
+
+// Recording returns what has been recorded until now.
+func (r *Recorder) Recording() *Recording {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+
+	return &Recording{Entries: entries}
+}
+
+func (r *Recorder) add(dir Direction, p []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	timeNow := r.TimeNow
+	if timeNow == nil {
+		timeNow = time.Now
+	}
+
+	now := timeNow()
+	if r.entries == nil {
+		r.start = now
+	}
+
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	r.entries = append(r.entries, Entry{
+		Time:      now.Sub(r.start),
+		Direction: dir,
+		Data:      data,
+	})
+}
@@ -0,0 +1,112 @@
+// Package replay contains utilities to record a RTSP session and to replay it with a mock server.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Direction is the direction of a recorded chunk of data.
+type Direction int
+
+// directions.
+const (
+	DirectionClientToServer Direction = iota
+	DirectionServerToClient
+)
+
+// String implements fmt.Stringer.
+func (d Direction) String() string {
+	switch d {
+	case DirectionClientToServer:
+		return "c2s"
+
+	case DirectionServerToClient:
+		return "s2c"
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Direction) MarshalText() ([]byte, error) {
+	switch d {
+	case DirectionClientToServer, DirectionServerToClient:
+		return []byte(d.String()), nil
+	}
+	return nil, fmt.Errorf("invalid direction: %d", int(d))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Direction) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "c2s":
+		*d = DirectionClientToServer
+
+	case "s2c":
+		*d = DirectionServerToClient
+
+	default:
+		return fmt.Errorf("invalid direction: '%s'", b)
+	}
+	return nil
+}
+
+// Entry is a chunk of data exchanged through the control connection.
+type Entry struct {
+	// time elapsed since the beginning of the recording.
+	Time time.Duration `json:"time"`
+
+	// direction of the chunk.
+	Direction Direction `json:"dir"`
+
+	// raw bytes.
+	Data []byte `json:"data"`
+}
+
+// Recording is a recorded RTSP session.
+type Recording struct {
+	Entries []Entry
+}
+
+// Unmarshal decodes a recording.
+// The format consists in an Entry encoded in JSON for each line.
+func (r *Recording) Unmarshal(rd io.Reader) error {
+	r.Entries = nil
+
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var e Entry
+		err := json.Unmarshal(line, &e)
+		if err != nil {
+			return fmt.Errorf("invalid entry: %v", err)
+		}
+
+		r.Entries = append(r.Entries, e)
+	}
+
+	return scanner.Err()
+}
+
+// Marshal encodes a recording.
+func (r Recording) Marshal(w io.Writer) error {
+	enc := json.NewEncoder(w)
+
+	for _, e := range r.Entries {
+		err := enc.Encode(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
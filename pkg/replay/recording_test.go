@@ -0,0 +1,41 @@
+package replay
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingMarshalUnmarshal(t *testing.T) {
+	rec := Recording{
+		Entries: []Entry{
+			{
+				Time:      0,
+				Direction: DirectionClientToServer,
+				Data:      []byte("OPTIONS rtsp://localhost:8554/teststream RTSP/1.0\r\nCSeq: 1\r\n\r\n"),
+			},
+			{
+				Time:      15 * time.Millisecond,
+				Direction: DirectionServerToClient,
+				Data:      []byte("RTSP/1.0 200 OK\r\nCSeq: 1\r\n\r\n"),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := rec.Marshal(&buf)
+	require.NoError(t, err)
+
+	var dec Recording
+	err = dec.Unmarshal(&buf)
+	require.NoError(t, err)
+	require.Equal(t, rec, dec)
+}
+
+func TestRecordingUnmarshalInvalidDirection(t *testing.T) {
+	var dec Recording
+	err := dec.Unmarshal(bytes.NewReader([]byte(`{"time":0,"dir":"abc","data":""}` + "\n")))
+	require.EqualError(t, err, "invalid entry: invalid direction: 'abc'")
+}
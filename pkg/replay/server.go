@@ -0,0 +1,344 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/conn"
+)
+
+type message struct {
+	time      time.Duration
+	direction Direction
+	content   interface{}
+}
+
+// parseDirection decodes the messages sent in a given direction.
+// Each message is associated with the time of the chunk that completed it.
+func parseDirection(entries []Entry, dir Direction) ([]message, error) {
+	var buf []byte
+	var ends []int
+	var times []time.Duration
+
+	for _, e := range entries {
+		if e.Direction == dir {
+			buf = append(buf, e.Data...)
+			ends = append(ends, len(buf))
+			times = append(times, e.Time)
+		}
+	}
+
+	if len(buf) == 0 {
+		return nil, nil
+	}
+
+	rd := bytes.NewReader(buf)
+	br := bufio.NewReader(rd)
+	var ret []message
+
+	for {
+		byts, err := br.Peek(2)
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode recorded message: %v", err)
+		}
+
+		var what interface{}
+
+		switch {
+		case byts[0] == base.InterleavedFrameMagicByte:
+			var fr base.InterleavedFrame
+			err = fr.Unmarshal(br)
+			what = &fr
+
+		case byts[0] == 'R' && byts[1] == 'T':
+			var res base.Response
+			err = res.Unmarshal(br)
+			what = &res
+
+		default:
+			var req base.Request
+			err = req.Unmarshal(br)
+			what = &req
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode recorded message: %v", err)
+		}
+
+		consumed := len(buf) - rd.Len() - br.Buffered()
+		i := sort.SearchInts(ends, consumed)
+
+		ret = append(ret, message{
+			time:      times[i],
+			direction: dir,
+			content:   what,
+		})
+	}
+}
+
+func readIgnoreFrames(c *conn.Conn) (interface{}, error) {
+	for {
+		what, err := c.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := what.(*base.InterleavedFrame); !ok {
+			return what, nil
+		}
+	}
+}
+
+// Server is a mock RTSP server that replays a Recording.
+// Every accepted connection receives the entire recorded exchange:
+// requests of the client are awaited and recorded responses, requests
+// and interleaved frames are sent back.
+type Server struct {
+	// address to listen on.
+	Address string
+	// recording to replay.
+	Recording *Recording
+	// send messages as fast as possible instead of respecting recorded timings.
+	IgnoreTiming bool
+	// timeout of read operations.
+	// It defaults to 10 seconds.
+	ReadTimeout time.Duration
+	// timeout of write operations.
+	// It defaults to 10 seconds.
+	WriteTimeout time.Duration
+	// function used to initialize the TCP listener.
+	// It defaults to net.Listen.
+	Listen func(network string, address string) (net.Listener, error)
+	// called when a connection is closed because of an error.
+	OnConnError func(error)
+
+	ctx       context.Context
+	ctxCancel func()
+	wg        sync.WaitGroup
+	ln        net.Listener
+	messages  []message
+	closeErr  error
+}
+
+// Start starts the server.
+func (s *Server) Start() error {
+	if s.Recording == nil {
+		return fmt.Errorf("Recording not provided")
+	}
+	if s.ReadTimeout == 0 {
+		s.ReadTimeout = 10 * time.Second
+	}
+	if s.WriteTimeout == 0 {
+		s.WriteTimeout = 10 * time.Second
+	}
+	if s.Listen == nil {
+		s.Listen = net.Listen
+	}
+	if s.OnConnError == nil {
+		s.OnConnError = func(err error) {
+			log.Println(err.Error())
+		}
+	}
+
+	c2s, err := parseDirection(s.Recording.Entries, DirectionClientToServer)
+	if err != nil {
+		return err
+	}
+
+	s2c, err := parseDirection(s.Recording.Entries, DirectionServerToClient)
+	if err != nil {
+		return err
+	}
+
+	s.messages = append(c2s, s2c...)
+	sort.SliceStable(s.messages, func(i, j int) bool {
+		return s.messages[i].time < s.messages[j].time
+	})
+
+	s.ln, err = s.Listen("tcp", s.Address)
+	if err != nil {
+		return err
+	}
+
+	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
+
+	s.wg.Add(1)
+	go s.run()
+
+	return nil
+}
+
+// Close closes the server and waits for all its resources to be released.
+func (s *Server) Close() {
+	s.ctxCancel()
+	s.ln.Close()
+	s.wg.Wait()
+}
+
+// Wait waits until the server is closed.
+func (s *Server) Wait() error {
+	s.wg.Wait()
+	return s.closeErr
+}
+
+func (s *Server) run() {
+	defer s.wg.Done()
+
+	for {
+		nconn, err := s.ln.Accept()
+		if err != nil {
+			select {
+			case <-s.ctx.Done():
+			default:
+				s.closeErr = err
+			}
+			s.ctxCancel()
+			return
+		}
+
+		s.wg.Add(1)
+		go s.runConn(nconn)
+	}
+}
+
+func (s *Server) runConn(nconn net.Conn) {
+	defer s.wg.Done()
+
+	connDone := make(chan struct{})
+	defer close(connDone)
+
+	go func() {
+		select {
+		case <-s.ctx.Done():
+		case <-connDone:
+		}
+		nconn.Close()
+	}()
+
+	err := s.replay(nconn)
+	if err != nil && s.ctx.Err() == nil {
+		s.OnConnError(err)
+	}
+}
+
+func (s *Server) sleep(d time.Duration) error {
+	if s.IgnoreTiming || d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
+
+func (s *Server) replay(nconn net.Conn) error {
+	c := conn.NewConn(nconn)
+	var cseq base.HeaderValue
+	var prevTime time.Duration
+	buf := make([]byte, 4+65535)
+
+	for _, m := range s.messages {
+		switch m.direction {
+		case DirectionClientToServer:
+			switch recorded := m.content.(type) {
+			case *base.Request:
+				nconn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+				what, err := readIgnoreFrames(c)
+				if err != nil {
+					return err
+				}
+
+				req, ok := what.(*base.Request)
+				if !ok {
+					return fmt.Errorf("expected request, received %T", what)
+				}
+
+				if req.Method != recorded.Method {
+					return fmt.Errorf("expected request with method %s, received %s", recorded.Method, req.Method)
+				}
+
+				cseq = req.Header["CSeq"]
+
+			case *base.Response:
+				nconn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+				what, err := readIgnoreFrames(c)
+				if err != nil {
+					return err
+				}
+
+				if _, ok := what.(*base.Response); !ok {
+					return fmt.Errorf("expected response, received %T", what)
+				}
+
+			default:
+				// frames sent by the client are not awaited.
+				continue
+			}
+
+		case DirectionServerToClient:
+			err := s.sleep(m.time - prevTime)
+			if err != nil {
+				return err
+			}
+
+			nconn.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+
+			switch recorded := m.content.(type) {
+			case *base.Response:
+				// responses are matched with requests by using CSeq,
+				// use the one of the current request.
+				res := &base.Response{
+					StatusCode:    recorded.StatusCode,
+					StatusMessage: recorded.StatusMessage,
+					Header:        make(base.Header),
+					Body:          recorded.Body,
+				}
+				for k, v := range recorded.Header {
+					res.Header[k] = v
+				}
+				if cseq != nil {
+					res.Header["CSeq"] = cseq
+				}
+
+				err = c.WriteResponse(res)
+
+			case *base.Request:
+				err = c.WriteRequest(recorded)
+
+			case *base.InterleavedFrame:
+				err = c.WriteInterleavedFrame(recorded, buf)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		prevTime = m.time
+	}
+
+	// wait for the client to close the connection
+	nconn.SetReadDeadline(time.Time{})
+	for {
+		_, err := nconn.Read(buf)
+		if err != nil {
+			return nil
+		}
+	}
+}
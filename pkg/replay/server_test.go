@@ -0,0 +1,185 @@
+package replay
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/conn"
+)
+
+func mustParseURL(s string) *base.URL {
+	u, err := base.ParseURL(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func doExchange(t *testing.T, nconn net.Conn, firstCSeq int) {
+	c := conn.NewConn(nconn)
+
+	err := c.WriteRequest(&base.Request{
+		Method: base.Options,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{strconv.Itoa(firstCSeq)},
+		},
+	})
+	require.NoError(t, err)
+
+	res, err := c.ReadResponse()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, base.HeaderValue{strconv.Itoa(firstCSeq)}, res.Header["CSeq"])
+	require.Equal(t, base.HeaderValue{"DESCRIBE, SETUP, PLAY"}, res.Header["Public"])
+
+	err = c.WriteRequest(&base.Request{
+		Method: base.Play,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{strconv.Itoa(firstCSeq + 1)},
+		},
+	})
+	require.NoError(t, err)
+
+	res, err = c.ReadResponse()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, base.HeaderValue{strconv.Itoa(firstCSeq + 1)}, res.Header["CSeq"])
+
+	fr, err := c.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, &base.InterleavedFrame{
+		Channel: 0,
+		Payload: []byte{1, 2, 3, 4},
+	}, fr)
+}
+
+func TestServer(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+
+	serverDone := make(chan struct{})
+
+	go func() {
+		defer close(serverDone)
+
+		nconn, err2 := l.Accept()
+		require.NoError(t, err2)
+		defer nconn.Close()
+		c := conn.NewConn(nconn)
+
+		req, err2 := c.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Options, req.Method)
+
+		err2 = c.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"CSeq":   req.Header["CSeq"],
+				"Public": base.HeaderValue{"DESCRIBE, SETUP, PLAY"},
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = c.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Play, req.Method)
+
+		err2 = c.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"CSeq": req.Header["CSeq"],
+			},
+		})
+		require.NoError(t, err2)
+
+		err2 = c.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: []byte{1, 2, 3, 4},
+		}, make([]byte, 1024))
+		require.NoError(t, err2)
+	}()
+
+	var r Recorder
+	dial := r.DialContext((&net.Dialer{}).DialContext)
+
+	nconn, err := dial(context.Background(), "tcp", "localhost:8554")
+	require.NoError(t, err)
+	doExchange(t, nconn, 1)
+	nconn.Close()
+
+	<-serverDone
+	l.Close()
+
+	rec := r.Recording()
+	require.NotEmpty(t, rec.Entries)
+
+	s := &Server{
+		Address:      "localhost:8554",
+		Recording:    rec,
+		IgnoreTiming: true,
+		OnConnError: func(err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
+	}
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err = net.DialTimeout("tcp", "localhost:8554", 5*time.Second)
+	require.NoError(t, err)
+	defer nconn.Close()
+
+	// CSeq of responses must be adapted to the ones of requests.
+	doExchange(t, nconn, 5)
+}
+
+func TestServerUnexpectedRequest(t *testing.T) {
+	s := &Server{
+		Address: "localhost:8554",
+		Recording: &Recording{
+			Entries: []Entry{
+				{
+					Direction: DirectionClientToServer,
+					Data:      []byte("OPTIONS rtsp://localhost:8554/teststream RTSP/1.0\r\nCSeq: 1\r\n\r\n"),
+				},
+				{
+					Direction: DirectionServerToClient,
+					Data:      []byte("RTSP/1.0 200 OK\r\nCSeq: 1\r\n\r\n"),
+				},
+			},
+		},
+		IgnoreTiming: true,
+	}
+
+	connErr := make(chan error, 1)
+	s.OnConnError = func(err error) {
+		connErr <- err
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err := net.DialTimeout("tcp", "localhost:8554", 5*time.Second)
+	require.NoError(t, err)
+	defer nconn.Close()
+
+	err = conn.NewConn(nconn).WriteRequest(&base.Request{
+		Method: base.Describe,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.EqualError(t, <-connErr, "expected request with method OPTIONS, received DESCRIBE")
+}
@@ -9,11 +9,13 @@ import (
 // RingBuffer is a ring buffer.
 type RingBuffer struct {
 	size       uint64
+	mask       uint64
 	mutex      sync.Mutex
 	cond       *sync.Cond
 	buffer     []interface{}
 	readIndex  uint64
 	writeIndex uint64
+	waiting    int
 	closed     bool
 }
 
@@ -27,6 +29,7 @@ func New(size uint64) (*RingBuffer, error) {
 
 	r := &RingBuffer{
 		size:   size,
+		mask:   size - 1,
 		buffer: make([]interface{}, size),
 	}
 
@@ -71,11 +74,17 @@ func (r *RingBuffer) Push(data interface{}) bool {
 	}
 
 	r.buffer[r.writeIndex] = data
-	r.writeIndex = (r.writeIndex + 1) % r.size
+	r.writeIndex = (r.writeIndex + 1) & r.mask
+
+	// wake up Pull() only when it is waiting, since Broadcast() is expensive
+	// when called for every pushed item.
+	waiting := r.waiting != 0
 
 	r.mutex.Unlock()
 
-	r.cond.Broadcast()
+	if waiting {
+		r.cond.Broadcast()
+	}
 
 	return true
 }
@@ -89,7 +98,7 @@ func (r *RingBuffer) Pull() (interface{}, bool) {
 
 		if data != nil {
 			r.buffer[r.readIndex] = nil
-			r.readIndex = (r.readIndex + 1) % r.size
+			r.readIndex = (r.readIndex + 1) & r.mask
 			r.mutex.Unlock()
 			return data, true
 		}
@@ -99,8 +108,39 @@ func (r *RingBuffer) Pull() (interface{}, bool) {
 			return nil, false
 		}
 
+		r.waiting++
 		r.cond.Wait()
+		r.waiting--
 
 		r.mutex.Unlock()
 	}
 }
+
+// Pop removes data from the beginning of the buffer without waiting.
+// It returns false if the buffer is empty.
+func (r *RingBuffer) Pop() (interface{}, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	data := r.buffer[r.readIndex]
+	if data == nil {
+		return nil, false
+	}
+
+	r.buffer[r.readIndex] = nil
+	r.readIndex = (r.readIndex + 1) & r.mask
+	return data, true
+}
+
+// Len returns the number of items in the buffer.
+func (r *RingBuffer) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// when the buffer is full, writeIndex reached readIndex
+	if r.buffer[r.readIndex] != nil && r.writeIndex == r.readIndex {
+		return int(r.size)
+	}
+
+	return int((r.writeIndex - r.readIndex) & r.mask)
+}
@@ -93,6 +93,58 @@ func TestOverflow(t *testing.T) {
 	}
 }
 
+func TestPop(t *testing.T) {
+	r, err := New(2)
+	require.NoError(t, err)
+
+	_, ok := r.Pop()
+	require.Equal(t, false, ok)
+
+	r.Push([]byte{1, 2, 3, 4})
+	r.Push([]byte{5, 6, 7, 8})
+
+	ok = r.Push([]byte{9, 10, 11, 12})
+	require.Equal(t, false, ok)
+
+	data, ok := r.Pop()
+	require.Equal(t, true, ok)
+	require.Equal(t, []byte{1, 2, 3, 4}, data)
+
+	ok = r.Push([]byte{9, 10, 11, 12})
+	require.Equal(t, true, ok)
+
+	data, ok = r.Pull()
+	require.Equal(t, true, ok)
+	require.Equal(t, []byte{5, 6, 7, 8}, data)
+
+	data, ok = r.Pull()
+	require.Equal(t, true, ok)
+	require.Equal(t, []byte{9, 10, 11, 12}, data)
+}
+
+func TestLen(t *testing.T) {
+	r, err := New(4)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, r.Len())
+
+	for i := 0; i < 4; i++ {
+		r.Push([]byte{1})
+		require.Equal(t, i+1, r.Len())
+	}
+
+	r.Pop()
+	require.Equal(t, 3, r.Len())
+
+	r.Push([]byte{2})
+	require.Equal(t, 4, r.Len())
+
+	for i := 0; i < 4; i++ {
+		r.Pop()
+	}
+	require.Equal(t, 0, r.Len())
+}
+
 func BenchmarkPushPullContinuous(b *testing.B) {
 	r, _ := New(1024 * 8)
 	defer r.Close()
@@ -0,0 +1,103 @@
+// Package rtcpapp contains the application-defined (APP) RTCP packet.
+package rtcpapp
+
+import (
+	"fmt"
+
+	"github.com/pion/rtcp"
+)
+
+const headerSize = 12
+
+// Packet is an application-defined (APP) RTCP packet.
+// It can be written with WritePacketRTCP, while received APP packets
+// are returned as *rtcp.RawPacket and can be decoded with Unmarshal.
+// Specification: https://datatracker.ietf.org/doc/html/rfc3550#section-6.7
+type Packet struct {
+	// application-dependent subtype (5 bits).
+	SubType uint8
+
+	// SSRC or CSRC of the sender.
+	SSRC uint32
+
+	// name of the application (4 ASCII characters).
+	Name [4]byte
+
+	// application-dependent data.
+	// Its length must be a multiple of 4.
+	Data []byte
+}
+
+var _ rtcp.Packet = (*Packet)(nil)
+
+// Unmarshal decodes a packet.
+func (p *Packet) Unmarshal(buf []byte) error {
+	var h rtcp.Header
+	err := h.Unmarshal(buf)
+	if err != nil {
+		return err
+	}
+
+	if h.Type != rtcp.TypeApplicationDefined {
+		return fmt.Errorf("wrong packet type: %v", h.Type)
+	}
+
+	size := (int(h.Length) + 1) * 4
+	if size < headerSize || len(buf) < size {
+		return fmt.Errorf("invalid packet size")
+	}
+
+	end := size
+	if h.Padding {
+		padding := int(buf[size-1])
+		if padding == 0 || (size-padding) < headerSize {
+			return fmt.Errorf("invalid padding")
+		}
+		end -= padding
+	}
+
+	p.SubType = h.Count
+	p.SSRC = uint32(buf[4])<<24 | uint32(buf[5])<<16 | uint32(buf[6])<<8 | uint32(buf[7])
+	copy(p.Name[:], buf[8:12])
+	p.Data = append([]byte(nil), buf[headerSize:end]...)
+
+	return nil
+}
+
+// Marshal encodes a packet.
+func (p *Packet) Marshal() ([]byte, error) {
+	if p.SubType > 0x1F {
+		return nil, fmt.Errorf("invalid subtype")
+	}
+
+	if (len(p.Data) % 4) != 0 {
+		return nil, fmt.Errorf("data length must be a multiple of 4")
+	}
+
+	buf := make([]byte, headerSize+len(p.Data))
+
+	h := rtcp.Header{
+		Count:  p.SubType,
+		Type:   rtcp.TypeApplicationDefined,
+		Length: uint16(len(buf)/4 - 1),
+	}
+	hbuf, err := h.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	copy(buf, hbuf)
+	buf[4] = byte(p.SSRC >> 24)
+	buf[5] = byte(p.SSRC >> 16)
+	buf[6] = byte(p.SSRC >> 8)
+	buf[7] = byte(p.SSRC)
+	copy(buf[8:], p.Name[:])
+	copy(buf[headerSize:], p.Data)
+
+	return buf, nil
+}
+
+// DestinationSSRC implements rtcp.Packet.
+func (p *Packet) DestinationSSRC() []uint32 {
+	return []uint32{p.SSRC}
+}
@@ -0,0 +1,47 @@
+package rtcpapp
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+var casePacket = Packet{
+	SubType: 3,
+	SSRC:    0x9dbb7812,
+	Name:    [4]byte{'P', 'T', 'Z', 'C'},
+	Data:    []byte{0x01, 0x02, 0x03, 0x04},
+}
+
+var casePacketEnc = []byte{
+	0x83, 0xcc, 0x00, 0x03, 0x9d, 0xbb, 0x78, 0x12,
+	0x50, 0x54, 0x5a, 0x43, 0x01, 0x02, 0x03, 0x04,
+}
+
+func TestMarshal(t *testing.T) {
+	buf, err := casePacket.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, casePacketEnc, buf)
+}
+
+func TestUnmarshal(t *testing.T) {
+	// APP packets are returned as raw packets by the RTCP library
+	pkts, err := rtcp.Unmarshal(casePacketEnc)
+	require.NoError(t, err)
+
+	raw, ok := pkts[0].(*rtcp.RawPacket)
+	require.Equal(t, true, ok)
+
+	var p Packet
+	err = p.Unmarshal(*raw)
+	require.NoError(t, err)
+	require.Equal(t, casePacket, p)
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var p Packet
+		p.Unmarshal(b) //nolint:errcheck
+	})
+}
@@ -0,0 +1,142 @@
+// Package rtcpcongestion contains utilities to generate RTCP congestion control feedback,
+// in the form of REMB and transport-wide congestion control (TWCC) packets.
+package rtcpcongestion
+
+import (
+	"crypto/rand"
+	"sort"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultREMBPeriod = 1 * time.Second
+
+	// loss-based estimation parameters, taken from
+	// https://datatracker.ietf.org/doc/html/draft-ietf-rmcat-gcc-02#section-6
+	rembLowLoss      = 0.02
+	rembHighLoss     = 0.1
+	rembIncreaseRate = 1.05
+	// the estimate can't exceed the received bitrate by more than this factor.
+	rembMaxGrowth = 1.5
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// REMBGenerator measures bitrate and losses of received RTP packets
+// and periodically generates REMB packets.
+// Specification: https://datatracker.ietf.org/doc/html/draft-alvestrand-rmcat-remb-03
+type REMBGenerator struct {
+	// SSRC of generated packets (optional).
+	// It defaults to a random value.
+	SenderSSRC *uint32
+
+	// period of generated packets (optional).
+	// It defaults to 1 second.
+	Period time.Duration
+
+	periodStart    time.Time
+	bytes          uint64
+	received       uint64
+	expected       uint64
+	ssrcs          map[uint32]uint16 // SSRC -> last sequence number
+	estimate       float64
+	estimateExists bool
+}
+
+// Init initializes the generator.
+func (g *REMBGenerator) Init() error {
+	if g.SenderSSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		g.SenderSSRC = &v
+	}
+	if g.Period == 0 {
+		g.Period = defaultREMBPeriod
+	}
+
+	g.ssrcs = make(map[uint32]uint16)
+
+	return nil
+}
+
+// ProcessPacket processes a received RTP packet.
+// size is the size of the packet on the wire.
+// It returns a REMB packet when the period has elapsed, otherwise nil.
+func (g *REMBGenerator) ProcessPacket(pkt *rtp.Packet, size int, now time.Time) *rtcp.ReceiverEstimatedMaximumBitrate {
+	if g.periodStart.IsZero() {
+		g.periodStart = now
+	}
+
+	g.bytes += uint64(size)
+	g.received++
+
+	if lastSeqNum, ok := g.ssrcs[pkt.SSRC]; ok {
+		diff := int16(pkt.SequenceNumber - lastSeqNum)
+		if diff > 0 {
+			g.expected += uint64(diff)
+			g.ssrcs[pkt.SSRC] = pkt.SequenceNumber
+		}
+	} else {
+		g.expected++
+		g.ssrcs[pkt.SSRC] = pkt.SequenceNumber
+	}
+
+	elapsed := now.Sub(g.periodStart)
+	if elapsed < g.Period {
+		return nil
+	}
+
+	receivedBitrate := float64(g.bytes*8) / elapsed.Seconds()
+
+	loss := float64(0)
+	if g.expected > g.received {
+		loss = float64(g.expected-g.received) / float64(g.expected)
+	}
+
+	switch {
+	case !g.estimateExists:
+		g.estimate = receivedBitrate
+		g.estimateExists = true
+
+	case loss > rembHighLoss:
+		g.estimate *= 1 - 0.5*loss
+
+	case loss < rembLowLoss:
+		g.estimate *= rembIncreaseRate
+	}
+
+	if maxEstimate := receivedBitrate * rembMaxGrowth; g.estimate > maxEstimate {
+		g.estimate = maxEstimate
+	}
+
+	ssrcs := make([]uint32, 0, len(g.ssrcs))
+	for ssrc := range g.ssrcs {
+		ssrcs = append(ssrcs, ssrc)
+	}
+	sort.Slice(ssrcs, func(i, j int) bool {
+		return ssrcs[i] < ssrcs[j]
+	})
+
+	g.periodStart = now
+	g.bytes = 0
+	g.received = 0
+	g.expected = 0
+
+	return &rtcp.ReceiverEstimatedMaximumBitrate{
+		SenderSSRC: *g.SenderSSRC,
+		Bitrate:    float32(g.estimate),
+		SSRCs:      ssrcs,
+	}
+}
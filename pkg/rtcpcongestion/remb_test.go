@@ -0,0 +1,77 @@
+package rtcpcongestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func TestREMBGenerator(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		lost    func(i int) bool
+		bitrate float32
+	}{
+		{
+			"no loss",
+			func(int) bool { return false },
+			1010000 * rembIncreaseRate,
+		},
+		{
+			"medium loss",
+			func(i int) bool { return (i % 20) == 0 },
+			1010000,
+		},
+		{
+			"high loss",
+			func(i int) bool { return (i % 5) == 0 },
+			1010000 * (1 - 0.5*20.0/101),
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			g := &REMBGenerator{
+				SenderSSRC: uint32Ptr(0x65f83afb),
+			}
+			err := g.Init()
+			require.NoError(t, err)
+
+			t0 := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+			var rembs []*rtcp.ReceiverEstimatedMaximumBitrate
+
+			for i := 0; i <= 201; i++ {
+				if i > 101 && i < 201 && ca.lost(i) {
+					continue
+				}
+
+				// 1250 bytes every 10ms = 1 Mbit/s
+				remb := g.ProcessPacket(&rtp.Packet{
+					Header: rtp.Header{
+						SequenceNumber: uint16(i),
+						SSRC:           0x9dbb7812,
+					},
+				}, 1250, t0.Add(time.Duration(i)*10*time.Millisecond))
+				if remb != nil {
+					rembs = append(rembs, remb)
+				}
+			}
+
+			require.Len(t, rembs, 2)
+
+			require.Equal(t, &rtcp.ReceiverEstimatedMaximumBitrate{
+				SenderSSRC: 0x65f83afb,
+				Bitrate:    1010000,
+				SSRCs:      []uint32{0x9dbb7812},
+			}, rembs[0])
+
+			require.InDelta(t, ca.bitrate, rembs[1].Bitrate, 1)
+		})
+	}
+}
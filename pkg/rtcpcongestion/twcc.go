@@ -0,0 +1,192 @@
+package rtcpcongestion
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultTWCCPeriod = 100 * time.Millisecond
+
+	// resolution of the reference time.
+	twccReferenceTimeUnit = 64 * time.Millisecond
+
+	// maximum run length of a run length chunk.
+	twccMaxRunLength = 1<<13 - 1
+
+	// maximum number of packets described by a single feedback.
+	twccMaxPackets = 1 << 14
+)
+
+type twccArrival struct {
+	seqNum uint16
+	time   time.Time
+}
+
+// TWCCGenerator records arrival times of received RTP packets that carry
+// a transport-wide sequence number and periodically generates TWCC packets.
+// Specification: https://datatracker.ietf.org/doc/html/draft-holmer-rmcat-transport-wide-cc-extensions-01
+type TWCCGenerator struct {
+	// ID of the transport-wide sequence number header extension.
+	ExtensionID uint8
+
+	// SSRC of generated packets (optional).
+	// It defaults to a random value.
+	SenderSSRC *uint32
+
+	// period of generated packets (optional).
+	// It defaults to 100 milliseconds.
+	Period time.Duration
+
+	timeZero    time.Time
+	periodStart time.Time
+	mediaSSRC   uint32
+	arrivals    []twccArrival
+	fbPktCount  uint8
+}
+
+// Init initializes the generator.
+func (g *TWCCGenerator) Init() error {
+	if g.SenderSSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		g.SenderSSRC = &v
+	}
+	if g.Period == 0 {
+		g.Period = defaultTWCCPeriod
+	}
+
+	return nil
+}
+
+// ProcessPacket processes a received RTP packet.
+// It returns a TWCC packet when the period has elapsed, otherwise nil.
+func (g *TWCCGenerator) ProcessPacket(pkt *rtp.Packet, now time.Time) *rtcp.TransportLayerCC {
+	ext := pkt.GetExtension(g.ExtensionID)
+	if len(ext) < 2 {
+		return nil
+	}
+
+	if g.timeZero.IsZero() {
+		g.timeZero = now
+		g.periodStart = now
+	}
+
+	g.mediaSSRC = pkt.SSRC
+
+	if len(g.arrivals) < twccMaxPackets {
+		g.arrivals = append(g.arrivals, twccArrival{
+			seqNum: binary.BigEndian.Uint16(ext),
+			time:   now,
+		})
+	}
+
+	if now.Sub(g.periodStart) < g.Period {
+		return nil
+	}
+
+	g.periodStart = now
+
+	return g.feedback()
+}
+
+func (g *TWCCGenerator) feedback() *rtcp.TransportLayerCC {
+	arrivals := g.arrivals
+	g.arrivals = nil
+
+	// sort by sequence number, taking into account overflows
+	ref := arrivals[0].seqNum
+	sort.SliceStable(arrivals, func(i, j int) bool {
+		return int16(arrivals[i].seqNum-ref) < int16(arrivals[j].seqNum-ref)
+	})
+
+	baseSeqNum := arrivals[0].seqNum
+	count := int(arrivals[len(arrivals)-1].seqNum-baseSeqNum) + 1
+
+	referenceTime := arrivals[0].time.Sub(g.timeZero) / twccReferenceTimeUnit
+	prevTime := g.timeZero.Add(referenceTime * twccReferenceTimeUnit)
+
+	symbols := make([]uint16, count)
+	var deltas []*rtcp.RecvDelta
+	size := 0 // size of deltas
+
+	for _, a := range arrivals {
+		pos := int(a.seqNum - baseSeqNum)
+
+		// duplicate
+		if symbols[pos] != rtcp.TypeTCCPacketNotReceived {
+			continue
+		}
+
+		units := int64(a.time.Sub(prevTime)/time.Microsecond) / rtcp.TypeTCCDeltaScaleFactor
+
+		if units >= 0 && units <= math.MaxUint8 {
+			symbols[pos] = rtcp.TypeTCCPacketReceivedSmallDelta
+			size++
+		} else {
+			if units > math.MaxInt16 {
+				units = math.MaxInt16
+			} else if units < math.MinInt16 {
+				units = math.MinInt16
+			}
+			symbols[pos] = rtcp.TypeTCCPacketReceivedLargeDelta
+			size += 2
+		}
+
+		delta := units * rtcp.TypeTCCDeltaScaleFactor
+
+		deltas = append(deltas, &rtcp.RecvDelta{
+			Type:  symbols[pos],
+			Delta: delta,
+		})
+
+		// use the quantized delta, in order not to accumulate errors
+		prevTime = prevTime.Add(time.Duration(delta) * time.Microsecond)
+	}
+
+	var chunks []rtcp.PacketStatusChunk
+
+	for i := 0; i < len(symbols); {
+		n := 1
+		for i+n < len(symbols) && symbols[i+n] == symbols[i] && n < twccMaxRunLength {
+			n++
+		}
+
+		chunks = append(chunks, &rtcp.RunLengthChunk{
+			Type:               rtcp.TypeTCCRunLengthChunk,
+			PacketStatusSymbol: symbols[i],
+			RunLength:          uint16(n),
+		})
+
+		i += n
+	}
+	size += 20 + len(chunks)*2 // header, fixed fields and chunks
+
+	pkt := &rtcp.TransportLayerCC{
+		SenderSSRC:         *g.SenderSSRC,
+		MediaSSRC:          g.mediaSSRC,
+		BaseSequenceNumber: baseSeqNum,
+		PacketStatusCount:  uint16(count),
+		ReferenceTime:      uint32(referenceTime) & 0xFFFFFF,
+		FbPktCount:         g.fbPktCount,
+		PacketChunks:       chunks,
+		RecvDeltas:         deltas,
+	}
+	g.fbPktCount++
+
+	pkt.Header = rtcp.Header{
+		Padding: (size % 4) != 0,
+		Count:   rtcp.FormatTCC,
+		Type:    rtcp.TypeTransportSpecificFeedback,
+		Length:  pkt.Len()/4 - 1,
+	}
+
+	return pkt
+}
@@ -0,0 +1,102 @@
+package rtcpcongestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTWCCGenerator(t *testing.T) {
+	g := &TWCCGenerator{
+		ExtensionID: 3,
+		SenderSSRC:  uint32Ptr(0x65f83afb),
+	}
+	err := g.Init()
+	require.NoError(t, err)
+
+	t0 := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	var fb *rtcp.TransportLayerCC
+
+	for i, ca := range []struct {
+		seqNum uint16
+		delay  time.Duration
+	}{
+		{65534, 0},
+		{65535, 10 * time.Millisecond},
+		// 0 is lost
+		{2, 20 * time.Millisecond},
+		{1, 21 * time.Millisecond},
+		{3, 120 * time.Millisecond},
+	} {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				SequenceNumber: uint16(i),
+				SSRC:           0x9dbb7812,
+			},
+		}
+		err = pkt.Header.SetExtension(3, []byte{byte(ca.seqNum >> 8), byte(ca.seqNum)})
+		require.NoError(t, err)
+
+		ret := g.ProcessPacket(pkt, t0.Add(ca.delay))
+		if i < 4 {
+			require.Nil(t, ret)
+		} else {
+			fb = ret
+		}
+	}
+
+	byts, err := fb.Marshal()
+	require.NoError(t, err)
+
+	var dec rtcp.TransportLayerCC
+	err = dec.Unmarshal(byts)
+	require.NoError(t, err)
+
+	require.Equal(t, rtcp.TransportLayerCC{
+		Header: rtcp.Header{
+			Padding: true,
+			Count:   rtcp.FormatTCC,
+			Type:    rtcp.TypeTransportSpecificFeedback,
+			Length:  8,
+		},
+		SenderSSRC:         0x65f83afb,
+		MediaSSRC:          0x9dbb7812,
+		BaseSequenceNumber: 65534,
+		PacketStatusCount:  6,
+		ReferenceTime:      0,
+		FbPktCount:         0,
+		PacketChunks: []rtcp.PacketStatusChunk{
+			&rtcp.RunLengthChunk{
+				Type:               rtcp.TypeTCCRunLengthChunk,
+				PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta,
+				RunLength:          2,
+			},
+			&rtcp.RunLengthChunk{
+				Type:               rtcp.TypeTCCRunLengthChunk,
+				PacketStatusSymbol: rtcp.TypeTCCPacketNotReceived,
+				RunLength:          1,
+			},
+			&rtcp.RunLengthChunk{
+				Type:               rtcp.TypeTCCRunLengthChunk,
+				PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta,
+				RunLength:          1,
+			},
+			&rtcp.RunLengthChunk{
+				Type:               rtcp.TypeTCCRunLengthChunk,
+				PacketStatusSymbol: rtcp.TypeTCCPacketReceivedLargeDelta,
+				RunLength:          2,
+			},
+		},
+		RecvDeltas: []*rtcp.RecvDelta{
+			{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: 0},
+			{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: 10000},
+			{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: 11000},
+			{Type: rtcp.TypeTCCPacketReceivedLargeDelta, Delta: -1000},
+			{Type: rtcp.TypeTCCPacketReceivedLargeDelta, Delta: 100000},
+		},
+	}, dec)
+}
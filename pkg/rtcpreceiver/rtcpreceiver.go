@@ -9,15 +9,38 @@ import (
 
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
 )
 
 // seconds since 1st January 1900
 // higher 32 bits are the integer part, lower 32 bits are the fractional part
 func ntpTimeRTCPToGo(v uint64) time.Time {
-	nano := int64((v>>32)*1000000000+(v&0xFFFFFFFF)) - 2208988800*1000000000
+	nano := int64((v>>32)*1000000000+((v&0xFFFFFFFF)*1000000000+(1<<31))>>32) - 2208988800*1000000000
 	return time.Unix(0, nano)
 }
 
+// seconds since 1st January 1900
+// higher 32 bits are the integer part, lower 32 bits are the fractional part
+func ntpTimeGoToRTCP(v time.Time) uint64 {
+	s := uint64(v.UnixNano()) + 2208988800*1000000000
+	return (s/1000000000)<<32 | ((s%1000000000)<<32)/1000000000
+}
+
+// minimum number of received packets between two losses
+// in order to consider them part of different loss bursts (RFC 3611, section 4.7.2).
+const gmin = 16
+
+const (
+	// fraction of the difference between the NTP time of a sender report and the predicted one
+	// that is applied to the timestamp mapping, in order to smooth out jitter and drift.
+	ntpSmoothingFactor = 8
+
+	// differences larger than this reset the timestamp mapping, since they are caused
+	// by clock jumps on the sender side.
+	ntpMaxCorrection = 1 * time.Second
+)
+
 func randUint32() (uint32, error) {
 	var b [4]byte
 	_, err := rand.Read(b[:])
@@ -32,7 +55,7 @@ type RTCPReceiver struct {
 	clockRate       float64
 	receiverSSRC    uint32
 	period          time.Duration
-	timeNow         func() time.Time
+	clock           clock.Clock
 	writePacketRTCP func(rtcp.Packet)
 	mutex           sync.RWMutex
 
@@ -47,14 +70,36 @@ type RTCPReceiver struct {
 	totalLost              uint32
 	totalLostSinceReport   uint32
 	totalSinceReport       uint32
+	totalExpected          uint64
 	jitter                 float64
 
+	// loss bursts
+	receivedSinceLoss uint32
+	burstLost         uint64
+	burstTotal        uint64
+	curBurstLost      uint64
+	curBurstTotal     uint64
+	gapLost           uint64
+	gapTotal          uint64
+	lossBursts        uint64
+
 	// data from RTCP packets
 	firstSenderReportReceived  bool
 	lastSenderReportTimeNTP    uint64
 	lastSenderReportTimeRTP    uint32
 	lastSenderReportTimeSystem time.Time
 
+	// mapping between RTP timestamps and NTP times, smoothed across sender reports
+	ntpBase    time.Time
+	ntpBaseRTP uint32
+
+	// extended reports
+	extendedReports bool
+	roundTripTime   time.Duration
+
+	// source description
+	sourceDescription SourceDescription
+
 	terminate chan struct{}
 	done      chan struct{}
 }
@@ -66,6 +111,22 @@ func New(
 	period time.Duration,
 	timeNow func() time.Time,
 	writePacketRTCP func(rtcp.Packet),
+) (*RTCPReceiver, error) {
+	if timeNow == nil {
+		timeNow = time.Now
+	}
+
+	return NewWithClock(clockRate, receiverSSRC, period, clock.WithNow(clock.System{}, timeNow), writePacketRTCP)
+}
+
+// NewWithClock allocates a RTCPReceiver that uses the given clock
+// to obtain the current time and to schedule reports.
+func NewWithClock(
+	clockRate int,
+	receiverSSRC *uint32,
+	period time.Duration,
+	clk clock.Clock,
+	writePacketRTCP func(rtcp.Packet),
 ) (*RTCPReceiver, error) {
 	if receiverSSRC == nil {
 		v, err := randUint32()
@@ -75,15 +136,11 @@ func New(
 		receiverSSRC = &v
 	}
 
-	if timeNow == nil {
-		timeNow = time.Now
-	}
-
 	rr := &RTCPReceiver{
 		clockRate:       float64(clockRate),
 		receiverSSRC:    *receiverSSRC,
 		period:          period,
-		timeNow:         timeNow,
+		clock:           clk,
 		writePacketRTCP: writePacketRTCP,
 		terminate:       make(chan struct{}),
 		done:            make(chan struct{}),
@@ -103,15 +160,14 @@ func (rr *RTCPReceiver) Close() {
 func (rr *RTCPReceiver) run() {
 	defer close(rr.done)
 
-	t := time.NewTicker(rr.period)
+	t := rr.clock.NewTicker(rr.period)
 	defer t.Stop()
 
 	for {
 		select {
-		case <-t.C:
-			report := rr.report()
-			if report != nil {
-				rr.writePacketRTCP(report)
+		case <-t.C():
+			for _, pkt := range rr.report() {
+				rr.writePacketRTCP(pkt)
 			}
 
 		case <-rr.terminate:
@@ -120,7 +176,16 @@ func (rr *RTCPReceiver) run() {
 	}
 }
 
-func (rr *RTCPReceiver) report() rtcp.Packet {
+// EnableExtendedReports enables the generation of RTCP extended reports (RFC 3611),
+// that are sent after receiver reports and contain a receiver reference time block
+// and a VoIP metrics block.
+func (rr *RTCPReceiver) EnableExtendedReports() {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	rr.extendedReports = true
+}
+
+func (rr *RTCPReceiver) report() []rtcp.Packet {
 	rr.mutex.Lock()
 	defer rr.mutex.Unlock()
 
@@ -128,7 +193,7 @@ func (rr *RTCPReceiver) report() rtcp.Packet {
 		return nil
 	}
 
-	system := rr.timeNow()
+	system := rr.clock.Now()
 
 	report := &rtcp.ReceiverReport{
 		SSRC: rr.receiverSSRC,
@@ -158,7 +223,118 @@ func (rr *RTCPReceiver) report() rtcp.Packet {
 	rr.totalLostSinceReport = 0
 	rr.totalSinceReport = 0
 
-	return report
+	if !rr.extendedReports {
+		return []rtcp.Packet{report}
+	}
+
+	return []rtcp.Packet{report, rr.extendedReport(system)}
+}
+
+func density(lost uint64, total uint64) uint8 {
+	if total == 0 {
+		return 0
+	}
+	v := lost * 256 / total
+	if v > 255 {
+		v = 255
+	}
+	return uint8(v)
+}
+
+func (rr *RTCPReceiver) extendedReport(system time.Time) rtcp.Packet {
+	rtt := rr.roundTripTime.Milliseconds()
+	if rtt > 0xFFFF {
+		rtt = 0xFFFF
+	}
+
+	burstLost, burstTotal, gapLost, gapTotal := rr.burstMetrics()
+
+	return &rtcp.ExtendedReport{
+		SenderSSRC: rr.receiverSSRC,
+		Reports: []rtcp.ReportBlock{
+			&rtcp.ReceiverReferenceTimeReportBlock{
+				NTPTimestamp: ntpTimeGoToRTCP(system),
+			},
+			&rtcp.VoIPMetricsReportBlock{
+				SSRC:           rr.senderSSRC,
+				LossRate:       density(uint64(rr.totalLost), rr.totalExpected),
+				BurstDensity:   density(burstLost, burstTotal),
+				GapDensity:     density(gapLost, gapTotal),
+				RoundTripDelay: uint16(rtt),
+				Gmin:           gmin,
+				// unavailable
+				RFactor:    127,
+				ExtRFactor: 127,
+				MOSLQ:      127,
+				MOSCQ:      127,
+			},
+		},
+	}
+}
+
+// burstMetrics returns lost and total packets inside and outside loss bursts.
+// The burst that is in progress is included.
+func (rr *RTCPReceiver) burstMetrics() (uint64, uint64, uint64, uint64) {
+	burstLost, burstTotal := rr.burstLost, rr.burstTotal
+	gapLost, gapTotal := rr.gapLost, rr.gapTotal
+
+	switch {
+	case rr.curBurstLost == 0:
+		gapTotal += uint64(rr.receivedSinceLoss)
+
+	case rr.curBurstLost == 1:
+		gapLost++
+		gapTotal += rr.curBurstTotal + uint64(rr.receivedSinceLoss)
+
+	default:
+		burstLost += rr.curBurstLost
+		burstTotal += rr.curBurstTotal
+		gapTotal += uint64(rr.receivedSinceLoss)
+	}
+
+	return burstLost, burstTotal, gapLost, gapTotal
+}
+
+// closeBurst moves the burst that is in progress into burst or gap counters.
+// A single lost packet surrounded by received packets is a gap loss.
+func (rr *RTCPReceiver) closeBurst() {
+	switch {
+	case rr.curBurstLost == 1:
+		rr.gapLost++
+		rr.gapTotal += rr.curBurstTotal
+
+	case rr.curBurstLost > 1:
+		rr.lossBursts++
+		rr.burstLost += rr.curBurstLost
+		rr.burstTotal += rr.curBurstTotal
+	}
+
+	rr.curBurstLost = 0
+	rr.curBurstTotal = 0
+}
+
+// processLoss updates loss burst counters when lost packets are detected.
+func (rr *RTCPReceiver) processLoss(lost uint64) {
+	if rr.curBurstLost != 0 && rr.receivedSinceLoss < gmin {
+		rr.curBurstTotal += uint64(rr.receivedSinceLoss) + lost
+		rr.curBurstLost += lost
+	} else {
+		rr.closeBurst()
+		rr.gapTotal += uint64(rr.receivedSinceLoss)
+		rr.curBurstLost = lost
+		rr.curBurstTotal = lost
+	}
+
+	rr.receivedSinceLoss = 0
+}
+
+// processReceived updates loss burst counters when a packet is received.
+func (rr *RTCPReceiver) processReceived() {
+	rr.receivedSinceLoss++
+
+	if rr.curBurstLost != 0 && rr.receivedSinceLoss == gmin {
+		rr.closeBurst()
+	}
 }
 
 // ProcessPacket extracts the needed data from RTP packets.
@@ -170,6 +346,8 @@ func (rr *RTCPReceiver) ProcessPacket(pkt *rtp.Packet, system time.Time, ptsEqua
 	if !rr.firstRTPPacketReceived {
 		rr.firstRTPPacketReceived = true
 		rr.totalSinceReport = 1
+		rr.totalExpected = 1
+		rr.receivedSinceLoss = 1
 		rr.lastSequenceNumber = pkt.SequenceNumber
 		rr.senderSSRC = pkt.SSRC
 
@@ -204,9 +382,14 @@ func (rr *RTCPReceiver) ProcessPacket(pkt *rtp.Packet, system time.Time, ptsEqua
 			if rr.totalLostSinceReport > 0xFFFFFF {
 				rr.totalLostSinceReport = 0xFFFFFF
 			}
+
+			rr.processLoss(uint64(uint16(diff) - 1))
 		}
 
+		rr.processReceived()
+
 		rr.totalSinceReport += uint32(uint16(diff))
+		rr.totalExpected += uint64(uint16(diff))
 		rr.lastSequenceNumber = pkt.SequenceNumber
 
 		if ptsEqualsDTS {
@@ -235,13 +418,97 @@ func (rr *RTCPReceiver) ProcessSenderReport(sr *rtcp.SenderReport, system time.T
 	rr.mutex.Lock()
 	defer rr.mutex.Unlock()
 
+	ntp := ntpTimeRTCPToGo(sr.NTPTime)
+
+	if rr.firstSenderReportReceived {
+		predicted := rr.packetNTP(sr.RTPTime)
+		diff := ntp.Sub(predicted)
+
+		if diff > -ntpMaxCorrection && diff < ntpMaxCorrection {
+			ntp = predicted.Add(diff / ntpSmoothingFactor)
+		}
+	}
+
+	rr.ntpBase = ntp
+	rr.ntpBaseRTP = sr.RTPTime
+
 	rr.firstSenderReportReceived = true
 	rr.lastSenderReportTimeNTP = sr.NTPTime
 	rr.lastSenderReportTimeRTP = sr.RTPTime
 	rr.lastSenderReportTimeSystem = system
 }
 
+// ProcessExtendedReport extracts the needed data from RTCP extended reports.
+// The round-trip time is computed from DLRR blocks.
+func (rr *RTCPReceiver) ProcessExtendedReport(xr *rtcp.ExtendedReport, system time.Time) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	for _, block := range xr.Reports {
+		dlrr, ok := block.(*rtcp.DLRRReportBlock)
+		if !ok {
+			continue
+		}
+
+		for _, report := range dlrr.Reports {
+			if report.SSRC != rr.receiverSSRC || report.LastRR == 0 {
+				continue
+			}
+
+			// middle 32 bits of the NTP timestamp, in units of 1/65536 seconds
+			now := uint32(ntpTimeGoToRTCP(system) >> 16)
+			rtt := int32(now - report.LastRR - report.DLRR)
+			if rtt < 0 {
+				rtt = 0
+			}
+
+			rr.roundTripTime = time.Duration(rtt) * time.Second / 65536
+		}
+	}
+}
+
+// ProcessSourceDescription extracts items from a chunk of a RTCP source description.
+func (rr *RTCPReceiver) ProcessSourceDescription(chunk *rtcp.SourceDescriptionChunk) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	for _, item := range chunk.Items {
+		switch item.Type {
+		case rtcp.SDESCNAME:
+			rr.sourceDescription.CNAME = item.Text
+
+		case rtcp.SDESName:
+			rr.sourceDescription.Name = item.Text
+
+		case rtcp.SDESTool:
+			rr.sourceDescription.Tool = item.Text
+		}
+	}
+}
+
+// SourceDescription contains items of RTCP source descriptions sent by the sender.
+type SourceDescription struct {
+	// canonical name.
+	CNAME string
+
+	// name of the user.
+	Name string
+
+	// name of the application.
+	Tool string
+}
+
+// SourceDescription returns items of RTCP source descriptions sent by the sender.
+func (rr *RTCPReceiver) SourceDescription() SourceDescription {
+	rr.mutex.RLock()
+	defer rr.mutex.RUnlock()
+	return rr.sourceDescription
+}
+
 // PacketNTP returns the NTP timestamp of the packet.
+// The mapping between RTP timestamps and NTP times is computed from sender reports;
+// differences between consecutive sender reports are smoothed out,
+// in order to prevent timestamps from jumping back and forth.
 func (rr *RTCPReceiver) PacketNTP(ts uint32) (time.Time, bool) {
 	rr.mutex.Lock()
 	defer rr.mutex.Unlock()
@@ -250,10 +517,13 @@ func (rr *RTCPReceiver) PacketNTP(ts uint32) (time.Time, bool) {
 		return time.Time{}, false
 	}
 
-	timeDiff := int32(ts - rr.lastSenderReportTimeRTP)
-	timeDiffGo := (time.Duration(timeDiff) * time.Second) / time.Duration(rr.clockRate)
+	return rr.packetNTP(ts), true
+}
 
-	return ntpTimeRTCPToGo(rr.lastSenderReportTimeNTP).Add(timeDiffGo), true
+func (rr *RTCPReceiver) packetNTP(ts uint32) time.Time {
+	timeDiff := int32(ts - rr.ntpBaseRTP)
+	timeDiffGo := (time.Duration(timeDiff) * time.Second) / time.Duration(rr.clockRate)
+	return rr.ntpBase.Add(timeDiffGo)
 }
 
 // SenderSSRC returns the SSRC of outgoing RTP packets.
@@ -262,3 +532,55 @@ func (rr *RTCPReceiver) SenderSSRC() (uint32, bool) {
 	defer rr.mutex.RUnlock()
 	return rr.senderSSRC, rr.firstRTPPacketReceived
 }
+
+// Stats are statistics of received RTP packets.
+type Stats struct {
+	// number of lost packets.
+	TotalLost uint32
+
+	// interarrival jitter.
+	Jitter time.Duration
+
+	// round-trip time, computed from RTCP extended reports.
+	// It is zero when the sender doesn't reply to extended reports.
+	RoundTripTime time.Duration
+
+	// number of loss bursts, that are sequences of lost packets
+	// separated by less than 16 received packets.
+	LossBursts uint64
+
+	// fraction of packets lost inside loss bursts.
+	BurstDensity float64
+
+	// fraction of packets lost outside loss bursts.
+	GapDensity float64
+}
+
+// Stats returns statistics of received RTP packets.
+func (rr *RTCPReceiver) Stats() Stats {
+	rr.mutex.RLock()
+	defer rr.mutex.RUnlock()
+
+	burstLost, burstTotal, gapLost, gapTotal := rr.burstMetrics()
+
+	lossBursts := rr.lossBursts
+	if rr.curBurstLost > 1 {
+		lossBursts++
+	}
+
+	return Stats{
+		TotalLost:     rr.totalLost,
+		Jitter:        time.Duration(rr.jitter / rr.clockRate * float64(time.Second)),
+		RoundTripTime: rr.roundTripTime,
+		LossBursts:    lossBursts,
+		BurstDensity:  fraction(burstLost, burstTotal),
+		GapDensity:    fraction(gapLost, gapTotal),
+	}
+}
+
+func fraction(lost uint64, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(lost) / float64(total)
+}
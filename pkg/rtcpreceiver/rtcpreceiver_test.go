@@ -390,3 +390,189 @@ func TestRTCPReceiverJitter(t *testing.T) {
 
 	<-done
 }
+
+func TestRTCPReceiverStats(t *testing.T) {
+	rr, err := New(
+		90000,
+		uint32Ptr(0x65f83afb),
+		time.Hour,
+		nil,
+		func(pkt rtcp.Packet) {})
+	require.NoError(t, err)
+	defer rr.Close()
+
+	for i, ca := range []struct {
+		seq uint16
+		ts  uint32
+		sys time.Time
+	}{
+		{946, 0xafb45733, time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)},
+		{950, 0xafb45733 + 90000, time.Date(2008, 0o5, 20, 22, 15, 21, 500000000, time.UTC)},
+	} {
+		err = rr.ProcessPacket(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: ca.seq,
+				Timestamp:      ca.ts,
+				SSRC:           0xba9da416,
+			},
+			Payload: []byte("\x00\x00"),
+		}, ca.sys, true)
+		require.NoError(t, err, i)
+	}
+
+	require.Equal(t, Stats{
+		TotalLost:    3,
+		Jitter:       31250 * time.Microsecond,
+		LossBursts:   1,
+		BurstDensity: 1,
+	}, rr.Stats())
+}
+
+func TestRTCPReceiverPacketNTP(t *testing.T) {
+	rr, err := New(
+		90000,
+		uint32Ptr(0x65f83afb),
+		time.Hour,
+		nil,
+		func(pkt rtcp.Packet) {})
+	require.NoError(t, err)
+	defer rr.Close()
+
+	_, ok := rr.PacketNTP(90000)
+	require.False(t, ok)
+
+	start := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	rr.ProcessSenderReport(&rtcp.SenderReport{
+		NTPTime: ntpTimeGoToRTCP(start),
+		RTPTime: 90000,
+	}, start)
+
+	ntp, ok := rr.PacketNTP(90000 + 45000)
+	require.True(t, ok)
+	require.Equal(t, start.Add(500*time.Millisecond), ntp.UTC())
+
+	// the sender clock is 80ms ahead of the RTP clock: the difference is smoothed
+	rr.ProcessSenderReport(&rtcp.SenderReport{
+		NTPTime: ntpTimeGoToRTCP(start.Add(5*time.Second + 80*time.Millisecond)),
+		RTPTime: 90000 + 5*90000,
+	}, start.Add(5*time.Second))
+
+	ntp, _ = rr.PacketNTP(90000 + 5*90000)
+	require.Equal(t, start.Add(5*time.Second+10*time.Millisecond), ntp.UTC())
+
+	// large differences reset the mapping
+	rr.ProcessSenderReport(&rtcp.SenderReport{
+		NTPTime: ntpTimeGoToRTCP(start.Add(1 * time.Hour)),
+		RTPTime: 90000 + 10*90000,
+	}, start.Add(10*time.Second))
+
+	ntp, _ = rr.PacketNTP(90000 + 10*90000)
+	require.Equal(t, start.Add(1*time.Hour), ntp.UTC())
+}
+
+func TestRTCPReceiverExtendedReport(t *testing.T) {
+	done := make(chan struct{})
+	n := 0
+
+	rr, err := New(
+		90000,
+		uint32Ptr(0x65f83afb),
+		500*time.Millisecond,
+		func() time.Time {
+			return time.Date(2008, 0o5, 20, 22, 15, 22, 0, time.UTC)
+		},
+		func(pkt rtcp.Packet) {
+			n++
+			switch n {
+			case 1:
+				require.IsType(t, &rtcp.ReceiverReport{}, pkt)
+
+			case 2:
+				require.Equal(t, &rtcp.ExtendedReport{
+					SenderSSRC: 0x65f83afb,
+					Reports: []rtcp.ReportBlock{
+						&rtcp.ReceiverReferenceTimeReportBlock{
+							NTPTimestamp: ntpTimeGoToRTCP(time.Date(2008, 0o5, 20, 22, 15, 22, 0, time.UTC)),
+						},
+						&rtcp.VoIPMetricsReportBlock{
+							SSRC:           0xba9da416,
+							LossRate:       153,
+							BurstDensity:   255,
+							RoundTripDelay: 1000,
+							Gmin:           16,
+							RFactor:        127,
+							ExtRFactor:     127,
+							MOSLQ:          127,
+							MOSCQ:          127,
+						},
+					},
+				}, pkt)
+				close(done)
+			}
+		})
+	require.NoError(t, err)
+	defer rr.Close()
+
+	rr.EnableExtendedReports()
+
+	for _, seq := range []uint16{946, 950} {
+		err = rr.ProcessPacket(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				Timestamp:      0xafb45733,
+				SSRC:           0xba9da416,
+			},
+			Payload: []byte("\x00\x00"),
+		}, time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC), true)
+		require.NoError(t, err)
+	}
+
+	rr.ProcessExtendedReport(&rtcp.ExtendedReport{
+		SenderSSRC: 0xba9da416,
+		Reports: []rtcp.ReportBlock{
+			&rtcp.DLRRReportBlock{
+				Reports: []rtcp.DLRRReport{{
+					SSRC:   0x65f83afb,
+					LastRR: uint32(ntpTimeGoToRTCP(time.Date(2008, 0o5, 20, 22, 15, 18, 0, time.UTC)) >> 16),
+					DLRR:   65536,
+				}},
+			},
+		},
+	}, time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC))
+
+	require.Equal(t, time.Second, rr.Stats().RoundTripTime)
+
+	<-done
+}
+
+func TestRTCPReceiverSourceDescription(t *testing.T) {
+	rr, err := New(
+		90000,
+		uint32Ptr(0x65f83afb),
+		time.Hour,
+		nil,
+		func(pkt rtcp.Packet) {})
+	require.NoError(t, err)
+	defer rr.Close()
+
+	rr.ProcessSourceDescription(&rtcp.SourceDescriptionChunk{
+		Source: 0xba9da416,
+		Items: []rtcp.SourceDescriptionItem{
+			{Type: rtcp.SDESCNAME, Text: "user@host"},
+			{Type: rtcp.SDESName, Text: "user"},
+			{Type: rtcp.SDESTool, Text: "encoder"},
+			{Type: rtcp.SDESEmail, Text: "user@example.com"},
+		},
+	})
+
+	require.Equal(t, SourceDescription{
+		CNAME: "user@host",
+		Name:  "user",
+		Tool:  "encoder",
+	}, rr.SourceDescription())
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
 )
 
 // seconds since 1st January 1900
@@ -16,11 +18,25 @@ func ntpTimeGoToRTCP(v time.Time) uint64 {
 	return (s/1000000000)<<32 | (s % 1000000000)
 }
 
+// number of sent sender reports that are kept
+// in order to match them with reception reports.
+const sentReportsCount = 8
+
+type sentReport struct {
+	lastSR     uint32
+	timeSystem time.Time
+}
+
+type receiverReferenceTime struct {
+	lastRR     uint32
+	timeSystem time.Time
+}
+
 // RTCPSender is a utility to generate RTCP sender reports.
 type RTCPSender struct {
 	clockRate       float64
 	period          time.Duration
-	timeNow         func() time.Time
+	clock           clock.Clock
 	writePacketRTCP func(rtcp.Packet)
 	mutex           sync.RWMutex
 
@@ -34,6 +50,13 @@ type RTCPSender struct {
 	packetCount        uint32
 	octetCount         uint32
 
+	// data from RTCP extended reports
+	receiverReferenceTimes map[uint32]receiverReferenceTime
+
+	// data of sent sender reports
+	sentReports    [sentReportsCount]sentReport
+	sentReportsPos int
+
 	terminate chan struct{}
 	done      chan struct{}
 }
@@ -49,10 +72,21 @@ func New(
 		timeNow = time.Now
 	}
 
+	return NewWithClock(clockRate, period, clock.WithNow(clock.System{}, timeNow), writePacketRTCP)
+}
+
+// NewWithClock allocates a RTCPSender that uses the given clock
+// to obtain the current time and to schedule reports.
+func NewWithClock(
+	clockRate int,
+	period time.Duration,
+	clk clock.Clock,
+	writePacketRTCP func(rtcp.Packet),
+) *RTCPSender {
 	rs := &RTCPSender{
 		clockRate:       float64(clockRate),
 		period:          period,
-		timeNow:         timeNow,
+		clock:           clk,
 		writePacketRTCP: writePacketRTCP,
 		terminate:       make(chan struct{}),
 		done:            make(chan struct{}),
@@ -72,15 +106,20 @@ func (rs *RTCPSender) Close() {
 func (rs *RTCPSender) run() {
 	defer close(rs.done)
 
-	t := time.NewTicker(rs.period)
+	t := rs.clock.NewTicker(rs.period)
 	defer t.Stop()
 
 	for {
 		select {
-		case <-t.C:
-			report := rs.report()
+		case <-t.C():
+			report := rs.Report()
 			if report != nil {
 				rs.writePacketRTCP(report)
+
+				xr := rs.extendedReport()
+				if xr != nil {
+					rs.writePacketRTCP(xr)
+				}
 			}
 
 		case <-rs.terminate:
@@ -89,7 +128,9 @@ func (rs *RTCPSender) run() {
 	}
 }
 
-func (rs *RTCPSender) report() rtcp.Packet {
+// Report generates a sender report.
+// It returns nil if no RTP packet with PTS equal to DTS has been processed yet.
+func (rs *RTCPSender) Report() rtcp.Packet {
 	rs.mutex.Lock()
 	defer rs.mutex.Unlock()
 
@@ -97,19 +138,105 @@ func (rs *RTCPSender) report() rtcp.Packet {
 		return nil
 	}
 
-	systemTimeDiff := rs.timeNow().Sub(rs.lastTimeSystem)
-	ntpTime := rs.lastTimeNTP.Add(systemTimeDiff)
+	now := rs.clock.Now()
+	systemTimeDiff := now.Sub(rs.lastTimeSystem)
+	ntpTime := ntpTimeGoToRTCP(rs.lastTimeNTP.Add(systemTimeDiff))
 	rtpTime := rs.lastTimeRTP + uint32(systemTimeDiff.Seconds()*rs.clockRate)
 
+	rs.sentReports[rs.sentReportsPos] = sentReport{
+		// middle 32 bits out of 64 in the NTP timestamp
+		lastSR:     uint32(ntpTime >> 16),
+		timeSystem: now,
+	}
+	rs.sentReportsPos = (rs.sentReportsPos + 1) % sentReportsCount
+
 	return &rtcp.SenderReport{
 		SSRC:        rs.senderSSRC,
-		NTPTime:     ntpTimeGoToRTCP(ntpTime),
+		NTPTime:     ntpTime,
 		RTPTime:     rtpTime,
 		PacketCount: rs.packetCount,
 		OctetCount:  rs.octetCount,
 	}
 }
 
+// extendedReport generates an extended report (RFC 3611) with a DLRR block,
+// that allows receivers to compute the round-trip time.
+// It returns nil if no receiver reference time has been received since the last extended report.
+func (rs *RTCPSender) extendedReport() rtcp.Packet {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	if len(rs.receiverReferenceTimes) == 0 {
+		return nil
+	}
+
+	now := rs.clock.Now()
+
+	block := &rtcp.DLRRReportBlock{}
+
+	for ssrc, rrt := range rs.receiverReferenceTimes {
+		block.Reports = append(block.Reports, rtcp.DLRRReport{
+			SSRC:   ssrc,
+			LastRR: rrt.lastRR,
+			// delay, expressed in units of 1/65536 seconds
+			DLRR: uint32(now.Sub(rrt.timeSystem).Seconds() * 65536),
+		})
+	}
+
+	rs.receiverReferenceTimes = nil
+
+	return &rtcp.ExtendedReport{
+		SenderSSRC: rs.senderSSRC,
+		Reports:    []rtcp.ReportBlock{block},
+	}
+}
+
+// ProcessExtendedReport extracts receiver reference times from RTCP extended reports.
+// They are replied with DLRR blocks, sent after the next sender report.
+func (rs *RTCPSender) ProcessExtendedReport(xr *rtcp.ExtendedReport, system time.Time) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	for _, block := range xr.Reports {
+		if rrt, ok := block.(*rtcp.ReceiverReferenceTimeReportBlock); ok {
+			if rs.receiverReferenceTimes == nil {
+				rs.receiverReferenceTimes = make(map[uint32]receiverReferenceTime)
+			}
+
+			rs.receiverReferenceTimes[xr.SenderSSRC] = receiverReferenceTime{
+				// middle 32 bits out of 64 in the NTP timestamp
+				lastRR:     uint32(rrt.NTPTimestamp >> 16),
+				timeSystem: system,
+			}
+		}
+	}
+}
+
+// ProcessReceptionReport computes the round-trip time from a reception report
+// that refers to one of the last sender reports generated by the RTCPSender (RFC 3550, section 6.4.1).
+// It returns false if the report doesn't refer to the sender or to a known sender report.
+func (rs *RTCPSender) ProcessReceptionReport(report *rtcp.ReceptionReport, system time.Time) (time.Duration, bool) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	if !rs.initialized || report.SSRC != rs.senderSSRC || report.LastSenderReport == 0 {
+		return 0, false
+	}
+
+	for _, sr := range rs.sentReports {
+		if sr.lastSR == report.LastSenderReport && !sr.timeSystem.IsZero() {
+			// delay, expressed in units of 1/65536 seconds
+			rtt := system.Sub(sr.timeSystem) - time.Duration(report.Delay)*time.Second/65536
+			if rtt < 0 {
+				rtt = 0
+			}
+			return rtt, true
+		}
+	}
+
+	return 0, false
+}
+
 // ProcessPacket extracts data from RTP packets.
 func (rs *RTCPSender) ProcessPacket(pkt *rtp.Packet, ntp time.Time, ptsEqualsDTS bool) {
 	rs.mutex.Lock()
@@ -119,7 +246,7 @@ func (rs *RTCPSender) ProcessPacket(pkt *rtp.Packet, ntp time.Time, ptsEqualsDTS
 		rs.initialized = true
 		rs.lastTimeRTP = pkt.Timestamp
 		rs.lastTimeNTP = ntp
-		rs.lastTimeSystem = rs.timeNow()
+		rs.lastTimeSystem = rs.clock.Now()
 		rs.senderSSRC = pkt.SSRC
 	}
 
@@ -8,6 +8,8 @@ import (
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
 )
 
 func TestRTCPSender(t *testing.T) {
@@ -96,3 +98,141 @@ func TestRTCPSender(t *testing.T) {
 
 	<-sent
 }
+
+func TestRTCPSenderExtendedReport(t *testing.T) {
+	done := make(chan struct{})
+	n := 0
+
+	rs := New(
+		90000,
+		100*time.Millisecond,
+		func() time.Time {
+			return time.Date(2008, 5, 20, 22, 16, 22, 0, time.UTC)
+		},
+		func(pkt rtcp.Packet) {
+			n++
+			switch n {
+			case 1:
+				require.IsType(t, &rtcp.SenderReport{}, pkt)
+
+			case 2:
+				require.Equal(t, &rtcp.ExtendedReport{
+					SenderSSRC: 0xba9da416,
+					Reports: []rtcp.ReportBlock{
+						&rtcp.DLRRReportBlock{
+							Reports: []rtcp.DLRRReport{{
+								SSRC:   0x65f83afb,
+								LastRR: 0x887a17ce,
+								DLRR:   2 * 65536,
+							}},
+						},
+					},
+				}, pkt)
+				close(done)
+			}
+		})
+	defer rs.Close()
+
+	rs.ProcessExtendedReport(&rtcp.ExtendedReport{
+		SenderSSRC: 0x65f83afb,
+		Reports: []rtcp.ReportBlock{
+			&rtcp.ReceiverReferenceTimeReportBlock{
+				NTPTimestamp: 0xe363887a17ced916,
+			},
+		},
+	}, time.Date(2008, 5, 20, 22, 16, 20, 0, time.UTC))
+
+	rs.ProcessPacket(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      1287987768,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}, time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC), true)
+
+	<-done
+}
+
+func TestRTCPSenderVirtualClock(t *testing.T) {
+	clk := clock.NewVirtual(time.Date(2008, 5, 20, 22, 16, 20, 0, time.UTC))
+
+	sent := make(chan rtcp.Packet)
+
+	rs := NewWithClock(
+		90000,
+		1*time.Second,
+		clk,
+		func(pkt rtcp.Packet) {
+			sent <- pkt
+		})
+	defer rs.Close()
+
+	rs.ProcessPacket(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      1287987768,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}, time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC), true)
+
+	clk.WaitTimers(1)
+
+	for i := 1; i <= 2; i++ {
+		clk.Advance(1 * time.Second)
+
+		require.Equal(t, &rtcp.SenderReport{
+			SSRC:        0xba9da416,
+			NTPTime:     ntpTimeGoToRTCP(time.Date(2008, 5, 20, 22, 15, 20+i, 0, time.UTC)),
+			RTPTime:     1287987768 + uint32(i)*90000,
+			PacketCount: 1,
+			OctetCount:  2,
+		}, <-sent)
+	}
+}
+
+func TestRTCPSenderReceptionReport(t *testing.T) {
+	clk := clock.NewVirtual(time.Date(2008, 5, 20, 22, 16, 20, 0, time.UTC))
+
+	rs := NewWithClock(90000, 1*time.Hour, clk, func(rtcp.Packet) {})
+	defer rs.Close()
+
+	rs.ProcessPacket(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      1287987768,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}, time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC), true)
+
+	sr := rs.Report().(*rtcp.SenderReport)
+
+	_, ok := rs.ProcessReceptionReport(&rtcp.ReceptionReport{
+		SSRC:             0x12345678,
+		LastSenderReport: uint32(sr.NTPTime >> 16),
+	}, time.Date(2008, 5, 20, 22, 16, 21, 0, time.UTC))
+	require.Equal(t, false, ok)
+
+	_, ok = rs.ProcessReceptionReport(&rtcp.ReceptionReport{
+		SSRC:             0xba9da416,
+		LastSenderReport: 0x1234,
+	}, time.Date(2008, 5, 20, 22, 16, 21, 0, time.UTC))
+	require.Equal(t, false, ok)
+
+	rtt, ok := rs.ProcessReceptionReport(&rtcp.ReceptionReport{
+		SSRC:             0xba9da416,
+		LastSenderReport: uint32(sr.NTPTime >> 16),
+		Delay:            65536 / 2,
+	}, time.Date(2008, 5, 20, 22, 16, 21, 0, time.UTC))
+	require.Equal(t, true, ok)
+	require.Equal(t, 500*time.Millisecond, rtt)
+}
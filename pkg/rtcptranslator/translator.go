@@ -0,0 +1,96 @@
+// Package rtcptranslator contains a utility to translate RTCP sender reports between streams.
+package rtcptranslator
+
+import (
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+// floor division, that works with negative numbers too.
+func divFloor(n, d int64) int64 {
+	v := n / d
+	if (n%d) != 0 && ((n < 0) != (d < 0)) {
+		v--
+	}
+	return v
+}
+
+// higher 32 bits are the integer part, lower 32 bits are the fractional part
+func ntpDurationToRTCP(d time.Duration) int64 {
+	secs := divFloor(int64(d), int64(time.Second))
+	frac := int64(d) - secs*int64(time.Second)
+	return secs<<32 + (frac<<32)/int64(time.Second)
+}
+
+// Translator translates RTP timestamps and RTCP sender reports of a media
+// from the clock and timestamp base of an upstream stream
+// to the ones of a downstream stream.
+// It is meant to be used by relays, that must forward sender reports
+// in a way that is coherent with forwarded RTP packets.
+type Translator struct {
+	upstreamClockRate   int64
+	downstreamClockRate int64
+	timestampOffset     uint32
+	ntpOffset           int64
+
+	initialized bool
+	lastTimeRTP uint32
+	lastExtRTP  int64
+}
+
+// New allocates a Translator.
+// timestampOffset is added to translated RTP timestamps,
+// ntpOffset is added to NTP timestamps of sender reports.
+func New(
+	upstreamClockRate int,
+	downstreamClockRate int,
+	timestampOffset uint32,
+	ntpOffset time.Duration,
+) *Translator {
+	return &Translator{
+		upstreamClockRate:   int64(upstreamClockRate),
+		downstreamClockRate: int64(downstreamClockRate),
+		timestampOffset:     timestampOffset,
+		ntpOffset:           ntpDurationToRTCP(ntpOffset),
+	}
+}
+
+// ClockRateMismatch returns whether the upstream and downstream clock rates are different.
+func (t *Translator) ClockRateMismatch() bool {
+	return t.upstreamClockRate != t.downstreamClockRate
+}
+
+// TranslateTimestamp translates the RTP timestamp of a packet.
+// It must be called with the timestamp of every forwarded RTP packet,
+// in order to track timestamp wrap-arounds when clock rates are different.
+func (t *Translator) TranslateTimestamp(ts uint32) uint32 {
+	if !t.initialized {
+		t.initialized = true
+		t.lastTimeRTP = ts
+		t.lastExtRTP = int64(ts)
+	} else {
+		t.lastExtRTP += int64(int32(ts - t.lastTimeRTP))
+		t.lastTimeRTP = ts
+	}
+
+	if !t.ClockRateMismatch() {
+		return ts + t.timestampOffset
+	}
+
+	return uint32(divFloor(t.lastExtRTP*t.downstreamClockRate, t.upstreamClockRate)) + t.timestampOffset
+}
+
+// TranslateSenderReport returns a copy of an upstream sender report,
+// with RTP and NTP timestamps translated to the downstream stream.
+func (t *Translator) TranslateSenderReport(sr *rtcp.SenderReport) *rtcp.SenderReport {
+	return &rtcp.SenderReport{
+		SSRC:              sr.SSRC,
+		NTPTime:           uint64(int64(sr.NTPTime) + t.ntpOffset),
+		RTPTime:           t.TranslateTimestamp(sr.RTPTime),
+		PacketCount:       sr.PacketCount,
+		OctetCount:        sr.OctetCount,
+		Reports:           sr.Reports,
+		ProfileExtensions: sr.ProfileExtensions,
+	}
+}
@@ -0,0 +1,64 @@
+package rtcptranslator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslatorSameClockRate(t *testing.T) {
+	tr := New(90000, 90000, 1000, 0)
+	require.False(t, tr.ClockRateMismatch())
+
+	require.Equal(t, uint32(999), tr.TranslateTimestamp(4294967295))
+
+	sr := tr.TranslateSenderReport(&rtcp.SenderReport{
+		SSRC:        0x38F27A2F,
+		NTPTime:     0xe363887a17ced916,
+		RTPTime:     4294967000,
+		PacketCount: 3,
+		OctetCount:  12,
+	})
+	require.Equal(t, &rtcp.SenderReport{
+		SSRC:        0x38F27A2F,
+		NTPTime:     0xe363887a17ced916,
+		RTPTime:     704,
+		PacketCount: 3,
+		OctetCount:  12,
+	}, sr)
+}
+
+func TestTranslatorClockRateMismatch(t *testing.T) {
+	tr := New(48000, 90000, 0, 1500*time.Millisecond)
+	require.True(t, tr.ClockRateMismatch())
+
+	require.Equal(t, uint32(3758096204), tr.TranslateTimestamp(4294967200))
+
+	// wrap-around
+	require.Equal(t, uint32(3758096684), tr.TranslateTimestamp(160))
+
+	sr := tr.TranslateSenderReport(&rtcp.SenderReport{
+		NTPTime: 0xe363887a17ced916,
+		RTPTime: 4294967200,
+	})
+	require.Equal(t, &rtcp.SenderReport{
+		NTPTime: 0xe363887a17ced916 + 1<<32 + 1<<31,
+		RTPTime: 3758096204,
+	}, sr)
+
+	sr = tr.TranslateSenderReport(&rtcp.SenderReport{
+		RTPTime: 4800,
+	})
+	require.Equal(t, uint32(3758105384), sr.RTPTime)
+}
+
+func TestTranslatorNegativeNTPOffset(t *testing.T) {
+	tr := New(90000, 90000, 0, -500*time.Millisecond)
+
+	sr := tr.TranslateSenderReport(&rtcp.SenderReport{
+		NTPTime: 10 << 32,
+	})
+	require.Equal(t, uint64(9<<32+1<<31), sr.NTPTime)
+}
@@ -0,0 +1,197 @@
+// Package rtpdemuxer implements a demuxer that splits RTP packets of a media into programmes.
+package rtpdemuxer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtptime"
+)
+
+var timeNow = time.Now
+
+// Programme is an independent stream multiplexed into a media,
+// identified by SSRC or by MID.
+type Programme struct {
+	// SSRC of the programme.
+	// When routing by MID, it is the SSRC of the first packet.
+	SSRC uint32
+
+	// MID of the programme (RFC8843).
+	// It is filled only when routing by MID.
+	MID string
+
+	onPacketRTP  func(*rtp.Packet)
+	timeDecoder  *rtptime.GlobalDecoder
+	lastPacketAt time.Time
+}
+
+// PacketPTS returns the PTS of a RTP packet of the programme.
+// Each programme has its own timestamp mapping.
+func (p *Programme) PacketPTS(forma format.Format, pkt *rtp.Packet) (time.Duration, bool) {
+	return p.timeDecoder.Decode(forma, pkt)
+}
+
+// Demuxer routes RTP packets of a media to programmes.
+// Programmes are added when their first packet is received
+// and removed when they stop sending packets or send a RTCP BYE.
+type Demuxer struct {
+	// ID of the RTP header extension that carries the MID (RFC8843).
+	// If zero, packets are routed by SSRC.
+	MIDExtensionID uint8
+
+	// Time after which a programme that doesn't receive packets is removed.
+	// It defaults to 10 seconds.
+	Timeout time.Duration
+
+	// Called when a programme appears.
+	// It must return the callback that receives packets of the programme.
+	// Depacketizers can be allocated here, one per programme.
+	OnProgrammeAdd func(*Programme) func(*rtp.Packet)
+
+	// Called when a programme is removed.
+	OnProgrammeRemove func(*Programme)
+
+	bySSRC map[uint32]*Programme
+	byMID  map[string]*Programme
+}
+
+// Initialize initializes a Demuxer.
+func (d *Demuxer) Initialize() error {
+	if d.OnProgrammeAdd == nil {
+		return fmt.Errorf("OnProgrammeAdd not provided")
+	}
+
+	if d.Timeout == 0 {
+		d.Timeout = 10 * time.Second
+	}
+	if d.OnProgrammeRemove == nil {
+		d.OnProgrammeRemove = func(*Programme) {}
+	}
+
+	d.bySSRC = make(map[uint32]*Programme)
+	d.byMID = make(map[string]*Programme)
+
+	return nil
+}
+
+// Programmes returns active programmes.
+func (d *Demuxer) Programmes() []*Programme {
+	seen := make(map[*Programme]struct{})
+	var ret []*Programme
+
+	for _, p := range d.bySSRC {
+		if _, ok := seen[p]; !ok {
+			seen[p] = struct{}{}
+			ret = append(ret, p)
+		}
+	}
+
+	return ret
+}
+
+// ProcessRTP processes a RTP packet.
+// Packets that cannot be associated with a programme are discarded.
+func (d *Demuxer) ProcessRTP(pkt *rtp.Packet) {
+	now := timeNow()
+
+	d.removeExpired(now)
+
+	p, ok := d.bySSRC[pkt.SSRC]
+
+	if d.MIDExtensionID != 0 {
+		// the MID may be sent only in the first packets of a SSRC.
+		if ext := pkt.GetExtension(d.MIDExtensionID); ext != nil {
+			mid := string(ext)
+
+			if !ok || p.MID != mid {
+				if ok {
+					delete(d.bySSRC, pkt.SSRC)
+					d.removeIfOrphan(p)
+				}
+
+				p, ok = d.byMID[mid]
+				if !ok {
+					p = d.add(mid, pkt.SSRC)
+				}
+				d.bySSRC[pkt.SSRC] = p
+				ok = true
+			}
+		}
+
+		if !ok {
+			return
+		}
+	} else if !ok {
+		p = d.add("", pkt.SSRC)
+		d.bySSRC[pkt.SSRC] = p
+	}
+
+	p.lastPacketAt = now
+	p.onPacketRTP(pkt)
+}
+
+// ProcessRTCP processes a RTCP packet.
+// Programmes whose SSRCs are all contained in a BYE are removed.
+func (d *Demuxer) ProcessRTCP(pkt rtcp.Packet) {
+	bye, ok := pkt.(*rtcp.Goodbye)
+	if !ok {
+		return
+	}
+
+	for _, ssrc := range bye.Sources {
+		p, ok := d.bySSRC[ssrc]
+		if !ok {
+			continue
+		}
+
+		delete(d.bySSRC, ssrc)
+		d.removeIfOrphan(p)
+	}
+}
+
+func (d *Demuxer) add(mid string, ssrc uint32) *Programme {
+	p := &Programme{
+		SSRC:        ssrc,
+		MID:         mid,
+		timeDecoder: rtptime.NewGlobalDecoder(),
+	}
+
+	if mid != "" {
+		d.byMID[mid] = p
+	}
+
+	p.onPacketRTP = d.OnProgrammeAdd(p)
+	if p.onPacketRTP == nil {
+		p.onPacketRTP = func(*rtp.Packet) {}
+	}
+
+	return p
+}
+
+func (d *Demuxer) removeIfOrphan(p *Programme) {
+	for _, p2 := range d.bySSRC {
+		if p2 == p {
+			return
+		}
+	}
+
+	if p.MID != "" {
+		delete(d.byMID, p.MID)
+	}
+
+	d.OnProgrammeRemove(p)
+}
+
+func (d *Demuxer) removeExpired(now time.Time) {
+	for ssrc, p := range d.bySSRC {
+		if now.Sub(p.lastPacketAt) >= d.Timeout {
+			delete(d.bySSRC, ssrc)
+			d.removeIfOrphan(p)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package rtpdemuxer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+func packetWithMID(ssrc uint32, seqNum uint16, mid string) *rtp.Packet {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: seqNum,
+			SSRC:           ssrc,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}
+
+	if mid != "" {
+		err := pkt.SetExtension(1, []byte(mid))
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return pkt
+}
+
+func TestDemuxerSSRC(t *testing.T) {
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	received := make(map[uint32][]uint16)
+	var removed []uint32
+
+	d := &Demuxer{
+		Timeout: 5 * time.Second,
+		OnProgrammeAdd: func(p *Programme) func(*rtp.Packet) {
+			return func(pkt *rtp.Packet) {
+				received[p.SSRC] = append(received[p.SSRC], pkt.SequenceNumber)
+			}
+		},
+		OnProgrammeRemove: func(p *Programme) {
+			removed = append(removed, p.SSRC)
+		},
+	}
+	err := d.Initialize()
+	require.NoError(t, err)
+
+	d.ProcessRTP(packetWithMID(1, 100, ""))
+	d.ProcessRTP(packetWithMID(2, 200, ""))
+	d.ProcessRTP(packetWithMID(1, 101, ""))
+
+	require.Equal(t, map[uint32][]uint16{
+		1: {100, 101},
+		2: {200},
+	}, received)
+	require.Len(t, d.Programmes(), 2)
+
+	d.ProcessRTCP(&rtcp.Goodbye{Sources: []uint32{2}})
+	require.Equal(t, []uint32{2}, removed)
+
+	now = now.Add(6 * time.Second)
+	d.ProcessRTP(packetWithMID(3, 300, ""))
+	require.Equal(t, []uint32{2, 1}, removed)
+	require.Equal(t, []uint16{300}, received[3])
+	require.Len(t, d.Programmes(), 1)
+}
+
+func TestDemuxerMID(t *testing.T) {
+	received := make(map[string][]uint16)
+	var removed []string
+
+	d := &Demuxer{
+		MIDExtensionID: 1,
+		OnProgrammeAdd: func(p *Programme) func(*rtp.Packet) {
+			return func(pkt *rtp.Packet) {
+				received[p.MID] = append(received[p.MID], pkt.SequenceNumber)
+			}
+		},
+		OnProgrammeRemove: func(p *Programme) {
+			removed = append(removed, p.MID)
+		},
+	}
+	err := d.Initialize()
+	require.NoError(t, err)
+
+	// unknown SSRC without MID
+	d.ProcessRTP(packetWithMID(1, 99, ""))
+
+	d.ProcessRTP(packetWithMID(1, 100, "a"))
+	d.ProcessRTP(packetWithMID(1, 101, ""))
+	d.ProcessRTP(packetWithMID(2, 200, "b"))
+
+	// SSRC change of an existing programme
+	d.ProcessRTP(packetWithMID(3, 102, "a"))
+	d.ProcessRTP(packetWithMID(3, 103, ""))
+
+	require.Equal(t, map[string][]uint16{
+		"a": {100, 101, 102, 103},
+		"b": {200},
+	}, received)
+	require.Len(t, d.Programmes(), 2)
+	require.Empty(t, removed)
+
+	d.ProcessRTCP(&rtcp.Goodbye{Sources: []uint32{1}})
+	require.Empty(t, removed)
+
+	d.ProcessRTCP(&rtcp.Goodbye{Sources: []uint32{3}})
+	require.Equal(t, []string{"a"}, removed)
+	require.Len(t, d.Programmes(), 1)
+}
+
+func TestDemuxerPacketPTS(t *testing.T) {
+	forma := &format.Generic{
+		PayloadTyp: 96,
+		RTPMa:      "private/90000",
+	}
+	err := forma.Init()
+	require.NoError(t, err)
+
+	var programmes []*Programme
+
+	d := &Demuxer{
+		OnProgrammeAdd: func(p *Programme) func(*rtp.Packet) {
+			programmes = append(programmes, p)
+			return nil
+		},
+	}
+	err = d.Initialize()
+	require.NoError(t, err)
+
+	pkt1 := packetWithMID(1, 100, "")
+	pkt1.Timestamp = 1000
+	d.ProcessRTP(pkt1)
+
+	pkt2 := packetWithMID(2, 100, "")
+	pkt2.Timestamp = 500000
+	d.ProcessRTP(pkt2)
+
+	pts, ok := programmes[0].PacketPTS(forma, pkt1)
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), pts)
+
+	pts, ok = programmes[1].PacketPTS(forma, pkt2)
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), pts)
+
+	pkt1.Timestamp += 90000
+	pts, ok = programmes[0].PacketPTS(forma, pkt1)
+	require.True(t, ok)
+	require.Equal(t, 1*time.Second, pts)
+}
+
+func TestDemuxerErrors(t *testing.T) {
+	d := &Demuxer{}
+	err := d.Initialize()
+	require.EqualError(t, err, "OnProgrammeAdd not provided")
+}
@@ -0,0 +1,74 @@
+package rtpextension
+
+import (
+	"fmt"
+	"time"
+)
+
+// AbsSendTimeURI is the URI of the absolute send time extension.
+const AbsSendTimeURI = "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"
+
+// offset between the NTP epoch (1900) and the Unix epoch (1970), in seconds.
+const ntpEpochOffset = 2208988800
+
+func timeToNTP(t time.Time) uint64 {
+	s := uint64(t.UnixNano()) + ntpEpochOffset*uint64(time.Second)
+	return (s/uint64(time.Second))<<32 | ((s%uint64(time.Second))<<32)/uint64(time.Second)
+}
+
+func ntpToTime(v uint64) time.Time {
+	s := (v >> 32) - ntpEpochOffset
+	ns := ((v & 0xFFFFFFFF) * uint64(time.Second)) >> 32
+	return time.Unix(int64(s), int64(ns))
+}
+
+// AbsSendTime is the absolute send time extension.
+// Specification: https://webrtc.googlesource.com/src/+/refs/heads/main/docs/native-code/rtp-hdrext/abs-send-time
+type AbsSendTime struct {
+	// send time, expressed as the 24 middle bits of a NTP timestamp
+	// (6 bits of seconds and 18 bits of fraction).
+	Timestamp uint32
+}
+
+// NewAbsSendTime allocates an AbsSendTime that contains the given time.
+func NewAbsSendTime(t time.Time) *AbsSendTime {
+	return &AbsSendTime{
+		Timestamp: uint32(timeToNTP(t)>>14) & 0xFFFFFF,
+	}
+}
+
+// URI implements Extension.
+func (e *AbsSendTime) URI() string {
+	return AbsSendTimeURI
+}
+
+// Unmarshal implements Extension.
+func (e *AbsSendTime) Unmarshal(buf []byte) error {
+	if len(buf) != 3 {
+		return fmt.Errorf("invalid abs-send-time size (%d)", len(buf))
+	}
+
+	e.Timestamp = uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2])
+	return nil
+}
+
+// Marshal implements Extension.
+func (e *AbsSendTime) Marshal() ([]byte, error) {
+	return []byte{
+		byte(e.Timestamp >> 16),
+		byte(e.Timestamp >> 8),
+		byte(e.Timestamp),
+	}, nil
+}
+
+// Estimate returns the absolute send time,
+// assuming that the packet was received at the given time
+// and less than 64 seconds have passed since the packet was sent.
+func (e *AbsSendTime) Estimate(receiveTime time.Time) time.Time {
+	receiveNTP := timeToNTP(receiveTime) >> 14
+	sendNTP := (receiveNTP &^ 0xFFFFFF) | uint64(e.Timestamp&0xFFFFFF)
+	if sendNTP > receiveNTP {
+		sendNTP -= 0x1000000
+	}
+	return ntpToTime(sendNTP << 14)
+}
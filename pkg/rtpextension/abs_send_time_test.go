@@ -0,0 +1,28 @@
+package rtpextension
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbsSendTime(t *testing.T) {
+	sendTime := time.Date(2008, 5, 20, 22, 15, 20, 500000000, time.UTC)
+
+	ext := NewAbsSendTime(sendTime)
+
+	buf, err := ext.Marshal()
+	require.NoError(t, err)
+
+	var dec AbsSendTime
+	err = dec.Unmarshal(buf)
+	require.NoError(t, err)
+	require.Equal(t, *ext, dec)
+
+	estimated := dec.Estimate(sendTime.Add(30 * time.Second))
+	require.InDelta(t, float64(sendTime.UnixNano()), float64(estimated.UnixNano()), float64(10*time.Microsecond))
+
+	err = dec.Unmarshal([]byte{1, 2})
+	require.EqualError(t, err, "invalid abs-send-time size (2)")
+}
@@ -0,0 +1,101 @@
+// Package rtpextension contains utilities to read and write RTP header extensions (RFC8285).
+package rtpextension
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+)
+
+// TransportCCURI is the URI of the transport-wide sequence number extension,
+// that is used by transport-wide congestion control.
+const TransportCCURI = "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"
+
+const (
+	extensionProfileOneByte = 0xBEDE
+	extensionProfileTwoByte = 0x1000
+)
+
+// Extension is a RTP header extension.
+// Custom extensions can be supported by implementing this interface.
+type Extension interface {
+	// URI returns the URI that identifies the extension in the extmap attribute.
+	URI() string
+
+	// Unmarshal decodes the extension payload.
+	Unmarshal(buf []byte) error
+
+	// Marshal encodes the extension payload.
+	Marshal() ([]byte, error)
+}
+
+// ID returns the ID that has been negotiated for an extension URI in a media.
+func ID(medi *description.Media, uri string) (uint8, bool) {
+	for _, e := range medi.HeaderExtensions {
+		if e.URI == uri && e.Direction != "inactive" {
+			return e.ID, true
+		}
+	}
+
+	return 0, false
+}
+
+// Read reads an extension from a RTP packet.
+// It returns false if the extension has not been negotiated or is not present in the packet.
+func Read(medi *description.Media, pkt *rtp.Packet, ext Extension) (bool, error) {
+	id, ok := ID(medi, ext.URI())
+	if !ok {
+		return false, nil
+	}
+
+	payload := pkt.Header.GetExtension(id)
+	if payload == nil {
+		return false, nil
+	}
+
+	err := ext.Unmarshal(payload)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Write writes an extension into a RTP packet.
+// The two-byte header format is used when the ID or the payload
+// can't be represented with the one-byte header format.
+func Write(medi *description.Media, pkt *rtp.Packet, ext Extension) error {
+	id, ok := ID(medi, ext.URI())
+	if !ok {
+		return fmt.Errorf("extension %s has not been negotiated", ext.URI())
+	}
+
+	payload, err := ext.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > 255 {
+		return fmt.Errorf("extension payload is too big")
+	}
+
+	twoByte := id > 14 || len(payload) == 0 || len(payload) > 16
+
+	switch {
+	case !pkt.Header.Extension:
+		pkt.Header.Extension = true
+		if twoByte {
+			pkt.Header.ExtensionProfile = extensionProfileTwoByte
+		} else {
+			pkt.Header.ExtensionProfile = extensionProfileOneByte
+		}
+
+	case twoByte && pkt.Header.ExtensionProfile == extensionProfileOneByte:
+		// every one-byte extension can be represented with the two-byte format
+		pkt.Header.ExtensionProfile = extensionProfileTwoByte
+	}
+
+	return pkt.Header.SetExtension(id, payload)
+}
@@ -0,0 +1,169 @@
+package rtpextension
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+var testMedia = &description.Media{
+	Type: description.MediaTypeVideo,
+	HeaderExtensions: []description.HeaderExtension{
+		{ID: 3, URI: TransportCCURI},
+		{ID: 2, URI: AbsSendTimeURI},
+		{ID: 14, URI: TransmissionOffsetURI},
+		{ID: 20, URI: SDESMIDURI},
+		{ID: 5, URI: "urn:example:inactive", Direction: "inactive"},
+	},
+	Formats: []format.Format{&format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+	}},
+}
+
+func TestID(t *testing.T) {
+	for _, ca := range []struct {
+		uri string
+		id  uint8
+		ok  bool
+	}{
+		{AbsSendTimeURI, 2, true},
+		{TransmissionOffsetURI, 14, true},
+		{SDESMIDURI, 20, true},
+		{TransportCCURI, 3, true},
+		{"urn:example:inactive", 0, false},
+		{"urn:example:missing", 0, false},
+	} {
+		t.Run(ca.uri, func(t *testing.T) {
+			id, ok := ID(testMedia, ca.uri)
+			require.Equal(t, ca.ok, ok)
+			require.Equal(t, ca.id, id)
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		exts    []Extension
+		profile uint16
+	}{
+		{
+			"one-byte",
+			[]Extension{
+				&AbsSendTime{Timestamp: 0x123456},
+				&TransmissionOffset{Offset: -1234},
+			},
+			extensionProfileOneByte,
+		},
+		{
+			"two-byte",
+			[]Extension{
+				&AbsSendTime{Timestamp: 0x123456},
+				&TransmissionOffset{Offset: -1234},
+				&SDESMID{MID: "video0"},
+			},
+			extensionProfileTwoByte,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			pkt := &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 123,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{1, 2, 3, 4},
+			}
+
+			for _, ext := range ca.exts {
+				err := Write(testMedia, pkt, ext)
+				require.NoError(t, err)
+			}
+
+			buf, err := pkt.Marshal()
+			require.NoError(t, err)
+
+			var dec rtp.Packet
+			err = dec.Unmarshal(buf)
+			require.NoError(t, err)
+			require.Equal(t, ca.profile, dec.ExtensionProfile)
+
+			var ast AbsSendTime
+			ok, err := Read(testMedia, &dec, &ast)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, AbsSendTime{Timestamp: 0x123456}, ast)
+
+			var toffset TransmissionOffset
+			ok, err = Read(testMedia, &dec, &toffset)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, TransmissionOffset{Offset: -1234}, toffset)
+
+			var mid SDESMID
+			ok, err = Read(testMedia, &dec, &mid)
+			require.NoError(t, err)
+			require.Equal(t, len(ca.exts) == 3, ok)
+			if ok {
+				require.Equal(t, SDESMID{MID: "video0"}, mid)
+			}
+		})
+	}
+}
+
+type customExtension struct {
+	uri     string
+	payload []byte
+}
+
+func (e *customExtension) URI() string {
+	return e.uri
+}
+
+func (e *customExtension) Unmarshal(buf []byte) error {
+	e.payload = buf
+	return nil
+}
+
+func (e *customExtension) Marshal() ([]byte, error) {
+	return e.payload, nil
+}
+
+func TestWriteReadCustom(t *testing.T) {
+	medi := &description.Media{
+		Type: description.MediaTypeVideo,
+		HeaderExtensions: []description.HeaderExtension{
+			{ID: 8, URI: "urn:example:custom"},
+		},
+	}
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:     2,
+			PayloadType: 96,
+		},
+	}
+
+	err := Write(medi, pkt, &customExtension{uri: "urn:example:custom", payload: []byte{5, 6, 7}})
+	require.NoError(t, err)
+
+	var ext customExtension
+	ext.uri = "urn:example:custom"
+	ok, err := Read(medi, pkt, &ext)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte{5, 6, 7}, ext.payload)
+
+	err = Write(medi, pkt, &customExtension{uri: "urn:example:missing"})
+	require.EqualError(t, err, "extension urn:example:missing has not been negotiated")
+
+	ok, err = Read(medi, pkt, &customExtension{uri: "urn:example:missing"})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,39 @@
+package rtpextension
+
+import (
+	"fmt"
+)
+
+// SDESMIDURI is the URI of the media identification extension.
+const SDESMIDURI = "urn:ietf:params:rtp-hdrext:sdes:mid"
+
+// SDESMID is the media identification extension.
+// Specification: https://datatracker.ietf.org/doc/html/rfc8843#section-15.2
+type SDESMID struct {
+	// MID of the media.
+	MID string
+}
+
+// URI implements Extension.
+func (e *SDESMID) URI() string {
+	return SDESMIDURI
+}
+
+// Unmarshal implements Extension.
+func (e *SDESMID) Unmarshal(buf []byte) error {
+	if len(buf) == 0 {
+		return fmt.Errorf("MID is empty")
+	}
+
+	e.MID = string(buf)
+	return nil
+}
+
+// Marshal implements Extension.
+func (e *SDESMID) Marshal() ([]byte, error) {
+	if len(e.MID) == 0 || len(e.MID) > 255 {
+		return nil, fmt.Errorf("invalid MID length (%d)", len(e.MID))
+	}
+
+	return []byte(e.MID), nil
+}
@@ -0,0 +1,26 @@
+package rtpextension
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSDESMID(t *testing.T) {
+	ext := SDESMID{MID: "audio"}
+
+	buf, err := ext.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, []byte("audio"), buf)
+
+	var dec SDESMID
+	err = dec.Unmarshal(buf)
+	require.NoError(t, err)
+	require.Equal(t, ext, dec)
+
+	err = dec.Unmarshal(nil)
+	require.EqualError(t, err, "MID is empty")
+
+	_, err = (&SDESMID{}).Marshal()
+	require.EqualError(t, err, "invalid MID length (0)")
+}
@@ -0,0 +1,46 @@
+package rtpextension
+
+import (
+	"fmt"
+)
+
+// TransmissionOffsetURI is the URI of the transmission time offset extension.
+const TransmissionOffsetURI = "urn:ietf:params:rtp-hdrext:toffset"
+
+// TransmissionOffset is the transmission time offset extension.
+// Specification: https://datatracker.ietf.org/doc/html/rfc5450
+type TransmissionOffset struct {
+	// offset between the transmission time and the sampling time
+	// of the packet, in RTP timestamp units.
+	// It must be between -8388608 and 8388607.
+	Offset int32
+}
+
+// URI implements Extension.
+func (e *TransmissionOffset) URI() string {
+	return TransmissionOffsetURI
+}
+
+// Unmarshal implements Extension.
+func (e *TransmissionOffset) Unmarshal(buf []byte) error {
+	if len(buf) != 3 {
+		return fmt.Errorf("invalid toffset size (%d)", len(buf))
+	}
+
+	// sign-extend the 24-bit value
+	e.Offset = int32(uint32(buf[0])<<24|uint32(buf[1])<<16|uint32(buf[2])<<8) >> 8
+	return nil
+}
+
+// Marshal implements Extension.
+func (e *TransmissionOffset) Marshal() ([]byte, error) {
+	if e.Offset < -(1<<23) || e.Offset >= (1<<23) {
+		return nil, fmt.Errorf("offset out of range (%d)", e.Offset)
+	}
+
+	return []byte{
+		byte(e.Offset >> 16),
+		byte(e.Offset >> 8),
+		byte(e.Offset),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package rtpextension
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransmissionOffset(t *testing.T) {
+	for _, ca := range []struct {
+		name   string
+		offset int32
+		enc    []byte
+	}{
+		{"positive", 0x123456, []byte{0x12, 0x34, 0x56}},
+		{"negative", -2, []byte{0xff, 0xff, 0xfe}},
+		{"min", -(1 << 23), []byte{0x80, 0x00, 0x00}},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			ext := TransmissionOffset{Offset: ca.offset}
+
+			buf, err := ext.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, ca.enc, buf)
+
+			var dec TransmissionOffset
+			err = dec.Unmarshal(buf)
+			require.NoError(t, err)
+			require.Equal(t, ext, dec)
+		})
+	}
+
+	_, err := (&TransmissionOffset{Offset: 1 << 23}).Marshal()
+	require.EqualError(t, err, "offset out of range (8388608)")
+}
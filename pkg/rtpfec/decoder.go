@@ -0,0 +1,240 @@
+package rtpfec
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultHistorySize = 512
+
+	// FEC packets that can't be used yet, since more than one of their packets is missing.
+	maxPendingFEC = 32
+)
+
+type decoderEntry struct {
+	ssrc   uint32
+	seqNum uint16
+	bits   []byte
+}
+
+type decoderPendingFEC struct {
+	ssrc    uint32
+	seqNums []uint16
+	bits    []byte
+}
+
+// Decoder recovers lost RTP packets by using FEC packets.
+// Specification: https://datatracker.ietf.org/doc/html/rfc8627
+type Decoder struct {
+	// number of received packets kept in history (optional).
+	// It defaults to 512.
+	HistorySize int
+
+	history []*decoderEntry
+	pending []*decoderPendingFEC
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	if d.HistorySize == 0 {
+		d.HistorySize = defaultHistorySize
+	}
+
+	d.history = make([]*decoderEntry, d.HistorySize)
+
+	return nil
+}
+
+// ProcessPacket processes a received RTP packet.
+// It returns packets that have been recovered thanks to it.
+func (d *Decoder) ProcessPacket(pkt *rtp.Packet) ([]*rtp.Packet, error) {
+	if d.find(pkt.SSRC, pkt.SequenceNumber) != nil {
+		return nil, nil
+	}
+
+	bits, err := bitstring(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	d.store(pkt.SSRC, pkt.SequenceNumber, bits)
+
+	return d.recoverPending(), nil
+}
+
+// ProcessFEC processes a received FEC packet.
+// It returns packets that have been recovered thanks to it.
+func (d *Decoder) ProcessFEC(pkt *rtp.Packet) ([]*rtp.Packet, error) {
+	seqNums, err := protectedSequenceNumbers(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	bits, err := fecBitstring(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	fec := &decoderPendingFEC{
+		ssrc:    pkt.CSRC[0],
+		seqNums: seqNums,
+		bits:    bits,
+	}
+
+	recovered, done := d.recover(fec)
+	if done {
+		return append(recovered, d.recoverPending()...), nil
+	}
+
+	d.pending = append(d.pending, fec)
+	if len(d.pending) > maxPendingFEC {
+		d.pending = d.pending[1:]
+	}
+
+	return nil, nil
+}
+
+func (d *Decoder) find(ssrc uint32, seqNum uint16) *decoderEntry {
+	e := d.history[int(seqNum)%d.HistorySize]
+	if e == nil || e.ssrc != ssrc || e.seqNum != seqNum {
+		return nil
+	}
+	return e
+}
+
+func (d *Decoder) store(ssrc uint32, seqNum uint16, bits []byte) {
+	d.history[int(seqNum)%d.HistorySize] = &decoderEntry{
+		ssrc:   ssrc,
+		seqNum: seqNum,
+		bits:   bits,
+	}
+}
+
+// recover tries to recover a packet by using a FEC packet.
+// It returns true when the FEC packet is not needed anymore.
+func (d *Decoder) recover(fec *decoderPendingFEC) ([]*rtp.Packet, bool) {
+	var missing []uint16
+
+	for _, seqNum := range fec.seqNums {
+		if d.find(fec.ssrc, seqNum) == nil {
+			missing = append(missing, seqNum)
+			if len(missing) > 1 {
+				return nil, false
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil, true
+	}
+
+	bits := append([]byte(nil), fec.bits...)
+
+	for _, seqNum := range fec.seqNums {
+		if seqNum != missing[0] {
+			bits = xorInto(bits, d.find(fec.ssrc, seqNum).bits)
+		}
+	}
+
+	pkt, err := packetFromBitstring(bits, fec.ssrc, missing[0])
+	if err != nil {
+		return nil, true
+	}
+
+	d.store(fec.ssrc, missing[0], bits[:bitstringHeaderSize+int(binary.BigEndian.Uint16(bits[8:]))])
+
+	return []*rtp.Packet{pkt}, true
+}
+
+// recoverPending tries to recover packets by using pending FEC packets,
+// until no more packets can be recovered.
+func (d *Decoder) recoverPending() []*rtp.Packet {
+	var ret []*rtp.Packet
+
+	for {
+		progress := false
+
+		for i := 0; i < len(d.pending); i++ {
+			recovered, done := d.recover(d.pending[i])
+			if done {
+				d.pending = append(d.pending[:i], d.pending[i+1:]...)
+				i--
+
+				if recovered != nil {
+					ret = append(ret, recovered...)
+					progress = true
+				}
+			}
+		}
+
+		if !progress {
+			return ret
+		}
+	}
+}
+
+func packetFromBitstring(bits []byte, ssrc uint32, seqNum uint16) (*rtp.Packet, error) {
+	l := int(binary.BigEndian.Uint16(bits[8:]))
+	if len(bits) < (bitstringHeaderSize + l) {
+		return nil, fmt.Errorf("invalid length recovery")
+	}
+
+	byts := make([]byte, 12+l)
+	copy(byts, bits[:8])
+	byts[0] = 0x80 | (byts[0] & 0x3F)
+	binary.BigEndian.PutUint16(byts[2:], seqNum)
+	binary.BigEndian.PutUint32(byts[8:], ssrc)
+	copy(byts[12:], bits[bitstringHeaderSize:bitstringHeaderSize+l])
+
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(byts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkt, nil
+}
+
+// protectedSequenceNumbers returns sequence numbers that are protected by a FEC packet.
+func protectedSequenceNumbers(pkt *rtp.Packet) ([]uint16, error) {
+	if len(pkt.CSRC) != 1 {
+		return nil, fmt.Errorf("FEC packets that protect %d streams are not supported", len(pkt.CSRC))
+	}
+
+	if len(pkt.Payload) < fecHeaderSize {
+		return nil, fmt.Errorf("payload is too short")
+	}
+
+	if (pkt.Payload[0] & 0x80) != 0 {
+		return nil, fmt.Errorf("retransmissions are not supported")
+	}
+
+	if (pkt.Payload[0] & 0x40) != 0 {
+		return nil, fmt.Errorf("fixed masks are not supported")
+	}
+
+	seqNums, _, err := unmarshalMask(pkt.Payload[fecHeaderSize:])
+	return seqNums, err
+}
+
+// fecBitstring returns the bitstring of a FEC packet, in the same layout of source bitstrings.
+func fecBitstring(pkt *rtp.Packet) ([]byte, error) {
+	_, n, err := unmarshalMask(pkt.Payload[fecHeaderSize:])
+	if err != nil {
+		return nil, err
+	}
+
+	payload := pkt.Payload[fecHeaderSize+n:]
+
+	ret := make([]byte, bitstringHeaderSize+len(payload))
+	ret[0] = pkt.Payload[0] & 0x3F
+	ret[1] = pkt.Payload[1]
+	copy(ret[4:8], pkt.Payload[4:8])
+	binary.BigEndian.PutUint16(ret[8:], binary.BigEndian.Uint16(pkt.Payload[2:]))
+	copy(ret[bitstringHeaderSize:], payload)
+
+	return ret, nil
+}
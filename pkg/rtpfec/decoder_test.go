@@ -0,0 +1,88 @@
+package rtpfec
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 98,
+		GroupSize:   4,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	d := &Decoder{}
+	err = d.Init()
+	require.NoError(t, err)
+
+	var pkts []*rtp.Packet
+	var fecs []*rtp.Packet
+
+	for i := 0; i < 8; i++ {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         (i % 2) == 1,
+				PayloadType:    96,
+				SequenceNumber: uint16(65532 + i),
+				Timestamp:      45343 + uint32(i/2)*3000,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: make([]byte, 1+i*3),
+		}
+		for j := range pkt.Payload {
+			pkt.Payload[j] = byte(i + j)
+		}
+		pkts = append(pkts, pkt)
+
+		fec, err2 := e.Encode(pkt)
+		require.NoError(t, err2)
+		if fec != nil {
+			fecs = append(fecs, fec)
+		}
+	}
+	require.Equal(t, 2, len(fecs))
+
+	// first group: the FEC packet is received after the other packets
+	for _, i := range []int{0, 1, 3} {
+		recovered, err2 := d.ProcessPacket(pkts[i])
+		require.NoError(t, err2)
+		require.Nil(t, recovered)
+	}
+
+	recovered, err := d.ProcessFEC(fecs[0])
+	require.NoError(t, err)
+	require.Equal(t, 1, len(recovered))
+
+	byts1, _ := recovered[0].Marshal()
+	byts2, _ := pkts[2].Marshal()
+	require.Equal(t, byts2, byts1)
+
+	// second group: the FEC packet is received before the other packets
+	recovered, err = d.ProcessFEC(fecs[1])
+	require.NoError(t, err)
+	require.Nil(t, recovered)
+
+	for _, i := range []int{4, 6} {
+		recovered, err = d.ProcessPacket(pkts[i])
+		require.NoError(t, err)
+		require.Nil(t, recovered)
+	}
+
+	recovered, err = d.ProcessPacket(pkts[7])
+	require.NoError(t, err)
+	require.Equal(t, 1, len(recovered))
+
+	byts1, _ = recovered[0].Marshal()
+	byts2, _ = pkts[5].Marshal()
+	require.Equal(t, byts2, byts1)
+
+	// a packet that has been recovered is not recovered twice
+	recovered, err = d.ProcessPacket(pkts[5])
+	require.NoError(t, err)
+	require.Nil(t, recovered)
+}
@@ -0,0 +1,137 @@
+package rtpfec
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultGroupSize = 10
+)
+
+// Encoder generates FEC packets that protect groups of consecutive RTP packets.
+// Each FEC packet allows to recover a single lost packet of its group.
+// Specification: https://datatracker.ietf.org/doc/html/rfc8627
+type Encoder struct {
+	// payload type of FEC packets.
+	PayloadType uint8
+
+	// SSRC of FEC packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of FEC packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// number of packets protected by each FEC packet (optional).
+	// The overhead is inversely proportional to it.
+	// It defaults to 10. Maximum is 110.
+	GroupSize int
+
+	sequenceNumber uint16
+	count          int
+	ssrc           uint32
+	snBase         uint16
+	nextSeqNum     uint16
+	timestamp      uint32
+	xor            []byte
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.GroupSize == 0 {
+		e.GroupSize = defaultGroupSize
+	}
+	if e.GroupSize < 1 || e.GroupSize > maxMaskSize {
+		return fmt.Errorf("invalid group size: %d", e.GroupSize)
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+
+	return nil
+}
+
+// Encode processes a RTP packet.
+// It returns a FEC packet when the packet completes a group.
+// Groups are interrupted when the SSRC changes or sequence numbers are not consecutive.
+func (e *Encoder) Encode(pkt *rtp.Packet) (*rtp.Packet, error) {
+	bits, err := bitstring(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.count != 0 && (pkt.SSRC != e.ssrc || pkt.SequenceNumber != e.nextSeqNum) {
+		e.count = 0
+	}
+
+	if e.count == 0 {
+		e.ssrc = pkt.SSRC
+		e.snBase = pkt.SequenceNumber
+		e.xor = e.xor[:0]
+	}
+
+	e.xor = xorInto(e.xor, bits)
+	e.count++
+	e.nextSeqNum = pkt.SequenceNumber + 1
+	e.timestamp = pkt.Timestamp
+
+	if e.count < e.GroupSize {
+		return nil, nil
+	}
+
+	ret := e.generate()
+	e.count = 0
+
+	return ret, nil
+}
+
+func (e *Encoder) generate() *rtp.Packet {
+	mask := make([]bool, e.count)
+	for i := range mask {
+		mask[i] = true
+	}
+
+	payload := make([]byte, fecHeaderSize, fecHeaderSize+16+len(e.xor)-bitstringHeaderSize)
+
+	// R = 0 and F = 0 (flexible mask), followed by P, X, CC, M and PT recovery
+	payload[0] = e.xor[0] & 0x3F
+	payload[1] = e.xor[1]
+	copy(payload[2:4], e.xor[8:10]) // length recovery
+	copy(payload[4:8], e.xor[4:8])  // timestamp recovery
+
+	payload = marshalMask(payload, e.snBase, mask)
+	payload = append(payload, e.xor[bitstringHeaderSize:]...)
+
+	ret := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    e.PayloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      e.timestamp,
+			SSRC:           *e.SSRC,
+			CSRC:           []uint32{e.ssrc},
+		},
+		Payload: payload,
+	}
+
+	e.sequenceNumber++
+
+	return ret
+}
@@ -0,0 +1,103 @@
+package rtpfec
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func TestEncoder(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           98,
+		SSRC:                  uint32Ptr(0x38F27A2F),
+		InitialSequenceNumber: uint16Ptr(1000),
+		GroupSize:             2,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	fec, err := e.Encode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 65535,
+			Timestamp:      45343,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	})
+	require.NoError(t, err)
+	require.Nil(t, fec)
+
+	fec, err = e.Encode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 0,
+			Timestamp:      45343,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{5, 6},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    98,
+			SequenceNumber: 1000,
+			Timestamp:      45343,
+			SSRC:           0x38F27A2F,
+			CSRC:           []uint32{0x9dbb7812},
+		},
+		Payload: []byte{
+			0x00, 0x80, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00,
+			0xff, 0xff, 0xe0, 0x00, 0x04, 0x04, 0x03, 0x04,
+		},
+	}, fec)
+
+	// groups are interrupted by gaps
+	for _, seqNum := range []uint16{1, 3, 4} {
+		fec, err = e.Encode(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: seqNum,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{1},
+		})
+		require.NoError(t, err)
+	}
+	require.NotNil(t, fec)
+
+	seqNums, err := protectedSequenceNumbers(fec)
+	require.NoError(t, err)
+	require.Equal(t, []uint16{3, 4}, seqNums)
+}
+
+func TestEncoderMask(t *testing.T) {
+	for _, size := range []int{15, 16, 46, 47, 110} {
+		mask := make([]bool, size)
+		for i := range mask {
+			mask[i] = true
+		}
+
+		buf := marshalMask(nil, 65500, mask)
+
+		seqNums, n, err := unmarshalMask(buf)
+		require.NoError(t, err)
+		require.Equal(t, len(buf), n)
+		require.Equal(t, size, len(seqNums))
+		require.Equal(t, uint16(65500+size-1), seqNums[len(seqNums)-1])
+	}
+}
@@ -0,0 +1,149 @@
+// Package rtpfec implements forward error correction of RTP packets
+// through Flexible FEC (RFC8627), with flexible masks.
+package rtpfec
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// maximum number of packets that can be protected by a FEC packet with a flexible mask.
+	maxMaskSize = 15 + 31 + 64
+
+	// size of the fields of the FEC header that precede sequence number bases and masks.
+	fecHeaderSize = 8
+
+	// size of the fields of the bitstring that precede CSRCs, extensions and payload.
+	bitstringHeaderSize = 10
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// bitstring generates the bitstring of a source packet, that is
+// the first 64 bits of the RTP header, the length of the packet without the fixed header,
+// and CSRCs, extensions, payload and padding.
+func bitstring(pkt *rtp.Packet) ([]byte, error) {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]byte, bitstringHeaderSize+len(byts)-12)
+	copy(ret, byts[:8])
+	binary.BigEndian.PutUint16(ret[8:], uint16(len(byts)-12))
+	copy(ret[bitstringHeaderSize:], byts[12:])
+
+	return ret, nil
+}
+
+// xorInto XORs src into dst, that is grown when needed.
+func xorInto(dst []byte, src []byte) []byte {
+	if len(src) > len(dst) {
+		dst = append(dst, make([]byte, len(src)-len(dst))...)
+	}
+
+	for i, b := range src {
+		dst[i] ^= b
+	}
+
+	return dst
+}
+
+// marshalMask encodes a sequence number base and a flexible mask.
+func marshalMask(buf []byte, snBase uint16, mask []bool) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, snBase)
+
+	var w1 uint16
+	for i := 0; i < 15 && i < len(mask); i++ {
+		if mask[i] {
+			w1 |= 1 << (14 - i)
+		}
+	}
+
+	if len(mask) <= 15 {
+		return binary.BigEndian.AppendUint16(buf, w1|0x8000)
+	}
+	buf = binary.BigEndian.AppendUint16(buf, w1)
+
+	var w2 uint32
+	for i := 15; i < 46 && i < len(mask); i++ {
+		if mask[i] {
+			w2 |= 1 << (30 - (i - 15))
+		}
+	}
+
+	if len(mask) <= 46 {
+		return binary.BigEndian.AppendUint32(buf, w2|0x80000000)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, w2)
+
+	var w3 uint64
+	for i := 46; i < maxMaskSize && i < len(mask); i++ {
+		if mask[i] {
+			w3 |= 1 << (63 - (i - 46))
+		}
+	}
+
+	return binary.BigEndian.AppendUint64(buf, w3)
+}
+
+// unmarshalMask decodes a sequence number base and a flexible mask.
+// It returns the sequence numbers of protected packets and the size of the fields.
+func unmarshalMask(buf []byte) ([]uint16, int, error) {
+	if len(buf) < 4 {
+		return nil, 0, fmt.Errorf("buffer is too short")
+	}
+
+	snBase := binary.BigEndian.Uint16(buf)
+	var ret []uint16
+
+	w1 := binary.BigEndian.Uint16(buf[2:])
+	for i := 0; i < 15; i++ {
+		if (w1 & (1 << (14 - i))) != 0 {
+			ret = append(ret, snBase+uint16(i))
+		}
+	}
+
+	if (w1 & 0x8000) != 0 {
+		return ret, 4, nil
+	}
+
+	if len(buf) < 8 {
+		return nil, 0, fmt.Errorf("buffer is too short")
+	}
+
+	w2 := binary.BigEndian.Uint32(buf[4:])
+	for i := 15; i < 46; i++ {
+		if (w2 & (1 << (30 - (i - 15)))) != 0 {
+			ret = append(ret, snBase+uint16(i))
+		}
+	}
+
+	if (w2 & 0x80000000) != 0 {
+		return ret, 8, nil
+	}
+
+	if len(buf) < 16 {
+		return nil, 0, fmt.Errorf("buffer is too short")
+	}
+
+	w3 := binary.BigEndian.Uint64(buf[8:])
+	for i := 46; i < maxMaskSize; i++ {
+		if (w3 & (1 << (63 - (i - 46)))) != 0 {
+			ret = append(ret, snBase+uint16(i))
+		}
+	}
+
+	return ret, 16, nil
+}
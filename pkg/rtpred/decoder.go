@@ -0,0 +1,97 @@
+package rtpred
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+type decoderBlock struct {
+	payloadType uint8
+	offset      uint32
+	length      int
+}
+
+// Decoder extracts RTP packets from RED packets.
+// Specification: https://datatracker.ietf.org/doc/html/rfc2198
+type Decoder struct {
+	initialized bool
+	lastSeqNum  uint16
+}
+
+// Decode decodes a RED packet.
+// It returns the primary packet, preceded by redundant packets
+// that replace packets which have not been received.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]*rtp.Packet, error) {
+	buf := pkt.Payload
+	var blocks []decoderBlock
+
+	for {
+		if len(buf) < 1 {
+			return nil, fmt.Errorf("payload is too short")
+		}
+
+		if (buf[0] & 0x80) == 0 {
+			blocks = append(blocks, decoderBlock{payloadType: buf[0]})
+			buf = buf[1:]
+			break
+		}
+
+		if len(buf) < 4 {
+			return nil, fmt.Errorf("payload is too short")
+		}
+
+		blocks = append(blocks, decoderBlock{
+			payloadType: buf[0] & 0x7F,
+			offset:      uint32(buf[1])<<6 | uint32(buf[2])>>2,
+			length:      int(buf[2]&0x03)<<8 | int(buf[3]),
+		})
+		buf = buf[4:]
+	}
+
+	redundantCount := len(blocks) - 1
+	ret := make([]*rtp.Packet, 0, len(blocks))
+
+	for i, block := range blocks {
+		var payload []byte
+
+		if i == redundantCount {
+			payload = buf
+		} else {
+			if len(buf) < block.length {
+				return nil, fmt.Errorf("payload is too short")
+			}
+			payload = buf[:block.length]
+			buf = buf[block.length:]
+		}
+
+		seqNum := pkt.SequenceNumber - uint16(redundantCount-i)
+
+		// redundant payloads are returned only when they replace missing packets
+		if i != redundantCount && (!d.initialized || int16(seqNum-d.lastSeqNum) <= 0) {
+			continue
+		}
+
+		out := &rtp.Packet{
+			Header:  pkt.Header,
+			Payload: payload,
+		}
+		out.Padding = false
+		out.PaddingSize = 0
+		out.PayloadType = block.payloadType
+		out.SequenceNumber = seqNum
+		out.Timestamp = pkt.Timestamp - block.offset
+		if i != redundantCount {
+			out.Marker = false
+		}
+
+		ret = append(ret, out)
+	}
+
+	if !d.initialized || int16(pkt.SequenceNumber-d.lastSeqNum) > 0 {
+		d.initialized = true
+		d.lastSeqNum = pkt.SequenceNumber
+	}
+
+	return ret, nil
+}
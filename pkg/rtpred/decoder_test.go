@@ -0,0 +1,68 @@
+package rtpred
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 63,
+		Depth:       2,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	var pkts []*rtp.Packet
+	var reds []*rtp.Packet
+
+	for i := 0; i < 6; i++ {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    111,
+				SequenceNumber: uint16(65533 + i),
+				Timestamp:      45343 + uint32(i)*960,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{byte(i), 1, 2, 3},
+		}
+		pkts = append(pkts, pkt)
+		reds = append(reds, e.Encode(pkt))
+	}
+
+	d := &Decoder{}
+
+	for _, ca := range []struct {
+		red []int
+		out []int
+	}{
+		{[]int{0}, []int{0}},
+		{[]int{1}, []int{1}},
+		// packets 2 and 3 are lost
+		{[]int{4}, []int{2, 3, 4}},
+		// packet 3 is received late
+		{[]int{3}, []int{3}},
+		{[]int{5}, []int{5}},
+	} {
+		out, err2 := d.Decode(reds[ca.red[0]])
+		require.NoError(t, err2)
+
+		expected := make([]*rtp.Packet, len(ca.out))
+		for i, j := range ca.out {
+			expected[i] = pkts[j]
+			if j != ca.red[0] {
+				tmp := *pkts[j]
+				tmp.Marker = false
+				expected[i] = &tmp
+			}
+		}
+		require.Equal(t, expected, out)
+	}
+
+	_, err = d.Decode(&rtp.Packet{Payload: []byte{0x80, 1}})
+	require.EqualError(t, err, "payload is too short")
+}
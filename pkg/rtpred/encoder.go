@@ -0,0 +1,115 @@
+package rtpred
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultDepth = 1
+)
+
+// Encoder generates RED packets, that contain the payload of a RTP packet
+// together with payloads of previous packets.
+// Specification: https://datatracker.ietf.org/doc/html/rfc2198
+type Encoder struct {
+	// payload type of RED packets.
+	PayloadType uint8
+
+	// number of previous payloads carried by each packet (optional).
+	// The overhead is proportional to it.
+	// It defaults to 1.
+	Depth int
+
+	history []*rtp.Packet
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.Depth == 0 {
+		e.Depth = defaultDepth
+	}
+	if e.Depth < 1 {
+		return fmt.Errorf("invalid depth: %d", e.Depth)
+	}
+
+	return nil
+}
+
+// Encode encodes a RTP packet into a RED packet.
+// Previous payloads are included only when sequence numbers are consecutive.
+func (e *Encoder) Encode(pkt *rtp.Packet) *rtp.Packet {
+	if len(e.history) != 0 {
+		last := e.history[len(e.history)-1]
+		if last.SSRC != pkt.SSRC || pkt.SequenceNumber != (last.SequenceNumber+1) {
+			e.history = e.history[:0]
+		}
+	}
+
+	// find previous payloads that can be represented
+	i := len(e.history)
+	for i > 0 {
+		prev := e.history[i-1]
+		offset := pkt.Timestamp - prev.Timestamp
+		if offset >= maxTimestampOffset || len(prev.Payload) >= maxBlockLength {
+			break
+		}
+		i--
+	}
+	redundant := e.history[i:]
+
+	n := 1 + len(pkt.Payload)
+	for _, prev := range redundant {
+		n += 4 + len(prev.Payload)
+	}
+
+	payload := make([]byte, 0, n)
+
+	for _, prev := range redundant {
+		offset := pkt.Timestamp - prev.Timestamp
+		l := len(prev.Payload)
+		payload = append(payload,
+			0x80|prev.PayloadType,
+			byte(offset>>6),
+			byte(offset<<2)|byte(l>>8),
+			byte(l))
+	}
+
+	payload = append(payload, pkt.PayloadType)
+
+	for _, prev := range redundant {
+		payload = append(payload, prev.Payload...)
+	}
+
+	payload = append(payload, pkt.Payload...)
+
+	e.history = append(e.history, &rtp.Packet{
+		Header: rtp.Header{
+			PayloadType:    pkt.PayloadType,
+			SequenceNumber: pkt.SequenceNumber,
+			Timestamp:      pkt.Timestamp,
+			SSRC:           pkt.SSRC,
+		},
+		Payload: append([]byte(nil), pkt.Payload...),
+	})
+	if len(e.history) > e.Depth {
+		e.history = e.history[1:]
+	}
+
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:          2,
+			Marker:           pkt.Marker,
+			PayloadType:      e.PayloadType,
+			SequenceNumber:   pkt.SequenceNumber,
+			Timestamp:        pkt.Timestamp,
+			SSRC:             pkt.SSRC,
+			CSRC:             pkt.CSRC,
+			Extension:        pkt.Extension,
+			ExtensionProfile: pkt.ExtensionProfile,
+			Extensions:       pkt.Extensions,
+		},
+		Payload: payload,
+	}
+}
@@ -0,0 +1,86 @@
+package rtpred
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 63,
+		Depth:       2,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	var pkts []*rtp.Packet
+
+	for i, seqNum := range []uint16{65535, 0, 1, 3} {
+		pkts = append(pkts, e.Encode(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    111,
+				SequenceNumber: seqNum,
+				Timestamp:      45343 + uint32(i)*960,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{byte(i), byte(i)},
+		}))
+	}
+
+	require.Equal(t, []*rtp.Packet{
+		{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    63,
+				SequenceNumber: 65535,
+				Timestamp:      45343,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{111, 0, 0},
+		},
+		{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    63,
+				SequenceNumber: 0,
+				Timestamp:      46303,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{
+				0xef, 0x0f, 0x00, 0x02, 111,
+				0, 0, 1, 1,
+			},
+		},
+		{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    63,
+				SequenceNumber: 1,
+				Timestamp:      47263,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{
+				0xef, 0x1e, 0x00, 0x02, 0xef, 0x0f, 0x00, 0x02, 111,
+				0, 0, 1, 1, 2, 2,
+			},
+		},
+		{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    63,
+				SequenceNumber: 3,
+				Timestamp:      48223,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{111, 3, 3},
+		},
+	}, pkts)
+}
@@ -0,0 +1,10 @@
+// Package rtpred implements redundant audio data (RFC2198).
+package rtpred
+
+const (
+	// maximum timestamp offset of redundant blocks.
+	maxTimestampOffset = 1 << 14
+
+	// maximum length of redundant blocks.
+	maxBlockLength = 1 << 10
+)
@@ -2,34 +2,100 @@
 package rtpreorderer
 
 import (
+	"sync/atomic"
+	"time"
+
 	"github.com/pion/rtp"
 )
 
 const (
-	bufferSize = 64
+	defaultBufferSize = 64
+	maxBufferSize     = 16384
 )
 
 // Reorderer filters incoming RTP packets, in order to
 // - order packets
 // - remove duplicate packets
 type Reorderer struct {
+	late           uint64 // must be first for atomic alignment on 32-bit platforms
+	dropped        uint64
+	bufferSize     uint16
+	window         time.Duration
 	initialized    bool
 	expectedSeqNum uint16
 	buffer         []*rtp.Packet
+	arrivals       []time.Time
 	absPos         uint16
 	negativeCount  int
 }
 
 // New allocates a Reorderer.
 func New() *Reorderer {
-	return &Reorderer{
-		buffer: make([]*rtp.Packet, bufferSize),
+	return NewWithOptions(defaultBufferSize, 0)
+}
+
+// NewWithOptions allocates a Reorderer with custom options.
+// size is the maximum number of buffered packets, and is rounded up to the next power of two.
+// If zero, it defaults to 64.
+// window is the maximum time a packet waits for the ones that precede it;
+// if zero, packets wait until the buffer is full.
+func NewWithOptions(size int, window time.Duration) *Reorderer {
+	if size == 0 {
+		size = defaultBufferSize
+	}
+
+	bufferSize := 1
+	for bufferSize < size && bufferSize < maxBufferSize {
+		bufferSize <<= 1
+	}
+
+	r := &Reorderer{
+		bufferSize: uint16(bufferSize),
+		window:     window,
+		buffer:     make([]*rtp.Packet, bufferSize),
 	}
+
+	if window != 0 {
+		r.arrivals = make([]time.Time, bufferSize)
+	}
+
+	return r
+}
+
+// Late returns the number of packets that have been discarded
+// because they arrived after the packets that follow them were returned, or were duplicated.
+func (r *Reorderer) Late() uint64 {
+	return atomic.LoadUint64(&r.late)
+}
+
+// Dropped returns the number of missing packets that have been skipped
+// because the buffer was full or the window expired.
+func (r *Reorderer) Dropped() uint64 {
+	return atomic.LoadUint64(&r.dropped)
 }
 
 // Process processes a RTP packet.
 // It returns a sequence of ordered packets and the number of lost packets.
 func (r *Reorderer) Process(pkt *rtp.Packet) ([]*rtp.Packet, int) {
+	return r.ProcessAt(pkt, time.Time{})
+}
+
+// ProcessAt processes a RTP packet received at the given time.
+// It returns a sequence of ordered packets and the number of lost packets.
+// When a window is set, it also returns packets whose window has expired.
+func (r *Reorderer) ProcessAt(pkt *rtp.Packet, now time.Time) ([]*rtp.Packet, int) {
+	ret, lost := r.process(pkt, now)
+
+	if r.window != 0 {
+		ret2, lost2 := r.Expire(now)
+		ret = append(ret, ret2...)
+		lost += lost2
+	}
+
+	return ret, lost
+}
+
+func (r *Reorderer) process(pkt *rtp.Packet, now time.Time) ([]*rtp.Packet, int) {
 	if !r.initialized {
 		r.initialized = true
 		r.expectedSeqNum = pkt.SequenceNumber + 1
@@ -45,12 +111,12 @@ func (r *Reorderer) Process(pkt *rtp.Packet) ([]*rtp.Packet, int) {
 		r.negativeCount++
 
 		// stream has been resetted, therefore reset reorderer too
-		if r.negativeCount > bufferSize {
+		if r.negativeCount > int(r.bufferSize) {
 			r.negativeCount = 0
 
 			// clear buffer
-			for i := uint16(0); i < bufferSize; i++ {
-				p := (r.absPos + i) & (bufferSize - 1)
+			for i := uint16(0); i < r.bufferSize; i++ {
+				p := (r.absPos + i) & (r.bufferSize - 1)
 				r.buffer[p] = nil
 			}
 
@@ -59,16 +125,17 @@ func (r *Reorderer) Process(pkt *rtp.Packet) ([]*rtp.Packet, int) {
 			return []*rtp.Packet{pkt}, 0
 		}
 
+		atomic.AddUint64(&r.late, 1)
 		return nil, 0
 	}
 	r.negativeCount = 0
 
 	// there's a missing packet and buffer is full.
 	// return entire buffer and clear it.
-	if relPos >= bufferSize {
+	if int(relPos) >= int(r.bufferSize) {
 		n := 1
-		for i := uint16(0); i < bufferSize; i++ {
-			p := (r.absPos + i) & (bufferSize - 1)
+		for i := uint16(0); i < r.bufferSize; i++ {
+			p := (r.absPos + i) & (r.bufferSize - 1)
 			if r.buffer[p] != nil {
 				n++
 			}
@@ -77,8 +144,8 @@ func (r *Reorderer) Process(pkt *rtp.Packet) ([]*rtp.Packet, int) {
 		ret := make([]*rtp.Packet, n)
 		pos := 0
 
-		for i := uint16(0); i < bufferSize; i++ {
-			p := (r.absPos + i) & (bufferSize - 1)
+		for i := uint16(0); i < r.bufferSize; i++ {
+			p := (r.absPos + i) & (r.bufferSize - 1)
 			if r.buffer[p] != nil {
 				ret[pos], r.buffer[p] = r.buffer[p], nil
 				pos++
@@ -88,29 +155,44 @@ func (r *Reorderer) Process(pkt *rtp.Packet) ([]*rtp.Packet, int) {
 		ret[pos] = pkt
 
 		r.expectedSeqNum = pkt.SequenceNumber + 1
-		return ret, int(relPos) - n + 1
+		lost := int(relPos) - n + 1
+		atomic.AddUint64(&r.dropped, uint64(lost))
+		return ret, lost
 	}
 
 	// there's a missing packet
 	if relPos != 0 {
-		p := (r.absPos + uint16(relPos)) & (bufferSize - 1)
+		p := (r.absPos + uint16(relPos)) & (r.bufferSize - 1)
 
 		// current packet is a duplicate. discard
 		if r.buffer[p] != nil {
+			atomic.AddUint64(&r.late, 1)
 			return nil, 0
 		}
 
 		// put current packet in buffer
 		r.buffer[p] = pkt
+		if r.arrivals != nil {
+			r.arrivals[p] = now
+		}
 		return nil, 0
 	}
 
 	// all packets have been received correctly.
 	// return them
 
+	r.absPos++
+	r.absPos &= (r.bufferSize - 1)
+
+	return r.popContiguous(pkt), 0
+}
+
+// popContiguous returns pkt and all buffered packets that follow it.
+// absPos must point to the position after pkt.
+func (r *Reorderer) popContiguous(pkt *rtp.Packet) []*rtp.Packet {
 	n := uint16(1)
 	for {
-		p := (r.absPos + n) & (bufferSize - 1)
+		p := (r.absPos + n - 1) & (r.bufferSize - 1)
 		if r.buffer[p] == nil {
 			break
 		}
@@ -118,18 +200,70 @@ func (r *Reorderer) Process(pkt *rtp.Packet) ([]*rtp.Packet, int) {
 	}
 
 	ret := make([]*rtp.Packet, n)
-
 	ret[0] = pkt
-	r.absPos++
-	r.absPos &= (bufferSize - 1)
 
 	for i := uint16(1); i < n; i++ {
 		ret[i], r.buffer[r.absPos] = r.buffer[r.absPos], nil
 		r.absPos++
-		r.absPos &= (bufferSize - 1)
+		r.absPos &= (r.bufferSize - 1)
 	}
 
 	r.expectedSeqNum = pkt.SequenceNumber + n
 
-	return ret, 0
+	return ret
+}
+
+// Deadline returns the time at which the window of the oldest buffered packet expires.
+// It returns false if there are no buffered packets or no window is set.
+func (r *Reorderer) Deadline() (time.Time, bool) {
+	if r.window == 0 {
+		return time.Time{}, false
+	}
+
+	var oldest time.Time
+	found := false
+
+	for p, pkt := range r.buffer {
+		if pkt != nil && (!found || r.arrivals[p].Before(oldest)) {
+			oldest = r.arrivals[p]
+			found = true
+		}
+	}
+
+	if !found {
+		return time.Time{}, false
+	}
+
+	return oldest.Add(r.window), true
+}
+
+// Expire returns buffered packets whose window has expired,
+// together with the packets that follow them, skipping missing packets.
+// It returns the number of lost packets.
+func (r *Reorderer) Expire(now time.Time) ([]*rtp.Packet, int) {
+	var ret []*rtp.Packet
+	lost := 0
+
+	for {
+		deadline, ok := r.Deadline()
+		if !ok || now.Before(deadline) {
+			return ret, lost
+		}
+
+		// skip missing packets until the first buffered one
+		i := uint16(1)
+		for r.buffer[(r.absPos+i)&(r.bufferSize-1)] == nil {
+			i++
+		}
+
+		p := (r.absPos + i) & (r.bufferSize - 1)
+		pkt := r.buffer[p]
+		r.buffer[p] = nil
+
+		lost += int(i)
+		atomic.AddUint64(&r.dropped, uint64(i))
+
+		r.absPos = (p + 1) & (r.bufferSize - 1)
+		ret = append(ret, r.popContiguous(pkt)...)
+	}
 }
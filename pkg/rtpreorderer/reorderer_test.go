@@ -2,6 +2,7 @@ package rtpreorderer
 
 import (
 	"testing"
+	"time"
 
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
@@ -255,3 +256,62 @@ func TestReset(t *testing.T) {
 	}}, out)
 	require.Equal(t, 0, missing)
 }
+
+func TestWindow(t *testing.T) {
+	r := NewWithOptions(30, 50*time.Millisecond)
+	require.Equal(t, uint16(32), r.bufferSize)
+
+	now := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	pkt := func(sn uint16) *rtp.Packet {
+		return &rtp.Packet{Header: rtp.Header{SequenceNumber: sn}}
+	}
+
+	out, lost := r.ProcessAt(pkt(100), now)
+	require.Equal(t, []*rtp.Packet{pkt(100)}, out)
+	require.Equal(t, 0, lost)
+
+	_, ok := r.Deadline()
+	require.False(t, ok)
+
+	// 101 and 102 are missing
+	out, lost = r.ProcessAt(pkt(103), now.Add(10*time.Millisecond))
+	require.Equal(t, []*rtp.Packet(nil), out)
+	require.Equal(t, 0, lost)
+
+	out, lost = r.ProcessAt(pkt(104), now.Add(20*time.Millisecond))
+	require.Equal(t, []*rtp.Packet(nil), out)
+	require.Equal(t, 0, lost)
+
+	deadline, ok := r.Deadline()
+	require.True(t, ok)
+	require.Equal(t, now.Add(60*time.Millisecond), deadline)
+
+	// 102 arrives, 101 is still missing
+	out, lost = r.ProcessAt(pkt(102), now.Add(30*time.Millisecond))
+	require.Equal(t, []*rtp.Packet(nil), out)
+	require.Equal(t, 0, lost)
+
+	out, lost = r.Expire(now.Add(59 * time.Millisecond))
+	require.Equal(t, []*rtp.Packet(nil), out)
+	require.Equal(t, 0, lost)
+
+	// window of 103 expires, therefore 101 is skipped
+	out, lost = r.Expire(now.Add(60 * time.Millisecond))
+	require.Equal(t, []*rtp.Packet{pkt(102), pkt(103), pkt(104)}, out)
+	require.Equal(t, 1, lost)
+
+	// 101 arrives late
+	out, lost = r.ProcessAt(pkt(101), now.Add(70*time.Millisecond))
+	require.Equal(t, []*rtp.Packet(nil), out)
+	require.Equal(t, 0, lost)
+
+	// windows are also checked when packets are processed
+	r.ProcessAt(pkt(107), now.Add(80*time.Millisecond))
+	out, lost = r.ProcessAt(pkt(108), now.Add(130*time.Millisecond))
+	require.Equal(t, []*rtp.Packet{pkt(107), pkt(108)}, out)
+	require.Equal(t, 2, lost)
+
+	require.Equal(t, uint64(1), r.Late())
+	require.Equal(t, uint64(3), r.Dropped())
+}
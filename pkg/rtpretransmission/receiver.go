@@ -0,0 +1,73 @@
+package rtpretransmission
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// packets that are further than this from the last received one
+	// are not requested, since they would be discarded by the reorderer anyway.
+	maxMissing = 64
+)
+
+// Receiver detects missing RTP packets, in order to request them with NACKs,
+// and decodes RTX packets.
+// Specification: https://datatracker.ietf.org/doc/html/rfc4588
+type Receiver struct {
+	// payload type of original packets.
+	PayloadType uint8
+
+	initialized    bool
+	expectedSeqNum uint16
+	ssrc           uint32
+}
+
+// ProcessPacket processes an original RTP packet.
+// It returns the sequence numbers of missing packets.
+func (r *Receiver) ProcessPacket(pkt *rtp.Packet) []uint16 {
+	if !r.initialized || pkt.SSRC != r.ssrc {
+		r.initialized = true
+		r.ssrc = pkt.SSRC
+		r.expectedSeqNum = pkt.SequenceNumber + 1
+		return nil
+	}
+
+	relPos := int16(pkt.SequenceNumber - r.expectedSeqNum)
+
+	// packet is a duplicate, a retransmission or is out of order
+	if relPos < 0 {
+		return nil
+	}
+
+	r.expectedSeqNum = pkt.SequenceNumber + 1
+
+	if relPos == 0 || relPos > maxMissing {
+		return nil
+	}
+
+	ret := make([]uint16, relPos)
+	for i := range ret {
+		ret[i] = pkt.SequenceNumber - uint16(relPos) + uint16(i)
+	}
+
+	return ret
+}
+
+// Decode decodes a RTX packet into the original packet.
+func (r *Receiver) Decode(pkt *rtp.Packet) (*rtp.Packet, error) {
+	if len(pkt.Payload) < 2 {
+		return nil, fmt.Errorf("RTX payload is too short")
+	}
+
+	header := pkt.Header.Clone()
+	header.PayloadType = r.PayloadType
+	header.SequenceNumber = uint16(pkt.Payload[0])<<8 | uint16(pkt.Payload[1])
+	header.SSRC = r.ssrc
+
+	return &rtp.Packet{
+		Header:  header,
+		Payload: pkt.Payload[2:],
+	}, nil
+}
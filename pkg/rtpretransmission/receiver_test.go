@@ -0,0 +1,61 @@
+package rtpretransmission
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiver(t *testing.T) {
+	r := &Receiver{
+		PayloadType: 96,
+	}
+
+	for _, ca := range []struct {
+		seqNum  uint16
+		missing []uint16
+	}{
+		{65533, nil},
+		{65534, nil},
+		{1, []uint16{65535, 0}},
+		{0, nil},
+		{2, nil},
+		{200, nil},
+	} {
+		missing := r.ProcessPacket(&rtp.Packet{
+			Header: rtp.Header{
+				SequenceNumber: ca.seqNum,
+				SSRC:           0x9dbb7812,
+			},
+		})
+		require.Equal(t, ca.missing, missing)
+	}
+
+	pkt, err := r.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    97,
+			SequenceNumber: 1000,
+			Timestamp:      45343,
+			SSRC:           0x38F27A2F,
+		},
+		Payload: []byte{0xff, 0xff, 1, 2, 3, 4},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 65535,
+			Timestamp:      45343,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}, pkt)
+
+	_, err = r.Decode(&rtp.Packet{Payload: []byte{1}})
+	require.EqualError(t, err, "RTX payload is too short")
+}
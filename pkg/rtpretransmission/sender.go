@@ -0,0 +1,130 @@
+// Package rtpretransmission implements retransmission of RTP packets
+// through NACKs (RFC4585) and RTX packets (RFC4588).
+package rtpretransmission
+
+import (
+	"crypto/rand"
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultHistorySize = 512
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Sender keeps a history of sent RTP packets and
+// generates RTX packets in response to NACKs.
+// Specification: https://datatracker.ietf.org/doc/html/rfc4588
+type Sender struct {
+	// payload type of RTX packets.
+	PayloadType uint8
+
+	// SSRC of RTX packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of RTX packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// number of packets kept in history (optional).
+	// It defaults to 512.
+	HistorySize int
+
+	mutex          sync.Mutex
+	history        []*rtp.Packet
+	mediaSSRC      uint32
+	sequenceNumber uint16
+}
+
+// Init initializes the sender.
+func (s *Sender) Init() error {
+	if s.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		s.SSRC = &v
+	}
+	if s.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		s.InitialSequenceNumber = &v2
+	}
+	if s.HistorySize == 0 {
+		s.HistorySize = defaultHistorySize
+	}
+
+	s.history = make([]*rtp.Packet, s.HistorySize)
+	s.sequenceNumber = *s.InitialSequenceNumber
+
+	return nil
+}
+
+// ProcessPacket stores a sent RTP packet into the history.
+func (s *Sender) ProcessPacket(pkt *rtp.Packet) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.mediaSSRC = pkt.SSRC
+	s.history[int(pkt.SequenceNumber)%s.HistorySize] = pkt.Clone()
+}
+
+// ProcessNACK processes a NACK.
+// It returns RTX packets that contain the requested packets.
+// Packets that are not in the history anymore are skipped.
+func (s *Sender) ProcessNACK(nack *rtcp.TransportLayerNack) []*rtp.Packet {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if nack.MediaSSRC != s.mediaSSRC {
+		return nil
+	}
+
+	var ret []*rtp.Packet
+
+	for _, pair := range nack.Nacks {
+		pair.Range(func(seqNum uint16) bool {
+			pkt := s.history[int(seqNum)%s.HistorySize]
+			if pkt != nil && pkt.SequenceNumber == seqNum {
+				ret = append(ret, s.encode(pkt))
+			}
+			return true
+		})
+	}
+
+	return ret
+}
+
+func (s *Sender) encode(pkt *rtp.Packet) *rtp.Packet {
+	payload := make([]byte, 2+len(pkt.Payload))
+	payload[0] = byte(pkt.SequenceNumber >> 8)
+	payload[1] = byte(pkt.SequenceNumber)
+	copy(payload[2:], pkt.Payload)
+
+	header := pkt.Header.Clone()
+	header.PayloadType = s.PayloadType
+	header.SequenceNumber = s.sequenceNumber
+	header.SSRC = *s.SSRC
+	header.Padding = false
+	s.sequenceNumber++
+
+	return &rtp.Packet{
+		Header:  header,
+		Payload: payload,
+	}
+}
@@ -0,0 +1,78 @@
+package rtpretransmission
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func TestSender(t *testing.T) {
+	s := &Sender{
+		PayloadType:           97,
+		SSRC:                  uint32Ptr(0x38F27A2F),
+		InitialSequenceNumber: uint16Ptr(1000),
+		HistorySize:           4,
+	}
+	err := s.Init()
+	require.NoError(t, err)
+
+	for i := uint16(0); i < 6; i++ {
+		s.ProcessPacket(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 65533 + i,
+				Timestamp:      45343,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		})
+	}
+
+	pkts := s.ProcessNACK(&rtcp.TransportLayerNack{
+		MediaSSRC: 0x9dbb7812,
+		// 65533 and 65534 are not in the history anymore
+		Nacks: rtcp.NackPairsFromSequenceNumbers([]uint16{65534, 65535, 1}),
+	})
+	require.Equal(t, []*rtp.Packet{
+		{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    97,
+				SequenceNumber: 1000,
+				Timestamp:      45343,
+				SSRC:           0x38F27A2F,
+			},
+			Payload: []byte{0xff, 0xff, 1, 2, 3, 4},
+		},
+		{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    97,
+				SequenceNumber: 1001,
+				Timestamp:      45343,
+				SSRC:           0x38F27A2F,
+			},
+			Payload: []byte{0x00, 0x01, 1, 2, 3, 4},
+		},
+	}, pkts)
+
+	pkts = s.ProcessNACK(&rtcp.TransportLayerNack{
+		MediaSSRC: 0x12345678,
+		Nacks:     rtcp.NackPairsFromSequenceNumbers([]uint16{1}),
+	})
+	require.Equal(t, []*rtp.Packet(nil), pkts)
+}
@@ -0,0 +1,189 @@
+// Package rtprewrite implements a utility to rewrite sequence numbers and timestamps
+// of RTP packets into a continuous space.
+package rtprewrite
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultMaxSequenceNumberJump = 1000
+	defaultMaxTimestampJump      = 10 * time.Second
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+func durationToTicks(d time.Duration, clockRate int) int64 {
+	cr := time.Duration(clockRate)
+	return int64((d/time.Second)*cr + ((d%time.Second)*cr)/time.Second)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Rewriter rewrites SSRC, sequence numbers and timestamps of the RTP packets of a track,
+// that can come from multiple upstream segments, into a continuous and monotonic space.
+// A new segment starts when the SSRC changes, when sequence numbers or timestamps
+// jump (i.e. because the source has been restarted) or when Restart() is called.
+// The first packet of a segment follows the last packet of the previous one,
+// and its timestamp is increased by the time elapsed between their arrival.
+type Rewriter struct {
+	// clock rate of the track.
+	ClockRate int
+
+	// SSRC of output packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of output packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// initial timestamp of output packets (optional).
+	// It defaults to a random value.
+	InitialTimestamp *uint32
+
+	// maximum difference between sequence numbers of consecutive packets
+	// of the same segment (optional).
+	// It defaults to 1000.
+	MaxSequenceNumberJump int
+
+	// maximum difference between timestamps of consecutive packets
+	// of the same segment (optional).
+	// It defaults to 10 seconds.
+	MaxTimestampJump time.Duration
+
+	initialized   bool
+	restart       bool
+	sourceSSRC    uint32
+	lastSeqNum    uint16
+	lastTimestamp uint32
+	lastTime      time.Time
+	seqNumOffset  uint16
+	timestampDiff uint32
+}
+
+// Init initializes the rewriter.
+func (r *Rewriter) Init() error {
+	if r.ClockRate <= 0 {
+		return fmt.Errorf("invalid clock rate: %d", r.ClockRate)
+	}
+	if r.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		r.SSRC = &v
+	}
+	if r.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		r.InitialSequenceNumber = &v2
+	}
+	if r.InitialTimestamp == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		r.InitialTimestamp = &v
+	}
+	if r.MaxSequenceNumberJump == 0 {
+		r.MaxSequenceNumberJump = defaultMaxSequenceNumberJump
+	}
+	if r.MaxTimestampJump == 0 {
+		r.MaxTimestampJump = defaultMaxTimestampJump
+	}
+
+	return nil
+}
+
+// Restart makes the next packet start a new segment.
+// It can be used when the upstream is replaced.
+func (r *Rewriter) Restart() {
+	r.restart = true
+}
+
+// Process rewrites a RTP packet received at the given time.
+// The input packet is not modified.
+// It returns the rewritten packet and whether the packet starts a new segment.
+func (r *Rewriter) Process(pkt *rtp.Packet, now time.Time) (*rtp.Packet, bool) {
+	newSegment := false
+
+	switch {
+	case !r.initialized:
+		r.initialized = true
+		r.seqNumOffset = *r.InitialSequenceNumber - pkt.SequenceNumber
+		r.timestampDiff = *r.InitialTimestamp - pkt.Timestamp
+		r.setLast(pkt, now)
+
+	case r.restart || pkt.SSRC != r.sourceSSRC || r.isJump(pkt):
+		r.restart = false
+		newSegment = true
+
+		elapsed := now.Sub(r.lastTime)
+		if elapsed < 0 {
+			elapsed = 0
+		}
+
+		// timestamps must increase, in order not to produce duplicates
+		ticks := uint32(durationToTicks(elapsed, r.ClockRate))
+		if ticks == 0 {
+			ticks = 1
+		}
+
+		lastOutSeqNum := r.lastSeqNum + r.seqNumOffset
+		lastOutTimestamp := r.lastTimestamp + r.timestampDiff
+
+		r.seqNumOffset = lastOutSeqNum + 1 - pkt.SequenceNumber
+		r.timestampDiff = lastOutTimestamp + ticks - pkt.Timestamp
+		r.setLast(pkt, now)
+
+	default:
+		// do not move back in case of reordered packets
+		if int16(pkt.SequenceNumber-r.lastSeqNum) > 0 {
+			r.setLast(pkt, now)
+		}
+	}
+
+	out := *pkt
+	out.SSRC = *r.SSRC
+	out.SequenceNumber = pkt.SequenceNumber + r.seqNumOffset
+	out.Timestamp = pkt.Timestamp + r.timestampDiff
+
+	return &out, newSegment
+}
+
+func (r *Rewriter) isJump(pkt *rtp.Packet) bool {
+	seqNumDiff := int64(int16(pkt.SequenceNumber - r.lastSeqNum))
+	if abs(seqNumDiff) > int64(r.MaxSequenceNumberJump) {
+		return true
+	}
+
+	timestampDiff := int64(int32(pkt.Timestamp - r.lastTimestamp))
+	return abs(timestampDiff) > durationToTicks(r.MaxTimestampJump, r.ClockRate)
+}
+
+func (r *Rewriter) setLast(pkt *rtp.Packet, now time.Time) {
+	r.sourceSSRC = pkt.SSRC
+	r.lastSeqNum = pkt.SequenceNumber
+	r.lastTimestamp = pkt.Timestamp
+	r.lastTime = now
+}
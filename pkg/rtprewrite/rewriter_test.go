@@ -0,0 +1,90 @@
+package rtprewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func TestRewriter(t *testing.T) {
+	r := &Rewriter{
+		ClockRate:             90000,
+		SSRC:                  uint32Ptr(0x10203040),
+		InitialSequenceNumber: uint16Ptr(65534),
+		InitialTimestamp:      uint32Ptr(1000),
+	}
+	err := r.Init()
+	require.NoError(t, err)
+
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	type entry struct {
+		ssrc      uint32
+		seqNum    uint16
+		timestamp uint32
+		elapsed   time.Duration
+		restart   bool
+
+		outSeqNum    uint16
+		outTimestamp uint32
+		newSegment   bool
+	}
+
+	for i, e := range []entry{
+		// first segment
+		{0x01, 100, 50000, 0, false, 65534, 1000, false},
+		{0x01, 101, 53000, 33 * time.Millisecond, false, 65535, 4000, false},
+		// reordered packet
+		{0x01, 103, 59000, 33 * time.Millisecond, false, 1, 10000, false},
+		{0x01, 102, 56000, 0, false, 0, 7000, false},
+		// SSRC change
+		{0x02, 7, 400, 500 * time.Millisecond, false, 2, 10000 + 45000, true},
+		{0x02, 8, 3400, 33 * time.Millisecond, false, 3, 10000 + 45000 + 3000, false},
+		// sequence number jump
+		{0x02, 30000, 6400, 33 * time.Millisecond, false, 4, 58000 + 2970, true},
+		// timestamp jump
+		{0x02, 30001, 6400 + 900001, 1 * time.Millisecond, false, 5, 60970 + 90, true},
+		// explicit restart without elapsed time
+		{0x02, 30002, 6400 + 903001, 0, true, 6, 61060 + 1, true},
+		{0x02, 30003, 6400 + 906001, 0, false, 7, 61061 + 3000, false},
+	} {
+		now = now.Add(e.elapsed)
+
+		if e.restart {
+			r.Restart()
+		}
+
+		out, newSegment := r.Process(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SSRC:           e.ssrc,
+				SequenceNumber: e.seqNum,
+				Timestamp:      e.timestamp,
+			},
+			Payload: []byte{1, 2, 3},
+		}, now)
+
+		require.Equal(t, uint32(0x10203040), out.SSRC, "packet %d", i)
+		require.Equal(t, e.outSeqNum, out.SequenceNumber, "packet %d", i)
+		require.Equal(t, e.outTimestamp, out.Timestamp, "packet %d", i)
+		require.Equal(t, e.newSegment, newSegment, "packet %d", i)
+		require.Equal(t, []byte{1, 2, 3}, out.Payload)
+	}
+}
+
+func TestRewriterInvalidClockRate(t *testing.T) {
+	r := &Rewriter{}
+	err := r.Init()
+	require.EqualError(t, err, "invalid clock rate: 0")
+}
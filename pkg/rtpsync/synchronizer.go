@@ -0,0 +1,166 @@
+// Package rtpsync contains a utility to synchronize RTP packets of multiple tracks.
+package rtpsync
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// seconds since 1st January 1900
+// higher 32 bits are the integer part, lower 32 bits are the fractional part
+func ntpTimeRTCPToGo(v uint64) time.Time {
+	nano := int64((v>>32)*1000000000+((v&0xFFFFFFFF)*1000000000+(1<<31))>>32) - 2208988800*1000000000
+	return time.Unix(0, nano)
+}
+
+type trackEntry struct {
+	pkt      *rtp.Packet
+	ntp      time.Time
+	received time.Time
+}
+
+// Track is a track of a Synchronizer.
+type Track struct {
+	clockRate int
+
+	// mapping between RTP timestamps and NTP times
+	initialized bool
+	fromSR      bool
+	ntpBase     time.Time
+	rtpBase     uint32
+
+	queue []trackEntry
+}
+
+func (t *Track) ntp(ts uint32) time.Time {
+	timeDiff := int32(ts - t.rtpBase)
+	timeDiffGo := (time.Duration(timeDiff) * time.Second) / time.Duration(t.clockRate)
+	return t.ntpBase.Add(timeDiffGo)
+}
+
+// Synchronizer receives RTP packets of multiple tracks of a session
+// and emits them in presentation order, together with an absolute timestamp
+// expressed in the clock of the sender (NTP).
+// Timestamps are computed from RTCP sender reports. Until a track receives its first sender report,
+// its timestamps are computed from the arrival time of its first packet, converted into
+// the clock of the sender through sender reports of other tracks, if any.
+type Synchronizer struct {
+	// Maximum time a packet is held in order to wait for packets of other tracks.
+	// It defaults to 1 second.
+	MaxDelay time.Duration
+
+	// Called when a packet is ready.
+	OnPacket func(t *Track, pkt *rtp.Packet, ntp time.Time)
+
+	tracks []*Track
+
+	// difference between the clock of the sender and the local clock
+	clockOffset    time.Duration
+	clockOffsetSet bool
+}
+
+// Initialize initializes Synchronizer.
+func (s *Synchronizer) Initialize() error {
+	if s.MaxDelay == 0 {
+		s.MaxDelay = 1 * time.Second
+	}
+	if s.OnPacket == nil {
+		return fmt.Errorf("OnPacket is not set")
+	}
+
+	return nil
+}
+
+// AddTrack adds a track with the given clock rate.
+func (s *Synchronizer) AddTrack(clockRate int) *Track {
+	t := &Track{
+		clockRate: clockRate,
+	}
+	s.tracks = append(s.tracks, t)
+	return t
+}
+
+// ProcessSenderReport processes a RTCP sender report of a track, received at the given time.
+func (s *Synchronizer) ProcessSenderReport(t *Track, sr *rtcp.SenderReport, now time.Time) {
+	ntp := ntpTimeRTCPToGo(sr.NTPTime)
+
+	if !s.clockOffsetSet {
+		s.clockOffsetSet = true
+		s.clockOffset = ntp.Sub(now)
+
+		// convert timestamps of tracks without sender reports into the clock of the sender
+		for _, ot := range s.tracks {
+			if ot.initialized && !ot.fromSR {
+				ot.ntpBase = ot.ntpBase.Add(s.clockOffset)
+				for i := range ot.queue {
+					ot.queue[i].ntp = ot.queue[i].ntp.Add(s.clockOffset)
+				}
+			}
+		}
+	}
+
+	t.initialized = true
+	t.fromSR = true
+	t.ntpBase = ntp
+	t.rtpBase = sr.RTPTime
+}
+
+// ProcessPacket processes a RTP packet of a track, received at the given time.
+// Packets of each track must be ordered by sequence number.
+func (s *Synchronizer) ProcessPacket(t *Track, pkt *rtp.Packet, now time.Time) {
+	if !t.initialized {
+		t.initialized = true
+		t.ntpBase = now.Add(s.clockOffset)
+		t.rtpBase = pkt.Timestamp
+	}
+
+	t.queue = append(t.queue, trackEntry{
+		pkt:      pkt,
+		ntp:      t.ntp(pkt.Timestamp),
+		received: now,
+	})
+
+	s.emit(now, false)
+}
+
+// Flush emits all held packets.
+func (s *Synchronizer) Flush() {
+	s.emit(time.Time{}, true)
+}
+
+func (s *Synchronizer) emit(now time.Time, flush bool) {
+	for {
+		var next *Track
+		complete := true
+		expired := flush
+
+		for _, t := range s.tracks {
+			if len(t.queue) == 0 {
+				complete = false
+				continue
+			}
+
+			if !flush && now.Sub(t.queue[0].received) >= s.MaxDelay {
+				expired = true
+			}
+
+			if next == nil || t.queue[0].ntp.Before(next.queue[0].ntp) {
+				next = t
+			}
+		}
+
+		// wait until all tracks have a packet, or until a packet has been held for too long
+		if next == nil || (!complete && !expired) {
+			return
+		}
+
+		e := next.queue[0]
+		next.queue[0] = trackEntry{}
+		next.queue = next.queue[1:]
+
+		s.OnPacket(next, e.pkt, e.ntp)
+	}
+}
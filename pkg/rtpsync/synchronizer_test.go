@@ -0,0 +1,130 @@
+package rtpsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+// seconds since 1st January 1900
+// higher 32 bits are the integer part, lower 32 bits are the fractional part
+func ntpTimeGoToRTCP(v time.Time) uint64 {
+	s := uint64(v.UnixNano()) + 2208988800*1000000000
+	return (s/1000000000)<<32 | ((s%1000000000)<<32)/1000000000
+}
+
+type testOutput struct {
+	t   *Track
+	seq uint16
+	ntp time.Time
+}
+
+func TestSynchronizer(t *testing.T) {
+	var out []testOutput
+
+	s := &Synchronizer{
+		MaxDelay: 500 * time.Millisecond,
+		OnPacket: func(t *Track, pkt *rtp.Packet, ntp time.Time) {
+			out = append(out, testOutput{t, pkt.SequenceNumber, ntp.UTC()})
+		},
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	video := s.AddTrack(90000)
+	audio := s.AddTrack(48000)
+
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+	ntp := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.ProcessSenderReport(video, &rtcp.SenderReport{
+		NTPTime: ntpTimeGoToRTCP(ntp),
+		RTPTime: 90000,
+	}, now)
+
+	s.ProcessSenderReport(audio, &rtcp.SenderReport{
+		NTPTime: ntpTimeGoToRTCP(ntp),
+		RTPTime: 48000,
+	}, now)
+
+	// video packets arrive before audio ones
+	s.ProcessPacket(video, &rtp.Packet{Header: rtp.Header{SequenceNumber: 1, Timestamp: 90000 + 9000}}, now)
+	s.ProcessPacket(video, &rtp.Packet{Header: rtp.Header{SequenceNumber: 2, Timestamp: 90000 + 18000}}, now)
+	require.Empty(t, out)
+
+	s.ProcessPacket(audio, &rtp.Packet{Header: rtp.Header{SequenceNumber: 10, Timestamp: 48000}}, now)
+	s.ProcessPacket(audio, &rtp.Packet{Header: rtp.Header{SequenceNumber: 11, Timestamp: 48000 + 9600}}, now)
+
+	// packets with the same timestamp are emitted in the order of tracks
+	require.Equal(t, []testOutput{
+		{audio, 10, ntp},
+		{video, 1, ntp.Add(100 * time.Millisecond)},
+		{video, 2, ntp.Add(200 * time.Millisecond)},
+	}, out)
+	out = nil
+
+	s.ProcessPacket(video, &rtp.Packet{Header: rtp.Header{SequenceNumber: 3, Timestamp: 90000 + 27000}},
+		now.Add(200*time.Millisecond))
+
+	require.Equal(t, []testOutput{
+		{audio, 11, ntp.Add(200 * time.Millisecond)},
+	}, out)
+	out = nil
+
+	// audio stops: video packets are emitted after MaxDelay
+	s.ProcessPacket(video, &rtp.Packet{Header: rtp.Header{SequenceNumber: 4, Timestamp: 90000 + 36000}},
+		now.Add(600*time.Millisecond))
+	require.Empty(t, out)
+
+	s.ProcessPacket(video, &rtp.Packet{Header: rtp.Header{SequenceNumber: 5, Timestamp: 90000 + 45000}},
+		now.Add(700*time.Millisecond))
+
+	require.Equal(t, []testOutput{
+		{video, 3, ntp.Add(300 * time.Millisecond)},
+	}, out)
+}
+
+func TestSynchronizerMissingSenderReport(t *testing.T) {
+	var out []testOutput
+
+	s := &Synchronizer{
+		OnPacket: func(t *Track, pkt *rtp.Packet, ntp time.Time) {
+			out = append(out, testOutput{t, pkt.SequenceNumber, ntp.UTC()})
+		},
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	video := s.AddTrack(90000)
+	audio := s.AddTrack(48000)
+
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+	ntp := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// audio never receives sender reports: its timestamps are computed from the arrival time
+	s.ProcessPacket(audio, &rtp.Packet{Header: rtp.Header{SequenceNumber: 10, Timestamp: 1000}}, now)
+
+	s.ProcessSenderReport(video, &rtcp.SenderReport{
+		NTPTime: ntpTimeGoToRTCP(ntp.Add(50 * time.Millisecond)),
+		RTPTime: 90000,
+	}, now.Add(50*time.Millisecond))
+
+	s.ProcessPacket(video, &rtp.Packet{Header: rtp.Header{SequenceNumber: 1, Timestamp: 90000}},
+		now.Add(50*time.Millisecond))
+
+	s.Flush()
+
+	require.Equal(t, []testOutput{
+		{audio, 10, ntp},
+		{video, 1, ntp.Add(50 * time.Millisecond)},
+	}, out)
+}
+
+func TestSynchronizerErrors(t *testing.T) {
+	s := &Synchronizer{}
+	err := s.Initialize()
+	require.EqualError(t, err, "OnPacket is not set")
+}
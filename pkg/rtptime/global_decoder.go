@@ -66,6 +66,25 @@ func NewGlobalDecoder() *GlobalDecoder {
 	}
 }
 
+// InitializeTrack sets the PTS of a RTP timestamp of a track, in order to
+// decode timestamps of the track relative to a known position
+// (i.e. the rtptime parameter of a RTP-Info header) instead of the first packet.
+// It replaces timestamps decoded previously.
+func (d *GlobalDecoder) InitializeTrack(
+	track GlobalDecoderTrack,
+	timestamp uint32,
+	pts time.Duration,
+) {
+	if track.ClockRate() == 0 {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.tracks[track] = newGlobalDecoderTrackData(pts, track.ClockRate(), timestamp)
+}
+
 // Decode decodes a timestamp.
 func (d *GlobalDecoder) Decode(
 	track GlobalDecoderTrack,
@@ -147,3 +147,20 @@ func TestGlobalDecoderInvalidClockRate(t *testing.T) {
 	_, ok := g.Decode(tr, &rtp.Packet{Header: rtp.Header{Timestamp: 90000}})
 	require.Equal(t, false, ok)
 }
+
+func TestGlobalDecoderInitializeTrack(t *testing.T) {
+	g := NewGlobalDecoder()
+
+	t1 := &dummyTrack{clockRate: 90000}
+
+	g.InitializeTrack(t1, 45000, 0)
+
+	// PTS is available even if the first packet doesn't have PTS equal to DTS
+	pts, ok := g.Decode(t1, &rtp.Packet{Header: rtp.Header{Timestamp: 45000 - 9000}})
+	require.Equal(t, true, ok)
+	require.Equal(t, -100*time.Millisecond, pts)
+
+	pts, ok = g.Decode(t1, &rtp.Packet{Header: rtp.Header{Timestamp: 45000 + 90000}})
+	require.Equal(t, true, ok)
+	require.Equal(t, 1*time.Second, pts)
+}
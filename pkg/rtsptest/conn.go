@@ -0,0 +1,81 @@
+package rtsptest
+
+import (
+	"net"
+	"sync"
+)
+
+type listener struct {
+	n      *Network
+	addr   *net.TCPAddr
+	conns  chan net.Conn
+	closed chan struct{}
+
+	closeOnce sync.Once
+}
+
+// Accept implements net.Listener.
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+
+	case <-l.closed:
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Addr: l.addr, Err: net.ErrClosed}
+	}
+}
+
+// Close implements net.Listener.
+func (l *listener) Close() error {
+	l.closeOnce.Do(func() {
+		l.n.removeListener(l)
+		close(l.closed)
+
+		// refuse connections that have not been accepted yet
+		for {
+			select {
+			case c := <-l.conns:
+				c.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// Addr implements net.Listener.
+func (l *listener) Addr() net.Addr {
+	return l.addr
+}
+
+// conn is a pipe with TCP addresses.
+type conn struct {
+	net.Conn
+	local   *net.TCPAddr
+	remote  *net.TCPAddr
+	onClose func()
+
+	closeOnce sync.Once
+}
+
+// Close implements net.Conn.
+func (c *conn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		if c.onClose != nil {
+			c.onClose()
+		}
+	})
+	return err
+}
+
+// LocalAddr implements net.Conn.
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr implements net.Conn.
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
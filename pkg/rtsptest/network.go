@@ -0,0 +1,274 @@
+package rtsptest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	firstEphemeralPort = 40000
+	listenerBacklog    = 64
+	packetQueueSize    = 1024
+)
+
+var errConnectionRefused = errors.New("connection refused")
+
+// Network is an in-memory network that connects servers and clients without real sockets.
+// TCP connections are implemented with pipes, while UDP packets are delivered through queues,
+// with configurable loss and latency.
+// All endpoints share the address 127.0.0.1, therefore ports are unique among the network.
+// Fields must be set before the network is used.
+type Network struct {
+	// probability that a UDP packet is lost, between 0 and 1.
+	// It defaults to 0.
+	UDPLoss float64
+	// delay of UDP packets.
+	// It defaults to 0.
+	UDPLatency time.Duration
+
+	mutex         sync.Mutex
+	listeners     map[int]*listener
+	packetConns   map[int]*packetConn
+	usedPorts     map[int]struct{}
+	ephemeralPort int
+}
+
+func (n *Network) initialize() {
+	if n.listeners == nil {
+		n.listeners = make(map[int]*listener)
+		n.packetConns = make(map[int]*packetConn)
+		n.usedPorts = make(map[int]struct{})
+		n.ephemeralPort = firstEphemeralPort
+	}
+}
+
+// allocatePort allocates the given port, or an ephemeral one when port is zero.
+// It must be called with the mutex locked.
+func (n *Network) allocatePort(port int, inUse func(int) bool) (int, error) {
+	if port != 0 {
+		if inUse(port) {
+			return 0, fmt.Errorf("port %d is already in use", port)
+		}
+		return port, nil
+	}
+
+	for i := firstEphemeralPort; i <= 65535; i++ {
+		port = n.ephemeralPort
+		n.ephemeralPort++
+		if n.ephemeralPort > 65535 {
+			n.ephemeralPort = firstEphemeralPort
+		}
+
+		if _, ok := n.usedPorts[port]; !ok && !inUse(port) {
+			return port, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no ports available")
+}
+
+func parsePort(network string, wantNetwork string, address string) (int, error) {
+	if !strings.HasPrefix(network, wantNetwork) {
+		return 0, fmt.Errorf("unsupported network: %s", network)
+	}
+
+	_, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return 0, err
+	}
+
+	if portStr == "" {
+		return 0, nil
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port: %s", portStr)
+	}
+
+	return int(port), nil
+}
+
+// Listen creates a TCP listener.
+// It can be used as Server.Listen.
+func (n *Network) Listen(network string, address string) (net.Listener, error) {
+	port, err := parsePort(network, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.initialize()
+
+	port, err = n.allocatePort(port, func(port int) bool {
+		_, ok := n.listeners[port]
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l := &listener{
+		n:      n,
+		addr:   &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port},
+		conns:  make(chan net.Conn, listenerBacklog),
+		closed: make(chan struct{}),
+	}
+	n.listeners[port] = l
+
+	return l, nil
+}
+
+// DialContext connects to a TCP listener.
+// It can be used as Client.DialContext.
+func (n *Network) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	port, err := parsePort(network, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+
+	n.initialize()
+
+	l, ok := n.listeners[port]
+	if !ok {
+		n.mutex.Unlock()
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errConnectionRefused}
+	}
+
+	localPort, err := n.allocatePort(0, func(port int) bool {
+		_, ok := n.listeners[port]
+		return ok
+	})
+	if err != nil {
+		n.mutex.Unlock()
+		return nil, err
+	}
+	n.usedPorts[localPort] = struct{}{}
+
+	n.mutex.Unlock()
+
+	localAddr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: localPort}
+	clientSide, serverSide := net.Pipe()
+
+	cc := &conn{
+		Conn:   clientSide,
+		local:  localAddr,
+		remote: l.addr,
+	}
+	cc.onClose = func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		delete(n.usedPorts, localPort)
+	}
+
+	sc := &conn{
+		Conn:   serverSide,
+		local:  l.addr,
+		remote: localAddr,
+	}
+
+	select {
+	case l.conns <- sc:
+		return cc, nil
+
+	case <-l.closed:
+		cc.Close()
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errConnectionRefused}
+
+	case <-ctx.Done():
+		cc.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// ListenPacket creates a UDP listener.
+// It can be used as Server.ListenPacket and Client.ListenPacket.
+func (n *Network) ListenPacket(network, address string) (net.PacketConn, error) {
+	port, err := parsePort(network, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.initialize()
+
+	port, err = n.allocatePort(port, func(port int) bool {
+		_, ok := n.packetConns[port]
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &packetConn{
+		n:               n,
+		addr:            &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port},
+		queue:           make(chan packet, packetQueueSize),
+		closed:          make(chan struct{}),
+		deadlineChanged: make(chan struct{}),
+	}
+	n.packetConns[port] = pc
+
+	return pc, nil
+}
+
+func (n *Network) removeListener(l *listener) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.listeners[l.addr.Port] == l {
+		delete(n.listeners, l.addr.Port)
+	}
+}
+
+func (n *Network) removePacketConn(pc *packetConn) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.packetConns[pc.addr.Port] == pc {
+		delete(n.packetConns, pc.addr.Port)
+	}
+}
+
+// sendPacket delivers a UDP packet, applying loss and latency.
+func (n *Network) sendPacket(from *net.UDPAddr, to *net.UDPAddr, payload []byte) {
+	n.mutex.Lock()
+	dest, ok := n.packetConns[to.Port]
+	n.mutex.Unlock()
+
+	// like with real UDP, packets sent to closed ports are discarded.
+	if !ok {
+		return
+	}
+
+	if n.UDPLoss > 0 && rand.Float64() < n.UDPLoss { //nolint:gosec
+		return
+	}
+
+	pkt := packet{
+		from:    from,
+		payload: append([]byte(nil), payload...),
+	}
+
+	if n.UDPLatency > 0 {
+		time.AfterFunc(n.UDPLatency, func() {
+			dest.push(pkt)
+		})
+		return
+	}
+
+	dest.push(pkt)
+}
@@ -0,0 +1,144 @@
+package rtsptest
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+type packet struct {
+	from    *net.UDPAddr
+	payload []byte
+}
+
+// packetConn is a UDP listener of a Network.
+type packetConn struct {
+	n      *Network
+	addr   *net.UDPAddr
+	queue  chan packet
+	closed chan struct{}
+
+	closeOnce       sync.Once
+	mutex           sync.Mutex
+	readDeadline    time.Time
+	deadlineChanged chan struct{}
+}
+
+func (pc *packetConn) push(pkt packet) {
+	select {
+	case <-pc.closed:
+	case pc.queue <- pkt:
+	default: // like with real UDP, packets are dropped when the receive buffer is full.
+	}
+}
+
+// ReadFrom implements net.PacketConn.
+func (pc *packetConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	for {
+		pc.mutex.Lock()
+		deadline := pc.readDeadline
+		deadlineChanged := pc.deadlineChanged
+		pc.mutex.Unlock()
+
+		var timeout <-chan time.Time
+		var timer *time.Timer
+
+		if !deadline.IsZero() {
+			d := time.Until(deadline)
+			if d <= 0 {
+				return 0, nil, pc.opError("read", os.ErrDeadlineExceeded)
+			}
+
+			timer = time.NewTimer(d)
+			timeout = timer.C
+		}
+
+		select {
+		case pkt := <-pc.queue:
+			if timer != nil {
+				timer.Stop()
+			}
+			return copy(p, pkt.payload), pkt.from, nil
+
+		case <-pc.closed:
+			if timer != nil {
+				timer.Stop()
+			}
+			return 0, nil, pc.opError("read", net.ErrClosed)
+
+		case <-timeout:
+			return 0, nil, pc.opError("read", os.ErrDeadlineExceeded)
+
+		case <-deadlineChanged:
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}
+}
+
+// WriteTo implements net.PacketConn.
+func (pc *packetConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	select {
+	case <-pc.closed:
+		return 0, pc.opError("write", net.ErrClosed)
+	default:
+	}
+
+	uaddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, pc.opError("write", net.InvalidAddrError("address is not a UDP address"))
+	}
+
+	pc.n.sendPacket(pc.addr, uaddr, p)
+
+	return len(p), nil
+}
+
+// Close implements net.PacketConn.
+func (pc *packetConn) Close() error {
+	pc.closeOnce.Do(func() {
+		pc.n.removePacketConn(pc)
+		close(pc.closed)
+	})
+	return nil
+}
+
+// LocalAddr implements net.PacketConn.
+func (pc *packetConn) LocalAddr() net.Addr {
+	return pc.addr
+}
+
+// SetDeadline implements net.PacketConn.
+func (pc *packetConn) SetDeadline(t time.Time) error {
+	return pc.SetReadDeadline(t)
+}
+
+// SetReadDeadline implements net.PacketConn.
+func (pc *packetConn) SetReadDeadline(t time.Time) error {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	pc.readDeadline = t
+	close(pc.deadlineChanged)
+	pc.deadlineChanged = make(chan struct{})
+
+	return nil
+}
+
+// SetWriteDeadline implements net.PacketConn.
+// Writes never block, therefore the deadline is ignored.
+func (pc *packetConn) SetWriteDeadline(_ time.Time) error {
+	return nil
+}
+
+// SetReadBuffer sets the size of the receive buffer.
+// The size of the queue is fixed, therefore the value is ignored.
+func (pc *packetConn) SetReadBuffer(_ int) error {
+	return nil
+}
+
+func (pc *packetConn) opError(op string, err error) error {
+	return &net.OpError{Op: op, Net: "udp", Addr: pc.addr, Err: err}
+}
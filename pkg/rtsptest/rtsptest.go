@@ -0,0 +1,24 @@
+// Package rtsptest contains utilities to test applications that use servers and clients,
+// that communicate through an in-memory network instead of real sockets.
+package rtsptest
+
+import (
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// StartServer attaches a Server to the network and starts it.
+// Multicast is not supported.
+func (n *Network) StartServer(s *gortsplib.Server) error {
+	s.Listen = n.Listen
+	s.ListenPacket = n.ListenPacket
+	return s.Start()
+}
+
+// StartClient attaches a Client to the network and starts it.
+// The client connects to the host of the given URL.
+func (n *Network) StartClient(c *gortsplib.Client, u *base.URL) error {
+	c.DialContext = n.DialContext
+	c.ListenPacket = n.ListenPacket
+	return c.Start(u.Scheme, u.Host)
+}
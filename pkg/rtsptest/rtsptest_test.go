@@ -0,0 +1,168 @@
+package rtsptest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+type testHandler struct {
+	stream *gortsplib.ServerStream
+}
+
+func (h *testHandler) OnDescribe(
+	_ *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{StatusCode: base.StatusOK}, h.stream, nil
+}
+
+func (h *testHandler) OnSetup(
+	_ *gortsplib.ServerHandlerOnSetupCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{StatusCode: base.StatusOK}, h.stream, nil
+}
+
+func (h *testHandler) OnPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+func TestServerClient(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(transport, func(t *testing.T) {
+			n := &Network{}
+
+			h := &testHandler{}
+
+			s := &gortsplib.Server{
+				Handler:        h,
+				RTSPAddress:    "localhost:8554",
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+			}
+
+			err := n.StartServer(s)
+			require.NoError(t, err)
+			defer s.Close()
+
+			desc := &description.Session{Medias: []*description.Media{{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{PayloadTyp: 96, PacketizationMode: 1}},
+			}}}
+
+			h.stream = gortsplib.NewServerStream(s, desc)
+			defer h.stream.Close()
+
+			c := gortsplib.Client{
+				Transport: func() *gortsplib.Transport {
+					v := gortsplib.TransportUDP
+					if transport == "tcp" {
+						v = gortsplib.TransportTCP
+					}
+					return &v
+				}(),
+			}
+
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+
+			err = n.StartClient(&c, u)
+			require.NoError(t, err)
+			defer c.Close()
+
+			sd, _, err := c.Describe(u)
+			require.NoError(t, err)
+
+			err = c.SetupAll(sd.BaseURL, sd.Medias)
+			require.NoError(t, err)
+
+			recv := make(chan *rtp.Packet, 1)
+
+			c.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+				recv <- pkt
+			})
+
+			_, err = c.Play(nil)
+			require.NoError(t, err)
+
+			err = h.stream.WritePacketRTP(desc.Medias[0], &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 946,
+					Timestamp:      54352,
+					SSRC:           753621,
+				},
+				Payload: []byte{5, 1, 2, 3, 4},
+			})
+			require.NoError(t, err)
+
+			pkt := <-recv
+			require.Equal(t, []byte{5, 1, 2, 3, 4}, pkt.Payload)
+		})
+	}
+}
+
+func TestUDPLatency(t *testing.T) {
+	n := &Network{
+		UDPLatency: 50 * time.Millisecond,
+	}
+
+	pc1, err := n.ListenPacket("udp", "127.0.0.1:5000")
+	require.NoError(t, err)
+	defer pc1.Close()
+
+	_, err = n.ListenPacket("udp", ":5000")
+	require.Error(t, err)
+
+	pc2, err := n.ListenPacket("udp", ":0")
+	require.NoError(t, err)
+	defer pc2.Close()
+
+	start := time.Now()
+
+	_, err = pc2.WriteTo([]byte{1, 2, 3}, pc1.LocalAddr())
+	require.NoError(t, err)
+
+	buf := make([]byte, 10)
+	nb, addr, err := pc1.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, buf[:nb])
+	require.Equal(t, pc2.LocalAddr(), addr)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestUDPLoss(t *testing.T) {
+	n := &Network{
+		UDPLoss: 1,
+	}
+
+	pc1, err := n.ListenPacket("udp", "127.0.0.1:5000")
+	require.NoError(t, err)
+	defer pc1.Close()
+
+	pc2, err := n.ListenPacket("udp", ":0")
+	require.NoError(t, err)
+	defer pc2.Close()
+
+	_, err = pc2.WriteTo([]byte{1, 2, 3}, pc1.LocalAddr())
+	require.NoError(t, err)
+
+	err = pc1.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	require.NoError(t, err)
+
+	buf := make([]byte, 10)
+	_, _, err = pc1.ReadFrom(buf)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
@@ -0,0 +1,183 @@
+// Package sap contains a Session Announcement Protocol (SAP, RFC2974) announcer,
+// that allows players to discover multicast streams without a control channel.
+package sap
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+)
+
+const (
+	defaultAddress  = "224.2.127.254:9875"
+	defaultInterval = 5 * time.Second
+	defaultTTL      = 16
+)
+
+func messageIDHash(payload []byte) uint16 {
+	h := fnv.New32a()
+	h.Write(payload) //nolint:errcheck
+	v := h.Sum32()
+	return uint16(v>>16) ^ uint16(v)
+}
+
+// Announcer periodically announces a SDP with SAP.
+// When closed, it sends a deletion packet.
+type Announcer struct {
+	// SDP to announce.
+	SDP []byte
+
+	// address to which announcements are sent (optional).
+	// It defaults to 224.2.127.254:9875, that is the address of the global scope
+	// and the one listened by VLC.
+	Address string
+
+	// interval between announcements (optional).
+	// A random offset of up to a third of it is added,
+	// in order to avoid synchronization among announcers (RFC2974).
+	// It defaults to 5 seconds.
+	Interval time.Duration
+
+	// TTL of multicast packets (optional).
+	// It should be equal to the TTL of the announced stream.
+	// It defaults to 16.
+	TTL int
+
+	// IP of the announcer (optional).
+	// It defaults to the local IP used to reach Address.
+	Source net.IP
+
+	// function used to initialize the UDP socket (optional).
+	// It defaults to net.ListenPacket.
+	ListenPacket func(network, address string) (net.PacketConn, error)
+
+	// clock used to schedule announcements (optional).
+	// It defaults to the system clock.
+	Clock clock.Clock
+
+	pc        net.PacketConn
+	addr      *net.UDPAddr
+	hash      uint16
+	rand      *rand.Rand
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Start starts the announcer.
+func (a *Announcer) Start() error {
+	if a.SDP == nil {
+		return fmt.Errorf("SDP not provided")
+	}
+	if a.Address == "" {
+		a.Address = defaultAddress
+	}
+	if a.Interval == 0 {
+		a.Interval = defaultInterval
+	}
+	if a.TTL == 0 {
+		a.TTL = defaultTTL
+	}
+	if a.ListenPacket == nil {
+		a.ListenPacket = net.ListenPacket
+	}
+	if a.Clock == nil {
+		a.Clock = clock.System{}
+	}
+
+	var err error
+	a.addr, err = net.ResolveUDPAddr("udp4", a.Address)
+	if err != nil {
+		return err
+	}
+
+	if a.Source == nil {
+		a.Source, err = localIP(a.addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.pc, err = a.ListenPacket("udp4", ":0")
+	if err != nil {
+		return err
+	}
+
+	if uc, ok := a.pc.(*net.UDPConn); ok && a.addr.IP.IsMulticast() {
+		err = ipv4.NewPacketConn(uc).SetMulticastTTL(a.TTL)
+		if err != nil {
+			a.pc.Close()
+			return err
+		}
+	}
+
+	a.hash = messageIDHash(a.SDP)
+	a.rand = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	a.terminate = make(chan struct{})
+	a.done = make(chan struct{})
+
+	go a.run()
+
+	return nil
+}
+
+// Close stops the announcer and sends a deletion packet.
+func (a *Announcer) Close() {
+	close(a.terminate)
+	<-a.done
+	a.write(true) //nolint:errcheck
+	a.pc.Close()
+}
+
+func (a *Announcer) run() {
+	defer close(a.done)
+
+	a.write(false) //nolint:errcheck
+
+	for {
+		t := a.Clock.NewTimer(a.Interval + time.Duration(a.rand.Int63n(int64(a.Interval/3)+1)))
+
+		select {
+		case <-t.C():
+			a.write(false) //nolint:errcheck
+
+		case <-a.terminate:
+			t.Stop()
+			return
+		}
+	}
+}
+
+func (a *Announcer) write(deletion bool) error {
+	pkt := Packet{
+		Deletion:      deletion,
+		MessageIDHash: a.hash,
+		Source:        a.Source,
+		Payload:       a.SDP,
+	}
+
+	buf, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = a.pc.WriteTo(buf, a.addr)
+	return err
+}
+
+// localIP returns the local IP that is used to reach an address.
+// No packet is sent.
+func localIP(addr *net.UDPAddr) (net.IP, error) {
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
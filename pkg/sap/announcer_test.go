@@ -0,0 +1,58 @@
+package sap
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnouncer(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	sdp := []byte("v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns= \r\n")
+
+	a := &Announcer{
+		SDP:      sdp,
+		Address:  pc.LocalAddr().String(),
+		Interval: 50 * time.Millisecond,
+	}
+	err = a.Start()
+	require.NoError(t, err)
+
+	read := func() Packet {
+		err := pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+		require.NoError(t, err)
+
+		buf := make([]byte, 1500)
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+
+		var pkt Packet
+		err = pkt.Unmarshal(buf[:n])
+		require.NoError(t, err)
+		return pkt
+	}
+
+	for i := 0; i < 2; i++ {
+		pkt := read()
+		require.False(t, pkt.Deletion)
+		require.Equal(t, DefaultPayloadType, pkt.PayloadType)
+		require.Equal(t, sdp, pkt.Payload)
+		require.Equal(t, net.IP{127, 0, 0, 1}, pkt.Source)
+		require.Equal(t, messageIDHash(sdp), pkt.MessageIDHash)
+	}
+
+	a.Close()
+
+	for {
+		pkt := read()
+		if pkt.Deletion {
+			require.Equal(t, sdp, pkt.Payload)
+			break
+		}
+	}
+}
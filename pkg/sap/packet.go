@@ -0,0 +1,121 @@
+package sap
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+const (
+	sapVersion = 1
+
+	// DefaultPayloadType is the payload type of SDP announcements.
+	DefaultPayloadType = "application/sdp"
+)
+
+// Packet is a SAP packet.
+// Authentication, encryption and compression are not supported.
+type Packet struct {
+	// whether the packet is a session deletion instead of an announcement.
+	Deletion bool
+
+	// hash that, together with Source, identifies a version of the announcement.
+	MessageIDHash uint16
+
+	// IP of the announcer (IPv4 or IPv6).
+	Source net.IP
+
+	// MIME type of the payload.
+	// When empty, it is assumed to be application/sdp.
+	PayloadType string
+
+	// payload (i.e. a SDP).
+	Payload []byte
+}
+
+// Unmarshal decodes a Packet.
+func (p *Packet) Unmarshal(buf []byte) error {
+	if len(buf) < 4 {
+		return fmt.Errorf("buffer is too short")
+	}
+
+	version := buf[0] >> 5
+	if version != sapVersion {
+		return fmt.Errorf("unsupported version: %d", version)
+	}
+
+	ipv6 := (buf[0] & 0x10) != 0
+	p.Deletion = (buf[0] & 0x04) != 0
+
+	if (buf[0] & 0x02) != 0 {
+		return fmt.Errorf("encrypted packets are not supported")
+	}
+
+	if (buf[0] & 0x01) != 0 {
+		return fmt.Errorf("compressed packets are not supported")
+	}
+
+	authLen := int(buf[1]) * 4
+	p.MessageIDHash = uint16(buf[2])<<8 | uint16(buf[3])
+	buf = buf[4:]
+
+	ipLen := 4
+	if ipv6 {
+		ipLen = 16
+	}
+
+	if len(buf) < (ipLen + authLen) {
+		return fmt.Errorf("buffer is too short")
+	}
+
+	p.Source = append(net.IP(nil), buf[:ipLen]...)
+	buf = buf[ipLen+authLen:]
+
+	// the payload type is optional. When missing, the payload starts with "v=0".
+	if bytes.HasPrefix(buf, []byte("v=0")) {
+		p.PayloadType = ""
+	} else {
+		i := bytes.IndexByte(buf, 0)
+		if i < 0 {
+			return fmt.Errorf("payload type is not terminated")
+		}
+		p.PayloadType = string(buf[:i])
+		buf = buf[i+1:]
+	}
+
+	p.Payload = buf
+
+	return nil
+}
+
+// Marshal encodes a Packet.
+func (p Packet) Marshal() ([]byte, error) {
+	header := byte(sapVersion << 5)
+
+	source := p.Source.To4()
+	if source == nil {
+		source = p.Source.To16()
+		if source == nil {
+			return nil, fmt.Errorf("invalid source: %v", p.Source)
+		}
+		header |= 0x10
+	}
+
+	if p.Deletion {
+		header |= 0x04
+	}
+
+	payloadType := p.PayloadType
+	if payloadType == "" {
+		payloadType = DefaultPayloadType
+	}
+
+	buf := make([]byte, 0, 4+len(source)+len(payloadType)+1+len(p.Payload))
+	buf = append(buf, header, 0, byte(p.MessageIDHash>>8), byte(p.MessageIDHash))
+	buf = append(buf, source...)
+	buf = append(buf, payloadType...)
+	buf = append(buf, 0)
+	buf = append(buf, p.Payload...)
+
+	return buf, nil
+}
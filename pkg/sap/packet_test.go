@@ -0,0 +1,74 @@
+package sap
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesPacket = []struct {
+	name string
+	byts []byte
+	pkt  Packet
+}{
+	{
+		"announcement",
+		append([]byte{
+			0x20, 0x00, 0x12, 0x34, 192, 168, 1, 2,
+		}, []byte("application/sdp\x00v=0\r\n")...),
+		Packet{
+			MessageIDHash: 0x1234,
+			Source:        net.IP{192, 168, 1, 2},
+			PayloadType:   "application/sdp",
+			Payload:       []byte("v=0\r\n"),
+		},
+	},
+	{
+		"deletion ipv6",
+		append(append([]byte{
+			0x34, 0x00, 0x12, 0x34,
+		}, net.ParseIP("2001:db8::1")...), []byte("application/sdp\x00v=0\r\n")...),
+		Packet{
+			Deletion:      true,
+			MessageIDHash: 0x1234,
+			Source:        net.ParseIP("2001:db8::1"),
+			PayloadType:   "application/sdp",
+			Payload:       []byte("v=0\r\n"),
+		},
+	},
+}
+
+func TestPacketUnmarshal(t *testing.T) {
+	for _, ca := range casesPacket {
+		t.Run(ca.name, func(t *testing.T) {
+			var pkt Packet
+			err := pkt.Unmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkt, pkt)
+		})
+	}
+}
+
+func TestPacketMarshal(t *testing.T) {
+	for _, ca := range casesPacket {
+		t.Run(ca.name, func(t *testing.T) {
+			byts, err := ca.pkt.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, ca.byts, byts)
+		})
+	}
+}
+
+func TestPacketUnmarshalWithoutPayloadType(t *testing.T) {
+	var pkt Packet
+	err := pkt.Unmarshal(append([]byte{
+		0x20, 0x01, 0x12, 0x34, 192, 168, 1, 2, 1, 2, 3, 4,
+	}, []byte("v=0\r\n")...))
+	require.NoError(t, err)
+	require.Equal(t, Packet{
+		MessageIDHash: 0x1234,
+		Source:        net.IP{192, 168, 1, 2},
+		Payload:       []byte("v=0\r\n"),
+	}, pkt)
+}
@@ -526,6 +526,7 @@ const (
 	stateSession
 	stateMedia
 	stateTimeDescription
+	stateSkippedMedia
 )
 
 func (s *SessionDescription) unmarshalSession(state *unmarshalState, key byte, val string) error {
@@ -666,68 +667,126 @@ func (s *SessionDescription) unmarshalMedia(key byte, val string) error {
 // This is rewritten from scratch to guarantee compatibility with most RTSP
 // implementations.
 func (s *SessionDescription) Unmarshal(byts []byte) error {
+	return s.unmarshal(byts, nil)
+}
+
+// UnmarshalLenient decodes a SessionDescription, tolerating common violations of the specification:
+// invalid lines are skipped, CR-only line endings are accepted, media descriptions with an
+// out-of-range port are decoded with a zero port, invalid media descriptions are skipped.
+// It returns a warning for each line that has been corrected or skipped.
+func (s *SessionDescription) UnmarshalLenient(byts []byte) []error {
+	var warnings []error
+	s.unmarshal(byts, func(err error) { //nolint:errcheck
+		warnings = append(warnings, err)
+	})
+	return warnings
+}
+
+// fixMediaPort replaces an invalid port of a media description with zero.
+func fixMediaPort(value string) (string, bool) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return "", false
+	}
+
+	parts := strings.Split(fields[1], "/")
+	if _, err := parsePort(parts[0]); err == nil {
+		return "", false
+	}
+
+	fields[1] = "0"
+	return strings.Join(fields, " "), true
+}
+
+func (s *SessionDescription) unmarshal(byts []byte, onWarning func(error)) error {
 	str := string(byts)
 
+	if onWarning != nil {
+		str = strings.ReplaceAll(str, "\r\n", "\n")
+		str = strings.ReplaceAll(str, "\r", "\n")
+	} else {
+		str = strings.ReplaceAll(str, "\r", "")
+	}
+
 	state := stateInitial
 
-	for _, line := range strings.Split(strings.ReplaceAll(str, "\r", ""), "\n") {
+	for _, line := range strings.Split(str, "\n") {
 		if line == "" {
 			continue
 		}
 
-		if len(line) < 2 || line[1] != '=' {
-			return fmt.Errorf("invalid line: (%s)", line)
+		err := s.unmarshalLine(&state, line)
+		if err == nil {
+			continue
 		}
 
-		key := line[0]
-		val := line[2:]
+		if onWarning == nil {
+			return err
+		}
 
-		switch state {
-		case stateInitial:
-			switch key {
-			case 'v':
-				err := s.unmarshalProtocolVersion(val)
-				if err != nil {
-					return err
-				}
-				state = stateSession
+		if !strings.HasPrefix(line, "m=") {
+			onWarning(fmt.Errorf("line skipped: %w", err))
+			continue
+		}
 
-			default:
-				state = stateSession
-				err := s.unmarshalSession(&state, key, val)
-				if err != nil {
-					return err
-				}
+		if fixed, ok := fixMediaPort(line[2:]); ok {
+			err2 := s.unmarshalLine(&state, "m="+fixed)
+			if err2 == nil {
+				onWarning(fmt.Errorf("port replaced with zero: %w", err))
+				continue
 			}
+		}
 
-		case stateSession:
-			err := s.unmarshalSession(&state, key, val)
-			if err != nil {
-				return err
-			}
+		// skip the media description and all its lines
+		onWarning(fmt.Errorf("media description skipped: %w", err))
+		state = stateSkippedMedia
+	}
 
-		case stateMedia:
-			err := s.unmarshalMedia(key, val)
-			if err != nil {
-				return err
-			}
+	return nil
+}
 
-		case stateTimeDescription:
-			switch key {
-			case 'r':
-				err := s.unmarshalRepeatTimes(val)
-				if err != nil {
-					return err
-				}
-
-			default:
-				state = stateSession
-				err := s.unmarshalSession(&state, key, val)
-				if err != nil {
-					return err
-				}
-			}
+func (s *SessionDescription) unmarshalLine(state *unmarshalState, line string) error {
+	if len(line) < 2 || line[1] != '=' {
+		return fmt.Errorf("invalid line: (%s)", line)
+	}
+
+	key := line[0]
+	val := line[2:]
+
+	switch *state {
+	case stateInitial:
+		*state = stateSession
+
+		if key == 'v' {
+			return s.unmarshalProtocolVersion(val)
 		}
+
+		return s.unmarshalSession(state, key, val)
+
+	case stateSession:
+		return s.unmarshalSession(state, key, val)
+
+	case stateMedia:
+		return s.unmarshalMedia(key, val)
+
+	case stateSkippedMedia:
+		if key != 'm' {
+			return nil
+		}
+
+		err := s.unmarshalMediaDescription(val)
+		if err != nil {
+			return err
+		}
+		*state = stateMedia
+
+	case stateTimeDescription:
+		if key == 'r' {
+			return s.unmarshalRepeatTimes(val)
+		}
+
+		*state = stateSession
+		return s.unmarshalSession(state, key, val)
 	}
 
 	return nil
@@ -2680,6 +2680,66 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestUnmarshalLenient(t *testing.T) {
+	byts := []byte("v=0\r" +
+		"o=- 0 0 IN IP4 127.0.0.1\r" +
+		"s=Stream\r" +
+		"invalid\r" +
+		"a=control:*\r" +
+		"m=video 70000 RTP/AVP 96\r" +
+		"a=rtpmap:96 H264/90000\r" +
+		"m=data 0 RTP/AVP 97\r" +
+		"a=rtpmap:97 private/90000\r" +
+		"m=audio 0 RTP/AVP 0\r" +
+		"a=control:trackID=2\r")
+
+	var desc SessionDescription
+	err := desc.Unmarshal(byts)
+	require.Error(t, err)
+
+	desc = SessionDescription{}
+	warnings := desc.UnmarshalLenient(byts)
+	require.Len(t, warnings, 3)
+	require.EqualError(t, warnings[0], "line skipped: invalid line: (invalid)")
+	require.EqualError(t, warnings[1], "port replaced with zero: sdp: invalid port value `70000`")
+	require.EqualError(t, warnings[2], "media description skipped: sdp: invalid value `data`")
+
+	require.Equal(t, SessionDescription{
+		Origin: psdp.Origin{
+			Username:       "-",
+			NetworkType:    "IN",
+			AddressType:    "IP4",
+			UnicastAddress: "127.0.0.1",
+		},
+		SessionName: "Stream",
+		Attributes: []psdp.Attribute{
+			{Key: "control", Value: "*"},
+		},
+		MediaDescriptions: []*psdp.MediaDescription{
+			{
+				MediaName: psdp.MediaName{
+					Media:   "video",
+					Protos:  []string{"RTP", "AVP"},
+					Formats: []string{"96"},
+				},
+				Attributes: []psdp.Attribute{
+					{Key: "rtpmap", Value: "96 H264/90000"},
+				},
+			},
+			{
+				MediaName: psdp.MediaName{
+					Media:   "audio",
+					Protos:  []string{"RTP", "AVP"},
+					Formats: []string{"0"},
+				},
+				Attributes: []psdp.Attribute{
+					{Key: "control", Value: "trackID=2"},
+				},
+			},
+		},
+	}, desc)
+}
+
 func FuzzUnmarshal(f *testing.F) {
 	f.Add("v=0\r\n" +
 		"t=2873397496 2873404696\r\n" +
@@ -2705,5 +2765,8 @@ func FuzzUnmarshal(f *testing.F) {
 	f.Fuzz(func(t *testing.T, b string) {
 		desc := SessionDescription{}
 		desc.Unmarshal([]byte(b)) //nolint:errcheck
+
+		desc = SessionDescription{}
+		desc.UnmarshalLenient([]byte(b))
 	})
 }
@@ -0,0 +1,440 @@
+// Package srtp contains a SRTP and SRTCP cryptographic context.
+// Specification: https://datatracker.ietf.org/doc/html/rfc3711
+package srtp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+const (
+	masterKeyLength  = 16
+	masterSaltLength = 14
+	authKeyLength    = 20
+	rtcpTagLength    = 10
+	rtcpIndexLength  = 4
+	maxRTCPIndex     = 0x7FFFFFFF
+	replayWindowSize = 64
+)
+
+// key derivation labels.
+const (
+	labelRTPEncryption  = 0x00
+	labelRTPAuth        = 0x01
+	labelRTPSalt        = 0x02
+	labelRTCPEncryption = 0x03
+	labelRTCPAuth       = 0x04
+	labelRTCPSalt       = 0x05
+)
+
+// Profile is a SRTP protection profile.
+type Profile int
+
+// protection profiles.
+const (
+	ProfileAESCM128HMACSHA180 Profile = iota
+	ProfileAESCM128HMACSHA132
+)
+
+var profileSuites = map[Profile]string{
+	ProfileAESCM128HMACSHA180: "AES_CM_128_HMAC_SHA1_80",
+	ProfileAESCM128HMACSHA132: "AES_CM_128_HMAC_SHA1_32",
+}
+
+// String implements fmt.Stringer.
+// It returns the name of the crypto suite, as used in SDP crypto attributes.
+func (p Profile) String() string {
+	if l, ok := profileSuites[p]; ok {
+		return l
+	}
+	return "unknown"
+}
+
+// ProfileFromSuite returns the profile that corresponds to the name of a crypto suite.
+func ProfileFromSuite(suite string) (Profile, error) {
+	for p, s := range profileSuites {
+		if s == suite {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported crypto suite: %v", suite)
+}
+
+func (p Profile) rtpTagLength() int {
+	if p == ProfileAESCM128HMACSHA132 {
+		return 4
+	}
+	return 10
+}
+
+// generate a keystream with AES in counter mode and XOR it with data.
+func xorKeyStream(block cipher.Block, iv []byte, dst []byte, src []byte) {
+	cipher.NewCTR(block, iv).XORKeyStream(dst, src)
+}
+
+// derive a session key from the master key.
+// The key derivation rate is assumed to be zero.
+func deriveKey(block cipher.Block, masterSalt []byte, label byte, n int) []byte {
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, masterSalt)
+	iv[7] ^= label
+
+	out := make([]byte, n)
+	xorKeyStream(block, iv, out, out)
+	return out
+}
+
+type sessionKeys struct {
+	block cipher.Block
+	salt  []byte
+	auth  hash.Hash
+}
+
+func newSessionKeys(masterBlock cipher.Block, masterSalt []byte, labelEnc, labelAuth, labelSalt byte) (*sessionKeys, error) {
+	block, err := aes.NewCipher(deriveKey(masterBlock, masterSalt, labelEnc, masterKeyLength))
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionKeys{
+		block: block,
+		salt:  deriveKey(masterBlock, masterSalt, labelSalt, masterSaltLength),
+		auth:  hmac.New(sha1.New, deriveKey(masterBlock, masterSalt, labelAuth, authKeyLength)),
+	}, nil
+}
+
+// IV = (k_s * 2^16) XOR (SSRC * 2^64) XOR (i * 2^16)
+func (k *sessionKeys) iv(ssrc uint32, index uint64) []byte {
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, k.salt)
+
+	var tmp [4]byte
+	binary.BigEndian.PutUint32(tmp[:], ssrc)
+	for i := 0; i < 4; i++ {
+		iv[4+i] ^= tmp[i]
+	}
+
+	for i := 0; i < 6; i++ {
+		iv[13-i] ^= byte(index >> (8 * i))
+	}
+
+	return iv
+}
+
+func (k *sessionKeys) tag(n int, parts ...[]byte) []byte {
+	k.auth.Reset()
+	for _, p := range parts {
+		k.auth.Write(p)
+	}
+	return k.auth.Sum(nil)[:n]
+}
+
+type replayWindow struct {
+	initialized bool
+	highest     uint64
+	mask        uint64
+}
+
+func (w *replayWindow) check(index uint64) bool {
+	if !w.initialized || index > w.highest {
+		return true
+	}
+
+	diff := w.highest - index
+	if diff >= replayWindowSize {
+		return false
+	}
+
+	return (w.mask & (1 << diff)) == 0
+}
+
+func (w *replayWindow) add(index uint64) {
+	if !w.initialized {
+		w.initialized = true
+		w.highest = index
+		w.mask = 1
+		return
+	}
+
+	if index > w.highest {
+		diff := index - w.highest
+		if diff >= replayWindowSize {
+			w.mask = 1
+		} else {
+			w.mask = (w.mask << diff) | 1
+		}
+		w.highest = index
+		return
+	}
+
+	w.mask |= 1 << (w.highest - index)
+}
+
+type rtpState struct {
+	initialized bool
+	roc         uint32
+	lastSeq     uint16
+	replay      replayWindow
+}
+
+// estimate the index of a packet.
+// Specification: RFC3711, Appendix A
+func (s *rtpState) estimateROC(seq uint16) uint32 {
+	if !s.initialized {
+		return 0
+	}
+
+	if s.lastSeq < 32768 {
+		if int(seq)-int(s.lastSeq) > 32768 {
+			return s.roc - 1
+		}
+		return s.roc
+	}
+
+	if int(s.lastSeq)-32768 > int(seq) {
+		return s.roc + 1
+	}
+	return s.roc
+}
+
+func (s *rtpState) update(roc uint32, seq uint16) {
+	switch {
+	case !s.initialized:
+		s.initialized = true
+		s.roc = roc
+		s.lastSeq = seq
+
+	case roc == s.roc+1:
+		s.roc = roc
+		s.lastSeq = seq
+
+	case roc == s.roc && seq > s.lastSeq:
+		s.lastSeq = seq
+	}
+}
+
+type rtcpState struct {
+	index  uint32
+	replay replayWindow
+}
+
+// Context is a SRTP and SRTCP cryptographic context.
+// It keeps track of rollover counters and replay windows of every SSRC.
+// It must be used to either protect or unprotect packets, not both.
+// RTP and RTCP methods use separate states, therefore they can be called
+// from different goroutines, while each of them can't be called concurrently.
+type Context struct {
+	profile  Profile
+	rtpKeys  *sessionKeys
+	rtcpKeys *sessionKeys
+	rtp      map[uint32]*rtpState
+	rtcp     map[uint32]*rtcpState
+}
+
+// New allocates a Context.
+// key is the concatenation of master key and master salt.
+func New(profile Profile, key []byte) (*Context, error) {
+	if _, ok := profileSuites[profile]; !ok {
+		return nil, fmt.Errorf("unsupported profile")
+	}
+
+	if len(key) != masterKeyLength+masterSaltLength {
+		return nil, fmt.Errorf("invalid key length: expected %d, got %d",
+			masterKeyLength+masterSaltLength, len(key))
+	}
+
+	masterBlock, err := aes.NewCipher(key[:masterKeyLength])
+	if err != nil {
+		return nil, err
+	}
+	masterSalt := key[masterKeyLength:]
+
+	rtpKeys, err := newSessionKeys(masterBlock, masterSalt, labelRTPEncryption, labelRTPAuth, labelRTPSalt)
+	if err != nil {
+		return nil, err
+	}
+
+	rtcpKeys, err := newSessionKeys(masterBlock, masterSalt, labelRTCPEncryption, labelRTCPAuth, labelRTCPSalt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Context{
+		profile:  profile,
+		rtpKeys:  rtpKeys,
+		rtcpKeys: rtcpKeys,
+		rtp:      make(map[uint32]*rtpState),
+		rtcp:     make(map[uint32]*rtcpState),
+	}, nil
+}
+
+func (c *Context) rtpState(ssrc uint32) *rtpState {
+	s, ok := c.rtp[ssrc]
+	if !ok {
+		s = &rtpState{}
+		c.rtp[ssrc] = s
+	}
+	return s
+}
+
+func (c *Context) rtcpState(ssrc uint32) *rtcpState {
+	s, ok := c.rtcp[ssrc]
+	if !ok {
+		s = &rtcpState{}
+		c.rtcp[ssrc] = s
+	}
+	return s
+}
+
+func rtpHeaderSize(buf []byte) (int, error) {
+	if len(buf) < 12 {
+		return 0, fmt.Errorf("RTP packet is too short")
+	}
+
+	n := 12 + 4*int(buf[0]&0x0F)
+
+	if (buf[0] & 0x10) != 0 {
+		if len(buf) < n+4 {
+			return 0, fmt.Errorf("RTP packet is too short")
+		}
+		n += 4 + 4*int(binary.BigEndian.Uint16(buf[n+2:]))
+	}
+
+	if len(buf) < n {
+		return 0, fmt.Errorf("RTP packet is too short")
+	}
+
+	return n, nil
+}
+
+// EncryptRTP protects a marshaled RTP packet.
+func (c *Context) EncryptRTP(pkt []byte) ([]byte, error) {
+	hl, err := rtpHeaderSize(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := binary.BigEndian.Uint16(pkt[2:])
+	ssrc := binary.BigEndian.Uint32(pkt[8:])
+
+	s := c.rtpState(ssrc)
+	roc := s.estimateROC(seq)
+	s.update(roc, seq)
+
+	out := make([]byte, len(pkt), len(pkt)+c.profile.rtpTagLength())
+	copy(out, pkt[:hl])
+	xorKeyStream(c.rtpKeys.block, c.rtpKeys.iv(ssrc, uint64(roc)<<16|uint64(seq)), out[hl:], pkt[hl:])
+
+	var rocBuf [4]byte
+	binary.BigEndian.PutUint32(rocBuf[:], roc)
+
+	return append(out, c.rtpKeys.tag(c.profile.rtpTagLength(), out, rocBuf[:])...), nil
+}
+
+// DecryptRTP authenticates and decrypts a SRTP packet.
+// It returns the marshaled RTP packet.
+func (c *Context) DecryptRTP(pkt []byte) ([]byte, error) {
+	tagLen := c.profile.rtpTagLength()
+
+	hl, err := rtpHeaderSize(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkt) < hl+tagLen {
+		return nil, fmt.Errorf("SRTP packet is too short")
+	}
+
+	seq := binary.BigEndian.Uint16(pkt[2:])
+	ssrc := binary.BigEndian.Uint32(pkt[8:])
+
+	s := c.rtpState(ssrc)
+	roc := s.estimateROC(seq)
+	index := uint64(roc)<<16 | uint64(seq)
+
+	if !s.replay.check(index) {
+		return nil, fmt.Errorf("replayed SRTP packet")
+	}
+
+	authenticated := pkt[:len(pkt)-tagLen]
+
+	var rocBuf [4]byte
+	binary.BigEndian.PutUint32(rocBuf[:], roc)
+
+	if subtle.ConstantTimeCompare(c.rtpKeys.tag(tagLen, authenticated, rocBuf[:]), pkt[len(pkt)-tagLen:]) != 1 {
+		return nil, fmt.Errorf("SRTP authentication failed")
+	}
+
+	s.update(roc, seq)
+	s.replay.add(index)
+
+	out := make([]byte, len(authenticated))
+	copy(out, authenticated[:hl])
+	xorKeyStream(c.rtpKeys.block, c.rtpKeys.iv(ssrc, index), out[hl:], authenticated[hl:])
+
+	return out, nil
+}
+
+// EncryptRTCP protects a marshaled RTCP packet (or compound packet).
+func (c *Context) EncryptRTCP(pkt []byte) ([]byte, error) {
+	if len(pkt) < 8 {
+		return nil, fmt.Errorf("RTCP packet is too short")
+	}
+
+	ssrc := binary.BigEndian.Uint32(pkt[4:])
+
+	s := c.rtcpState(ssrc)
+	index := s.index
+	s.index = (s.index + 1) & maxRTCPIndex
+
+	out := make([]byte, len(pkt), len(pkt)+rtcpIndexLength+rtcpTagLength)
+	copy(out, pkt[:8])
+	xorKeyStream(c.rtcpKeys.block, c.rtcpKeys.iv(ssrc, uint64(index)), out[8:], pkt[8:])
+
+	// E flag and SRTCP index
+	out = binary.BigEndian.AppendUint32(out, index|1<<31)
+
+	return append(out, c.rtcpKeys.tag(rtcpTagLength, out)...), nil
+}
+
+// DecryptRTCP authenticates and decrypts a SRTCP packet.
+// It returns the marshaled RTCP packet.
+func (c *Context) DecryptRTCP(pkt []byte) ([]byte, error) {
+	if len(pkt) < 8+rtcpIndexLength+rtcpTagLength {
+		return nil, fmt.Errorf("SRTCP packet is too short")
+	}
+
+	authenticated := pkt[:len(pkt)-rtcpTagLength]
+
+	if subtle.ConstantTimeCompare(c.rtcpKeys.tag(rtcpTagLength, authenticated), pkt[len(pkt)-rtcpTagLength:]) != 1 {
+		return nil, fmt.Errorf("SRTCP authentication failed")
+	}
+
+	v := binary.BigEndian.Uint32(authenticated[len(authenticated)-rtcpIndexLength:])
+	encrypted := (v >> 31) != 0
+	index := v & maxRTCPIndex
+
+	ssrc := binary.BigEndian.Uint32(pkt[4:])
+	s := c.rtcpState(ssrc)
+
+	if !s.replay.check(uint64(index)) {
+		return nil, fmt.Errorf("replayed SRTCP packet")
+	}
+	s.replay.add(uint64(index))
+
+	payload := authenticated[:len(authenticated)-rtcpIndexLength]
+	out := make([]byte, len(payload))
+	copy(out, payload)
+
+	if encrypted {
+		xorKeyStream(c.rtcpKeys.block, c.rtcpKeys.iv(ssrc, uint64(index)), out[8:], payload[8:])
+	}
+
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package srtp
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(s string) []byte {
+	byts, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return byts
+}
+
+var testKey = mustDecodeHex("E1F97A0D3E018BE0D64FA32C06DE4139" + "0EC675AD498AFEEBB6960B3AABE6")
+
+func TestKeyStream(t *testing.T) {
+	// RFC3711, B.2
+	block, err := aes.NewCipher(mustDecodeHex("2B7E151628AED2A6ABF7158809CF4F3C"))
+	require.NoError(t, err)
+
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, mustDecodeHex("F0F1F2F3F4F5F6F7F8F9FAFBFCFD"))
+
+	out := make([]byte, 48)
+	xorKeyStream(block, iv, out, out)
+	require.Equal(t, mustDecodeHex(
+		"E03EAD0935C95E80E166B16DD92B4EB4"+
+			"D23513162B02D0F72A43A2FE4A5F97AB"+
+			"41E95B3BB0A2E8DD477901E4FCA894C0"), out)
+}
+
+func TestKeyDerivation(t *testing.T) {
+	// RFC3711, B.3
+	block, err := aes.NewCipher(testKey[:masterKeyLength])
+	require.NoError(t, err)
+	salt := testKey[masterKeyLength:]
+
+	require.Equal(t, mustDecodeHex("C61E7A93744F39EE10734AFE3FF7A087"),
+		deriveKey(block, salt, labelRTPEncryption, masterKeyLength))
+	require.Equal(t, mustDecodeHex("30CBBC08863D8C85D49DB34A9AE1"),
+		deriveKey(block, salt, labelRTPSalt, masterSaltLength))
+	require.Equal(t, mustDecodeHex("CEBE321F6FF7716B6FD4AB49AF256A156D38BAA4"),
+		deriveKey(block, salt, labelRTPAuth, authKeyLength))
+}
+
+func TestProfileFromSuite(t *testing.T) {
+	p, err := ProfileFromSuite("AES_CM_128_HMAC_SHA1_32")
+	require.NoError(t, err)
+	require.Equal(t, ProfileAESCM128HMACSHA132, p)
+	require.Equal(t, "AES_CM_128_HMAC_SHA1_32", p.String())
+
+	_, err = ProfileFromSuite("F8_128_HMAC_SHA1_80")
+	require.EqualError(t, err, "unsupported crypto suite: F8_128_HMAC_SHA1_80")
+}
+
+func TestRTP(t *testing.T) {
+	for _, profile := range []Profile{
+		ProfileAESCM128HMACSHA180,
+		ProfileAESCM128HMACSHA132,
+	} {
+		t.Run(profile.String(), func(t *testing.T) {
+			enc, err := New(profile, testKey)
+			require.NoError(t, err)
+
+			dec, err := New(profile, testKey)
+			require.NoError(t, err)
+
+			// sequence numbers wrap around
+			for _, seq := range []uint16{65534, 65535, 0, 1} {
+				pkt := rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: seq,
+						Timestamp:      45343,
+						SSRC:           0x9dbb7812,
+					},
+					Payload: []byte{0x01, 0x02, 0x03, 0x04},
+				}
+
+				plain, err := pkt.Marshal()
+				require.NoError(t, err)
+
+				encrypted, err := enc.EncryptRTP(plain)
+				require.NoError(t, err)
+				require.Len(t, encrypted, len(plain)+profile.rtpTagLength())
+				require.Equal(t, plain[:12], encrypted[:12])
+				require.NotEqual(t, plain[12:], encrypted[12:len(plain)])
+
+				decrypted, err := dec.DecryptRTP(encrypted)
+				require.NoError(t, err)
+				require.Equal(t, plain, decrypted)
+
+				_, err = dec.DecryptRTP(encrypted)
+				require.EqualError(t, err, "replayed SRTP packet")
+			}
+
+			require.Equal(t, uint32(1), dec.rtp[0x9dbb7812].roc)
+		})
+	}
+}
+
+func TestRTPAuthenticationError(t *testing.T) {
+	enc, err := New(ProfileAESCM128HMACSHA180, testKey)
+	require.NoError(t, err)
+
+	dec, err := New(ProfileAESCM128HMACSHA180, testKey)
+	require.NoError(t, err)
+
+	plain, err := (&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			SequenceNumber: 1234,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	}).Marshal()
+	require.NoError(t, err)
+
+	encrypted, err := enc.EncryptRTP(plain)
+	require.NoError(t, err)
+
+	encrypted[13] ^= 0xFF
+
+	_, err = dec.DecryptRTP(encrypted)
+	require.EqualError(t, err, "SRTP authentication failed")
+}
+
+func TestRTCP(t *testing.T) {
+	enc, err := New(ProfileAESCM128HMACSHA132, testKey)
+	require.NoError(t, err)
+
+	dec, err := New(ProfileAESCM128HMACSHA132, testKey)
+	require.NoError(t, err)
+
+	plain, err := (&rtcp.SenderReport{
+		SSRC:        0x38F27A2F,
+		NTPTime:     0xe363887a17ced916,
+		RTPTime:     1287981738,
+		PacketCount: 714,
+		OctetCount:  859127,
+	}).Marshal()
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		encrypted, err := enc.EncryptRTCP(plain)
+		require.NoError(t, err)
+		require.Len(t, encrypted, len(plain)+rtcpIndexLength+rtcpTagLength)
+		require.Equal(t, plain[:8], encrypted[:8])
+
+		decrypted, err := dec.DecryptRTCP(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, plain, decrypted)
+
+		_, err = dec.DecryptRTCP(encrypted)
+		require.EqualError(t, err, "replayed SRTCP packet")
+
+		encrypted[10] ^= 0xFF
+
+		_, err = dec.DecryptRTCP(encrypted)
+		require.EqualError(t, err, "SRTCP authentication failed")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(ProfileAESCM128HMACSHA180, []byte{1, 2, 3})
+	require.EqualError(t, err, "invalid key length: expected 30, got 3")
+}
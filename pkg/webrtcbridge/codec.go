@@ -0,0 +1,168 @@
+// Package webrtcbridge contains adapters between gortsplib formats and RTP flows
+// and the interfaces of pion/webrtc, in order to translate streams between RTSP and WebRTC.
+//
+// pion/webrtc is not imported, in order not to add it to the dependencies of gortsplib.
+// Codecs have the same fields as webrtc.RTPCodecParameters and TrackWriter writes
+// to any track that implements WriteRTP(), like webrtc.TrackLocalStaticRTP:
+//
+//	c, _ := webrtcbridge.CodecFromFormat(medi.Type, forma)
+//
+//	track, _ := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{
+//		MimeType:    c.MimeType,
+//		ClockRate:   c.ClockRate,
+//		Channels:    c.Channels,
+//		SDPFmtpLine: c.SDPFmtpLine,
+//	}, "video", "gortsplib")
+//
+//	w := &webrtcbridge.TrackWriter{Format: forma, Track: track}
+//	w.Init()
+//
+//	client.OnPacketRTP(medi, forma, func(pkt *rtp.Packet) {
+//		w.WritePacketRTP(pkt)
+//	})
+//
+// In the opposite direction, TrackReader reads from any track that implements ReadRTP(),
+// like webrtc.TrackRemote, and provides the media and format of the track:
+//
+//	c := track.Codec()
+//
+//	r := &webrtcbridge.TrackReader[interceptor.Attributes]{
+//		Codec: &webrtcbridge.Codec{
+//			MimeType:    c.MimeType,
+//			ClockRate:   c.ClockRate,
+//			Channels:    c.Channels,
+//			SDPFmtpLine: c.SDPFmtpLine,
+//			PayloadType: uint8(c.PayloadType),
+//		},
+//		Track: track,
+//	}
+//	r.Init()
+//
+//	stream := gortsplib.NewServerStream(server, &description.Session{Medias: []*description.Media{r.Media}})
+//
+//	for {
+//		pkt, err := r.ReadPacketRTP()
+//		if err != nil {
+//			break
+//		}
+//		stream.WritePacketRTP(r.Media, pkt)
+//	}
+package webrtcbridge
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// Codec is a codec, described with the fields of webrtc.RTPCodecParameters.
+type Codec struct {
+	// MIME type, in the form "video/H264".
+	MimeType string
+	// clock rate of RTP timestamps.
+	ClockRate uint32
+	// channel count, zero when not specified.
+	Channels uint16
+	// parameters of the fmtp attribute, in the form "key1=value1;key2=value2".
+	SDPFmtpLine string
+	// payload type.
+	PayloadType uint8
+}
+
+// CodecFromFormat converts a format into a Codec.
+// Browsers support only a subset of formats (H264, H265, VP8, VP9, AV1, Opus, G722, G711),
+// therefore the result must be matched against the codecs of the peer.
+func CodecFromFormat(mediaType description.MediaType, forma format.Format) (*Codec, error) {
+	parts := strings.Split(forma.RTPMap(), "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("format %s doesn't have a rtpmap", forma.Codec())
+	}
+
+	clockRate, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clock rate: %v", parts[1])
+	}
+
+	c := &Codec{
+		MimeType:    string(mediaType) + "/" + parts[0],
+		ClockRate:   uint32(clockRate),
+		SDPFmtpLine: marshalFMTP(forma.FMTP()),
+		PayloadType: forma.PayloadType(),
+	}
+
+	if len(parts) >= 3 {
+		channels, err := strconv.ParseUint(parts[2], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel count: %v", parts[2])
+		}
+		c.Channels = uint16(channels)
+	}
+
+	return c, nil
+}
+
+// FormatFromCodec converts a Codec into a format.
+func FormatFromCodec(c *Codec) (format.Format, error) {
+	parts := strings.SplitN(c.MimeType, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid MIME type: %v", c.MimeType)
+	}
+
+	rtpMap := parts[1] + "/" + strconv.FormatUint(uint64(c.ClockRate), 10)
+	if c.Channels != 0 {
+		rtpMap += "/" + strconv.FormatUint(uint64(c.Channels), 10)
+	}
+
+	return format.Unmarshal(strings.ToLower(parts[0]), c.PayloadType, rtpMap, unmarshalFMTP(c.SDPFmtpLine))
+}
+
+func marshalFMTP(fmtp map[string]string) string {
+	keys := make([]string, 0, len(fmtp))
+	for key := range fmtp {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if key == "" {
+			parts[i] = fmtp[key]
+		} else {
+			parts[i] = key + "=" + fmtp[key]
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func unmarshalFMTP(line string) map[string]string {
+	if line == "" {
+		return nil
+	}
+
+	fmtp := make(map[string]string)
+
+	for _, kv := range strings.Split(line, ";") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+
+		tmp := strings.SplitN(kv, "=", 2)
+
+		// parameters that are not in the key=value form, like the ones of RED (RFC2198),
+		// are stored with an empty key.
+		if len(tmp) != 2 {
+			fmtp[""] = kv
+			continue
+		}
+
+		fmtp[strings.ToLower(tmp[0])] = tmp[1]
+	}
+
+	return fmtp
+}
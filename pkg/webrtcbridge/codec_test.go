@@ -0,0 +1,97 @@
+package webrtcbridge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+var casesCodec = []struct {
+	name      string
+	mediaType description.MediaType
+	format    format.Format
+	codec     *Codec
+}{
+	{
+		"h264",
+		description.MediaTypeVideo,
+		&format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+			SPS: []byte{
+				0x67, 0x64, 0x00, 0x0c, 0xac, 0x3b, 0x50, 0xb0,
+				0x4b, 0x42, 0x00, 0x00, 0x03, 0x00, 0x02, 0x00,
+				0x00, 0x03, 0x00, 0x3d, 0x08,
+			},
+			PPS: []byte{0x68, 0xee, 0x3c, 0x80},
+		},
+		&Codec{
+			MimeType:  "video/H264",
+			ClockRate: 90000,
+			SDPFmtpLine: "packetization-mode=1;profile-level-id=64000C;" +
+				"sprop-parameter-sets=Z2QADKw7ULBLQgAAAwACAAADAD0I,aO48gA==",
+			PayloadType: 96,
+		},
+	},
+	{
+		"vp8",
+		description.MediaTypeVideo,
+		&format.VP8{
+			PayloadTyp: 97,
+		},
+		&Codec{
+			MimeType:    "video/VP8",
+			ClockRate:   90000,
+			PayloadType: 97,
+		},
+	},
+	{
+		"opus",
+		description.MediaTypeAudio,
+		&format.Opus{
+			PayloadTyp: 111,
+			IsStereo:   true,
+		},
+		&Codec{
+			MimeType:    "audio/opus",
+			ClockRate:   48000,
+			Channels:    2,
+			SDPFmtpLine: "sprop-stereo=1",
+			PayloadType: 111,
+		},
+	},
+	{
+		"g711",
+		description.MediaTypeAudio,
+		&format.G711{
+			MULaw: true,
+		},
+		&Codec{
+			MimeType:  "audio/PCMU",
+			ClockRate: 8000,
+		},
+	},
+}
+
+func TestCodecFromFormat(t *testing.T) {
+	for _, ca := range casesCodec {
+		t.Run(ca.name, func(t *testing.T) {
+			c, err := CodecFromFormat(ca.mediaType, ca.format)
+			require.NoError(t, err)
+			require.Equal(t, ca.codec, c)
+		})
+	}
+}
+
+func TestFormatFromCodec(t *testing.T) {
+	for _, ca := range casesCodec {
+		t.Run(ca.name, func(t *testing.T) {
+			forma, err := FormatFromCodec(ca.codec)
+			require.NoError(t, err)
+			require.Equal(t, ca.format, forma)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package webrtcbridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// RTPReader is a track that returns RTP packets, like webrtc.TrackRemote.
+// A is the type of the attributes returned together with packets
+// (interceptor.Attributes in case of webrtc.TrackRemote), that are not used.
+type RTPReader[A any] interface {
+	ReadRTP() (*rtp.Packet, A, error)
+}
+
+// TrackReader reads RTP packets from a WebRTC track and
+// provides them together with the corresponding media and format.
+//
+// Packets are returned as they are, since RTP payloads of WebRTC can be
+// written to a gortsplib.ServerStream or sent by a gortsplib.Client without changes.
+// Packets with a payload type different from the one of the codec and
+// padding-only packets are discarded.
+type TrackReader[A any] struct {
+	// codec of the track, filled from webrtc.TrackRemote.Codec().
+	Codec *Codec
+
+	// track to read from.
+	Track RTPReader[A]
+
+	// media of the track.
+	// It is filled by Init().
+	Media *description.Media
+
+	// format of the track.
+	// It is filled by Init().
+	Format format.Format
+}
+
+// Init initializes the TrackReader.
+func (r *TrackReader[A]) Init() error {
+	if r.Codec == nil {
+		return fmt.Errorf("codec not provided")
+	}
+	if r.Track == nil {
+		return fmt.Errorf("track not provided")
+	}
+
+	var err error
+	r.Format, err = FormatFromCodec(r.Codec)
+	if err != nil {
+		return err
+	}
+
+	mediaType := description.MediaType(strings.ToLower(strings.SplitN(r.Codec.MimeType, "/", 2)[0]))
+
+	r.Media = &description.Media{
+		Type:    mediaType,
+		Formats: []format.Format{r.Format},
+	}
+
+	return nil
+}
+
+// ReadPacketRTP reads a RTP packet.
+func (r *TrackReader[A]) ReadPacketRTP() (*rtp.Packet, error) {
+	for {
+		pkt, _, err := r.Track.ReadRTP()
+		if err != nil {
+			return nil, err
+		}
+
+		if pkt.PayloadType != r.Codec.PayloadType || len(pkt.Payload) == 0 {
+			continue
+		}
+
+		return pkt, nil
+	}
+}
@@ -0,0 +1,100 @@
+package webrtcbridge
+
+import (
+	"io"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// testAttributes has the same type as interceptor.Attributes.
+type testAttributes map[interface{}]interface{}
+
+type testRemoteTrack struct {
+	pkts []*rtp.Packet
+}
+
+func (t *testRemoteTrack) ReadRTP() (*rtp.Packet, testAttributes, error) {
+	if len(t.pkts) == 0 {
+		return nil, nil, io.EOF
+	}
+
+	pkt := t.pkts[0]
+	t.pkts = t.pkts[1:]
+	return pkt, testAttributes{}, nil
+}
+
+func TestTrackReader(t *testing.T) {
+	for _, ca := range casesCodec {
+		t.Run(ca.name, func(t *testing.T) {
+			r := &TrackReader[testAttributes]{
+				Codec: ca.codec,
+				Track: &testRemoteTrack{},
+			}
+			err := r.Init()
+			require.NoError(t, err)
+			require.Equal(t, ca.format, r.Format)
+			require.Equal(t, &description.Media{
+				Type:    ca.mediaType,
+				Formats: []format.Format{ca.format},
+			}, r.Media)
+		})
+	}
+}
+
+func TestTrackReaderReadPacketRTP(t *testing.T) {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    111,
+			SequenceNumber: 124,
+			Timestamp:      45678,
+		},
+		Payload: []byte{1, 2, 3},
+	}
+
+	track := &testRemoteTrack{pkts: []*rtp.Packet{
+		{ // different payload type
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    112,
+				SequenceNumber: 122,
+			},
+			Payload: []byte{1, 2, 3},
+		},
+		{ // padding only
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    111,
+				SequenceNumber: 123,
+				Padding:        true,
+			},
+			PaddingSize: 4,
+		},
+		pkt,
+	}}
+
+	r := &TrackReader[testAttributes]{
+		Codec: &Codec{
+			MimeType:    "audio/opus",
+			ClockRate:   48000,
+			Channels:    2,
+			SDPFmtpLine: "sprop-stereo=1",
+			PayloadType: 111,
+		},
+		Track: track,
+	}
+	err := r.Init()
+	require.NoError(t, err)
+
+	pkt2, err := r.ReadPacketRTP()
+	require.NoError(t, err)
+	require.Equal(t, pkt, pkt2)
+
+	_, err = r.ReadPacketRTP()
+	require.Equal(t, io.EOF, err)
+}
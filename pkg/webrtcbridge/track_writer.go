@@ -0,0 +1,270 @@
+package webrtcbridge
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph265"
+)
+
+// payload size of packets that fit into the MTU used by WebRTC implementations (1200 bytes),
+// after the RTP header (12 bytes) and the SRTP authentication tag (10 bytes).
+const defaultMaxPayloadSize = 1200 - 12 - 10
+
+// RTPWriter is a track that accepts RTP packets, like webrtc.TrackLocalStaticRTP.
+type RTPWriter interface {
+	WriteRTP(pkt *rtp.Packet) error
+}
+
+// TrackWriter writes RTP packets of a format to a WebRTC track.
+//
+// H264 and H265 packets are decoded into access units and encoded again,
+// in order to fit into the MTU of WebRTC and to send parameter sets before every
+// random access unit, since browsers don't read them from the SDP and
+// viewers can join at any time. Access units that precede the first random access
+// unit are discarded. Packets of other formats are written as they are.
+//
+// Timestamps are kept, therefore the presentation time of packets is preserved.
+// SSRC and payload type are set by the track.
+type TrackWriter struct {
+	// format of packets.
+	Format format.Format
+
+	// track to write to.
+	Track RTPWriter
+
+	// maximum size of RTP payloads (optional).
+	// It defaults to 1178, that fits into the MTU of WebRTC.
+	MaxPayloadSize int
+
+	h264Dec *rtph264.Decoder
+	h264Enc *rtph264.Encoder
+	h265Dec *rtph265.Decoder
+	h265Enc *rtph265.Encoder
+	vps     []byte
+	sps     []byte
+	pps     []byte
+	started bool
+}
+
+// Init initializes the TrackWriter.
+func (w *TrackWriter) Init() error {
+	if w.Format == nil {
+		return fmt.Errorf("format not provided")
+	}
+	if w.Track == nil {
+		return fmt.Errorf("track not provided")
+	}
+	if w.MaxPayloadSize == 0 {
+		w.MaxPayloadSize = defaultMaxPayloadSize
+	}
+
+	switch forma := w.Format.(type) {
+	case *format.H264:
+		if forma.PacketizationMode == 0 {
+			return fmt.Errorf("packetization mode 0 is not supported by WebRTC")
+		}
+
+		var err error
+		w.h264Dec, err = forma.CreateDecoder()
+		if err != nil {
+			return err
+		}
+
+		w.h264Enc = &rtph264.Encoder{
+			PayloadType:       forma.PayloadTyp,
+			PayloadMaxSize:    w.MaxPayloadSize,
+			PacketizationMode: 1,
+		}
+		err = w.h264Enc.Init()
+		if err != nil {
+			return err
+		}
+
+		w.sps, w.pps = forma.SafeParams()
+
+	case *format.H265:
+		var err error
+		w.h265Dec, err = forma.CreateDecoder()
+		if err != nil {
+			return err
+		}
+
+		w.h265Enc = &rtph265.Encoder{
+			PayloadType:    forma.PayloadTyp,
+			PayloadMaxSize: w.MaxPayloadSize,
+		}
+		err = w.h265Enc.Init()
+		if err != nil {
+			return err
+		}
+
+		w.vps, w.sps, w.pps = forma.SafeParams()
+	}
+
+	return nil
+}
+
+// WritePacketRTP writes a RTP packet.
+func (w *TrackWriter) WritePacketRTP(pkt *rtp.Packet) error {
+	switch {
+	case w.h264Dec != nil:
+		au, err := w.h264Dec.Decode(pkt)
+		if err != nil {
+			if errors.Is(err, rtph264.ErrMorePacketsNeeded) {
+				return nil
+			}
+			return err
+		}
+
+		au = w.prepareH264(au)
+		if au == nil {
+			return nil
+		}
+
+		pkts, err := w.h264Enc.Encode(au)
+		if err != nil {
+			return err
+		}
+
+		return w.writePackets(pkts, pkt.Timestamp)
+
+	case w.h265Dec != nil:
+		au, err := w.h265Dec.Decode(pkt)
+		if err != nil {
+			if errors.Is(err, rtph265.ErrMorePacketsNeeded) {
+				return nil
+			}
+			return err
+		}
+
+		au = w.prepareH265(au)
+		if au == nil {
+			return nil
+		}
+
+		pkts, err := w.h265Enc.Encode(au)
+		if err != nil {
+			return err
+		}
+
+		return w.writePackets(pkts, pkt.Timestamp)
+
+	default:
+		return w.Track.WriteRTP(pkt)
+	}
+}
+
+func (w *TrackWriter) writePackets(pkts []*rtp.Packet, timestamp uint32) error {
+	for _, pkt := range pkts {
+		pkt.Timestamp = timestamp
+
+		err := w.Track.WriteRTP(pkt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepareH264 stores in-band parameter sets, prepends them to IDR access units
+// and discards access units until the first IDR.
+func (w *TrackWriter) prepareH264(au [][]byte) [][]byte {
+	ret := make([][]byte, 0, len(au)+2)
+	idr := false
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			w.sps = nalu
+			continue
+
+		case h264.NALUTypePPS:
+			w.pps = nalu
+			continue
+
+		case h264.NALUTypeIDR:
+			idr = true
+
+		case h264.NALUTypeAccessUnitDelimiter:
+			continue
+		}
+
+		ret = append(ret, nalu)
+	}
+
+	if !idr {
+		if !w.started || len(ret) == 0 {
+			return nil
+		}
+		return ret
+	}
+
+	if w.sps == nil || w.pps == nil {
+		return nil
+	}
+
+	w.started = true
+
+	return append([][]byte{w.sps, w.pps}, ret...)
+}
+
+// prepareH265 stores in-band parameter sets, prepends them to random access units
+// and discards access units until the first random access unit.
+func (w *TrackWriter) prepareH265(au [][]byte) [][]byte {
+	ret := make([][]byte, 0, len(au)+3)
+	randomAccess := false
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+		case h265.NALUType_VPS_NUT:
+			w.vps = nalu
+			continue
+
+		case h265.NALUType_SPS_NUT:
+			w.sps = nalu
+			continue
+
+		case h265.NALUType_PPS_NUT:
+			w.pps = nalu
+			continue
+
+		case h265.NALUType_IDR_W_RADL, h265.NALUType_IDR_N_LP, h265.NALUType_CRA_NUT:
+			randomAccess = true
+
+		case h265.NALUType_AUD_NUT:
+			continue
+		}
+
+		ret = append(ret, nalu)
+	}
+
+	if !randomAccess {
+		if !w.started || len(ret) == 0 {
+			return nil
+		}
+		return ret
+	}
+
+	if w.vps == nil || w.sps == nil || w.pps == nil {
+		return nil
+	}
+
+	w.started = true
+
+	return append([][]byte{w.vps, w.sps, w.pps}, ret...)
+}
@@ -0,0 +1,109 @@
+package webrtcbridge
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+type testTrack struct {
+	pkts []*rtp.Packet
+}
+
+func (t *testTrack) WriteRTP(pkt *rtp.Packet) error {
+	t.pkts = append(t.pkts, pkt)
+	return nil
+}
+
+func TestTrackWriterH264(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+		SPS: []byte{
+			0x67, 0x64, 0x00, 0x0c, 0xac, 0x3b, 0x50, 0xb0,
+			0x4b, 0x42, 0x00, 0x00, 0x03, 0x00, 0x02, 0x00,
+			0x00, 0x03, 0x00, 0x3d, 0x08,
+		},
+		PPS: []byte{0x68, 0xee, 0x3c, 0x80},
+	}
+
+	track := &testTrack{}
+
+	w := &TrackWriter{
+		Format: forma,
+		Track:  track,
+	}
+	err := w.Init()
+	require.NoError(t, err)
+
+	// packets are produced with a payload size greater than the WebRTC one
+	enc, err := forma.CreateEncoder()
+	require.NoError(t, err)
+	enc.PayloadMaxSize = 1450
+
+	write := func(au [][]byte, timestamp uint32) {
+		pkts, err := enc.Encode(au)
+		require.NoError(t, err)
+
+		for _, pkt := range pkts {
+			pkt.Timestamp = timestamp
+			err = w.WritePacketRTP(pkt)
+			require.NoError(t, err)
+		}
+	}
+
+	// non-IDR before the first IDR
+	write([][]byte{{0x01, 0x02}}, 1000)
+	require.Empty(t, track.pkts)
+
+	idr := append([]byte{0x65}, bytes.Repeat([]byte{1}, 3000)...)
+	write([][]byte{idr}, 2000)
+
+	require.Equal(t, []byte{0x18}, track.pkts[0].Payload[:1]) // parameters are aggregated
+	require.Greater(t, len(track.pkts), 3)
+
+	var buf []byte
+	for _, pkt := range track.pkts[1:] {
+		require.Equal(t, uint32(2000), pkt.Timestamp)
+		require.LessOrEqual(t, len(pkt.Payload), 1178)
+		buf = append(buf, pkt.Payload[2:]...)
+	}
+	require.Equal(t, idr[1:], buf)
+	require.True(t, track.pkts[len(track.pkts)-1].Marker)
+
+	track.pkts = nil
+
+	write([][]byte{{0x01, 0x03}}, 3000)
+	require.Len(t, track.pkts, 1)
+	require.Equal(t, []byte{0x01, 0x03}, track.pkts[0].Payload)
+	require.Equal(t, uint32(3000), track.pkts[0].Timestamp)
+}
+
+func TestTrackWriterPassthrough(t *testing.T) {
+	track := &testTrack{}
+
+	w := &TrackWriter{
+		Format: &format.Opus{PayloadTyp: 111, IsStereo: true},
+		Track:  track,
+	}
+	err := w.Init()
+	require.NoError(t, err)
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    111,
+			SequenceNumber: 123,
+			Timestamp:      45678,
+		},
+		Payload: []byte{1, 2, 3},
+	}
+
+	err = w.WritePacketRTP(pkt)
+	require.NoError(t, err)
+	require.Equal(t, []*rtp.Packet{pkt}, track.pkts)
+}
@@ -0,0 +1,248 @@
+package gortsplib
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// ReceiverQuality is an estimate of the quality of a received format,
+// computed continuously from received packets.
+type ReceiverQuality struct {
+	// fraction of packets that have been lost.
+	LossRate float64
+
+	// fraction of packets that have been received out of order.
+	ReorderRate float64
+
+	// number of video frames that can't be decoded entirely,
+	// since some of their packets, like the last fragment of a fragmentation unit, are missing.
+	FramesIncomplete uint64
+
+	// estimated time during which video has been frozen,
+	// from each incomplete frame to the next complete one.
+	FreezeDuration time.Duration
+
+	// quality score, between 0 (unusable) and 100 (perfect).
+	// It is decreased by losses, jitter, reordering and freezes.
+	Score float64
+}
+
+type fragmentationKind int
+
+const (
+	fragmentationNone fragmentationKind = iota
+	fragmentationH264
+	fragmentationH265
+)
+
+// receiverQuality computes the quality of a received format.
+type receiverQuality struct {
+	clockRate     int
+	video         bool
+	fragmentation fragmentationKind
+
+	mutex            sync.Mutex
+	received         uint64
+	lost             uint64
+	reordered        uint64
+	highestSeqNum    uint16
+	initialized      bool
+	frameOpen        bool
+	frameTimestamp   uint32
+	frameDamaged     bool
+	fragmentOpen     bool
+	framesIncomplete uint64
+	frozen           bool
+	freezeStart      uint32
+	freezeDuration   time.Duration
+	firstTimestamp   uint32
+	lastTimestamp    uint32
+	timestampSet     bool
+}
+
+func newReceiverQuality(medi *description.Media, forma format.Format, clockRate int) *receiverQuality {
+	q := &receiverQuality{
+		clockRate: clockRate,
+		video:     medi.Type == description.MediaTypeVideo,
+	}
+
+	switch forma.(type) {
+	case *format.H264:
+		q.fragmentation = fragmentationH264
+
+	case *format.H265:
+		q.fragmentation = fragmentationH265
+	}
+
+	return q
+}
+
+// processArrival processes a packet in order of arrival, before reordering.
+func (q *receiverQuality) processArrival(pkt *rtp.Packet) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.received++
+
+	if !q.initialized {
+		q.initialized = true
+		q.highestSeqNum = pkt.SequenceNumber
+		return
+	}
+
+	diff := int16(pkt.SequenceNumber - q.highestSeqNum)
+	if diff < 0 {
+		q.reordered++
+	} else {
+		q.highestSeqNum = pkt.SequenceNumber
+	}
+}
+
+// processLost processes packets that have been lost.
+func (q *receiverQuality) processLost(lost int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.lost += uint64(lost)
+
+	// missing packets belong either to the frame in progress or to the next one.
+	q.frameDamaged = true
+}
+
+// processPacket processes a packet in order of sequence number.
+func (q *receiverQuality) processPacket(pkt *rtp.Packet) {
+	if !q.video {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !q.timestampSet {
+		q.timestampSet = true
+		q.firstTimestamp = pkt.Timestamp
+	}
+	q.lastTimestamp = pkt.Timestamp
+
+	// the previous frame ended without its last packet
+	if q.frameOpen && pkt.Timestamp != q.frameTimestamp {
+		q.frameDamaged = true
+		q.closeFrame()
+	}
+
+	if !q.frameOpen {
+		q.frameOpen = true
+		q.frameTimestamp = pkt.Timestamp
+		q.fragmentOpen = false
+	}
+
+	start, end, ok := q.fragment(pkt.Payload)
+	if ok {
+		// the previous fragmentation unit ended without its last fragment
+		if start && q.fragmentOpen {
+			q.frameDamaged = true
+		}
+		q.fragmentOpen = !end
+	} else if q.fragmentOpen {
+		q.frameDamaged = true
+		q.fragmentOpen = false
+	}
+
+	if pkt.Marker {
+		if q.fragmentOpen {
+			q.frameDamaged = true
+		}
+		q.closeFrame()
+	}
+}
+
+// fragment returns the start and end flags of a fragmentation unit.
+func (q *receiverQuality) fragment(payload []byte) (bool, bool, bool) {
+	switch q.fragmentation {
+	case fragmentationH264:
+		if len(payload) >= 2 && (payload[0]&0x1F) == 28 {
+			return (payload[1] & 0x80) != 0, (payload[1] & 0x40) != 0, true
+		}
+
+	case fragmentationH265:
+		if len(payload) >= 3 && ((payload[0]>>1)&0x3F) == 49 {
+			return (payload[2] & 0x80) != 0, (payload[2] & 0x40) != 0, true
+		}
+	}
+
+	return false, false, false
+}
+
+func (q *receiverQuality) closeFrame() {
+	if q.frameDamaged {
+		q.framesIncomplete++
+
+		if !q.frozen {
+			q.frozen = true
+			q.freezeStart = q.frameTimestamp
+		}
+	} else if q.frozen {
+		q.frozen = false
+		q.freezeDuration += q.timestampDuration(q.frameTimestamp - q.freezeStart)
+	}
+
+	q.frameOpen = false
+	q.frameDamaged = false
+	q.fragmentOpen = false
+}
+
+func (q *receiverQuality) timestampDuration(v uint32) time.Duration {
+	return time.Duration(v) * time.Second / time.Duration(q.clockRate)
+}
+
+// quality returns the quality, given the jitter computed by the RTCP receiver.
+func (q *receiverQuality) quality(jitter time.Duration) ReceiverQuality {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	ret := ReceiverQuality{
+		FramesIncomplete: q.framesIncomplete,
+		FreezeDuration:   q.freezeDuration,
+	}
+
+	if total := q.received + q.lost; total != 0 {
+		ret.LossRate = float64(q.lost) / float64(total)
+	}
+
+	if q.received != 0 {
+		ret.ReorderRate = float64(q.reordered) / float64(q.received)
+	}
+
+	var freezeRatio float64
+	if q.timestampSet && q.clockRate != 0 {
+		if elapsed := q.timestampDuration(q.lastTimestamp - q.firstTimestamp); elapsed > 0 {
+			freezeRatio = float64(ret.FreezeDuration) / float64(elapsed)
+		}
+	}
+
+	// each percent of losses costs 5 points, each 10ms of jitter costs 1 point,
+	// each percent of reordered packets costs 2 points, each percent of frozen time costs 1 point.
+	ret.Score = 100 -
+		capPenalty(ret.LossRate*500, 60) -
+		capPenalty(float64(jitter)/float64(10*time.Millisecond), 20) -
+		capPenalty(ret.ReorderRate*200, 10) -
+		capPenalty(freezeRatio*100, 40)
+
+	if ret.Score < 0 {
+		ret.Score = 0
+	}
+
+	return ret
+}
+
+func capPenalty(v float64, max float64) float64 {
+	if v > max {
+		return max
+	}
+	return v
+}
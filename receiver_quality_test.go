@@ -0,0 +1,73 @@
+package gortsplib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+func TestReceiverQualityVideo(t *testing.T) {
+	q := newReceiverQuality(testH264Media, testH264Media.Formats[0], 90000)
+
+	pkts := []*rtp.Packet{
+		// complete frame, made of a fragmentation unit
+		{
+			Header:  rtp.Header{SequenceNumber: 1, Timestamp: 0},
+			Payload: []byte{0x1c, 0x85, 0x01},
+		},
+		{
+			Header:  rtp.Header{SequenceNumber: 2, Timestamp: 0, Marker: true},
+			Payload: []byte{0x1c, 0x45, 0x02},
+		},
+		// frame whose last fragment is lost
+		{
+			Header:  rtp.Header{SequenceNumber: 3, Timestamp: 3000},
+			Payload: []byte{0x1c, 0x85, 0x03},
+		},
+		// complete frame, that ends the freeze
+		{
+			Header:  rtp.Header{SequenceNumber: 5, Timestamp: 6000, Marker: true},
+			Payload: []byte{0x05, 0x04},
+		},
+	}
+
+	for _, pkt := range pkts {
+		q.processArrival(pkt)
+		if pkt.SequenceNumber == 5 {
+			q.processLost(1)
+		}
+		q.processPacket(pkt)
+	}
+
+	// out of order arrivals
+	q.processArrival(&rtp.Packet{Header: rtp.Header{SequenceNumber: 7}})
+	q.processArrival(&rtp.Packet{Header: rtp.Header{SequenceNumber: 6}})
+
+	qu := q.quality(0)
+	require.InDelta(t, 1.0/7, qu.LossRate, 0.0001)
+	require.InDelta(t, 1.0/6, qu.ReorderRate, 0.0001)
+	require.Equal(t, uint64(1), qu.FramesIncomplete)
+	require.Equal(t, 3000*time.Second/90000, qu.FreezeDuration)
+	require.Equal(t, float64(0), qu.Score)
+}
+
+func TestReceiverQualityScore(t *testing.T) {
+	q := newReceiverQuality(&description.Media{
+		Type:    description.MediaTypeAudio,
+		Formats: []format.Format{&format.G711{}},
+	}, &format.G711{}, 8000)
+
+	for i := 0; i < 10; i++ {
+		pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i), Timestamp: uint32(i * 160)}}
+		q.processArrival(pkt)
+		q.processPacket(pkt)
+	}
+
+	require.Equal(t, ReceiverQuality{Score: 100}, q.quality(0))
+	require.Equal(t, ReceiverQuality{Score: 95}, q.quality(50*time.Millisecond))
+}
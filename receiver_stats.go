@@ -0,0 +1,97 @@
+package gortsplib
+
+import (
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtcpreceiver"
+)
+
+// ReceiverStats are statistics of a format received by a Client or a ServerSession.
+type ReceiverStats struct {
+	// media of the format.
+	Media *description.Media
+
+	// format.
+	Format format.Format
+
+	// items of RTCP source descriptions sent by the sender (CNAME, NAME, TOOL).
+	SourceDescription rtcpreceiver.SourceDescription
+
+	// number of UDP packets that have been discarded
+	// because they arrived too late to be reordered, or were duplicated.
+	PacketsLate uint64
+
+	// number of missing UDP packets that have been skipped
+	// because the reordering buffer was full or the reordering window expired.
+	PacketsDropped uint64
+
+	// quality estimate, that allows to grade the link.
+	Quality ReceiverQuality
+
+	rtcpreceiver.Stats
+}
+
+// Stats returns statistics of the formats that are being read.
+// Medias are returned in no particular order.
+func (c *Client) Stats() []ReceiverStats {
+	var ret []ReceiverStats
+
+	for _, cm := range c.medias {
+		for _, forma := range cm.media.Formats {
+			ct, ok := cm.formats[forma.PayloadType()]
+			if !ok || ct.rtcpReceiver == nil {
+				continue
+			}
+
+			st := ReceiverStats{
+				Media:             cm.media,
+				Format:            forma,
+				SourceDescription: ct.rtcpReceiver.SourceDescription(),
+				Stats:             ct.rtcpReceiver.Stats(),
+			}
+
+			st.Quality = ct.quality.quality(st.Jitter)
+
+			if ct.udpReorderer != nil {
+				st.PacketsLate = ct.udpReorderer.Late()
+				st.PacketsDropped = ct.udpReorderer.Dropped()
+			}
+
+			ret = append(ret, st)
+		}
+	}
+
+	return ret
+}
+
+// Stats returns statistics of the formats that are being published.
+func (ss *ServerSession) Stats() []ReceiverStats {
+	var ret []ReceiverStats
+
+	for _, sm := range ss.setuppedMediasOrdered {
+		for _, forma := range sm.media.Formats {
+			sf, ok := sm.formats[forma.PayloadType()]
+			if !ok || sf.rtcpReceiver == nil {
+				continue
+			}
+
+			st := ReceiverStats{
+				Media:             sm.media,
+				Format:            forma,
+				SourceDescription: sf.rtcpReceiver.SourceDescription(),
+				Stats:             sf.rtcpReceiver.Stats(),
+			}
+
+			st.Quality = sf.quality.quality(st.Jitter)
+
+			if sf.udpReorderer != nil {
+				st.PacketsLate = sf.udpReorderer.Late()
+				st.PacketsDropped = sf.udpReorderer.Dropped()
+			}
+
+			ret = append(ret, st)
+		}
+	}
+
+	return ret
+}
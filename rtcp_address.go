@@ -0,0 +1,28 @@
+package gortsplib
+
+import (
+	"net"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+)
+
+// multicastRTCPAddress returns IP and port of the multicast group used by RTCP.
+// By default, RTCP uses the RTP group, with the port provided by the Transport header.
+// The rtcp attribute of the media (RFC3605) can be used to override them.
+func multicastRTCPAddress(medi *description.Media, defaultIP net.IP, defaultPort int) (net.IP, int) {
+	if medi.RTCPPort == 0 {
+		return defaultIP, defaultPort
+	}
+
+	if medi.RTCPAddress == "" {
+		return defaultIP, medi.RTCPPort
+	}
+
+	// ignore placeholder and unicast addresses
+	ip := net.ParseIP(medi.RTCPAddress)
+	if ip == nil || !ip.IsMulticast() {
+		return defaultIP, defaultPort
+	}
+
+	return ip, medi.RTCPPort
+}
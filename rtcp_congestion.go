@@ -0,0 +1,66 @@
+package gortsplib
+
+import (
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtcpcongestion"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpextension"
+)
+
+// generates REMB packets and, when the transport-wide sequence number
+// extension is available, TWCC packets.
+type congestionFeedbackGenerator struct {
+	remb *rtcpcongestion.REMBGenerator
+	twcc *rtcpcongestion.TWCCGenerator
+}
+
+func newCongestionFeedbackGenerator(medi *description.Media) *congestionFeedbackGenerator {
+	g := &congestionFeedbackGenerator{
+		remb: &rtcpcongestion.REMBGenerator{},
+	}
+	err := g.remb.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	if id, ok := rtpextension.ID(medi, rtpextension.TransportCCURI); ok {
+		g.twcc = &rtcpcongestion.TWCCGenerator{
+			ExtensionID: id,
+			SenderSSRC:  g.remb.SenderSSRC,
+		}
+		err = g.twcc.Init()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return g
+}
+
+func (g *congestionFeedbackGenerator) processPacket(pkt *rtp.Packet, size int, now time.Time) []rtcp.Packet {
+	var ret []rtcp.Packet
+
+	if remb := g.remb.ProcessPacket(pkt, size, now); remb != nil {
+		ret = append(ret, remb)
+	}
+
+	if g.twcc != nil {
+		if twcc := g.twcc.ProcessPacket(pkt, now); twcc != nil {
+			ret = append(ret, twcc)
+		}
+	}
+
+	return ret
+}
+
+// bandwidthEstimate extracts a bandwidth estimate, in bits per second, from a RTCP packet.
+func bandwidthEstimate(pkt rtcp.Packet) (uint64, bool) {
+	if remb, ok := pkt.(*rtcp.ReceiverEstimatedMaximumBitrate); ok {
+		return uint64(remb.Bitrate), true
+	}
+	return 0, false
+}
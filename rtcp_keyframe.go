@@ -0,0 +1,21 @@
+package gortsplib
+
+import (
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+// minimum interval between two keyframe requests of the same media
+// that are passed to the callback of a ServerStream.
+const keyframeRequestMinInterval = 1 * time.Second
+
+// isKeyframeRequest checks whether a RTCP packet is a
+// Picture Loss Indication (PLI) or a Full Intra Request (FIR).
+func isKeyframeRequest(pkt rtcp.Packet) bool {
+	switch pkt.(type) {
+	case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+		return true
+	}
+	return false
+}
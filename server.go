@@ -7,10 +7,16 @@ import (
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/logger"
+	"github.com/bluenviron/gortsplib/v4/pkg/metrics"
 )
 
 func extractPort(address string) (int, error) {
@@ -55,10 +61,28 @@ type Server struct {
 	RTSPAddress string
 	// a port to send and receive RTP packets with the UDP transport.
 	// If UDPRTPAddress and UDPRTCPAddress are filled, the server can support the UDP transport.
+	// An address without host (i.e. ":8000") or with the IPv6 unspecified host (i.e. "[::]:8000")
+	// accepts both IPv4 and IPv6 clients, while other addresses accept only clients of their family;
+	// clients of the other family are asked to use a different transport.
 	UDPRTPAddress string
 	// a port to send and receive RTCP packets with the UDP transport.
 	// If UDPRTPAddress and UDPRTCPAddress are filled, the server can support the UDP transport.
 	UDPRTCPAddress string
+	// number of UDP packets that are read or written with a single system call
+	// (recvmmsg / sendmmsg). Large batches reduce the CPU usage of servers
+	// that receive or send many streams with the UDP transport.
+	// RTP packets that are queued for the same reader are written together.
+	// This is supported on Linux only, on other platforms packets are read
+	// and written one at a time.
+	// It defaults to 1.
+	UDPBatchSize int
+	// write RTP packets that are queued for the same reader, like the packets
+	// of a frame or retransmissions, with UDP generic segmentation offload (UDP_SEGMENT),
+	// handing the kernel a single coalesced buffer. This is supported on Linux only
+	// and is disabled automatically when the network interface doesn't support it.
+	// Whether offload is active is returned by UDPSegmentationOffloadActive().
+	// It defaults to false.
+	UDPWriteBatching bool
 	// a range of multicast IPs to use with the UDP-multicast transport.
 	// If MulticastIPRange, MulticastRTPPort, MulticastRTCPPort are filled, the server
 	// can support the UDP-multicast transport.
@@ -77,8 +101,45 @@ type Server struct {
 	// timeout of write operations.
 	// It defaults to 10 seconds
 	WriteTimeout time.Duration
+	// timeout of sessions that are playing with the UDP or UDP-multicast transport
+	// and are not sending RTSP keepalives or RTCP packets.
+	// It is advertised to clients through the Session header.
+	// It can be changed for each session with ServerSession.SetTimeout().
+	// It defaults to 60 seconds.
+	SessionTimeout time.Duration
+	// criteria that keep sessions with the UDP or UDP-multicast transport alive (optional).
+	// It defaults to a policy that keeps playing sessions alive when RTSP keepalives or RTCP packets
+	// are received within the session timeout, and recording sessions alive when RTP or RTCP packets
+	// are received within ReadTimeout.
+	// Sessions with the TCP transport are alive as long as their connection is.
+	Liveness *ServerLivenessPolicy
 	// a TLS configuration to accept TLS (RTSPS) connections.
+	// SNI-based certificate selection and ALPN can be configured through its
+	// GetCertificate and NextProtos fields.
 	TLSConfig *tls.Config
+	// function called during the TLS handshake of every connection.
+	// It can be used to verify client certificates, that must be requested
+	// by setting TLSConfig.ClientAuth. If it returns an error, the handshake fails
+	// and the connection is closed.
+	// It requires TLSConfig.
+	VerifyClientCertificate func(cs *tls.ConnectionState, remoteAddr net.Addr) error
+	// authentication methods accepted by ServerHandlerOnAuthenticateCtx.Validate.
+	// It defaults to Basic and Digest.
+	AuthMethods []headers.AuthMethod
+	// realm advertised in the WWW-Authenticate header.
+	// It defaults to "IPCAM".
+	AuthRealm string
+	// algorithms of the Digest authentication method, in order of preference.
+	// It defaults to MD5.
+	AuthDigestAlgorithms []auth.DigestAlgorithm
+	// lifetime of the Digest nonce. When it expires, clients are asked
+	// to compute again their credentials.
+	// It defaults to zero (the nonce never expires).
+	AuthNonceLifetime time.Duration
+	// accept RTSP connections tunneled into HTTP (or HTTPS, when TLSConfig is set),
+	// in addition to standard ones.
+	// It defaults to false.
+	TunnelEnable bool
 	// Size of the queue of outgoing packets.
 	// It defaults to 256.
 	WriteQueueSize int
@@ -86,8 +147,102 @@ type Server struct {
 	// This must be less than the UDP MTU (1472 bytes).
 	// It defaults to 1472.
 	MaxPacketSize int
+	// number of RTP packets of each format kept by ServerStreams in order to
+	// answer NACKs with RTX packets. Retransmission is performed when the stream
+	// description contains a RTX format associated with the format.
+	// It defaults to 512.
+	RTXHistorySize int
+	// number of RTP packets protected by each FEC packet generated by ServerStreams.
+	// FEC packets are generated when the stream description contains a FlexFEC format.
+	// It defaults to 10.
+	FECGroupSize int
+	// number of previous payloads carried by each RED packet generated by ServerStreams.
+	// RED packets are generated when the stream description contains a RED format,
+	// and replace packets of its primary format.
+	// It defaults to 1.
+	REDDepth int
 	// disable automatic RTCP sender reports.
 	DisableRTCPSenderReports bool
+	// when receiving streams, send RTCP congestion control feedback to clients:
+	// REMB packets and, when the client provides the transport-wide
+	// sequence number extension, TWCC packets.
+	// It defaults to false.
+	RTCPCongestionFeedbackEnable bool
+	// when receiving streams, send RTCP extended reports (RFC 3611) to clients, together with receiver reports.
+	// They allow to compute the round-trip time, available with ServerSession.Stats().
+	// It defaults to false.
+	RTCPExtendedReportsEnable bool
+	// reuse buffers of incoming packets, in order to reduce allocations and GC pressure.
+	// When enabled, packets passed to ServerSession.OnPacketRTP and ServerSession.OnPacketRTCP callbacks,
+	// together with their payloads, are valid only until the callback returns;
+	// they must be cloned (i.e. with rtp.Packet.Clone()) in order to be stored
+	// or passed to other routines. This includes decoders returned by
+	// format.CreateDecoder(), that keep references to payloads between calls.
+	// It defaults to false.
+	PacketBufferReuseEnable bool
+	// when a session starts playing with the UDP or TCP transport, withhold
+	// video packets until a keyframe is received, for at most this amount of time.
+	// It defaults to zero (packets are not withheld).
+	KeyframeTimeout time.Duration
+	// maximum bitrate of RTP packets sent to each session with the UDP or TCP transport, in bits per second.
+	// When the limit is reached, non-reference frames are discarded first.
+	// Video frames are always discarded entirely, from the first packet to the one with the marker bit.
+	// It defaults to zero (no limit).
+	MaxSessionBitrate int
+	// maximum number of sessions.
+	// When the limit is reached, requests that create sessions are answered with 503 Service Unavailable.
+	// It defaults to zero (no limit).
+	MaxSessions int
+	// maximum number of sessions created by clients with the same IP.
+	// When the limit is reached, requests that create sessions are answered with 503 Service Unavailable.
+	// It defaults to zero (no limit).
+	MaxSessionsPerIP int
+	// maximum number of sessions created by clients with the same IP that are not playing or recording yet.
+	// It protects against clients that send SETUP requests without ever starting the session.
+	// When the limit is reached, requests that create sessions are answered with 429 Too Many Requests.
+	// It defaults to zero (no limit).
+	MaxInitialSessionsPerIP int
+	// maximum number of requests per second that can be sent through a connection.
+	// Short bursts of up to this number of requests are allowed.
+	// When the limit is reached, requests are answered with 429 Too Many Requests,
+	// or the connection is closed when ServerHandlerOnFlood says so.
+	// It defaults to zero (no limit).
+	MaxRequestsPerSecond int
+	// maximum size of the header of requests, in bytes.
+	// When the limit is reached, requests are answered with 400 Bad Request,
+	// or the connection is closed when ServerHandlerOnFlood says so.
+	// It defaults to zero (no limit other than the ones of the parser).
+	MaxHeaderSize int
+	// maximum number of header entries of requests.
+	// When the limit is reached, requests are answered with 400 Bad Request,
+	// or the connection is closed when ServerHandlerOnFlood says so.
+	// It defaults to zero (no limit other than the ones of the parser).
+	MaxHeaderCount int
+	// decode requests with a lenient parser, that accepts LF-only line endings,
+	// missing spaces after colons, folded headers and irregular whitespace,
+	// in order to support old encoders.
+	// It defaults to false (requests must comply with the specification).
+	LenientParsing bool
+	// validate requests and responses exchanged with clients against
+	// RFC 2326 and RFC 7826 (required headers, Session presence, CSeq monotonicity,
+	// status code legality), in order to certify devices and debug interoperability issues.
+	// Violations are reported through ServerHandlerOnConformanceViolation
+	// and don't interrupt the connection.
+	// It defaults to false.
+	ConformanceCheck bool
+	// maximum number of readers of each ServerStream.
+	// When the limit is reached, SETUP requests are answered with 453 Not Enough Bandwidth.
+	// It defaults to zero (no limit).
+	MaxReadersPerStream int
+	// maximum bitrate of RTP packets sent to readers of all streams, in bits per second.
+	// It is estimated from the bitrate of each stream and from the number of its readers.
+	// When the limit is reached, SETUP and PLAY requests are answered with 453 Not Enough Bandwidth.
+	// It defaults to zero (no limit).
+	MaxBitrate uint64
+	// limits on the data received from publishing sessions.
+	// They can be overridden for each session with ServerSession.SetRecordLimits().
+	// It defaults to nil (no limits).
+	RecordLimits *ServerRecordLimits
 
 	//
 	// handler (optional)
@@ -95,16 +250,53 @@ type Server struct {
 	// an handler to handle server events.
 	// It may implement one or more of the ServerHandler* interfaces.
 	Handler ServerHandler
+	// a router that dispatches DESCRIBE, ANNOUNCE and SETUP requests
+	// to route handlers by path and host (optional).
+	// Requests that don't match any route are passed to Handler,
+	// or refused with 404 if Handler can't handle them.
+	Router *ServerRouter
+	// a collector of metrics, like sessions, packets, bytes, losses and jitter (optional).
+	// It is fed by sessions and by streams attached to the server.
+	// It defaults to metrics.Discard.
+	Metrics metrics.Collector
+	// a logger of connections, requests, responses, sessions, transport negotiation and RTCP reports (optional).
+	// Warnings of sessions that are not handled by the Handler are written into it too.
+	// It defaults to logger.Standard, that writes warnings and errors with the standard log package.
+	Logger logger.Logger
+	// a listener of lifecycle events, like requests, responses, bytes,
+	// sessions and transports (optional).
+	// It may implement one or more of the EventsListener* interfaces.
+	EventsListener EventsListener
+	// middlewares that wrap the handling of requests, in order to observe and
+	// rewrite requests and responses (header injection, fix-ups for non-compliant clients,
+	// rate limiting, logging). The first middleware is the outermost one.
+	Middlewares []ServerMiddleware
+	// an admission policy, that is consulted on the first SETUP and PLAY
+	// requests of each session, after MaxReadersPerStream and MaxBitrate (optional).
+	Admission ServerAdmission
 
 	//
 	// system functions (all optional)
 	//
 	// function used to initialize the TCP listener.
+	// It can return any net.Listener, allowing to accept RTSP over custom connections
+	// (QUIC streams, WebSockets, serial lines, in-memory pipes).
+	// When connections are not IP-based, only the TCP transport is accepted.
 	// It defaults to net.Listen.
 	Listen func(network string, address string) (net.Listener, error)
 	// function used to initialize UDP listeners.
+	// It can return any net.PacketConn that implements SetReadBuffer(int) error
+	// and that uses *net.UDPAddr addresses, like the in-memory ones of the rtsptest package.
 	// It defaults to net.ListenPacket.
 	ListenPacket func(network, address string) (net.PacketConn, error)
+	// options of the TCP listener and of TCP connections (optional).
+	TCPSocketOptions *SocketOptions
+	// options of UDP and UDP-multicast listeners (optional).
+	UDPSocketOptions *SocketOptions
+	// clock used to obtain the current time and to schedule RTCP reports and timeouts.
+	// It can be replaced with a clock.Virtual in order to run tests and simulations deterministically.
+	// It defaults to the system clock.
+	Clock clock.Clock
 
 	//
 	// private
@@ -113,28 +305,42 @@ type Server struct {
 	timeNow              func() time.Time
 	senderReportPeriod   time.Duration
 	receiverReportPeriod time.Duration
-	sessionTimeout       time.Duration
 	checkStreamPeriod    time.Duration
 
-	ctx             context.Context
-	ctxCancel       func()
-	wg              sync.WaitGroup
-	multicastNet    *net.IPNet
-	multicastNextIP net.IP
-	tcpListener     *serverTCPListener
-	udpRTPListener  *serverUDPListener
-	udpRTCPListener *serverUDPListener
-	sessions        map[string]*ServerSession
-	conns           map[*ServerConn]struct{}
-	closeError      error
+	events           *eventsEmitter
+	metrics          *roleMetrics
+	requestHandler   ServerRequestHandler
+	ctx              context.Context
+	ctxCancel        func()
+	wg               sync.WaitGroup
+	multicastNet     *net.IPNet
+	multicastNextIP  net.IP
+	tcpListener      *serverTCPListener
+	udpRTPListener   *serverUDPListener
+	udpRTCPListener  *serverUDPListener
+	sessions         map[string]*ServerSession
+	sessionCount     *int64
+	streamsMutex     sync.Mutex
+	streams          map[*ServerStream]struct{}
+	moveReadersMutex sync.Mutex
+	conns            map[*ServerConn]struct{}
+	tunnels          map[string]*ServerConn
+	closeError       error
+	draining         bool
+	drainDone        bool
+	drained          chan struct{}
 
 	// in
 	chNewConn        chan net.Conn
 	chAcceptErr      chan error
 	chCloseConn      chan *ServerConn
+	chRegisterTunnel chan serverRegisterTunnelReq
+	chAttachTunnel   chan serverAttachTunnelReq
 	chHandleRequest  chan sessionRequestReq
+	chRestoreSession chan serverRestoreSessionReq
 	chCloseSession   chan *ServerSession
 	chGetMulticastIP chan chGetMulticastIPReq
+	chShutdown       chan struct{}
 }
 
 // Start starts the server.
@@ -151,11 +357,43 @@ func (s *Server) Start() error {
 	} else if (s.WriteQueueSize & (s.WriteQueueSize - 1)) != 0 {
 		return fmt.Errorf("WriteQueueSize must be a power of two")
 	}
+	if s.RecordLimits != nil {
+		err := s.RecordLimits.validate()
+		if err != nil {
+			return err
+		}
+	}
+	if s.AuthRealm == "" {
+		s.AuthRealm = "IPCAM"
+	}
 	if s.MaxPacketSize == 0 {
 		s.MaxPacketSize = udpMaxPayloadSize
 	} else if s.MaxPacketSize > udpMaxPayloadSize {
 		return fmt.Errorf("MaxPacketSize must be less than %d", udpMaxPayloadSize)
 	}
+	if s.RTXHistorySize == 0 {
+		s.RTXHistorySize = 512
+	}
+	if s.FECGroupSize == 0 {
+		s.FECGroupSize = 10
+	}
+	if s.REDDepth == 0 {
+		s.REDDepth = 1
+	}
+	if s.UDPBatchSize == 0 {
+		s.UDPBatchSize = 1
+	} else if s.UDPBatchSize < 0 {
+		return fmt.Errorf("UDPBatchSize must be greater than zero")
+	}
+
+	err := s.TCPSocketOptions.validate()
+	if err != nil {
+		return err
+	}
+	err = s.UDPSocketOptions.validate()
+	if err != nil {
+		return err
+	}
 
 	// system functions
 	if s.Listen == nil {
@@ -165,18 +403,33 @@ func (s *Server) Start() error {
 		s.ListenPacket = net.ListenPacket
 	}
 
+	if s.Clock == nil {
+		s.Clock = clock.System{}
+	}
+
 	// private
 	if s.timeNow == nil {
-		s.timeNow = time.Now
+		s.timeNow = s.Clock.Now
+	} else {
+		s.Clock = clock.WithNow(s.Clock, s.timeNow)
+	}
+	if s.Logger == nil {
+		s.Logger = logger.Standard
 	}
+	s.events = newEventsEmitter(s.EventsListener)
+	if s.Metrics == nil {
+		s.Metrics = metrics.Discard
+	}
+	s.metrics = newRoleMetrics(s.Metrics, "server")
+	s.requestHandler = chainServerMiddlewares(s.Middlewares, (*ServerConn).handleRequestBase)
 	if s.senderReportPeriod == 0 {
 		s.senderReportPeriod = 10 * time.Second
 	}
 	if s.receiverReportPeriod == 0 {
 		s.receiverReportPeriod = 10 * time.Second
 	}
-	if s.sessionTimeout == 0 {
-		s.sessionTimeout = 1 * 60 * time.Second
+	if s.SessionTimeout == 0 {
+		s.SessionTimeout = 1 * 60 * time.Second
 	}
 	if s.checkStreamPeriod == 0 {
 		s.checkStreamPeriod = 1 * time.Second
@@ -190,6 +443,10 @@ func (s *Server) Start() error {
 		return fmt.Errorf("TLS can't be used with UDP-multicast")
 	}
 
+	if s.TLSConfig == nil && s.VerifyClientCertificate != nil {
+		return fmt.Errorf("VerifyClientCertificate requires TLSConfig")
+	}
+
 	if s.RTSPAddress == "" {
 		return fmt.Errorf("RTSPAddress not provided")
 	}
@@ -221,23 +478,42 @@ func (s *Server) Start() error {
 		s.udpRTPListener, err = newServerUDPListener(
 			s.ListenPacket,
 			s.WriteTimeout,
+			s.PacketBufferReuseEnable,
+			s.UDPBatchSize,
 			false,
+			0,
+			nil,
 			s.UDPRTPAddress,
 		)
 		if err != nil {
 			return err
 		}
 
+		if s.UDPWriteBatching {
+			s.udpRTPListener.batchConn.enableSegmentationOffload()
+		}
+
 		s.udpRTCPListener, err = newServerUDPListener(
 			s.ListenPacket,
 			s.WriteTimeout,
+			s.PacketBufferReuseEnable,
+			s.UDPBatchSize,
 			false,
+			0,
+			nil,
 			s.UDPRTCPAddress,
 		)
 		if err != nil {
 			s.udpRTPListener.close()
 			return err
 		}
+
+		err = s.applyUDPSocketOptions(s.udpRTPListener, s.udpRTCPListener)
+		if err != nil {
+			s.udpRTPListener.close()
+			s.udpRTCPListener.close()
+			return err
+		}
 	}
 
 	if s.MulticastIPRange != "" && (s.MulticastRTPPort == 0 || s.MulticastRTCPPort == 0) ||
@@ -291,15 +567,21 @@ func (s *Server) Start() error {
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 
 	s.sessions = make(map[string]*ServerSession)
+	s.sessionCount = new(int64)
 	s.conns = make(map[*ServerConn]struct{})
 	s.chNewConn = make(chan net.Conn)
 	s.chAcceptErr = make(chan error)
 	s.chCloseConn = make(chan *ServerConn)
+	s.tunnels = make(map[string]*ServerConn)
+	s.chRegisterTunnel = make(chan serverRegisterTunnelReq)
+	s.chAttachTunnel = make(chan serverAttachTunnelReq)
 	s.chHandleRequest = make(chan sessionRequestReq)
+	s.chRestoreSession = make(chan serverRestoreSessionReq)
 	s.chCloseSession = make(chan *ServerSession)
 	s.chGetMulticastIP = make(chan chGetMulticastIPReq)
+	s.chShutdown = make(chan struct{})
+	s.drained = make(chan struct{})
 
-	var err error
 	s.tcpListener, err = newServerTCPListener(s)
 	if err != nil {
 		if s.udpRTPListener != nil {
@@ -318,12 +600,59 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// UDPSegmentationOffloadActive returns whether UDP generic segmentation offload
+// is used to write packets. It requires UDPWriteBatching.
+func (s *Server) UDPSegmentationOffloadActive() bool {
+	return s.udpRTPListener != nil && s.udpRTPListener.batchConn.segmentationOffloadActive()
+}
+
+// udpWriteBatchSize returns the maximum number of queued RTP packets
+// that are written together to a reader. 1 means that batching is disabled.
+func (s *Server) udpWriteBatchSize() int {
+	if s.UDPBatchSize > 1 {
+		return s.UDPBatchSize
+	}
+	if s.UDPWriteBatching {
+		return udpDefaultWriteBatchSize
+	}
+	return 1
+}
+
 // Close closes all the server resources and waits for them to close.
 func (s *Server) Close() {
 	s.ctxCancel()
 	s.wg.Wait()
 }
 
+// Shutdown closes the server gracefully.
+// It stops accepting new connections and sessions, sends a RTCP BYE packet to
+// sessions that are reading, followed by an ANNOUNCE request with an end-of-stream notice
+// once their write queue has been flushed, closes sessions that are neither reading
+// nor publishing, then waits for the remaining sessions to be closed by their clients.
+// When ctx is done, the remaining sessions are closed forcibly.
+// Sessions closed because of the shutdown are reported with ErrServerShuttingDown.
+func (s *Server) Shutdown(ctx context.Context) error {
+	select {
+	case s.chShutdown <- struct{}{}:
+	case <-s.ctx.Done():
+		s.wg.Wait()
+		return nil
+	}
+
+	var err error
+
+	select {
+	case <-s.drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-s.ctx.Done():
+	}
+
+	s.Close()
+
+	return err
+}
+
 // Wait waits until all server resources are closed.
 // This can happen when a fatal error occurs or when Close() is called.
 func (s *Server) Wait() error {
@@ -353,6 +682,10 @@ func (s *Server) runInner() error {
 	for {
 		select {
 		case err := <-s.chAcceptErr:
+			// listener has been closed on purpose
+			if s.draining {
+				continue
+			}
 			return err
 
 		case nconn := <-s.chNewConn:
@@ -360,12 +693,37 @@ func (s *Server) runInner() error {
 			s.conns[sc] = struct{}{}
 
 		case sc := <-s.chCloseConn:
+			if sc.tunnelCookie != "" && s.tunnels[sc.tunnelCookie] == sc {
+				delete(s.tunnels, sc.tunnelCookie)
+			}
+
 			if _, ok := s.conns[sc]; !ok {
 				continue
 			}
 			delete(s.conns, sc)
 			sc.Close()
 
+		case req := <-s.chRegisterTunnel:
+			if _, ok := s.tunnels[req.cookie]; ok {
+				req.res <- liberrors.ErrServerTunnelSessionCookieInUse{}
+				continue
+			}
+
+			req.sc.tunnelCookie = req.cookie
+			s.tunnels[req.cookie] = req.sc
+			req.res <- nil
+
+		case req := <-s.chAttachTunnel:
+			sc, ok := s.tunnels[req.cookie]
+			if !ok {
+				req.res <- false
+				continue
+			}
+
+			delete(s.tunnels, req.cookie)
+			sc.chTunnelPost <- req.post
+			req.res <- true
+
 		case req := <-s.chHandleRequest:
 			if ss, ok := s.sessions[req.id]; ok {
 				if !req.sc.ip().Equal(ss.author.ip()) ||
@@ -400,8 +758,28 @@ func (s *Server) runInner() error {
 					continue
 				}
 
+				if s.draining {
+					req.res <- sessionRequestRes{
+						res: &base.Response{
+							StatusCode: base.StatusServiceUnavailable,
+						},
+						err: liberrors.ErrServerShuttingDown{},
+					}
+					continue
+				}
+
+				res, err := s.checkSessionLimits(req.sc)
+				if res != nil {
+					req.res <- sessionRequestRes{
+						res: res,
+						err: err,
+					}
+					continue
+				}
+
 				ss := newServerSession(s, req.sc)
 				s.sessions[ss.secretID] = ss
+				atomic.StoreInt64(s.sessionCount, int64(len(s.sessions)))
 
 				select {
 				case ss.chHandleRequest <- req:
@@ -415,12 +793,18 @@ func (s *Server) runInner() error {
 				}
 			}
 
+		case req := <-s.chRestoreSession:
+			ss, err := s.restoreSession(req)
+			req.res <- serverRestoreSessionRes{ss: ss, err: err}
+
 		case ss := <-s.chCloseSession:
 			if sss, ok := s.sessions[ss.secretID]; !ok || sss != ss {
 				continue
 			}
 			delete(s.sessions, ss.secretID)
+			atomic.StoreInt64(s.sessionCount, int64(len(s.sessions)))
 			ss.Close()
+			s.checkDrained()
 
 		case req := <-s.chGetMulticastIP:
 			ip32 := uint32(s.multicastNextIP[0])<<24 | uint32(s.multicastNextIP[1])<<16 |
@@ -436,12 +820,33 @@ func (s *Server) runInner() error {
 			s.multicastNextIP = ip
 			req.res <- ip
 
+		case <-s.chShutdown:
+			if s.draining {
+				continue
+			}
+			s.draining = true
+
+			s.tcpListener.close()
+
+			for _, ss := range s.sessions {
+				ss.drain()
+			}
+
+			s.checkDrained()
+
 		case <-s.ctx.Done():
 			return liberrors.ErrServerTerminated{}
 		}
 	}
 }
 
+func (s *Server) checkDrained() {
+	if s.draining && !s.drainDone && len(s.sessions) == 0 {
+		s.drainDone = true
+		close(s.drained)
+	}
+}
+
 // StartAndWait starts the server and waits until a fatal error.
 func (s *Server) StartAndWait() error {
 	err := s.Start()
@@ -485,6 +890,36 @@ func (s *Server) closeConn(sc *ServerConn) {
 	}
 }
 
+func (s *Server) registerTunnel(cookie string, sc *ServerConn) error {
+	req := serverRegisterTunnelReq{
+		cookie: cookie,
+		sc:     sc,
+		res:    make(chan error),
+	}
+
+	select {
+	case s.chRegisterTunnel <- req:
+		return <-req.res
+	case <-s.ctx.Done():
+		return liberrors.ErrServerTerminated{}
+	}
+}
+
+func (s *Server) attachTunnel(cookie string, post *serverTunnelPost) bool {
+	req := serverAttachTunnelReq{
+		cookie: cookie,
+		post:   post,
+		res:    make(chan bool),
+	}
+
+	select {
+	case s.chAttachTunnel <- req:
+		return <-req.res
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
 func (s *Server) closeSession(ss *ServerSession) {
 	select {
 	case s.chCloseSession <- ss:
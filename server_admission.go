@@ -0,0 +1,145 @@
+package gortsplib
+
+import (
+	"sync/atomic"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// ServerAdmissionCtx is the context of an admission decision.
+type ServerAdmissionCtx struct {
+	Session *ServerSession
+	Conn    *ServerConn
+	Request *base.Request
+	// stream that is going to be read. It is nil when the session is publishing.
+	Stream *ServerStream
+	// number of sessions of the server, including the current one.
+	Sessions int
+	// number of readers of the stream, including the current session.
+	Readers int
+	// bitrate of RTP packets currently sent by the server to readers, in bits per second.
+	OutboundBitrate uint64
+	// bitrate that is going to be added to OutboundBitrate by the current session, in bits per second.
+	AdditionalBitrate uint64
+}
+
+// ServerAdmission decides whether sessions are allowed to start.
+type ServerAdmission interface {
+	// called on the first SETUP request of a session and on the first PLAY request.
+	// It must return base.StatusOK to accept the request, or another status code
+	// (i.e. base.StatusNotEnoughBandwidth or base.StatusServiceUnavailable) to reject it.
+	Admit(ctx *ServerAdmissionCtx) base.StatusCode
+}
+
+// registerStream adds a stream to the ones whose bitrate is part of the outbound bitrate.
+func (s *Server) registerStream(st *ServerStream) {
+	s.streamsMutex.Lock()
+	defer s.streamsMutex.Unlock()
+
+	if s.streams == nil {
+		s.streams = make(map[*ServerStream]struct{})
+	}
+	s.streams[st] = struct{}{}
+}
+
+func (s *Server) unregisterStream(st *ServerStream) {
+	s.streamsMutex.Lock()
+	defer s.streamsMutex.Unlock()
+
+	delete(s.streams, st)
+}
+
+// OutboundBitrate returns the bitrate of RTP packets sent to readers
+// of all streams of the server, in bits per second.
+func (s *Server) OutboundBitrate() uint64 {
+	s.streamsMutex.Lock()
+	defer s.streamsMutex.Unlock()
+
+	var ret uint64
+	for st := range s.streams {
+		ret += st.outboundBitrate()
+	}
+	return ret
+}
+
+// checkSessionLimits is called by the main loop before creating a session.
+func (s *Server) checkSessionLimits(sc *ServerConn) (*base.Response, error) {
+	if s.MaxSessions != 0 && len(s.sessions) >= s.MaxSessions {
+		return &base.Response{
+			StatusCode: base.StatusServiceUnavailable,
+		}, liberrors.ErrServerSessionsLimitReached{Max: s.MaxSessions}
+	}
+
+	if s.MaxSessionsPerIP != 0 {
+		count := 0
+		for _, ss := range s.sessions {
+			if ss.author.ip().Equal(sc.ip()) && ss.author.zone() == sc.zone() {
+				count++
+			}
+		}
+
+		if count >= s.MaxSessionsPerIP {
+			return &base.Response{
+				StatusCode: base.StatusServiceUnavailable,
+			}, liberrors.ErrServerSessionsPerIPLimitReached{Max: s.MaxSessionsPerIP}
+		}
+	}
+
+	if s.MaxInitialSessionsPerIP != 0 {
+		count := 0
+		for _, ss := range s.sessions {
+			if atomic.LoadInt32(ss.started) == 0 &&
+				ss.author.ip().Equal(sc.ip()) && ss.author.zone() == sc.zone() {
+				count++
+			}
+		}
+
+		if count >= s.MaxInitialSessionsPerIP {
+			return &base.Response{
+				StatusCode: base.StatusTooManyRequests,
+			}, liberrors.ErrServerInitialSessionsPerIPLimitReached{Max: s.MaxInitialSessionsPerIP}
+		}
+	}
+
+	return nil, nil
+}
+
+// admit checks whether a session can start setting up or playing a stream.
+func (s *Server) admit(
+	sc *ServerConn,
+	ss *ServerSession,
+	req *base.Request,
+	stream *ServerStream,
+) (*base.Response, error) {
+	ctx := &ServerAdmissionCtx{
+		Session:         ss,
+		Conn:            sc,
+		Request:         req,
+		Stream:          stream,
+		Sessions:        int(atomic.LoadInt64(s.sessionCount)),
+		OutboundBitrate: s.OutboundBitrate(),
+	}
+
+	if stream != nil {
+		ctx.Readers = stream.readerCount(ss)
+		ctx.AdditionalBitrate = stream.additionalBitrate(ss)
+
+		if s.MaxBitrate != 0 && ctx.AdditionalBitrate != 0 &&
+			(ctx.OutboundBitrate+ctx.AdditionalBitrate) > s.MaxBitrate {
+			return &base.Response{
+				StatusCode: base.StatusNotEnoughBandwidth,
+			}, liberrors.ErrServerBitrateLimitReached{Max: s.MaxBitrate}
+		}
+	}
+
+	if s.Admission != nil {
+		if code := s.Admission.Admit(ctx); code != base.StatusOK {
+			return &base.Response{
+				StatusCode: code,
+			}, liberrors.ErrServerAdmissionRejected{StatusCode: code}
+		}
+	}
+
+	return nil, nil
+}
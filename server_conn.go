@@ -3,17 +3,24 @@ package gortsplib
 import (
 	"context"
 	"crypto/tls"
+	"io"
 	"net"
 	gourl "net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/bytecounter"
+	"github.com/bluenviron/gortsplib/v4/pkg/conformance"
 	"github.com/bluenviron/gortsplib/v4/pkg/conn"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/logger"
+	"github.com/bluenviron/gortsplib/v4/pkg/metrics"
+	"github.com/bluenviron/gortsplib/v4/pkg/parameters"
 )
 
 func getSessionID(header base.Header) string {
@@ -32,9 +39,13 @@ func serverSideDescription(d *description.Session, contentBase *base.URL) *descr
 
 	for i, medi := range d.Medias {
 		mc := &description.Media{
-			Type:          medi.Type,
-			ID:            medi.ID,
-			IsBackChannel: medi.IsBackChannel,
+			Type:             medi.Type,
+			ID:               medi.ID,
+			IsBackChannel:    medi.IsBackChannel,
+			RTCPPort:         medi.RTCPPort,
+			RTCPAddress:      medi.RTCPAddress,
+			Crypto:           medi.Crypto,
+			HeaderExtensions: medi.HeaderExtensions,
 			// we have to use trackID=number in order to support clients
 			// like the Grandstream GXV3500.
 			Control: "trackID=" + strconv.FormatInt(int64(i), 10),
@@ -54,6 +65,74 @@ func serverSideDescription(d *description.Session, contentBase *base.URL) *descr
 	return out
 }
 
+// serverConnTLSConfig returns the TLS configuration of a connection,
+// that calls Server.VerifyClientCertificate at the end of the handshake.
+// bodies of GET_PARAMETER and SET_PARAMETER requests are decoded
+// when they are declared as parameters, or when they are not declared at all.
+func hasParametersBody(req *base.Request) bool {
+	if len(req.Body) == 0 {
+		return false
+	}
+
+	ct, ok := req.Header["Content-Type"]
+	return !ok || (len(ct) == 1 && strings.HasPrefix(ct[0], parameters.ContentType))
+}
+
+func requestParameterNames(req *base.Request) ([]string, error) {
+	if !hasParametersBody(req) {
+		return nil, nil
+	}
+
+	var names parameters.Names
+	err := names.Unmarshal(req.Body)
+	if err != nil {
+		return nil, liberrors.ErrServerParametersInvalid{Err: err}
+	}
+
+	return names, nil
+}
+
+func requestParameters(req *base.Request) (map[string]string, error) {
+	if !hasParametersBody(req) {
+		return nil, nil
+	}
+
+	var params parameters.Parameters
+	err := params.Unmarshal(req.Body)
+	if err != nil {
+		return nil, liberrors.ErrServerParametersInvalid{Err: err}
+	}
+
+	return params, nil
+}
+
+func serverConnTLSConfig(s *Server, remoteAddr net.Addr) *tls.Config {
+	if s.VerifyClientCertificate == nil {
+		return s.TLSConfig
+	}
+
+	conf := s.TLSConfig.Clone()
+	verifyConnection := conf.VerifyConnection
+
+	conf.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verifyConnection != nil {
+			err := verifyConnection(cs)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := s.VerifyClientCertificate(&cs, remoteAddr)
+		if err != nil {
+			return liberrors.ErrServerClientCertificateRejected{Err: err}
+		}
+
+		return nil
+	}
+
+	return conf
+}
+
 type readReq struct {
 	req *base.Request
 	res chan error
@@ -67,13 +146,31 @@ type ServerConn struct {
 	ctx        context.Context
 	ctxCancel  func()
 	userData   interface{}
-	remoteAddr *net.TCPAddr
+	remoteIP   net.IP
+	remoteZone string
 	bc         *bytecounter.ByteCounter
 	conn       *conn.Conn
 	session    *ServerSession
+	tlsConn    *tls.Conn
+	authNonce  string
+
+	authNonceTime time.Time
+
+	tunnelCookie string
+
+	requestLimiter  *requestRateLimiter
+	log             logger.Logger
+	cseq            int
+	pendingRequests map[string]serverConnPendingRequest
+
+	conformanceRequests *conformance.Checker // requests of the client
+	conformanceServer   *conformance.Checker // requests of the server
 
 	// in
 	chReadRequest   chan readReq
+	chReadResponse  chan readRes
+	chWriteRequest  chan writeReq
+	chTunnelPost    chan *serverTunnelPost
 	chReadError     chan error
 	chRemoveSession chan *ServerSession
 
@@ -84,11 +181,25 @@ type ServerConn struct {
 func newServerConn(
 	s *Server,
 	nconn net.Conn,
+) *ServerConn {
+	sc := allocServerConn(s, nconn)
+
+	s.wg.Add(1)
+	go sc.run()
+
+	return sc
+}
+
+func allocServerConn(
+	s *Server,
+	nconn net.Conn,
 ) *ServerConn {
 	ctx, ctxCancel := context.WithCancel(s.ctx)
 
+	var tlsConn *tls.Conn
 	if s.TLSConfig != nil {
-		nconn = tls.Server(nconn, s.TLSConfig)
+		tlsConn = tls.Server(nconn, serverConnTLSConfig(s, nconn.RemoteAddr()))
+		nconn = tlsConn
 	}
 
 	sc := &ServerConn{
@@ -97,15 +208,18 @@ func newServerConn(
 		bc:              bytecounter.New(nconn, nil, nil),
 		ctx:             ctx,
 		ctxCancel:       ctxCancel,
-		remoteAddr:      nconn.RemoteAddr().(*net.TCPAddr),
+		tlsConn:         tlsConn,
 		chReadRequest:   make(chan readReq),
+		chReadResponse:  make(chan readRes),
+		chWriteRequest:  make(chan writeReq),
 		chReadError:     make(chan error),
 		chRemoveSession: make(chan *ServerSession),
+		chTunnelPost:    make(chan *serverTunnelPost, 1),
 		done:            make(chan struct{}),
 	}
 
-	s.wg.Add(1)
-	go sc.run()
+	sc.remoteIP, sc.remoteZone = addrIPZone(nconn.RemoteAddr())
+	sc.log = logger.With(s.Logger, "conn", nconn.RemoteAddr())
 
 	return sc
 }
@@ -120,6 +234,16 @@ func (sc *ServerConn) NetConn() net.Conn {
 	return sc.nconn
 }
 
+// TLSConnectionState returns the state of the TLS connection,
+// or nil if the connection is not encrypted.
+func (sc *ServerConn) TLSConnectionState() *tls.ConnectionState {
+	if sc.tlsConn == nil {
+		return nil
+	}
+	cs := sc.tlsConn.ConnectionState()
+	return &cs
+}
+
 // BytesReceived returns the number of read bytes.
 func (sc *ServerConn) BytesReceived() uint64 {
 	return sc.bc.BytesReceived()
@@ -141,33 +265,78 @@ func (sc *ServerConn) UserData() interface{} {
 }
 
 func (sc *ServerConn) ip() net.IP {
-	return sc.remoteAddr.IP
+	return sc.remoteIP
 }
 
 func (sc *ServerConn) zone() string {
-	return sc.remoteAddr.Zone
+	return sc.remoteZone
 }
 
 func (sc *ServerConn) run() {
 	defer sc.s.wg.Done()
 	defer close(sc.done)
 
+	sc.log.Debug("connection opened")
+
+	var err error
+
+	// restored connections are already linked to a session
+	if sc.s.TunnelEnable && sc.session == nil {
+		err = sc.runTunnel()
+
+		// the connection has become part of another one
+		if _, ok := err.(errServerTunnelAttached); ok {
+			sc.s.closeConn(sc)
+			return
+		}
+
+		sc.bc = bytecounter.New(sc.nconn, nil, nil)
+	}
+
 	if h, ok := sc.s.Handler.(ServerHandlerOnConnOpen); ok {
 		h.OnConnOpen(&ServerHandlerOnConnOpenCtx{
 			Conn: sc,
 		})
 	}
 
-	sc.conn = conn.NewConn(sc.bc)
-	cr := newServerConnReader(sc)
+	if err == nil {
+		var rw io.ReadWriter = sc.bc
+		if sc.s.Metrics != metrics.Discard {
+			rw = &metricsConn{
+				rw: rw,
+				m:  sc.s.metrics,
+			}
+		}
+		if sc.s.events.onBytesReceived != nil || sc.s.events.onBytesSent != nil {
+			rw = &eventsConn{
+				rw:     rw,
+				events: sc.s.events,
+				src:    EventSource{Conn: sc},
+			}
+		}
 
-	err := sc.runInner()
+		sc.conn = conn.NewConn(rw)
+		sc.conn.SetReuseFramePayloads(sc.s.PacketBufferReuseEnable)
+		sc.conn.SetLenientParsing(sc.s.LenientParsing)
+		cr := newServerConnReader(sc)
 
-	sc.ctxCancel()
+		if sc.s.ConformanceCheck {
+			sc.conformanceRequests = &conformance.Checker{OnViolation: sc.onConformanceViolation}
+			sc.conformanceServer = &conformance.Checker{OnViolation: sc.onConformanceViolation}
+		}
 
-	sc.nconn.Close()
+		err = sc.runInner()
 
-	cr.wait()
+		sc.ctxCancel()
+
+		sc.nconn.Close()
+
+		cr.wait()
+	} else {
+		sc.ctxCancel()
+
+		sc.nconn.Close()
+	}
 
 	if sc.session != nil {
 		sc.session.removeConn(sc)
@@ -175,12 +344,21 @@ func (sc *ServerConn) run() {
 
 	sc.s.closeConn(sc)
 
+	// close the POST connection of a tunnel that has been attached too late
+	select {
+	case post := <-sc.chTunnelPost:
+		post.nconn.Close()
+	default:
+	}
+
 	if h, ok := sc.s.Handler.(ServerHandlerOnConnClose); ok {
 		h.OnConnClose(&ServerHandlerOnConnCloseCtx{
 			Conn:  sc,
 			Error: err,
 		})
 	}
+
+	sc.log.Debug("connection closed", "err", err)
 }
 
 func (sc *ServerConn) runInner() error {
@@ -189,6 +367,12 @@ func (sc *ServerConn) runInner() error {
 		case req := <-sc.chReadRequest:
 			req.res <- sc.handleRequestOuter(req.req)
 
+		case res := <-sc.chReadResponse:
+			res.err <- sc.handleResponse(res.res)
+
+		case req := <-sc.chWriteRequest:
+			req.res <- sc.writeRequest(req)
+
 		case err := <-sc.chReadError:
 			return err
 
@@ -216,6 +400,13 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 		}, liberrors.ErrServerInvalidPath{}
 	}
 
+	if h, ok := sc.s.Handler.(ServerHandlerOnAuthenticate); ok {
+		res, err := sc.authenticate(h, req)
+		if res != nil {
+			return res, err
+		}
+	}
+
 	sxID := getSessionID(req.Header)
 
 	var path string
@@ -239,13 +430,13 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 		}
 
 		var methods []string
-		if _, ok := sc.s.Handler.(ServerHandlerOnDescribe); ok {
+		if sc.s.handlesMethod(base.Describe) {
 			methods = append(methods, string(base.Describe))
 		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnAnnounce); ok {
+		if sc.s.handlesMethod(base.Announce) {
 			methods = append(methods, string(base.Announce))
 		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnSetup); ok {
+		if sc.s.handlesMethod(base.Setup) {
 			methods = append(methods, string(base.Setup))
 		}
 		if _, ok := sc.s.Handler.(ServerHandlerOnPlay); ok {
@@ -271,12 +462,13 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 		}, nil
 
 	case base.Describe:
-		if h, ok := sc.s.Handler.(ServerHandlerOnDescribe); ok {
-			res, stream, err := h.OnDescribe(&ServerHandlerOnDescribeCtx{
+		if h, params := sc.s.routeRequest(base.Describe, req.URL, path); h != nil {
+			res, stream, err := h.(ServerHandlerOnDescribe).OnDescribe(&ServerHandlerOnDescribeCtx{
 				Conn:    sc,
 				Request: req,
 				Path:    path,
 				Query:   query,
+				Params:  params,
 			})
 
 			if res.StatusCode == base.StatusOK {
@@ -300,7 +492,20 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 				}
 
 				if stream != nil {
-					byts, _ := serverSideDescription(stream.desc, req.URL).Marshal(multicast)
+					ssd := serverSideDescription(stream.desc, req.URL).MarshalSDP(multicast)
+
+					if h, ok := sc.s.Handler.(ServerHandlerOnDescribeSDP); ok {
+						h.OnDescribeSDP(&ServerHandlerOnDescribeSDPCtx{
+							Conn:    sc,
+							Request: req,
+							Path:    path,
+							Query:   query,
+							Stream:  stream,
+							SDP:     ssd,
+						})
+					}
+
+					byts, _ := ssd.Marshal()
 					res.Body = byts
 				}
 			}
@@ -308,13 +513,19 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 			return res, err
 		}
 
+		if sc.s.Router != nil {
+			return &base.Response{
+				StatusCode: base.StatusNotFound,
+			}, liberrors.ErrServerRouteNotFound{Path: path}
+		}
+
 	case base.Announce:
-		if _, ok := sc.s.Handler.(ServerHandlerOnAnnounce); ok {
+		if sc.s.handlesMethod(base.Announce) {
 			return sc.handleRequestInSession(sxID, req, true)
 		}
 
 	case base.Setup:
-		if _, ok := sc.s.Handler.(ServerHandlerOnSetup); ok {
+		if sc.s.handlesMethod(base.Setup) {
 			return sc.handleRequestInSession(sxID, req, true)
 		}
 
@@ -350,11 +561,19 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 		}
 
 		if h, ok := sc.s.Handler.(ServerHandlerOnGetParameter); ok {
+			names, err := requestParameterNames(req)
+			if err != nil {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, err
+			}
+
 			return h.OnGetParameter(&ServerHandlerOnGetParameterCtx{
-				Conn:    sc,
-				Request: req,
-				Path:    path,
-				Query:   query,
+				Conn:       sc,
+				Request:    req,
+				Path:       path,
+				Query:      query,
+				Parameters: names,
 			})
 		}
 
@@ -364,11 +583,19 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 		}
 
 		if h, ok := sc.s.Handler.(ServerHandlerOnSetParameter); ok {
+			params, err := requestParameters(req)
+			if err != nil {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, err
+			}
+
 			return h.OnSetParameter(&ServerHandlerOnSetParameterCtx{
-				Conn:    sc,
-				Request: req,
-				Path:    path,
-				Query:   query,
+				Conn:       sc,
+				Request:    req,
+				Path:       path,
+				Query:      query,
+				Parameters: params,
 			})
 		}
 	}
@@ -378,12 +605,126 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 	}, nil
 }
 
+func (sc *ServerConn) authenticate(h ServerHandlerOnAuthenticate, req *base.Request) (*base.Response, error) {
+	// the nonce is shared by all the requests of the connection,
+	// since clients compute the Digest response once.
+	if sc.authNonce == "" {
+		err := sc.renewAuthNonce()
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusInternalServerError,
+			}, err
+		}
+	}
+
+	expired := sc.s.AuthNonceLifetime != 0 &&
+		sc.s.timeNow().Sub(sc.authNonceTime) >= sc.s.AuthNonceLifetime
+
+	ctx := &ServerHandlerOnAuthenticateCtx{
+		Conn:    sc,
+		Request: req,
+		nonce:   sc.authNonce,
+	}
+
+	if req.URL != nil {
+		if pathAndQuery, ok := req.URL.RTSPPathAndQuery(); ok {
+			ctx.Path, ctx.Query = base.PathSplitQuery(pathAndQuery)
+		}
+	}
+
+	var authorization headers.Authorization
+	if authorization.Unmarshal(req.Header["Authorization"]) == nil {
+		ctx.Authorization = &authorization
+	}
+
+	err := h.OnAuthenticate(ctx)
+
+	if expired {
+		err2 := sc.renewAuthNonce()
+		if err2 != nil {
+			return &base.Response{
+				StatusCode: base.StatusInternalServerError,
+			}, err2
+		}
+	}
+
+	// do not return errors, in order to allow the client to retry.
+	switch {
+	case err != nil:
+		return sc.unauthorizedResponse(false), nil
+
+	// credentials are valid, but they have been computed with an expired nonce.
+	case expired && ctx.digestValidated:
+		return sc.unauthorizedResponse(true), nil
+	}
+
+	return nil, nil
+}
+
+func (sc *ServerConn) renewAuthNonce() error {
+	nonce, err := auth.GenerateNonce()
+	if err != nil {
+		return err
+	}
+
+	sc.authNonce = nonce
+	sc.authNonceTime = sc.s.timeNow()
+	return nil
+}
+
+func (sc *ServerConn) unauthorizedResponse(stale bool) *base.Response {
+	return &base.Response{
+		StatusCode: base.StatusUnauthorized,
+		Header: base.Header{
+			"WWW-Authenticate": auth.WWWAuthenticate{
+				Methods:          sc.s.AuthMethods,
+				DigestAlgorithms: sc.s.AuthDigestAlgorithms,
+				DigestQOP:        true,
+				Realm:            sc.s.AuthRealm,
+				Nonce:            sc.authNonce,
+				Stale:            stale,
+			}.Marshal(),
+		},
+	}
+}
+
+func (sc *ServerConn) onConformanceViolation(v *conformance.Violation) {
+	if h, ok := sc.s.Handler.(ServerHandlerOnConformanceViolation); ok {
+		h.OnConformanceViolation(&ServerHandlerOnConformanceViolationCtx{
+			Conn:      sc,
+			Violation: v,
+		})
+	} else {
+		sc.log.Warn("conformance violation", "err", v)
+	}
+}
+
 func (sc *ServerConn) handleRequestOuter(req *base.Request) error {
 	if h, ok := sc.s.Handler.(ServerHandlerOnRequest); ok {
 		h.OnRequest(sc, req)
 	}
 
-	res, err := sc.handleRequestInner(req)
+	if sc.conformanceRequests != nil {
+		sc.conformanceRequests.CheckRequest(req)
+	}
+
+	sc.s.events.requestReceived(EventSource{Conn: sc, Session: sc.session}, req)
+	sc.log.Debug("request received", "method", req.Method, "url", req.URL, "cseq", req.Header["CSeq"])
+
+	res, err := sc.checkRequestLimits(req)
+	if err != nil {
+		return err
+	}
+
+	if res == nil {
+		res, err = sc.s.requestHandler(sc, req)
+	}
+
+	if res == nil {
+		res = &base.Response{
+			StatusCode: base.StatusInternalServerError,
+		}
+	}
 
 	if res.Header == nil {
 		res.Header = make(base.Header)
@@ -394,22 +735,44 @@ func (sc *ServerConn) handleRequestOuter(req *base.Request) error {
 		res.Header["CSeq"] = req.Header["CSeq"]
 	}
 
-	// add server
-	res.Header["Server"] = base.HeaderValue{"gortsplib"}
-
 	if h, ok := sc.s.Handler.(ServerHandlerOnResponse); ok {
 		h.OnResponse(sc, res)
 	}
 
+	if sc.conformanceRequests != nil {
+		sc.conformanceRequests.CheckResponse(req, res)
+	}
+
 	sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.WriteTimeout))
 	err2 := sc.conn.WriteResponse(res)
-	if err == nil && err2 != nil {
+	if err2 == nil {
+		sc.s.events.responseSent(EventSource{Conn: sc, Session: sc.session}, res)
+		sc.log.Debug("response sent", "method", req.Method, "cseq", req.Header["CSeq"], "status", res.StatusCode)
+
+		if sc.session != nil {
+			sc.session.capture.writeMessages(sc.nconn.LocalAddr(), sc.nconn.RemoteAddr(), req, res)
+		}
+	} else if err == nil {
 		err = err2
 	}
 
 	return err
 }
 
+// handleRequestBase is the innermost ServerRequestHandler.
+func (sc *ServerConn) handleRequestBase(req *base.Request) (*base.Response, error) {
+	res, err := sc.handleRequestInner(req)
+
+	if res.Header == nil {
+		res.Header = make(base.Header)
+	}
+
+	// add server
+	res.Header["Server"] = base.HeaderValue{"gortsplib"}
+
+	return res, err
+}
+
 func (sc *ServerConn) handleRequestInSession(
 	sxID string,
 	req *base.Request,
@@ -47,6 +47,11 @@ func (cr *serverConnReader) run() {
 
 	readFunc := cr.readFuncStandard
 
+	// restored TCP sessions are already playing or recording
+	if cr.sc.session != nil && cr.sc.session.tcpConn == cr.sc {
+		readFunc = cr.readFuncTCP
+	}
+
 	for {
 		err := readFunc()
 		if err, ok := err.(errSwitchReadFunc); ok {
@@ -83,7 +88,10 @@ func (cr *serverConnReader) readFuncStandard() error {
 			}
 
 		case *base.Response:
-			return liberrors.ErrServerUnexpectedResponse{}
+			err := cr.sc.readResponse(what)
+			if err != nil {
+				return err
+			}
 
 		case *base.InterleavedFrame:
 			return liberrors.ErrServerUnexpectedFrame{}
@@ -97,8 +105,25 @@ func (cr *serverConnReader) readFuncTCP() error {
 
 	cr.sc.session.startWriter()
 
+	ss := cr.sc.session
+
+	var queue *asyncProcessor
+
+	if ss.state == ServerSessionStateRecord {
+		if l := ss.effectiveRecordLimits(); l != nil && l.MaxQueuedFrames != 0 {
+			queue = &asyncProcessor{
+				maxBytes:     uint64(l.MaxQueuedBytes),
+				policy:       ServerWriteQueuePolicyBlock,
+				blockTimeout: cr.sc.s.ReadTimeout,
+			}
+			queue.allocateBuffer(l.MaxQueuedFrames)
+			queue.start()
+			defer queue.stop()
+		}
+	}
+
 	for {
-		if cr.sc.session.state == ServerSessionStateRecord {
+		if ss.state == ServerSessionStateRecord {
 			cr.sc.nconn.SetReadDeadline(time.Now().Add(cr.sc.s.ReadTimeout))
 		}
 
@@ -109,6 +134,14 @@ func (cr *serverConnReader) readFuncTCP() error {
 
 		switch what := what.(type) {
 		case *base.Request:
+			// process queued frames before the request, that may change the state of the session
+			if queue != nil {
+				done := make(chan struct{})
+				if queue.push(func() { close(done) }) {
+					<-done
+				}
+			}
+
 			cres := make(chan error)
 			req := readReq{req: what, res: cres}
 			err := cr.sc.readRequest(req)
@@ -117,14 +150,69 @@ func (cr *serverConnReader) readFuncTCP() error {
 			}
 
 		case *base.Response:
-			return liberrors.ErrServerUnexpectedResponse{}
+			err := cr.sc.readResponse(what)
+			if err != nil {
+				return err
+			}
 
 		case *base.InterleavedFrame:
-			atomic.AddUint64(cr.sc.session.bytesReceived, uint64(len(what.Payload)))
+			atomic.AddUint64(ss.bytesReceived, uint64(len(what.Payload)))
+			ss.capture.writeFrame(cr.sc.nconn.RemoteAddr(), cr.sc.nconn.LocalAddr(), what)
+
+			if ss.state == ServerSessionStateRecord && !ss.checkRecordPacketSize(len(what.Payload)) {
+				continue
+			}
 
-			if cb, ok := cr.sc.session.tcpCallbackByChannel[what.Channel]; ok {
-				cb(what.Payload)
+			if queue == nil {
+				cr.processFrame(what.Channel, what.Payload)
+				continue
+			}
+
+			err := cr.queueFrame(queue, what)
+			if err != nil {
+				return err
 			}
 		}
 	}
 }
+
+func (cr *serverConnReader) processFrame(channel int, payload []byte) {
+	if cb, ok := cr.sc.session.tcpCallbackByChannel[channel]; ok {
+		cb(payload)
+	} else if cr.sc.session.onInterleavedFrame != nil {
+		cr.sc.session.onInterleavedFrame(channel, payload)
+	}
+}
+
+// queueFrame detaches the processing of a frame from the reading routine.
+// When the queue is full, the reading routine waits, applying backpressure to the client.
+func (cr *serverConnReader) queueFrame(queue *asyncProcessor, frame *base.InterleavedFrame) error {
+	ss := cr.sc.session
+
+	channel := frame.Channel
+	payload := frame.Payload
+
+	// the payload buffer is reused by the next read
+	if cr.sc.s.PacketBufferReuseEnable {
+		payload = append([]byte(nil), payload...)
+	}
+
+	if queue.full(len(payload)) {
+		atomic.AddUint64(ss.recordCounters.queueFullEvents, 1)
+	}
+
+	atomic.AddInt64(ss.recordCounters.queuedFrames, 1)
+
+	ok := queue.pushSized(len(payload), func() {
+		atomic.AddInt64(ss.recordCounters.queuedFrames, -1)
+		cr.processFrame(channel, payload)
+	})
+	if !ok {
+		atomic.AddInt64(ss.recordCounters.queuedFrames, -1)
+		err := liberrors.ErrServerRecordQueueFull{}
+		ss.onRecordLimitExceeded(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package gortsplib
+
+import (
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// Redirect sends a REDIRECT request to the client, in order to ask it
+// to connect to another location (i.e. another server of a farm).
+// u is the URL of the resource that has been moved.
+// The response of the client is passed to ServerHandlerOnRedirect.
+func (sc *ServerConn) Redirect(u *base.URL, location *base.URL) error {
+	req := writeReq{
+		req: &base.Request{
+			Method: base.Redirect,
+			URL:    u,
+			Header: base.Header{
+				"Location": base.HeaderValue{location.String()},
+			},
+		},
+		res: make(chan error),
+	}
+
+	select {
+	case sc.chWriteRequest <- req:
+		return <-req.res
+
+	case <-sc.ctx.Done():
+		return liberrors.ErrServerTerminated{}
+	}
+}
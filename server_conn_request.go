@@ -0,0 +1,154 @@
+package gortsplib
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+type writeReq struct {
+	req *base.Request
+	res chan error
+
+	// optional, receives the response of the client.
+	response chan *base.Response
+}
+
+type readRes struct {
+	res *base.Response
+	err chan error
+}
+
+type serverConnPendingRequest struct {
+	req      *base.Request
+	response chan *base.Response
+}
+
+// request sends a request to the client and waits for its response.
+func (sc *ServerConn) request(req *base.Request) (*base.Response, error) {
+	wr := writeReq{
+		req:      req,
+		res:      make(chan error),
+		response: make(chan *base.Response, 1),
+	}
+
+	select {
+	case sc.chWriteRequest <- wr:
+		err := <-wr.res
+		if err != nil {
+			return nil, err
+		}
+
+	case <-sc.ctx.Done():
+		return nil, liberrors.ErrServerTerminated{}
+	}
+
+	t := sc.s.Clock.NewTimer(sc.s.ReadTimeout)
+	defer t.Stop()
+
+	select {
+	case res := <-wr.response:
+		return res, nil
+
+	case <-t.C():
+		return nil, liberrors.ErrServerRequestTimedOut{}
+
+	case <-sc.ctx.Done():
+		return nil, liberrors.ErrServerTerminated{}
+	}
+}
+
+func (sc *ServerConn) writeRequest(wr writeReq) error {
+	req := wr.req
+
+	if req.Header == nil {
+		req.Header = make(base.Header)
+	}
+
+	sc.cseq++
+	cseq := strconv.FormatInt(int64(sc.cseq), 10)
+	req.Header["CSeq"] = base.HeaderValue{cseq}
+
+	if sc.session != nil {
+		req.Header["Session"] = headers.Session{
+			Session: sc.session.secretID,
+		}.Marshal()
+	}
+
+	sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.WriteTimeout))
+	err := sc.conn.WriteRequest(req)
+	if err != nil {
+		return err
+	}
+
+	sc.s.events.requestSent(EventSource{Conn: sc, Session: sc.session}, req)
+	sc.log.Debug("request sent", "method", req.Method, "url", req.URL, "cseq", cseq)
+
+	if sc.conformanceServer != nil {
+		sc.conformanceServer.CheckRequest(req)
+	}
+
+	if sc.pendingRequests == nil {
+		sc.pendingRequests = make(map[string]serverConnPendingRequest)
+	}
+	sc.pendingRequests[cseq] = serverConnPendingRequest{
+		req:      req,
+		response: wr.response,
+	}
+
+	return nil
+}
+
+// handleResponse handles a response to a request sent by the server.
+func (sc *ServerConn) handleResponse(res *base.Response) error {
+	cseq, ok := res.Header["CSeq"]
+	if !ok || len(cseq) != 1 {
+		return liberrors.ErrServerUnexpectedResponse{}
+	}
+
+	pr, ok := sc.pendingRequests[cseq[0]]
+	if !ok {
+		return liberrors.ErrServerUnexpectedResponse{}
+	}
+	delete(sc.pendingRequests, cseq[0])
+
+	sc.s.events.responseReceived(EventSource{Conn: sc, Session: sc.session}, res)
+	sc.log.Debug("response received", "method", pr.req.Method, "cseq", cseq[0], "status", res.StatusCode)
+
+	if sc.conformanceServer != nil {
+		sc.conformanceServer.CheckResponse(pr.req, res)
+	}
+
+	// the channel is buffered, therefore this never blocks,
+	// even when the caller has stopped waiting.
+	if pr.response != nil {
+		pr.response <- res
+	}
+
+	if pr.req.Method == base.Redirect {
+		if h, ok := sc.s.Handler.(ServerHandlerOnRedirect); ok {
+			h.OnRedirect(&ServerHandlerOnRedirectCtx{
+				Conn:     sc,
+				Request:  pr.req,
+				Response: res,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (sc *ServerConn) readResponse(res *base.Response) error {
+	rr := readRes{res: res, err: make(chan error)}
+
+	select {
+	case sc.chReadResponse <- rr:
+		return <-rr.err
+
+	case <-sc.ctx.Done():
+		return liberrors.ErrServerTerminated{}
+	}
+}
@@ -0,0 +1,104 @@
+package gortsplib
+
+import (
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// requestRateLimiter is a token bucket that limits the requests of a connection.
+type requestRateLimiter struct {
+	max    int
+	tokens float64
+	last   time.Time
+}
+
+func (l *requestRateLimiter) allow(now time.Time) bool {
+	if l.last.IsZero() {
+		l.tokens = float64(l.max)
+	} else {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.max)
+		if l.tokens > float64(l.max) {
+			l.tokens = float64(l.max)
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+func headerSize(h base.Header) (int, int) {
+	size := 0
+	count := 0
+
+	for key, vals := range h {
+		for _, val := range vals {
+			size += len(key) + 2 + len(val) + 2
+			count++
+		}
+	}
+
+	return size, count
+}
+
+// checkRequestLimits checks a request against MaxRequestsPerSecond, MaxHeaderSize and MaxHeaderCount.
+// When a limit is exceeded, it returns the response and, if the connection must be closed, an error.
+func (sc *ServerConn) checkRequestLimits(req *base.Request) (*base.Response, error) {
+	var res *base.Response
+	var err error
+
+	if sc.s.MaxRequestsPerSecond != 0 {
+		if sc.requestLimiter == nil {
+			sc.requestLimiter = &requestRateLimiter{max: sc.s.MaxRequestsPerSecond}
+		}
+
+		if !sc.requestLimiter.allow(sc.s.timeNow()) {
+			res = &base.Response{
+				StatusCode: base.StatusTooManyRequests,
+			}
+			err = liberrors.ErrServerRequestRateLimitReached{Max: sc.s.MaxRequestsPerSecond}
+		}
+	}
+
+	if res == nil && (sc.s.MaxHeaderSize != 0 || sc.s.MaxHeaderCount != 0) {
+		size, count := headerSize(req.Header)
+
+		switch {
+		case sc.s.MaxHeaderSize != 0 && size > sc.s.MaxHeaderSize:
+			res = &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}
+			err = liberrors.ErrServerHeaderSizeLimitReached{Size: size, Max: sc.s.MaxHeaderSize}
+
+		case sc.s.MaxHeaderCount != 0 && count > sc.s.MaxHeaderCount:
+			res = &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}
+			err = liberrors.ErrServerHeaderCountLimitReached{Count: count, Max: sc.s.MaxHeaderCount}
+		}
+	}
+
+	if res == nil {
+		return nil, nil
+	}
+
+	sc.log.Warn("request limit exceeded", "method", req.Method, "err", err)
+
+	if h, ok := sc.s.Handler.(ServerHandlerOnFlood); ok {
+		if h.OnFlood(&ServerHandlerOnFloodCtx{
+			Conn:    sc,
+			Request: req,
+			Error:   err,
+		}) {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
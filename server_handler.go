@@ -1,8 +1,15 @@
 package gortsplib
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/conformance"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 )
 
 // ServerHandler is the interface implemented by all the server handlers.
@@ -67,12 +74,77 @@ type ServerHandlerOnResponse interface {
 	OnResponse(*ServerConn, *base.Response)
 }
 
+func serverDigestAlgorithmAllowed(allowed []auth.DigestAlgorithm, v *string) bool {
+	algo := string(auth.DigestAlgorithmMD5)
+	if v != nil {
+		algo = *v
+	}
+
+	if allowed == nil {
+		allowed = []auth.DigestAlgorithm{auth.DigestAlgorithmMD5}
+	}
+
+	for _, a := range allowed {
+		if strings.EqualFold(string(a), algo) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerHandlerOnAuthenticateCtx is the context of OnAuthenticate.
+type ServerHandlerOnAuthenticateCtx struct {
+	Conn    *ServerConn
+	Request *base.Request
+	Path    string
+	Query   string
+	// parsed Authorization header.
+	// It is nil when the header is missing or invalid.
+	Authorization *headers.Authorization
+
+	nonce           string
+	digestValidated bool
+}
+
+// Validate checks whether the request contains the provided credentials,
+// sent with one of the authentication methods allowed by the server.
+func (ctx *ServerHandlerOnAuthenticateCtx) Validate(user string, pass string) error {
+	// prevent clients from using algorithms that are not allowed
+	if ctx.Authorization != nil && ctx.Authorization.Method == headers.AuthDigest &&
+		!serverDigestAlgorithmAllowed(ctx.Conn.s.AuthDigestAlgorithms, ctx.Authorization.DigestValues.Algorithm) {
+		return fmt.Errorf("algorithm not allowed")
+	}
+
+	err := auth.Validate(ctx.Request, user, pass, nil,
+		ctx.Conn.s.AuthMethods, ctx.Conn.s.AuthRealm, ctx.nonce)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Authorization.Method == headers.AuthDigest {
+		ctx.digestValidated = true
+	}
+
+	return nil
+}
+
+// ServerHandlerOnAuthenticate can be implemented by a ServerHandler.
+type ServerHandlerOnAuthenticate interface {
+	// called when receiving any request from a connection, before handling it.
+	// If it returns an error, the request is rejected with a 401 response
+	// that contains the WWW-Authenticate header.
+	OnAuthenticate(*ServerHandlerOnAuthenticateCtx) error
+}
+
 // ServerHandlerOnDescribeCtx is the context of OnDescribe.
 type ServerHandlerOnDescribeCtx struct {
 	Conn    *ServerConn
 	Request *base.Request
 	Path    string
 	Query   string
+
+	// parameters captured by the route of Server.Router that matched the request.
+	Params map[string]string
 }
 
 // ServerHandlerOnDescribe can be implemented by a ServerHandler.
@@ -81,6 +153,23 @@ type ServerHandlerOnDescribe interface {
 	OnDescribe(*ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error)
 }
 
+// ServerHandlerOnDescribeSDPCtx is the context of OnDescribeSDP.
+type ServerHandlerOnDescribeSDPCtx struct {
+	Conn    *ServerConn
+	Request *base.Request
+	Path    string
+	Query   string
+	Stream  *ServerStream
+	SDP     *sdp.SessionDescription
+}
+
+// ServerHandlerOnDescribeSDP can be implemented by a ServerHandler.
+type ServerHandlerOnDescribeSDP interface {
+	// called after OnDescribe, before the SDP generated from the stream is sent to the client.
+	// The SDP can be modified, i.e. by adding session or media attributes and bandwidth lines.
+	OnDescribeSDP(*ServerHandlerOnDescribeSDPCtx)
+}
+
 // ServerHandlerOnAnnounceCtx is the context of OnAnnounce.
 type ServerHandlerOnAnnounceCtx struct {
 	Session     *ServerSession
@@ -89,6 +178,9 @@ type ServerHandlerOnAnnounceCtx struct {
 	Path        string
 	Query       string
 	Description *description.Session
+
+	// parameters captured by the route of Server.Router that matched the request.
+	Params map[string]string
 }
 
 // ServerHandlerOnAnnounce can be implemented by a ServerHandler.
@@ -97,6 +189,28 @@ type ServerHandlerOnAnnounce interface {
 	OnAnnounce(*ServerHandlerOnAnnounceCtx) (*base.Response, error)
 }
 
+// ServerHandlerOnStreamUpdateCtx is the context of OnStreamUpdate.
+type ServerHandlerOnStreamUpdateCtx struct {
+	Session     *ServerSession
+	Conn        *ServerConn
+	Request     *base.Request
+	Path        string
+	Query       string
+	Description *description.Session
+}
+
+// ServerHandlerOnStreamUpdate can be implemented by a ServerHandler.
+type ServerHandlerOnStreamUpdate interface {
+	// called when receiving an ANNOUNCE request on a session that is already publishing,
+	// to update the stream description (i.e. when a camera changes resolution).
+	// The new description contains the same medias of the previous one,
+	// while formats can be different. Medias in Description replace the previous ones,
+	// callbacks of formats with the same payload type are preserved
+	// and callbacks of other formats can be set inside the handler.
+	// If the handler doesn't return StatusOK, the previous description is kept.
+	OnStreamUpdate(*ServerHandlerOnStreamUpdateCtx) (*base.Response, error)
+}
+
 // ServerHandlerOnSetupCtx is the context of OnSetup.
 type ServerHandlerOnSetupCtx struct {
 	Session   *ServerSession
@@ -105,6 +219,14 @@ type ServerHandlerOnSetupCtx struct {
 	Path      string
 	Query     string
 	Transport Transport
+
+	// whether the client asked to append data to an existing recording
+	// instead of replacing it (Transport: mode=record;append).
+	// Handlers that don't support appending must refuse the request.
+	Append bool
+
+	// parameters captured by the route of Server.Router that matched the request.
+	Params map[string]string
 }
 
 // ServerHandlerOnSetup can be implemented by a ServerHandler.
@@ -118,12 +240,38 @@ type ServerHandlerOnSetup interface {
 }
 
 // ServerHandlerOnPlayCtx is the context of OnPlay.
+// PLAY requests are delivered even when the session is already playing,
+// in order to allow seeking.
 type ServerHandlerOnPlayCtx struct {
 	Session *ServerSession
 	Conn    *ServerConn
 	Request *base.Request
 	Path    string
 	Query   string
+
+	// requested range, parsed from the Range header.
+	// It is nil when the header is not present.
+	// The range that is actually applied can be sent back by filling
+	// the Range header of the response. In the same way, RTP-Info can be
+	// filled by the handler, otherwise it is generated automatically.
+	Range *headers.Range
+
+	// requested scale, parsed from the Scale header.
+	// It is nil when the header is not present.
+	// The scale that is actually applied can be sent back by filling
+	// the Scale header of the response.
+	Scale *headers.Scale
+
+	// requested speed, parsed from the Speed header.
+	// It is nil when the header is not present.
+	// The speed that is actually applied can be sent back by filling
+	// the Speed header of the response.
+	Speed *headers.Speed
+
+	// media targeted by the request, when the request resumes
+	// a single media paused with a PAUSE request.
+	// It is nil when the request refers to the whole session.
+	Media *description.Media
 }
 
 // ServerHandlerOnPlay can be implemented by a ServerHandler.
@@ -139,6 +287,10 @@ type ServerHandlerOnRecordCtx struct {
 	Request *base.Request
 	Path    string
 	Query   string
+
+	// whether the client asked to append data to an existing recording
+	// in SETUP requests.
+	Append bool
 }
 
 // ServerHandlerOnRecord can be implemented by a ServerHandler.
@@ -154,6 +306,11 @@ type ServerHandlerOnPauseCtx struct {
 	Request *base.Request
 	Path    string
 	Query   string
+
+	// media targeted by the request, when the request refers to
+	// a single media of a playing session.
+	// It is nil when the request refers to the whole session.
+	Media *description.Media
 }
 
 // ServerHandlerOnPause can be implemented by a ServerHandler.
@@ -169,6 +326,11 @@ type ServerHandlerOnGetParameterCtx struct {
 	Request *base.Request
 	Path    string
 	Query   string
+
+	// names of requested parameters, decoded from the request body.
+	// Values can be sent back by filling the response body
+	// with parameters.Parameters.Marshal().
+	Parameters []string
 }
 
 // ServerHandlerOnGetParameter can be implemented by a ServerHandler.
@@ -184,6 +346,9 @@ type ServerHandlerOnSetParameterCtx struct {
 	Request *base.Request
 	Path    string
 	Query   string
+
+	// parameters to set, decoded from the request body.
+	Parameters map[string]string
 }
 
 // ServerHandlerOnSetParameter can be implemented by a ServerHandler.
@@ -227,3 +392,100 @@ type ServerHandlerOnStreamWriteError interface {
 	// called when a ServerStream is unable to write packets to a session.
 	OnStreamWriteError(*ServerHandlerOnStreamWriteErrorCtx)
 }
+
+// ServerHandlerOnKeyframeRequestCtx is the context of OnKeyframeRequest.
+type ServerHandlerOnKeyframeRequestCtx struct {
+	Session *ServerSession
+	Stream  *ServerStream
+	Media   *description.Media
+}
+
+// ServerHandlerOnKeyframeRequest can be implemented by a ServerHandler.
+type ServerHandlerOnKeyframeRequest interface {
+	// called when a session starts playing a video media,
+	// or when it asks for a keyframe by sending a RTCP PLI or FIR.
+	// It can be used to request a keyframe to the source of the stream.
+	OnKeyframeRequest(*ServerHandlerOnKeyframeRequestCtx)
+}
+
+// ServerHandlerOnThrottleCtx is the context of OnThrottle.
+type ServerHandlerOnThrottleCtx struct {
+	Session   *ServerSession
+	Throttled bool
+}
+
+// ServerHandlerOnThrottle can be implemented by a ServerHandler.
+type ServerHandlerOnThrottle interface {
+	// called when a session starts or stops being throttled, because of the bitrate limit.
+	// It is called by the routine of the session, after the packet that caused the change has been processed.
+	OnThrottle(*ServerHandlerOnThrottleCtx)
+}
+
+// ServerHandlerOnStreamEndedCtx is the context of OnStreamEnded.
+type ServerHandlerOnStreamEndedCtx struct {
+	Session *ServerSession
+	Reason  string
+}
+
+// ServerHandlerOnStreamEnded can be implemented by a ServerHandler.
+type ServerHandlerOnStreamEnded interface {
+	// called when a publishing client signals the end of the stream with a RTCP BYE packet.
+	// It is called once per RECORD request.
+	OnStreamEnded(*ServerHandlerOnStreamEndedCtx)
+}
+
+// ServerHandlerOnFloodCtx is the context of OnFlood.
+type ServerHandlerOnFloodCtx struct {
+	Conn    *ServerConn
+	Request *base.Request
+	// limit that has been exceeded.
+	Error error
+}
+
+// ServerHandlerOnFlood can be implemented by a ServerHandler.
+type ServerHandlerOnFlood interface {
+	// called when a request exceeds MaxRequestsPerSecond, MaxHeaderSize or MaxHeaderCount.
+	// If it returns true, the client is treated as an attacker and the connection
+	// is closed without replying. Otherwise, the request is answered with an error status code.
+	OnFlood(*ServerHandlerOnFloodCtx) bool
+}
+
+// ServerHandlerOnRedirectCtx is the context of OnRedirect.
+type ServerHandlerOnRedirectCtx struct {
+	Conn     *ServerConn
+	Request  *base.Request
+	Response *base.Response
+}
+
+// ServerHandlerOnRedirect can be implemented by a ServerHandler.
+type ServerHandlerOnRedirect interface {
+	// called when a client replies to a REDIRECT request sent with ServerConn.Redirect().
+	OnRedirect(*ServerHandlerOnRedirectCtx)
+}
+
+// ServerHandlerOnConformanceViolationCtx is the context of OnConformanceViolation.
+type ServerHandlerOnConformanceViolationCtx struct {
+	Conn      *ServerConn
+	Violation *conformance.Violation
+}
+
+// ServerHandlerOnConformanceViolation can be implemented by a ServerHandler.
+type ServerHandlerOnConformanceViolation interface {
+	// called when a request or a response violates the specification.
+	// It is used only when Server.ConformanceCheck is true.
+	OnConformanceViolation(*ServerHandlerOnConformanceViolationCtx)
+}
+
+// ServerHandlerOnRecordLimitExceededCtx is the context of OnRecordLimitExceeded.
+type ServerHandlerOnRecordLimitExceededCtx struct {
+	Session *ServerSession
+	// limit that has been exceeded.
+	Error error
+}
+
+// ServerHandlerOnRecordLimitExceeded can be implemented by a ServerHandler.
+type ServerHandlerOnRecordLimitExceeded interface {
+	// called when data received from a publishing session exceeds ServerRecordLimits.
+	// It is called by the routine that is reading the session and must not block.
+	OnRecordLimitExceeded(*ServerHandlerOnRecordLimitExceededCtx)
+}
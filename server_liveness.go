@@ -0,0 +1,90 @@
+package gortsplib
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ServerSessionActivity contains the times of the last activities of a session.
+// Times of packets are equal to the time in which the session started
+// playing or recording until the first packet is received.
+type ServerSessionActivity struct {
+	// time of the last RTSP request.
+	LastRequest time.Time
+
+	// time of the last RTP packet received from the client (RECORD sessions only).
+	LastRTP time.Time
+
+	// time of the last RTCP packet received from the client
+	// (receiver reports of readers, sender reports of publishers).
+	LastRTCP time.Time
+}
+
+// ServerLivenessPolicy defines when sessions with the UDP or UDP-multicast transport are alive.
+// Sessions are alive when at least one of the enabled criteria is satisfied,
+// otherwise they are closed with ErrServerSessionTimedOut.
+// When no criteria are enabled, sessions never time out.
+type ServerLivenessPolicy struct {
+	// maximum time between RTSP requests (keepalives).
+	// Zero disables the criterion.
+	RequestTimeout time.Duration
+
+	// maximum time between RTCP packets.
+	// Zero disables the criterion.
+	RTCPTimeout time.Duration
+
+	// maximum time between RTP packets, that are received by RECORD sessions only.
+	// Zero disables the criterion.
+	RTPTimeout time.Duration
+
+	// custom predicate (optional).
+	// When set, it replaces the other criteria.
+	// It is called periodically by the routine of the session.
+	IsAlive func(ss *ServerSession, act ServerSessionActivity) bool
+}
+
+func (p *ServerLivenessPolicy) isAlive(ss *ServerSession, act ServerSessionActivity, now time.Time) bool {
+	if p.IsAlive != nil {
+		return p.IsAlive(ss, act)
+	}
+
+	if p.RequestTimeout == 0 && p.RTCPTimeout == 0 && p.RTPTimeout == 0 {
+		return true
+	}
+
+	return (p.RequestTimeout != 0 && now.Sub(act.LastRequest) < p.RequestTimeout) ||
+		(p.RTCPTimeout != 0 && now.Sub(act.LastRTCP) < p.RTCPTimeout) ||
+		(p.RTPTimeout != 0 && ss.state == ServerSessionStateRecord && now.Sub(act.LastRTP) < p.RTPTimeout)
+}
+
+// Activity returns the times of the last activities of the session.
+func (ss *ServerSession) Activity() ServerSessionActivity {
+	return ServerSessionActivity{
+		LastRequest: time.Unix(0, atomic.LoadInt64(ss.lastRequestTime)),
+		LastRTP:     time.Unix(0, atomic.LoadInt64(ss.udpLastRTPTime)),
+		LastRTCP:    time.Unix(0, atomic.LoadInt64(ss.udpLastRTCPTime)),
+	}
+}
+
+func (ss *ServerSession) resetActivity() {
+	now := ss.s.timeNow().UnixNano()
+	atomic.StoreInt64(ss.udpLastRTPTime, now)
+	atomic.StoreInt64(ss.udpLastRTCPTime, now)
+}
+
+func (ss *ServerSession) isAlive(now time.Time) bool {
+	act := ss.Activity()
+
+	if ss.s.Liveness != nil {
+		return ss.s.Liveness.isAlive(ss, act, now)
+	}
+
+	// in case of RECORD, timeout happens when no RTP or RTCP packets are being received
+	if ss.state == ServerSessionStateRecord {
+		return now.Sub(act.LastRTP) < ss.s.ReadTimeout || now.Sub(act.LastRTCP) < ss.s.ReadTimeout
+	}
+
+	// in case of PLAY, timeout happens when no RTSP keepalives and no RTCP packets are being received
+	timeout := ss.sessionTimeout()
+	return now.Sub(act.LastRequest) < timeout || now.Sub(act.LastRTCP) < timeout
+}
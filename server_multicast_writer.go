@@ -1,36 +1,79 @@
 package gortsplib
 
 import (
+	"encoding/binary"
 	"net"
 
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
 )
 
 type serverMulticastWriter struct {
+	ttl      int
 	rtpl     *serverUDPListener
 	rtcpl    *serverUDPListener
 	writer   asyncProcessor
 	rtpAddr  *net.UDPAddr
 	rtcpAddr *net.UDPAddr
+
+	writeRTP  func([]byte)
+	writeRTCP func([]byte)
+}
+
+// nthIP returns the n-th IP of a IPv4 network.
+func nthIP(ipnet *net.IPNet, n uint32) net.IP {
+	ip32 := binary.BigEndian.Uint32(ipnet.IP.To4())
+	mask := binary.BigEndian.Uint32(ipnet.Mask[len(ipnet.Mask)-4:])
+	ip32 = (ip32 & mask) | ((ip32 + n) & ^mask)
+
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, ip32)
+	return ip
 }
 
-func newServerMulticastWriter(s *Server) (*serverMulticastWriter, error) {
-	ip, err := s.getMulticastIP()
+func newServerMulticastWriter(
+	s *Server,
+	medi *description.Media,
+	ip net.IP,
+	ttl int,
+	intf *net.Interface,
+) (*serverMulticastWriter, error) {
+	if ip == nil {
+		var err error
+		ip, err = s.getMulticastIP()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	srtp, err := newMediaSRTP(medi)
 	if err != nil {
 		return nil, err
 	}
 
+	rtcpIP, rtcpPort := multicastRTCPAddress(medi, ip, s.MulticastRTCPPort)
+
 	rtpl, rtcpl, err := newServerUDPListenerMulticastPair(
 		s.ListenPacket,
 		s.WriteTimeout,
 		s.MulticastRTPPort,
-		s.MulticastRTCPPort,
+		rtcpPort,
 		ip,
+		rtcpIP,
+		ttl,
+		intf,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	err = s.applyUDPSocketOptions(rtpl, rtcpl)
+	if err != nil {
+		rtpl.close()
+		rtcpl.close()
+		return nil, err
+	}
+
 	rtpAddr := &net.UDPAddr{
 		IP:   rtpl.ip(),
 		Port: rtpl.port(),
@@ -42,12 +85,25 @@ func newServerMulticastWriter(s *Server) (*serverMulticastWriter, error) {
 	}
 
 	h := &serverMulticastWriter{
+		ttl:      ttl,
 		rtpl:     rtpl,
 		rtcpl:    rtcpl,
 		rtpAddr:  rtpAddr,
 		rtcpAddr: rtcpAddr,
 	}
 
+	h.writeRTP = func(payload []byte) {
+		h.rtpl.write(payload, h.rtpAddr) //nolint:errcheck
+	}
+	h.writeRTCP = func(payload []byte) {
+		h.rtcpl.write(payload, h.rtcpAddr) //nolint:errcheck
+	}
+
+	if srtp != nil {
+		h.writeRTP = srtp.encryptRTP(h.writeRTP)
+		h.writeRTCP = srtp.encryptRTCP(h.writeRTCP)
+	}
+
 	h.writer.allocateBuffer(s.WriteQueueSize)
 	h.writer.start()
 
@@ -64,9 +120,21 @@ func (h *serverMulticastWriter) ip() net.IP {
 	return h.rtpl.ip()
 }
 
+// TTL advertised inside the Transport header.
+func (h *serverMulticastWriter) headerTTL() uint {
+	if h.ttl != 0 {
+		return uint(h.ttl)
+	}
+	return 127
+}
+
+func (h *serverMulticastWriter) rtcpPort() int {
+	return h.rtcpl.port()
+}
+
 func (h *serverMulticastWriter) writePacketRTP(payload []byte) error {
 	ok := h.writer.push(func() {
-		h.rtpl.write(payload, h.rtpAddr) //nolint:errcheck
+		h.writeRTP(payload)
 	})
 	if !ok {
 		return liberrors.ErrServerWriteQueueFull{}
@@ -77,7 +145,7 @@ func (h *serverMulticastWriter) writePacketRTP(payload []byte) error {
 
 func (h *serverMulticastWriter) writePacketRTCP(payload []byte) error {
 	ok := h.writer.push(func() {
-		h.rtcpl.write(payload, h.rtcpAddr) //nolint:errcheck
+		h.writeRTCP(payload)
 	})
 	if !ok {
 		return liberrors.ErrServerWriteQueueFull{}
@@ -2,6 +2,7 @@ package gortsplib
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"net"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	psdp "github.com/pion/sdp/v3"
@@ -22,7 +24,10 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpextension"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
+	"github.com/bluenviron/gortsplib/v4/pkg/srtp"
 )
 
 func uintPtr(v uint) *uint {
@@ -37,6 +42,10 @@ func uint32Ptr(v uint32) *uint32 {
 	return &v
 }
 
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
 func multicastCapableIP(t *testing.T) string {
 	intfs, err := net.Interfaces()
 	require.NoError(t, err)
@@ -1097,11 +1106,168 @@ func TestServerPlayRTCPReport(t *testing.T) {
 				OctetCount:  1,
 			}, packets[0])
 
-			doTeardown(t, conn, "rtsp://localhost:8554/teststream", session)
+			if ca == "udp" {
+				doTeardown(t, conn, "rtsp://localhost:8554/teststream", session)
+			} else {
+				err = conn.WriteRequest(&base.Request{
+					Method: base.Teardown,
+					URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+					Header: base.Header{
+						"CSeq":    base.HeaderValue{"1"},
+						"Session": base.HeaderValue{session},
+					},
+				})
+				require.NoError(t, err)
+
+				// a RTCP BYE is sent before the response
+				f, err := conn.ReadInterleavedFrame()
+				require.NoError(t, err)
+				require.Equal(t, 1, f.Channel)
+
+				packets, err = rtcp.Unmarshal(f.Payload)
+				require.NoError(t, err)
+				require.Equal(t, []rtcp.Packet{&rtcp.Goodbye{
+					Sources: []uint32{0x38F27A2F},
+				}}, packets)
+
+				res, err := conn.ReadResponse()
+				require.NoError(t, err)
+				require.Equal(t, base.StatusOK, res.StatusCode)
+			}
 		})
 	}
 }
 
+func TestServerPlayTransportInfo(t *testing.T) {
+	var stream *ServerStream
+	sessionCreated := make(chan *ServerSession, 1)
+
+	var curTime time.Time
+	var curTimeMutex sync.Mutex
+
+	setCurTime := func(v time.Time) {
+		curTimeMutex.Lock()
+		defer curTimeMutex.Unlock()
+		curTime = v
+	}
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				sessionCreated <- ctx.Session
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+		timeNow: func() time.Time {
+			curTimeMutex.Lock()
+			defer curTimeMutex.Unlock()
+			return curTime
+		},
+		senderReportPeriod: 100 * time.Millisecond,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Mode:           transportModePtr(headers.TransportModePlay),
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{4, 5},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	ss := <-sessionCreated
+
+	setCurTime(time.Date(2014, 6, 7, 15, 0, 0, 0, time.UTC))
+
+	err = stream.WritePacketRTPWithNTP(
+		stream.Description().Medias[0],
+		&rtp.Packet{
+			Header: rtp.Header{
+				Version:     2,
+				PayloadType: 96,
+				SSRC:        0x38F27A2F,
+				Timestamp:   240000,
+			},
+			Payload: []byte{0x05}, // IDR
+		},
+		time.Date(2017, 8, 10, 12, 22, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 4, f.Channel)
+
+	f, err = conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 5, f.Channel)
+
+	packets, err := rtcp.Unmarshal(f.Payload)
+	require.NoError(t, err)
+	sr := packets[0].(*rtcp.SenderReport)
+
+	// the receiver report is received 800ms after the sender report, and it was delayed by 500ms
+	setCurTime(time.Date(2014, 6, 7, 15, 0, 0, 800000000, time.UTC))
+
+	byts, _ := (&rtcp.ReceiverReport{
+		SSRC: 0x65f83afb,
+		Reports: []rtcp.ReceptionReport{{
+			SSRC:             sr.SSRC,
+			LastSenderReport: uint32(sr.NTPTime >> 16),
+			Delay:            65536 / 2,
+		}},
+	}).Marshal()
+
+	err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: 5,
+		Payload: byts,
+	}, make([]byte, 1024))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		infos := ss.TransportInfo()
+		return len(infos) == 1 && infos[0].RoundTripTime != 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, []MediaTransportInfo{{
+		Media:          stream.Description().Medias[0],
+		Transport:      TransportTCP,
+		InterleavedIDs: &[2]int{4, 5},
+		SSRCs:          map[uint8]uint32{96: 0x38F27A2F},
+		RoundTripTime:  300 * time.Millisecond,
+	}}, ss.TransportInfo())
+}
+
 func TestServerPlayVLCMulticast(t *testing.T) {
 	var stream *ServerStream
 	listenIP := multicastCapableIP(t)
@@ -1149,56 +1315,31 @@ func TestServerPlayVLCMulticast(t *testing.T) {
 	require.Equal(t, "224.1.0.0", desc.ConnectionInformation.Address.Address)
 }
 
-func TestServerPlayTCPResponseBeforeFrames(t *testing.T) {
+func TestServerPlayDescribeSDP(t *testing.T) {
 	var stream *ServerStream
-	writerDone := make(chan struct{})
-	writerTerminate := make(chan struct{})
 
 	s := &Server{
-		RTSPAddress: "localhost:8554",
 		Handler: &testServerHandler{
-			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
-				close(writerTerminate)
-				<-writerDone
-			},
-			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
-				return &base.Response{
-					StatusCode: base.StatusOK,
-				}, stream, nil
-			},
-			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
-			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
-				go func() {
-					defer close(writerDone)
-
-					err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
-					require.NoError(t, err)
-
-					ti := time.NewTicker(50 * time.Millisecond)
-					defer ti.Stop()
-
-					for {
-						select {
-						case <-ti.C:
-							err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
-							require.NoError(t, err)
-						case <-writerTerminate:
-							return
-						}
-					}
-				}()
-
-				time.Sleep(50 * time.Millisecond)
+			onDescribeSDP: func(ctx *ServerHandlerOnDescribeSDPCtx) {
+				require.Equal(t, stream, ctx.Stream)
+				require.Equal(t, "/teststream", ctx.Path)
 
-				return &base.Response{
-					StatusCode: base.StatusOK,
-				}, nil
+				ctx.SDP.Attributes = append(ctx.SDP.Attributes, psdp.Attribute{
+					Key:   "x-custom",
+					Value: "value",
+				})
+				ctx.SDP.MediaDescriptions[0].Bandwidth = append(ctx.SDP.MediaDescriptions[0].Bandwidth, psdp.Bandwidth{
+					Type:      "AS",
+					Bandwidth: 1000,
+				})
 			},
 		},
+		RTSPAddress: "localhost:8554",
 	}
 
 	err := s.Start()
@@ -1208,32 +1349,29 @@ func TestServerPlayTCPResponseBeforeFrames(t *testing.T) {
 	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
 	defer stream.Close()
 
-	nconn, err := net.Dial("tcp", "localhost:8554")
-	require.NoError(t, err)
-	defer nconn.Close()
-	conn := conn.NewConn(nconn)
-
-	desc := doDescribe(t, conn)
-
-	inTH := &headers.Transport{
-		Protocol:       headers.TransportProtocolTCP,
-		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-		Mode:           transportModePtr(headers.TransportModePlay),
-		InterleavedIDs: &[2]int{0, 1},
-	}
-
-	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+	c := Client{}
 
-	session := readSession(t, res)
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
 
-	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
 
-	_, err = conn.ReadInterleavedFrame()
+	desc, res, err := c.Describe(u)
 	require.NoError(t, err)
+	require.Contains(t, string(res.Body), "a=x-custom:value\r\n")
+
+	// unsupported attributes are available in the decoded SDP
+	v, ok := desc.SDP.Attribute("x-custom")
+	require.True(t, ok)
+	require.Equal(t, "value", v)
+	require.Equal(t, []psdp.Bandwidth{{Type: "AS", Bandwidth: 1000}}, desc.SDP.MediaDescriptions[0].Bandwidth)
 }
 
-func TestServerPlayPlayPlay(t *testing.T) {
+func TestServerPlayMulticastRTCPAddress(t *testing.T) {
 	var stream *ServerStream
+	listenIP := multicastCapableIP(t)
 
 	s := &Server{
 		Handler: &testServerHandler{
@@ -1253,52 +1391,72 @@ func TestServerPlayPlayPlay(t *testing.T) {
 				}, nil
 			},
 		},
-		UDPRTPAddress:  "127.0.0.1:8000",
-		UDPRTCPAddress: "127.0.0.1:8001",
-		RTSPAddress:    "localhost:8554",
+		RTSPAddress:       listenIP + ":8554",
+		MulticastIPRange:  "224.1.0.0/16",
+		MulticastRTPPort:  8000,
+		MulticastRTCPPort: 8001,
 	}
 
 	err := s.Start()
 	require.NoError(t, err)
 	defer s.Close()
 
-	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	media := &description.Media{
+		Type:        description.MediaTypeVideo,
+		RTCPPort:    8011,
+		RTCPAddress: "224.1.0.200",
+		Formats:     testH264Media.Formats,
+	}
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{media}})
 	defer stream.Close()
 
-	nconn, err := net.Dial("tcp", "localhost:8554")
+	c := Client{
+		Transport: transportPtr(TransportUDPMulticast),
+	}
+
+	u, err := base.ParseURL("rtsp://" + listenIP + ":8554/teststream")
 	require.NoError(t, err)
-	defer nconn.Close()
-	conn := conn.NewConn(nconn)
 
-	desc := doDescribe(t, conn)
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
 
-	inTH := &headers.Transport{
-		Protocol:    headers.TransportProtocolUDP,
-		Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
-		Mode:        transportModePtr(headers.TransportModePlay),
-		ClientPorts: &[2]int{30450, 30451},
-	}
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+	require.Equal(t, 8011, sd.Medias[0].RTCPPort)
+	require.Equal(t, "224.1.0.200", sd.Medias[0].RTCPAddress)
 
-	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+	res, err := c.Setup(sd.BaseURL, sd.Medias[0], 0, 0)
+	require.NoError(t, err)
 
-	session := readSession(t, res)
+	var th headers.Transport
+	err = th.Unmarshal(res.Header["Transport"])
+	require.NoError(t, err)
+	require.Equal(t, &[2]int{8000, 8011}, th.Ports)
 
-	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
-	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	packetRecv := make(chan struct{})
+
+	c.OnPacketRTCP(sd.Medias[0], func(pkt rtcp.Packet) {
+		require.Equal(t, &testRTCPPacket, pkt)
+		close(packetRecv)
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	err = stream.WritePacketRTCP(media, &testRTCPPacket)
+	require.NoError(t, err)
+
+	<-packetRecv
 }
 
-func TestServerPlayPlayPausePlay(t *testing.T) {
+func TestServerPlayRange(t *testing.T) {
 	var stream *ServerStream
-	writerStarted := false
-	writerDone := make(chan struct{})
-	writerTerminate := make(chan struct{})
+	var ranges []*headers.Range
 
 	s := &Server{
 		Handler: &testServerHandler{
-			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
-				close(writerTerminate)
-				<-writerDone
-			},
 			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
@@ -1310,33 +1468,33 @@ func TestServerPlayPlayPausePlay(t *testing.T) {
 				}, stream, nil
 			},
 			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
-				if !writerStarted {
-					writerStarted = true
-					go func() {
-						defer close(writerDone)
+				ranges = append(ranges, ctx.Range)
 
-						ti := time.NewTicker(50 * time.Millisecond)
-						defer ti.Stop()
+				if ctx.Range == nil || ctx.Range.Value.(*headers.RangeNPT).Now {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil
+				}
 
-						for {
-							select {
-							case <-ti.C:
-								err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
-								require.NoError(t, err)
-							case <-writerTerminate:
-								return
-							}
-						}
-					}()
+				// seek to the nearest keyframe
+				applied := &headers.Range{
+					Value: &headers.RangeNPT{
+						Start: ctx.Range.Value.(*headers.RangeNPT).Start - 500*time.Millisecond,
+					},
 				}
 
+				rtpInfo := headers.RTPInfo{{
+					URL:            "rtsp://localhost:8554/teststream/trackID=0",
+					SequenceNumber: uint16Ptr(123),
+					Timestamp:      uint32Ptr(456),
+				}}
+
 				return &base.Response{
 					StatusCode: base.StatusOK,
-				}, nil
-			},
-			onPause: func(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
-				return &base.Response{
-					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Range":    applied.Marshal(),
+						"RTP-Info": rtpInfo.Marshal(),
+					},
 				}, nil
 			},
 		},
@@ -1355,32 +1513,322 @@ func TestServerPlayPlayPausePlay(t *testing.T) {
 	defer nconn.Close()
 	conn := conn.NewConn(nconn)
 
-	desc := doDescribe(t, conn)
-
 	inTH := &headers.Transport{
-		Protocol:       headers.TransportProtocolTCP,
-		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
 		Mode:           transportModePtr(headers.TransportModePlay),
+		Protocol:       headers.TransportProtocolTCP,
 		InterleavedIDs: &[2]int{0, 1},
 	}
 
-	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+	res, _ := doSetup(t, conn, "rtsp://localhost:8554/teststream/"+relativeControlAttribute(doDescribe(t, conn).MediaDescriptions[0]), inTH, "")
 
 	session := readSession(t, res)
 
-	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
-	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
-	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
-}
-
-func TestServerPlayPlayPausePause(t *testing.T) {
-	var stream *ServerStream
-	writerDone := make(chan struct{})
-	writerTerminate := make(chan struct{})
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Play,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"3"},
+			"Session": base.HeaderValue{session},
+			"Range":   base.HeaderValue{"npt=10-"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, base.HeaderValue{"npt=9.5-"}, res.Header["Range"])
+	require.Equal(t, base.HeaderValue{"url=rtsp://localhost:8554/teststream/trackID=0;seq=123;rtptime=456"},
+		res.Header["RTP-Info"])
 
-	s := &Server{
-		Handler: &testServerHandler{
-			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+	// seek while playing
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Play,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"4"},
+			"Session": base.HeaderValue{session},
+			"Range":   base.HeaderValue{"npt=20-"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, base.HeaderValue{"npt=19.5-"}, res.Header["Range"])
+
+	// live position
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Play,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"5"},
+			"Session": base.HeaderValue{session},
+			"Range":   base.HeaderValue{"npt=now-"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	// invalid ranges are ignored
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Play,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"6"},
+			"Session": base.HeaderValue{session},
+			"Range":   base.HeaderValue{"invalid"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	require.Equal(t, []*headers.Range{
+		{Value: &headers.RangeNPT{Start: 10 * time.Second}},
+		{Value: &headers.RangeNPT{Start: 20 * time.Second}},
+		{Value: &headers.RangeNPT{Now: true}},
+		nil,
+	}, ranges)
+}
+
+func TestServerPlayScaleSpeed(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				require.Equal(t, &headers.Scale{Value: 4}, ctx.Scale)
+				require.Equal(t, &headers.Speed{Value: 1, Max: float64Ptr(2)}, ctx.Speed)
+
+				// the maximum supported scale is 2
+				return &base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Scale": headers.Scale{Value: 2}.Marshal(),
+					},
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+		Scale:     &headers.Scale{Value: 4},
+		Speed:     &headers.Speed{Value: 1, Max: float64Ptr(2)},
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	res, err := c.Play(nil)
+	require.NoError(t, err)
+
+	var scale headers.Scale
+	err = scale.Unmarshal(res.Header["Scale"])
+	require.NoError(t, err)
+	require.Equal(t, headers.Scale{Value: 2}, scale)
+}
+
+func TestServerPlayMulticastConfig(t *testing.T) {
+	var stream *ServerStream
+	listenIP := multicastCapableIP(t)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress:       listenIP + ":8554",
+		MulticastIPRange:  "224.1.0.0/16",
+		MulticastRTPPort:  8000,
+		MulticastRTCPPort: 8001,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medias := []*description.Media{
+		testH264Media,
+		{
+			Type:    description.MediaTypeAudio,
+			Formats: []format.Format{&format.G711{}},
+		},
+	}
+
+	stream = NewServerStream(s, &description.Session{Medias: medias})
+	defer stream.Close()
+
+	err = stream.SetMulticastConfig(ServerStreamMulticastConfig{IPRange: "192.168.0.0/24"})
+	require.EqualError(t, err, "'192.168.0.0/24' is not a IPv4 multicast range")
+
+	err = stream.SetMulticastConfig(ServerStreamMulticastConfig{IPRange: "239.2.0.0/31"})
+	require.EqualError(t, err, "range '239.2.0.0/31' is too small for 2 medias")
+
+	err = stream.SetMulticastConfig(ServerStreamMulticastConfig{
+		IPRange:      "239.2.0.0/24",
+		TTL:          4,
+		SourceEnable: true,
+	})
+	require.NoError(t, err)
+
+	c := Client{
+		Transport: transportPtr(TransportUDPMulticast),
+	}
+
+	u, err := base.ParseURL("rtsp://" + listenIP + ":8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	for i, medi := range sd.Medias {
+		res, err2 := c.Setup(sd.BaseURL, medi, 0, 0)
+		require.NoError(t, err2)
+
+		var th headers.Transport
+		err2 = th.Unmarshal(res.Header["Transport"])
+		require.NoError(t, err2)
+		require.Equal(t, net.IPv4(239, 2, 0, byte(i+1)).To4(), th.Destination.To4())
+		require.Equal(t, uint(4), *th.TTL)
+		require.Equal(t, net.ParseIP(listenIP).To4(), th.Source.To4())
+	}
+
+	packetRecv := make(chan struct{})
+
+	c.OnPacketRTCP(sd.Medias[1], func(pkt rtcp.Packet) {
+		require.Equal(t, &testRTCPPacket, pkt)
+		close(packetRecv)
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	err = stream.WritePacketRTCP(medias[1], &testRTCPPacket)
+	require.NoError(t, err)
+
+	<-packetRecv
+}
+
+func TestServerPlayMulticastBroadcast(t *testing.T) {
+	listenIP := multicastCapableIP(t)
+
+	s := &Server{
+		Handler:           &testServerHandler{},
+		RTSPAddress:       listenIP + ":8554",
+		MulticastIPRange:  "224.1.0.0/16",
+		MulticastRTPPort:  8000,
+		MulticastRTCPPort: 8001,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medias := []*description.Media{
+		testH264Media,
+		{
+			Type:    description.MediaTypeAudio,
+			Formats: []format.Format{&format.G711{}},
+		},
+	}
+
+	stream := NewServerStream(s, &description.Session{Medias: medias})
+	defer stream.Close()
+
+	err = stream.SetMulticastConfig(ServerStreamMulticastConfig{
+		IPRange: "239.2.0.0/24",
+		TTL:     4,
+	})
+	require.NoError(t, err)
+
+	_, err = stream.MulticastSDP()
+	require.EqualError(t, err, "stream is not sent with UDP-multicast")
+
+	err = stream.StartMulticast()
+	require.NoError(t, err)
+
+	err = stream.StartMulticast()
+	require.EqualError(t, err, "stream is already broadcasted")
+
+	err = stream.SetMulticastConfig(ServerStreamMulticastConfig{})
+	require.EqualError(t, err, "multicast configuration can't be changed while the stream is broadcasted")
+
+	byts, err := stream.MulticastSDP()
+	require.NoError(t, err)
+	require.Equal(t, "v=0\r\n"+
+		"o=- 0 0 IN IP4 127.0.0.1\r\n"+
+		"s= \r\n"+
+		"c=IN IP4 239.2.0.1/4\r\n"+
+		"t=0 0\r\n"+
+		"m=video 8000 RTP/AVP 96\r\n"+
+		"c=IN IP4 239.2.0.1/4\r\n"+
+		"a=rtpmap:96 H264/90000\r\n"+
+		"a=fmtp:96 packetization-mode=1; profile-level-id=42C028; "+
+		"sprop-parameter-sets=Z0LAKNkAeAIn5YQAAAMABAAAAwDwPGDJIA==,RAHAJS8FMkA=\r\n"+
+		"m=audio 8000 RTP/AVP 8\r\n"+
+		"c=IN IP4 239.2.0.2/4\r\n"+
+		"a=rtpmap:8 PCMA/8000\r\n",
+		string(byts))
+
+	err = stream.WritePacketRTP(medias[0], &testRTPPacket)
+	require.NoError(t, err)
+
+	stream.StopMulticast()
+
+	_, err = stream.MulticastSDP()
+	require.EqualError(t, err, "stream is not sent with UDP-multicast")
+}
+
+func TestServerPlayTCPResponseBeforeFrames(t *testing.T) {
+	var stream *ServerStream
+	writerDone := make(chan struct{})
+	writerTerminate := make(chan struct{})
+
+	s := &Server{
+		RTSPAddress: "localhost:8554",
+		Handler: &testServerHandler{
+			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
 				close(writerTerminate)
 				<-writerDone
 			},
@@ -1398,6 +1846,9 @@ func TestServerPlayPlayPausePause(t *testing.T) {
 				go func() {
 					defer close(writerDone)
 
+					err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
+					require.NoError(t, err)
+
 					ti := time.NewTicker(50 * time.Millisecond)
 					defer ti.Stop()
 
@@ -1412,11 +1863,1961 @@ func TestServerPlayPlayPausePause(t *testing.T) {
 					}
 				}()
 
+				time.Sleep(50 * time.Millisecond)
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:           transportModePtr(headers.TransportModePlay),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	_, err = conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+}
+
+func TestServerPlayPlayPlay(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		UDPRTPAddress:  "127.0.0.1:8000",
+		UDPRTCPAddress: "127.0.0.1:8001",
+		RTSPAddress:    "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Protocol:    headers.TransportProtocolUDP,
+		Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:        transportModePtr(headers.TransportModePlay),
+		ClientPorts: &[2]int{30450, 30451},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+}
+
+func TestServerPlayPlayPausePlay(t *testing.T) {
+	var stream *ServerStream
+	writerStarted := false
+	writerDone := make(chan struct{})
+	writerTerminate := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+				close(writerTerminate)
+				<-writerDone
+			},
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				if !writerStarted {
+					writerStarted = true
+					go func() {
+						defer close(writerDone)
+
+						ti := time.NewTicker(50 * time.Millisecond)
+						defer ti.Stop()
+
+						for {
+							select {
+							case <-ti.C:
+								err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
+								require.NoError(t, err)
+							case <-writerTerminate:
+								return
+							}
+						}
+					}()
+				}
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPause: func(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:           transportModePtr(headers.TransportModePlay),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+}
+
+func TestServerPlayPlayPausePause(t *testing.T) {
+	var stream *ServerStream
+	writerDone := make(chan struct{})
+	writerTerminate := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+				close(writerTerminate)
+				<-writerDone
+			},
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				go func() {
+					defer close(writerDone)
+
+					ti := time.NewTicker(50 * time.Millisecond)
+					defer ti.Stop()
+
+					for {
+						select {
+						case <-ti.C:
+							err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
+							require.NoError(t, err)
+						case <-writerTerminate:
+							return
+						}
+					}
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPause: func(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:           transportModePtr(headers.TransportModePlay),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
+}
+
+func TestServerPlayPauseMedia(t *testing.T) {
+	var stream *ServerStream
+	var pausedMedia *description.Media
+	var resumedMedia *description.Media
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				require.Equal(t, "/teststream", ctx.Path)
+				resumedMedia = ctx.Media
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPause: func(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+				require.Equal(t, "/teststream", ctx.Path)
+				pausedMedia = ctx.Media
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medias := []*description.Media{
+		testH264Media,
+		{
+			Type:    description.MediaTypeAudio,
+			Formats: []format.Format{&format.G711{}},
+		},
+	}
+
+	stream = NewServerStream(s, &description.Session{Medias: medias})
+	defer stream.Close()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan int, 10)
+
+	for i, medi := range sd.Medias {
+		ci := i
+		c.OnPacketRTP(medi, medi.Formats[0], func(_ *rtp.Packet) {
+			recv <- ci
+		})
+	}
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+	require.Nil(t, resumedMedia)
+
+	_, err = c.PauseMedia(sd.Medias[1])
+	require.NoError(t, err)
+	require.Equal(t, medias[1], pausedMedia)
+
+	audioPacket := rtp.Packet{
+		Header: rtp.Header{
+			Version:     2,
+			PayloadType: medias[1].Formats[0].PayloadType(),
+			SSRC:        0x38F27A2F,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}
+
+	// packets of the paused media are not sent, therefore
+	// the first received packet belongs to the other media.
+	err = stream.WritePacketRTP(medias[1], &audioPacket)
+	require.NoError(t, err)
+
+	err = stream.WritePacketRTP(medias[0], &testRTPPacket)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, <-recv)
+
+	res, err := c.ResumeMedia(sd.Medias[1])
+	require.NoError(t, err)
+	require.Equal(t, medias[1], resumedMedia)
+
+	var ri headers.RTPInfo
+	err = ri.Unmarshal(res.Header["RTP-Info"])
+	require.NoError(t, err)
+	require.Len(t, ri, 1)
+	require.Equal(t, "rtsp://localhost:8554/teststream/trackID=1", ri[0].URL)
+
+	err = stream.WritePacketRTP(medias[1], &audioPacket)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, <-recv)
+}
+
+func TestServerPlayTimeout(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"multicast",
+		// there's no timeout when reading with TCP
+	} {
+		t.Run(transport, func(t *testing.T) {
+			var stream *ServerStream
+			sessionClosed := make(chan struct{})
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+						close(sessionClosed)
+					},
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				ReadTimeout:       1 * time.Second,
+				SessionTimeout:    1 * time.Second,
+				RTSPAddress:       "localhost:8554",
+				checkStreamPeriod: 500 * time.Millisecond,
+			}
+
+			switch transport {
+			case "udp":
+				s.UDPRTPAddress = "127.0.0.1:8000"
+				s.UDPRTCPAddress = "127.0.0.1:8001"
+
+			case "multicast":
+				s.MulticastIPRange = "224.1.0.0/16"
+				s.MulticastRTPPort = 8000
+				s.MulticastRTCPPort = 8001
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+			defer stream.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(nconn)
+
+			desc := doDescribe(t, conn)
+
+			inTH := &headers.Transport{
+				Mode: transportModePtr(headers.TransportModePlay),
+			}
+
+			switch transport {
+			case "udp":
+				v := headers.TransportDeliveryUnicast
+				inTH.Delivery = &v
+				inTH.Protocol = headers.TransportProtocolUDP
+				inTH.ClientPorts = &[2]int{35466, 35467}
+
+			case "multicast":
+				v := headers.TransportDeliveryMulticast
+				inTH.Delivery = &v
+				inTH.Protocol = headers.TransportProtocolUDP
+			}
+
+			res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+			session := readSession(t, res)
+
+			doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+			<-sessionClosed
+		})
+	}
+}
+
+func TestServerPlaySessionSetTimeout(t *testing.T) {
+	var stream *ServerStream
+	sessionClosed := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onSessionClose: func(_ *ServerHandlerOnSessionCloseCtx) {
+				close(sessionClosed)
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				ctx.Session.SetTimeout(1 * time.Second)
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress:       "localhost:8554",
+		UDPRTPAddress:     "127.0.0.1:8000",
+		UDPRTCPAddress:    "127.0.0.1:8001",
+		checkStreamPeriod: 500 * time.Millisecond,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	v := headers.TransportDeliveryUnicast
+	inTH := &headers.Transport{
+		Mode:        transportModePtr(headers.TransportModePlay),
+		Delivery:    &v,
+		Protocol:    headers.TransportProtocolUDP,
+		ClientPorts: &[2]int{35466, 35467},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	var sx headers.Session
+	err = sx.Unmarshal(res.Header["Session"])
+	require.NoError(t, err)
+	require.Equal(t, uint(1), *sx.Timeout)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", sx.Session)
+
+	select {
+	case <-sessionClosed:
+	case <-time.After(5 * time.Second):
+		t.Error("session was not closed")
+	}
+}
+
+func TestServerPlayLiveness(t *testing.T) {
+	for _, ca := range []string{
+		"no criteria",
+		"request timeout",
+		"predicate",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var stream *ServerStream
+			sessionClosed := make(chan struct{})
+
+			var liveness *ServerLivenessPolicy
+
+			switch ca {
+			case "no criteria":
+				liveness = &ServerLivenessPolicy{}
+
+			case "request timeout":
+				liveness = &ServerLivenessPolicy{
+					RequestTimeout: 300 * time.Millisecond,
+				}
+
+			case "predicate":
+				liveness = &ServerLivenessPolicy{
+					IsAlive: func(_ *ServerSession, act ServerSessionActivity) bool {
+						require.False(t, act.LastRequest.IsZero())
+						return false
+					},
+				}
+			}
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onSessionClose: func(_ *ServerHandlerOnSessionCloseCtx) {
+						close(sessionClosed)
+					},
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress:       "localhost:8554",
+				UDPRTPAddress:     "127.0.0.1:8000",
+				UDPRTCPAddress:    "127.0.0.1:8001",
+				SessionTimeout:    300 * time.Millisecond,
+				Liveness:          liveness,
+				checkStreamPeriod: 100 * time.Millisecond,
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+			defer stream.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(nconn)
+
+			desc := doDescribe(t, conn)
+
+			v := headers.TransportDeliveryUnicast
+			inTH := &headers.Transport{
+				Mode:        transportModePtr(headers.TransportModePlay),
+				Delivery:    &v,
+				Protocol:    headers.TransportProtocolUDP,
+				ClientPorts: &[2]int{35466, 35467},
+			}
+
+			res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+			session := readSession(t, res)
+
+			doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+			select {
+			case <-sessionClosed:
+				require.NotEqual(t, "no criteria", ca)
+			case <-time.After(1 * time.Second):
+				require.Equal(t, "no criteria", ca)
+			}
+		})
+	}
+}
+
+func TestServerPlayParameterSets(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	forma := &format.H264{
+		PayloadTyp:        96,
+		SPS:               testH264Media.Formats[0].(*format.H264).SPS,
+		PPS:               testH264Media.Formats[0].(*format.H264).PPS,
+		PacketizationMode: 1,
+	}
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{forma},
+	}}})
+	stream.InjectParameterSets = true
+	stream.AnnounceParameterSets = true
+	defer stream.Close()
+
+	recv := make(chan *rtp.Packet, 10)
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			recv <- pkt
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	clientFormat := c.lastMedias[0].Formats[0].(*format.H264)
+
+	newSPS := []byte{0x67, 0x64, 0x00, 0x28, 0xac, 0xb4, 0x03, 0xc0, 0x11, 0x3f, 0x2a}
+	newPPS := []byte{0x68, 0xee, 0x3c, 0x80}
+
+	for i, payload := range [][]byte{newSPS, newPPS, {0x65, 0x01}} {
+		err = stream.WritePacketRTP(stream.Description().Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: uint16(100 + i),
+				Timestamp:      1000,
+				SSRC:           0x38F27A2F,
+				Marker:         i == 2,
+			},
+			Payload: payload,
+		})
+		require.NoError(t, err)
+	}
+
+	sps, pps := forma.SafeParams()
+	require.Equal(t, newSPS, sps)
+	require.Equal(t, newPPS, pps)
+
+	for _, payload := range [][]byte{newSPS, newPPS, {0x65, 0x01}} {
+		pkt := <-recv
+		require.Equal(t, payload, pkt.Payload)
+	}
+
+	// parameter sets are injected before IDRs that are not preceded by them
+	err = stream.WritePacketRTP(stream.Description().Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 103,
+			Timestamp:      4000,
+			SSRC:           0x38F27A2F,
+			Marker:         true,
+		},
+		Payload: []byte{0x65, 0x02},
+	})
+	require.NoError(t, err)
+
+	pkt := <-recv
+	require.Equal(t, uint16(103), pkt.SequenceNumber)
+	require.Equal(t, append(append(append([]byte{0x78, 0x00, 0x0b}, newSPS...), 0x00, 0x04), newPPS...), pkt.Payload)
+
+	pkt = <-recv
+	require.Equal(t, uint16(104), pkt.SequenceNumber)
+	require.Equal(t, []byte{0x65, 0x02}, pkt.Payload)
+
+	// the client receives the updated description
+	for {
+		sps, pps = clientFormat.SafeParams()
+		if bytes.Equal(sps, newSPS) {
+			require.Equal(t, newPPS, pps)
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestServerPlayStreamGroup(t *testing.T) {
+	var group *ServerStreamGroup
+	sessions := make(chan *ServerSession, 1)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				stream, err := group.Stream(ctx.Request, ctx.Query)
+				if err != nil {
+					return &base.Response{
+						StatusCode: base.StatusNotFound,
+					}, nil, err
+				}
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				stream, err := group.Stream(ctx.Request, ctx.Query)
+				if err != nil {
+					return &base.Response{
+						StatusCode: base.StatusNotFound,
+					}, nil, err
+				}
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				sessions <- ctx.Session
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	highSPS := testH264Media.Formats[0].(*format.H264).SPS
+	highPPS := testH264Media.Formats[0].(*format.H264).PPS
+	lowSPS := []byte{0x67, 0x64, 0x00, 0x28, 0xac, 0xb4, 0x03, 0xc0, 0x11, 0x3f, 0x2a}
+	lowPPS := []byte{0x68, 0xee, 0x3c, 0x80}
+
+	newRendition := func(sps []byte, pps []byte) *ServerStream {
+		return NewServerStream(s, &description.Session{Medias: []*description.Media{{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				SPS:               sps,
+				PPS:               pps,
+				PacketizationMode: 1,
+			}},
+		}}})
+	}
+
+	high := newRendition(highSPS, highPPS)
+	defer high.Close()
+
+	low := newRendition(lowSPS, lowPPS)
+	defer low.Close()
+
+	group = &ServerStreamGroup{
+		Renditions: map[string]*ServerStream{
+			"high": high,
+			"low":  low,
+		},
+		DefaultRendition: "high",
+	}
+	err = group.Initialize()
+	require.NoError(t, err)
+
+	keyframeRequested := make(chan struct{}, 1)
+	high.OnKeyframeRequest(func(_ *description.Media) {
+		select {
+		case keyframeRequested <- struct{}{}:
+		default:
+		}
+	})
+
+	recv := make(chan *rtp.Packet, 10)
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream?rendition=low",
+		func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			recv <- pkt
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	ss := <-sessions
+
+	rendition, ok := group.Rendition(ss)
+	require.True(t, ok)
+	require.Equal(t, "low", rendition)
+
+	ssrcs := map[*ServerStream]uint32{
+		high: 0x38F27A2F,
+		low:  0x7A2F38F2,
+	}
+
+	writePacket := func(st *ServerStream, seqNum uint16, timestamp uint32, payload []byte) {
+		err2 := st.WritePacketRTP(st.Description().Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: seqNum,
+				Timestamp:      timestamp,
+				SSRC:           ssrcs[st],
+				Marker:         true,
+			},
+			Payload: payload,
+		})
+		require.NoError(t, err2)
+	}
+
+	writePacket(low, 100, 1000, []byte{0x65, 0x01})
+
+	pkt := <-recv
+	require.Equal(t, uint16(100), pkt.SequenceNumber)
+	require.Equal(t, ssrcs[low], pkt.SSRC)
+	ssrc := pkt.SSRC
+
+	err = group.SwitchReader(ss, "high")
+	require.NoError(t, err)
+
+	<-keyframeRequested
+
+	// packets of the new rendition are sent from the first keyframe
+	writePacket(high, 500, 90000, []byte{0x01, 0x01})
+	writePacket(low, 101, 4000, []byte{0x01, 0x02})
+
+	pkt = <-recv
+	require.Equal(t, uint16(101), pkt.SequenceNumber)
+	require.Equal(t, []byte{0x01, 0x02}, pkt.Payload)
+
+	writePacket(high, 501, 93000, []byte{0x65, 0x03})
+
+	// parameter sets of the new rendition are sent before the keyframe
+	pkt = <-recv
+	require.Equal(t, uint16(102), pkt.SequenceNumber)
+	require.Equal(t, ssrc, pkt.SSRC)
+	require.Equal(t, append(append(append([]byte{0x78, 0x00, byte(len(highSPS))}, highSPS...),
+		0x00, byte(len(highPPS))), highPPS...), pkt.Payload)
+	timestamp := pkt.Timestamp
+
+	pkt = <-recv
+	require.Equal(t, uint16(103), pkt.SequenceNumber)
+	require.Equal(t, ssrc, pkt.SSRC)
+	require.Equal(t, timestamp, pkt.Timestamp)
+	require.Equal(t, []byte{0x65, 0x03}, pkt.Payload)
+
+	// packets of the previous rendition are not sent anymore
+	writePacket(low, 102, 7000, []byte{0x01, 0x04})
+	writePacket(high, 502, 96000, []byte{0x01, 0x05})
+
+	pkt = <-recv
+	require.Equal(t, uint16(104), pkt.SequenceNumber)
+	require.Equal(t, ssrc, pkt.SSRC)
+	require.Equal(t, timestamp+3000, pkt.Timestamp)
+	require.Equal(t, []byte{0x01, 0x05}, pkt.Payload)
+
+	for {
+		rendition, _ = group.Rendition(ss)
+		if rendition == "high" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writePacket(high, 503, 99000, []byte{0x01, 0x06})
+
+	pkt = <-recv
+	require.Equal(t, uint16(105), pkt.SequenceNumber)
+	require.Equal(t, ssrc, pkt.SSRC)
+	require.Equal(t, timestamp+6000, pkt.Timestamp)
+
+	err = group.SwitchReader(ss, "medium")
+	require.Equal(t, liberrors.ErrServerRenditionNotFound{Name: "medium"}, err)
+}
+
+func TestServerPlayWithoutTeardown(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(transport, func(t *testing.T) {
+			var stream *ServerStream
+			nconnClosed := make(chan struct{})
+			sessionClosed := make(chan struct{})
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+						close(nconnClosed)
+					},
+					onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+						close(sessionClosed)
+					},
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				ReadTimeout:    1 * time.Second,
+				SessionTimeout: 1 * time.Second,
+				RTSPAddress:    "localhost:8554",
+			}
+
+			if transport == "udp" {
+				s.UDPRTPAddress = "127.0.0.1:8000"
+				s.UDPRTCPAddress = "127.0.0.1:8001"
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+			defer stream.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(nconn)
+
+			desc := doDescribe(t, conn)
+
+			inTH := &headers.Transport{
+				Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+				Mode:     transportModePtr(headers.TransportModePlay),
+			}
+
+			if transport == "udp" {
+				inTH.Protocol = headers.TransportProtocolUDP
+				inTH.ClientPorts = &[2]int{35466, 35467}
+			} else {
+				inTH.Protocol = headers.TransportProtocolTCP
+				inTH.InterleavedIDs = &[2]int{0, 1}
+			}
+
+			res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+			session := readSession(t, res)
+
+			doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+			nconn.Close()
+
+			<-sessionClosed
+			<-nconnClosed
+		})
+	}
+}
+
+func TestServerPlayUDPChangeConn(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onGetParameter: func(ctx *ServerHandlerOnGetParameterCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		UDPRTPAddress:  "127.0.0.1:8000",
+		UDPRTCPAddress: "127.0.0.1:8001",
+		RTSPAddress:    "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	sxID := ""
+
+	func() {
+		nconn, err := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		desc := doDescribe(t, conn)
+
+		inTH := &headers.Transport{
+			Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+			Mode:        transportModePtr(headers.TransportModePlay),
+			Protocol:    headers.TransportProtocolUDP,
+			ClientPorts: &[2]int{35466, 35467},
+		}
+
+		res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+		session := readSession(t, res)
+
+		doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+		sxID = session
+	}()
+
+	func() {
+		nconn, err := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		res, err := writeReqReadRes(conn, base.Request{
+			Method: base.GetParameter,
+			URL:    mustParseURL("rtsp://localhost:8554/teststream/"),
+			Header: base.Header{
+				"CSeq":    base.HeaderValue{"4"},
+				"Session": base.HeaderValue{sxID},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, base.StatusOK, res.StatusCode)
+	}()
+}
+
+func TestServerPlayPartialMedias(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				go func() {
+					time.Sleep(500 * time.Millisecond)
+					err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
+					require.NoError(t, err)
+					err = stream.WritePacketRTP(stream.Description().Medias[1], &testRTPPacket)
+					require.NoError(t, err)
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media, testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:           transportModePtr(headers.TransportModePlay),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{4, 5},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 4, f.Channel)
+	require.Equal(t, testRTPPacketMarshaled, f.Payload)
+}
+
+func TestServerPlayKeyframeTimeout(t *testing.T) {
+	for _, ca := range []string{"keyframe", "timeout"} {
+		t.Run(ca, func(t *testing.T) {
+			var stream *ServerStream
+			keyframeReq := make(chan *description.Media, 1)
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onKeyframeReq: func(ctx *ServerHandlerOnKeyframeRequestCtx) {
+						require.Equal(t, stream, ctx.Stream)
+						keyframeReq <- ctx.Media
+					},
+				},
+				RTSPAddress: "localhost:8554",
+				KeyframeTimeout: func() time.Duration {
+					if ca == "keyframe" {
+						return 10 * time.Second
+					}
+					return 200 * time.Millisecond
+				}(),
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+			defer stream.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(nconn)
+
+			desc := doDescribe(t, conn)
+
+			inTH := &headers.Transport{
+				Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+				Mode:           transportModePtr(headers.TransportModePlay),
+				Protocol:       headers.TransportProtocolTCP,
+				InterleavedIDs: &[2]int{0, 1},
+			}
+
+			res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+			session := readSession(t, res)
+
+			doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+			require.Equal(t, stream.Description().Medias[0], <-keyframeReq)
+
+			nonIDR := func(seqNum uint16) *rtp.Packet {
+				return &rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: seqNum,
+						SSRC:           0x38F27A2F,
+					},
+					Payload: []byte{0x01, 0x02},
+				}
+			}
+
+			err = stream.WritePacketRTP(stream.Description().Medias[0], nonIDR(1))
+			require.NoError(t, err)
+
+			var expected *rtp.Packet
+
+			if ca == "keyframe" {
+				expected = &rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: 2,
+						SSRC:           0x38F27A2F,
+					},
+					Payload: []byte{0x05, 0x02},
+				}
+			} else {
+				time.Sleep(300 * time.Millisecond)
+				expected = nonIDR(2)
+			}
+
+			err = stream.WritePacketRTP(stream.Description().Medias[0], expected)
+			require.NoError(t, err)
+
+			err = stream.WritePacketRTP(stream.Description().Medias[0], nonIDR(3))
+			require.NoError(t, err)
+
+			for _, pkt := range []*rtp.Packet{expected, nonIDR(3)} {
+				f, err := conn.ReadInterleavedFrame()
+				require.NoError(t, err)
+				require.Equal(t, 0, f.Channel)
+				require.Equal(t, mustMarshalPacketRTP(pkt), f.Payload)
+			}
+		})
+	}
+}
+
+func TestServerPlayMaxSessionBitrate(t *testing.T) {
+	var stream *ServerStream
+	throttled := make(chan *ServerHandlerOnThrottleCtx, 1)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onThrottle: func(ctx *ServerHandlerOnThrottleCtx) {
+				throttled <- ctx
+			},
+		},
+		RTSPAddress:       "localhost:8554",
+		MaxSessionBitrate: 100 * 8,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:           transportModePtr(headers.TransportModePlay),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	// packets of non-reference frames are discarded when the bucket is less than half full
+	for i := 0; i < 10; i++ {
+		pkt := testRTPPacket
+		pkt.Marker = true
+		err = stream.WritePacketRTP(stream.Description().Medias[0], &pkt)
+		require.NoError(t, err)
+	}
+
+	ctx := <-throttled
+	require.Equal(t, true, ctx.Throttled)
+	require.Equal(t, uint64(16*4), stream.BytesSent())
+
+	for i := 0; i < 4; i++ {
+		f, err := conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+
+		var pkt rtp.Packet
+		err = pkt.Unmarshal(f.Payload)
+		require.NoError(t, err)
+		require.Equal(t, testRTPPacket.Payload, pkt.Payload)
+	}
+}
+
+func TestServerPlayAV1Layers(t *testing.T) {
+	var stream *ServerStream
+	nackReceived := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				maxSpatialID := uint8(0)
+				err := ctx.Session.SetAV1Layers(stream.Description().Medias[0], nil, &maxSpatialID)
+				require.NoError(t, err)
+
+				ctx.Session.OnPacketRTCP(stream.Description().Medias[0], func(pkt rtcp.Packet) {
+					if _, ok := pkt.(*rtcp.TransportLayerNack); ok {
+						close(nackReceived)
+					}
+				})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medi := &description.Media{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{
+			&format.AV1{PayloadTyp: 96},
+			&format.RTX{
+				PayloadTyp: 97,
+				ClockRat:   90000,
+				APT:        96,
+			},
+		},
+	}
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{medi}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:           transportModePtr(headers.TransportModePlay),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	baseOBU := []byte{0x34, 0x00, 0x01, 0x02}
+	enhancementOBU := []byte{0x34, 0x08, 0x03, 0x04}
+
+	err = stream.WritePacketRTP(stream.Description().Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           753621,
+		},
+		Payload: append(append([]byte{0x00, 0x04}, baseOBU...), append([]byte{0x04}, enhancementOBU...)...),
+	})
+	require.NoError(t, err)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+
+	var pkt rtp.Packet
+	err = pkt.Unmarshal(f.Payload)
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{0x00, 0x04}, baseOBU...), pkt.Payload)
+	require.Equal(t, uint16(123), pkt.SequenceNumber)
+	require.Equal(t, true, pkt.Marker)
+
+	// sequence numbers are rewritten, therefore NACKs must not cause retransmissions
+	err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: 1,
+		Payload: mustMarshalPacketRTCP(&rtcp.TransportLayerNack{
+			MediaSSRC: 753621,
+			Nacks:     []rtcp.NackPair{{PacketID: 123}},
+		}),
+	}, make([]byte, 1024))
+	require.NoError(t, err)
+
+	<-nackReceived
+
+	err = stream.WritePacketRTP(stream.Description().Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 124,
+			Timestamp:      45343,
+			SSRC:           753621,
+		},
+		Payload: append([]byte{0x00, 0x04}, baseOBU...),
+	})
+	require.NoError(t, err)
+
+	f, err = conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+
+	err = pkt.Unmarshal(f.Payload)
+	require.NoError(t, err)
+	require.Equal(t, uint8(96), pkt.PayloadType)
+	require.Equal(t, uint16(124), pkt.SequenceNumber)
+}
+
+func TestServerPlayAdditionalInfos(t *testing.T) {
+	getInfos := func() (*headers.RTPInfo, []*uint32) {
+		nconn, err := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		desc := doDescribe(t, conn)
+
+		inTH := &headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Mode:           transportModePtr(headers.TransportModePlay),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: &[2]int{0, 1},
+		}
+
+		res, th := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+		ssrcs := make([]*uint32, 2)
+		ssrcs[0] = th.SSRC
+
+		inTH = &headers.Transport{
+			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+			Mode:           transportModePtr(headers.TransportModePlay),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: &[2]int{2, 3},
+		}
+
+		session := readSession(t, res)
+
+		_, th = doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[1]), inTH, session)
+
+		ssrcs[1] = th.SSRC
+
+		res = doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+		var ri headers.RTPInfo
+		err = ri.Unmarshal(res.Header["RTP-Info"])
+		require.NoError(t, err)
+
+		return &ri, ssrcs
+	}
+
+	forma := &format.Generic{
+		PayloadTyp: 96,
+		RTPMa:      "private/90000",
+	}
+	err := forma.Init()
+	require.NoError(t, err)
+
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{
+		Medias: []*description.Media{
+			{
+				Type:    "application",
+				Formats: []format.Format{forma},
+			},
+			{
+				Type:    "application",
+				Formats: []format.Format{forma},
+			},
+		},
+	})
+	defer stream.Close()
+
+	err = stream.WritePacketRTP(stream.Description().Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 556,
+			Timestamp:      984512368,
+			SSRC:           96342362,
+		},
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	})
+	require.NoError(t, err)
+
+	rtpInfo, ssrcs := getInfos()
+	require.True(t, strings.HasPrefix(mustParseURL((*rtpInfo)[0].URL).Path, "/teststream/trackID="))
+	require.Equal(t, &headers.RTPInfo{
+		&headers.RTPInfoEntry{
+			URL: (&base.URL{
+				Scheme: "rtsp",
+				Host:   "localhost:8554",
+				Path:   mustParseURL((*rtpInfo)[0].URL).Path,
+			}).String(),
+			SequenceNumber: uint16Ptr(557),
+			Timestamp:      (*rtpInfo)[0].Timestamp,
+			SSRC:           uint32Ptr(96342362),
+		},
+	}, rtpInfo)
+	require.Equal(t, []*uint32{
+		uint32Ptr(96342362),
+		nil,
+	}, ssrcs)
+
+	err = stream.WritePacketRTP(stream.Description().Medias[1], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 87,
+			Timestamp:      756436454,
+			SSRC:           536474323,
+		},
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	})
+	require.NoError(t, err)
+
+	rtpInfo, ssrcs = getInfos()
+	require.True(t, strings.HasPrefix(mustParseURL((*rtpInfo)[0].URL).Path, "/teststream/trackID="))
+	require.Equal(t, &headers.RTPInfo{
+		&headers.RTPInfoEntry{
+			URL: (&base.URL{
+				Scheme: "rtsp",
+				Host:   "localhost:8554",
+				Path:   mustParseURL((*rtpInfo)[0].URL).Path,
+			}).String(),
+			SequenceNumber: uint16Ptr(557),
+			Timestamp:      (*rtpInfo)[0].Timestamp,
+			SSRC:           uint32Ptr(96342362),
+		},
+		&headers.RTPInfoEntry{
+			URL: (&base.URL{
+				Scheme: "rtsp",
+				Host:   "localhost:8554",
+				Path:   mustParseURL((*rtpInfo)[1].URL).Path,
+			}).String(),
+			SequenceNumber: uint16Ptr(88),
+			Timestamp:      (*rtpInfo)[1].Timestamp,
+			SSRC:           uint32Ptr(536474323),
+		},
+	}, rtpInfo)
+	require.Equal(t, []*uint32{
+		uint32Ptr(96342362),
+		uint32Ptr(536474323),
+	}, ssrcs)
+}
+
+func TestServerPlayNoInterleavedIDs(t *testing.T) {
+	forma := &format.Generic{
+		PayloadTyp: 96,
+		RTPMa:      "private/90000",
+	}
+	err := forma.Init()
+	require.NoError(t, err)
+
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{
+		Medias: []*description.Media{
+			{
+				Type:    "application",
+				Formats: []format.Format{forma},
+			},
+			{
+				Type:    "application",
+				Formats: []format.Format{forma},
+			},
+		},
+	})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:     transportModePtr(headers.TransportModePlay),
+		Protocol: headers.TransportProtocolTCP,
+	}
+
+	res, th := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	require.Equal(t, &[2]int{0, 1}, th.InterleavedIDs)
+
+	session := readSession(t, res)
+
+	_, th = doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[1]), inTH, session)
+
+	require.Equal(t, &[2]int{2, 3}, th.InterleavedIDs)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	for i := 0; i < 2; i++ {
+		err := stream.WritePacketRTP(stream.Description().Medias[i], &testRTPPacket)
+		require.NoError(t, err)
+
+		f, err := conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+		require.Equal(t, i*2, f.Channel)
+		require.Equal(t, testRTPPacketMarshaled, f.Payload)
+	}
+}
+
+func TestServerPlayInterleavedFrames(t *testing.T) {
+	var stream *ServerStream
+	frameRecv := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				ctx.Session.OnInterleavedFrame(func(channel int, payload []byte) {
+					require.Equal(t, 10, channel)
+					require.Equal(t, []byte{5, 6, 7, 8}, payload)
+					close(frameRecv)
+				})
+
+				err := ctx.Session.WriteInterleavedFrame(1, []byte{1, 2, 3, 4})
+				require.Equal(t, liberrors.ErrServerInterleavedChannelInUse{Channel: 1}, err)
+
+				err = ctx.Session.WriteInterleavedFrame(12, []byte{1, 2, 3, 4})
+				require.NoError(t, err)
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:     transportModePtr(headers.TransportModePlay),
+		Protocol: headers.TransportProtocolTCP,
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 12, f.Channel)
+	require.Equal(t, []byte{1, 2, 3, 4}, f.Payload)
+
+	err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: 10,
+		Payload: []byte{5, 6, 7, 8},
+	}, make([]byte, 1024))
+	require.NoError(t, err)
+
+	<-frameRecv
+}
+
+func TestServerPlayTeardownGoodbye(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	desc := doDescribe(t, conn)
+
+	inTH := &headers.Transport{
+		Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:     transportModePtr(headers.TransportModePlay),
+		Protocol: headers.TransportProtocolTCP,
+	}
+
+	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	err = stream.WritePacketRTP(testH264Media, &rtp.Packet{
+		Header: rtp.Header{
+			Version:     2,
+			PayloadType: 96,
+			SSRC:        0x38F27A2F,
+		},
+		Payload: []byte{5, 1, 2, 3},
+	})
+	require.NoError(t, err)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 0, f.Channel)
+
+	ssrc, ok := stream.senderSSRC(testH264Media)
+	require.True(t, ok)
+
+	err = conn.WriteRequest(&base.Request{
+		Method: base.Teardown,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"1"},
+			"Session": base.HeaderValue{session},
+		},
+	})
+	require.NoError(t, err)
+
+	// BYE is sent before the response
+	what, err := conn.Read()
+	require.NoError(t, err)
+	f, ok = what.(*base.InterleavedFrame)
+	require.True(t, ok)
+	require.Equal(t, 1, f.Channel)
+
+	packets, err := rtcp.Unmarshal(f.Payload)
+	require.NoError(t, err)
+	require.Equal(t, []rtcp.Packet{&rtcp.Goodbye{
+		Sources: []uint32{ssrc},
+	}}, packets)
+
+	what, err = conn.Read()
+	require.NoError(t, err)
+	res, ok = what.(*base.Response)
+	require.True(t, ok)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+}
+
+func TestServerPlayRequestedInterleavedIDs(t *testing.T) {
+	forma := &format.Generic{
+		PayloadTyp: 96,
+		RTPMa:      "private/90000",
+	}
+	err := forma.Init()
+	require.NoError(t, err)
+
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
-				}, nil
+				}, stream, nil
 			},
-			onPause: func(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, nil
@@ -1425,11 +3826,26 @@ func TestServerPlayPlayPausePause(t *testing.T) {
 		RTSPAddress: "localhost:8554",
 	}
 
-	err := s.Start()
+	err = s.Start()
 	require.NoError(t, err)
 	defer s.Close()
 
-	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	stream = NewServerStream(s, &description.Session{
+		Medias: []*description.Media{
+			{
+				Type:    "application",
+				Formats: []format.Format{forma},
+			},
+			{
+				Type:    "application",
+				Formats: []format.Format{forma},
+			},
+			{
+				Type:    "application",
+				Formats: []format.Format{forma},
+			},
+		},
+	})
 	defer stream.Close()
 
 	nconn, err := net.Dial("tcp", "localhost:8554")
@@ -1440,69 +3856,151 @@ func TestServerPlayPlayPausePause(t *testing.T) {
 	desc := doDescribe(t, conn)
 
 	inTH := &headers.Transport{
-		Protocol:       headers.TransportProtocolTCP,
 		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
 		Mode:           transportModePtr(headers.TransportModePlay),
-		InterleavedIDs: &[2]int{0, 1},
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{6, 7},
 	}
 
-	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+	res, th := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+	require.Equal(t, &[2]int{6, 7}, th.InterleavedIDs)
 
 	session := readSession(t, res)
 
+	inTH.InterleavedIDs = &[2]int{2, 3}
+	_, th = doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[1]), inTH, session)
+	require.Equal(t, &[2]int{2, 3}, th.InterleavedIDs)
+
+	// channels in use are reassigned
+	inTH.InterleavedIDs = &[2]int{6, 7}
+	_, th = doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[2]), inTH, session)
+	require.Equal(t, &[2]int{0, 1}, th.InterleavedIDs)
+
 	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
 
-	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
+	for i, ch := range []int{6, 2, 0} {
+		err := stream.WritePacketRTP(stream.Description().Medias[i], &testRTPPacket)
+		require.NoError(t, err)
 
-	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
+		f, err := conn.ReadInterleavedFrame()
+		require.NoError(t, err)
+		require.Equal(t, ch, f.Channel)
+		require.Equal(t, testRTPPacketMarshaled, f.Payload)
+	}
 }
 
-func TestServerPlayTimeout(t *testing.T) {
-	for _, transport := range []string{
-		"udp",
-		"multicast",
-		// there's no timeout when reading with TCP
+func TestServerPlayBytesSent(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		RTSPAddress:       "localhost:8554",
+		MulticastIPRange:  "224.1.0.0/16",
+		MulticastRTPPort:  8000,
+		MulticastRTCPPort: 8001,
+		Handler: &testServerHandler{
+			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	for _, transport := range []string{"tcp", "multicast"} {
+		nconn, err := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
+
+		desc := doDescribe(t, conn)
+
+		inTH := &headers.Transport{
+			Mode: transportModePtr(headers.TransportModePlay),
+		}
+
+		if transport == "multicast" {
+			v := headers.TransportDeliveryMulticast
+			inTH.Delivery = &v
+			inTH.Protocol = headers.TransportProtocolUDP
+		} else {
+			v := headers.TransportDeliveryUnicast
+			inTH.Delivery = &v
+			inTH.Protocol = headers.TransportProtocolTCP
+			inTH.InterleavedIDs = &[2]int{0, 1}
+		}
+
+		res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+
+		session := readSession(t, res)
+
+		doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	}
+
+	err = stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(16*2), stream.BytesSent())
+}
+
+func TestServerPlayTunnel(t *testing.T) {
+	for _, ca := range []string{
+		"http",
+		"https",
+		"standard",
 	} {
-		t.Run(transport, func(t *testing.T) {
+		t.Run(ca, func(t *testing.T) {
 			var stream *ServerStream
-			sessionClosed := make(chan struct{})
+			connOpened := make(chan struct{}, 1)
 
 			s := &Server{
 				Handler: &testServerHandler{
-					onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
-						close(sessionClosed)
+					onConnOpen: func(_ *ServerHandlerOnConnOpenCtx) {
+						connOpened <- struct{}{}
 					},
-					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 						return &base.Response{
 							StatusCode: base.StatusOK,
 						}, stream, nil
 					},
-					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
 						return &base.Response{
 							StatusCode: base.StatusOK,
 						}, stream, nil
 					},
-					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+					onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
 						return &base.Response{
 							StatusCode: base.StatusOK,
 						}, nil
 					},
 				},
-				ReadTimeout:       1 * time.Second,
-				sessionTimeout:    1 * time.Second,
-				RTSPAddress:       "localhost:8554",
-				checkStreamPeriod: 500 * time.Millisecond,
+				RTSPAddress:  "localhost:8554",
+				TunnelEnable: true,
 			}
 
-			switch transport {
-			case "udp":
-				s.UDPRTPAddress = "127.0.0.1:8000"
-				s.UDPRTCPAddress = "127.0.0.1:8001"
+			scheme := "rtsp"
 
-			case "multicast":
-				s.MulticastIPRange = "224.1.0.0/16"
-				s.MulticastRTPPort = 8000
-				s.MulticastRTCPPort = 8001
+			if ca == "https" {
+				cert, err := tls.X509KeyPair(serverCert, serverKey)
+				require.NoError(t, err)
+				s.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+				scheme = "rtsps"
 			}
 
 			err := s.Start()
@@ -1512,233 +4010,559 @@ func TestServerPlayTimeout(t *testing.T) {
 			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
 			defer stream.Close()
 
-			nconn, err := net.Dial("tcp", "localhost:8554")
+			c := Client{
+				TLSConfig: &tls.Config{InsecureSkipVerify: true},
+				Transport: transportPtr(TransportTCP),
+			}
+
+			if ca != "standard" {
+				c.Tunnel = TunnelHTTP
+			}
+
+			u, err := base.ParseURL(scheme + "://localhost:8554/teststream")
 			require.NoError(t, err)
-			defer nconn.Close()
-			conn := conn.NewConn(nconn)
 
-			desc := doDescribe(t, conn)
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
 
-			inTH := &headers.Transport{
-				Mode: transportModePtr(headers.TransportModePlay),
-			}
+			sd, _, err := c.Describe(u)
+			require.NoError(t, err)
 
-			switch transport {
-			case "udp":
-				v := headers.TransportDeliveryUnicast
-				inTH.Delivery = &v
-				inTH.Protocol = headers.TransportProtocolUDP
-				inTH.ClientPorts = &[2]int{35466, 35467}
+			// a single connection is seen by the handler
+			<-connOpened
+			require.Len(t, connOpened, 0)
 
-			case "multicast":
-				v := headers.TransportDeliveryMulticast
-				inTH.Delivery = &v
-				inTH.Protocol = headers.TransportProtocolUDP
-			}
+			err = c.SetupAll(sd.BaseURL, sd.Medias)
+			require.NoError(t, err)
 
-			res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+			packetRecv := make(chan struct{})
 
-			session := readSession(t, res)
+			c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+				require.Equal(t, &testRTPPacket, pkt)
+				close(packetRecv)
+			})
 
-			doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+			_, err = c.Play(nil)
+			require.NoError(t, err)
 
-			<-sessionClosed
+			err = stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
+			require.NoError(t, err)
+
+			<-packetRecv
 		})
 	}
 }
 
-func TestServerPlayWithoutTeardown(t *testing.T) {
-	for _, transport := range []string{
+var testSRTPKey = []byte{
+	0xe1, 0xf9, 0x7a, 0x0d, 0x3e, 0x01, 0x8b, 0xe0,
+	0xd6, 0x4f, 0xa3, 0x2c, 0x06, 0xde, 0x41, 0x39,
+	0x0e, 0xc6, 0x75, 0xad, 0x49, 0x8a, 0xfe, 0xeb,
+	0xb6, 0x96, 0x0b, 0x3a, 0xab, 0xe6,
+}
+
+func TestServerPlaySRTP(t *testing.T) {
+	for _, ca := range []string{
 		"udp",
 		"tcp",
+		"raw",
 	} {
-		t.Run(transport, func(t *testing.T) {
+		t.Run(ca, func(t *testing.T) {
 			var stream *ServerStream
-			nconnClosed := make(chan struct{})
-			sessionClosed := make(chan struct{})
 
 			s := &Server{
 				Handler: &testServerHandler{
-					onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
-						close(nconnClosed)
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
 					},
-					onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
-						close(sessionClosed)
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
 					},
-					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+					onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress:    "localhost:8554",
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			medi := &description.Media{
+				Type:    description.MediaTypeVideo,
+				Formats: testH264Media.Formats,
+				Crypto: []description.Crypto{{
+					Tag:   1,
+					Suite: "AES_CM_128_HMAC_SHA1_80",
+					Keys:  []description.CryptoKey{{Key: testSRTPKey}},
+				}},
+			}
+
+			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{medi}})
+			defer stream.Close()
+
+			if ca == "raw" {
+				nconn, err := net.Dial("tcp", "localhost:8554")
+				require.NoError(t, err)
+				defer nconn.Close()
+				conn := conn.NewConn(nconn)
+
+				desc := doDescribe(t, conn)
+				require.Equal(t, []string{"RTP", "SAVP"}, desc.MediaDescriptions[0].MediaName.Protos)
+
+				inTH := &headers.Transport{
+					Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+					Mode:           transportModePtr(headers.TransportModePlay),
+					Protocol:       headers.TransportProtocolTCP,
+					Secure:         true,
+					InterleavedIDs: &[2]int{0, 1},
+				}
+
+				res, th := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+				require.True(t, th.Secure)
+
+				session := readSession(t, res)
+
+				doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+				err = stream.WritePacketRTP(medi, &testRTPPacket)
+				require.NoError(t, err)
+
+				f, err := conn.ReadInterleavedFrame()
+				require.NoError(t, err)
+				require.Equal(t, 0, f.Channel)
+				require.NotEqual(t, testRTPPacketMarshaled, f.Payload)
+
+				srtpCtx, err := srtp.New(srtp.ProfileAESCM128HMACSHA180, testSRTPKey)
+				require.NoError(t, err)
+
+				dec, err := srtpCtx.DecryptRTP(f.Payload)
+				require.NoError(t, err)
+				require.Equal(t, testRTPPacketMarshaled, dec)
+				return
+			}
+
+			c := Client{
+				Transport: func() *Transport {
+					if ca == "udp" {
+						return transportPtr(TransportUDP)
+					}
+					return transportPtr(TransportTCP)
+				}(),
+			}
+
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
+
+			sd, _, err := c.Describe(u)
+			require.NoError(t, err)
+			require.Len(t, sd.Medias[0].Crypto, 1)
+
+			err = c.SetupAll(sd.BaseURL, sd.Medias)
+			require.NoError(t, err)
+
+			packetRecv := make(chan struct{})
+
+			c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+				require.Equal(t, &testRTPPacket, pkt)
+				close(packetRecv)
+			})
+
+			_, err = c.Play(nil)
+			require.NoError(t, err)
+
+			err = stream.WritePacketRTP(medi, &testRTPPacket)
+			require.NoError(t, err)
+
+			<-packetRecv
+		})
+	}
+}
+
+func TestServerPlayRTX(t *testing.T) {
+	for _, ca := range []string{
+		"default",
+		"write batching",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var stream *ServerStream
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 						return &base.Response{
 							StatusCode: base.StatusOK,
 						}, stream, nil
 					},
-					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
 						return &base.Response{
 							StatusCode: base.StatusOK,
 						}, stream, nil
 					},
-					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+					onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
 						return &base.Response{
 							StatusCode: base.StatusOK,
 						}, nil
 					},
 				},
-				ReadTimeout:    1 * time.Second,
-				sessionTimeout: 1 * time.Second,
 				RTSPAddress:    "localhost:8554",
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
 			}
 
-			if transport == "udp" {
-				s.UDPRTPAddress = "127.0.0.1:8000"
-				s.UDPRTCPAddress = "127.0.0.1:8001"
+			if ca == "write batching" {
+				s.UDPBatchSize = 8
+				s.UDPWriteBatching = true
 			}
 
 			err := s.Start()
 			require.NoError(t, err)
 			defer s.Close()
 
-			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+			medi := &description.Media{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{
+					testH264Media.Formats[0],
+					&format.RTX{
+						PayloadTyp: 97,
+						ClockRat:   90000,
+						APT:        96,
+					},
+				},
+			}
+
+			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{medi}})
 			defer stream.Close()
 
-			nconn, err := net.Dial("tcp", "localhost:8554")
+			c := Client{
+				Transport: transportPtr(TransportUDP),
+			}
+
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
 			require.NoError(t, err)
-			defer nconn.Close()
-			conn := conn.NewConn(nconn)
 
-			desc := doDescribe(t, conn)
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
 
-			inTH := &headers.Transport{
-				Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
-				Mode:     transportModePtr(headers.TransportModePlay),
-			}
+			sd, _, err := c.Describe(u)
+			require.NoError(t, err)
+			require.IsType(t, &format.RTX{}, sd.Medias[0].Formats[1])
 
-			if transport == "udp" {
-				inTH.Protocol = headers.TransportProtocolUDP
-				inTH.ClientPorts = &[2]int{35466, 35467}
-			} else {
-				inTH.Protocol = headers.TransportProtocolTCP
-				inTH.InterleavedIDs = &[2]int{0, 1}
-			}
+			err = c.SetupAll(sd.BaseURL, sd.Medias)
+			require.NoError(t, err)
 
-			res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+			var seqNums []uint16
+			done := make(chan struct{})
 
-			session := readSession(t, res)
+			c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+				require.Equal(t, uint8(96), pkt.PayloadType)
+				require.Equal(t, testRTPPacket.SSRC, pkt.SSRC)
+				require.Equal(t, testRTPPacket.Payload, pkt.Payload)
+				seqNums = append(seqNums, pkt.SequenceNumber)
+				if len(seqNums) == 4 {
+					close(done)
+				}
+			})
 
-			doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+			_, err = c.Play(nil)
+			require.NoError(t, err)
 
-			nconn.Close()
+			for i := uint16(0); i < 4; i++ {
+				pkt := testRTPPacket
+				pkt.SequenceNumber = 1000 + i
 
-			<-sessionClosed
-			<-nconnClosed
+				// simulate the loss of two packets
+				if i == 1 || i == 2 {
+					stream.streamMedias[medi].formats[96].rtxSender.ProcessPacket(&pkt)
+					continue
+				}
+
+				err = stream.WritePacketRTP(medi, &pkt)
+				require.NoError(t, err)
+			}
+
+			<-done
+			require.Equal(t, []uint16{1000, 1001, 1002, 1003}, seqNums)
+
+			if ca == "write batching" {
+				require.Equal(t, udpSegmentationOffloadSupported(s.udpRTPListener.pc), s.UDPSegmentationOffloadActive())
+			}
 		})
 	}
 }
 
-func TestServerPlayUDPChangeConn(t *testing.T) {
+func TestServerPlayFEC(t *testing.T) {
 	var stream *ServerStream
 
 	s := &Server{
 		Handler: &testServerHandler{
-			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
-			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
-			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
-				return &base.Response{
-					StatusCode: base.StatusOK,
-				}, nil
-			},
-			onGetParameter: func(ctx *ServerHandlerOnGetParameterCtx) (*base.Response, error) {
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, nil
 			},
 		},
+		RTSPAddress:    "localhost:8554",
 		UDPRTPAddress:  "127.0.0.1:8000",
 		UDPRTCPAddress: "127.0.0.1:8001",
-		RTSPAddress:    "localhost:8554",
+		FECGroupSize:   4,
 	}
 
 	err := s.Start()
 	require.NoError(t, err)
 	defer s.Close()
 
-	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	medi := &description.Media{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{
+			testH264Media.Formats[0],
+			&format.FlexFEC{
+				PayloadTyp:   98,
+				ClockRat:     90000,
+				RepairWindow: 200000,
+			},
+		},
+	}
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{medi}})
 	defer stream.Close()
 
-	sxID := ""
+	c := Client{
+		Transport: transportPtr(TransportUDP),
+	}
 
-	func() {
-		nconn, err := net.Dial("tcp", "localhost:8554")
-		require.NoError(t, err)
-		defer nconn.Close()
-		conn := conn.NewConn(nconn)
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
 
-		desc := doDescribe(t, conn)
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
 
-		inTH := &headers.Transport{
-			Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
-			Mode:        transportModePtr(headers.TransportModePlay),
-			Protocol:    headers.TransportProtocolUDP,
-			ClientPorts: &[2]int{35466, 35467},
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+	require.IsType(t, &format.FlexFEC{}, sd.Medias[0].Formats[1])
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	var seqNums []uint16
+	done := make(chan struct{})
+
+	c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+		require.Equal(t, uint8(96), pkt.PayloadType)
+		require.Equal(t, testRTPPacket.SSRC, pkt.SSRC)
+		require.Equal(t, testRTPPacket.Payload, pkt.Payload)
+		seqNums = append(seqNums, pkt.SequenceNumber)
+		if len(seqNums) == 4 {
+			close(done)
 		}
+	})
 
-		res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+	c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[1], func(_ *rtp.Packet) {
+		t.Errorf("FEC packets should not be delivered")
+	})
 
-		session := readSession(t, res)
+	_, err = c.Play(nil)
+	require.NoError(t, err)
 
-		doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	sm := stream.streamMedias[medi]
 
-		sxID = session
-	}()
+	for i := uint16(0); i < 4; i++ {
+		pkt := testRTPPacket
+		pkt.SequenceNumber = 1000 + i
 
-	func() {
-		nconn, err := net.Dial("tcp", "localhost:8554")
+		// simulate the loss of a packet
+		if i == 1 {
+			_, err = sm.generateFEC(sm.formats[96], &pkt)
+			require.NoError(t, err)
+			continue
+		}
+
+		err = stream.WritePacketRTP(medi, &pkt)
 		require.NoError(t, err)
-		defer nconn.Close()
-		conn := conn.NewConn(nconn)
+	}
 
-		res, err := writeReqReadRes(conn, base.Request{
-			Method: base.GetParameter,
-			URL:    mustParseURL("rtsp://localhost:8554/teststream/"),
-			Header: base.Header{
-				"CSeq":    base.HeaderValue{"4"},
-				"Session": base.HeaderValue{sxID},
-			},
+	<-done
+	require.Equal(t, []uint16{1000, 1001, 1002, 1003}, seqNums)
+}
+
+func TestServerPlayRED(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(transport, func(t *testing.T) {
+			var stream *ServerStream
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress:    "localhost:8554",
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+				REDDepth:       2,
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			medi := &description.Media{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{
+					&format.RED{
+						PayloadTyp:   63,
+						ClockRat:     48000,
+						ChannelCount: 2,
+						PayloadTypes: []uint8{111, 111},
+					},
+					&format.Opus{
+						PayloadTyp:   111,
+						ChannelCount: 2,
+					},
+				},
+			}
+
+			stream = NewServerStream(s, &description.Session{Medias: []*description.Media{medi}})
+			defer stream.Close()
+
+			c := Client{
+				Transport: func() *Transport {
+					if transport == "udp" {
+						return transportPtr(TransportUDP)
+					}
+					return transportPtr(TransportTCP)
+				}(),
+			}
+
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
+
+			sd, _, err := c.Describe(u)
+			require.NoError(t, err)
+			require.IsType(t, &format.RED{}, sd.Medias[0].Formats[0])
+
+			err = c.SetupAll(sd.BaseURL, sd.Medias)
+			require.NoError(t, err)
+
+			var seqNums []uint16
+			done := make(chan struct{})
+
+			c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(_ *rtp.Packet) {
+				t.Errorf("RED packets should not be delivered")
+			})
+
+			c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[1], func(pkt *rtp.Packet) {
+				require.Equal(t, uint8(111), pkt.PayloadType)
+				require.Equal(t, uint32(1000+960*uint32(pkt.SequenceNumber-1000)), pkt.Timestamp)
+				require.Equal(t, []byte{byte(pkt.SequenceNumber), 2, 3}, pkt.Payload)
+				seqNums = append(seqNums, pkt.SequenceNumber)
+				if len(seqNums) == 4 {
+					close(done)
+				}
+			})
+
+			_, err = c.Play(nil)
+			require.NoError(t, err)
+
+			sm := stream.streamMedias[medi]
+
+			for i := uint16(0); i < 4; i++ {
+				pkt := rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         true,
+						PayloadType:    111,
+						SequenceNumber: 1000 + i,
+						Timestamp:      1000 + 960*uint32(i),
+						SSRC:           0x38F27A2F,
+					},
+					Payload: []byte{byte(1000 + i), 2, 3},
+				}
+
+				// simulate the loss of two packets
+				if transport == "udp" && (i == 1 || i == 2) {
+					sm.encodeRED(&pkt)
+					continue
+				}
+
+				err = stream.WritePacketRTP(medi, &pkt)
+				require.NoError(t, err)
+			}
+
+			<-done
+			require.Equal(t, []uint16{1000, 1001, 1002, 1003}, seqNums)
 		})
-		require.NoError(t, err)
-		require.Equal(t, base.StatusOK, res.StatusCode)
-	}()
+	}
 }
 
-func TestServerPlayPartialMedias(t *testing.T) {
+func TestServerPlayDropUntilKeyframe(t *testing.T) {
 	var stream *ServerStream
+	var session *ServerSession
 
 	s := &Server{
 		Handler: &testServerHandler{
-			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
 			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				err := ctx.Session.SetWriteQueue(&ServerWriteQueue{
+					Size:   4,
+					Policy: ServerWriteQueuePolicyDropOldest,
+				})
+				require.NoError(t, err)
+
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
 			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
-				go func() {
-					time.Sleep(500 * time.Millisecond)
-					err := stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
-					require.NoError(t, err)
-					err = stream.WritePacketRTP(stream.Description().Medias[1], &testRTPPacket)
-					require.NoError(t, err)
-				}()
-
+				session = ctx.Session
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, nil
@@ -1751,7 +4575,8 @@ func TestServerPlayPartialMedias(t *testing.T) {
 	require.NoError(t, err)
 	defer s.Close()
 
-	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media, testH264Media}})
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	stream.DropUntilKeyFrame = true
 	defer stream.Close()
 
 	nconn, err := net.Dial("tcp", "localhost:8554")
@@ -1765,268 +4590,412 @@ func TestServerPlayPartialMedias(t *testing.T) {
 		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
 		Mode:           transportModePtr(headers.TransportModePlay),
 		Protocol:       headers.TransportProtocolTCP,
-		InterleavedIDs: &[2]int{4, 5},
+		InterleavedIDs: &[2]int{0, 1},
 	}
 
 	res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
 
-	session := readSession(t, res)
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", readSession(t, res))
 
-	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	// stall the writer of the session
+	blocked := make(chan struct{})
+	unblock := make(chan struct{})
+	session.writer.push(func() {
+		close(blocked)
+		<-unblock
+	})
+	<-blocked
 
-	f, err := conn.ReadInterleavedFrame()
-	require.NoError(t, err)
-	require.Equal(t, 4, f.Channel)
-	require.Equal(t, testRTPPacketMarshaled, f.Payload)
-}
+	write := func(id byte, idr bool) {
+		nalu := byte(h264.NALUTypeNonIDR)
+		if idr {
+			nalu = byte(h264.NALUTypeIDR)
+		}
 
-func TestServerPlayAdditionalInfos(t *testing.T) {
-	getInfos := func() (*headers.RTPInfo, []*uint32) {
-		nconn, err := net.Dial("tcp", "localhost:8554")
+		err = stream.WritePacketRTP(testH264Media, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: uint16(id),
+				SSRC:           753621,
+			},
+			Payload: []byte{nalu, id},
+		})
 		require.NoError(t, err)
-		defer nconn.Close()
-		conn := conn.NewConn(nconn)
+	}
 
-		desc := doDescribe(t, conn)
+	read := func() byte {
+		for {
+			f, err2 := conn.ReadInterleavedFrame()
+			require.NoError(t, err2)
 
-		inTH := &headers.Transport{
-			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-			Mode:           transportModePtr(headers.TransportModePlay),
-			Protocol:       headers.TransportProtocolTCP,
-			InterleavedIDs: &[2]int{0, 1},
+			if f.Channel == 0 {
+				var pkt rtp.Packet
+				err2 = pkt.Unmarshal(f.Payload)
+				require.NoError(t, err2)
+				return pkt.Payload[1]
+			}
 		}
+	}
 
-		res, th := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+	write(1, true)
+	write(2, false)
+	write(3, false)
+	write(4, false)
+	write(5, false) // evicts 1
+	write(6, false) // withheld
+	write(7, true)  // resumes, evicts 2
+	write(8, false) // withheld
 
-		ssrcs := make([]*uint32, 2)
-		ssrcs[0] = th.SSRC
+	close(unblock)
+
+	var received []byte
+	for i := 0; i < 4; i++ {
+		received = append(received, read())
+	}
+
+	write(9, false) // withheld
+	write(10, true)
+	write(11, false)
+
+	for i := 0; i < 2; i++ {
+		received = append(received, read())
+	}
+
+	require.Equal(t, []byte{3, 4, 5, 7, 10, 11}, received)
+	require.Equal(t, uint64(2), session.WriteQueueDropped())
+}
+
+func TestServerPlayUDPWriteBatching(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress:      "localhost:8554",
+		UDPRTPAddress:    "127.0.0.1:8000",
+		UDPRTCPAddress:   "127.0.0.1:8001",
+		UDPBatchSize:     8,
+		UDPWriteBatching: true,
+	}
 
-		inTH = &headers.Transport{
-			Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
-			Mode:           transportModePtr(headers.TransportModePlay),
-			Protocol:       headers.TransportProtocolTCP,
-			InterleavedIDs: &[2]int{2, 3},
-		}
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
 
-		session := readSession(t, res)
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
 
-		_, th = doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[1]), inTH, session)
+	c := Client{
+		Transport: transportPtr(TransportUDP),
+	}
 
-		ssrcs[1] = th.SSRC
+	var seqNums []uint16
+	done := make(chan struct{})
 
-		res = doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			seqNums = append(seqNums, pkt.SequenceNumber)
+			if len(seqNums) == 20 {
+				close(done)
+			}
+		})
+	require.NoError(t, err)
+	defer c.Close()
 
-		var ri headers.RTPInfo
-		err = ri.Unmarshal(res.Header["RTP-Info"])
+	// a burst of packets is written with the regular write path
+	for i := uint16(0); i < 20; i++ {
+		pkt := testRTPPacket
+		pkt.SequenceNumber = 1000 + i
+		err = stream.WritePacketRTP(testH264Media, &pkt)
 		require.NoError(t, err)
-
-		return &ri, ssrcs
 	}
 
-	forma := &format.Generic{
-		PayloadTyp: 96,
-		RTPMa:      "private/90000",
+	<-done
+
+	exp := make([]uint16, 20)
+	for i := range exp {
+		exp[i] = 1000 + uint16(i)
 	}
-	err := forma.Init()
-	require.NoError(t, err)
+	require.Equal(t, exp, seqNums)
 
+	require.Equal(t, udpSegmentationOffloadSupported(s.udpRTPListener.pc), s.UDPSegmentationOffloadActive())
+}
+
+func TestServerPlayCongestionFeedback(t *testing.T) {
 	var stream *ServerStream
+	twccRecv := make(chan struct{}, 1)
 
 	s := &Server{
 		Handler: &testServerHandler{
-			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
-			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
 			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				ctx.Session.OnPacketRTCPAny(func(_ *description.Media, pkt rtcp.Packet) {
+					if _, ok := pkt.(*rtcp.TransportLayerCC); ok {
+						select {
+						case twccRecv <- struct{}{}:
+						default:
+						}
+					}
+				})
+
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, nil
 			},
 		},
-		RTSPAddress: "localhost:8554",
+		RTSPAddress:    "localhost:8554",
+		UDPRTPAddress:  "127.0.0.1:8000",
+		UDPRTCPAddress: "127.0.0.1:8001",
 	}
 
-	err = s.Start()
+	err := s.Start()
 	require.NoError(t, err)
 	defer s.Close()
 
-	stream = NewServerStream(s, &description.Session{
-		Medias: []*description.Media{
-			{
-				Type:    "application",
-				Formats: []format.Format{forma},
-			},
-			{
-				Type:    "application",
-				Formats: []format.Format{forma},
-			},
-		},
-	})
+	medi := &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: testH264Media.Formats,
+		HeaderExtensions: []description.HeaderExtension{{
+			ID:  3,
+			URI: rtpextension.TransportCCURI,
+		}},
+	}
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{medi}})
 	defer stream.Close()
 
-	err = stream.WritePacketRTP(stream.Description().Medias[0], &rtp.Packet{
-		Header: rtp.Header{
-			Version:        2,
-			PayloadType:    96,
-			SequenceNumber: 556,
-			Timestamp:      984512368,
-			SSRC:           96342362,
-		},
-		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	estimate := make(chan uint64, 1)
+
+	stream.OnBandwidthEstimate(func(m *description.Media, bitrate uint64) {
+		require.Equal(t, medi, m)
+		select {
+		case estimate <- bitrate:
+		default:
+		}
 	})
-	require.NoError(t, err)
 
-	rtpInfo, ssrcs := getInfos()
-	require.True(t, strings.HasPrefix(mustParseURL((*rtpInfo)[0].URL).Path, "/teststream/trackID="))
-	require.Equal(t, &headers.RTPInfo{
-		&headers.RTPInfoEntry{
-			URL: (&base.URL{
-				Scheme: "rtsp",
-				Host:   "localhost:8554",
-				Path:   mustParseURL((*rtpInfo)[0].URL).Path,
-			}).String(),
-			SequenceNumber: uint16Ptr(557),
-			Timestamp:      (*rtpInfo)[0].Timestamp,
+	var timeMutex sync.Mutex
+	curTime := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	c := Client{
+		Transport:                    transportPtr(TransportUDP),
+		RTCPCongestionFeedbackEnable: true,
+		timeNow: func() time.Time {
+			timeMutex.Lock()
+			defer timeMutex.Unlock()
+			curTime = curTime.Add(100 * time.Millisecond)
+			return curTime
 		},
-	}, rtpInfo)
-	require.Equal(t, []*uint32{
-		uint32Ptr(96342362),
-		nil,
-	}, ssrcs)
+	}
 
-	err = stream.WritePacketRTP(stream.Description().Medias[1], &rtp.Packet{
-		Header: rtp.Header{
-			Version:        2,
-			PayloadType:    96,
-			SequenceNumber: 87,
-			Timestamp:      756436454,
-			SSRC:           536474323,
-		},
-		Payload: []byte{0x01, 0x02, 0x03, 0x04},
-	})
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
 	require.NoError(t, err)
 
-	rtpInfo, ssrcs = getInfos()
-	require.True(t, strings.HasPrefix(mustParseURL((*rtpInfo)[0].URL).Path, "/teststream/trackID="))
-	require.Equal(t, &headers.RTPInfo{
-		&headers.RTPInfoEntry{
-			URL: (&base.URL{
-				Scheme: "rtsp",
-				Host:   "localhost:8554",
-				Path:   mustParseURL((*rtpInfo)[0].URL).Path,
-			}).String(),
-			SequenceNumber: uint16Ptr(557),
-			Timestamp:      (*rtpInfo)[0].Timestamp,
-		},
-		&headers.RTPInfoEntry{
-			URL: (&base.URL{
-				Scheme: "rtsp",
-				Host:   "localhost:8554",
-				Path:   mustParseURL((*rtpInfo)[1].URL).Path,
-			}).String(),
-			SequenceNumber: uint16Ptr(88),
-			Timestamp:      (*rtpInfo)[1].Timestamp,
-		},
-	}, rtpInfo)
-	require.Equal(t, []*uint32{
-		uint32Ptr(96342362),
-		uint32Ptr(536474323),
-	}, ssrcs)
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sd, _, err := c.Describe(u)
+	require.NoError(t, err)
+	require.Equal(t, medi.HeaderExtensions, sd.Medias[0].HeaderExtensions)
+
+	err = c.SetupAll(sd.BaseURL, sd.Medias)
+	require.NoError(t, err)
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	estimateRecv := false
+	twccRecvd := false
+
+	for i := uint16(0); !estimateRecv || !twccRecvd; i++ {
+		pkt := testRTPPacket
+		pkt.Header.Extensions = nil
+		pkt.SequenceNumber = i
+		err = pkt.Header.SetExtension(3, []byte{byte(i >> 8), byte(i)})
+		require.NoError(t, err)
+
+		err = stream.WritePacketRTP(medi, &pkt)
+		require.NoError(t, err)
+
+		select {
+		case bitrate := <-estimate:
+			require.NotZero(t, bitrate)
+			estimateRecv = true
+		case <-twccRecv:
+			twccRecvd = true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
 }
 
-func TestServerPlayNoInterleavedIDs(t *testing.T) {
-	forma := &format.Generic{
-		PayloadTyp: 96,
-		RTPMa:      "private/90000",
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case nconn := <-l.conns:
+		return nconn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
 	}
-	err := forma.Init()
-	require.NoError(t, err)
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
 
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "pipe"}
+}
+
+func (l *pipeListener) dial(_ context.Context, _ string, _ string) (net.Conn, error) {
+	clientConn, serverConn := net.Pipe()
+
+	select {
+	case l.conns <- serverConn:
+		return clientConn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func TestServerPlayCustomConn(t *testing.T) {
 	var stream *ServerStream
 
+	l := &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+
 	s := &Server{
 		Handler: &testServerHandler{
-			onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
 			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				require.Equal(t, TransportTCP, ctx.Transport)
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, stream, nil
 			},
-			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
 				}, nil
 			},
 		},
-		RTSPAddress: "localhost:8554",
+		RTSPAddress:    "localhost:8554",
+		UDPRTPAddress:  "127.0.0.1:8000",
+		UDPRTCPAddress: "127.0.0.1:8001",
+		Listen: func(_ string, _ string) (net.Listener, error) {
+			return l, nil
+		},
 	}
 
-	err = s.Start()
+	err := s.Start()
 	require.NoError(t, err)
 	defer s.Close()
 
-	stream = NewServerStream(s, &description.Session{
-		Medias: []*description.Media{
-			{
-				Type:    "application",
-				Formats: []format.Format{forma},
-			},
-			{
-				Type:    "application",
-				Formats: []format.Format{forma},
-			},
-		},
-	})
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
 	defer stream.Close()
 
-	nconn, err := net.Dial("tcp", "localhost:8554")
-	require.NoError(t, err)
-	defer nconn.Close()
-	conn := conn.NewConn(nconn)
+	t.Run("udp refused", func(t *testing.T) {
+		nconn, err2 := l.dial(context.Background(), "tcp", "localhost:8554")
+		require.NoError(t, err2)
+		defer nconn.Close()
+		conn := conn.NewConn(nconn)
 
-	desc := doDescribe(t, conn)
+		desc := doDescribe(t, conn)
 
-	inTH := &headers.Transport{
-		Delivery: deliveryPtr(headers.TransportDeliveryUnicast),
-		Mode:     transportModePtr(headers.TransportModePlay),
-		Protocol: headers.TransportProtocolTCP,
-	}
+		inTH := &headers.Transport{
+			Delivery:    deliveryPtr(headers.TransportDeliveryUnicast),
+			Mode:        transportModePtr(headers.TransportModePlay),
+			Protocol:    headers.TransportProtocolUDP,
+			ClientPorts: &[2]int{35466, 35467},
+		}
 
-	res, th := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
+		res, err2 := writeReqReadRes(conn, base.Request{
+			Method: base.Setup,
+			URL:    mustParseURL(absoluteControlAttribute(desc.MediaDescriptions[0])),
+			Header: base.Header{
+				"CSeq":      base.HeaderValue{"1"},
+				"Transport": inTH.Marshal(),
+			},
+		})
+		require.NoError(t, err2)
+		require.Equal(t, base.StatusUnsupportedTransport, res.StatusCode)
+	})
 
-	require.Equal(t, &[2]int{0, 1}, th.InterleavedIDs)
+	t.Run("play", func(t *testing.T) {
+		c := Client{
+			DialContext: l.dial,
+		}
 
-	session := readSession(t, res)
+		u, err2 := base.ParseURL("rtsp://localhost:8554/teststream")
+		require.NoError(t, err2)
 
-	_, th = doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[1]), inTH, session)
+		err2 = c.Start(u.Scheme, u.Host)
+		require.NoError(t, err2)
+		defer c.Close()
 
-	require.Equal(t, &[2]int{2, 3}, th.InterleavedIDs)
+		sd, _, err2 := c.Describe(u)
+		require.NoError(t, err2)
 
-	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+		err2 = c.SetupAll(sd.BaseURL, sd.Medias)
+		require.NoError(t, err2)
+		require.Equal(t, TransportTCP, *c.effectiveTransport)
 
-	for i := 0; i < 2; i++ {
-		err := stream.WritePacketRTP(stream.Description().Medias[i], &testRTPPacket)
-		require.NoError(t, err)
+		packetRecv := make(chan struct{})
 
-		f, err := conn.ReadInterleavedFrame()
-		require.NoError(t, err)
-		require.Equal(t, i*2, f.Channel)
-		require.Equal(t, testRTPPacketMarshaled, f.Payload)
-	}
+		c.OnPacketRTP(sd.Medias[0], sd.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+			require.Equal(t, testRTPPacket.Payload, pkt.Payload)
+			close(packetRecv)
+		})
+
+		_, err2 = c.Play(nil)
+		require.NoError(t, err2)
+
+		err2 = stream.WritePacketRTP(testH264Media, &testRTPPacket)
+		require.NoError(t, err2)
+
+		<-packetRecv
+	})
 }
 
-func TestServerPlayBytesSent(t *testing.T) {
+func TestServerPlayMoveReaders(t *testing.T) {
 	var stream *ServerStream
 
 	s := &Server{
@@ -2060,9 +5029,29 @@ func TestServerPlayBytesSent(t *testing.T) {
 	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
 	defer stream.Close()
 
+	backup := NewServerStream(s, &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		}},
+	}}})
+	defer backup.Close()
+
+	other := NewServerStream(s, &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        97,
+			PacketizationMode: 1,
+		}},
+	}}})
+	defer other.Close()
+
+	var tcpConn *conn.Conn
+
 	for _, transport := range []string{"tcp", "multicast"} {
-		nconn, err := net.Dial("tcp", "localhost:8554")
-		require.NoError(t, err)
+		nconn, err2 := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err2)
 		defer nconn.Close()
 		conn := conn.NewConn(nconn)
 
@@ -2081,6 +5070,7 @@ func TestServerPlayBytesSent(t *testing.T) {
 			inTH.Delivery = &v
 			inTH.Protocol = headers.TransportProtocolTCP
 			inTH.InterleavedIDs = &[2]int{0, 1}
+			tcpConn = conn
 		}
 
 		res, _ := doSetup(t, conn, absoluteControlAttribute(desc.MediaDescriptions[0]), inTH, "")
@@ -2090,8 +5080,60 @@ func TestServerPlayBytesSent(t *testing.T) {
 		doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
 	}
 
-	err = stream.WritePacketRTP(stream.Description().Medias[0], &testRTPPacket)
+	ntp := time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)
+
+	err = stream.WritePacketRTPWithNTP(testH264Media, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 100,
+			Timestamp:      1000,
+			SSRC:           0x38F27A2F,
+		},
+		Payload: []byte{0x05, 1, 2, 3}, // IDR
+	}, ntp)
+	require.NoError(t, err)
+
+	f, err := tcpConn.ReadInterleavedFrame()
 	require.NoError(t, err)
+	require.Equal(t, 0, f.Channel)
 
+	err = stream.MoveReaders(other)
+	require.EqualError(t, err, "destination stream must contain the same medias and formats")
+
+	err = stream.MoveReaders(backup)
+	require.NoError(t, err)
+
+	err = stream.MoveReaders(backup)
+	require.EqualError(t, err, "destination stream already has readers")
+
+	err = backup.WritePacketRTPWithNTP(backup.Description().Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 5,
+			Timestamp:      50,
+			SSRC:           0x11223344,
+		},
+		Payload: []byte{0x05, 1, 2, 3}, // IDR
+	}, ntp.Add(1*time.Second))
+	require.NoError(t, err)
+
+	f, err = tcpConn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 0, f.Channel)
+
+	var pkt rtp.Packet
+	err = pkt.Unmarshal(f.Payload)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0x38F27A2F), pkt.SSRC)
+	require.Equal(t, uint16(101), pkt.SequenceNumber)
+	require.Equal(t, uint32(1000+90000), pkt.Timestamp)
+
+	// packets are sent to the TCP and to the multicast readers
+	require.Equal(t, uint64(16*2), backup.BytesSent())
+
+	err = stream.WritePacketRTPWithNTP(testH264Media, &testRTPPacket, ntp)
+	require.NoError(t, err)
 	require.Equal(t, uint64(16*2), stream.BytesSent())
 }
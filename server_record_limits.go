@@ -0,0 +1,83 @@
+package gortsplib
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ServerRecordLimits contains limits on the data received from a publishing session,
+// that prevent a misbehaving client from increasing the memory usage of the server.
+type ServerRecordLimits struct {
+	// maximum number of interleaved frames that are queued before being processed.
+	// When the queue is full, the connection is not read anymore, in order to apply
+	// backpressure to the client, and is closed if the queue doesn't empty within ReadTimeout.
+	// It must be a power of two.
+	// It defaults to 0, that means that frames are processed as soon as they are read.
+	MaxQueuedFrames int
+
+	// maximum number of queued bytes.
+	// It defaults to 0 (unlimited).
+	MaxQueuedBytes int
+
+	// maximum size of RTP / RTCP packets. Bigger packets are discarded.
+	// It defaults to 0 (unlimited).
+	MaxPacketSize int
+
+	// maximum number of RTP packets received with UDP
+	// that are buffered in order to restore their order.
+	// It defaults to 64.
+	MaxReorderedPackets int
+}
+
+func (l *ServerRecordLimits) validate() error {
+	if l.MaxQueuedFrames < 0 || (l.MaxQueuedFrames&(l.MaxQueuedFrames-1)) != 0 {
+		return fmt.Errorf("maximum number of queued frames must be a power of two")
+	}
+	if l.MaxQueuedBytes < 0 {
+		return fmt.Errorf("invalid maximum number of queued bytes: %d", l.MaxQueuedBytes)
+	}
+	if l.MaxPacketSize < 0 {
+		return fmt.Errorf("invalid maximum packet size: %d", l.MaxPacketSize)
+	}
+	if l.MaxReorderedPackets < 0 {
+		return fmt.Errorf("invalid maximum number of reordered packets: %d", l.MaxReorderedPackets)
+	}
+	return nil
+}
+
+// ServerRecordLimitsStats contains counters about the limits of a publishing session.
+type ServerRecordLimitsStats struct {
+	// number of interleaved frames that are currently queued.
+	QueuedFrames int64
+	// number of times the frame queue was full and the connection stopped being read.
+	QueueFullEvents uint64
+	// number of packets discarded since they exceeded MaxPacketSize.
+	PacketsTooBig uint64
+	// number of missing packets that were skipped since the reorder buffer was full.
+	ReorderSkipped uint64
+}
+
+type serverRecordLimitsCounters struct {
+	queuedFrames    *int64
+	queueFullEvents *uint64
+	packetsTooBig   *uint64
+	reorderSkipped  *uint64
+}
+
+func newServerRecordLimitsCounters() serverRecordLimitsCounters {
+	return serverRecordLimitsCounters{
+		queuedFrames:    new(int64),
+		queueFullEvents: new(uint64),
+		packetsTooBig:   new(uint64),
+		reorderSkipped:  new(uint64),
+	}
+}
+
+func (c serverRecordLimitsCounters) stats() ServerRecordLimitsStats {
+	return ServerRecordLimitsStats{
+		QueuedFrames:    atomic.LoadInt64(c.queuedFrames),
+		QueueFullEvents: atomic.LoadUint64(c.queueFullEvents),
+		PacketsTooBig:   atomic.LoadUint64(c.packetsTooBig),
+		ReorderSkipped:  atomic.LoadUint64(c.reorderSkipped),
+	}
+}
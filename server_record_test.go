@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"net"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,6 +19,9 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtcpapp"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpextension"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 )
 
@@ -1548,3 +1552,1070 @@ func TestServerRecordPausePause(t *testing.T) {
 
 	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
 }
+
+func TestServerRecordAppend(t *testing.T) {
+	for _, ca := range []string{
+		"append",
+		"replace",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						require.Equal(t, ca == "append", ctx.Append)
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						require.Equal(t, ca == "append", ctx.Append)
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress: "localhost:8554",
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(nconn)
+
+			medias := []*description.Media{testH264Media}
+
+			doAnnounce(t, conn, "rtsp://localhost:8554/teststream", medias)
+
+			inTH := &headers.Transport{
+				Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+				Mode:           transportModePtr(headers.TransportModeRecord),
+				Protocol:       headers.TransportProtocolTCP,
+				InterleavedIDs: &[2]int{0, 1},
+				Append:         ca == "append",
+			}
+
+			res, th := doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medias[0].Control, inTH, "")
+			require.Equal(t, ca == "append", th.Append)
+
+			session := readSession(t, res)
+
+			doRecord(t, conn, "rtsp://localhost:8554/teststream", session)
+		})
+	}
+}
+
+func TestServerRecordSRTP(t *testing.T) {
+	for _, ca := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			recv := make(chan struct{})
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						require.Len(t, ctx.Description.Medias[0].Crypto, 1)
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						ctx.Session.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+							require.Equal(t, &testRTPPacket, pkt)
+							close(recv)
+						})
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+				RTSPAddress:    "localhost:8554",
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			medi := &description.Media{
+				Type:    description.MediaTypeVideo,
+				Formats: testH264Media.Formats,
+				Crypto: []description.Crypto{{
+					Tag:   1,
+					Suite: "AES_CM_128_HMAC_SHA1_80",
+					Keys:  []description.CryptoKey{{Key: testSRTPKey}},
+				}},
+			}
+
+			c := Client{
+				Transport: func() *Transport {
+					if ca == "udp" {
+						return transportPtr(TransportUDP)
+					}
+					return transportPtr(TransportTCP)
+				}(),
+			}
+
+			err = c.StartRecording("rtsp://localhost:8554/teststream",
+				&description.Session{Medias: []*description.Media{medi}})
+			require.NoError(t, err)
+			defer c.Close()
+
+			err = c.WritePacketRTP(medi, &testRTPPacket)
+			require.NoError(t, err)
+
+			<-recv
+		})
+	}
+}
+
+func TestServerRecordCongestionFeedback(t *testing.T) {
+	for _, ca := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var timeMutex sync.Mutex
+			curTime := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(_ *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				UDPRTPAddress:                "127.0.0.1:8000",
+				UDPRTCPAddress:               "127.0.0.1:8001",
+				RTSPAddress:                  "localhost:8554",
+				RTCPCongestionFeedbackEnable: true,
+				timeNow: func() time.Time {
+					timeMutex.Lock()
+					defer timeMutex.Unlock()
+					curTime = curTime.Add(100 * time.Millisecond)
+					return curTime
+				},
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			medi := testH264Media
+
+			c := Client{
+				Transport: func() *Transport {
+					if ca == "udp" {
+						return transportPtr(TransportUDP)
+					}
+					return transportPtr(TransportTCP)
+				}(),
+			}
+
+			err = c.StartRecording("rtsp://localhost:8554/teststream",
+				&description.Session{Medias: []*description.Media{medi}})
+			require.NoError(t, err)
+			defer c.Close()
+
+			estimate := make(chan uint64, 1)
+
+			c.OnBandwidthEstimate(func(m *description.Media, bitrate uint64) {
+				require.Equal(t, medi, m)
+				select {
+				case estimate <- bitrate:
+				default:
+				}
+			})
+
+			for i := uint16(0); ; i++ {
+				pkt := testRTPPacket
+				pkt.SequenceNumber = i
+
+				err = c.WritePacketRTP(medi, &pkt)
+				require.NoError(t, err)
+
+				select {
+				case bitrate := <-estimate:
+					require.NotZero(t, bitrate)
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		})
+	}
+}
+
+func TestServerRecordRTPExtension(t *testing.T) {
+	received := make(chan *rtpextension.AbsSendTime)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				medi := ctx.Session.AnnouncedDescription().Medias[0]
+
+				ctx.Session.OnPacketRTPExtension(medi, rtpextension.AbsSendTimeURI,
+					func(_ *rtp.Packet, payload []byte) {
+						var ext rtpextension.AbsSendTime
+						err := ext.Unmarshal(payload)
+						require.NoError(t, err)
+						received <- &ext
+					})
+
+				ctx.Session.OnPacketRTPExtension(medi, rtpextension.SDESMIDURI,
+					func(_ *rtp.Packet, _ []byte) {
+						t.Errorf("should not happen")
+					})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medi := &description.Media{
+		Type: description.MediaTypeVideo,
+		HeaderExtensions: []description.HeaderExtension{{
+			ID:  2,
+			URI: rtpextension.AbsSendTimeURI,
+		}},
+		Formats: []format.Format{testH264Media.Formats[0]},
+	}
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = c.StartRecording("rtsp://localhost:8554/teststream",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer c.Close()
+
+	pkt := testRTPPacket
+	err = rtpextension.Write(medi, &pkt, &rtpextension.AbsSendTime{Timestamp: 0x123456})
+	require.NoError(t, err)
+
+	err = c.WritePacketRTP(medi, &pkt)
+	require.NoError(t, err)
+
+	ext := <-received
+	require.Equal(t, &rtpextension.AbsSendTime{Timestamp: 0x123456}, ext)
+}
+
+func TestServerRecordRED(t *testing.T) {
+	received := make(chan *rtp.Packet, 2)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				ctx.Session.OnPacketRTPAny(func(_ *description.Media, forma format.Format, pkt *rtp.Packet) {
+					require.IsType(t, &format.Opus{}, forma)
+					received <- pkt
+				})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medi := &description.Media{
+		Type: description.MediaTypeAudio,
+		Formats: []format.Format{
+			&format.RED{
+				PayloadTyp:   63,
+				ClockRat:     48000,
+				ChannelCount: 2,
+				PayloadTypes: []uint8{111, 111},
+			},
+			&format.Opus{
+				PayloadTyp:   111,
+				ChannelCount: 2,
+			},
+		},
+	}
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = c.StartRecording("rtsp://localhost:8554/teststream",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := uint16(0); i < 2; i++ {
+		err = c.WritePacketRTP(medi, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    111,
+				SequenceNumber: 1000 + i,
+				Timestamp:      1000 + 960*uint32(i),
+				SSRC:           0x38F27A2F,
+			},
+			Payload: []byte{byte(i), 2, 3},
+		})
+		require.NoError(t, err)
+	}
+
+	for i := uint16(0); i < 2; i++ {
+		pkt := <-received
+		require.Equal(t, uint8(111), pkt.PayloadType)
+		require.Equal(t, 1000+i, pkt.SequenceNumber)
+		require.Equal(t, []byte{byte(i), 2, 3}, pkt.Payload)
+	}
+}
+
+func TestServerRecordStreamEnded(t *testing.T) {
+	sdesRecv := make(chan struct{})
+	type streamEnded struct {
+		reason string
+		stats  []ReceiverStats
+	}
+	ended := make(chan streamEnded, 1)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				ctx.Session.OnPacketRTCPAny(func(_ *description.Media, pkt rtcp.Packet) {
+					if _, ok := pkt.(*rtcp.SourceDescription); ok {
+						close(sdesRecv)
+					}
+				})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onStreamEnded: func(ctx *ServerHandlerOnStreamEndedCtx) {
+				ended <- streamEnded{
+					reason: ctx.Reason,
+					stats:  ctx.Session.Stats(),
+				}
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medi := testH264Media
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = c.StartRecording("rtsp://localhost:8554/teststream",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer c.Close()
+
+	err = c.WritePacketRTP(medi, &rtp.Packet{
+		Header: rtp.Header{
+			Version:     2,
+			PayloadType: 96,
+			SSRC:        0x38F27A2F,
+		},
+		Payload: []byte{5, 1, 2, 3},
+	})
+	require.NoError(t, err)
+
+	err = c.WritePacketRTCP(medi, &rtcp.SourceDescription{
+		Chunks: []rtcp.SourceDescriptionChunk{{
+			Source: 0x38F27A2F,
+			Items: []rtcp.SourceDescriptionItem{
+				{Type: rtcp.SDESCNAME, Text: "myhost"},
+				{Type: rtcp.SDESTool, Text: "mytool"},
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	<-sdesRecv
+
+	// the client sends a BYE when closing
+	c.Close()
+
+	e := <-ended
+	require.Equal(t, "", e.reason)
+	require.Len(t, e.stats, 1)
+	require.Equal(t, "myhost", e.stats[0].SourceDescription.CNAME)
+	require.Equal(t, "mytool", e.stats[0].SourceDescription.Tool)
+}
+
+func TestServerRecordPacketBufferReuse(t *testing.T) {
+	for _, ca := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			received := make(chan []byte, 4)
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						ctx.Session.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+							// packets are valid only until the callback returns
+							received <- append([]byte(nil), pkt.Payload...)
+						})
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				UDPRTPAddress:           "127.0.0.1:8000",
+				UDPRTCPAddress:          "127.0.0.1:8001",
+				RTSPAddress:             "localhost:8554",
+				PacketBufferReuseEnable: true,
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			medi := testH264Media
+
+			c := Client{
+				Transport: func() *Transport {
+					if ca == "udp" {
+						return transportPtr(TransportUDP)
+					}
+					return transportPtr(TransportTCP)
+				}(),
+			}
+
+			err = c.StartRecording("rtsp://localhost:8554/teststream",
+				&description.Session{Medias: []*description.Media{medi}})
+			require.NoError(t, err)
+			defer c.Close()
+
+			// the third packet is sent before the second one, in order to
+			// make the UDP reorderer keep it after its buffer has been reused.
+			for _, seqNum := range []uint16{0, 2, 1, 3} {
+				pkt := testRTPPacket
+				pkt.SequenceNumber = seqNum
+				pkt.Payload = []byte{byte(seqNum), byte(seqNum), byte(seqNum), byte(seqNum)}
+
+				err = c.WritePacketRTP(medi, &pkt)
+				require.NoError(t, err)
+
+				if ca == "udp" {
+					// preserve the sending order
+					time.Sleep(10 * time.Millisecond)
+				}
+			}
+
+			expected := [][]byte{{0, 0, 0, 0}, {1, 1, 1, 1}, {2, 2, 2, 2}, {3, 3, 3, 3}}
+			if ca == "tcp" {
+				expected = [][]byte{{0, 0, 0, 0}, {2, 2, 2, 2}, {1, 1, 1, 1}, {3, 3, 3, 3}}
+			}
+
+			for _, exp := range expected {
+				require.Equal(t, exp, <-received)
+			}
+		})
+	}
+}
+
+func TestServerRecordUDPBatch(t *testing.T) {
+	received := make(chan uint16, 16)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				ctx.Session.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+					received <- pkt.SequenceNumber
+				})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		UDPRTPAddress:  "127.0.0.1:8000",
+		UDPRTCPAddress: "127.0.0.1:8001",
+		RTSPAddress:    "localhost:8554",
+		UDPBatchSize:   8,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medi := testH264Media
+
+	c := Client{
+		Transport: transportPtr(TransportUDP),
+	}
+
+	err = c.StartRecording("rtsp://localhost:8554/teststream",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := uint16(0); i < 16; i++ {
+		pkt := testRTPPacket
+		pkt.SequenceNumber = i
+		err = c.WritePacketRTP(medi, &pkt)
+		require.NoError(t, err)
+	}
+
+	for i := uint16(0); i < 16; i++ {
+		require.Equal(t, i, <-received)
+	}
+}
+
+type testServerHandlerStreamUpdate struct {
+	*testServerHandler
+	onStreamUpdate func(*ServerHandlerOnStreamUpdateCtx) (*base.Response, error)
+}
+
+func (sh *testServerHandlerStreamUpdate) OnStreamUpdate(
+	ctx *ServerHandlerOnStreamUpdateCtx,
+) (*base.Response, error) {
+	return sh.onStreamUpdate(ctx)
+}
+
+func TestServerRecordStreamUpdate(t *testing.T) {
+	testSPS2 := []byte{0x67, 0x64, 0x00, 0x28, 0xac, 0xb4, 0x03, 0xc0, 0x11, 0x3f, 0x2a}
+
+	received := make(chan format.Format, 1)
+	updated := make(chan *description.Session, 1)
+
+	s := &Server{
+		Handler: &testServerHandlerStreamUpdate{
+			testServerHandler: &testServerHandler{
+				onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil
+				},
+				onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil, nil
+				},
+				onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+					medi := ctx.Session.AnnouncedDescription().Medias[0]
+					ss := ctx.Session
+
+					ctx.Session.OnPacketRTP(medi, medi.Formats[0], func(_ *rtp.Packet) {
+						received <- ss.AnnouncedDescription().Medias[0].Formats[0]
+					})
+
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil
+				},
+			},
+			onStreamUpdate: func(ctx *ServerHandlerOnStreamUpdateCtx) (*base.Response, error) {
+				updated <- ctx.Description
+				require.Equal(t, ctx.Description, ctx.Session.AnnouncedDescription())
+				require.Equal(t, ctx.Description.Medias, ctx.Session.SetuppedMedias())
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	newMedias := func(sps []byte) []*description.Media {
+		return []*description.Media{{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				SPS:               sps,
+				PPS:               []byte{0x44, 0x01, 0xc0, 0x25, 0x2f, 0x05, 0x32, 0x40},
+				PacketizationMode: 1,
+			}},
+		}}
+	}
+
+	doAnnounce(t, conn, "rtsp://localhost:8554/teststream", newMedias(testH264Media.Formats[0].(*format.H264).SPS))
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       deliveryPtr(headers.TransportDeliveryUnicast),
+		Mode:           transportModePtr(headers.TransportModeRecord),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, "rtsp://localhost:8554/teststream/trackID=0", inTH, "")
+
+	session := readSession(t, res)
+
+	doRecord(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: 0,
+		Payload: testRTPPacketMarshaled,
+	}, make([]byte, 1024))
+	require.NoError(t, err)
+
+	forma := <-received
+	require.Equal(t, testH264Media.Formats[0].(*format.H264).SPS, forma.(*format.H264).SPS)
+
+	// same medias with different formats are accepted
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Announce,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":         base.HeaderValue{"4"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+			"Session":      base.HeaderValue{session},
+		},
+		Body: mediasToSDP(newMedias(testSPS2)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	desc := <-updated
+	require.Equal(t, testSPS2, desc.Medias[0].Formats[0].(*format.H264).SPS)
+
+	err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: 0,
+		Payload: testRTPPacketMarshaled,
+	}, make([]byte, 1024))
+	require.NoError(t, err)
+
+	forma = <-received
+	require.Equal(t, testSPS2, forma.(*format.H264).SPS)
+
+	// different medias are refused
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Announce,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":         base.HeaderValue{"5"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+			"Session":      base.HeaderValue{session},
+		},
+		Body: mediasToSDP(append(newMedias(testSPS2), newMedias(testSPS2)...)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusBadRequest, res.StatusCode)
+}
+
+func TestServerRecordRTCPRaw(t *testing.T) {
+	clientApp := mustMarshalPacketRTCP(&rtcpapp.Packet{
+		SubType: 1,
+		SSRC:    0x9dbb7812,
+		Name:    [4]byte{'P', 'T', 'Z', 'C'},
+		Data:    []byte{0x01, 0x02, 0x03, 0x04},
+	})
+
+	serverApp := mustMarshalPacketRTCP(&rtcpapp.Packet{
+		SubType: 2,
+		SSRC:    0x9dbb7812,
+		Name:    [4]byte{'P', 'T', 'Z', 'C'},
+		Data:    []byte{0x05, 0x06, 0x07, 0x08},
+	})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				ctx.Session.OnPacketRTCPRawAny(func(medi *description.Media, payload []byte) {
+					// application-defined packet
+					if payload[1] == 204 {
+						require.Equal(t, clientApp, payload)
+						err := ctx.Session.WritePacketRTCPRaw(medi, serverApp)
+						require.NoError(t, err)
+					}
+				})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	u := mustParseURL("rtsp://localhost:8554/teststream")
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	medias := []*description.Media{testH264Media}
+
+	_, err = c.Announce(u, &description.Session{Medias: medias})
+	require.NoError(t, err)
+
+	err = c.SetupAll(u, medias)
+	require.NoError(t, err)
+
+	recv := make(chan struct{})
+
+	c.OnPacketRTCPRaw(medias[0], func(payload []byte) {
+		require.Equal(t, serverApp, payload)
+		close(recv)
+	})
+
+	_, err = c.Record()
+	require.NoError(t, err)
+
+	err = c.WritePacketRTCPRaw(medias[0], clientApp)
+	require.NoError(t, err)
+
+	err = c.WritePacketRTCPRaw(medias[0], []byte{0x01, 0x02})
+	require.Error(t, err)
+
+	<-recv
+}
+
+func TestServerRecordKeyframeRequest(t *testing.T) {
+	var mutex sync.Mutex
+	var stream *ServerStream
+	firstPacket := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				if ctx.Session.State() == ServerSessionStatePreRecord {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil, nil
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				publisher := ctx.Session
+				desc := publisher.AnnouncedDescription()
+
+				err := publisher.RequestKeyframe(desc.Medias[0])
+				require.Equal(t, liberrors.ErrServerSSRCUnknown{}, err)
+
+				var once sync.Once
+				publisher.OnPacketRTPAny(func(_ *description.Media, _ format.Format, _ *rtp.Packet) {
+					once.Do(func() { close(firstPacket) })
+				})
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				stream = NewServerStream(ctx.Session.s, desc)
+				stream.OnKeyframeRequest(func(medi *description.Media) {
+					err2 := publisher.RequestKeyframe(medi)
+					require.NoError(t, err2)
+
+					err2 = publisher.RequestKeyframeFIR(medi)
+					require.NoError(t, err2)
+				})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	defer func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if stream != nil {
+			stream.Close()
+		}
+	}()
+
+	medi := &description.Media{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		}},
+	}
+
+	publisher := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	keyframeRequests := make(chan *description.Media, 2)
+
+	publisher.OnKeyframeRequest(func(m *description.Media) {
+		keyframeRequests <- m
+	})
+
+	err = publisher.StartRecording("rtsp://localhost:8554/teststream",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	err = publisher.WritePacketRTP(medi, &testRTPPacket)
+	require.NoError(t, err)
+
+	<-firstPacket
+
+	reader := Client{
+		Transport: transportPtr(TransportTCP),
+	}
+
+	err = readAll(&reader, "rtsp://localhost:8554/teststream", nil)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	// PLI and FIR
+	require.Equal(t, medi, <-keyframeRequests)
+	require.Equal(t, medi, <-keyframeRequests)
+}
+
+func TestServerRecordLimits(t *testing.T) {
+	for _, ca := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var session *ServerSession
+			received := make(chan uint16, 16)
+			limitErrors := make(chan error, 16)
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						err := ctx.Session.SetRecordLimits(&ServerRecordLimits{
+							MaxQueuedFrames:     4,
+							MaxQueuedBytes:      1000,
+							MaxPacketSize:       100,
+							MaxReorderedPackets: 2,
+						})
+						require.NoError(t, err)
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						session = ctx.Session
+
+						ctx.Session.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+							received <- pkt.SequenceNumber
+						})
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onRecordLimit: func(ctx *ServerHandlerOnRecordLimitExceededCtx) {
+						limitErrors <- ctx.Error
+					},
+				},
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+				RTSPAddress:    "localhost:8554",
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			medi := testH264Media
+
+			c := Client{
+				Transport: func() *Transport {
+					if ca == "udp" {
+						return transportPtr(TransportUDP)
+					}
+					return transportPtr(TransportTCP)
+				}(),
+			}
+
+			err = c.StartRecording("rtsp://localhost:8554/teststream",
+				&description.Session{Medias: []*description.Media{medi}})
+			require.NoError(t, err)
+			defer c.Close()
+
+			for seqNum := uint16(0); seqNum < 6; seqNum++ {
+				pkt := testRTPPacket
+				pkt.SequenceNumber = seqNum
+
+				// the second packet exceeds MaxPacketSize
+				if seqNum == 1 {
+					pkt.Payload = bytes.Repeat([]byte{1}, 200)
+				}
+
+				err = c.WritePacketRTP(medi, &pkt)
+				require.NoError(t, err)
+
+				if ca == "udp" {
+					// preserve the sending order
+					time.Sleep(10 * time.Millisecond)
+				}
+			}
+
+			require.Equal(t, liberrors.ErrServerRecordPacketTooBig{Size: 212, Max: 100}, <-limitErrors)
+
+			if ca == "udp" {
+				// the missing packet is skipped when the reorder buffer is full
+				require.Equal(t, liberrors.ErrServerRecordReorderBufferFull{Skipped: 1}, <-limitErrors)
+			}
+
+			for _, exp := range []uint16{0, 2, 3, 4, 5} {
+				require.Equal(t, exp, <-received)
+			}
+
+			stats := session.RecordLimitsStats()
+			require.Equal(t, uint64(1), stats.PacketsTooBig)
+
+			if ca == "udp" {
+				require.Equal(t, uint64(1), stats.ReorderSkipped)
+			} else {
+				require.Equal(t, uint64(0), stats.ReorderSkipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package gortsplib
+
+import (
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+)
+
+// ServerRoute is a route of a ServerRouter.
+type ServerRoute struct {
+	// host that requests must be addressed to (optional).
+	// It is compared with the host of the request URL, without port and case-insensitively.
+	// It can start with "*." in order to match all subdomains of a domain.
+	// It defaults to any host.
+	Host string
+
+	// pattern that the path of requests must match.
+	// Segments are separated by slashes, and can be:
+	// - literals, that must match exactly;
+	// - "{name}", that matches a single segment and captures it as the "name" parameter;
+	// - "*", as last segment, that matches zero or more segments and captures them as the "*" parameter.
+	// For instance, "/cam/{id}/stream" matches "/cam/1/stream" with parameter "id" equal to "1".
+	Path string
+
+	// handler of requests that match the route.
+	// It may implement one or more of ServerHandlerOnDescribe, ServerHandlerOnAnnounce, ServerHandlerOnSetup.
+	Handler ServerHandler
+}
+
+// ServerRouter dispatches DESCRIBE, ANNOUNCE and SETUP requests of a Server
+// to handlers by path and host.
+// Routes are evaluated in order, and the first one that matches the request
+// and whose handler implements the method of the request is used.
+type ServerRouter struct {
+	Routes []ServerRoute
+}
+
+func routeHandlesMethod(h ServerHandler, method base.Method) bool {
+	switch method {
+	case base.Describe:
+		_, ok := h.(ServerHandlerOnDescribe)
+		return ok
+
+	case base.Announce:
+		_, ok := h.(ServerHandlerOnAnnounce)
+		return ok
+
+	case base.Setup:
+		_, ok := h.(ServerHandlerOnSetup)
+		return ok
+	}
+
+	return false
+}
+
+func routeMatchHost(pattern string, host string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(host)
+
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+
+	return host == pattern
+}
+
+func routeSplitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func routeMatchPath(pattern string, path string) (map[string]string, bool) {
+	patternSegments := routeSplitPath(pattern)
+	pathSegments := routeSplitPath(path)
+
+	params := make(map[string]string)
+
+	for i, seg := range patternSegments {
+		if seg == "*" && i == len(patternSegments)-1 {
+			if i < len(pathSegments) {
+				params["*"] = strings.Join(pathSegments[i:], "/")
+			} else {
+				params["*"] = ""
+			}
+			return params, true
+		}
+
+		if i >= len(pathSegments) {
+			return nil, false
+		}
+
+		if len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}' {
+			params[seg[1:len(seg)-1]] = pathSegments[i]
+			continue
+		}
+
+		if seg != pathSegments[i] {
+			return nil, false
+		}
+	}
+
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+func (r *ServerRouter) match(method base.Method, host string, path string) (ServerHandler, map[string]string) {
+	for _, route := range r.Routes {
+		if !routeHandlesMethod(route.Handler, method) || !routeMatchHost(route.Host, host) {
+			continue
+		}
+
+		if params, ok := routeMatchPath(route.Path, path); ok {
+			return route.Handler, params
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *ServerRouter) handlesMethod(method base.Method) bool {
+	for _, route := range r.Routes {
+		if routeHandlesMethod(route.Handler, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// handlesMethod returns whether the server handles DESCRIBE, ANNOUNCE or SETUP requests,
+// through the router or the handler.
+func (s *Server) handlesMethod(method base.Method) bool {
+	if s.Router != nil && s.Router.handlesMethod(method) {
+		return true
+	}
+	return routeHandlesMethod(s.Handler, method)
+}
+
+// routeRequest returns the handler of a DESCRIBE, ANNOUNCE or SETUP request,
+// and the parameters captured by the route.
+// Requests that don't match any route are handled by the handler.
+func (s *Server) routeRequest(method base.Method, u *base.URL, path string) (ServerHandler, map[string]string) {
+	if s.Router != nil {
+		if h, params := s.Router.match(method, u.Hostname(), path); h != nil {
+			return h, params
+		}
+	}
+
+	if routeHandlesMethod(s.Handler, method) {
+		return s.Handler, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,195 @@
+package gortsplib
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/conn"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+)
+
+func TestServerRouteMatchPath(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		pattern string
+		path    string
+		params  map[string]string
+	}{
+		{
+			"literal",
+			"/teststream",
+			"/teststream",
+			map[string]string{},
+		},
+		{
+			"literal mismatch",
+			"/teststream",
+			"/otherstream",
+			nil,
+		},
+		{
+			"root",
+			"/",
+			"/",
+			map[string]string{},
+		},
+		{
+			"parameters",
+			"/cam/{id}/{profile}",
+			"/cam/12/main",
+			map[string]string{"id": "12", "profile": "main"},
+		},
+		{
+			"parameters too short",
+			"/cam/{id}/stream",
+			"/cam/12",
+			nil,
+		},
+		{
+			"parameters too long",
+			"/cam/{id}",
+			"/cam/12/stream",
+			nil,
+		},
+		{
+			"wildcard",
+			"/tenant/{name}/*",
+			"/tenant/a/b/c",
+			map[string]string{"name": "a", "*": "b/c"},
+		},
+		{
+			"wildcard empty",
+			"/tenant/*",
+			"/tenant",
+			map[string]string{"*": ""},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			params, ok := routeMatchPath(ca.pattern, ca.path)
+			require.Equal(t, ca.params != nil, ok)
+			if ok {
+				require.Equal(t, ca.params, params)
+			}
+		})
+	}
+}
+
+func TestServerRouteMatchHost(t *testing.T) {
+	require.Equal(t, true, routeMatchHost("", "myhost"))
+	require.Equal(t, true, routeMatchHost("MyHost", "myhost"))
+	require.Equal(t, false, routeMatchHost("myhost", "otherhost"))
+	require.Equal(t, true, routeMatchHost("*.example.com", "cam.example.com"))
+	require.Equal(t, false, routeMatchHost("*.example.com", "example.com"))
+}
+
+func TestServerRouter(t *testing.T) {
+	var stream *ServerStream
+	describeParams := make(chan map[string]string, 1)
+	setupParams := make(chan map[string]string, 1)
+
+	s := &Server{
+		Router: &ServerRouter{
+			Routes: []ServerRoute{
+				{
+					Host: "*.example.com",
+					Path: "/tenant/*",
+					Handler: &testServerHandler{
+						onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+							describeParams <- ctx.Params
+							return &base.Response{
+								StatusCode: base.StatusOK,
+							}, stream, nil
+						},
+					},
+				},
+				{
+					Path: "/cam/{id}/stream",
+					Handler: &testServerHandler{
+						onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+							describeParams <- ctx.Params
+							return &base.Response{
+								StatusCode: base.StatusOK,
+							}, stream, nil
+						},
+						onSetup: func(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+							setupParams <- ctx.Params
+							return &base.Response{
+								StatusCode: base.StatusOK,
+							}, stream, nil
+						},
+					},
+				},
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = NewServerStream(s, &description.Session{Medias: []*description.Media{testH264Media}})
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(nconn)
+
+	res, err := writeReqReadRes(conn, base.Request{
+		Method: base.Options,
+		URL:    mustParseURL("rtsp://localhost:8554/"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, base.HeaderValue{"DESCRIBE, ANNOUNCE, SETUP, GET_PARAMETER, TEARDOWN"}, res.Header["Public"])
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Describe,
+		URL:    mustParseURL("rtsp://localhost:8554/cam/12/stream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"2"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, map[string]string{"id": "12"}, <-describeParams)
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Describe,
+		URL:    mustParseURL("rtsp://cam1.example.com:8554/tenant/a/b"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"3"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, map[string]string{"*": "a/b"}, <-describeParams)
+
+	inTH := &headers.Transport{
+		Mode:           transportModePtr(headers.TransportModePlay),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ = doSetup(t, conn, "rtsp://localhost:8554/cam/12/stream/trackID=0", inTH, "")
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, map[string]string{"id": "12"}, <-setupParams)
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Describe,
+		URL:    mustParseURL("rtsp://localhost:8554/tenant/a/b"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"4"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusNotFound, res.StatusCode)
+}
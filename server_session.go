@@ -2,11 +2,13 @@ package gortsplib
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,10 +17,15 @@ import (
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/chaos"
+	"github.com/bluenviron/gortsplib/v4/pkg/clock"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpav1"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/logger"
+	"github.com/bluenviron/gortsplib/v4/pkg/parameters"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtptime"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 )
@@ -102,13 +109,13 @@ func findMediaByTrackID(medias []*description.Media, trackID string) *descriptio
 	return medias[id]
 }
 
-func findFirstSupportedTransportHeader(s *Server, tsh headers.Transports) *headers.Transport {
+func findFirstSupportedTransportHeader(s *Server, tsh headers.Transports, clientIP net.IP) *headers.Transport {
 	// Per RFC2326 section 12.39, client specifies transports in order of preference.
 	// Filter out the ones we don't support and then pick first supported transport.
 	for _, tr := range tsh {
 		isMulticast := tr.Delivery != nil && *tr.Delivery == headers.TransportDeliveryMulticast
 		if tr.Protocol == headers.TransportProtocolUDP &&
-			((!isMulticast && s.udpRTPListener == nil) ||
+			((!isMulticast && (s.udpRTPListener == nil || !s.udpRTPListener.reaches(clientIP))) ||
 				(isMulticast && s.MulticastIPRange == "")) {
 			continue
 		}
@@ -117,6 +124,48 @@ func findFirstSupportedTransportHeader(s *Server, tsh headers.Transports) *heade
 	return nil
 }
 
+func readAnnouncedDescription(req *base.Request, path string) (*description.Session, error) {
+	ct, ok := req.Header["Content-Type"]
+	if !ok || len(ct) != 1 {
+		return nil, liberrors.ErrServerContentTypeMissing{}
+	}
+
+	if ct[0] != "application/sdp" {
+		return nil, liberrors.ErrServerContentTypeUnsupported{CT: ct}
+	}
+
+	var ssd sdp.SessionDescription
+	err := ssd.Unmarshal(req.Body)
+	if err != nil {
+		return nil, liberrors.ErrServerSDPInvalid{Err: err}
+	}
+
+	var desc description.Session
+	err = desc.Unmarshal(&ssd)
+	if err != nil {
+		return nil, liberrors.ErrServerSDPInvalid{Err: err}
+	}
+
+	for _, medi := range desc.Medias {
+		mediURL, err := medi.URL(req.URL)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate media URL")
+		}
+
+		mediPath, ok := mediURL.RTSPPathAndQuery()
+		if !ok {
+			return nil, fmt.Errorf("invalid media URL (%v)", mediURL)
+		}
+
+		if !strings.HasPrefix(mediPath, path) {
+			return nil, fmt.Errorf("invalid media path: must begin with '%s', but is '%s'",
+				path, mediPath)
+		}
+	}
+
+	return &desc, nil
+}
+
 func generateRTPInfo(
 	now time.Time,
 	setuppedMediasOrdered []*serverSessionMedia,
@@ -181,6 +230,9 @@ type ServerSession struct {
 	secretID string // must not be shared, allows to take ownership of the session
 	author   *ServerConn
 
+	metricsSessionID string
+	readerShard      int // shard of the active readers of streams
+
 	ctx                   context.Context
 	ctxCancel             func()
 	bytesReceived         *uint64
@@ -191,51 +243,115 @@ type ServerSession struct {
 	setuppedMedias        map[*description.Media]*serverSessionMedia
 	setuppedMediasOrdered []*serverSessionMedia
 	tcpCallbackByChannel  map[int]readFunc
+	onInterleavedFrame    OnInterleavedFrameFunc
 	setuppedTransport     *Transport
 	setuppedStream        *ServerStream // read
+	streamMutex           sync.RWMutex  // read, protects setuppedStream and medias against moves
 	setuppedPath          string
 	setuppedQuery         string
-	lastRequestTime       time.Time
+	lastRequestTime       *int64
+	timeout               *int64
 	tcpConn               *ServerConn
 	announcedDesc         *description.Session // publish
-	udpLastPacketTime     *int64               // publish
-	udpCheckStreamTimer   *time.Timer
+	recordAppend          bool                 // publish
+	udpLastRTPTime        *int64               // publish
+	udpLastRTCPTime       *int64
+	streamEnded           *int32 // publish
+	started               *int32
+	switchingRendition    *int32 // read
+	udpCheckStreamTimer   clock.Timer
 	writer                asyncProcessor
+	udpPendingMedias      []*serverSessionMedia // read, accessed by the writer only
 	timeDecoder           *rtptime.GlobalDecoder
+	bitrateLimiter        *bitrateLimiter     // read
+	throttleNotified      bool                // read
+	writeQueue            *ServerWriteQueue   // read
+	recordLimits          *ServerRecordLimits // publish
+	recordCounters        serverRecordLimitsCounters
+	log                   logger.Logger
+	capture               packetCapture
+	impairer              *chaos.Impairer
+	pacer                 *pacer // read, used by the writer
+	draining              bool
+	lastConn              *ServerConn
+	lastRequestURL        *base.URL
+	restored              chan struct{} // restored sessions only
 
 	// in
 	chHandleRequest chan sessionRequestReq
 	chRemoveConn    chan *ServerConn
 	chStartWriter   chan struct{}
+	chDrain         chan struct{}
+	chWriteOverflow chan struct{}
+	chThrottle      chan struct{}
+	chMoveReader    chan serverSessionMoveReaderReq
+	chConn          chan serverSessionConnReq
 }
 
 func newServerSession(
 	s *Server,
 	author *ServerConn,
 ) *ServerSession {
-	ctx, ctxCancel := context.WithCancel(s.ctx)
-
 	// use an UUID without dashes, since dashes confuse some clients.
 	secretID := strings.ReplaceAll(uuid.New().String(), "-", "")
 
+	ss := allocServerSession(s, author, secretID)
+
+	s.wg.Add(1)
+	go ss.run()
+
+	return ss
+}
+
+func allocServerSession(
+	s *Server,
+	author *ServerConn,
+	secretID string,
+) *ServerSession {
+	ctx, ctxCancel := context.WithCancel(s.ctx)
+
+	metricsSessionID := s.metrics.newSessionID()
+
 	ss := &ServerSession{
 		s:                   s,
 		secretID:            secretID,
 		author:              author,
+		metricsSessionID:    metricsSessionID,
+		readerShard:         serverStreamReadersShardOf(secretID),
+		log:                 logger.With(s.Logger, "session", metricsSessionID),
 		ctx:                 ctx,
 		ctxCancel:           ctxCancel,
 		bytesReceived:       new(uint64),
 		bytesSent:           new(uint64),
+		recordCounters:      newServerRecordLimitsCounters(),
+		started:             new(int32),
+		switchingRendition:  new(int32),
 		conns:               make(map[*ServerConn]struct{}),
-		lastRequestTime:     s.timeNow(),
+		lastRequestTime:     new(int64),
+		timeout:             new(int64),
+		udpLastRTPTime:      new(int64),
+		udpLastRTCPTime:     new(int64),
 		udpCheckStreamTimer: emptyTimer(),
 		chHandleRequest:     make(chan sessionRequestReq),
 		chRemoveConn:        make(chan *ServerConn),
 		chStartWriter:       make(chan struct{}),
+		chDrain:             make(chan struct{}, 1),
+		chWriteOverflow:     make(chan struct{}, 1),
+		chThrottle:          make(chan struct{}, 1),
+		chMoveReader:        make(chan serverSessionMoveReaderReq),
+		chConn:              make(chan serverSessionConnReq),
 	}
 
-	s.wg.Add(1)
-	go ss.run()
+	*ss.lastRequestTime = s.timeNow().UnixNano()
+
+	if s.MaxSessionBitrate != 0 {
+		ss.bitrateLimiter = newBitrateLimiter(s.MaxSessionBitrate, s.timeNow)
+	}
+
+	ss.impairer = &chaos.Impairer{Clock: s.Clock}
+	ss.impairer.Init()
+
+	ss.pacer = newPacer(s.Clock, ss.writer.queued)
 
 	return ss
 }
@@ -267,6 +383,8 @@ func (ss *ServerSession) SetuppedTransport() *Transport {
 
 // SetuppedStream returns the stream associated with the session.
 func (ss *ServerSession) SetuppedStream() *ServerStream {
+	ss.streamMutex.RLock()
+	defer ss.streamMutex.RUnlock()
 	return ss.setuppedStream
 }
 
@@ -294,6 +412,53 @@ func (ss *ServerSession) SetuppedMedias() []*description.Media {
 	return ret
 }
 
+// SetAV1Layers sets the maximum temporal and spatial layers of the AV1 format of a setupped media.
+// OBUs that belong to higher enhancement layers are removed from packets sent to the session,
+// allowing to adapt the bitrate of scalable streams to the bandwidth of the reader.
+// A nil value removes the corresponding limit.
+// It has no effect on multicast sessions.
+// Since sequence numbers of filtered packets are rewritten, retransmissions (RTX) of the format
+// are disabled for the session.
+func (ss *ServerSession) SetAV1Layers(medi *description.Media, maxTemporalID *uint8, maxSpatialID *uint8) error {
+	sm, ok := ss.setuppedMedias[medi]
+	if !ok {
+		return liberrors.ErrServerMediaNotFound{}
+	}
+
+	hasAV1 := false
+	for _, forma := range medi.Formats {
+		if _, ok := forma.(*format.AV1); ok {
+			hasAV1 = true
+			break
+		}
+	}
+	if !hasAV1 {
+		return fmt.Errorf("media does not contain an AV1 format")
+	}
+
+	sm.av1LayerFilterMutex.Lock()
+	defer sm.av1LayerFilterMutex.Unlock()
+
+	// the filter is never replaced, in order to keep sequence numbers consistent
+	if sm.av1LayerFilter == nil {
+		sm.av1LayerFilter = &rtpav1.LayerFilter{}
+	}
+
+	sm.av1LayerFilter.MaxTemporalID = nil
+	if maxTemporalID != nil {
+		v := *maxTemporalID
+		sm.av1LayerFilter.MaxTemporalID = &v
+	}
+
+	sm.av1LayerFilter.MaxSpatialID = nil
+	if maxSpatialID != nil {
+		v := *maxSpatialID
+		sm.av1LayerFilter.MaxSpatialID = &v
+	}
+
+	return nil
+}
+
 // SetUserData sets some user data associated to the session.
 func (ss *ServerSession) SetUserData(v interface{}) {
 	ss.userData = v
@@ -304,6 +469,141 @@ func (ss *ServerSession) UserData() interface{} {
 	return ss.userData
 }
 
+// SetWriteQueue sets the parameters of the write queue of the session,
+// that is used to send packets to readers.
+// It must be called before PLAY, for instance inside OnSetup().
+func (ss *ServerSession) SetWriteQueue(q *ServerWriteQueue) error {
+	err := q.validate()
+	if err != nil {
+		return err
+	}
+
+	ss.writeQueue = q
+	return nil
+}
+
+// WriteQueueDropped returns the number of packets that were discarded
+// since the write queue was full.
+func (ss *ServerSession) WriteQueueDropped() uint64 {
+	return atomic.LoadUint64(&ss.writer.dropped)
+}
+
+// SetRecordLimits sets limits on the data received from the session,
+// overriding Server.RecordLimits.
+// It must be called before RECORD, for instance inside OnAnnounce().
+func (ss *ServerSession) SetRecordLimits(l *ServerRecordLimits) error {
+	err := l.validate()
+	if err != nil {
+		return err
+	}
+
+	ss.recordLimits = l
+	return nil
+}
+
+// RecordLimitsStats returns counters about the limits on the data received from the session.
+func (ss *ServerSession) RecordLimitsStats() ServerRecordLimitsStats {
+	return ss.recordCounters.stats()
+}
+
+func (ss *ServerSession) effectiveRecordLimits() *ServerRecordLimits {
+	if ss.recordLimits != nil {
+		return ss.recordLimits
+	}
+	return ss.s.RecordLimits
+}
+
+// SetTimeout sets the timeout of the session, overriding Server.SessionTimeout.
+// The new timeout is advertised to the client in the following responses
+// and is enforced immediately.
+// It can be called at any time. A zero value restores Server.SessionTimeout.
+func (ss *ServerSession) SetTimeout(timeout time.Duration) {
+	atomic.StoreInt64(ss.timeout, int64(timeout))
+}
+
+func (ss *ServerSession) sessionTimeout() time.Duration {
+	if v := atomic.LoadInt64(ss.timeout); v != 0 {
+		return time.Duration(v)
+	}
+	return ss.s.SessionTimeout
+}
+
+// StartPacketCapture starts writing RTSP messages, RTP and RTCP packets
+// exchanged with the client into w, in the pcapng format.
+// It can be called at any time, and replaces any previous capture.
+func (ss *ServerSession) StartPacketCapture(w io.Writer) error {
+	return ss.capture.start(w)
+}
+
+// StopPacketCapture stops writing packets into the capture started with StartPacketCapture.
+func (ss *ServerSession) StopPacketCapture() {
+	ss.capture.stop()
+}
+
+// SetImpairment sets impairments (loss, duplication, reordering, jitter)
+// to apply to RTP and RTCP packets received from the client, with any transport,
+// in order to test the session against realistic network conditions.
+// It can be called at any time. A nil value disables impairments.
+func (ss *ServerSession) SetImpairment(conf *chaos.Config) {
+	ss.impairer.SetConfig(conf)
+}
+
+// SetPacingWindow sets the period of time over which bursts of RTP packets
+// directed to the session are spread, overriding ServerStream.PacingWindow.
+// It can be called at any time. A negative value restores the window of the stream,
+// while zero disables pacing.
+func (ss *ServerSession) SetPacingWindow(window time.Duration) {
+	ss.pacer.setWindow(window)
+}
+
+func (ss *ServerSession) allocateWriteQueue() {
+	size := ss.s.WriteQueueSize
+	ss.writer.maxBytes = 0
+	ss.writer.policy = ServerWriteQueuePolicyDropNewest
+
+	if q := ss.writeQueue; q != nil {
+		if q.Size != 0 {
+			size = q.Size
+		}
+
+		ss.writer.maxBytes = uint64(q.MaxBytes)
+		ss.writer.policy = q.Policy
+
+		ss.writer.blockTimeout = q.BlockTimeout
+		if ss.writer.blockTimeout == 0 {
+			ss.writer.blockTimeout = ss.s.WriteTimeout
+		}
+	}
+
+	ss.writer.onIdle = ss.flushPendingPacketsRTP
+	ss.writer.allocateBuffer(size)
+}
+
+// flushPendingPacketsRTP writes RTP packets that have been queued
+// in order to be written together. It is called by the writer.
+func (ss *ServerSession) flushPendingPacketsRTP() {
+	for _, sm := range ss.udpPendingMedias {
+		sm.flushPendingPacketsRTP()
+	}
+	ss.udpPendingMedias = ss.udpPendingMedias[:0]
+}
+
+func (ss *ServerSession) pushWrite(size int, cb func()) error {
+	ok := ss.writer.pushSized(size, cb)
+	if !ok {
+		if ss.writer.policy == ServerWriteQueuePolicyDisconnect {
+			select {
+			case ss.chWriteOverflow <- struct{}{}:
+			default:
+			}
+		}
+
+		return liberrors.ErrServerWriteQueueFull{}
+	}
+
+	return nil
+}
+
 func (ss *ServerSession) onPacketLost(err error) {
 	if h, ok := ss.s.Handler.(ServerHandlerOnPacketLost); ok {
 		h.OnPacketLost(&ServerHandlerOnPacketLostCtx{
@@ -311,7 +611,7 @@ func (ss *ServerSession) onPacketLost(err error) {
 			Error:   err,
 		})
 	} else {
-		log.Println(err.Error())
+		ss.log.Warn("packets lost", "err", err)
 	}
 }
 
@@ -322,10 +622,33 @@ func (ss *ServerSession) onDecodeError(err error) {
 			Error:   err,
 		})
 	} else {
-		log.Println(err.Error())
+		ss.log.Warn("decode error", "err", err)
 	}
 }
 
+func (ss *ServerSession) onRecordLimitExceeded(err error) {
+	if h, ok := ss.s.Handler.(ServerHandlerOnRecordLimitExceeded); ok {
+		h.OnRecordLimitExceeded(&ServerHandlerOnRecordLimitExceededCtx{
+			Session: ss,
+			Error:   err,
+		})
+	} else {
+		ss.log.Warn("record limit exceeded", "err", err)
+	}
+}
+
+// checkRecordPacketSize discards packets that exceed ServerRecordLimits.MaxPacketSize.
+func (ss *ServerSession) checkRecordPacketSize(size int) bool {
+	l := ss.effectiveRecordLimits()
+	if l == nil || l.MaxPacketSize == 0 || size <= l.MaxPacketSize {
+		return true
+	}
+
+	atomic.AddUint64(ss.recordCounters.packetsTooBig, 1)
+	ss.onRecordLimitExceeded(liberrors.ErrServerRecordPacketTooBig{Size: size, Max: l.MaxPacketSize})
+	return false
+}
+
 func (ss *ServerSession) onStreamWriteError(err error) {
 	if h, ok := ss.s.Handler.(ServerHandlerOnStreamWriteError); ok {
 		h.OnStreamWriteError(&ServerHandlerOnStreamWriteErrorCtx{
@@ -333,7 +656,48 @@ func (ss *ServerSession) onStreamWriteError(err error) {
 			Error:   err,
 		})
 	} else {
-		log.Println(err.Error())
+		ss.log.Warn("stream write error", "err", err)
+	}
+}
+
+func (ss *ServerSession) allowPacketRTP(
+	sm *serverSessionMedia,
+	forma format.Format,
+	pkt *rtp.Packet,
+	size int,
+) bool {
+	if ss.bitrateLimiter == nil {
+		return true
+	}
+
+	// video access units end with the marker bit
+	last := sm.media.Type != description.MediaTypeVideo || pkt.Marker
+
+	ok, changed := ss.bitrateLimiter.allow(&sm.bitrateUnit, size, isDiscardable(forma, pkt), last)
+
+	// OnThrottle() is called by the session routine, since the stream is locked here
+	if changed {
+		select {
+		case ss.chThrottle <- struct{}{}:
+		default:
+		}
+	}
+
+	return ok
+}
+
+func (ss *ServerSession) notifyThrottle() {
+	throttled := ss.bitrateLimiter.isThrottled()
+	if throttled == ss.throttleNotified {
+		return
+	}
+	ss.throttleNotified = throttled
+
+	if h, ok := ss.s.Handler.(ServerHandlerOnThrottle); ok {
+		h.OnThrottle(&ServerHandlerOnThrottleCtx{
+			Session:   ss,
+			Throttled: throttled,
+		})
 	}
 }
 
@@ -361,6 +725,14 @@ func (ss *ServerSession) run() {
 		})
 	}
 
+	if ss.restored != nil {
+		ss.startRestored()
+	}
+
+	ss.s.metrics.sessions.Add(1)
+	ss.s.events.sessionCreated(EventSource{Conn: ss.author, Session: ss})
+	ss.log.Info("session opened")
+
 	err := ss.runInner()
 
 	ss.ctxCancel()
@@ -386,6 +758,7 @@ func (ss *ServerSession) run() {
 
 	for _, sm := range ss.setuppedMedias {
 		sm.stop()
+		sm.deleteMetrics()
 	}
 
 	ss.s.closeSession(ss)
@@ -396,18 +769,29 @@ func (ss *ServerSession) run() {
 			Error:   err,
 		})
 	}
+
+	ss.s.metrics.sessions.Add(-1)
+	ss.s.events.sessionDestroyed(EventSource{Session: ss}, err)
+	ss.log.Info("session closed", "err", err)
 }
 
 func (ss *ServerSession) runInner() error {
 	for {
 		select {
 		case req := <-ss.chHandleRequest:
-			ss.lastRequestTime = ss.s.timeNow()
+			atomic.StoreInt64(ss.lastRequestTime, ss.s.timeNow().UnixNano())
 
 			if _, ok := ss.conns[req.sc]; !ok {
 				ss.conns[req.sc] = struct{}{}
 			}
 
+			ss.lastConn = req.sc
+
+			// SETUP URLs point to medias
+			if req.req.Method != base.Setup || ss.lastRequestURL == nil {
+				ss.lastRequestURL = req.req.URL
+			}
+
 			res, err := ss.handleRequestInner(req.sc, req.req)
 
 			returnedSession := ss
@@ -430,7 +814,7 @@ func (ss *ServerSession) runInner() error {
 								ss.state == ServerSessionStatePlay) &&
 								(*ss.setuppedTransport == TransportUDP ||
 									*ss.setuppedTransport == TransportUDPMulticast) {
-								v := uint(ss.s.sessionTimeout / time.Second)
+								v := uint(ss.sessionTimeout() / time.Second)
 								return &v
 							}
 							return nil
@@ -477,31 +861,285 @@ func (ss *ServerSession) runInner() error {
 				ss.writer.start()
 			}
 
-		case <-ss.udpCheckStreamTimer.C:
-			now := ss.s.timeNow()
+		case <-ss.udpCheckStreamTimer.C():
+			if !ss.isAlive(ss.s.timeNow()) {
+				return liberrors.ErrServerSessionTimedOut{}
+			}
 
-			lft := atomic.LoadInt64(ss.udpLastPacketTime)
+			ss.udpCheckStreamTimer = ss.s.Clock.NewTimer(ss.s.checkStreamPeriod)
 
-			// in case of RECORD, timeout happens when no RTP or RTCP packets are being received
-			if ss.state == ServerSessionStateRecord {
-				if now.Sub(time.Unix(lft, 0)) >= ss.s.ReadTimeout {
-					return liberrors.ErrServerSessionTimedOut{}
-				}
+		case <-ss.chWriteOverflow:
+			return liberrors.ErrServerWriteQueueOverflow{}
 
-				// in case of PLAY, timeout happens when no RTSP keepalives and no RTCP packets are being received
-			} else if now.Sub(ss.lastRequestTime) >= ss.s.sessionTimeout &&
-				now.Sub(time.Unix(lft, 0)) >= ss.s.sessionTimeout {
-				return liberrors.ErrServerSessionTimedOut{}
-			}
+		case <-ss.chThrottle:
+			ss.notifyThrottle()
+
+		case req := <-ss.chMoveReader:
+			ss.doMoveReader(req)
+			close(req.done)
+
+		case req := <-ss.chConn:
+			ss.handleConnRequest(req)
+
+		case <-ss.chDrain:
+			ss.draining = true
+
+			switch ss.state {
+			case ServerSessionStatePlay:
+				ss.writeGoodbye()
+				ss.announceShutdown()
 
-			ss.udpCheckStreamTimer = time.NewTimer(ss.s.checkStreamPeriod)
+			case ServerSessionStateRecord:
+				// wait for the publisher to send TEARDOWN
+
+			default:
+				return liberrors.ErrServerShuttingDown{}
+			}
 
 		case <-ss.ctx.Done():
+			if ss.draining {
+				return liberrors.ErrServerShuttingDown{}
+			}
 			return liberrors.ErrServerTerminated{}
 		}
 	}
 }
 
+// writeGoodbye notifies readers that the stream is ending.
+func (ss *ServerSession) writeGoodbye() {
+	for _, sm := range ss.setuppedMediasOrdered {
+		ssrcs := ss.setuppedStream.senderSSRCs(sm.media)
+		if len(ssrcs) == 0 {
+			continue
+		}
+
+		byts, err := (&rtcp.Goodbye{
+			Sources: ssrcs,
+			Reason:  "server is shutting down",
+		}).Marshal()
+		if err != nil {
+			continue
+		}
+
+		if *ss.setuppedTransport == TransportUDPMulticast {
+			ss.setuppedStream.writePacketRTCPMulticast(sm.media, byts) //nolint:errcheck
+		} else {
+			ss.writePacketRTCP(sm.media, byts) //nolint:errcheck
+		}
+	}
+}
+
+// announceShutdown sends an end-of-stream notice to the client
+// once the packets in the write queue have been written.
+func (ss *ServerSession) announceShutdown() {
+	flushed := make(chan struct{})
+
+	if !ss.writer.running || !ss.writer.pushBarrier(func() { close(flushed) }) {
+		close(flushed)
+	}
+
+	go func() {
+		select {
+		case <-flushed:
+		case <-ss.ctx.Done():
+			return
+		}
+
+		err := ss.AnnounceEndOfStream()
+		if err != nil {
+			ss.log.Debug("unable to announce end of stream", "err", err)
+		}
+	}()
+}
+
+func readPlayHeaders(req *base.Request) (*headers.Range, *headers.Scale, *headers.Speed, error) {
+	var ra *headers.Range
+	if v, ok := req.Header["Range"]; ok {
+		ra = &headers.Range{}
+		err := ra.Unmarshal(v)
+		if err != nil {
+			// some clients send Range values that are not standard;
+			// ignore them instead of refusing the request
+			ra = nil
+		}
+	}
+
+	var scale *headers.Scale
+	if v, ok := req.Header["Scale"]; ok {
+		scale = &headers.Scale{}
+		err := scale.Unmarshal(v)
+		if err != nil {
+			return nil, nil, nil, liberrors.ErrServerScaleHeaderInvalid{Err: err}
+		}
+	}
+
+	var speed *headers.Speed
+	if v, ok := req.Header["Speed"]; ok {
+		speed = &headers.Speed{}
+		err := speed.Unmarshal(v)
+		if err != nil {
+			return nil, nil, nil, liberrors.ErrServerSpeedHeaderInvalid{Err: err}
+		}
+	}
+
+	return ra, scale, speed, nil
+}
+
+// fills RTP-Info, unless it has been already filled by the handler.
+func (ss *ServerSession) fillRTPInfo(res *base.Response, u *base.URL, medias []*serverSessionMedia) {
+	if _, ok := res.Header["RTP-Info"]; ok {
+		return
+	}
+
+	rtpInfo, ok := generateRTPInfo(
+		ss.s.timeNow(),
+		medias,
+		ss.setuppedStream,
+		ss.setuppedPath,
+		u)
+
+	if ok {
+		if res.Header == nil {
+			res.Header = make(base.Header)
+		}
+		res.Header["RTP-Info"] = rtpInfo.Marshal()
+	}
+}
+
+// findPlayingMediaByPath returns the media targeted by a PLAY or PAUSE request,
+// when the session is playing with a unicast transport and the request
+// refers to a single media. In this case, the path of the session is returned too.
+func (ss *ServerSession) findPlayingMediaByPath(path string) (string, *serverSessionMedia) {
+	if ss.state != ServerSessionStatePlay || *ss.setuppedTransport == TransportUDPMulticast {
+		return path, nil
+	}
+
+	i := stringsReverseIndex(path, "/trackID=")
+	if i < 0 || path[:i] != ss.setuppedPath {
+		return path, nil
+	}
+
+	medi := findMediaByTrackID(ss.setuppedStream.desc.Medias, path[i+len("/trackID="):])
+	if medi == nil {
+		return path, nil
+	}
+
+	sm, ok := ss.setuppedMedias[medi]
+	if !ok {
+		return path, nil
+	}
+
+	return path[:i], sm
+}
+
+func (ss *ServerSession) resumeMedia(sm *serverSessionMedia) {
+	if !atomic.CompareAndSwapInt32(sm.paused, 1, 0) {
+		return
+	}
+
+	if ss.s.KeyframeTimeout != 0 {
+		atomic.StoreInt64(sm.keyframeDeadline, ss.s.timeNow().Add(ss.s.KeyframeTimeout).UnixNano())
+	}
+}
+
+// handleStreamUpdate handles an ANNOUNCE request received while publishing,
+// that updates formats of announced medias.
+func (ss *ServerSession) handleStreamUpdate(
+	sc *ServerConn,
+	req *base.Request,
+	path string,
+	query string,
+) (*base.Response, error) {
+	if path != ss.setuppedPath {
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}, liberrors.ErrServerPathHasChanged{Prev: ss.setuppedPath, Cur: path}
+	}
+
+	desc, err := readAnnouncedDescription(req, path)
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}, err
+	}
+
+	prevDesc := ss.announcedDesc
+
+	if len(desc.Medias) != len(prevDesc.Medias) {
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}, liberrors.ErrServerStreamUpdateMediasChanged{}
+	}
+
+	for i, medi := range desc.Medias {
+		if medi.Type != prevDesc.Medias[i].Type {
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}, liberrors.ErrServerStreamUpdateMediasChanged{}
+		}
+	}
+
+	if ss.state == ServerSessionStateRecord {
+		for _, sm := range ss.setuppedMedias {
+			sm.stop()
+		}
+	}
+
+	prevFormats := make(map[*serverSessionMedia]map[uint8]*serverSessionFormat)
+
+	for i, prevMedi := range prevDesc.Medias {
+		sm, ok := ss.setuppedMedias[prevMedi]
+		if !ok {
+			continue
+		}
+
+		prevFormats[sm] = sm.formats
+		sm.updateMedia(desc.Medias[i])
+		delete(ss.setuppedMedias, prevMedi)
+		ss.setuppedMedias[sm.media] = sm
+	}
+
+	ss.announcedDesc = desc
+
+	res, err := ss.s.Handler.(ServerHandlerOnStreamUpdate).OnStreamUpdate(&ServerHandlerOnStreamUpdateCtx{
+		Session:     ss,
+		Conn:        sc,
+		Request:     req,
+		Path:        path,
+		Query:       query,
+		Description: desc,
+	})
+
+	if res.StatusCode != base.StatusOK {
+		for i, medi := range desc.Medias {
+			sm, ok := ss.setuppedMedias[medi]
+			if !ok {
+				continue
+			}
+
+			sm.media = prevDesc.Medias[i]
+			sm.formats = prevFormats[sm]
+			if sm.congestionFeedback != nil {
+				sm.congestionFeedback = newCongestionFeedbackGenerator(sm.media)
+			}
+			delete(ss.setuppedMedias, medi)
+			ss.setuppedMedias[sm.media] = sm
+		}
+
+		ss.announcedDesc = prevDesc
+	}
+
+	if ss.state == ServerSessionStateRecord {
+		ss.timeDecoder = rtptime.NewGlobalDecoder()
+
+		for _, sm := range ss.setuppedMedias {
+			sm.start()
+		}
+	}
+
+	return res, err
+}
+
 func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (*base.Response, error) {
 	if ss.tcpConn != nil && sc != ss.tcpConn {
 		return &base.Response{
@@ -531,13 +1169,13 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 	switch req.Method {
 	case base.Options:
 		var methods []string
-		if _, ok := sc.s.Handler.(ServerHandlerOnDescribe); ok {
+		if sc.s.handlesMethod(base.Describe) {
 			methods = append(methods, string(base.Describe))
 		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnAnnounce); ok {
+		if sc.s.handlesMethod(base.Announce) {
 			methods = append(methods, string(base.Announce))
 		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnSetup); ok {
+		if sc.s.handlesMethod(base.Setup) {
 			methods = append(methods, string(base.Setup))
 		}
 		if _, ok := sc.s.Handler.(ServerHandlerOnPlay); ok {
@@ -563,6 +1201,11 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		}, nil
 
 	case base.Announce:
+		if _, ok := sc.s.Handler.(ServerHandlerOnStreamUpdate); ok &&
+			(ss.state == ServerSessionStatePreRecord || ss.state == ServerSessionStateRecord) {
+			return ss.handleStreamUpdate(sc, req, path, query)
+		}
+
 		err := ss.checkState(map[ServerSessionState]struct{}{
 			ServerSessionStateInitial: {},
 		})
@@ -572,65 +1215,28 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, err
 		}
 
-		ct, ok := req.Header["Content-Type"]
-		if !ok || len(ct) != 1 {
-			return &base.Response{
-				StatusCode: base.StatusBadRequest,
-			}, liberrors.ErrServerContentTypeMissing{}
-		}
-
-		if ct[0] != "application/sdp" {
-			return &base.Response{
-				StatusCode: base.StatusBadRequest,
-			}, liberrors.ErrServerContentTypeUnsupported{CT: ct}
-		}
-
-		var ssd sdp.SessionDescription
-		err = ssd.Unmarshal(req.Body)
+		desc, err := readAnnouncedDescription(req, path)
 		if err != nil {
 			return &base.Response{
 				StatusCode: base.StatusBadRequest,
-			}, liberrors.ErrServerSDPInvalid{Err: err}
+			}, err
 		}
 
-		var desc description.Session
-		err = desc.Unmarshal(&ssd)
-		if err != nil {
+		h, params := ss.s.routeRequest(base.Announce, req.URL, path)
+		if h == nil {
 			return &base.Response{
-				StatusCode: base.StatusBadRequest,
-			}, liberrors.ErrServerSDPInvalid{Err: err}
+				StatusCode: base.StatusNotFound,
+			}, liberrors.ErrServerRouteNotFound{Path: path}
 		}
 
-		for _, medi := range desc.Medias {
-			mediURL, err := medi.URL(req.URL)
-			if err != nil {
-				return &base.Response{
-					StatusCode: base.StatusBadRequest,
-				}, fmt.Errorf("unable to generate media URL")
-			}
-
-			mediPath, ok := mediURL.RTSPPathAndQuery()
-			if !ok {
-				return &base.Response{
-					StatusCode: base.StatusBadRequest,
-				}, fmt.Errorf("invalid media URL (%v)", mediURL)
-			}
-
-			if !strings.HasPrefix(mediPath, path) {
-				return &base.Response{
-						StatusCode: base.StatusBadRequest,
-					}, fmt.Errorf("invalid media path: must begin with '%s', but is '%s'",
-						path, mediPath)
-			}
-		}
-
-		res, err := ss.s.Handler.(ServerHandlerOnAnnounce).OnAnnounce(&ServerHandlerOnAnnounceCtx{
+		res, err := h.(ServerHandlerOnAnnounce).OnAnnounce(&ServerHandlerOnAnnounceCtx{
 			Session:     ss,
 			Conn:        sc,
 			Request:     req,
 			Path:        path,
 			Query:       query,
-			Description: &desc,
+			Description: desc,
+			Params:      params,
 		})
 
 		if res.StatusCode != base.StatusOK {
@@ -640,7 +1246,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		ss.state = ServerSessionStatePreRecord
 		ss.setuppedPath = path
 		ss.setuppedQuery = query
-		ss.announcedDesc = &desc
+		ss.announcedDesc = desc
 
 		return res, err
 
@@ -664,7 +1270,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, liberrors.ErrServerTransportHeaderInvalid{Err: err}
 		}
 
-		inTH := findFirstSupportedTransportHeader(ss.s, inTSH)
+		inTH := findFirstSupportedTransportHeader(ss.s, inTSH, sc.ip())
 		if inTH == nil {
 			return &base.Response{
 				StatusCode: base.StatusUnsupportedTransport,
@@ -718,15 +1324,16 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 						StatusCode: base.StatusBadRequest,
 					}, liberrors.ErrServerTransportHeaderInvalidInterleavedIDs{}
 				}
-
-				if ss.isChannelPairInUse(inTH.InterleavedIDs[0]) {
-					return &base.Response{
-						StatusCode: base.StatusBadRequest,
-					}, liberrors.ErrServerTransportHeaderInterleavedIDsInUse{}
-				}
 			}
 		}
 
+		// UDP requires the IP of the client, that is not available with custom connections
+		if transport != TransportTCP && sc.ip() == nil {
+			return &base.Response{
+				StatusCode: base.StatusUnsupportedTransport,
+			}, nil
+		}
+
 		if ss.setuppedTransport != nil && *ss.setuppedTransport != transport {
 			return &base.Response{
 				StatusCode: base.StatusBadRequest,
@@ -755,13 +1362,22 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}
 		}
 
-		res, stream, err := ss.s.Handler.(ServerHandlerOnSetup).OnSetup(&ServerHandlerOnSetupCtx{
+		h, params := ss.s.routeRequest(base.Setup, req.URL, path)
+		if h == nil {
+			return &base.Response{
+				StatusCode: base.StatusNotFound,
+			}, liberrors.ErrServerRouteNotFound{Path: path}
+		}
+
+		res, stream, err := h.(ServerHandlerOnSetup).OnSetup(&ServerHandlerOnSetupCtx{
 			Session:   ss,
 			Conn:      sc,
 			Request:   req,
 			Path:      path,
 			Query:     query,
 			Transport: transport,
+			Append:    ss.state == ServerSessionStatePreRecord && inTH.Append,
+			Params:    params,
 		})
 
 		// workaround to prevent a bug in rtspclientsink
@@ -800,13 +1416,42 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, liberrors.ErrServerMediaAlreadySetup{}
 		}
 
+		srtp, err := newMediaSRTP(medi)
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}, err
+		}
+
 		ss.setuppedTransport = &transport
 
+		if len(ss.setuppedMedias) == 0 {
+			var readStream *ServerStream
+			if ss.state == ServerSessionStateInitial {
+				readStream = stream
+			}
+
+			res, err := ss.s.admit(sc, ss, req, readStream)
+			if res != nil {
+				ss.setuppedTransport = nil
+				return res, err
+			}
+		}
+
 		if ss.state == ServerSessionStateInitial {
 			err := stream.readerAdd(ss,
 				inTH.ClientPorts,
 			)
 			if err != nil {
+				ss.setuppedTransport = nil
+
+				var eerr liberrors.ErrServerReadersLimitReached
+				if errors.As(err, &eerr) {
+					return &base.Response{
+						StatusCode: base.StatusNotEnoughBandwidth,
+					}, err
+				}
+
 				return &base.Response{
 					StatusCode: base.StatusBadRequest,
 				}, err
@@ -815,7 +1460,9 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			ss.state = ServerSessionStatePrePlay
 			ss.setuppedPath = path
 			ss.setuppedQuery = query
+			ss.streamMutex.Lock()
 			ss.setuppedStream = stream
+			ss.streamMutex.Unlock()
 		}
 
 		th := headers.Transport{}
@@ -825,6 +1472,9 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			if ok {
 				th.SSRC = &ssrc
 			}
+		} else if inTH.Append {
+			ss.recordAppend = true
+			th.Append = true
 		}
 
 		if res.Header == nil {
@@ -833,6 +1483,9 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 		sm := newServerSessionMedia(ss, medi)
 
+		sm.srtp = srtp
+		th.Secure = (srtp != nil)
+
 		switch transport {
 		case TransportUDP:
 			sm.udpRTPReadPort = inTH.ClientPorts[0]
@@ -860,14 +1513,24 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			th.Protocol = headers.TransportProtocolUDP
 			de := headers.TransportDeliveryMulticast
 			th.Delivery = &de
-			v := uint(127)
+			mw := stream.streamMedias[medi].multicastWriter
+			v := mw.headerTTL()
 			th.TTL = &v
-			d := stream.streamMedias[medi].multicastWriter.ip()
+			d := mw.ip()
 			th.Destination = &d
-			th.Ports = &[2]int{ss.s.MulticastRTPPort, ss.s.MulticastRTCPPort}
+			var localIP net.IP
+			if addr, ok := sc.nconn.LocalAddr().(*net.TCPAddr); ok {
+				localIP = addr.IP
+			}
+			if src := stream.multicastSource(localIP); src != nil {
+				th.Source = &src
+			}
+			th.Ports = &[2]int{ss.s.MulticastRTPPort, mw.rtcpPort()}
 
 		default: // TCP
-			if inTH.InterleavedIDs != nil {
+			// honor the requested channels when they are free,
+			// otherwise pick other ones. The final assignment is written in the response.
+			if inTH.InterleavedIDs != nil && !ss.isChannelPairInUse(inTH.InterleavedIDs[0]) {
 				sm.tcpChannel = inTH.InterleavedIDs[0]
 			} else {
 				sm.tcpChannel = ss.findFreeChannelPair()
@@ -887,6 +1550,9 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 		res.Header["Transport"] = th.Marshal()
 
+		ss.s.events.transportNegotiated(EventSource{Conn: sc, Session: ss}, medi, transport)
+		ss.log.Info("transport negotiated", "media", medi.Type, "url", req.URL, "transport", transport)
+
 		return res, err
 
 	case base.Play:
@@ -907,12 +1573,39 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, liberrors.ErrServerPathHasChanged{Prev: ss.setuppedPath, Cur: path}
 		}
 
+		if ss.State() == ServerSessionStatePrePlay {
+			res, err := ss.s.admit(sc, ss, req, ss.setuppedStream)
+			if res != nil {
+				return res, err
+			}
+		}
+
+		var resumedMedia *serverSessionMedia
+		path, resumedMedia = ss.findPlayingMediaByPath(path)
+
 		// allocate writeBuffer before calling OnPlay().
 		// in this way it's possible to call ServerSession.WritePacket*()
 		// inside the callback.
 		if ss.state != ServerSessionStatePlay &&
 			*ss.setuppedTransport != TransportUDPMulticast {
-			ss.writer.allocateBuffer(ss.s.WriteQueueSize)
+			ss.allocateWriteQueue()
+		}
+
+		ra, scale, speed, err := readPlayHeaders(req)
+		if err != nil {
+			if ss.state != ServerSessionStatePlay {
+				ss.writer.buffer = nil
+			}
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}, err
+		}
+
+		_, err = ss.switchRenditionFromRequest(req, query)
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}, err
 		}
 
 		res, err := sc.s.Handler.(ServerHandlerOnPlay).OnPlay(&ServerHandlerOnPlayCtx{
@@ -921,6 +1614,10 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			Request: req,
 			Path:    path,
 			Query:   query,
+			Range:   ra,
+			Scale:   scale,
+			Speed:   speed,
+			Media:   resumedMedia.mediaOrNil(),
 		})
 
 		if res.StatusCode != base.StatusOK {
@@ -931,13 +1628,22 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		}
 
 		if ss.state == ServerSessionStatePlay {
+			if resumedMedia != nil {
+				ss.resumeMedia(resumedMedia)
+				ss.fillRTPInfo(res, req.URL, []*serverSessionMedia{resumedMedia})
+			} else {
+				for _, sm := range ss.setuppedMediasOrdered {
+					ss.resumeMedia(sm)
+				}
+				ss.fillRTPInfo(res, req.URL, ss.setuppedMediasOrdered)
+			}
 			return res, err
 		}
 
 		ss.state = ServerSessionStatePlay
+		atomic.StoreInt32(ss.started, 1)
 
-		v := ss.s.timeNow().Unix()
-		ss.udpLastPacketTime = &v
+		ss.resetActivity()
 
 		ss.timeDecoder = rtptime.NewGlobalDecoder()
 
@@ -947,11 +1653,11 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 		switch *ss.setuppedTransport {
 		case TransportUDP:
-			ss.udpCheckStreamTimer = time.NewTimer(ss.s.checkStreamPeriod)
+			ss.udpCheckStreamTimer = ss.s.Clock.NewTimer(ss.s.checkStreamPeriod)
 			ss.writer.start()
 
 		case TransportUDPMulticast:
-			ss.udpCheckStreamTimer = time.NewTimer(ss.s.checkStreamPeriod)
+			ss.udpCheckStreamTimer = ss.s.Clock.NewTimer(ss.s.checkStreamPeriod)
 
 		default: // TCP
 			ss.tcpConn = sc
@@ -961,20 +1667,14 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 		ss.setuppedStream.readerSetActive(ss)
 
-		rtpInfo, ok := generateRTPInfo(
-			ss.s.timeNow(),
-			ss.setuppedMediasOrdered,
-			ss.setuppedStream,
-			ss.setuppedPath,
-			req.URL)
-
-		if ok {
-			if res.Header == nil {
-				res.Header = make(base.Header)
+		for _, sm := range ss.setuppedMediasOrdered {
+			if sm.media.Type == description.MediaTypeVideo {
+				sm.keyframeRequest()
 			}
-			res.Header["RTP-Info"] = rtpInfo.Marshal()
 		}
 
+		ss.fillRTPInfo(res, req.URL, ss.setuppedMediasOrdered)
+
 		return res, err
 
 	case base.Record:
@@ -1013,6 +1713,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			Request: req,
 			Path:    path,
 			Query:   query,
+			Append:  ss.recordAppend,
 		})
 
 		if res.StatusCode != base.StatusOK {
@@ -1021,9 +1722,11 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		}
 
 		ss.state = ServerSessionStateRecord
+		atomic.StoreInt32(ss.started, 1)
+
+		ss.resetActivity()
 
-		v := ss.s.timeNow().Unix()
-		ss.udpLastPacketTime = &v
+		ss.streamEnded = new(int32)
 
 		ss.timeDecoder = rtptime.NewGlobalDecoder()
 
@@ -1033,7 +1736,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 		switch *ss.setuppedTransport {
 		case TransportUDP:
-			ss.udpCheckStreamTimer = time.NewTimer(ss.s.checkStreamPeriod)
+			ss.udpCheckStreamTimer = ss.s.Clock.NewTimer(ss.s.checkStreamPeriod)
 			ss.writer.start()
 
 		default: // TCP
@@ -1057,18 +1760,32 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, err
 		}
 
+		var pausedMedia *serverSessionMedia
+		path, pausedMedia = ss.findPlayingMediaByPath(path)
+
 		res, err := ss.s.Handler.(ServerHandlerOnPause).OnPause(&ServerHandlerOnPauseCtx{
 			Session: ss,
 			Conn:    sc,
 			Request: req,
 			Path:    path,
 			Query:   query,
+			Media:   pausedMedia.mediaOrNil(),
 		})
 
 		if res.StatusCode != base.StatusOK {
 			return res, err
 		}
 
+		// pause a single media, while the others keep playing
+		if pausedMedia != nil {
+			atomic.StoreInt32(pausedMedia.paused, 1)
+			return res, err
+		}
+
+		for _, sm := range ss.setuppedMedias {
+			atomic.StoreInt32(sm.paused, 0)
+		}
+
 		if ss.setuppedStream != nil {
 			ss.setuppedStream.readerSetInactive(ss)
 		}
@@ -1113,6 +1830,16 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		return res, err
 
 	case base.Teardown:
+		// a BYE has already been sent when draining
+		if ss.state == ServerSessionStatePlay && *ss.setuppedTransport != TransportUDPMulticast && !ss.draining {
+			ss.setuppedStream.readerSetInactive(ss)
+			ss.writer.stop()
+
+			for _, sm := range ss.setuppedMediasOrdered {
+				sm.writeGoodbye()
+			}
+		}
+
 		var err error
 		if (ss.state == ServerSessionStatePlay || ss.state == ServerSessionStateRecord) &&
 			*ss.setuppedTransport == TransportTCP {
@@ -1125,12 +1852,20 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 	case base.GetParameter:
 		if h, ok := sc.s.Handler.(ServerHandlerOnGetParameter); ok {
+			names, err := requestParameterNames(req)
+			if err != nil {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, err
+			}
+
 			return h.OnGetParameter(&ServerHandlerOnGetParameterCtx{
-				Session: ss,
-				Conn:    sc,
-				Request: req,
-				Path:    path,
-				Query:   query,
+				Session:    ss,
+				Conn:       sc,
+				Request:    req,
+				Path:       path,
+				Query:      query,
+				Parameters: names,
 			})
 		}
 
@@ -1139,21 +1874,42 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		return &base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
-				"Content-Type": base.HeaderValue{"text/parameters"},
+				"Content-Type": base.HeaderValue{parameters.ContentType},
 			},
 			Body: []byte{},
 		}, nil
 
 	case base.SetParameter:
+		switched, err := ss.switchRenditionFromRequest(req, query)
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}, err
+		}
+
 		if h, ok := sc.s.Handler.(ServerHandlerOnSetParameter); ok {
+			params, err := requestParameters(req)
+			if err != nil {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, err
+			}
+
 			return h.OnSetParameter(&ServerHandlerOnSetParameterCtx{
-				Session: ss,
-				Conn:    sc,
-				Request: req,
-				Path:    path,
-				Query:   query,
+				Session:    ss,
+				Conn:       sc,
+				Request:    req,
+				Path:       path,
+				Query:      query,
+				Parameters: params,
 			})
 		}
+
+		if switched {
+			return &base.Response{
+				StatusCode: base.StatusOK,
+			}, nil
+		}
 	}
 
 	return &base.Response{
@@ -1170,6 +1926,15 @@ func (ss *ServerSession) isChannelPairInUse(channel int) bool {
 	return false
 }
 
+func (ss *ServerSession) isChannelInUse(channel int) bool {
+	for _, sm := range ss.setuppedMedias {
+		if sm.tcpChannel == channel || (sm.tcpChannel+1) == channel {
+			return true
+		}
+	}
+	return false
+}
+
 func (ss *ServerSession) findFreeChannelPair() int {
 	for i := 0; ; i += 2 { // prefer even channels
 		if !ss.isChannelPairInUse(i) {
@@ -1200,6 +1965,17 @@ func (ss *ServerSession) OnPacketRTCPAny(cb OnPacketRTCPAnyFunc) {
 	}
 }
 
+// OnPacketRTCPRawAny sets the callback that is called when a compound RTCP packet
+// is read from any setupped media.
+func (ss *ServerSession) OnPacketRTCPRawAny(cb OnPacketRTCPRawAnyFunc) {
+	for _, sm := range ss.setuppedMedias {
+		cmedia := sm.media
+		ss.OnPacketRTCPRaw(sm.media, func(payload []byte) {
+			cb(cmedia, payload)
+		})
+	}
+}
+
 // OnPacketRTP sets the callback that is called when a RTP packet is read.
 func (ss *ServerSession) OnPacketRTP(medi *description.Media, forma format.Format, cb OnPacketRTPFunc) {
 	sm := ss.setuppedMedias[medi]
@@ -1207,12 +1983,34 @@ func (ss *ServerSession) OnPacketRTP(medi *description.Media, forma format.Forma
 	st.onPacketRTP = cb
 }
 
+// OnPacketRTPExtension sets the callback that is called when a RTP packet
+// that contains the header extension with the given URI is read.
+// The callback is never called if the extension has not been negotiated.
+func (ss *ServerSession) OnPacketRTPExtension(medi *description.Media, uri string, cb OnPacketRTPExtensionFunc) {
+	sm := ss.setuppedMedias[medi]
+	sm.setPacketRTPExtensionCallback(uri, cb)
+}
+
 // OnPacketRTCP sets the callback that is called when a RTCP packet is read.
 func (ss *ServerSession) OnPacketRTCP(medi *description.Media, cb OnPacketRTCPFunc) {
 	sm := ss.setuppedMedias[medi]
 	sm.onPacketRTCP = cb
 }
 
+// OnPacketRTCPRaw sets the callback that is called when a compound RTCP packet is read,
+// before it is decoded. Packets that can't be decoded are passed to the callback too.
+func (ss *ServerSession) OnPacketRTCPRaw(medi *description.Media, cb OnPacketRTCPRawFunc) {
+	sm := ss.setuppedMedias[medi]
+	sm.onPacketRTCPRaw = cb
+}
+
+// OnInterleavedFrame sets the callback that is called when an interleaved frame
+// is read on a channel that is not bound to any media.
+// It is used with the TCP transport only.
+func (ss *ServerSession) OnInterleavedFrame(cb OnInterleavedFrameFunc) {
+	ss.onInterleavedFrame = cb
+}
+
 func (ss *ServerSession) writePacketRTP(medi *description.Media, byts []byte) error {
 	sm := ss.setuppedMedias[medi]
 	return sm.writePacketRTP(byts)
@@ -1245,6 +2043,108 @@ func (ss *ServerSession) WritePacketRTCP(medi *description.Media, pkt rtcp.Packe
 	return ss.writePacketRTCP(medi, byts)
 }
 
+// WritePacketRTCPRaw writes a compound RTCP packet to the session.
+// It allows to send packets that are not supported by the RTCP library,
+// like application-defined (APP) packets.
+func (ss *ServerSession) WritePacketRTCPRaw(medi *description.Media, payload []byte) error {
+	_, err := rtcp.Unmarshal(payload)
+	if err != nil {
+		return err
+	}
+
+	// the packet is written asynchronously
+	byts := append([]byte(nil), payload...)
+
+	return ss.writePacketRTCP(medi, byts)
+}
+
+// RequestKeyframe asks the publisher to send a keyframe of a media,
+// by sending a RTCP Picture Loss Indication.
+// It can be called only after at least one RTP packet of the media has been received.
+func (ss *ServerSession) RequestKeyframe(medi *description.Media) error {
+	return ss.requestKeyframe(medi, func(ssrc uint32) rtcp.Packet {
+		return &rtcp.PictureLossIndication{
+			MediaSSRC: ssrc,
+		}
+	})
+}
+
+// RequestKeyframeFIR asks the publisher to send a keyframe of a media,
+// by sending a RTCP Full Intra Request.
+// It can be used with encoders that ignore Picture Loss Indications.
+// It can be called only after at least one RTP packet of the media has been received.
+func (ss *ServerSession) RequestKeyframeFIR(medi *description.Media) error {
+	sm := ss.setuppedMedias[medi]
+
+	return ss.requestKeyframe(medi, func(ssrc uint32) rtcp.Packet {
+		return &rtcp.FullIntraRequest{
+			FIR: []rtcp.FIREntry{{
+				SSRC:           ssrc,
+				SequenceNumber: uint8(atomic.AddUint32(sm.firSeqNum, 1)),
+			}},
+		}
+	})
+}
+
+func (ss *ServerSession) requestKeyframe(medi *description.Media, generate func(ssrc uint32) rtcp.Packet) error {
+	sm := ss.setuppedMedias[medi]
+	sent := false
+
+	for _, sf := range sm.formats {
+		if sf.rtcpReceiver == nil {
+			continue
+		}
+
+		ssrc, ok := sf.rtcpReceiver.SenderSSRC()
+		if !ok {
+			continue
+		}
+
+		err := ss.WritePacketRTCP(medi, generate(ssrc))
+		if err != nil {
+			return err
+		}
+		sent = true
+	}
+
+	if !sent {
+		return liberrors.ErrServerSSRCUnknown{}
+	}
+
+	return nil
+}
+
+// WriteInterleavedFrame writes an interleaved frame to the session,
+// on a channel that is not bound to any media.
+// It can be called only when the session is playing or recording with the TCP transport.
+func (ss *ServerSession) WriteInterleavedFrame(channel int, payload []byte) error {
+	if channel < 0 || channel > 255 {
+		return fmt.Errorf("invalid channel: %d", channel)
+	}
+	if len(payload) > 65535 {
+		return fmt.Errorf("payload is too big")
+	}
+
+	if ss.setuppedTransport == nil || *ss.setuppedTransport != TransportTCP {
+		return liberrors.ErrServerInterleavedFramesNotAvailable{}
+	}
+
+	if ss.isChannelInUse(channel) {
+		return liberrors.ErrServerInterleavedChannelInUse{Channel: channel}
+	}
+
+	fr := &base.InterleavedFrame{
+		Channel: channel,
+		Payload: payload,
+	}
+
+	return ss.pushWrite(len(payload), func() {
+		atomic.AddUint64(ss.bytesSent, uint64(len(payload)))
+		ss.tcpConn.nconn.SetWriteDeadline(time.Now().Add(ss.s.WriteTimeout))
+		ss.tcpConn.conn.WriteInterleavedFrame(fr, make([]byte, fr.MarshalSize())) //nolint:errcheck
+	})
+}
+
 // PacketPTS returns the PTS of an incoming RTP packet.
 // It is computed by decoding the packet timestamp and sychronizing it with other tracks.
 func (ss *ServerSession) PacketPTS(medi *description.Media, pkt *rtp.Packet) (time.Duration, bool) {
@@ -1281,6 +2181,15 @@ func (ss *ServerSession) removeConn(sc *ServerConn) {
 	}
 }
 
+// drain asks the session to terminate gracefully.
+// It never blocks, since it's called by the server routine.
+func (ss *ServerSession) drain() {
+	select {
+	case ss.chDrain <- struct{}{}:
+	default:
+	}
+}
+
 func (ss *ServerSession) startWriter() {
 	select {
 	case ss.chStartWriter <- struct{}{}:
@@ -1,6 +1,7 @@
 package gortsplib
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/rtcp"
@@ -8,8 +9,10 @@ import (
 
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/metrics"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtcpreceiver"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtplossdetector"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpred"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtpreorderer"
 )
 
@@ -19,6 +22,12 @@ type serverSessionFormat struct {
 	udpReorderer    *rtpreorderer.Reorderer
 	tcpLossDetector *rtplossdetector.LossDetector
 	rtcpReceiver    *rtcpreceiver.RTCPReceiver
+	packetsReceived metrics.Counter
+	metricsLabels   metrics.Labels
+	packetsLost     metrics.Counter
+	jitter          metrics.Gauge
+	quality         *receiverQuality
+	redDecoder      *rtpred.Decoder
 	onPacketRTP     OnPacketRTPFunc
 }
 
@@ -33,25 +42,51 @@ func newServerSessionFormat(sm *serverSessionMedia, forma format.Format) *server
 func (sf *serverSessionFormat) start() {
 	if sf.sm.ss.state != ServerSessionStatePlay {
 		if *sf.sm.ss.setuppedTransport == TransportUDP || *sf.sm.ss.setuppedTransport == TransportUDPMulticast {
-			sf.udpReorderer = rtpreorderer.New()
+			if l := sf.sm.ss.effectiveRecordLimits(); l != nil {
+				sf.udpReorderer = rtpreorderer.NewWithOptions(l.MaxReorderedPackets, 0)
+			} else {
+				sf.udpReorderer = rtpreorderer.New()
+			}
 		} else {
 			sf.tcpLossDetector = rtplossdetector.New()
 		}
 
+		sf.packetsReceived = sf.sm.ss.s.metrics.packetsReceived[*sf.sm.ss.setuppedTransport]
+		sf.metricsLabels = sf.sm.ss.s.metrics.trackLabels(
+			sf.sm.ss.metricsSessionID, sf.sm.ss.setuppedPath, sf.sm.media, sf.format)
+		sf.packetsLost = sf.sm.ss.s.metrics.packetsLost(sf.metricsLabels)
+		sf.jitter = sf.sm.ss.s.metrics.jitter(sf.metricsLabels)
+		sf.quality = newReceiverQuality(sf.sm.media, sf.format, sf.format.ClockRate())
+
+		if red, ok := sf.format.(*format.RED); ok && len(red.PayloadTypes) != 0 {
+			if target, ok := sf.sm.formats[red.PayloadTypes[0]]; ok && target != sf {
+				sf.redDecoder = &rtpred.Decoder{}
+			}
+		}
+
 		var err error
-		sf.rtcpReceiver, err = rtcpreceiver.New(
+		sf.rtcpReceiver, err = rtcpreceiver.NewWithClock(
 			sf.format.ClockRate(),
 			nil,
 			sf.sm.ss.s.receiverReportPeriod,
-			sf.sm.ss.s.timeNow,
+			sf.sm.ss.s.Clock,
 			func(pkt rtcp.Packet) {
+				if rr, ok := pkt.(*rtcp.ReceiverReport); ok && len(rr.Reports) != 0 {
+					sf.jitter.Set(float64(rr.Reports[0].Jitter) / float64(sf.format.ClockRate()))
+				}
+
 				if *sf.sm.ss.setuppedTransport == TransportUDP || *sf.sm.ss.setuppedTransport == TransportUDPMulticast {
+					sf.sm.ss.log.Debug("RTCP receiver report sent", "media", sf.sm.media.Type, "payload_type", sf.format.PayloadType())
 					sf.sm.ss.WritePacketRTCP(sf.sm.media, pkt) //nolint:errcheck
 				}
 			})
 		if err != nil {
 			panic(err)
 		}
+
+		if sf.sm.ss.s.RTCPExtendedReportsEnable {
+			sf.rtcpReceiver.EnableExtendedReports()
+		}
 	}
 }
 
@@ -62,13 +97,46 @@ func (sf *serverSessionFormat) stop() {
 	}
 }
 
+func (sf *serverSessionFormat) deleteMetrics() {
+	if sf.metricsLabels != nil {
+		sf.sm.ss.s.metrics.deleteTrack(sf.metricsLabels)
+	}
+}
+
 func (sf *serverSessionFormat) readRTPUDP(pkt *rtp.Packet, now time.Time) {
+	// RED packets are replaced by the packets they contain
+	if sf.redDecoder != nil {
+		sf.readRED(pkt, func(target *serverSessionFormat, pkt *rtp.Packet) {
+			target.readRTPUDP(pkt, now)
+		})
+		return
+	}
+
+	sf.quality.processArrival(pkt)
+
+	droppedBefore := sf.udpReorderer.Dropped()
+
 	packets, lost := sf.udpReorderer.Process(pkt)
+	if sf.sm.ss.s.PacketBufferReuseEnable {
+		detachBufferedPacketRTP(pkt, packets)
+	}
+
+	if skipped := sf.udpReorderer.Dropped() - droppedBefore; skipped != 0 {
+		atomic.AddUint64(sf.sm.ss.recordCounters.reorderSkipped, skipped)
+		if sf.sm.ss.effectiveRecordLimits() != nil {
+			sf.sm.ss.onRecordLimitExceeded(liberrors.ErrServerRecordReorderBufferFull{Skipped: int(skipped)})
+		}
+	}
+
 	if lost != 0 {
+		sf.packetsLost.Add(uint64(lost))
+		sf.quality.processLost(lost)
 		sf.sm.ss.onPacketLost(liberrors.ErrServerRTPPacketsLost{Lost: lost})
 		// do not return
 	}
 
+	sf.packetsReceived.Add(uint64(len(packets)))
+
 	for _, pkt := range packets {
 		err := sf.rtcpReceiver.ProcessPacket(pkt, now, sf.format.PTSEqualsDTS(pkt))
 		if err != nil {
@@ -76,17 +144,30 @@ func (sf *serverSessionFormat) readRTPUDP(pkt *rtp.Packet, now time.Time) {
 			continue
 		}
 
+		sf.quality.processPacket(pkt)
+		sf.sm.readRTPExtensions(pkt)
 		sf.onPacketRTP(pkt)
 	}
 }
 
 func (sf *serverSessionFormat) readRTPTCP(pkt *rtp.Packet) {
+	if sf.redDecoder != nil {
+		sf.readRED(pkt, (*serverSessionFormat).readRTPTCP)
+		return
+	}
+
+	sf.quality.processArrival(pkt)
+
 	lost := sf.tcpLossDetector.Process(pkt)
 	if lost != 0 {
+		sf.packetsLost.Add(uint64(lost))
+		sf.quality.processLost(lost)
 		sf.sm.ss.onPacketLost(liberrors.ErrServerRTPPacketsLost{Lost: lost})
 		// do not return
 	}
 
+	sf.packetsReceived.Add(1)
+
 	now := sf.sm.ss.s.timeNow()
 
 	err := sf.rtcpReceiver.ProcessPacket(pkt, now, sf.format.PTSEqualsDTS(pkt))
@@ -95,5 +176,26 @@ func (sf *serverSessionFormat) readRTPTCP(pkt *rtp.Packet) {
 		return
 	}
 
+	sf.quality.processPacket(pkt)
+	sf.sm.readRTPExtensions(pkt)
 	sf.onPacketRTP(pkt)
 }
+
+// readRED extracts packets from a RED packet and passes them to their formats.
+func (sf *serverSessionFormat) readRED(pkt *rtp.Packet, read func(*serverSessionFormat, *rtp.Packet)) {
+	packets, err := sf.redDecoder.Decode(pkt)
+	if err != nil {
+		sf.sm.ss.onDecodeError(err)
+		return
+	}
+
+	for _, pkt := range packets {
+		target, ok := sf.sm.formats[pkt.PayloadType]
+		if !ok || target == sf {
+			sf.sm.ss.onDecodeError(liberrors.ErrServerRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+			continue
+		}
+
+		read(target, pkt)
+	}
+}
@@ -2,6 +2,7 @@ package gortsplib
 
 import (
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -10,7 +11,10 @@ import (
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpav1"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpextension"
 )
 
 type serverSessionMedia struct {
@@ -25,16 +29,39 @@ type serverSessionMedia struct {
 	tcpRTCPFrame           *base.InterleavedFrame
 	tcpBuffer              []byte
 	formats                map[uint8]*serverSessionFormat // record only
+	keyframeDeadline       *int64                         // play only
+	droppingUntilKeyframe  *int32                         // play only
+	lastWriteQueueDropped  *uint64                        // play only
+	udpRTPPending          [][]byte                       // play only, accessed by the writer only
 	writePacketRTPInQueue  func([]byte)
 	writePacketRTCPInQueue func([]byte)
 	onPacketRTCP           OnPacketRTCPFunc
+	onPacketRTCPRaw        OnPacketRTCPRawFunc
+	onPacketRTPExtensions  map[uint8]OnPacketRTPExtensionFunc // record only
+	srtp                   *mediaSRTP
+	congestionFeedback     *congestionFeedbackGenerator // record only
+	paused                 *int32                       // play only
+	av1LayerFilterMutex    sync.Mutex                   // play only
+	av1LayerFilter         *rtpav1.LayerFilter          // play only
+	bitrateUnit            bitrateLimiterUnit           // play only, protected by the bitrate limiter
+	firSeqNum              *uint32                      // record only
+	continuities           atomic.Value                 // play only, map[*serverStreamFormat]*rtpContinuity
+	continuitiesMutex      sync.Mutex                   // play only
+	roundTripTime          *int64                       // play only
 }
 
 func newServerSessionMedia(ss *ServerSession, medi *description.Media) *serverSessionMedia {
 	sm := &serverSessionMedia{
-		ss:           ss,
-		media:        medi,
-		onPacketRTCP: func(rtcp.Packet) {},
+		ss:                    ss,
+		media:                 medi,
+		keyframeDeadline:      new(int64),
+		droppingUntilKeyframe: new(int32),
+		lastWriteQueueDropped: new(uint64),
+		onPacketRTCP:          func(rtcp.Packet) {},
+		onPacketRTCPRaw:       func([]byte) {},
+		paused:                new(int32),
+		firSeqNum:             new(uint32),
+		roundTripTime:         new(int64),
 	}
 
 	if ss.state == ServerSessionStatePreRecord {
@@ -42,11 +69,43 @@ func newServerSessionMedia(ss *ServerSession, medi *description.Media) *serverSe
 		for _, forma := range medi.Formats {
 			sm.formats[forma.PayloadType()] = newServerSessionFormat(sm, forma)
 		}
+
+		if ss.s.RTCPCongestionFeedbackEnable {
+			sm.congestionFeedback = newCongestionFeedbackGenerator(medi)
+		}
 	}
 
 	return sm
 }
 
+// updateMedia replaces the media with an updated version of it.
+// Callbacks of formats with the same payload type are preserved.
+func (sm *serverSessionMedia) updateMedia(medi *description.Media) {
+	formats := make(map[uint8]*serverSessionFormat)
+
+	for _, forma := range medi.Formats {
+		sf := newServerSessionFormat(sm, forma)
+		if prev, ok := sm.formats[forma.PayloadType()]; ok {
+			sf.onPacketRTP = prev.onPacketRTP
+		}
+		formats[forma.PayloadType()] = sf
+	}
+
+	sm.media = medi
+	sm.formats = formats
+
+	if sm.congestionFeedback != nil {
+		sm.congestionFeedback = newCongestionFeedbackGenerator(medi)
+	}
+}
+
+func (sm *serverSessionMedia) mediaOrNil() *description.Media {
+	if sm == nil {
+		return nil
+	}
+	return sm.media
+}
+
 func (sm *serverSessionMedia) start() {
 	// allocate udpRTCPReceiver before udpRTCPListener
 	// otherwise udpRTCPReceiver.LastSSRC() can't be called.
@@ -59,25 +118,37 @@ func (sm *serverSessionMedia) start() {
 		sm.writePacketRTPInQueue = sm.writePacketRTPInQueueUDP
 		sm.writePacketRTCPInQueue = sm.writePacketRTCPInQueueUDP
 
+		// encrypted packets are written one at a time
+		if *sm.ss.setuppedTransport == TransportUDP && sm.ss.state == ServerSessionStatePlay &&
+			sm.srtp == nil && sm.ss.s.udpWriteBatchSize() > 1 {
+			sm.writePacketRTPInQueue = sm.writePacketRTPInQueueUDPBatched
+		}
+
+		sm.encryptWrites()
+
 		if *sm.ss.setuppedTransport == TransportUDP {
 			if sm.ss.state == ServerSessionStatePlay {
 				// firewall opening is performed with RTCP sender reports generated by ServerStream
 
 				// readers can send RTCP packets only
-				sm.ss.s.udpRTCPListener.addClient(sm.ss.author.ip(), sm.udpRTCPReadPort, sm.readRTCPUDPPlay)
+				sm.ss.s.udpRTCPListener.addClient(sm.ss.author.ip(), sm.udpRTCPReadPort,
+					sm.captureUDP(sm.ss.s.udpRTCPListener, sm.udpRTCPReadPort, sm.impairRead(sm.decryptRTCP(sm.readRTCPUDPPlay))))
 			} else {
 				// open the firewall by sending empty packets to the counterpart.
 				sm.ss.WritePacketRTP(sm.media, &rtp.Packet{Header: rtp.Header{Version: 2}}) //nolint:errcheck
 				sm.ss.WritePacketRTCP(sm.media, &rtcp.ReceiverReport{})                     //nolint:errcheck
 
-				sm.ss.s.udpRTPListener.addClient(sm.ss.author.ip(), sm.udpRTPReadPort, sm.readRTPUDPRecord)
-				sm.ss.s.udpRTCPListener.addClient(sm.ss.author.ip(), sm.udpRTCPReadPort, sm.readRTCPUDPRecord)
+				sm.ss.s.udpRTPListener.addClient(sm.ss.author.ip(), sm.udpRTPReadPort,
+					sm.captureUDP(sm.ss.s.udpRTPListener, sm.udpRTPReadPort, sm.impairRead(sm.decryptRTP(sm.readRTPUDPRecord))))
+				sm.ss.s.udpRTCPListener.addClient(sm.ss.author.ip(), sm.udpRTCPReadPort,
+					sm.captureUDP(sm.ss.s.udpRTCPListener, sm.udpRTCPReadPort, sm.impairRead(sm.decryptRTCP(sm.readRTCPUDPRecord))))
 			}
 		}
 
 	case TransportTCP:
 		sm.writePacketRTPInQueue = sm.writePacketRTPInQueueTCP
 		sm.writePacketRTCPInQueue = sm.writePacketRTCPInQueueTCP
+		sm.encryptWrites()
 
 		if sm.ss.tcpCallbackByChannel == nil {
 			sm.ss.tcpCallbackByChannel = make(map[int]readFunc)
@@ -85,10 +156,10 @@ func (sm *serverSessionMedia) start() {
 
 		if sm.ss.state == ServerSessionStatePlay {
 			sm.ss.tcpCallbackByChannel[sm.tcpChannel] = sm.readRTPTCPPlay
-			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.readRTCPTCPPlay
+			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.impairRead(sm.decryptRTCP(sm.readRTCPTCPPlay))
 		} else {
-			sm.ss.tcpCallbackByChannel[sm.tcpChannel] = sm.readRTPTCPRecord
-			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.readRTCPTCPRecord
+			sm.ss.tcpCallbackByChannel[sm.tcpChannel] = sm.impairRead(sm.decryptRTP(sm.readRTPTCPRecord))
+			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.impairRead(sm.decryptRTCP(sm.readRTCPTCPRecord))
 		}
 
 		sm.tcpRTPFrame = &base.InterleavedFrame{Channel: sm.tcpChannel}
@@ -97,17 +168,73 @@ func (sm *serverSessionMedia) start() {
 	}
 }
 
+func (sm *serverSessionMedia) encryptWrites() {
+	if sm.srtp != nil {
+		sm.writePacketRTPInQueue = sm.srtp.encryptRTP(sm.writePacketRTPInQueue)
+		sm.writePacketRTCPInQueue = sm.srtp.encryptRTCP(sm.writePacketRTCPInQueue)
+	}
+}
+
+func (sm *serverSessionMedia) decryptRTP(cb readFunc) readFunc {
+	if sm.srtp == nil {
+		return cb
+	}
+	return sm.srtp.decryptRTP(cb, sm.ss.onDecodeError)
+}
+
+func (sm *serverSessionMedia) decryptRTCP(cb readFunc) readFunc {
+	if sm.srtp == nil {
+		return cb
+	}
+	return sm.srtp.decryptRTCP(cb, sm.ss.onDecodeError)
+}
+
+// impairRead wraps a callback in order to apply the impairments of the session to incoming packets.
+// Packets are impaired before being decrypted, as it happens in the network.
+func (sm *serverSessionMedia) impairRead(cb readFunc) readFunc {
+	return impairRead(sm.ss.impairer, cb)
+}
+
 func (sm *serverSessionMedia) stop() {
 	if *sm.ss.setuppedTransport == TransportUDP {
 		sm.ss.s.udpRTPListener.removeClient(sm.ss.author.ip(), sm.udpRTPReadPort)
 		sm.ss.s.udpRTCPListener.removeClient(sm.ss.author.ip(), sm.udpRTCPReadPort)
 	}
 
+	// discard delayed packets before stopping formats
+	sm.ss.impairer.Reset()
+
 	for _, sf := range sm.formats {
 		sf.stop()
 	}
 }
 
+func (sm *serverSessionMedia) deleteMetrics() {
+	for _, sf := range sm.formats {
+		sf.deleteMetrics()
+	}
+}
+
+func (sm *serverSessionMedia) setPacketRTPExtensionCallback(uri string, cb OnPacketRTPExtensionFunc) {
+	id, ok := rtpextension.ID(sm.media, uri)
+	if !ok {
+		return
+	}
+
+	if sm.onPacketRTPExtensions == nil {
+		sm.onPacketRTPExtensions = make(map[uint8]OnPacketRTPExtensionFunc)
+	}
+	sm.onPacketRTPExtensions[id] = cb
+}
+
+func (sm *serverSessionMedia) readRTPExtensions(pkt *rtp.Packet) {
+	for id, cb := range sm.onPacketRTPExtensions {
+		if payload := pkt.Header.GetExtension(id); payload != nil {
+			cb(pkt, payload)
+		}
+	}
+}
+
 func (sm *serverSessionMedia) findFormatWithSSRC(ssrc uint32) *serverSessionFormat {
 	for _, format := range sm.formats {
 		tssrc, ok := format.rtcpReceiver.SenderSSRC()
@@ -118,14 +245,166 @@ func (sm *serverSessionMedia) findFormatWithSSRC(ssrc uint32) *serverSessionForm
 	return nil
 }
 
+// processSourceDescription routes chunks of RTCP source descriptions to receivers of formats.
+func (sm *serverSessionMedia) processSourceDescription(sdes *rtcp.SourceDescription) {
+	for i := range sdes.Chunks {
+		format := sm.findFormatWithSSRC(sdes.Chunks[i].Source)
+		if format != nil {
+			format.rtcpReceiver.ProcessSourceDescription(&sdes.Chunks[i])
+		}
+	}
+}
+
+// processGoodbye signals the end of the stream, once per RECORD request.
+func (sm *serverSessionMedia) processGoodbye(bye *rtcp.Goodbye) {
+	if !atomic.CompareAndSwapInt32(sm.ss.streamEnded, 0, 1) {
+		return
+	}
+
+	if h, ok := sm.ss.s.Handler.(ServerHandlerOnStreamEnded); ok {
+		h.OnStreamEnded(&ServerHandlerOnStreamEndedCtx{
+			Session: sm.ss,
+			Reason:  bye.Reason,
+		})
+	}
+}
+
+// readStream returns the stream read by the session and the corresponding media.
+// They are changed by ServerStream.MoveReaders().
+func (sm *serverSessionMedia) readStream() (*ServerStream, *description.Media) {
+	sm.ss.streamMutex.RLock()
+	defer sm.ss.streamMutex.RUnlock()
+	return sm.ss.setuppedStream, sm.media
+}
+
+// forwardRTCPToStream passes RTCP packets sent by readers that are relevant to the stream.
+func (sm *serverSessionMedia) forwardRTCPToStream(pkt rtcp.Packet) {
+	if nack, ok := pkt.(*rtcp.TransportLayerNack); ok {
+		st, medi := sm.readStream()
+		st.readerRetransmit(sm, medi, nack)
+	}
+
+	if bitrate, ok := bandwidthEstimate(pkt); ok {
+		st, medi := sm.readStream()
+		st.readerBandwidthEstimate(medi, bitrate)
+	}
+
+	if xr, ok := pkt.(*rtcp.ExtendedReport); ok {
+		st, medi := sm.readStream()
+		st.readerExtendedReport(medi, xr)
+	}
+
+	if rr, ok := pkt.(*rtcp.ReceiverReport); ok {
+		st, medi := sm.readStream()
+		if rtt, ok := st.readerReceptionReports(medi, rr.Reports); ok {
+			atomic.StoreInt64(sm.roundTripTime, int64(rtt))
+		}
+	}
+
+	if isKeyframeRequest(pkt) {
+		sm.keyframeRequest()
+	}
+}
+
+// keyframeRequest notifies the handler and the stream that the reader needs a keyframe.
+func (sm *serverSessionMedia) keyframeRequest() {
+	st, medi := sm.readStream()
+
+	if h, ok := sm.ss.s.Handler.(ServerHandlerOnKeyframeRequest); ok {
+		h.OnKeyframeRequest(&ServerHandlerOnKeyframeRequestCtx{
+			Session: sm.ss,
+			Stream:  st,
+			Media:   medi,
+		})
+	}
+
+	st.readerKeyframeRequest(medi)
+}
+
+// writeGoodbye sends a RTCP BYE packet that contains the SSRCs of the stream.
+// It must be called after the writer has been stopped.
+func (sm *serverSessionMedia) writeGoodbye() {
+	sources := sm.ss.setuppedStream.senderSSRCs(sm.media)
+	if sources == nil {
+		return
+	}
+
+	byts, err := (&rtcp.Goodbye{
+		Sources: sources,
+	}).Marshal()
+	if err != nil {
+		return
+	}
+
+	sm.writePacketRTCPInQueue(byts)
+}
+
+// processExtendedReport routes RTCP extended reports to receivers of formats.
+func (sm *serverSessionMedia) processExtendedReport(xr *rtcp.ExtendedReport, now time.Time) {
+	for _, format := range sm.formats {
+		format.rtcpReceiver.ProcessExtendedReport(xr, now)
+	}
+}
+
+// captureUDP wraps a callback of a UDP listener in order to write incoming packets into the packet capture.
+func (sm *serverSessionMedia) captureUDP(u *serverUDPListener, port int, cb readFunc) readFunc {
+	return func(payload []byte) {
+		sm.ss.capture.writeUDP(&net.UDPAddr{IP: sm.ss.author.ip(), Port: port}, u.pc.LocalAddr(), payload)
+		cb(payload)
+	}
+}
+
 func (sm *serverSessionMedia) writePacketRTPInQueueUDP(payload []byte) {
 	atomic.AddUint64(sm.ss.bytesSent, uint64(len(payload)))
+	sm.ss.s.events.bytesSent(EventSource{Session: sm.ss}, len(payload))
+	sm.ss.s.metrics.bytesSent[*sm.ss.setuppedTransport].Add(uint64(len(payload)))
 	sm.ss.s.udpRTPListener.write(payload, sm.udpRTPWriteAddr) //nolint:errcheck
+	sm.ss.capture.writeUDP(sm.ss.s.udpRTPListener.pc.LocalAddr(), sm.udpRTPWriteAddr, payload)
+}
+
+// writePacketRTPInQueueUDPBatched queues a RTP packet, that is written together
+// with the following ones when the write queue is empty or the batch is full.
+func (sm *serverSessionMedia) writePacketRTPInQueueUDPBatched(payload []byte) {
+	if len(sm.udpRTPPending) == 0 {
+		sm.ss.udpPendingMedias = append(sm.ss.udpPendingMedias, sm)
+	}
+
+	sm.udpRTPPending = append(sm.udpRTPPending, payload)
+
+	if len(sm.udpRTPPending) >= sm.ss.s.udpWriteBatchSize() {
+		sm.flushPendingPacketsRTP()
+	}
+}
+
+func (sm *serverSessionMedia) flushPendingPacketsRTP() {
+	if len(sm.udpRTPPending) == 0 {
+		return
+	}
+
+	for _, payload := range sm.udpRTPPending {
+		atomic.AddUint64(sm.ss.bytesSent, uint64(len(payload)))
+		sm.ss.s.events.bytesSent(EventSource{Session: sm.ss}, len(payload))
+		sm.ss.s.metrics.bytesSent[TransportUDP].Add(uint64(len(payload)))
+	}
+
+	sm.ss.s.udpRTPListener.writeBatch(sm.udpRTPPending, sm.udpRTPWriteAddr) //nolint:errcheck
+
+	for _, payload := range sm.udpRTPPending {
+		sm.ss.capture.writeUDP(sm.ss.s.udpRTPListener.pc.LocalAddr(), sm.udpRTPWriteAddr, payload)
+	}
+
+	for i := range sm.udpRTPPending {
+		sm.udpRTPPending[i] = nil
+	}
+	sm.udpRTPPending = sm.udpRTPPending[:0]
 }
 
 func (sm *serverSessionMedia) writePacketRTCPInQueueUDP(payload []byte) {
 	atomic.AddUint64(sm.ss.bytesSent, uint64(len(payload)))
+	sm.ss.s.events.bytesSent(EventSource{Session: sm.ss}, len(payload))
+	sm.ss.s.metrics.bytesSent[*sm.ss.setuppedTransport].Add(uint64(len(payload)))
 	sm.ss.s.udpRTCPListener.write(payload, sm.udpRTCPWriteAddr) //nolint:errcheck
+	sm.ss.capture.writeUDP(sm.ss.s.udpRTCPListener.pc.LocalAddr(), sm.udpRTCPWriteAddr, payload)
 }
 
 func (sm *serverSessionMedia) writePacketRTPInQueueTCP(payload []byte) {
@@ -133,6 +412,7 @@ func (sm *serverSessionMedia) writePacketRTPInQueueTCP(payload []byte) {
 	sm.tcpRTPFrame.Payload = payload
 	sm.ss.tcpConn.nconn.SetWriteDeadline(time.Now().Add(sm.ss.s.WriteTimeout))
 	sm.ss.tcpConn.conn.WriteInterleavedFrame(sm.tcpRTPFrame, sm.tcpBuffer) //nolint:errcheck
+	sm.ss.capture.writeFrame(sm.ss.tcpConn.nconn.LocalAddr(), sm.ss.tcpConn.nconn.RemoteAddr(), sm.tcpRTPFrame)
 }
 
 func (sm *serverSessionMedia) writePacketRTCPInQueueTCP(payload []byte) {
@@ -140,40 +420,204 @@ func (sm *serverSessionMedia) writePacketRTCPInQueueTCP(payload []byte) {
 	sm.tcpRTCPFrame.Payload = payload
 	sm.ss.tcpConn.nconn.SetWriteDeadline(time.Now().Add(sm.ss.s.WriteTimeout))
 	sm.ss.tcpConn.conn.WriteInterleavedFrame(sm.tcpRTCPFrame, sm.tcpBuffer) //nolint:errcheck
+	sm.ss.capture.writeFrame(sm.ss.tcpConn.nconn.LocalAddr(), sm.ss.tcpConn.nconn.RemoteAddr(), sm.tcpRTCPFrame)
+}
+
+// waitingKeyframe checks whether packets must be withheld since the session is waiting for a keyframe.
+func (sm *serverSessionMedia) waitingKeyframe(randomAccess bool) bool {
+	deadline := atomic.LoadInt64(sm.keyframeDeadline)
+	if deadline == 0 {
+		return false
+	}
+
+	if !randomAccess && sm.ss.s.timeNow().UnixNano() < deadline {
+		return true
+	}
+
+	atomic.StoreInt64(sm.keyframeDeadline, 0)
+	return false
+}
+
+// keyframeStart checks whether a RTP packet is the first one of a keyframe.
+// The second return value is false when the format doesn't have keyframes.
+func keyframeStart(forma format.Format, pkt *rtp.Packet) (bool, bool) {
+	switch forma.(type) {
+	case *format.H264, *format.H265, *format.H266:
+		return forma.PTSEqualsDTS(pkt), true
+
+	case *format.AV1:
+		// N bit of the aggregation header, that marks the first packet of a coded video sequence
+		return len(pkt.Payload) != 0 && (pkt.Payload[0]&0x08) != 0, true
+	}
+
+	return false, false
+}
+
+// writePacketRTPUntilKeyframe writes a RTP packet. After packets have been dropped
+// because the write queue is full, video packets are withheld until the next keyframe.
+func (sm *serverSessionMedia) writePacketRTPUntilKeyframe(forma format.Format, byts []byte, pkt *rtp.Packet) error {
+	keyframe, ok := keyframeStart(forma, pkt)
+	if !ok {
+		return sm.writePacketRTP(byts)
+	}
+
+	// the write queue is shared among medias, therefore packets of this media
+	// may have been discarded while packets of other medias were written.
+	dropped := sm.ss.WriteQueueDropped()
+	if atomic.SwapUint64(sm.lastWriteQueueDropped, dropped) != dropped {
+		atomic.StoreInt32(sm.droppingUntilKeyframe, 1)
+	}
+
+	if atomic.LoadInt32(sm.droppingUntilKeyframe) != 0 {
+		if !keyframe {
+			return nil
+		}
+		atomic.StoreInt32(sm.droppingUntilKeyframe, 0)
+	}
+
+	err := sm.writePacketRTP(byts)
+
+	// the packet has been dropped, or older packets have been discarded to make room for it
+	newDropped := sm.ss.WriteQueueDropped()
+	atomic.StoreUint64(sm.lastWriteQueueDropped, newDropped)
+	if err != nil || newDropped != dropped {
+		atomic.StoreInt32(sm.droppingUntilKeyframe, 1)
+	}
+
+	return err
+}
+
+// filtersLayers returns whether the sequence numbers of outgoing packets of the format
+// are rewritten by the AV1 layer filter.
+func (sm *serverSessionMedia) filtersLayers(forma format.Format) bool {
+	if _, ok := forma.(*format.AV1); !ok {
+		return false
+	}
+
+	sm.av1LayerFilterMutex.Lock()
+	defer sm.av1LayerFilterMutex.Unlock()
+
+	return sm.av1LayerFilter != nil
+}
+
+// filterPacketRTP removes AV1 enhancement layers from outgoing packets, if requested.
+// It returns false when the packet must not be sent.
+func (sm *serverSessionMedia) filterPacketRTP(
+	forma format.Format,
+	byts []byte,
+	pkt *rtp.Packet,
+) ([]byte, *rtp.Packet, bool) {
+	if _, ok := forma.(*format.AV1); !ok {
+		return byts, pkt, true
+	}
+
+	sm.av1LayerFilterMutex.Lock()
+	defer sm.av1LayerFilterMutex.Unlock()
+
+	if sm.av1LayerFilter == nil {
+		return byts, pkt, true
+	}
+
+	out := sm.av1LayerFilter.Process(pkt)
+	if out == nil {
+		return nil, nil, false
+	}
+
+	if out == pkt {
+		return byts, pkt, true
+	}
+
+	outByts, err := out.Marshal()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return outByts, out, true
 }
 
 func (sm *serverSessionMedia) writePacketRTP(payload []byte) error {
-	ok := sm.ss.writer.push(func() {
+	if atomic.LoadInt32(sm.paused) != 0 {
+		return nil
+	}
+
+	err := sm.ss.pushWrite(len(payload), func() {
+		sm.ss.pacer.wait()
 		sm.writePacketRTPInQueue(payload)
 	})
-	if !ok {
-		return liberrors.ErrServerWriteQueueFull{}
+	if err != nil {
+		return err
 	}
 
+	sm.ss.s.metrics.packetsSent[*sm.ss.setuppedTransport].Add(1)
+
 	return nil
 }
 
+// writePacketsRTP writes multiple RTP packets with a single entry of the write queue.
+// With the UDP transport, they are sent together when batches are enabled.
+func (sm *serverSessionMedia) writePacketsRTP(payloads [][]byte) error {
+	if atomic.LoadInt32(sm.paused) != 0 {
+		return nil
+	}
+
+	size := 0
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+
+	err := sm.ss.pushWrite(size, func() {
+		sm.writePacketsRTPInQueue(payloads)
+	})
+	if err != nil {
+		return err
+	}
+
+	sm.ss.s.metrics.packetsSent[*sm.ss.setuppedTransport].Add(uint64(len(payloads)))
+
+	return nil
+}
+
+func (sm *serverSessionMedia) writePacketsRTPInQueue(payloads [][]byte) {
+	for _, payload := range payloads {
+		sm.writePacketRTPInQueue(payload)
+	}
+}
+
 func (sm *serverSessionMedia) writePacketRTCP(payload []byte) error {
-	ok := sm.ss.writer.push(func() {
+	// sender reports are suppressed too while the media is paused
+	if atomic.LoadInt32(sm.paused) != 0 {
+		return nil
+	}
+
+	return sm.ss.pushWrite(len(payload), func() {
 		sm.writePacketRTCPInQueue(payload)
 	})
-	if !ok {
-		return liberrors.ErrServerWriteQueueFull{}
+}
+
+func (sm *serverSessionMedia) processCongestionFeedback(pkt *rtp.Packet, size int, now time.Time) {
+	if sm.congestionFeedback == nil {
+		return
 	}
 
-	return nil
+	for _, fb := range sm.congestionFeedback.processPacket(pkt, size, now) {
+		sm.ss.WritePacketRTCP(sm.media, fb) //nolint:errcheck
+	}
 }
 
 func (sm *serverSessionMedia) readRTCPUDPPlay(payload []byte) {
 	plen := len(payload)
 
 	atomic.AddUint64(sm.ss.bytesReceived, uint64(plen))
+	sm.ss.s.events.bytesReceived(EventSource{Session: sm.ss}, plen)
+	sm.ss.s.metrics.bytesReceived[*sm.ss.setuppedTransport].Add(uint64(plen))
 
 	if plen == (udpMaxPayloadSize + 1) {
 		sm.ss.onDecodeError(liberrors.ErrServerRTCPPacketTooBigUDP{})
 		return
 	}
 
+	sm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		sm.ss.onDecodeError(err)
@@ -181,9 +625,10 @@ func (sm *serverSessionMedia) readRTCPUDPPlay(payload []byte) {
 	}
 
 	now := sm.ss.s.timeNow()
-	atomic.StoreInt64(sm.ss.udpLastPacketTime, now.Unix())
+	atomic.StoreInt64(sm.ss.udpLastRTCPTime, now.UnixNano())
 
 	for _, pkt := range packets {
+		sm.forwardRTCPToStream(pkt)
 		sm.onPacketRTCP(pkt)
 	}
 }
@@ -192,12 +637,18 @@ func (sm *serverSessionMedia) readRTPUDPRecord(payload []byte) {
 	plen := len(payload)
 
 	atomic.AddUint64(sm.ss.bytesReceived, uint64(plen))
+	sm.ss.s.events.bytesReceived(EventSource{Session: sm.ss}, plen)
+	sm.ss.s.metrics.bytesReceived[*sm.ss.setuppedTransport].Add(uint64(plen))
 
 	if plen == (udpMaxPayloadSize + 1) {
 		sm.ss.onDecodeError(liberrors.ErrServerRTPPacketTooBigUDP{})
 		return
 	}
 
+	if !sm.ss.checkRecordPacketSize(plen) {
+		return
+	}
+
 	pkt := &rtp.Packet{}
 	err := pkt.Unmarshal(payload)
 	if err != nil {
@@ -212,7 +663,9 @@ func (sm *serverSessionMedia) readRTPUDPRecord(payload []byte) {
 	}
 
 	now := sm.ss.s.timeNow()
-	atomic.StoreInt64(sm.ss.udpLastPacketTime, now.Unix())
+	atomic.StoreInt64(sm.ss.udpLastRTPTime, now.UnixNano())
+
+	sm.processCongestionFeedback(pkt, plen, now)
 
 	forma.readRTPUDP(pkt, now)
 }
@@ -221,12 +674,20 @@ func (sm *serverSessionMedia) readRTCPUDPRecord(payload []byte) {
 	plen := len(payload)
 
 	atomic.AddUint64(sm.ss.bytesReceived, uint64(plen))
+	sm.ss.s.events.bytesReceived(EventSource{Session: sm.ss}, plen)
+	sm.ss.s.metrics.bytesReceived[*sm.ss.setuppedTransport].Add(uint64(plen))
 
 	if plen == (udpMaxPayloadSize + 1) {
 		sm.ss.onDecodeError(liberrors.ErrServerRTCPPacketTooBigUDP{})
 		return
 	}
 
+	if !sm.ss.checkRecordPacketSize(plen) {
+		return
+	}
+
+	sm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		sm.ss.onDecodeError(err)
@@ -234,7 +695,7 @@ func (sm *serverSessionMedia) readRTCPUDPRecord(payload []byte) {
 	}
 
 	now := sm.ss.s.timeNow()
-	atomic.StoreInt64(sm.ss.udpLastPacketTime, now.Unix())
+	atomic.StoreInt64(sm.ss.udpLastRTCPTime, now.UnixNano())
 
 	for _, pkt := range packets {
 		if sr, ok := pkt.(*rtcp.SenderReport); ok {
@@ -244,6 +705,18 @@ func (sm *serverSessionMedia) readRTCPUDPRecord(payload []byte) {
 			}
 		}
 
+		if xr, ok := pkt.(*rtcp.ExtendedReport); ok {
+			sm.processExtendedReport(xr, now)
+		}
+
+		if sdes, ok := pkt.(*rtcp.SourceDescription); ok {
+			sm.processSourceDescription(sdes)
+		}
+
+		if bye, ok := pkt.(*rtcp.Goodbye); ok {
+			sm.processGoodbye(bye)
+		}
+
 		sm.onPacketRTCP(pkt)
 	}
 }
@@ -257,6 +730,8 @@ func (sm *serverSessionMedia) readRTCPTCPPlay(payload []byte) {
 		return
 	}
 
+	sm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		sm.ss.onDecodeError(err)
@@ -264,6 +739,7 @@ func (sm *serverSessionMedia) readRTCPTCPPlay(payload []byte) {
 	}
 
 	for _, pkt := range packets {
+		sm.forwardRTCPToStream(pkt)
 		sm.onPacketRTCP(pkt)
 	}
 }
@@ -282,6 +758,8 @@ func (sm *serverSessionMedia) readRTPTCPRecord(payload []byte) {
 		return
 	}
 
+	sm.processCongestionFeedback(pkt, len(payload), sm.ss.s.timeNow())
+
 	forma.readRTPTCP(pkt)
 }
 
@@ -291,6 +769,8 @@ func (sm *serverSessionMedia) readRTCPTCPRecord(payload []byte) {
 		return
 	}
 
+	sm.onPacketRTCPRaw(payload)
+
 	packets, err := rtcp.Unmarshal(payload)
 	if err != nil {
 		sm.ss.onDecodeError(err)
@@ -307,6 +787,18 @@ func (sm *serverSessionMedia) readRTCPTCPRecord(payload []byte) {
 			}
 		}
 
+		if xr, ok := pkt.(*rtcp.ExtendedReport); ok {
+			sm.processExtendedReport(xr, now)
+		}
+
+		if sdes, ok := pkt.(*rtcp.SourceDescription); ok {
+			sm.processSourceDescription(sdes)
+		}
+
+		if bye, ok := pkt.(*rtcp.Goodbye); ok {
+			sm.processGoodbye(bye)
+		}
+
 		sm.onPacketRTCP(pkt)
 	}
 }
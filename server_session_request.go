@@ -0,0 +1,134 @@
+package gortsplib
+
+import (
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// endOfStreamNotice is the X-Notice value of ANNOUNCE requests
+// that signal the end of the stream.
+const endOfStreamNotice = `2101 "End-of-Stream Reached"`
+
+type serverSessionConnRes struct {
+	sc *ServerConn
+	u  *base.URL
+}
+
+type serverSessionConnReq struct {
+	res chan serverSessionConnRes
+}
+
+// Request sends a request to the client, through the connection that sent
+// the last request of the session, and waits for the response.
+// When the URL of the request is nil, it is filled with the URL of the session.
+// The CSeq and Session headers are set automatically.
+// It must not be called inside ServerHandler callbacks.
+func (ss *ServerSession) Request(req *base.Request) (*base.Response, error) {
+	sc, u, err := ss.requestConn()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL == nil {
+		req.URL = u
+	}
+
+	return sc.request(req)
+}
+
+// requestConn returns the connection used to send requests to the client and the URL of the session.
+func (ss *ServerSession) requestConn() (*ServerConn, *base.URL, error) {
+	req := serverSessionConnReq{res: make(chan serverSessionConnRes)}
+
+	var res serverSessionConnRes
+
+	select {
+	case ss.chConn <- req:
+		res = <-req.res
+
+	case <-ss.ctx.Done():
+		return nil, nil, liberrors.ErrServerTerminated{}
+	}
+
+	if res.sc == nil {
+		return nil, nil, liberrors.ErrServerSessionNoConnection{}
+	}
+
+	return res.sc, res.u, nil
+}
+
+// Ping sends a GET_PARAMETER request without body to the client,
+// in order to check whether it is still alive.
+// It is mostly useful with TCP sessions, whose clients are not required to send keepalives.
+func (ss *ServerSession) Ping() error {
+	return checkClientResponse(ss.Request(&base.Request{
+		Method: base.GetParameter,
+	}))
+}
+
+// AnnounceEndOfStream notifies the client that the stream has ended,
+// by sending an ANNOUNCE request with an end-of-stream notice.
+func (ss *ServerSession) AnnounceEndOfStream() error {
+	return checkClientResponse(ss.Request(&base.Request{
+		Method: base.Announce,
+		Header: base.Header{
+			"X-Notice": base.HeaderValue{endOfStreamNotice},
+		},
+	}))
+}
+
+// announceDescription sends an ANNOUNCE request that contains the description of a stream.
+func (ss *ServerSession) announceDescription(st *ServerStream) error {
+	sc, u, err := ss.requestConn()
+	if err != nil {
+		return err
+	}
+
+	byts, err := serverSideDescription(st.desc, u).Marshal(false)
+	if err != nil {
+		return err
+	}
+
+	return checkClientResponse(sc.request(&base.Request{
+		Method: base.Announce,
+		URL:    u,
+		Header: base.Header{
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: byts,
+	}))
+}
+
+// checkClientResponse checks that a request has been accepted by the client.
+func checkClientResponse(res *base.Response, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return liberrors.ErrServerBadStatusCode{
+			Code:     res.StatusCode,
+			Message:  res.StatusMessage,
+			Response: res,
+		}
+	}
+
+	return nil
+}
+
+// handleConnRequest returns the connection that is used to send requests to the client.
+func (ss *ServerSession) handleConnRequest(req serverSessionConnReq) {
+	sc := ss.lastConn
+	if _, ok := ss.conns[sc]; !ok {
+		sc = nil
+		for c := range ss.conns {
+			sc = c
+			break
+		}
+	}
+
+	req.res <- serverSessionConnRes{
+		sc: sc,
+		u:  ss.lastRequestURL,
+	}
+}
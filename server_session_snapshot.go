@@ -0,0 +1,429 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtptime"
+	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
+)
+
+type fileConn interface {
+	File() (*os.File, error)
+}
+
+func dupFile(v interface{}) (*os.File, error) {
+	fc, ok := v.(fileConn)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support file descriptor passing", v)
+	}
+	return fc.File()
+}
+
+// ServerSessionMediaSnapshot is the state of a setupped media of a ServerSession.
+type ServerSessionMediaSnapshot struct {
+	// index of the media inside the stream description (play)
+	// or inside the announced description (record).
+	MediaIndex int
+
+	// client ports (UDP only).
+	ClientPorts *[2]int `json:",omitempty"`
+
+	// first interleaved channel (TCP only).
+	TCPChannel int
+}
+
+// ServerSessionSnapshot is the minimal state of a ServerSession
+// that is needed to restore it inside another process,
+// in order to upgrade a server without interrupting sessions.
+// It can be serialized with encoding/json.
+type ServerSessionSnapshot struct {
+	// session ID.
+	ID string
+
+	// state, that is either ServerSessionStatePlay or ServerSessionStateRecord.
+	State ServerSessionState
+
+	// path and query sent during SETUP or ANNOUNCE.
+	Path  string
+	Query string
+
+	// transport protocol, that is either TransportUDP or TransportTCP.
+	Transport Transport
+
+	// setupped medias.
+	Medias []ServerSessionMediaSnapshot
+
+	// SDP of the announced description (record only).
+	AnnouncedDescription []byte `json:",omitempty"`
+}
+
+// Snapshot exports the state of the session, that can be restored with Server.RestoreSession.
+// Only sessions that are playing or recording with TCP or UDP-unicast transports can be exported.
+// Sessions that use SRTP can't be exported, since the state of the encryption is not exported.
+func (ss *ServerSession) Snapshot() (*ServerSessionSnapshot, error) {
+	if ss.state != ServerSessionStatePlay && ss.state != ServerSessionStateRecord {
+		return nil, fmt.Errorf("session is not playing or recording")
+	}
+
+	if *ss.setuppedTransport != TransportUDP && *ss.setuppedTransport != TransportTCP {
+		return nil, fmt.Errorf("transport %v can't be exported", *ss.setuppedTransport)
+	}
+
+	snap := &ServerSessionSnapshot{
+		ID:        ss.secretID,
+		State:     ss.state,
+		Path:      ss.setuppedPath,
+		Query:     ss.setuppedQuery,
+		Transport: *ss.setuppedTransport,
+	}
+
+	var medias []*description.Media
+	if ss.state == ServerSessionStatePlay {
+		medias = ss.SetuppedStream().desc.Medias
+	} else {
+		medias = ss.announcedDesc.Medias
+
+		var err error
+		snap.AnnouncedDescription, err = ss.announcedDesc.Marshal(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sm := range ss.setuppedMediasOrdered {
+		if sm.srtp != nil {
+			return nil, fmt.Errorf("sessions that use SRTP can't be exported")
+		}
+
+		msnap := ServerSessionMediaSnapshot{
+			MediaIndex: mediaIndex(medias, sm.media),
+		}
+
+		if *ss.setuppedTransport == TransportUDP {
+			msnap.ClientPorts = &[2]int{sm.udpRTPReadPort, sm.udpRTCPReadPort}
+		} else {
+			msnap.TCPChannel = sm.tcpChannel
+		}
+
+		snap.Medias = append(snap.Medias, msnap)
+	}
+
+	return snap, nil
+}
+
+func mediaIndex(medias []*description.Media, medi *description.Media) int {
+	for i, m := range medias {
+		if m == medi {
+			return i
+		}
+	}
+	return -1
+}
+
+type serverRestoreSessionRes struct {
+	ss  *ServerSession
+	err error
+}
+
+type serverRestoreSessionReq struct {
+	snap   *ServerSessionSnapshot
+	stream *ServerStream
+	nconn  net.Conn
+	res    chan serverRestoreSessionRes
+}
+
+// RestoreSession restores a session that has been exported with ServerSession.Snapshot(),
+// usually by another process.
+// stream is the stream read by the session, and is required when the session is playing.
+// nconn is the RTSP connection of the client, that is usually inherited from the other process
+// through ServerConn.File() and net.FileConn().
+// OnConnOpen and OnSessionOpen are called, while OnSetup, OnPlay and OnRecord are not,
+// therefore callbacks of recording sessions must be set inside OnSessionOpen.
+// The other process must be stopped with Close(), since Shutdown() notifies readers
+// that the stream is ending.
+// TLS connections can't be restored.
+func (s *Server) RestoreSession(
+	snap *ServerSessionSnapshot,
+	stream *ServerStream,
+	nconn net.Conn,
+) (*ServerSession, error) {
+	req := serverRestoreSessionReq{
+		snap:   snap,
+		stream: stream,
+		nconn:  nconn,
+		res:    make(chan serverRestoreSessionRes),
+	}
+
+	select {
+	case s.chRestoreSession <- req:
+		res := <-req.res
+		if res.err != nil {
+			return nil, res.err
+		}
+
+		// wait until the session is sending or receiving packets
+		<-res.ss.restored
+
+		return res.ss, nil
+
+	case <-s.ctx.Done():
+		return nil, liberrors.ErrServerTerminated{}
+	}
+}
+
+// restoreSession is called by the server goroutine.
+func (s *Server) restoreSession(req serverRestoreSessionReq) (*ServerSession, error) {
+	snap := req.snap
+
+	if s.draining {
+		return nil, liberrors.ErrServerShuttingDown{}
+	}
+
+	if s.TLSConfig != nil {
+		return nil, fmt.Errorf("sessions can't be restored when TLS is enabled")
+	}
+
+	if snap.ID == "" {
+		return nil, fmt.Errorf("session ID is missing")
+	}
+
+	if _, ok := s.sessions[snap.ID]; ok {
+		return nil, fmt.Errorf("session '%s' already exists", snap.ID)
+	}
+
+	switch snap.Transport {
+	case TransportUDP:
+		if s.udpRTPListener == nil {
+			return nil, fmt.Errorf("UDP is disabled")
+		}
+
+	case TransportTCP:
+
+	default:
+		return nil, fmt.Errorf("transport %v can't be restored", snap.Transport)
+	}
+
+	var desc *description.Session
+
+	switch snap.State {
+	case ServerSessionStatePlay:
+		if req.stream == nil {
+			return nil, fmt.Errorf("stream is required by sessions that are playing")
+		}
+		desc = req.stream.desc
+
+	case ServerSessionStateRecord:
+		var ssd sdp.SessionDescription
+		err := ssd.Unmarshal(snap.AnnouncedDescription)
+		if err != nil {
+			return nil, liberrors.ErrServerSDPInvalid{Err: err}
+		}
+
+		desc = &description.Session{}
+		err = desc.Unmarshal(&ssd)
+		if err != nil {
+			return nil, liberrors.ErrServerSDPInvalid{Err: err}
+		}
+
+		if len(snap.Medias) != len(desc.Medias) {
+			return nil, liberrors.ErrServerNotAllAnnouncedMediasSetup{}
+		}
+
+	default:
+		return nil, fmt.Errorf("state %v can't be restored", snap.State)
+	}
+
+	if len(snap.Medias) == 0 {
+		return nil, fmt.Errorf("no medias have been setupped")
+	}
+
+	medias := make([]*description.Media, len(snap.Medias))
+
+	for i, msnap := range snap.Medias {
+		if msnap.MediaIndex < 0 || msnap.MediaIndex >= len(desc.Medias) {
+			return nil, liberrors.ErrServerMediaNotFound{}
+		}
+
+		medi := desc.Medias[msnap.MediaIndex]
+		if mediaIndex(medias[:i], medi) >= 0 {
+			return nil, liberrors.ErrServerMediaAlreadySetup{}
+		}
+
+		if len(medi.Crypto) != 0 {
+			return nil, fmt.Errorf("sessions that use SRTP can't be restored")
+		}
+
+		if snap.Transport == TransportUDP && msnap.ClientPorts == nil {
+			return nil, liberrors.ErrServerTransportHeaderNoClientPorts{}
+		}
+
+		medias[i] = medi
+	}
+
+	sc := allocServerConn(s, req.nconn)
+	ss := allocServerSession(s, sc, snap.ID)
+
+	transport := snap.Transport
+	ss.setuppedTransport = &transport
+	ss.setuppedPath = snap.Path
+	ss.setuppedQuery = snap.Query
+
+	if snap.State == ServerSessionStatePlay {
+		ss.state = ServerSessionStatePrePlay
+	} else {
+		ss.state = ServerSessionStatePreRecord
+		ss.announcedDesc = desc
+	}
+
+	ss.setuppedMedias = make(map[*description.Media]*serverSessionMedia)
+
+	for i, medi := range medias {
+		sm := newServerSessionMedia(ss, medi)
+
+		if transport == TransportUDP {
+			sm.udpRTPReadPort = snap.Medias[i].ClientPorts[0]
+			sm.udpRTCPReadPort = snap.Medias[i].ClientPorts[1]
+
+			sm.udpRTPWriteAddr = &net.UDPAddr{
+				IP:   sc.ip(),
+				Zone: sc.zone(),
+				Port: sm.udpRTPReadPort,
+			}
+
+			sm.udpRTCPWriteAddr = &net.UDPAddr{
+				IP:   sc.ip(),
+				Zone: sc.zone(),
+				Port: sm.udpRTCPReadPort,
+			}
+		} else {
+			sm.tcpChannel = snap.Medias[i].TCPChannel
+		}
+
+		ss.setuppedMedias[medi] = sm
+		ss.setuppedMediasOrdered = append(ss.setuppedMediasOrdered, sm)
+	}
+
+	if ss.state == ServerSessionStatePrePlay {
+		var clientPorts *[2]int
+		if transport == TransportUDP {
+			clientPorts = snap.Medias[0].ClientPorts
+		}
+
+		err := req.stream.readerAdd(ss, clientPorts)
+		if err != nil {
+			ss.ctxCancel()
+			sc.ctxCancel()
+			return nil, err
+		}
+
+		ss.setuppedStream = req.stream
+		ss.allocateWriteQueue()
+		ss.state = ServerSessionStatePlay
+	} else {
+		ss.writer.allocateBuffer(8)
+		ss.streamEnded = new(int32)
+		ss.state = ServerSessionStateRecord
+	}
+
+	ss.restored = make(chan struct{})
+	ss.conns[sc] = struct{}{}
+	sc.session = ss
+
+	if transport == TransportTCP {
+		ss.tcpConn = sc
+	}
+
+	s.conns[sc] = struct{}{}
+	s.sessions[ss.secretID] = ss
+	atomic.StoreInt64(s.sessionCount, int64(len(s.sessions)))
+
+	s.wg.Add(2)
+	go ss.run()
+	go sc.run()
+
+	ss.log.Info("session restored", "state", ss.state, "transport", transport)
+
+	return ss, nil
+}
+
+// startRestored starts a restored session.
+// It is called by the session goroutine after OnSessionOpen,
+// in order to allow setting callbacks.
+func (ss *ServerSession) startRestored() {
+	atomic.StoreInt32(ss.started, 1)
+
+	ss.resetActivity()
+
+	ss.timeDecoder = rtptime.NewGlobalDecoder()
+
+	for _, sm := range ss.setuppedMedias {
+		sm.start()
+	}
+
+	if *ss.setuppedTransport == TransportUDP {
+		ss.udpCheckStreamTimer = ss.s.Clock.NewTimer(ss.s.checkStreamPeriod)
+		ss.writer.start()
+	}
+	// with TCP, writer.start() is called by ServerConn
+
+	if ss.state == ServerSessionStatePlay {
+		ss.setuppedStream.readerSetActive(ss)
+	}
+
+	close(ss.restored)
+}
+
+// File returns a duplicate of the file descriptor of the connection,
+// that can be passed to another process in order to restore sessions with Server.RestoreSession.
+func (sc *ServerConn) File() (*os.File, error) {
+	if sc.tlsConn != nil {
+		return nil, fmt.Errorf("TLS connections can't be exported")
+	}
+	return dupFile(sc.nconn)
+}
+
+// ServerListenerFiles contains duplicates of the file descriptors of the listeners of a Server.
+type ServerListenerFiles struct {
+	// RTSP listener.
+	TCP *os.File
+
+	// RTP and RTCP listeners (UDP only).
+	UDPRTP  *os.File
+	UDPRTCP *os.File
+}
+
+// ListenerFiles returns duplicates of the file descriptors of the listeners of the server,
+// that can be passed to another process and used inside Server.Listen and Server.ListenPacket
+// through net.FileListener and net.FilePacketConn, in order to keep listening on the same sockets
+// without interruptions.
+func (s *Server) ListenerFiles() (*ServerListenerFiles, error) {
+	ret := &ServerListenerFiles{}
+
+	var err error
+	ret.TCP, err = dupFile(s.tcpListener.ln)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.udpRTPListener != nil {
+		ret.UDPRTP, err = dupFile(s.udpRTPListener.pc)
+		if err != nil {
+			ret.TCP.Close()
+			return nil, err
+		}
+
+		ret.UDPRTCP, err = dupFile(s.udpRTCPListener.pc)
+		if err != nil {
+			ret.TCP.Close()
+			ret.UDPRTP.Close()
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,282 @@
+package gortsplib
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+func testIDRPacket(seqNum uint16, timestamp uint32, ssrc uint32) *rtp.Packet {
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: seqNum,
+			Timestamp:      timestamp,
+			SSRC:           ssrc,
+		},
+		Payload: []byte{0x05, 1, 2, 3},
+	}
+}
+
+func TestServerStreamSnapshot(t *testing.T) {
+	s := &Server{
+		Handler:     &testServerHandler{},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	desc := &description.Session{Medias: []*description.Media{testH264Media}}
+
+	stream1 := NewServerStream(s, desc)
+	defer stream1.Close()
+
+	require.Equal(t, &ServerStreamSnapshot{}, stream1.Snapshot())
+
+	ntp := time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)
+
+	err = stream1.WritePacketRTPWithNTP(testH264Media, testIDRPacket(1000, 45000, 0x38F27A2F), ntp)
+	require.NoError(t, err)
+
+	byts, err := json.Marshal(stream1.Snapshot())
+	require.NoError(t, err)
+
+	var snap ServerStreamSnapshot
+	err = json.Unmarshal(byts, &snap)
+	require.NoError(t, err)
+
+	require.Equal(t, ServerStreamSnapshot{
+		Formats: []ServerStreamFormatSnapshot{{
+			MediaIndex:         0,
+			PayloadType:        96,
+			SSRC:               0x38F27A2F,
+			LastSequenceNumber: 1000,
+			LastRTPTime:        45000,
+			LastNTP:            ntp,
+		}},
+	}, snap)
+
+	stream2 := NewServerStream(s, desc)
+	defer stream2.Close()
+
+	err = stream2.Restore(&snap)
+	require.NoError(t, err)
+
+	err = stream2.WritePacketRTPWithNTP(testH264Media, testIDRPacket(5, 10, 0x12345678), ntp.Add(1*time.Second))
+	require.NoError(t, err)
+
+	require.Equal(t, &ServerStreamSnapshot{
+		Formats: []ServerStreamFormatSnapshot{{
+			MediaIndex:         0,
+			PayloadType:        96,
+			SSRC:               0x38F27A2F,
+			LastSequenceNumber: 1001,
+			LastRTPTime:        45000 + 90000,
+			LastNTP:            ntp.Add(1 * time.Second),
+		}},
+	}, stream2.Snapshot())
+
+	err = stream2.Restore(&ServerStreamSnapshot{
+		Formats: []ServerStreamFormatSnapshot{{
+			MediaIndex:  0,
+			PayloadType: 97,
+		}},
+	})
+	require.Error(t, err)
+}
+
+func TestServerRestoreSession(t *testing.T) {
+	for _, transport := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(transport, func(t *testing.T) {
+			type playReq struct {
+				ss *ServerSession
+				sc *ServerConn
+			}
+
+			var stream1 *ServerStream
+			chPlay := make(chan playReq, 1)
+
+			s1 := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream1, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream1, nil
+					},
+					onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+						chPlay <- playReq{ss: ctx.Session, sc: ctx.Conn}
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress:    "localhost:8554",
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+			}
+
+			err := s1.Start()
+			require.NoError(t, err)
+			defer s1.Close()
+
+			desc1 := &description.Session{Medias: []*description.Media{{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{PayloadTyp: 96, PacketizationMode: 1}},
+			}}}
+
+			stream1 = NewServerStream(s1, desc1)
+
+			c := Client{
+				Transport: func() *Transport {
+					if transport == "udp" {
+						return transportPtr(TransportUDP)
+					}
+					return transportPtr(TransportTCP)
+				}(),
+			}
+
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
+
+			sd, _, err := c.Describe(u)
+			require.NoError(t, err)
+
+			err = c.SetupAll(sd.BaseURL, sd.Medias)
+			require.NoError(t, err)
+
+			chPacket := make(chan *rtp.Packet)
+
+			c.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+				chPacket <- pkt
+			})
+
+			_, err = c.Play(nil)
+			require.NoError(t, err)
+
+			play := <-chPlay
+
+			ntp := time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)
+
+			err = stream1.WritePacketRTPWithNTP(desc1.Medias[0], testIDRPacket(1000, 45000, 0x38F27A2F), ntp)
+			require.NoError(t, err)
+
+			pkt := <-chPacket
+			require.Equal(t, uint16(1000), pkt.SequenceNumber)
+
+			// export state, listeners and connection
+
+			sessionSnap, err := play.ss.Snapshot()
+			require.NoError(t, err)
+
+			byts, err := json.Marshal(sessionSnap)
+			require.NoError(t, err)
+
+			var sessionSnap2 ServerSessionSnapshot
+			err = json.Unmarshal(byts, &sessionSnap2)
+			require.NoError(t, err)
+			require.Equal(t, *sessionSnap, sessionSnap2)
+
+			streamSnap := stream1.Snapshot()
+
+			files, err := s1.ListenerFiles()
+			require.NoError(t, err)
+
+			connFile, err := play.sc.File()
+			require.NoError(t, err)
+			defer connFile.Close()
+
+			stream1.Close()
+			s1.Close()
+
+			// restore them
+
+			chSessionClose := make(chan *ServerSession, 1)
+
+			s2 := &Server{
+				Handler: &testServerHandler{
+					onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+						chSessionClose <- ctx.Session
+					},
+				},
+				RTSPAddress:    "localhost:8554",
+				UDPRTPAddress:  "127.0.0.1:8000",
+				UDPRTCPAddress: "127.0.0.1:8001",
+				Listen: func(_ string, _ string) (net.Listener, error) {
+					defer files.TCP.Close()
+					return net.FileListener(files.TCP)
+				},
+				ListenPacket: func(_ string, address string) (net.PacketConn, error) {
+					if strings.HasSuffix(address, ":8000") {
+						defer files.UDPRTP.Close()
+						return net.FilePacketConn(files.UDPRTP)
+					}
+					defer files.UDPRTCP.Close()
+					return net.FilePacketConn(files.UDPRTCP)
+				},
+			}
+
+			err = s2.Start()
+			require.NoError(t, err)
+			defer s2.Close()
+
+			desc2 := &description.Session{Medias: []*description.Media{{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{PayloadTyp: 96, PacketizationMode: 1}},
+			}}}
+
+			stream2 := NewServerStream(s2, desc2)
+			defer stream2.Close()
+
+			err = stream2.Restore(streamSnap)
+			require.NoError(t, err)
+
+			nconn, err := net.FileConn(connFile)
+			require.NoError(t, err)
+
+			ss, err := s2.RestoreSession(&sessionSnap2, stream2, nconn)
+			require.NoError(t, err)
+			require.Equal(t, sessionSnap.ID, ss.secretID)
+			require.Equal(t, ServerSessionStatePlay, ss.State())
+
+			_, err = s2.RestoreSession(&sessionSnap2, stream2, nconn)
+			require.Error(t, err)
+
+			err = stream2.WritePacketRTPWithNTP(desc2.Medias[0], testIDRPacket(5, 10, 0x12345678), ntp.Add(1*time.Second))
+			require.NoError(t, err)
+
+			pkt = <-chPacket
+			require.Equal(t, uint32(0x38F27A2F), pkt.SSRC)
+			require.Equal(t, uint16(1001), pkt.SequenceNumber)
+			require.Equal(t, uint32(45000+90000), pkt.Timestamp)
+
+			// the RTSP connection is still usable
+			c.Close()
+			require.Equal(t, ss, <-chSessionClose)
+		})
+	}
+}
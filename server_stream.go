@@ -1,6 +1,9 @@
 package gortsplib
 
 import (
+	"fmt"
+	"io"
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,6 +12,7 @@ import (
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
 )
@@ -23,22 +27,111 @@ func firstFormat(formats map[uint8]*serverStreamFormat) *serverStreamFormat {
 	return formats[firstKey]
 }
 
+// RTX and FlexFEC formats are excluded, since their packets are generated from packets of other formats,
+// while the primary format of RED is excluded, since its packets are sent inside RED packets.
+func primaryFormats(formats map[uint8]*serverStreamFormat) map[uint8]*serverStreamFormat {
+	ret := make(map[uint8]*serverStreamFormat, len(formats))
+	for key, sf := range formats {
+		switch sf.format.(type) {
+		case *format.RTX, *format.FlexFEC:
+		default:
+			if sf != sf.sm.redTarget {
+				ret[key] = sf
+			}
+		}
+	}
+	return ret
+}
+
+// ServerStreamMulticastConfig is the UDP-multicast configuration of a ServerStream.
+type ServerStreamMulticastConfig struct {
+	// range of multicast IPs assigned to the stream (optional).
+	// The n-th media of the stream is sent to the n-th IP of the range,
+	// therefore group assignment is deterministic.
+	// It defaults to IPs allocated from Server.MulticastIPRange.
+	IPRange string
+
+	// TTL of multicast packets (optional).
+	// It defaults to 16.
+	TTL int
+
+	// interface used to send multicast packets (optional).
+	// It defaults to all multicast-capable interfaces.
+	Interface *net.Interface
+
+	// include the source address inside the Transport header,
+	// allowing readers to perform a source-specific join (SSM).
+	SourceEnable bool
+}
+
 // ServerStream represents a data stream.
 // This is in charge of
 // - distributing the stream to each reader
 // - allocating multicast listeners
 // - gathering infos about the stream in order to generate SSRC and RTP-Info
 type ServerStream struct {
+	// when packets directed to a reader are dropped since its write queue is full,
+	// withhold video packets until the next keyframe (H264, H265, H266, AV1),
+	// in order to avoid sending partial groups of pictures that would corrupt decoding.
+	// It must be set before writing packets.
+	// It defaults to false.
+	DropUntilKeyFrame bool
+
+	// when packets of a format are written with the SSRC of another format of the stream
+	// (i.e. when multiple publishers write to the stream), replace it with a random unique SSRC
+	// before sending packets to readers. Collisions are reported to
+	// EventsListenerOnSSRCCollision anyway.
+	// It must be set before writing packets.
+	// It defaults to false.
+	RemapCollidingSSRCs bool
+
+	// inject parameter sets (H264 SPS and PPS, H265 VPS, SPS and PPS) before
+	// random access units that are not preceded by them, in order to allow
+	// readers that join the stream to decode it from the first keyframe.
+	// Parameter sets written in-band are always used to update formats of the stream,
+	// therefore DESCRIBE responses always contain the latest ones.
+	// It must be set before writing packets.
+	// It defaults to false.
+	InjectParameterSets bool
+
+	// when parameter sets written in-band change, send an ANNOUNCE request
+	// with the updated stream description to readers.
+	// Readers that don't support ANNOUNCE requests of servers may close the connection.
+	// It must be set before writing packets.
+	// It defaults to false.
+	AnnounceParameterSets bool
+
+	// period of time over which bursts of RTP packets are spread when they are sent to readers,
+	// in order not to overwhelm client networks and switches with shallow buffers
+	// (i.e. a key frame that is written in less than a millisecond is sent in PacingWindow).
+	// Packets of bursts that exceed the write queue are dropped, therefore the queue
+	// must be able to contain the largest burst. It should be smaller than the frame interval,
+	// in order not to increase latency. It doesn't apply to multicast readers.
+	// It can be overridden per session with ServerSession.SetPacingWindow().
+	// It must be set before adding readers.
+	// It defaults to zero (pacing disabled).
+	PacingWindow time.Duration
+
 	s    *Server
 	desc *description.Session
 
-	mutex                sync.RWMutex
-	readers              map[*ServerSession]struct{}
-	multicastReaderCount int
-	activeUnicastReaders map[*ServerSession]struct{}
-	streamMedias         map[*description.Media]*serverStreamMedia
-	closed               bool
-	bytesSent            *uint64
+	mutex                 sync.RWMutex
+	readers               map[*ServerSession]struct{}
+	multicastReaderCount  int
+	activeUnicastReaders  map[*ServerSession]struct{}
+	streamMedias          map[*description.Media]*serverStreamMedia
+	closed                bool
+	bytesSent             *uint64
+	bitrate               *bitrateMeter
+	onBandwidthEstimate   OnBandwidthEstimateFunc
+	onKeyframeRequest     OnKeyframeRequestFunc
+	group                 *ServerStreamGroup
+	switchingReaders      map[*ServerSession]*serverStreamGroupSwitch
+	multicastConfig       ServerStreamMulticastConfig
+	multicastNet          *net.IPNet
+	multicastWritersMoved bool
+	multicastBroadcast    bool
+	ssrcMutex             sync.Mutex
 }
 
 // NewServerStream allocates a ServerStream.
@@ -48,7 +141,9 @@ func NewServerStream(s *Server, desc *description.Session) *ServerStream {
 		desc:                 desc,
 		readers:              make(map[*ServerSession]struct{}),
 		activeUnicastReaders: make(map[*ServerSession]struct{}),
+		switchingReaders:     make(map[*ServerSession]*serverStreamGroupSwitch),
 		bytesSent:            new(uint64),
+		bitrate:              newBitrateMeter(s.timeNow),
 	}
 
 	st.streamMedias = make(map[*description.Media]*serverStreamMedia, len(desc.Medias))
@@ -56,6 +151,8 @@ func NewServerStream(s *Server, desc *description.Session) *ServerStream {
 		st.streamMedias[medi] = newServerStreamMedia(st, medi, i)
 	}
 
+	s.registerStream(st)
+
 	return st
 }
 
@@ -65,6 +162,8 @@ func (st *ServerStream) Close() {
 	st.closed = true
 	st.mutex.Unlock()
 
+	st.s.unregisterStream(st)
+
 	for ss := range st.readers {
 		ss.Close()
 	}
@@ -79,6 +178,121 @@ func (st *ServerStream) BytesSent() uint64 {
 	return atomic.LoadUint64(st.bytesSent)
 }
 
+// Bitrate returns the bitrate of written RTP packets, in bits per second,
+// measured over the last second.
+func (st *ServerStream) Bitrate() uint64 {
+	return st.bitrate.get()
+}
+
+// outboundBitrate returns the bitrate of RTP packets sent to readers.
+// Packets sent with the UDP-multicast transport are counted once.
+func (st *ServerStream) outboundBitrate() uint64 {
+	st.mutex.RLock()
+	readers := len(st.activeUnicastReaders)
+	if st.multicastReaderCount != 0 || st.multicastBroadcast {
+		readers++
+	}
+	st.mutex.RUnlock()
+
+	return st.Bitrate() * uint64(readers)
+}
+
+// readerCount returns the number of readers, including the given session.
+func (st *ServerStream) readerCount(ss *ServerSession) int {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if _, ok := st.readers[ss]; ok {
+		return len(st.readers)
+	}
+	return len(st.readers) + 1
+}
+
+// additionalBitrate returns the bitrate that is added to the outbound bitrate
+// when the given session starts reading.
+func (st *ServerStream) additionalBitrate(ss *ServerSession) uint64 {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if ss.setuppedTransport != nil && *ss.setuppedTransport == TransportUDPMulticast {
+		others := st.multicastReaderCount
+		if _, ok := st.readers[ss]; ok {
+			others--
+		}
+		if others != 0 {
+			return 0
+		}
+	}
+
+	return st.bitrate.get()
+}
+
+// OnBandwidthEstimate sets the callback that is called when a reader
+// sends a bandwidth estimate (REMB).
+// Estimates of all readers are passed to the callback.
+// It can be used to adapt the bitrate of encoders.
+func (st *ServerStream) OnBandwidthEstimate(cb OnBandwidthEstimateFunc) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	st.onBandwidthEstimate = cb
+}
+
+// OnKeyframeRequest sets the callback that is called when a reader
+// starts playing a video media or asks for a keyframe (RTCP PLI or FIR).
+// Requests of the same media are passed to the callback at most once per second.
+// It can be used to forward the request to the publisher, with ServerSession.RequestKeyframe()
+// or Client.RequestKeyframe(), in order to allow new readers to start decoding immediately.
+func (st *ServerStream) OnKeyframeRequest(cb OnKeyframeRequestFunc) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	st.onKeyframeRequest = cb
+}
+
+// SetMulticastConfig sets the UDP-multicast configuration of the stream.
+// It must be called before readers are added.
+// UDP-multicast must be enabled on the server by filling
+// MulticastIPRange, MulticastRTPPort and MulticastRTCPPort.
+func (st *ServerStream) SetMulticastConfig(conf ServerStreamMulticastConfig) error {
+	var multicastNet *net.IPNet
+
+	if conf.IPRange != "" {
+		var err error
+		_, multicastNet, err = net.ParseCIDR(conf.IPRange)
+		if err != nil {
+			return err
+		}
+
+		if multicastNet.IP.To4() == nil || !multicastNet.IP.IsMulticast() {
+			return fmt.Errorf("'%s' is not a IPv4 multicast range", conf.IPRange)
+		}
+
+		ones, bits := multicastNet.Mask.Size()
+		if (uint64(1) << (bits - ones)) <= uint64(len(st.desc.Medias)) {
+			return fmt.Errorf("range '%s' is too small for %d medias", conf.IPRange, len(st.desc.Medias))
+		}
+	}
+
+	if conf.TTL < 0 || conf.TTL > 255 {
+		return fmt.Errorf("invalid TTL: %d", conf.TTL)
+	}
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.multicastReaderCount != 0 {
+		return fmt.Errorf("multicast configuration can't be changed while there are multicast readers")
+	}
+
+	if st.multicastBroadcast {
+		return fmt.Errorf("multicast configuration can't be changed while the stream is broadcasted")
+	}
+
+	st.multicastConfig = conf
+	st.multicastNet = multicastNet
+
+	return nil
+}
+
 // Description returns the description of the stream.
 func (st *ServerStream) Description() *description.Session {
 	return st.desc
@@ -88,32 +302,87 @@ func (st *ServerStream) senderSSRC(medi *description.Media) (uint32, bool) {
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
 
-	sm := st.streamMedias[medi]
+	formats := primaryFormats(st.streamMedias[medi].formats)
 
 	// senderSSRC() is used to fill SSRC inside the Transport header.
 	// if there are multiple formats inside a single media stream,
 	// do not return anything, since Transport headers don't support multiple SSRCs.
-	if len(sm.formats) > 1 {
+	if len(formats) != 1 {
 		return 0, false
 	}
 
-	return firstFormat(sm.formats).rtcpSender.SenderSSRC()
+	return firstFormat(formats).rtcpSender.SenderSSRC()
 }
 
-func (st *ServerStream) rtpInfoEntry(medi *description.Media, now time.Time) *headers.RTPInfoEntry {
+func (st *ServerStream) senderSSRCs(medi *description.Media) []uint32 {
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
 
+	var ret []uint32
+
+	for _, sf := range st.streamMedias[medi].formats {
+		if ssrc, ok := sf.rtcpSender.SenderSSRC(); ok {
+			ret = append(ret, ssrc)
+		}
+	}
+
+	return ret
+}
+
+// readerTransportInfo fills the SSRCs and the multicast details of a media read by a session.
+func (st *ServerStream) readerTransportInfo(medi *description.Media, info *MediaTransportInfo) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	sm, ok := st.streamMedias[medi]
+	if !ok {
+		return
+	}
+
+	for pt, sf := range sm.formats {
+		if ssrc, ok := sf.rtcpSender.SenderSSRC(); ok {
+			info.SSRCs[pt] = ssrc
+		}
+	}
+
+	if info.Transport == TransportUDPMulticast && sm.multicastWriter != nil {
+		ports := &[2]int{st.s.MulticastRTPPort, sm.multicastWriter.rtcpPort()}
+		info.LocalPorts = ports
+		info.RemotePorts = ports
+		info.MulticastGroup = sm.multicastWriter.ip()
+	}
+}
+
+func (st *ServerStream) writePacketRTCPMulticast(medi *description.Media, byts []byte) error {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if st.closed {
+		return liberrors.ErrServerStreamClosed{}
+	}
+
 	sm := st.streamMedias[medi]
+	if sm.multicastWriter == nil {
+		return nil
+	}
+
+	return sm.multicastWriter.writePacketRTCP(byts)
+}
+
+func (st *ServerStream) rtpInfoEntry(medi *description.Media, now time.Time) *headers.RTPInfoEntry {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	formats := primaryFormats(st.streamMedias[medi].formats)
 
 	// if there are multiple formats inside a single media stream,
 	// do not generate a RTP-Info entry, since RTP-Info doesn't support
 	// multiple sequence numbers / timestamps.
-	if len(sm.formats) > 1 {
+	if len(formats) != 1 {
 		return nil
 	}
 
-	format := firstFormat(sm.formats)
+	format := firstFormat(formats)
 
 	lastSeqNum, lastTimeRTP, lastTimeNTP, ok := format.rtcpSender.LastPacketData()
 	if !ok {
@@ -135,10 +404,123 @@ func (st *ServerStream) rtpInfoEntry(medi *description.Media, now time.Time) *he
 		uint64(now.Sub(lastTimeNTP).Seconds()*float64(clockRate)) -
 		uint64(clockRate)/10)
 
-	return &headers.RTPInfoEntry{
+	entry := &headers.RTPInfoEntry{
 		SequenceNumber: &seqNum,
 		Timestamp:      &ts,
 	}
+
+	if ssrc, ok := format.rtcpSender.SenderSSRC(); ok {
+		entry.SSRC = &ssrc
+	}
+
+	return entry
+}
+
+func (st *ServerStream) readerRetransmit(
+	ssm *serverSessionMedia,
+	medi *description.Media,
+	nack *rtcp.TransportLayerNack,
+) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if st.closed {
+		return
+	}
+
+	// readers may have been moved to another stream in the meanwhile
+	sm, ok := st.streamMedias[medi]
+	if !ok {
+		return
+	}
+
+	sm.retransmit(ssm, nack)
+}
+
+func (st *ServerStream) readerExtendedReport(medi *description.Media, xr *rtcp.ExtendedReport) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if st.closed {
+		return
+	}
+
+	sm, ok := st.streamMedias[medi]
+	if !ok {
+		return
+	}
+
+	now := st.s.timeNow()
+
+	for _, sf := range sm.formats {
+		sf.rtcpSender.ProcessExtendedReport(xr, now)
+	}
+}
+
+// readerReceptionReports computes the round-trip time of a reader
+// from reception reports that refer to the formats of a media.
+func (st *ServerStream) readerReceptionReports(
+	medi *description.Media,
+	reports []rtcp.ReceptionReport,
+) (time.Duration, bool) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if st.closed {
+		return 0, false
+	}
+
+	sm, ok := st.streamMedias[medi]
+	if !ok {
+		return 0, false
+	}
+
+	now := st.s.timeNow()
+
+	for i := range reports {
+		for _, sf := range sm.formats {
+			if rtt, ok := sf.rtcpSender.ProcessReceptionReport(&reports[i], now); ok {
+				return rtt, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func (st *ServerStream) readerBandwidthEstimate(medi *description.Media, bitrate uint64) {
+	st.mutex.RLock()
+	cb := st.onBandwidthEstimate
+	st.mutex.RUnlock()
+
+	if cb != nil {
+		cb(medi, bitrate)
+	}
+}
+
+func (st *ServerStream) readerKeyframeRequest(medi *description.Media) {
+	st.mutex.RLock()
+	cb := st.onKeyframeRequest
+	sm, ok := st.streamMedias[medi]
+	closed := st.closed
+	st.mutex.RUnlock()
+
+	if cb == nil || !ok || closed {
+		return
+	}
+
+	now := st.s.timeNow().UnixNano()
+	last := atomic.LoadInt64(sm.lastKeyframeRequest)
+
+	if last != 0 && time.Duration(now-last) < keyframeRequestMinInterval {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt64(sm.lastKeyframeRequest, last, now) {
+		return
+	}
+
+	cb(medi)
 }
 
 func (st *ServerStream) readerAdd(
@@ -152,6 +534,10 @@ func (st *ServerStream) readerAdd(
 		return liberrors.ErrServerStreamClosed{}
 	}
 
+	if st.s.MaxReadersPerStream != 0 && len(st.readers) >= st.s.MaxReadersPerStream {
+		return liberrors.ErrServerReadersLimitReached{Max: st.s.MaxReadersPerStream}
+	}
+
 	switch *ss.setuppedTransport {
 	case TransportUDP:
 		// check whether UDP ports and IP are already assigned to another reader
@@ -168,13 +554,14 @@ func (st *ServerStream) readerAdd(
 		}
 
 	case TransportUDPMulticast:
-		if st.multicastReaderCount == 0 {
-			for _, media := range st.streamMedias {
-				mh, err := newServerMulticastWriter(st.s)
-				if err != nil {
-					return err
-				}
-				media.multicastWriter = mh
+		if st.multicastWritersMoved {
+			return liberrors.ErrServerStreamReadersMoved{}
+		}
+
+		if st.multicastReaderCount == 0 && !st.multicastBroadcast {
+			err := st.allocateMulticastWriters()
+			if err != nil {
+				return err
 			}
 		}
 		st.multicastReaderCount++
@@ -193,19 +580,51 @@ func (st *ServerStream) readerRemove(ss *ServerSession) {
 		return
 	}
 
+	if _, ok := st.readers[ss]; !ok {
+		return
+	}
+
 	delete(st.readers, ss)
 
 	if *ss.setuppedTransport == TransportUDPMulticast {
 		st.multicastReaderCount--
 		if st.multicastReaderCount == 0 {
-			for _, media := range st.streamMedias {
-				media.multicastWriter.close()
-				media.multicastWriter = nil
+			st.multicastWritersMoved = false
+			if !st.multicastBroadcast {
+				st.closeMulticastWriters()
 			}
 		}
 	}
 }
 
+func (st *ServerStream) allocateMulticastWriters() error {
+	for medi, media := range st.streamMedias {
+		var ip net.IP
+		if st.multicastNet != nil {
+			ip = nthIP(st.multicastNet, uint32(media.trackID)+1)
+		}
+
+		mh, err := newServerMulticastWriter(st.s, medi, ip, st.multicastConfig.TTL, st.multicastConfig.Interface)
+		if err != nil {
+			st.closeMulticastWriters()
+			return err
+		}
+		media.multicastWriter = mh
+	}
+
+	return nil
+}
+
+func (st *ServerStream) closeMulticastWriters() {
+	for _, media := range st.streamMedias {
+		// writers may have been moved to another stream
+		if media.multicastWriter != nil {
+			media.multicastWriter.close()
+			media.multicastWriter = nil
+		}
+	}
+}
+
 func (st *ServerStream) readerSetActive(ss *ServerSession) {
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
@@ -216,16 +635,34 @@ func (st *ServerStream) readerSetActive(ss *ServerSession) {
 
 	if *ss.setuppedTransport == TransportUDPMulticast {
 		for medi, sm := range ss.setuppedMedias {
-			streamMedia := st.streamMedias[medi]
+			streamMedia, ok := st.streamMedias[medi]
+			if !ok || streamMedia.multicastWriter == nil {
+				continue
+			}
 			streamMedia.multicastWriter.rtcpl.addClient(
 				ss.author.ip(), streamMedia.multicastWriter.rtcpl.port(), sm.readRTCPUDPPlay)
 		}
 	} else {
-		st.activeUnicastReaders[ss] = struct{}{}
+		if _, ok := st.activeUnicastReaders[ss]; !ok {
+			st.activeUnicastReaders[ss] = struct{}{}
+			st.addActiveReader(ss)
+		}
+		ss.pacer.setStreamWindow(st.PacingWindow)
+
+		if st.s.KeyframeTimeout != 0 {
+			deadline := st.s.timeNow().Add(st.s.KeyframeTimeout).UnixNano()
+			for _, sm := range ss.setuppedMedias {
+				atomic.StoreInt64(sm.keyframeDeadline, deadline)
+			}
+		}
 	}
 }
 
 func (st *ServerStream) readerSetInactive(ss *ServerSession) {
+	if st.group != nil {
+		st.group.cancelSwitch(ss)
+	}
+
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
 
@@ -235,12 +672,77 @@ func (st *ServerStream) readerSetInactive(ss *ServerSession) {
 
 	if *ss.setuppedTransport == TransportUDPMulticast {
 		for medi := range ss.setuppedMedias {
-			streamMedia := st.streamMedias[medi]
+			streamMedia, ok := st.streamMedias[medi]
+			if !ok || streamMedia.multicastWriter == nil {
+				continue
+			}
 			streamMedia.multicastWriter.rtcpl.removeClient(ss.author.ip(), streamMedia.multicastWriter.rtcpl.port())
 		}
 	} else {
-		delete(st.activeUnicastReaders, ss)
+		if _, ok := st.activeUnicastReaders[ss]; ok {
+			delete(st.activeUnicastReaders, ss)
+			st.removeActiveReader(ss)
+		}
+	}
+}
+
+// addActiveReader adds the medias of a session to the active unicast readers of each media,
+// in order to write packets without looking up readers and their medias.
+// It must be called with the mutex locked.
+func (st *ServerStream) addActiveReader(ss *ServerSession) {
+	for medi, ssm := range ss.setuppedMedias {
+		if sm, ok := st.streamMedias[medi]; ok {
+			sm.readers.add(ssm)
+		}
+	}
+}
+
+// removeActiveReader removes the medias of a session from the active unicast readers of each media.
+// It must be called with the mutex locked.
+func (st *ServerStream) removeActiveReader(ss *ServerSession) {
+	for medi, ssm := range ss.setuppedMedias {
+		if sm, ok := st.streamMedias[medi]; ok {
+			sm.readers.remove(ssm)
+		}
+	}
+}
+
+// removeSwitchingReader removes a session that is switching to the stream.
+func (st *ServerStream) removeSwitchingReader(ss *ServerSession) *serverStreamGroupSwitch {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	sw, ok := st.switchingReaders[ss]
+	if !ok {
+		return nil
+	}
+
+	delete(st.switchingReaders, ss)
+	return sw
+}
+
+// multicastSource returns the source address of multicast packets,
+// or nil if it must not be advertised.
+func (st *ServerStream) multicastSource(localIP net.IP) net.IP {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if !st.multicastConfig.SourceEnable {
+		return nil
 	}
+
+	if st.multicastConfig.Interface != nil {
+		addrs, err := st.multicastConfig.Interface.Addrs()
+		if err == nil {
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+					return ipnet.IP.To4()
+				}
+			}
+		}
+	}
+
+	return localIP
 }
 
 // WritePacketRTP writes a RTP packet to all the readers of the stream.
@@ -251,13 +753,6 @@ func (st *ServerStream) WritePacketRTP(medi *description.Media, pkt *rtp.Packet)
 // WritePacketRTPWithNTP writes a RTP packet to all the readers of the stream.
 // ntp is the absolute time of the packet, and is sent with periodic RTCP sender reports.
 func (st *ServerStream) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet, ntp time.Time) error {
-	byts := make([]byte, st.s.MaxPacketSize)
-	n, err := pkt.MarshalTo(byts)
-	if err != nil {
-		return err
-	}
-	byts = byts[:n]
-
 	st.mutex.RLock()
 	defer st.mutex.RUnlock()
 
@@ -266,8 +761,79 @@ func (st *ServerStream) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.
 	}
 
 	sm := st.streamMedias[medi]
-	sf := sm.formats[pkt.PayloadType]
-	return sf.writePacketRTP(byts, pkt, ntp)
+	sf, ok := sm.formats[pkt.PayloadType]
+	if !ok {
+		return liberrors.ErrServerRTPPacketPayloadTypeNotInMedia{PayloadType: pkt.PayloadType}
+	}
+
+	if sf.parameterSets != nil {
+		var injected *rtp.Packet
+		var changed bool
+		injected, pkt, changed = sf.parameterSets.process(pkt, st.InjectParameterSets)
+
+		if changed && st.AnnounceParameterSets {
+			st.announceDescription()
+		}
+
+		if injected != nil {
+			err := st.writePacketRTPInner(sf, injected, ntp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if sf == sm.redTarget {
+		pkt = sm.encodeRED(pkt)
+		sf = sm.redFormat
+	}
+
+	return st.writePacketRTPInner(sf, pkt, ntp)
+}
+
+func (st *ServerStream) writePacketRTPInner(sf *serverStreamFormat, pkt *rtp.Packet, ntp time.Time) error {
+	if sf.continuity != nil {
+		pkt = sf.continuity.process(pkt, ntp)
+	}
+
+	pkt = sf.mapSSRC(pkt)
+
+	// marshal the packet once, into a buffer shared among all readers.
+	n := pkt.MarshalSize()
+	if n > st.s.MaxPacketSize {
+		return io.ErrShortBuffer
+	}
+
+	byts := make([]byte, n)
+	_, err := pkt.MarshalTo(byts)
+	if err != nil {
+		return err
+	}
+
+	st.bitrate.add(n)
+
+	err = sf.writePacketRTP(byts, pkt, ntp)
+	if err != nil {
+		return err
+	}
+
+	fecPkt, err := sf.sm.generateFEC(sf, pkt)
+	if err != nil {
+		return err
+	}
+
+	if fecPkt != nil {
+		return st.writePacketRTPInner(sf.sm.fecFormat, fecPkt, ntp)
+	}
+
+	return nil
+}
+
+// announceDescription sends the stream description to readers, in background.
+func (st *ServerStream) announceDescription() {
+	for ss := range st.readers {
+		go ss.announceDescription(st) //nolint:errcheck
+	}
 }
 
 // WritePacketRTCP writes a RTCP packet to all the readers of the stream.
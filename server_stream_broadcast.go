@@ -0,0 +1,142 @@
+package gortsplib
+
+import (
+	"fmt"
+	"strconv"
+
+	psdp "github.com/pion/sdp/v3"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// StartMulticast starts sending the stream with the UDP-multicast transport even when
+// there are no multicast readers, in order to allow players to receive it without
+// the RTSP control channel, by using the SDP returned by MulticastSDP(),
+// that can be saved into a .sdp file or announced with SAP (see pkg/sap).
+// UDP-multicast must be enabled on the server.
+// Multicast readers that perform a SETUP receive the same packets.
+func (st *ServerStream) StartMulticast() error {
+	if st.s.MulticastRTPPort == 0 {
+		return fmt.Errorf("UDP-multicast is not enabled on the server")
+	}
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.closed {
+		return liberrors.ErrServerStreamClosed{}
+	}
+
+	if st.multicastBroadcast {
+		return fmt.Errorf("stream is already broadcasted")
+	}
+
+	if st.multicastWritersMoved {
+		return liberrors.ErrServerStreamReadersMoved{}
+	}
+
+	if st.multicastReaderCount == 0 {
+		err := st.allocateMulticastWriters()
+		if err != nil {
+			return err
+		}
+	}
+
+	st.multicastBroadcast = true
+
+	return nil
+}
+
+// StopMulticast stops sending the stream started with StartMulticast().
+// The stream is still sent to multicast readers, if there are any.
+func (st *ServerStream) StopMulticast() {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.closed || !st.multicastBroadcast {
+		return
+	}
+
+	st.multicastBroadcast = false
+
+	if st.multicastReaderCount == 0 {
+		st.closeMulticastWriters()
+	}
+}
+
+// MulticastSDP returns a standalone SDP that describes the stream sent with the UDP-multicast transport,
+// containing multicast groups, ports and TTL of every media.
+// The stream must be sent with StartMulticast() or must have multicast readers.
+func (st *ServerStream) MulticastSDP() ([]byte, error) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	if st.closed {
+		return nil, liberrors.ErrServerStreamClosed{}
+	}
+
+	desc := &description.Session{
+		Title:     st.desc.Title,
+		FECGroups: st.desc.FECGroups,
+		Medias:    make([]*description.Media, len(st.desc.Medias)),
+	}
+
+	for i, medi := range st.desc.Medias {
+		desc.Medias[i] = &description.Media{
+			Type:             medi.Type,
+			ID:               medi.ID,
+			RTCPPort:         medi.RTCPPort,
+			RTCPAddress:      medi.RTCPAddress,
+			Crypto:           medi.Crypto,
+			HeaderExtensions: medi.HeaderExtensions,
+			PTime:            medi.PTime,
+			Formats:          medi.Formats,
+		}
+	}
+
+	sout := desc.MarshalSDP(true)
+
+	for i, medi := range st.desc.Medias {
+		w := st.streamMedias[medi].multicastWriter
+		if w == nil {
+			return nil, fmt.Errorf("stream is not sent with UDP-multicast")
+		}
+
+		md := sout.MediaDescriptions[i]
+
+		// the control attribute is meaningless without the RTSP control channel
+		attrs := md.Attributes[:0]
+		for _, attr := range md.Attributes {
+			if attr.Key != "control" {
+				attrs = append(attrs, attr)
+			}
+		}
+		md.Attributes = attrs
+
+		ttl := int(w.headerTTL())
+
+		md.MediaName.Port = psdp.RangedPort{Value: w.rtpl.port()}
+		md.ConnectionInformation = &psdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: "IP4",
+			Address: &psdp.Address{
+				Address: w.ip().String(),
+				TTL:     &ttl,
+			},
+		}
+
+		if medi.RTCPPort == 0 && w.rtcpPort() != w.rtpl.port()+1 {
+			md.Attributes = append(md.Attributes, psdp.Attribute{
+				Key:   "rtcp",
+				Value: strconv.FormatInt(int64(w.rtcpPort()), 10),
+			})
+		}
+
+		if i == 0 {
+			sout.ConnectionInformation = md.ConnectionInformation
+		}
+	}
+
+	return sout.Marshal()
+}
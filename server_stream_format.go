@@ -9,24 +9,30 @@ import (
 
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/rtcpsender"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpretransmission"
 )
 
 type serverStreamFormat struct {
-	sm         *serverStreamMedia
-	format     format.Format
-	rtcpSender *rtcpsender.RTCPSender
+	sm            *serverStreamMedia
+	format        format.Format
+	rtcpSender    *rtcpsender.RTCPSender
+	rtxSender     *rtpretransmission.Sender
+	continuity    *rtpContinuity
+	parameterSets *parameterSetsTracker // H264 and H265 only
+	ssrc          atomic.Value          // serverStreamFormatSSRC
 }
 
 func newServerStreamFormat(sm *serverStreamMedia, forma format.Format) *serverStreamFormat {
 	sf := &serverStreamFormat{
-		sm:     sm,
-		format: forma,
+		sm:            sm,
+		format:        forma,
+		parameterSets: newParameterSetsTracker(forma),
 	}
 
-	sf.rtcpSender = rtcpsender.New(
+	sf.rtcpSender = rtcpsender.NewWithClock(
 		forma.ClockRate(),
 		sm.st.s.senderReportPeriod,
-		sm.st.s.timeNow,
+		sm.st.s.Clock,
 		func(pkt rtcp.Packet) {
 			if !sm.st.s.DisableRTCPSenderReports {
 				sm.st.WritePacketRTCP(sm.media, pkt) //nolint:errcheck
@@ -38,23 +44,33 @@ func newServerStreamFormat(sm *serverStreamMedia, forma format.Format) *serverSt
 }
 
 func (sf *serverStreamFormat) writePacketRTP(byts []byte, pkt *rtp.Packet, ntp time.Time) error {
-	sf.rtcpSender.ProcessPacket(pkt, ntp, sf.format.PTSEqualsDTS(pkt))
+	ptsEqualsDTS := sf.format.PTSEqualsDTS(pkt)
+	sf.rtcpSender.ProcessPacket(pkt, ntp, ptsEqualsDTS)
+
+	if sf.rtxSender != nil {
+		sf.rtxSender.ProcessPacket(pkt)
+	}
 
 	le := uint64(len(byts))
 
-	// send unicast
-	for r := range sf.sm.st.activeUnicastReaders {
-		sm, ok := r.setuppedMedias[sf.sm.media]
-		if ok {
-			err := sm.writePacketRTP(byts)
-			if err != nil {
-				r.onStreamWriteError(err)
-			} else {
-				atomic.AddUint64(sf.sm.st.bytesSent, le)
+	// send unicast.
+	// byts is shared among readers, that must not modify it.
+	for i := range sf.sm.readers.shards {
+		for _, sm := range sf.sm.readers.shards[i].load() {
+			// the session is switching to another rendition
+			if atomic.LoadInt32(sm.ss.switchingRendition) != 0 {
+				continue
 			}
+
+			sf.writePacketRTPToReader(sm.ss, sm, byts, pkt, ntp, ptsEqualsDTS)
 		}
 	}
 
+	// send to sessions that are switching to the stream
+	for _, sw := range sf.sm.st.switchingReaders {
+		sw.writePacketRTP(sf, byts, pkt, ntp, ptsEqualsDTS)
+	}
+
 	// send multicast
 	if sf.sm.multicastWriter != nil {
 		err := sf.sm.multicastWriter.writePacketRTP(byts)
@@ -62,7 +78,44 @@ func (sf *serverStreamFormat) writePacketRTP(byts []byte, pkt *rtp.Packet, ntp t
 			return err
 		}
 		atomic.AddUint64(sf.sm.st.bytesSent, le)
+		sf.sm.st.s.metrics.packetsSent[TransportUDPMulticast].Add(1)
+		sf.sm.st.s.metrics.bytesSent[TransportUDPMulticast].Add(le)
 	}
 
 	return nil
 }
+
+func (sf *serverStreamFormat) writePacketRTPToReader(
+	r *ServerSession,
+	sm *serverSessionMedia,
+	byts []byte,
+	pkt *rtp.Packet,
+	ntp time.Time,
+	ptsEqualsDTS bool,
+) {
+	if sm.waitingKeyframe(ptsEqualsDTS) {
+		return
+	}
+
+	rbyts, rpkt, ok := sm.rewritePacketRTP(sf, byts, pkt, ntp)
+	if !ok {
+		return
+	}
+
+	rbyts, rpkt, ok = sm.filterPacketRTP(sf.format, rbyts, rpkt)
+	if !ok || !r.allowPacketRTP(sm, sf.format, rpkt, len(rbyts)) {
+		return
+	}
+
+	var err error
+	if sf.sm.st.DropUntilKeyFrame {
+		err = sm.writePacketRTPUntilKeyframe(sf.format, rbyts, rpkt)
+	} else {
+		err = sm.writePacketRTP(rbyts)
+	}
+	if err != nil {
+		r.onStreamWriteError(err)
+	} else {
+		atomic.AddUint64(sf.sm.st.bytesSent, uint64(len(rbyts)))
+	}
+}
@@ -0,0 +1,494 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net/http"
+	gourl "net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// ServerStreamGroup is a group of streams that contain different renditions
+// of the same content (simulcast), like the 1080p, 720p and 360p versions of the feed of a camera.
+// Readers are assigned a rendition when they read the group, and can switch to another one
+// while playing, by sending a PLAY or SET_PARAMETER request that contains the rendition
+// header or query parameter, or when the server calls SwitchReader().
+// Switches take place at the first keyframe of the new rendition, and readers keep receiving
+// packets with the same SSRCs and with continuous sequence numbers and timestamps.
+type ServerStreamGroup struct {
+	// renditions, indexed by name.
+	// They must belong to the same server and must have the same medias and formats.
+	Renditions map[string]*ServerStream
+
+	// rendition assigned to readers that do not ask for a specific one.
+	DefaultRendition string
+
+	// name of the header that selects a rendition.
+	// It defaults to "X-Rendition".
+	Header string
+
+	// name of the query parameter that selects a rendition.
+	// It defaults to "rendition".
+	QueryParameter string
+
+	mutex sync.Mutex
+}
+
+// Initialize initializes a ServerStreamGroup.
+// It must be called before readers are added to renditions.
+func (g *ServerStreamGroup) Initialize() error {
+	if g.Header == "" {
+		g.Header = "X-Rendition"
+	}
+	if g.QueryParameter == "" {
+		g.QueryParameter = "rendition"
+	}
+
+	def, ok := g.Renditions[g.DefaultRendition]
+	if !ok {
+		return fmt.Errorf("default rendition '%s' not found", g.DefaultRendition)
+	}
+
+	for _, st := range g.Renditions {
+		if st.s != def.s {
+			return fmt.Errorf("renditions must belong to the same server")
+		}
+
+		if !sameMediasAndFormats(def.desc, st.desc) {
+			return fmt.Errorf("renditions must contain the same medias and formats")
+		}
+
+		if st.group != nil {
+			return fmt.Errorf("stream already belongs to a group")
+		}
+	}
+
+	for _, st := range g.Renditions {
+		st.group = g
+	}
+
+	return nil
+}
+
+// requestedRendition returns the rendition selected by a request.
+// The header has priority over the query parameter.
+func (g *ServerStreamGroup) requestedRendition(req *base.Request, query string) (string, bool) {
+	if v, ok := req.Header[http.CanonicalHeaderKey(g.Header)]; ok && len(v) == 1 {
+		return strings.TrimSpace(v[0]), true
+	}
+
+	if q, err := gourl.ParseQuery(query); err == nil {
+		if v := q.Get(g.QueryParameter); v != "" {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// Stream returns the rendition selected by a request, through the rendition header or query parameter,
+// or the default rendition when the request does not select any.
+// It can be used inside OnDescribe and OnSetup handlers, where query is provided by the context.
+func (g *ServerStreamGroup) Stream(req *base.Request, query string) (*ServerStream, error) {
+	name, ok := g.requestedRendition(req, query)
+	if !ok {
+		return g.Renditions[g.DefaultRendition], nil
+	}
+
+	st, ok := g.Renditions[name]
+	if !ok {
+		return nil, liberrors.ErrServerRenditionNotFound{Name: name}
+	}
+
+	return st, nil
+}
+
+// Rendition returns the name of the rendition read by a session.
+// A rendition that is being switched to is returned only after the switch took place.
+func (g *ServerStreamGroup) Rendition(ss *ServerSession) (string, bool) {
+	ss.streamMutex.RLock()
+	st := ss.setuppedStream
+	ss.streamMutex.RUnlock()
+
+	for name, cur := range g.Renditions {
+		if cur == st {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// SwitchReader switches a session that is reading a rendition of the group to another rendition.
+// The switch takes place when the first keyframe of the other rendition is written,
+// and a keyframe request is passed to the OnKeyframeRequest callback of the other rendition.
+// A switch that has not taken place yet is replaced by the new one.
+func (g *ServerStreamGroup) SwitchReader(ss *ServerSession, rendition string) error {
+	dest, ok := g.Renditions[rendition]
+	if !ok {
+		return liberrors.ErrServerRenditionNotFound{Name: rendition}
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	ss.streamMutex.RLock()
+	from := ss.setuppedStream
+	medias := make(map[*description.Media]*serverSessionMedia, len(ss.setuppedMedias))
+	if from != nil && from.group == g {
+		for i, medi := range from.desc.Medias {
+			if sm, ok := ss.setuppedMedias[medi]; ok {
+				medias[dest.desc.Medias[i]] = sm
+			}
+		}
+	}
+	ss.streamMutex.RUnlock()
+
+	if from == nil || from.group != g || atomic.LoadInt32(ss.started) == 0 {
+		return fmt.Errorf("session is not playing a rendition of the group")
+	}
+
+	if *ss.setuppedTransport == TransportUDPMulticast {
+		return fmt.Errorf("sessions that use the UDP-multicast transport can't switch rendition")
+	}
+
+	if atomic.LoadInt32(ss.switchingRendition) != 0 {
+		return fmt.Errorf("a rendition switch is already taking place")
+	}
+
+	for _, st := range g.Renditions {
+		st.removeSwitchingReader(ss)
+	}
+
+	if dest == from {
+		return nil
+	}
+
+	sw := &serverStreamGroupSwitch{
+		ss:     ss,
+		from:   from,
+		to:     dest,
+		medias: medias,
+	}
+
+	for _, medi := range dest.desc.Medias {
+		if medi.Type == description.MediaTypeVideo {
+			sw.keyframeMedia = medi
+			break
+		}
+	}
+
+	dest.mutex.Lock()
+
+	if dest.closed {
+		dest.mutex.Unlock()
+		return liberrors.ErrServerStreamClosed{}
+	}
+
+	dest.switchingReaders[ss] = sw
+
+	dest.mutex.Unlock()
+
+	if sw.keyframeMedia != nil {
+		dest.readerKeyframeRequest(sw.keyframeMedia)
+	}
+
+	return nil
+}
+
+// cancelSwitch cancels the rendition switch of a session.
+func (g *ServerStreamGroup) cancelSwitch(ss *ServerSession) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, st := range g.Renditions {
+		if sw := st.removeSwitchingReader(ss); sw != nil {
+			sw.cancel()
+		}
+	}
+}
+
+// switchRenditionFromRequest switches the rendition read by a session,
+// when a PLAY or SET_PARAMETER request selects another one.
+func (ss *ServerSession) switchRenditionFromRequest(req *base.Request, query string) (bool, error) {
+	if ss.state != ServerSessionStatePlay || ss.setuppedStream == nil || ss.setuppedStream.group == nil {
+		return false, nil
+	}
+
+	g := ss.setuppedStream.group
+
+	name, ok := g.requestedRendition(req, query)
+	if !ok {
+		return false, nil
+	}
+
+	return true, g.SwitchReader(ss, name)
+}
+
+// serverStreamGroupSwitch is a rendition switch of a session.
+// It is stored into the destination stream until the reader has been moved.
+type serverStreamGroupSwitch struct {
+	ss            *ServerSession
+	from          *ServerStream
+	to            *ServerStream
+	medias        map[*description.Media]*serverSessionMedia // medias of the destination stream
+	keyframeMedia *description.Media
+
+	mutex   sync.Mutex
+	started bool
+}
+
+// writePacketRTP writes a packet of the destination stream to the session,
+// starting from the first keyframe.
+func (sw *serverStreamGroupSwitch) writePacketRTP(
+	sf *serverStreamFormat,
+	byts []byte,
+	pkt *rtp.Packet,
+	ntp time.Time,
+	ptsEqualsDTS bool,
+) {
+	sm, ok := sw.medias[sf.sm.media]
+	if !ok {
+		return
+	}
+
+	if !sw.start(sf, sm, pkt, ntp) {
+		return
+	}
+
+	sf.writePacketRTPToReader(sw.ss, sm, byts, pkt, ntp, ptsEqualsDTS)
+}
+
+// start starts the switch when a packet is a keyframe, or when there are no keyframes.
+// From then on, packets of the previous stream are not sent to the session anymore.
+// It returns whether the switch has started.
+func (sw *serverStreamGroupSwitch) start(sf *serverStreamFormat, sm *serverSessionMedia, pkt *rtp.Packet, ntp time.Time) bool {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	if sw.started {
+		return true
+	}
+
+	if sw.keyframeMedia != nil {
+		if sf.sm.media != sw.keyframeMedia {
+			return false
+		}
+
+		if keyframe, ok := keyframeStart(sf.format, pkt); ok && !keyframe {
+			return false
+		}
+	}
+
+	sw.started = true
+	atomic.StoreInt32(sw.ss.switchingRendition, 1)
+
+	// rewrite packets of the destination stream in order to continue the ones sent by the previous stream
+	for destMedia, ssm := range sw.medias {
+		prevMedia := sw.from.streamMedias[sw.from.desc.Medias[indexOfMedia(sw.to.desc.Medias, destMedia)]]
+
+		for payloadType, destFormat := range sw.to.streamMedias[destMedia].formats {
+			if c := ssm.nextContinuity(prevMedia.formats[payloadType]); c != nil {
+				ssm.setContinuity(destFormat, c)
+			}
+		}
+	}
+
+	// the decoder of the reader needs the parameter sets of the new rendition
+	if sf.parameterSets != nil {
+		if payload := sf.parameterSets.aggregate(); payload != nil {
+			if c := sm.continuity(sf); c != nil {
+				injected := &rtp.Packet{
+					Header:  pkt.Header,
+					Payload: payload,
+				}
+				injected.Marker = false
+
+				out := c.process(injected, ntp)
+				c.shift()
+
+				if buf, err := out.Marshal(); err == nil {
+					sm.writePacketRTP(buf) //nolint:errcheck
+				}
+			}
+		}
+	}
+
+	go sw.finish()
+
+	return true
+}
+
+// finish moves the session to the destination stream.
+func (sw *serverStreamGroupSwitch) finish() {
+	medias := make(map[*description.Media]*description.Media, len(sw.from.desc.Medias))
+	for i, medi := range sw.from.desc.Medias {
+		medias[medi] = sw.to.desc.Medias[i]
+	}
+
+	sw.from.s.moveReadersMutex.Lock()
+	sw.ss.moveReader(serverSessionMoveReaderReq{
+		from:   sw.from,
+		to:     sw.to,
+		medias: medias,
+		sw:     sw,
+		done:   make(chan struct{}),
+	})
+	sw.from.s.moveReadersMutex.Unlock()
+
+	// the move did not take place (i.e. the session has been closed)
+	if sw.to.removeSwitchingReader(sw.ss) == sw {
+		sw.cancel()
+	}
+}
+
+// cancel restores the session after a switch that did not take place.
+func (sw *serverStreamGroupSwitch) cancel() {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	if !sw.started {
+		return
+	}
+
+	for _, sm := range sw.medias {
+		sm.removeContinuities(sw.to)
+	}
+
+	atomic.StoreInt32(sw.ss.switchingRendition, 0)
+}
+
+// complete is called when the session has been moved to the destination stream.
+func (sw *serverStreamGroupSwitch) complete() {
+	for _, sm := range sw.medias {
+		sm.removeContinuities(sw.from)
+	}
+
+	atomic.StoreInt32(sw.ss.switchingRendition, 0)
+}
+
+func indexOfMedia(medias []*description.Media, medi *description.Media) int {
+	for i, cur := range medias {
+		if cur == medi {
+			return i
+		}
+	}
+	return -1
+}
+
+// continuity returns the rtpContinuity that rewrites packets of a format sent to the session.
+func (sm *serverSessionMedia) continuity(sf *serverStreamFormat) *rtpContinuity {
+	continuities, _ := sm.continuities.Load().(map[*serverStreamFormat]*rtpContinuity)
+	return continuities[sf]
+}
+
+// nextContinuity returns a rtpContinuity that continues packets of a format that have been sent to the session.
+func (sm *serverSessionMedia) nextContinuity(prev *serverStreamFormat) *rtpContinuity {
+	c := newRTPContinuity(prev)
+
+	if cur := sm.continuity(prev); cur != nil {
+		return cur.chain(c)
+	}
+
+	return c
+}
+
+func (sm *serverSessionMedia) setContinuity(sf *serverStreamFormat, c *rtpContinuity) {
+	sm.continuitiesMutex.Lock()
+	defer sm.continuitiesMutex.Unlock()
+
+	cur, _ := sm.continuities.Load().(map[*serverStreamFormat]*rtpContinuity)
+
+	continuities := make(map[*serverStreamFormat]*rtpContinuity, len(cur)+1)
+	for k, v := range cur {
+		continuities[k] = v
+	}
+	continuities[sf] = c
+
+	sm.continuities.Store(continuities)
+}
+
+// removeContinuities removes rtpContinuities of formats of a stream.
+func (sm *serverSessionMedia) removeContinuities(st *ServerStream) {
+	sm.continuitiesMutex.Lock()
+	defer sm.continuitiesMutex.Unlock()
+
+	cur, _ := sm.continuities.Load().(map[*serverStreamFormat]*rtpContinuity)
+
+	continuities := make(map[*serverStreamFormat]*rtpContinuity, len(cur))
+	for k, v := range cur {
+		if k.sm.st != st {
+			continuities[k] = v
+		}
+	}
+
+	sm.continuities.Store(continuities)
+}
+
+// rewritePacketRTP rewrites a RTP packet of a format after a rendition switch.
+func (sm *serverSessionMedia) rewritePacketRTP(
+	sf *serverStreamFormat,
+	byts []byte,
+	pkt *rtp.Packet,
+	ntp time.Time,
+) ([]byte, *rtp.Packet, bool) {
+	c := sm.continuity(sf)
+	if c == nil {
+		return byts, pkt, true
+	}
+
+	out := c.process(pkt, ntp)
+
+	outByts, err := out.Marshal()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return outByts, out, true
+}
+
+// rewritePacketRTCP rewrites sender reports of a media after a rendition switch.
+func (sm *serverSessionMedia) rewritePacketRTCP(streamMedia *serverStreamMedia, byts []byte) []byte {
+	continuities, _ := sm.continuities.Load().(map[*serverStreamFormat]*rtpContinuity)
+	if len(continuities) == 0 {
+		return byts
+	}
+
+	packets, err := rtcp.Unmarshal(byts)
+	if err != nil {
+		return byts
+	}
+
+	changed := false
+
+	for _, pkt := range packets {
+		if sr, ok := pkt.(*rtcp.SenderReport); ok {
+			for sf, c := range continuities {
+				if sf.sm == streamMedia && c.processSenderReport(sr) {
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	if !changed {
+		return byts
+	}
+
+	out, err := rtcp.Marshal(packets)
+	if err != nil {
+		return byts
+	}
+
+	return out
+}
@@ -1,7 +1,17 @@
 package gortsplib
 
 import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpfec"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpred"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtpretransmission"
 )
 
 type serverStreamMedia struct {
@@ -10,13 +20,24 @@ type serverStreamMedia struct {
 	trackID         int
 	formats         map[uint8]*serverStreamFormat
 	multicastWriter *serverMulticastWriter
+	readers         serverStreamReaders // active unicast readers
+
+	lastKeyframeRequest *int64
+	fecFormat           *serverStreamFormat
+	fecEncoder          *rtpfec.Encoder
+	fecMutex            sync.Mutex
+	redFormat           *serverStreamFormat
+	redTarget           *serverStreamFormat
+	redEncoder          *rtpred.Encoder
+	redMutex            sync.Mutex
 }
 
 func newServerStreamMedia(st *ServerStream, medi *description.Media, trackID int) *serverStreamMedia {
 	sm := &serverStreamMedia{
-		st:      st,
-		media:   medi,
-		trackID: trackID,
+		st:                  st,
+		media:               medi,
+		trackID:             trackID,
+		lastKeyframeRequest: new(int64),
 	}
 
 	sm.formats = make(map[uint8]*serverStreamFormat)
@@ -26,6 +47,48 @@ func newServerStreamMedia(st *ServerStream, medi *description.Media, trackID int
 			forma)
 	}
 
+	for _, sf := range sm.formats {
+		if rtx, ok := sf.format.(*format.RTX); ok {
+			if target, ok := sm.formats[rtx.APT]; ok {
+				target.rtxSender = &rtpretransmission.Sender{
+					PayloadType: rtx.PayloadTyp,
+					HistorySize: st.s.RTXHistorySize,
+				}
+				err := target.rtxSender.Init()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+
+		if fec, ok := sf.format.(*format.FlexFEC); ok && sm.fecFormat == nil {
+			sm.fecFormat = sf
+			sm.fecEncoder = &rtpfec.Encoder{
+				PayloadType: fec.PayloadTyp,
+				GroupSize:   st.s.FECGroupSize,
+			}
+			err := sm.fecEncoder.Init()
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		if red, ok := sf.format.(*format.RED); ok && sm.redFormat == nil && len(red.PayloadTypes) != 0 {
+			if target, ok := sm.formats[red.PayloadTypes[0]]; ok && target != sf {
+				sm.redFormat = sf
+				sm.redTarget = target
+				sm.redEncoder = &rtpred.Encoder{
+					PayloadType: red.PayloadTyp,
+					Depth:       st.s.REDDepth,
+				}
+				err := sm.redEncoder.Init()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
+
 	return sm
 }
 
@@ -41,14 +104,34 @@ func (sm *serverStreamMedia) close() {
 	}
 }
 
+// generateFEC processes an outgoing packet with the FEC encoder,
+// and returns a FEC packet when available.
+func (sm *serverStreamMedia) generateFEC(sf *serverStreamFormat, pkt *rtp.Packet) (*rtp.Packet, error) {
+	if sm.fecEncoder == nil || sf == sm.fecFormat {
+		return nil, nil
+	}
+
+	sm.fecMutex.Lock()
+	defer sm.fecMutex.Unlock()
+
+	return sm.fecEncoder.Encode(pkt)
+}
+
+// encodeRED encodes an outgoing packet of the primary format of RED into a RED packet.
+func (sm *serverStreamMedia) encodeRED(pkt *rtp.Packet) *rtp.Packet {
+	sm.redMutex.Lock()
+	defer sm.redMutex.Unlock()
+
+	return sm.redEncoder.Encode(pkt)
+}
+
 func (sm *serverStreamMedia) writePacketRTCP(byts []byte) error {
 	// send unicast
-	for r := range sm.st.activeUnicastReaders {
-		sm, ok := r.setuppedMedias[sm.media]
-		if ok {
-			err := sm.writePacketRTCP(byts)
+	for i := range sm.readers.shards {
+		for _, ssm := range sm.readers.shards[i].load() {
+			err := ssm.writePacketRTCP(ssm.rewritePacketRTCP(sm, byts))
 			if err != nil {
-				r.onStreamWriteError(err)
+				ssm.ss.onStreamWriteError(err)
 			}
 		}
 	}
@@ -63,3 +146,39 @@ func (sm *serverStreamMedia) writePacketRTCP(byts []byte) error {
 
 	return nil
 }
+
+func (sm *serverStreamMedia) retransmit(ssm *serverSessionMedia, nack *rtcp.TransportLayerNack) {
+	for _, sf := range sm.formats {
+		// the retransmission history is indexed by original sequence numbers,
+		// while NACKs of readers with a layer filter or that switched rendition refer to rewritten ones.
+		if sf.rtxSender == nil || ssm.filtersLayers(sf.format) || ssm.continuity(sf) != nil {
+			continue
+		}
+
+		var payloads [][]byte
+		size := 0
+
+		for _, pkt := range sf.rtxSender.ProcessNACK(nack) {
+			byts, err := pkt.Marshal()
+			if err != nil {
+				continue
+			}
+
+			payloads = append(payloads, byts)
+			size += len(byts)
+		}
+
+		if payloads == nil {
+			continue
+		}
+
+		// retransmitted packets are sent together
+		err := ssm.writePacketsRTP(payloads)
+		if err != nil {
+			ssm.ss.onStreamWriteError(err)
+			return
+		}
+
+		atomic.AddUint64(sm.st.bytesSent, uint64(size))
+	}
+}
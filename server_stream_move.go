@@ -0,0 +1,326 @@
+package gortsplib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// rtpContinuity rewrites SSRC, sequence numbers and timestamps of RTP packets of a format,
+// in order to continue the ones previously sent by another stream.
+type rtpContinuity struct {
+	clockRate   int
+	ssrc        uint32
+	lastSeqNum  uint16
+	lastTimeRTP uint32
+	lastTimeNTP time.Time
+
+	mutex          sync.Mutex
+	initialized    bool
+	inSSRC         uint32
+	seqNumOffset   uint16
+	timestampDelta uint32
+}
+
+func newRTPContinuity(prev *serverStreamFormat) *rtpContinuity {
+	ssrc, ok := prev.rtcpSender.SenderSSRC()
+	if !ok {
+		return nil
+	}
+
+	lastSeqNum, lastTimeRTP, lastTimeNTP, _ := prev.rtcpSender.LastPacketData()
+
+	return &rtpContinuity{
+		clockRate:   prev.format.ClockRate(),
+		ssrc:        ssrc,
+		lastSeqNum:  lastSeqNum,
+		lastTimeRTP: lastTimeRTP,
+		lastTimeNTP: lastTimeNTP,
+	}
+}
+
+func (c *rtpContinuity) process(pkt *rtp.Packet, ntp time.Time) *rtp.Packet {
+	c.mutex.Lock()
+
+	if !c.initialized {
+		c.initialized = true
+		c.inSSRC = pkt.SSRC
+		c.seqNumOffset = c.lastSeqNum + 1 - pkt.SequenceNumber
+
+		// the timestamp of the first packet is computed from the time elapsed since
+		// the last packet of the previous stream.
+		elapsed := ntp.Sub(c.lastTimeNTP)
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		c.timestampDelta = c.lastTimeRTP + uint32(elapsed.Seconds()*float64(c.clockRate)) - pkt.Timestamp
+	}
+
+	seqNumOffset := c.seqNumOffset
+	timestampDelta := c.timestampDelta
+
+	c.mutex.Unlock()
+
+	ret := *pkt
+	ret.SSRC = c.ssrc
+	ret.SequenceNumber += seqNumOffset
+	ret.Timestamp += timestampDelta
+	return &ret
+}
+
+// shift shifts sequence numbers of next packets by one,
+// in order to make room for a packet that has been injected.
+func (c *rtpContinuity) shift() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.seqNumOffset++
+}
+
+// chain returns a rtpContinuity that continues packets that have been rewritten by c,
+// given a rtpContinuity that continues the original ones.
+func (c *rtpContinuity) chain(next *rtpContinuity) *rtpContinuity {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// no packets have been rewritten by c
+	if !c.initialized || next == nil {
+		return &rtpContinuity{
+			clockRate:   c.clockRate,
+			ssrc:        c.ssrc,
+			lastSeqNum:  c.lastSeqNum,
+			lastTimeRTP: c.lastTimeRTP,
+			lastTimeNTP: c.lastTimeNTP,
+		}
+	}
+
+	return &rtpContinuity{
+		clockRate:   next.clockRate,
+		ssrc:        c.ssrc,
+		lastSeqNum:  next.lastSeqNum + c.seqNumOffset,
+		lastTimeRTP: next.lastTimeRTP + c.timestampDelta,
+		lastTimeNTP: next.lastTimeNTP,
+	}
+}
+
+// processSenderReport rewrites a sender report of the packets rewritten by c.
+// It returns false when the report refers to other packets.
+func (c *rtpContinuity) processSenderReport(sr *rtcp.SenderReport) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.initialized || sr.SSRC != c.inSSRC {
+		return false
+	}
+
+	sr.SSRC = c.ssrc
+	sr.RTPTime += c.timestampDelta
+	return true
+}
+
+func sameMediasAndFormats(desc1 *description.Session, desc2 *description.Session) bool {
+	if len(desc1.Medias) != len(desc2.Medias) {
+		return false
+	}
+
+	for i, medi1 := range desc1.Medias {
+		medi2 := desc2.Medias[i]
+
+		if medi1.Type != medi2.Type || len(medi1.Formats) != len(medi2.Formats) {
+			return false
+		}
+
+		payloadTypes := make(map[uint8]struct{}, len(medi2.Formats))
+		for _, forma := range medi2.Formats {
+			payloadTypes[forma.PayloadType()] = struct{}{}
+		}
+
+		for _, forma := range medi1.Formats {
+			if _, ok := payloadTypes[forma.PayloadType()]; !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// MoveReaders moves all readers of the stream to another stream, without requiring them to perform a new SETUP.
+// The other stream must have the same medias and formats and must not have readers.
+// Packets written to the other stream are sent with the SSRCs of the stream,
+// and their sequence numbers and timestamps continue the ones of the stream.
+// RTP-Info headers and RTCP sender reports of the other stream are consistent with them.
+// This allows to replace the source of a stream (i.e. switch from a primary encoder to a backup one)
+// without interrupting readers.
+// After the call, OnSetup, OnDescribe and OnPlay handlers must return the other stream.
+func (st *ServerStream) MoveReaders(dest *ServerStream) error {
+	if dest == st || dest.s != st.s {
+		return fmt.Errorf("destination stream must be a different stream of the same server")
+	}
+
+	if !sameMediasAndFormats(st.desc, dest.desc) {
+		return fmt.Errorf("destination stream must contain the same medias and formats")
+	}
+
+	// calls are serialized in order to prevent deadlocks
+	// when readers are moved in both directions at the same time.
+	st.s.moveReadersMutex.Lock()
+	defer st.s.moveReadersMutex.Unlock()
+
+	medias := make(map[*description.Media]*description.Media, len(st.desc.Medias))
+	for i, medi := range st.desc.Medias {
+		medias[medi] = dest.desc.Medias[i]
+	}
+
+	st.mutex.RLock()
+
+	if st.closed {
+		st.mutex.RUnlock()
+		return liberrors.ErrServerStreamClosed{}
+	}
+
+	if st.multicastBroadcast && st.multicastReaderCount != 0 {
+		st.mutex.RUnlock()
+		return fmt.Errorf("readers of streams broadcasted with UDP-multicast can't be moved")
+	}
+
+	readers := make([]*ServerSession, 0, len(st.readers))
+	for ss := range st.readers {
+		readers = append(readers, ss)
+	}
+
+	st.mutex.RUnlock()
+
+	dest.mutex.Lock()
+
+	if dest.closed {
+		dest.mutex.Unlock()
+		return liberrors.ErrServerStreamClosed{}
+	}
+
+	if len(dest.readers) != 0 {
+		dest.mutex.Unlock()
+		return fmt.Errorf("destination stream already has readers")
+	}
+
+	if dest.multicastBroadcast {
+		dest.mutex.Unlock()
+		return fmt.Errorf("destination stream is broadcasted with UDP-multicast")
+	}
+
+	for medi, sm := range st.streamMedias {
+		destMedia := dest.streamMedias[medias[medi]]
+
+		for payloadType, sf := range sm.formats {
+			destMedia.formats[payloadType].continuity = newRTPContinuity(sf)
+		}
+	}
+
+	dest.mutex.Unlock()
+
+	for _, ss := range readers {
+		ss.moveReader(serverSessionMoveReaderReq{
+			from:   st,
+			to:     dest,
+			medias: medias,
+			done:   make(chan struct{}),
+		})
+	}
+
+	return nil
+}
+
+type serverSessionMoveReaderReq struct {
+	from   *ServerStream
+	to     *ServerStream
+	medias map[*description.Media]*description.Media
+	sw     *serverStreamGroupSwitch // rendition switches only
+	done   chan struct{}
+}
+
+func (ss *ServerSession) moveReader(req serverSessionMoveReaderReq) {
+	select {
+	case ss.chMoveReader <- req:
+		<-req.done
+	case <-ss.ctx.Done():
+	}
+}
+
+func (ss *ServerSession) doMoveReader(req serverSessionMoveReaderReq) {
+	if ss.setuppedStream != req.from {
+		return
+	}
+
+	req.from.mutex.Lock()
+	defer req.from.mutex.Unlock()
+
+	req.to.mutex.Lock()
+	defer req.to.mutex.Unlock()
+
+	if req.from.closed || req.to.closed {
+		return
+	}
+
+	if _, ok := req.from.readers[ss]; !ok {
+		return
+	}
+
+	if req.sw != nil {
+		if req.to.switchingReaders[ss] != req.sw {
+			return
+		}
+		delete(req.to.switchingReaders, ss)
+		req.sw.complete()
+	}
+
+	delete(req.from.readers, ss)
+	req.to.readers[ss] = struct{}{}
+
+	_, active := req.from.activeUnicastReaders[ss]
+	if active {
+		delete(req.from.activeUnicastReaders, ss)
+		req.from.removeActiveReader(ss)
+	}
+
+	if *ss.setuppedTransport == TransportUDPMulticast {
+		// multicast writers are moved together with the first reader,
+		// in order to keep multicast groups and ports.
+		if req.to.multicastReaderCount == 0 {
+			for medi, sm := range req.from.streamMedias {
+				req.to.streamMedias[req.medias[medi]].multicastWriter = sm.multicastWriter
+				sm.multicastWriter = nil
+			}
+			req.from.multicastWritersMoved = true
+		}
+
+		req.from.multicastReaderCount--
+		if req.from.multicastReaderCount == 0 {
+			req.from.multicastWritersMoved = false
+		}
+		req.to.multicastReaderCount++
+	}
+
+	setuppedMedias := make(map[*description.Media]*serverSessionMedia, len(ss.setuppedMedias))
+
+	ss.streamMutex.Lock()
+	defer ss.streamMutex.Unlock()
+
+	for medi, sm := range ss.setuppedMedias {
+		sm.media = req.medias[medi]
+		setuppedMedias[sm.media] = sm
+	}
+
+	ss.setuppedMedias = setuppedMedias
+	ss.setuppedStream = req.to
+	ss.pacer.setStreamWindow(req.to.PacingWindow)
+
+	if active {
+		req.to.activeUnicastReaders[ss] = struct{}{}
+		req.to.addActiveReader(ss)
+	}
+}
@@ -0,0 +1,281 @@
+package gortsplib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// parameterSetsTracker detects parameter sets (H264 SPS and PPS, H265 VPS, SPS and PPS)
+// that are written in-band, updates the format when they change
+// and optionally injects them before random access units that are not preceded by them.
+type parameterSetsTracker struct {
+	format format.Format
+
+	mutex         sync.Mutex
+	seqNumOffset  uint16
+	auInitialized bool
+	auTimestamp   uint32
+	vps           []byte
+	sps           []byte
+	pps           []byte
+}
+
+func newParameterSetsTracker(forma format.Format) *parameterSetsTracker {
+	switch forma.(type) {
+	case *format.H264, *format.H265:
+		return &parameterSetsTracker{format: forma}
+	}
+	return nil
+}
+
+// process processes an outgoing packet.
+// It returns a packet that must be sent before it, the packet itself,
+// with a shifted sequence number if packets have been injected,
+// and whether parameter sets of the format have changed.
+func (t *parameterSetsTracker) process(pkt *rtp.Packet, inject bool) (*rtp.Packet, *rtp.Packet, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// parameter sets are valid within the access unit they belong to
+	if !t.auInitialized || pkt.Timestamp != t.auTimestamp {
+		t.auInitialized = true
+		t.auTimestamp = pkt.Timestamp
+		t.vps = nil
+		t.sps = nil
+		t.pps = nil
+	}
+
+	randomAccess := t.scan(pkt.Payload)
+
+	var injected *rtp.Packet
+	changed := false
+
+	if randomAccess {
+		changed = t.commit()
+
+		if inject && !t.complete() {
+			if payload := t.aggregate(); payload != nil {
+				injected = &rtp.Packet{
+					Header:  pkt.Header,
+					Payload: payload,
+				}
+				injected.Marker = false
+				injected.SequenceNumber += t.seqNumOffset
+				t.seqNumOffset++
+			}
+		}
+	}
+
+	if t.seqNumOffset != 0 {
+		ret := *pkt
+		ret.SequenceNumber += t.seqNumOffset
+		pkt = &ret
+	}
+
+	return injected, pkt, changed
+}
+
+// scan stores parameter sets contained in a payload,
+// and returns whether the payload starts a random access unit.
+func (t *parameterSetsTracker) scan(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+
+	switch t.format.(type) {
+	case *format.H264:
+		typ := h264.NALUType(payload[0] & 0x1F)
+
+		switch typ {
+		case h264.NALUTypeSTAPA:
+			randomAccess := false
+			for _, nalu := range splitAggregate(payload[1:]) {
+				if t.processNALU(nalu) {
+					randomAccess = true
+				}
+			}
+			return randomAccess
+
+		case h264.NALUTypeFUA:
+			return len(payload) >= 2 && (payload[1]&0x80) != 0 &&
+				h264.NALUType(payload[1]&0x1F) == h264.NALUTypeIDR
+		}
+
+	case *format.H265:
+		if len(payload) < 2 {
+			return false
+		}
+
+		typ := h265.NALUType((payload[0] >> 1) & 0b111111)
+
+		switch typ {
+		case h265.NALUType_AggregationUnit:
+			randomAccess := false
+			for _, nalu := range splitAggregate(payload[2:]) {
+				if t.processNALU(nalu) {
+					randomAccess = true
+				}
+			}
+			return randomAccess
+
+		case h265.NALUType_FragmentationUnit:
+			return len(payload) >= 3 && (payload[2]&0x80) != 0 &&
+				isH265RandomAccess(h265.NALUType(payload[2]&0b111111))
+		}
+	}
+
+	return t.processNALU(payload)
+}
+
+// processNALU stores a parameter set and returns whether the NALU is a random access one.
+func (t *parameterSetsTracker) processNALU(nalu []byte) bool {
+	if len(nalu) == 0 {
+		return false
+	}
+
+	switch t.format.(type) {
+	case *format.H264:
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			t.sps = append([]byte(nil), nalu...)
+
+		case h264.NALUTypePPS:
+			t.pps = append([]byte(nil), nalu...)
+
+		case h264.NALUTypeIDR:
+			return true
+		}
+
+	case *format.H265:
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+
+		switch typ {
+		case h265.NALUType_VPS_NUT:
+			t.vps = append([]byte(nil), nalu...)
+
+		case h265.NALUType_SPS_NUT:
+			t.sps = append([]byte(nil), nalu...)
+
+		case h265.NALUType_PPS_NUT:
+			t.pps = append([]byte(nil), nalu...)
+
+		default:
+			return isH265RandomAccess(typ)
+		}
+	}
+
+	return false
+}
+
+func isH265RandomAccess(typ h265.NALUType) bool {
+	return typ >= h265.NALUType_BLA_W_LP && typ <= h265.NALUType_CRA_NUT
+}
+
+// complete checks whether the access unit contains all the parameter sets.
+func (t *parameterSetsTracker) complete() bool {
+	if _, ok := t.format.(*format.H265); ok && t.vps == nil {
+		return false
+	}
+	return t.sps != nil && t.pps != nil
+}
+
+// commit updates the format with the parameter sets of the access unit.
+func (t *parameterSetsTracker) commit() bool {
+	switch forma := t.format.(type) {
+	case *format.H264:
+		sps, pps := forma.SafeParams()
+		nsps, npps := pickParameterSet(t.sps, sps), pickParameterSet(t.pps, pps)
+
+		if !bytes.Equal(nsps, sps) || !bytes.Equal(npps, pps) {
+			forma.SafeSetParams(nsps, npps)
+			return true
+		}
+
+	case *format.H265:
+		vps, sps, pps := forma.SafeParams()
+		nvps, nsps, npps := pickParameterSet(t.vps, vps), pickParameterSet(t.sps, sps), pickParameterSet(t.pps, pps)
+
+		if !bytes.Equal(nvps, vps) || !bytes.Equal(nsps, sps) || !bytes.Equal(npps, pps) {
+			forma.SafeSetParams(nvps, nsps, npps)
+			return true
+		}
+	}
+
+	return false
+}
+
+// aggregate returns a STAP-A (H264) or AP (H265) payload that contains
+// the parameter sets of the format, or nil if they are not known.
+func (t *parameterSetsTracker) aggregate() []byte {
+	var header []byte
+	var nalus [][]byte
+
+	switch forma := t.format.(type) {
+	case *format.H264:
+		sps, pps := forma.SafeParams()
+		if sps == nil || pps == nil {
+			return nil
+		}
+
+		header = []byte{(sps[0] & 0x60) | byte(h264.NALUTypeSTAPA)}
+		nalus = [][]byte{sps, pps}
+
+	case *format.H265:
+		vps, sps, pps := forma.SafeParams()
+		if vps == nil || sps == nil || pps == nil {
+			return nil
+		}
+
+		header = []byte{byte(h265.NALUType_AggregationUnit) << 1, 1}
+		nalus = [][]byte{vps, sps, pps}
+	}
+
+	n := len(header)
+	for _, nalu := range nalus {
+		n += 2 + len(nalu)
+	}
+
+	buf := make([]byte, n)
+	pos := copy(buf, header)
+
+	for _, nalu := range nalus {
+		binary.BigEndian.PutUint16(buf[pos:], uint16(len(nalu)))
+		pos += 2
+		pos += copy(buf[pos:], nalu)
+	}
+
+	return buf
+}
+
+// splitAggregate splits the NALUs of a STAP-A or AP payload, without its header.
+func splitAggregate(buf []byte) [][]byte {
+	var ret [][]byte
+
+	for len(buf) >= 2 {
+		size := int(binary.BigEndian.Uint16(buf))
+		buf = buf[2:]
+
+		if size == 0 || size > len(buf) {
+			break
+		}
+
+		ret = append(ret, buf[:size])
+		buf = buf[size:]
+	}
+
+	return ret
+}
+
+func pickParameterSet(v []byte, fallback []byte) []byte {
+	if v != nil {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,73 @@
+package gortsplib
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// number of shards of the active readers of a media.
+const serverStreamReadersShards = 16
+
+func serverStreamReadersShardOf(secretID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(secretID))
+	return int(h.Sum32() % serverStreamReadersShards)
+}
+
+type serverStreamReadersShard struct {
+	mutex   sync.Mutex
+	readers atomic.Pointer[[]*serverSessionMedia]
+}
+
+func (sh *serverStreamReadersShard) load() []*serverSessionMedia {
+	if r := sh.readers.Load(); r != nil {
+		return *r
+	}
+	return nil
+}
+
+// serverStreamReaders is the set of active unicast readers of a media.
+// Readers are split into shards, each one stored as an immutable slice,
+// in order to iterate over them without locking and
+// to add or remove a reader by copying a single shard only.
+type serverStreamReaders struct {
+	shards [serverStreamReadersShards]serverStreamReadersShard
+}
+
+func (r *serverStreamReaders) add(ssm *serverSessionMedia) {
+	sh := &r.shards[ssm.ss.readerShard]
+
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	cur := sh.load()
+	readers := make([]*serverSessionMedia, len(cur), len(cur)+1)
+	copy(readers, cur)
+	readers = append(readers, ssm)
+	sh.readers.Store(&readers)
+}
+
+func (r *serverStreamReaders) remove(ssm *serverSessionMedia) {
+	sh := &r.shards[ssm.ss.readerShard]
+
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	cur := sh.load()
+	readers := make([]*serverSessionMedia, 0, len(cur))
+	for _, cssm := range cur {
+		if cssm != ssm {
+			readers = append(readers, cssm)
+		}
+	}
+	sh.readers.Store(&readers)
+}
+
+func (r *serverStreamReaders) len() int {
+	n := 0
+	for i := range r.shards {
+		n += len(r.shards[i].load())
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package gortsplib
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// ServerStreamFormatSnapshot is the state of a format of a ServerStream.
+type ServerStreamFormatSnapshot struct {
+	// index of the media inside the stream description.
+	MediaIndex int
+
+	// payload type of the format.
+	PayloadType uint8
+
+	// SSRC of sent packets.
+	SSRC uint32
+
+	// sequence number, RTP timestamp and NTP timestamp of the last sent packet.
+	LastSequenceNumber uint16
+	LastRTPTime        uint32
+	LastNTP            time.Time
+}
+
+// ServerStreamSnapshot is the minimal state of a ServerStream
+// that is needed to restore it inside another process.
+// It can be serialized with encoding/json.
+type ServerStreamSnapshot struct {
+	// formats that have sent at least one packet.
+	Formats []ServerStreamFormatSnapshot
+}
+
+// Snapshot exports the state of the stream, that can be restored with ServerStream.Restore.
+func (st *ServerStream) Snapshot() *ServerStreamSnapshot {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+
+	snap := &ServerStreamSnapshot{}
+
+	for i, medi := range st.desc.Medias {
+		for _, forma := range medi.Formats {
+			sf := st.streamMedias[medi].formats[forma.PayloadType()]
+
+			c := newRTPContinuity(sf)
+			if c == nil {
+				continue
+			}
+
+			snap.Formats = append(snap.Formats, ServerStreamFormatSnapshot{
+				MediaIndex:         i,
+				PayloadType:        forma.PayloadType(),
+				SSRC:               c.ssrc,
+				LastSequenceNumber: c.lastSeqNum,
+				LastRTPTime:        c.lastTimeRTP,
+				LastNTP:            c.lastTimeNTP,
+			})
+		}
+	}
+
+	return snap
+}
+
+// Restore restores the state of a stream exported by ServerStream.Snapshot(), usually by another process.
+// Packets written to the stream are sent with the exported SSRCs,
+// and their sequence numbers and timestamps continue the exported ones,
+// therefore restored readers are not interrupted.
+// It must be called before writing packets.
+func (st *ServerStream) Restore(snap *ServerStreamSnapshot) error {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.closed {
+		return liberrors.ErrServerStreamClosed{}
+	}
+
+	for _, fsnap := range snap.Formats {
+		if fsnap.MediaIndex < 0 || fsnap.MediaIndex >= len(st.desc.Medias) {
+			return fmt.Errorf("invalid media index: %d", fsnap.MediaIndex)
+		}
+
+		sm := st.streamMedias[st.desc.Medias[fsnap.MediaIndex]]
+
+		sf, ok := sm.formats[fsnap.PayloadType]
+		if !ok {
+			return liberrors.ErrServerRTPPacketPayloadTypeNotInMedia{PayloadType: fsnap.PayloadType}
+		}
+
+		sf.continuity = &rtpContinuity{
+			clockRate:   sf.format.ClockRate(),
+			ssrc:        fsnap.SSRC,
+			lastSeqNum:  fsnap.LastSequenceNumber,
+			lastTimeRTP: fsnap.LastRTPTime,
+			lastTimeNTP: fsnap.LastNTP,
+		}
+	}
+
+	return nil
+}
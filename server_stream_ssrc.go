@@ -0,0 +1,91 @@
+package gortsplib
+
+import (
+	"crypto/rand"
+
+	"github.com/pion/rtp"
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// serverStreamFormatSSRC is the SSRC of packets written to a format
+// and the SSRC of packets sent to readers.
+type serverStreamFormatSSRC struct {
+	in  uint32
+	out uint32
+}
+
+// mapSSRC replaces the SSRC of a packet when it collides with the one of another format.
+func (sf *serverStreamFormat) mapSSRC(pkt *rtp.Packet) *rtp.Packet {
+	v, ok := sf.ssrc.Load().(serverStreamFormatSSRC)
+	if !ok || v.in != pkt.SSRC {
+		v = sf.sm.st.updateSSRC(sf, pkt.SSRC)
+	}
+
+	if v.out == v.in {
+		return pkt
+	}
+
+	ret := *pkt
+	ret.SSRC = v.out
+	return &ret
+}
+
+// updateSSRC is called when the SSRC of a format changes,
+// in order to check whether it collides with the SSRC of another format.
+func (st *ServerStream) updateSSRC(sf *serverStreamFormat, ssrc uint32) serverStreamFormatSSRC {
+	st.ssrcMutex.Lock()
+	defer st.ssrcMutex.Unlock()
+
+	v := serverStreamFormatSSRC{in: ssrc, out: ssrc}
+
+	if st.ssrcInUse(sf, ssrc) {
+		if st.RemapCollidingSSRCs {
+			for {
+				n, err := randUint32()
+				if err != nil {
+					break
+				}
+
+				if !st.ssrcInUse(sf, n) {
+					v.out = n
+					break
+				}
+			}
+		}
+
+		st.s.events.ssrcCollision(st, sf.sm.media, sf.format, v.in, v.out)
+	}
+
+	sf.ssrc.Store(v)
+
+	return v
+}
+
+// ssrcInUse checks whether a SSRC is used by formats other than sf.
+func (st *ServerStream) ssrcInUse(sf *serverStreamFormat, ssrc uint32) bool {
+	for _, sm := range st.streamMedias {
+		for _, osf := range sm.formats {
+			if osf.rtxSender != nil && *osf.rtxSender.SSRC == ssrc {
+				return true
+			}
+
+			if osf == sf {
+				continue
+			}
+
+			if v, ok := osf.ssrc.Load().(serverStreamFormatSSRC); ok && v.out == ssrc {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,270 @@
+package gortsplib
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+type benchmarkServerHandler struct{}
+
+// OnStreamWriteError implements ServerHandlerOnStreamWriteError.
+// Packets are dropped when the write queue is full, since writers are slower than the benchmark.
+func (benchmarkServerHandler) OnStreamWriteError(_ *ServerHandlerOnStreamWriteErrorCtx) {
+}
+
+// newBenchmarkReader creates a session that reads a stream with the TCP transport
+// and discards packets, without any connection.
+func newBenchmarkReader(s *Server, st *ServerStream) *ServerSession {
+	ss := allocServerSession(s, nil, strings.ReplaceAll(uuid.New().String(), "-", ""))
+	ss.state = ServerSessionStatePlay
+	ss.setuppedTransport = transportPtr(TransportTCP)
+	ss.setuppedStream = st
+	ss.setuppedMedias = make(map[*description.Media]*serverSessionMedia)
+
+	for _, medi := range st.desc.Medias {
+		sm := newServerSessionMedia(ss, medi)
+		sm.writePacketRTPInQueue = func([]byte) {}
+		sm.writePacketRTCPInQueue = func([]byte) {}
+		ss.setuppedMedias[medi] = sm
+		ss.setuppedMediasOrdered = append(ss.setuppedMediasOrdered, sm)
+	}
+
+	ss.allocateWriteQueue()
+	ss.writer.start()
+
+	err := st.readerAdd(ss, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	st.readerSetActive(ss)
+
+	return ss
+}
+
+func closeBenchmarkReader(ss *ServerSession) {
+	ss.setuppedStream.readerSetInactive(ss)
+	ss.setuppedStream.readerRemove(ss)
+	ss.writer.stop()
+}
+
+func newBenchmarkStream(s *Server) *ServerStream {
+	return NewServerStream(s, &description.Session{Medias: []*description.Media{{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{PayloadTyp: 96, PacketizationMode: 1}},
+	}}})
+}
+
+func TestServerStreamBenchmarkReader(t *testing.T) {
+	s := &Server{
+		Handler:     &testServerHandler{},
+		RTSPAddress: "localhost:8554",
+	}
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	st := newBenchmarkStream(s)
+	defer st.Close()
+
+	var written uint64
+
+	ss := newBenchmarkReader(s, st)
+	ss.setuppedMediasOrdered[0].writePacketRTPInQueue = func([]byte) {
+		atomic.AddUint64(&written, 1)
+	}
+
+	for i := 0; i < 10; i++ {
+		pkt := testRTPPacket
+		pkt.SequenceNumber = uint16(i)
+		err = st.WritePacketRTP(st.desc.Medias[0], &pkt)
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadUint64(&written) == 10
+	}, 2*time.Second, 10*time.Millisecond)
+
+	closeBenchmarkReader(ss)
+}
+
+func TestServerStreamReadersShards(t *testing.T) {
+	s := &Server{
+		Handler:     &testServerHandler{},
+		RTSPAddress: "localhost:8554",
+	}
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	st := newBenchmarkStream(s)
+	defer st.Close()
+
+	var written uint64
+
+	readers := make([]*ServerSession, 100)
+	for i := range readers {
+		readers[i] = newBenchmarkReader(s, st)
+		readers[i].setuppedMediasOrdered[0].writePacketRTPInQueue = func([]byte) {
+			atomic.AddUint64(&written, 1)
+		}
+	}
+
+	require.Equal(t, 100, st.streamMedias[st.desc.Medias[0]].readers.len())
+
+	pkt := testRTPPacket
+	err = st.WritePacketRTP(st.desc.Medias[0], &pkt)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadUint64(&written) == 100
+	}, 2*time.Second, 10*time.Millisecond)
+
+	for _, ss := range readers[:50] {
+		closeBenchmarkReader(ss)
+	}
+
+	require.Equal(t, 50, st.streamMedias[st.desc.Medias[0]].readers.len())
+
+	err = st.WritePacketRTP(st.desc.Medias[0], &pkt)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadUint64(&written) == 150
+	}, 2*time.Second, 10*time.Millisecond)
+
+	for _, ss := range readers[50:] {
+		closeBenchmarkReader(ss)
+	}
+}
+
+func BenchmarkServerStreamWritePacketRTP(b *testing.B) {
+	for _, ca := range []struct {
+		name    string
+		streams int
+		readers int
+	}{
+		{
+			"1 stream x 1000 readers",
+			1,
+			1000,
+		},
+		{
+			"1000 streams x 1 reader",
+			1000,
+			1,
+		},
+	} {
+		b.Run(ca.name, func(b *testing.B) {
+			s := &Server{
+				Handler:        benchmarkServerHandler{},
+				RTSPAddress:    "localhost:8554",
+				WriteQueueSize: 1024,
+			}
+			err := s.Start()
+			require.NoError(b, err)
+			defer s.Close()
+
+			streams := make([]*ServerStream, ca.streams)
+			var readers []*ServerSession
+
+			for i := range streams {
+				streams[i] = newBenchmarkStream(s)
+				defer streams[i].Close()
+
+				for j := 0; j < ca.readers; j++ {
+					readers = append(readers, newBenchmarkReader(s, streams[i]))
+				}
+			}
+
+			defer func() {
+				for _, ss := range readers {
+					closeBenchmarkReader(ss)
+				}
+			}()
+
+			var next uint64
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			// streams are written in parallel, each one by a single routine at a time
+			b.RunParallel(func(pb *testing.PB) {
+				st := streams[int(atomic.AddUint64(&next, 1)-1)%len(streams)]
+				pkt := testRTPPacket
+
+				for pb.Next() {
+					pkt.SequenceNumber++
+					st.WritePacketRTP(st.desc.Medias[0], &pkt) //nolint:errcheck
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkServerStreamReaderChurn measures the cost of readers
+// that start and stop reading a stream with 1000 readers, while the stream is written.
+func BenchmarkServerStreamReaderChurn(b *testing.B) {
+	s := &Server{
+		Handler:        benchmarkServerHandler{},
+		RTSPAddress:    "localhost:8554",
+		WriteQueueSize: 1024,
+	}
+	err := s.Start()
+	require.NoError(b, err)
+	defer s.Close()
+
+	st := newBenchmarkStream(s)
+	defer st.Close()
+
+	readers := make([]*ServerSession, 1000)
+	for i := range readers {
+		readers[i] = newBenchmarkReader(s, st)
+	}
+
+	defer func() {
+		for _, ss := range readers {
+			closeBenchmarkReader(ss)
+		}
+	}()
+
+	done := make(chan struct{})
+	writerDone := make(chan struct{})
+
+	go func() {
+		defer close(writerDone)
+		pkt := testRTPPacket
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			pkt.SequenceNumber++
+			st.WritePacketRTP(st.desc.Medias[0], &pkt) //nolint:errcheck
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ss := readers[i%len(readers)]
+		st.readerSetInactive(ss)
+		st.readerSetActive(ss)
+	}
+
+	b.StopTimer()
+	close(done)
+	<-writerDone
+}
@@ -17,6 +17,12 @@ func newServerTCPListener(
 		return nil, err
 	}
 
+	err = s.TCPSocketOptions.apply(ln, nil)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+
 	sl := &serverTCPListener{
 		s:  s,
 		ln: ln,
@@ -42,6 +48,11 @@ func (sl *serverTCPListener) run() {
 			return
 		}
 
+		err = sl.s.TCPSocketOptions.apply(nconn, nil)
+		if err != nil {
+			sl.s.Logger.Warn("unable to apply socket options", "err", err)
+		}
+
 		sl.s.newConn(nconn)
 	}
 }
@@ -0,0 +1,283 @@
+package gortsplib
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/liberrors"
+)
+
+// errServerTunnelAttached is returned when a POST connection
+// has been attached to the GET connection of a tunnel.
+type errServerTunnelAttached struct{}
+
+func (errServerTunnelAttached) Error() string {
+	return "tunnel attached"
+}
+
+// tunnelDecoder decodes base64 data sent through the POST connection of a tunnel.
+// Every group of 4 characters is decoded separately, since clients usually
+// encode every chunk separately, producing padding in the middle of the stream.
+type tunnelDecoder struct {
+	br  *bufio.Reader
+	buf []byte
+}
+
+// Read implements io.Reader.
+func (d *tunnelDecoder) Read(p []byte) (int, error) {
+	if len(d.buf) == 0 {
+		var group [4]byte
+		i := 0
+
+		for i < 4 {
+			b, err := d.br.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+
+			switch b {
+			case '\r', '\n', ' ', '\t':
+				continue
+			}
+
+			group[i] = b
+			i++
+		}
+
+		dec := make([]byte, 3)
+		n, err := base64.StdEncoding.Decode(dec, group[:])
+		if err != nil {
+			return 0, err
+		}
+		d.buf = dec[:n]
+	}
+
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// serverBufferedConn is a net.Conn whose initial data has been read into a buffer.
+type serverBufferedConn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+// Read implements net.Conn.
+func (bc *serverBufferedConn) Read(p []byte) (int, error) {
+	return bc.br.Read(p)
+}
+
+// serverTunnelConn is a net.Conn that implements RTSP-over-HTTP tunneling.
+// Incoming data is read from the POST connection and decoded from base64,
+// outgoing data is written into the GET connection.
+type serverTunnelConn struct {
+	getConn  net.Conn
+	postConn net.Conn
+	dec      *tunnelDecoder
+}
+
+// Read implements net.Conn.
+func (tc *serverTunnelConn) Read(p []byte) (int, error) {
+	return tc.dec.Read(p)
+}
+
+// Write implements net.Conn.
+func (tc *serverTunnelConn) Write(p []byte) (int, error) {
+	return tc.getConn.Write(p)
+}
+
+// Close implements net.Conn.
+func (tc *serverTunnelConn) Close() error {
+	err1 := tc.getConn.Close()
+	err2 := tc.postConn.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// LocalAddr implements net.Conn.
+func (tc *serverTunnelConn) LocalAddr() net.Addr {
+	return tc.getConn.LocalAddr()
+}
+
+// RemoteAddr implements net.Conn.
+func (tc *serverTunnelConn) RemoteAddr() net.Addr {
+	return tc.getConn.RemoteAddr()
+}
+
+// SetDeadline implements net.Conn.
+func (tc *serverTunnelConn) SetDeadline(t time.Time) error {
+	err := tc.getConn.SetDeadline(t)
+	if err != nil {
+		return err
+	}
+	return tc.postConn.SetDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+func (tc *serverTunnelConn) SetReadDeadline(t time.Time) error {
+	return tc.postConn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn.
+func (tc *serverTunnelConn) SetWriteDeadline(t time.Time) error {
+	return tc.getConn.SetWriteDeadline(t)
+}
+
+type serverTunnelPost struct {
+	nconn net.Conn
+	br    *bufio.Reader
+}
+
+type serverRegisterTunnelReq struct {
+	cookie string
+	sc     *ServerConn
+	res    chan error
+}
+
+type serverAttachTunnelReq struct {
+	cookie string
+	post   *serverTunnelPost
+	res    chan bool
+}
+
+// runTunnel detects whether the connection is part of a RTSP-over-HTTP tunnel
+// and, in case it's a GET connection, waits for the related POST connection.
+func (sc *ServerConn) runTunnel() error {
+	nconn := sc.nconn
+
+	// interrupt blocking operations when the connection is closed.
+	watcherTerminate := make(chan struct{})
+	watcherDone := make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		select {
+		case <-sc.ctx.Done():
+			nconn.Close()
+		case <-watcherTerminate:
+		}
+	}()
+	defer func() {
+		close(watcherTerminate)
+		<-watcherDone
+	}()
+
+	nconn.SetReadDeadline(time.Now().Add(sc.s.ReadTimeout))
+	defer nconn.SetReadDeadline(time.Time{})
+
+	br := bufio.NewReaderSize(nconn, 4096)
+
+	byts, err := br.Peek(4)
+	if err != nil {
+		return err
+	}
+
+	switch string(byts) {
+	case "GET ":
+		return sc.runTunnelGet(br)
+
+	case "POST":
+		return sc.runTunnelPost(br)
+	}
+
+	sc.nconn = &serverBufferedConn{
+		Conn: nconn,
+		br:   br,
+	}
+	return nil
+}
+
+func (sc *ServerConn) runTunnelGet(br *bufio.Reader) error {
+	hreq, err := http.ReadRequest(br)
+	if err != nil {
+		return err
+	}
+
+	cookie := hreq.Header.Get("x-sessioncookie")
+	if cookie == "" {
+		sc.writeTunnelResponse(base.StatusBadRequest) //nolint:errcheck
+		return liberrors.ErrServerTunnelSessionCookieMissing{}
+	}
+
+	err = sc.s.registerTunnel(cookie, sc)
+	if err != nil {
+		sc.writeTunnelResponse(base.StatusBadRequest) //nolint:errcheck
+		return err
+	}
+
+	err = sc.writeTunnelResponse(base.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	timer := sc.s.Clock.NewTimer(sc.s.ReadTimeout)
+	defer timer.Stop()
+
+	select {
+	case post := <-sc.chTunnelPost:
+		sc.nconn = &serverTunnelConn{
+			getConn:  sc.nconn,
+			postConn: post.nconn,
+			dec:      &tunnelDecoder{br: post.br},
+		}
+		return nil
+
+	case <-timer.C():
+		return liberrors.ErrServerTunnelPostTimeout{}
+
+	case <-sc.ctx.Done():
+		return liberrors.ErrServerTerminated{}
+	}
+}
+
+func (sc *ServerConn) runTunnelPost(br *bufio.Reader) error {
+	hreq, err := http.ReadRequest(br)
+	if err != nil {
+		return err
+	}
+
+	cookie := hreq.Header.Get("x-sessioncookie")
+	if cookie == "" {
+		return liberrors.ErrServerTunnelSessionCookieMissing{}
+	}
+
+	ok := sc.s.attachTunnel(cookie, &serverTunnelPost{
+		nconn: sc.nconn,
+		br:    br,
+	})
+	if !ok {
+		return liberrors.ErrServerTunnelGetNotFound{}
+	}
+
+	return errServerTunnelAttached{}
+}
+
+func (sc *ServerConn) writeTunnelResponse(statusCode base.StatusCode) error {
+	header := base.Header{
+		"Cache-Control": base.HeaderValue{"no-store"},
+		"Pragma":        base.HeaderValue{"no-cache"},
+	}
+
+	if statusCode == base.StatusOK {
+		header["Content-Type"] = base.HeaderValue{"application/x-rtsp-tunnelled"}
+	}
+
+	byts, err := base.Response{
+		StatusCode: statusCode,
+		Protocol:   "HTTP/1.0",
+		Header:     header,
+	}.Marshal()
+	if err != nil {
+		return err
+	}
+
+	sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.WriteTimeout))
+	_, err = sc.nconn.Write(byts)
+	return err
+}
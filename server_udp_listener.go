@@ -1,6 +1,7 @@
 package gortsplib
 
 import (
+	"fmt"
 	"net"
 	"strconv"
 	"sync"
@@ -29,6 +30,8 @@ type serverUDPListener struct {
 	pc           net.PacketConn
 	listenIP     net.IP
 	writeTimeout time.Duration
+	reuseBuffers bool
+	batchConn    *udpBatchConn
 	clientsMutex sync.RWMutex
 	clients      map[clientAddr]readFunc
 
@@ -41,11 +44,18 @@ func newServerUDPListenerMulticastPair(
 	multicastRTPPort int,
 	multicastRTCPPort int,
 	ip net.IP,
+	rtcpIP net.IP,
+	multicastTTL int,
+	multicastInterface *net.Interface,
 ) (*serverUDPListener, *serverUDPListener, error) {
 	rtpl, err := newServerUDPListener(
 		listenPacket,
 		writeTimeout,
+		false,
+		1,
 		true,
+		multicastTTL,
+		multicastInterface,
 		net.JoinHostPort(ip.String(), strconv.FormatInt(int64(multicastRTPPort), 10)),
 	)
 	if err != nil {
@@ -55,8 +65,12 @@ func newServerUDPListenerMulticastPair(
 	rtcpl, err := newServerUDPListener(
 		listenPacket,
 		writeTimeout,
+		false,
+		1,
 		true,
-		net.JoinHostPort(ip.String(), strconv.FormatInt(int64(multicastRTCPPort), 10)),
+		multicastTTL,
+		multicastInterface,
+		net.JoinHostPort(rtcpIP.String(), strconv.FormatInt(int64(multicastRTCPPort), 10)),
 	)
 	if err != nil {
 		rtpl.close()
@@ -66,17 +80,37 @@ func newServerUDPListenerMulticastPair(
 	return rtpl, rtcpl, nil
 }
 
+// applyUDPSocketOptions applies UDPSocketOptions to a pair of listeners.
+func (s *Server) applyUDPSocketOptions(rtpl *serverUDPListener, rtcpl *serverUDPListener) error {
+	err := s.UDPSocketOptions.apply(rtpl.pc, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.UDPSocketOptions.apply(rtcpl.pc, nil)
+}
+
 func newServerUDPListener(
 	listenPacket func(network, address string) (net.PacketConn, error),
 	writeTimeout time.Duration,
+	reuseBuffers bool,
+	batchSize int,
 	multicastEnable bool,
+	multicastTTL int,
+	multicastInterface *net.Interface,
 	address string,
 ) (*serverUDPListener, error) {
 	var pc packetConn
 	var listenIP net.IP
 	if multicastEnable {
 		var err error
-		pc, err = multicast.NewMultiConn(address, false, listenPacket)
+		if multicastInterface != nil {
+			pc, err = multicast.NewSingleConnWithOptions(multicastInterface, address,
+				multicast.SingleConnOptions{TTL: multicastTTL}, listenPacket)
+		} else {
+			pc, err = multicast.NewMultiConnWithOptions(address, false,
+				multicast.MultiConnOptions{TTL: multicastTTL}, listenPacket)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -91,8 +125,13 @@ func newServerUDPListener(
 		if err != nil {
 			return nil, err
 		}
-		pc = tmp.(*net.UDPConn)
-		listenIP = tmp.LocalAddr().(*net.UDPAddr).IP
+		var ok bool
+		pc, ok = tmp.(packetConn)
+		if !ok {
+			tmp.Close()
+			return nil, fmt.Errorf("packet connection does not implement SetReadBuffer()")
+		}
+		listenIP, _ = addrIPZone(tmp.LocalAddr())
 	}
 
 	err := pc.SetReadBuffer(udpKernelReadBufferSize)
@@ -106,6 +145,8 @@ func newServerUDPListener(
 		listenIP:     listenIP,
 		clients:      make(map[clientAddr]readFunc),
 		writeTimeout: writeTimeout,
+		reuseBuffers: reuseBuffers,
+		batchConn:    newUDPBatchConn(pc, batchSize),
 		done:         make(chan struct{}),
 	}
 
@@ -123,6 +164,26 @@ func (u *serverUDPListener) ip() net.IP {
 	return u.listenIP
 }
 
+// reaches checks whether the listener can exchange packets with the given IP,
+// that is, whether they belong to the same address family.
+// Listeners bound to the IPv6 unspecified address are dual-stack.
+func (u *serverUDPListener) reaches(ip net.IP) bool {
+	// connection is not IP-based
+	if ip == nil {
+		return true
+	}
+
+	if u.listenIP.To4() != nil {
+		return ip.To4() != nil
+	}
+
+	if u.listenIP.IsUnspecified() {
+		return true
+	}
+
+	return ip.To4() == nil
+}
+
 func (u *serverUDPListener) port() int {
 	return u.pc.LocalAddr().(*net.UDPAddr).Port
 }
@@ -130,28 +191,30 @@ func (u *serverUDPListener) port() int {
 func (u *serverUDPListener) run() {
 	defer close(u.done)
 
+	var r udpReader
+	r.initialize(u.batchConn, u.reuseBuffers)
+	defer r.close()
+
 	for {
-		buf := make([]byte, udpMaxPayloadSize+1)
-		n, addr2, err := u.pc.ReadFrom(buf)
+		err := r.read(u.processPacket)
 		if err != nil {
 			break
 		}
-		addr := addr2.(*net.UDPAddr)
-
-		func() {
-			u.clientsMutex.RLock()
-			defer u.clientsMutex.RUnlock()
+	}
+}
 
-			var clientAddr clientAddr
-			clientAddr.fill(addr.IP, addr.Port)
-			cb, ok := u.clients[clientAddr]
-			if !ok {
-				return
-			}
+func (u *serverUDPListener) processPacket(buf []byte, addr *net.UDPAddr) {
+	u.clientsMutex.RLock()
+	defer u.clientsMutex.RUnlock()
 
-			cb(buf[:n])
-		}()
+	var clientAddr clientAddr
+	clientAddr.fill(addr.IP, addr.Port)
+	cb, ok := u.clients[clientAddr]
+	if !ok {
+		return
 	}
+
+	cb(buf)
 }
 
 func (u *serverUDPListener) write(buf []byte, addr *net.UDPAddr) error {
@@ -162,6 +225,13 @@ func (u *serverUDPListener) write(buf []byte, addr *net.UDPAddr) error {
 	return err
 }
 
+// writeBatch writes multiple packets to the same address,
+// with a single system call when batches are enabled.
+func (u *serverUDPListener) writeBatch(bufs [][]byte, addr *net.UDPAddr) error {
+	u.pc.SetWriteDeadline(time.Now().Add(u.writeTimeout))
+	return u.batchConn.writeBatch(bufs, addr)
+}
+
 func (u *serverUDPListener) addClient(ip net.IP, port int, cb readFunc) {
 	var addr clientAddr
 	addr.fill(ip, port)
@@ -0,0 +1,74 @@
+package gortsplib
+
+import (
+	"fmt"
+	"time"
+)
+
+// ServerWriteQueuePolicy is the policy applied when the write queue of a session is full.
+type ServerWriteQueuePolicy int
+
+// write queue policies.
+const (
+	// discard the packet that is being written.
+	ServerWriteQueuePolicyDropNewest ServerWriteQueuePolicy = iota
+
+	// discard the oldest queued packets in order to make room for the packet that is being written.
+	ServerWriteQueuePolicyDropOldest
+
+	// discard the packet that is being written and close the session.
+	ServerWriteQueuePolicyDisconnect
+
+	// wait until there is room in the queue, up to BlockTimeout, then discard the packet.
+	// Since streams write to their readers sequentially, a slow reader
+	// delays all the other readers of the same stream.
+	ServerWriteQueuePolicyBlock
+)
+
+var serverWriteQueuePolicyLabels = map[ServerWriteQueuePolicy]string{
+	ServerWriteQueuePolicyDropNewest: "drop-newest",
+	ServerWriteQueuePolicyDropOldest: "drop-oldest",
+	ServerWriteQueuePolicyDisconnect: "disconnect",
+	ServerWriteQueuePolicyBlock:      "block",
+}
+
+// String implements fmt.Stringer.
+func (p ServerWriteQueuePolicy) String() string {
+	if l, ok := serverWriteQueuePolicyLabels[p]; ok {
+		return l
+	}
+	return "unknown"
+}
+
+// ServerWriteQueue contains the parameters of the write queue of a session,
+// that detaches the routine that is writing a stream from the connection of a reader.
+type ServerWriteQueue struct {
+	// maximum number of queued packets. It must be a power of two.
+	// It defaults to Server.WriteQueueSize.
+	Size int
+
+	// maximum number of queued bytes.
+	// It defaults to 0 (unlimited).
+	MaxBytes int
+
+	// policy applied when the queue is full.
+	// It defaults to ServerWriteQueuePolicyDropNewest.
+	Policy ServerWriteQueuePolicy
+
+	// maximum time spent waiting for room in the queue with ServerWriteQueuePolicyBlock.
+	// It defaults to Server.WriteTimeout.
+	BlockTimeout time.Duration
+}
+
+func (q *ServerWriteQueue) validate() error {
+	if q.Size < 0 || (q.Size&(q.Size-1)) != 0 {
+		return fmt.Errorf("write queue size must be a power of two")
+	}
+	if q.MaxBytes < 0 {
+		return fmt.Errorf("invalid maximum number of queued bytes: %d", q.MaxBytes)
+	}
+	if _, ok := serverWriteQueuePolicyLabels[q.Policy]; !ok {
+		return fmt.Errorf("invalid write queue policy: %d", q.Policy)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+)
+
+// Common DSCP (Differentiated Services Code Point) values.
+const (
+	// DSCPExpeditedForwarding is suited for audio.
+	DSCPExpeditedForwarding = 46
+
+	// DSCPAssuredForwarding41 is suited for video.
+	DSCPAssuredForwarding41 = 34
+)
+
+// SocketOptions are options applied to sockets.
+// They are ignored by connections that are not sockets.
+type SocketOptions struct {
+	// DSCP (Differentiated Services Code Point) of outgoing packets,
+	// between 0 and 63 (optional).
+	// It is applied to TCP and UDP unicast sockets.
+	DSCP int
+
+	// DSCP of outgoing packets of specific media types (optional).
+	// It overrides DSCP in UDP unicast sockets of clients,
+	// that are dedicated to a single media.
+	MediaDSCP map[description.MediaType]int
+
+	// name of a network interface or VRF sockets are bound to,
+	// with SO_BINDTODEVICE (optional). It is supported on Linux only.
+	BindToDevice string
+
+	// size of the kernel send buffer, SO_SNDBUF (optional).
+	SendBufferSize int
+
+	// size of the kernel receive buffer, SO_RCVBUF (optional).
+	ReceiveBufferSize int
+}
+
+func (o *SocketOptions) validate() error {
+	if o == nil {
+		return nil
+	}
+
+	if o.DSCP < 0 || o.DSCP > 63 {
+		return fmt.Errorf("invalid DSCP: %d", o.DSCP)
+	}
+
+	for _, v := range o.MediaDSCP {
+		if v < 0 || v > 63 {
+			return fmt.Errorf("invalid DSCP: %d", v)
+		}
+	}
+
+	return nil
+}
+
+func (o *SocketOptions) dscp(medi *description.Media) int {
+	if medi != nil {
+		if v, ok := o.MediaDSCP[medi.Type]; ok {
+			return v
+		}
+	}
+	return o.DSCP
+}
+
+// control applies options that must be set before sockets are connected.
+// It can be used as Control function of net.Dialer.
+func (o *SocketOptions) control(_ string, _ string, rc syscall.RawConn) error {
+	if o.BindToDevice == "" {
+		return nil
+	}
+	return bindToDevice(rc, o.BindToDevice)
+}
+
+// apply applies options to a connection or listener,
+// that can belong to a specific media.
+func (o *SocketOptions) apply(conn interface{}, medi *description.Media) error {
+	if o == nil {
+		return nil
+	}
+
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+
+	if o.BindToDevice != "" {
+		rc, err := sc.SyscallConn()
+		if err != nil {
+			return err
+		}
+
+		err = bindToDevice(rc, o.BindToDevice)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dscp := o.dscp(medi); dscp != 0 {
+		err := setDSCP(conn, dscp)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.SendBufferSize != 0 {
+		if c, ok := conn.(interface{ SetWriteBuffer(int) error }); ok {
+			err := c.SetWriteBuffer(o.SendBufferSize)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.ReceiveBufferSize != 0 {
+		if c, ok := conn.(interface{ SetReadBuffer(int) error }); ok {
+			err := c.SetReadBuffer(o.ReceiveBufferSize)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setDSCP sets the DSCP of outgoing packets, through the TOS field of IPv4
+// or the Traffic Class field of IPv6.
+func setDSCP(conn interface{}, dscp int) error {
+	tos := dscp << 2
+
+	switch c := conn.(type) {
+	case *net.TCPConn:
+		if isIPv4Addr(c.LocalAddr()) {
+			return ipv4.NewConn(c).SetTOS(tos)
+		}
+		return ipv6.NewConn(c).SetTrafficClass(tos)
+
+	case *net.UDPConn:
+		if isIPv4Addr(c.LocalAddr()) {
+			return ipv4.NewPacketConn(c).SetTOS(tos)
+		}
+
+		// dual-stack sockets send IPv4 packets too
+		ipv4.NewPacketConn(c).SetTOS(tos) //nolint:errcheck
+		return ipv6.NewPacketConn(c).SetTrafficClass(tos)
+	}
+
+	return nil
+}
+
+func isIPv4Addr(addr net.Addr) bool {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP.To4() != nil
+
+	case *net.UDPAddr:
+		return a.IP.To4() != nil
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package gortsplib
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+)
+
+func TestSocketOptionsApply(t *testing.T) {
+	o := &SocketOptions{
+		DSCP: DSCPAssuredForwarding41,
+		MediaDSCP: map[description.MediaType]int{
+			description.MediaTypeAudio: DSCPExpeditedForwarding,
+		},
+		SendBufferSize:    64 * 1024,
+		ReceiveBufferSize: 64 * 1024,
+	}
+
+	for _, ca := range []struct {
+		name string
+		medi *description.Media
+		dscp int
+	}{
+		{"default", nil, DSCPAssuredForwarding41},
+		{"video", &description.Media{Type: description.MediaTypeVideo}, DSCPAssuredForwarding41},
+		{"audio", &description.Media{Type: description.MediaTypeAudio}, DSCPExpeditedForwarding},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer pc.Close()
+
+			err = o.apply(pc, ca.medi)
+			require.NoError(t, err)
+
+			tos, err := ipv4.NewPacketConn(pc).TOS()
+			require.NoError(t, err)
+			require.Equal(t, ca.dscp<<2, tos)
+		})
+	}
+}
+
+func TestSocketOptionsInvalid(t *testing.T) {
+	s := &Server{
+		RTSPAddress:      "localhost:8554",
+		TCPSocketOptions: &SocketOptions{DSCP: 64},
+	}
+	err := s.Start()
+	require.EqualError(t, err, "invalid DSCP: 64")
+
+	c := Client{
+		UDPSocketOptions: &SocketOptions{
+			MediaDSCP: map[description.MediaType]int{
+				description.MediaTypeVideo: -1,
+			},
+		},
+	}
+	err = c.Start("rtsp", "localhost:8554")
+	require.EqualError(t, err, "invalid DSCP: -1")
+}
+
+func TestServerSocketOptions(t *testing.T) {
+	s := &Server{
+		Handler:          &testServerHandler{},
+		RTSPAddress:      "127.0.0.1:8554",
+		UDPRTPAddress:    "127.0.0.1:8000",
+		UDPRTCPAddress:   "127.0.0.1:8001",
+		TCPSocketOptions: &SocketOptions{DSCP: DSCPAssuredForwarding41},
+		UDPSocketOptions: &SocketOptions{DSCP: DSCPExpeditedForwarding},
+	}
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	tos, err := ipv4.NewPacketConn(s.udpRTPListener.pc).TOS()
+	require.NoError(t, err)
+	require.Equal(t, DSCPExpeditedForwarding<<2, tos)
+
+	c := Client{
+		TCPSocketOptions: &SocketOptions{DSCP: DSCPAssuredForwarding41},
+	}
+	err = c.Start("rtsp", "127.0.0.1:8554")
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Options(mustParseURL("rtsp://127.0.0.1:8554/"))
+	require.NoError(t, err)
+
+	tos, err = ipv4.NewConn(c.nconn.(*net.TCPConn)).TOS()
+	require.NoError(t, err)
+	require.Equal(t, DSCPAssuredForwarding41<<2, tos)
+}
@@ -0,0 +1,151 @@
+package gortsplib
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtcpreceiver"
+)
+
+// MediaTransportInfo contains details of the transport negotiated for a media
+// by a Client or a ServerSession.
+type MediaTransportInfo struct {
+	// media.
+	Media *description.Media
+
+	// transport protocol.
+	Transport Transport
+
+	// local RTP and RTCP ports (UDP and UDP-multicast only).
+	LocalPorts *[2]int
+
+	// remote RTP and RTCP ports (UDP and UDP-multicast only).
+	RemotePorts *[2]int
+
+	// interleaved channels of RTP and RTCP packets (TCP only).
+	InterleavedIDs *[2]int
+
+	// multicast group (UDP-multicast only).
+	MulticastGroup net.IP
+
+	// SSRCs of RTP packets that are read or written, by payload type.
+	// SSRCs are available after the first packet of a format has been read or written.
+	SSRCs map[uint8]uint32
+
+	// round-trip time, estimated from RTCP reports.
+	// Writers estimate it from receiver reports;
+	// readers estimate it from extended reports (RTCPExtendedReportsEnable).
+	// It is zero until the first estimate is available, and it is updated with each report.
+	RoundTripTime time.Duration
+}
+
+func receiverRoundTripTime(rr *rtcpreceiver.RTCPReceiver, cur time.Duration) time.Duration {
+	if rtt := rr.Stats().RoundTripTime; rtt > cur {
+		return rtt
+	}
+	return cur
+}
+
+// TransportInfo returns details of the transport negotiated for each media.
+// Medias are returned in no particular order.
+func (c *Client) TransportInfo() []MediaTransportInfo {
+	if c.effectiveTransport == nil {
+		return nil
+	}
+
+	ret := make([]MediaTransportInfo, 0, len(c.medias))
+
+	for _, cm := range c.medias {
+		info := MediaTransportInfo{
+			Media:         cm.media,
+			Transport:     *c.effectiveTransport,
+			SSRCs:         make(map[uint8]uint32),
+			RoundTripTime: time.Duration(atomic.LoadInt64(cm.roundTripTime)),
+		}
+
+		if cm.udpRTPListener != nil {
+			info.LocalPorts = &[2]int{cm.udpRTPListener.port(), cm.udpRTCPListener.port()}
+
+			if cm.udpRTPListener.writeAddr != nil && cm.udpRTCPListener.writeAddr != nil {
+				info.RemotePorts = &[2]int{cm.udpRTPListener.writeAddr.Port, cm.udpRTCPListener.writeAddr.Port}
+			}
+
+			if info.Transport == TransportUDPMulticast && cm.udpRTPListener.writeAddr != nil {
+				info.MulticastGroup = cm.udpRTPListener.writeAddr.IP
+			}
+		} else {
+			info.InterleavedIDs = &[2]int{cm.tcpChannel, cm.tcpChannel + 1}
+		}
+
+		for pt, ct := range cm.formats {
+			if ct.rtcpReceiver != nil {
+				if ssrc, ok := ct.rtcpReceiver.SenderSSRC(); ok {
+					info.SSRCs[pt] = ssrc
+				}
+				info.RoundTripTime = receiverRoundTripTime(ct.rtcpReceiver, info.RoundTripTime)
+			}
+
+			if ct.rtcpSender != nil {
+				if ssrc, ok := ct.rtcpSender.SenderSSRC(); ok {
+					info.SSRCs[pt] = ssrc
+				}
+			}
+		}
+
+		ret = append(ret, info)
+	}
+
+	return ret
+}
+
+// TransportInfo returns details of the transport negotiated for each media.
+func (ss *ServerSession) TransportInfo() []MediaTransportInfo {
+	if ss.setuppedTransport == nil {
+		return nil
+	}
+
+	ret := make([]MediaTransportInfo, 0, len(ss.setuppedMediasOrdered))
+
+	for _, sm := range ss.setuppedMediasOrdered {
+		info := MediaTransportInfo{
+			Media:         sm.media,
+			Transport:     *ss.setuppedTransport,
+			SSRCs:         make(map[uint8]uint32),
+			RoundTripTime: time.Duration(atomic.LoadInt64(sm.roundTripTime)),
+		}
+
+		switch info.Transport {
+		case TransportUDP:
+			info.LocalPorts = &[2]int{ss.s.udpRTPListener.port(), ss.s.udpRTCPListener.port()}
+			info.RemotePorts = &[2]int{sm.udpRTPReadPort, sm.udpRTCPReadPort}
+
+		case TransportTCP:
+			info.InterleavedIDs = &[2]int{sm.tcpChannel, sm.tcpChannel + 1}
+		}
+
+		if sm.formats != nil {
+			for pt, sf := range sm.formats {
+				if sf.rtcpReceiver == nil {
+					continue
+				}
+
+				if ssrc, ok := sf.rtcpReceiver.SenderSSRC(); ok {
+					info.SSRCs[pt] = ssrc
+				}
+				info.RoundTripTime = receiverRoundTripTime(sf.rtcpReceiver, info.RoundTripTime)
+			}
+		} else {
+			// SSRCs and multicast details are owned by the stream
+			st, medi := sm.readStream()
+			if st != nil {
+				st.readerTransportInfo(medi, &info)
+			}
+		}
+
+		ret = append(ret, info)
+	}
+
+	return ret
+}
@@ -0,0 +1,23 @@
+package gortsplib
+
+// Tunnel is a tunneling method.
+type Tunnel int
+
+// tunneling methods.
+const (
+	TunnelNone Tunnel = iota
+	TunnelHTTP
+)
+
+var tunnelLabels = map[Tunnel]string{
+	TunnelNone: "none",
+	TunnelHTTP: "HTTP",
+}
+
+// String implements fmt.Stringer.
+func (t Tunnel) String() string {
+	if l, ok := tunnelLabels[t]; ok {
+		return l
+	}
+	return "unknown"
+}
@@ -0,0 +1,132 @@
+package gortsplib
+
+import (
+	"net"
+	"sync/atomic"
+
+	"golang.org/x/net/ipv4"
+)
+
+// a connection that is able to read and write multiple packets with a single system call.
+type udpBatchPacketConn interface {
+	ReadBatch(ms []ipv4.Message, flags int) (int, error)
+	WriteBatch(ms []ipv4.Message, flags int) (int, error)
+}
+
+// udpBatchConn reads and writes UDP packets in batches (recvmmsg / sendmmsg).
+// When batches are not supported, packets are read and written one at a time.
+type udpBatchConn struct {
+	pc        net.PacketConn
+	bc        udpBatchPacketConn
+	readMsgs  []ipv4.Message
+	batchSize int
+	gso       int32
+}
+
+func newUDPBatchConn(pc net.PacketConn, batchSize int) *udpBatchConn {
+	b := &udpBatchConn{
+		pc:        pc,
+		batchSize: 1,
+	}
+
+	if batchSize > 1 {
+		b.bc = newUDPBatchPacketConn(pc)
+		if b.bc != nil {
+			b.batchSize = batchSize
+			b.readMsgs = make([]ipv4.Message, batchSize)
+			for i := range b.readMsgs {
+				b.readMsgs[i].Buffers = make([][]byte, 1)
+			}
+		}
+	}
+
+	return b
+}
+
+// readBatch reads up to len(bufs) packets.
+// It returns the number of packets read. Sizes and sources of packets are stored into ns and addrs.
+func (b *udpBatchConn) readBatch(bufs [][]byte, ns []int, addrs []*net.UDPAddr) (int, error) {
+	if b.bc == nil {
+		n, addr, err := b.pc.ReadFrom(bufs[0])
+		if err != nil {
+			return 0, err
+		}
+
+		ns[0] = n
+		addrs[0] = addr.(*net.UDPAddr)
+		return 1, nil
+	}
+
+	for i := range bufs {
+		b.readMsgs[i].Buffers[0] = bufs[i]
+	}
+
+	n, err := b.bc.ReadBatch(b.readMsgs[:len(bufs)], 0)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < n; i++ {
+		ns[i] = b.readMsgs[i].N
+		addrs[i] = b.readMsgs[i].Addr.(*net.UDPAddr)
+	}
+
+	return n, nil
+}
+
+// enableSegmentationOffload enables UDP generic segmentation offload (GSO),
+// that allows to write bursts of packets with a single buffer.
+// It returns false if the feature is not supported.
+func (b *udpBatchConn) enableSegmentationOffload() bool {
+	if !udpSegmentationOffloadSupported(b.pc) {
+		return false
+	}
+
+	atomic.StoreInt32(&b.gso, 1)
+	return true
+}
+
+// segmentationOffloadActive returns whether segmentation offload is in use.
+func (b *udpBatchConn) segmentationOffloadActive() bool {
+	return atomic.LoadInt32(&b.gso) == 1
+}
+
+// writeBatch writes packets to the same destination.
+func (b *udpBatchConn) writeBatch(bufs [][]byte, addr *net.UDPAddr) error {
+	if len(bufs) > 1 && b.segmentationOffloadActive() {
+		err := writeSegmented(b.pc, bufs, addr)
+		if err == nil || !isUDPSegmentationOffloadError(err) {
+			return err
+		}
+
+		// the network interface doesn't support segmentation offload.
+		// disable it and write packets again with the other methods.
+		atomic.StoreInt32(&b.gso, 0)
+	}
+
+	if b.bc == nil || len(bufs) == 1 {
+		for _, buf := range bufs {
+			_, err := b.pc.WriteTo(buf, addr)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	msgs := make([]ipv4.Message, len(bufs))
+	for i, buf := range bufs {
+		msgs[i].Buffers = [][]byte{buf}
+		msgs[i].Addr = addr
+	}
+
+	for len(msgs) != 0 {
+		n, err := b.bc.WriteBatch(msgs, 0)
+		if err != nil {
+			return err
+		}
+		msgs = msgs[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package gortsplib
+
+import (
+	"net"
+)
+
+// newUDPBatchPacketConn returns a connection that reads and writes
+// packets in batches with recvmmsg and sendmmsg.
+// It is not supported on this platform.
+func newUDPBatchPacketConn(_ net.PacketConn) udpBatchPacketConn {
+	return nil
+}
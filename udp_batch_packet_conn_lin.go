@@ -0,0 +1,25 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"net"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// newUDPBatchPacketConn returns a connection that reads and writes
+// packets in batches with recvmmsg and sendmmsg.
+func newUDPBatchPacketConn(pc net.PacketConn) udpBatchPacketConn {
+	uc, ok := pc.(*net.UDPConn)
+	if !ok {
+		return nil
+	}
+
+	if uc.LocalAddr().(*net.UDPAddr).IP.To4() != nil {
+		return ipv4.NewPacketConn(uc)
+	}
+	return ipv6.NewPacketConn(uc)
+}
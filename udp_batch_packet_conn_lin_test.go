@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDPBatchConn(t *testing.T) {
+	for _, ca := range []string{"udp4", "udp6"} {
+		t.Run(ca, func(t *testing.T) {
+			addr := "127.0.0.1:0"
+			if ca == "udp6" {
+				addr = "[::1]:0"
+			}
+
+			pc1, err := net.ListenPacket(ca, addr)
+			if err != nil {
+				t.Skip("network is not available")
+			}
+			defer pc1.Close()
+
+			pc2, err := net.ListenPacket(ca, addr)
+			require.NoError(t, err)
+			defer pc2.Close()
+
+			writer := newUDPBatchConn(pc1, 4)
+			require.NotNil(t, writer.bc)
+
+			reader := newUDPBatchConn(pc2, 4)
+			require.Equal(t, 4, reader.batchSize)
+
+			err = writer.writeBatch([][]byte{{1, 2}, {3, 4, 5}, {6}}, pc2.LocalAddr().(*net.UDPAddr))
+			require.NoError(t, err)
+
+			pc2.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+			bufs := [][]byte{make([]byte, 16), make([]byte, 16), make([]byte, 16), make([]byte, 16)}
+			ns := make([]int, 4)
+			addrs := make([]*net.UDPAddr, 4)
+
+			var received [][]byte
+
+			for len(received) < 3 {
+				n, err := reader.readBatch(bufs, ns, addrs)
+				require.NoError(t, err)
+
+				for i := 0; i < n; i++ {
+					received = append(received, append([]byte(nil), bufs[i][:ns[i]]...))
+					require.Equal(t, pc1.LocalAddr().(*net.UDPAddr).Port, addrs[i].Port)
+				}
+			}
+
+			require.Equal(t, [][]byte{{1, 2}, {3, 4, 5}, {6}}, received)
+		})
+	}
+}
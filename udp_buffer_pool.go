@@ -0,0 +1,28 @@
+package gortsplib
+
+import (
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+// buffers used to read UDP packets when buffer reuse is enabled.
+// Each read routine holds its buffers for its entire lifetime and returns them when it exits.
+var udpBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, udpMaxPayloadSize+1)
+		return &buf
+	},
+}
+
+// detachBufferedPacketRTP copies a packet that has been kept by the reorderer
+// out of the read buffer, since the buffer is going to be reused.
+func detachBufferedPacketRTP(pkt *rtp.Packet, returned []*rtp.Packet) {
+	for _, p := range returned {
+		if p == pkt {
+			return
+		}
+	}
+
+	*pkt = *pkt.Clone()
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package gortsplib
+
+import (
+	"net"
+)
+
+// udpKernelDrops returns the number of packets dropped by the kernel
+// because the receive buffer of a socket was full.
+// It is not supported on this platform.
+func udpKernelDrops(_ net.PacketConn) (uint64, bool) {
+	return 0, false
+}
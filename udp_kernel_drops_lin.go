@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// udpKernelDrops returns the number of packets dropped by the kernel
+// because the receive buffer of a socket was full.
+// The counter is read from /proc/net/udp and /proc/net/udp6.
+func udpKernelDrops(pc net.PacketConn) (uint64, bool) {
+	sc, ok := pc.(syscall.Conn)
+	if !ok {
+		return 0, false
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return 0, false
+	}
+
+	var inode uint64
+	err = rc.Control(func(fd uintptr) {
+		var st syscall.Stat_t
+		if syscall.Fstat(int(fd), &st) == nil {
+			inode = st.Ino
+		}
+	})
+	if err != nil || inode == 0 {
+		return 0, false
+	}
+
+	for _, path := range []string{"/proc/net/udp", "/proc/net/udp6"} {
+		drops, ok := udpKernelDropsFromFile(path, inode)
+		if ok {
+			return drops, true
+		}
+	}
+
+	return 0, false
+}
+
+func udpKernelDropsFromFile(path string, inode uint64) (uint64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	return parseProcNetUDP(bufio.NewScanner(f), inode)
+}
+
+// parseProcNetUDP finds the drop counter of a socket in the format of /proc/net/udp:
+// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ref pointer drops
+func parseProcNetUDP(sc *bufio.Scanner, inode uint64) (uint64, bool) {
+	// skip header
+	if !sc.Scan() {
+		return 0, false
+	}
+
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 13 {
+			continue
+		}
+
+		v, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || v != inode {
+			continue
+		}
+
+		drops, err := strconv.ParseUint(fields[12], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return drops, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,59 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcNetUDP(t *testing.T) {
+	const content = "   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt" +
+		"   uid  timeout inode ref pointer drops\n" +
+		"  123: 00000000:1F90 00000000:0000 07 00000000:00000000 00:00000000 00000000" +
+		"     0        0 41234 2 0000000000000000 0\n" +
+		"  456: 00000000:1F91 00000000:0000 07 00000000:00034000 00:00000000 00000000" +
+		"     0        0 41235 2 0000000000000000 37\n"
+
+	drops, ok := parseProcNetUDP(bufio.NewScanner(strings.NewReader(content)), 41235)
+	require.Equal(t, true, ok)
+	require.Equal(t, uint64(37), drops)
+
+	_, ok = parseProcNetUDP(bufio.NewScanner(strings.NewReader(content)), 41236)
+	require.Equal(t, false, ok)
+}
+
+func TestUDPKernelDrops(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	drops, ok := udpKernelDrops(pc)
+	if !ok {
+		t.Skip("drop counter is not available")
+	}
+	require.Equal(t, uint64(0), drops)
+
+	err = pc.(*net.UDPConn).SetReadBuffer(1024)
+	require.NoError(t, err)
+
+	sender, err := net.Dial("udp4", pc.LocalAddr().String())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	// fill the receive buffer without reading
+	for i := 0; i < 200; i++ {
+		sender.Write(make([]byte, 1000)) //nolint:errcheck
+	}
+
+	require.Eventually(t, func() bool {
+		drops, _ = udpKernelDrops(pc)
+		return drops != 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
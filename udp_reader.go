@@ -0,0 +1,67 @@
+package gortsplib
+
+import (
+	"net"
+)
+
+// udpReader reads UDP packets in batches, reusing buffers when requested.
+type udpReader struct {
+	conn         *udpBatchConn
+	reuseBuffers bool
+
+	bufs  [][]byte
+	pbufs []*[]byte
+	ns    []int
+	addrs []*net.UDPAddr
+}
+
+func (r *udpReader) initialize(conn *udpBatchConn, reuseBuffers bool) {
+	r.conn = conn
+	r.reuseBuffers = reuseBuffers
+
+	size := r.conn.batchSize
+	r.bufs = make([][]byte, size)
+	r.ns = make([]int, size)
+	r.addrs = make([]*net.UDPAddr, size)
+
+	if reuseBuffers {
+		r.pbufs = make([]*[]byte, size)
+		for i := range r.pbufs {
+			r.pbufs[i] = udpBufferPool.Get().(*[]byte)
+			r.bufs[i] = *r.pbufs[i]
+		}
+	}
+}
+
+func (r *udpReader) close() {
+	for _, pbuf := range r.pbufs {
+		udpBufferPool.Put(pbuf)
+	}
+}
+
+// read reads a batch of packets and calls cb for each of them.
+func (r *udpReader) read(cb func(buf []byte, addr *net.UDPAddr)) error {
+	if !r.reuseBuffers {
+		for i, buf := range r.bufs {
+			if buf == nil {
+				r.bufs[i] = make([]byte, udpMaxPayloadSize+1)
+			}
+		}
+	}
+
+	n, err := r.conn.readBatch(r.bufs, r.ns, r.addrs)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		cb(r.bufs[i][:r.ns[i]], r.addrs[i])
+
+		// buffers passed to callbacks can't be used again
+		if !r.reuseBuffers {
+			r.bufs[i] = nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+)
+
+// udpSegmentationOffloadSupported checks whether the kernel supports
+// UDP generic segmentation offload (UDP_SEGMENT) on a socket.
+// It is not supported on this platform.
+func udpSegmentationOffloadSupported(_ net.PacketConn) bool {
+	return false
+}
+
+func isUDPSegmentationOffloadError(_ error) bool {
+	return false
+}
+
+func writeSegmented(_ net.PacketConn, _ [][]byte, _ *net.UDPAddr) error {
+	return fmt.Errorf("unsupported")
+}
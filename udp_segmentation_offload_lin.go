@@ -0,0 +1,107 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"errors"
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// maximum number of segments that can be sent with a single system call.
+	udpMaxSegments = 64
+
+	// maximum size of a coalesced buffer.
+	udpMaxSegmentedSize = 65000
+)
+
+// udpSegmentationOffloadSupported checks whether the kernel supports
+// UDP generic segmentation offload (UDP_SEGMENT) on a socket.
+func udpSegmentationOffloadSupported(pc net.PacketConn) bool {
+	uc, ok := pc.(*net.UDPConn)
+	if !ok {
+		return false
+	}
+
+	rc, err := uc.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		_, serr = unix.GetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_SEGMENT)
+	})
+	return err == nil && serr == nil
+}
+
+// isUDPSegmentationOffloadError checks whether an error is caused
+// by a network interface that doesn't support segmentation offload.
+func isUDPSegmentationOffloadError(err error) bool {
+	return errors.Is(err, unix.EIO)
+}
+
+func udpSegmentSizeControlMessage(size int) []byte {
+	oob := make([]byte, unix.CmsgSpace(2))
+	h := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	h.Level = unix.IPPROTO_UDP
+	h.Type = unix.UDP_SEGMENT
+	h.SetLen(unix.CmsgLen(2))
+
+	// the segment size is in native byte order
+	*(*uint16)(unsafe.Pointer(&oob[unix.CmsgLen(0)])) = uint16(size)
+	return oob
+}
+
+// writeSegmented writes packets to the same destination, coalescing
+// consecutive packets with the same size into a single buffer that is
+// segmented by the kernel. Only the last packet of each buffer can be smaller.
+func writeSegmented(pc net.PacketConn, bufs [][]byte, addr *net.UDPAddr) error {
+	uc := pc.(*net.UDPConn)
+
+	for len(bufs) != 0 {
+		segSize := len(bufs[0])
+		n := 1
+		size := segSize
+
+		for n < len(bufs) && n < udpMaxSegments && (size+len(bufs[n])) <= udpMaxSegmentedSize {
+			l := len(bufs[n])
+			if l > segSize {
+				break
+			}
+
+			n++
+			size += l
+
+			// a smaller packet terminates the buffer
+			if l < segSize {
+				break
+			}
+		}
+
+		if n == 1 {
+			_, err := uc.WriteTo(bufs[0], addr)
+			if err != nil {
+				return err
+			}
+		} else {
+			buf := make([]byte, 0, size)
+			for _, b := range bufs[:n] {
+				buf = append(buf, b...)
+			}
+
+			_, _, err := uc.WriteMsgUDP(buf, udpSegmentSizeControlMessage(segSize), addr)
+			if err != nil {
+				return err
+			}
+		}
+
+		bufs = bufs[n:]
+	}
+
+	return nil
+}
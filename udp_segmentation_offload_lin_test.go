@@ -0,0 +1,50 @@
+//go:build linux
+// +build linux
+
+package gortsplib
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDPSegmentationOffload(t *testing.T) {
+	pc1, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc1.Close()
+
+	pc2, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc2.Close()
+
+	writer := newUDPBatchConn(pc1, 1)
+	if !writer.enableSegmentationOffload() {
+		t.Skip("segmentation offload is not supported")
+	}
+
+	sent := [][]byte{
+		bytes.Repeat([]byte{1}, 100),
+		bytes.Repeat([]byte{2}, 100),
+		bytes.Repeat([]byte{3}, 100),
+		bytes.Repeat([]byte{4}, 50),
+		bytes.Repeat([]byte{5}, 200),
+		bytes.Repeat([]byte{6}, 200),
+	}
+
+	err = writer.writeBatch(sent, pc2.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	require.True(t, writer.segmentationOffloadActive())
+
+	pc2.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	for _, exp := range sent {
+		buf := make([]byte, 1500)
+		n, _, err := pc2.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, exp, buf[:n])
+	}
+}